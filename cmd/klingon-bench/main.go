@@ -0,0 +1,133 @@
+// Package main provides klingon-bench - the built-in benchmark suite.
+//
+// It runs the crypto, transaction-building, storage and RPC benchmarks in
+// process and prints a table. With -out the report is saved as JSON; with
+// -compare the run is compared with an earlier report (for example the last
+// release's), and -max-regression-bps makes slower runs exit non-zero.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/bench"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+var (
+	version = "0.1.0-dev"
+	commit  = "unknown"
+)
+
+func main() {
+	var (
+		filter        = flag.String("filter", "", "Run only benchmarks matching this regular expression")
+		benchTime     = flag.String("benchtime", "1s", "Run time per benchmark, or an iteration count such as 100x")
+		outFile       = flag.String("out", "", "Write the JSON report to this file")
+		compareFile   = flag.String("compare", "", "Compare with an earlier JSON report")
+		maxRegression = flag.Int64("max-regression-bps", -1, "Exit 1 if a benchmark is slower than -compare by more than this (bps, -1 disables)")
+		list          = flag.Bool("list", false, "List the benchmarks and exit")
+	)
+	flag.Parse()
+
+	log := logging.New(&logging.Config{
+		Level:      "warn",
+		TimeFormat: time.TimeOnly,
+	})
+	logging.SetDefault(log)
+
+	cases := append(bench.Cases(), rpcCases()...)
+	if *list {
+		selected, err := bench.Select(cases, *filter)
+		if err != nil {
+			log.Fatal("Invalid filter", "error", err)
+		}
+		for _, c := range selected {
+			fmt.Println(c.Name)
+		}
+		return
+	}
+
+	var base *bench.Report
+	if *compareFile != "" {
+		var err error
+		if base, err = readReport(*compareFile); err != nil {
+			log.Fatal("Failed to read base report", "file", *compareFile, "error", err)
+		}
+	}
+
+	if err := bench.SetBenchTime(*benchTime); err != nil {
+		log.Fatal("Invalid benchtime", "value", *benchTime, "error", err)
+	}
+	report, err := bench.Run(cases, *filter, fmt.Sprintf("%s (%s)", version, commit))
+	if err != nil {
+		log.Fatal("Benchmark run failed", "error", err)
+	}
+	printReport(report)
+
+	if *outFile != "" {
+		data, _ := json.MarshalIndent(report, "", "  ")
+		if err := os.WriteFile(*outFile, append(data, '\n'), 0644); err != nil {
+			log.Fatal("Failed to write report", "file", *outFile, "error", err)
+		}
+	}
+
+	if base == nil {
+		return
+	}
+	comparisons := bench.Compare(base, report)
+	printComparison(base, comparisons)
+	if *maxRegression >= 0 {
+		if slower := bench.Regressions(comparisons, *maxRegression); len(slower) > 0 {
+			fmt.Printf("\n%d benchmark(s) regressed by more than %s\n", len(slower), formatBps(*maxRegression))
+			os.Exit(1)
+		}
+	}
+}
+
+func readReport(path string) (*bench.Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report bench.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+func printReport(report *bench.Report) {
+	fmt.Printf("klingon-bench %s, %s %s/%s, %d CPUs, %s\n\n",
+		report.Version, report.GoVersion, report.OS, report.Arch, report.CPUs, report.Duration)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "benchmark\titerations\tns/op\tB/op\tallocs/op\t")
+	for _, r := range report.Results {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%d\t%d\t\n", r.Name, r.Iterations, r.NsPerOp, r.BytesPerOp, r.AllocsPerOp)
+	}
+	w.Flush()
+}
+
+func printComparison(base *bench.Report, comparisons []bench.Comparison) {
+	fmt.Printf("\nCompared with %s (%s)\n\n", base.Version, base.StartedAt.Format(time.DateOnly))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "benchmark\tbase ns/op\tns/op\tdelta\tallocs/op\t")
+	for _, c := range comparisons {
+		fmt.Fprintf(w, "%s\t%d\t%d\t%s\t%d -> %d\t\n", c.Name, c.BaseNsPerOp, c.NsPerOp, formatBps(c.DeltaBps), c.BaseAllocs, c.Allocs)
+	}
+	w.Flush()
+}
+
+// formatBps formats basis points as a signed percentage.
+func formatBps(bps int64) string {
+	sign := "+"
+	if bps < 0 {
+		sign = "-"
+		bps = -bps
+	}
+	return fmt.Sprintf("%s%d.%02d%%", sign, bps/100, bps%100)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/bench"
+)
+
+func TestFormatBps(t *testing.T) {
+	tests := []struct {
+		bps  int64
+		want string
+	}{
+		{0, "+0.00%"},
+		{5, "+0.05%"},
+		{1234, "+12.34%"},
+		{-250, "-2.50%"},
+		{-10000, "-100.00%"},
+	}
+	for _, tt := range tests {
+		if got := formatBps(tt.bps); got != tt.want {
+			t.Errorf("formatBps(%d) = %q, want %q", tt.bps, got, tt.want)
+		}
+	}
+}
+
+func TestRPCCases(t *testing.T) {
+	if err := bench.SetBenchTime("1x"); err != nil {
+		t.Fatal(err)
+	}
+	report, err := bench.Run(rpcCases(), "", "test")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != 2 || report.Results[0].Name != "rpc/call" || report.Results[1].Name != "rpc/http" {
+		t.Errorf("results = %+v", report.Results)
+	}
+}
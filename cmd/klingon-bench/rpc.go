@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/bench"
+	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// rpcCases returns the RPC benchmarks: handler dispatch and the full HTTP
+// request path, against a server with an empty store.
+func rpcCases() []bench.Case {
+	return []bench.Case{
+		{Name: "rpc/call", Run: benchRPCCall},
+		{Name: "rpc/http", Run: benchRPCHTTP},
+	}
+}
+
+// rpcServer returns a server backed by a storage in a temporary directory.
+func rpcServer(b *testing.B) *rpc.Server {
+	dir, err := os.MkdirTemp("", "klingon-bench-rpc-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	store, err := storage.New(&storage.Config{DataDir: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		store.Close()
+		os.RemoveAll(dir)
+	})
+	return rpc.NewServer(nil, store, nil, nil)
+}
+
+func benchRPCCall(b *testing.B) {
+	s := rpcServer(b)
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Call(ctx, "orders_list", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchRPCHTTP(b *testing.B) {
+	h := rpcServer(b).Handler()
+	body := []byte(`{"jsonrpc":"2.0","method":"orders_list","params":{"limit":10},"id":1}`)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status %d", rec.Code)
+		}
+	}
+}
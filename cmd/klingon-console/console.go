@@ -0,0 +1,351 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/pkg/client"
+)
+
+const (
+	maxLogLines  = 200 // Event lines kept for the log panel
+	maxPeerRows  = 5
+	maxOrderRows = 8
+)
+
+// Hotkey actions that need a second press to confirm.
+const (
+	actionApprove = "a" // Fund the selected swap
+	actionCancel  = "c" // Refund our leg of the selected swap
+)
+
+// swapRow is a swap with its deadlines for the swaps panel.
+type swapRow struct {
+	client.SwapListItem
+	Deadlines []client.Deadline
+}
+
+// snapshot is the node state shown by the console.
+type snapshot struct {
+	status client.NodeStatusResult
+	peers  []client.PeerInfo
+	orders []client.OrderInfo
+	swaps  []swapRow
+	err    error // Last refresh error, shown in the header
+}
+
+// Console is the operator console state. It only talks to the node through
+// the JSON-RPC and WebSocket APIs.
+type Console struct {
+	client *client.Client
+
+	mu       sync.Mutex
+	snap     snapshot
+	logs     []string
+	selected int
+	pending  string // Action awaiting confirmation
+	message  string // Result of the last action
+}
+
+// NewConsole returns a console for a node.
+func NewConsole(api *client.Client) *Console {
+	return &Console{client: api}
+}
+
+// Refresh reloads peers, the order book and swaps from the node.
+func (c *Console) Refresh(ctx context.Context) {
+	var snap snapshot
+
+	status, err := c.client.NodeStatus(ctx)
+	if err != nil {
+		snap.err = err
+	} else {
+		snap.status = *status
+	}
+
+	peers, err := c.client.PeersList(ctx)
+	if err != nil && snap.err == nil {
+		snap.err = err
+	}
+	if peers != nil {
+		snap.peers = peers.Peers
+	}
+
+	orders, err := c.client.OrdersList(ctx, &client.OrdersListParams{Status: "open"})
+	if err != nil && snap.err == nil {
+		snap.err = err
+	}
+	if orders != nil {
+		snap.orders = orders.Orders
+	}
+
+	swaps, err := c.client.SwapList(ctx, &client.SwapListParams{})
+	if err != nil && snap.err == nil {
+		snap.err = err
+	}
+	if swaps == nil {
+		swaps = &client.SwapListResult{}
+	}
+	for _, item := range swaps.Swaps {
+		row := swapRow{SwapListItem: item}
+		if status, err := c.client.SwapStatus(ctx, item.TradeID); err == nil {
+			row.Deadlines = status.Deadlines
+		}
+		snap.swaps = append(snap.swaps, row)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.snap = snap
+	if c.selected >= len(snap.swaps) {
+		c.selected = len(snap.swaps) - 1
+	}
+	if c.selected < 0 {
+		c.selected = 0
+	}
+}
+
+// HandleEvent adds a WebSocket event to the log panel.
+func (c *Console) HandleEvent(event client.Event) {
+	line := time.Unix(event.Timestamp, 0).Format(time.TimeOnly) + " " + event.Type
+	var data map[string]interface{}
+	if json.Unmarshal(event.Data, &data) == nil {
+		for _, key := range []string{"trade_id", "order_id", "peer_id", "state"} {
+			if v, ok := data[key].(string); ok && v != "" {
+				line += " " + key + "=" + v
+			}
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.logs = append(c.logs, line)
+	if len(c.logs) > maxLogLines {
+		c.logs = c.logs[len(c.logs)-maxLogLines:]
+	}
+}
+
+// HandleKey handles a key press and reports whether the console should quit.
+// Approve and cancel act on the selected swap after a second press.
+func (c *Console) HandleKey(ctx context.Context, key string) bool {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = ""
+	var selected *swapRow
+	if c.selected < len(c.snap.swaps) {
+		row := c.snap.swaps[c.selected]
+		selected = &row
+	}
+
+	switch key {
+	case "q":
+		c.mu.Unlock()
+		return true
+	case "up", "k":
+		if c.selected > 0 {
+			c.selected--
+		}
+		c.mu.Unlock()
+		return false
+	case "down", "j":
+		if c.selected < len(c.snap.swaps)-1 {
+			c.selected++
+		}
+		c.mu.Unlock()
+		return false
+	case actionApprove, actionCancel, "r":
+		if selected == nil {
+			c.message = "No swap selected"
+			c.mu.Unlock()
+			return false
+		}
+		if key != "r" && pending != key {
+			c.pending = key
+			verb := "Fund"
+			if key == actionCancel {
+				verb = "Refund our leg of"
+			}
+			c.message = fmt.Sprintf("%s swap %s? Press %s again to confirm", verb, shortID(selected.TradeID), key)
+			c.mu.Unlock()
+			return false
+		}
+	default:
+		c.message = ""
+		c.mu.Unlock()
+		return false
+	}
+	c.mu.Unlock()
+
+	// Run the action without holding the lock
+	message := c.runAction(ctx, key, selected)
+	c.mu.Lock()
+	c.message = message
+	c.mu.Unlock()
+	return false
+}
+
+// runAction runs a swap hotkey action and returns its result message.
+func (c *Console) runAction(ctx context.Context, key string, row *swapRow) string {
+	id := shortID(row.TradeID)
+	switch key {
+	case actionApprove:
+		result, err := c.client.SwapFund(ctx, row.TradeID)
+		if err != nil {
+			return "Fund failed: " + err.Error()
+		}
+		return fmt.Sprintf("Funded %s on %s: %s", id, result.Chain, result.TxID)
+	case actionCancel:
+		params := &client.SwapRefundParams{TradeID: row.TradeID, Chain: localChain(row.SwapListItem)}
+		result, err := c.client.SwapRefund(ctx, params)
+		if err != nil {
+			return "Refund failed: " + err.Error()
+		}
+		return fmt.Sprintf("Refunded %s on %s: %s", id, params.Chain, result.RefundTxID)
+	default:
+		// Retry: load the swap again if needed, then reconcile it
+		_, _ = c.client.SwapRecover(ctx, row.TradeID)
+		report, err := c.client.SwapReconcile(ctx, row.TradeID)
+		if err != nil {
+			return "Retry failed: " + err.Error()
+		}
+		if len(report.Findings) == 0 {
+			return fmt.Sprintf("Retried %s: %s, nothing new on chain", id, report.State)
+		}
+		return fmt.Sprintf("Retried %s: %s, found %s", id, report.State, strings.Join(report.Findings, ", "))
+	}
+}
+
+// Render draws the console panels into a width x height screen.
+func (c *Console) Render(w io.Writer, width, height int, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	var lines []string
+	add := func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	}
+
+	header := fmt.Sprintf("klingon-console  peers %d  known %d  uptime %s", c.snap.status.PeerCount, c.snap.status.KnownPeers, c.snap.status.Uptime)
+	if p := c.snap.status.Partition; p != nil && p.Degraded {
+		header += "  DEGRADED"
+	}
+	if c.snap.err != nil {
+		header += "  error: " + c.snap.err.Error()
+	}
+	add("%s", header)
+
+	add("")
+	add("PEERS (%d)", len(c.snap.peers))
+	for i, p := range c.snap.peers {
+		if i == maxPeerRows {
+			add("  ... %d more", len(c.snap.peers)-maxPeerRows)
+			break
+		}
+		quality := ""
+		if p.Quality != nil {
+			quality = fmt.Sprintf("  rtt %dms  loss %d‰", p.Quality.RTTMicros/1000, p.Quality.LossPermille)
+		}
+		add("  %s%s", p.PeerID, quality)
+	}
+
+	add("")
+	add("ORDER BOOK (%d)", len(c.snap.orders))
+	for i, o := range c.snap.orders {
+		if i == maxOrderRows {
+			add("  ... %d more", len(c.snap.orders)-maxOrderRows)
+			break
+		}
+		mine := " "
+		if o.IsLocal {
+			mine = "*"
+		}
+		add(" %s%-10s %d %s -> %d %s", mine, shortID(o.ID), o.OfferAmount, o.OfferChain, o.RequestAmount, o.RequestChain)
+	}
+
+	add("")
+	add("SWAPS (%d)", len(c.snap.swaps))
+	for i, s := range c.snap.swaps {
+		cursor := " "
+		if i == c.selected {
+			cursor = ">"
+		}
+		add(" %s%-10s %-9s %-9s %d %s -> %d %s  %s", cursor, shortID(s.TradeID), s.Role, s.State,
+			s.OfferAmount, s.OfferChain, s.RequestAmount, s.RequestChain, nextDeadline(s.Deadlines, now))
+	}
+
+	footer := []string{
+		"",
+		c.message,
+		"up/down select  a approve (fund)  c cancel (refund)  r retry (reconcile)  q quit",
+	}
+
+	// Recent events fill the remaining rows
+	add("")
+	add("EVENTS")
+	room := height - len(lines) - len(footer)
+	logs := c.logs
+	if room < 0 {
+		room = 0
+	}
+	if len(logs) > room {
+		logs = logs[len(logs)-room:]
+	}
+	for _, l := range logs {
+		add("  %s", l)
+	}
+	for len(lines) < height-len(footer) {
+		add("")
+	}
+	lines = append(lines, footer...)
+
+	for i, l := range lines {
+		if len([]rune(l)) > width {
+			l = string([]rune(l)[:width])
+		}
+		fmt.Fprint(w, l)
+		if i < len(lines)-1 {
+			fmt.Fprint(w, "\r\n")
+		}
+	}
+}
+
+// nextDeadline returns the countdown to the earliest pending deadline.
+func nextDeadline(deadlines []client.Deadline, now time.Time) string {
+	var next *client.Deadline
+	for i := range deadlines {
+		d := &deadlines[i]
+		if d.At == 0 || d.At <= now.Unix() {
+			continue
+		}
+		if next == nil || d.At < next.At {
+			next = d
+		}
+	}
+	if next == nil {
+		return ""
+	}
+	left := time.Unix(next.At, 0).Sub(now).Truncate(time.Second)
+	return fmt.Sprintf("%s in %s", next.Kind, left)
+}
+
+// localChain returns the chain we fund in a swap.
+func localChain(s client.SwapListItem) string {
+	if s.Role == string(swap.RoleInitiator) {
+		return s.OfferChain
+	}
+	return s.RequestChain
+}
+
+// shortID shortens an ID for display.
+func shortID(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
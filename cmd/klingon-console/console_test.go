@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/pkg/client"
+)
+
+// fakeNode is a scripted JSON-RPC node that records calls.
+type fakeNode struct {
+	mu      sync.Mutex
+	calls   []string
+	params  map[string]json.RawMessage
+	results map[string]interface{}
+}
+
+func (f *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpc.Request
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, req.Method)
+	f.params[req.Method] = req.Params
+	result, ok := f.results[req.Method]
+	f.mu.Unlock()
+
+	resp := rpc.Response{JSONRPC: "2.0", ID: req.ID}
+	if ok {
+		resp.Result = result
+	} else {
+		resp.Result = map[string]string{}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeNode) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	n := 0
+	for _, c := range f.calls {
+		if c == method {
+			n++
+		}
+	}
+	return n
+}
+
+var consoleNow = time.Unix(1700000000, 0)
+
+func newTestConsole(t *testing.T) (*Console, *fakeNode) {
+	t.Helper()
+	node := &fakeNode{
+		params: make(map[string]json.RawMessage),
+		results: map[string]interface{}{
+			"node_status": rpc.NodeStatusResult{PeerCount: 2, KnownPeers: 7, Uptime: "1h0m0s"},
+			"peers_list":  rpc.PeersListResult{Peers: []rpc.PeerInfo{{PeerID: "12D3KooWpeer1"}, {PeerID: "12D3KooWpeer2"}}},
+			"orders_list": rpc.OrdersListResult{Orders: []rpc.OrderInfo{
+				{ID: "order-aaaaaaaa", IsLocal: true, OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000},
+			}},
+			"swap_list": rpc.SwapListResult{Swaps: []rpc.SwapListItem{
+				{TradeID: "trade-1111", State: "funding", Role: "initiator", OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000},
+				{TradeID: "trade-2222", State: "funded", Role: "responder", OfferChain: "BTC", OfferAmount: 5000, RequestChain: "LTC", RequestAmount: 50000},
+			}},
+			"swap_status": rpc.SwapStatusResult{Deadlines: []swap.Deadline{
+				{Kind: swap.DeadlineOurRefund, At: consoleNow.Unix() + 7200},
+				{Kind: swap.DeadlineLatestClaim, At: consoleNow.Unix() + 90},
+				{Kind: swap.DeadlineTheirRefund, At: consoleNow.Unix() - 10},
+			}},
+			"swap_reconcile": swap.ReconcileReport{State: swap.StateRedeemed, Findings: []string{swap.FindingRedeemed}},
+		},
+	}
+	srv := httptest.NewServer(node)
+	t.Cleanup(srv.Close)
+
+	c := NewConsole(client.New(srv.URL))
+	c.Refresh(context.Background())
+	return c, node
+}
+
+func render(c *Console, height int) string {
+	var buf bytes.Buffer
+	c.Render(&buf, 120, height, consoleNow)
+	return buf.String()
+}
+
+func TestConsoleRender(t *testing.T) {
+	c, _ := newTestConsole(t)
+	c.HandleEvent(client.Event{Type: "swap_state_changed", Timestamp: consoleNow.Unix(), Data: json.RawMessage(`{"trade_id":"trade-1111","state":"funded"}`)})
+
+	screen := render(c, 30)
+	for _, want := range []string{
+		"peers 2  known 7",
+		"PEERS (2)",
+		"12D3KooWpeer1",
+		"*order-aa",
+		"SWAPS (2)",
+		">trade-11",
+		"latest_claim in 1m30s", // Earliest pending deadline
+		"swap_state_changed trade_id=trade-1111 state=funded",
+		"q quit",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("screen missing %q:\n%s", want, screen)
+		}
+	}
+	if rows := strings.Count(screen, "\r\n") + 1; rows != 30 {
+		t.Errorf("rendered %d rows, want 30", rows)
+	}
+
+	// Old events scroll off a small screen
+	for i := 0; i < 50; i++ {
+		c.HandleEvent(client.Event{Type: "peer_connected", Timestamp: consoleNow.Unix()})
+	}
+	if screen := render(c, 24); strings.Contains(screen, "swap_state_changed") {
+		t.Error("oldest event should have scrolled off")
+	}
+}
+
+func TestConsoleSelection(t *testing.T) {
+	c, _ := newTestConsole(t)
+	ctx := context.Background()
+
+	c.HandleKey(ctx, "down")
+	c.HandleKey(ctx, "down") // Stops at the last swap
+	if !strings.Contains(render(c, 30), ">trade-22") {
+		t.Error("down should select the second swap")
+	}
+	c.HandleKey(ctx, "k")
+	if !strings.Contains(render(c, 30), ">trade-11") {
+		t.Error("k should select the first swap")
+	}
+	if !c.HandleKey(ctx, "q") {
+		t.Error("q should quit")
+	}
+}
+
+func TestConsoleActionsNeedConfirmation(t *testing.T) {
+	c, node := newTestConsole(t)
+	ctx := context.Background()
+
+	// Approve funds after a second press
+	c.HandleKey(ctx, "a")
+	if node.count("swap_fund") != 0 || !strings.Contains(c.message, "Press a again") {
+		t.Fatalf("first press should ask to confirm, message = %q", c.message)
+	}
+	c.HandleKey(ctx, "a")
+	if node.count("swap_fund") != 1 {
+		t.Error("second press should fund")
+	}
+
+	// Any other key drops a pending action
+	c.HandleKey(ctx, "c")
+	c.HandleKey(ctx, "x")
+	c.HandleKey(ctx, "c")
+	if node.count("swap_refund") != 0 {
+		t.Error("refund ran without confirmation")
+	}
+	c.HandleKey(ctx, "c")
+	if node.count("swap_refund") != 1 {
+		t.Fatal("refund not run")
+	}
+	var params rpc.SwapRefundParams
+	_ = json.Unmarshal(node.params["swap_refund"], &params)
+	if params.TradeID != "trade-1111" || params.Chain != "BTC" {
+		t.Errorf("refund params = %+v, want our BTC leg", params)
+	}
+
+	// Retry recovers and reconciles at once
+	c.HandleKey(ctx, "r")
+	if node.count("swap_recover") != 1 || node.count("swap_reconcile") != 1 {
+		t.Error("retry should recover and reconcile")
+	}
+	if !strings.Contains(c.message, "found redeemed") {
+		t.Errorf("message = %q", c.message)
+	}
+}
@@ -0,0 +1,150 @@
+// Package main provides klingon-console - a terminal operator console for klingond.
+//
+// The console shows live panels for peers, the order book, active swaps with
+// their state and deadline countdowns, and recent node events. Hotkeys fund,
+// refund or retry the selected swap. It is backed entirely by the node's
+// JSON-RPC and WebSocket APIs, so it can run against a local or remote node.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+
+	"github.com/Klingon-tech/klingdex/pkg/client"
+)
+
+// Terminal control sequences.
+const (
+	altScreenOn  = "\x1b[?1049h\x1b[?25l" // Alternate screen, hide cursor
+	altScreenOff = "\x1b[?25h\x1b[?1049l"
+	clearScreen  = "\x1b[H\x1b[2J"
+)
+
+func main() {
+	var (
+		apiURL  = flag.String("api", "http://127.0.0.1:8080", "klingond JSON-RPC URL")
+		apiKey  = flag.String("api-key", "", "API key, when the node requires one (api.keys)")
+		refresh = flag.Duration("refresh", 5*time.Second, "Polling interval for peers, orders and swaps")
+	)
+	flag.Parse()
+
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		fmt.Fprintln(os.Stderr, "klingon-console needs an interactive terminal")
+		os.Exit(1)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api := client.New(*apiURL,
+		client.WithAPIKey(*apiKey),
+		client.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+		client.WithReconnect(time.Second, 5*time.Second),
+	)
+	console := NewConsole(api)
+	if _, err := api.NodeStatus(ctx); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to reach node at %s: %v\n", *apiURL, err)
+		os.Exit(1)
+	}
+
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to set up terminal: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(altScreenOn)
+	defer func() {
+		fmt.Print(altScreenOff)
+		term.Restore(fd, state)
+	}()
+
+	events := api.Subscribe(ctx)
+
+	keys := make(chan string)
+	go readKeys(os.Stdin, keys)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+
+	draw := func() {
+		width, height, err := term.GetSize(os.Stdout.Fd())
+		if err != nil {
+			width, height = 100, 40
+		}
+		fmt.Print(clearScreen)
+		console.Render(os.Stdout, width, height, time.Now())
+	}
+
+	console.Refresh(ctx)
+	draw()
+
+	poll := time.NewTicker(*refresh)
+	defer poll.Stop()
+	tick := time.NewTicker(time.Second) // Countdowns
+	defer tick.Stop()
+	dirty := false
+
+	for {
+		select {
+		case <-sigCh:
+			return
+		case key, ok := <-keys:
+			if !ok || console.HandleKey(ctx, key) {
+				return
+			}
+			console.Refresh(ctx)
+			draw()
+		case event := <-events:
+			console.HandleEvent(event)
+			dirty = true // Refresh on the next tick, events come in bursts
+		case <-poll.C:
+			dirty = true
+		case <-tick.C:
+			if dirty {
+				console.Refresh(ctx)
+				dirty = false
+			}
+			draw()
+		}
+	}
+}
+
+// readKeys sends key presses from a raw terminal as names: arrow keys as
+// "up"/"down", Ctrl-C as "q", others as the typed character.
+func readKeys(f *os.File, keys chan<- string) {
+	defer close(keys)
+	r := bufio.NewReader(f)
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return
+		}
+		switch b {
+		case 0x03:
+			keys <- "q"
+		case 0x1b:
+			if next, _ := r.Peek(2); len(next) == 2 && next[0] == '[' {
+				arrow := next[1]
+				r.Discard(2)
+				switch arrow {
+				case 'A':
+					keys <- "up"
+				case 'B':
+					keys <- "down"
+				}
+			}
+		default:
+			keys <- string(rune(b))
+		}
+	}
+}
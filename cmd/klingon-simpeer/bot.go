@@ -0,0 +1,410 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/client"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Behavior controls how the simulated peer deviates from the honest protocol.
+type Behavior string
+
+const (
+	// BehaviorHonest follows the protocol to completion.
+	BehaviorHonest Behavior = "honest"
+	// BehaviorWithholdNonce never sends MuSig2 nonces (HTLC swaps proceed honestly).
+	BehaviorWithholdNonce Behavior = "withhold_nonce"
+	// BehaviorNeverFund initializes the swap but never funds its escrow.
+	BehaviorNeverFund Behavior = "never_fund"
+	// BehaviorClaimLate waits ClaimDelay after both sides are funded before claiming/redeeming.
+	BehaviorClaimLate Behavior = "claim_late"
+)
+
+// ParseBehavior parses a behavior name.
+func ParseBehavior(s string) (Behavior, error) {
+	switch b := Behavior(strings.ToLower(strings.TrimSpace(s))); b {
+	case BehaviorHonest, BehaviorWithholdNonce, BehaviorNeverFund, BehaviorClaimLate:
+		return b, nil
+	default:
+		return "", fmt.Errorf("unknown behavior %q (valid: honest, withhold_nonce, never_fund, claim_late)", s)
+	}
+}
+
+// Pair is a trading pair the simulated peer accepts trades for.
+// If both amounts are set, the peer also keeps an open order for the pair.
+type Pair struct {
+	OfferChain    string
+	OfferAmount   uint64
+	RequestChain  string
+	RequestAmount uint64
+}
+
+// HasAmounts returns true if the pair carries order amounts.
+func (p Pair) HasAmounts() bool {
+	return p.OfferAmount > 0 && p.RequestAmount > 0
+}
+
+// Matches returns true if an order with the given chains belongs to this pair.
+func (p Pair) Matches(offerChain, requestChain string) bool {
+	return strings.EqualFold(p.OfferChain, offerChain) && strings.EqualFold(p.RequestChain, requestChain)
+}
+
+// ParsePairs parses a comma-separated list of pairs.
+// Format: "BTC/LTC" or "BTC:10000/LTC:100000" (amounts in smallest units).
+func ParsePairs(s string) ([]Pair, error) {
+	var pairs []Pair
+	for _, spec := range strings.Split(s, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		sides := strings.Split(spec, "/")
+		if len(sides) != 2 {
+			return nil, fmt.Errorf("invalid pair %q: expected OFFER/REQUEST", spec)
+		}
+
+		offerChain, offerAmount, err := parsePairSide(sides[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pair %q: %w", spec, err)
+		}
+		requestChain, requestAmount, err := parsePairSide(sides[1])
+		if err != nil {
+			return nil, fmt.Errorf("invalid pair %q: %w", spec, err)
+		}
+		if (offerAmount == 0) != (requestAmount == 0) {
+			return nil, fmt.Errorf("invalid pair %q: amounts must be set on both sides or neither", spec)
+		}
+
+		pairs = append(pairs, Pair{
+			OfferChain:    offerChain,
+			OfferAmount:   offerAmount,
+			RequestChain:  requestChain,
+			RequestAmount: requestAmount,
+		})
+	}
+	if len(pairs) == 0 {
+		return nil, fmt.Errorf("at least one pair is required")
+	}
+	return pairs, nil
+}
+
+// parsePairSide parses "BTC" or "BTC:10000".
+func parsePairSide(s string) (string, uint64, error) {
+	parts := strings.SplitN(strings.TrimSpace(s), ":", 2)
+	symbol := strings.ToUpper(strings.TrimSpace(parts[0]))
+	if symbol == "" {
+		return "", 0, fmt.Errorf("empty chain symbol")
+	}
+	if len(parts) == 1 {
+		return symbol, 0, nil
+	}
+	amount, err := strconv.ParseUint(strings.TrimSpace(parts[1]), 10, 64)
+	if err != nil || amount == 0 {
+		return "", 0, fmt.Errorf("invalid amount %q", parts[1])
+	}
+	return symbol, amount, nil
+}
+
+// tradeStep is the simulated peer's progress through a single trade.
+type tradeStep int
+
+const (
+	stepInit tradeStep = iota
+	stepFund
+	stepWaitFunding
+	stepSettle
+	stepDone
+)
+
+// tradeProgress tracks per-trade progress.
+type tradeProgress struct {
+	step         tradeStep
+	method       string
+	offerChain   string
+	requestChain string
+	bothFundedAt time.Time
+	nonceSent    bool
+	signed       bool
+	revealed     bool
+}
+
+// BotConfig configures the simulated peer.
+type BotConfig struct {
+	Pairs        []Pair
+	Behavior     Behavior
+	ClaimDelay   time.Duration
+	PollInterval time.Duration
+	Method       string // Method used for orders the peer posts
+}
+
+// Bot is an autonomous maker that drives incoming trades over the node's RPC API.
+type Bot struct {
+	cfg    BotConfig
+	client *client.Client
+	peerID string
+	trades map[string]*tradeProgress
+	log    *logging.Logger
+}
+
+// NewBot creates a simulated peer.
+func NewBot(cfg BotConfig, api *client.Client) *Bot {
+	return &Bot{
+		cfg:    cfg,
+		client: api,
+		trades: make(map[string]*tradeProgress),
+		log:    logging.GetDefault().Component("simpeer"),
+	}
+}
+
+// Run polls the node until ctx is cancelled.
+func (b *Bot) Run(ctx context.Context) error {
+	info, err := b.client.NodeInfo(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get node info: %w", err)
+	}
+	b.peerID = info.PeerID
+
+	ticker := time.NewTicker(b.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		b.tick(ctx)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// tick runs one polling round: keep orders posted, then advance all trades.
+func (b *Bot) tick(ctx context.Context) {
+	if err := b.ensureOrders(ctx); err != nil {
+		b.log.Warn("Failed to maintain orders", "error", err)
+	}
+
+	list, err := b.client.TradesList(ctx, &client.TradesListParams{})
+	if err != nil {
+		b.log.Warn("Failed to list trades", "error", err)
+		return
+	}
+
+	for _, trade := range list.Trades {
+		if trade.MakerPeerID != b.peerID {
+			continue
+		}
+
+		progress, ok := b.trades[trade.ID]
+		if !ok {
+			if isTerminalTradeState(trade.State) {
+				continue
+			}
+			var err error
+			progress, err = b.accept(ctx, trade)
+			if err != nil {
+				b.log.Debug("Ignoring trade", "trade_id", trade.ID, "reason", err)
+				continue
+			}
+			b.trades[trade.ID] = progress
+		}
+
+		if progress.step == stepDone {
+			continue
+		}
+		if err := b.advance(ctx, trade.ID, progress); err != nil {
+			b.log.Debug("Trade step pending", "trade_id", trade.ID, "step", progress.step, "error", err)
+		}
+	}
+}
+
+// ensureOrders keeps one open local order for every pair that has amounts.
+func (b *Bot) ensureOrders(ctx context.Context) error {
+	list, err := b.client.OrdersList(ctx, &client.OrdersListParams{
+		Status:    string(storage.OrderStatusOpen),
+		LocalOnly: true,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, pair := range b.cfg.Pairs {
+		if !pair.HasAmounts() {
+			continue
+		}
+
+		open := false
+		for _, o := range list.Orders {
+			if pair.Matches(o.OfferChain, o.RequestChain) {
+				open = true
+				break
+			}
+		}
+		if open {
+			continue
+		}
+
+		order, err := b.client.OrdersCreate(ctx, &client.OrderCreateParams{
+			OfferChain:       pair.OfferChain,
+			OfferAmount:      pair.OfferAmount,
+			RequestChain:     pair.RequestChain,
+			RequestAmount:    pair.RequestAmount,
+			PreferredMethods: []string{b.cfg.Method},
+		})
+		if err != nil {
+			return err
+		}
+		b.log.Info("Posted order", "id", order.ID, "offer", pair.OfferChain, "request", pair.RequestChain)
+	}
+	return nil
+}
+
+// accept decides whether to handle a newly seen trade.
+func (b *Bot) accept(ctx context.Context, trade client.TradeInfo) (*tradeProgress, error) {
+	order, err := b.client.OrdersGet(ctx, trade.OrderID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pair := range b.cfg.Pairs {
+		if pair.Matches(order.OfferChain, order.RequestChain) {
+			b.log.Info("Accepted trade",
+				"trade_id", trade.ID,
+				"pair", order.OfferChain+"/"+order.RequestChain,
+				"method", trade.Method,
+				"behavior", b.cfg.Behavior,
+			)
+			return &tradeProgress{
+				step:         stepInit,
+				method:       trade.Method,
+				offerChain:   order.OfferChain,
+				requestChain: order.RequestChain,
+			}, nil
+		}
+	}
+	return nil, fmt.Errorf("pair %s/%s not configured", order.OfferChain, order.RequestChain)
+}
+
+// advance moves a trade forward by at most one step.
+func (b *Bot) advance(ctx context.Context, tradeID string, p *tradeProgress) error {
+	switch p.step {
+	case stepInit:
+		if _, err := b.client.SwapInit(ctx, &client.SwapInitParams{TradeID: tradeID}); err != nil {
+			return err
+		}
+		p.step = stepFund
+		b.log.Info("Swap initialized", "trade_id", tradeID)
+
+	case stepFund:
+		if b.cfg.Behavior == BehaviorNeverFund {
+			b.log.Warn("Byzantine: never funding escrow", "trade_id", tradeID)
+			p.step = stepDone
+			return nil
+		}
+		res, err := b.client.SwapFund(ctx, tradeID)
+		if err != nil {
+			return err
+		}
+		p.step = stepWaitFunding
+		b.log.Info("Escrow funded", "trade_id", tradeID, "chain", res.Chain, "txid", res.TxID)
+
+	case stepWaitFunding:
+		res, err := b.client.SwapCheckFunding(ctx, tradeID)
+		if err != nil {
+			return err
+		}
+		if !res.BothFunded {
+			return fmt.Errorf("waiting for funding confirmations")
+		}
+		p.bothFundedAt = time.Now()
+		p.step = stepSettle
+		b.log.Info("Both escrows funded", "trade_id", tradeID)
+
+	case stepSettle:
+		if b.cfg.Behavior == BehaviorClaimLate && time.Since(p.bothFundedAt) < b.cfg.ClaimDelay {
+			return fmt.Errorf("byzantine: delaying claim until %s", p.bothFundedAt.Add(b.cfg.ClaimDelay).Format(time.TimeOnly))
+		}
+		if p.method == "htlc" {
+			return b.settleHTLC(ctx, tradeID, p)
+		}
+		return b.settleMuSig2(ctx, tradeID, p)
+	}
+
+	return nil
+}
+
+// settleHTLC reveals the secret and claims the counterparty's HTLC (maker is the initiator).
+func (b *Bot) settleHTLC(ctx context.Context, tradeID string, p *tradeProgress) error {
+	if !p.revealed {
+		if _, err := b.client.SwapHTLCRevealSecret(ctx, tradeID); err != nil {
+			return err
+		}
+		p.revealed = true
+	}
+
+	res, err := b.client.SwapHTLCClaim(ctx, &client.SwapHTLCClaimParams{
+		TradeID: tradeID,
+		Chain:   p.requestChain,
+	})
+	if err != nil {
+		return err
+	}
+	p.step = stepDone
+	b.log.Info("HTLC claimed", "trade_id", tradeID, "chain", res.Chain, "txid", res.ClaimTxID)
+	return nil
+}
+
+// settleMuSig2 exchanges nonces, signs, and redeems.
+func (b *Bot) settleMuSig2(ctx context.Context, tradeID string, p *tradeProgress) error {
+	if b.cfg.Behavior == BehaviorWithholdNonce {
+		if !p.nonceSent {
+			b.log.Warn("Byzantine: withholding nonces", "trade_id", tradeID)
+			p.nonceSent = true // Only log once
+		}
+		return nil
+	}
+
+	if !p.nonceSent {
+		if _, err := b.client.SwapExchangeNonce(ctx, tradeID); err != nil {
+			return err
+		}
+		p.nonceSent = true
+	}
+
+	status, err := b.client.SwapStatus(ctx, tradeID)
+	if err != nil {
+		return err
+	}
+	if !status.HasOfferNonces || !status.HasRequestNonces {
+		return fmt.Errorf("waiting for remote nonces")
+	}
+
+	if !p.signed {
+		if _, err := b.client.SwapSign(ctx, tradeID); err != nil {
+			return err
+		}
+		p.signed = true
+	}
+
+	if _, err := b.client.SwapRedeem(ctx, tradeID); err != nil {
+		return err
+	}
+	p.step = stepDone
+	b.log.Info("Swap redeemed", "trade_id", tradeID)
+	return nil
+}
+
+// isTerminalTradeState returns true if a trade needs no further action.
+func isTerminalTradeState(state string) bool {
+	switch storage.TradeState(state) {
+	case storage.TradeStateRedeemed, storage.TradeStateRefunded, storage.TradeStateFailed, storage.TradeStateAborted:
+		return true
+	}
+	return false
+}
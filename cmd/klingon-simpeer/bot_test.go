@@ -0,0 +1,214 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/pkg/client"
+)
+
+func TestParsePairs(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []Pair
+		wantErr bool
+	}{
+		{"chains only", "BTC/LTC", []Pair{{OfferChain: "BTC", RequestChain: "LTC"}}, false},
+		{"with amounts", "btc:10000/ltc:100000", []Pair{{OfferChain: "BTC", OfferAmount: 10000, RequestChain: "LTC", RequestAmount: 100000}}, false},
+		{"multiple", "BTC/LTC, LTC/BTC", []Pair{{OfferChain: "BTC", RequestChain: "LTC"}, {OfferChain: "LTC", RequestChain: "BTC"}}, false},
+		{"empty", "", nil, true},
+		{"missing side", "BTC", nil, true},
+		{"one-sided amount", "BTC:100/LTC", nil, true},
+		{"bad amount", "BTC:abc/LTC:1", nil, true},
+		{"zero amount", "BTC:0/LTC:0", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePairs(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePairs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("ParsePairs() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("pair %d = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseBehavior(t *testing.T) {
+	for _, valid := range []string{"honest", "withhold_nonce", "never_fund", "CLAIM_LATE"} {
+		if _, err := ParseBehavior(valid); err != nil {
+			t.Errorf("ParseBehavior(%q) error = %v", valid, err)
+		}
+	}
+	if _, err := ParseBehavior("evil"); err == nil {
+		t.Error("ParseBehavior(evil) expected error")
+	}
+}
+
+func TestPairMatches(t *testing.T) {
+	p := Pair{OfferChain: "BTC", RequestChain: "LTC"}
+	if !p.Matches("btc", "LTC") {
+		t.Error("expected case-insensitive match")
+	}
+	if p.Matches("LTC", "BTC") {
+		t.Error("reversed pair should not match")
+	}
+}
+
+// fakeNode is a scripted JSON-RPC node that records called methods.
+type fakeNode struct {
+	mu      sync.Mutex
+	calls   []string
+	results map[string]interface{}
+}
+
+func (f *fakeNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req rpc.Request
+	_ = json.NewDecoder(r.Body).Decode(&req)
+
+	f.mu.Lock()
+	f.calls = append(f.calls, req.Method)
+	result, ok := f.results[req.Method]
+	f.mu.Unlock()
+
+	resp := rpc.Response{JSONRPC: "2.0", ID: req.ID}
+	if ok {
+		resp.Result = result
+	} else {
+		resp.Result = map[string]string{}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeNode) called(method string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c == method {
+			return true
+		}
+	}
+	return false
+}
+
+func newFakeNode() *fakeNode {
+	return &fakeNode{results: map[string]interface{}{
+		"node_info": rpc.NodeInfoResult{PeerID: "maker"},
+		"trades_list": rpc.TradesListResult{Trades: []rpc.TradeInfo{
+			{ID: "trade-1", OrderID: "order-1", MakerPeerID: "maker", Method: "htlc", State: "init"},
+			{ID: "trade-2", OrderID: "order-2", MakerPeerID: "someone-else", Method: "htlc", State: "init"},
+		}},
+		"orders_list":       rpc.OrdersListResult{},
+		"orders_get":        rpc.OrderInfo{ID: "order-1", OfferChain: "BTC", RequestChain: "LTC"},
+		"swap_checkFunding": rpc.SwapCheckFundingResult{BothFunded: true},
+	}}
+}
+
+func runTicks(t *testing.T, node *fakeNode, cfg BotConfig, ticks int) *Bot {
+	t.Helper()
+	srv := httptest.NewServer(node)
+	defer srv.Close()
+
+	bot := NewBot(cfg, client.New(srv.URL))
+	bot.peerID = "maker"
+	for i := 0; i < ticks; i++ {
+		bot.tick(context.Background())
+	}
+	return bot
+}
+
+func TestBotHonestHTLC(t *testing.T) {
+	node := newFakeNode()
+	bot := runTicks(t, node, BotConfig{
+		Pairs:    []Pair{{OfferChain: "BTC", RequestChain: "LTC"}},
+		Behavior: BehaviorHonest,
+	}, 5)
+
+	for _, method := range []string{"swap_init", "swap_fund", "swap_checkFunding", "swap_htlcRevealSecret", "swap_htlcClaim"} {
+		if !node.called(method) {
+			t.Errorf("expected %s to be called", method)
+		}
+	}
+	if _, ok := bot.trades["trade-2"]; ok {
+		t.Error("trade from another maker should be ignored")
+	}
+	if bot.trades["trade-1"].step != stepDone {
+		t.Errorf("trade step = %d, want done", bot.trades["trade-1"].step)
+	}
+}
+
+func TestBotNeverFund(t *testing.T) {
+	node := newFakeNode()
+	runTicks(t, node, BotConfig{
+		Pairs:    []Pair{{OfferChain: "BTC", RequestChain: "LTC"}},
+		Behavior: BehaviorNeverFund,
+	}, 5)
+
+	if !node.called("swap_init") {
+		t.Error("expected swap_init to be called")
+	}
+	if node.called("swap_fund") {
+		t.Error("never_fund must not call swap_fund")
+	}
+}
+
+func TestBotClaimLate(t *testing.T) {
+	node := newFakeNode()
+	runTicks(t, node, BotConfig{
+		Pairs:      []Pair{{OfferChain: "BTC", RequestChain: "LTC"}},
+		Behavior:   BehaviorClaimLate,
+		ClaimDelay: time.Hour,
+	}, 6)
+
+	if !node.called("swap_fund") {
+		t.Error("claim_late should still fund")
+	}
+	if node.called("swap_htlcClaim") {
+		t.Error("claim_late must not claim before the delay")
+	}
+}
+
+func TestBotIgnoresUnconfiguredPair(t *testing.T) {
+	node := newFakeNode()
+	bot := runTicks(t, node, BotConfig{
+		Pairs:    []Pair{{OfferChain: "DOGE", RequestChain: "LTC"}},
+		Behavior: BehaviorHonest,
+	}, 2)
+
+	if node.called("swap_init") {
+		t.Error("unconfigured pair must not be initialized")
+	}
+	if len(bot.trades) != 0 {
+		t.Errorf("tracked trades = %d, want 0", len(bot.trades))
+	}
+}
+
+func TestBotPostsOrders(t *testing.T) {
+	node := newFakeNode()
+	runTicks(t, node, BotConfig{
+		Pairs:    []Pair{{OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000}},
+		Behavior: BehaviorHonest,
+		Method:   "htlc",
+	}, 1)
+
+	if !node.called("orders_create") {
+		t.Error("expected orders_create for pair with amounts")
+	}
+}
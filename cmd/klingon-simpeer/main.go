@@ -0,0 +1,92 @@
+// Package main provides klingon-simpeer - a simulated counterparty for QA.
+//
+// The simulated peer drives a running testnet klingond node over its JSON-RPC API.
+// It keeps orders open for the configured pairs, automatically accepts every trade
+// taken against them, and follows the swap protocol either honestly or with a
+// configurable byzantine behavior so failure handling can be tested end to end.
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/pkg/client"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func main() {
+	var (
+		apiURL       = flag.String("api", "http://127.0.0.1:18080", "klingond JSON-RPC URL (testnet node)")
+		apiKey       = flag.String("api-key", "", "API key, when the node requires one (api.keys)")
+		pairs        = flag.String("pairs", "", "Pairs to accept, comma-separated: BTC/LTC or BTC:10000/LTC:100000 (amounts keep an order open)")
+		behavior     = flag.String("behavior", string(BehaviorHonest), "Protocol behavior: honest, withhold_nonce, never_fund, claim_late")
+		claimDelay   = flag.Duration("claim-delay", 30*time.Minute, "Delay before claiming when behavior is claim_late")
+		pollInterval = flag.Duration("poll", 5*time.Second, "Polling interval")
+		method       = flag.String("method", "htlc", "Swap method for posted orders (htlc, musig2)")
+		logLevel     = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
+	)
+	flag.Parse()
+
+	log := logging.New(&logging.Config{
+		Level:      *logLevel,
+		TimeFormat: time.TimeOnly,
+	})
+	logging.SetDefault(log)
+
+	parsedPairs, err := ParsePairs(*pairs)
+	if err != nil {
+		log.Fatal("Invalid pairs", "error", err)
+	}
+	parsedBehavior, err := ParseBehavior(*behavior)
+	if err != nil {
+		log.Fatal("Invalid behavior", "error", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	api := client.New(*apiURL,
+		client.WithAPIKey(*apiKey),
+		client.WithHTTPClient(&http.Client{Timeout: 30 * time.Second}),
+	)
+
+	// Safety: only ever run against a testnet node with an unlocked wallet
+	status, err := api.WalletStatus(ctx)
+	if err != nil {
+		log.Fatal("Failed to reach node", "api", *apiURL, "error", err)
+	}
+	if status.Network != string(chain.Testnet) {
+		log.Fatal("Refusing to run against a non-testnet node", "network", status.Network)
+	}
+	if !status.Unlocked {
+		log.Fatal("Node wallet must be unlocked")
+	}
+
+	bot := NewBot(BotConfig{
+		Pairs:        parsedPairs,
+		Behavior:     parsedBehavior,
+		ClaimDelay:   *claimDelay,
+		PollInterval: *pollInterval,
+		Method:       *method,
+	}, api)
+
+	log.Info("Simulated peer started", "api", *apiURL, "pairs", *pairs, "behavior", parsedBehavior)
+
+	go func() {
+		sigCh := make(chan os.Signal, 1)
+		signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+		<-sigCh
+		log.Info("Shutting down...")
+		cancel()
+	}()
+
+	if err := bot.Run(ctx); err != nil {
+		log.Fatal("Simulated peer failed", "error", err)
+	}
+}
@@ -0,0 +1,90 @@
+// Package main provides klingon-vectors - the protocol test vector generator.
+//
+// It derives two parties' keys, nonces and secrets from a fixed seed and
+// prints the resulting key aggregations, signatures, HTLC scripts, swap IDs
+// and terms digests as JSON. Other client implementations check themselves
+// against the output; -check compares a published file with what this build
+// generates from the file's seed.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/vectors"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func main() {
+	var (
+		seed      = flag.String("seed", vectors.DefaultSeed, "Hex seed to derive the vectors from")
+		network   = flag.String("network", string(chain.Testnet), "Network: mainnet or testnet")
+		outFile   = flag.String("out", "", "Write the vectors to this file instead of stdout")
+		checkFile = flag.String("check", "", "Compare a vectors file with the vectors generated from its seed")
+	)
+	flag.Parse()
+
+	log := logging.New(&logging.Config{
+		Level:      "warn",
+		TimeFormat: time.TimeOnly,
+	})
+	logging.SetDefault(log)
+
+	if *checkFile != "" {
+		diffs, err := check(*checkFile)
+		if err != nil {
+			log.Fatal("Failed to check vectors", "file", *checkFile, "error", err)
+		}
+		for _, d := range diffs {
+			fmt.Println("mismatch:", d)
+		}
+		if len(diffs) > 0 {
+			os.Exit(1)
+		}
+		fmt.Println("vectors match")
+		return
+	}
+
+	net := chain.Network(*network)
+	if net != chain.Mainnet && net != chain.Testnet {
+		log.Fatal("Invalid network", "network", *network)
+	}
+	set, err := vectors.Generate(*seed, net)
+	if err != nil {
+		log.Fatal("Failed to generate vectors", "error", err)
+	}
+	data, _ := json.MarshalIndent(set, "", "  ")
+	data = append(data, '\n')
+	if *outFile == "" {
+		os.Stdout.Write(data)
+		return
+	}
+	if err := os.WriteFile(*outFile, data, 0644); err != nil {
+		log.Fatal("Failed to write vectors", "file", *outFile, "error", err)
+	}
+}
+
+// check regenerates the vectors of a file's seed and network and returns
+// the paths that differ.
+func check(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var want vectors.Set
+	if err := json.Unmarshal(data, &want); err != nil {
+		return nil, err
+	}
+	if want.Version != vectors.Version {
+		return nil, fmt.Errorf("file has version %d, this build generates version %d", want.Version, vectors.Version)
+	}
+	got, err := vectors.Generate(want.Seed, chain.Network(want.Network))
+	if err != nil {
+		return nil, err
+	}
+	return vectors.Compare(&want, got)
+}
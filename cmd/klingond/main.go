@@ -13,14 +13,27 @@ import (
 
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	"github.com/Klingon-tech/klingdex/internal/archive"
 	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/backup"
 	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/compliance"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/consolidate"
+	"github.com/Klingon-tech/klingdex/internal/export"
+	"github.com/Klingon-tech/klingdex/internal/lightning"
 	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/nostr"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
 	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/internal/setup"
 	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/internal/swap"
 	"github.com/Klingon-tech/klingdex/internal/sync"
+	"github.com/Klingon-tech/klingdex/internal/telemetry"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/Klingon-tech/klingdex/internal/watchtower"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
 )
 
@@ -36,12 +49,23 @@ func main() {
 		configFile     = flag.String("config", "", "Config file path (default: <data-dir>/config.yaml)")
 		listenAddr     = flag.String("listen", "", "Listen address (multiaddr), overrides config")
 		apiAddr        = flag.String("api", "127.0.0.1:8080", "JSON-RPC API address")
+		tlsCert        = flag.String("tls-cert", "", "API TLS certificate file (PEM), overrides config")
+		tlsKey         = flag.String("tls-key", "", "API TLS key file (PEM), overrides config")
 		enableMDNS     = flag.Bool("mdns", true, "Enable mDNS discovery")
 		enableDHT      = flag.Bool("dht", true, "Enable DHT discovery")
 		testnet        = flag.Bool("testnet", false, "Run on testnet (separate network and data)")
 		bootstrapPeers = flag.String("bootstrap", "", "Bootstrap peers (comma-separated multiaddrs)")
 		logLevel       = flag.String("log-level", "info", "Log level (debug, info, warn, error)")
 		showVersion    = flag.Bool("version", false, "Show version and exit")
+		restoreBackup  = flag.String("restore-backup", "", "Restore an encrypted backup (file path, or \"s3\" for the latest upload) and exit")
+		coldMode       = flag.Bool("cold", false, "Cold mode: only claim and refund existing swaps, overrides config")
+		fsck           = flag.Bool("fsck", false, "Check storage integrity and exit")
+		fsckRepair     = flag.Bool("fsck-repair", false, "Check storage integrity, quarantine or delete bad records and exit")
+		initSetup      = flag.Bool("init", false, "Run the interactive first-run setup and exit")
+		replicaOf      = flag.String("replica", "", "Serve read-only API traffic from the primary node's data directory, overrides config")
+		enableTor      = flag.Bool("tor", false, "Dial onion addresses through Tor and publish an onion service, overrides config")
+		torSocks       = flag.String("tor-socks", "", "Tor SOCKS5 proxy address (host:port), overrides config")
+		torOnly        = flag.Bool("tor-only", false, "Route all P2P traffic through Tor (implies -tor), overrides config")
 	)
 	flag.Parse()
 
@@ -57,6 +81,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	if *initSetup {
+		runSetup(log, *dataDir)
+		return
+	}
+
 	// Determine data directory (testnet uses subdirectory)
 	effectiveDataDir := *dataDir
 	if *testnet {
@@ -94,9 +123,29 @@ func main() {
 		cfg.NetworkType = node.NetworkMainnet
 	}
 
+	if *tlsCert != "" || *tlsKey != "" {
+		cfg.API.TLS.CertFile = expandPath(*tlsCert)
+		cfg.API.TLS.KeyFile = expandPath(*tlsKey)
+	}
+	if cfg.API.TLS.UsesACME() && cfg.API.TLS.ACMECacheDir == "" {
+		cfg.API.TLS.ACMECacheDir = filepath.Join(expandPath(effectiveDataDir), "acme")
+	}
+
 	if *bootstrapPeers != "" {
 		cfg.Network.BootstrapPeers = parseBootstrapPeers(*bootstrapPeers)
 	}
+	if *coldMode {
+		cfg.ColdMode = true
+	}
+	if *enableTor || *torOnly {
+		cfg.Network.Tor.Enabled = true
+	}
+	if *torOnly {
+		cfg.Network.Tor.Only = true
+	}
+	if *torSocks != "" {
+		cfg.Network.Tor.SocksAddr = *torSocks
+	}
 
 	// Update logging with config level
 	log = logging.New(&logging.Config{
@@ -107,12 +156,36 @@ func main() {
 
 	log.Info("Config loaded", "path", node.ConfigPath(effectiveDataDir))
 
+	if *replicaOf != "" {
+		cfg.Replica.Enabled = true
+		cfg.Replica.PrimaryDataDir = *replicaOf
+	}
+	if err := cfg.Replica.Validate(); err != nil {
+		log.Fatal("Invalid replica config", "error", err)
+	}
+	if cfg.Replica.Enabled {
+		runReplica(log, cfg, *apiAddr)
+		return
+	}
+
+	// Tracing: export spans of the swap lifecycle over OTLP
+	tracer, err := tracing.New(cfg.Tracing, version)
+	if err != nil {
+		log.Fatal("Invalid tracing config", "error", err)
+	}
+	tracer.Start()
+
 	// Create context with cancellation
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
 	// Initialize storage
 	dataPath := expandPath(cfg.Storage.DataDir)
+
+	if *restoreBackup != "" {
+		runRestore(ctx, log, cfg, dataPath, *restoreBackup)
+		return
+	}
 	storeCfg := &storage.Config{
 		DataDir: dataPath,
 	}
@@ -123,33 +196,118 @@ func main() {
 	defer store.Close()
 	log.Info("Storage initialized", "path", dataPath)
 
+	if *fsck || *fsckRepair {
+		runFsck(log, store, *fsckRepair)
+		return
+	}
+
 	// Initialize wallet service
 	walletNetwork := chain.Mainnet
 	if *testnet {
 		walletNetwork = chain.Testnet
 	}
 
+	// Select per-chain test networks (e.g. BTC signet, ETH holesky) before
+	// any chain params or backends are used
+	if err := cfg.ApplyTestnets(); err != nil {
+		log.Fatal("Invalid testnet selection", "error", err)
+	}
+	if *testnet && len(cfg.Testnets) > 0 {
+		log.Info("Test networks selected", "testnets", cfg.Testnets)
+	}
+
 	// Initialize backend registry for blockchain access
-	backendRegistry := backend.NewDefaultRegistry(walletNetwork)
+	backendRegistry := backend.NewConfiguredRegistry(walletNetwork, cfg.Backends)
+	if err := cfg.BackendPlugins.Validate(); err != nil {
+		log.Fatal("Invalid backend_plugins config", "error", err)
+	}
+	if cfg.BackendPlugins.Dir != "" {
+		plugins, err := backendRegistry.LoadPlugins(cfg.BackendPlugins, cfg.Backends)
+		if err != nil {
+			log.Warn("Some backend plugins failed to start", "dir", cfg.BackendPlugins.Dir, "error", err)
+		}
+		for _, p := range plugins {
+			log.Info("Backend plugin loaded", "name", p.Info.Name, "version", p.Info.Version, "chains", p.Info.Chains)
+		}
+		defer backendRegistry.CloseAll()
+	}
 	log.Info("Backend registry initialized", "network", walletNetwork, "backends", backendRegistry.List())
 
+	if err := cfg.SpendingPolicy.Validate(); err != nil {
+		log.Fatal("Invalid spending policy", "error", err)
+	}
+	if err := cfg.KeyHygiene.Validate(); err != nil {
+		log.Fatal("Invalid key_hygiene config", "error", err)
+	}
+	// Lock seed material in memory before any wallet is unlocked
+	if cfg.KeyHygiene.Mlock {
+		wallet.SetMemoryLocking(true)
+		if !wallet.MemoryLocking().Supported {
+			log.Warn("Memory locking is not supported on this platform, seed material is only zeroed after use")
+		}
+	}
 	walletService := wallet.NewService(&wallet.ServiceConfig{
 		DataDir:  dataPath,
 		Network:  walletNetwork,
 		Backends: backendRegistry,
+		Policy:   &cfg.SpendingPolicy,
+		Store:    store,
 	})
-	log.Info("Wallet service initialized", "network", walletNetwork)
+	log.Info("Wallet service initialized", "network", walletNetwork, "policy_rules", len(cfg.SpendingPolicy.Chains))
 
 	// Initialize swap coordinator with backends and wallet service
 	coordinator := swap.NewCoordinator(&swap.CoordinatorConfig{
-		Store:         store,
-		Network:       walletNetwork,
-		Backends:      backendRegistry.All(),
-		WalletService: walletService,
+		Store:          store,
+		Network:        walletNetwork,
+		Backends:       backendRegistry.All(),
+		WalletService:  walletService,
+		JobMaxAttempts: config.DefaultSwapConfig().JobMaxAttempts,
 	})
 	defer coordinator.Close()
 	log.Info("Swap coordinator initialized")
 
+	// Startup checks: data dir, ports, clock, backends and API auth, before
+	// anything binds or trades
+	if err := cfg.StartupChecks.Validate(); err != nil {
+		log.Fatal("Invalid startup checks config", "error", err)
+	}
+	if cfg.StartupChecks.Enabled {
+		runStartupChecks(ctx, log, cfg, dataPath, *apiAddr, backendRegistry)
+	}
+
+	// Cold mode: rescue in-flight swaps without starting or funding any
+	// (before pending funding jobs are resumed)
+	if cfg.ColdMode {
+		coordinator.SetColdMode(true)
+		log.Warn("Cold mode: new swaps and funding are disabled, existing swaps are only claimed or refunded")
+	}
+
+	// Lightning: settle BTC legs through the user's node (before swaps are
+	// loaded, so open invoices are watched again)
+	if cfg.Lightning.Enabled {
+		ln, err := lightning.New(cfg.Lightning)
+		if err != nil {
+			log.Fatal("Invalid lightning config", "error", err)
+		}
+		coordinator.SetLightning(ln, cfg.Lightning)
+		log.Info("Lightning settlement enabled", "implementation", cfg.Lightning.Implementation, "host", cfg.Lightning.Host)
+	}
+
+	// Monero: BTC/XMR adaptor swaps through monero-wallet-rpc (before swaps
+	// are loaded, so their drivers resume)
+	if err := cfg.Monero.Validate(); err != nil {
+		log.Fatal("Invalid monero config", "error", err)
+	}
+	if cfg.Monero.Enabled {
+		coordinator.SetMonero(backend.NewMoneroWalletRPC(cfg.Monero.URL, cfg.Monero.User, cfg.Monero.Password), cfg.Monero.WalletFile)
+		log.Info("Monero swaps enabled", "url", cfg.Monero.URL)
+	}
+
+	// Snapshots: load pending swaps from one blob, reconcile in the background
+	if cfg.Snapshots.Enabled {
+		coordinator.StartSnapshots(cfg.Snapshots.Interval)
+	}
+
 	// Load pending swaps from database on startup
 	if err := coordinator.LoadPendingSwaps(ctx); err != nil {
 		log.Warn("Failed to load pending swaps", "error", err)
@@ -157,6 +315,87 @@ func main() {
 		log.Info("Pending swaps loaded from database")
 	}
 
+	// Key hygiene: check that finished swaps keep no keys or secrets
+	if cfg.KeyHygiene.AuditInterval > 0 {
+		if _, err := coordinator.AuditKeyHygiene(); err != nil {
+			log.Warn("Key hygiene audit failed", "error", err)
+		}
+		coordinator.StartKeyHygieneAudit(cfg.KeyHygiene.AuditInterval)
+	}
+
+	// Finish on-chain side effects interrupted by a crash or restart
+	if resumed, err := coordinator.ResumePendingJobs(ctx); err != nil {
+		log.Warn("Failed to resume pending jobs", "error", err)
+	} else if resumed > 0 {
+		log.Info("Resumed pending on-chain jobs", "count", resumed)
+	}
+	coordinator.StartBroadcastJobMonitor(swap.BroadcastJobInterval)
+
+	// Auto-claim: claim our side once the counterparty revealed the secret
+	if err := coordinator.SetAutoClaimPolicy(cfg.AutoClaim); err != nil {
+		log.Fatal("Invalid auto-claim config", "error", err)
+	}
+	coordinator.StartAutoClaimMonitor()
+	log.Info("Auto-claim policy set", "mode", cfg.AutoClaim.Mode, "chain_overrides", len(cfg.AutoClaim.Chains))
+	coordinator.StartDeadlineMonitor(cfg.Deadlines.UpdateInterval)
+
+	// Refund watcher: refund our expired locks when the counterparty vanished
+	swap.NewRefundWatcher(coordinator, cfg.RefundWatcher).Start()
+	log.Info("Refund watcher", "enabled", cfg.RefundWatcher.Enabled, "interval", cfg.RefundWatcher.CheckInterval)
+	coordinator.StartEVMCreateCancelMonitor(swap.EVMCreateCancelInterval)
+
+	// Zero-conf: small swaps may proceed on unconfirmed counterparty funding
+	if err := coordinator.SetZeroConfPolicy(cfg.ZeroConf); err != nil {
+		log.Fatal("Invalid zero-conf config", "error", err)
+	}
+
+	// Funding variance: proceed, request a top-up, or abort on short escrows
+	if err := coordinator.SetFundingVariancePolicy(cfg.FundingVariance); err != nil {
+		log.Fatal("Invalid funding variance config", "error", err)
+	}
+
+	// Affordability: refuse trades the wallet can't fund alongside pending swaps
+	if err := coordinator.SetAffordabilityPolicy(cfg.Affordability); err != nil {
+		log.Fatal("Invalid affordability config", "error", err)
+	}
+
+	// Confirmation: hold funding transactions for approval in wallet UIs
+	if err := coordinator.SetConfirmationPolicy(cfg.Confirmation); err != nil {
+		log.Fatal("Invalid confirmation config", "error", err)
+	}
+
+	// Contract pause: halt new swaps on chains whose HTLC contract is paused
+	if err := coordinator.SetContractPausePolicy(cfg.ContractPause); err != nil {
+		log.Fatal("Invalid contract pause config", "error", err)
+	}
+	coordinator.StartContractPauseMonitor()
+
+	// Operation timeouts: per-kind deadlines of broadcasts, chain queries and waits
+	if err := coordinator.SetOperationTimeouts(cfg.OperationTimeouts); err != nil {
+		log.Fatal("Invalid operation timeouts config", "error", err)
+	}
+
+	// Privacy: warn about or refuse address reuse; enforce claims to fresh addresses
+	if err := cfg.Privacy.Validate(); err != nil {
+		log.Fatal("Invalid privacy config", "error", err)
+	}
+	coordinator.SetFreshAddresses(cfg.Privacy.Enforce())
+
+	// Protocol: refuse new trades with peers older than the minimum version
+	if err := cfg.Protocol.Validate(); err != nil {
+		log.Fatal("Invalid protocol config", "error", err)
+	}
+
+	// Rendezvous: private trading groups discovered through rendezvous nodes
+	if err := cfg.Rendezvous.Validate(); err != nil {
+		log.Fatal("Invalid rendezvous config", "error", err)
+	}
+
+	// Tor: dial peers through its SOCKS5 proxy and publish an onion service
+	if err := cfg.Network.Tor.Validate(); err != nil {
+		log.Fatal("Invalid tor config", "error", err)
+	}
+
 	// Create node
 	log.Info("Starting Klingon P2P Node...")
 	n, err := node.New(ctx, cfg)
@@ -187,6 +426,161 @@ func main() {
 
 	// Start RPC server
 	rpcServer := rpc.NewServer(n, store, walletService, coordinator)
+	rpcServer.SetTracer(tracer)
+	rpcServer.SetTLS(cfg.API.TLS)
+	rpcServer.SetAPIKeys(cfg.API.Keys)
+	rpcServer.SetAdminKeys(cfg.API.AdminKeys)
+	rpcServer.SetDashboard(cfg.API.Dashboard)
+	rpcServer.SetSlowCallThreshold(cfg.API.SlowCallThreshold)
+	if cfg.API.Public {
+		rpcServer.SetPublic()
+	}
+	if err := rpcServer.SetWebSocket(cfg.API.WebSocket); err != nil {
+		log.Fatal("Invalid WebSocket config", "error", err)
+	}
+
+	// Watchtower: broadcast pre-signed refunds/claims for trusted offline peers
+	var tower *watchtower.Tower
+	if cfg.Watchtower.Enabled {
+		tower = watchtower.New(cfg.Watchtower, store, backendRegistry.All())
+		tower.Start()
+		rpcServer.SetWatchtower(tower)
+	}
+
+	// Analytics export: on demand via export_run, periodically when enabled
+	exportCfg := cfg.Export
+	if exportCfg.Dir == "" {
+		exportCfg.Dir = filepath.Join(dataPath, "exports")
+	}
+	exporter, err := export.New(exportCfg, store)
+	if err != nil {
+		log.Fatal("Invalid export config", "error", err)
+	}
+	rpcServer.SetExporter(exporter)
+	if exportCfg.Enabled {
+		exporter.Start()
+	}
+
+	// Encrypted backups: replicate to trusted peers/S3, hold backups for peers
+	backupDir := filepath.Join(dataPath, "backups")
+	replicator, err := backup.New(cfg.Backup, store, n.Host(), backupDir)
+	if err != nil {
+		log.Fatal("Invalid backup config", "error", err)
+	}
+	replicator.Start()
+	rpcServer.SetBackup(replicator, backupDir)
+
+	// Backend trace capture bundles from backend_captureStop
+	rpcServer.SetCaptureDir(filepath.Join(dataPath, "captures"))
+	if cfg.Backup.Enabled {
+		// Any event means "back up soon"; one queued is enough
+		coordinator.Subscribe(func(swap.SwapEvent) { replicator.Trigger() }, swap.SubscriberOptions{
+			Name:      "backup",
+			QueueSize: 1,
+			Overflow:  swap.OverflowDropNewest,
+		})
+	}
+
+	// Compliance: signed, hash-chained audit records at trade checkpoints
+	streamer, err := compliance.New(cfg.Compliance, store, coordinator, n.Host().Peerstore().PrivKey(n.ID()))
+	if err != nil {
+		log.Fatal("Invalid compliance config", "error", err)
+	}
+	rpcServer.SetCompliance(streamer)
+	if cfg.Compliance.Enabled {
+		coordinator.Subscribe(streamer.HandleSwapEvent, swap.SubscriberOptions{Name: "compliance", QueueSize: 4096})
+		streamer.Start()
+	}
+
+	// Telemetry: opt-in anonymized network statistics
+	reporter, err := telemetry.New(cfg.Telemetry, store, string(walletNetwork))
+	if err != nil {
+		log.Fatal("Invalid telemetry config", "error", err)
+	}
+	rpcServer.SetTelemetry(reporter)
+	if cfg.Telemetry.Enabled {
+		if cfg.Telemetry.Collector == "" {
+			topic, err := n.PubSub().Join(telemetry.Topic)
+			if err != nil {
+				log.Fatal("Failed to join telemetry topic", "error", err)
+			}
+			reporter.SetPublisher(func(ctx context.Context, data []byte) error {
+				return topic.Publish(ctx, data)
+			})
+		}
+		reporter.Start()
+	}
+
+	// Nostr: mirror signed orders to relays beyond our libp2p peers
+	bridge, err := nostr.New(cfg.Nostr, string(walletNetwork))
+	if err != nil {
+		log.Fatal("Invalid nostr config", "error", err)
+	}
+	rpcServer.SetNostr(bridge)
+	bridge.Start()
+
+	// UTXO consolidation: merge small UTXOs while fees are low and swaps idle
+	consolidator, err := consolidate.New(cfg.Consolidation, walletService, store)
+	if err != nil {
+		log.Fatal("Invalid consolidation config", "error", err)
+	}
+	consolidator.SetActive(coordinator.HasActiveSwapOn)
+	rpcServer.SetConsolidator(consolidator)
+	consolidator.Start()
+
+	// Swap archival: old finished swaps move to compressed archive files
+	archiver, err := archive.New(cfg.Archive, filepath.Join(dataPath, "archive"), store)
+	if err != nil {
+		log.Fatal("Invalid archive config", "error", err)
+	}
+	rpcServer.SetArchive(archiver)
+	archiver.Start()
+
+	// Price sanity: flag or hide off-market orders, guard creates and takes
+	var priceChecker *pricefeed.Checker
+	if cfg.PriceSanity.Enabled {
+		priceChecker, err = pricefeed.New(cfg.PriceSanity)
+		if err != nil {
+			log.Fatal("Invalid price sanity config", "error", err)
+		}
+		priceChecker.Start()
+		rpcServer.SetPriceChecker(priceChecker)
+	}
+
+	// Event audit log: persist WebSocket events for events_query
+	rpcServer.SetEventLog(cfg.EventLog)
+
+	// Order identity: optionally drop orders without a wallet signature
+	rpcServer.SetRequireSignedOrders(cfg.Identity.RequireSignedOrders)
+	rpcServer.SetOrderPoW(cfg.OrderPoW)
+	rpcServer.SetOrderSchema(cfg.OrderSchema)
+	rpcServer.SetMetaClaim(cfg.MetaClaim)
+	rpcServer.SetPrivacy(cfg.Privacy)
+	if err := cfg.WrappedAssets.Validate(); err != nil {
+		log.Fatal("Invalid wrapped_assets config", "error", err)
+	}
+	rpcServer.SetWrappedAssets(cfg.WrappedAssets)
+	if err := cfg.FeeNegotiation.Validate(); err != nil {
+		log.Fatal("Invalid fee_negotiation config", "error", err)
+	}
+	rpcServer.SetFeeNegotiation(cfg.FeeNegotiation)
+	if err := cfg.Precision.Validate(); err != nil {
+		log.Fatal("Invalid precision config", "error", err)
+	}
+	rpcServer.SetPrecision(cfg.Precision)
+	if err := cfg.Aggregation.Validate(); err != nil {
+		log.Fatal("Invalid aggregation config", "error", err)
+	}
+	rpcServer.SetAggregation(cfg.Aggregation)
+	if err := cfg.Finality.Validate(); err != nil {
+		log.Fatal("Invalid finality config", "error", err)
+	}
+	rpcServer.SetFinality(cfg.Finality)
+	if err := cfg.Reannounce.Validate(); err != nil {
+		log.Fatal("Invalid reannounce config", "error", err)
+	}
+	rpcServer.SetReannounce(cfg.Reannounce)
+
 	if err := rpcServer.Start(*apiAddr); err != nil {
 		log.Fatal("Failed to start RPC server", "error", err)
 	}
@@ -194,6 +588,9 @@ func main() {
 	// Set up swap message handlers (for order broadcasting, etc.)
 	rpcServer.SetupSwapHandlers()
 
+	// Re-validate and re-announce our open orders once peers are connected
+	rpcServer.ReannounceOrders(ctx)
+
 	// Initialize order and trade sync services
 	orderSync := sync.NewOrderSync(n.Host(), store, nil)
 	if err := orderSync.Start(); err != nil {
@@ -205,8 +602,29 @@ func main() {
 		log.Warn("Failed to start trade sync", "error", err)
 	}
 
+	// Trade history sync with the user's other nodes (mutual allow-list)
+	historySync, err := sync.NewHistorySync(n.Host(), store, cfg.HistorySync)
+	if err != nil {
+		log.Fatal("Invalid history sync config", "error", err)
+	}
+	if err := historySync.Start(); err != nil {
+		log.Warn("Failed to start history sync", "error", err)
+	}
+	rpcServer.SetHistorySync(historySync)
+
 	log.Info("Order/trade sync initialized")
 
+	// Subsystems operators can stop and start at runtime
+	subsystems := node.NewSubsystems()
+	subsystems.Register(node.Subsystem{Name: node.SubsystemStorage, Essential: true}, true)
+	storageDep := []string{node.SubsystemStorage}
+	subsystems.Register(node.Subsystem{Name: node.SubsystemOrderSync, DependsOn: storageDep, Start: orderSync.Start, Stop: orderSync.Stop}, true)
+	subsystems.Register(node.Subsystem{Name: node.SubsystemTradeSync, DependsOn: storageDep, Start: tradeSync.Start, Stop: tradeSync.Stop}, true)
+	subsystems.Register(node.Subsystem{Name: node.SubsystemHistorySync, DependsOn: storageDep, Start: historySync.Start, Stop: historySync.Stop}, true)
+	subsystems.Register(node.Subsystem{Name: node.SubsystemDHT, Start: n.StartDHT, Stop: n.StopDHT}, n.DHT() != nil)
+	subsystems.Register(node.Subsystem{Name: node.SubsystemMDNS, Start: n.StartMDNS, Stop: n.StopMDNS}, n.MDNSRunning())
+	rpcServer.SetSubsystems(subsystems)
+
 	// Print node info
 	printBanner(log, n, cfg, *apiAddr)
 
@@ -219,7 +637,7 @@ func main() {
 			hub.Broadcast(rpc.EventPeerConnected, map[string]interface{}{
 				"peer_id":     p.String(),
 				"total_peers": n.PeerCount(),
-			})
+			}, rpc.TopicPeers)
 		}
 	})
 
@@ -230,7 +648,50 @@ func main() {
 			hub.Broadcast(rpc.EventPeerDisconnected, map[string]interface{}{
 				"peer_id":     p.String(),
 				"total_peers": n.PeerCount(),
-			})
+			}, rpc.TopicPeers)
+		}
+	})
+
+	// Degraded mode: pause new trades while isolated and notify clients
+	n.Partition().OnChange(func(status node.PartitionStatus) {
+		coordinator.SetDegraded(status.Degraded)
+		if !status.Degraded {
+			// Peers may have dropped our orders while we were unreachable
+			rpcServer.ReannounceOrders(ctx)
+		}
+		if hub := rpcServer.WSHub(); hub != nil {
+			event := rpc.EventNodeRecovered
+			if status.Degraded {
+				event = rpc.EventNodeDegraded
+			}
+			hub.Broadcast(event, status)
+		}
+	})
+
+	// Swap messages routed around a failed direct connection
+	n.OnDeliveryPath(func(event node.DeliveryPathEvent) {
+		if hub := rpcServer.WSHub(); hub != nil {
+			eventType := rpc.EventSwapPathRestored
+			if event.Degraded() {
+				eventType = rpc.EventSwapPathDegraded
+			}
+			hub.Broadcast(eventType, event)
+		}
+	})
+
+	// Clock skew: adjust deadlines, optionally pause new trades, warn clients
+	n.Clock().OnChange(func(status node.ClockStatus) {
+		var offset time.Duration
+		if status.Skewed {
+			offset = status.Offset()
+		}
+		coordinator.SetClockSkew(offset, status.Skewed && n.Clock().RefuseTrades())
+		if hub := rpcServer.WSHub(); hub != nil {
+			event := rpc.EventClockSynced
+			if status.Skewed {
+				event = rpc.EventClockSkewed
+			}
+			hub.Broadcast(event, status)
 		}
 	})
 
@@ -270,6 +731,21 @@ func main() {
 	if tradeSync != nil {
 		tradeSync.Stop()
 	}
+	historySync.Stop()
+
+	if tower != nil {
+		tower.Stop()
+	}
+	exporter.Stop()
+	replicator.Stop()
+	streamer.Stop()
+	reporter.Stop()
+	bridge.Stop()
+	consolidator.Stop()
+	archiver.Stop()
+	if priceChecker != nil {
+		priceChecker.Stop()
+	}
 
 	if err := rpcServer.Stop(); err != nil {
 		log.Error("Error stopping RPC server", "error", err)
@@ -277,10 +753,157 @@ func main() {
 	if err := n.Stop(); err != nil {
 		log.Error("Error during shutdown", "error", err)
 	}
+	tracer.Stop()
 
 	log.Info("Goodbye!")
 }
 
+// runRestore installs an encrypted backup as the node database. The
+// passphrase comes from KLINGON_BACKUP_PASSPHRASE or backup.passphrase.
+func runRestore(ctx context.Context, log *logging.Logger, cfg *node.Config, dataPath, source string) {
+	passphrase := os.Getenv("KLINGON_BACKUP_PASSPHRASE")
+	if passphrase == "" {
+		passphrase = cfg.Backup.Passphrase
+	}
+	if passphrase == "" {
+		log.Fatal("Backup passphrase required (KLINGON_BACKUP_PASSPHRASE or backup.passphrase)")
+	}
+
+	var data []byte
+	var err error
+	if source == "s3" {
+		data, err = backup.FetchLatestFromS3(ctx, cfg.Backup.S3)
+	} else {
+		data, err = os.ReadFile(expandPath(source))
+	}
+	if err != nil {
+		log.Fatal("Failed to read backup", "source", source, "error", err)
+	}
+
+	if err := os.MkdirAll(dataPath, 0700); err != nil {
+		log.Fatal("Failed to create data directory", "error", err)
+	}
+	result, err := backup.Restore(data, passphrase, dataPath)
+	if err != nil {
+		log.Fatal("Failed to restore backup", "error", err)
+	}
+	log.Info("Backup restored",
+		"node_id", result.NodeID,
+		"created_at", time.UnixMilli(result.CreatedAt).Format(time.RFC3339),
+		"previous_db", result.PreviousDB,
+	)
+}
+
+// runFsck checks the storage, logs what it found and exits non-zero when
+// problems remain.
+func runFsck(log *logging.Logger, store *storage.Storage, repair bool) {
+	report, err := store.Fsck(repair)
+	if err != nil {
+		log.Fatal("Storage check failed", "error", err)
+	}
+	for _, line := range report.Integrity {
+		if line != "ok" {
+			log.Error("Database integrity", "problem", line)
+		}
+	}
+	remaining := 0
+	for _, issue := range report.Issues {
+		log.Warn("Bad record",
+			"table", issue.Table,
+			"key", issue.Key,
+			"problem", issue.Problem,
+			"action", issue.Action,
+			"repaired", issue.Repaired,
+		)
+		if !issue.Repaired {
+			remaining++
+		}
+	}
+	log.Info("Storage check complete", "checked", report.Checked, "issues", len(report.Issues), "repaired", report.Repaired)
+	if remaining > 0 || len(report.Integrity) != 1 || report.Integrity[0] != "ok" {
+		store.Close()
+		os.Exit(1)
+	}
+}
+
+// runReplica serves the read-only API methods from the primary's database
+// until interrupted. Nothing else of the node is started.
+func runReplica(log *logging.Logger, cfg *node.Config, apiAddr string) {
+	primaryPath := expandPath(cfg.Replica.PrimaryDataDir)
+	store, err := storage.New(&storage.Config{DataDir: primaryPath, ReadOnly: true})
+	if err != nil {
+		log.Fatal("Failed to open the primary's storage", "error", err)
+	}
+	defer store.Close()
+
+	rpcServer := rpc.NewServer(nil, store, nil, nil)
+	rpcServer.SetTLS(cfg.API.TLS)
+	rpcServer.SetAPIKeys(cfg.API.Keys)
+	rpcServer.SetAdminKeys(cfg.API.AdminKeys)
+	rpcServer.SetSlowCallThreshold(cfg.API.SlowCallThreshold)
+	if err := rpcServer.SetWebSocket(cfg.API.WebSocket); err != nil {
+		log.Fatal("Invalid WebSocket config", "error", err)
+	}
+	rpcServer.SetOrderSchema(cfg.OrderSchema)
+	rpcServer.SetReplica(cfg.Replica.PollInterval)
+	if err := rpcServer.Start(apiAddr); err != nil {
+		log.Fatal("Failed to start RPC server", "error", err)
+	}
+	log.Info("Read replica started", "primary", primaryPath, "api", apiAddr)
+
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
+	<-sigCh
+
+	if err := rpcServer.Stop(); err != nil {
+		log.Error("Error stopping RPC server", "error", err)
+	}
+	log.Info("Goodbye!")
+}
+
+// runSetup runs the first-run wizard on the terminal.
+func runSetup(log *logging.Logger, dataDir string) {
+	if _, err := setup.NewWizard(os.Stdin, os.Stdout).Run(context.Background(), dataDir); err != nil {
+		log.Fatal("Setup failed", "error", err)
+	}
+}
+
+// runStartupChecks logs the startup checklist and applies the failure
+// policy: start anyway, start in cold mode, or exit.
+func runStartupChecks(ctx context.Context, log *logging.Logger, cfg *node.Config, dataPath, apiAddr string, backends *backend.Registry) {
+	list := setup.Run(ctx, setup.Options{
+		Config:   cfg,
+		DataDir:  dataPath,
+		APIAddr:  apiAddr,
+		Backends: backends.All(),
+		Timeout:  cfg.StartupChecks.Timeout,
+	})
+	for _, check := range list.Checks {
+		switch check.Status {
+		case setup.StatusOK:
+			log.Debug("Startup check passed", "check", check.Name, "detail", check.Detail)
+		case setup.StatusWarn:
+			log.Warn("Startup check warning", "check", check.Name, "detail", check.Detail)
+		default:
+			log.Error("Startup check failed", "check", check.Name, "detail", check.Detail)
+		}
+	}
+	if list.OK() {
+		log.Info("Startup checks passed", "checks", len(list.Checks))
+		return
+	}
+
+	switch cfg.StartupChecks.OnFailure {
+	case node.StartupFailureExit:
+		log.Fatal("Startup checks failed, not starting", "failed", len(list.Failed()))
+	case node.StartupFailureCold:
+		log.Warn("Startup checks failed, starting in cold mode", "failed", len(list.Failed()))
+		cfg.ColdMode = true
+	default:
+		log.Warn("Startup checks failed, starting anyway", "failed", len(list.Failed()))
+	}
+}
+
 // expandPath expands ~ to home directory.
 func expandPath(path string) string {
 	if len(path) > 0 && path[0] == '~' {
@@ -308,11 +931,26 @@ func printBanner(log *logging.Logger, n *node.Node, cfg *node.Config, apiAddr st
 	for _, addr := range n.Addrs() {
 		log.Infof("    %s/p2p/%s", addr.String(), n.ID().String())
 	}
+	if onion := n.OnionAddr(); onion != nil {
+		log.Info("")
+		log.Infof("  Onion: %s/p2p/%s", onion.String(), n.ID().String())
+	}
 	log.Info("")
-	log.Infof("  API: http://%s", apiAddr)
-	log.Infof("  WS:  ws://%s/ws", apiAddr)
+	httpScheme, wsScheme := "http", "ws"
+	if cfg.API.TLS.Enabled() {
+		httpScheme, wsScheme = "https", "wss"
+	}
+	log.Infof("  API: %s://%s", httpScheme, apiAddr)
+	log.Infof("  WS:  %s://%s/ws", wsScheme, apiAddr)
 	log.Info("")
-	log.Infof("  Network: %s | mDNS: %v | DHT: %v", networkLabel, cfg.Network.EnableMDNS, cfg.Network.EnableDHT)
+	log.Infof("  Network: %s | mDNS: %v | DHT: %v", networkLabel, n.MDNSRunning(), cfg.Network.EnableDHT)
+	if tor := cfg.Network.Tor; tor.Enabled {
+		mode := "onion peers"
+		if tor.Only {
+			mode = "all traffic"
+		}
+		log.Infof("  Tor: %s via %s", mode, tor.SocksAddr)
+	}
 	log.Infof("  Data dir: %s", expandPath(cfg.Storage.DataDir))
 	log.Info("")
 	log.Info("=================================================")
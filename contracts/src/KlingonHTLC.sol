@@ -5,6 +5,8 @@ import "@openzeppelin/contracts/token/ERC20/IERC20.sol";
 import "@openzeppelin/contracts/token/ERC20/utils/SafeERC20.sol";
 import "@openzeppelin/contracts/utils/ReentrancyGuard.sol";
 import "@openzeppelin/contracts/access/Ownable.sol";
+import "@openzeppelin/contracts/utils/cryptography/ECDSA.sol";
+import "@openzeppelin/contracts/utils/cryptography/EIP712.sol";
 
 /**
  * @title KlingonHTLC
@@ -12,8 +14,10 @@ import "@openzeppelin/contracts/access/Ownable.sol";
  * @notice Hash Time-Locked Contract for atomic swaps
  * @dev Supports native tokens (ETH/BNB/MATIC) and ERC20 tokens
  *      Used for same-chain, cross-EVM, and cross-chain (Bitcoin) swaps
+ *      Receivers without native gas can authorize a relayer to claim for
+ *      them, paying the relayer in kind out of the swap output
  */
-contract KlingonHTLC is ReentrancyGuard, Ownable {
+contract KlingonHTLC is ReentrancyGuard, Ownable, EIP712 {
     using SafeERC20 for IERC20;
 
     // ============ Constants ============
@@ -33,6 +37,11 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
     /// @notice Maximum fee in basis points (5% = 500 bps)
     uint256 public constant MAX_FEE_BPS = 500;
 
+    /// @notice EIP-712 type of a receiver's claim authorization
+    bytes32 public constant CLAIM_AUTHORIZATION_TYPEHASH = keccak256(
+        "ClaimAuthorization(bytes32 swapId,address relayer,uint256 relayerFee,uint256 deadline)"
+    );
+
     // ============ Enums ============
 
     /// @notice Possible states for a swap
@@ -92,6 +101,13 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
         bytes32 secret
     );
 
+    /// @notice Emitted with SwapClaimed when a relayer submitted the claim
+    event SwapClaimRelayed(
+        bytes32 indexed swapId,
+        address indexed relayer,
+        uint256 relayerFee
+    );
+
     /// @notice Emitted when a swap is refunded
     event SwapRefunded(
         bytes32 indexed swapId,
@@ -122,6 +138,10 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
     error ContractPaused();
     error InvalidDaoAddress();
     error FeeTooHigh();
+    error AuthorizationExpired();
+    error InvalidSignature();
+    error NotRelayer();
+    error RelayerFeeTooHigh();
 
     // ============ Constructor ============
 
@@ -129,7 +149,7 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
      * @notice Initialize the HTLC contract
      * @param _daoAddress Address to receive fees
      */
-    constructor(address _daoAddress) Ownable(msg.sender) {
+    constructor(address _daoAddress) Ownable(msg.sender) EIP712("KlingonHTLC", "1") {
         if (_daoAddress == address(0)) revert InvalidDaoAddress();
         daoAddress = _daoAddress;
     }
@@ -254,27 +274,58 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
         uint256 receiverAmount = swap.amount - swap.daoFee;
 
         // Interactions (external calls LAST)
-        if (swap.token == address(0)) {
-            // Native token transfer
-            (bool success, ) = swap.receiver.call{value: receiverAmount}("");
-            if (!success) revert TransferFailed();
-
-            if (swap.daoFee > 0) {
-                (success, ) = daoAddress.call{value: swap.daoFee}("");
-                if (!success) revert TransferFailed();
-            }
-        } else {
-            // ERC20 token transfer
-            IERC20(swap.token).safeTransfer(swap.receiver, receiverAmount);
-            if (swap.daoFee > 0) {
-                IERC20(swap.token).safeTransfer(daoAddress, swap.daoFee);
-            }
-        }
+        _transferOut(swap.token, swap.receiver, receiverAmount);
+        _transferOut(swap.token, daoAddress, swap.daoFee);
 
         // Emit event with SECRET (critical for cross-chain swaps!)
         emit SwapClaimed(swapId, msg.sender, secret);
     }
 
+    /**
+     * @notice Claim on behalf of the receiver with its signed authorization
+     * @dev Lets a receiver without native gas have a relayer submit the claim.
+     *      The submitter is paid relayerFee in kind, out of the receiver's
+     *      amount. The secret is emitted in SwapClaimed as for claim().
+     * @param swapId The swap identifier
+     * @param secret The 32-byte secret that hashes to secretHash
+     * @param relayer Relayer allowed to submit the claim (address(0) for anyone)
+     * @param relayerFee Amount paid to the submitter out of the receiver's amount
+     * @param deadline Unix timestamp after which the authorization expires
+     * @param signature Receiver's EIP-712 signature of the ClaimAuthorization
+     */
+    function claimWithSignature(
+        bytes32 swapId,
+        bytes32 secret,
+        address relayer,
+        uint256 relayerFee,
+        uint256 deadline,
+        bytes calldata signature
+    ) external nonReentrant {
+        Swap storage swap = swaps[swapId];
+
+        // Checks
+        if (swap.state != SwapState.Active) revert SwapNotActive();
+        if (block.timestamp > deadline) revert AuthorizationExpired();
+        if (relayer != address(0) && relayer != msg.sender) revert NotRelayer();
+        if (sha256(abi.encodePacked(secret)) != swap.secretHash) revert InvalidSecret();
+        bytes32 digest = hashClaimAuthorization(swapId, relayer, relayerFee, deadline);
+        if (ECDSA.recover(digest, signature) != swap.receiver) revert InvalidSignature();
+
+        uint256 receiverAmount = swap.amount - swap.daoFee;
+        if (relayerFee >= receiverAmount) revert RelayerFeeTooHigh();
+
+        // Effects
+        swap.state = SwapState.Claimed;
+
+        // Interactions
+        _transferOut(swap.token, swap.receiver, receiverAmount - relayerFee);
+        _transferOut(swap.token, msg.sender, relayerFee);
+        _transferOut(swap.token, daoAddress, swap.daoFee);
+
+        emit SwapClaimed(swapId, swap.receiver, secret);
+        emit SwapClaimRelayed(swapId, msg.sender, relayerFee);
+    }
+
     /**
      * @notice Refund swap funds after timelock expires
      * @dev Only the original sender can refund, and only after timelock
@@ -381,6 +432,28 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
         ));
     }
 
+    /**
+     * @notice EIP-712 digest the receiver signs to authorize a relayed claim
+     * @param swapId The swap identifier
+     * @param relayer Relayer allowed to submit the claim (address(0) for anyone)
+     * @param relayerFee Amount paid to the submitter out of the receiver's amount
+     * @param deadline Unix timestamp after which the authorization expires
+     */
+    function hashClaimAuthorization(
+        bytes32 swapId,
+        address relayer,
+        uint256 relayerFee,
+        uint256 deadline
+    ) public view returns (bytes32) {
+        return _hashTypedDataV4(keccak256(abi.encode(
+            CLAIM_AUTHORIZATION_TYPEHASH,
+            swapId,
+            relayer,
+            relayerFee,
+            deadline
+        )));
+    }
+
     /**
      * @notice Get the current chain ID
      * @return The chain ID
@@ -422,6 +495,20 @@ contract KlingonHTLC is ReentrancyGuard, Ownable {
 
     // ============ Internal Functions ============
 
+    /**
+     * @notice Send native tokens or ERC20 tokens out of the contract
+     * @dev Does nothing for a zero amount
+     */
+    function _transferOut(address token, address to, uint256 amount) internal {
+        if (amount == 0) return;
+        if (token == address(0)) {
+            (bool success, ) = to.call{value: amount}("");
+            if (!success) revert TransferFailed();
+        } else {
+            IERC20(token).safeTransfer(to, amount);
+        }
+    }
+
     /**
      * @notice Validate swap creation parameters
      */
@@ -400,6 +400,130 @@ contract KlingonHTLCTest is Test {
         assertEq(uint8(swap.state), uint8(KlingonHTLC.SwapState.Claimed));
     }
 
+    // ============ Relayed Claim Tests ============
+
+    uint256 internal constant RECEIVER_KEY = 0xC0FFEE;
+    address internal relayer = address(0x5E1A);
+
+    event SwapClaimRelayed(
+        bytes32 indexed swapId,
+        address indexed relayer,
+        uint256 relayerFee
+    );
+
+    function _createSwapForSigner(bytes32 swapId) internal returns (address receiver) {
+        receiver = vm.addr(RECEIVER_KEY);
+        vm.prank(alice);
+        htlc.createSwapNative{value: SWAP_AMOUNT}(swapId, receiver, secretHash, block.timestamp + TIMELOCK);
+    }
+
+    function _signClaim(bytes32 swapId, address allowed, uint256 fee, uint256 deadline) internal view returns (bytes memory) {
+        bytes32 digest = htlc.hashClaimAuthorization(swapId, allowed, fee, deadline);
+        (uint8 v, bytes32 r, bytes32 s) = vm.sign(RECEIVER_KEY, digest);
+        return abi.encodePacked(r, s, v);
+    }
+
+    function test_ClaimWithSignatureNative() public {
+        bytes32 swapId = keccak256("relayed-claim");
+        address receiver = _createSwapForSigner(swapId);
+        uint256 fee = 0.001 ether;
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, fee, deadline);
+
+        uint256 daoBalanceBefore = dao.balance;
+
+        vm.prank(relayer);
+        vm.expectEmit(true, true, false, true);
+        emit SwapClaimed(swapId, receiver, secret);
+        vm.expectEmit(true, true, false, true);
+        emit SwapClaimRelayed(swapId, relayer, fee);
+        htlc.claimWithSignature(swapId, secret, relayer, fee, deadline, signature);
+
+        uint256 expectedFee = SWAP_AMOUNT * 20 / 10000;
+        assertEq(receiver.balance, SWAP_AMOUNT - expectedFee - fee);
+        assertEq(relayer.balance, fee);
+        assertEq(dao.balance, daoBalanceBefore + expectedFee);
+        assertEq(uint8(htlc.getSwap(swapId).state), uint8(KlingonHTLC.SwapState.Claimed));
+    }
+
+    function test_ClaimWithSignatureERC20() public {
+        bytes32 swapId = keccak256("relayed-claim-erc20");
+        address receiver = vm.addr(RECEIVER_KEY);
+        uint256 amount = 1000 * 10**18;
+        uint256 fee = 5 * 10**18;
+
+        vm.startPrank(alice);
+        token.approve(address(htlc), amount);
+        htlc.createSwapERC20(swapId, receiver, address(token), amount, secretHash, block.timestamp + TIMELOCK);
+        vm.stopPrank();
+
+        // address(0) lets anyone submit the claim
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, address(0), fee, deadline);
+        vm.prank(bob);
+        htlc.claimWithSignature(swapId, secret, address(0), fee, deadline, signature);
+
+        uint256 expectedFee = amount * 20 / 10000;
+        assertEq(token.balanceOf(receiver), amount - expectedFee - fee);
+        assertEq(token.balanceOf(dao), expectedFee);
+    }
+
+    function test_RevertOnClaimWithSignatureWrongRelayer() public {
+        bytes32 swapId = keccak256("relayed-claim-relayer");
+        _createSwapForSigner(swapId);
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, 1000, deadline);
+
+        vm.prank(bob);
+        vm.expectRevert(KlingonHTLC.NotRelayer.selector);
+        htlc.claimWithSignature(swapId, secret, relayer, 1000, deadline, signature);
+    }
+
+    function test_RevertOnClaimWithSignatureTamperedFee() public {
+        bytes32 swapId = keccak256("relayed-claim-fee");
+        _createSwapForSigner(swapId);
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, 1000, deadline);
+
+        vm.prank(relayer);
+        vm.expectRevert(KlingonHTLC.InvalidSignature.selector);
+        htlc.claimWithSignature(swapId, secret, relayer, 0.5 ether, deadline, signature);
+    }
+
+    function test_RevertOnClaimWithSignatureExpired() public {
+        bytes32 swapId = keccak256("relayed-claim-expired");
+        _createSwapForSigner(swapId);
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, 1000, deadline);
+
+        vm.warp(deadline + 1);
+        vm.prank(relayer);
+        vm.expectRevert(KlingonHTLC.AuthorizationExpired.selector);
+        htlc.claimWithSignature(swapId, secret, relayer, 1000, deadline, signature);
+    }
+
+    function test_RevertOnClaimWithSignatureFeeTooHigh() public {
+        bytes32 swapId = keccak256("relayed-claim-fee-high");
+        _createSwapForSigner(swapId);
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, SWAP_AMOUNT, deadline);
+
+        vm.prank(relayer);
+        vm.expectRevert(KlingonHTLC.RelayerFeeTooHigh.selector);
+        htlc.claimWithSignature(swapId, secret, relayer, SWAP_AMOUNT, deadline, signature);
+    }
+
+    function test_RevertOnClaimWithSignatureWrongSecret() public {
+        bytes32 swapId = keccak256("relayed-claim-secret");
+        _createSwapForSigner(swapId);
+        uint256 deadline = block.timestamp + 1 hours;
+        bytes memory signature = _signClaim(swapId, relayer, 1000, deadline);
+
+        vm.prank(relayer);
+        vm.expectRevert(KlingonHTLC.InvalidSecret.selector);
+        htlc.claimWithSignature(swapId, bytes32(uint256(1)), relayer, 1000, deadline, signature);
+    }
+
     // ============ Admin Tests ============
 
     function test_SetDaoAddress() public {
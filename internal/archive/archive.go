@@ -0,0 +1,315 @@
+// Package archive moves old finished swaps to cold storage.
+//
+// Swaps that reached a terminal state more than the retention period ago are
+// written to gzip-compressed archive files in <data_dir>/archive and removed,
+// with their legs, from the database. Each file carries a SHA-256 of its
+// content, checked before anything is read back. The database keeps a small
+// index (trade ID, order, peers, chains, state, completion time, file), so
+// archived swaps can be searched without opening files, and a swap is
+// restored to the database when it is looked up again. Trades are not
+// archived: they are small and feed the history and peer statistics.
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// FormatVersion is the version of the archive file format.
+const FormatVersion = 1
+
+// ErrCorrupt is returned for an archive file that fails its integrity check.
+var ErrCorrupt = errors.New("archive file is corrupt")
+
+// Entry is one archived swap.
+type Entry struct {
+	Swap *storage.SwapRecord `json:"swap"`
+	Legs []*storage.SwapLeg  `json:"legs,omitempty"`
+}
+
+// file is the decompressed content of an archive file. Checksum is the hex
+// SHA-256 of the exact Swaps bytes.
+type file struct {
+	Version   int             `json:"version"`
+	CreatedAt int64           `json:"created_at"`
+	Count     int             `json:"count"`
+	Checksum  string          `json:"checksum"`
+	Swaps     json.RawMessage `json:"swaps"`
+}
+
+// RunResult is the outcome of an archival run.
+type RunResult struct {
+	File     string `json:"file,omitempty"`
+	Archived int    `json:"archived"`
+}
+
+// Archiver archives finished swaps and restores them on demand.
+type Archiver struct {
+	cfg   node.ArchiveConfig
+	dir   string
+	store *storage.Storage
+	log   *logging.Logger
+
+	runMu sync.Mutex // Serializes runs and restores
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates an archiver writing to dir.
+func New(cfg node.ArchiveConfig, dir string, store *storage.Storage) (*Archiver, error) {
+	defaults := node.DefaultConfig().Archive
+	if cfg.Retention <= 0 {
+		cfg.Retention = defaults.Retention
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.MaxPerFile <= 0 {
+		cfg.MaxPerFile = defaults.MaxPerFile
+	}
+	if store == nil {
+		return nil, fmt.Errorf("archive requires storage")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Archiver{
+		cfg:    cfg,
+		dir:    dir,
+		store:  store,
+		log:    logging.GetDefault().Component("archive"),
+		ctx:    ctx,
+		cancel: cancel,
+	}, nil
+}
+
+// Dir returns the archive directory.
+func (a *Archiver) Dir() string {
+	return a.dir
+}
+
+// Start archives due swaps every Interval.
+func (a *Archiver) Start() {
+	if !a.cfg.Enabled {
+		return
+	}
+	a.wg.Add(1)
+	go func() {
+		defer a.wg.Done()
+		ticker := time.NewTicker(a.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			if _, err := a.Run(a.ctx); err != nil {
+				a.log.Warn("Swap archival failed", "error", err)
+			}
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	a.log.Info("Swap archival started", "retention", a.cfg.Retention, "interval", a.cfg.Interval, "dir", a.dir)
+}
+
+// Stop stops periodic archival.
+func (a *Archiver) Stop() {
+	a.cancel()
+	a.wg.Wait()
+}
+
+// Run archives the swaps that finished more than Retention ago, up to
+// MaxPerFile per file.
+func (a *Archiver) Run(ctx context.Context) (*RunResult, error) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	result := &RunResult{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return result, err
+		}
+		records, err := a.store.ListArchivableSwaps(time.Now().Add(-a.cfg.Retention), a.cfg.MaxPerFile)
+		if err != nil {
+			return result, err
+		}
+		if len(records) == 0 {
+			return result, nil
+		}
+
+		name, err := a.archive(records)
+		if err != nil {
+			return result, err
+		}
+		result.File = name
+		result.Archived += len(records)
+		a.log.Info("Archived swaps", "count", len(records), "file", name)
+		if len(records) < a.cfg.MaxPerFile {
+			return result, nil
+		}
+	}
+}
+
+// archive writes records to a new archive file and moves them out of the
+// database. The file is complete on disk before anything is deleted.
+func (a *Archiver) archive(records []*storage.SwapRecord) (string, error) {
+	entries := make([]Entry, 0, len(records))
+	for _, r := range records {
+		legs, err := a.store.GetSwapLegsByTradeID(r.TradeID)
+		if err != nil {
+			return "", fmt.Errorf("failed to read legs of %s: %w", r.TradeID, err)
+		}
+		entries = append(entries, Entry{Swap: r, Legs: legs})
+	}
+
+	if err := os.MkdirAll(a.dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create archive dir: %w", err)
+	}
+	now := time.Now().UTC()
+	name := fmt.Sprintf("swaps-%s.json.gz", now.Format("20060102T150405.000000000Z"))
+	if err := writeFile(filepath.Join(a.dir, name), entries, now); err != nil {
+		return "", err
+	}
+	if err := a.store.MarkSwapsArchived(name, records); err != nil {
+		os.Remove(filepath.Join(a.dir, name))
+		return "", err
+	}
+	return name, nil
+}
+
+// Search returns the index entries matching filter.
+func (a *Archiver) Search(filter storage.ArchiveFilter) ([]*storage.ArchivedSwap, error) {
+	return a.store.SearchArchivedSwaps(filter)
+}
+
+// Restore re-imports an archived swap into the database. It returns
+// storage.ErrArchivedSwapNotFound if the trade is not archived.
+func (a *Archiver) Restore(tradeID string) (*Entry, error) {
+	a.runMu.Lock()
+	defer a.runMu.Unlock()
+
+	indexed, err := a.store.GetArchivedSwap(tradeID)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := ReadFile(filepath.Join(a.dir, indexed.File))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", indexed.File, err)
+	}
+	for i := range entries {
+		e := &entries[i]
+		if e.Swap == nil || e.Swap.TradeID != tradeID {
+			continue
+		}
+		if err := a.store.RestoreArchivedSwap(e.Swap, e.Legs); err != nil {
+			return nil, err
+		}
+		a.log.Info("Restored archived swap", "trade_id", tradeID, "file", indexed.File)
+		return e, nil
+	}
+	return nil, fmt.Errorf("%w: %s not in %s", ErrCorrupt, tradeID, indexed.File)
+}
+
+// writeFile writes entries to path atomically.
+func writeFile(path string, entries []Entry, createdAt time.Time) error {
+	swaps, err := json.Marshal(entries)
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(swaps)
+	content, err := json.Marshal(&file{
+		Version:   FormatVersion,
+		CreatedAt: createdAt.Unix(),
+		Count:     len(entries),
+		Checksum:  hex.EncodeToString(sum[:]),
+		Swaps:     swaps,
+	})
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(content); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to create archive file: %w", err)
+	}
+	if _, err := f.Write(buf.Bytes()); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to write archive file: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return fmt.Errorf("failed to sync archive file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// ReadFile reads and verifies an archive file.
+func ReadFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	content, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err) // Includes gzip CRC failures
+	}
+
+	var af file
+	if err := json.Unmarshal(content, &af); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if af.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported archive version %d", af.Version)
+	}
+	sum := sha256.Sum256(af.Swaps)
+	if hex.EncodeToString(sum[:]) != af.Checksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrCorrupt)
+	}
+
+	var entries []Entry
+	if err := json.Unmarshal(af.Swaps, &entries); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrCorrupt, err)
+	}
+	if len(entries) != af.Count {
+		return nil, fmt.Errorf("%w: %d swaps, header says %d", ErrCorrupt, len(entries), af.Count)
+	}
+	return entries, nil
+}
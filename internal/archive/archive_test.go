@@ -0,0 +1,176 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newTestArchiver(t *testing.T) (*Archiver, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	a, err := New(node.ArchiveConfig{Retention: 30 * 24 * time.Hour, MaxPerFile: 2}, filepath.Join(t.TempDir(), "archive"), store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return a, store
+}
+
+// saveSwap stores a swap that finished at completedAt (zero for a pending swap).
+func saveSwap(t *testing.T, store *storage.Storage, id string, state storage.SwapState, completedAt time.Time) {
+	t.Helper()
+	if err := store.SaveSwap(&storage.SwapRecord{
+		TradeID: id, OrderID: "order-" + id, MakerPeerID: "makerA", TakerPeerID: "takerB",
+		OurRole: "maker", IsMaker: true, OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000, State: state,
+		MethodData: []byte(`{"local_pubkey":"02aa"}`), RedeemTxID: "redeem-" + id,
+		CompletedAt: completedAt,
+	}); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+	if err := store.CreateSwapLeg(&storage.SwapLeg{
+		ID: id + "-offer", TradeID: id, LegType: storage.SwapLegTypeOffer, Chain: "BTC", Amount: 100000,
+		OurRole: storage.SwapLegRoleSender, State: storage.SwapLegStateInit, CreatedAt: time.Unix(1700000000, 0),
+	}); err != nil {
+		t.Fatalf("CreateSwapLeg() error = %v", err)
+	}
+}
+
+func TestRunArchivesOldFinishedSwaps(t *testing.T) {
+	a, store := newTestArchiver(t)
+	old := time.Now().Add(-60 * 24 * time.Hour)
+	saveSwap(t, store, "old-1", storage.SwapStateRedeemed, old)
+	saveSwap(t, store, "old-2", storage.SwapStateRefunded, old.Add(time.Hour))
+	saveSwap(t, store, "old-3", storage.SwapStateRedeemed, old.Add(2*time.Hour))
+	saveSwap(t, store, "recent", storage.SwapStateRedeemed, time.Now().Add(-time.Hour))
+	saveSwap(t, store, "pending", storage.SwapStateFunded, time.Time{})
+
+	result, err := a.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Archived != 3 {
+		t.Errorf("Archived = %d, want 3", result.Archived)
+	}
+	files, _ := filepath.Glob(filepath.Join(a.Dir(), "swaps-*.json.gz"))
+	if len(files) != 2 {
+		t.Errorf("archive files = %d, want 2 (MaxPerFile 2)", len(files))
+	}
+
+	for _, id := range []string{"old-1", "old-2", "old-3"} {
+		if _, err := store.GetSwap(id); err == nil {
+			t.Errorf("%s still in the database", id)
+		}
+		if legs, _ := store.GetSwapLegsByTradeID(id); len(legs) != 0 {
+			t.Errorf("%s legs still in the database", id)
+		}
+	}
+	for _, id := range []string{"recent", "pending"} {
+		if _, err := store.GetSwap(id); err != nil {
+			t.Errorf("%s archived too early: %v", id, err)
+		}
+	}
+
+	found, err := a.Search(storage.ArchiveFilter{Chain: "LTC"})
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(found) != 3 || found[0].TradeID != "old-3" {
+		t.Errorf("Search() = %d entries, first %+v", len(found), found[0])
+	}
+
+	// Nothing more is due
+	if result, err := a.Run(context.Background()); err != nil || result.Archived != 0 {
+		t.Errorf("second Run() = %+v, %v", result, err)
+	}
+}
+
+func TestRestore(t *testing.T) {
+	a, store := newTestArchiver(t)
+	saveSwap(t, store, "old-1", storage.SwapStateRedeemed, time.Now().Add(-60*24*time.Hour))
+	if _, err := a.Run(context.Background()); err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	entry, err := a.Restore("old-1")
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if len(entry.Legs) != 1 {
+		t.Errorf("restored legs = %d, want 1", len(entry.Legs))
+	}
+
+	swap, err := store.GetSwap("old-1")
+	if err != nil {
+		t.Fatalf("GetSwap() after restore error = %v", err)
+	}
+	if swap.State != storage.SwapStateRedeemed || swap.RedeemTxID != "redeem-old-1" || string(swap.MethodData) != `{"local_pubkey":"02aa"}` {
+		t.Errorf("restored swap = %+v", swap)
+	}
+	if legs, _ := store.GetSwapLegsByTradeID("old-1"); len(legs) != 1 || legs[0].ID != "old-1-offer" {
+		t.Errorf("restored legs = %+v", legs)
+	}
+	if _, err := store.GetArchivedSwap("old-1"); !errors.Is(err, storage.ErrArchivedSwapNotFound) {
+		t.Errorf("index entry after restore: %v", err)
+	}
+
+	if _, err := a.Restore("unknown"); !errors.Is(err, storage.ErrArchivedSwapNotFound) {
+		t.Errorf("Restore(unknown) error = %v", err)
+	}
+}
+
+func TestReadFileDetectsCorruption(t *testing.T) {
+	a, store := newTestArchiver(t)
+	saveSwap(t, store, "old-1", storage.SwapStateRedeemed, time.Now().Add(-60*24*time.Hour))
+	result, err := a.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	path := filepath.Join(a.Dir(), result.File)
+	if _, err := ReadFile(path); err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+
+	// Re-compress an edited document: gzip is valid, the checksum is not
+	f, _ := os.Open(path)
+	zr, _ := gzip.NewReader(f)
+	content, _ := io.ReadAll(zr)
+	f.Close()
+	edited := bytes.Replace(content, []byte("100000"), []byte("900000"), 1)
+	out, _ := os.Create(path)
+	zw := gzip.NewWriter(out)
+	zw.Write(edited)
+	zw.Close()
+	out.Close()
+
+	if _, err := ReadFile(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("ReadFile() of an edited file error = %v, want ErrCorrupt", err)
+	}
+	if _, err := a.Restore("old-1"); err == nil {
+		t.Error("Restore() from a corrupt file should fail")
+	}
+	if _, err := store.GetSwap("old-1"); err == nil {
+		t.Error("corrupt archive was restored")
+	}
+
+	// Truncated file
+	data, _ := os.ReadFile(path)
+	os.WriteFile(path, data[:len(data)/2], 0600)
+	if _, err := ReadFile(path); !errors.Is(err, ErrCorrupt) {
+		t.Errorf("ReadFile() of a truncated file error = %v, want ErrCorrupt", err)
+	}
+}
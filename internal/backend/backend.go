@@ -155,6 +155,9 @@ type Config struct {
 	TestnetURL string  `yaml:"testnet"`
 	RPCType    RPCType `yaml:"rpc_type,omitempty"` // For JSON-RPC: "bitcoin" or "evm"
 
+	// TestnetURLs overrides TestnetURL per named test network (e.g. "signet", "holesky").
+	TestnetURLs map[string]string `yaml:"testnets,omitempty"`
+
 	// For Electrum
 	Servers []string `yaml:"servers,omitempty"`
 
@@ -166,6 +169,18 @@ type Config struct {
 	Timeout int `yaml:"timeout,omitempty"` // seconds, default 30
 }
 
+// URL returns the backend URL for a network.
+// On testnet the URL for the chain's selected test network is preferred.
+func (c *Config) URL(network chain.Network, testnet string) string {
+	if network != chain.Testnet {
+		return c.MainnetURL
+	}
+	if url, ok := c.TestnetURLs[testnet]; ok && url != "" {
+		return url
+	}
+	return c.TestnetURL
+}
+
 // DefaultConfigs returns default backend configurations for all supported chains.
 func DefaultConfigs() map[string]*Config {
 	return map[string]*Config{
@@ -173,6 +188,11 @@ func DefaultConfigs() map[string]*Config {
 			Type:       TypeMempool,
 			MainnetURL: "https://mempool.space/api",
 			TestnetURL: "https://mempool.space/testnet4/api",
+			TestnetURLs: map[string]string{
+				chain.TestnetBTCTestnet3: "https://mempool.space/testnet/api",
+				chain.TestnetBTCTestnet4: "https://mempool.space/testnet4/api",
+				chain.TestnetBTCSignet:   "https://mempool.space/signet/api",
+			},
 		},
 		"LTC": {
 			Type:       TypeMempool,
@@ -189,6 +209,10 @@ func DefaultConfigs() map[string]*Config {
 			RPCType:    RPCTypeEVM,
 			MainnetURL: "https://eth.llamarpc.com",
 			TestnetURL: "https://ethereum-sepolia-rpc.publicnode.com",
+			TestnetURLs: map[string]string{
+				chain.TestnetETHSepolia: "https://ethereum-sepolia-rpc.publicnode.com",
+				chain.TestnetETHHolesky: "https://ethereum-holesky-rpc.publicnode.com",
+			},
 		},
 		"BSC": {
 			Type:       TypeJSONRPC,
@@ -244,6 +268,7 @@ func DefaultConfigs() map[string]*Config {
 // Registry holds backend instances by chain symbol.
 type Registry struct {
 	backends map[string]Backend
+	plugins  []*Plugin
 }
 
 // NewRegistry creates a new backend registry.
@@ -255,40 +280,55 @@ func NewRegistry() *Registry {
 
 // NewDefaultRegistry creates a registry with default backends for the given network.
 func NewDefaultRegistry(network chain.Network) *Registry {
+	return NewConfiguredRegistry(network, nil)
+}
+
+// NewConfiguredRegistry creates a registry with the default backends for the
+// given network, replacing those of the chains configured in overrides.
+func NewConfiguredRegistry(network chain.Network, overrides map[string]*Config) *Registry {
 	r := NewRegistry()
 	configs := DefaultConfigs()
+	for symbol, cfg := range overrides {
+		if cfg != nil {
+			configs[symbol] = cfg
+		}
+	}
 
 	for symbol, cfg := range configs {
-		var url string
-		if network == chain.Testnet {
-			url = cfg.TestnetURL
-		} else {
-			url = cfg.MainnetURL
-		}
+		url := cfg.URL(network, chain.ActiveTestnet(symbol))
 
 		if url == "" {
 			continue
 		}
 
-		switch cfg.Type {
-		case TypeMempool:
-			r.Register(symbol, NewMempoolBackend(url))
-		case TypeEsplora:
-			r.Register(symbol, NewEsploraBackend(url))
-		case TypeBlockbook:
-			r.Register(symbol, NewBlockbookBackend(url))
-		case TypeJSONRPC:
-			// Only register if RPCType is specified (EVM chains)
-			if cfg.RPCType != "" {
-				r.Register(symbol, NewJSONRPCBackend(url, cfg.RPCType, cfg.RPCUser, cfg.RPCPass))
-			}
-			// Skip SOL/XMR for now - they need specialized implementations
+		if b := New(cfg, url); b != nil {
+			r.Register(symbol, b)
 		}
 	}
 
 	return r
 }
 
+// New creates a backend of the config's type for url. It returns nil for
+// types without an implementation here.
+func New(cfg *Config, url string) Backend {
+	switch cfg.Type {
+	case TypeMempool:
+		return NewMempoolBackend(url)
+	case TypeEsplora:
+		return NewEsploraBackend(url)
+	case TypeBlockbook:
+		return NewBlockbookBackend(url)
+	case TypeJSONRPC:
+		// Only if RPCType is specified (EVM chains)
+		if cfg.RPCType != "" {
+			return NewJSONRPCBackend(url, cfg.RPCType, cfg.RPCUser, cfg.RPCPass)
+		}
+		// Skip SOL/XMR for now - they need specialized implementations
+	}
+	return nil
+}
+
 // Register adds a backend to the registry.
 func (r *Registry) Register(symbol string, backend Backend) {
 	r.backends[symbol] = backend
@@ -319,11 +359,14 @@ func (r *Registry) ConnectAll(ctx context.Context) error {
 	return nil
 }
 
-// CloseAll closes all registered backends.
+// CloseAll closes all registered backends and stops the plugins.
 func (r *Registry) CloseAll() {
 	for _, b := range r.backends {
 		b.Close()
 	}
+	for _, p := range r.plugins {
+		p.Close()
+	}
 }
 
 // All returns all backends as a map.
@@ -52,6 +52,26 @@ func TestDefaultConfigTypes(t *testing.T) {
 	}
 }
 
+func TestConfigURL(t *testing.T) {
+	cfg := DefaultConfigs()["BTC"]
+
+	if got := cfg.URL(chain.Mainnet, chain.TestnetBTCSignet); got != cfg.MainnetURL {
+		t.Errorf("mainnet URL = %s, want %s", got, cfg.MainnetURL)
+	}
+	if got := cfg.URL(chain.Testnet, chain.TestnetBTCSignet); got != "https://mempool.space/signet/api" {
+		t.Errorf("signet URL = %s", got)
+	}
+	if got := cfg.URL(chain.Testnet, ""); got != cfg.TestnetURL {
+		t.Errorf("default testnet URL = %s, want %s", got, cfg.TestnetURL)
+	}
+
+	// Chains without per-network URLs fall back to TestnetURL
+	ltc := DefaultConfigs()["LTC"]
+	if got := ltc.URL(chain.Testnet, "anything"); got != ltc.TestnetURL {
+		t.Errorf("LTC testnet URL = %s, want %s", got, ltc.TestnetURL)
+	}
+}
+
 func TestNewMempoolBackend(t *testing.T) {
 	backend := NewMempoolBackend("https://mempool.space/api")
 
@@ -990,3 +1010,29 @@ func TestNewDefaultRegistry(t *testing.T) {
 		})
 	}
 }
+
+func TestNewConfiguredRegistry(t *testing.T) {
+	reg := NewConfiguredRegistry(chain.Mainnet, map[string]*Config{
+		"BTC":  {Type: TypeEsplora, MainnetURL: "http://127.0.0.1:3002"},
+		"DOGE": {Type: TypeBlockbook}, // No mainnet URL: not registered
+		"LTC":  nil,
+	})
+
+	b, ok := reg.Get("BTC")
+	if !ok || b.Type() != TypeEsplora {
+		t.Errorf("BTC backend = %v, want the configured esplora", b)
+	}
+	if _, ok := reg.Get("DOGE"); ok {
+		t.Error("DOGE registered without a URL")
+	}
+	if b, ok := reg.Get("LTC"); !ok || b.Type() != TypeMempool {
+		t.Error("LTC should keep its default backend")
+	}
+	if b, ok := reg.Get("ETH"); !ok || b.Type() != TypeJSONRPC {
+		t.Error("ETH should keep its default backend")
+	}
+
+	if New(&Config{Type: TypeJSONRPC}, "http://localhost") != nil {
+		t.Error("New() created a JSON-RPC backend without an RPC type")
+	}
+}
@@ -26,10 +26,8 @@ func NewBlockbookBackend(baseURL string) *BlockbookBackend {
 	baseURL = strings.TrimSuffix(baseURL, "/")
 
 	return &BlockbookBackend{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: newHTTPClient(30 * time.Second),
 	}
 }
 
@@ -0,0 +1,495 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Backend trace capture records the requests and responses of all backends
+// for a time window or a single trade, with credentials redacted, into a
+// bundle that ReplayHandler and ReplayElectrum serve back in tests.
+
+// CaptureBundleVersion is the version of the capture bundle format.
+const CaptureBundleVersion = 1
+
+// MaxCaptureEntries bounds a capture; later exchanges are counted as dropped.
+const MaxCaptureEntries = 10000
+
+// redacted replaces secrets in captured exchanges.
+const redacted = "REDACTED"
+
+// Capture kinds.
+const (
+	CaptureKindHTTP     = "http"
+	CaptureKindElectrum = "electrum"
+)
+
+// Capture errors
+var (
+	ErrCaptureActive   = errors.New("a capture is already running")
+	ErrNoCaptureActive = errors.New("no capture running")
+)
+
+// CaptureFilter selects what a capture records. Exchanges made for other
+// trades, or outside any trade when TradeID is set, are skipped.
+type CaptureFilter struct {
+	TradeID string    `json:"trade_id,omitempty"`
+	Until   time.Time `json:"until"` // End of the capture window
+}
+
+// CaptureEntry is one recorded backend exchange.
+type CaptureEntry struct {
+	Time     int64  `json:"time"` // Unix milliseconds
+	Kind     string `json:"kind"` // http or electrum
+	TradeID  string `json:"trade_id,omitempty"`
+	Duration int64  `json:"duration_ms"`
+
+	// HTTP exchanges
+	Method         string            `json:"method,omitempty"`
+	URL            string            `json:"url,omitempty"` // Redacted
+	RequestHeader  map[string]string `json:"request_header,omitempty"`
+	Status         int               `json:"status,omitempty"`
+	ResponseHeader map[string]string `json:"response_header,omitempty"`
+
+	// Electrum exchanges use Server and RPCMethod
+	Server    string `json:"server,omitempty"`
+	RPCMethod string `json:"rpc_method,omitempty"`
+
+	// JSON bodies are redacted and compacted; other bodies (plain text
+	// heights, raw hex) are kept verbatim in the text fields
+	RequestBody  json.RawMessage `json:"request_body,omitempty"`
+	RequestText  string          `json:"request_text,omitempty"`
+	ResponseBody json.RawMessage `json:"response_body,omitempty"`
+	ResponseText string          `json:"response_text,omitempty"`
+	Error        string          `json:"error,omitempty"`
+}
+
+// CaptureBundle is the output of a capture.
+type CaptureBundle struct {
+	Version   int            `json:"version"`
+	StartedAt int64          `json:"started_at"`
+	StoppedAt int64          `json:"stopped_at"`
+	Filter    CaptureFilter  `json:"filter"`
+	Dropped   int            `json:"dropped,omitempty"` // Exchanges past MaxCaptureEntries
+	Entries   []CaptureEntry `json:"entries"`
+}
+
+// CaptureStatus describes the running capture.
+type CaptureStatus struct {
+	Active    bool          `json:"active"`
+	Expired   bool          `json:"expired,omitempty"` // Window over, awaiting stop
+	StartedAt int64         `json:"started_at,omitempty"`
+	Filter    CaptureFilter `json:"filter"`
+	Entries   int           `json:"entries"`
+	Dropped   int           `json:"dropped,omitempty"`
+}
+
+// capture is a running capture.
+type capture struct {
+	mu     sync.Mutex
+	bundle CaptureBundle
+}
+
+// activeCapture is the running capture shared by all backends, like the
+// per-chain test network selection in the chain package.
+var activeCapture atomic.Pointer[capture]
+
+// StartCapture begins recording backend exchanges.
+func StartCapture(filter CaptureFilter) error {
+	if filter.Until.IsZero() {
+		return fmt.Errorf("capture window end is required")
+	}
+	c := &capture{bundle: CaptureBundle{
+		Version:   CaptureBundleVersion,
+		StartedAt: time.Now().Unix(),
+		Filter:    filter,
+		Entries:   []CaptureEntry{},
+	}}
+	if !activeCapture.CompareAndSwap(nil, c) {
+		return ErrCaptureActive
+	}
+	return nil
+}
+
+// StopCapture ends the running capture and returns what it recorded.
+func StopCapture() (*CaptureBundle, error) {
+	c := activeCapture.Swap(nil)
+	if c == nil {
+		return nil, ErrNoCaptureActive
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bundle.StoppedAt = time.Now().Unix()
+	return &c.bundle, nil
+}
+
+// GetCaptureStatus returns the state of the running capture.
+func GetCaptureStatus() *CaptureStatus {
+	c := activeCapture.Load()
+	if c == nil {
+		return &CaptureStatus{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return &CaptureStatus{
+		Active:    true,
+		Expired:   time.Now().After(c.bundle.Filter.Until),
+		StartedAt: c.bundle.StartedAt,
+		Filter:    c.bundle.Filter,
+		Entries:   len(c.bundle.Entries),
+		Dropped:   c.bundle.Dropped,
+	}
+}
+
+// captureFor returns the running capture if it wants exchanges made with ctx.
+func captureFor(ctx context.Context) *capture {
+	c := activeCapture.Load()
+	if c == nil {
+		return nil
+	}
+	filter := c.bundle.Filter // Immutable after start
+	if time.Now().After(filter.Until) {
+		return nil
+	}
+	if filter.TradeID != "" && TradeIDFromContext(ctx) != filter.TradeID {
+		return nil
+	}
+	return c
+}
+
+// add appends an entry unless the capture is full.
+func (c *capture) add(e CaptureEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.bundle.Entries) >= MaxCaptureEntries {
+		c.bundle.Dropped++
+		return
+	}
+	c.bundle.Entries = append(c.bundle.Entries, e)
+}
+
+// tradeIDKey is the context key for the trade a backend call is made for.
+type tradeIDKey struct{}
+
+// WithTradeID tags backend calls made with ctx as belonging to a trade.
+func WithTradeID(ctx context.Context, tradeID string) context.Context {
+	return context.WithValue(ctx, tradeIDKey{}, tradeID)
+}
+
+// TradeIDFromContext returns the trade tagged by WithTradeID.
+func TradeIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(tradeIDKey{}).(string)
+	return id
+}
+
+// SaveCaptureBundle writes a bundle to dir and returns its path.
+func SaveCaptureBundle(dir string, b *CaptureBundle) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create capture dir: %w", err)
+	}
+	data, err := json.MarshalIndent(b, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("backend-%d.json", b.StartedAt)
+	if b.Filter.TradeID != "" {
+		name = fmt.Sprintf("backend-%s-%d.json", b.Filter.TradeID, b.StartedAt)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to write capture bundle: %w", err)
+	}
+	return path, nil
+}
+
+// LoadCaptureBundle reads a bundle written by SaveCaptureBundle.
+func LoadCaptureBundle(path string) (*CaptureBundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var b CaptureBundle
+	if err := json.Unmarshal(data, &b); err != nil {
+		return nil, fmt.Errorf("invalid capture bundle: %w", err)
+	}
+	if b.Version != CaptureBundleVersion {
+		return nil, fmt.Errorf("unsupported capture bundle version %d", b.Version)
+	}
+	return &b, nil
+}
+
+// newHTTPClient returns the HTTP client used by the HTTP backends.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: &captureTransport{base: http.DefaultTransport},
+	}
+}
+
+// captureTransport records exchanges while a capture is running.
+type captureTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *captureTransport) RoundTrip(req *http.Request) (resp *http.Response, err error) {
+	ctx, span := tracing.Start(req.Context(), "backend", "backend.http "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("http.request.method", req.Method), attribute.String("server.address", req.URL.Host)))
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
+			if err == nil && resp.StatusCode >= 500 {
+				span.SetStatus(codes.Error, resp.Status)
+			}
+		}
+		tracing.End(span, err)
+	}()
+	req = req.WithContext(ctx)
+
+	if err := chaos.DelayBackend(req.Context()); err != nil {
+		return nil, err
+	}
+	c := captureFor(req.Context())
+	if c == nil {
+		return t.base.RoundTrip(req)
+	}
+
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+		req.Body.Close()
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	entry := CaptureEntry{
+		Time:          start.UnixMilli(),
+		Kind:          CaptureKindHTTP,
+		TradeID:       TradeIDFromContext(req.Context()),
+		Method:        req.Method,
+		URL:           redactURL(req.URL),
+		RequestHeader: redactHeader(req.Header),
+	}
+
+	resp, err = t.base.RoundTrip(req)
+	if err != nil {
+		entry.Duration = time.Since(start).Milliseconds()
+		redactExchange(&entry, reqBody, nil)
+		entry.Error = err.Error()
+		c.add(entry)
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	entry.Duration = time.Since(start).Milliseconds()
+	entry.Status = resp.StatusCode
+	entry.ResponseHeader = redactHeader(resp.Header)
+	redactExchange(&entry, reqBody, respBody)
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.add(entry)
+	return resp, err
+}
+
+// recordElectrum records an Electrum call made for ctx.
+func recordElectrum(ctx context.Context, server, method string, request, response []byte, start time.Time, callErr error) {
+	c := captureFor(ctx)
+	if c == nil {
+		return
+	}
+	entry := CaptureEntry{
+		Time:      start.UnixMilli(),
+		Kind:      CaptureKindElectrum,
+		TradeID:   TradeIDFromContext(ctx),
+		Duration:  time.Since(start).Milliseconds(),
+		Server:    server,
+		RPCMethod: method,
+	}
+	redactExchange(&entry, request, response)
+	if callErr != nil {
+		entry.Error = callErr.Error()
+	}
+	c.add(entry)
+}
+
+// sensitiveHeaders are replaced wholesale.
+var sensitiveHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+	"Set-Cookie":          true,
+	"X-Api-Key":           true,
+}
+
+// sensitiveParams are query parameters holding API keys.
+var sensitiveParams = map[string]bool{
+	"apikey": true, "api_key": true, "key": true, "token": true,
+	"access_token": true, "auth": true, "secret": true,
+}
+
+// sensitiveFields are JSON object keys whose values are redacted.
+var sensitiveFields = map[string]bool{
+	"privkey": true, "private_key": true, "privatekey": true, "wif": true,
+	"xprv": true, "seed": true, "mnemonic": true, "password": true,
+	"passphrase": true, "rpcpassword": true,
+}
+
+// sensitiveRPCMethods carry keys or passphrases in their params or result.
+var sensitiveRPCMethods = map[string]bool{
+	"dumpprivkey":               true,
+	"importprivkey":             true,
+	"walletpassphrase":          true,
+	"walletpassphrasechange":    true,
+	"encryptwallet":             true,
+	"signrawtransactionwithkey": true,
+	"dumpwallet":                true,
+	"listdescriptors":           true,
+	"importdescriptors":         true,
+	"personal_unlockAccount":    true,
+	"personal_importRawKey":     true,
+}
+
+// pathKeyHosts are RPC providers that carry the API key in the URL path,
+// with the number of leading path segments (version or network) kept.
+var pathKeyHosts = map[string]int{
+	"infura.io":    1, // /v3/<key>
+	"alchemy.com":  1, // /v2/<key>
+	"quiknode.pro": 0, // /<token>/
+	"rpc.ankr.com": 1, // /eth/<key>
+}
+
+// redactURL drops user info, API key query parameters and the path
+// segments of providers that put the key there.
+func redactURL(u *url.URL) string {
+	r := *u
+	r.User = nil
+	if keep, ok := pathKeyHost(r.Hostname()); ok {
+		segments := strings.Split(r.Path, "/")
+		for i := range segments {
+			if i > keep && segments[i] != "" {
+				segments[i] = redacted
+			}
+		}
+		r.Path, r.RawPath = strings.Join(segments, "/"), ""
+	}
+	if r.RawQuery != "" {
+		q := r.Query()
+		for k := range q {
+			if sensitiveParams[strings.ToLower(k)] {
+				q.Set(k, redacted)
+			}
+		}
+		r.RawQuery = q.Encode()
+	}
+	return r.String()
+}
+
+// pathKeyHost returns the path segments kept for a provider host.
+func pathKeyHost(host string) (int, bool) {
+	host = strings.ToLower(host)
+	for suffix, keep := range pathKeyHosts {
+		if host == suffix || strings.HasSuffix(host, "."+suffix) {
+			return keep, true
+		}
+	}
+	return 0, false
+}
+
+// redactHeader flattens a header, replacing credentials.
+func redactHeader(h http.Header) map[string]string {
+	if len(h) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(h))
+	for k, v := range h {
+		if sensitiveHeaders[http.CanonicalHeaderKey(k)] {
+			out[k] = redacted
+			continue
+		}
+		out[k] = strings.Join(v, ", ")
+	}
+	return out
+}
+
+// redactExchange stores a request and its response in e, redacted. The
+// params and result of sensitive JSON-RPC methods are dropped entirely.
+func redactExchange(e *CaptureEntry, request, response []byte) {
+	var call struct {
+		Method string `json:"method"`
+	}
+	json.Unmarshal(request, &call)
+	reqDrop, respDrop := "", ""
+	if sensitiveRPCMethods[call.Method] {
+		reqDrop, respDrop = "params", "result"
+	}
+	e.RequestBody, e.RequestText = redactBody(request, reqDrop)
+	e.ResponseBody, e.ResponseText = redactBody(response, respDrop)
+}
+
+// redactBody returns a JSON body with secrets and the drop field of an
+// object redacted, or a non-JSON body as text.
+func redactBody(body []byte, drop string) (json.RawMessage, string) {
+	if len(body) == 0 {
+		return nil, ""
+	}
+	var v interface{}
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil || dec.More() {
+		return nil, string(body)
+	}
+	redactValue(v)
+	if obj, ok := v.(map[string]interface{}); ok && drop != "" {
+		if _, exists := obj[drop]; exists {
+			obj[drop] = redacted
+		}
+	}
+	out, err := json.Marshal(v)
+	if err != nil {
+		return nil, string(body)
+	}
+	return out, ""
+}
+
+// redactValue replaces sensitive fields in a decoded JSON value in place.
+func redactValue(v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if sensitiveFields[strings.ToLower(k)] {
+				x[k] = redacted
+				continue
+			}
+			redactValue(val)
+		}
+	case []interface{}:
+		for _, e := range x {
+			redactValue(e)
+		}
+	}
+}
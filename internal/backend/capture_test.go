@@ -0,0 +1,295 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestCapture starts a capture that is stopped at test cleanup.
+func startTestCapture(t *testing.T, tradeID string) {
+	t.Helper()
+	if err := StartCapture(CaptureFilter{TradeID: tradeID, Until: time.Now().Add(time.Minute)}); err != nil {
+		t.Fatalf("StartCapture() error = %v", err)
+	}
+	t.Cleanup(func() { StopCapture() })
+}
+
+// newExplorer returns a mempool.space-like explorer.
+func newExplorer(t *testing.T) *httptest.Server {
+	t.Helper()
+	height := 850000
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/blocks/tip/height":
+			fmt.Fprint(w, height)
+			height++
+		case "/api/tx/abc":
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprint(w, `{"txid":"abc","status":{"confirmed":true,"block_height":849990},"vin":[],"vout":[{"value":5000,"scriptpubkey_address":"bc1qx"}]}`)
+		case "/api/tx":
+			fmt.Fprint(w, "0123abcd")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestCaptureAndReplayHTTP(t *testing.T) {
+	srv := newExplorer(t)
+	b := NewMempoolBackend(srv.URL + "/api")
+	ctx := context.Background()
+
+	startTestCapture(t, "")
+	h1, _ := b.GetBlockHeight(ctx)
+	h2, _ := b.GetBlockHeight(ctx)
+	tx, err := b.GetTransaction(ctx, "abc")
+	if err != nil {
+		t.Fatalf("GetTransaction() error = %v", err)
+	}
+	txid, _ := b.BroadcastTransaction(ctx, "deadbeef")
+
+	bundle, err := StopCapture()
+	if err != nil {
+		t.Fatalf("StopCapture() error = %v", err)
+	}
+	if len(bundle.Entries) < 4 {
+		t.Fatalf("captured %d entries, want at least 4", len(bundle.Entries))
+	}
+
+	// Round-trip through a file
+	path, err := SaveCaptureBundle(t.TempDir(), bundle)
+	if err != nil {
+		t.Fatalf("SaveCaptureBundle() error = %v", err)
+	}
+	loaded, err := LoadCaptureBundle(path)
+	if err != nil {
+		t.Fatalf("LoadCaptureBundle() error = %v", err)
+	}
+
+	replay := NewReplay(loaded)
+	mock := httptest.NewServer(replay)
+	defer mock.Close()
+	rb := NewMempoolBackend(mock.URL + "/api")
+
+	if got, _ := rb.GetBlockHeight(ctx); got != h1 {
+		t.Errorf("replayed height = %d, want %d", got, h1)
+	}
+	if got, _ := rb.GetBlockHeight(ctx); got != h2 {
+		t.Errorf("second replayed height = %d, want %d", got, h2)
+	}
+	rtx, err := rb.GetTransaction(ctx, "abc")
+	if err != nil || rtx.BlockHeight != tx.BlockHeight || rtx.Outputs[0].Value != 5000 {
+		t.Errorf("replayed tx = %+v, %v", rtx, err)
+	}
+	if got, _ := rb.BroadcastTransaction(ctx, "deadbeef"); got != txid {
+		t.Errorf("replayed broadcast = %q, want %q", got, txid)
+	}
+
+	// Exhausted requests repeat the last response
+	last, _ := rb.GetBlockHeight(ctx)
+	if again, _ := rb.GetBlockHeight(ctx); again != last || last == 0 {
+		t.Errorf("exhausted replay heights = %d, %d", last, again)
+	}
+
+	if _, err := rb.GetTransaction(ctx, "unknown"); err == nil {
+		t.Error("unrecorded request should fail")
+	}
+	if misses := replay.Misses(); len(misses) != 1 || !strings.Contains(misses[0], "/api/tx/unknown") {
+		t.Errorf("Misses() = %v", misses)
+	}
+}
+
+func TestCaptureTradeFilter(t *testing.T) {
+	srv := newExplorer(t)
+	b := NewMempoolBackend(srv.URL + "/api")
+
+	startTestCapture(t, "trade-1")
+	b.GetBlockHeight(context.Background())
+	b.GetBlockHeight(WithTradeID(context.Background(), "trade-2"))
+	b.GetBlockHeight(WithTradeID(context.Background(), "trade-1"))
+
+	st := GetCaptureStatus()
+	if !st.Active || st.Entries != 1 || st.Filter.TradeID != "trade-1" {
+		t.Errorf("status = %+v, want one entry for trade-1", st)
+	}
+	bundle, _ := StopCapture()
+	if bundle.Entries[0].TradeID != "trade-1" {
+		t.Errorf("entry trade = %q", bundle.Entries[0].TradeID)
+	}
+}
+
+func TestCaptureWindow(t *testing.T) {
+	if err := StartCapture(CaptureFilter{}); err == nil {
+		t.Error("StartCapture() without a window end should fail")
+	}
+	if err := StartCapture(CaptureFilter{Until: time.Now().Add(-time.Second)}); err != nil {
+		t.Fatalf("StartCapture() error = %v", err)
+	}
+	defer StopCapture()
+	if err := StartCapture(CaptureFilter{Until: time.Now().Add(time.Minute)}); err != ErrCaptureActive {
+		t.Errorf("second StartCapture() error = %v, want ErrCaptureActive", err)
+	}
+
+	srv := newExplorer(t)
+	NewMempoolBackend(srv.URL + "/api").GetBlockHeight(context.Background())
+	if st := GetCaptureStatus(); !st.Expired || st.Entries != 0 {
+		t.Errorf("status = %+v, want expired with no entries", st)
+	}
+}
+
+func TestCaptureRedaction(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     uint64 `json:"id"`
+			Method string `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		result := `"0x10"`
+		if req.Method == "dumpprivkey" {
+			result = `"L1secretwif"`
+		}
+		fmt.Fprintf(w, `{"jsonrpc":"2.0","id":%d,"result":%s}`, req.ID, result)
+	}))
+	defer srv.Close()
+
+	u := strings.Replace(srv.URL, "http://", "http://user:pw@", 1) + "/rpc?apikey=topsecret&chain=1"
+	b := NewJSONRPCBackend(u, RPCTypeBitcoin, "rpcuser", "rpcpass")
+	ctx := context.Background()
+
+	startTestCapture(t, "")
+	b.call(ctx, "dumpprivkey", []interface{}{"bc1qx"}, true)
+	b.call(ctx, "importmulti", []interface{}{map[string]interface{}{"privkey": "L1other"}}, true)
+	bundle, _ := StopCapture()
+
+	data, _ := json.Marshal(bundle)
+	for _, secret := range []string{"topsecret", "user:pw", "L1secretwif", "L1other", "bc1qx", "Basic "} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("bundle contains %q: %s", secret, data)
+		}
+	}
+	if e := bundle.Entries[0]; e.RequestHeader["Authorization"] != redacted || !strings.Contains(e.URL, "chain=1") {
+		t.Errorf("entry = %+v", e)
+	}
+}
+
+func TestRedactURL(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"https://mainnet.infura.io/v3/0123456789abcdef0123456789abcdef", "https://mainnet.infura.io/v3/REDACTED"},
+		{"https://eth-mainnet.g.alchemy.com/v2/AbCdEfGhIjKlMnOpQrStUvWx", "https://eth-mainnet.g.alchemy.com/v2/REDACTED"},
+		{"https://rpc.ankr.com/eth/f00dfeed", "https://rpc.ankr.com/eth/REDACTED"},
+		{"https://x.quiknode.pro/t0ken/", "https://x.quiknode.pro/REDACTED/"},
+		{"https://mempool.space/api/tx/abcd", "https://mempool.space/api/tx/abcd"},
+		{"https://u:p@node.example.com/rpc?apikey=k&chain=1", "https://node.example.com/rpc?apikey=REDACTED&chain=1"},
+	}
+	for _, tt := range tests {
+		u, _ := url.Parse(tt.in)
+		if got := redactURL(u); got != tt.want {
+			t.Errorf("redactURL(%s) = %s, want %s", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestReplayJSONRPCIDs(t *testing.T) {
+	bundle := &CaptureBundle{Version: CaptureBundleVersion, Entries: []CaptureEntry{{
+		Kind:         CaptureKindHTTP,
+		Method:       http.MethodPost,
+		URL:          "https://eth.example/rpc",
+		Status:       http.StatusOK,
+		RequestBody:  json.RawMessage(`{"id":7,"jsonrpc":"2.0","method":"eth_blockNumber","params":[]}`),
+		ResponseBody: json.RawMessage(`{"id":7,"jsonrpc":"2.0","result":"0x2a"}`),
+	}}}
+	mock := httptest.NewServer(NewReplay(bundle))
+	defer mock.Close()
+
+	b := NewJSONRPCBackend(mock.URL+"/rpc", RPCTypeEVM, "", "")
+	b.requestID.Store(100) // Different id than captured
+	height, err := b.GetBlockHeight(context.Background())
+	if err != nil || height != 42 {
+		t.Errorf("GetBlockHeight() = %d, %v, want 42", height, err)
+	}
+}
+
+func TestCaptureAndReplayElectrum(t *testing.T) {
+	// A minimal Electrum server
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+				r := bufio.NewReader(conn)
+				for {
+					line, err := r.ReadBytes('\n')
+					if err != nil {
+						return
+					}
+					var req struct {
+						ID     uint64 `json:"id"`
+						Method string `json:"method"`
+					}
+					json.Unmarshal(line, &req)
+					result := `["test", "1.4"]`
+					if req.Method == "blockchain.headers.subscribe" {
+						result = `{"height": 123, "hex": ""}`
+					}
+					fmt.Fprintf(conn, "{\"jsonrpc\":\"2.0\",\"id\":%d,\"result\":%s}\n", req.ID, result)
+				}
+			}(conn)
+		}
+	}()
+
+	ctx := context.Background()
+	startTestCapture(t, "")
+	e := NewElectrumBackend([]string{ln.Addr().String()}, false)
+	if err := e.Connect(ctx); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	defer e.Close()
+	if h, err := e.GetBlockHeight(ctx); err != nil || h != 123 {
+		t.Fatalf("GetBlockHeight() = %d, %v", h, err)
+	}
+	bundle, _ := StopCapture()
+	if len(bundle.Entries) != 2 || bundle.Entries[1].RPCMethod != "blockchain.headers.subscribe" {
+		t.Fatalf("entries = %+v", bundle.Entries)
+	}
+
+	mockLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	defer mockLn.Close()
+	go NewReplay(bundle).ServeElectrum(mockLn)
+
+	re := NewElectrumBackend([]string{mockLn.Addr().String()}, false)
+	re.requestID.Store(50)
+	if err := re.Connect(ctx); err != nil {
+		t.Fatalf("replay Connect() error = %v", err)
+	}
+	defer re.Close()
+	if h, err := re.GetBlockHeight(ctx); err != nil || h != 123 {
+		t.Errorf("replayed GetBlockHeight() = %d, %v", h, err)
+	}
+	if _, err := re.GetFeeEstimates(ctx); err != nil {
+		t.Errorf("GetFeeEstimates() error = %v", err) // Unrecorded calls fail individually
+	}
+}
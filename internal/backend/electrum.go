@@ -19,12 +19,18 @@ import (
 	"github.com/btcsuite/btcd/btcutil/bech32"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // ElectrumBackend implements Backend using the Electrum protocol.
 // Supports both TCP and SSL connections.
 type ElectrumBackend struct {
 	servers   []string // List of server addresses (host:port)
+	server    string   // Connected server
 	useTLS    bool
 	conn      net.Conn
 	reader    *bufio.Reader
@@ -81,8 +87,9 @@ func (e *ElectrumBackend) Connect(ctx context.Context) error {
 		e.conn = conn
 		e.reader = bufio.NewReader(conn)
 
-		// Test connection with server.version
-		_, err = e.call("server.version", []interface{}{"klingon", "1.4"})
+		// Test connection with server.version (lock already held)
+		e.server = server
+		_, err = e.roundTrip(ctx, "server.version", []interface{}{"klingon", "1.4"})
 		if err != nil {
 			conn.Close()
 			lastErr = err
@@ -121,7 +128,7 @@ func (e *ElectrumBackend) GetAddressInfo(ctx context.Context, address string) (*
 	scriptHash := addressToScriptHash(address)
 
 	// Get balance
-	balanceResult, err := e.call("blockchain.scripthash.get_balance", []interface{}{scriptHash})
+	balanceResult, err := e.call(ctx, "blockchain.scripthash.get_balance", []interface{}{scriptHash})
 	if err != nil {
 		return nil, err
 	}
@@ -135,7 +142,7 @@ func (e *ElectrumBackend) GetAddressInfo(ctx context.Context, address string) (*
 	unconfirmed := int64(balance["unconfirmed"].(float64))
 
 	// Get history for tx count
-	historyResult, err := e.call("blockchain.scripthash.get_history", []interface{}{scriptHash})
+	historyResult, err := e.call(ctx, "blockchain.scripthash.get_history", []interface{}{scriptHash})
 	if err != nil {
 		return nil, err
 	}
@@ -157,7 +164,7 @@ func (e *ElectrumBackend) GetAddressInfo(ctx context.Context, address string) (*
 func (e *ElectrumBackend) GetAddressUTXOs(ctx context.Context, address string) ([]UTXO, error) {
 	scriptHash := addressToScriptHash(address)
 
-	result, err := e.call("blockchain.scripthash.listunspent", []interface{}{scriptHash})
+	result, err := e.call(ctx, "blockchain.scripthash.listunspent", []interface{}{scriptHash})
 	if err != nil {
 		return nil, err
 	}
@@ -194,7 +201,7 @@ func (e *ElectrumBackend) GetAddressUTXOs(ctx context.Context, address string) (
 func (e *ElectrumBackend) GetAddressTxs(ctx context.Context, address string, lastSeenTxID string) ([]Transaction, error) {
 	scriptHash := addressToScriptHash(address)
 
-	result, err := e.call("blockchain.scripthash.get_history", []interface{}{scriptHash})
+	result, err := e.call(ctx, "blockchain.scripthash.get_history", []interface{}{scriptHash})
 	if err != nil {
 		return nil, err
 	}
@@ -231,7 +238,7 @@ func (e *ElectrumBackend) GetAddressTxs(ctx context.Context, address string, las
 
 // GetTransaction returns a transaction by ID.
 func (e *ElectrumBackend) GetTransaction(ctx context.Context, txID string) (*Transaction, error) {
-	result, err := e.call("blockchain.transaction.get", []interface{}{txID, true})
+	result, err := e.call(ctx, "blockchain.transaction.get", []interface{}{txID, true})
 	if err != nil {
 		return nil, err
 	}
@@ -267,7 +274,7 @@ func (e *ElectrumBackend) GetTransaction(ctx context.Context, txID string) (*Tra
 
 // GetRawTransaction returns raw transaction hex.
 func (e *ElectrumBackend) GetRawTransaction(ctx context.Context, txID string) ([]byte, error) {
-	result, err := e.call("blockchain.transaction.get", []interface{}{txID, false})
+	result, err := e.call(ctx, "blockchain.transaction.get", []interface{}{txID, false})
 	if err != nil {
 		return nil, err
 	}
@@ -282,7 +289,7 @@ func (e *ElectrumBackend) GetRawTransaction(ctx context.Context, txID string) ([
 
 // BroadcastTransaction broadcasts a raw transaction.
 func (e *ElectrumBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
-	result, err := e.call("blockchain.transaction.broadcast", []interface{}{rawTxHex})
+	result, err := e.call(ctx, "blockchain.transaction.broadcast", []interface{}{rawTxHex})
 	if err != nil {
 		return "", fmt.Errorf("%w: %v", ErrBroadcastFailed, err)
 	}
@@ -297,7 +304,7 @@ func (e *ElectrumBackend) BroadcastTransaction(ctx context.Context, rawTxHex str
 
 // GetBlockHeight returns the current block height.
 func (e *ElectrumBackend) GetBlockHeight(ctx context.Context) (int64, error) {
-	result, err := e.call("blockchain.headers.subscribe", []interface{}{})
+	result, err := e.call(ctx, "blockchain.headers.subscribe", []interface{}{})
 	if err != nil {
 		return 0, err
 	}
@@ -324,7 +331,7 @@ func (e *ElectrumBackend) GetBlockHeader(ctx context.Context, hashOrHeight strin
 		return nil, fmt.Errorf("electrum requires block height, not hash")
 	}
 
-	result, err := e.call("blockchain.block.header", []interface{}{height, 0})
+	result, err := e.call(ctx, "blockchain.block.header", []interface{}{height, 0})
 	if err != nil {
 		return nil, err
 	}
@@ -440,28 +447,28 @@ func (e *ElectrumBackend) GetFeeEstimates(ctx context.Context) (*FeeEstimate, er
 	estimates := &FeeEstimate{}
 
 	// 1 block (fastest)
-	if result, err := e.call("blockchain.estimatefee", []interface{}{1}); err == nil {
+	if result, err := e.call(ctx, "blockchain.estimatefee", []interface{}{1}); err == nil {
 		if fee, ok := result.(float64); ok && fee > 0 {
 			estimates.FastestFee = uint64(fee * 1e8 / 1000) // BTC/kB to sat/vB
 		}
 	}
 
 	// 3 blocks (~30 min)
-	if result, err := e.call("blockchain.estimatefee", []interface{}{3}); err == nil {
+	if result, err := e.call(ctx, "blockchain.estimatefee", []interface{}{3}); err == nil {
 		if fee, ok := result.(float64); ok && fee > 0 {
 			estimates.HalfHourFee = uint64(fee * 1e8 / 1000)
 		}
 	}
 
 	// 6 blocks (~1 hour)
-	if result, err := e.call("blockchain.estimatefee", []interface{}{6}); err == nil {
+	if result, err := e.call(ctx, "blockchain.estimatefee", []interface{}{6}); err == nil {
 		if fee, ok := result.(float64); ok && fee > 0 {
 			estimates.HourFee = uint64(fee * 1e8 / 1000)
 		}
 	}
 
 	// 144 blocks (~1 day)
-	if result, err := e.call("blockchain.estimatefee", []interface{}{144}); err == nil {
+	if result, err := e.call(ctx, "blockchain.estimatefee", []interface{}{144}); err == nil {
 		if fee, ok := result.(float64); ok && fee > 0 {
 			estimates.EconomyFee = uint64(fee * 1e8 / 1000)
 		}
@@ -473,14 +480,21 @@ func (e *ElectrumBackend) GetFeeEstimates(ctx context.Context) (*FeeEstimate, er
 }
 
 // call makes an Electrum JSON-RPC call.
-func (e *ElectrumBackend) call(method string, params []interface{}) (interface{}, error) {
+func (e *ElectrumBackend) call(ctx context.Context, method string, params []interface{}) (interface{}, error) {
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
 	if !e.connected || e.conn == nil {
 		return nil, ErrNotConnected
 	}
+	if err := chaos.DelayBackend(ctx); err != nil {
+		return nil, err
+	}
+	return e.roundTrip(ctx, method, params)
+}
 
+// roundTrip sends a request on the connection. e.mu must be held.
+func (e *ElectrumBackend) roundTrip(ctx context.Context, method string, params []interface{}) (result interface{}, err error) {
 	id := e.requestID.Add(1)
 
 	request := map[string]interface{}{
@@ -495,6 +509,16 @@ func (e *ElectrumBackend) call(method string, params []interface{}) (interface{}
 		return nil, err
 	}
 
+	_, span := tracing.Start(ctx, "backend", "electrum "+method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("rpc.method", method), attribute.String("server.address", e.server)))
+	var line []byte
+	start := time.Now()
+	defer func() {
+		recordElectrum(ctx, e.server, method, data, line, start, err)
+		tracing.End(span, err)
+	}()
+
 	// Set deadline
 	e.conn.SetDeadline(time.Now().Add(e.timeout))
 
@@ -505,7 +529,7 @@ func (e *ElectrumBackend) call(method string, params []interface{}) (interface{}
 	}
 
 	// Read response
-	line, err := e.reader.ReadBytes('\n')
+	line, err = e.reader.ReadBytes('\n')
 	if err != nil {
 		e.connected = false
 		return nil, err
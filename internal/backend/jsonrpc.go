@@ -42,13 +42,11 @@ type JSONRPCBackend struct {
 // rpcType should be "bitcoin" for Bitcoin Core or "evm" for Ethereum.
 func NewJSONRPCBackend(rpcURL string, rpcType RPCType, user, pass string) *JSONRPCBackend {
 	return &JSONRPCBackend{
-		rpcURL:  rpcURL,
-		rpcType: rpcType,
-		rpcUser: user,
-		rpcPass: pass,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		rpcURL:     rpcURL,
+		rpcType:    rpcType,
+		rpcUser:    user,
+		rpcPass:    pass,
+		httpClient: newHTTPClient(30 * time.Second),
 	}
 }
 
@@ -691,6 +689,26 @@ func (j *JSONRPCBackend) EVMCall(ctx context.Context, to string, data []byte) ([
 	return hex.DecodeString(strings.TrimPrefix(hexResult, "0x"))
 }
 
+// EVMGetCode returns the deployed bytecode at an address (eth_getCode).
+// An empty result means the address is an externally owned account.
+func (j *JSONRPCBackend) EVMGetCode(ctx context.Context, address string) ([]byte, error) {
+	if j.rpcType != RPCTypeEVM {
+		return nil, fmt.Errorf("EVMGetCode only available for EVM backends")
+	}
+
+	result, err := j.evmCall(ctx, "eth_getCode", []interface{}{address, "latest"})
+	if err != nil {
+		return nil, err
+	}
+
+	var hexResult string
+	if err := json.Unmarshal(result, &hexResult); err != nil {
+		return nil, err
+	}
+
+	return hex.DecodeString(strings.TrimPrefix(hexResult, "0x"))
+}
+
 // EVMGetChainID returns the chain ID.
 func (j *JSONRPCBackend) EVMGetChainID(ctx context.Context) (uint64, error) {
 	if j.rpcType != RPCTypeEVM {
@@ -26,10 +26,8 @@ func NewMempoolBackend(baseURL string) *MempoolBackend {
 	baseURL = strings.TrimSuffix(baseURL, "/")
 
 	return &MempoolBackend{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+		baseURL:    baseURL,
+		httpClient: newHTTPClient(30 * time.Second),
 	}
 }
 
@@ -0,0 +1,345 @@
+package backend
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MoneroWalletRPC is a client of monero-wallet-rpc, the wallet backend of
+// the XMR legs of adaptor swaps. Unlike the chain backends it holds keys:
+// the shared swap address is restored into it from the two spend key shares
+// to sweep it.
+//
+// The wallet's --rpc-login credentials are sent with HTTP digest auth, the
+// only scheme monero-wallet-rpc accepts.
+type MoneroWalletRPC struct {
+	rpcURL     string
+	httpClient *http.Client
+	requestID  atomic.Uint64
+	auth       *digestAuth // nil without a login
+}
+
+// NewMoneroWalletRPC creates a client of the monero-wallet-rpc at rpcURL
+// (e.g. http://127.0.0.1:18083). An empty user is for a wallet run with
+// --disable-rpc-login.
+func NewMoneroWalletRPC(rpcURL, user, pass string) *MoneroWalletRPC {
+	m := &MoneroWalletRPC{
+		rpcURL:     strings.TrimSuffix(rpcURL, "/") + "/json_rpc",
+		httpClient: newHTTPClient(60 * time.Second),
+	}
+	if user != "" {
+		m.auth = &digestAuth{user: user, pass: pass}
+	}
+	return m
+}
+
+// MoneroBalance is a wallet balance in piconero.
+type MoneroBalance struct {
+	Balance         uint64 `json:"balance"`
+	UnlockedBalance uint64 `json:"unlocked_balance"`
+}
+
+// MoneroTransfer is a sent transaction. TxKey lets the recipient prove the
+// payment with CheckTxKey.
+type MoneroTransfer struct {
+	TxHash string `json:"tx_hash"`
+	TxKey  string `json:"tx_key"`
+	Amount uint64 `json:"amount"`
+	Fee    uint64 `json:"fee"`
+}
+
+// MoneroTxProof is what a tx key proves about a payment to an address.
+type MoneroTxProof struct {
+	Confirmations uint64 `json:"confirmations"`
+	InPool        bool   `json:"in_pool"`
+	Received      uint64 `json:"received"`
+}
+
+// GetHeight returns the wallet's synced height.
+func (m *MoneroWalletRPC) GetHeight(ctx context.Context) (int64, error) {
+	var result struct {
+		Height int64 `json:"height"`
+	}
+	if err := m.call(ctx, "get_height", nil, &result); err != nil {
+		return 0, err
+	}
+	return result.Height, nil
+}
+
+// GetBalance returns the balance of the open wallet's main account.
+func (m *MoneroWalletRPC) GetBalance(ctx context.Context) (*MoneroBalance, error) {
+	var result MoneroBalance
+	if err := m.call(ctx, "get_balance", map[string]interface{}{"account_index": 0}, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GetAddress returns the primary address of the open wallet.
+func (m *MoneroWalletRPC) GetAddress(ctx context.Context) (string, error) {
+	var result struct {
+		Address string `json:"address"`
+	}
+	if err := m.call(ctx, "get_address", map[string]interface{}{"account_index": 0}, &result); err != nil {
+		return "", err
+	}
+	return result.Address, nil
+}
+
+// Transfer sends amount piconero to address, returning the tx key so the
+// counterparty can check the lock.
+func (m *MoneroWalletRPC) Transfer(ctx context.Context, address string, amount uint64) (*MoneroTransfer, error) {
+	params := map[string]interface{}{
+		"destinations":  []map[string]interface{}{{"amount": amount, "address": address}},
+		"account_index": 0,
+		"get_tx_key":    true,
+	}
+	var result MoneroTransfer
+	if err := m.call(ctx, "transfer", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// CheckTxKey checks a payment to address using its tx key.
+func (m *MoneroWalletRPC) CheckTxKey(ctx context.Context, txID, txKey, address string) (*MoneroTxProof, error) {
+	params := map[string]interface{}{"txid": txID, "tx_key": txKey, "address": address}
+	var result MoneroTxProof
+	if err := m.call(ctx, "check_tx_key", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// GenerateFromKeys restores a wallet file from its keys and opens it. An
+// empty spendKey restores a view-only wallet, which watches the shared
+// address before the swap settles.
+func (m *MoneroWalletRPC) GenerateFromKeys(ctx context.Context, filename, address, spendKey, viewKey string, restoreHeight int64) error {
+	params := map[string]interface{}{
+		"filename":         filename,
+		"address":          address,
+		"spendkey":         spendKey,
+		"viewkey":          viewKey,
+		"password":         "",
+		"restore_height":   restoreHeight,
+		"autosave_current": false,
+	}
+	return m.call(ctx, "generate_from_keys", params, nil)
+}
+
+// OpenWallet opens a wallet file.
+func (m *MoneroWalletRPC) OpenWallet(ctx context.Context, filename string) error {
+	return m.call(ctx, "open_wallet", map[string]interface{}{"filename": filename, "password": ""}, nil)
+}
+
+// CloseWallet saves and closes the open wallet.
+func (m *MoneroWalletRPC) CloseWallet(ctx context.Context) error {
+	return m.call(ctx, "close_wallet", nil, nil)
+}
+
+// Refresh scans the chain for the open wallet's outputs.
+func (m *MoneroWalletRPC) Refresh(ctx context.Context) error {
+	return m.call(ctx, "refresh", nil, nil)
+}
+
+// SweepAll sends the open wallet's whole unlocked balance to address.
+func (m *MoneroWalletRPC) SweepAll(ctx context.Context, address string) ([]string, error) {
+	var result struct {
+		TxHashList []string `json:"tx_hash_list"`
+	}
+	if err := m.call(ctx, "sweep_all", map[string]interface{}{"address": address, "account_index": 0}, &result); err != nil {
+		return nil, err
+	}
+	return result.TxHashList, nil
+}
+
+func (m *MoneroWalletRPC) call(ctx context.Context, method string, params interface{}, result interface{}) error {
+	request := map[string]interface{}{
+		"jsonrpc": "2.0",
+		"id":      m.requestID.Add(1),
+		"method":  method,
+	}
+	if params != nil {
+		request["params"] = params
+	}
+	data, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+
+	resp, err := m.post(ctx, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("monero-wallet-rpc %s: login rejected", method)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("monero-wallet-rpc %s: HTTP %d", method, resp.StatusCode)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	if response.Error != nil {
+		return fmt.Errorf("monero-wallet-rpc %s error %d: %s", method, response.Error.Code, response.Error.Message)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(response.Result, result)
+}
+
+// post sends a JSON-RPC request, answering a digest challenge once: the
+// first request, and any after the wallet expired the nonce, is challenged.
+func (m *MoneroWalletRPC) post(ctx context.Context, data []byte) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.rpcURL, bytes.NewReader(data))
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		if m.auth != nil {
+			m.auth.authorize(req)
+		}
+
+		resp, err := m.httpClient.Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrNotConnected, err)
+		}
+		if resp.StatusCode != http.StatusUnauthorized || m.auth == nil || attempt > 0 {
+			return resp, nil
+		}
+		challenged := m.auth.challenge(resp.Header.Get("WWW-Authenticate"))
+		resp.Body.Close()
+		if !challenged {
+			return nil, fmt.Errorf("monero-wallet-rpc: unsupported authentication %q", resp.Header.Get("WWW-Authenticate"))
+		}
+	}
+}
+
+// digestAuth answers RFC 7616 digest challenges with MD5 and qop=auth, as
+// monero-wallet-rpc issues them.
+type digestAuth struct {
+	user, pass string
+
+	mu     sync.Mutex
+	realm  string
+	nonce  string
+	opaque string
+	qop    bool
+	nc     uint32
+}
+
+// challenge records a WWW-Authenticate digest challenge. It returns false if
+// the challenge can't be answered.
+func (d *digestAuth) challenge(header string) bool {
+	scheme, params, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Digest") {
+		return false
+	}
+	fields := parseDigestParams(params)
+	if alg := fields["algorithm"]; alg != "" && !strings.EqualFold(alg, "MD5") {
+		return false
+	}
+	if fields["nonce"] == "" {
+		return false
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.realm = fields["realm"]
+	d.nonce = fields["nonce"]
+	d.opaque = fields["opaque"]
+	d.qop = false
+	for _, q := range strings.Split(fields["qop"], ",") {
+		if strings.TrimSpace(q) == "auth" {
+			d.qop = true
+		}
+	}
+	d.nc = 0
+	return true
+}
+
+// authorize adds the Authorization header once a challenge was received.
+func (d *digestAuth) authorize(req *http.Request) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.nonce == "" {
+		return
+	}
+
+	md5hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	uri := req.URL.RequestURI()
+	ha1 := md5hex(d.user + ":" + d.realm + ":" + d.pass)
+	ha2 := md5hex(req.Method + ":" + uri)
+
+	h := fmt.Sprintf(`Digest username="%s", realm="%s", nonce="%s", uri="%s", algorithm=MD5`, d.user, d.realm, d.nonce, uri)
+	if d.qop {
+		d.nc++
+		var b [8]byte
+		rand.Read(b[:])
+		cnonce := hex.EncodeToString(b[:])
+		nc := fmt.Sprintf("%08x", d.nc)
+		h += fmt.Sprintf(`, qop=auth, nc=%s, cnonce="%s", response="%s"`, nc, cnonce,
+			md5hex(ha1+":"+d.nonce+":"+nc+":"+cnonce+":auth:"+ha2))
+	} else {
+		h += fmt.Sprintf(`, response="%s"`, md5hex(ha1+":"+d.nonce+":"+ha2))
+	}
+	if d.opaque != "" {
+		h += fmt.Sprintf(`, opaque="%s"`, d.opaque)
+	}
+	req.Header.Set("Authorization", h)
+}
+
+// parseDigestParams splits the comma-separated key=value pairs of a digest
+// challenge, unquoting the values.
+func parseDigestParams(s string) map[string]string {
+	fields := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, s = rest[1:end+1], rest[end+2:]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+		}
+		fields[strings.ToLower(strings.TrimSpace(key))] = strings.TrimSpace(value)
+	}
+	return fields
+}
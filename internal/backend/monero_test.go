@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newMoneroWallet serves canned monero-wallet-rpc results and records the
+// params of each call.
+func newMoneroWallet(t *testing.T, calls map[string]map[string]interface{}) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/json_rpc" {
+			http.NotFound(w, r)
+			return
+		}
+		var req struct {
+			ID     uint64                 `json:"id"`
+			Method string                 `json:"method"`
+			Params map[string]interface{} `json:"params"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("bad request: %v", err)
+		}
+		calls[req.Method] = req.Params
+
+		results := map[string]string{
+			"get_height":         `{"height": 3100000}`,
+			"get_balance":        `{"balance": 1500000000000, "unlocked_balance": 1000000000000}`,
+			"get_address":        `{"address": "4main", "addresses": []}`,
+			"transfer":           `{"tx_hash": "aa", "tx_key": "bb", "amount": 1000, "fee": 30}`,
+			"check_tx_key":       `{"confirmations": 12, "in_pool": false, "received": 1000}`,
+			"generate_from_keys": `{"address": "5abc", "info": "Wallet has been generated successfully."}`,
+			"sweep_all":          `{"tx_hash_list": ["cc", "dd"]}`,
+		}
+		result, ok := results[req.Method]
+		if !ok {
+			w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-13,"message":"No wallet file"}}`))
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":` + result + `}`))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestMoneroWalletRPC(t *testing.T) {
+	calls := map[string]map[string]interface{}{}
+	m := NewMoneroWalletRPC(newMoneroWallet(t, calls).URL+"/", "", "")
+	ctx := context.Background()
+
+	if h, err := m.GetHeight(ctx); err != nil || h != 3100000 {
+		t.Errorf("GetHeight() = %d, %v", h, err)
+	}
+	if b, err := m.GetBalance(ctx); err != nil || b.Balance != 1500000000000 || b.UnlockedBalance != 1000000000000 {
+		t.Errorf("GetBalance() = %+v, %v", b, err)
+	}
+	if addr, err := m.GetAddress(ctx); err != nil || addr != "4main" {
+		t.Errorf("GetAddress() = %q, %v", addr, err)
+	}
+
+	tr, err := m.Transfer(ctx, "5shared", 1000)
+	if err != nil || tr.TxHash != "aa" || tr.TxKey != "bb" || tr.Fee != 30 {
+		t.Errorf("Transfer() = %+v, %v", tr, err)
+	}
+	if calls["transfer"]["get_tx_key"] != true {
+		t.Errorf("transfer params = %v, want the tx key requested", calls["transfer"])
+	}
+
+	proof, err := m.CheckTxKey(ctx, "aa", "bb", "5shared")
+	if err != nil || proof.Confirmations != 12 || proof.Received != 1000 {
+		t.Errorf("CheckTxKey() = %+v, %v", proof, err)
+	}
+
+	if err := m.GenerateFromKeys(ctx, "swap-1", "5shared", "", "0f", 3099000); err != nil {
+		t.Errorf("GenerateFromKeys() error = %v", err)
+	}
+	if calls["generate_from_keys"]["restore_height"] != float64(3099000) {
+		t.Errorf("generate_from_keys params = %v", calls["generate_from_keys"])
+	}
+
+	if txs, err := m.SweepAll(ctx, "4dest"); err != nil || len(txs) != 2 {
+		t.Errorf("SweepAll() = %v, %v", txs, err)
+	}
+
+	if err := m.Refresh(ctx); err == nil || !strings.Contains(err.Error(), "No wallet file") {
+		t.Errorf("Refresh() error = %v, want the RPC error", err)
+	}
+}
+
+func TestMoneroWalletRPCDigestAuth(t *testing.T) {
+	const realm, nonce = "monero-rpc", "abc123"
+	md5hex := func(s string) string {
+		sum := md5.Sum([]byte(s))
+		return hex.EncodeToString(sum[:])
+	}
+	var challenges int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := parseDigestParams(strings.TrimPrefix(r.Header.Get("Authorization"), "Digest "))
+		ha1 := md5hex("klingdex:" + realm + ":secret")
+		ha2 := md5hex(r.Method + ":" + f["uri"])
+		want := md5hex(ha1 + ":" + nonce + ":" + f["nc"] + ":" + f["cnonce"] + ":auth:" + ha2)
+		if f["username"] != "klingdex" || f["nonce"] != nonce || f["qop"] != "auth" || f["response"] != want {
+			challenges++
+			w.Header().Set("WWW-Authenticate", `Digest qop="auth",algorithm=MD5,realm="`+realm+`",nonce="`+nonce+`",stale=false`)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":{"height": 3100000}}`))
+	}))
+	t.Cleanup(srv.Close)
+	ctx := context.Background()
+
+	m := NewMoneroWalletRPC(srv.URL, "klingdex", "secret")
+	for i := 0; i < 2; i++ {
+		if h, err := m.GetHeight(ctx); err != nil || h != 3100000 {
+			t.Fatalf("GetHeight() = %d, %v", h, err)
+		}
+	}
+	// The second call reuses the nonce
+	if challenges != 1 {
+		t.Errorf("challenged %d times, want 1", challenges)
+	}
+
+	bad := NewMoneroWalletRPC(srv.URL, "klingdex", "wrong")
+	if _, err := bad.GetHeight(ctx); err == nil || !strings.Contains(err.Error(), "login rejected") {
+		t.Errorf("GetHeight() with a wrong password error = %v", err)
+	}
+}
@@ -0,0 +1,845 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	"github.com/Klingon-tech/klingdex/internal/backend/pluginpb"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Backend plugins serve chains klingond has no built-in backend for (or
+// replace a built-in one) from a separate process, so they can be added
+// without recompiling. A plugin is an executable in the configured plugin
+// directory. klingond starts it with PluginSocketEnv naming a Unix socket,
+// the plugin serves the gRPC Backend service of pluginpb/plugin.proto there,
+// and klingond calls Info and then the Backend methods with a Request naming
+// the chain. The plugin's stdout and stderr are passed through, and its
+// stdin closes when klingond stops it. Go plugins call ServePlugin from
+// main.
+//
+// A plugin that exits is restarted after RestartDelay, doubling up to
+// MaxRestartDelay while it keeps failing, and its connected chains are
+// connected again.
+
+// PluginProtocolVersion is the version of the plugin protocol.
+const PluginProtocolVersion = 2
+
+// PluginSocketEnv names the environment variable holding the Unix socket
+// path a plugin serves on.
+const PluginSocketEnv = "KLINGDEX_PLUGIN_SOCKET"
+
+// TypePlugin is the type of backends served by plugins.
+const TypePlugin Type = "plugin"
+
+// ErrPluginExited is returned by calls to a plugin whose process is gone.
+var ErrPluginExited = errors.New("backend plugin exited")
+
+// pluginErrors are the errors passed through plugins, with the status code
+// they travel with. They are matched by message, so callers can still use
+// errors.Is.
+var pluginErrors = []struct {
+	err  error
+	code codes.Code
+}{
+	{ErrNotConnected, codes.FailedPrecondition},
+	{ErrTxNotFound, codes.NotFound},
+	{ErrAddressNotFound, codes.NotFound},
+	{ErrInvalidTx, codes.InvalidArgument},
+	{ErrBroadcastFailed, codes.Aborted},
+	{ErrRateLimited, codes.ResourceExhausted},
+}
+
+// PluginConfig configures backend plugin discovery.
+type PluginConfig struct {
+	// Dir holds the plugin executables. Empty disables plugins.
+	Dir string `yaml:"dir,omitempty"`
+
+	// StartTimeout bounds a plugin's start and Info call.
+	StartTimeout time.Duration `yaml:"start_timeout,omitempty"`
+
+	// CallTimeout bounds each call whose context has no deadline.
+	CallTimeout time.Duration `yaml:"call_timeout,omitempty"`
+
+	// RestartDelay is the wait before restarting an exited plugin. It
+	// doubles up to MaxRestartDelay while restarts fail or the plugin
+	// exits again within MaxRestartDelay.
+	RestartDelay    time.Duration `yaml:"restart_delay,omitempty"`
+	MaxRestartDelay time.Duration `yaml:"max_restart_delay,omitempty"`
+}
+
+// DefaultPluginConfig returns the default plugin config (disabled).
+func DefaultPluginConfig() PluginConfig {
+	return PluginConfig{
+		StartTimeout:    10 * time.Second,
+		CallTimeout:     30 * time.Second,
+		RestartDelay:    time.Second,
+		MaxRestartDelay: time.Minute,
+	}
+}
+
+// Validate checks the plugin config.
+func (c *PluginConfig) Validate() error {
+	if c.Dir == "" {
+		return nil
+	}
+	if info, err := os.Stat(c.Dir); err != nil || !info.IsDir() {
+		return fmt.Errorf("plugin dir %q is not a directory", c.Dir)
+	}
+	if c.StartTimeout <= 0 || c.CallTimeout <= 0 {
+		return fmt.Errorf("plugin start_timeout and call_timeout must be positive")
+	}
+	if c.RestartDelay <= 0 || c.MaxRestartDelay < c.RestartDelay {
+		return fmt.Errorf("plugin restart_delay must be positive and at most max_restart_delay")
+	}
+	return nil
+}
+
+// PluginInfo is a plugin's answer to Info.
+type PluginInfo struct {
+	Name     string   `json:"name"`
+	Version  string   `json:"version"`
+	Protocol int      `json:"protocol"`
+	Chains   []string `json:"chains"`
+}
+
+// check validates the info of a started plugin.
+func (info *PluginInfo) check() error {
+	if info.Protocol != PluginProtocolVersion {
+		return fmt.Errorf("plugin speaks protocol %d, want %d", info.Protocol, PluginProtocolVersion)
+	}
+	if len(info.Chains) == 0 {
+		return fmt.Errorf("plugin serves no chains")
+	}
+	return nil
+}
+
+// pluginProcess is one run of a plugin executable.
+type pluginProcess struct {
+	cmd     *exec.Cmd
+	stdin   io.WriteCloser
+	conn    *grpc.ClientConn
+	client  pluginpb.BackendClient
+	dir     string // holds the socket
+	started time.Time
+	exited  chan struct{}
+
+	stopOnce sync.Once
+}
+
+// startPluginProcess starts a plugin executable and asks for its info.
+func startPluginProcess(path string, cfg PluginConfig) (*pluginProcess, *PluginInfo, error) {
+	dir, err := os.MkdirTemp("", "klingdex-plugin-")
+	if err != nil {
+		return nil, nil, err
+	}
+	socket := filepath.Join(dir, "plugin.sock")
+
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), PluginSocketEnv+"="+socket)
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err == nil {
+		err = cmd.Start()
+	}
+	if err != nil {
+		os.RemoveAll(dir)
+		return nil, nil, fmt.Errorf("failed to start plugin: %w", err)
+	}
+	proc := &pluginProcess{
+		cmd:     cmd,
+		stdin:   stdin,
+		dir:     dir,
+		started: time.Now(),
+		exited:  make(chan struct{}),
+	}
+	go func() {
+		cmd.Wait()
+		close(proc.exited)
+	}()
+
+	proc.conn, err = grpc.NewClient("unix://"+socket, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		proc.stop()
+		return nil, nil, err
+	}
+	proc.client = pluginpb.NewBackendClient(proc.conn)
+
+	// The plugin may not listen yet: wait for it, until it exits
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.StartTimeout)
+	defer cancel()
+	reply, err := callProcess(ctx, proc, 0, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.InfoResponse, error) {
+		return c.Info(ctx, &pluginpb.InfoRequest{}, grpc.WaitForReady(true))
+	})
+	if err != nil {
+		proc.stop()
+		return nil, nil, fmt.Errorf("plugin info: %w", err)
+	}
+	info := &PluginInfo{
+		Name:     reply.GetName(),
+		Version:  reply.GetVersion(),
+		Protocol: int(reply.GetProtocol()),
+		Chains:   reply.GetChains(),
+	}
+	if err := info.check(); err != nil {
+		proc.stop()
+		return nil, nil, err
+	}
+	return proc, info, nil
+}
+
+// hasExited reports whether the process is gone.
+func (pp *pluginProcess) hasExited() bool {
+	select {
+	case <-pp.exited:
+		return true
+	default:
+		return false
+	}
+}
+
+// stop closes the plugin's stdin, kills it if it doesn't exit within a
+// second, and removes its socket.
+func (pp *pluginProcess) stop() {
+	pp.stopOnce.Do(func() {
+		if pp.conn != nil {
+			pp.conn.Close()
+		}
+		pp.stdin.Close()
+		select {
+		case <-pp.exited:
+		case <-time.After(time.Second):
+			pp.cmd.Process.Kill()
+			<-pp.exited
+		}
+		os.RemoveAll(pp.dir)
+	})
+}
+
+// callProcess calls a plugin method, giving up when ctx ends or the
+// process exits. timeout bounds calls whose ctx has no deadline.
+func callProcess[T any](ctx context.Context, proc *pluginProcess, timeout time.Duration, fn func(context.Context, pluginpb.BackendClient) (T, error)) (T, error) {
+	var cancel context.CancelFunc
+	if _, ok := ctx.Deadline(); !ok && timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	callCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		select {
+		case <-proc.exited:
+			cancel()
+		case <-callCtx.Done():
+		}
+	}()
+
+	reply, err := fn(callCtx, proc.client)
+	if err != nil {
+		var zero T
+		switch {
+		case proc.hasExited():
+			return zero, ErrPluginExited
+		case ctx.Err() != nil:
+			return zero, ctx.Err()
+		case status.Code(err) == codes.Unavailable:
+			// The connection broke: the process is likely on its way out
+			select {
+			case <-proc.exited:
+				return zero, ErrPluginExited
+			case <-time.After(time.Second):
+				return zero, fmt.Errorf("%w: %s", ErrNotConnected, status.Convert(err).Message())
+			}
+		}
+		return zero, pluginError(err)
+	}
+	return reply, nil
+}
+
+// pluginError maps the status of a failed call back to the error it came
+// from.
+func pluginError(err error) error {
+	st, ok := status.FromError(err)
+	if !ok {
+		return err
+	}
+	msg := st.Message()
+	for _, known := range pluginErrors {
+		if msg == known.err.Error() {
+			return known.err
+		}
+		if rest, ok := strings.CutPrefix(msg, known.err.Error()+": "); ok {
+			return fmt.Errorf("%w: %s", known.err, rest)
+		}
+	}
+	return errors.New(msg)
+}
+
+// pluginStatus is the status a plugin returns for a backend error.
+func pluginStatus(err error) error {
+	for _, known := range pluginErrors {
+		if errors.Is(err, known.err) {
+			return status.Error(known.code, err.Error())
+		}
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
+
+// Plugin is a supervised plugin executable, restarted when it exits.
+type Plugin struct {
+	Path string
+	Info PluginInfo
+
+	cfg PluginConfig
+	log *logging.Logger
+
+	mu       sync.Mutex
+	proc     *pluginProcess
+	backends map[string]*PluginBackend
+	restarts int
+
+	closed    chan struct{}
+	done      chan struct{} // supervise returned
+	closeOnce sync.Once
+}
+
+// StartPlugin starts a plugin executable, asks for its info and supervises
+// it.
+func StartPlugin(path string, cfg PluginConfig) (*Plugin, error) {
+	proc, info, err := startPluginProcess(path, cfg)
+	if err != nil {
+		return nil, err
+	}
+	p := &Plugin{
+		Path:     path,
+		Info:     *info,
+		cfg:      cfg,
+		log:      logging.GetDefault().Component("backend"),
+		proc:     proc,
+		backends: make(map[string]*PluginBackend),
+		closed:   make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go p.supervise(proc)
+	return p, nil
+}
+
+// DiscoverPlugins starts every executable in cfg.Dir. Plugins that fail to
+// start are skipped and reported in the returned error.
+func DiscoverPlugins(cfg PluginConfig) ([]*Plugin, error) {
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	var plugins []*Plugin
+	var errs []error
+	for _, e := range entries {
+		info, err := e.Info()
+		if err != nil || !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+			continue
+		}
+		path := filepath.Join(cfg.Dir, e.Name())
+		p, err := StartPlugin(path, cfg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", e.Name(), err))
+			continue
+		}
+		plugins = append(plugins, p)
+	}
+	return plugins, errors.Join(errs...)
+}
+
+// supervise restarts the plugin whenever its process exits, until Close.
+func (p *Plugin) supervise(proc *pluginProcess) {
+	defer close(p.done)
+	delay := p.cfg.RestartDelay
+	for {
+		select {
+		case <-p.closed:
+			return
+		case <-proc.exited:
+		}
+		proc.stop()
+		if time.Since(proc.started) >= p.cfg.MaxRestartDelay {
+			delay = p.cfg.RestartDelay
+		}
+
+		for {
+			p.log.Warn("Backend plugin exited, restarting", "plugin", p.Info.Name, "delay", delay)
+			select {
+			case <-p.closed:
+				return
+			case <-time.After(delay):
+			}
+			delay = min(2*delay, p.cfg.MaxRestartDelay)
+
+			next, info, err := startPluginProcess(p.Path, p.cfg)
+			if err != nil {
+				p.log.Warn("Failed to restart backend plugin", "plugin", p.Info.Name, "error", err)
+				continue
+			}
+			if info.Name != p.Info.Name {
+				p.log.Warn("Restarted backend plugin changed its name", "plugin", p.Info.Name, "name", info.Name)
+			}
+			proc = next
+			break
+		}
+
+		p.mu.Lock()
+		p.proc = proc
+		p.restarts++
+		var reconnect []*PluginBackend
+		for _, b := range p.backends {
+			if b.wantConnected() {
+				reconnect = append(reconnect, b)
+			}
+		}
+		p.mu.Unlock()
+		p.log.Info("Backend plugin restarted", "plugin", p.Info.Name)
+
+		for _, b := range reconnect {
+			if err := b.connect(context.Background()); err != nil {
+				p.log.Warn("Failed to reconnect plugin chain", "plugin", p.Info.Name, "chain", b.chain, "error", err)
+			}
+		}
+	}
+}
+
+// process returns the current plugin process.
+func (p *Plugin) process() *pluginProcess {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.proc
+}
+
+// Backend returns the plugin's backend for a chain it serves.
+func (p *Plugin) Backend(symbol string) Backend {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	b, ok := p.backends[symbol]
+	if !ok {
+		b = &PluginBackend{plugin: p, chain: symbol}
+		p.backends[symbol] = b
+	}
+	return b
+}
+
+// Close stops supervising the plugin and stops its process.
+func (p *Plugin) Close() error {
+	p.closeOnce.Do(func() {
+		close(p.closed)
+		<-p.done
+		p.process().stop()
+	})
+	return nil
+}
+
+// Exited reports whether the plugin process is gone and not restarted
+// yet.
+func (p *Plugin) Exited() bool {
+	return p.process().hasExited()
+}
+
+// Restarts returns how often the plugin was restarted.
+func (p *Plugin) Restarts() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.restarts
+}
+
+// pluginCall calls a method of the current plugin process.
+func pluginCall[T any](ctx context.Context, p *Plugin, fn func(context.Context, pluginpb.BackendClient) (T, error)) (T, error) {
+	return callProcess(ctx, p.process(), p.cfg.CallTimeout, fn)
+}
+
+// PluginBackend is a chain served by a plugin.
+type PluginBackend struct {
+	plugin *Plugin
+	chain  string
+
+	mu        sync.Mutex
+	connected bool
+}
+
+func (b *PluginBackend) request() *pluginpb.Request {
+	return &pluginpb.Request{Chain: b.chain}
+}
+
+// Type returns TypePlugin.
+func (b *PluginBackend) Type() Type { return TypePlugin }
+
+// Plugin returns the plugin serving the chain.
+func (b *PluginBackend) Plugin() *Plugin { return b.plugin }
+
+// Connect asks the plugin to connect the chain. The chain is connected
+// again whenever the plugin restarts, until Close.
+func (b *PluginBackend) Connect(ctx context.Context) error {
+	if err := b.connect(ctx); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.connected = true
+	b.mu.Unlock()
+	return nil
+}
+
+func (b *PluginBackend) connect(ctx context.Context) error {
+	_, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.ConnectResponse, error) {
+		return c.Connect(ctx, b.request())
+	})
+	return err
+}
+
+func (b *PluginBackend) wantConnected() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.connected
+}
+
+// Close marks the chain closed; the process is stopped by Plugin.Close.
+func (b *PluginBackend) Close() error {
+	b.mu.Lock()
+	b.connected = false
+	b.mu.Unlock()
+	return nil
+}
+
+// IsConnected returns true if connected and the plugin is running.
+func (b *PluginBackend) IsConnected() bool {
+	return b.wantConnected() && !b.plugin.Exited()
+}
+
+// GetAddressInfo returns address information.
+func (b *PluginBackend) GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error) {
+	req := b.request()
+	req.Address = address
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.AddressInfo, error) {
+		return c.GetAddressInfo(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return addressInfoFromPB(reply), nil
+}
+
+// GetAddressUTXOs returns unspent outputs for an address.
+func (b *PluginBackend) GetAddressUTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	req := b.request()
+	req.Address = address
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.UTXOList, error) {
+		return c.GetAddressUTXOs(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return utxosFromPB(reply.GetUtxos()), nil
+}
+
+// GetAddressTxs returns transactions for an address.
+func (b *PluginBackend) GetAddressTxs(ctx context.Context, address string, lastSeenTxID string) ([]Transaction, error) {
+	req := b.request()
+	req.Address = address
+	req.LastSeenTxid = lastSeenTxID
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.TransactionList, error) {
+		return c.GetAddressTxs(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	txs := make([]Transaction, 0, len(reply.GetTxs()))
+	for _, tx := range reply.GetTxs() {
+		txs = append(txs, *transactionFromPB(tx))
+	}
+	return txs, nil
+}
+
+// GetTransaction returns transaction details.
+func (b *PluginBackend) GetTransaction(ctx context.Context, txID string) (*Transaction, error) {
+	req := b.request()
+	req.Txid = txID
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.Transaction, error) {
+		return c.GetTransaction(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactionFromPB(reply), nil
+}
+
+// GetRawTransaction returns raw transaction bytes.
+func (b *PluginBackend) GetRawTransaction(ctx context.Context, txID string) ([]byte, error) {
+	req := b.request()
+	req.Txid = txID
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.RawTransaction, error) {
+		return c.GetRawTransaction(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return reply.GetRaw(), nil
+}
+
+// BroadcastTransaction broadcasts a signed transaction.
+func (b *PluginBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	req := b.request()
+	req.RawTxHex = rawTxHex
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.BroadcastResponse, error) {
+		return c.BroadcastTransaction(ctx, req)
+	})
+	if err != nil {
+		return "", err
+	}
+	return reply.GetTxid(), nil
+}
+
+// GetBlockHeight returns the current block height.
+func (b *PluginBackend) GetBlockHeight(ctx context.Context) (int64, error) {
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.BlockHeight, error) {
+		return c.GetBlockHeight(ctx, b.request())
+	})
+	if err != nil {
+		return 0, err
+	}
+	return reply.GetHeight(), nil
+}
+
+// GetBlockHeader returns block header by hash or height.
+func (b *PluginBackend) GetBlockHeader(ctx context.Context, hashOrHeight string) (*BlockHeader, error) {
+	req := b.request()
+	req.HashOrHeight = hashOrHeight
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.BlockHeader, error) {
+		return c.GetBlockHeader(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return blockHeaderFromPB(reply), nil
+}
+
+// GetFeeEstimates returns fee estimates.
+func (b *PluginBackend) GetFeeEstimates(ctx context.Context) (*FeeEstimate, error) {
+	reply, err := pluginCall(ctx, b.plugin, func(ctx context.Context, c pluginpb.BackendClient) (*pluginpb.FeeEstimate, error) {
+		return c.GetFeeEstimates(ctx, b.request())
+	})
+	if err != nil {
+		return nil, err
+	}
+	return feeEstimateFromPB(reply), nil
+}
+
+// LoadPlugins discovers the plugins in cfg.Dir and registers their chains,
+// replacing built-in defaults. Chains in keep (those with an explicit
+// backend config) keep their backend. It returns the started plugins, also
+// when some failed to start (reported in the error).
+func (r *Registry) LoadPlugins(cfg PluginConfig, keep map[string]*Config) ([]*Plugin, error) {
+	plugins, err := DiscoverPlugins(cfg)
+	for _, p := range plugins {
+		chains := append([]string(nil), p.Info.Chains...)
+		sort.Strings(chains)
+		for _, symbol := range chains {
+			if keep[symbol] != nil {
+				continue
+			}
+			if old, ok := r.backends[symbol]; ok && old.Type() == TypePlugin {
+				continue // the first plugin found serves the chain
+			}
+			r.Register(symbol, p.Backend(symbol))
+		}
+	}
+	r.plugins = append(r.plugins, plugins...)
+	return plugins, err
+}
+
+// Plugins returns the plugins loaded into the registry.
+func (r *Registry) Plugins() []*Plugin {
+	return r.plugins
+}
+
+// ServePlugin serves backends as a plugin on the socket klingond passes in
+// PluginSocketEnv, until klingond closes the plugin's stdin. backends maps
+// chain symbols to the backends serving them.
+func ServePlugin(name, version string, backends map[string]Backend) error {
+	socket := os.Getenv(PluginSocketEnv)
+	if socket == "" {
+		return fmt.Errorf("%s is not set; plugins are started by klingond", PluginSocketEnv)
+	}
+	lis, err := net.Listen("unix", socket)
+	if err != nil {
+		return err
+	}
+	return servePlugin(lis, os.Stdin, name, version, backends)
+}
+
+func servePlugin(lis net.Listener, stdin io.Reader, name, version string, backends map[string]Backend) error {
+	server := grpc.NewServer()
+	pluginpb.RegisterBackendServer(server, &pluginServer{name: name, version: version, backends: backends})
+	go func() {
+		io.Copy(io.Discard, stdin)
+		server.Stop()
+	}()
+	return server.Serve(lis)
+}
+
+// pluginServer is the plugin side of the protocol.
+type pluginServer struct {
+	pluginpb.UnimplementedBackendServer
+
+	name     string
+	version  string
+	backends map[string]Backend
+}
+
+func (s *pluginServer) backend(req *pluginpb.Request) (Backend, error) {
+	b, ok := s.backends[req.GetChain()]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "chain %s not served", req.GetChain())
+	}
+	return b, nil
+}
+
+func (s *pluginServer) Info(ctx context.Context, _ *pluginpb.InfoRequest) (*pluginpb.InfoResponse, error) {
+	reply := &pluginpb.InfoResponse{Name: s.name, Version: s.version, Protocol: PluginProtocolVersion}
+	for symbol := range s.backends {
+		reply.Chains = append(reply.Chains, symbol)
+	}
+	sort.Strings(reply.Chains)
+	return reply, nil
+}
+
+func (s *pluginServer) Connect(ctx context.Context, req *pluginpb.Request) (*pluginpb.ConnectResponse, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Connect(ctx); err != nil {
+		return nil, pluginStatus(err)
+	}
+	return &pluginpb.ConnectResponse{}, nil
+}
+
+func (s *pluginServer) GetAddressInfo(ctx context.Context, req *pluginpb.Request) (*pluginpb.AddressInfo, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	info, err := b.GetAddressInfo(ctx, req.GetAddress())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return addressInfoToPB(info), nil
+}
+
+func (s *pluginServer) GetAddressUTXOs(ctx context.Context, req *pluginpb.Request) (*pluginpb.UTXOList, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	utxos, err := b.GetAddressUTXOs(ctx, req.GetAddress())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return &pluginpb.UTXOList{Utxos: utxosToPB(utxos)}, nil
+}
+
+func (s *pluginServer) GetAddressTxs(ctx context.Context, req *pluginpb.Request) (*pluginpb.TransactionList, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	txs, err := b.GetAddressTxs(ctx, req.GetAddress(), req.GetLastSeenTxid())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	reply := &pluginpb.TransactionList{}
+	for i := range txs {
+		reply.Txs = append(reply.Txs, transactionToPB(&txs[i]))
+	}
+	return reply, nil
+}
+
+func (s *pluginServer) GetTransaction(ctx context.Context, req *pluginpb.Request) (*pluginpb.Transaction, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	tx, err := b.GetTransaction(ctx, req.GetTxid())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return transactionToPB(tx), nil
+}
+
+func (s *pluginServer) GetRawTransaction(ctx context.Context, req *pluginpb.Request) (*pluginpb.RawTransaction, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := b.GetRawTransaction(ctx, req.GetTxid())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return &pluginpb.RawTransaction{Raw: raw}, nil
+}
+
+func (s *pluginServer) BroadcastTransaction(ctx context.Context, req *pluginpb.Request) (*pluginpb.BroadcastResponse, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	txid, err := b.BroadcastTransaction(ctx, req.GetRawTxHex())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return &pluginpb.BroadcastResponse{Txid: txid}, nil
+}
+
+func (s *pluginServer) GetBlockHeight(ctx context.Context, req *pluginpb.Request) (*pluginpb.BlockHeight, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	height, err := b.GetBlockHeight(ctx)
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return &pluginpb.BlockHeight{Height: height}, nil
+}
+
+func (s *pluginServer) GetBlockHeader(ctx context.Context, req *pluginpb.Request) (*pluginpb.BlockHeader, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	header, err := b.GetBlockHeader(ctx, req.GetHashOrHeight())
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return blockHeaderToPB(header), nil
+}
+
+func (s *pluginServer) GetFeeEstimates(ctx context.Context, req *pluginpb.Request) (*pluginpb.FeeEstimate, error) {
+	b, err := s.backend(req)
+	if err != nil {
+		return nil, err
+	}
+	fees, err := b.GetFeeEstimates(ctx)
+	if err != nil {
+		return nil, pluginStatus(err)
+	}
+	return feeEstimateToPB(fees), nil
+}
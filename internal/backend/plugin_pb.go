@@ -0,0 +1,203 @@
+package backend
+
+import "github.com/Klingon-tech/klingdex/internal/backend/pluginpb"
+
+// Conversions between the backend types and their plugin protocol messages.
+
+func addressInfoToPB(info *AddressInfo) *pluginpb.AddressInfo {
+	return &pluginpb.AddressInfo{
+		Address:        info.Address,
+		TxCount:        info.TxCount,
+		FundedTxoCount: info.FundedTxCount,
+		SpentTxoCount:  info.SpentTxCount,
+		FundedTxoSum:   info.FundedSum,
+		SpentTxoSum:    info.SpentSum,
+		Balance:        info.Balance,
+		MempoolBalance: info.MempoolBalance,
+	}
+}
+
+func addressInfoFromPB(info *pluginpb.AddressInfo) *AddressInfo {
+	return &AddressInfo{
+		Address:        info.GetAddress(),
+		TxCount:        info.GetTxCount(),
+		FundedTxCount:  info.GetFundedTxoCount(),
+		SpentTxCount:   info.GetSpentTxoCount(),
+		FundedSum:      info.GetFundedTxoSum(),
+		SpentSum:       info.GetSpentTxoSum(),
+		Balance:        info.GetBalance(),
+		MempoolBalance: info.GetMempoolBalance(),
+	}
+}
+
+func utxosToPB(utxos []UTXO) []*pluginpb.UTXO {
+	out := make([]*pluginpb.UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		out = append(out, &pluginpb.UTXO{
+			Txid:          u.TxID,
+			Vout:          u.Vout,
+			Value:         u.Amount,
+			Scriptpubkey:  u.ScriptPubKey,
+			Confirmations: u.Confirmations,
+			BlockHeight:   u.BlockHeight,
+		})
+	}
+	return out
+}
+
+func utxosFromPB(utxos []*pluginpb.UTXO) []UTXO {
+	out := make([]UTXO, 0, len(utxos))
+	for _, u := range utxos {
+		out = append(out, UTXO{
+			TxID:          u.GetTxid(),
+			Vout:          u.GetVout(),
+			Amount:        u.GetValue(),
+			ScriptPubKey:  u.GetScriptpubkey(),
+			Confirmations: u.GetConfirmations(),
+			BlockHeight:   u.GetBlockHeight(),
+		})
+	}
+	return out
+}
+
+func txOutputToPB(o *TxOutput) *pluginpb.TxOutput {
+	if o == nil {
+		return nil
+	}
+	return &pluginpb.TxOutput{
+		Scriptpubkey:        o.ScriptPubKey,
+		ScriptpubkeyAsm:     o.ScriptPubKeyAsm,
+		ScriptpubkeyType:    o.ScriptPubKeyType,
+		ScriptpubkeyAddress: o.ScriptPubKeyAddr,
+		Value:               o.Value,
+	}
+}
+
+func txOutputFromPB(o *pluginpb.TxOutput) *TxOutput {
+	if o == nil {
+		return nil
+	}
+	return &TxOutput{
+		ScriptPubKey:     o.GetScriptpubkey(),
+		ScriptPubKeyAsm:  o.GetScriptpubkeyAsm(),
+		ScriptPubKeyType: o.GetScriptpubkeyType(),
+		ScriptPubKeyAddr: o.GetScriptpubkeyAddress(),
+		Value:            o.GetValue(),
+	}
+}
+
+func transactionToPB(tx *Transaction) *pluginpb.Transaction {
+	out := &pluginpb.Transaction{
+		Txid:          tx.TxID,
+		Version:       tx.Version,
+		Size:          tx.Size,
+		Vsize:         tx.VSize,
+		Weight:        tx.Weight,
+		Locktime:      tx.LockTime,
+		Fee:           tx.Fee,
+		Confirmed:     tx.Confirmed,
+		BlockHash:     tx.BlockHash,
+		BlockHeight:   tx.BlockHeight,
+		BlockTime:     tx.BlockTime,
+		Confirmations: tx.Confirmations,
+		Hex:           tx.Hex,
+	}
+	for _, in := range tx.Inputs {
+		out.Vin = append(out.Vin, &pluginpb.TxInput{
+			Txid:         in.TxID,
+			Vout:         in.Vout,
+			Scriptsig:    in.ScriptSig,
+			ScriptsigAsm: in.ScriptSigAsm,
+			Witness:      in.Witness,
+			Sequence:     in.Sequence,
+			Prevout:      txOutputToPB(in.PrevOut),
+		})
+	}
+	for i := range tx.Outputs {
+		out.Vout = append(out.Vout, txOutputToPB(&tx.Outputs[i]))
+	}
+	return out
+}
+
+func transactionFromPB(tx *pluginpb.Transaction) *Transaction {
+	out := &Transaction{
+		TxID:          tx.GetTxid(),
+		Version:       tx.GetVersion(),
+		Size:          tx.GetSize(),
+		VSize:         tx.GetVsize(),
+		Weight:        tx.GetWeight(),
+		LockTime:      tx.GetLocktime(),
+		Fee:           tx.GetFee(),
+		Confirmed:     tx.GetConfirmed(),
+		BlockHash:     tx.GetBlockHash(),
+		BlockHeight:   tx.GetBlockHeight(),
+		BlockTime:     tx.GetBlockTime(),
+		Confirmations: tx.GetConfirmations(),
+		Hex:           tx.GetHex(),
+	}
+	for _, in := range tx.GetVin() {
+		out.Inputs = append(out.Inputs, TxInput{
+			TxID:         in.GetTxid(),
+			Vout:         in.GetVout(),
+			ScriptSig:    in.GetScriptsig(),
+			ScriptSigAsm: in.GetScriptsigAsm(),
+			Witness:      in.GetWitness(),
+			Sequence:     in.GetSequence(),
+			PrevOut:      txOutputFromPB(in.GetPrevout()),
+		})
+	}
+	for _, o := range tx.GetVout() {
+		out.Outputs = append(out.Outputs, *txOutputFromPB(o))
+	}
+	return out
+}
+
+func blockHeaderToPB(h *BlockHeader) *pluginpb.BlockHeader {
+	return &pluginpb.BlockHeader{
+		Hash:              h.Hash,
+		Height:            h.Height,
+		Version:           h.Version,
+		Previousblockhash: h.PreviousHash,
+		MerkleRoot:        h.MerkleRoot,
+		Timestamp:         h.Timestamp,
+		Bits:              h.Bits,
+		Nonce:             h.Nonce,
+		Difficulty:        h.Difficulty,
+		TxCount:           h.TxCount,
+	}
+}
+
+func blockHeaderFromPB(h *pluginpb.BlockHeader) *BlockHeader {
+	return &BlockHeader{
+		Hash:         h.GetHash(),
+		Height:       h.GetHeight(),
+		Version:      h.GetVersion(),
+		PreviousHash: h.GetPreviousblockhash(),
+		MerkleRoot:   h.GetMerkleRoot(),
+		Timestamp:    h.GetTimestamp(),
+		Bits:         h.GetBits(),
+		Nonce:        h.GetNonce(),
+		Difficulty:   h.GetDifficulty(),
+		TxCount:      h.GetTxCount(),
+	}
+}
+
+func feeEstimateToPB(f *FeeEstimate) *pluginpb.FeeEstimate {
+	return &pluginpb.FeeEstimate{
+		FastestFee:  f.FastestFee,
+		HalfHourFee: f.HalfHourFee,
+		HourFee:     f.HourFee,
+		EconomyFee:  f.EconomyFee,
+		MinimumFee:  f.MinimumFee,
+	}
+}
+
+func feeEstimateFromPB(f *pluginpb.FeeEstimate) *FeeEstimate {
+	return &FeeEstimate{
+		FastestFee:  f.GetFastestFee(),
+		HalfHourFee: f.GetHalfHourFee(),
+		HourFee:     f.GetHourFee(),
+		EconomyFee:  f.GetEconomyFee(),
+		MinimumFee:  f.GetMinimumFee(),
+	}
+}
@@ -0,0 +1,196 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeChain is the backend the test plugin serves. Methods it doesn't
+// override panic.
+type fakeChain struct {
+	Backend
+}
+
+func (f *fakeChain) Connect(ctx context.Context) error { return nil }
+
+func (f *fakeChain) GetBlockHeight(ctx context.Context) (int64, error) { return 4242, nil }
+
+func (f *fakeChain) GetTransaction(ctx context.Context, txID string) (*Transaction, error) {
+	if txID != "aa" {
+		return nil, ErrTxNotFound
+	}
+	return &Transaction{TxID: txID, Confirmations: 3, Outputs: []TxOutput{{Value: 5000}}}, nil
+}
+
+func (f *fakeChain) GetAddressUTXOs(ctx context.Context, address string) ([]UTXO, error) {
+	return []UTXO{{TxID: "aa", Vout: 1, Amount: 5000}}, nil
+}
+
+func (f *fakeChain) GetRawTransaction(ctx context.Context, txID string) ([]byte, error) {
+	return []byte{0x01, 0x02}, nil
+}
+
+func (f *fakeChain) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	if rawTxHex == "" {
+		return "", ErrInvalidTx
+	}
+	return "bb", nil
+}
+
+// TestPluginHelperProcess is the plugin process the tests start.
+func TestPluginHelperProcess(t *testing.T) {
+	if os.Getenv("KLINGDEX_TEST_BACKEND_PLUGIN") != "1" {
+		return
+	}
+	ServePlugin("fake", "1.0", map[string]Backend{"PRIV": &fakeChain{}, "BTC": &fakeChain{}})
+	os.Exit(0)
+}
+
+// writePluginDir returns a plugin dir holding a script that runs the test
+// binary as a plugin, a script that fails, and a file that isn't executable.
+func writePluginDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	write := func(name, content string, mode os.FileMode) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), mode); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("fake", "#!/bin/sh\nKLINGDEX_TEST_BACKEND_PLUGIN=1 exec \""+os.Args[0]+"\" -test.run='^TestPluginHelperProcess$'\n", 0755)
+	write("broken", "#!/bin/sh\nexit 1\n", 0755)
+	write("README", "not a plugin", 0644)
+	return dir
+}
+
+func testPluginConfig(dir string) PluginConfig {
+	cfg := DefaultPluginConfig()
+	cfg.Dir = dir
+	cfg.StartTimeout = 5 * time.Second
+	cfg.RestartDelay = 100 * time.Millisecond
+	return cfg
+}
+
+func TestRegistryLoadPlugins(t *testing.T) {
+	cfg := testPluginConfig(writePluginDir(t))
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	r := NewRegistry()
+	builtin := NewMempoolBackend("https://mempool.space/api")
+	r.Register("BTC", builtin)
+	plugins, err := r.LoadPlugins(cfg, map[string]*Config{"BTC": {Type: TypeMempool}})
+	defer r.CloseAll()
+	if err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("LoadPlugins() error = %v, want the broken plugin reported", err)
+	}
+	if len(plugins) != 1 || plugins[0].Info.Name != "fake" || len(r.Plugins()) != 1 {
+		t.Fatalf("plugins = %+v, want the fake one", plugins)
+	}
+
+	// The explicitly configured chain keeps its backend
+	if b, _ := r.Get("BTC"); b != builtin {
+		t.Error("plugin replaced a configured backend")
+	}
+	b, ok := r.Get("PRIV")
+	if !ok || b.Type() != TypePlugin {
+		t.Fatalf("PRIV backend = %v", b)
+	}
+
+	ctx := context.Background()
+	if err := b.Connect(ctx); err != nil || !b.IsConnected() {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if h, err := b.GetBlockHeight(ctx); err != nil || h != 4242 {
+		t.Errorf("GetBlockHeight() = %d, %v", h, err)
+	}
+	if tx, err := b.GetTransaction(ctx, "aa"); err != nil || tx.Confirmations != 3 || tx.Outputs[0].Value != 5000 {
+		t.Errorf("GetTransaction() = %+v, %v", tx, err)
+	}
+	if _, err := b.GetTransaction(ctx, "cc"); !errors.Is(err, ErrTxNotFound) {
+		t.Errorf("missing tx error = %v, want ErrTxNotFound", err)
+	}
+	if utxos, err := b.GetAddressUTXOs(ctx, "addr"); err != nil || len(utxos) != 1 || utxos[0].Amount != 5000 {
+		t.Errorf("GetAddressUTXOs() = %+v, %v", utxos, err)
+	}
+	if raw, err := b.GetRawTransaction(ctx, "aa"); err != nil || len(raw) != 2 {
+		t.Errorf("GetRawTransaction() = %x, %v", raw, err)
+	}
+	if _, err := b.BroadcastTransaction(ctx, ""); !errors.Is(err, ErrInvalidTx) {
+		t.Errorf("broadcast error = %v, want ErrInvalidTx", err)
+	}
+
+	// A crashed plugin fails its calls instead of hanging them
+	proc := plugins[0].process()
+	proc.cmd.Process.Kill()
+	if _, err := b.GetBlockHeight(ctx); !errors.Is(err, ErrPluginExited) {
+		t.Errorf("call after exit error = %v, want ErrPluginExited", err)
+	}
+	<-proc.exited
+	if b.IsConnected() {
+		t.Error("backend of an exited plugin is connected")
+	}
+
+	// It is restarted and its connected chain connected again
+	deadline := time.Now().Add(5 * time.Second)
+	for plugins[0].Restarts() == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if plugins[0].Restarts() != 1 || !b.IsConnected() {
+		t.Fatalf("Restarts() = %d, IsConnected() = %v after the plugin exited", plugins[0].Restarts(), b.IsConnected())
+	}
+	if h, err := b.GetBlockHeight(ctx); err != nil || h != 4242 {
+		t.Errorf("GetBlockHeight() after restart = %d, %v", h, err)
+	}
+}
+
+func TestPluginConfigValidate(t *testing.T) {
+	cfg := DefaultPluginConfig()
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("disabled config error = %v", err)
+	}
+	cfg.Dir = filepath.Join(t.TempDir(), "missing")
+	if err := cfg.Validate(); err == nil {
+		t.Error("accepted a missing plugin dir")
+	}
+	cfg.Dir = t.TempDir()
+	cfg.CallTimeout = 0
+	if err := cfg.Validate(); err == nil {
+		t.Error("accepted a zero call timeout")
+	}
+	cfg = DefaultPluginConfig()
+	cfg.Dir = t.TempDir()
+	cfg.MaxRestartDelay = cfg.RestartDelay / 2
+	if err := cfg.Validate(); err == nil {
+		t.Error("accepted max_restart_delay below restart_delay")
+	}
+}
+
+func TestPluginErrorRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want error
+	}{
+		{"sentinel", ErrTxNotFound, ErrTxNotFound},
+		{"wrapped sentinel", fmt.Errorf("%w: mempool conflict", ErrInvalidTx), ErrInvalidTx},
+		{"other", errors.New("disk full"), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pluginError(pluginStatus(tt.err))
+			if got.Error() != tt.err.Error() {
+				t.Errorf("message = %q, want %q", got, tt.err)
+			}
+			if tt.want != nil && !errors.Is(got, tt.want) {
+				t.Errorf("error %v does not match %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+)
+
+// Replay serves the exchanges of a capture bundle back to backends, for
+// reproducing explorer-specific bugs in tests. Point an HTTP backend at an
+// httptest.Server running it (keeping the captured base path, e.g.
+// srv.URL+"/api"), or an Electrum backend at ServeElectrum's listener.
+//
+// Requests match on method, path, query and body, ignoring JSON-RPC ids.
+// Repeated requests get the recorded responses in order, then the last one.
+type Replay struct {
+	mu      sync.Mutex
+	entries map[string][]CaptureEntry
+	misses  []string
+}
+
+// NewReplay returns a Replay of a bundle's exchanges.
+func NewReplay(b *CaptureBundle) *Replay {
+	r := &Replay{entries: make(map[string][]CaptureEntry)}
+	for _, e := range b.Entries {
+		var key string
+		switch e.Kind {
+		case CaptureKindHTTP:
+			u, err := url.Parse(e.URL)
+			if err != nil {
+				continue
+			}
+			key = replayKey(e.Method+" "+u.RequestURI(), e.RequestBody, e.RequestText)
+		case CaptureKindElectrum:
+			key = replayKey(CaptureKindElectrum, e.RequestBody, e.RequestText)
+		default:
+			continue
+		}
+		r.entries[key] = append(r.entries[key], e)
+	}
+	return r
+}
+
+// Misses returns the requests that had no recorded response.
+func (r *Replay) Misses() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.misses...)
+}
+
+// next returns the recorded exchange for a request key.
+func (r *Replay) next(key string) (CaptureEntry, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	queue := r.entries[key]
+	if len(queue) == 0 {
+		r.misses = append(r.misses, key)
+		return CaptureEntry{}, false
+	}
+	if len(queue) > 1 {
+		r.entries[key] = queue[1:]
+	}
+	return queue[0], true
+}
+
+// ServeHTTP implements http.Handler for HTTP backends.
+func (r *Replay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	// Redact the request the way it was captured
+	var in CaptureEntry
+	redactExchange(&in, body, nil)
+	u := *req.URL
+	redactedURL, _ := url.Parse(redactURL(&u))
+
+	e, ok := r.next(replayKey(req.Method+" "+redactedURL.RequestURI(), in.RequestBody, in.RequestText))
+	if !ok {
+		http.Error(w, "replay: no recorded response", http.StatusNotImplemented)
+		return
+	}
+	if e.Status == 0 {
+		// Transport error when captured
+		http.Error(w, "replay: "+e.Error, http.StatusBadGateway)
+		return
+	}
+
+	for k, v := range e.ResponseHeader {
+		if v != redacted && k != "Content-Length" {
+			w.Header().Set(k, v)
+		}
+	}
+	w.WriteHeader(e.Status)
+	w.Write(replayResponse(e, in.RequestBody))
+}
+
+// ServeElectrum answers Electrum requests on ln until it is closed.
+func (r *Replay) ServeElectrum(ln net.Listener) {
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		go r.serveElectrumConn(conn)
+	}
+}
+
+// serveElectrumConn answers newline-delimited requests on one connection.
+func (r *Replay) serveElectrumConn(conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			return
+		}
+		var in CaptureEntry
+		redactExchange(&in, bytes.TrimSpace(line), nil)
+
+		var resp []byte
+		if e, ok := r.next(replayKey(CaptureKindElectrum, in.RequestBody, in.RequestText)); ok {
+			resp = replayResponse(e, in.RequestBody)
+		} else {
+			resp, _ = json.Marshal(map[string]interface{}{
+				"id":    requestID(in.RequestBody),
+				"error": map[string]interface{}{"code": -32601, "message": "replay: no recorded response"},
+			})
+		}
+		if _, err := conn.Write(append(resp, '\n')); err != nil {
+			return
+		}
+	}
+}
+
+// replayResponse returns a recorded response body, with a JSON-RPC id
+// rewritten to the replayed request's.
+func replayResponse(e CaptureEntry, request json.RawMessage) []byte {
+	if e.ResponseBody == nil {
+		return []byte(e.ResponseText)
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(e.ResponseBody, &obj) != nil {
+		return e.ResponseBody
+	}
+	if _, ok := obj["id"]; !ok {
+		return e.ResponseBody
+	}
+	if id := requestID(request); id != nil {
+		obj["id"] = id
+	}
+	out, err := json.Marshal(obj)
+	if err != nil {
+		return e.ResponseBody
+	}
+	return out
+}
+
+// requestID returns the id of a JSON-RPC request.
+func requestID(request json.RawMessage) json.RawMessage {
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(request, &obj) != nil {
+		return nil
+	}
+	return obj["id"]
+}
+
+// replayKey identifies a request by prefix and body, ignoring JSON-RPC ids.
+func replayKey(prefix string, body json.RawMessage, text string) string {
+	if body == nil {
+		return prefix + " " + text
+	}
+	var obj map[string]json.RawMessage
+	if json.Unmarshal(body, &obj) == nil {
+		delete(obj, "id")
+		if b, err := json.Marshal(obj); err == nil {
+			return prefix + " " + string(b)
+		}
+	}
+	return prefix + " " + string(body)
+}
@@ -0,0 +1,384 @@
+// Package backup replicates the node's encrypted critical state so a disk
+// failure mid-swap doesn't strand funds.
+//
+// A backup is a consistent snapshot of the database (swap records, HTLC
+// secrets, the keys each swap used, wallet addresses and UTXOs; the wallet
+// seed lives in its own file and is never included), gzip-compressed and encrypted with a passphrase.
+// Backups are pushed to trusted peers over /klingon/backup/1.0.0 and/or to an
+// S3-compatible endpoint whenever the database changed, and can be restored
+// with klingond -restore-backup.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/s3"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// S3 object names. Each backup is also kept under its creation time.
+const (
+	s3LatestObject = "latest.kbak"
+	fileExtension  = ".kbak"
+)
+
+// triggerDelay coalesces bursts of swap events into one backup.
+const triggerDelay = 10 * time.Second
+
+// TargetStatus is the replication state for one destination.
+type TargetStatus struct {
+	Target        string `json:"target"` // Peer ID or "s3"
+	LastSuccessAt int64  `json:"last_success_at,omitempty"`
+	LastCreatedAt int64  `json:"last_created_at,omitempty"` // Backup held by the target
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// Status describes the replicator state.
+type Status struct {
+	Enabled       bool            `json:"enabled"`
+	LastRunAt     int64           `json:"last_run_at,omitempty"`
+	LastCreatedAt int64           `json:"last_created_at,omitempty"`
+	LastSize      int             `json:"last_size,omitempty"`
+	LastError     string          `json:"last_error,omitempty"`
+	Targets       []*TargetStatus `json:"targets"`
+	Held          []*HeldBackup   `json:"held"`
+}
+
+// RunResult is the outcome of a backup run.
+type RunResult struct {
+	CreatedAt int64           `json:"created_at,omitempty"`
+	Size      int             `json:"size,omitempty"`
+	Unchanged bool            `json:"unchanged"` // Database unchanged since the last backup
+	Targets   []*TargetStatus `json:"targets"`
+}
+
+// Replicator creates encrypted backups and sends them to the configured
+// targets. It also holds backups for peers listed in AcceptFrom.
+type Replicator struct {
+	cfg    node.BackupConfig
+	store  *storage.Storage
+	host   host.Host
+	nodeID string
+	dir    string
+	sealer *sealer
+	s3     *s3.Client
+	log    *logging.Logger
+
+	runMu         sync.Mutex
+	mu            sync.RWMutex
+	lastHash      [sha256.Size]byte
+	lastCreatedAt int64
+	status        Status
+	targets       map[string]*TargetStatus
+
+	trigger chan struct{}
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// New creates a replicator. dir holds snapshots in progress and backups
+// held for peers. h may be nil when peer replication is not used.
+func New(cfg node.BackupConfig, store *storage.Storage, h host.Host, dir string) (*Replicator, error) {
+	defaults := node.DefaultConfig().Backup
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.MaxSize <= 0 {
+		cfg.MaxSize = defaults.MaxSize
+	}
+	if dir == "" {
+		return nil, fmt.Errorf("backup dir is required")
+	}
+	for _, id := range append(append([]string{}, cfg.Peers...), cfg.AcceptFrom...) {
+		if _, err := peer.Decode(id); err != nil {
+			return nil, fmt.Errorf("invalid backup peer ID %q: %w", id, err)
+		}
+	}
+	if (len(cfg.Peers) > 0 || len(cfg.AcceptFrom) > 0) && h == nil {
+		return nil, fmt.Errorf("backup peers require a P2P host")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r := &Replicator{
+		cfg:     cfg,
+		store:   store,
+		host:    h,
+		dir:     dir,
+		log:     logging.GetDefault().Component("backup"),
+		targets: make(map[string]*TargetStatus),
+		trigger: make(chan struct{}, 1),
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+	if h != nil {
+		r.nodeID = h.ID().String()
+	}
+
+	if cfg.Enabled {
+		if len(cfg.Peers) == 0 && !cfg.S3.Enabled() {
+			return nil, fmt.Errorf("backup enabled without peers or s3 target")
+		}
+		if err := wallet.ValidatePassword(cfg.Passphrase); err != nil {
+			return nil, fmt.Errorf("invalid backup passphrase: %w", err)
+		}
+		sealer, err := newSealer(cfg.Passphrase)
+		if err != nil {
+			return nil, err
+		}
+		r.sealer = sealer
+		if cfg.S3.Enabled() {
+			r.s3 = s3.New(cfg.S3)
+		}
+		for _, id := range cfg.Peers {
+			r.targets[id] = &TargetStatus{Target: id}
+		}
+		if r.s3 != nil {
+			r.targets["s3"] = &TargetStatus{Target: "s3"}
+		}
+	}
+	return r, nil
+}
+
+// Start serves backups held for peers and, when enabled, replicates every
+// interval and after Trigger.
+func (r *Replicator) Start() {
+	if r.host != nil && len(r.cfg.AcceptFrom) > 0 {
+		r.host.SetStreamHandler(protocol.ID(Protocol), r.handleStream)
+	}
+	if !r.cfg.Enabled {
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-r.trigger:
+				// Let related state changes land in the same backup
+				select {
+				case <-r.ctx.Done():
+					return
+				case <-time.After(triggerDelay):
+				}
+			}
+			if _, err := r.Run(r.ctx, false); err != nil {
+				r.log.Warn("Backup failed", "error", err)
+			}
+		}
+	}()
+	r.log.Info("Backup replication started", "peers", len(r.cfg.Peers), "s3", r.s3 != nil, "interval", r.cfg.Interval)
+}
+
+// Stop stops replication and serving.
+func (r *Replicator) Stop() {
+	if r.host != nil && len(r.cfg.AcceptFrom) > 0 {
+		r.host.RemoveStreamHandler(protocol.ID(Protocol))
+	}
+	r.cancel()
+	r.wg.Wait()
+}
+
+// Trigger requests a backup soon, e.g. after a swap state change.
+func (r *Replicator) Trigger() {
+	select {
+	case r.trigger <- struct{}{}:
+	default:
+	}
+}
+
+// Run creates a backup and sends it to every target. Unless force is set, a
+// database unchanged since the last successful backup is not re-sent.
+func (r *Replicator) Run(ctx context.Context, force bool) (*RunResult, error) {
+	if r.sealer == nil {
+		return nil, fmt.Errorf("backup replication is not enabled")
+	}
+
+	r.runMu.Lock()
+	defer r.runMu.Unlock()
+
+	result, err := r.run(ctx, force)
+
+	r.mu.Lock()
+	r.status.LastRunAt = time.Now().Unix()
+	r.status.LastError = ""
+	if err != nil {
+		r.status.LastError = err.Error()
+	}
+	r.mu.Unlock()
+	return result, err
+}
+
+func (r *Replicator) run(ctx context.Context, force bool) (*RunResult, error) {
+	plaintext, err := r.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	defer wallet.SecureClear(plaintext)
+
+	hash := sha256.Sum256(plaintext)
+	r.mu.RLock()
+	unchanged := hash == r.lastHash && r.allTargetsCurrent()
+	lastCreatedAt := r.lastCreatedAt
+	r.mu.RUnlock()
+	if unchanged && !force {
+		return &RunResult{Unchanged: true, Targets: r.targetStatuses()}, nil
+	}
+
+	createdAt := time.Now().UnixMilli()
+	if createdAt <= lastCreatedAt {
+		createdAt = lastCreatedAt + 1
+	}
+	env, err := r.sealer.seal(r.nodeID, createdAt, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		return nil, err
+	}
+
+	var failed int
+	for _, target := range r.targetNames() {
+		err := r.send(ctx, target, createdAt, data)
+		r.mu.Lock()
+		st := r.targets[target]
+		if err != nil {
+			failed++
+			st.LastError = err.Error()
+			r.log.Warn("Backup replication failed", "target", target, "error", err)
+		} else {
+			st.LastError = ""
+			st.LastSuccessAt = time.Now().Unix()
+			st.LastCreatedAt = createdAt
+		}
+		r.mu.Unlock()
+	}
+
+	r.mu.Lock()
+	r.lastHash = hash
+	r.lastCreatedAt = createdAt
+	r.status.LastCreatedAt = createdAt
+	r.status.LastSize = len(data)
+	r.mu.Unlock()
+
+	result := &RunResult{CreatedAt: createdAt, Size: len(data), Targets: r.targetStatuses()}
+	if failed == len(r.targets) {
+		return result, fmt.Errorf("backup not replicated to any target")
+	}
+	r.log.Info("Backup replicated", "created_at", createdAt, "size", len(data), "failed_targets", failed)
+	return result, nil
+}
+
+// snapshot returns the gzip-compressed database snapshot.
+func (r *Replicator) snapshot() ([]byte, error) {
+	if err := os.MkdirAll(r.dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create backup dir: %w", err)
+	}
+	path := filepath.Join(r.dir, "snapshot.db")
+	defer os.Remove(path)
+	if err := r.store.Snapshot(path); err != nil {
+		return nil, err
+	}
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	defer wallet.SecureClear(raw)
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(raw); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (r *Replicator) send(ctx context.Context, target string, createdAt int64, data []byte) error {
+	if target == "s3" {
+		if err := r.s3.Put(ctx, strconv.FormatInt(createdAt, 10)+fileExtension, data); err != nil {
+			return err
+		}
+		return r.s3.Put(ctx, s3LatestObject, data)
+	}
+	id, err := peer.Decode(target)
+	if err != nil {
+		return err
+	}
+	return r.pushToPeer(ctx, id, data)
+}
+
+// allTargetsCurrent returns true if every target holds the latest backup.
+// Callers hold r.mu.
+func (r *Replicator) allTargetsCurrent() bool {
+	for _, st := range r.targets {
+		if st.LastCreatedAt != r.lastCreatedAt || st.LastError != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func (r *Replicator) targetNames() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return sortedKeys(r.targets)
+}
+
+func (r *Replicator) targetStatuses() []*TargetStatus {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	statuses := make([]*TargetStatus, 0, len(r.targets))
+	for _, name := range sortedKeys(r.targets) {
+		st := *r.targets[name]
+		statuses = append(statuses, &st)
+	}
+	return statuses
+}
+
+// Status returns the replication state and the backups held for peers.
+func (r *Replicator) Status() *Status {
+	r.mu.RLock()
+	status := r.status
+	r.mu.RUnlock()
+
+	status.Enabled = r.sealer != nil
+	status.Targets = r.targetStatuses()
+	status.Held = r.heldBackups()
+	return &status
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
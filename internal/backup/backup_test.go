@@ -0,0 +1,282 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+const testPassphrase = "Correct-Horse-Battery-9"
+
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	order := &storage.Order{
+		ID: "order-1", PeerID: "us", Status: storage.OrderStatusOpen, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := store.RecordSwapKeyUsage(&storage.SwapKeyUsage{
+		TradeID: "trade-1", Purpose: storage.KeyPurposeSwapKey, Chain: "BTC", PubKey: "02aa",
+		Recovery: storage.KeyRecoveryBackup,
+	}); err != nil {
+		t.Fatalf("RecordSwapKeyUsage() error = %v", err)
+	}
+	return store
+}
+
+// fakeS3 keeps objects in memory.
+type fakeS3 struct {
+	mu      sync.Mutex
+	objects map[string][]byte
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{objects: make(map[string][]byte)}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			f.objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			data, ok := f.objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(data)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	s, err := newSealer(testPassphrase)
+	if err != nil {
+		t.Fatalf("newSealer() error = %v", err)
+	}
+	env, err := s.seal("node-1", 1000, []byte("critical state"))
+	if err != nil {
+		t.Fatalf("seal() error = %v", err)
+	}
+	data, err := env.Marshal()
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+
+	decoded, err := Unmarshal(data)
+	if err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	plaintext, err := Open(decoded, testPassphrase)
+	if err != nil {
+		t.Fatalf("Open() error = %v", err)
+	}
+	if string(plaintext) != "critical state" {
+		t.Errorf("plaintext = %q", plaintext)
+	}
+
+	if _, err := Open(decoded, "Wrong-Passphrase-123"); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("wrong passphrase: err = %v, want ErrDecrypt", err)
+	}
+
+	// Flipped ciphertext bit fails the checksum holders verify
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := Unmarshal(tampered); !errors.Is(err, ErrIntegrity) {
+		t.Errorf("tampered ciphertext: err = %v, want ErrIntegrity", err)
+	}
+
+	// Rewritten metadata fails authentication
+	decoded.Header.CreatedAt = 2000
+	if _, err := Open(decoded, testPassphrase); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("tampered header: err = %v, want ErrDecrypt", err)
+	}
+
+	if _, err := Unmarshal([]byte("not a backup")); !errors.Is(err, ErrInvalidEnvelope) {
+		t.Errorf("garbage: err = %v, want ErrInvalidEnvelope", err)
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	store := newTestStore(t)
+	dir := t.TempDir()
+
+	if _, err := New(node.BackupConfig{Enabled: true, Passphrase: testPassphrase}, store, nil, dir); err == nil {
+		t.Error("expected error without targets")
+	}
+	s3cfg := node.S3Config{Endpoint: "http://localhost", Bucket: "b"}
+	if _, err := New(node.BackupConfig{Enabled: true, Passphrase: "short", S3: s3cfg}, store, nil, dir); err == nil {
+		t.Error("expected error for weak passphrase")
+	}
+	if _, err := New(node.BackupConfig{Peers: []string{"not-a-peer"}}, store, nil, dir); err == nil {
+		t.Error("expected error for invalid peer ID")
+	}
+}
+
+func TestReplicateToS3AndRestore(t *testing.T) {
+	store := newTestStore(t)
+	objects, srv := newFakeS3(t)
+
+	r, err := New(node.BackupConfig{
+		Enabled:    true,
+		Passphrase: testPassphrase,
+		S3:         node.S3Config{Endpoint: srv.URL, Bucket: "backups", Prefix: "node-1"},
+	}, store, nil, t.TempDir())
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	result, err := r.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Unchanged || result.CreatedAt == 0 {
+		t.Fatalf("first run = %+v, want a new backup", result)
+	}
+	if _, ok := objects.objects["/backups/node-1/latest.kbak"]; !ok {
+		t.Fatalf("latest.kbak not uploaded, objects: %v", len(objects.objects))
+	}
+	if len(objects.objects) != 2 {
+		t.Errorf("uploaded %d objects, want latest and timestamped", len(objects.objects))
+	}
+
+	// Unchanged database is not re-sent unless forced
+	result, err = r.Run(context.Background(), false)
+	if err != nil || !result.Unchanged {
+		t.Errorf("second run = %+v, %v; want unchanged", result, err)
+	}
+	result, err = r.Run(context.Background(), true)
+	if err != nil || result.Unchanged {
+		t.Errorf("forced run = %+v, %v; want a new backup", result, err)
+	}
+
+	status := r.Status()
+	if !status.Enabled || len(status.Targets) != 1 || status.Targets[0].LastError != "" {
+		t.Errorf("status = %+v", status)
+	}
+
+	// Restore into a data dir with a different database
+	data, err := FetchLatestFromS3(context.Background(), node.S3Config{Endpoint: srv.URL, Bucket: "backups", Prefix: "node-1"})
+	if err != nil {
+		t.Fatalf("FetchLatestFromS3() error = %v", err)
+	}
+	dataDir := t.TempDir()
+	other, err := storage.New(&storage.Config{DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	other.Close()
+
+	restored, err := Restore(data, testPassphrase, dataDir)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if restored.PreviousDB == "" {
+		t.Error("existing database should be kept aside")
+	}
+	if _, err := os.Stat(restored.PreviousDB); err != nil {
+		t.Errorf("previous database missing: %v", err)
+	}
+
+	check, err := storage.New(&storage.Config{DataDir: dataDir})
+	if err != nil {
+		t.Fatalf("open restored storage: %v", err)
+	}
+	defer check.Close()
+	if _, err := check.GetOrder("order-1"); err != nil {
+		t.Errorf("restored database is missing order-1: %v", err)
+	}
+	if keys, err := check.GetSwapKeyUsage("trade-1"); err != nil || len(keys) != 1 {
+		t.Errorf("restored key usage = %v, %v; want the swap key of trade-1", keys, err)
+	}
+
+	if _, err := Restore(data, "Wrong-Passphrase-123", t.TempDir()); !errors.Is(err, ErrDecrypt) {
+		t.Errorf("Restore() with wrong passphrase: err = %v, want ErrDecrypt", err)
+	}
+}
+
+func TestReplicateToPeer(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	owner, holder := hosts[0], hosts[1]
+
+	holderDir := t.TempDir()
+	holderRep, err := New(node.BackupConfig{AcceptFrom: []string{owner.ID().String()}}, newTestStore(t), holder, holderDir)
+	if err != nil {
+		t.Fatalf("New(holder) error = %v", err)
+	}
+	holderRep.Start()
+	defer holderRep.Stop()
+
+	ownerRep, err := New(node.BackupConfig{
+		Enabled:    true,
+		Passphrase: testPassphrase,
+		Peers:      []string{holder.ID().String()},
+	}, newTestStore(t), owner, t.TempDir())
+	if err != nil {
+		t.Fatalf("New(owner) error = %v", err)
+	}
+
+	result, err := ownerRep.Run(context.Background(), false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	held := holderRep.Status().Held
+	if len(held) != 1 || held[0].PeerID != owner.ID().String() || held[0].CreatedAt != result.CreatedAt {
+		t.Fatalf("held = %+v, want owner's backup", held)
+	}
+
+	env, data, err := ownerRep.FetchFromPeer(context.Background(), holder.ID())
+	if err != nil {
+		t.Fatalf("FetchFromPeer() error = %v", err)
+	}
+	if env.Header.CreatedAt != result.CreatedAt {
+		t.Errorf("fetched CreatedAt = %d, want %d", env.Header.CreatedAt, result.CreatedAt)
+	}
+	path, err := SaveFetched(t.TempDir(), env, data)
+	if err != nil || !strings.HasSuffix(path, fileExtension) {
+		t.Errorf("SaveFetched() = %s, %v", path, err)
+	}
+
+	// The holder rejects replaying an older backup
+	if _, err := holderRep.storeHeld(owner.ID(), strings.NewReader(string(data)), int64(len(data))); err == nil {
+		t.Error("storeHeld() should reject a backup that is not newer")
+	}
+
+	// Peers outside accept_from are refused
+	if _, _, err := holderRep.FetchFromPeer(context.Background(), owner.ID()); err == nil {
+		t.Error("fetch from a node that holds nothing should fail")
+	}
+	if _, err := os.Stat(filepath.Join(holderDir, "peers", owner.ID().String()+fileExtension)); err != nil {
+		t.Errorf("held backup file missing: %v", err)
+	}
+}
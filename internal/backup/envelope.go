@@ -0,0 +1,201 @@
+// Package backup - Encrypted backup envelope format.
+package backup
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Envelope file layout: magic, uint32 big-endian header length, JSON header,
+// ciphertext.
+const (
+	envelopeMagic   = "KBAK"
+	envelopeVersion = 1
+	maxHeaderSize   = 64 * 1024
+)
+
+// Argon2id parameters, matching the wallet seed encryption.
+const (
+	kdfTime        = 3
+	kdfMemory      = 64 * 1024
+	kdfParallelism = 4
+	kdfKeyLen      = 32
+	kdfSaltLen     = 32
+)
+
+// Envelope errors
+var (
+	ErrInvalidEnvelope = errors.New("invalid backup envelope")
+	ErrIntegrity       = errors.New("backup integrity check failed")
+	ErrDecrypt         = errors.New("failed to decrypt backup (wrong passphrase?)")
+)
+
+// Header describes an encrypted backup. Holders can verify CipherSHA256
+// without the passphrase; the other fields are authenticated by AES-GCM.
+type Header struct {
+	Version      int    `json:"version"`
+	NodeID       string `json:"node_id"`
+	CreatedAt    int64  `json:"created_at"` // Unix milliseconds, increases per backup
+	CipherSHA256 string `json:"cipher_sha256"`
+
+	Salt        []byte `json:"salt"`
+	Nonce       []byte `json:"nonce"`
+	Time        uint32 `json:"time"`
+	Memory      uint32 `json:"memory"`
+	Parallelism uint8  `json:"parallelism"`
+}
+
+// Envelope is an encrypted backup.
+type Envelope struct {
+	Header     Header
+	Ciphertext []byte
+}
+
+// aad binds the header metadata to the ciphertext.
+func (h *Header) aad() []byte {
+	return []byte("klingdex-backup|" + strconv.Itoa(h.Version) + "|" + h.NodeID + "|" + strconv.FormatInt(h.CreatedAt, 10))
+}
+
+// sealer encrypts backups with a key derived once per process.
+type sealer struct {
+	key  []byte
+	salt []byte
+}
+
+func newSealer(passphrase string) (*sealer, error) {
+	salt := make([]byte, kdfSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	return &sealer{
+		key:  argon2.IDKey([]byte(passphrase), salt, kdfTime, kdfMemory, kdfParallelism, kdfKeyLen),
+		salt: salt,
+	}, nil
+}
+
+// seal encrypts plaintext into an envelope.
+func (s *sealer) seal(nodeID string, createdAt int64, plaintext []byte) (*Envelope, error) {
+	gcm, err := newGCM(s.key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	header := Header{
+		Version:     envelopeVersion,
+		NodeID:      nodeID,
+		CreatedAt:   createdAt,
+		Salt:        s.salt,
+		Nonce:       nonce,
+		Time:        kdfTime,
+		Memory:      kdfMemory,
+		Parallelism: kdfParallelism,
+	}
+	ciphertext := gcm.Seal(nil, nonce, plaintext, header.aad())
+	sum := sha256.Sum256(ciphertext)
+	header.CipherSHA256 = hex.EncodeToString(sum[:])
+
+	return &Envelope{Header: header, Ciphertext: ciphertext}, nil
+}
+
+// Open verifies and decrypts an envelope.
+func Open(env *Envelope, passphrase string) ([]byte, error) {
+	if err := env.Verify(); err != nil {
+		return nil, err
+	}
+	h := env.Header
+	key := argon2.IDKey([]byte(passphrase), h.Salt, h.Time, h.Memory, h.Parallelism, kdfKeyLen)
+	defer wallet.SecureClear(key)
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(h.Nonce) != gcm.NonceSize() {
+		return nil, ErrInvalidEnvelope
+	}
+	plaintext, err := gcm.Open(nil, h.Nonce, env.Ciphertext, h.aad())
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
+
+// Verify checks the envelope structure and ciphertext checksum.
+func (env *Envelope) Verify() error {
+	h := env.Header
+	if h.Version != envelopeVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidEnvelope, h.Version)
+	}
+	if h.CreatedAt <= 0 || len(h.Salt) == 0 || h.Time == 0 || h.Memory == 0 || h.Parallelism == 0 {
+		return ErrInvalidEnvelope
+	}
+	sum := sha256.Sum256(env.Ciphertext)
+	if hex.EncodeToString(sum[:]) != h.CipherSHA256 {
+		return ErrIntegrity
+	}
+	return nil
+}
+
+// Marshal encodes the envelope for storage or transfer.
+func (env *Envelope) Marshal() ([]byte, error) {
+	header, err := json.Marshal(&env.Header)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.Grow(len(envelopeMagic) + 4 + len(header) + len(env.Ciphertext))
+	buf.WriteString(envelopeMagic)
+	var length [4]byte
+	binary.BigEndian.PutUint32(length[:], uint32(len(header)))
+	buf.Write(length[:])
+	buf.Write(header)
+	buf.Write(env.Ciphertext)
+	return buf.Bytes(), nil
+}
+
+// Unmarshal decodes and verifies an envelope.
+func Unmarshal(data []byte) (*Envelope, error) {
+	if len(data) < len(envelopeMagic)+4 || string(data[:len(envelopeMagic)]) != envelopeMagic {
+		return nil, ErrInvalidEnvelope
+	}
+	data = data[len(envelopeMagic):]
+	length := binary.BigEndian.Uint32(data[:4])
+	data = data[4:]
+	if length > maxHeaderSize || int(length) > len(data) {
+		return nil, ErrInvalidEnvelope
+	}
+
+	env := &Envelope{Ciphertext: data[length:]}
+	if err := json.Unmarshal(data[:length], &env.Header); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidEnvelope, err)
+	}
+	if err := env.Verify(); err != nil {
+		return nil, err
+	}
+	return env, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,290 @@
+// Package backup - Peer backup protocol.
+package backup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// Protocol is the libp2p protocol for storing and fetching backups.
+const Protocol = "/klingon/backup/1.0.0"
+
+// Protocol operations.
+const (
+	opStore = "store"
+	opFetch = "fetch"
+)
+
+// streamTimeout bounds a single backup transfer.
+const streamTimeout = 5 * time.Minute
+
+// lineLimits bound the request and response lines.
+var lineLimits = node.MessageLimits{MaxBytes: 4 * 1024, MaxDepth: 1, MaxElements: 8}
+
+// request is the JSON line that starts a backup stream. For store, Size
+// bytes of envelope follow.
+type request struct {
+	Op   string `json:"op"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// response is the JSON line answering a request. For a successful fetch,
+// Size bytes of envelope follow.
+type response struct {
+	OK        bool   `json:"ok"`
+	Error     string `json:"error,omitempty"`
+	Size      int64  `json:"size,omitempty"`
+	CreatedAt int64  `json:"created_at,omitempty"`
+}
+
+// HeldBackup is a backup we hold for a peer.
+type HeldBackup struct {
+	PeerID    string `json:"peer_id"`
+	CreatedAt int64  `json:"created_at"`
+	Size      int64  `json:"size"`
+	UpdatedAt int64  `json:"updated_at"`
+}
+
+// pushToPeer sends a backup to a holder.
+func (r *Replicator) pushToPeer(ctx context.Context, id peer.ID, data []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+	defer cancel()
+
+	stream, err := r.host.NewStream(ctx, id, protocol.ID(Protocol))
+	if err != nil {
+		return fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(stream).Encode(&request{Op: opStore, Size: int64(len(data))}); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	if _, err := stream.Write(data); err != nil {
+		return fmt.Errorf("failed to send backup: %w", err)
+	}
+	stream.CloseWrite()
+
+	resp, err := readResponse(bufio.NewReader(stream))
+	if err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("peer rejected backup: %s", resp.Error)
+	}
+	return nil
+}
+
+// FetchFromPeer retrieves our latest backup from a holder and verifies its
+// integrity. The backup can only be fetched with the node identity that
+// stored it.
+func (r *Replicator) FetchFromPeer(ctx context.Context, id peer.ID) (*Envelope, []byte, error) {
+	if r.host == nil {
+		return nil, nil, fmt.Errorf("P2P host not available")
+	}
+	ctx, cancel := context.WithTimeout(ctx, streamTimeout)
+	defer cancel()
+
+	stream, err := r.host.NewStream(ctx, id, protocol.ID(Protocol))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(stream).Encode(&request{Op: opFetch}); err != nil {
+		return nil, nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	stream.CloseWrite()
+
+	reader := bufio.NewReader(stream)
+	resp, err := readResponse(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !resp.OK {
+		return nil, nil, fmt.Errorf("peer has no backup for us: %s", resp.Error)
+	}
+	if resp.Size <= 0 || resp.Size > r.cfg.MaxSize {
+		return nil, nil, fmt.Errorf("invalid backup size: %d", resp.Size)
+	}
+
+	data := make([]byte, resp.Size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return nil, nil, fmt.Errorf("failed to read backup: %w", err)
+	}
+	env, err := Unmarshal(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return env, data, nil
+}
+
+// handleStream serves store and fetch requests from peers in AcceptFrom.
+func (r *Replicator) handleStream(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(streamTimeout))
+
+	remote := stream.Conn().RemotePeer()
+	reply := func(resp *response, body []byte) {
+		if err := json.NewEncoder(stream).Encode(resp); err != nil {
+			return
+		}
+		if body != nil {
+			stream.Write(body)
+		}
+	}
+
+	if !r.cfg.Accepts(remote.String()) {
+		r.log.Debug("Backup request from untrusted peer", "peer", remote)
+		reply(&response{Error: "not accepted"}, nil)
+		return
+	}
+
+	reader := bufio.NewReader(io.LimitReader(stream, r.cfg.MaxSize+maxHeaderSize))
+	line, err := lineLimits.ReadLine(reader)
+	if err != nil {
+		return
+	}
+	var req request
+	if err := lineLimits.Unmarshal(line, &req); err != nil {
+		reply(&response{Error: "invalid request"}, nil)
+		return
+	}
+
+	switch req.Op {
+	case opStore:
+		createdAt, err := r.storeHeld(remote, reader, req.Size)
+		if err != nil {
+			r.log.Warn("Rejected peer backup", "peer", remote, "error", err)
+			reply(&response{Error: err.Error()}, nil)
+			return
+		}
+		r.log.Info("Stored peer backup", "peer", remote, "created_at", createdAt, "size", req.Size)
+		reply(&response{OK: true, CreatedAt: createdAt}, nil)
+
+	case opFetch:
+		data, err := os.ReadFile(r.heldPath(remote.String()))
+		if err != nil {
+			reply(&response{Error: "no backup held"}, nil)
+			return
+		}
+		env, err := Unmarshal(data)
+		if err != nil {
+			reply(&response{Error: "held backup is corrupt"}, nil)
+			return
+		}
+		r.log.Info("Serving peer backup", "peer", remote, "created_at", env.Header.CreatedAt)
+		reply(&response{OK: true, Size: int64(len(data)), CreatedAt: env.Header.CreatedAt}, data)
+
+	default:
+		reply(&response{Error: "unknown op"}, nil)
+	}
+}
+
+// storeHeld validates a peer's backup and replaces the one we hold if newer.
+func (r *Replicator) storeHeld(remote peer.ID, reader io.Reader, size int64) (int64, error) {
+	if size <= 0 || size > r.cfg.MaxSize {
+		return 0, fmt.Errorf("backup size %d exceeds limit %d", size, r.cfg.MaxSize)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(reader, data); err != nil {
+		return 0, fmt.Errorf("failed to read backup: %w", err)
+	}
+	env, err := Unmarshal(data)
+	if err != nil {
+		return 0, err
+	}
+	if env.Header.NodeID != remote.String() {
+		return 0, fmt.Errorf("backup belongs to %s", env.Header.NodeID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	path := r.heldPath(remote.String())
+	if existing, err := os.ReadFile(path); err == nil {
+		if held, err := Unmarshal(existing); err == nil && held.Header.CreatedAt >= env.Header.CreatedAt {
+			return 0, fmt.Errorf("backup is older than the one held")
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return 0, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return 0, err
+	}
+	return env.Header.CreatedAt, nil
+}
+
+func (r *Replicator) heldPath(peerID string) string {
+	return filepath.Join(r.dir, "peers", peerID+fileExtension)
+}
+
+// heldBackups lists the backups we hold for peers.
+func (r *Replicator) heldBackups() []*HeldBackup {
+	held := []*HeldBackup{}
+	entries, err := os.ReadDir(filepath.Join(r.dir, "peers"))
+	if err != nil {
+		return held
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if !strings.HasSuffix(name, fileExtension) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(r.dir, "peers", name))
+		if err != nil {
+			continue
+		}
+		env, err := Unmarshal(data)
+		if err != nil {
+			continue
+		}
+		info, _ := entry.Info()
+		hb := &HeldBackup{
+			PeerID:    strings.TrimSuffix(name, fileExtension),
+			CreatedAt: env.Header.CreatedAt,
+			Size:      int64(len(data)),
+		}
+		if info != nil {
+			hb.UpdatedAt = info.ModTime().Unix()
+		}
+		held = append(held, hb)
+	}
+	return held
+}
+
+func readResponse(reader *bufio.Reader) (*response, error) {
+	line, err := lineLimits.ReadLine(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	var resp response
+	if err := lineLimits.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	return &resp, nil
+}
@@ -0,0 +1,127 @@
+// Package backup - Restoring a backup into a data directory.
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/s3"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// maxRestoreSize bounds the decompressed database size.
+const maxRestoreSize = 16 << 30
+
+// RestoreResult describes a restored backup.
+type RestoreResult struct {
+	NodeID     string `json:"node_id"`
+	CreatedAt  int64  `json:"created_at"`
+	PreviousDB string `json:"previous_db,omitempty"` // Where the replaced database was moved
+}
+
+// Restore decrypts a backup and installs it as the database in dataDir. The
+// node must not be running. An existing database is kept alongside as
+// klingon.db.pre-restore-<unix>.
+func Restore(data []byte, passphrase, dataDir string) (*RestoreResult, error) {
+	env, err := Unmarshal(data)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := Open(env, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	defer wallet.SecureClear(plaintext)
+
+	// Decompress into a scratch data dir and check it opens as a database
+	scratch, err := os.MkdirTemp(dataDir, "restore-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create restore dir: %w", err)
+	}
+	defer os.RemoveAll(scratch)
+
+	restored := filepath.Join(scratch, storage.DBFileName)
+	if err := gunzipTo(restored, plaintext); err != nil {
+		return nil, err
+	}
+	check, err := storage.New(&storage.Config{DataDir: scratch})
+	if err != nil {
+		return nil, fmt.Errorf("backup does not contain a valid database: %w", err)
+	}
+	check.Close()
+
+	result := &RestoreResult{NodeID: env.Header.NodeID, CreatedAt: env.Header.CreatedAt}
+	dbPath := filepath.Join(dataDir, storage.DBFileName)
+	if _, err := os.Stat(dbPath); err == nil {
+		result.PreviousDB = dbPath + ".pre-restore-" + strconv.FormatInt(time.Now().Unix(), 10)
+		if err := os.Rename(dbPath, result.PreviousDB); err != nil {
+			return nil, fmt.Errorf("failed to move existing database: %w", err)
+		}
+	}
+	// Stale WAL files would be replayed into the restored database
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if _, err := os.Stat(dbPath + suffix); err == nil && result.PreviousDB != "" {
+			os.Rename(dbPath+suffix, result.PreviousDB+suffix)
+		} else {
+			os.Remove(dbPath + suffix)
+		}
+	}
+	if err := os.Rename(restored, dbPath); err != nil {
+		return nil, fmt.Errorf("failed to install restored database: %w", err)
+	}
+	return result, nil
+}
+
+// FetchLatestFromS3 downloads the most recent backup from S3.
+func FetchLatestFromS3(ctx context.Context, cfg node.S3Config) ([]byte, error) {
+	if !cfg.Enabled() {
+		return nil, fmt.Errorf("backup s3 endpoint not configured")
+	}
+	data, err := s3.New(cfg).Get(ctx, s3LatestObject)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := Unmarshal(data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// SaveFetched writes a backup fetched from a peer to dir for a later restore.
+func SaveFetched(dir string, env *Envelope, data []byte) (string, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "restore-"+strconv.FormatInt(env.Header.CreatedAt, 10)+fileExtension)
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("failed to save backup: %w", err)
+	}
+	return path, nil
+}
+
+func gunzipTo(path string, compressed []byte) error {
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return fmt.Errorf("failed to decompress backup: %w", err)
+	}
+	defer zr.Close()
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, io.LimitReader(zr, maxRestoreSize)); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to decompress backup: %w", err)
+	}
+	return f.Close()
+}
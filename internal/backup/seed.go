@@ -0,0 +1,113 @@
+// Package backup - Remote seed backups.
+//
+// The database backups above never contain the wallet seed. A seed backup
+// is the mnemonic encrypted with a separate recovery passphrase (see
+// wallet.ExportSeedBackup), uploaded with its verification record to a
+// user-chosen S3 bucket or WebDAV folder and read back to prove it landed.
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/s3"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Remote seed backup object names.
+const (
+	SeedObject         = "wallet-seed.kseed"
+	SeedVerifyObject   = "wallet-seed.verify.json"
+	RemoteTargetS3     = "s3"
+	RemoteTargetWebDAV = "webdav"
+)
+
+// RemoteTarget is where a seed backup is stored.
+type RemoteTarget struct {
+	Type   string        // RemoteTargetS3 or RemoteTargetWebDAV
+	S3     node.S3Config // Type s3
+	WebDAV WebDAVConfig  // Type webdav
+}
+
+// objectStore is the part of the S3 and WebDAV clients seed backups use.
+type objectStore interface {
+	Put(ctx context.Context, name string, data []byte) error
+	Get(ctx context.Context, name string) ([]byte, error)
+}
+
+func (t *RemoteTarget) store() (objectStore, error) {
+	switch t.Type {
+	case RemoteTargetS3:
+		if !t.S3.Enabled() {
+			return nil, fmt.Errorf("s3 target needs an endpoint and a bucket")
+		}
+		return s3.New(t.S3), nil
+	case RemoteTargetWebDAV:
+		return newWebDAVClient(t.WebDAV)
+	default:
+		return nil, fmt.Errorf("unknown remote backup target %q (use s3 or webdav)", t.Type)
+	}
+}
+
+// UploadSeed stores a seed backup and its verification record, then reads
+// both back and checks the backup against the record.
+func UploadSeed(ctx context.Context, target RemoteTarget, data []byte, v *wallet.SeedVerification) error {
+	store, err := target.store()
+	if err != nil {
+		return err
+	}
+	record, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := store.Put(ctx, SeedObject, data); err != nil {
+		return err
+	}
+	// The record goes last: a present record means a complete backup
+	if err := store.Put(ctx, SeedVerifyObject, record); err != nil {
+		return err
+	}
+
+	got, gotV, err := downloadSeed(ctx, store)
+	if err != nil {
+		return fmt.Errorf("failed to read back seed backup: %w", err)
+	}
+	if gotV.BackupSHA256 != v.BackupSHA256 || !wallet.SeedBackupMatches(got, gotV) {
+		return wallet.ErrSeedBackupChecksum
+	}
+	return nil
+}
+
+// DownloadSeed fetches a seed backup and its verification record.
+func DownloadSeed(ctx context.Context, target RemoteTarget) ([]byte, *wallet.SeedVerification, error) {
+	store, err := target.store()
+	if err != nil {
+		return nil, nil, err
+	}
+	return downloadSeed(ctx, store)
+}
+
+func downloadSeed(ctx context.Context, store objectStore) ([]byte, *wallet.SeedVerification, error) {
+	record, err := store.Get(ctx, SeedVerifyObject)
+	if err != nil {
+		if errors.Is(err, s3.ErrNotFound) {
+			err = ErrRemoteNotFound
+		}
+		return nil, nil, err
+	}
+	var v wallet.SeedVerification
+	if err := json.Unmarshal(record, &v); err != nil {
+		return nil, nil, fmt.Errorf("invalid seed verification record: %w", err)
+	}
+	data, err := store.Get(ctx, SeedObject)
+	if err != nil {
+		if errors.Is(err, s3.ErrNotFound) {
+			err = ErrRemoteNotFound
+		}
+		return nil, nil, err
+	}
+	return data, &v, nil
+}
@@ -0,0 +1,121 @@
+package backup
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// newTestSeedBackup exports a seed backup of a fresh testnet wallet.
+func newTestSeedBackup(t *testing.T) ([]byte, *wallet.SeedVerification) {
+	t.Helper()
+	svc := wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if err := svc.CreateWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	data, v, err := svc.ExportSeedBackup("TestPassword123!", testPassphrase)
+	if err != nil {
+		t.Fatalf("ExportSeedBackup() error = %v", err)
+	}
+	return data, v
+}
+
+func TestSeedWebDAV(t *testing.T) {
+	data, v := newTestSeedBackup(t)
+
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	collection := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		if user, pass, _ := r.BasicAuth(); user != "me" || pass != "app-password" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.Method {
+		case "MKCOL":
+			collection = true
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodPut:
+			if !collection {
+				w.WriteHeader(http.StatusConflict)
+				return
+			}
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+			w.WriteHeader(http.StatusCreated)
+		case http.MethodGet:
+			obj, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(obj)
+		}
+	}))
+	defer srv.Close()
+
+	target := RemoteTarget{Type: RemoteTargetWebDAV, WebDAV: WebDAVConfig{URL: srv.URL + "/dav/klingdex/", Username: "me", Password: "app-password"}}
+	ctx := context.Background()
+	if _, _, err := DownloadSeed(ctx, target); !errors.Is(err, ErrRemoteNotFound) {
+		t.Errorf("DownloadSeed() before upload error = %v, want ErrRemoteNotFound", err)
+	}
+	if err := UploadSeed(ctx, target, data, v); err != nil {
+		t.Fatalf("UploadSeed() error = %v", err)
+	}
+	if !collection || objects["/dav/klingdex/"+SeedObject] == nil || objects["/dav/klingdex/"+SeedVerifyObject] == nil {
+		t.Fatalf("objects = %v, want both in a created collection", objects)
+	}
+
+	got, gotV, err := DownloadSeed(ctx, target)
+	if err != nil {
+		t.Fatalf("DownloadSeed() error = %v", err)
+	}
+	if string(got) != string(data) || *gotV != *v {
+		t.Error("downloaded seed backup differs from the upload")
+	}
+
+	target.WebDAV.Password = "wrong"
+	if err := UploadSeed(ctx, target, data, v); err == nil || !strings.Contains(err.Error(), "Unauthorized") {
+		t.Errorf("UploadSeed() with bad credentials error = %v", err)
+	}
+}
+
+func TestSeedS3(t *testing.T) {
+	data, v := newTestSeedBackup(t)
+	fake, srv := newFakeS3(t)
+
+	target := RemoteTarget{Type: RemoteTargetS3, S3: node.S3Config{Endpoint: srv.URL, Bucket: "seeds", Prefix: "node-1", Region: "us-east-1"}}
+	ctx := context.Background()
+	if err := UploadSeed(ctx, target, data, v); err != nil {
+		t.Fatalf("UploadSeed() error = %v", err)
+	}
+	if fake.objects["/seeds/node-1/"+SeedObject] == nil {
+		t.Errorf("objects = %v", fake.objects)
+	}
+
+	// A corrupted object fails the read-back and the download check
+	fake.objects["/seeds/node-1/"+SeedObject] = []byte(`{"version":1}`)
+	got, gotV, err := DownloadSeed(ctx, target)
+	if err != nil {
+		t.Fatalf("DownloadSeed() error = %v", err)
+	}
+	if wallet.SeedBackupMatches(got, gotV) {
+		t.Error("corrupted backup matched its verification record")
+	}
+
+	for _, bad := range []RemoteTarget{{Type: "ftp"}, {Type: RemoteTargetS3}, {Type: RemoteTargetWebDAV, WebDAV: WebDAVConfig{URL: "ftp://host"}}} {
+		if err := UploadSeed(ctx, bad, data, v); err == nil {
+			t.Errorf("UploadSeed(%+v) should fail", bad)
+		}
+	}
+}
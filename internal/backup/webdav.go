@@ -0,0 +1,123 @@
+// Package backup - Minimal WebDAV client for remote seed backups.
+package backup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrRemoteNotFound is returned when a remote seed backup does not exist.
+var ErrRemoteNotFound = errors.New("remote backup not found")
+
+// maxSeedObjectSize bounds downloaded seed backup objects.
+const maxSeedObjectSize = 1 << 20
+
+// WebDAVConfig is a WebDAV collection (Nextcloud, ownCloud, a NAS, ...).
+type WebDAVConfig struct {
+	URL      string `json:"url"` // Collection the objects are stored in
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// webdavClient PUTs and GETs objects in a WebDAV collection.
+type webdavClient struct {
+	cfg    WebDAVConfig
+	client *http.Client
+}
+
+func newWebDAVClient(cfg WebDAVConfig) (*webdavClient, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webdav url: %q", cfg.URL)
+	}
+	return &webdavClient{cfg: cfg, client: &http.Client{Timeout: time.Minute}}, nil
+}
+
+// Put stores data in the collection, creating the collection if the server
+// reports it missing.
+func (c *webdavClient) Put(ctx context.Context, name string, data []byte) error {
+	status, err := c.put(ctx, name, data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		if err := c.mkcol(ctx); err != nil {
+			return err
+		}
+		if status, err = c.put(ctx, name, data); err != nil {
+			return err
+		}
+	}
+	if status/100 != 2 {
+		return fmt.Errorf("webdav upload of %s failed: %s", name, http.StatusText(status))
+	}
+	return nil
+}
+
+// Get fetches an object from the collection.
+func (c *webdavClient) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := c.do(ctx, http.MethodGet, c.objectURL(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrRemoteNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("webdav download of %s failed: %s", name, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxSeedObjectSize))
+}
+
+func (c *webdavClient) put(ctx context.Context, name string, data []byte) (int, error) {
+	resp, err := c.do(ctx, http.MethodPut, c.objectURL(name), data)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+func (c *webdavClient) mkcol(ctx context.Context) error {
+	resp, err := c.do(ctx, "MKCOL", strings.TrimRight(c.cfg.URL, "/")+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	// 405: the collection already exists
+	if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("webdav collection create failed: %s", resp.Status)
+	}
+	return nil
+}
+
+func (c *webdavClient) objectURL(name string) string {
+	return strings.TrimRight(c.cfg.URL, "/") + "/" + url.PathEscape(name)
+}
+
+func (c *webdavClient) do(ctx context.Context, method, target string, data []byte) (*http.Response, error) {
+	var body io.Reader
+	if data != nil {
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	if c.cfg.Username != "" {
+		req.SetBasicAuth(c.cfg.Username, c.cfg.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("webdav request failed: %w", err)
+	}
+	return resp, nil
+}
@@ -0,0 +1,164 @@
+// Package bench provides the built-in benchmark suite.
+//
+// The suite covers the hot paths of a swap: MuSig2 signing rounds, HTLC
+// script construction, UTXO selection over large wallets and storage of swap
+// records. klingon-bench adds its own cases (RPC handler throughput). Cases
+// are plain testing benchmarks, so the same suite runs under `go test -bench`
+// and, through testing.Benchmark, from klingon-bench. Reports are JSON so the
+// results of two releases can be compared with Compare.
+package bench
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"testing"
+	"time"
+)
+
+// ErrNoCases is returned when the filter matches no benchmark.
+var ErrNoCases = errors.New("no benchmarks match the filter")
+
+// Case is a named benchmark.
+type Case struct {
+	Name string
+	Run  func(b *testing.B)
+}
+
+// Result is the outcome of one benchmark.
+type Result struct {
+	Name        string `json:"name"`
+	Iterations  int    `json:"iterations"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	BytesPerOp  int64  `json:"bytes_per_op"`
+	AllocsPerOp int64  `json:"allocs_per_op"`
+}
+
+// Report is the result of a suite run.
+type Report struct {
+	Version   string    `json:"version,omitempty"`
+	GoVersion string    `json:"go_version"`
+	OS        string    `json:"os"`
+	Arch      string    `json:"arch"`
+	CPUs      int       `json:"cpus"`
+	StartedAt time.Time `json:"started_at"`
+	Duration  string    `json:"duration"`
+	Results   []Result  `json:"results"`
+}
+
+// SetBenchTime sets how long testing.Benchmark runs each case, as a
+// duration ("2s") or an iteration count ("100x"). Outside go test the
+// default is one second.
+func SetBenchTime(value string) error {
+	testing.Init()
+	return flag.Set("test.benchtime", value)
+}
+
+// Select returns the cases whose name matches filter (a regular expression;
+// empty matches all).
+func Select(cases []Case, filter string) ([]Case, error) {
+	if filter == "" {
+		return cases, nil
+	}
+	re, err := regexp.Compile(filter)
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	var out []Case
+	for _, c := range cases {
+		if re.MatchString(c.Name) {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+// Run runs the cases matching filter, one after another.
+func Run(cases []Case, filter, version string) (*Report, error) {
+	selected, err := Select(cases, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, ErrNoCases
+	}
+
+	report := &Report{
+		Version:   version,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+		StartedAt: time.Now().UTC(),
+	}
+	for _, c := range selected {
+		r := testing.Benchmark(c.Run)
+		if r.N == 0 {
+			return nil, fmt.Errorf("benchmark %s failed", c.Name)
+		}
+		report.Results = append(report.Results, Result{
+			Name:        c.Name,
+			Iterations:  r.N,
+			NsPerOp:     r.NsPerOp(),
+			BytesPerOp:  r.AllocedBytesPerOp(),
+			AllocsPerOp: r.AllocsPerOp(),
+		})
+	}
+	report.Duration = time.Since(report.StartedAt).Round(time.Millisecond).String()
+	return report, nil
+}
+
+// Comparison is the change of one benchmark between two reports.
+type Comparison struct {
+	Name        string `json:"name"`
+	BaseNsPerOp int64  `json:"base_ns_per_op"`
+	NsPerOp     int64  `json:"ns_per_op"`
+	DeltaBps    int64  `json:"delta_bps"` // Positive: slower than base
+	BaseAllocs  int64  `json:"base_allocs_per_op"`
+	Allocs      int64  `json:"allocs_per_op"`
+}
+
+// Compare returns the change of every benchmark present in both reports,
+// sorted by name.
+func Compare(base, current *Report) []Comparison {
+	baseByName := make(map[string]Result, len(base.Results))
+	for _, r := range base.Results {
+		baseByName[r.Name] = r
+	}
+
+	var out []Comparison
+	for _, r := range current.Results {
+		b, ok := baseByName[r.Name]
+		if !ok {
+			continue
+		}
+		c := Comparison{
+			Name:        r.Name,
+			BaseNsPerOp: b.NsPerOp,
+			NsPerOp:     r.NsPerOp,
+			BaseAllocs:  b.AllocsPerOp,
+			Allocs:      r.AllocsPerOp,
+		}
+		if b.NsPerOp > 0 {
+			c.DeltaBps = (r.NsPerOp - b.NsPerOp) * 10000 / b.NsPerOp
+		}
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Regressions returns the comparisons slower than base by more than
+// thresholdBps.
+func Regressions(comparisons []Comparison, thresholdBps int64) []Comparison {
+	var out []Comparison
+	for _, c := range comparisons {
+		if c.DeltaBps > thresholdBps {
+			out = append(out, c)
+		}
+	}
+	return out
+}
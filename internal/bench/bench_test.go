@@ -0,0 +1,96 @@
+package bench
+
+import (
+	"errors"
+	"flag"
+	"testing"
+)
+
+// BenchmarkSuite runs the built-in cases under go test:
+//
+//	go test -run '^$' -bench . ./internal/bench/
+func BenchmarkSuite(b *testing.B) {
+	for _, c := range Cases() {
+		b.Run(c.Name, c.Run)
+	}
+}
+
+// oneIteration makes testing.Benchmark run each case once.
+func oneIteration(t *testing.T) {
+	t.Helper()
+	f := flag.Lookup("test.benchtime")
+	old := f.Value.String()
+	if err := f.Value.Set("1x"); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { f.Value.Set(old) })
+}
+
+func TestCasesRun(t *testing.T) {
+	oneIteration(t)
+
+	// Every case runs without setup errors
+	report, err := Run(Cases(), "", "")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != len(Cases()) {
+		t.Errorf("got %d results, want %d", len(report.Results), len(Cases()))
+	}
+}
+
+func TestRunFilter(t *testing.T) {
+	oneIteration(t)
+
+	report, err := Run(Cases(), "^htlc/", "test")
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if len(report.Results) != 1 || report.Results[0].Name != "htlc/script" {
+		t.Fatalf("results = %+v", report.Results)
+	}
+	if r := report.Results[0]; r.Iterations == 0 || r.NsPerOp <= 0 {
+		t.Errorf("result = %+v", r)
+	}
+	if report.Version != "test" || report.GoVersion == "" {
+		t.Errorf("report = %+v", report)
+	}
+
+	if _, err := Run(Cases(), "^nothing$", ""); !errors.Is(err, ErrNoCases) {
+		t.Errorf("error = %v, want ErrNoCases", err)
+	}
+	if _, err := Run(Cases(), "(", ""); err == nil {
+		t.Error("expected an error for an invalid filter")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := &Report{Results: []Result{
+		{Name: "b", NsPerOp: 1000, AllocsPerOp: 4},
+		{Name: "a", NsPerOp: 200},
+		{Name: "gone", NsPerOp: 10},
+	}}
+	current := &Report{Results: []Result{
+		{Name: "a", NsPerOp: 150},
+		{Name: "b", NsPerOp: 1250, AllocsPerOp: 6},
+		{Name: "new", NsPerOp: 10},
+	}}
+
+	got := Compare(base, current)
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "b" {
+		t.Fatalf("Compare() = %+v", got)
+	}
+	if got[0].DeltaBps != -2500 || got[1].DeltaBps != 2500 {
+		t.Errorf("deltas = %d, %d; want -2500, 2500", got[0].DeltaBps, got[1].DeltaBps)
+	}
+	if got[1].BaseAllocs != 4 || got[1].Allocs != 6 {
+		t.Errorf("allocs = %+v", got[1])
+	}
+
+	if r := Regressions(got, 1000); len(r) != 1 || r[0].Name != "b" {
+		t.Errorf("Regressions(1000) = %+v", r)
+	}
+	if r := Regressions(got, 3000); len(r) != 0 {
+		t.Errorf("Regressions(3000) = %+v", r)
+	}
+}
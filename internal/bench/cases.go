@@ -0,0 +1,194 @@
+// Package bench - Built-in benchmark cases.
+package bench
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// Cases returns the built-in benchmarks.
+func Cases() []Case {
+	return []Case{
+		{Name: "musig2/taproot_address", Run: benchMuSig2Address},
+		{Name: "musig2/sign_round", Run: benchMuSig2SignRound},
+		{Name: "htlc/script", Run: benchHTLCScript},
+		{Name: "utxo/select_10k", Run: func(b *testing.B) { benchSelectUTXOs(b, 10000) }},
+		{Name: "utxo/select_100k", Run: func(b *testing.B) { benchSelectUTXOs(b, 100000) }},
+		{Name: "storage/swap_write", Run: benchSwapWrite},
+		{Name: "storage/swap_read", Run: benchSwapRead},
+	}
+}
+
+// musig2Pair returns two sessions that exchanged pubkeys.
+func musig2Pair(b *testing.B, alice, bob *btcec.PrivateKey) (*swap.MuSig2Session, *swap.MuSig2Session) {
+	a, err := swap.NewMuSig2Session("BTC", chain.Testnet, alice)
+	if err != nil {
+		b.Fatal(err)
+	}
+	o, err := swap.NewMuSig2Session("BTC", chain.Testnet, bob)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := a.SetRemotePubKey(o.GetLocalPubKey()); err != nil {
+		b.Fatal(err)
+	}
+	if err := o.SetRemotePubKey(a.GetLocalPubKey()); err != nil {
+		b.Fatal(err)
+	}
+	return a, o
+}
+
+func benchMuSig2Address(b *testing.B) {
+	alice, _ := btcec.NewPrivateKey()
+	bob, _ := btcec.NewPrivateKey()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a, _ := musig2Pair(b, alice, bob)
+		if _, err := a.GenerateSwapAddress(144, alice.PubKey()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchMuSig2SignRound runs a full signing round: nonces, signing sessions,
+// both partial signatures and their aggregation.
+func benchMuSig2SignRound(b *testing.B) {
+	alice, _ := btcec.NewPrivateKey()
+	bob, _ := btcec.NewPrivateKey()
+	msg := chainhash.Hash(sha256.Sum256([]byte("klingdex bench")))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		a, o := musig2Pair(b, alice, bob)
+		an, err := a.GenerateNonces()
+		if err != nil {
+			b.Fatal(err)
+		}
+		on, err := o.GenerateNonces()
+		if err != nil {
+			b.Fatal(err)
+		}
+		a.SetRemoteNonce(on.PubNonce)
+		o.SetRemoteNonce(an.PubNonce)
+		if err := a.InitSigningSession(); err != nil {
+			b.Fatal(err)
+		}
+		if err := o.InitSigningSession(); err != nil {
+			b.Fatal(err)
+		}
+		aSig, err := a.Sign(&msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		oSig, err := o.Sign(&msg)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := a.CombineSignatures(aSig, oSig); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchHTLCScript(b *testing.B) {
+	receiver, _ := btcec.NewPrivateKey()
+	sender, _ := btcec.NewPrivateKey()
+	hash := sha256.Sum256([]byte("secret"))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := swap.BuildHTLCScriptData(hash[:], receiver.PubKey(), sender.PubKey(), 144, "BTC", chain.Testnet); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchSelectUTXOs selects coins for half the balance of a wallet with n
+// UTXOs of varied value.
+func benchSelectUTXOs(b *testing.B, n int) {
+	utxos := make([]backend.UTXO, n)
+	var total uint64
+	for i := range utxos {
+		amount := uint64(1000 + (i*7919)%100000)
+		utxos[i] = backend.UTXO{TxID: fmt.Sprintf("%064x", i), Vout: uint32(i % 4), Amount: amount, Confirmations: 6}
+		total += amount
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := swap.SelectUTXOs(utxos, total/2, 2); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchStore returns a storage in a temporary directory.
+func benchStore(b *testing.B) *storage.Storage {
+	dir, err := os.MkdirTemp("", "klingon-bench-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	store, err := storage.New(&storage.Config{DataDir: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		b.Fatal(err)
+	}
+	b.Cleanup(func() {
+		store.Close()
+		os.RemoveAll(dir)
+	})
+	return store
+}
+
+// benchSwapRecord returns a swap record with method data of realistic size.
+func benchSwapRecord(id string) *storage.SwapRecord {
+	return &storage.SwapRecord{
+		TradeID:       id,
+		OrderID:       "order-" + id,
+		MakerPeerID:   "12D3KooWMaker",
+		TakerPeerID:   "12D3KooWTaker",
+		OurRole:       "maker",
+		IsMaker:       true,
+		OfferChain:    "BTC",
+		OfferAmount:   100000,
+		RequestChain:  "LTC",
+		RequestAmount: 1000000,
+		State:         storage.SwapStateFunding,
+		MethodData:    []byte(fmt.Sprintf(`{"local_pubkey":"%066x","remote_pubkey":"%066x","offer_chain":{},"request_chain":{}}`, 1, 2)),
+		TimeoutHeight: 800144,
+		CreatedAt:     time.Now(),
+	}
+}
+
+func benchSwapWrite(b *testing.B) {
+	store := benchStore(b)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := store.SaveSwap(benchSwapRecord(fmt.Sprintf("bench-%d", i%1000))); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchSwapRead(b *testing.B) {
+	store := benchStore(b)
+	for i := 0; i < 1000; i++ {
+		if err := store.SaveSwap(benchSwapRecord(fmt.Sprintf("bench-%d", i))); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := store.GetSwap(fmt.Sprintf("bench-%d", i%1000)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -29,10 +29,18 @@ func init() {
 		DefaultAddressType: AddressP2WPKH,
 	})
 
-	// Bitcoin Testnet (testnet3)
-	Register("BTC", Testnet, &Params{
+	// Bitcoin test networks. testnet3, testnet4 and signet share address
+	// prefixes and key versions; testnet4 is the default.
+	RegisterTestnet("BTC", TestnetBTCTestnet4, bitcoinTestParams("Bitcoin Testnet4"))
+	RegisterTestnet("BTC", TestnetBTCTestnet3, bitcoinTestParams("Bitcoin Testnet3"))
+	RegisterTestnet("BTC", TestnetBTCSignet, bitcoinTestParams("Bitcoin Signet"))
+}
+
+// bitcoinTestParams returns params for a Bitcoin test network.
+func bitcoinTestParams(name string) *Params {
+	return &Params{
 		Symbol:   "BTC",
-		Name:     "Bitcoin Testnet",
+		Name:     name,
 		Type:     ChainTypeBitcoin,
 		Decimals: 8,
 
@@ -55,5 +63,5 @@ func init() {
 		SupportsTaproot: true,
 
 		DefaultAddressType: AddressP2WPKH,
-	})
+	}
 }
@@ -2,6 +2,8 @@
 // All chain-specific values are hardcoded here - no external configuration needed.
 package chain
 
+import "sync"
+
 // Network represents mainnet or testnet.
 type Network string
 
@@ -121,8 +123,19 @@ func itoa(n uint32) string {
 // Registry holds all chain parameters indexed by symbol.
 var registry = make(map[string]map[Network]*Params)
 
+// registryMu guards registry and the test network selection (testnets.go),
+// which SelectTestnet changes while Get may be called from other goroutines.
+var registryMu sync.RWMutex
+
 // Register adds chain params to the registry.
 func Register(symbol string, network Network, params *Params) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	register(symbol, network, params)
+}
+
+// register is Register for callers holding registryMu.
+func register(symbol string, network Network, params *Params) {
 	if registry[symbol] == nil {
 		registry[symbol] = make(map[Network]*Params)
 	}
@@ -131,6 +144,9 @@ func Register(symbol string, network Network, params *Params) {
 
 // Get returns chain params for a symbol and network.
 func Get(symbol string, network Network) (*Params, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	nets, ok := registry[symbol]
 	if !ok {
 		return nil, false
@@ -141,6 +157,9 @@ func Get(symbol string, network Network) (*Params, bool) {
 
 // List returns all registered chain symbols.
 func List() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	symbols := make([]string, 0, len(registry))
 	for symbol := range registry {
 		symbols = append(symbols, symbol)
@@ -150,6 +169,9 @@ func List() []string {
 
 // ListByType returns all chains of a specific type.
 func ListByType(chainType ChainType) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	var symbols []string
 	for symbol, nets := range registry {
 		for _, params := range nets {
@@ -164,12 +186,18 @@ func ListByType(chainType ChainType) []string {
 
 // IsSupported returns true if the chain is registered.
 func IsSupported(symbol string) bool {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	_, ok := registry[symbol]
 	return ok
 }
 
 // GetByChainID returns chain params for an EVM chain ID.
 func GetByChainID(chainID uint64, network Network) (*Params, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	for _, nets := range registry {
 		if params, ok := nets[network]; ok {
 			if params.Type == ChainTypeEVM && params.ChainID == chainID {
@@ -191,6 +219,9 @@ func (p *Params) GetNativeToken() string {
 
 // ListEVMChains returns all EVM chains with their chain IDs.
 func ListEVMChains(network Network) map[string]uint64 {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
 	result := make(map[string]uint64)
 	for symbol, nets := range registry {
 		if params, ok := nets[network]; ok {
@@ -368,6 +368,19 @@ func TestListEVMChains(t *testing.T) {
 	}
 }
 
+func TestGetTokenByAddress(t *testing.T) {
+	token := GetTokenByAddress(1, "0xdac17f958d2ee523a2206206994597c13d831ec7")
+	if token == nil || token.Symbol != "USDT" || token.Decimals != 6 {
+		t.Fatalf("GetTokenByAddress(USDT lowercase) = %+v", token)
+	}
+	if GetTokenByAddress(56, "0xdAC17F958D2ee523a2206206994597C13D831ec7") != nil {
+		t.Error("Ethereum USDT address should not resolve on BSC")
+	}
+	if GetTokenByAddress(1, "0x0000000000000000000000000000000000000001") != nil {
+		t.Error("unknown address should not resolve")
+	}
+}
+
 func TestTokenRegistry(t *testing.T) {
 	// Test USDT on different chains
 	usdtTests := []struct {
@@ -422,3 +435,143 @@ func TestTokenRegistry(t *testing.T) {
 		t.Errorf("expected at least 4 tokens on Ethereum, got %d", len(ethTokens))
 	}
 }
+
+func TestUnderlying(t *testing.T) {
+	tests := []struct {
+		chain      string
+		token      string
+		underlying string
+		decimals   uint8
+	}{
+		{"BTC", "", "BTC", 8},
+		{"ETH", "", "ETH", 18},
+		{"ARBITRUM", "", "ETH", 18},
+		{"BSC", "", "BNB", 18},
+		{"ETH", "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599", "BTC", 8},   // WBTC
+		{"BASE", "0x4200000000000000000000000000000000000006", "ETH", 18}, // WETH
+		{"BSC", "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c", "BNB", 18},  // WBNB
+		{"BSC", "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d", "USDC", 18}, // Binance-Peg USDC
+		{"ETH", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", "USDC", 6},
+	}
+	for _, tc := range tests {
+		underlying, decimals, ok := Underlying(tc.chain, Mainnet, tc.token)
+		if !ok || underlying != tc.underlying || decimals != tc.decimals {
+			t.Errorf("Underlying(%s, %s) = %s, %d, %v; want %s, %d", tc.chain, tc.token, underlying, decimals, ok, tc.underlying, tc.decimals)
+		}
+	}
+
+	if _, _, ok := Underlying("ETH", Mainnet, "0x0000000000000000000000000000000000000001"); ok {
+		t.Error("unregistered token should not resolve")
+	}
+	if _, _, ok := Underlying("NOPE", Mainnet, ""); ok {
+		t.Error("unknown chain should not resolve")
+	}
+
+	if usdc := GetToken(56, "USDC"); !usdc.Bridged || usdc.Underlying != "" {
+		t.Errorf("BSC USDC = %+v, want bridged with its own symbol as asset", usdc)
+	}
+	if usdc := GetToken(1, "USDC"); usdc.Bridged {
+		t.Error("Ethereum USDC is issued natively")
+	}
+}
+
+func TestTestnetVariants(t *testing.T) {
+	btc := TestnetVariants("BTC")
+	if len(btc) != 3 {
+		t.Fatalf("BTC test networks = %v, want 3", btc)
+	}
+	if ActiveTestnet("BTC") != TestnetBTCTestnet4 {
+		t.Errorf("default BTC test network = %s, want %s", ActiveTestnet("BTC"), TestnetBTCTestnet4)
+	}
+	if ActiveTestnet("ETH") != TestnetETHSepolia {
+		t.Errorf("default ETH test network = %s, want %s", ActiveTestnet("ETH"), TestnetETHSepolia)
+	}
+	if len(TestnetVariants("LTC")) != 0 {
+		t.Error("LTC should have no selectable test networks")
+	}
+	if got := TestnetChains(TestnetBTCSignet); len(got) != 1 || got[0] != "BTC" {
+		t.Errorf("TestnetChains(signet) = %v, want [BTC]", got)
+	}
+	if got := TestnetChains("regtest"); len(got) != 0 {
+		t.Errorf("TestnetChains(regtest) = %v, want none", got)
+	}
+
+	holesky, ok := GetTestnet("ETH", TestnetETHHolesky)
+	if !ok {
+		t.Fatal("ETH holesky should be registered")
+	}
+	if holesky.ChainID != 17000 {
+		t.Errorf("Holesky ChainID = %d, want 17000", holesky.ChainID)
+	}
+}
+
+func TestSelectTestnet(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SelectTestnet("ETH", TestnetETHSepolia)
+		_ = SelectTestnet("BTC", TestnetBTCTestnet4)
+	})
+
+	if err := SelectTestnet("ETH", TestnetETHHolesky); err != nil {
+		t.Fatalf("SelectTestnet() error = %v", err)
+	}
+	params, _ := Get("ETH", Testnet)
+	if params.ChainID != 17000 {
+		t.Errorf("ETH testnet ChainID = %d, want 17000", params.ChainID)
+	}
+	if _, ok := GetByChainID(17000, Testnet); !ok {
+		t.Error("GetByChainID(17000) should find holesky once selected")
+	}
+
+	if err := SelectTestnet("BTC", TestnetBTCSignet); err != nil {
+		t.Fatalf("SelectTestnet() error = %v", err)
+	}
+	params, _ = Get("BTC", Testnet)
+	if params.Name != "Bitcoin Signet" || params.Bech32HRP != "tb" {
+		t.Errorf("BTC testnet = %s (%s), want Bitcoin Signet (tb)", params.Name, params.Bech32HRP)
+	}
+
+	if err := SelectTestnet("ETH", "goerli"); err == nil {
+		t.Error("expected error for unknown test network")
+	}
+	if err := SelectTestnet("LTC", "testnet"); err == nil {
+		t.Error("expected error for chain without test networks")
+	}
+	if ActiveTestnet("ETH") != TestnetETHHolesky {
+		t.Error("failed selection must not change the active test network")
+	}
+}
+
+func TestSelectTestnetConcurrentGet(t *testing.T) {
+	t.Cleanup(func() {
+		_ = SelectTestnet("ETH", TestnetETHSepolia)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 200; i++ {
+			name := TestnetETHSepolia
+			if i%2 == 0 {
+				name = TestnetETHHolesky
+			}
+			if err := SelectTestnet("ETH", name); err != nil {
+				t.Errorf("SelectTestnet() error = %v", err)
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			return
+		default:
+		}
+		params, ok := Get("ETH", Testnet)
+		if !ok || (params.ChainID != 11155111 && params.ChainID != 17000) {
+			t.Fatalf("Get(ETH, testnet) = %v, %v during selection", params, ok)
+		}
+		_ = ActiveTestnet("ETH")
+		_ = TestnetVariants("ETH")
+	}
+}
@@ -23,8 +23,8 @@ func init() {
 		DefaultAddressType: AddressEVM,
 	})
 
-	// Ethereum Sepolia Testnet (chainID 11155111)
-	Register("ETH", Testnet, &Params{
+	// Ethereum Sepolia Testnet (chainID 11155111) - default
+	RegisterTestnet("ETH", TestnetETHSepolia, &Params{
 		Symbol:      "ETH",
 		Name:        "Ethereum Sepolia",
 		Type:        ChainTypeEVM,
@@ -41,6 +41,24 @@ func init() {
 		DefaultAddressType: AddressEVM,
 	})
 
+	// Ethereum Holesky Testnet (chainID 17000)
+	RegisterTestnet("ETH", TestnetETHHolesky, &Params{
+		Symbol:      "ETH",
+		Name:        "Ethereum Holesky",
+		Type:        ChainTypeEVM,
+		Decimals:    18,
+		NativeToken: "ETH",
+
+		CoinType:       60,
+		DefaultPurpose: 44,
+
+		ChainID: 17000,
+
+		SupportsSegWit:     false,
+		SupportsTaproot:    false,
+		DefaultAddressType: AddressEVM,
+	})
+
 	// ==========================================================================
 	// BNB Smart Chain (BSC)
 	// ==========================================================================
@@ -0,0 +1,109 @@
+package chain
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Test network names for chains with more than one public testnet.
+const (
+	TestnetBTCTestnet3 = "testnet3"
+	TestnetBTCTestnet4 = "testnet4"
+	TestnetBTCSignet   = "signet"
+	TestnetETHSepolia  = "sepolia"
+	TestnetETHHolesky  = "holesky"
+)
+
+// testnets holds the selectable test networks per chain symbol.
+var testnets = make(map[string]map[string]*Params)
+
+// activeTestnets holds the selected test network name per chain symbol.
+var activeTestnets = make(map[string]string)
+
+// RegisterTestnet adds a named test network for a chain.
+// The first test network registered for a symbol is the default and is also
+// registered as the symbol's Testnet params.
+func RegisterTestnet(symbol, name string, params *Params) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if testnets[symbol] == nil {
+		testnets[symbol] = make(map[string]*Params)
+	}
+	testnets[symbol][name] = params
+
+	if _, ok := activeTestnets[symbol]; !ok {
+		activeTestnets[symbol] = name
+		register(symbol, Testnet, params)
+	}
+}
+
+// SelectTestnet makes the named test network the one returned by Get(symbol, Testnet).
+// It is meant to be called during startup; Get callers see the switch as a whole.
+func SelectTestnet(symbol, name string) error {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	nets, ok := testnets[symbol]
+	if !ok {
+		return fmt.Errorf("chain %s has no selectable test networks", symbol)
+	}
+	params, ok := nets[name]
+	if !ok {
+		return fmt.Errorf("unknown test network %q for %s (available: %v)", name, symbol, testnetVariants(symbol))
+	}
+	activeTestnets[symbol] = name
+	register(symbol, Testnet, params)
+	return nil
+}
+
+// ActiveTestnet returns the selected test network name for a chain.
+// Returns an empty string for chains with a single testnet.
+func ActiveTestnet(symbol string) string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return activeTestnets[symbol]
+}
+
+// TestnetVariants returns the selectable test network names for a chain, sorted.
+func TestnetVariants(symbol string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	return testnetVariants(symbol)
+}
+
+// TestnetChains returns the chains that have the named test network, sorted.
+func TestnetChains(name string) []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	var symbols []string
+	for symbol, nets := range testnets {
+		if _, ok := nets[name]; ok {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// testnetVariants is TestnetVariants for callers holding registryMu.
+func testnetVariants(symbol string) []string {
+	names := make([]string, 0, len(testnets[symbol]))
+	for name := range testnets[symbol] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// GetTestnet returns chain params for a specific test network.
+func GetTestnet(symbol, name string) (*Params, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	params, ok := testnets[symbol][name]
+	return params, ok
+}
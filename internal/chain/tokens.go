@@ -1,5 +1,7 @@
 package chain
 
+import "strings"
+
 // TokenInfo contains information about an ERC-20 token on a specific chain.
 type TokenInfo struct {
 	Symbol   string // Token symbol (USDT, USDC, etc.)
@@ -7,6 +9,14 @@ type TokenInfo struct {
 	Decimals uint8  // Token decimals
 	Address  string // Contract address on this chain
 	ChainID  uint64 // EVM chain ID
+
+	// Underlying is the asset a wrapped token represents (BTC for WBTC,
+	// ETH for WETH); empty when the token is its own asset.
+	Underlying string
+
+	// Bridged is set for representations locked or minted by a bridge or
+	// wrapper contract rather than issued natively on this chain.
+	Bridged bool
 }
 
 // tokenRegistry maps chainID -> symbol -> TokenInfo
@@ -31,18 +41,22 @@ func init() {
 		ChainID:  1,
 	})
 	registerToken(1, &TokenInfo{
-		Symbol:   "WETH",
-		Name:     "Wrapped Ether",
-		Decimals: 18,
-		Address:  "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
-		ChainID:  1,
+		Symbol:     "WETH",
+		Name:       "Wrapped Ether",
+		Decimals:   18,
+		Address:    "0xC02aaA39b223FE8D0A0e5C4F27eAD9083C756Cc2",
+		ChainID:    1,
+		Underlying: "ETH",
+		Bridged:    true,
 	})
 	registerToken(1, &TokenInfo{
-		Symbol:   "WBTC",
-		Name:     "Wrapped Bitcoin",
-		Decimals: 8,
-		Address:  "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599",
-		ChainID:  1,
+		Symbol:     "WBTC",
+		Name:       "Wrapped Bitcoin",
+		Decimals:   8,
+		Address:    "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599",
+		ChainID:    1,
+		Underlying: "BTC",
+		Bridged:    true,
 	})
 	registerToken(1, &TokenInfo{
 		Symbol:   "DAI",
@@ -61,6 +75,7 @@ func init() {
 		Decimals: 6,
 		Address:  "0xFd086bC7CD5C481DCC9C85ebE478A1C0b69FCbb9",
 		ChainID:  42161,
+		Bridged:  true,
 	})
 	registerToken(42161, &TokenInfo{
 		Symbol:   "USDC",
@@ -70,18 +85,22 @@ func init() {
 		ChainID:  42161,
 	})
 	registerToken(42161, &TokenInfo{
-		Symbol:   "WETH",
-		Name:     "Wrapped Ether",
-		Decimals: 18,
-		Address:  "0x82aF49447D8a07e3bd95BD0d56f35241523fBab1",
-		ChainID:  42161,
+		Symbol:     "WETH",
+		Name:       "Wrapped Ether",
+		Decimals:   18,
+		Address:    "0x82aF49447D8a07e3bd95BD0d56f35241523fBab1",
+		ChainID:    42161,
+		Underlying: "ETH",
+		Bridged:    true,
 	})
 	registerToken(42161, &TokenInfo{
-		Symbol:   "WBTC",
-		Name:     "Wrapped Bitcoin",
-		Decimals: 8,
-		Address:  "0x2f2a2543B76A4166549F7aaB2e75Bef0aefC5B0f",
-		ChainID:  42161,
+		Symbol:     "WBTC",
+		Name:       "Wrapped Bitcoin",
+		Decimals:   8,
+		Address:    "0x2f2a2543B76A4166549F7aaB2e75Bef0aefC5B0f",
+		ChainID:    42161,
+		Underlying: "BTC",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
@@ -93,6 +112,7 @@ func init() {
 		Decimals: 6,
 		Address:  "0x94b008aA00579c1307B0EF2c499aD98a8ce58e58",
 		ChainID:  10,
+		Bridged:  true,
 	})
 	registerToken(10, &TokenInfo{
 		Symbol:   "USDC",
@@ -102,11 +122,13 @@ func init() {
 		ChainID:  10,
 	})
 	registerToken(10, &TokenInfo{
-		Symbol:   "WETH",
-		Name:     "Wrapped Ether",
-		Decimals: 18,
-		Address:  "0x4200000000000000000000000000000000000006",
-		ChainID:  10,
+		Symbol:     "WETH",
+		Name:       "Wrapped Ether",
+		Decimals:   18,
+		Address:    "0x4200000000000000000000000000000000000006",
+		ChainID:    10,
+		Underlying: "ETH",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
@@ -120,22 +142,26 @@ func init() {
 		ChainID:  8453,
 	})
 	registerToken(8453, &TokenInfo{
-		Symbol:   "WETH",
-		Name:     "Wrapped Ether",
-		Decimals: 18,
-		Address:  "0x4200000000000000000000000000000000000006",
-		ChainID:  8453,
+		Symbol:     "WETH",
+		Name:       "Wrapped Ether",
+		Decimals:   18,
+		Address:    "0x4200000000000000000000000000000000000006",
+		ChainID:    8453,
+		Underlying: "ETH",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
 	// BNB Smart Chain (chainID 56)
 	// ==========================================================================
+	// USDT and USDC are Binance-Peg tokens, backed by the Ethereum issuance
 	registerToken(56, &TokenInfo{
 		Symbol:   "USDT",
 		Name:     "Tether USD",
 		Decimals: 18, // BSC USDT has 18 decimals
 		Address:  "0x55d398326f99059fF775485246999027B3197955",
 		ChainID:  56,
+		Bridged:  true,
 	})
 	registerToken(56, &TokenInfo{
 		Symbol:   "USDC",
@@ -143,13 +169,16 @@ func init() {
 		Decimals: 18,
 		Address:  "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d",
 		ChainID:  56,
+		Bridged:  true,
 	})
 	registerToken(56, &TokenInfo{
-		Symbol:   "WBNB",
-		Name:     "Wrapped BNB",
-		Decimals: 18,
-		Address:  "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
-		ChainID:  56,
+		Symbol:     "WBNB",
+		Name:       "Wrapped BNB",
+		Decimals:   18,
+		Address:    "0xbb4CdB9CBd36B01bD1cBaEBF2De08d9173bc095c",
+		ChainID:    56,
+		Underlying: "BNB",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
@@ -161,6 +190,7 @@ func init() {
 		Decimals: 6,
 		Address:  "0xc2132D05D31c914a87C6611C10748AEb04B58e8F",
 		ChainID:  137,
+		Bridged:  true,
 	})
 	registerToken(137, &TokenInfo{
 		Symbol:   "USDC",
@@ -170,18 +200,22 @@ func init() {
 		ChainID:  137,
 	})
 	registerToken(137, &TokenInfo{
-		Symbol:   "WETH",
-		Name:     "Wrapped Ether",
-		Decimals: 18,
-		Address:  "0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619",
-		ChainID:  137,
+		Symbol:     "WETH",
+		Name:       "Wrapped Ether",
+		Decimals:   18,
+		Address:    "0x7ceB23fD6bC0adD59E62ac25578270cFf1b9f619",
+		ChainID:    137,
+		Underlying: "ETH",
+		Bridged:    true,
 	})
 	registerToken(137, &TokenInfo{
-		Symbol:   "WPOL",
-		Name:     "Wrapped POL",
-		Decimals: 18,
-		Address:  "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270",
-		ChainID:  137,
+		Symbol:     "WPOL",
+		Name:       "Wrapped POL",
+		Decimals:   18,
+		Address:    "0x0d500B1d8E8eF31E21C99d1Db9A6444d3ADf1270",
+		ChainID:    137,
+		Underlying: "POL",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
@@ -202,11 +236,13 @@ func init() {
 		ChainID:  43114,
 	})
 	registerToken(43114, &TokenInfo{
-		Symbol:   "WAVAX",
-		Name:     "Wrapped AVAX",
-		Decimals: 18,
-		Address:  "0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7",
-		ChainID:  43114,
+		Symbol:     "WAVAX",
+		Name:       "Wrapped AVAX",
+		Decimals:   18,
+		Address:    "0xB31f66AA3C1e785363F0875A1B74E27b85FD66c7",
+		ChainID:    43114,
+		Underlying: "AVAX",
+		Bridged:    true,
 	})
 
 	// ==========================================================================
@@ -239,6 +275,17 @@ func GetToken(chainID uint64, symbol string) *TokenInfo {
 	return nil
 }
 
+// GetTokenByAddress returns token info for a contract address on a specific
+// chain (case-insensitive). Returns nil if the token is not registered.
+func GetTokenByAddress(chainID uint64, address string) *TokenInfo {
+	for _, token := range tokenRegistry[chainID] {
+		if strings.EqualFold(token.Address, address) {
+			return token
+		}
+	}
+	return nil
+}
+
 // GetTokenAddress returns the contract address for a token on a specific chain.
 // Returns empty string if not found.
 func GetTokenAddress(chainID uint64, symbol string) string {
@@ -285,3 +332,31 @@ func GetTokenDecimals(chainID uint64, symbol string) uint8 {
 	}
 	return 0
 }
+
+// UnderlyingAsset returns the asset a token represents: its underlying for
+// a wrapped or bridged token, its own symbol otherwise.
+func (t *TokenInfo) UnderlyingAsset() string {
+	if t.Underlying != "" {
+		return t.Underlying
+	}
+	return t.Symbol
+}
+
+// Underlying returns the asset one side of a swap represents, with the
+// decimals its amounts are counted in. token is the ERC-20 contract address,
+// empty for the chain's native coin. It returns false for an unknown chain
+// or unregistered token.
+func Underlying(symbol string, network Network, token string) (string, uint8, bool) {
+	params, ok := Get(symbol, network)
+	if !ok {
+		return "", 0, false
+	}
+	if token == "" {
+		return params.GetNativeToken(), params.Decimals, true
+	}
+	info := GetTokenByAddress(params.ChainID, token)
+	if info == nil {
+		return "", 0, false
+	}
+	return info.UnderlyingAsset(), info.Decimals, true
+}
@@ -0,0 +1,127 @@
+// Package chaos injects failures for resilience testing.
+//
+// The hooks are compiled in only with the "chaos" build tag; in normal
+// builds every hook is a no-op the compiler removes. In a chaos build the
+// failures are configured with Configure, or for a whole daemon from the
+// KLINGDEX_CHAOS environment variable holding a JSON Config, e.g.
+//
+//	KLINGDEX_CHAOS='{"drop_message_bps":500,"crash_points":{"after_job_persist":2000}}'
+//
+// Rates are in basis points (1/100 of a percent). The hooks:
+//
+//   - DropMessage: P2P messages are lost on send. Gossip is dropped
+//     silently; direct messages fail as if the peer never acknowledged them.
+//   - DelayBackend: chain backend requests are delayed by BackendDelayMs.
+//   - StorageError: swap, job and outbox writes fail with ErrInjected.
+//   - CrashPoint: the process exits (or panics with *Crash) at a named
+//     point before or after state is persisted.
+package chaos
+
+import (
+	"errors"
+	"fmt"
+)
+
+// EnvVar holds the JSON Config a chaos build applies at startup.
+const EnvVar = "KLINGDEX_CHAOS"
+
+// CrashExitCode is the exit status of an injected crash.
+const CrashExitCode = 86
+
+// Crash points around persistence.
+const (
+	PointBeforeSaveSwap      = "before_save_swap"      // Swap state decided, not yet saved
+	PointAfterSaveSwap       = "after_save_swap"       // Swap state saved
+	PointAfterJobPersist     = "after_job_persist"     // Side effect recorded as a job, not executed
+	PointAfterSideEffect     = "after_side_effect"     // Side effect executed, job not marked done
+	PointAfterMessageEnqueue = "after_message_enqueue" // Direct message in the outbox, not sent
+)
+
+// Points lists the crash points.
+var Points = []string{
+	PointBeforeSaveSwap,
+	PointAfterSaveSwap,
+	PointAfterJobPersist,
+	PointAfterSideEffect,
+	PointAfterMessageEnqueue,
+}
+
+func knownPoint(point string) bool {
+	for _, p := range Points {
+		if p == point {
+			return true
+		}
+	}
+	return false
+}
+
+// ErrInjected is the error injected storage writes and dropped direct
+// messages fail with.
+var ErrInjected = errors.New("chaos: injected failure")
+
+// Config sets the failure rates.
+type Config struct {
+	// DropMessageBps drops P2P messages; MessageTypes limits drops to the
+	// given message types (empty drops any type).
+	DropMessageBps int      `json:"drop_message_bps,omitempty"`
+	MessageTypes   []string `json:"message_types,omitempty"`
+
+	// BackendDelayBps delays chain backend requests by BackendDelayMs.
+	BackendDelayBps int `json:"backend_delay_bps,omitempty"`
+	BackendDelayMs  int `json:"backend_delay_ms,omitempty"`
+
+	// StorageErrorBps fails swap, job and outbox writes.
+	StorageErrorBps int `json:"storage_error_bps,omitempty"`
+
+	// CrashPoints maps crash points to the rate of crashing there.
+	CrashPoints map[string]int `json:"crash_points,omitempty"`
+
+	// CrashPanic panics with *Crash instead of exiting, for in-process
+	// tests that recover and restart the component.
+	CrashPanic bool `json:"crash_panic,omitempty"`
+
+	// Seed makes the injected failures reproducible (0 seeds from the
+	// clock).
+	Seed int64 `json:"seed,omitempty"`
+}
+
+// Validate checks the rates.
+func (c Config) Validate() error {
+	rates := map[string]int{
+		"drop_message_bps":  c.DropMessageBps,
+		"backend_delay_bps": c.BackendDelayBps,
+		"storage_error_bps": c.StorageErrorBps,
+	}
+	for point, bps := range c.CrashPoints {
+		if !knownPoint(point) {
+			return fmt.Errorf("unknown chaos crash point %q", point)
+		}
+		rates["crash_points."+point] = bps
+	}
+	for name, bps := range rates {
+		if bps < 0 || bps > 10000 {
+			return fmt.Errorf("chaos %s must be between 0 and 10000 basis points", name)
+		}
+	}
+	if c.BackendDelayBps > 0 && c.BackendDelayMs <= 0 {
+		return fmt.Errorf("chaos backend_delay_bps needs a backend_delay_ms")
+	}
+	return nil
+}
+
+// Crash is the panic value of an injected crash with CrashPanic.
+type Crash struct {
+	Point string
+}
+
+func (c *Crash) Error() string {
+	return "chaos: crash at " + c.Point
+}
+
+// Stats counts the injected failures.
+type Stats struct {
+	DroppedMessages int `json:"dropped_messages"`
+	DelayedRequests int `json:"delayed_requests"`
+	StorageErrors   int `json:"storage_errors"`
+	Crashes         int `json:"crashes"`
+}
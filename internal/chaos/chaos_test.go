@@ -0,0 +1,42 @@
+package chaos
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     Config
+		wantErr string
+	}{
+		{"empty", Config{}, ""},
+		{"all", Config{
+			DropMessageBps:  500,
+			BackendDelayBps: 1000,
+			BackendDelayMs:  200,
+			StorageErrorBps: 10000,
+			CrashPoints:     map[string]int{PointAfterSideEffect: 2500},
+		}, ""},
+		{"rate above 100%", Config{DropMessageBps: 10001}, "drop_message_bps"},
+		{"negative rate", Config{StorageErrorBps: -1}, "storage_error_bps"},
+		{"delay without duration", Config{BackendDelayBps: 100}, "backend_delay_ms"},
+		{"unknown point", Config{CrashPoints: map[string]int{"after_lunch": 1}}, "after_lunch"},
+		{"point rate", Config{CrashPoints: map[string]int{PointBeforeSaveSwap: 20000}}, PointBeforeSaveSwap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.cfg.Validate()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Validate() error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+//go:build !chaos
+
+package chaos
+
+import (
+	"context"
+	"fmt"
+)
+
+// Enabled reports whether the chaos hooks are compiled in.
+const Enabled = false
+
+// Configure fails: the hooks are not compiled in.
+func Configure(cfg Config) error {
+	return fmt.Errorf("chaos hooks not compiled in (build with -tags chaos)")
+}
+
+// DropMessage reports whether to drop a P2P message of msgType.
+func DropMessage(msgType string) bool { return false }
+
+// DelayBackend delays a chain backend request.
+func DelayBackend(ctx context.Context) error { return nil }
+
+// StorageError returns the error to fail the storage write op with.
+func StorageError(op string) error { return nil }
+
+// CrashPoint crashes the process at point.
+func CrashPoint(point string) {}
+
+// GetStats returns the injected failure counts.
+func GetStats() Stats { return Stats{} }
@@ -0,0 +1,30 @@
+//go:build !chaos
+
+package chaos
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHooksDisabled(t *testing.T) {
+	if Enabled {
+		t.Fatal("Enabled = true without the chaos tag")
+	}
+	if err := Configure(Config{StorageErrorBps: 10000}); err == nil {
+		t.Fatal("Configure() succeeded without the chaos tag")
+	}
+	if DropMessage("swap_offer") {
+		t.Error("DropMessage() = true")
+	}
+	if err := DelayBackend(context.Background()); err != nil {
+		t.Errorf("DelayBackend() error = %v", err)
+	}
+	if err := StorageError("save_swap"); err != nil {
+		t.Errorf("StorageError() error = %v", err)
+	}
+	CrashPoint(PointAfterSideEffect)
+	if st := GetStats(); st != (Stats{}) {
+		t.Errorf("GetStats() = %+v, want zero", st)
+	}
+}
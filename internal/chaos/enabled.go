@@ -0,0 +1,146 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"context"
+	"encoding/json"
+	"math/rand"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Enabled reports whether the chaos hooks are compiled in.
+const Enabled = true
+
+var (
+	mu    sync.Mutex
+	cfg   Config
+	rng   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	stats Stats
+	log   = logging.GetDefault().Component("chaos")
+)
+
+func init() {
+	raw := os.Getenv(EnvVar)
+	if raw == "" {
+		return
+	}
+	var c Config
+	if err := json.Unmarshal([]byte(raw), &c); err != nil {
+		log.Error("Invalid chaos config", "env", EnvVar, "error", err)
+		os.Exit(2)
+	}
+	if err := Configure(c); err != nil {
+		log.Error("Invalid chaos config", "env", EnvVar, "error", err)
+		os.Exit(2)
+	}
+}
+
+// Configure replaces the failure rates and resets the counts.
+func Configure(c Config) error {
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	cfg = c
+	stats = Stats{}
+	if c.Seed != 0 {
+		rng = rand.New(rand.NewSource(c.Seed))
+	}
+	log.Warn("Chaos failure injection active",
+		"drop_message_bps", c.DropMessageBps,
+		"backend_delay_bps", c.BackendDelayBps,
+		"storage_error_bps", c.StorageErrorBps,
+		"crash_points", c.CrashPoints,
+	)
+	return nil
+}
+
+// roll reports whether an event with rate bps happens. Caller holds mu.
+func roll(bps int) bool {
+	return bps > 0 && rng.Intn(10000) < bps
+}
+
+// DropMessage reports whether to drop a P2P message of msgType.
+func DropMessage(msgType string) bool {
+	mu.Lock()
+	defer mu.Unlock()
+	if len(cfg.MessageTypes) > 0 {
+		match := false
+		for _, t := range cfg.MessageTypes {
+			match = match || t == msgType
+		}
+		if !match {
+			return false
+		}
+	}
+	if !roll(cfg.DropMessageBps) {
+		return false
+	}
+	stats.DroppedMessages++
+	log.Debug("Dropping P2P message", "type", msgType)
+	return true
+}
+
+// DelayBackend delays a chain backend request.
+func DelayBackend(ctx context.Context) error {
+	mu.Lock()
+	delay := time.Duration(0)
+	if roll(cfg.BackendDelayBps) {
+		delay = time.Duration(cfg.BackendDelayMs) * time.Millisecond
+		stats.DelayedRequests++
+	}
+	mu.Unlock()
+	if delay == 0 {
+		return nil
+	}
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(delay):
+		return nil
+	}
+}
+
+// StorageError returns the error to fail the storage write op with.
+func StorageError(op string) error {
+	mu.Lock()
+	defer mu.Unlock()
+	if !roll(cfg.StorageErrorBps) {
+		return nil
+	}
+	stats.StorageErrors++
+	log.Debug("Failing storage write", "op", op)
+	return ErrInjected
+}
+
+// CrashPoint crashes the process at point.
+func CrashPoint(point string) {
+	mu.Lock()
+	crash := roll(cfg.CrashPoints[point])
+	if crash {
+		stats.Crashes++
+	}
+	panicOnCrash := cfg.CrashPanic
+	mu.Unlock()
+	if !crash {
+		return
+	}
+	log.Warn("Injected crash", "point", point)
+	if panicOnCrash {
+		panic(&Crash{Point: point})
+	}
+	os.Exit(CrashExitCode)
+}
+
+// GetStats returns the injected failure counts.
+func GetStats() Stats {
+	mu.Lock()
+	defer mu.Unlock()
+	return stats
+}
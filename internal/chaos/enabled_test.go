@@ -0,0 +1,96 @@
+//go:build chaos
+
+package chaos
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func configure(t *testing.T, cfg Config) {
+	t.Helper()
+	if err := Configure(cfg); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	t.Cleanup(func() { Configure(Config{}) })
+}
+
+func TestDropMessage(t *testing.T) {
+	configure(t, Config{DropMessageBps: 10000, MessageTypes: []string{"swap_pubkey"}})
+	if !DropMessage("swap_pubkey") {
+		t.Error("DropMessage(swap_pubkey) = false at 100%")
+	}
+	if DropMessage("swap_offer") {
+		t.Error("DropMessage(swap_offer) = true for a type not listed")
+	}
+	if got := GetStats().DroppedMessages; got != 1 {
+		t.Errorf("DroppedMessages = %d, want 1", got)
+	}
+}
+
+func TestDropMessageRate(t *testing.T) {
+	configure(t, Config{DropMessageBps: 2500, Seed: 1})
+	dropped := 0
+	for i := 0; i < 10000; i++ {
+		if DropMessage("swap_offer") {
+			dropped++
+		}
+	}
+	// 25% with a generous margin
+	if dropped < 2200 || dropped > 2800 {
+		t.Errorf("dropped %d of 10000 at 2500 bps", dropped)
+	}
+}
+
+func TestDelayBackend(t *testing.T) {
+	configure(t, Config{BackendDelayBps: 10000, BackendDelayMs: 50})
+	start := time.Now()
+	if err := DelayBackend(context.Background()); err != nil {
+		t.Fatalf("DelayBackend() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("DelayBackend() returned after %s, want >= 50ms", elapsed)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := DelayBackend(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("DelayBackend() with cancelled ctx error = %v", err)
+	}
+}
+
+func TestStorageError(t *testing.T) {
+	if err := StorageError("save_swap"); err != nil {
+		t.Fatalf("StorageError() unconfigured error = %v", err)
+	}
+	configure(t, Config{StorageErrorBps: 10000})
+	if err := StorageError("save_swap"); !errors.Is(err, ErrInjected) {
+		t.Fatalf("StorageError() error = %v, want ErrInjected", err)
+	}
+}
+
+func TestCrashPointPanics(t *testing.T) {
+	configure(t, Config{CrashPoints: map[string]int{PointAfterSaveSwap: 10000}, CrashPanic: true})
+
+	CrashPoint(PointBeforeSaveSwap) // Not configured
+
+	defer func() {
+		crash, ok := recover().(*Crash)
+		if !ok || crash.Point != PointAfterSaveSwap {
+			t.Fatalf("recovered %v, want crash at %s", crash, PointAfterSaveSwap)
+		}
+		if got := GetStats().Crashes; got != 1 {
+			t.Errorf("Crashes = %d, want 1", got)
+		}
+	}()
+	CrashPoint(PointAfterSaveSwap)
+	t.Fatal("CrashPoint() returned")
+}
+
+func TestConfigureRejectsInvalid(t *testing.T) {
+	if err := Configure(Config{DropMessageBps: 20000}); err == nil {
+		t.Fatal("Configure() accepted an invalid config")
+	}
+}
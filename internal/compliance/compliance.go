@@ -0,0 +1,543 @@
+// Package compliance streams signed audit records of each trade to an
+// operator's compliance endpoint.
+//
+// At every configured checkpoint (started, funded, claimed, completed,
+// refunded) the node records a snapshot of the trade: counterparty peer ID,
+// addresses, amounts, timestamps and transaction IDs. Records form a hash
+// chain: each hash covers the previous hash and the record entry, so a
+// removed, reordered or edited record breaks every hash after it. Each hash
+// is signed with the node's libp2p identity key.
+//
+// Records are stored before delivery and POSTed in sequence order. While the
+// endpoint is down they stay queued and are retried every RetryInterval.
+package compliance
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// SignatureHeader carries the HMAC-SHA256 of the body when a secret is set.
+const SignatureHeader = "X-Klingdex-Signature"
+
+// GenesisHash is the prev_hash of the first record.
+var GenesisHash = hex.EncodeToString(make([]byte, sha256.Size))
+
+// deliveryBatch bounds the records sent per delivery run.
+const deliveryBatch = 100
+
+// checkpointEvents maps swap event types to checkpoints. Events not listed
+// are not recorded.
+var checkpointEvents = map[string]string{
+	"swap_initiated":             node.ComplianceCheckpointStarted,
+	"swap_joined":                node.ComplianceCheckpointStarted,
+	"cross_chain_swap_initiated": node.ComplianceCheckpointStarted,
+	"cross_chain_swap_joined":    node.ComplianceCheckpointStarted,
+	"evm_swap_initiated":         node.ComplianceCheckpointStarted,
+	"evm_swap_joined":            node.ComplianceCheckpointStarted,
+
+	"funding_broadcast": node.ComplianceCheckpointFunded,
+	"funding_set":       node.ComplianceCheckpointFunded,
+	"funding_confirmed": node.ComplianceCheckpointFunded,
+	"evm_htlc_created":  node.ComplianceCheckpointFunded,
+
+	"htlc_claimed":     node.ComplianceCheckpointClaimed,
+	"evm_htlc_claimed": node.ComplianceCheckpointClaimed,
+	"secret_revealed":  node.ComplianceCheckpointClaimed,
+
+	"swap_completed": node.ComplianceCheckpointCompleted,
+
+	"swap_refunded":     node.ComplianceCheckpointRefunded,
+	"refunded":          node.ComplianceCheckpointRefunded,
+	"timeout_refund":    node.ComplianceCheckpointRefunded,
+	"htlc_refunded":     node.ComplianceCheckpointRefunded,
+	"evm_htlc_refunded": node.ComplianceCheckpointRefunded,
+}
+
+// Checkpoint returns the checkpoint a swap event type belongs to, or "".
+func Checkpoint(eventType string) string {
+	return checkpointEvents[eventType]
+}
+
+// SnapshotSource provides the audit view of a trade. *swap.Coordinator
+// implements it.
+type SnapshotSource interface {
+	AuditSnapshot(tradeID string) (*swap.AuditSnapshot, error)
+}
+
+// Entry is the audited content of a record.
+type Entry struct {
+	Seq        int64               `json:"seq"`
+	NodeID     string              `json:"node_id"`
+	Checkpoint string              `json:"checkpoint"`
+	Event      string              `json:"event"`
+	TradeID    string              `json:"trade_id"`
+	Timestamp  int64               `json:"timestamp"` // Unix milliseconds
+	Swap       *swap.AuditSnapshot `json:"swap,omitempty"`
+	EventData  json.RawMessage     `json:"event_data,omitempty"`
+}
+
+// Record is what the endpoint receives. Hash is hex SHA-256 over the
+// decoded PrevHash followed by the exact Entry bytes; Signature is the
+// base64 signature of the decoded Hash by the NodeID key.
+type Record struct {
+	Seq       int64           `json:"seq"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+	Signature string          `json:"signature"`
+	NodeID    string          `json:"node_id"`
+	Entry     json.RawMessage `json:"entry"`
+}
+
+// Status describes the streamer state.
+type Status struct {
+	Enabled         bool     `json:"enabled"`
+	Endpoint        string   `json:"endpoint,omitempty"`
+	NodeID          string   `json:"node_id"`
+	Checkpoints     []string `json:"checkpoints"`
+	Total           int      `json:"total"`
+	Pending         int      `json:"pending"`
+	LastSeq         int64    `json:"last_seq"`
+	LastDeliveredAt int64    `json:"last_delivered_at,omitempty"`
+	LastError       string   `json:"last_error,omitempty"`
+}
+
+// Streamer records audit records at checkpoints and delivers them.
+type Streamer struct {
+	cfg         node.ComplianceConfig
+	store       *storage.Storage
+	source      SnapshotSource
+	key         crypto.PrivKey
+	nodeID      string
+	checkpoints map[string]bool
+	client      *http.Client
+	log         *logging.Logger
+
+	appendMu        sync.Mutex // Serializes chain appends
+	deliverMu       sync.Mutex // Serializes deliveries
+	mu              sync.RWMutex
+	lastDeliveredAt int64
+	lastError       string
+
+	wake   chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a streamer that signs records with key.
+func New(cfg node.ComplianceConfig, store *storage.Storage, source SnapshotSource, key crypto.PrivKey) (*Streamer, error) {
+	defaults := node.DefaultConfig().Compliance
+	if cfg.RetryInterval <= 0 {
+		cfg.RetryInterval = defaults.RetryInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.Enabled && cfg.Endpoint == "" {
+		return nil, fmt.Errorf("compliance enabled without endpoint")
+	}
+	if key == nil {
+		return nil, fmt.Errorf("compliance signing key is required")
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid compliance signing key: %w", err)
+	}
+
+	checkpoints := make(map[string]bool)
+	for _, cp := range cfg.Checkpoints {
+		if !validCheckpoint(cp) {
+			return nil, fmt.Errorf("unknown compliance checkpoint %q", cp)
+		}
+		checkpoints[cp] = true
+	}
+	if len(checkpoints) == 0 {
+		for _, cp := range checkpointEvents {
+			checkpoints[cp] = true
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Streamer{
+		cfg:         cfg,
+		store:       store,
+		source:      source,
+		key:         key,
+		nodeID:      id.String(),
+		checkpoints: checkpoints,
+		client:      &http.Client{Timeout: cfg.Timeout},
+		log:         logging.GetDefault().Component("compliance"),
+		wake:        make(chan struct{}, 1),
+		ctx:         ctx,
+		cancel:      cancel,
+	}, nil
+}
+
+func validCheckpoint(cp string) bool {
+	for _, known := range checkpointEvents {
+		if cp == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Start delivers queued records every RetryInterval and after each new record.
+func (s *Streamer) Start() {
+	if !s.cfg.Enabled {
+		return
+	}
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.cfg.RetryInterval)
+		defer ticker.Stop()
+		for {
+			if err := s.Deliver(s.ctx); err != nil {
+				s.log.Warn("Compliance delivery failed", "error", err)
+			}
+			select {
+			case <-s.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-s.wake:
+			}
+		}
+	}()
+	s.log.Info("Compliance streaming started", "endpoint", s.cfg.Endpoint, "retry_interval", s.cfg.RetryInterval)
+}
+
+// Stop stops delivery. Queued records are delivered after the next start.
+func (s *Streamer) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+// HandleSwapEvent records checkpoint events. Register it with
+// Coordinator.OnEvent.
+func (s *Streamer) HandleSwapEvent(event swap.SwapEvent) {
+	if !s.cfg.Enabled {
+		return
+	}
+	cp := Checkpoint(event.EventType)
+	if cp == "" || !s.checkpoints[cp] {
+		return
+	}
+	if _, err := s.Record(event, cp); err != nil {
+		s.log.Error("Failed to record compliance checkpoint", "trade_id", event.TradeID, "event", event.EventType, "error", err)
+		return
+	}
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Record appends a signed record for event to the chain.
+func (s *Streamer) Record(event swap.SwapEvent, checkpoint string) (*Record, error) {
+	entry := Entry{
+		NodeID:     s.nodeID,
+		Checkpoint: checkpoint,
+		Event:      event.EventType,
+		TradeID:    event.TradeID,
+		Timestamp:  event.Timestamp.UnixMilli(),
+	}
+	if event.Timestamp.IsZero() {
+		entry.Timestamp = time.Now().UnixMilli()
+	}
+	if s.source != nil {
+		snap, err := s.source.AuditSnapshot(event.TradeID)
+		if err != nil {
+			// Record the event anyway; a gap in the timeline is worse
+			s.log.Warn("No swap snapshot for compliance record", "trade_id", event.TradeID, "error", err)
+		}
+		entry.Swap = snap
+	}
+	if event.Data != nil {
+		data, err := json.Marshal(event.Data)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode event data: %w", err)
+		}
+		entry.EventData = data
+	}
+
+	s.appendMu.Lock()
+	defer s.appendMu.Unlock()
+
+	prevHash := GenesisHash
+	last, err := s.store.LastComplianceRecord()
+	if err != nil {
+		return nil, err
+	}
+	if last != nil {
+		entry.Seq = last.Seq + 1
+		prevHash = last.Hash
+	} else {
+		entry.Seq = 1
+	}
+
+	record, err := s.seal(entry, prevHash)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := json.Marshal(record)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.store.AppendComplianceRecord(&storage.ComplianceRecord{
+		Seq:        record.Seq,
+		TradeID:    entry.TradeID,
+		Checkpoint: checkpoint,
+		Hash:       record.Hash,
+		Payload:    payload,
+	}); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// seal hashes and signs entry as the successor of prevHash.
+func (s *Streamer) seal(entry Entry, prevHash string) (*Record, error) {
+	entryJSON, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	hash, err := chainHash(prevHash, entryJSON)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := s.key.Sign(hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign compliance record: %w", err)
+	}
+	return &Record{
+		Seq:       entry.Seq,
+		PrevHash:  prevHash,
+		Hash:      hex.EncodeToString(hash),
+		Signature: base64.StdEncoding.EncodeToString(sig),
+		NodeID:    s.nodeID,
+		Entry:     entryJSON,
+	}, nil
+}
+
+func chainHash(prevHash string, entry []byte) ([]byte, error) {
+	prev, err := hex.DecodeString(prevHash)
+	if err != nil || len(prev) != sha256.Size {
+		return nil, fmt.Errorf("invalid prev_hash %q", prevHash)
+	}
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(entry)
+	return h.Sum(nil), nil
+}
+
+// Deliver sends queued records in sequence order, stopping at the first
+// failure so the endpoint never sees a gap.
+func (s *Streamer) Deliver(ctx context.Context) error {
+	if !s.cfg.Enabled {
+		return fmt.Errorf("compliance streaming is not enabled")
+	}
+
+	s.deliverMu.Lock()
+	defer s.deliverMu.Unlock()
+
+	pending, err := s.store.PendingComplianceRecords(deliveryBatch)
+	if err != nil {
+		return err
+	}
+	for _, r := range pending {
+		if err := s.post(ctx, r.Payload); err != nil {
+			if markErr := s.store.MarkComplianceRecordFailed(r.Seq, err.Error()); markErr != nil {
+				s.log.Warn("Failed to update compliance record", "seq", r.Seq, "error", markErr)
+			}
+			s.setResult(err)
+			return fmt.Errorf("record %d: %w", r.Seq, err)
+		}
+		if err := s.store.MarkComplianceRecordDelivered(r.Seq); err != nil {
+			return err
+		}
+		s.setResult(nil)
+	}
+	if len(pending) == deliveryBatch {
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+func (s *Streamer) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.cfg.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.cfg.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign(s.cfg.Secret, body))
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned %s", resp.Status)
+	}
+	return nil
+}
+
+func (s *Streamer) setResult(err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err != nil {
+		s.lastError = err.Error()
+		return
+	}
+	s.lastError = ""
+	s.lastDeliveredAt = time.Now().Unix()
+}
+
+// Sign returns the hex HMAC-SHA256 of body, as sent in SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Status returns the streamer state and queue totals.
+func (s *Streamer) Status() (*Status, error) {
+	stats, err := s.store.GetComplianceStats()
+	if err != nil {
+		return nil, err
+	}
+	st := &Status{
+		Enabled:  s.cfg.Enabled,
+		Endpoint: s.cfg.Endpoint,
+		NodeID:   s.nodeID,
+		Total:    stats.Total,
+		Pending:  stats.Pending,
+		LastSeq:  stats.LastSeq,
+	}
+	for _, cp := range []string{
+		node.ComplianceCheckpointStarted, node.ComplianceCheckpointFunded, node.ComplianceCheckpointClaimed,
+		node.ComplianceCheckpointCompleted, node.ComplianceCheckpointRefunded,
+	} {
+		if s.checkpoints[cp] {
+			st.Checkpoints = append(st.Checkpoints, cp)
+		}
+	}
+	s.mu.RLock()
+	st.LastDeliveredAt = s.lastDeliveredAt
+	st.LastError = s.lastError
+	s.mu.RUnlock()
+	return st, nil
+}
+
+// Records returns stored records after afterSeq in chain order, optionally
+// for one trade.
+func (s *Streamer) Records(tradeID string, afterSeq int64, limit int) ([]*Record, error) {
+	stored, err := s.store.ListComplianceRecords(tradeID, afterSeq, limit)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]*Record, 0, len(stored))
+	for _, r := range stored {
+		var rec Record
+		if err := json.Unmarshal(r.Payload, &rec); err != nil {
+			return nil, fmt.Errorf("corrupt compliance record %d: %w", r.Seq, err)
+		}
+		records = append(records, &rec)
+	}
+	return records, nil
+}
+
+// VerifyChain checks the whole stored chain and returns the number of
+// records verified.
+func (s *Streamer) VerifyChain() (int, error) {
+	var all []*Record
+	var after int64
+	for {
+		page, err := s.Records("", after, 1000)
+		if err != nil {
+			return 0, err
+		}
+		if len(page) == 0 {
+			break
+		}
+		all = append(all, page...)
+		after = page[len(page)-1].Seq
+	}
+	if err := Verify(all, GenesisHash); err != nil {
+		return 0, err
+	}
+	return len(all), nil
+}
+
+// Verify checks that records are consecutive, correctly chained from
+// prevHash and signed by their node ID. Pass GenesisHash to verify a chain
+// from its first record.
+func Verify(records []*Record, prevHash string) error {
+	for i, r := range records {
+		if i > 0 && r.Seq != records[i-1].Seq+1 {
+			return fmt.Errorf("record %d: sequence gap after %d", r.Seq, records[i-1].Seq)
+		}
+		if r.PrevHash != prevHash {
+			return fmt.Errorf("record %d: prev_hash does not match the previous record", r.Seq)
+		}
+		hash, err := chainHash(r.PrevHash, r.Entry)
+		if err != nil {
+			return fmt.Errorf("record %d: %w", r.Seq, err)
+		}
+		if hex.EncodeToString(hash) != r.Hash {
+			return fmt.Errorf("record %d: hash mismatch", r.Seq)
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(r.Entry, &entry); err != nil {
+			return fmt.Errorf("record %d: invalid entry: %w", r.Seq, err)
+		}
+		if entry.Seq != r.Seq || entry.NodeID != r.NodeID {
+			return fmt.Errorf("record %d: entry does not match the record", r.Seq)
+		}
+
+		id, err := peer.Decode(r.NodeID)
+		if err != nil {
+			return fmt.Errorf("record %d: invalid node ID: %w", r.Seq, err)
+		}
+		pub, err := id.ExtractPublicKey()
+		if err != nil {
+			return fmt.Errorf("record %d: no public key in node ID: %w", r.Seq, err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(r.Signature)
+		if err != nil {
+			return fmt.Errorf("record %d: invalid signature encoding: %w", r.Seq, err)
+		}
+		ok, err := pub.Verify(hash, sig)
+		if err != nil || !ok {
+			return fmt.Errorf("record %d: invalid signature", r.Seq)
+		}
+		prevHash = r.Hash
+	}
+	return nil
+}
@@ -0,0 +1,240 @@
+package compliance
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// fakeSource returns a fixed snapshot for every trade.
+type fakeSource struct{}
+
+func (fakeSource) AuditSnapshot(tradeID string) (*swap.AuditSnapshot, error) {
+	return &swap.AuditSnapshot{
+		TradeID:            tradeID,
+		CounterpartyPeerID: "12D3KooWCounterparty",
+		OfferChain:         "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		LocalFundingTxID: "fund-txid",
+	}, nil
+}
+
+// endpoint records delivered bodies and can be switched off.
+type endpoint struct {
+	mu     sync.Mutex
+	down   bool
+	bodies [][]byte
+	sigs   []string
+}
+
+func (e *endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.down {
+		http.Error(w, "maintenance", http.StatusServiceUnavailable)
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	e.bodies = append(e.bodies, body)
+	e.sigs = append(e.sigs, r.Header.Get(SignatureHeader))
+}
+
+func newTestStreamer(t *testing.T, cfg node.ComplianceConfig) *Streamer {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatalf("GenerateEd25519Key() error = %v", err)
+	}
+	s, err := New(cfg, store, fakeSource{}, key)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return s
+}
+
+func swapEvent(tradeID, eventType string) swap.SwapEvent {
+	return swap.SwapEvent{
+		TradeID:   tradeID,
+		EventType: eventType,
+		Data:      map[string]string{"txid": "abc"},
+		Timestamp: time.Unix(1700000000, 0),
+	}
+}
+
+func TestNewValidation(t *testing.T) {
+	key, _, _ := crypto.GenerateEd25519Key(nil)
+	if _, err := New(node.ComplianceConfig{Enabled: true}, nil, nil, key); err == nil {
+		t.Error("New() should require an endpoint when enabled")
+	}
+	if _, err := New(node.ComplianceConfig{Checkpoints: []string{"settled"}}, nil, nil, key); err == nil {
+		t.Error("New() should reject unknown checkpoints")
+	}
+	if _, err := New(node.ComplianceConfig{}, nil, nil, nil); err == nil {
+		t.Error("New() should require a signing key")
+	}
+}
+
+func TestRecordChain(t *testing.T) {
+	s := newTestStreamer(t, node.ComplianceConfig{Enabled: true, Endpoint: "http://127.0.0.1:1"})
+
+	s.HandleSwapEvent(swapEvent("t1", "swap_initiated"))
+	s.HandleSwapEvent(swapEvent("t1", "nonces_exchanged")) // Not a checkpoint
+	s.HandleSwapEvent(swapEvent("t1", "funding_broadcast"))
+	s.HandleSwapEvent(swapEvent("t2", "swap_joined"))
+	s.HandleSwapEvent(swapEvent("t1", "swap_completed"))
+
+	records, err := s.Records("", 0, 100)
+	if err != nil {
+		t.Fatalf("Records() error = %v", err)
+	}
+	if len(records) != 4 {
+		t.Fatalf("Records() = %d records, want 4", len(records))
+	}
+	if records[0].PrevHash != GenesisHash || records[1].PrevHash != records[0].Hash {
+		t.Error("records are not chained")
+	}
+	if err := Verify(records, GenesisHash); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+	if n, err := s.VerifyChain(); err != nil || n != 4 {
+		t.Errorf("VerifyChain() = %d, %v, want 4", n, err)
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(records[1].Entry, &entry); err != nil {
+		t.Fatalf("Unmarshal(entry) error = %v", err)
+	}
+	if entry.Checkpoint != "funded" || entry.Event != "funding_broadcast" || entry.TradeID != "t1" {
+		t.Errorf("entry = %+v", entry)
+	}
+	if entry.Swap == nil || entry.Swap.CounterpartyPeerID != "12D3KooWCounterparty" || entry.Swap.LocalFundingTxID != "fund-txid" {
+		t.Errorf("entry.Swap = %+v", entry.Swap)
+	}
+	if entry.Timestamp != 1700000000000 || string(entry.EventData) != `{"txid":"abc"}` {
+		t.Errorf("entry timestamp/data = %d %s", entry.Timestamp, entry.EventData)
+	}
+
+	t1, _ := s.Records("t1", 0, 100)
+	if len(t1) != 3 {
+		t.Errorf("Records(t1) = %d, want 3", len(t1))
+	}
+
+	// Tampering breaks verification
+	tampered := *records[1]
+	tampered.Entry = json.RawMessage(strings.Replace(string(tampered.Entry), "fund-txid", "other-txid", 1))
+	if err := Verify([]*Record{records[0], &tampered, records[2]}, GenesisHash); err == nil {
+		t.Error("Verify() should reject an edited entry")
+	}
+	if err := Verify([]*Record{records[0], records[2]}, GenesisHash); err == nil {
+		t.Error("Verify() should reject a removed record")
+	}
+	forged := *records[0]
+	forged.Signature = records[1].Signature
+	if err := Verify([]*Record{&forged}, GenesisHash); err == nil {
+		t.Error("Verify() should reject a wrong signature")
+	}
+
+	// A partial chain verifies from the last known hash
+	if err := Verify(records[2:], records[1].Hash); err != nil {
+		t.Errorf("Verify(tail) error = %v", err)
+	}
+}
+
+func TestCheckpointFilter(t *testing.T) {
+	s := newTestStreamer(t, node.ComplianceConfig{
+		Enabled:     true,
+		Endpoint:    "http://127.0.0.1:1",
+		Checkpoints: []string{node.ComplianceCheckpointCompleted, node.ComplianceCheckpointRefunded},
+	})
+
+	s.HandleSwapEvent(swapEvent("t1", "swap_initiated"))
+	s.HandleSwapEvent(swapEvent("t1", "htlc_refunded"))
+
+	st, err := s.Status()
+	if err != nil {
+		t.Fatalf("Status() error = %v", err)
+	}
+	if st.Total != 1 || len(st.Checkpoints) != 2 {
+		t.Errorf("Status() = %+v, want 1 record and 2 checkpoints", st)
+	}
+
+	disabled := newTestStreamer(t, node.ComplianceConfig{})
+	disabled.HandleSwapEvent(swapEvent("t1", "swap_initiated"))
+	if st, _ := disabled.Status(); st.Total != 0 {
+		t.Errorf("disabled streamer recorded %d records", st.Total)
+	}
+}
+
+func TestDeliverQueuesWhileDown(t *testing.T) {
+	ep := &endpoint{down: true}
+	srv := httptest.NewServer(ep)
+	defer srv.Close()
+
+	s := newTestStreamer(t, node.ComplianceConfig{Enabled: true, Endpoint: srv.URL, Secret: "s3cret"})
+	s.HandleSwapEvent(swapEvent("t1", "swap_initiated"))
+	s.HandleSwapEvent(swapEvent("t1", "swap_completed"))
+
+	if err := s.Deliver(context.Background()); err == nil {
+		t.Fatal("Deliver() should fail while the endpoint is down")
+	}
+	st, _ := s.Status()
+	if st.Pending != 2 || st.LastError == "" {
+		t.Errorf("Status() while down = %+v, want 2 pending with an error", st)
+	}
+
+	ep.mu.Lock()
+	ep.down = false
+	ep.mu.Unlock()
+
+	if err := s.Deliver(context.Background()); err != nil {
+		t.Fatalf("Deliver() error = %v", err)
+	}
+	st, _ = s.Status()
+	if st.Pending != 0 || st.LastError != "" || st.LastDeliveredAt == 0 {
+		t.Errorf("Status() after delivery = %+v", st)
+	}
+
+	if len(ep.bodies) != 2 {
+		t.Fatalf("endpoint received %d records, want 2", len(ep.bodies))
+	}
+	var delivered []*Record
+	for i, body := range ep.bodies {
+		if ep.sigs[i] != "sha256="+Sign("s3cret", body) {
+			t.Errorf("record %d has HMAC %q", i, ep.sigs[i])
+		}
+		var r Record
+		if err := json.Unmarshal(body, &r); err != nil {
+			t.Fatalf("Unmarshal(body) error = %v", err)
+		}
+		delivered = append(delivered, &r)
+	}
+	if delivered[0].Seq != 1 || delivered[1].Seq != 2 {
+		t.Errorf("delivered out of order: %d, %d", delivered[0].Seq, delivered[1].Seq)
+	}
+	if err := Verify(delivered, GenesisHash); err != nil {
+		t.Errorf("Verify(delivered) error = %v", err)
+	}
+
+	// Nothing is re-sent
+	if err := s.Deliver(context.Background()); err != nil || len(ep.bodies) != 2 {
+		t.Errorf("second Deliver() = %v, %d bodies", err, len(ep.bodies))
+	}
+}
@@ -3,7 +3,11 @@
 // No hardcoded values should exist elsewhere in the codebase.
 package config
 
-import "time"
+import (
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
 
 // =============================================================================
 // Network Types
@@ -340,6 +344,97 @@ var TestnetChainParams = map[string]ChainParams{
 	},
 }
 
+// TestnetVariantChainParams contains parameters for chains with more than one
+// selectable test network, keyed by symbol and test network name. Entries
+// override TestnetChainParams for the chain's selected test network.
+var TestnetVariantChainParams = map[string]map[string]ChainParams{
+	"BTC": {
+		chain.TestnetBTCTestnet3: {
+			ExplorerURL:   "https://mempool.space/testnet",
+			Confirmations: 1,
+		},
+		chain.TestnetBTCTestnet4: {
+			ExplorerURL:   "https://mempool.space/testnet4",
+			Confirmations: 1,
+		},
+		chain.TestnetBTCSignet: {
+			ExplorerURL:   "https://mempool.space/signet",
+			Confirmations: 1,
+		},
+	},
+	"ETH": {
+		chain.TestnetETHSepolia: {
+			ChainID:       11155111,
+			RPCEndpoint:   "https://rpc.sepolia.org",
+			ExplorerURL:   "https://sepolia.etherscan.io",
+			Confirmations: 2,
+		},
+		chain.TestnetETHHolesky: {
+			ChainID:       17000,
+			RPCEndpoint:   "https://ethereum-holesky-rpc.publicnode.com",
+			ExplorerURL:   "https://holesky.etherscan.io",
+			Confirmations: 2,
+		},
+	},
+}
+
+// SelectedTestnetChainParams returns testnet chain parameters with each chain's
+// selected test network (see chain.SelectTestnet) applied.
+func SelectedTestnetChainParams() map[string]ChainParams {
+	params := make(map[string]ChainParams, len(TestnetChainParams))
+	for symbol, p := range TestnetChainParams {
+		params[symbol] = p
+	}
+	for symbol, variants := range TestnetVariantChainParams {
+		if p, ok := variants[chain.ActiveTestnet(symbol)]; ok {
+			params[symbol] = p
+		}
+	}
+	return params
+}
+
+// =============================================================================
+// Dust Configuration
+// =============================================================================
+
+// ChainDustConfig holds chain-specific dust parameters for UTXO chains.
+type ChainDustConfig struct {
+	// RelayDustLimit is the smallest output standard nodes relay (in satoshis).
+	RelayDustLimit uint64
+
+	// SpendInputVBytes is the size of the input needed to spend an output
+	// later. Outputs worth less than spending them costs are uneconomical.
+	SpendInputVBytes uint64
+}
+
+// DefaultChainDust applies to UTXO chains without an entry in ChainDustLimits.
+var DefaultChainDust = ChainDustConfig{RelayDustLimit: 546, SpendInputVBytes: 68}
+
+// ChainDustLimits defines chain-specific dust parameters.
+var ChainDustLimits = map[string]ChainDustConfig{
+	"BTC":  {RelayDustLimit: 546, SpendInputVBytes: 68},     // P2PKH dust limit, P2WPKH input
+	"LTC":  {RelayDustLimit: 546, SpendInputVBytes: 68},     // Same policy as Bitcoin Core
+	"DOGE": {RelayDustLimit: 1000000, SpendInputVBytes: 68}, // 0.01 DOGE
+}
+
+// GetChainDust returns the dust parameters for a chain.
+func GetChainDust(symbol string) ChainDustConfig {
+	if cfg, ok := ChainDustLimits[symbol]; ok {
+		return cfg
+	}
+	return DefaultChainDust
+}
+
+// Threshold returns the smallest economical output at the given fee rate
+// (sat/vB): the relay dust limit or the cost of spending it, whichever is higher.
+func (d ChainDustConfig) Threshold(feeRate uint64) uint64 {
+	spendCost := d.SpendInputVBytes * feeRate
+	if spendCost > d.RelayDustLimit {
+		return spendCost
+	}
+	return d.RelayDustLimit
+}
+
 // =============================================================================
 // DAO Fee Addresses
 // =============================================================================
@@ -432,6 +527,44 @@ func (f FeeConfig) CalculateNodeOperatorShare(feeAmount uint64) uint64 {
 	return (feeAmount * uint64(f.NodeOperatorShareBPS)) / 10000
 }
 
+// =============================================================================
+// Price Feed Configuration
+// =============================================================================
+
+// DefaultPriceFeedURL is the CoinGecko-compatible simple price endpoint used
+// for order book sanity checks.
+const DefaultPriceFeedURL = "https://api.coingecko.com/api/v3/simple/price"
+
+// PriceFeedCoinIDs maps chain symbols to price feed coin IDs. L2s that use
+// ETH as their native asset are priced as ETH.
+var PriceFeedCoinIDs = map[string]string{
+	"BTC":      "bitcoin",
+	"LTC":      "litecoin",
+	"DOGE":     "dogecoin",
+	"XMR":      "monero",
+	"ETH":      "ethereum",
+	"BSC":      "binancecoin",
+	"POLYGON":  "polygon-ecosystem-token",
+	"ARBITRUM": "ethereum",
+	"OPTIMISM": "ethereum",
+	"BASE":     "ethereum",
+	"AVAX":     "avalanche-2",
+	"SOL":      "solana",
+}
+
+// PriceFeedTokenIDs maps ERC-20 token symbols of the token registry to price
+// feed coin IDs. Wrapped tokens are priced as the coin they wrap.
+var PriceFeedTokenIDs = map[string]string{
+	"USDT":  "tether",
+	"USDC":  "usd-coin",
+	"DAI":   "dai",
+	"WETH":  "ethereum",
+	"WBTC":  "bitcoin",
+	"WBNB":  "binancecoin",
+	"WPOL":  "polygon-ecosystem-token",
+	"WAVAX": "avalanche-2",
+}
+
 // =============================================================================
 // Atomic Swap Configuration
 // =============================================================================
@@ -453,6 +586,16 @@ type SwapConfig struct {
 
 	// MaxSwapDuration is the maximum time a swap can be active.
 	MaxSwapDuration time.Duration
+
+	// JobMaxAttempts is how many times an on-chain side effect (broadcast or
+	// contract call) is attempted before the job is abandoned and a new
+	// decision may replace it. Zero retries forever.
+	JobMaxAttempts int
+
+	// ClaimSafetyMargin is how long before the counterparty's refund opens we
+	// should have claimed, for chains with timestamp timelocks (EVM). Block
+	// based chains use their SafetyMarginBlocks.
+	ClaimSafetyMargin time.Duration
 }
 
 // DefaultSwapConfig returns the default swap configuration.
@@ -463,6 +606,8 @@ func DefaultSwapConfig() SwapConfig {
 		MinLockTimeDelta:  12 * time.Hour, // 12 hours minimum difference
 		SecretSize:        32,              // 32 bytes (256 bits)
 		MaxSwapDuration:   72 * time.Hour, // 72 hours max
+		JobMaxAttempts:    10,
+		ClaimSafetyMargin: time.Hour,
 	}
 }
 
@@ -607,7 +752,7 @@ func NewExchangeConfig(network NetworkType) *ExchangeConfig {
 
 	if network == Testnet {
 		cfg.DAOAddrs = TestnetDAOAddresses
-		cfg.ChainParams = TestnetChainParams
+		cfg.ChainParams = SelectedTestnetChainParams()
 	} else {
 		cfg.DAOAddrs = MainnetDAOAddresses
 		cfg.ChainParams = MainnetChainParams
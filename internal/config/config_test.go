@@ -3,6 +3,7 @@ package config
 import (
 	"testing"
 
+	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/ethereum/go-ethereum/common"
 )
 
@@ -270,6 +271,11 @@ func TestSwapConfig(t *testing.T) {
 	if swap.SecretSize != 32 {
 		t.Errorf("secret size should be 32 bytes, got %d", swap.SecretSize)
 	}
+
+	// Claim margin must leave room before the responder's refund
+	if swap.ClaimSafetyMargin <= 0 || swap.ClaimSafetyMargin >= swap.ResponderLockTime {
+		t.Errorf("claim safety margin %v should be within the responder lock time", swap.ClaimSafetyMargin)
+	}
 }
 
 func TestListSupportedCoins(t *testing.T) {
@@ -431,3 +437,80 @@ func TestGetEVMContracts(t *testing.T) {
 		t.Error("GetEVMContracts(999999) should return nil")
 	}
 }
+
+func TestSelectedTestnetChainParams(t *testing.T) {
+	t.Cleanup(func() { _ = chain.SelectTestnet("ETH", chain.TestnetETHSepolia) })
+
+	if err := chain.SelectTestnet("ETH", chain.TestnetETHHolesky); err != nil {
+		t.Fatalf("SelectTestnet() error = %v", err)
+	}
+	cfg := NewExchangeConfig(Testnet)
+	ethParams, _ := cfg.GetChainParams("ETH")
+	if ethParams.ChainID != 17000 {
+		t.Errorf("ETH chain ID = %d, want 17000 (Holesky)", ethParams.ChainID)
+	}
+	if ethParams.ExplorerURL != "https://holesky.etherscan.io" {
+		t.Errorf("ETH explorer = %s", ethParams.ExplorerURL)
+	}
+
+	// The shared testnet table must not be modified
+	if TestnetChainParams["ETH"].ChainID != 11155111 {
+		t.Error("TestnetChainParams was modified")
+	}
+	if GetEVMContracts(17000) == nil {
+		t.Error("Holesky should be in the contract registry")
+	}
+}
+
+func TestChainDustThreshold(t *testing.T) {
+	btc := GetChainDust("BTC")
+	if got := btc.Threshold(1); got != 546 {
+		t.Errorf("BTC threshold at 1 sat/vB = %d, want relay limit 546", got)
+	}
+	if got := btc.Threshold(50); got != 68*50 {
+		t.Errorf("BTC threshold at 50 sat/vB = %d, want spend cost %d", got, 68*50)
+	}
+
+	if got := GetChainDust("DOGE").Threshold(10); got != 1000000 {
+		t.Errorf("DOGE threshold = %d, want 1000000", got)
+	}
+	if got := GetChainDust("UNKNOWN"); got != DefaultChainDust {
+		t.Errorf("GetChainDust(UNKNOWN) = %+v, want default", got)
+	}
+}
+
+func TestUpgradeHTLCContract(t *testing.T) {
+	const chainID = 999998
+	defer delete(evmContractRegistry, chainID)
+	v1 := common.HexToAddress("0x0000000000000000000000000000000000000001")
+	v2 := common.HexToAddress("0x0000000000000000000000000000000000000002")
+
+	UpgradeHTLCContract(chainID, v1)
+	if GetHTLCContract(chainID) != v1 || len(GetLegacyHTLCContracts(chainID)) != 0 {
+		t.Fatalf("first deployment = %+v", GetEVMContracts(chainID))
+	}
+	UpgradeHTLCContract(chainID, v2)
+	if GetHTLCContract(chainID) != v2 {
+		t.Errorf("HTLC contract = %s, want v2", GetHTLCContract(chainID).Hex())
+	}
+	if legacy := GetLegacyHTLCContracts(chainID); len(legacy) != 1 || legacy[0] != v1 {
+		t.Errorf("legacy contracts = %v, want v1", legacy)
+	}
+	if !IsKnownHTLCContract(chainID, v1) || !IsKnownHTLCContract(chainID, v2) {
+		t.Error("an upgraded chain doesn't know both versions")
+	}
+	if IsKnownHTLCContract(chainID, common.Address{}) || IsKnownHTLCContract(chainID, common.HexToAddress("0x03")) {
+		t.Error("IsKnownHTLCContract() accepted an unknown address")
+	}
+
+	// Upgrading to the current contract changes nothing; rolling back
+	// keeps both versions
+	UpgradeHTLCContract(chainID, v2)
+	if legacy := GetLegacyHTLCContracts(chainID); len(legacy) != 1 {
+		t.Errorf("legacy contracts = %v after a no-op upgrade", legacy)
+	}
+	UpgradeHTLCContract(chainID, v1)
+	if legacy := GetLegacyHTLCContracts(chainID); GetHTLCContract(chainID) != v1 || len(legacy) != 1 || legacy[0] != v2 {
+		t.Errorf("after rollback: current %s, legacy %v", GetHTLCContract(chainID).Hex(), legacy)
+	}
+}
@@ -11,6 +11,11 @@ type EVMContractAddresses struct {
 	// HTLCContract is the KlingonHTLC contract address for atomic swaps
 	HTLCContract common.Address
 
+	// LegacyHTLCContracts are previous KlingonHTLC versions on the chain,
+	// newest first. Swaps created on one finish there; new swaps use
+	// HTLCContract.
+	LegacyHTLCContracts []common.Address
+
 	// TODO: Future contract addresses
 	// DEXRouter    common.Address // For potential DEX integration
 	// Staking      common.Address // For KGX staking
@@ -27,6 +32,11 @@ var evmContractRegistry = map[uint64]*EVMContractAddresses{
 		HTLCContract: common.HexToAddress("0x628c677e7b8889e64564d3f381565a9e6656aade"),
 	},
 
+	// Ethereum Holesky (chainID 17000)
+	17000: {
+		HTLCContract: common.Address{}, // TODO: Deploy
+	},
+
 	// BSC Testnet (chainID 97)
 	97: {
 		HTLCContract: common.HexToAddress("0xC8515f07b08b586a2Fd6A389585D9a182D03adFB"),
@@ -129,6 +139,33 @@ func ListDeployedHTLCChains() []uint64 {
 	return chains
 }
 
+// GetLegacyHTLCContracts returns the previous HTLC contract versions of a
+// chain, newest first.
+func GetLegacyHTLCContracts(chainID uint64) []common.Address {
+	if contracts := evmContractRegistry[chainID]; contracts != nil {
+		return contracts.LegacyHTLCContracts
+	}
+	return nil
+}
+
+// IsKnownHTLCContract returns true if address is the current or a legacy
+// HTLC contract of the chain.
+func IsKnownHTLCContract(chainID uint64, address common.Address) bool {
+	contracts := evmContractRegistry[chainID]
+	if contracts == nil || address == (common.Address{}) {
+		return false
+	}
+	if contracts.HTLCContract == address {
+		return true
+	}
+	for _, legacy := range contracts.LegacyHTLCContracts {
+		if legacy == address {
+			return true
+		}
+	}
+	return false
+}
+
 // RegisterEVMContracts registers or updates contract addresses for a chain.
 // This can be used at runtime to update addresses (e.g., from config file).
 func RegisterEVMContracts(chainID uint64, contracts *EVMContractAddresses) {
@@ -143,3 +180,28 @@ func SetHTLCContract(chainID uint64, address common.Address) {
 	}
 	evmContractRegistry[chainID].HTLCContract = address
 }
+
+// UpgradeHTLCContract makes address the HTLC contract of a chain, keeping
+// the previous one as the newest legacy contract.
+func UpgradeHTLCContract(chainID uint64, address common.Address) {
+	contracts := evmContractRegistry[chainID]
+	if contracts == nil {
+		SetHTLCContract(chainID, address)
+		return
+	}
+	previous := contracts.HTLCContract
+	if previous == address {
+		return
+	}
+	legacy := make([]common.Address, 0, len(contracts.LegacyHTLCContracts)+1)
+	if previous != (common.Address{}) {
+		legacy = append(legacy, previous)
+	}
+	for _, old := range contracts.LegacyHTLCContracts {
+		if old != address && old != previous {
+			legacy = append(legacy, old)
+		}
+	}
+	contracts.HTLCContract = address
+	contracts.LegacyHTLCContracts = legacy
+}
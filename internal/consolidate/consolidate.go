@@ -0,0 +1,293 @@
+// Package consolidate merges fragmented wallet UTXOs during quiet periods.
+//
+// Every Interval each configured chain is checked: when the wallet is
+// unlocked, no swap on the chain is running and the economy fee rate is at
+// most MaxFeeRate, up to MaxInputs of the smallest eligible UTXOs are merged
+// into one output at a fresh change address. Addresses and outpoints listed
+// as exclusions are never spent. Each consolidation is recorded in the
+// wallet transaction history.
+package consolidate
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Wallet is the wallet consolidations spend from; *wallet.Service
+// implements it.
+type Wallet interface {
+	IsUnlocked() bool
+	GetFeeEstimates(ctx context.Context, symbol string) (*backend.FeeEstimate, error)
+	ListAllUTXOs(ctx context.Context, symbol string, store *storage.Storage) ([]*wallet.AddressUTXO, error)
+	Consolidate(ctx context.Context, symbol string, utxos []*wallet.AddressUTXO, feeRate uint64, store *storage.Storage) (*wallet.MultiAddressTxResult, string, error)
+}
+
+// ActiveFunc reports whether a swap is running on a chain.
+type ActiveFunc func(symbol string) bool
+
+// Result is the outcome of checking one chain.
+type Result struct {
+	Chain   string `json:"chain"`
+	Skipped string `json:"skipped,omitempty"` // Why nothing was consolidated
+	FeeRate uint64 `json:"fee_rate,omitempty"`
+	Inputs  int    `json:"inputs,omitempty"`
+	TxID    string `json:"txid,omitempty"`
+	Amount  uint64 `json:"amount,omitempty"`
+	Fee     uint64 `json:"fee,omitempty"`
+	Address string `json:"address,omitempty"`
+}
+
+// ChainStatus is the last check of a chain.
+type ChainStatus struct {
+	Chain     string  `json:"chain"`
+	LastRunAt int64   `json:"last_run_at,omitempty"`
+	LastError string  `json:"last_error,omitempty"`
+	Last      *Result `json:"last,omitempty"`
+}
+
+// Status describes the consolidator state.
+type Status struct {
+	Enabled    bool           `json:"enabled"`
+	Interval   string         `json:"interval"`
+	MaxFeeRate uint64         `json:"max_fee_rate"`
+	Chains     []*ChainStatus `json:"chains"`
+}
+
+// Consolidator checks chains and consolidates their UTXOs.
+type Consolidator struct {
+	cfg    node.ConsolidationConfig
+	wallet Wallet
+	store  *storage.Storage
+	log    *logging.Logger
+
+	runMu  sync.Mutex // One consolidation at a time
+	mu     sync.RWMutex
+	active ActiveFunc
+	status map[string]*ChainStatus
+
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a consolidator.
+func New(cfg node.ConsolidationConfig, w Wallet, store *storage.Storage) (*Consolidator, error) {
+	defaults := node.DefaultConfig().Consolidation
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.MaxFeeRate == 0 {
+		cfg.MaxFeeRate = defaults.MaxFeeRate
+	}
+	if cfg.MinUTXOs <= 0 {
+		cfg.MinUTXOs = defaults.MinUTXOs
+	}
+	if cfg.MaxInputs <= 0 {
+		cfg.MaxInputs = defaults.MaxInputs
+	}
+	if cfg.MinUTXOs < 2 {
+		return nil, fmt.Errorf("consolidation min_utxos must be at least 2")
+	}
+	if cfg.MaxInputs < cfg.MinUTXOs {
+		return nil, fmt.Errorf("consolidation max_inputs %d is below min_utxos %d", cfg.MaxInputs, cfg.MinUTXOs)
+	}
+	if cfg.Enabled && len(cfg.Chains) == 0 {
+		return nil, fmt.Errorf("consolidation is enabled but no chains are configured")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Consolidator{
+		cfg:    cfg,
+		wallet: w,
+		store:  store,
+		log:    logging.GetDefault().Component("consolidate"),
+		status: make(map[string]*ChainStatus),
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, symbol := range cfg.Chains {
+		c.status[symbol] = &ChainStatus{Chain: symbol}
+	}
+	return c, nil
+}
+
+// SetActive sets how running swaps are detected. Without it, no chain is
+// consolidated.
+func (c *Consolidator) SetActive(fn ActiveFunc) {
+	c.mu.Lock()
+	c.active = fn
+	c.mu.Unlock()
+}
+
+// Start checks the chains every Interval.
+func (c *Consolidator) Start() {
+	if !c.cfg.Enabled {
+		return
+	}
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				for _, symbol := range c.cfg.Chains {
+					if _, err := c.Run(c.ctx, symbol, false); err != nil {
+						c.log.Warn("UTXO consolidation failed", "chain", symbol, "error", err)
+					}
+				}
+			}
+		}
+	}()
+	c.log.Info("UTXO consolidation started", "chains", c.cfg.Chains, "interval", c.cfg.Interval, "max_fee_rate", c.cfg.MaxFeeRate)
+}
+
+// Stop stops checking.
+func (c *Consolidator) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Run checks one chain and consolidates it if it qualifies. force ignores
+// the fee rate threshold; running swaps still block it, since the
+// consolidation could spend coins a swap is about to fund with.
+func (c *Consolidator) Run(ctx context.Context, symbol string, force bool) (*Result, error) {
+	c.runMu.Lock()
+	defer c.runMu.Unlock()
+
+	result, err := c.run(ctx, symbol, force)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st, ok := c.status[symbol]
+	if !ok {
+		st = &ChainStatus{Chain: symbol}
+		c.status[symbol] = st
+	}
+	st.LastRunAt = c.now().Unix()
+	st.LastError = ""
+	if err != nil {
+		st.LastError = err.Error()
+		return nil, err
+	}
+	st.Last = result
+	return result, nil
+}
+
+func (c *Consolidator) run(ctx context.Context, symbol string, force bool) (*Result, error) {
+	result := &Result{Chain: symbol}
+
+	if !c.wallet.IsUnlocked() {
+		result.Skipped = "wallet locked"
+		return result, nil
+	}
+	c.mu.RLock()
+	active := c.active
+	c.mu.RUnlock()
+	if active == nil || active(symbol) {
+		result.Skipped = "swaps active on chain"
+		return result, nil
+	}
+
+	fees, err := c.wallet.GetFeeEstimates(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee estimates: %w", err)
+	}
+	result.FeeRate = economyRate(fees)
+	if result.FeeRate == 0 {
+		return nil, fmt.Errorf("no fee estimate for %s", symbol)
+	}
+	if result.FeeRate > c.cfg.MaxFeeRate && !force {
+		result.Skipped = fmt.Sprintf("fee rate %d above %d sat/vB", result.FeeRate, c.cfg.MaxFeeRate)
+		return result, nil
+	}
+
+	utxos, err := c.wallet.ListAllUTXOs(ctx, symbol, c.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list UTXOs: %w", err)
+	}
+	selected := wallet.SelectConsolidationUTXOs(utxos, wallet.ConsolidationFilter{
+		MaxInputs:        c.cfg.MaxInputs,
+		MaxValue:         c.cfg.MaxUTXOValue,
+		ExcludeAddresses: c.cfg.ExcludeAddresses,
+		ExcludeOutpoints: c.cfg.ExcludeOutpoints,
+	})
+	if len(selected) < c.cfg.MinUTXOs {
+		result.Skipped = fmt.Sprintf("%d eligible UTXOs, fewer than %d", len(selected), c.cfg.MinUTXOs)
+		return result, nil
+	}
+
+	tx, addr, err := c.wallet.Consolidate(ctx, symbol, selected, result.FeeRate, c.store)
+	if err != nil {
+		return nil, err
+	}
+	result.Inputs = len(selected)
+	result.TxID = tx.TxID
+	result.Amount = tx.TotalOutput
+	result.Fee = tx.Fee
+	result.Address = addr
+
+	if c.store != nil {
+		if err := c.store.RecordWalletTransaction(&storage.WalletTransaction{
+			Chain:   symbol,
+			Kind:    storage.WalletTxConsolidation,
+			TxID:    tx.TxID,
+			Inputs:  result.Inputs,
+			Amount:  result.Amount,
+			Fee:     result.Fee,
+			FeeRate: result.FeeRate,
+			Address: addr,
+		}); err != nil {
+			// Already broadcast; report it rather than fail
+			c.log.Warn("Failed to record consolidation", "chain", symbol, "txid", tx.TxID, "error", err)
+		}
+	}
+	c.log.Info("UTXOs consolidated", "chain", symbol, "inputs", result.Inputs, "txid", tx.TxID, "fee_rate", result.FeeRate)
+	return result, nil
+}
+
+// economyRate returns the low-priority fee rate, falling back to slower
+// estimates the backend may lack.
+func economyRate(fees *backend.FeeEstimate) uint64 {
+	if fees == nil {
+		return 0
+	}
+	for _, rate := range []uint64{fees.EconomyFee, fees.HourFee, fees.HalfHourFee, fees.FastestFee} {
+		if rate > 0 {
+			return max(rate, fees.MinimumFee)
+		}
+	}
+	return 0
+}
+
+// Status returns the consolidator state.
+func (c *Consolidator) Status() *Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	st := &Status{
+		Enabled:    c.cfg.Enabled,
+		Interval:   c.cfg.Interval.String(),
+		MaxFeeRate: c.cfg.MaxFeeRate,
+		Chains:     make([]*ChainStatus, 0, len(c.status)),
+	}
+	for _, cs := range c.status {
+		copied := *cs
+		st.Chains = append(st.Chains, &copied)
+	}
+	sort.Slice(st.Chains, func(i, j int) bool { return st.Chains[i].Chain < st.Chains[j].Chain })
+	return st
+}
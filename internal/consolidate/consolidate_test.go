@@ -0,0 +1,163 @@
+package consolidate
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// fakeWallet records consolidations instead of broadcasting them.
+type fakeWallet struct {
+	locked   bool
+	fees     backend.FeeEstimate
+	utxos    []*wallet.AddressUTXO
+	consumed []*wallet.AddressUTXO
+	feeRate  uint64
+}
+
+func (w *fakeWallet) IsUnlocked() bool { return !w.locked }
+
+func (w *fakeWallet) GetFeeEstimates(ctx context.Context, symbol string) (*backend.FeeEstimate, error) {
+	return &w.fees, nil
+}
+
+func (w *fakeWallet) ListAllUTXOs(ctx context.Context, symbol string, store *storage.Storage) ([]*wallet.AddressUTXO, error) {
+	return w.utxos, nil
+}
+
+func (w *fakeWallet) Consolidate(ctx context.Context, symbol string, utxos []*wallet.AddressUTXO, feeRate uint64, store *storage.Storage) (*wallet.MultiAddressTxResult, string, error) {
+	w.consumed = utxos
+	w.feeRate = feeRate
+	var total uint64
+	for _, u := range utxos {
+		total += u.Amount
+	}
+	return &wallet.MultiAddressTxResult{TxID: "consolidation-tx", TotalInput: total, TotalOutput: total - 500, Fee: 500}, "tb1qfresh", nil
+}
+
+func newTestConsolidator(t *testing.T, w *fakeWallet) (*Consolidator, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	c, err := New(node.ConsolidationConfig{
+		Enabled:          true,
+		Chains:           []string{"BTC"},
+		MaxFeeRate:       2,
+		MinUTXOs:         3,
+		MaxInputs:        4,
+		ExcludeOutpoints: []string{"tx0:0"},
+	}, w, store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c, store
+}
+
+func testUTXOs(n int) []*wallet.AddressUTXO {
+	utxos := make([]*wallet.AddressUTXO, n)
+	for i := range utxos {
+		utxos[i] = &wallet.AddressUTXO{TxID: fmt.Sprintf("tx%d", i), Amount: uint64(1000 * (i + 1)), Address: "tb1qsmall"}
+	}
+	return utxos
+}
+
+func TestNewValidation(t *testing.T) {
+	if _, err := New(node.ConsolidationConfig{Enabled: true}, &fakeWallet{}, nil); err == nil {
+		t.Error("New() accepted enabled consolidation without chains")
+	}
+	if _, err := New(node.ConsolidationConfig{MinUTXOs: 1}, &fakeWallet{}, nil); err == nil {
+		t.Error("New() accepted min_utxos 1")
+	}
+	if _, err := New(node.ConsolidationConfig{MinUTXOs: 10, MaxInputs: 5}, &fakeWallet{}, nil); err == nil {
+		t.Error("New() accepted max_inputs below min_utxos")
+	}
+	c, err := New(node.ConsolidationConfig{}, &fakeWallet{}, nil)
+	if err != nil {
+		t.Fatalf("New() with defaults error = %v", err)
+	}
+	if c.cfg.Interval <= 0 || c.cfg.MaxFeeRate == 0 {
+		t.Errorf("defaults not applied: %+v", c.cfg)
+	}
+}
+
+func TestRunSkips(t *testing.T) {
+	w := &fakeWallet{fees: backend.FeeEstimate{EconomyFee: 1}, utxos: testUTXOs(6)}
+	c, _ := newTestConsolidator(t, w)
+	ctx := context.Background()
+
+	// No active-swap check configured yet
+	result, err := c.Run(ctx, "BTC", false)
+	if err != nil || result.Skipped == "" {
+		t.Fatalf("Run() without SetActive = %+v, %v; want skipped", result, err)
+	}
+
+	busy := true
+	c.SetActive(func(symbol string) bool { return busy })
+	if result, _ := c.Run(ctx, "BTC", true); !strings.Contains(result.Skipped, "swaps active") {
+		t.Errorf("Run() with active swaps = %+v, want skipped even when forced", result)
+	}
+	busy = false
+
+	w.locked = true
+	if result, _ := c.Run(ctx, "BTC", false); result.Skipped != "wallet locked" {
+		t.Errorf("Run() with locked wallet = %+v", result)
+	}
+	w.locked = false
+
+	w.fees = backend.FeeEstimate{EconomyFee: 5}
+	if result, _ := c.Run(ctx, "BTC", false); !strings.Contains(result.Skipped, "fee rate 5") {
+		t.Errorf("Run() with high fees = %+v", result)
+	}
+	w.fees = backend.FeeEstimate{EconomyFee: 1}
+
+	// One excluded, two left: below min_utxos
+	w.utxos = testUTXOs(3)
+	if result, _ := c.Run(ctx, "BTC", false); !strings.Contains(result.Skipped, "2 eligible") {
+		t.Errorf("Run() with few UTXOs = %+v", result)
+	}
+	if w.consumed != nil {
+		t.Error("a skipped run consolidated")
+	}
+}
+
+func TestRunConsolidates(t *testing.T) {
+	w := &fakeWallet{fees: backend.FeeEstimate{EconomyFee: 0, HourFee: 2, MinimumFee: 1}, utxos: testUTXOs(8)}
+	c, store := newTestConsolidator(t, w)
+	c.SetActive(func(string) bool { return false })
+
+	result, err := c.Run(context.Background(), "BTC", false)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if result.Skipped != "" || result.TxID != "consolidation-tx" || result.Inputs != 4 || result.FeeRate != 2 {
+		t.Fatalf("Run() = %+v, want 4 inputs consolidated at 2 sat/vB", result)
+	}
+	// The excluded outpoint is kept; the smallest of the rest are merged
+	if len(w.consumed) != 4 || w.consumed[0].TxID != "tx1" || w.consumed[3].TxID != "tx4" {
+		t.Errorf("consumed %+v, want tx1..tx4", w.consumed)
+	}
+
+	history, err := store.ListWalletTransactions("BTC", 0)
+	if err != nil {
+		t.Fatalf("ListWalletTransactions() error = %v", err)
+	}
+	if len(history) != 1 || history[0].Kind != storage.WalletTxConsolidation || history[0].Inputs != 4 ||
+		history[0].Address != "tb1qfresh" || history[0].Amount != result.Amount {
+		t.Errorf("history = %+v, want the consolidation", history)
+	}
+
+	status := c.Status()
+	if len(status.Chains) != 1 || status.Chains[0].Last == nil || status.Chains[0].Last.TxID != "consolidation-tx" {
+		t.Errorf("Status() = %+v", status)
+	}
+}
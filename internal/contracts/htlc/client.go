@@ -7,6 +7,7 @@ import (
 	"crypto/ecdsa"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
@@ -366,7 +367,7 @@ func (c *Client) WatchSwapCreated(
 				if event == nil {
 					return
 				}
-				outCh <- &SwapCreatedEvent{
+				out := &SwapCreatedEvent{
 					SwapID:     event.SwapId,
 					Sender:     event.Sender,
 					Receiver:   event.Receiver,
@@ -378,6 +379,13 @@ func (c *Client) WatchSwapCreated(
 					TxHash:     event.Raw.TxHash,
 					BlockNum:   event.Raw.BlockNumber,
 				}
+				select {
+				case outCh <- out:
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Err():
+				return
 			case <-ctx.Done():
 				return
 			}
@@ -415,13 +423,20 @@ func (c *Client) WatchSwapClaimed(
 		for {
 			select {
 			case event := <-ch:
-				outCh <- &SwapClaimedEvent{
+				out := &SwapClaimedEvent{
 					SwapID:   event.SwapId,
 					Receiver: event.Receiver,
 					Secret:   event.Secret,
 					TxHash:   event.Raw.TxHash,
 					BlockNum: event.Raw.BlockNumber,
 				}
+				select {
+				case outCh <- out:
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Err():
+				return
 			case <-ctx.Done():
 				return
 			}
@@ -458,12 +473,51 @@ func (c *Client) WatchSwapRefunded(
 		for {
 			select {
 			case event := <-ch:
-				outCh <- &SwapRefundedEvent{
+				out := &SwapRefundedEvent{
 					SwapID:   event.SwapId,
 					Sender:   event.Sender,
 					TxHash:   event.Raw.TxHash,
 					BlockNum: event.Raw.BlockNumber,
 				}
+				select {
+				case outCh <- out:
+				case <-ctx.Done():
+					return
+				}
+			case <-sub.Err():
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return outCh, nil
+}
+
+// WatchPaused watches for Paused events and sends the new pause state. The
+// channel is closed when the subscription ends (ctx done or connection lost).
+// Subscriptions need a WebSocket RPC endpoint.
+func (c *Client) WatchPaused(ctx context.Context) (<-chan bool, error) {
+	ch := make(chan *KlingonHTLCPaused, 10)
+
+	sub, err := c.contract.WatchPaused(&bind.WatchOpts{Context: ctx}, ch)
+	if err != nil {
+		close(ch)
+		return nil, fmt.Errorf("failed to watch Paused: %w", err)
+	}
+
+	outCh := make(chan bool, 10)
+	go func() {
+		defer close(outCh)
+		defer sub.Unsubscribe()
+
+		for {
+			select {
+			case event := <-ch:
+				outCh <- event.IsPaused
+			case <-sub.Err():
+				return
 			case <-ctx.Done():
 				return
 			}
@@ -566,6 +620,33 @@ func (c *Client) GetSwapClaimedEvents(
 	return events, nil
 }
 
+// FindSwapClaimed returns the SwapClaimed event of a swap emitted at or
+// after fromBlock, nil if the swap has not been claimed.
+func (c *Client) FindSwapClaimed(ctx context.Context, swapID [32]byte, fromBlock uint64) (*SwapClaimedEvent, error) {
+	opts := &bind.FilterOpts{
+		Start:   fromBlock,
+		Context: ctx,
+	}
+
+	iter, err := c.contract.FilterSwapClaimed(opts, [][32]byte{swapID}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter SwapClaimed: %w", err)
+	}
+	defer iter.Close()
+
+	if !iter.Next() {
+		return nil, iter.Error()
+	}
+	event := iter.Event
+	return &SwapClaimedEvent{
+		SwapID:   event.SwapId,
+		Receiver: event.Receiver,
+		Secret:   event.Secret,
+		TxHash:   event.Raw.TxHash,
+		BlockNum: event.Raw.BlockNumber,
+	}, nil
+}
+
 // GetSecretFromClaim extracts the secret from a claim transaction
 // Useful when you know a claim happened but missed the event
 func (c *Client) GetSecretFromClaim(ctx context.Context, txHash common.Hash) ([32]byte, error) {
@@ -638,6 +719,97 @@ func (c *Client) EstimateGasCreateSwapNative(
 	return c.client.EstimateGas(ctx, msg)
 }
 
+// =============================================================================
+// Transaction Replacement
+// =============================================================================
+
+// ReplacementBumpPercent is how much a replacement raises each fee of the
+// transaction it replaces. Nodes require at least 10% to accept one.
+const ReplacementBumpPercent = 25
+
+// cancelGasLimit is the gas of a plain transfer.
+const cancelGasLimit = 21000
+
+// BumpFee returns fee raised by ReplacementBumpPercent, rounded up.
+func BumpFee(fee *big.Int) *big.Int {
+	bumped := new(big.Int).Mul(fee, big.NewInt(100+ReplacementBumpPercent))
+	bumped.Add(bumped, big.NewInt(99))
+	return bumped.Div(bumped, big.NewInt(100))
+}
+
+// PendingTransaction returns a transaction by hash and whether it is still
+// in the mempool.
+func (c *Client) PendingTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	return c.client.TransactionByHash(ctx, hash)
+}
+
+// Receipt returns the receipt of a mined transaction, nil while it isn't.
+func (c *Client) Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	receipt, err := c.client.TransactionReceipt(ctx, hash)
+	if errors.Is(err, ethereum.NotFound) {
+		return nil, nil
+	}
+	return receipt, err
+}
+
+// CancelTransaction replaces a pending transaction with a zero-value
+// transfer to its sender at the same nonce, paying the bumped fees or the
+// current ones, whichever are higher. Whichever of the two is mined first
+// takes the nonce; the other is dropped.
+func (c *Client) CancelTransaction(ctx context.Context, privateKey *ecdsa.PrivateKey, tx *types.Transaction) (*types.Transaction, error) {
+	gasPrice, err := c.client.SuggestGasPrice(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var gasTip *big.Int
+	if tx.Type() == types.DynamicFeeTxType {
+		if gasTip, err = c.client.SuggestGasTipCap(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	signedTx, err := types.SignNewTx(privateKey, types.LatestSignerForChainID(c.chainID), cancelTxData(tx, from, gasPrice, gasTip))
+	if err != nil {
+		return nil, err
+	}
+	if err := c.client.SendTransaction(ctx, signedTx); err != nil {
+		return nil, err
+	}
+	return signedTx, nil
+}
+
+// cancelTxData builds the self-transfer replacing tx. gasTip is only used
+// for dynamic fee transactions.
+func cancelTxData(tx *types.Transaction, from common.Address, gasPrice, gasTip *big.Int) types.TxData {
+	if tx.Type() == types.DynamicFeeTxType {
+		tip := maxBig(BumpFee(tx.GasTipCap()), gasTip)
+		return &types.DynamicFeeTx{
+			ChainID:   tx.ChainId(),
+			Nonce:     tx.Nonce(),
+			GasTipCap: tip,
+			GasFeeCap: maxBig(BumpFee(tx.GasFeeCap()), new(big.Int).Add(gasPrice, tip)),
+			Gas:       cancelGasLimit,
+			To:        &from,
+			Value:     new(big.Int),
+		}
+	}
+	return &types.LegacyTx{
+		Nonce:    tx.Nonce(),
+		GasPrice: maxBig(BumpFee(tx.GasPrice()), gasPrice),
+		Gas:      cancelGasLimit,
+		To:       &from,
+		Value:    new(big.Int),
+	}
+}
+
+func maxBig(a, b *big.Int) *big.Int {
+	if b != nil && b.Cmp(a) > 0 {
+		return new(big.Int).Set(b)
+	}
+	return a
+}
+
 // =============================================================================
 // ERC20 Helpers
 // =============================================================================
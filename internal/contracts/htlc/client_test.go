@@ -19,6 +19,7 @@ import (
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/ethclient"
 )
@@ -204,6 +205,59 @@ func TestParsePrivateKey(t *testing.T) {
 	}
 }
 
+func TestBumpFee(t *testing.T) {
+	tests := []struct {
+		fee, want int64
+	}{
+		{0, 0},
+		{1, 2},
+		{100, 125},
+		{1000000001, 1250000002},
+	}
+	for _, tt := range tests {
+		if got := BumpFee(big.NewInt(tt.fee)); got.Int64() != tt.want {
+			t.Errorf("BumpFee(%d) = %s, want %d", tt.fee, got, tt.want)
+		}
+	}
+}
+
+func TestCancelTxData(t *testing.T) {
+	from := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	htlcAddr := common.HexToAddress("0x00000000000000000000000000000000000000bb")
+
+	legacy := types.NewTransaction(7, htlcAddr, big.NewInt(1e18), 200000, big.NewInt(100), []byte{1})
+	data, ok := cancelTxData(legacy, from, big.NewInt(110), nil).(*types.LegacyTx)
+	if !ok {
+		t.Fatal("legacy transaction not replaced by a legacy one")
+	}
+	if data.Nonce != 7 || *data.To != from || data.Value.Sign() != 0 || data.Gas != cancelGasLimit || len(data.Data) != 0 {
+		t.Errorf("replacement = %+v, want a zero-value self-transfer at nonce 7", data)
+	}
+	if data.GasPrice.Int64() != 125 {
+		t.Errorf("gas price = %s, want the bumped 125", data.GasPrice)
+	}
+	// The current price wins when it's above the bump
+	data = cancelTxData(legacy, from, big.NewInt(300), nil).(*types.LegacyTx)
+	if data.GasPrice.Int64() != 300 {
+		t.Errorf("gas price = %s, want the current 300", data.GasPrice)
+	}
+
+	dynamic := types.NewTx(&types.DynamicFeeTx{
+		ChainID: big.NewInt(1), Nonce: 9, GasTipCap: big.NewInt(10), GasFeeCap: big.NewInt(200),
+		Gas: 200000, To: &htlcAddr, Value: big.NewInt(1e18),
+	})
+	dyn, ok := cancelTxData(dynamic, from, big.NewInt(50), big.NewInt(20)).(*types.DynamicFeeTx)
+	if !ok {
+		t.Fatal("dynamic fee transaction not replaced by a dynamic fee one")
+	}
+	if dyn.Nonce != 9 || dyn.ChainID.Int64() != 1 || *dyn.To != from || dyn.Value.Sign() != 0 {
+		t.Errorf("replacement = %+v, want a zero-value self-transfer at nonce 9", dyn)
+	}
+	if dyn.GasTipCap.Int64() != 20 || dyn.GasFeeCap.Int64() != 250 {
+		t.Errorf("fees = tip %s cap %s, want tip 20 cap 250", dyn.GasTipCap, dyn.GasFeeCap)
+	}
+}
+
 // =============================================================================
 // Integration Tests (require Anvil node)
 // =============================================================================
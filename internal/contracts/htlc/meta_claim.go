@@ -0,0 +1,202 @@
+// Package htlc - Relayed claims for receivers without native gas.
+//
+// The receiver signs an EIP-712 ClaimAuthorization and hands it with the
+// secret to a relayer, which submits claimWithSignature and is paid the
+// relayer fee in kind, out of the receiver's amount.
+package htlc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/accounts/abi/bind"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// metaClaimABI is the part of the KlingonHTLC ABI for relayed claims.
+const metaClaimABI = `[
+{"type":"function","name":"claimWithSignature","inputs":[{"name":"swapId","type":"bytes32"},{"name":"secret","type":"bytes32"},{"name":"relayer","type":"address"},{"name":"relayerFee","type":"uint256"},{"name":"deadline","type":"uint256"},{"name":"signature","type":"bytes"}],"outputs":[],"stateMutability":"nonpayable"},
+{"type":"function","name":"hashClaimAuthorization","inputs":[{"name":"swapId","type":"bytes32"},{"name":"relayer","type":"address"},{"name":"relayerFee","type":"uint256"},{"name":"deadline","type":"uint256"}],"outputs":[{"name":"","type":"bytes32"}],"stateMutability":"view"},
+{"type":"event","name":"SwapClaimRelayed","inputs":[{"name":"swapId","type":"bytes32","indexed":true},{"name":"relayer","type":"address","indexed":true},{"name":"relayerFee","type":"uint256","indexed":false}],"anonymous":false},
+{"type":"error","name":"AuthorizationExpired","inputs":[]},
+{"type":"error","name":"InvalidSignature","inputs":[]},
+{"type":"error","name":"NotRelayer","inputs":[]},
+{"type":"error","name":"RelayerFeeTooHigh","inputs":[]}
+]`
+
+var parsedMetaClaimABI = func() abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(metaClaimABI))
+	if err != nil {
+		panic(fmt.Sprintf("invalid meta-claim ABI: %v", err))
+	}
+	return parsed
+}()
+
+// EIP-712 type hashes, as in KlingonHTLC.
+var (
+	eip712DomainTypeHash = crypto.Keccak256Hash([]byte(
+		"EIP712Domain(string name,string version,uint256 chainId,address verifyingContract)"))
+	claimAuthorizationTypeHash = crypto.Keccak256Hash([]byte(
+		"ClaimAuthorization(bytes32 swapId,address relayer,uint256 relayerFee,uint256 deadline)"))
+)
+
+// EIP-712 domain of KlingonHTLC.
+const (
+	eip712DomainName    = "KlingonHTLC"
+	eip712DomainVersion = "1"
+)
+
+// ClaimAuthorization is a receiver's signed permission for a relayer to
+// claim a swap on its behalf.
+type ClaimAuthorization struct {
+	SwapID     [32]byte
+	Relayer    common.Address // Allowed submitter; zero lets anyone submit
+	RelayerFee *big.Int       // Paid to the submitter out of the receiver's amount
+	Deadline   *big.Int       // Unix timestamp after which it expires
+	Signature  []byte         // 65 bytes, [R || S || V] with V 27 or 28
+}
+
+// ClaimAuthorizationDigest returns the EIP-712 digest of an authorization
+// for the contract at contractAddress, as hashClaimAuthorization computes it.
+func ClaimAuthorizationDigest(chainID *big.Int, contractAddress common.Address, auth *ClaimAuthorization) [32]byte {
+	domain := crypto.Keccak256(
+		eip712DomainTypeHash.Bytes(),
+		crypto.Keccak256([]byte(eip712DomainName)),
+		crypto.Keccak256([]byte(eip712DomainVersion)),
+		common.LeftPadBytes(chainID.Bytes(), 32),
+		common.LeftPadBytes(contractAddress.Bytes(), 32),
+	)
+	structHash := crypto.Keccak256(
+		claimAuthorizationTypeHash.Bytes(),
+		auth.SwapID[:],
+		common.LeftPadBytes(auth.Relayer.Bytes(), 32),
+		common.LeftPadBytes(auth.RelayerFee.Bytes(), 32),
+		common.LeftPadBytes(auth.Deadline.Bytes(), 32),
+	)
+	var digest [32]byte
+	copy(digest[:], crypto.Keccak256([]byte{0x19, 0x01}, domain, structHash))
+	return digest
+}
+
+// SignClaimAuthorization signs an authorization with the receiver's key.
+func SignClaimAuthorization(privateKey *ecdsa.PrivateKey, chainID *big.Int, contractAddress common.Address, auth *ClaimAuthorization) error {
+	digest := ClaimAuthorizationDigest(chainID, contractAddress, auth)
+	sig, err := crypto.Sign(digest[:], privateKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign claim authorization: %w", err)
+	}
+	sig[64] += 27
+	auth.Signature = sig
+	return nil
+}
+
+// RecoverClaimAuthorizer returns the address that signed an authorization.
+func RecoverClaimAuthorizer(chainID *big.Int, contractAddress common.Address, auth *ClaimAuthorization) (common.Address, error) {
+	if len(auth.Signature) != 65 {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(auth.Signature))
+	}
+	sig := make([]byte, 65)
+	copy(sig, auth.Signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+	digest := ClaimAuthorizationDigest(chainID, contractAddress, auth)
+	pub, err := crypto.SigToPub(digest[:], sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("invalid signature: %w", err)
+	}
+	return crypto.PubkeyToAddress(*pub), nil
+}
+
+// SignClaimAuthorization signs an authorization for this contract.
+func (c *Client) SignClaimAuthorization(privateKey *ecdsa.PrivateKey, auth *ClaimAuthorization) error {
+	return SignClaimAuthorization(privateKey, c.chainID, c.contractAddress, auth)
+}
+
+// VerifyClaimAuthorization checks an authorization against the on-chain
+// swap before submitting it: the swap is active, the receiver signed it, the
+// secret matches, relayer may submit it, it hasn't expired and the fee
+// leaves the receiver something.
+func (c *Client) VerifyClaimAuthorization(ctx context.Context, auth *ClaimAuthorization, secret [32]byte, relayer common.Address) (*Swap, error) {
+	if auth.RelayerFee == nil || auth.Deadline == nil {
+		return nil, fmt.Errorf("relayer fee and deadline are required")
+	}
+	if auth.Relayer != (common.Address{}) && auth.Relayer != relayer {
+		return nil, fmt.Errorf("authorization is for relayer %s", auth.Relayer.Hex())
+	}
+	if auth.Deadline.Int64() <= time.Now().Unix() {
+		return nil, fmt.Errorf("authorization expired")
+	}
+
+	swap, err := c.GetSwap(ctx, auth.SwapID)
+	if err != nil {
+		return nil, err
+	}
+	if !swap.IsActive() {
+		return nil, fmt.Errorf("swap is %s", swap.State)
+	}
+	if !VerifySecret(secret, swap.SecretHash) {
+		return nil, fmt.Errorf("secret does not match the swap")
+	}
+	signer, err := RecoverClaimAuthorizer(c.chainID, c.contractAddress, auth)
+	if err != nil {
+		return nil, err
+	}
+	if signer != swap.Receiver {
+		return nil, fmt.Errorf("authorization signed by %s, not the receiver %s", signer.Hex(), swap.Receiver.Hex())
+	}
+	receiverAmount := new(big.Int).Sub(swap.Amount, swap.DaoFee)
+	if auth.RelayerFee.Cmp(receiverAmount) >= 0 {
+		return nil, fmt.Errorf("relayer fee %s is not below the receiver amount %s", auth.RelayerFee, receiverAmount)
+	}
+	return swap, nil
+}
+
+// SupportsMetaClaims reports whether the contract accepts relayed claims
+// signed for the domain ClaimAuthorizationDigest uses. Deployments from
+// before claimWithSignature lack hashClaimAuthorization, so the call fails;
+// ones with another EIP-712 domain return a different digest.
+func (c *Client) SupportsMetaClaims(ctx context.Context) (bool, error) {
+	code, err := c.client.CodeAt(ctx, c.contractAddress, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to get contract code: %w", err)
+	}
+	if len(code) == 0 {
+		return false, fmt.Errorf("no contract at %s", c.contractAddress.Hex())
+	}
+
+	probe := &ClaimAuthorization{RelayerFee: big.NewInt(1), Deadline: big.NewInt(1)}
+	contract := bind.NewBoundContract(c.contractAddress, parsedMetaClaimABI, c.client, c.client, c.client)
+	var out []interface{}
+	err = contract.Call(&bind.CallOpts{Context: ctx}, &out, "hashClaimAuthorization",
+		probe.SwapID, probe.Relayer, probe.RelayerFee, probe.Deadline)
+	if err != nil || len(out) != 1 {
+		return false, nil
+	}
+	digest, ok := out[0].([32]byte)
+	return ok && digest == ClaimAuthorizationDigest(c.chainID, c.contractAddress, probe), nil
+}
+
+// ClaimWithSignature submits a relayed claim, paying the gas with the
+// relayer's key. The relayer fee goes to the relayer's address.
+func (c *Client) ClaimWithSignature(
+	ctx context.Context,
+	relayerKey *ecdsa.PrivateKey,
+	secret [32]byte,
+	auth *ClaimAuthorization,
+) (*types.Transaction, error) {
+	opts, err := c.newTransactor(ctx, relayerKey)
+	if err != nil {
+		return nil, err
+	}
+	contract := bind.NewBoundContract(c.contractAddress, parsedMetaClaimABI, c.client, c.client, c.client)
+	return contract.Transact(opts, "claimWithSignature",
+		auth.SwapID, secret, auth.Relayer, auth.RelayerFee, auth.Deadline, auth.Signature)
+}
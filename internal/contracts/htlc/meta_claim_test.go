@@ -0,0 +1,182 @@
+package htlc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/common/math"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/signer/core/apitypes"
+)
+
+func newTestClaimAuthorization() *ClaimAuthorization {
+	return &ClaimAuthorization{
+		SwapID:     [32]byte{1, 2, 3},
+		Relayer:    common.HexToAddress("0x5E1A000000000000000000000000000000005E1A"),
+		RelayerFee: big.NewInt(1_000_000_000_000_000),
+		Deadline:   big.NewInt(1_900_000_000),
+	}
+}
+
+func TestClaimAuthorizationDigestEIP712(t *testing.T) {
+	chainID := big.NewInt(11155111)
+	contract := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	auth := newTestClaimAuthorization()
+
+	typed := apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+				{Name: "chainId", Type: "uint256"},
+				{Name: "verifyingContract", Type: "address"},
+			},
+			"ClaimAuthorization": {
+				{Name: "swapId", Type: "bytes32"},
+				{Name: "relayer", Type: "address"},
+				{Name: "relayerFee", Type: "uint256"},
+				{Name: "deadline", Type: "uint256"},
+			},
+		},
+		PrimaryType: "ClaimAuthorization",
+		Domain: apitypes.TypedDataDomain{
+			Name:              "KlingonHTLC",
+			Version:           "1",
+			ChainId:           (*math.HexOrDecimal256)(chainID),
+			VerifyingContract: contract.Hex(),
+		},
+		Message: apitypes.TypedDataMessage{
+			"swapId":     hexutil.Encode(auth.SwapID[:]),
+			"relayer":    auth.Relayer.Hex(),
+			"relayerFee": auth.RelayerFee.String(),
+			"deadline":   auth.Deadline.String(),
+		},
+	}
+	want, _, err := apitypes.TypedDataAndHash(typed)
+	if err != nil {
+		t.Fatalf("TypedDataAndHash() error = %v", err)
+	}
+
+	got := ClaimAuthorizationDigest(chainID, contract, auth)
+	if !bytes.Equal(got[:], want) {
+		t.Errorf("ClaimAuthorizationDigest() = %x, want %x", got, want)
+	}
+
+	// The digest binds the chain and the contract
+	if other := ClaimAuthorizationDigest(big.NewInt(1), contract, auth); other == got {
+		t.Error("digest does not depend on the chain ID")
+	}
+	if other := ClaimAuthorizationDigest(chainID, common.Address{}, auth); other == got {
+		t.Error("digest does not depend on the contract")
+	}
+}
+
+func TestSignClaimAuthorization(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	chainID := big.NewInt(1)
+	contract := common.HexToAddress("0x1234567890123456789012345678901234567890")
+	auth := newTestClaimAuthorization()
+
+	if err := SignClaimAuthorization(key, chainID, contract, auth); err != nil {
+		t.Fatalf("SignClaimAuthorization() error = %v", err)
+	}
+	if len(auth.Signature) != 65 || (auth.Signature[64] != 27 && auth.Signature[64] != 28) {
+		t.Fatalf("signature = %x, want 65 bytes with V 27 or 28", auth.Signature)
+	}
+
+	signer, err := RecoverClaimAuthorizer(chainID, contract, auth)
+	if err != nil {
+		t.Fatalf("RecoverClaimAuthorizer() error = %v", err)
+	}
+	if signer != AddressFromPrivateKey(key) {
+		t.Errorf("signer = %s, want %s", signer.Hex(), AddressFromPrivateKey(key).Hex())
+	}
+
+	// A raised fee no longer recovers the receiver
+	tampered := *auth
+	tampered.RelayerFee = new(big.Int).Mul(auth.RelayerFee, big.NewInt(10))
+	if signer, err := RecoverClaimAuthorizer(chainID, contract, &tampered); err == nil && signer == AddressFromPrivateKey(key) {
+		t.Error("tampered fee recovered the receiver")
+	}
+
+	short := *auth
+	short.Signature = auth.Signature[:64]
+	if _, err := RecoverClaimAuthorizer(chainID, contract, &short); err == nil {
+		t.Error("RecoverClaimAuthorizer() accepted a 64-byte signature")
+	}
+}
+
+// fakeEVMNode answers the JSON-RPC calls of SupportsMetaClaims. digest is
+// what hashClaimAuthorization returns; nil makes the call revert.
+func fakeEVMNode(t *testing.T, code string, digest func(chainID *big.Int, contract common.Address) []byte) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage   `json:"id"`
+			Method string            `json:"method"`
+			Params []json.RawMessage `json:"params"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+		switch req.Method {
+		case "eth_chainId":
+			resp["result"] = "0x1"
+		case "eth_getCode":
+			resp["result"] = code
+		case "eth_call":
+			var call struct {
+				To common.Address `json:"to"`
+			}
+			json.Unmarshal(req.Params[0], &call)
+			if out := digest(big.NewInt(1), call.To); out != nil {
+				resp["result"] = hexutil.Encode(out)
+			} else {
+				resp["error"] = map[string]interface{}{"code": 3, "message": "execution reverted"}
+			}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestSupportsMetaClaims(t *testing.T) {
+	probe := &ClaimAuthorization{RelayerFee: big.NewInt(1), Deadline: big.NewInt(1)}
+	current := func(chainID *big.Int, contract common.Address) []byte {
+		d := ClaimAuthorizationDigest(chainID, contract, probe)
+		return d[:]
+	}
+	tests := []struct {
+		name    string
+		code    string
+		digest  func(*big.Int, common.Address) []byte
+		want    bool
+		wantErr bool
+	}{
+		{"current contract", "0x6080", current, true, false},
+		{"before claimWithSignature", "0x6080", func(*big.Int, common.Address) []byte { return nil }, false, false},
+		{"other domain", "0x6080", func(*big.Int, common.Address) []byte { return make([]byte, 32) }, false, false},
+		{"no contract", "0x", current, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := fakeEVMNode(t, tt.code, tt.digest)
+			client, err := NewClient(srv.URL, common.HexToAddress("0xC0DE00000000000000000000000000000000C0DE"))
+			if err != nil {
+				t.Fatalf("NewClient() error = %v", err)
+			}
+			defer client.Close()
+			got, err := client.SupportsMetaClaims(context.Background())
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("SupportsMetaClaims() = %v, %v; want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+// Package export - Exported datasets and their versioned schemas.
+package export
+
+import (
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// ColumnType is the logical type of an exported column.
+type ColumnType string
+
+const (
+	ColumnString ColumnType = "string"
+	ColumnInt64  ColumnType = "int64"
+	ColumnUint64 ColumnType = "uint64" // Amounts in smallest units
+)
+
+// Column describes one exported column.
+type Column struct {
+	Name string     `json:"name"`
+	Type ColumnType `json:"type"`
+}
+
+// Dataset is an exported table. Version is bumped whenever columns change
+// so downstream queries can pin a schema.
+type Dataset struct {
+	Name    string
+	Version int
+	Columns []Column
+	rows    func(store *storage.Storage) ([][]interface{}, error)
+}
+
+// Dataset names.
+const (
+	DatasetOrders = "orders"
+	DatasetTrades = "trades"
+	DatasetSwaps  = "swaps"
+	DatasetFees   = "fees"
+)
+
+// Datasets returns all exportable datasets.
+func Datasets() []*Dataset {
+	return []*Dataset{ordersDataset, tradesDataset, swapsDataset, feesDataset}
+}
+
+// unixOrZero returns the Unix time, or 0 for an unset time.
+func unixOrZero(t *time.Time) int64 {
+	if t == nil || t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
+
+func boolInt(b bool) int64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+var ordersDataset = &Dataset{
+	Name:    DatasetOrders,
+	Version: 1,
+	Columns: []Column{
+		{"id", ColumnString},
+		{"peer_id", ColumnString},
+		{"status", ColumnString},
+		{"is_local", ColumnInt64},
+		{"offer_chain", ColumnString},
+		{"offer_amount", ColumnUint64},
+		{"request_chain", ColumnString},
+		{"request_amount", ColumnUint64},
+		{"preferred_methods", ColumnString},
+		{"fee_terms", ColumnString},
+		{"created_at", ColumnInt64},
+		{"expires_at", ColumnInt64},
+		{"updated_at", ColumnInt64},
+	},
+	rows: func(store *storage.Storage) ([][]interface{}, error) {
+		orders, err := store.ListOrders(storage.OrderFilter{})
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(orders))
+		for _, o := range orders {
+			rows = append(rows, []interface{}{
+				o.ID, o.PeerID, string(o.Status), boolInt(o.IsLocal),
+				o.OfferChain, o.OfferAmount, o.RequestChain, o.RequestAmount,
+				strings.Join(o.PreferredMethods, ","), o.FeeTerms,
+				o.CreatedAt.Unix(), unixOrZero(o.ExpiresAt), unixOrZero(o.UpdatedAt),
+			})
+		}
+		return rows, nil
+	},
+}
+
+var tradesDataset = &Dataset{
+	Name:    DatasetTrades,
+	Version: 1,
+	Columns: []Column{
+		{"id", ColumnString},
+		{"order_id", ColumnString},
+		{"maker_peer_id", ColumnString},
+		{"taker_peer_id", ColumnString},
+		{"our_role", ColumnString},
+		{"method", ColumnString},
+		{"state", ColumnString},
+		{"offer_chain", ColumnString},
+		{"offer_amount", ColumnUint64},
+		{"request_chain", ColumnString},
+		{"request_amount", ColumnUint64},
+		{"failure_reason", ColumnString},
+		{"created_at", ColumnInt64},
+		{"updated_at", ColumnInt64},
+		{"completed_at", ColumnInt64},
+	},
+	rows: func(store *storage.Storage) ([][]interface{}, error) {
+		trades, err := store.ListTrades(storage.TradeFilter{})
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(trades))
+		for _, t := range trades {
+			rows = append(rows, []interface{}{
+				t.ID, t.OrderID, t.MakerPeerID, t.TakerPeerID,
+				string(t.OurRole), t.Method, string(t.State),
+				t.OfferChain, t.OfferAmount, t.RequestChain, t.RequestAmount,
+				t.FailureReason,
+				t.CreatedAt.Unix(), unixOrZero(t.UpdatedAt), unixOrZero(t.CompletedAt),
+			})
+		}
+		return rows, nil
+	},
+}
+
+// swapsDataset exports swap outcomes. Method data is never exported since it
+// holds signing session state.
+var swapsDataset = &Dataset{
+	Name:    DatasetSwaps,
+	Version: 1,
+	Columns: []Column{
+		{"trade_id", ColumnString},
+		{"order_id", ColumnString},
+		{"our_role", ColumnString},
+		{"state", ColumnString},
+		{"offer_chain", ColumnString},
+		{"offer_amount", ColumnUint64},
+		{"request_chain", ColumnString},
+		{"request_amount", ColumnUint64},
+		{"local_funding_txid", ColumnString},
+		{"remote_funding_txid", ColumnString},
+		{"redeem_txid", ColumnString},
+		{"refund_txid", ColumnString},
+		{"timeout_height", ColumnInt64},
+		{"request_timeout_height", ColumnInt64},
+		{"fee_terms", ColumnString},
+		{"failure_reason", ColumnString},
+		{"created_at", ColumnInt64},
+		{"updated_at", ColumnInt64},
+		{"completed_at", ColumnInt64},
+	},
+	rows: func(store *storage.Storage) ([][]interface{}, error) {
+		swaps, err := store.ListSwaps(0, true)
+		if err != nil {
+			return nil, err
+		}
+		rows := make([][]interface{}, 0, len(swaps))
+		for _, s := range swaps {
+			rows = append(rows, []interface{}{
+				s.TradeID, s.OrderID, s.OurRole, string(s.State),
+				s.OfferChain, s.OfferAmount, s.RequestChain, s.RequestAmount,
+				s.LocalFundingTxID, s.RemoteFundingTxID, s.RedeemTxID, s.RefundTxID,
+				int64(s.TimeoutHeight), int64(s.RequestTimeoutHeight),
+				string(s.FeeTerms), s.FailureReason,
+				s.CreatedAt.Unix(), s.UpdatedAt.Unix(), unixOrZero(&s.CompletedAt),
+			})
+		}
+		return rows, nil
+	},
+}
+
+// Fee ledger entry types.
+const (
+	FeeTypeDAO            = "dao"
+	FeeTypeClaimAllowance = "claim_allowance"
+)
+
+// feesDataset is the fee ledger derived from redeemed swaps: the DAO fee we
+// paid and any claim fee allowance we covered for the counterparty.
+var feesDataset = &Dataset{
+	Name:    DatasetFees,
+	Version: 1,
+	Columns: []Column{
+		{"trade_id", ColumnString},
+		{"fee_type", ColumnString},
+		{"chain", ColumnString},
+		{"our_role", ColumnString},
+		{"leg_amount", ColumnUint64},
+		{"amount", ColumnUint64},
+		{"completed_at", ColumnInt64},
+	},
+	rows: func(store *storage.Storage) ([][]interface{}, error) {
+		swaps, err := store.ListSwaps(0, true)
+		if err != nil {
+			return nil, err
+		}
+		var rows [][]interface{}
+		for _, s := range swaps {
+			if s.State != storage.SwapStateRedeemed {
+				continue
+			}
+			terms, err := swap.ParseFeeTerms(s.FeeTerms)
+			if err != nil {
+				return nil, err
+			}
+			for _, entry := range feeEntries(store, s, terms) {
+				rows = append(rows, []interface{}{
+					s.TradeID, entry.feeType, entry.chain, s.OurRole,
+					entry.legAmount, entry.amount, unixOrZero(&s.CompletedAt),
+				})
+			}
+		}
+		return rows, nil
+	},
+}
+
+type feeEntry struct {
+	feeType   string
+	chain     string
+	legAmount uint64
+	amount    uint64
+}
+
+// feeEntries derives the fees we paid on a redeemed swap, following where the
+// coordinator charges them: MuSig2 takes the DAO fee from our funding leg,
+// HTLC methods take it from the leg we claim.
+func feeEntries(store *storage.Storage, s *storage.SwapRecord, terms swap.FeeTerms) []feeEntry {
+	fundChain, fundAmount := s.RequestChain, s.RequestAmount
+	claimChain, claimAmount := s.OfferChain, s.OfferAmount
+	if s.IsMaker {
+		fundChain, fundAmount, claimChain, claimAmount = claimChain, claimAmount, fundChain, fundAmount
+	}
+
+	daoChain, daoAmount := fundChain, fundAmount
+	if trade, err := store.GetTrade(s.TradeID); err == nil && strings.HasPrefix(trade.Method, "htlc") {
+		daoChain, daoAmount = claimChain, claimAmount
+	}
+
+	var entries []feeEntry
+	if fee := terms.DAOFee(daoAmount, s.IsMaker); fee > 0 {
+		entries = append(entries, feeEntry{FeeTypeDAO, daoChain, daoAmount, fee})
+	}
+
+	ourPayer := swap.FeePayerTaker
+	if s.IsMaker {
+		ourPayer = swap.FeePayerMaker
+	}
+	if terms.ClaimFeePayer == ourPayer && terms.ClaimFeeAllowance > 0 {
+		entries = append(entries, feeEntry{FeeTypeClaimAllowance, fundChain, fundAmount, terms.ClaimFeeAllowance})
+	}
+	return entries
+}
@@ -0,0 +1,292 @@
+// Package export writes orders, trades, swaps and the fee ledger as CSV or
+// Parquet files for analysis in external tools.
+//
+// Each run writes <dataset>.v<version>.<run_id>.<format> files plus a
+// manifest.<run_id>.json describing the dataset schema versions, columns and
+// row counts. Runs happen on demand (export_run) or periodically, and can be
+// uploaded to an S3-compatible endpoint.
+package export
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/s3"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Export formats.
+const (
+	FormatCSV     = "csv"
+	FormatParquet = "parquet"
+)
+
+// File is one exported dataset file.
+type File struct {
+	Dataset string `json:"dataset"`
+	Version int    `json:"version"`
+	Format  string `json:"format"`
+	Name    string `json:"name"`
+	Rows    int    `json:"rows"`
+}
+
+// ManifestDataset describes one dataset in a run manifest.
+type ManifestDataset struct {
+	Name    string   `json:"name"`
+	Version int      `json:"version"`
+	Columns []Column `json:"columns"`
+	Rows    int      `json:"rows"`
+}
+
+// Manifest describes an export run.
+type Manifest struct {
+	RunID     string             `json:"run_id"`
+	CreatedAt int64              `json:"created_at"`
+	Datasets  []*ManifestDataset `json:"datasets"`
+	Files     []*File            `json:"files"`
+}
+
+// Result is the outcome of an export run.
+type Result struct {
+	Manifest *Manifest `json:"manifest"`
+	Dir      string    `json:"dir"`
+	Uploaded bool      `json:"uploaded"`
+}
+
+// Exporter dumps storage tables for analytics.
+type Exporter struct {
+	cfg   node.ExportConfig
+	store *storage.Storage
+	s3    *s3.Client
+	log   *logging.Logger
+
+	runMu  sync.Mutex
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates an exporter. cfg.Dir must be set.
+func New(cfg node.ExportConfig, store *storage.Storage) (*Exporter, error) {
+	defaults := node.DefaultConfig().Export
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if len(cfg.Formats) == 0 {
+		cfg.Formats = defaults.Formats
+	}
+	if err := validateFormats(cfg.Formats); err != nil {
+		return nil, err
+	}
+	if cfg.Dir == "" {
+		return nil, fmt.Errorf("export dir is required")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e := &Exporter{
+		cfg:    cfg,
+		store:  store,
+		log:    logging.GetDefault().Component("export"),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	if cfg.S3.Enabled() {
+		e.s3 = s3.New(cfg.S3)
+	}
+	return e, nil
+}
+
+// Start runs an export every configured interval.
+func (e *Exporter) Start() {
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		ticker := time.NewTicker(e.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-ticker.C:
+				result, err := e.Run(e.ctx, nil, nil)
+				if err != nil {
+					e.log.Warn("Periodic export failed", "error", err)
+					continue
+				}
+				e.log.Info("Export written", "run_id", result.Manifest.RunID, "files", len(result.Manifest.Files))
+			}
+		}
+	}()
+	e.log.Info("Exporter started", "dir", e.cfg.Dir, "interval", e.cfg.Interval, "formats", e.cfg.Formats, "s3", e.s3 != nil)
+}
+
+// Stop stops periodic exports.
+func (e *Exporter) Stop() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+// Run exports the named datasets (all when empty) in the given formats
+// (the configured formats when empty).
+func (e *Exporter) Run(ctx context.Context, datasets, formats []string) (*Result, error) {
+	if len(formats) == 0 {
+		formats = e.cfg.Formats
+	}
+	if err := validateFormats(formats); err != nil {
+		return nil, err
+	}
+	selected, err := selectDatasets(datasets)
+	if err != nil {
+		return nil, err
+	}
+
+	e.runMu.Lock()
+	defer e.runMu.Unlock()
+
+	if err := os.MkdirAll(e.cfg.Dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create export dir: %w", err)
+	}
+
+	now := time.Now().UTC()
+	manifest := &Manifest{
+		RunID:     now.Format("20060102T150405Z"),
+		CreatedAt: now.Unix(),
+	}
+	outputs := make(map[string][]byte)
+
+	for _, ds := range selected {
+		rows, err := ds.rows(e.store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", ds.Name, err)
+		}
+		manifest.Datasets = append(manifest.Datasets, &ManifestDataset{
+			Name: ds.Name, Version: ds.Version, Columns: ds.Columns, Rows: len(rows),
+		})
+
+		for _, format := range formats {
+			var buf bytes.Buffer
+			switch format {
+			case FormatCSV:
+				err = writeCSV(&buf, ds.Columns, rows)
+			case FormatParquet:
+				err = writeParquet(&buf, ds.Columns, rows, map[string]string{
+					"klingdex.dataset":        ds.Name,
+					"klingdex.schema_version": strconv.Itoa(ds.Version),
+				})
+			}
+			if err != nil {
+				return nil, fmt.Errorf("failed to encode %s as %s: %w", ds.Name, format, err)
+			}
+
+			name := fmt.Sprintf("%s.v%d.%s.%s", ds.Name, ds.Version, manifest.RunID, format)
+			outputs[name] = buf.Bytes()
+			manifest.Files = append(manifest.Files, &File{
+				Dataset: ds.Name, Version: ds.Version, Format: format, Name: name, Rows: len(rows),
+			})
+		}
+	}
+
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	outputs["manifest."+manifest.RunID+".json"] = manifestData
+
+	// Write the manifest last so its presence marks a complete run
+	names := sortedKeys(outputs)
+	sort.SliceStable(names, func(i, j int) bool { return !isManifest(names[i]) && isManifest(names[j]) })
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(e.cfg.Dir, name), outputs[name], 0600); err != nil {
+			return nil, fmt.Errorf("failed to write %s: %w", name, err)
+		}
+	}
+
+	result := &Result{Manifest: manifest, Dir: e.cfg.Dir}
+	if e.s3 != nil {
+		for _, name := range names {
+			if err := e.s3.Put(ctx, name, outputs[name]); err != nil {
+				return result, err
+			}
+		}
+		result.Uploaded = true
+	}
+	return result, nil
+}
+
+func isManifest(name string) bool {
+	return filepath.Ext(name) == ".json"
+}
+
+// writeCSV writes a header row followed by the rows.
+func writeCSV(buf *bytes.Buffer, columns []Column, rows [][]interface{}) error {
+	w := csv.NewWriter(buf)
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = col.Name
+	}
+	if err := w.Write(header); err != nil {
+		return err
+	}
+
+	record := make([]string, len(columns))
+	for _, row := range rows {
+		for i, v := range row {
+			record[i] = fmt.Sprint(v)
+		}
+		if err := w.Write(record); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func validateFormats(formats []string) error {
+	for _, f := range formats {
+		if f != FormatCSV && f != FormatParquet {
+			return fmt.Errorf("unsupported export format: %q (use csv or parquet)", f)
+		}
+	}
+	return nil
+}
+
+func selectDatasets(names []string) ([]*Dataset, error) {
+	all := Datasets()
+	if len(names) == 0 {
+		return all, nil
+	}
+	var selected []*Dataset
+	for _, name := range names {
+		var found *Dataset
+		for _, ds := range all {
+			if ds.Name == name {
+				found = ds
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("unknown dataset: %q", name)
+		}
+		selected = append(selected, found)
+	}
+	return selected, nil
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,161 @@
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func newTestExporter(t *testing.T, formats ...string) (*Exporter, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	e, err := New(node.ExportConfig{Dir: t.TempDir(), Formats: formats}, store)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return e, store
+}
+
+func seedSwap(t *testing.T, store *storage.Storage, terms swap.FeeTerms) {
+	t.Helper()
+	now := time.Now()
+	order := &storage.Order{
+		ID: "order-1", PeerID: "peer-a", Status: storage.OrderStatusCompleted, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 1000000, RequestChain: "LTC", RequestAmount: 50000000,
+		PreferredMethods: []string{"musig2", "htlc"}, CreatedAt: now,
+	}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateTrade(&storage.Trade{
+		ID: "trade-1", OrderID: "order-1", MakerPeerID: "peer-a", TakerPeerID: "peer-b",
+		OurRole: storage.TradeRoleMaker, Method: "musig2", State: storage.TradeStateRedeemed,
+		OfferChain: "BTC", OfferAmount: 1000000, RequestChain: "LTC", RequestAmount: 50000000, CreatedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+	feeTerms, _ := json.Marshal(terms)
+	if err := store.SaveSwap(&storage.SwapRecord{
+		TradeID: "trade-1", OrderID: "order-1", MakerPeerID: "peer-a", TakerPeerID: "peer-b",
+		OurRole: "maker", IsMaker: true,
+		OfferChain: "BTC", OfferAmount: 1000000, RequestChain: "LTC", RequestAmount: 50000000,
+		State: storage.SwapStateRedeemed, FeeTerms: feeTerms, MethodData: json.RawMessage(`{"secret":"never exported"}`),
+		CompletedAt: now,
+	}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readCSV(t *testing.T, path string) [][]string {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return records
+}
+
+func TestRunWritesVersionedFilesAndManifest(t *testing.T) {
+	e, store := newTestExporter(t, FormatCSV, FormatParquet)
+	seedSwap(t, store, swap.FeeTerms{ClaimFeePayer: swap.FeePayerMaker, ClaimFeeAllowance: 500})
+
+	result, err := e.Run(context.Background(), nil, nil)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	m := result.Manifest
+	if len(m.Datasets) != 4 || len(m.Files) != 8 {
+		t.Fatalf("manifest has %d datasets and %d files, want 4 and 8", len(m.Datasets), len(m.Files))
+	}
+
+	manifestData, err := os.ReadFile(filepath.Join(result.Dir, "manifest."+m.RunID+".json"))
+	if err != nil {
+		t.Fatalf("manifest not written: %v", err)
+	}
+	var onDisk Manifest
+	if err := json.Unmarshal(manifestData, &onDisk); err != nil || onDisk.RunID != m.RunID {
+		t.Errorf("manifest on disk = %+v, %v", onDisk, err)
+	}
+
+	for _, f := range m.Files {
+		if !strings.HasPrefix(f.Name, f.Dataset+".v1.") {
+			t.Errorf("file %s does not carry its schema version", f.Name)
+		}
+		if _, err := os.Stat(filepath.Join(result.Dir, f.Name)); err != nil {
+			t.Errorf("file %s not written: %v", f.Name, err)
+		}
+	}
+
+	swaps := readCSV(t, filepath.Join(result.Dir, "swaps.v1."+m.RunID+".csv"))
+	if len(swaps) != 2 || swaps[1][0] != "trade-1" {
+		t.Errorf("swaps.csv = %v", swaps)
+	}
+	for _, col := range swaps[0] {
+		if col == "method_data" {
+			t.Error("method data must not be exported")
+		}
+	}
+
+	orders := readCSV(t, filepath.Join(result.Dir, "orders.v1."+m.RunID+".csv"))
+	if len(orders) != 2 || orders[1][8] != "musig2,htlc" {
+		t.Errorf("orders.csv = %v", orders)
+	}
+}
+
+func TestFeeLedger(t *testing.T) {
+	e, store := newTestExporter(t)
+	seedSwap(t, store, swap.FeeTerms{ClaimFeePayer: swap.FeePayerMaker, ClaimFeeAllowance: 500})
+
+	result, err := e.Run(context.Background(), []string{DatasetFees}, []string{FormatCSV})
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	fees := readCSV(t, filepath.Join(result.Dir, "fees.v1."+result.Manifest.RunID+".csv"))
+	if len(fees) != 3 {
+		t.Fatalf("fees.csv = %v, want header + dao + claim allowance", fees)
+	}
+
+	// Maker on MuSig2 pays its DAO fee on the BTC funding leg
+	wantDAO := swap.CalculateDAOFee(1000000, true)
+	if fees[1][1] != FeeTypeDAO || fees[1][2] != "BTC" || fees[1][5] != strconv.FormatUint(wantDAO, 10) {
+		t.Errorf("dao fee row = %v, want BTC %d", fees[1], wantDAO)
+	}
+	if fees[2][1] != FeeTypeClaimAllowance || fees[2][5] != "500" {
+		t.Errorf("claim allowance row = %v", fees[2])
+	}
+}
+
+func TestRunRejectsUnknownDatasetAndFormat(t *testing.T) {
+	e, _ := newTestExporter(t)
+	ctx := context.Background()
+
+	if _, err := e.Run(ctx, []string{"secrets"}, nil); err == nil {
+		t.Error("expected error for unknown dataset")
+	}
+	if _, err := e.Run(ctx, nil, []string{"xlsx"}); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if _, err := New(node.ExportConfig{Dir: t.TempDir(), Formats: []string{"json"}}, nil); err == nil {
+		t.Error("expected New() to reject unknown formats")
+	}
+}
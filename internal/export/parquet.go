@@ -0,0 +1,287 @@
+// Package export - Minimal Apache Parquet writer.
+//
+// Writes a single row group of REQUIRED INT64 and UTF8 BYTE_ARRAY columns,
+// PLAIN encoded and uncompressed, with the file metadata in Thrift compact
+// protocol. This covers the flat tables we export without pulling in a
+// Parquet dependency.
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+const parquetMagic = "PAR1"
+
+// Parquet physical types, encodings and enums used by the writer.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeByteArray = 6
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetRepetitionRequired = 0
+	parquetConvertedUTF8      = 0
+	parquetConvertedUint64    = 10
+	parquetCodecUncompressed  = 0
+	parquetPageData           = 0
+)
+
+// Thrift compact protocol field types.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// writeParquet writes a table as a Parquet file. Metadata is stored in the
+// file's key/value metadata (e.g. the schema version).
+func writeParquet(w io.Writer, columns []Column, rows [][]interface{}, metadata map[string]string) error {
+	var buf bytes.Buffer
+	buf.WriteString(parquetMagic)
+
+	type chunk struct {
+		offset int64
+		size   int64
+	}
+	chunks := make([]chunk, len(columns))
+
+	for i, col := range columns {
+		var page bytes.Buffer
+		for _, row := range rows {
+			if err := writePlainValue(&page, col, row[i]); err != nil {
+				return err
+			}
+		}
+
+		header := newThriftWriter()
+		header.i32(1, parquetPageData)
+		header.i32(2, int32(page.Len()))
+		header.i32(3, int32(page.Len()))
+		header.beginStruct(5) // DataPageHeader
+		header.i32(1, int32(len(rows)))
+		header.i32(2, parquetEncodingPlain)
+		header.i32(3, parquetEncodingRLE)
+		header.i32(4, parquetEncodingRLE)
+		header.endStruct()
+		header.stop()
+
+		chunks[i].offset = int64(buf.Len())
+		buf.Write(header.bytes())
+		buf.Write(page.Bytes())
+		chunks[i].size = int64(buf.Len()) - chunks[i].offset
+	}
+
+	var totalSize int64
+	for _, c := range chunks {
+		totalSize += c.size
+	}
+
+	meta := newThriftWriter()
+	meta.i32(1, 1) // version
+
+	// Schema: root element followed by one leaf per column
+	meta.listHeader(2, thriftStruct, len(columns)+1)
+	meta.beginElem()
+	meta.binary(4, "schema")
+	meta.i32(5, int32(len(columns)))
+	meta.endElem()
+	for _, col := range columns {
+		meta.beginElem()
+		meta.i32(1, col.parquetType())
+		meta.i32(3, parquetRepetitionRequired)
+		meta.binary(4, col.Name)
+		switch col.Type {
+		case ColumnString:
+			meta.i32(6, parquetConvertedUTF8)
+		case ColumnUint64:
+			meta.i32(6, parquetConvertedUint64)
+		}
+		meta.endElem()
+	}
+
+	meta.i64(3, int64(len(rows)))
+
+	// One row group holding every column chunk
+	meta.listHeader(4, thriftStruct, 1)
+	meta.beginElem()
+	meta.listHeader(1, thriftStruct, len(columns))
+	for i, col := range columns {
+		meta.beginElem()
+		meta.i64(2, chunks[i].offset)
+		meta.beginStruct(3) // ColumnMetaData
+		meta.i32(1, col.parquetType())
+		meta.listHeader(2, thriftI32, 1)
+		meta.varint(zigzag(parquetEncodingPlain))
+		meta.listHeader(3, thriftBinary, 1)
+		meta.rawBinary(col.Name)
+		meta.i32(4, parquetCodecUncompressed)
+		meta.i64(5, int64(len(rows)))
+		meta.i64(6, chunks[i].size)
+		meta.i64(7, chunks[i].size)
+		meta.i64(9, chunks[i].offset)
+		meta.endStruct()
+		meta.endElem()
+	}
+	meta.i64(2, totalSize)
+	meta.i64(3, int64(len(rows)))
+	meta.endElem()
+
+	if len(metadata) > 0 {
+		keys := sortedKeys(metadata)
+		meta.listHeader(5, thriftStruct, len(keys))
+		for _, k := range keys {
+			meta.beginElem()
+			meta.binary(1, k)
+			meta.binary(2, metadata[k])
+			meta.endElem()
+		}
+	}
+	meta.binary(6, "klingdex")
+	meta.stop()
+
+	footer := meta.bytes()
+	buf.Write(footer)
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	buf.Write(length[:])
+	buf.WriteString(parquetMagic)
+
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// writePlainValue appends a PLAIN-encoded value for the column.
+func writePlainValue(buf *bytes.Buffer, col Column, v interface{}) error {
+	switch col.Type {
+	case ColumnInt64:
+		n, ok := v.(int64)
+		if !ok {
+			return fmt.Errorf("column %s: expected int64, got %T", col.Name, v)
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(n))
+		buf.Write(b[:])
+	case ColumnUint64:
+		n, ok := v.(uint64)
+		if !ok {
+			return fmt.Errorf("column %s: expected uint64, got %T", col.Name, v)
+		}
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], n)
+		buf.Write(b[:])
+	default:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("column %s: expected string, got %T", col.Name, v)
+		}
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], uint32(len(s)))
+		buf.Write(b[:])
+		buf.WriteString(s)
+	}
+	return nil
+}
+
+func (c Column) parquetType() int32 {
+	if c.Type == ColumnInt64 || c.Type == ColumnUint64 {
+		return parquetTypeInt64
+	}
+	return parquetTypeByteArray
+}
+
+// thriftWriter encodes structs in the Thrift compact protocol.
+type thriftWriter struct {
+	buf    bytes.Buffer
+	last   int16
+	parent []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{}
+}
+
+func (t *thriftWriter) bytes() []byte {
+	return t.buf.Bytes()
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	t.buf.Write(b[:n])
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.rawBinary(s)
+}
+
+func (t *thriftWriter) rawBinary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) listHeader(id int16, elemType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf.WriteByte(byte(size)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xF0 | elemType)
+		t.varint(uint64(size))
+	}
+}
+
+// beginStruct starts a nested struct field.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.beginElem()
+}
+
+func (t *thriftWriter) endStruct() {
+	t.endElem()
+}
+
+// beginElem starts a struct that is a list element (no field header).
+func (t *thriftWriter) beginElem() {
+	t.parent = append(t.parent, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) endElem() {
+	t.stop()
+	t.last = t.parent[len(t.parent)-1]
+	t.parent = t.parent[:len(t.parent)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
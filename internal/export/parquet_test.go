@@ -0,0 +1,143 @@
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// thriftReader decodes Thrift compact structs into field maps for inspection.
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.data[r.pos : r.pos+n])
+		r.pos += n
+		return s
+	case thriftList:
+		header := r.data[r.pos]
+		r.pos++
+		size, elem := int(header>>4), header&0x0F
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.value(elem)
+		}
+		return list
+	case thriftStruct:
+		return r.structure()
+	}
+	panic("unsupported thrift type")
+}
+
+func (r *thriftReader) structure() map[int16]interface{} {
+	fields := make(map[int16]interface{})
+	var last int16
+	for {
+		header := r.data[r.pos]
+		r.pos++
+		if header == 0 {
+			return fields
+		}
+		typ := header & 0x0F
+		id := last + int16(header>>4)
+		if header>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		fields[id] = r.value(typ)
+		last = id
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	columns := []Column{{"id", ColumnString}, {"amount", ColumnUint64}, {"created_at", ColumnInt64}}
+	rows := [][]interface{}{
+		{"order-1", uint64(18446744073709551615), int64(1700000000)},
+		{"order-2", uint64(5), int64(-1)},
+	}
+
+	var buf bytes.Buffer
+	if err := writeParquet(&buf, columns, rows, map[string]string{"klingdex.schema_version": "1"}); err != nil {
+		t.Fatalf("writeParquet() error = %v", err)
+	}
+	data := buf.Bytes()
+
+	if string(data[:4]) != parquetMagic || string(data[len(data)-4:]) != parquetMagic {
+		t.Fatal("missing PAR1 magic")
+	}
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	meta := (&thriftReader{data: data[len(data)-8-footerLen : len(data)-8]}).structure()
+
+	if meta[3].(int64) != 2 {
+		t.Errorf("num_rows = %v, want 2", meta[3])
+	}
+	schema := meta[2].([]interface{})
+	if len(schema) != 4 || schema[0].(map[int16]interface{})[5].(int64) != 3 {
+		t.Fatalf("schema = %v, want root with 3 children", schema)
+	}
+	if leaf := schema[2].(map[int16]interface{}); leaf[4] != "amount" || leaf[1].(int64) != parquetTypeInt64 || leaf[6].(int64) != parquetConvertedUint64 {
+		t.Errorf("amount schema element = %v", leaf)
+	}
+	kv := meta[5].([]interface{})[0].(map[int16]interface{})
+	if kv[1] != "klingdex.schema_version" || kv[2] != "1" {
+		t.Errorf("key_value_metadata = %v", kv)
+	}
+
+	// Read the id column back from its data page
+	rowGroup := meta[4].([]interface{})[0].(map[int16]interface{})
+	chunks := rowGroup[1].([]interface{})
+	if len(chunks) != 3 {
+		t.Fatalf("column chunks = %d, want 3", len(chunks))
+	}
+	colMeta := chunks[0].(map[int16]interface{})[3].(map[int16]interface{})
+	r := &thriftReader{data: data, pos: int(colMeta[9].(int64))}
+	pageHeader := r.structure()
+	if pageHeader[5].(map[int16]interface{})[1].(int64) != 2 {
+		t.Errorf("page num_values = %v", pageHeader[5])
+	}
+	var ids []string
+	for i := 0; i < 2; i++ {
+		n := int(binary.LittleEndian.Uint32(data[r.pos:]))
+		ids = append(ids, string(data[r.pos+4:r.pos+4+n]))
+		r.pos += 4 + n
+	}
+	if ids[0] != "order-1" || ids[1] != "order-2" {
+		t.Errorf("id column = %v", ids)
+	}
+
+	// The amount column keeps the full uint64 range
+	colMeta = chunks[1].(map[int16]interface{})[3].(map[int16]interface{})
+	r = &thriftReader{data: data, pos: int(colMeta[9].(int64))}
+	r.structure()
+	if got := binary.LittleEndian.Uint64(data[r.pos:]); got != 18446744073709551615 {
+		t.Errorf("amount = %d, want max uint64", got)
+	}
+}
+
+func TestWriteParquetTypeMismatch(t *testing.T) {
+	var buf bytes.Buffer
+	err := writeParquet(&buf, []Column{{"amount", ColumnUint64}}, [][]interface{}{{"not a number"}}, nil)
+	if err == nil {
+		t.Error("expected error for a value of the wrong type")
+	}
+}
@@ -0,0 +1,54 @@
+package fuzz
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestBytes(t *testing.T) {
+	valid := []byte{1, 2, 3, 4}
+	seeds := Bytes(valid)
+
+	wantLens := map[int]bool{0: false, 2: false, 3: false, 4: false, 5: false}
+	for _, s := range seeds {
+		if _, ok := wantLens[len(s)]; ok {
+			wantLens[len(s)] = true
+		}
+	}
+	for l, seen := range wantLens {
+		if !seen {
+			t.Errorf("no seed of length %d", l)
+		}
+	}
+	if !bytes.Equal(valid, []byte{1, 2, 3, 4}) {
+		t.Errorf("Bytes() modified its input: %v", valid)
+	}
+}
+
+func TestHex(t *testing.T) {
+	seeds := Hex([]byte{0xab, 0xcd})
+	var valid, odd bool
+	for _, s := range seeds {
+		switch s {
+		case "abcd":
+			valid = true
+		case "abc":
+			odd = true
+		}
+	}
+	if !valid || !odd {
+		t.Errorf("Hex() = %v, want valid and odd-length seeds", seeds)
+	}
+}
+
+func TestJSON(t *testing.T) {
+	seeds := JSON(map[string]string{"secret": "00"})
+	var v map[string]string
+	if err := json.Unmarshal(seeds[0], &v); err != nil || v["secret"] != "00" {
+		t.Errorf("first seed should be the valid document: %s", seeds[0])
+	}
+	if json.Valid(seeds[1]) {
+		t.Errorf("truncated seed is valid JSON: %s", seeds[1])
+	}
+}
@@ -0,0 +1,269 @@
+// Package lightning - Core Lightning client.
+//
+// CLN is driven through its clnrest plugin: every RPC method is a POST to
+// /v1/<method> with JSON parameters and the rune in a header.
+package lightning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// clnPollInterval is how often a pending payment is looked up.
+const clnPollInterval = 2 * time.Second
+
+// clnClient is a Client for CLN's clnrest plugin.
+type clnClient struct {
+	host           string
+	rune           string
+	client         *http.Client
+	paymentTimeout time.Duration
+}
+
+func newCLN(cfg Config) (*clnClient, error) {
+	u, err := url.Parse(cfg.Host)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid lightning.host %q", cfg.Host)
+	}
+	token, err := os.ReadFile(cfg.RunePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rune: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSCertPath != "" {
+		if transport.TLSClientConfig, err = lndTLSConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return &clnClient{
+		host:           strings.TrimRight(cfg.Host, "/"),
+		rune:           strings.TrimSpace(string(token)),
+		client:         &http.Client{Transport: transport, Timeout: cfg.PaymentTimeout + 30*time.Second},
+		paymentTimeout: cfg.PaymentTimeout,
+	}, nil
+}
+
+// clnMsat decodes CLN amounts, numbers of msat or "<n>msat" strings on
+// older versions, into sat.
+type clnMsat uint64
+
+func (m *clnMsat) UnmarshalJSON(data []byte) error {
+	s := strings.TrimSuffix(strings.Trim(string(data), `"`), "msat")
+	if s == "" || s == "null" {
+		*m = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*m = clnMsat(v / 1000)
+	return nil
+}
+
+func (c *clnClient) AddInvoice(ctx context.Context, preimage []byte, amountSat uint64, expiry time.Duration, memo string) (*Invoice, error) {
+	// Labels are unique, also across invoices reissued for the same hash
+	hash := sha256.Sum256(preimage)
+	req := map[string]interface{}{
+		"amount_msat": amountSat * 1000,
+		"label":       "klingdex-" + hex.EncodeToString(hash[:]) + "-" + strconv.FormatInt(time.Now().UnixNano(), 36),
+		"description": memo,
+		"expiry":      int64(expiry / time.Second),
+		"preimage":    hex.EncodeToString(preimage),
+	}
+	var resp struct {
+		PaymentHash string `json:"payment_hash"`
+		Bolt11      string `json:"bolt11"`
+	}
+	if err := c.call(ctx, "invoice", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to add invoice: %w", err)
+	}
+	paymentHash, err := hex.DecodeString(resp.PaymentHash)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payment hash %q", resp.PaymentHash)
+	}
+	return &Invoice{PaymentRequest: resp.Bolt11, PaymentHash: paymentHash, AmountSat: amountSat, State: InvoiceOpen}, nil
+}
+
+func (c *clnClient) LookupInvoice(ctx context.Context, paymentHash []byte) (*Invoice, error) {
+	var resp struct {
+		Invoices []struct {
+			Bolt11     string  `json:"bolt11"`
+			AmountMsat clnMsat `json:"amount_msat"`
+			Status     string  `json:"status"`
+		} `json:"invoices"`
+	}
+	if err := c.call(ctx, "listinvoices", map[string]string{"payment_hash": hex.EncodeToString(paymentHash)}, &resp); err != nil {
+		return nil, err
+	}
+	if len(resp.Invoices) == 0 {
+		return nil, ErrInvoiceNotFound
+	}
+	inv := resp.Invoices[0]
+	state := InvoiceOpen
+	switch inv.Status {
+	case "paid":
+		state = InvoiceSettled
+	case "expired":
+		state = InvoiceCanceled
+	}
+	return &Invoice{PaymentRequest: inv.Bolt11, PaymentHash: paymentHash, AmountSat: uint64(inv.AmountMsat), State: state}, nil
+}
+
+func (c *clnClient) DecodeInvoice(ctx context.Context, paymentRequest string) (*DecodedInvoice, error) {
+	var resp struct {
+		Valid       bool    `json:"valid"`
+		Payee       string  `json:"payee"`
+		PaymentHash string  `json:"payment_hash"`
+		AmountMsat  clnMsat `json:"amount_msat"`
+		CreatedAt   int64   `json:"created_at"`
+		Expiry      int64   `json:"expiry"`
+		CLTVExpiry  uint32  `json:"min_final_cltv_expiry"`
+	}
+	if err := c.call(ctx, "decode", map[string]string{"string": paymentRequest}, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode invoice: %w", err)
+	}
+	if !resp.Valid {
+		return nil, fmt.Errorf("failed to decode invoice: not a valid bolt11 invoice")
+	}
+	hash, err := hex.DecodeString(resp.PaymentHash)
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("invalid payment hash %q", resp.PaymentHash)
+	}
+	return &DecodedInvoice{
+		Destination: resp.Payee,
+		PaymentHash: hash,
+		AmountSat:   uint64(resp.AmountMsat),
+		CreatedAt:   time.Unix(resp.CreatedAt, 0),
+		Expiry:      time.Duration(resp.Expiry) * time.Second,
+		CLTVExpiry:  resp.CLTVExpiry,
+	}, nil
+}
+
+// clnPayment is a payment as returned by pay and listpays.
+type clnPayment struct {
+	Status          string  `json:"status"`
+	PaymentPreimage string  `json:"payment_preimage"` // pay
+	Preimage        string  `json:"preimage"`         // listpays
+	AmountMsat      clnMsat `json:"amount_msat"`
+	AmountSentMsat  clnMsat `json:"amount_sent_msat"`
+}
+
+// toPayment returns the payment once it completed, or nil while it is
+// pending.
+func (p *clnPayment) toPayment() (*Payment, error) {
+	switch p.Status {
+	case "complete":
+		preimage, err := hex.DecodeString(p.PaymentPreimage + p.Preimage)
+		if err != nil || len(preimage) != 32 {
+			return nil, fmt.Errorf("%w: invalid preimage", ErrPaymentFailed)
+		}
+		var fee uint64
+		if p.AmountSentMsat > p.AmountMsat {
+			fee = uint64(p.AmountSentMsat - p.AmountMsat)
+		}
+		return &Payment{Preimage: preimage, FeeSat: fee}, nil
+	case "failed":
+		return nil, fmt.Errorf("%w: payment failed", ErrPaymentFailed)
+	default:
+		return nil, nil
+	}
+}
+
+func (c *clnClient) PayInvoice(ctx context.Context, paymentRequest string, maxFeeSat uint64) (*Payment, error) {
+	req := map[string]interface{}{
+		"bolt11":    paymentRequest,
+		"maxfee":    maxFeeSat * 1000,
+		"retry_for": int64(c.paymentTimeout / time.Second),
+	}
+	var resp clnPayment
+	if err := c.call(ctx, "pay", req, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	p, err := resp.toPayment()
+	if p == nil && err == nil {
+		err = fmt.Errorf("%w: payment still pending", ErrPaymentFailed)
+	}
+	return p, err
+}
+
+func (c *clnClient) TrackPayment(ctx context.Context, paymentHash []byte) (*Payment, error) {
+	ticker := time.NewTicker(clnPollInterval)
+	defer ticker.Stop()
+	for {
+		var resp struct {
+			Pays []clnPayment `json:"pays"`
+		}
+		if err := c.call(ctx, "listpays", map[string]string{"payment_hash": hex.EncodeToString(paymentHash)}, &resp); err != nil {
+			return nil, err
+		}
+		if len(resp.Pays) == 0 {
+			return nil, ErrPaymentNotFound
+		}
+		// A failed attempt may have been retried: any completed one wins
+		var pending bool
+		for _, pay := range resp.Pays {
+			if p, _ := pay.toPayment(); p != nil {
+				return p, nil
+			}
+			pending = pending || pay.Status == "pending"
+		}
+		if !pending {
+			return nil, fmt.Errorf("%w: payment failed", ErrPaymentFailed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// call sends an RPC call to clnrest and decodes its result into out.
+func (c *clnClient) call(ctx context.Context, method string, params, out interface{}) error {
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.host+"/v1/"+method, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Rune", c.rune)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("cln request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLNDResponse))
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		if e.Message == "" {
+			e.Message = resp.Status
+		}
+		return fmt.Errorf("cln: %s", e.Message)
+	}
+	return json.Unmarshal(data, out)
+}
@@ -0,0 +1,180 @@
+package lightning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestCLN starts a fake clnrest and a client trusting it.
+func newTestCLN(t *testing.T, handler func(method string, params map[string]interface{}) (interface{}, int)) Client {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || !strings.HasPrefix(r.URL.Path, "/v1/") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Header.Get("Rune") != "rune-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 1501, "message": "Not authorized: Not derived from master"})
+			return
+		}
+		var params map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&params)
+		resp, code := handler(strings.TrimPrefix(r.URL.Path, "/v1/"), params)
+		w.WriteHeader(code)
+		json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "server.pem")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(certPath, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	runePath := filepath.Join(dir, "rune")
+	if err := os.WriteFile(runePath, []byte("rune-token\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{
+		Enabled: true, Implementation: ImplementationCLN, Host: srv.URL,
+		RunePath: runePath, TLSCertPath: certPath,
+		MaxFeeBps: 50, InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestCLNInvoices(t *testing.T) {
+	preimage := bytes.Repeat([]byte{7}, 32)
+	hash := sha256.Sum256(preimage)
+	hashHex := hex.EncodeToString(hash[:])
+
+	c := newTestCLN(t, func(method string, params map[string]interface{}) (interface{}, int) {
+		switch method {
+		case "invoice":
+			if params["preimage"] != hex.EncodeToString(preimage) || params["amount_msat"] != 50000000.0 || params["expiry"] != 3600.0 {
+				t.Errorf("invoice params = %v", params)
+			}
+			return map[string]interface{}{"payment_hash": hashHex, "bolt11": "lntb500u1test", "expires_at": 1700003600}, http.StatusCreated
+		case "listinvoices":
+			if params["payment_hash"] != hashHex {
+				return map[string]interface{}{"invoices": []interface{}{}}, http.StatusCreated
+			}
+			// Older versions send amounts as strings
+			return map[string]interface{}{"invoices": []interface{}{
+				map[string]interface{}{"bolt11": "lntb500u1test", "payment_hash": hashHex, "amount_msat": "50000000msat", "status": "paid"},
+			}}, http.StatusCreated
+		}
+		return map[string]interface{}{"code": -32601, "message": "Unknown command"}, http.StatusInternalServerError
+	})
+	ctx := context.Background()
+
+	inv, err := c.AddInvoice(ctx, preimage, 50000, time.Hour, "klingdex swap")
+	if err != nil {
+		t.Fatalf("AddInvoice() error = %v", err)
+	}
+	if inv.PaymentRequest != "lntb500u1test" || !bytes.Equal(inv.PaymentHash, hash[:]) || inv.State != InvoiceOpen {
+		t.Errorf("AddInvoice() = %+v", inv)
+	}
+
+	got, err := c.LookupInvoice(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("LookupInvoice() error = %v", err)
+	}
+	if got.State != InvoiceSettled || got.AmountSat != 50000 {
+		t.Errorf("LookupInvoice() = %+v", got)
+	}
+	if _, err := c.LookupInvoice(ctx, make([]byte, 32)); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Errorf("LookupInvoice(unknown) error = %v, want ErrInvoiceNotFound", err)
+	}
+}
+
+func TestCLNPay(t *testing.T) {
+	preimage := bytes.Repeat([]byte{9}, 32)
+	hash := sha256.Sum256(preimage)
+	hashHex := hex.EncodeToString(hash[:])
+	failed := bytes.Repeat([]byte{1}, 32)
+
+	c := newTestCLN(t, func(method string, params map[string]interface{}) (interface{}, int) {
+		switch method {
+		case "decode":
+			return map[string]interface{}{
+				"type": "bolt11 invoice", "valid": true, "payee": "02ab", "payment_hash": hashHex,
+				"amount_msat": 50000000, "created_at": 1700000000, "expiry": 3600, "min_final_cltv_expiry": 80,
+			}, http.StatusCreated
+		case "pay":
+			if params["bolt11"] == "lnfail" {
+				return map[string]interface{}{"code": 210, "message": "Ran out of routes to try after 3 attempts"}, http.StatusInternalServerError
+			}
+			if params["maxfee"] != 250000.0 || params["retry_for"] != 60.0 {
+				t.Errorf("pay params = %v", params)
+			}
+			return map[string]interface{}{
+				"status": "complete", "payment_preimage": hex.EncodeToString(preimage),
+				"amount_msat": 50000000, "amount_sent_msat": 50012000,
+			}, http.StatusCreated
+		case "listpays":
+			switch params["payment_hash"] {
+			case hashHex:
+				return map[string]interface{}{"pays": []interface{}{
+					map[string]interface{}{"status": "failed"},
+					map[string]interface{}{"status": "complete", "preimage": hex.EncodeToString(preimage), "amount_msat": 50000000, "amount_sent_msat": 50007000},
+				}}, http.StatusCreated
+			case hex.EncodeToString(failed):
+				return map[string]interface{}{"pays": []interface{}{map[string]interface{}{"status": "failed"}}}, http.StatusCreated
+			}
+			return map[string]interface{}{"pays": []interface{}{}}, http.StatusCreated
+		}
+		return map[string]interface{}{"code": -32601, "message": "Unknown command"}, http.StatusInternalServerError
+	})
+	ctx := context.Background()
+
+	dec, err := c.DecodeInvoice(ctx, "lntb500u1test")
+	if err != nil {
+		t.Fatalf("DecodeInvoice() error = %v", err)
+	}
+	if !bytes.Equal(dec.PaymentHash, hash[:]) || dec.AmountSat != 50000 || dec.CLTVExpiry != 80 || dec.ExpiresAt().Unix() != 1700003600 {
+		t.Errorf("DecodeInvoice() = %+v", dec)
+	}
+
+	pay, err := c.PayInvoice(ctx, "lntb500u1test", 250)
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 12 {
+		t.Errorf("PayInvoice() = %+v", pay)
+	}
+	if _, err := c.PayInvoice(ctx, "lnfail", 1); !errors.Is(err, ErrPaymentFailed) || !strings.Contains(err.Error(), "Ran out of routes") {
+		t.Errorf("PayInvoice() error = %v, want payment failed: ran out of routes", err)
+	}
+
+	pay, err = c.TrackPayment(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("TrackPayment() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 7 {
+		t.Errorf("TrackPayment() = %+v", pay)
+	}
+	if _, err := c.TrackPayment(ctx, failed); !errors.Is(err, ErrPaymentFailed) {
+		t.Errorf("TrackPayment(failed) error = %v, want ErrPaymentFailed", err)
+	}
+	if _, err := c.TrackPayment(ctx, make([]byte, 32)); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("TrackPayment(unknown) error = %v, want ErrPaymentNotFound", err)
+	}
+}
@@ -0,0 +1,193 @@
+// Package lightning settles BTC swap legs over the Lightning Network.
+//
+// The node doesn't run Lightning itself: it drives the user's own node. An
+// HTLC swap locks both legs to the same SHA-256 payment hash, which is what a
+// Lightning invoice is locked to as well, so the BTC leg can be an invoice
+// instead of an on-chain HTLC (a submarine swap): the maker issues an invoice
+// for its swap secret, the taker pays it once the maker's on-chain leg is
+// locked, and the preimage the payment returns lets the taker claim that leg.
+package lightning
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Implementations.
+const (
+	ImplementationLND = "lnd"
+	ImplementationCLN = "cln" // Core Lightning over clnrest
+)
+
+// LND transports.
+const (
+	TransportREST = "rest" // REST gateway (default)
+	TransportGRPC = "grpc" // Native gRPC port
+)
+
+// Lightning errors
+var (
+	ErrInvoiceNotFound = errors.New("invoice not found")
+	ErrPaymentFailed   = errors.New("lightning payment failed")
+	ErrPaymentNotFound = errors.New("lightning payment not found")
+)
+
+// Config holds the connection to the user's Lightning node.
+type Config struct {
+	// Enabled connects to the node and allows Lightning settlement.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// Implementation of the node: "lnd" or "cln".
+	Implementation string `yaml:"implementation,omitempty" json:"implementation,omitempty"`
+
+	// Transport is how LND is reached: "rest" (default) or "grpc". CLN
+	// is reached over its clnrest plugin.
+	Transport string `yaml:"transport,omitempty" json:"transport,omitempty"`
+
+	// Host is the LND REST address, e.g. https://127.0.0.1:8080, or the
+	// gRPC host:port, e.g. 127.0.0.1:10009. For CLN it is the clnrest
+	// address, e.g. https://127.0.0.1:3010.
+	Host string `yaml:"host,omitempty" json:"host,omitempty"`
+
+	// MacaroonPath is an LND macaroon allowed to create invoices and pay
+	// (invoice.macaroon is not enough to pay; admin.macaroon is).
+	MacaroonPath string `yaml:"macaroon_path,omitempty" json:"macaroon_path,omitempty"`
+
+	// RunePath is a file holding a CLN rune allowed to call invoice,
+	// listinvoices, decode, pay and listpays.
+	RunePath string `yaml:"rune_path,omitempty" json:"rune_path,omitempty"`
+
+	// TLSCertPath is LND's tls.cert or clnrest's certificate. Empty uses
+	// the system roots.
+	TLSCertPath string `yaml:"tls_cert_path,omitempty" json:"tls_cert_path,omitempty"`
+
+	// MaxFeeBps bounds the routing fee of a payment, in basis points of
+	// the amount (at least 1 sat).
+	MaxFeeBps uint32 `yaml:"max_fee_bps,omitempty" json:"max_fee_bps,omitempty"`
+
+	// InvoiceExpiry is how long issued invoices can be paid.
+	InvoiceExpiry time.Duration `yaml:"invoice_expiry,omitempty" json:"invoice_expiry,omitempty"`
+
+	// PaymentTimeout bounds a payment attempt.
+	PaymentTimeout time.Duration `yaml:"payment_timeout,omitempty" json:"payment_timeout,omitempty"`
+}
+
+// Validate checks the configuration of an enabled connection.
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	switch c.Implementation {
+	case ImplementationLND:
+		if c.Transport != "" && c.Transport != TransportREST && c.Transport != TransportGRPC {
+			return fmt.Errorf("unsupported lightning.transport %q", c.Transport)
+		}
+		if c.Host == "" || c.MacaroonPath == "" {
+			return fmt.Errorf("lightning.host and lightning.macaroon_path are required")
+		}
+	case ImplementationCLN:
+		if c.Transport != "" && c.Transport != TransportREST {
+			return fmt.Errorf("lightning.transport %q is not supported by cln", c.Transport)
+		}
+		if c.Host == "" || c.RunePath == "" {
+			return fmt.Errorf("lightning.host and lightning.rune_path are required")
+		}
+	default:
+		return fmt.Errorf("unsupported lightning.implementation %q", c.Implementation)
+	}
+	if c.MaxFeeBps > 10000 {
+		return fmt.Errorf("lightning.max_fee_bps must be at most 10000")
+	}
+	if c.InvoiceExpiry <= 0 || c.PaymentTimeout <= 0 {
+		return fmt.Errorf("lightning.invoice_expiry and payment_timeout must be positive")
+	}
+	return nil
+}
+
+// MaxFee returns the routing fee limit for paying amountSat.
+func (c *Config) MaxFee(amountSat uint64) uint64 {
+	return max(amountSat*uint64(c.MaxFeeBps)/10000, 1)
+}
+
+// InvoiceState is the state of an invoice we issued.
+type InvoiceState string
+
+// Invoice states.
+const (
+	InvoiceOpen     InvoiceState = "open"
+	InvoiceAccepted InvoiceState = "accepted" // Hold invoice with the payment locked in
+	InvoiceSettled  InvoiceState = "settled"
+	InvoiceCanceled InvoiceState = "canceled"
+)
+
+// Invoice is an invoice issued by our node.
+type Invoice struct {
+	PaymentRequest string
+	PaymentHash    []byte
+	AmountSat      uint64
+	State          InvoiceState
+}
+
+// DecodedInvoice is what a payment request commits to.
+type DecodedInvoice struct {
+	Destination string // Payee node public key (hex)
+	PaymentHash []byte
+	AmountSat   uint64
+	CreatedAt   time.Time
+	Expiry      time.Duration
+	CLTVExpiry  uint32 // Final hop CLTV delta in blocks
+}
+
+// ExpiresAt returns when the invoice can no longer be paid.
+func (d *DecodedInvoice) ExpiresAt() time.Time {
+	return d.CreatedAt.Add(d.Expiry)
+}
+
+// Payment is a completed payment.
+type Payment struct {
+	Preimage []byte
+	FeeSat   uint64
+}
+
+// Client is a Lightning node.
+// TODO: hold invoices (AddHoldInvoice/SettleInvoice) for swaps whose maker
+// pays the BTC leg over Lightning; the taker doesn't know the preimage then.
+type Client interface {
+	// AddInvoice issues an invoice locked to SHA-256(preimage).
+	AddInvoice(ctx context.Context, preimage []byte, amountSat uint64, expiry time.Duration, memo string) (*Invoice, error)
+
+	// LookupInvoice returns an invoice we issued by its payment hash.
+	LookupInvoice(ctx context.Context, paymentHash []byte) (*Invoice, error)
+
+	// DecodeInvoice decodes a payment request.
+	DecodeInvoice(ctx context.Context, paymentRequest string) (*DecodedInvoice, error)
+
+	// PayInvoice pays a payment request, spending at most maxFeeSat on
+	// routing, and returns the preimage.
+	PayInvoice(ctx context.Context, paymentRequest string, maxFeeSat uint64) (*Payment, error)
+
+	// TrackPayment waits for our payment to paymentHash to complete and
+	// returns it, ErrPaymentFailed if it failed, or ErrPaymentNotFound if
+	// it was never made.
+	TrackPayment(ctx context.Context, paymentHash []byte) (*Payment, error)
+}
+
+// New connects to the configured Lightning node.
+func New(cfg Config) (Client, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if !cfg.Enabled {
+		return nil, fmt.Errorf("lightning is disabled")
+	}
+	switch {
+	case cfg.Implementation == ImplementationCLN:
+		return newCLN(cfg)
+	case cfg.Transport == TransportGRPC:
+		return newLNDGRPC(cfg)
+	default:
+		return newLND(cfg)
+	}
+}
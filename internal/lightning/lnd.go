@@ -0,0 +1,299 @@
+// Package lightning - LND client.
+//
+// LND is driven through its REST gateway by default, which serves the same
+// RPC services as its gRPC port with the macaroon in a header. The gRPC
+// transport is in lnd_grpc.go.
+package lightning
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxLNDResponse bounds the size of REST responses.
+const maxLNDResponse = 1 << 20
+
+// lndClient is a Client for LND's REST gateway.
+type lndClient struct {
+	host     string
+	macaroon string // Hex
+	client   *http.Client
+}
+
+func newLND(cfg Config) (*lndClient, error) {
+	u, err := url.Parse(cfg.Host)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return nil, fmt.Errorf("invalid lightning.host %q", cfg.Host)
+	}
+	mac, err := os.ReadFile(cfg.MacaroonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macaroon: %w", err)
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.TLSCertPath != "" {
+		if transport.TLSClientConfig, err = lndTLSConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	return &lndClient{
+		host:     strings.TrimRight(cfg.Host, "/"),
+		macaroon: hex.EncodeToString(mac),
+		client:   &http.Client{Transport: transport, Timeout: cfg.PaymentTimeout + 30*time.Second},
+	}, nil
+}
+
+// lndTLSConfig trusts LND's tls.cert, or the system roots without one.
+func lndTLSConfig(cfg Config) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLSCertPath == "" {
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.TLSCertPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read LND TLS certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("no certificate in %s", cfg.TLSCertPath)
+	}
+	tlsCfg.RootCAs = pool
+	return tlsCfg, nil
+}
+
+// lndInt decodes LND's int64 and uint64 fields, which are JSON strings.
+type lndInt uint64
+
+func (n *lndInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseUint(s, 10, 64)
+	if err != nil {
+		return err
+	}
+	*n = lndInt(v)
+	return nil
+}
+
+type lndInvoice struct {
+	RHash          []byte `json:"r_hash"` // base64 in JSON
+	PaymentRequest string `json:"payment_request"`
+	Value          lndInt `json:"value"`
+	State          string `json:"state"`
+}
+
+func (inv *lndInvoice) toInvoice() *Invoice {
+	return &Invoice{
+		PaymentRequest: inv.PaymentRequest,
+		PaymentHash:    inv.RHash,
+		AmountSat:      uint64(inv.Value),
+		State:          InvoiceState(strings.ToLower(inv.State)),
+	}
+}
+
+func (c *lndClient) AddInvoice(ctx context.Context, preimage []byte, amountSat uint64, expiry time.Duration, memo string) (*Invoice, error) {
+	req := map[string]string{
+		"r_preimage": base64.StdEncoding.EncodeToString(preimage),
+		"value":      strconv.FormatUint(amountSat, 10),
+		"expiry":     strconv.FormatInt(int64(expiry/time.Second), 10),
+		"memo":       memo,
+	}
+	var resp struct {
+		RHash          []byte `json:"r_hash"`
+		PaymentRequest string `json:"payment_request"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/invoices", req, &resp); err != nil {
+		return nil, fmt.Errorf("failed to add invoice: %w", err)
+	}
+	return &Invoice{PaymentRequest: resp.PaymentRequest, PaymentHash: resp.RHash, AmountSat: amountSat, State: InvoiceOpen}, nil
+}
+
+func (c *lndClient) LookupInvoice(ctx context.Context, paymentHash []byte) (*Invoice, error) {
+	var resp lndInvoice
+	if err := c.do(ctx, http.MethodGet, "/v1/invoice/"+hex.EncodeToString(paymentHash), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.toInvoice(), nil
+}
+
+func (c *lndClient) DecodeInvoice(ctx context.Context, paymentRequest string) (*DecodedInvoice, error) {
+	var resp struct {
+		Destination string `json:"destination"`
+		PaymentHash string `json:"payment_hash"` // Hex
+		NumSatoshis lndInt `json:"num_satoshis"`
+		Timestamp   lndInt `json:"timestamp"`
+		Expiry      lndInt `json:"expiry"`
+		CLTVExpiry  lndInt `json:"cltv_expiry"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/payreq/"+url.PathEscape(paymentRequest), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to decode invoice: %w", err)
+	}
+	hash, err := hex.DecodeString(resp.PaymentHash)
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("invalid payment hash %q", resp.PaymentHash)
+	}
+	return &DecodedInvoice{
+		Destination: resp.Destination,
+		PaymentHash: hash,
+		AmountSat:   uint64(resp.NumSatoshis),
+		CreatedAt:   time.Unix(int64(resp.Timestamp), 0),
+		Expiry:      time.Duration(resp.Expiry) * time.Second,
+		CLTVExpiry:  uint32(resp.CLTVExpiry),
+	}, nil
+}
+
+func (c *lndClient) PayInvoice(ctx context.Context, paymentRequest string, maxFeeSat uint64) (*Payment, error) {
+	req := map[string]interface{}{
+		"payment_request": paymentRequest,
+		"fee_limit":       map[string]string{"fixed": strconv.FormatUint(maxFeeSat, 10)},
+	}
+	var resp struct {
+		PaymentError    string `json:"payment_error"`
+		PaymentPreimage []byte `json:"payment_preimage"`
+		PaymentRoute    struct {
+			TotalFees lndInt `json:"total_fees"`
+		} `json:"payment_route"`
+	}
+	if err := c.do(ctx, http.MethodPost, "/v1/channels/transactions", req, &resp); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	if resp.PaymentError != "" {
+		return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, resp.PaymentError)
+	}
+	if len(resp.PaymentPreimage) != 32 {
+		return nil, fmt.Errorf("%w: no preimage returned", ErrPaymentFailed)
+	}
+	return &Payment{Preimage: resp.PaymentPreimage, FeeSat: uint64(resp.PaymentRoute.TotalFees)}, nil
+}
+
+func (c *lndClient) TrackPayment(ctx context.Context, paymentHash []byte) (*Payment, error) {
+	// Only the final state is streamed, after which the stream ends
+	path := "/v2/router/track/" + base64.URLEncoding.EncodeToString(paymentHash) + "?no_inflight_updates=true"
+	data, err := c.request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		if errors.Is(err, ErrInvoiceNotFound) || strings.Contains(err.Error(), "isn't initiated") {
+			return nil, ErrPaymentNotFound
+		}
+		return nil, err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	for {
+		var msg struct {
+			Result *lndPayment `json:"result"`
+			Error  *struct {
+				Message string `json:"message"`
+			} `json:"error"`
+		}
+		if err := dec.Decode(&msg); err == io.EOF {
+			return nil, errors.New("lnd: payment tracking ended before the payment completed")
+		} else if err != nil {
+			return nil, err
+		}
+		switch {
+		case msg.Error != nil && strings.Contains(msg.Error.Message, "isn't initiated"):
+			return nil, ErrPaymentNotFound
+		case msg.Error != nil:
+			return nil, fmt.Errorf("lnd: %s", msg.Error.Message)
+		case msg.Result != nil:
+			if p, err := msg.Result.toPayment(); p != nil || err != nil {
+				return p, err
+			}
+		}
+	}
+}
+
+// lndPayment is LND's Payment message.
+type lndPayment struct {
+	Status          string `json:"status"`
+	PaymentPreimage string `json:"payment_preimage"` // Hex
+	FeeSat          lndInt `json:"fee_sat"`
+	FailureReason   string `json:"failure_reason"`
+}
+
+// toPayment returns the payment once it completed, or nil while it is in
+// flight.
+func (p *lndPayment) toPayment() (*Payment, error) {
+	switch p.Status {
+	case "SUCCEEDED":
+		preimage, err := hex.DecodeString(p.PaymentPreimage)
+		if err != nil || len(preimage) != 32 {
+			return nil, fmt.Errorf("%w: invalid preimage %q", ErrPaymentFailed, p.PaymentPreimage)
+		}
+		return &Payment{Preimage: preimage, FeeSat: uint64(p.FeeSat)}, nil
+	case "FAILED":
+		return nil, fmt.Errorf("%w: %s", ErrPaymentFailed, strings.ToLower(strings.TrimPrefix(p.FailureReason, "FAILURE_REASON_")))
+	default:
+		return nil, nil
+	}
+}
+
+func (c *lndClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	data, err := c.request(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// request sends a REST request and returns the response body.
+func (c *lndClient) request(ctx context.Context, method, path string, body interface{}) ([]byte, error) {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		r = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.host+path, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Grpc-Metadata-macaroon", c.macaroon)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("lnd request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxLNDResponse))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 != 2 {
+		var e struct {
+			Message string `json:"message"`
+		}
+		json.Unmarshal(data, &e)
+		if resp.StatusCode == http.StatusNotFound || strings.Contains(e.Message, "unable to locate invoice") {
+			return nil, ErrInvoiceNotFound
+		}
+		if e.Message == "" {
+			e.Message = resp.Status
+		}
+		return nil, fmt.Errorf("lnd: %s", e.Message)
+	}
+	return data, nil
+}
@@ -0,0 +1,175 @@
+// Package lightning - LND client over gRPC.
+//
+// The gRPC transport talks to LND's own port (10009 by default) with the
+// subset of its protos in lndrpc, the macaroon as per-call metadata.
+package lightning
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+
+	"github.com/Klingon-tech/klingdex/internal/lightning/lndrpc"
+)
+
+// lndGRPCClient is a Client for LND's gRPC port.
+type lndGRPCClient struct {
+	lightning      lndrpc.LightningClient
+	router         lndrpc.RouterClient
+	paymentTimeout time.Duration
+}
+
+// macaroonCredentials sends the macaroon with every call.
+type macaroonCredentials string
+
+func (m macaroonCredentials) GetRequestMetadata(ctx context.Context, uri ...string) (map[string]string, error) {
+	return map[string]string{"macaroon": string(m)}, nil
+}
+
+func (m macaroonCredentials) RequireTransportSecurity() bool {
+	return true
+}
+
+func newLNDGRPC(cfg Config) (*lndGRPCClient, error) {
+	if strings.Contains(cfg.Host, "://") {
+		return nil, fmt.Errorf("invalid lightning.host %q: the gRPC transport takes host:port", cfg.Host)
+	}
+	mac, err := os.ReadFile(cfg.MacaroonPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read macaroon: %w", err)
+	}
+	tlsCfg, err := lndTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(cfg.Host,
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsCfg)),
+		grpc.WithPerRPCCredentials(macaroonCredentials(hex.EncodeToString(mac))),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(maxLNDResponse)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("invalid lightning.host %q: %w", cfg.Host, err)
+	}
+	return &lndGRPCClient{
+		lightning:      lndrpc.NewLightningClient(conn),
+		router:         lndrpc.NewRouterClient(conn),
+		paymentTimeout: cfg.PaymentTimeout,
+	}, nil
+}
+
+func (c *lndGRPCClient) AddInvoice(ctx context.Context, preimage []byte, amountSat uint64, expiry time.Duration, memo string) (*Invoice, error) {
+	resp, err := c.lightning.AddInvoice(ctx, &lndrpc.Invoice{
+		Memo:      memo,
+		RPreimage: preimage,
+		Value:     int64(amountSat),
+		Expiry:    int64(expiry / time.Second),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add invoice: %w", lndGRPCError(err))
+	}
+	return &Invoice{PaymentRequest: resp.PaymentRequest, PaymentHash: resp.RHash, AmountSat: amountSat, State: InvoiceOpen}, nil
+}
+
+func (c *lndGRPCClient) LookupInvoice(ctx context.Context, paymentHash []byte) (*Invoice, error) {
+	resp, err := c.lightning.LookupInvoice(ctx, &lndrpc.PaymentHash{RHash: paymentHash})
+	if err != nil {
+		if status.Code(err) == codes.NotFound || strings.Contains(status.Convert(err).Message(), "unable to locate invoice") {
+			return nil, ErrInvoiceNotFound
+		}
+		return nil, lndGRPCError(err)
+	}
+	return &Invoice{
+		PaymentRequest: resp.PaymentRequest,
+		PaymentHash:    resp.RHash,
+		AmountSat:      uint64(resp.Value),
+		State:          InvoiceState(strings.ToLower(resp.State.String())),
+	}, nil
+}
+
+func (c *lndGRPCClient) DecodeInvoice(ctx context.Context, paymentRequest string) (*DecodedInvoice, error) {
+	resp, err := c.lightning.DecodePayReq(ctx, &lndrpc.PayReqString{PayReq: paymentRequest})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode invoice: %w", lndGRPCError(err))
+	}
+	hash, err := hex.DecodeString(resp.PaymentHash)
+	if err != nil || len(hash) != 32 {
+		return nil, fmt.Errorf("invalid payment hash %q", resp.PaymentHash)
+	}
+	return &DecodedInvoice{
+		Destination: resp.Destination,
+		PaymentHash: hash,
+		AmountSat:   uint64(resp.NumSatoshis),
+		CreatedAt:   time.Unix(resp.Timestamp, 0),
+		Expiry:      time.Duration(resp.Expiry) * time.Second,
+		CLTVExpiry:  uint32(resp.CltvExpiry),
+	}, nil
+}
+
+func (c *lndGRPCClient) PayInvoice(ctx context.Context, paymentRequest string, maxFeeSat uint64) (*Payment, error) {
+	stream, err := c.router.SendPaymentV2(ctx, &lndrpc.SendPaymentRequest{
+		PaymentRequest:    paymentRequest,
+		TimeoutSeconds:    int32(c.paymentTimeout / time.Second),
+		FeeLimitSat:       int64(maxFeeSat),
+		NoInflightUpdates: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, lndGRPCError(err))
+	}
+	p, err := recvPayment(stream)
+	if err != nil && !errors.Is(err, ErrPaymentFailed) {
+		return nil, fmt.Errorf("%w: %v", ErrPaymentFailed, err)
+	}
+	return p, err
+}
+
+func (c *lndGRPCClient) TrackPayment(ctx context.Context, paymentHash []byte) (*Payment, error) {
+	stream, err := c.router.TrackPaymentV2(ctx, &lndrpc.TrackPaymentRequest{PaymentHash: paymentHash, NoInflightUpdates: true})
+	if err != nil {
+		return nil, lndGRPCError(err)
+	}
+	return recvPayment(stream)
+}
+
+// recvPayment reads payment updates until the payment completes.
+func recvPayment(stream grpc.ServerStreamingClient[lndrpc.Payment]) (*Payment, error) {
+	for {
+		update, err := stream.Recv()
+		if err == io.EOF {
+			return nil, errors.New("lnd: payment tracking ended before the payment completed")
+		}
+		if err != nil {
+			if status.Code(err) == codes.NotFound || strings.Contains(status.Convert(err).Message(), "isn't initiated") {
+				return nil, ErrPaymentNotFound
+			}
+			return nil, lndGRPCError(err)
+		}
+		p := lndPayment{
+			Status:          update.Status.String(),
+			PaymentPreimage: update.PaymentPreimage,
+			FeeSat:          lndInt(update.FeeSat),
+			FailureReason:   update.FailureReason.String(),
+		}
+		if payment, err := p.toPayment(); payment != nil || err != nil {
+			return payment, err
+		}
+	}
+}
+
+// lndGRPCError drops the gRPC status wrapping of an LND error.
+func lndGRPCError(err error) error {
+	if st, ok := status.FromError(err); ok {
+		return fmt.Errorf("lnd: %s", st.Message())
+	}
+	return err
+}
@@ -0,0 +1,189 @@
+package lightning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/Klingon-tech/klingdex/internal/lightning/lndrpc"
+)
+
+// fakeLNDGRPC serves the Lightning and Router services.
+type fakeLNDGRPC struct {
+	lndrpc.UnimplementedLightningServer
+	lndrpc.UnimplementedRouterServer
+	preimage []byte
+	feeLimit int64
+}
+
+func (f *fakeLNDGRPC) AddInvoice(ctx context.Context, req *lndrpc.Invoice) (*lndrpc.AddInvoiceResponse, error) {
+	hash := sha256.Sum256(req.RPreimage)
+	return &lndrpc.AddInvoiceResponse{RHash: hash[:], PaymentRequest: "lntb500u1test"}, nil
+}
+
+func (f *fakeLNDGRPC) LookupInvoice(ctx context.Context, req *lndrpc.PaymentHash) (*lndrpc.Invoice, error) {
+	hash := sha256.Sum256(f.preimage)
+	if !bytes.Equal(req.RHash, hash[:]) {
+		return nil, status.Error(codes.Unknown, "unable to locate invoice")
+	}
+	return &lndrpc.Invoice{RHash: hash[:], PaymentRequest: "lntb500u1test", Value: 50000, State: lndrpc.Invoice_SETTLED}, nil
+}
+
+func (f *fakeLNDGRPC) DecodePayReq(ctx context.Context, req *lndrpc.PayReqString) (*lndrpc.PayReq, error) {
+	hash := sha256.Sum256(f.preimage)
+	return &lndrpc.PayReq{Destination: "02ab", PaymentHash: hex.EncodeToString(hash[:]), NumSatoshis: 50000, Timestamp: 1700000000, Expiry: 3600, CltvExpiry: 80}, nil
+}
+
+func (f *fakeLNDGRPC) SendPaymentV2(req *lndrpc.SendPaymentRequest, stream grpc.ServerStreamingServer[lndrpc.Payment]) error {
+	f.feeLimit = req.FeeLimitSat
+	if req.PaymentRequest == "lnfail" {
+		return stream.Send(&lndrpc.Payment{Status: lndrpc.Payment_FAILED, FailureReason: lndrpc.PaymentFailureReason_FAILURE_REASON_NO_ROUTE})
+	}
+	return stream.Send(&lndrpc.Payment{Status: lndrpc.Payment_SUCCEEDED, PaymentPreimage: hex.EncodeToString(f.preimage), FeeSat: 12})
+}
+
+func (f *fakeLNDGRPC) TrackPaymentV2(req *lndrpc.TrackPaymentRequest, stream grpc.ServerStreamingServer[lndrpc.Payment]) error {
+	hash := sha256.Sum256(f.preimage)
+	if !bytes.Equal(req.PaymentHash, hash[:]) {
+		return status.Error(codes.NotFound, "payment isn't initiated")
+	}
+	if err := stream.Send(&lndrpc.Payment{Status: lndrpc.Payment_IN_FLIGHT}); err != nil {
+		return err
+	}
+	return stream.Send(&lndrpc.Payment{Status: lndrpc.Payment_SUCCEEDED, PaymentPreimage: hex.EncodeToString(f.preimage), FeeSat: 7})
+}
+
+// newTestLNDGRPC starts a fake LND gRPC port and a client trusting it.
+func newTestLNDGRPC(t *testing.T, fake *fakeLNDGRPC) Client {
+	t.Helper()
+
+	// Borrow httptest's certificate for 127.0.0.1
+	certSrv := httptest.NewUnstartedServer(nil)
+	certSrv.StartTLS()
+	certSrv.Close()
+	tlsCfg := certSrv.TLS.Clone()
+	tlsCfg.NextProtos = nil
+
+	checkMacaroon := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if got := md.Get("macaroon"); len(got) != 1 || got[0] != hex.EncodeToString([]byte("macaroon")) {
+			return status.Error(codes.Unknown, "verification failed")
+		}
+		return nil
+	}
+	srv := grpc.NewServer(grpc.Creds(credentials.NewTLS(tlsCfg)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := checkMacaroon(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := checkMacaroon(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	)
+	lndrpc.RegisterLightningServer(srv, fake)
+	lndrpc.RegisterRouterServer(srv, fake)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.cert")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certSrv.Certificate().Raw})
+	if err := os.WriteFile(certPath, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	macPath := filepath.Join(dir, "admin.macaroon")
+	if err := os.WriteFile(macPath, []byte("macaroon"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{
+		Enabled: true, Implementation: ImplementationLND, Transport: TransportGRPC, Host: lis.Addr().String(),
+		MacaroonPath: macPath, TLSCertPath: certPath,
+		MaxFeeBps: 50, InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestLNDGRPC(t *testing.T) {
+	preimage := bytes.Repeat([]byte{9}, 32)
+	hash := sha256.Sum256(preimage)
+	fake := &fakeLNDGRPC{preimage: preimage}
+	c := newTestLNDGRPC(t, fake)
+	ctx := context.Background()
+
+	inv, err := c.AddInvoice(ctx, preimage, 50000, time.Hour, "klingdex swap")
+	if err != nil {
+		t.Fatalf("AddInvoice() error = %v", err)
+	}
+	if inv.PaymentRequest != "lntb500u1test" || !bytes.Equal(inv.PaymentHash, hash[:]) || inv.State != InvoiceOpen {
+		t.Errorf("AddInvoice() = %+v", inv)
+	}
+	got, err := c.LookupInvoice(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("LookupInvoice() error = %v", err)
+	}
+	if got.State != InvoiceSettled || got.AmountSat != 50000 {
+		t.Errorf("LookupInvoice() = %+v", got)
+	}
+	if _, err := c.LookupInvoice(ctx, make([]byte, 32)); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Errorf("LookupInvoice(unknown) error = %v, want ErrInvoiceNotFound", err)
+	}
+
+	dec, err := c.DecodeInvoice(ctx, "lntb500u1test")
+	if err != nil {
+		t.Fatalf("DecodeInvoice() error = %v", err)
+	}
+	if !bytes.Equal(dec.PaymentHash, hash[:]) || dec.AmountSat != 50000 || dec.CLTVExpiry != 80 || dec.ExpiresAt().Unix() != 1700003600 {
+		t.Errorf("DecodeInvoice() = %+v", dec)
+	}
+
+	pay, err := c.PayInvoice(ctx, "lntb500u1test", 250)
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 12 || fake.feeLimit != 250 {
+		t.Errorf("PayInvoice() = %+v, fee limit %d", pay, fake.feeLimit)
+	}
+	if _, err := c.PayInvoice(ctx, "lnfail", 1); !errors.Is(err, ErrPaymentFailed) || !strings.Contains(err.Error(), "no_route") {
+		t.Errorf("PayInvoice() error = %v, want payment failed: no_route", err)
+	}
+
+	pay, err = c.TrackPayment(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("TrackPayment() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 7 {
+		t.Errorf("TrackPayment() = %+v", pay)
+	}
+	if _, err := c.TrackPayment(ctx, make([]byte, 32)); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("TrackPayment(unknown) error = %v, want ErrPaymentNotFound", err)
+	}
+}
@@ -0,0 +1,220 @@
+package lightning
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestLND starts a fake LND REST gateway and a client trusting it.
+func newTestLND(t *testing.T, handler http.HandlerFunc) Client {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Grpc-Metadata-macaroon") != hex.EncodeToString([]byte("macaroon")) {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(map[string]interface{}{"code": 2, "message": "verification failed"})
+			return
+		}
+		handler(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "tls.cert")
+	cert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(certPath, cert, 0600); err != nil {
+		t.Fatal(err)
+	}
+	macPath := filepath.Join(dir, "admin.macaroon")
+	if err := os.WriteFile(macPath, []byte("macaroon"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := New(Config{
+		Enabled: true, Implementation: ImplementationLND, Host: srv.URL,
+		MacaroonPath: macPath, TLSCertPath: certPath,
+		MaxFeeBps: 50, InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute,
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestLNDInvoices(t *testing.T) {
+	preimage := bytes.Repeat([]byte{7}, 32)
+	hash := sha256.Sum256(preimage)
+
+	c := newTestLND(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/v1/invoices":
+			var req map[string]string
+			json.NewDecoder(r.Body).Decode(&req)
+			if req["r_preimage"] != base64.StdEncoding.EncodeToString(preimage) || req["value"] != "50000" || req["expiry"] != "3600" {
+				t.Errorf("AddInvoice request = %v", req)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"r_hash": hash[:], "payment_request": "lntb500u1test", "add_index": "3"})
+		case r.URL.Path == "/v1/invoice/"+hex.EncodeToString(hash[:]):
+			w.Write([]byte(`{"r_hash":"` + base64.StdEncoding.EncodeToString(hash[:]) + `","payment_request":"lntb500u1test","value":"50000","state":"SETTLED"}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/invoice/"):
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(`{"code":2,"message":"unable to locate invoice"}`))
+		}
+	})
+	ctx := context.Background()
+
+	inv, err := c.AddInvoice(ctx, preimage, 50000, time.Hour, "klingdex swap")
+	if err != nil {
+		t.Fatalf("AddInvoice() error = %v", err)
+	}
+	if inv.PaymentRequest != "lntb500u1test" || !bytes.Equal(inv.PaymentHash, hash[:]) || inv.State != InvoiceOpen {
+		t.Errorf("AddInvoice() = %+v", inv)
+	}
+
+	got, err := c.LookupInvoice(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("LookupInvoice() error = %v", err)
+	}
+	if got.State != InvoiceSettled || got.AmountSat != 50000 {
+		t.Errorf("LookupInvoice() = %+v", got)
+	}
+	if _, err := c.LookupInvoice(ctx, make([]byte, 32)); !errors.Is(err, ErrInvoiceNotFound) {
+		t.Errorf("LookupInvoice(unknown) error = %v, want ErrInvoiceNotFound", err)
+	}
+}
+
+func TestLNDPay(t *testing.T) {
+	preimage := bytes.Repeat([]byte{9}, 32)
+	hash := sha256.Sum256(preimage)
+
+	c := newTestLND(t, func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/payreq/"):
+			w.Write([]byte(`{"destination":"02ab","payment_hash":"` + hex.EncodeToString(hash[:]) + `","num_satoshis":"50000","timestamp":"1700000000","expiry":"3600","cltv_expiry":"80"}`))
+		case r.URL.Path == "/v1/channels/transactions":
+			var req struct {
+				PaymentRequest string            `json:"payment_request"`
+				FeeLimit       map[string]string `json:"fee_limit"`
+			}
+			json.NewDecoder(r.Body).Decode(&req)
+			if req.PaymentRequest == "lnfail" {
+				json.NewEncoder(w).Encode(map[string]string{"payment_error": "no route"})
+				return
+			}
+			if req.FeeLimit["fixed"] != "250" {
+				t.Errorf("fee limit = %v", req.FeeLimit)
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"payment_preimage": preimage,
+				"payment_route":    map[string]string{"total_fees": "12"},
+			})
+		}
+	})
+	ctx := context.Background()
+
+	dec, err := c.DecodeInvoice(ctx, "lntb500u1test")
+	if err != nil {
+		t.Fatalf("DecodeInvoice() error = %v", err)
+	}
+	if !bytes.Equal(dec.PaymentHash, hash[:]) || dec.AmountSat != 50000 || dec.CLTVExpiry != 80 || dec.ExpiresAt().Unix() != 1700003600 {
+		t.Errorf("DecodeInvoice() = %+v", dec)
+	}
+
+	cfg := Config{MaxFeeBps: 50}
+	pay, err := c.PayInvoice(ctx, "lntb500u1test", cfg.MaxFee(50000))
+	if err != nil {
+		t.Fatalf("PayInvoice() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 12 {
+		t.Errorf("PayInvoice() = %+v", pay)
+	}
+	if _, err := c.PayInvoice(ctx, "lnfail", 1); !errors.Is(err, ErrPaymentFailed) || !strings.Contains(err.Error(), "no route") {
+		t.Errorf("PayInvoice() error = %v, want payment failed: no route", err)
+	}
+}
+
+func TestLNDTrackPayment(t *testing.T) {
+	preimage := bytes.Repeat([]byte{9}, 32)
+	hash := sha256.Sum256(preimage)
+	failed := bytes.Repeat([]byte{1}, 32)
+
+	c := newTestLND(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("no_inflight_updates") != "true" {
+			t.Errorf("TrackPaymentV2 query = %v", r.URL.Query())
+		}
+		switch r.URL.Path {
+		case "/v2/router/track/" + base64.URLEncoding.EncodeToString(hash[:]):
+			w.Write([]byte(`{"result":{"status":"IN_FLIGHT"}}` + "\n"))
+			w.Write([]byte(`{"result":{"status":"SUCCEEDED","payment_preimage":"` + hex.EncodeToString(preimage) + `","fee_sat":"7"}}` + "\n"))
+		case "/v2/router/track/" + base64.URLEncoding.EncodeToString(failed):
+			w.Write([]byte(`{"result":{"status":"FAILED","failure_reason":"FAILURE_REASON_NO_ROUTE"}}` + "\n"))
+		default:
+			w.Write([]byte(`{"error":{"code":5,"message":"payment isn't initiated"}}` + "\n"))
+		}
+	})
+	ctx := context.Background()
+
+	pay, err := c.TrackPayment(ctx, hash[:])
+	if err != nil {
+		t.Fatalf("TrackPayment() error = %v", err)
+	}
+	if !bytes.Equal(pay.Preimage, preimage) || pay.FeeSat != 7 {
+		t.Errorf("TrackPayment() = %+v", pay)
+	}
+	if _, err := c.TrackPayment(ctx, failed); !errors.Is(err, ErrPaymentFailed) || !strings.Contains(err.Error(), "no_route") {
+		t.Errorf("TrackPayment(failed) error = %v, want payment failed: no_route", err)
+	}
+	if _, err := c.TrackPayment(ctx, make([]byte, 32)); !errors.Is(err, ErrPaymentNotFound) {
+		t.Errorf("TrackPayment(unknown) error = %v, want ErrPaymentNotFound", err)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Enabled: true, Implementation: ImplementationLND, Host: "https://127.0.0.1:8080", MacaroonPath: "m", InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() error = %v", err)
+	}
+	for _, mod := range []func(*Config){
+		func(c *Config) { c.Implementation = "eclair" },
+		func(c *Config) { c.Implementation = ImplementationCLN },
+		func(c *Config) { c.Host = "" },
+		func(c *Config) { c.Transport = "ws" },
+		func(c *Config) { c.MaxFeeBps = 10001 },
+		func(c *Config) { c.PaymentTimeout = 0 },
+	} {
+		cfg := valid
+		mod(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%+v) accepted an invalid config", cfg)
+		}
+	}
+	cln := Config{Enabled: true, Implementation: ImplementationCLN, Host: "https://127.0.0.1:3010", RunePath: "r", InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute}
+	if err := cln.Validate(); err != nil {
+		t.Errorf("Validate(cln) error = %v", err)
+	}
+	cln.Transport = TransportGRPC
+	if err := cln.Validate(); err == nil {
+		t.Error("Validate() accepted cln over gRPC")
+	}
+	if err := (&Config{Implementation: "eclair"}).Validate(); err != nil {
+		t.Errorf("disabled config error = %v", err)
+	}
+	if _, err := New(Config{}); err == nil {
+		t.Error("New() of a disabled config should fail")
+	}
+	if got := (&Config{MaxFeeBps: 50}).MaxFee(100); got != 1 {
+		t.Errorf("MaxFee(100) = %d, want the 1 sat floor", got)
+	}
+}
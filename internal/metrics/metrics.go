@@ -0,0 +1,119 @@
+// Package metrics exposes node metrics in the Prometheus text format.
+//
+// Metrics live in a dedicated registry (rather than the global default one
+// that libp2p also registers into) and are served on the API's /metrics path.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "klingdex"
+
+// Registry holds all klingdex metrics.
+var Registry = prometheus.NewRegistry()
+
+// Network health metrics.
+var (
+	// NodeDegraded is 1 while the node is in degraded mode (isolated from the network).
+	NodeDegraded = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "node_degraded",
+		Help:      "1 while the node is isolated and not accepting new trades.",
+	})
+
+	// PartitionSignal is 1 for each active isolation signal.
+	PartitionSignal = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "partition_signal",
+		Help:      "1 while an isolation signal (no_peers, dht_failing, gossip_silent) is active.",
+	}, []string{"signal"})
+
+	// NonBootstrapPeers is the number of connected peers other than bootstrap nodes.
+	NonBootstrapPeers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "peers_non_bootstrap",
+		Help:      "Connected peers excluding configured bootstrap peers.",
+	})
+
+	// PeerProtocolVersions is the number of peers seen per swap protocol version.
+	PeerProtocolVersions = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "peer_protocol_versions",
+		Help:      "Peers we received messages from, by the swap protocol version they speak.",
+	}, []string{"version"})
+
+	// TradesRefused counts trades refused before they started, by reason code.
+	TradesRefused = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "trades_refused_total",
+		Help:      "Trades refused with a peer before they started, by reason code.",
+	}, []string{"code"})
+
+	// SwapFailures counts failed, refunded and refused swaps by failure
+	// category (swap.FailureCategory).
+	SwapFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "swap_failures_total",
+		Help:      "Swaps that failed, were refunded or were refused, by failure category.",
+	}, []string{"category"})
+)
+
+// JSON-RPC metrics, labelled by method. Only registered methods are
+// recorded, so the label set is bounded.
+var (
+	// RPCCalls counts JSON-RPC calls.
+	RPCCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_calls_total",
+		Help:      "JSON-RPC calls by method.",
+	}, []string{"method"})
+
+	// RPCErrors counts JSON-RPC calls that returned an error.
+	RPCErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_errors_total",
+		Help:      "JSON-RPC calls that returned an error, by method.",
+	}, []string{"method"})
+
+	// RPCDuration is the JSON-RPC handler latency.
+	RPCDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "rpc_duration_seconds",
+		Help:      "JSON-RPC handler latency by method.",
+		Buckets:   []float64{.001, .005, .025, .1, .25, .5, 1, 2.5, 5, 10, 30},
+	}, []string{"method"})
+
+	// RPCSlowCalls counts calls over the slow-call threshold.
+	RPCSlowCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rpc_slow_calls_total",
+		Help:      "JSON-RPC calls slower than api.slow_call_threshold, by method.",
+	}, []string{"method"})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		NodeDegraded,
+		PartitionSignal,
+		NonBootstrapPeers,
+		PeerProtocolVersions,
+		TradesRefused,
+		SwapFailures,
+		RPCCalls,
+		RPCErrors,
+		RPCDuration,
+		RPCSlowCalls,
+	)
+}
+
+// Handler serves the registry in the Prometheus exposition format.
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
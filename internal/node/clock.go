@@ -0,0 +1,333 @@
+// Package node - Clock skew detection against NTP and peer time.
+package node
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Clock offset sources reported in ClockStatus.Source.
+const (
+	ClockSourceNTP   = "ntp"   // Median offset of the NTP servers that answered
+	ClockSourcePeers = "peers" // Median offset of the peers' ACK timestamps
+)
+
+// ClockConfig holds clock skew detection settings.
+type ClockConfig struct {
+	// NTPServers are queried at startup and every CheckInterval ("host" or
+	// "host:port"). Without servers, or when none answers, the offset is
+	// estimated from the timestamps of peers' message ACKs.
+	NTPServers []string `yaml:"ntp_servers,omitempty"`
+
+	// CheckInterval is how often the clock is checked.
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+
+	// NTPTimeout bounds each NTP query.
+	NTPTimeout time.Duration `yaml:"ntp_timeout,omitempty"`
+
+	// MaxSkew is the offset above which the clock counts as skewed.
+	MaxSkew time.Duration `yaml:"max_skew,omitempty"`
+
+	// MinPeerSamples is the number of peers needed for a peer-time estimate.
+	MinPeerSamples int `yaml:"min_peer_samples,omitempty"`
+
+	// RefuseTrades stops starting or joining new swaps while the clock is
+	// skewed.
+	RefuseTrades bool `yaml:"refuse_trades,omitempty"`
+}
+
+// ClockStatus is the latest clock assessment. Offset is how far the local
+// clock is behind the reference: true time = local time + offset.
+type ClockStatus struct {
+	Skewed      bool      `json:"skewed"`
+	OffsetMs    int64     `json:"offset_ms"`
+	Source      string    `json:"source,omitempty"` // Empty until a reference was reached
+	NTPServers  int       `json:"ntp_servers"`      // NTP servers that answered
+	PeerSamples int       `json:"peer_samples"`
+	MaxSkewMs   int64     `json:"max_skew_ms"`
+	CheckedAt   time.Time `json:"checked_at"`
+}
+
+// Offset returns the offset as a duration.
+func (s ClockStatus) Offset() time.Duration {
+	return time.Duration(s.OffsetMs) * time.Millisecond
+}
+
+// peerTimeSample is a peer's clock offset measured from an ACK.
+type peerTimeSample struct {
+	offset time.Duration
+	at     time.Time
+}
+
+// peerSampleTTL is how long a peer's time sample is used.
+const peerSampleTTL = time.Hour
+
+// ntpQueryFunc queries one NTP server for the local clock offset.
+type ntpQueryFunc func(ctx context.Context, server string, timeout time.Duration) (time.Duration, error)
+
+// ClockMonitor checks the local clock against NTP servers and, as a
+// fallback, the clocks of the peers we exchange messages with. Swap timelocks
+// are measured against block times and EVM timestamps, so a skewed clock
+// misjudges how long is left before a refund or claim deadline.
+type ClockMonitor struct {
+	cfg   ClockConfig
+	query ntpQueryFunc
+	log   *logging.Logger
+
+	mu       sync.RWMutex
+	status   ClockStatus
+	peers    map[peer.ID]peerTimeSample
+	onChange []func(ClockStatus)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewClockMonitor creates a clock monitor.
+func NewClockMonitor(cfg ClockConfig) *ClockMonitor {
+	defaults := DefaultConfig().Clock
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaults.CheckInterval
+	}
+	if cfg.NTPTimeout <= 0 {
+		cfg.NTPTimeout = defaults.NTPTimeout
+	}
+	if cfg.MaxSkew <= 0 {
+		cfg.MaxSkew = defaults.MaxSkew
+	}
+	if cfg.MinPeerSamples <= 0 {
+		cfg.MinPeerSamples = defaults.MinPeerSamples
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ClockMonitor{
+		cfg:    cfg,
+		query:  queryNTP,
+		log:    logging.GetDefault().Component("clock"),
+		status: ClockStatus{MaxSkewMs: cfg.MaxSkew.Milliseconds()},
+		peers:  make(map[peer.ID]peerTimeSample),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// OnChange registers a callback invoked after every check that finds the
+// clock skewed (the offset may have moved) and when it is back in sync.
+func (m *ClockMonitor) OnChange(fn func(ClockStatus)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onChange = append(m.onChange, fn)
+}
+
+// Status returns the latest assessment.
+func (m *ClockMonitor) Status() ClockStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// RefuseTrades returns true if new swaps should be refused while skewed.
+func (m *ClockMonitor) RefuseTrades() bool {
+	return m.cfg.RefuseTrades
+}
+
+// RecordPeerTime records a peer's clock reading taken between sent and
+// received, our send and receive times of the exchange. Readings have one
+// second resolution.
+func (m *ClockMonitor) RecordPeerTime(p peer.ID, remote, sent, received time.Time) {
+	if remote.IsZero() || received.Before(sent) {
+		return
+	}
+	midpoint := sent.Add(received.Sub(sent) / 2)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.peers[p] = peerTimeSample{offset: remote.Sub(midpoint), at: received}
+}
+
+// Start checks the clock now and then every CheckInterval.
+func (m *ClockMonitor) Start() {
+	m.wg.Add(1)
+	go func() {
+		defer m.wg.Done()
+		m.Check(m.ctx)
+		ticker := time.NewTicker(m.cfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-m.ctx.Done():
+				return
+			case <-ticker.C:
+				m.Check(m.ctx)
+			}
+		}
+	}()
+}
+
+// Stop stops periodic checks.
+func (m *ClockMonitor) Stop() {
+	m.cancel()
+	m.wg.Wait()
+}
+
+// Check measures the clock offset and updates the status. NTP is preferred;
+// peer time is used when no server answered.
+func (m *ClockMonitor) Check(ctx context.Context) ClockStatus {
+	var ntpOffsets []time.Duration
+	for _, server := range m.cfg.NTPServers {
+		offset, err := m.query(ctx, server, m.cfg.NTPTimeout)
+		if err != nil {
+			m.log.Debug("NTP query failed", "server", server, "error", err)
+			continue
+		}
+		ntpOffsets = append(ntpOffsets, offset)
+	}
+
+	now := time.Now()
+	m.mu.Lock()
+	var peerOffsets []time.Duration
+	for id, sample := range m.peers {
+		if now.Sub(sample.at) > peerSampleTTL {
+			delete(m.peers, id)
+			continue
+		}
+		peerOffsets = append(peerOffsets, sample.offset)
+	}
+
+	status := ClockStatus{
+		NTPServers:  len(ntpOffsets),
+		PeerSamples: len(peerOffsets),
+		MaxSkewMs:   m.cfg.MaxSkew.Milliseconds(),
+		CheckedAt:   now,
+	}
+	var offset time.Duration
+	switch {
+	case len(ntpOffsets) > 0:
+		status.Source = ClockSourceNTP
+		offset = medianDuration(ntpOffsets)
+	case len(peerOffsets) >= m.cfg.MinPeerSamples:
+		status.Source = ClockSourcePeers
+		offset = medianDuration(peerOffsets)
+	}
+	status.OffsetMs = offset.Milliseconds()
+	status.Skewed = status.Source != "" && absDuration(offset) > m.cfg.MaxSkew
+
+	changed := status.Skewed != m.status.Skewed
+	m.status = status
+	callbacks := append([]func(ClockStatus){}, m.onChange...)
+	m.mu.Unlock()
+
+	if status.Skewed {
+		m.log.Warn("Local clock is skewed, swap deadlines are adjusted",
+			"offset", offset.Round(time.Millisecond), "source", status.Source, "max_skew", m.cfg.MaxSkew,
+			"refuse_trades", m.cfg.RefuseTrades)
+	} else if changed {
+		m.log.Info("Local clock back in sync", "offset", offset.Round(time.Millisecond), "source", status.Source)
+	}
+	if changed || status.Skewed {
+		for _, fn := range callbacks {
+			fn(status)
+		}
+	}
+	return status
+}
+
+// medianDuration returns the median of values (not empty). The slice is
+// sorted in place.
+func medianDuration(values []time.Duration) time.Duration {
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+	mid := len(values) / 2
+	if len(values)%2 == 0 {
+		return (values[mid-1] + values[mid]) / 2
+	}
+	return values[mid]
+}
+
+func absDuration(d time.Duration) time.Duration {
+	if d < 0 {
+		return -d
+	}
+	return d
+}
+
+// =============================================================================
+// SNTP client (RFC 4330)
+// =============================================================================
+
+// ntpEpochOffset is the number of seconds between 1900 (NTP) and 1970 (Unix).
+const ntpEpochOffset = 2208988800
+
+var errBadNTPResponse = errors.New("invalid NTP response")
+
+// queryNTP sends one SNTP request and returns the local clock offset:
+// ((t2 - t1) + (t3 - t4)) / 2.
+func queryNTP(ctx context.Context, server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "123")
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "udp", server)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	req := make([]byte, 48)
+	req[0] = 0x23 // LI 0, version 4, mode 3 (client)
+	t1 := time.Now()
+	binary.BigEndian.PutUint64(req[40:], toNTPTime(t1))
+	if _, err := conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, 48)
+	n, err := conn.Read(resp)
+	t4 := time.Now()
+	if err != nil {
+		return 0, err
+	}
+	if n < 48 {
+		return 0, errBadNTPResponse
+	}
+	if mode := resp[0] & 0x07; mode != 4 {
+		return 0, fmt.Errorf("%w: mode %d", errBadNTPResponse, mode)
+	}
+	if stratum := resp[1]; stratum == 0 || stratum > 15 {
+		return 0, fmt.Errorf("%w: stratum %d", errBadNTPResponse, stratum)
+	}
+	if binary.BigEndian.Uint64(resp[24:]) != binary.BigEndian.Uint64(req[40:]) {
+		return 0, fmt.Errorf("%w: originate timestamp mismatch", errBadNTPResponse)
+	}
+
+	t2 := fromNTPTime(binary.BigEndian.Uint64(resp[32:]))
+	t3 := fromNTPTime(binary.BigEndian.Uint64(resp[40:]))
+	return (t2.Sub(t1) + t3.Sub(t4)) / 2, nil
+}
+
+func toNTPTime(t time.Time) uint64 {
+	sec := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return sec<<32 | frac
+}
+
+func fromNTPTime(v uint64) time.Time {
+	sec := int64(v>>32) - ntpEpochOffset
+	nsec := int64((v & 0xffffffff) * 1e9 >> 32)
+	return time.Unix(sec, nsec)
+}
@@ -0,0 +1,136 @@
+package node
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+// newTestClock returns a monitor whose NTP servers answer with the given
+// offsets; a zero offset is a server that doesn't answer.
+func newTestClock(offsets map[string]time.Duration) *ClockMonitor {
+	var servers []string
+	for s := range offsets {
+		servers = append(servers, s)
+	}
+	m := NewClockMonitor(ClockConfig{NTPServers: servers, MaxSkew: 30 * time.Second, MinPeerSamples: 2})
+	m.query = func(ctx context.Context, server string, timeout time.Duration) (time.Duration, error) {
+		if offsets[server] == 0 {
+			return 0, errors.New("timeout")
+		}
+		return offsets[server], nil
+	}
+	return m
+}
+
+func TestClockMonitorNTP(t *testing.T) {
+	m := newTestClock(map[string]time.Duration{
+		"a": 40 * time.Second,
+		"b": 50 * time.Second,
+		"c": 0,
+	})
+	var calls []ClockStatus
+	m.OnChange(func(s ClockStatus) { calls = append(calls, s) })
+
+	status := m.Check(context.Background())
+	if status.Source != ClockSourceNTP || status.NTPServers != 2 {
+		t.Errorf("Source = %q, NTPServers = %d", status.Source, status.NTPServers)
+	}
+	if status.Offset() != 45*time.Second || !status.Skewed {
+		t.Errorf("Offset = %v, Skewed = %v, want 45s skewed", status.Offset(), status.Skewed)
+	}
+	if len(calls) != 1 {
+		t.Errorf("OnChange called %d times, want 1", len(calls))
+	}
+}
+
+func TestClockMonitorPeerFallback(t *testing.T) {
+	m := newTestClock(nil)
+	now := time.Now()
+
+	// One peer is not enough
+	m.RecordPeerTime(peer.ID("p1"), now.Add(-2*time.Minute), now.Add(-time.Second), now)
+	if status := m.Check(context.Background()); status.Source != "" || status.Skewed {
+		t.Errorf("status = %+v, want no reference with one peer", status)
+	}
+
+	m.RecordPeerTime(peer.ID("p2"), now.Add(-2*time.Minute), now.Add(-time.Second), now)
+	status := m.Check(context.Background())
+	if status.Source != ClockSourcePeers || status.PeerSamples != 2 {
+		t.Errorf("Source = %q, PeerSamples = %d", status.Source, status.PeerSamples)
+	}
+	// The reading is compared with the middle of the exchange
+	if want := -2*time.Minute + 500*time.Millisecond; status.Offset() != want || !status.Skewed {
+		t.Errorf("Offset = %v, want %v skewed", status.Offset(), want)
+	}
+}
+
+func TestClockMonitorInSync(t *testing.T) {
+	m := newTestClock(map[string]time.Duration{"a": 2 * time.Second})
+	calls := 0
+	m.OnChange(func(ClockStatus) { calls++ })
+
+	if status := m.Check(context.Background()); status.Skewed {
+		t.Errorf("Skewed with offset %v", status.Offset())
+	}
+	if calls != 0 {
+		t.Errorf("OnChange called %d times, want 0", calls)
+	}
+
+	// Skewed, then back in sync
+	m.query = func(context.Context, string, time.Duration) (time.Duration, error) { return -time.Minute, nil }
+	m.Check(context.Background())
+	m.query = func(context.Context, string, time.Duration) (time.Duration, error) { return time.Second, nil }
+	if status := m.Check(context.Background()); status.Skewed {
+		t.Error("still skewed after the clock was fixed")
+	}
+	if calls != 2 {
+		t.Errorf("OnChange called %d times, want 2", calls)
+	}
+}
+
+func TestQueryNTP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// A server ten seconds ahead of us
+	go func() {
+		req := make([]byte, 48)
+		_, addr, err := conn.ReadFrom(req)
+		if err != nil {
+			return
+		}
+		resp := make([]byte, 48)
+		resp[0] = 0x24 // Version 4, mode 4 (server)
+		resp[1] = 2
+		copy(resp[24:32], req[40:48])
+		ts := toNTPTime(time.Now().Add(10 * time.Second))
+		binary.BigEndian.PutUint64(resp[32:], ts)
+		binary.BigEndian.PutUint64(resp[40:], ts)
+		conn.WriteTo(resp, addr)
+	}()
+
+	offset, err := queryNTP(context.Background(), conn.LocalAddr().String(), 2*time.Second)
+	if err != nil {
+		t.Fatalf("queryNTP() error = %v", err)
+	}
+	if offset < 9*time.Second || offset > 11*time.Second {
+		t.Errorf("offset = %v, want about 10s", offset)
+	}
+}
+
+func TestNTPTimeRoundTrip(t *testing.T) {
+	now := time.Unix(1700000000, 123456789)
+	got := fromNTPTime(toNTPTime(now))
+	if d := got.Sub(now); d < -time.Microsecond || d > time.Microsecond {
+		t.Errorf("round trip = %v, want %v", got, now)
+	}
+}
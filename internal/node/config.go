@@ -8,6 +8,12 @@ import (
 	"time"
 
 	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/lightning"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
 	"gopkg.in/yaml.v3"
 )
 
@@ -50,6 +56,190 @@ type Config struct {
 	// Backends holds blockchain API configurations per chain symbol.
 	// If not specified, defaults to public APIs (mempool.space, etc.)
 	Backends map[string]*backend.Config `yaml:"backends,omitempty"`
+
+	// BackendPlugins loads out-of-process backends from a directory for
+	// chains without a built-in one. Chains in Backends keep their backend.
+	BackendPlugins backend.PluginConfig `yaml:"backend_plugins,omitempty"`
+
+	// API holds JSON-RPC/WebSocket API server settings.
+	API APIConfig `yaml:"api,omitempty"`
+
+	// Testnets selects a named test network per chain symbol when running on
+	// testnet (e.g. BTC: signet, ETH: holesky). Unset chains use their default.
+	Testnets map[string]string `yaml:"testnets,omitempty"`
+
+	// Watchtower configures broadcasting pre-signed refunds and claims for
+	// trusted peers while they are offline.
+	Watchtower WatchtowerConfig `yaml:"watchtower,omitempty"`
+
+	// SpendingPolicy restricts outbound wallet sends: daily limits per chain,
+	// destination whitelists and an approval token above a threshold.
+	SpendingPolicy wallet.SpendingPolicy `yaml:"spending_policy,omitempty"`
+
+	// Partition configures isolation detection; while isolated the node
+	// enters degraded mode and stops accepting new trades.
+	Partition PartitionConfig `yaml:"partition,omitempty"`
+
+	// Clock checks the local clock against NTP and peer time; swap deadlines
+	// are adjusted for the measured skew.
+	Clock ClockConfig `yaml:"clock,omitempty"`
+
+	// Protocol sets the minimum swap protocol version of counterparties,
+	// for coordinated network upgrades.
+	Protocol ProtocolConfig `yaml:"protocol,omitempty"`
+
+	// Export configures CSV/Parquet dumps of orders, trades, swaps and fees
+	// for analytics.
+	Export ExportConfig `yaml:"export,omitempty"`
+
+	// PriceSanity flags or hides orders priced far off the external market
+	// rate and guards order creation and takes against fat-finger prices.
+	PriceSanity PriceSanityConfig `yaml:"price_sanity,omitempty"`
+
+	// Backup replicates the encrypted database (swaps, secrets, wallet
+	// metadata; never the seed) to trusted peers and/or S3.
+	Backup BackupConfig `yaml:"backup,omitempty"`
+
+	// HistorySync replicates completed trades and orders (never keys)
+	// between the user's own nodes.
+	HistorySync HistorySyncConfig `yaml:"history_sync,omitempty"`
+
+	// AutoClaim decides when we claim after the counterparty revealed the
+	// secret: immediately, after N confirmations of their claim, or manually.
+	AutoClaim swap.AutoClaimPolicy `yaml:"auto_claim,omitempty"`
+
+	// RefundWatcher refunds our funded swaps once their timelock expired,
+	// when the counterparty disappeared.
+	RefundWatcher swap.RefundWatcherPolicy `yaml:"refund_watcher,omitempty"`
+
+	// ZeroConf lets small swaps act on the counterparty's unconfirmed
+	// funding after a double-spend monitoring window.
+	ZeroConf swap.ZeroConfPolicy `yaml:"zero_conf,omitempty"`
+
+	// FundingVariance decides what happens when the counterparty funds its
+	// escrow with a different amount: proceed, request a top-up, or abort.
+	FundingVariance swap.FundingVariancePolicy `yaml:"funding_variance,omitempty"`
+
+	// Affordability rejects trades whose funding leg exceeds the balance
+	// not already promised to other swaps.
+	Affordability swap.AffordabilityPolicy `yaml:"affordability,omitempty"`
+
+	// Confirmation holds fund-moving steps for the user's approval
+	// (swap_confirmStep) with a preview of the transaction to broadcast.
+	Confirmation swap.ConfirmationPolicy `yaml:"confirmation,omitempty"`
+
+	// ContractPause watches the EVM HTLC contracts: a paused contract halts
+	// new swaps on its chain and raises alerts until unpaused.
+	ContractPause swap.ContractPausePolicy `yaml:"contract_pause,omitempty"`
+
+	// OperationTimeouts bounds each broadcast, chain query and secret wait
+	// of a swap; swap_cancelOperation aborts one sooner.
+	OperationTimeouts swap.OperationTimeouts `yaml:"operation_timeouts,omitempty"`
+
+	// Deadlines controls the swap_deadlines WebSocket updates.
+	Deadlines DeadlinesConfig `yaml:"deadlines,omitempty"`
+
+	// EventLog persists the events sent to WebSocket clients for events_query.
+	EventLog EventLogConfig `yaml:"event_log,omitempty"`
+
+	// PeerQuality configures peer latency probing.
+	PeerQuality PeerQualityConfig `yaml:"peer_quality,omitempty"`
+
+	// Compliance streams a signed, hash-chained audit record of every trade
+	// checkpoint to a compliance endpoint.
+	Compliance ComplianceConfig `yaml:"compliance,omitempty"`
+
+	// Telemetry publishes anonymized network statistics for the project
+	// (opt-in).
+	Telemetry TelemetryConfig `yaml:"telemetry,omitempty"`
+
+	// OrderPoW requires a proof-of-work on gossiped orders so junk orders
+	// are expensive to publish.
+	OrderPoW OrderPoWConfig `yaml:"order_pow,omitempty"`
+
+	// OrderSchema sets the deprecation window of the unversioned order
+	// format.
+	OrderSchema OrderSchemaConfig `yaml:"order_schema,omitempty"`
+
+	// MetaClaim configures relayed claims of EVM HTLCs, for receiving on a
+	// chain we hold no gas on.
+	MetaClaim MetaClaimConfig `yaml:"meta_claim,omitempty"`
+
+	// Archive moves old completed swaps out of the database into compressed
+	// archive files.
+	Archive ArchiveConfig `yaml:"archive,omitempty"`
+
+	// Nostr mirrors our signed orders to Nostr relays and ingests orders
+	// other nodes publish there (opt-in).
+	Nostr NostrConfig `yaml:"nostr,omitempty"`
+
+	// Snapshots speed up restarts with many pending swaps.
+	Snapshots SnapshotConfig `yaml:"snapshots,omitempty"`
+
+	// Consolidation merges small wallet UTXOs while fees are low and no
+	// swaps are running (opt-in).
+	Consolidation ConsolidationConfig `yaml:"consolidation,omitempty"`
+
+	// Tracing exports OpenTelemetry spans of the swap lifecycle to an OTLP
+	// collector (opt-in).
+	Tracing tracing.Config `yaml:"tracing,omitempty"`
+
+	// Lightning settles BTC swap legs over the user's Lightning node
+	// (opt-in).
+	Lightning lightning.Config `yaml:"lightning,omitempty"`
+
+	// Monero connects to monero-wallet-rpc for BTC/XMR adaptor swaps
+	// (opt-in).
+	Monero MoneroConfig `yaml:"monero,omitempty"`
+
+	// Reannounce re-validates and re-announces our open orders after a
+	// restart or a network partition, cancelling stale ones.
+	Reannounce ReannounceConfig `yaml:"reannounce,omitempty"`
+
+	// Privacy warns about reused addresses in wallet sends and swap
+	// payouts, or refuses them and claims to fresh addresses.
+	Privacy PrivacyConfig `yaml:"privacy,omitempty"`
+
+	// WrappedAssets rejects swaps between an asset and its own wrapped or
+	// bridged form (BTC for WBTC, ETH for WETH) at unfavorable rates.
+	WrappedAssets WrappedAssetsConfig `yaml:"wrapped_assets,omitempty"`
+
+	// FeeNegotiation lets the parties agree on a request amount adjustment
+	// compensating the one whose chain's mining fees dominate.
+	FeeNegotiation FeeNegotiationConfig `yaml:"fee_negotiation,omitempty"`
+
+	// Aggregation combines small trades against the same maker, taken
+	// within a short window, into one swap per chain leg.
+	Aggregation AggregationConfig `yaml:"aggregation,omitempty"`
+
+	// StartupChecks verifies the data directory, ports, clock, backends
+	// and API auth before the node starts.
+	StartupChecks StartupChecksConfig `yaml:"startup_checks,omitempty"`
+
+	// Finality overrides the confirmations after which a chain's
+	// transactions are reported final in API responses.
+	Finality FinalityConfig `yaml:"finality,omitempty"`
+
+	// Precision lowers the precision amounts derived during quoting (fee
+	// adjustments) are rounded to, per chain.
+	Precision PrecisionConfig `yaml:"precision,omitempty"`
+
+	// KeyHygiene locks decrypted seed material in memory and audits that
+	// finished swaps retain no private keys or secrets.
+	KeyHygiene KeyHygieneConfig `yaml:"key_hygiene,omitempty"`
+
+	// Rendezvous lets members of private trading groups discover each
+	// other through designated rendezvous nodes instead of the public DHT.
+	Rendezvous RendezvousConfig `yaml:"rendezvous,omitempty"`
+
+	// Replica runs the node as a read replica of another node's database,
+	// serving read-only API traffic apart from the primary.
+	Replica ReplicaConfig `yaml:"replica,omitempty"`
+
+	// ColdMode only monitors, claims and refunds existing swaps: no orders
+	// are created or taken and nothing is funded, e.g. on a recovery
+	// machine rescuing in-flight trades.
+	ColdMode bool `yaml:"cold_mode,omitempty"`
 }
 
 // DHTPrefix returns the DHT protocol prefix for the configured network.
@@ -96,15 +286,63 @@ func (c *Config) GetBackendURL(symbol string) string {
 		return ""
 	}
 	if c.IsTestnet() {
-		return cfg.TestnetURL
+		return cfg.URL(chain.Testnet, chain.ActiveTestnet(symbol))
 	}
 	return cfg.MainnetURL
 }
 
+// ApplyTestnets selects the configured test network for each chain.
+// It is a no-op on mainnet.
+func (c *Config) ApplyTestnets() error {
+	if !c.IsTestnet() {
+		return nil
+	}
+	for symbol, name := range c.Testnets {
+		if err := chain.SelectTestnet(symbol, name); err != nil {
+			return fmt.Errorf("testnets: %w", err)
+		}
+	}
+	return nil
+}
+
 // IdentityConfig holds identity-related settings.
 type IdentityConfig struct {
 	// KeyFile is the path to the node's private key file.
 	KeyFile string `yaml:"key_file"`
+
+	// RequireSignedOrders drops remote orders that are not signed by a
+	// wallet identity key with receive address proofs.
+	RequireSignedOrders bool `yaml:"require_signed_orders,omitempty"`
+}
+
+// OrderPoWConfig holds the anti-spam requirement on order announcements.
+type OrderPoWConfig struct {
+	// Bits is the proof-of-work difficulty (leading zero bits) remote orders
+	// must carry to be stored and relayed, and that our orders are mined
+	// at. 0 accepts orders without proof-of-work.
+	Bits uint8 `yaml:"bits"`
+
+	// ExemptCompletedTrades exempts makers we completed at least this many
+	// trades with (0 exempts nobody).
+	ExemptCompletedTrades int `yaml:"exempt_completed_trades"`
+}
+
+// OrderSchemaConfig holds the order format compatibility settings.
+type OrderSchemaConfig struct {
+	// LegacyUntil is when order announcements in the unversioned format
+	// (without "schema") stop being accepted. Zero accepts them indefinitely.
+	LegacyUntil time.Time `yaml:"legacy_until,omitempty"`
+}
+
+// MetaClaimConfig holds the relayed EVM claim settings.
+type MetaClaimConfig struct {
+	// ValidFor is how long a claim authorization stays valid. It never
+	// outlives the HTLC timelock.
+	ValidFor time.Duration `yaml:"valid_for"`
+
+	// Relayers maps an EVM chain symbol to the address of a third-party
+	// relayer authorized with relayer "configured".
+	Relayers map[string]string `yaml:"relayers,omitempty"`
 }
 
 // NetworkConfig holds P2P network settings.
@@ -132,6 +370,9 @@ type NetworkConfig struct {
 
 	// ConnectionManager settings
 	ConnMgr ConnMgrConfig `yaml:"conn_mgr"`
+
+	// Tor dials peers over Tor and publishes an onion service.
+	Tor TorConfig `yaml:"tor"`
 }
 
 // ConnMgrConfig holds connection manager settings.
@@ -146,6 +387,706 @@ type ConnMgrConfig struct {
 	GracePeriod time.Duration `yaml:"grace_period"`
 }
 
+// APIConfig holds JSON-RPC/WebSocket API server settings.
+type APIConfig struct {
+	// TLS configures HTTPS/WSS for the API server.
+	TLS TLSConfig `yaml:"tls,omitempty"`
+
+	// WebSocket configures event delivery to WebSocket clients.
+	WebSocket WebSocketConfig `yaml:"websocket,omitempty"`
+
+	// Keys are the API keys clients present as "Authorization: Bearer
+	// <key>" (or ?api_key= on /ws). Empty leaves the API unauthenticated.
+	Keys []string `yaml:"keys,omitempty"`
+
+	// AdminKeys are API keys that also grant operator access, such as
+	// streaming the node's logs over WebSocket. Setting any enables
+	// authentication like Keys.
+	AdminKeys []string `yaml:"admin_keys,omitempty"`
+
+	// Dashboard serves the read-only web dashboard at /dashboard.
+	Dashboard bool `yaml:"dashboard,omitempty"`
+
+	// Public serves only read-only methods (order book, network stats,
+	// completed trade aggregates) for explorer-style hosting. Wallet, swap
+	// and admin handlers are unregistered at startup, and the dashboard and
+	// metrics are not served.
+	Public bool `yaml:"public,omitempty"`
+
+	// SlowCallThreshold logs calls that take longer, with their method,
+	// duration and redacted params. Zero disables the slow-call log.
+	SlowCallThreshold time.Duration `yaml:"slow_call_threshold,omitempty"`
+}
+
+// Slow WebSocket client policies.
+const (
+	WSSlowClientDropOldest = "drop_oldest" // Discard the client's oldest queued event
+	WSSlowClientDisconnect = "disconnect"  // Close the client's connection
+)
+
+// WebSocketConfig holds WebSocket event delivery settings. Each client has
+// its own outbound queue, so a slow client never holds up the others.
+type WebSocketConfig struct {
+	// QueueSize is the number of events queued per client.
+	QueueSize int `yaml:"queue_size,omitempty"`
+
+	// SlowClient is what happens when a client's queue is full:
+	// "drop_oldest" (default) or "disconnect".
+	SlowClient string `yaml:"slow_client,omitempty"`
+
+	// MaxMessageSize limits the size of messages read from clients (bytes).
+	MaxMessageSize int64 `yaml:"max_message_size,omitempty"`
+
+	// WriteTimeout closes a client whose connection accepts no data for this long.
+	WriteTimeout time.Duration `yaml:"write_timeout,omitempty"`
+
+	// Compression negotiates permessage-deflate with clients that support it.
+	Compression bool `yaml:"compression"`
+
+	// LogRate caps the log records per second streamed to each logs
+	// subscriber; records beyond it are skipped and counted.
+	LogRate int `yaml:"log_rate,omitempty"`
+
+	// TopicReplay is the number of recent events kept per topic and
+	// replayed to clients subscribing to it; 0 disables the replay.
+	TopicReplay int `yaml:"topic_replay"`
+}
+
+// Validate checks the queue size and slow client policy.
+func (w *WebSocketConfig) Validate() error {
+	if w.QueueSize <= 0 || w.MaxMessageSize <= 0 || w.WriteTimeout <= 0 || w.LogRate <= 0 {
+		return fmt.Errorf("api.websocket queue_size, max_message_size, write_timeout and log_rate must be positive")
+	}
+	if w.TopicReplay < 0 {
+		return fmt.Errorf("api.websocket.topic_replay must not be negative")
+	}
+	if w.SlowClient != WSSlowClientDropOldest && w.SlowClient != WSSlowClientDisconnect {
+		return fmt.Errorf("invalid api.websocket.slow_client %q", w.SlowClient)
+	}
+	return nil
+}
+
+// TLSConfig holds API server TLS settings.
+// Either a static certificate (CertFile/KeyFile) or ACME automation
+// (ACMEDomains) enables TLS; static certificates take precedence.
+type TLSConfig struct {
+	// CertFile and KeyFile are PEM-encoded certificate and key paths.
+	CertFile string `yaml:"cert_file,omitempty"`
+	KeyFile  string `yaml:"key_file,omitempty"`
+
+	// ACMEDomains enables automatic certificates (Let's Encrypt) for these domains.
+	ACMEDomains []string `yaml:"acme_domains,omitempty"`
+
+	// ACMEEmail is the contact address registered with the ACME CA.
+	ACMEEmail string `yaml:"acme_email,omitempty"`
+
+	// ACMECacheDir stores issued certificates (default: <data_dir>/acme).
+	ACMECacheDir string `yaml:"acme_cache_dir,omitempty"`
+
+	// ACMEHTTPAddr optionally serves HTTP-01 challenges (e.g. ":80").
+	// Without it only the TLS-ALPN-01 challenge on the API port is used.
+	ACMEHTTPAddr string `yaml:"acme_http_addr,omitempty"`
+
+	// AllowInsecure permits plaintext HTTP when binding to a non-loopback address.
+	AllowInsecure bool `yaml:"allow_insecure,omitempty"`
+}
+
+// Enabled returns true if a static certificate or ACME is configured.
+func (t *TLSConfig) Enabled() bool {
+	return t.UsesStaticCert() || t.UsesACME()
+}
+
+// UsesStaticCert returns true if a certificate/key pair is configured.
+func (t *TLSConfig) UsesStaticCert() bool {
+	return t.CertFile != "" || t.KeyFile != ""
+}
+
+// UsesACME returns true if ACME automation should be used.
+func (t *TLSConfig) UsesACME() bool {
+	return !t.UsesStaticCert() && len(t.ACMEDomains) > 0
+}
+
+// WatchtowerConfig holds watchtower settings.
+type WatchtowerConfig struct {
+	// Enabled accepts bundles from TrustedPeers and watches their swaps.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// TrustedPeers are the peer IDs allowed to register bundles
+	// (e.g. the same user's mobile node).
+	TrustedPeers []string `yaml:"trusted_peers,omitempty"`
+
+	// MaxBundlesPerPeer limits the active obligations per peer (0 = unlimited).
+	MaxBundlesPerPeer int `yaml:"max_bundles_per_peer,omitempty"`
+
+	// CheckInterval is how often bundle triggers are evaluated.
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+}
+
+// IsTrusted returns true if the peer may register bundles.
+func (w *WatchtowerConfig) IsTrusted(peerID string) bool {
+	for _, trusted := range w.TrustedPeers {
+		if trusted == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// ExportConfig holds analytics export settings.
+type ExportConfig struct {
+	// Enabled runs an export every Interval; export_run works regardless.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Dir is the local output directory (default: <data_dir>/exports).
+	Dir string `yaml:"dir,omitempty"`
+
+	// Interval between periodic exports.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Formats to write: csv, parquet.
+	Formats []string `yaml:"formats,omitempty"`
+
+	// S3 uploads each export to an S3-compatible endpoint when set.
+	S3 S3Config `yaml:"s3,omitempty"`
+}
+
+// S3Config holds S3-compatible object storage settings.
+type S3Config struct {
+	Endpoint  string `yaml:"endpoint,omitempty"` // e.g. https://s3.amazonaws.com, http://minio:9000
+	Bucket    string `yaml:"bucket,omitempty"`
+	Region    string `yaml:"region,omitempty"`
+	Prefix    string `yaml:"prefix,omitempty"`
+	AccessKey string `yaml:"access_key,omitempty"`
+	SecretKey string `yaml:"secret_key,omitempty"`
+}
+
+// Enabled returns true if an S3 endpoint is configured.
+func (c *S3Config) Enabled() bool {
+	return c.Endpoint != "" && c.Bucket != ""
+}
+
+// BackupConfig holds encrypted backup replication settings.
+type BackupConfig struct {
+	// Enabled replicates a backup every Interval and after swap events.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Interval is the longest time between backup checks. Unchanged
+	// databases are not re-sent.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Passphrase encrypts backups (Argon2id + AES-256-GCM). It is needed to
+	// restore, so keep a copy off this machine.
+	Passphrase string `yaml:"passphrase,omitempty"`
+
+	// Peers are trusted peer IDs that hold our backups.
+	Peers []string `yaml:"peers,omitempty"`
+
+	// AcceptFrom are peer IDs whose backups we hold.
+	AcceptFrom []string `yaml:"accept_from,omitempty"`
+
+	// MaxSize is the largest backup accepted from a peer, in bytes.
+	MaxSize int64 `yaml:"max_size,omitempty"`
+
+	// S3 uploads each backup to an S3-compatible endpoint when set.
+	S3 S3Config `yaml:"s3,omitempty"`
+}
+
+// Accepts returns true if we hold backups for the peer.
+func (b *BackupConfig) Accepts(peerID string) bool {
+	for _, p := range b.AcceptFrom {
+		if p == peerID {
+			return true
+		}
+	}
+	return false
+}
+
+// HistorySyncConfig holds trade history sync settings.
+type HistorySyncConfig struct {
+	// Peers are the peer IDs of our other nodes. Both nodes must list each
+	// other; sync_pair adds peers at runtime.
+	Peers []string `yaml:"peers,omitempty"`
+
+	// Interval between syncs with connected paired nodes.
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// Price sanity actions for off-market orders in listings.
+const (
+	PriceActionFlag = "flag" // Annotate off-market orders
+	PriceActionHide = "hide" // Leave remote off-market orders out of listings
+)
+
+// PriceSanityConfig holds order book price sanity settings.
+type PriceSanityConfig struct {
+	// Enabled fetches market prices and checks orders against them.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// FeedURL is a CoinGecko-compatible simple price endpoint.
+	FeedURL string `yaml:"feed_url,omitempty"`
+
+	// RefreshInterval is how often prices are fetched.
+	RefreshInterval time.Duration `yaml:"refresh_interval,omitempty"`
+
+	// MaxPriceAge is how old a price may be before checks report unknown.
+	MaxPriceAge time.Duration `yaml:"max_price_age,omitempty"`
+
+	// MaxDeviationBps is the default bound on how far an order's rate may
+	// deviate from the market rate, in basis points (2000 = 20%).
+	MaxDeviationBps uint32 `yaml:"max_deviation_bps,omitempty"`
+
+	// Pairs overrides MaxDeviationBps per pair, e.g. "BTC/LTC": 500.
+	// A pair applies in both directions.
+	Pairs map[string]uint32 `yaml:"pairs,omitempty"`
+
+	// Action is what listings do with off-market orders: flag or hide.
+	Action string `yaml:"action,omitempty"`
+
+	// StaticPrices pins USD prices per chain (decimal strings), taking
+	// precedence over the feed. Useful for testnets and offline nodes.
+	StaticPrices map[string]string `yaml:"static_prices,omitempty"`
+}
+
+// DeadlinesConfig holds swap deadline (refund/claim countdown) settings.
+type DeadlinesConfig struct {
+	// UpdateInterval is how often deadlines are recomputed; an event is
+	// only sent when a swap's deadlines changed (new block, deadline passed).
+	UpdateInterval time.Duration `yaml:"update_interval,omitempty"`
+}
+
+// EventLogConfig holds the WebSocket event audit log settings.
+type EventLogConfig struct {
+	// Enabled records every event broadcast to WebSocket clients.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxAge drops events older than this (0 = keep regardless of age).
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+
+	// MaxEvents keeps at most this many of the newest events (0 = no limit).
+	MaxEvents int `yaml:"max_events,omitempty"`
+}
+
+// Compliance audit checkpoints.
+const (
+	ComplianceCheckpointStarted   = "started"   // Swap initiated or joined
+	ComplianceCheckpointFunded    = "funded"    // Funding broadcast or confirmed, EVM HTLC created
+	ComplianceCheckpointClaimed   = "claimed"   // HTLC claimed or secret revealed
+	ComplianceCheckpointCompleted = "completed" // Swap completed
+	ComplianceCheckpointRefunded  = "refunded"  // Funds refunded
+)
+
+// ComplianceConfig holds compliance audit streaming settings.
+type ComplianceConfig struct {
+	// Enabled records an audit record at every checkpoint and delivers it
+	// to Endpoint. Records are queued locally while the endpoint is down.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Endpoint receives each record as an HTTP POST with a JSON body.
+	Endpoint string `yaml:"endpoint,omitempty"`
+
+	// Secret, if set, authenticates deliveries with an HMAC-SHA256 of the
+	// body in the X-Klingdex-Signature header.
+	Secret string `yaml:"secret,omitempty"`
+
+	// Checkpoints selects the recorded checkpoints (empty = all).
+	Checkpoints []string `yaml:"checkpoints,omitempty"`
+
+	// RetryInterval is how often queued records are retried.
+	RetryInterval time.Duration `yaml:"retry_interval,omitempty"`
+
+	// Timeout bounds one delivery request.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// TelemetryConfig holds anonymized network statistics settings.
+type TelemetryConfig struct {
+	// Enabled sends a report of the swaps finished in each Interval.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Interval is the period each report covers (at least 1h).
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// Collector receives reports as an HTTPS POST. Empty publishes them on
+	// the telemetry gossip topic instead.
+	Collector string `yaml:"collector,omitempty"`
+
+	// MinPairSwaps is the fewest swaps a chain pair needs to be reported on
+	// its own; smaller pairs are folded into "other".
+	MinPairSwaps int `yaml:"min_pair_swaps,omitempty"`
+
+	// Timeout bounds one collector request.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// ArchiveConfig holds swap archival settings.
+type ArchiveConfig struct {
+	// Enabled archives completed swaps older than Retention every Interval.
+	// Archived swaps can still be searched and restored.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Retention is how long finished swaps stay in the database.
+	Retention time.Duration `yaml:"retention,omitempty"`
+
+	// Interval is how often archival runs.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// MaxPerFile bounds the swaps written to one archive file.
+	MaxPerFile int `yaml:"max_per_file,omitempty"`
+}
+
+// NostrConfig holds the Nostr order bridge settings.
+type NostrConfig struct {
+	// Enabled publishes our signed orders to Relays and ingests the orders
+	// other klingdex nodes publish there.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Relays are the relay URLs (wss://, or ws:// on loopback).
+	Relays []string `yaml:"relays,omitempty"`
+
+	// Lookback is how far back orders are fetched when subscribing.
+	Lookback time.Duration `yaml:"lookback,omitempty"`
+
+	// ReconnectInterval is the wait before reconnecting to a relay.
+	ReconnectInterval time.Duration `yaml:"reconnect_interval,omitempty"`
+}
+
+// SnapshotConfig holds swap state snapshot settings.
+type SnapshotConfig struct {
+	// Enabled snapshots the pending swaps every Interval and at shutdown.
+	// Startup then loads the snapshot and reconciles swaps against the
+	// chains in the background instead of before the node is ready.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Interval is how often a snapshot is taken.
+	Interval time.Duration `yaml:"interval,omitempty"`
+}
+
+// ConsolidationConfig holds the UTXO consolidation settings.
+type ConsolidationConfig struct {
+	// Enabled checks the Chains every Interval and consolidates those that
+	// qualify.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// Chains are the Bitcoin-family chains to consolidate.
+	Chains []string `yaml:"chains,omitempty"`
+
+	// Interval is how often the chains are checked.
+	Interval time.Duration `yaml:"interval,omitempty"`
+
+	// MaxFeeRate is the highest economy fee rate (sat/vB) at which a chain
+	// is consolidated.
+	MaxFeeRate uint64 `yaml:"max_fee_rate,omitempty"`
+
+	// MinUTXOs is the fewest eligible UTXOs worth consolidating.
+	MinUTXOs int `yaml:"min_utxos,omitempty"`
+
+	// MaxInputs bounds the UTXOs merged in one transaction.
+	MaxInputs int `yaml:"max_inputs,omitempty"`
+
+	// MaxUTXOValue only merges UTXOs worth at most this much, in smallest
+	// units (0 = any).
+	MaxUTXOValue uint64 `yaml:"max_utxo_value,omitempty"`
+
+	// ExcludeAddresses and ExcludeOutpoints (txid:vout) are never
+	// consolidated (coin control).
+	ExcludeAddresses []string `yaml:"exclude_addresses,omitempty"`
+	ExcludeOutpoints []string `yaml:"exclude_outpoints,omitempty"`
+}
+
+// ReannounceConfig holds the re-announcement of our open orders.
+type ReannounceConfig struct {
+	// Enabled re-announces our open orders at startup and when the node
+	// recovers from a partition.
+	Enabled bool `yaml:"enabled"`
+
+	// PeerWait is how long to wait for a first peer before announcing.
+	PeerWait time.Duration `yaml:"peer_wait,omitempty"`
+
+	// RetryInterval and MaxAttempts bound the retries of orders whose
+	// broadcast failed.
+	RetryInterval time.Duration `yaml:"retry_interval,omitempty"`
+	MaxAttempts   int           `yaml:"max_attempts,omitempty"`
+
+	// KeepStale leaves orders that failed re-validation (off-market rate,
+	// funds no longer available) open but unannounced instead of
+	// cancelling them.
+	KeepStale bool `yaml:"keep_stale,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *ReannounceConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.PeerWait < 0 || c.RetryInterval <= 0 {
+		return fmt.Errorf("reannounce.peer_wait must not be negative and reannounce.retry_interval must be positive")
+	}
+	if c.MaxAttempts < 1 {
+		return fmt.Errorf("reannounce.max_attempts must be at least 1")
+	}
+	return nil
+}
+
+// MoneroConfig holds the monero-wallet-rpc connection used by BTC/XMR
+// adaptor swaps. Both parties need it: the XMR party locks from the wallet,
+// the BTC party checks the lock and sweeps it when it redeems.
+type MoneroConfig struct {
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// URL of monero-wallet-rpc, e.g. http://127.0.0.1:18083. User and
+	// Password are its --rpc-login, sent with HTTP digest auth.
+	URL      string `yaml:"url,omitempty"`
+	User     string `yaml:"user,omitempty"`
+	Password string `yaml:"password,omitempty"`
+
+	// WalletFile is the main wallet, reopened after a swap's shared wallet
+	// was swept.
+	WalletFile string `yaml:"wallet_file,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *MoneroConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.URL == "" || c.WalletFile == "" {
+		return fmt.Errorf("monero.url and monero.wallet_file are required")
+	}
+	return nil
+}
+
+// Address reuse modes.
+const (
+	AddressReuseWarn    = "warn"    // Warn in RPC results and address_reuse events
+	AddressReuseEnforce = "enforce" // Refuse reuse and claim to fresh addresses
+)
+
+// PrivacyConfig holds the wallet privacy settings.
+type PrivacyConfig struct {
+	// AddressReuse is "warn" (default) or "enforce". Enforce refuses sends
+	// and payouts to addresses already used unless the request sets
+	// allow_address_reuse, and pays swap claims and refunds to unused
+	// wallet addresses instead of the first one.
+	AddressReuse string `yaml:"address_reuse,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *PrivacyConfig) Validate() error {
+	switch c.AddressReuse {
+	case "", AddressReuseWarn, AddressReuseEnforce:
+		return nil
+	}
+	return fmt.Errorf("unknown privacy.address_reuse %q (warn, enforce)", c.AddressReuse)
+}
+
+// Enforce returns true when fresh addresses are enforced.
+func (c *PrivacyConfig) Enforce() bool {
+	return c.AddressReuse == AddressReuseEnforce
+}
+
+// WrappedAssetsConfig holds the policy for swaps whose legs represent the
+// same underlying asset, e.g. BTC for WBTC.
+type WrappedAssetsConfig struct {
+	// RejectUnfavorable refuses to create or take such orders when we
+	// receive less than we give, decimals adjusted, by more than
+	// MaxDiscountBps. Listings flag the pairs either way.
+	RejectUnfavorable bool `yaml:"reject_unfavorable,omitempty"`
+
+	// MaxDiscountBps is the discount accepted to cover wrapping and bridge
+	// fees, in basis points.
+	MaxDiscountBps uint32 `yaml:"max_discount_bps,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *WrappedAssetsConfig) Validate() error {
+	if c.MaxDiscountBps >= 10000 {
+		return fmt.Errorf("wrapped_assets.max_discount_bps must be below 10000")
+	}
+	return nil
+}
+
+// FeeNegotiationConfig holds the in-band negotiation of fee adjustments.
+type FeeNegotiationConfig struct {
+	// Enabled answers the counterparty's fee adjustment proposals; when
+	// disabled they are rejected.
+	Enabled bool `yaml:"enabled"`
+
+	// MaxAdjustmentBps bounds the adjustments we propose or accept, in
+	// basis points of the request amount.
+	MaxAdjustmentBps uint32 `yaml:"max_adjustment_bps,omitempty"`
+
+	// ToleranceBps is how far a proposal may favor the proposer beyond our
+	// own estimate, in basis points of the request amount, covering fee
+	// oracles that disagree.
+	ToleranceBps uint32 `yaml:"tolerance_bps,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *FeeNegotiationConfig) Validate() error {
+	if c.MaxAdjustmentBps > swap.MaxFeeAdjustmentBps {
+		return fmt.Errorf("fee_negotiation.max_adjustment_bps must be at most %d", swap.MaxFeeAdjustmentBps)
+	}
+	if c.ToleranceBps > c.MaxAdjustmentBps {
+		return fmt.Errorf("fee_negotiation.tolerance_bps must not exceed max_adjustment_bps")
+	}
+	return nil
+}
+
+// AggregationConfig holds the combining of trades into aggregate swaps.
+type AggregationConfig struct {
+	// Accept answers a taker's proposal to combine its trades against our
+	// orders; when disabled they are rejected.
+	Accept bool `yaml:"accept"`
+
+	// Window is how long trades taken with aggregate set are collected
+	// before they are proposed, and the most their creation times may
+	// spread in an accepted proposal.
+	Window time.Duration `yaml:"window,omitempty"`
+
+	// MaxTrades is the most trades combined into one aggregate; a batch
+	// reaching it is proposed right away.
+	MaxTrades int `yaml:"max_trades,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *AggregationConfig) Validate() error {
+	if c.Window <= 0 {
+		return fmt.Errorf("aggregation.window must be positive")
+	}
+	if c.MaxTrades < 2 {
+		return fmt.Errorf("aggregation.max_trades must be at least 2")
+	}
+	return nil
+}
+
+// Startup check failure policies.
+const (
+	StartupFailureWarn = "warn" // Log the failed checks and start
+	StartupFailureCold = "cold" // Start in cold mode: no new trades
+	StartupFailureExit = "exit" // Refuse to start
+)
+
+// StartupChecksConfig holds the checks run before the node starts.
+type StartupChecksConfig struct {
+	// Enabled runs the checks at every start.
+	Enabled bool `yaml:"enabled"`
+
+	// OnFailure is what happens when a check fails: warn, cold or exit.
+	// Warnings (e.g. no wallet yet) never stop the node.
+	OnFailure string `yaml:"on_failure,omitempty"`
+
+	// Timeout bounds the backend and clock checks.
+	Timeout time.Duration `yaml:"timeout,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *StartupChecksConfig) Validate() error {
+	switch c.OnFailure {
+	case StartupFailureWarn, StartupFailureCold, StartupFailureExit:
+	default:
+		return fmt.Errorf("startup_checks.on_failure must be %s, %s or %s", StartupFailureWarn, StartupFailureCold, StartupFailureExit)
+	}
+	if c.Timeout <= 0 {
+		return fmt.Errorf("startup_checks.timeout must be positive")
+	}
+	return nil
+}
+
+// FinalityConfig holds the per-chain depths behind the finality labels
+// (pending, confirmed, final) of API responses.
+type FinalityConfig struct {
+	// Depths maps a chain symbol to the confirmations after which its
+	// transactions are considered safe from reorgs. Chains not listed use
+	// their network's default (config.ChainParams.Confirmations).
+	Depths map[string]uint32 `yaml:"depths,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *FinalityConfig) Validate() error {
+	for symbol, depth := range c.Depths {
+		if !config.IsCoinSupported(symbol) {
+			return fmt.Errorf("finality.depths: unsupported chain %q", symbol)
+		}
+		if depth == 0 {
+			return fmt.Errorf("finality.depths.%s must be positive", symbol)
+		}
+	}
+	return nil
+}
+
+// PrecisionConfig holds the quote precision of derived amounts.
+type PrecisionConfig struct {
+	// QuoteDecimals maps a chain symbol to the decimals its derived amounts
+	// are quoted to. Chains not listed use their decimals, at most
+	// swap.MaxQuoteDecimals; the counterparty's lower precision wins.
+	QuoteDecimals map[string]uint8 `yaml:"quote_decimals,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *PrecisionConfig) Validate() error {
+	for symbol, decimals := range c.QuoteDecimals {
+		if !config.IsCoinSupported(symbol) {
+			return fmt.Errorf("precision.quote_decimals: unsupported chain %q", symbol)
+		}
+		if decimals == 0 || decimals > swap.MaxQuoteDecimals {
+			return fmt.Errorf("precision.quote_decimals.%s must be between 1 and %d", symbol, swap.MaxQuoteDecimals)
+		}
+	}
+	return nil
+}
+
+// KeyHygieneConfig holds the handling of private key material.
+type KeyHygieneConfig struct {
+	// Mlock locks derived encryption keys, decrypted mnemonics and seeds
+	// in memory while they're in use, keeping them out of swap. Needs
+	// CAP_IPC_LOCK or a large enough RLIMIT_MEMLOCK; a buffer that can't
+	// be locked is still zeroed after use.
+	Mlock bool `yaml:"mlock,omitempty"`
+
+	// AuditInterval is how often finished swaps are checked for private
+	// keys and secrets left in memory or in the database. 0 disables the
+	// audit.
+	AuditInterval time.Duration `yaml:"audit_interval,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *KeyHygieneConfig) Validate() error {
+	if c.AuditInterval < 0 {
+		return fmt.Errorf("key_hygiene.audit_interval must not be negative")
+	}
+	return nil
+}
+
+// ReplicaConfig holds read replica settings.
+type ReplicaConfig struct {
+	// Enabled starts a read replica instead of a node: no P2P host, wallet
+	// or swap coordinator, only the read-only API methods over the
+	// primary's database, opened read-only.
+	Enabled bool `yaml:"enabled,omitempty"`
+
+	// PrimaryDataDir is the data directory of the primary node, on the
+	// same machine or a shared filesystem that supports SQLite locking.
+	PrimaryDataDir string `yaml:"primary_data_dir,omitempty"`
+
+	// PollInterval is how often the replica checks the database for the
+	// primary's writes to refresh its order book.
+	PollInterval time.Duration `yaml:"poll_interval,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *ReplicaConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.PrimaryDataDir == "" {
+		return fmt.Errorf("replica.primary_data_dir is required")
+	}
+	if c.PollInterval <= 0 {
+		return fmt.Errorf("replica.poll_interval must be positive")
+	}
+	return nil
+}
+
 // StorageConfig holds storage settings.
 type StorageConfig struct {
 	// DataDir is the directory for all data files.
@@ -186,6 +1127,11 @@ func DefaultConfig() *Config {
 				HighWater:   400,
 				GracePeriod: time.Minute,
 			},
+			Tor: TorConfig{
+				SocksAddr:    "127.0.0.1:9050",
+				ControlAddr:  "127.0.0.1:9051",
+				OnionService: true,
+			},
 		},
 		Storage: StorageConfig{
 			DataDir: "~/.klingon",
@@ -194,12 +1140,197 @@ func DefaultConfig() *Config {
 			Level: "info",
 			File:  "",
 		},
+		Watchtower: WatchtowerConfig{
+			MaxBundlesPerPeer: 100,
+			CheckInterval:     time.Minute,
+		},
+		Partition: PartitionConfig{
+			CheckInterval:       30 * time.Second,
+			StartupGrace:        2 * time.Minute,
+			GossipSilence:       10 * time.Minute,
+			DHTFailureThreshold: 3,
+		},
+		Clock: ClockConfig{
+			NTPServers:     []string{"pool.ntp.org", "time.cloudflare.com"},
+			CheckInterval:  30 * time.Minute,
+			NTPTimeout:     5 * time.Second,
+			MaxSkew:        30 * time.Second,
+			MinPeerSamples: 3,
+		},
+		Export: ExportConfig{
+			Interval: 24 * time.Hour,
+			Formats:  []string{"csv"},
+		},
+		Backup: BackupConfig{
+			Interval: 5 * time.Minute,
+			MaxSize:  256 << 20,
+		},
+		HistorySync: HistorySyncConfig{
+			Interval: 10 * time.Minute,
+		},
+		PriceSanity: PriceSanityConfig{
+			FeedURL:         config.DefaultPriceFeedURL,
+			RefreshInterval: 5 * time.Minute,
+			MaxPriceAge:     30 * time.Minute,
+			MaxDeviationBps: 2000,
+			Action:          PriceActionFlag,
+		},
+		AutoClaim: swap.AutoClaimPolicy{
+			Mode:          swap.AutoClaimImmediate,
+			Confirmations: 1,
+			CheckInterval: 30 * time.Second,
+		},
+		RefundWatcher: swap.RefundWatcherPolicy{
+			Enabled:       true,
+			CheckInterval: time.Minute,
+		},
+		ZeroConf: swap.ZeroConfPolicy{
+			MonitorWindow:      2 * time.Minute,
+			MinCompletedTrades: 3,
+			MaxFailureRateBps:  1000,
+		},
+		FundingVariance: swap.FundingVariancePolicy{
+			Enabled:      true,
+			ToleranceBps: 50,
+			TopUpBps:     500,
+			TopUpTimeout: time.Hour,
+		},
+		Affordability: swap.AffordabilityPolicy{
+			Enabled:          true,
+			MinConfirmations: 1,
+		},
+		Confirmation: swap.ConfirmationPolicy{
+			Mode:    swap.ConfirmAuto,
+			Timeout: 10 * time.Minute,
+		},
+		ContractPause: swap.ContractPausePolicy{
+			Enabled:       true,
+			PollInterval:  time.Minute,
+			AlertInterval: 10 * time.Minute,
+		},
+		OperationTimeouts: swap.OperationTimeouts{
+			Broadcast:  2 * time.Minute,
+			ChainQuery: time.Minute,
+			SecretWait: 30 * time.Minute,
+		},
+		Deadlines: DeadlinesConfig{
+			UpdateInterval: time.Minute,
+		},
+		API: APIConfig{
+			WebSocket:         DefaultWebSocketConfig(),
+			SlowCallThreshold: 2 * time.Second,
+		},
+		EventLog: EventLogConfig{
+			Enabled:   true,
+			MaxAge:    30 * 24 * time.Hour,
+			MaxEvents: 100000,
+		},
+		PeerQuality: PeerQualityConfig{
+			ProbeInterval: time.Minute,
+			MaxAge:        30 * 24 * time.Hour,
+		},
+		Compliance: ComplianceConfig{
+			RetryInterval: 30 * time.Second,
+			Timeout:       10 * time.Second,
+		},
+		Telemetry: TelemetryConfig{
+			Interval:     24 * time.Hour,
+			MinPairSwaps: 3,
+			Timeout:      10 * time.Second,
+		},
+		OrderPoW: OrderPoWConfig{
+			Bits:                  20,
+			ExemptCompletedTrades: 3,
+		},
+		OrderSchema: OrderSchemaConfig{
+			LegacyUntil: time.Date(2027, time.April, 1, 0, 0, 0, 0, time.UTC),
+		},
+		MetaClaim: MetaClaimConfig{
+			ValidFor: time.Hour,
+		},
+		Archive: ArchiveConfig{
+			Retention:  90 * 24 * time.Hour,
+			Interval:   24 * time.Hour,
+			MaxPerFile: 1000,
+		},
+		Nostr: NostrConfig{
+			Lookback:          24 * time.Hour,
+			ReconnectInterval: 30 * time.Second,
+		},
+		Snapshots: SnapshotConfig{
+			Interval: 5 * time.Minute,
+		},
+		KeyHygiene: KeyHygieneConfig{
+			AuditInterval: 10 * time.Minute,
+		},
+		Replica: ReplicaConfig{
+			PollInterval: 2 * time.Second,
+		},
+		BackendPlugins: backend.DefaultPluginConfig(),
+		Rendezvous: RendezvousConfig{
+			TTL:              2 * time.Hour,
+			DiscoverInterval: time.Minute,
+			MaxRegistrations: 10000,
+		},
+		Consolidation: ConsolidationConfig{
+			Interval:   time.Hour,
+			MaxFeeRate: 2,
+			MinUTXOs:   10,
+			MaxInputs:  100,
+		},
+		Tracing: tracing.DefaultConfig(),
+		Reannounce: ReannounceConfig{
+			Enabled:       true,
+			PeerWait:      time.Minute,
+			RetryInterval: 30 * time.Second,
+			MaxAttempts:   10,
+		},
+		Privacy: PrivacyConfig{
+			AddressReuse: AddressReuseWarn,
+		},
+		WrappedAssets: WrappedAssetsConfig{
+			MaxDiscountBps: 50,
+		},
+		FeeNegotiation: FeeNegotiationConfig{
+			Enabled:          true,
+			MaxAdjustmentBps: 100,
+			ToleranceBps:     10,
+		},
+		Aggregation: AggregationConfig{
+			Accept:    true,
+			Window:    30 * time.Second,
+			MaxTrades: 20,
+		},
+		StartupChecks: StartupChecksConfig{
+			Enabled:   true,
+			OnFailure: StartupFailureWarn,
+			Timeout:   10 * time.Second,
+		},
+		Lightning: lightning.Config{
+			Implementation: lightning.ImplementationLND,
+			MaxFeeBps:      50,
+			InvoiceExpiry:  time.Hour,
+			PaymentTimeout: time.Minute,
+		},
 	}
 }
 
 // ConfigFileName is the default config file name.
 const ConfigFileName = "config.yaml"
 
+// DefaultWebSocketConfig returns the default WebSocket delivery settings.
+func DefaultWebSocketConfig() WebSocketConfig {
+	return WebSocketConfig{
+		QueueSize:      256,
+		SlowClient:     WSSlowClientDropOldest,
+		MaxMessageSize: 4096,
+		WriteTimeout:   10 * time.Second,
+		Compression:    true,
+		LogRate:        50,
+		TopicReplay:    20,
+	}
+}
+
 // LoadConfig loads configuration from a YAML file.
 // If the file doesn't exist, it creates one with default values.
 func LoadConfig(dataDir string) (*Config, error) {
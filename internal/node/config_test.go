@@ -3,8 +3,12 @@ package node
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/swap"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -98,6 +102,37 @@ func TestConfigIsTestnet(t *testing.T) {
 	}
 }
 
+func TestConfigApplyTestnets(t *testing.T) {
+	t.Cleanup(func() { _ = chain.SelectTestnet("BTC", chain.TestnetBTCTestnet4) })
+
+	cfg := DefaultConfig()
+	cfg.Testnets = map[string]string{"BTC": chain.TestnetBTCSignet}
+
+	// Mainnet ignores testnet selection
+	if err := cfg.ApplyTestnets(); err != nil {
+		t.Fatalf("ApplyTestnets() on mainnet error = %v", err)
+	}
+	if chain.ActiveTestnet("BTC") != chain.TestnetBTCTestnet4 {
+		t.Error("mainnet must not change the selected test network")
+	}
+
+	cfg.NetworkType = NetworkTestnet
+	if err := cfg.ApplyTestnets(); err != nil {
+		t.Fatalf("ApplyTestnets() error = %v", err)
+	}
+	if chain.ActiveTestnet("BTC") != chain.TestnetBTCSignet {
+		t.Errorf("BTC test network = %s, want signet", chain.ActiveTestnet("BTC"))
+	}
+	if got := cfg.GetBackendURL("BTC"); got != "https://mempool.space/signet/api" {
+		t.Errorf("BTC backend URL = %s", got)
+	}
+
+	cfg.Testnets = map[string]string{"BTC": "regtest"}
+	if err := cfg.ApplyTestnets(); err == nil {
+		t.Error("expected error for unknown test network")
+	}
+}
+
 func TestLoadConfigCreatesDefault(t *testing.T) {
 	// Create temp directory
 	tmpDir, err := os.MkdirTemp("", "klingon-test-*")
@@ -140,6 +175,7 @@ func TestLoadConfigReadsExisting(t *testing.T) {
 	customConfig := `network_type: testnet
 identity:
   key_file: custom.key
+  require_signed_orders: true
 network:
   listen_addrs:
     - /ip4/0.0.0.0/tcp/5001
@@ -168,6 +204,10 @@ logging:
 		t.Errorf("expected custom.key, got %s", cfg.Identity.KeyFile)
 	}
 
+	if !cfg.Identity.RequireSignedOrders {
+		t.Error("expected RequireSignedOrders to be true")
+	}
+
 	if len(cfg.Network.ListenAddrs) != 1 || cfg.Network.ListenAddrs[0] != "/ip4/0.0.0.0/tcp/5001" {
 		t.Errorf("unexpected listen addrs: %v", cfg.Network.ListenAddrs)
 	}
@@ -272,3 +312,978 @@ func containsHelper(s, substr string) bool {
 	}
 	return false
 }
+
+func TestTLSConfigEnabled(t *testing.T) {
+	var tlsCfg TLSConfig
+	if tlsCfg.Enabled() {
+		t.Error("empty TLS config should be disabled")
+	}
+
+	tlsCfg.ACMEDomains = []string{"api.example.com"}
+	if !tlsCfg.Enabled() || !tlsCfg.UsesACME() {
+		t.Error("ACME domains should enable ACME")
+	}
+
+	tlsCfg.CertFile = "cert.pem"
+	tlsCfg.KeyFile = "key.pem"
+	if !tlsCfg.UsesStaticCert() || tlsCfg.UsesACME() {
+		t.Error("static certificate should take precedence over ACME")
+	}
+}
+
+func TestWatchtowerConfigIsTrusted(t *testing.T) {
+	cfg := DefaultConfig()
+	if cfg.Watchtower.Enabled {
+		t.Error("watchtower must be opt-in")
+	}
+	if cfg.Watchtower.CheckInterval <= 0 || cfg.Watchtower.MaxBundlesPerPeer <= 0 {
+		t.Error("watchtower defaults should set check interval and bundle limit")
+	}
+
+	cfg.Watchtower.TrustedPeers = []string{"12D3KooWMobile"}
+	if !cfg.Watchtower.IsTrusted("12D3KooWMobile") {
+		t.Error("configured peer should be trusted")
+	}
+	if cfg.Watchtower.IsTrusted("12D3KooWOther") {
+		t.Error("unknown peer should not be trusted")
+	}
+}
+
+// TestLoadConfigSections loads a config file setting part of one section at
+// a time and checks the set fields are read and the rest keep their defaults.
+func TestLoadConfigSections(t *testing.T) {
+	def := DefaultConfig()
+	pluginDir := t.TempDir()
+	tests := []struct {
+		name  string
+		yaml  string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "auto_claim",
+			yaml: `auto_claim:
+  mode: confirmations
+  confirmations: 2
+  chains:
+    ETH:
+      mode: manual
+`,
+			check: func(t *testing.T, cfg *Config) {
+				rule, source := cfg.AutoClaim.RuleFor("BTC")
+				if rule.Mode != swap.AutoClaimConfirmations || rule.Confirmations != 2 || source != swap.AutoClaimSourceDefault {
+					t.Errorf("RuleFor(BTC) = %+v (%s), want 2 confirmations", rule, source)
+				}
+				rule, source = cfg.AutoClaim.RuleFor("ETH")
+				if rule.Mode != swap.AutoClaimManual || source != swap.AutoClaimSourceChain {
+					t.Errorf("RuleFor(ETH) = %+v (%s), want manual chain override", rule, source)
+				}
+				if cfg.AutoClaim.CheckInterval != def.AutoClaim.CheckInterval {
+					t.Errorf("CheckInterval = %v, want default %v", cfg.AutoClaim.CheckInterval, def.AutoClaim.CheckInterval)
+				}
+			},
+		},
+		{
+			name: "deadlines",
+			yaml: "deadlines:\n  update_interval: 15s\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Deadlines.UpdateInterval != 15*time.Second {
+					t.Errorf("UpdateInterval = %v, want 15s", cfg.Deadlines.UpdateInterval)
+				}
+			},
+		},
+		{
+			name: "event_log",
+			yaml: "event_log:\n  enabled: false\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.EventLog.Enabled {
+					t.Error("event_log.enabled: false not applied")
+				}
+				if cfg.EventLog.MaxEvents != def.EventLog.MaxEvents {
+					t.Errorf("MaxEvents = %d, want default %d", cfg.EventLog.MaxEvents, def.EventLog.MaxEvents)
+				}
+			},
+		},
+		{
+			name: "peer_quality",
+			yaml: "peer_quality:\n  probe_interval: 10s\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.PeerQuality.ProbeInterval != 10*time.Second {
+					t.Errorf("ProbeInterval = %v, want 10s", cfg.PeerQuality.ProbeInterval)
+				}
+				if cfg.PeerQuality.MaxAge != def.PeerQuality.MaxAge {
+					t.Errorf("MaxAge = %v, want default", cfg.PeerQuality.MaxAge)
+				}
+			},
+		},
+		{
+			name: "compliance",
+			yaml: "compliance:\n  enabled: true\n  endpoint: https://audit.example.com/klingdex\n  checkpoints: [started, completed]\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Compliance.Enabled || cfg.Compliance.Endpoint != "https://audit.example.com/klingdex" || len(cfg.Compliance.Checkpoints) != 2 {
+					t.Errorf("Compliance = %+v", cfg.Compliance)
+				}
+				if cfg.Compliance.RetryInterval != def.Compliance.RetryInterval {
+					t.Errorf("RetryInterval = %v, want default", cfg.Compliance.RetryInterval)
+				}
+			},
+		},
+		{
+			name: "zero_conf",
+			yaml: `zero_conf:
+  enabled: true
+  max_amounts:
+    BTC: 100000
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.ZeroConf.Enabled || cfg.ZeroConf.MaxAmounts["BTC"] != 100000 {
+					t.Errorf("ZeroConf = %+v", cfg.ZeroConf)
+				}
+				if cfg.ZeroConf.MonitorWindow != def.ZeroConf.MonitorWindow || cfg.ZeroConf.MaxFailureRateBps != def.ZeroConf.MaxFailureRateBps {
+					t.Errorf("ZeroConf = %+v, want default window and failure rate", cfg.ZeroConf)
+				}
+				if err := cfg.ZeroConf.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "funding_variance",
+			yaml: `funding_variance:
+  tolerance_bps: 10
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.FundingVariance.Enabled || cfg.FundingVariance.ToleranceBps != 10 {
+					t.Errorf("FundingVariance = %+v", cfg.FundingVariance)
+				}
+				if cfg.FundingVariance.TopUpBps != def.FundingVariance.TopUpBps || cfg.FundingVariance.TopUpTimeout != def.FundingVariance.TopUpTimeout {
+					t.Errorf("FundingVariance = %+v, want default top-up band", cfg.FundingVariance)
+				}
+			},
+		},
+		{
+			name: "history_sync",
+			yaml: "history_sync:\n  peers: [12D3KooWLaptop]\n",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.HistorySync.Peers) != 1 || cfg.HistorySync.Peers[0] != "12D3KooWLaptop" {
+					t.Errorf("Peers = %v", cfg.HistorySync.Peers)
+				}
+				if cfg.HistorySync.Interval != def.HistorySync.Interval {
+					t.Errorf("Interval = %v, want default", cfg.HistorySync.Interval)
+				}
+			},
+		},
+		{
+			name: "contract_pause",
+			yaml: `contract_pause:
+  alert_interval: 1m
+  webhook_url: http://localhost:9000/alerts
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.ContractPause.Enabled || cfg.ContractPause.AlertInterval != time.Minute || cfg.ContractPause.PollInterval != def.ContractPause.PollInterval {
+					t.Errorf("ContractPause = %+v", cfg.ContractPause)
+				}
+				if cfg.ContractPause.WebhookURL != "http://localhost:9000/alerts" {
+					t.Errorf("WebhookURL = %q", cfg.ContractPause.WebhookURL)
+				}
+			},
+		},
+		{
+			name: "api.websocket",
+			yaml: `api:
+  websocket:
+    slow_client: disconnect
+    compression: false
+`,
+			check: func(t *testing.T, cfg *Config) {
+				ws := cfg.API.WebSocket
+				if ws.SlowClient != WSSlowClientDisconnect || ws.Compression || ws.QueueSize != def.API.WebSocket.QueueSize {
+					t.Errorf("WebSocket = %+v", ws)
+				}
+			},
+		},
+		{
+			name: "order_pow",
+			yaml: "order_pow:\n  bits: 16\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OrderPoW.Bits != 16 {
+					t.Errorf("Bits = %d, want 16", cfg.OrderPoW.Bits)
+				}
+				if cfg.OrderPoW.ExemptCompletedTrades != def.OrderPoW.ExemptCompletedTrades {
+					t.Errorf("ExemptCompletedTrades = %d, want default", cfg.OrderPoW.ExemptCompletedTrades)
+				}
+			},
+		},
+		{
+			name: "archive",
+			yaml: "archive:\n  enabled: true\n  retention: 720h\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Archive.Enabled || cfg.Archive.Retention != 720*time.Hour {
+					t.Errorf("Archive = %+v", cfg.Archive)
+				}
+				if cfg.Archive.Interval != def.Archive.Interval {
+					t.Errorf("Interval = %v, want default", cfg.Archive.Interval)
+				}
+			},
+		},
+		{
+			name: "clock",
+			yaml: "clock:\n  ntp_servers: []\n  max_skew: 10s\n  refuse_trades: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Clock.NTPServers) != 0 || cfg.Clock.MaxSkew != 10*time.Second || !cfg.Clock.RefuseTrades {
+					t.Errorf("Clock = %+v", cfg.Clock)
+				}
+				if cfg.Clock.CheckInterval != def.Clock.CheckInterval {
+					t.Errorf("CheckInterval = %v, want default", cfg.Clock.CheckInterval)
+				}
+			},
+		},
+		{
+			name: "order_schema",
+			yaml: "order_schema:\n  legacy_until: 2027-06-30T00:00:00Z\n",
+			check: func(t *testing.T, cfg *Config) {
+				if want := time.Date(2027, time.June, 30, 0, 0, 0, 0, time.UTC); !cfg.OrderSchema.LegacyUntil.Equal(want) {
+					t.Errorf("LegacyUntil = %v, want %v", cfg.OrderSchema.LegacyUntil, want)
+				}
+			},
+		},
+		{
+			name: "meta_claim",
+			yaml: "meta_claim:\n  relayers:\n    ETH: \"0x5E1A000000000000000000000000000000005E1A\"\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.MetaClaim.ValidFor != time.Hour {
+					t.Errorf("ValidFor = %v, want the default 1h", cfg.MetaClaim.ValidFor)
+				}
+				if cfg.MetaClaim.Relayers["ETH"] != "0x5E1A000000000000000000000000000000005E1A" {
+					t.Errorf("Relayers = %v", cfg.MetaClaim.Relayers)
+				}
+			},
+		},
+		{
+			name: "telemetry",
+			yaml: "telemetry:\n  enabled: true\n  collector: https://telemetry.example.com/v1\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Telemetry.Enabled || cfg.Telemetry.Collector != "https://telemetry.example.com/v1" || cfg.Telemetry.Interval != 24*time.Hour {
+					t.Errorf("Telemetry = %+v", cfg.Telemetry)
+				}
+			},
+		},
+		{
+			name: "api.keys",
+			yaml: "api:\n  keys:\n    - k1\n    - k2\n  admin_keys:\n    - root\n  dashboard: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.API.Keys) != 2 || len(cfg.API.AdminKeys) != 1 || !cfg.API.Dashboard {
+					t.Errorf("API = %+v", cfg.API)
+				}
+			},
+		},
+		{
+			name: "nostr",
+			yaml: "nostr:\n  enabled: true\n  relays:\n    - wss://relay.example.com\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Nostr.Enabled || len(cfg.Nostr.Relays) != 1 || cfg.Nostr.Lookback != 24*time.Hour {
+					t.Errorf("Nostr = %+v", cfg.Nostr)
+				}
+			},
+		},
+		{
+			name: "snapshots",
+			yaml: "snapshots:\n  enabled: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Snapshots.Enabled || cfg.Snapshots.Interval != 5*time.Minute {
+					t.Errorf("Snapshots = %+v", cfg.Snapshots)
+				}
+			},
+		},
+		{
+			name: "operation_timeouts",
+			yaml: `operation_timeouts:
+  broadcast: 45s
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.OperationTimeouts.Broadcast != 45*time.Second || cfg.OperationTimeouts.SecretWait != def.OperationTimeouts.SecretWait {
+					t.Errorf("OperationTimeouts = %+v", cfg.OperationTimeouts)
+				}
+			},
+		},
+		{
+			name: "api.public",
+			yaml: "api:\n  public: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.API.Public {
+					t.Errorf("API = %+v, want public", cfg.API)
+				}
+			},
+		},
+		{
+			name: "consolidation",
+			yaml: "consolidation:\n  enabled: true\n  chains: [BTC]\n  max_fee_rate: 3\n  exclude_outpoints: [\"aa:0\"]\n",
+			check: func(t *testing.T, cfg *Config) {
+				c := cfg.Consolidation
+				if !c.Enabled || len(c.Chains) != 1 || c.MaxFeeRate != 3 || c.MinUTXOs != 10 || len(c.ExcludeOutpoints) != 1 {
+					t.Errorf("Consolidation = %+v", c)
+				}
+			},
+		},
+		{
+			name: "tracing",
+			yaml: "tracing:\n  enabled: true\n  endpoint: https://otel.example.com/v1/traces\n  sample_percent: 10\n  headers:\n    authorization: Bearer x\n",
+			check: func(t *testing.T, cfg *Config) {
+				c := cfg.Tracing
+				if !c.Enabled || c.Endpoint != "https://otel.example.com/v1/traces" || c.SamplePercent != 10 ||
+					c.Headers["authorization"] != "Bearer x" || c.BatchSize != def.Tracing.BatchSize {
+					t.Errorf("Tracing = %+v", c)
+				}
+			},
+		},
+		{
+			name: "affordability",
+			yaml: `affordability:
+  enabled: false
+`,
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Affordability.Enabled || cfg.Affordability.MinConfirmations != 1 {
+					t.Errorf("Affordability = %+v, want disabled with the default confirmations", cfg.Affordability)
+				}
+			},
+		},
+		{
+			name: "api.slow_call_threshold",
+			yaml: "api:\n  slow_call_threshold: 500ms\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.API.SlowCallThreshold != 500*time.Millisecond {
+					t.Errorf("slow_call_threshold = %v, want 500ms", cfg.API.SlowCallThreshold)
+				}
+			},
+		},
+		{
+			name: "lightning",
+			yaml: "lightning:\n  enabled: true\n  host: https://127.0.0.1:8080\n  macaroon_path: /lnd/admin.macaroon\n  max_fee_bps: 100\n",
+			check: func(t *testing.T, cfg *Config) {
+				c := cfg.Lightning
+				if !c.Enabled || c.Host != "https://127.0.0.1:8080" || c.MaxFeeBps != 100 || c.PaymentTimeout != def.Lightning.PaymentTimeout {
+					t.Errorf("Lightning = %+v", c)
+				}
+				if err := c.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "confirmation",
+			yaml: "confirmation:\n  mode: manual\n  auto_confirm_below:\n    BTC: 100000\n",
+			check: func(t *testing.T, cfg *Config) {
+				c := cfg.Confirmation
+				if c.Mode != swap.ConfirmManual || c.AutoConfirmBelow["BTC"] != 100_000 || c.Timeout != def.Confirmation.Timeout {
+					t.Errorf("Confirmation = %+v", c)
+				}
+				if err := c.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "protocol",
+			yaml: "protocol:\n  min_peer_version: 2\n  upgrade_hint: https://example.org/releases\n",
+			check: func(t *testing.T, cfg *Config) {
+				if c := cfg.Protocol; c.MinPeerVersion != 2 || c.UpgradeHint != "https://example.org/releases" {
+					t.Errorf("Protocol = %+v", c)
+				}
+				if err := cfg.Protocol.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "cold_mode",
+			yaml: "cold_mode: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.ColdMode {
+					t.Error("ColdMode = false, want true")
+				}
+			},
+		},
+		{
+			name: "privacy",
+			yaml: "privacy:\n  address_reuse: enforce\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.Privacy.Enforce() {
+					t.Errorf("Privacy = %+v, want enforce", cfg.Privacy)
+				}
+				if err := cfg.Privacy.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "reannounce",
+			yaml: "reannounce:\n  enabled: false\n  keep_stale: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Reannounce.Enabled || !cfg.Reannounce.KeepStale || cfg.Reannounce.MaxAttempts != 10 {
+					t.Errorf("Reannounce = %+v", cfg.Reannounce)
+				}
+			},
+		},
+		{
+			name: "wrapped_assets",
+			yaml: "wrapped_assets:\n  reject_unfavorable: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.WrappedAssets.RejectUnfavorable || cfg.WrappedAssets.MaxDiscountBps != 50 {
+					t.Errorf("WrappedAssets = %+v", cfg.WrappedAssets)
+				}
+			},
+		},
+		{
+			name: "fee_negotiation",
+			yaml: "fee_negotiation:\n  enabled: false\n  max_adjustment_bps: 200\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.FeeNegotiation.Enabled || cfg.FeeNegotiation.MaxAdjustmentBps != 200 || cfg.FeeNegotiation.ToleranceBps != 10 {
+					t.Errorf("FeeNegotiation = %+v", cfg.FeeNegotiation)
+				}
+			},
+		},
+		{
+			name: "aggregation",
+			yaml: "aggregation:\n  accept: false\n  window: 10s\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Aggregation.Accept || cfg.Aggregation.Window != 10*time.Second || cfg.Aggregation.MaxTrades != 20 {
+					t.Errorf("Aggregation = %+v", cfg.Aggregation)
+				}
+			},
+		},
+		{
+			name: "startup_checks",
+			yaml: "startup_checks:\n  enabled: false\n  on_failure: cold\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.StartupChecks.Enabled || cfg.StartupChecks.OnFailure != StartupFailureCold || cfg.StartupChecks.Timeout != 10*time.Second {
+					t.Errorf("StartupChecks = %+v", cfg.StartupChecks)
+				}
+			},
+		},
+		{
+			name: "finality",
+			yaml: "finality:\n  depths:\n    LTC: 3\n    ETH: 12\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Finality.Depths["LTC"] != 3 || cfg.Finality.Depths["ETH"] != 12 {
+					t.Errorf("Finality = %+v", cfg.Finality)
+				}
+				if err := cfg.Finality.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "key_hygiene",
+			yaml: "key_hygiene:\n  mlock: true\n",
+			check: func(t *testing.T, cfg *Config) {
+				if !cfg.KeyHygiene.Mlock || cfg.KeyHygiene.AuditInterval != 10*time.Minute {
+					t.Errorf("KeyHygiene = %+v", cfg.KeyHygiene)
+				}
+			},
+		},
+		{
+			name: "precision",
+			yaml: "precision:\n  quote_decimals:\n    ETH: 6\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Precision.QuoteDecimals["ETH"] != 6 {
+					t.Errorf("Precision = %+v", cfg.Precision)
+				}
+				if err := cfg.Precision.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "rendezvous",
+			yaml: "rendezvous:\n  points:\n    - " + testRendezvousPoint + "\n  groups:\n    - name: otc\n      key: " + testRendezvousKey + "\n",
+			check: func(t *testing.T, cfg *Config) {
+				if len(cfg.Rendezvous.Groups) != 1 || cfg.Rendezvous.Groups[0].Key != testRendezvousKey || cfg.Rendezvous.TTL != 2*time.Hour {
+					t.Errorf("Rendezvous = %+v", cfg.Rendezvous)
+				}
+				if err := cfg.Rendezvous.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "replica",
+			yaml: "replica:\n  enabled: true\n  primary_data_dir: /srv/klingon\n",
+			check: func(t *testing.T, cfg *Config) {
+				if r := cfg.Replica; !r.Enabled || r.PrimaryDataDir != "/srv/klingon" || r.PollInterval != 2*time.Second {
+					t.Errorf("Replica = %+v", r)
+				}
+				if err := cfg.Replica.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "backend_plugins",
+			yaml: "backend_plugins:\n  dir: " + pluginDir + "\n  call_timeout: 5s\n",
+			check: func(t *testing.T, cfg *Config) {
+				if p := cfg.BackendPlugins; p.Dir != pluginDir || p.CallTimeout != 5*time.Second || p.StartTimeout != 10*time.Second {
+					t.Errorf("BackendPlugins = %+v", p)
+				}
+				if err := cfg.BackendPlugins.Validate(); err != nil {
+					t.Errorf("Validate() error = %v", err)
+				}
+			},
+		},
+		{
+			name: "monero",
+			yaml: "monero:\n  enabled: true\n  url: http://127.0.0.1:18083\n  user: klingdex\n  wallet_file: main\n",
+			check: func(t *testing.T, cfg *Config) {
+				if err := cfg.Monero.Validate(); err != nil || cfg.Monero.User != "klingdex" || cfg.Monero.WalletFile != "main" {
+					t.Errorf("loaded monero = %+v, Validate() error = %v", cfg.Monero, err)
+				}
+			},
+		},
+		{
+			name: "refund_watcher",
+			yaml: "refund_watcher:\n  enabled: false\n  margin_blocks: 3\n",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.RefundWatcher.Enabled || cfg.RefundWatcher.MarginBlocks != 3 || cfg.RefundWatcher.CheckInterval != time.Minute {
+					t.Errorf("RefundWatcher = %+v, want disabled with 3 margin blocks", cfg.RefundWatcher)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(tmpDir, ConfigFileName), []byte(tt.yaml), 0600); err != nil {
+				t.Fatal(err)
+			}
+			cfg, err := LoadConfig(tmpDir)
+			if err != nil {
+				t.Fatalf("LoadConfig() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestAutoClaimConfig(t *testing.T) {
+	defaults := DefaultConfig().AutoClaim
+	if defaults.Mode != swap.AutoClaimImmediate || defaults.CheckInterval <= 0 {
+		t.Errorf("default auto-claim = %+v, want immediate with a check interval", defaults)
+	}
+	if err := defaults.Validate(); err != nil {
+		t.Errorf("default auto-claim invalid: %v", err)
+	}
+}
+
+func TestZeroConfConfig(t *testing.T) {
+	defaults := DefaultConfig().ZeroConf
+	if defaults.Enabled || defaults.MonitorWindow <= 0 || defaults.MinCompletedTrades == 0 {
+		t.Errorf("default zero-conf = %+v, want disabled with a window and history requirement", defaults)
+	}
+}
+
+func TestFundingVarianceConfig(t *testing.T) {
+	defaults := DefaultConfig().FundingVariance
+	if !defaults.Enabled || defaults.ToleranceBps == 0 || defaults.TopUpBps <= defaults.ToleranceBps {
+		t.Errorf("default funding variance = %+v, want enabled with a top-up band", defaults)
+	}
+	if err := defaults.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+}
+
+func TestAffordabilityConfig(t *testing.T) {
+	defaults := DefaultConfig().Affordability
+	if !defaults.Enabled || defaults.MinConfirmations != 1 {
+		t.Errorf("default affordability = %+v, want enabled with 1 confirmation", defaults)
+	}
+}
+
+func TestContractPauseConfig(t *testing.T) {
+	defaults := DefaultConfig().ContractPause
+	if err := defaults.Validate(); err != nil || !defaults.Enabled {
+		t.Errorf("default contract pause = %+v, %v, want enabled and valid", defaults, err)
+	}
+}
+
+func TestOperationTimeoutsConfig(t *testing.T) {
+	defaults := DefaultConfig().OperationTimeouts
+	if err := defaults.Validate(); err != nil || defaults.Broadcast <= 0 || defaults.ChainQuery <= 0 || defaults.SecretWait <= 0 {
+		t.Errorf("default operation timeouts = %+v, %v", defaults, err)
+	}
+}
+
+func TestWebSocketConfig(t *testing.T) {
+	defaults := DefaultConfig().API.WebSocket
+	if err := defaults.Validate(); err != nil || !defaults.Compression || defaults.SlowClient != WSSlowClientDropOldest {
+		t.Errorf("default websocket = %+v, %v", defaults, err)
+	}
+
+	ws := defaults
+	ws.SlowClient = "block"
+	if err := ws.Validate(); err == nil {
+		t.Error("Validate() accepted an unknown slow_client policy")
+	}
+	ws = defaults
+	ws.QueueSize = 0
+	if err := ws.Validate(); err == nil {
+		t.Error("Validate() accepted a zero queue size")
+	}
+	ws = defaults
+	ws.LogRate = 0
+	if err := ws.Validate(); err == nil {
+		t.Error("Validate() accepted a zero log rate")
+	}
+}
+
+func TestEventLogConfig(t *testing.T) {
+	defaults := DefaultConfig().EventLog
+	if !defaults.Enabled || defaults.MaxAge <= 0 || defaults.MaxEvents <= 0 {
+		t.Errorf("default event log = %+v, want enabled with bounded retention", defaults)
+	}
+}
+
+func TestDeadlinesConfig(t *testing.T) {
+	if got := DefaultConfig().Deadlines.UpdateInterval; got != time.Minute {
+		t.Errorf("default deadlines update interval = %v, want 1m", got)
+	}
+}
+
+func TestRefundWatcherConfig(t *testing.T) {
+	if got := DefaultConfig().RefundWatcher; !got.Enabled || got.CheckInterval != time.Minute {
+		t.Errorf("default refund watcher = %+v, want enabled every 1m", got)
+	}
+}
+
+func TestPeerQualityConfig(t *testing.T) {
+	if got := DefaultConfig().PeerQuality.ProbeInterval; got != time.Minute {
+		t.Errorf("default probe interval = %v, want 1m", got)
+	}
+}
+
+func TestComplianceConfig(t *testing.T) {
+	defaults := DefaultConfig().Compliance
+	if defaults.Enabled || defaults.RetryInterval <= 0 || defaults.Timeout <= 0 {
+		t.Errorf("default compliance = %+v, want disabled with retry interval and timeout", defaults)
+	}
+}
+
+func TestHistorySyncConfig(t *testing.T) {
+	defaults := DefaultConfig().HistorySync
+	if len(defaults.Peers) != 0 || defaults.Interval <= 0 {
+		t.Errorf("default history sync = %+v, want no peers with an interval", defaults)
+	}
+}
+
+func TestOrderPoWConfig(t *testing.T) {
+	defaults := DefaultConfig().OrderPoW
+	if defaults.Bits == 0 || defaults.ExemptCompletedTrades <= 0 {
+		t.Errorf("default order PoW = %+v, want a difficulty and an exemption", defaults)
+	}
+}
+
+func TestOrderSchemaConfig(t *testing.T) {
+	if DefaultConfig().OrderSchema.LegacyUntil.IsZero() {
+		t.Error("default order schema has no deprecation date for the unversioned format")
+	}
+}
+
+func TestTelemetryConfig(t *testing.T) {
+	defaults := DefaultConfig().Telemetry
+	if defaults.Enabled || defaults.Interval <= 0 || defaults.MinPairSwaps <= 0 {
+		t.Errorf("default telemetry = %+v, want disabled with an interval and pair threshold", defaults)
+	}
+}
+
+func TestMetaClaimConfig(t *testing.T) {
+	if DefaultConfig().MetaClaim.ValidFor <= 0 {
+		t.Error("default meta-claim authorizations have no validity")
+	}
+}
+
+func TestArchiveConfig(t *testing.T) {
+	defaults := DefaultConfig().Archive
+	if defaults.Enabled || defaults.Retention <= 0 || defaults.Interval <= 0 || defaults.MaxPerFile <= 0 {
+		t.Errorf("default archive = %+v, want disabled with retention, interval and file size", defaults)
+	}
+}
+
+func TestClockConfig(t *testing.T) {
+	defaults := DefaultConfig().Clock
+	if len(defaults.NTPServers) == 0 || defaults.MaxSkew <= 0 || defaults.CheckInterval <= 0 || defaults.RefuseTrades {
+		t.Errorf("default clock = %+v, want NTP servers and skew limit, trades not refused", defaults)
+	}
+}
+
+func TestAPIAuthConfig(t *testing.T) {
+	if cfg := DefaultConfig().API; len(cfg.Keys) != 0 || cfg.Dashboard {
+		t.Errorf("default API = %+v, want no keys and no dashboard", cfg)
+	}
+}
+
+func TestSlowCallConfig(t *testing.T) {
+	if got := DefaultConfig().API.SlowCallThreshold; got != 2*time.Second {
+		t.Errorf("default slow_call_threshold = %v, want 2s", got)
+	}
+}
+
+func TestPublicAPIConfig(t *testing.T) {
+	if DefaultConfig().API.Public {
+		t.Error("public API mode on by default")
+	}
+}
+
+func TestNostrConfig(t *testing.T) {
+	defaults := DefaultConfig().Nostr
+	if defaults.Enabled || defaults.Lookback <= 0 || defaults.ReconnectInterval <= 0 {
+		t.Errorf("default nostr = %+v, want disabled with a lookback and reconnect interval", defaults)
+	}
+}
+
+func TestSnapshotConfig(t *testing.T) {
+	defaults := DefaultConfig().Snapshots
+	if defaults.Enabled || defaults.Interval <= 0 {
+		t.Errorf("default snapshots = %+v, want disabled with an interval", defaults)
+	}
+}
+
+func TestConsolidationConfig(t *testing.T) {
+	defaults := DefaultConfig().Consolidation
+	if defaults.Enabled || defaults.Interval <= 0 || defaults.MaxFeeRate == 0 || defaults.MinUTXOs <= 0 || defaults.MaxInputs <= 0 {
+		t.Errorf("default consolidation = %+v, want disabled with thresholds", defaults)
+	}
+}
+
+func TestTracingConfig(t *testing.T) {
+	defaults := DefaultConfig().Tracing
+	if defaults.Enabled || defaults.Endpoint == "" || defaults.SamplePercent != 100 {
+		t.Errorf("default tracing = %+v, want disabled with an endpoint", defaults)
+	}
+}
+
+func TestLightningConfig(t *testing.T) {
+	defaults := DefaultConfig().Lightning
+	if defaults.Enabled || defaults.Implementation != "lnd" || defaults.MaxFeeBps != 50 || defaults.InvoiceExpiry != time.Hour {
+		t.Errorf("default lightning = %+v, want disabled lnd", defaults)
+	}
+}
+
+func TestConfirmationConfig(t *testing.T) {
+	defaults := DefaultConfig().Confirmation
+	if defaults.Mode != swap.ConfirmAuto || defaults.Timeout != 10*time.Minute {
+		t.Errorf("default confirmation = %+v, want auto, 10m", defaults)
+	}
+}
+
+func TestProtocolConfig(t *testing.T) {
+	if min := DefaultConfig().Protocol.MinPeerVersion; min != 0 {
+		t.Errorf("default min_peer_version = %d, want 0 (any)", min)
+	}
+}
+
+func TestColdModeConfig(t *testing.T) {
+	if DefaultConfig().ColdMode {
+		t.Error("cold mode on by default")
+	}
+}
+
+func TestPrivacyConfig(t *testing.T) {
+	def := DefaultConfig().Privacy
+	if def.AddressReuse != AddressReuseWarn || def.Enforce() {
+		t.Errorf("default privacy = %+v, want warn", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&PrivacyConfig{AddressReuse: "strict"}).Validate(); err == nil {
+		t.Error("Validate() accepted an unknown mode")
+	}
+}
+
+func TestReannounceConfig(t *testing.T) {
+	def := DefaultConfig().Reannounce
+	if !def.Enabled || def.KeepStale {
+		t.Errorf("default reannounce = %+v, want enabled and cancelling stale orders", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&ReannounceConfig{Enabled: true, RetryInterval: time.Second}).Validate(); err == nil {
+		t.Error("Validate() accepted zero max_attempts")
+	}
+	if err := (&ReannounceConfig{}).Validate(); err != nil {
+		t.Errorf("disabled Validate() error = %v", err)
+	}
+}
+
+func TestWrappedAssetsConfig(t *testing.T) {
+	def := DefaultConfig().WrappedAssets
+	if def.RejectUnfavorable || def.MaxDiscountBps != 50 {
+		t.Errorf("default wrapped_assets = %+v, want flag only with 50 bps", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&WrappedAssetsConfig{MaxDiscountBps: 10000}).Validate(); err == nil {
+		t.Error("Validate() accepted a 100% discount")
+	}
+}
+
+func TestFeeNegotiationConfig(t *testing.T) {
+	def := DefaultConfig().FeeNegotiation
+	if !def.Enabled || def.MaxAdjustmentBps != 100 || def.ToleranceBps != 10 {
+		t.Errorf("default fee_negotiation = %+v", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&FeeNegotiationConfig{MaxAdjustmentBps: 5000}).Validate(); err == nil {
+		t.Error("Validate() accepted an adjustment above the protocol bound")
+	}
+	if err := (&FeeNegotiationConfig{MaxAdjustmentBps: 10, ToleranceBps: 20}).Validate(); err == nil {
+		t.Error("Validate() accepted a tolerance above the bound")
+	}
+}
+
+func TestAggregationConfig(t *testing.T) {
+	def := DefaultConfig().Aggregation
+	if !def.Accept || def.Window != 30*time.Second || def.MaxTrades != 20 {
+		t.Errorf("default aggregation = %+v", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&AggregationConfig{MaxTrades: 5}).Validate(); err == nil {
+		t.Error("Validate() accepted a zero window")
+	}
+	if err := (&AggregationConfig{Window: time.Second, MaxTrades: 1}).Validate(); err == nil {
+		t.Error("Validate() accepted a single-trade aggregate")
+	}
+}
+
+func TestStartupChecksConfig(t *testing.T) {
+	def := DefaultConfig().StartupChecks
+	if !def.Enabled || def.OnFailure != StartupFailureWarn || def.Timeout != 10*time.Second {
+		t.Errorf("default startup checks = %+v", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&StartupChecksConfig{OnFailure: "panic", Timeout: time.Second}).Validate(); err == nil {
+		t.Error("Validate() accepted an unknown failure policy")
+	}
+	if err := (&StartupChecksConfig{OnFailure: StartupFailureExit}).Validate(); err == nil {
+		t.Error("Validate() accepted a zero timeout")
+	}
+}
+
+func TestFinalityConfig(t *testing.T) {
+	def := DefaultConfig().Finality
+	if len(def.Depths) != 0 {
+		t.Errorf("default finality depths = %v, want chain defaults", def.Depths)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&FinalityConfig{Depths: map[string]uint32{"FOO": 3}}).Validate(); err == nil {
+		t.Error("Validate() accepted an unsupported chain")
+	}
+	if err := (&FinalityConfig{Depths: map[string]uint32{"LTC": 0}}).Validate(); err == nil {
+		t.Error("Validate() accepted a zero depth")
+	}
+}
+
+func TestKeyHygieneConfig(t *testing.T) {
+	def := DefaultConfig().KeyHygiene
+	if def.Mlock || def.AuditInterval <= 0 {
+		t.Errorf("default key hygiene = %+v, want no mlock with an audit interval", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&KeyHygieneConfig{AuditInterval: -time.Minute}).Validate(); err == nil {
+		t.Error("Validate() accepted a negative audit interval")
+	}
+}
+
+func TestPrecisionConfig(t *testing.T) {
+	def := DefaultConfig().Precision
+	if len(def.QuoteDecimals) != 0 {
+		t.Errorf("default quote decimals = %v, want asset defaults", def.QuoteDecimals)
+	}
+	if err := (&PrecisionConfig{QuoteDecimals: map[string]uint8{"FOO": 6}}).Validate(); err == nil {
+		t.Error("Validate() accepted an unsupported chain")
+	}
+	for _, decimals := range []uint8{0, 9} {
+		if err := (&PrecisionConfig{QuoteDecimals: map[string]uint8{"ETH": decimals}}).Validate(); err == nil {
+			t.Errorf("Validate() accepted %d quote decimals", decimals)
+		}
+	}
+}
+
+// testRendezvousPoint and testRendezvousKey make a valid rendezvous config.
+const testRendezvousPoint = "/ip4/203.0.113.7/tcp/4001/p2p/12D3KooWQYhTNQdmr3ArTeUHRYzFg94BKyTkoWBDWez9kSCVe2Xo"
+
+var testRendezvousKey = strings.Repeat("ab", 32)
+
+func TestRendezvousConfig(t *testing.T) {
+	def := DefaultConfig().Rendezvous
+	if def.Enabled() {
+		t.Errorf("default rendezvous = %+v, want off", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+
+	valid := func() RendezvousConfig {
+		c := def
+		c.Points = []string{testRendezvousPoint}
+		c.Groups = []RendezvousGroup{{Name: "otc", Key: testRendezvousKey}}
+		return c
+	}
+	for name, mutate := range map[string]func(*RendezvousConfig){
+		"no points":      func(c *RendezvousConfig) { c.Points = nil },
+		"point no peer":  func(c *RendezvousConfig) { c.Points = []string{"/ip4/203.0.113.7/tcp/4001"} },
+		"short key":      func(c *RendezvousConfig) { c.Groups[0].Key = "abcd" },
+		"no name":        func(c *RendezvousConfig) { c.Groups[0].Name = "" },
+		"duplicate":      func(c *RendezvousConfig) { c.Groups = append(c.Groups, c.Groups[0]) },
+		"short ttl":      func(c *RendezvousConfig) { c.TTL = time.Second },
+		"no interval":    func(c *RendezvousConfig) { c.DiscoverInterval = 0 },
+		"serve no limit": func(c *RendezvousConfig) { c.Serve = true; c.MaxRegistrations = 0 },
+	} {
+		c := valid()
+		mutate(&c)
+		if err := c.Validate(); err == nil {
+			t.Errorf("Validate() accepted %s", name)
+		}
+	}
+}
+
+func TestReplicaConfig(t *testing.T) {
+	def := DefaultConfig().Replica
+	if def.Enabled || def.PollInterval <= 0 {
+		t.Errorf("default replica = %+v, want disabled with a poll interval", def)
+	}
+	if err := def.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&ReplicaConfig{Enabled: true, PollInterval: time.Second}).Validate(); err == nil {
+		t.Error("Validate() accepted a replica without a primary")
+	}
+	if err := (&ReplicaConfig{Enabled: true, PrimaryDataDir: "/data", PollInterval: 0}).Validate(); err == nil {
+		t.Error("Validate() accepted a zero poll interval")
+	}
+}
+
+func TestBackendPluginsConfig(t *testing.T) {
+	def := DefaultConfig().BackendPlugins
+	if def.Dir != "" || def.StartTimeout <= 0 || def.CallTimeout <= 0 {
+		t.Errorf("default backend plugins = %+v, want disabled with timeouts", def)
+	}
+}
+
+func TestMoneroConfig(t *testing.T) {
+	if err := DefaultConfig().Monero.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	if err := (&MoneroConfig{Enabled: true, URL: "http://127.0.0.1:18083"}).Validate(); err == nil {
+		t.Error("Validate() accepted a missing wallet_file")
+	}
+}
@@ -137,7 +137,7 @@ func (e *MessageEncryptor) Decrypt(envelope *EncryptedEnvelope) (*SwapMessage, e
 
 	// Unmarshal message
 	var msg SwapMessage
-	if err := json.Unmarshal(plaintext, &msg); err != nil {
+	if err := SwapMessageLimits.Unmarshal(plaintext, &msg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal message: %w", err)
 	}
 
@@ -0,0 +1,222 @@
+// Package node - Connectivity diagnostics of a single peer.
+//
+// DiagnosePeer answers "why can't I trade with this peer": it dials the
+// peer, waits for identify, lists the protocols both sides support, pings it
+// and opens (then resets) a stream on each protocol trading needs. Every
+// step is reported even when an earlier one fails, with a summary of the
+// problems found.
+package node
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/libp2p/go-libp2p/p2p/protocol/identify"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// diagnoseStepTimeout bounds each step of a diagnosis.
+const diagnoseStepTimeout = 15 * time.Second
+
+// ProtocolProbe is the result of opening a stream on one protocol.
+type ProtocolProbe struct {
+	Protocol   string `json:"protocol"`
+	Advertised bool   `json:"advertised"` // Listed by the peer's identify
+	OK         bool   `json:"ok"`
+	Millis     int64  `json:"ms"` // Time to negotiate the stream
+	Error      string `json:"error,omitempty"`
+}
+
+// PeerDiagnosis is the report of DiagnosePeer.
+type PeerDiagnosis struct {
+	PeerID           string           `json:"peer_id"`
+	Addrs            []string         `json:"addrs"` // Addresses dialed
+	Connected        bool             `json:"connected"`
+	AlreadyConnected bool             `json:"already_connected"`
+	DialMillis       int64            `json:"dial_ms"`
+	DialError        string           `json:"dial_error,omitempty"`
+	RemoteAddr       string           `json:"remote_addr,omitempty"` // Of the connection used
+	Relayed          bool             `json:"relayed"`               // Limited circuit relay connection
+	AgentVersion     string           `json:"agent_version,omitempty"`
+	ProtocolVersion  string           `json:"protocol_version,omitempty"`
+	IdentifyError    string           `json:"identify_error,omitempty"`
+	Protocols        []string         `json:"protocols"` // Supported by both sides
+	RTTMicros        int64            `json:"rtt_us,omitempty"`
+	PingError        string           `json:"ping_error,omitempty"`
+	Streams          []*ProtocolProbe `json:"streams"`
+	Problems         []string         `json:"problems"`
+}
+
+// ParsePeerTarget parses a peer ID or a multiaddr ending in /p2p/<id>.
+func ParsePeerTarget(target string) (peer.AddrInfo, error) {
+	if strings.HasPrefix(target, "/") {
+		ma, err := multiaddr.NewMultiaddr(target)
+		if err != nil {
+			return peer.AddrInfo{}, fmt.Errorf("invalid multiaddr: %w", err)
+		}
+		pi, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return peer.AddrInfo{}, fmt.Errorf("invalid peer addr info: %w", err)
+		}
+		return *pi, nil
+	}
+	id, err := peer.Decode(target)
+	if err != nil {
+		return peer.AddrInfo{}, fmt.Errorf("invalid peer ID: %w", err)
+	}
+	return peer.AddrInfo{ID: id}, nil
+}
+
+// DiagnosePeer dials a peer from h and probes the given protocols.
+func DiagnosePeer(ctx context.Context, h host.Host, pi peer.AddrInfo, protocols []protocol.ID) *PeerDiagnosis {
+	d := &PeerDiagnosis{PeerID: pi.ID.String(), Addrs: []string{}, Protocols: []string{}, Streams: []*ProtocolProbe{}, Problems: []string{}}
+	if pi.ID == h.ID() {
+		d.Problems = append(d.Problems, "target is this node")
+		return d
+	}
+
+	addrs := pi.Addrs
+	if len(addrs) == 0 {
+		addrs = h.Peerstore().Addrs(pi.ID)
+	}
+	for _, a := range addrs {
+		d.Addrs = append(d.Addrs, a.String())
+	}
+	d.AlreadyConnected = h.Network().Connectedness(pi.ID) == network.Connected
+
+	dialCtx, cancel := context.WithTimeout(ctx, diagnoseStepTimeout)
+	start := time.Now()
+	err := h.Connect(dialCtx, peer.AddrInfo{ID: pi.ID, Addrs: addrs})
+	d.DialMillis = time.Since(start).Milliseconds()
+	cancel()
+	if err != nil {
+		d.DialError = err.Error()
+		if len(addrs) == 0 {
+			d.Problems = append(d.Problems, "no known address for the peer")
+		}
+		d.Problems = append(d.Problems, "dial failed: "+err.Error())
+		return d
+	}
+	d.Connected = true
+
+	conns := h.Network().ConnsToPeer(pi.ID)
+	if len(conns) > 0 {
+		conn := conns[0]
+		d.RemoteAddr = conn.RemoteMultiaddr().String()
+		d.Relayed = conn.Stat().Limited
+		if d.Relayed {
+			d.Problems = append(d.Problems, "only a limited relay connection: direct dial failed (NAT or firewall)")
+		}
+		diagnoseIdentify(ctx, h, conn, d)
+	}
+
+	supported := make(map[protocol.ID]bool)
+	if theirs, err := h.Peerstore().GetProtocols(pi.ID); err == nil {
+		ours := make(map[protocol.ID]bool)
+		for _, p := range h.Mux().Protocols() {
+			ours[p] = true
+		}
+		for _, p := range theirs {
+			supported[p] = true
+			if ours[p] {
+				d.Protocols = append(d.Protocols, string(p))
+			}
+		}
+		sort.Strings(d.Protocols)
+	}
+
+	pingCtx, cancel := context.WithTimeout(ctx, diagnoseStepTimeout)
+	res := <-ping.Ping(pingCtx, h, pi.ID)
+	cancel()
+	if res.Error != nil {
+		d.PingError = res.Error.Error()
+	} else {
+		d.RTTMicros = res.RTT.Microseconds()
+	}
+
+	for _, proto := range protocols {
+		probe := probeProtocol(ctx, h, pi.ID, proto)
+		probe.Advertised = supported[proto]
+		if !probe.OK {
+			d.Problems = append(d.Problems, fmt.Sprintf("cannot open %s: %s", proto, probe.Error))
+		}
+		d.Streams = append(d.Streams, probe)
+	}
+	return d
+}
+
+// diagnoseIdentify waits for identify on conn and records the peer's agent.
+func diagnoseIdentify(ctx context.Context, h host.Host, conn network.Conn, d *PeerDiagnosis) {
+	ids, ok := h.(interface{ IDService() identify.IDService })
+	if !ok || ids.IDService() == nil {
+		d.IdentifyError = "identify not available"
+		return
+	}
+	select {
+	case <-ids.IDService().IdentifyWait(conn):
+	case <-time.After(diagnoseStepTimeout):
+		d.IdentifyError = "identify timed out"
+		d.Problems = append(d.Problems, "identify timed out")
+		return
+	case <-ctx.Done():
+		d.IdentifyError = ctx.Err().Error()
+		return
+	}
+
+	if v, err := h.Peerstore().Get(conn.RemotePeer(), "AgentVersion"); err == nil {
+		d.AgentVersion, _ = v.(string)
+	}
+	if v, err := h.Peerstore().Get(conn.RemotePeer(), "ProtocolVersion"); err == nil {
+		d.ProtocolVersion, _ = v.(string)
+	}
+}
+
+// probeProtocol opens a stream on proto and resets it without sending.
+func probeProtocol(ctx context.Context, h host.Host, p peer.ID, proto protocol.ID) *ProtocolProbe {
+	probe := &ProtocolProbe{Protocol: string(proto)}
+	streamCtx, cancel := context.WithTimeout(ctx, diagnoseStepTimeout)
+	defer cancel()
+
+	start := time.Now()
+	s, err := h.NewStream(network.WithAllowLimitedConn(streamCtx, "diagnose"), p, proto)
+	probe.Millis = time.Since(start).Milliseconds()
+	if err != nil {
+		probe.Error = err.Error()
+		return probe
+	}
+	s.Reset()
+	probe.OK = true
+	return probe
+}
+
+// Diagnose resolves a peer ID or multiaddr, looking the peer up in the DHT
+// when no address is known, and runs DiagnosePeer for protocols.
+func (n *Node) Diagnose(ctx context.Context, target string, protocols []protocol.ID) (*PeerDiagnosis, error) {
+	pi, err := ParsePeerTarget(target)
+	if err != nil {
+		return nil, err
+	}
+	if kad := n.DHT(); len(pi.Addrs) == 0 && len(n.host.Peerstore().Addrs(pi.ID)) == 0 && kad != nil {
+		findCtx, cancel := context.WithTimeout(ctx, diagnoseStepTimeout)
+		if found, err := kad.FindPeer(findCtx, pi.ID); err == nil {
+			pi.Addrs = found.Addrs
+		} else {
+			n.log.Debug("DHT lookup failed", "peer", shortID(pi.ID), "error", err)
+		}
+		cancel()
+	}
+
+	d := DiagnosePeer(ctx, n.host, pi, protocols)
+	if v, ok := n.Protocol().Version(pi.ID); ok && v < n.Protocol().MinPeerVersion() {
+		d.Problems = append(d.Problems, fmt.Sprintf("swap protocol version %d is below our minimum %d", v, n.Protocol().MinPeerVersion()))
+	}
+	return d, nil
+}
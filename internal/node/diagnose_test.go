@@ -0,0 +1,86 @@
+package node
+
+import (
+	"context"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+)
+
+func TestDiagnosePeer(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	us, them := hosts[0], hosts[1]
+
+	// The peer serves direct swap messages but not order sync
+	them.SetStreamHandler(SwapDirectProtocol, func(s network.Stream) { s.Reset() })
+	us.SetStreamHandler(SwapDirectProtocol, func(s network.Stream) { s.Reset() })
+	ping.NewPingService(them)
+
+	const orderSync = "/klingon/ordersync/1.0.0"
+	pi := peer.AddrInfo{ID: them.ID(), Addrs: them.Addrs()}
+	d := DiagnosePeer(context.Background(), us, pi, []protocol.ID{SwapDirectProtocol, orderSync})
+
+	if !d.Connected || d.DialError != "" || d.AlreadyConnected {
+		t.Fatalf("dial: %+v", d)
+	}
+	if d.PingError != "" {
+		t.Errorf("PingError = %s", d.PingError)
+	}
+	if len(d.Streams) != 2 || !d.Streams[0].OK || d.Streams[1].OK || d.Streams[1].Error == "" {
+		t.Errorf("streams = %+v %+v", d.Streams[0], d.Streams[1])
+	}
+	found := false
+	for _, p := range d.Protocols {
+		if p == string(SwapDirectProtocol) {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Protocols = %v, want %s in common", d.Protocols, SwapDirectProtocol)
+	}
+	if len(d.Problems) != 1 {
+		t.Errorf("Problems = %v, want the order sync failure", d.Problems)
+	}
+
+	// Unreachable peer
+	if err := mn.UnlinkPeers(us.ID(), them.ID()); err != nil {
+		t.Fatalf("UnlinkPeers() error = %v", err)
+	}
+	if err := mn.DisconnectPeers(us.ID(), them.ID()); err != nil {
+		t.Fatalf("DisconnectPeers() error = %v", err)
+	}
+	d = DiagnosePeer(context.Background(), us, pi, nil)
+	if d.Connected || d.DialError == "" || len(d.Problems) == 0 {
+		t.Errorf("unreachable: %+v", d)
+	}
+
+	if d = DiagnosePeer(context.Background(), us, peer.AddrInfo{ID: us.ID()}, nil); d.Connected || len(d.Problems) != 1 {
+		t.Errorf("self: %+v", d)
+	}
+}
+
+func TestParsePeerTarget(t *testing.T) {
+	const id = "12D3KooWEyoppNCUx8Yx66oV9fJnriXwCcXwDDUA2kj6vnc6iDEp"
+	pi, err := ParsePeerTarget(id)
+	if err != nil || pi.ID.String() != id || len(pi.Addrs) != 0 {
+		t.Errorf("ParsePeerTarget(id) = %+v, %v", pi, err)
+	}
+	pi, err = ParsePeerTarget("/ip4/1.2.3.4/tcp/4001/p2p/" + id)
+	if err != nil || pi.ID.String() != id || len(pi.Addrs) != 1 {
+		t.Errorf("ParsePeerTarget(multiaddr) = %+v, %v", pi, err)
+	}
+	for _, bad := range []string{"", "nope", "/ip4/1.2.3.4/tcp/4001"} {
+		if _, err := ParsePeerTarget(bad); err == nil {
+			t.Errorf("ParsePeerTarget(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package node
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/fuzz"
+)
+
+func FuzzReadLengthPrefixed(f *testing.F) {
+	var framed bytes.Buffer
+	writeLengthPrefixed(&framed, []byte(`{"type":"ack"}`))
+	f.Add(framed.Bytes())
+	f.Add([]byte{0, 0, 0})
+	f.Add([]byte{0xff, 0xff, 0xff, 0xff, 1})
+	f.Add([]byte{0, 0, 0, 10, 1, 2})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		msg, err := readLengthPrefixed(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if len(msg) > maxMessageSize || len(data) < 4 || int(binary.BigEndian.Uint32(data)) != len(msg) {
+			t.Fatalf("read %d bytes from frame %x", len(msg), data[:4])
+		}
+	})
+}
+
+// fuzzPayloads maps message types to the payload they carry.
+func fuzzPayloads() map[string]func() interface{} {
+	return map[string]func() interface{}{
+		SwapMsgAck:              func() interface{} { return &AckPayload{} },
+		SwapMsgPubKeyExchange:   func() interface{} { return &PubKeyExchangePayload{} },
+		SwapMsgNonceExchange:    func() interface{} { return &NonceExchangePayload{} },
+		SwapMsgFundingInfo:      func() interface{} { return &FundingInfoPayload{} },
+		SwapMsgPartialSig:       func() interface{} { return &PartialSigPayload{} },
+		SwapMsgHTLCSecretHash:   func() interface{} { return &HTLCSecretHashPayload{} },
+		SwapMsgHTLCSecretReveal: func() interface{} { return &HTLCSecretRevealPayload{} },
+		SwapMsgHTLCClaim:        func() interface{} { return &HTLCClaimPayload{} },
+		SwapMsgEVMFundingInfo:   func() interface{} { return &EVMFundingInfoPayload{} },
+		SwapMsgEVMClaimed:       func() interface{} { return &EVMClaimPayload{} },
+		SwapMsgEVMRefunded:      func() interface{} { return &EVMRefundPayload{} },
+	}
+}
+
+func FuzzSwapMessage(f *testing.F) {
+	for _, seed := range []*SwapMessage{
+		mustSwapMessage(NewSwapMessage(SwapMsgNonceExchange, "trade-1", &NonceExchangePayload{OfferNonce: "00", RequestNonce: "11"})),
+		mustSwapMessage(NewHTLCSecretRevealMessage("trade-1", "abcd")),
+		mustSwapMessage(NewHTLCClaimMessage("trade-1", "BTC", "txid", "")),
+		{Type: SwapMsgPartialSig, Payload: []byte(`{"offer_partial_sig":1}`)},
+		{Type: SwapMsgAck, Payload: []byte(`null`)},
+	} {
+		for _, data := range fuzz.JSON(seed) {
+			f.Add(data)
+		}
+	}
+
+	payloads := fuzzPayloads()
+	f.Fuzz(func(t *testing.T, data []byte) {
+		var msg SwapMessage
+		if err := json.Unmarshal(data, &msg); err != nil {
+			return
+		}
+		if newPayload, ok := payloads[msg.Type]; ok && len(msg.Payload) > 0 {
+			json.Unmarshal(msg.Payload, newPayload())
+		}
+
+		// Decoded messages re-encode to an equivalent message
+		encoded, err := json.Marshal(&msg)
+		if err != nil {
+			t.Fatalf("Marshal() error = %v", err)
+		}
+		var again SwapMessage
+		if err := json.Unmarshal(encoded, &again); err != nil {
+			t.Fatalf("re-decoding %s: %v", encoded, err)
+		}
+		if again.Type != msg.Type || again.TradeID != msg.TradeID || again.MessageID != msg.MessageID {
+			t.Fatalf("round trip changed message: %+v != %+v", again, msg)
+		}
+	})
+}
+
+func mustSwapMessage(msg *SwapMessage, err error) *SwapMessage {
+	if err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+func FuzzDecryptEnvelope(f *testing.F) {
+	senderPriv, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		f.Fatal(err)
+	}
+	recipientPriv, recipientPub, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		f.Fatal(err)
+	}
+	recipientID, err := peer.IDFromPublicKey(recipientPub)
+	if err != nil {
+		f.Fatal(err)
+	}
+	sender, err := NewMessageEncryptor(senderPriv, recipientID)
+	if err != nil {
+		f.Fatal(err)
+	}
+	recipient, err := NewMessageEncryptor(recipientPriv, recipientID)
+	if err != nil {
+		f.Fatal(err)
+	}
+
+	valid, err := sender.Encrypt(recipientID, &SwapMessage{Type: SwapMsgAck, TradeID: "trade-1"})
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, key := range fuzz.Bytes(valid.EphemeralPubKey) {
+		f.Add(key, valid.Nonce, valid.Ciphertext)
+	}
+	for _, nonce := range fuzz.Bytes(valid.Nonce) {
+		f.Add(valid.EphemeralPubKey, nonce, valid.Ciphertext)
+	}
+	for _, ciphertext := range fuzz.Bytes(valid.Ciphertext) {
+		f.Add(valid.EphemeralPubKey, valid.Nonce, ciphertext)
+	}
+
+	f.Fuzz(func(t *testing.T, ephemeralKey, nonce, ciphertext []byte) {
+		envelope := &EncryptedEnvelope{
+			RecipientPeerID: recipientID.String(),
+			SenderPeerID:    "x",
+			EphemeralPubKey: ephemeralKey,
+			Nonce:           nonce,
+			Ciphertext:      ciphertext,
+		}
+		msg, err := recipient.Decrypt(envelope)
+		if err != nil {
+			return
+		}
+		if msg == nil {
+			t.Fatal("Decrypt() returned nil message without error")
+		}
+	})
+}
+
+func TestShortPeerIDString(t *testing.T) {
+	if got := shortPeerIDString("12D3KooWAbcdefghijk"); got != "12D3KooWAbcd" {
+		t.Errorf("shortPeerIDString(long) = %q", got)
+	}
+	if got := shortPeerIDString("x"); got != "x" {
+		t.Errorf("shortPeerIDString(short) = %q", got)
+	}
+}
@@ -0,0 +1,163 @@
+// Package node - Size and shape limits of P2P protocol messages.
+//
+// Every message read from a peer is bounded before it is decoded. Its size
+// is checked while it is read, so an oversized message is refused after at
+// most its limit plus one byte instead of being buffered whole. A scan of
+// its JSON tokens then bounds the nesting depth and the items of any array
+// or object, so a message within its size can't expand into a huge number
+// of allocations (e.g. a sync page of millions of empty orders). Only then
+// is it unmarshaled.
+package node
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrMessageTooLarge is returned for messages over their size limit.
+var ErrMessageTooLarge = errors.New("message too large")
+
+// ErrMessageTooComplex is returned for messages nested too deep or with
+// too many items.
+var ErrMessageTooComplex = errors.New("message too complex")
+
+// MessageLimits bound one protocol message.
+type MessageLimits struct {
+	MaxBytes    int64 // Encoded size
+	MaxDepth    int   // Nesting of objects and arrays
+	MaxElements int   // Items of one array, fields of one object
+}
+
+// Limits of the P2P protocols.
+var (
+	// SwapMessageLimits bound swap messages (gossiped, encrypted or direct),
+	// their ACKs and encrypted envelopes.
+	SwapMessageLimits = MessageLimits{MaxBytes: maxMessageSize, MaxDepth: 16, MaxElements: 1024}
+
+	// SyncRequestLimits bound order, trade and history sync requests.
+	SyncRequestLimits = MessageLimits{MaxBytes: 4 * 1024, MaxDepth: 2, MaxElements: 16}
+
+	// SyncResponseLimits bound one page of order, trade or history sync
+	// (at most a few hundred records).
+	SyncResponseLimits = MessageLimits{MaxBytes: 8 * 1024 * 1024, MaxDepth: 16, MaxElements: 512}
+
+	// SyncReconcileLimits bound the ranges and wanted orders a peer sends
+	// when reconciling its order book with ours.
+	SyncReconcileLimits = MessageLimits{MaxBytes: 256 * 1024, MaxDepth: 5, MaxElements: 128}
+
+	// RendezvousLimits bound rendezvous requests and responses (at most
+	// maxRendezvousDiscover records).
+	RendezvousLimits = MessageLimits{MaxBytes: 512 * 1024, MaxDepth: 5, MaxElements: 128}
+)
+
+// Unmarshal checks data against the limits and decodes it into v.
+func (l MessageLimits) Unmarshal(data []byte, v interface{}) error {
+	if err := l.Check(data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Check scans data's JSON tokens against the limits without decoding it.
+func (l MessageLimits) Check(data []byte) error {
+	if l.MaxBytes > 0 && int64(len(data)) > l.MaxBytes {
+		return fmt.Errorf("%w: %d > %d bytes", ErrMessageTooLarge, len(data), l.MaxBytes)
+	}
+
+	type container struct {
+		object bool
+		items  int // Tokens: keys and values count separately in objects
+	}
+	var stack []container
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			if len(stack) > 0 {
+				return io.ErrUnexpectedEOF
+			}
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if tok == json.Delim('}') || tok == json.Delim(']') {
+			stack = stack[:len(stack)-1]
+			continue
+		}
+
+		if len(stack) > 0 {
+			top := &stack[len(stack)-1]
+			top.items++
+			limit := l.MaxElements
+			if top.object {
+				limit *= 2
+			}
+			if l.MaxElements > 0 && top.items > limit {
+				return fmt.Errorf("%w: more than %d items", ErrMessageTooComplex, l.MaxElements)
+			}
+		}
+		if tok == json.Delim('{') || tok == json.Delim('[') {
+			if l.MaxDepth > 0 && len(stack) >= l.MaxDepth {
+				return fmt.Errorf("%w: nested deeper than %d", ErrMessageTooComplex, l.MaxDepth)
+			}
+			stack = append(stack, container{object: tok == json.Delim('{')})
+		}
+	}
+}
+
+// Decode reads one JSON value from a stream, refusing it as soon as it
+// exceeds MaxBytes, and decodes it into v.
+func (l MessageLimits) Decode(r io.Reader, v interface{}) error {
+	var raw json.RawMessage
+	if err := json.NewDecoder(&limitedReader{r: r, max: l.MaxBytes, left: l.MaxBytes}).Decode(&raw); err != nil {
+		return err
+	}
+	return l.Unmarshal(raw, v)
+}
+
+// ReadLine reads a newline-terminated line of at most MaxBytes.
+func (l MessageLimits) ReadLine(r *bufio.Reader) ([]byte, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if int64(len(line)) > l.MaxBytes {
+			return nil, fmt.Errorf("%w: over %d bytes", ErrMessageTooLarge, l.MaxBytes)
+		}
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// limitedReader reads at most max bytes, then fails with ErrMessageTooLarge
+// if the stream has more.
+type limitedReader struct {
+	r    io.Reader
+	max  int64
+	left int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	if l.left <= 0 {
+		var probe [1]byte
+		n, err := l.r.Read(probe[:])
+		if n > 0 {
+			return 0, fmt.Errorf("%w: over %d bytes", ErrMessageTooLarge, l.max)
+		}
+		return 0, err
+	}
+	if int64(len(p)) > l.left {
+		p = p[:l.left]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	return n, err
+}
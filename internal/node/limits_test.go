@@ -0,0 +1,103 @@
+package node
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestMessageLimitsCheck(t *testing.T) {
+	limits := MessageLimits{MaxBytes: 64, MaxDepth: 2, MaxElements: 3}
+
+	tests := []struct {
+		name string
+		data string
+		want error
+	}{
+		{"within", `{"a":[1,2,3],"b":"x","c":{}}`, nil},
+		{"too large", `"` + strings.Repeat("x", 64) + `"`, ErrMessageTooLarge},
+		{"too deep", `{"a":{"b":{}}}`, ErrMessageTooComplex},
+		{"array too long", `[1,2,3,4]`, ErrMessageTooComplex},
+		{"too many fields", `{"a":1,"b":2,"c":3,"d":4}`, ErrMessageTooComplex},
+		{"nested array too long", `{"a":[{},{},{},{}]}`, ErrMessageTooComplex},
+	}
+	for _, tt := range tests {
+		err := limits.Check([]byte(tt.data))
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: Check() error = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	if err := limits.Check([]byte(`{"a":`)); err == nil {
+		t.Error("Check() accepted truncated JSON")
+	}
+
+	var v struct{ A []int }
+	if err := limits.Unmarshal([]byte(`{"A":[1,2,3,4]}`), &v); !errors.Is(err, ErrMessageTooComplex) || v.A != nil {
+		t.Errorf("Unmarshal() decoded a message over the limits: %v, %v", err, v.A)
+	}
+	if err := limits.Unmarshal([]byte(`{"A":[1,2]}`), &v); err != nil || len(v.A) != 2 {
+		t.Errorf("Unmarshal() = %v, %v", v.A, err)
+	}
+}
+
+// countingReader counts the bytes read from an endless message.
+type countingReader struct {
+	r    io.Reader
+	read int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.read += n
+	return n, err
+}
+
+// endless repeats a byte forever.
+type endless byte
+
+func (e endless) Read(p []byte) (int, error) {
+	for i := range p {
+		p[i] = byte(e)
+	}
+	return len(p), nil
+}
+
+func TestMessageLimitsDecode(t *testing.T) {
+	limits := MessageLimits{MaxBytes: 1024, MaxDepth: 4, MaxElements: 16}
+
+	// A multi-gigabyte blob is refused after the limit
+	r := &countingReader{r: io.MultiReader(strings.NewReader(`{"orders":"`), endless('x'))}
+	var v map[string]interface{}
+	if err := limits.Decode(r, &v); !errors.Is(err, ErrMessageTooLarge) {
+		t.Fatalf("Decode() error = %v, want ErrMessageTooLarge", err)
+	}
+	if r.read > 1025 {
+		t.Errorf("read %d bytes of an oversized message", r.read)
+	}
+
+	// A value of exactly the limit passes, whatever follows it
+	msg := `{"a":"` + strings.Repeat("x", 1024-8) + `"}`
+	if err := limits.Decode(strings.NewReader(msg+"\n"), &v); err != nil || len(v["a"].(string)) != 1016 {
+		t.Errorf("Decode() at the limit error = %v", err)
+	}
+	if err := limits.Decode(strings.NewReader(`[[[[[]]]]]`), &v); !errors.Is(err, ErrMessageTooComplex) {
+		t.Errorf("Decode() of a deep message error = %v", err)
+	}
+	if err := limits.Decode(strings.NewReader(""), &v); err != io.EOF {
+		t.Errorf("Decode() of an empty stream error = %v, want EOF", err)
+	}
+}
+
+func TestMessageLimitsReadLine(t *testing.T) {
+	limits := MessageLimits{MaxBytes: 8192}
+
+	line, err := limits.ReadLine(bufio.NewReader(strings.NewReader(strings.Repeat("x", 6000) + "\nrest")))
+	if err != nil || len(line) != 6001 {
+		t.Errorf("ReadLine() = %d bytes, %v", len(line), err)
+	}
+	if _, err := limits.ReadLine(bufio.NewReader(endless('x'))); !errors.Is(err, ErrMessageTooLarge) {
+		t.Errorf("ReadLine() of an endless line error = %v", err)
+	}
+}
@@ -1,5 +1,6 @@
 // Package node - Message sender with persistence and retry support.
-// Implements hybrid delivery: direct streams when connected, encrypted PubSub as fallback.
+// Implements hybrid delivery: direct streams when connected, then a circuit
+// relay, then encrypted PubSub as fallback.
 package node
 
 import (
@@ -9,11 +10,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/libp2p/go-libp2p/core/network"
 	"github.com/libp2p/go-libp2p/core/peer"
 
+	"github.com/Klingon-tech/klingdex/internal/chaos"
 	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // MessageSenderConfig configures the message sender behavior.
@@ -26,6 +30,8 @@ type MessageSenderConfig struct {
 	MaxRetries           int           // Maximum retry attempts before giving up (default: 50)
 	DHTLookupTimeout     time.Duration // Timeout for DHT peer lookup (default: 30s)
 	ConnectTimeout       time.Duration // Timeout for connecting to peer (default: 15s)
+	RelayFallback        bool          // Route through a circuit relay when the peer can't be reached directly (default: true)
+	MaxRelayCandidates   int           // Relays tried per delivery attempt (default: 3)
 }
 
 // DefaultMessageSenderConfig returns the default configuration.
@@ -39,6 +45,8 @@ func DefaultMessageSenderConfig() MessageSenderConfig {
 		MaxRetries:           50, // ~8 hours with exponential backoff to 10min
 		DHTLookupTimeout:     30 * time.Second,
 		ConnectTimeout:       15 * time.Second,
+		RelayFallback:        true,
+		MaxRelayCandidates:   3,
 	}
 }
 
@@ -50,6 +58,7 @@ type MessageSender struct {
 	streamHandler *StreamHandler
 	encryptor     *MessageEncryptor
 	config        MessageSenderConfig
+	paths         deliveryPaths
 	log           *logging.Logger
 }
 
@@ -91,6 +100,7 @@ func (s *MessageSender) SendDirect(ctx context.Context, peerID peer.ID, tradeID
 	msg.RequiresAck = true
 	msg.FromPeer = s.node.ID().String()
 	msg.Timestamp = time.Now().Unix()
+	msg.ProtocolVersion = ProtocolVersion
 
 	// Get next sequence number for this trade
 	seq, err := s.storage.GetNextLocalSequence(tradeID)
@@ -119,6 +129,7 @@ func (s *MessageSender) SendDirect(ctx context.Context, peerID peer.ID, tradeID
 	if err := s.storage.EnqueueMessage(outboxMsg); err != nil {
 		return fmt.Errorf("failed to persist message: %w", err)
 	}
+	chaos.CrashPoint(chaos.PointAfterMessageEnqueue)
 
 	s.log.Debug("Message enqueued",
 		"type", msg.Type,
@@ -137,8 +148,13 @@ func (s *MessageSender) SendDirect(ctx context.Context, peerID peer.ID, tradeID
 // Strategy:
 // 1. If not connected, try to find peer via DHT and connect
 // 2. If connected, try direct stream (fastest, most private)
-// 3. If direct fails, fallback to encrypted PubSub (guaranteed delivery through gossip)
+// 3. If that fails, connect through a circuit relay and retry the stream
+// 4. If that fails too, fallback to encrypted PubSub (guaranteed delivery through gossip)
 func (s *MessageSender) attemptDelivery(ctx context.Context, peerID peer.ID, msg *SwapMessage) {
+	ctx, span := tracing.StartTrade(ctx, "p2p", msg.TradeID, "p2p.deliver "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindProducer))
+	defer span.End()
+
 	// Check if swap has expired (minus buffer)
 	deadline := time.Unix(msg.SwapTimeout, 0).Add(-s.config.StopBeforeExpiry)
 	if time.Now().After(deadline) {
@@ -157,7 +173,7 @@ func (s *MessageSender) attemptDelivery(ctx context.Context, peerID peer.ID, msg
 	}
 
 	// Step 1: Try to establish connection if not connected
-	if s.node.Host().Network().Connectedness(peerID) != network.Connected {
+	if !s.isConnected(peerID) {
 		s.log.Debug("Peer not connected, attempting DHT lookup",
 			"peer", shortPeerID(peerID),
 			"message_id", msg.MessageID)
@@ -167,32 +183,27 @@ func (s *MessageSender) attemptDelivery(ctx context.Context, peerID peer.ID, msg
 		}
 	}
 
-	// Step 2: Try direct stream if now connected
-	if s.node.Host().Network().Connectedness(peerID) == network.Connected {
-		deliveryCtx, cancel := context.WithTimeout(ctx, s.config.AckTimeout)
-		err := s.streamHandler.SendDirectMessage(deliveryCtx, peerID, msg)
-		cancel()
-
-		if err == nil {
-			// Success via direct stream
-			if err := s.storage.MarkMessageAcked(msg.MessageID); err != nil {
-				s.log.Warn("Failed to mark message ACKed", "error", err)
-			}
-			s.log.Debug("Message delivered via direct stream",
-				"type", msg.Type,
-				"trade_id", msg.TradeID,
-				"message_id", msg.MessageID)
+	// Step 2: Try direct stream if now connected (directly or through a relay)
+	if s.isConnected(peerID) {
+		if s.deliverViaStream(ctx, peerID, msg) {
 			return
 		}
+	}
 
-		s.log.Debug("Direct stream failed, trying encrypted PubSub",
-			"peer", shortPeerID(peerID),
-			"error", err)
+	// Step 3: Route through a circuit relay when the peer can't be dialed
+	if s.config.RelayFallback && !s.isConnected(peerID) {
+		if relay, ok := s.tryConnectViaRelay(ctx, peerID); ok {
+			s.log.Debug("Connected to peer via relay", "peer", shortPeerID(peerID), "relay", shortPeerID(relay))
+			if s.deliverViaStream(ctx, peerID, msg) {
+				return
+			}
+		}
 	}
 
-	// Step 3: Fallback to encrypted PubSub
+	// Step 4: Fallback to encrypted PubSub
 	if s.encryptor != nil {
 		if s.sendViaEncryptedPubSub(ctx, peerID, msg) {
+			s.recordDeliveryPath(msg.TradeID, peerID, DeliveryPathPubSub, "")
 			// Message sent via PubSub, ACK will come back via PubSub too
 			s.log.Debug("Message sent via encrypted PubSub",
 				"type", msg.Type,
@@ -206,6 +217,7 @@ func (s *MessageSender) attemptDelivery(ctx context.Context, peerID peer.ID, msg
 	}
 
 	// All delivery methods failed, schedule retry
+	span.SetStatus(codes.Error, "all delivery methods failed")
 	s.log.Debug("All delivery methods failed, scheduling retry",
 		"peer", shortPeerID(peerID),
 		"message_id", msg.MessageID)
@@ -221,6 +233,32 @@ func (s *MessageSender) attemptDelivery(ctx context.Context, peerID peer.ID, msg
 	s.scheduleRetry(msg.MessageID, retryCount)
 }
 
+// deliverViaStream sends msg over a stream on the current connection to
+// peerID and marks it ACKed on success.
+func (s *MessageSender) deliverViaStream(ctx context.Context, peerID peer.ID, msg *SwapMessage) bool {
+	deliveryCtx, cancel := context.WithTimeout(ctx, s.config.AckTimeout)
+	err := s.streamHandler.SendDirectMessage(deliveryCtx, peerID, msg)
+	cancel()
+	if err != nil {
+		s.log.Debug("Direct stream failed",
+			"peer", shortPeerID(peerID),
+			"error", err)
+		return false
+	}
+
+	if err := s.storage.MarkMessageAcked(msg.MessageID); err != nil {
+		s.log.Warn("Failed to mark message ACKed", "error", err)
+	}
+	path, relay := s.connectionPath(peerID)
+	s.recordDeliveryPath(msg.TradeID, peerID, path, relay)
+	s.log.Debug("Message delivered via stream",
+		"type", msg.Type,
+		"trade_id", msg.TradeID,
+		"message_id", msg.MessageID,
+		"path", path)
+	return true
+}
+
 // tryConnectViaDHT attempts to find and connect to a peer using the DHT.
 func (s *MessageSender) tryConnectViaDHT(ctx context.Context, peerID peer.ID) bool {
 	dht := s.node.DHT()
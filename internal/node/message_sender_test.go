@@ -32,6 +32,10 @@ func TestDefaultMessageSenderConfig(t *testing.T) {
 	if cfg.MaxRetries != 50 {
 		t.Errorf("MaxRetries = %d, want %d", cfg.MaxRetries, 50)
 	}
+
+	if !cfg.RelayFallback || cfg.MaxRelayCandidates != 3 {
+		t.Errorf("RelayFallback = %v, MaxRelayCandidates = %d, want enabled with 3", cfg.RelayFallback, cfg.MaxRelayCandidates)
+	}
 }
 
 func TestMessageSenderConfigCustom(t *testing.T) {
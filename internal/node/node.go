@@ -7,6 +7,7 @@ import (
 	"os"
 	"path/filepath"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/libp2p/go-libp2p"
@@ -38,8 +39,9 @@ type Node struct {
 	log    *logging.Logger
 
 	// Discovery
-	mdnsService mdns.Service
-	routingDisc *drouting.RoutingDiscovery
+	mdnsService     mdns.Service
+	routingDisc     *drouting.RoutingDiscovery
+	discoveryCancel context.CancelFunc // Stops the DHT advertise and discovery loops
 
 	// Peer persistence
 	peerStoreAdapter *PeerStoreAdapter
@@ -52,6 +54,27 @@ type Node struct {
 	messageSender *MessageSender
 	retryWorker   *RetryWorker
 	peerMonitor   *PeerMonitor
+	peerQuality   *PeerQualityTracker
+
+	// Partition detection (degraded mode)
+	partition    *PartitionDetector
+	bootstrapIDs map[peer.ID]bool
+	dhtFailures  atomic.Int32 // Consecutive failed discovery queries
+	lastGossipAt atomic.Int64 // Unix nanos of the last gossip message from a peer
+
+	// Clock skew detection
+	clock *ClockMonitor
+
+	// Protocol versions of peers and the minimum we trade with
+	protocol *ProtocolTracker
+
+	// Private group discovery (nil: not configured)
+	rendezvous *RendezvousService
+
+	// Onion service (nil: not published)
+	torControl *torControl
+	onionAddr  atomic.Value // multiaddr.Multiaddr
+	torOnly    bool         // Fixed before the host is built
 
 	// State
 	ctx       context.Context
@@ -61,6 +84,7 @@ type Node struct {
 	// Callbacks
 	onPeerConnected    func(peer.ID)
 	onPeerDisconnected func(peer.ID)
+	onDeliveryPath     []func(DeliveryPathEvent)
 
 	mu sync.RWMutex
 }
@@ -70,11 +94,15 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 	ctx, cancel := context.WithCancel(ctx)
 
 	node := &Node{
-		config: cfg,
-		ctx:    ctx,
-		cancel: cancel,
-		log:    logging.GetDefault().Component("node"),
+		config:       cfg,
+		ctx:          ctx,
+		cancel:       cancel,
+		log:          logging.GetDefault().Component("node"),
+		bootstrapIDs: make(map[peer.ID]bool),
 	}
+	node.partition = NewPartitionDetector(cfg.Partition, node)
+	node.clock = NewClockMonitor(cfg.Clock)
+	node.protocol = NewProtocolTracker(cfg.Protocol)
 
 	// Load or generate identity key
 	privKey, err := node.loadOrCreateKey()
@@ -93,6 +121,11 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 		}
 		listenAddrs = append(listenAddrs, ma)
 	}
+	torOnly := cfg.Network.Tor.Enabled && cfg.Network.Tor.Only
+	node.torOnly = torOnly
+	if torOnly {
+		listenAddrs = torListenAddrs(listenAddrs)
+	}
 
 	// Create connection manager
 	cm, err := connmgr.NewConnManager(
@@ -110,13 +143,26 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 		libp2p.Identity(privKey),
 		libp2p.ListenAddrs(listenAddrs...),
 		libp2p.ConnectionManager(cm),
-		libp2p.DefaultTransports,
 		libp2p.DefaultMuxers,
 		libp2p.DefaultSecurity,
 	}
 
+	// Tor: dial onion addresses through the proxy and prefer them. In
+	// Tor-only mode the Tor transport replaces the default ones.
+	if cfg.Network.Tor.Enabled {
+		opts = append(opts,
+			libp2p.Transport(newTorTransport(cfg.Network.Tor)),
+			libp2p.DialRanker(torDialRanker),
+			libp2p.WithDialTimeout(torDialTimeout),
+			libp2p.AddrsFactory(node.torAddrsFactory),
+		)
+	}
+	if !torOnly {
+		opts = append(opts, libp2p.DefaultTransports)
+	}
+
 	// Add NAT options
-	if cfg.Network.EnableNAT {
+	if cfg.Network.EnableNAT && !torOnly {
 		opts = append(opts, libp2p.NATPortMap())
 	}
 
@@ -124,10 +170,24 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 		opts = append(opts, libp2p.EnableRelay())
 	}
 
-	if cfg.Network.EnableHolePunching {
+	if cfg.Network.EnableHolePunching && !torOnly {
 		opts = append(opts, libp2p.EnableHolePunching())
 	}
 
+	// Rendezvous points relay for group members behind NAT, which reserve
+	// slots on them
+	if cfg.Rendezvous.Serve {
+		opts = append(opts, libp2p.EnableRelayService())
+	}
+	if cfg.Network.EnableRelay && len(cfg.Rendezvous.Points) > 0 {
+		points, err := cfg.Rendezvous.pointInfos()
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		opts = append(opts, libp2p.EnableAutoRelayWithStaticRelays(points))
+	}
+
 	// Create host
 	h, err := libp2p.New(opts...)
 	if err != nil {
@@ -163,6 +223,18 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 		},
 	})
 
+	// Publish the onion service before the DHT advertises our addresses
+	if cfg.Network.Tor.Enabled && cfg.Network.Tor.OnionService {
+		if err := node.startOnionService(); err != nil {
+			if torOnly {
+				h.Close()
+				cancel()
+				return nil, fmt.Errorf("failed to publish onion service: %w", err)
+			}
+			node.log.Warn("Onion service failed", "error", err)
+		}
+	}
+
 	// Initialize DHT
 	if cfg.Network.EnableDHT {
 		if err := node.initDHT(ctx); err != nil {
@@ -179,8 +251,17 @@ func New(ctx context.Context, cfg *Config) (*Node, error) {
 		return nil, fmt.Errorf("failed to initialize pubsub: %w", err)
 	}
 
-	// Initialize mDNS discovery
-	if cfg.Network.EnableMDNS {
+	if cfg.Rendezvous.Enabled() {
+		node.rendezvous, err = NewRendezvousService(h, cfg.Rendezvous)
+		if err != nil {
+			h.Close()
+			cancel()
+			return nil, fmt.Errorf("failed to create rendezvous service: %w", err)
+		}
+	}
+
+	// Initialize mDNS discovery; it would reveal us on the LAN in Tor-only mode
+	if cfg.Network.EnableMDNS && !torOnly {
 		if err := node.initMDNS(); err != nil {
 			// mDNS failure is not fatal
 			node.log.Warn("mDNS initialization failed", "error", err)
@@ -230,8 +311,7 @@ func (n *Node) loadOrCreateKey() (crypto.PrivKey, error) {
 
 // initDHT initializes the Kademlia DHT.
 func (n *Node) initDHT(ctx context.Context) error {
-	var err error
-	n.dht, err = dht.New(ctx, n.host,
+	kad, err := dht.New(ctx, n.host,
 		dht.Mode(dht.ModeAutoServer),
 		dht.ProtocolPrefix(protocol.ID(n.config.DHTPrefix())),
 	)
@@ -240,12 +320,16 @@ func (n *Node) initDHT(ctx context.Context) error {
 	}
 
 	// Bootstrap the DHT
-	if err := n.dht.Bootstrap(ctx); err != nil {
+	if err := kad.Bootstrap(ctx); err != nil {
+		kad.Close()
 		return err
 	}
 
+	n.mu.Lock()
+	n.dht = kad
 	// Create routing discovery
-	n.routingDisc = drouting.NewRoutingDiscovery(n.dht)
+	n.routingDisc = drouting.NewRoutingDiscovery(kad)
+	n.mu.Unlock()
 
 	return nil
 }
@@ -256,14 +340,21 @@ func (n *Node) initPubSub(ctx context.Context) error {
 	n.pubsub, err = pubsub.NewGossipSub(ctx, n.host,
 		pubsub.WithPeerExchange(true),
 		pubsub.WithFloodPublish(true),
+		pubsub.WithMaxMessageSize(int(SwapMessageLimits.MaxBytes)),
 	)
 	return err
 }
 
 // initMDNS initializes mDNS discovery for local network peers.
 func (n *Node) initMDNS() error {
-	n.mdnsService = mdns.NewMdnsService(n.host, n.config.DiscoveryNamespace(), n)
-	return n.mdnsService.Start()
+	svc := mdns.NewMdnsService(n.host, n.config.DiscoveryNamespace(), n)
+	if err := svc.Start(); err != nil {
+		return err
+	}
+	n.mu.Lock()
+	n.mdnsService = svc
+	n.mu.Unlock()
+	return nil
 }
 
 // HandlePeerFound is called when mDNS discovers a peer.
@@ -302,6 +393,9 @@ func (n *Node) Start() error {
 			n.log.Warn("Invalid bootstrap peer info", "addr", addrStr, "error", err)
 			continue
 		}
+		n.mu.Lock()
+		n.bootstrapIDs[pi.ID] = true
+		n.mu.Unlock()
 
 		go func(pi peer.AddrInfo) {
 			ctx, cancel := context.WithTimeout(n.ctx, 30*time.Second)
@@ -314,16 +408,14 @@ func (n *Node) Start() error {
 		}(*pi)
 	}
 
-	// Advertise ourselves for discovery
-	if n.routingDisc != nil {
-		go func() {
-			dutil.Advertise(n.ctx, n.routingDisc, n.config.DiscoveryNamespace())
-		}()
-
-		// Start peer discovery loop
-		go n.discoverPeers()
+	n.startDiscovery()
+	if n.rendezvous != nil {
+		n.rendezvous.Start()
 	}
 
+	n.partition.Start()
+	n.clock.Start()
+
 	// Initialize swap handler if pubsub is available
 	if n.pubsub != nil {
 		swapHandler, err := NewSwapHandler(n)
@@ -360,20 +452,42 @@ func (n *Node) GetTopic(topicName string) *pubsub.Topic {
 	}
 }
 
+// startDiscovery advertises us on the DHT and starts the peer discovery
+// loop, if the DHT runs.
+func (n *Node) startDiscovery() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.routingDisc == nil {
+		return
+	}
+	ctx, cancel := context.WithCancel(n.ctx)
+	n.discoveryCancel = cancel
+
+	// Advertise ourselves for discovery
+	go func(disc *drouting.RoutingDiscovery) {
+		dutil.Advertise(ctx, disc, n.config.DiscoveryNamespace())
+	}(n.routingDisc)
+
+	// Start peer discovery loop
+	go n.discoverPeers(ctx, n.dht, n.routingDisc)
+}
+
 // discoverPeers continuously discovers new peers.
-func (n *Node) discoverPeers() {
+func (n *Node) discoverPeers(ctx context.Context, kad *dht.IpfsDHT, disc *drouting.RoutingDiscovery) {
 	ticker := time.NewTicker(30 * time.Second)
 	defer ticker.Stop()
 
 	for {
 		select {
-		case <-n.ctx.Done():
+		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			peers, err := dutil.FindPeers(n.ctx, n.routingDisc, n.config.DiscoveryNamespace())
-			if err != nil {
+			peers, err := dutil.FindPeers(ctx, disc, n.config.DiscoveryNamespace())
+			if err != nil || kad.RoutingTable().Size() == 0 {
+				n.dhtFailures.Add(1)
 				continue
 			}
+			n.dhtFailures.Store(0)
 
 			for _, pi := range peers {
 				if pi.ID == n.host.ID() {
@@ -398,6 +512,8 @@ func (n *Node) discoverPeers() {
 // Stop stops the node gracefully.
 func (n *Node) Stop() error {
 	n.cancel()
+	n.partition.Stop()
+	n.clock.Stop()
 
 	// Stop direct messaging components first
 	if n.retryWorker != nil {
@@ -408,6 +524,10 @@ func (n *Node) Stop() error {
 		n.peerMonitor.Stop()
 	}
 
+	if n.peerQuality != nil {
+		n.peerQuality.Stop()
+	}
+
 	if n.streamHandler != nil {
 		n.streamHandler.Stop()
 	}
@@ -417,13 +537,24 @@ func (n *Node) Stop() error {
 		n.swapHandler.Stop()
 	}
 
+	if n.rendezvous != nil {
+		n.rendezvous.Stop()
+	}
+
 	// Stop discovery
-	if n.mdnsService != nil {
-		n.mdnsService.Close()
+	n.mu.RLock()
+	svc, kad, ctrl := n.mdnsService, n.dht, n.torControl
+	n.mu.RUnlock()
+	if svc != nil {
+		svc.Close()
+	}
+
+	if kad != nil {
+		kad.Close()
 	}
 
-	if n.dht != nil {
-		n.dht.Close()
+	if ctrl != nil {
+		ctrl.Close()
 	}
 
 	return n.host.Close()
@@ -446,9 +577,77 @@ func (n *Node) Host() host.Host {
 
 // DHT returns the Kademlia DHT.
 func (n *Node) DHT() *dht.IpfsDHT {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.dht
 }
 
+// StartDHT starts the DHT and DHT peer discovery after StopDHT.
+func (n *Node) StartDHT() error {
+	if n.DHT() != nil {
+		return fmt.Errorf("DHT is already running")
+	}
+	if err := n.initDHT(n.ctx); err != nil {
+		return fmt.Errorf("failed to initialize DHT: %w", err)
+	}
+	n.startDiscovery()
+	n.log.Info("DHT started")
+	return nil
+}
+
+// StopDHT stops DHT peer discovery and closes the DHT. Peer lookups for
+// direct messages fall back to known addresses until StartDHT.
+func (n *Node) StopDHT() error {
+	n.mu.Lock()
+	kad := n.dht
+	if kad == nil {
+		n.mu.Unlock()
+		return fmt.Errorf("DHT is not running")
+	}
+	if n.discoveryCancel != nil {
+		n.discoveryCancel()
+		n.discoveryCancel = nil
+	}
+	n.dht = nil
+	n.routingDisc = nil
+	n.mu.Unlock()
+
+	n.log.Info("DHT stopped")
+	return kad.Close()
+}
+
+// StartMDNS starts local network discovery after StopMDNS.
+func (n *Node) StartMDNS() error {
+	if n.MDNSRunning() {
+		return fmt.Errorf("mDNS is already running")
+	}
+	if err := n.initMDNS(); err != nil {
+		return fmt.Errorf("failed to start mDNS: %w", err)
+	}
+	n.log.Info("mDNS started")
+	return nil
+}
+
+// MDNSRunning returns true if local network discovery runs.
+func (n *Node) MDNSRunning() bool {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.mdnsService != nil
+}
+
+// StopMDNS stops local network discovery.
+func (n *Node) StopMDNS() error {
+	n.mu.Lock()
+	svc := n.mdnsService
+	n.mdnsService = nil
+	n.mu.Unlock()
+	if svc == nil {
+		return fmt.Errorf("mDNS is not running")
+	}
+	n.log.Info("mDNS stopped")
+	return svc.Close()
+}
+
 // PubSub returns the GossipSub instance.
 func (n *Node) PubSub() *pubsub.PubSub {
 	return n.pubsub
@@ -532,6 +731,7 @@ func (n *Node) SetupDirectMessaging(store *storage.Storage) error {
 
 	// Create message sender with default config
 	senderCfg := DefaultMessageSenderConfig()
+	senderCfg.RelayFallback = n.config.Network.EnableRelay
 	n.messageSender = NewMessageSender(n, store, n.streamHandler, senderCfg)
 
 	// Create and start retry worker
@@ -546,10 +746,20 @@ func (n *Node) SetupDirectMessaging(store *storage.Storage) error {
 		// Not fatal - direct messaging can still work without event-based flushing
 	}
 
+	// Measure peer latency and message loss
+	n.peerQuality = NewPeerQualityTracker(n, store, n.config.PeerQuality)
+	n.peerQuality.Start()
+
 	n.log.Info("Direct messaging initialized")
 	return nil
 }
 
+// PeerQuality returns the peer latency and message loss tracker (nil before
+// SetupDirectMessaging).
+func (n *Node) PeerQuality() *PeerQualityTracker {
+	return n.peerQuality
+}
+
 // StreamHandler returns the direct stream handler.
 func (n *Node) StreamHandler() *StreamHandler {
 	return n.streamHandler
@@ -575,3 +785,57 @@ func (n *Node) RegisterDirectHandler(msgType string, handler SwapMessageHandler)
 		n.streamHandler.OnMessage(msgType, handler)
 	}
 }
+
+// Partition returns the partition detector.
+func (n *Node) Partition() *PartitionDetector {
+	return n.partition
+}
+
+// Rendezvous returns the private group discovery service, nil when not
+// configured.
+func (n *Node) Rendezvous() *RendezvousService {
+	return n.rendezvous
+}
+
+// Clock returns the clock skew monitor.
+func (n *Node) Clock() *ClockMonitor {
+	return n.clock
+}
+
+// Protocol returns the tracker of peers' protocol versions.
+func (n *Node) Protocol() *ProtocolTracker {
+	return n.protocol
+}
+
+// NonBootstrapPeerCount returns the number of connected peers that are not bootstrap nodes.
+func (n *Node) NonBootstrapPeerCount() int {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+
+	count := 0
+	for _, p := range n.host.Network().Peers() {
+		if !n.bootstrapIDs[p] {
+			count++
+		}
+	}
+	return count
+}
+
+// DHTFailures returns the number of consecutive failed DHT discovery queries.
+func (n *Node) DHTFailures() int {
+	return int(n.dhtFailures.Load())
+}
+
+// LastGossipAt returns when gossip was last received from a peer (zero if never).
+func (n *Node) LastGossipAt() time.Time {
+	ns := n.lastGossipAt.Load()
+	if ns == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, ns)
+}
+
+// recordGossip notes that a gossip message arrived from a peer.
+func (n *Node) recordGossip() {
+	n.lastGossipAt.Store(time.Now().UnixNano())
+}
@@ -0,0 +1,216 @@
+// Package node - Network partition detection and degraded mode.
+package node
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Isolation signals reported in PartitionStatus.Signals.
+const (
+	SignalNoPeers      = "no_peers"      // No peers connected beyond bootstrap nodes
+	SignalDHTFailing   = "dht_failing"   // DHT routing table empty or discovery queries failing
+	SignalGossipSilent = "gossip_silent" // No gossip received for GossipSilence
+)
+
+// PartitionConfig holds partition detection settings.
+type PartitionConfig struct {
+	// CheckInterval is how often isolation signals are evaluated.
+	CheckInterval time.Duration `yaml:"check_interval,omitempty"`
+
+	// StartupGrace delays detection after start while peers are discovered.
+	StartupGrace time.Duration `yaml:"startup_grace,omitempty"`
+
+	// GossipSilence is how long without any gossip counts as silence.
+	GossipSilence time.Duration `yaml:"gossip_silence,omitempty"`
+
+	// DHTFailureThreshold is the number of consecutive failed discovery
+	// queries after which the DHT counts as failing.
+	DHTFailureThreshold int `yaml:"dht_failure_threshold,omitempty"`
+}
+
+// PartitionStatus is the node's connectivity assessment.
+type PartitionStatus struct {
+	Degraded          bool      `json:"degraded"`
+	Signals           []string  `json:"signals"`
+	NonBootstrapPeers int       `json:"non_bootstrap_peers"`
+	DHTFailures       int       `json:"dht_failures"`
+	LastGossipAt      int64     `json:"last_gossip_at,omitempty"`
+	Since             time.Time `json:"since"` // When the current mode was entered
+}
+
+// partitionSignals provides the raw connectivity observations.
+type partitionSignals interface {
+	NonBootstrapPeerCount() int
+	DHTFailures() int
+	LastGossipAt() time.Time
+}
+
+// PartitionDetector declares degraded mode when the node is isolated: no
+// peers beyond bootstrap nodes, or a failing DHT together with gossip silence.
+// A quiet but connected network does not degrade the node.
+type PartitionDetector struct {
+	cfg     PartitionConfig
+	signals partitionSignals
+	started time.Time
+	log     *logging.Logger
+
+	mu       sync.RWMutex
+	status   PartitionStatus
+	onChange []func(PartitionStatus)
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPartitionDetector creates a detector over the given signal source.
+func NewPartitionDetector(cfg PartitionConfig, signals partitionSignals) *PartitionDetector {
+	defaults := DefaultConfig().Partition
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = defaults.CheckInterval
+	}
+	if cfg.GossipSilence <= 0 {
+		cfg.GossipSilence = defaults.GossipSilence
+	}
+	if cfg.DHTFailureThreshold <= 0 {
+		cfg.DHTFailureThreshold = defaults.DHTFailureThreshold
+	}
+
+	now := time.Now()
+	ctx, cancel := context.WithCancel(context.Background())
+	return &PartitionDetector{
+		cfg:     cfg,
+		signals: signals,
+		started: now,
+		log:     logging.GetDefault().Component("partition"),
+		status:  PartitionStatus{Signals: []string{}, Since: now},
+		ctx:     ctx,
+		cancel:  cancel,
+	}
+}
+
+// OnChange registers a callback invoked when the node enters or leaves degraded mode.
+func (d *PartitionDetector) OnChange(fn func(PartitionStatus)) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.onChange = append(d.onChange, fn)
+}
+
+// Status returns the latest assessment.
+func (d *PartitionDetector) Status() PartitionStatus {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.status
+}
+
+// Degraded returns true while the node is isolated.
+func (d *PartitionDetector) Degraded() bool {
+	return d.Status().Degraded
+}
+
+// Start evaluates the signals periodically.
+func (d *PartitionDetector) Start() {
+	d.wg.Add(1)
+	go func() {
+		defer d.wg.Done()
+		ticker := time.NewTicker(d.cfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-d.ctx.Done():
+				return
+			case <-ticker.C:
+				d.Check(time.Now())
+			}
+		}
+	}()
+}
+
+// Stop stops periodic evaluation.
+func (d *PartitionDetector) Stop() {
+	d.cancel()
+	d.wg.Wait()
+}
+
+// Check evaluates the signals at now and updates the status.
+func (d *PartitionDetector) Check(now time.Time) PartitionStatus {
+	peers := d.signals.NonBootstrapPeerCount()
+	dhtFailures := d.signals.DHTFailures()
+	lastGossip := d.signals.LastGossipAt()
+
+	// Gossip silence is measured from start until the first message arrives
+	gossipRef := lastGossip
+	if gossipRef.IsZero() {
+		gossipRef = d.started
+	}
+
+	noPeers := peers == 0
+	dhtFailing := dhtFailures >= d.cfg.DHTFailureThreshold
+	gossipSilent := now.Sub(gossipRef) >= d.cfg.GossipSilence
+
+	signals := []string{}
+	for _, s := range []struct {
+		name   string
+		active bool
+	}{
+		{SignalNoPeers, noPeers},
+		{SignalDHTFailing, dhtFailing},
+		{SignalGossipSilent, gossipSilent},
+	} {
+		value := 0.0
+		if s.active {
+			signals = append(signals, s.name)
+			value = 1
+		}
+		metrics.PartitionSignal.WithLabelValues(s.name).Set(value)
+	}
+	metrics.NonBootstrapPeers.Set(float64(peers))
+
+	degraded := noPeers || (dhtFailing && gossipSilent)
+	if now.Sub(d.started) < d.cfg.StartupGrace {
+		degraded = false
+	}
+
+	d.mu.Lock()
+	changed := degraded != d.status.Degraded
+	since := d.status.Since
+	if changed {
+		since = now
+	}
+	d.status = PartitionStatus{
+		Degraded:          degraded,
+		Signals:           signals,
+		NonBootstrapPeers: peers,
+		DHTFailures:       dhtFailures,
+		Since:             since,
+	}
+	if !lastGossip.IsZero() {
+		d.status.LastGossipAt = lastGossip.Unix()
+	}
+	status := d.status
+	callbacks := append([]func(PartitionStatus){}, d.onChange...)
+	d.mu.Unlock()
+
+	if degraded {
+		metrics.NodeDegraded.Set(1)
+	} else {
+		metrics.NodeDegraded.Set(0)
+	}
+
+	if changed {
+		if degraded {
+			d.log.Warn("Node isolated, entering degraded mode", "signals", signals, "peers", peers)
+		} else {
+			d.log.Info("Connectivity recovered, leaving degraded mode", "peers", peers)
+		}
+		for _, fn := range callbacks {
+			fn(status)
+		}
+	}
+	return status
+}
@@ -0,0 +1,115 @@
+package node
+
+import (
+	"testing"
+	"time"
+)
+
+type fakeSignals struct {
+	peers       int
+	dhtFailures int
+	lastGossip  time.Time
+}
+
+func (f *fakeSignals) NonBootstrapPeerCount() int { return f.peers }
+func (f *fakeSignals) DHTFailures() int           { return f.dhtFailures }
+func (f *fakeSignals) LastGossipAt() time.Time    { return f.lastGossip }
+
+func newTestDetector(signals *fakeSignals) *PartitionDetector {
+	return NewPartitionDetector(PartitionConfig{
+		CheckInterval:       time.Second,
+		StartupGrace:        time.Minute,
+		GossipSilence:       10 * time.Minute,
+		DHTFailureThreshold: 3,
+	}, signals)
+}
+
+func TestPartitionDetectorStartupGrace(t *testing.T) {
+	d := newTestDetector(&fakeSignals{peers: 0})
+
+	status := d.Check(d.started.Add(30 * time.Second))
+	if status.Degraded {
+		t.Error("should not be degraded during startup grace")
+	}
+	if len(status.Signals) != 1 || status.Signals[0] != SignalNoPeers {
+		t.Errorf("Signals = %v, want [%s]", status.Signals, SignalNoPeers)
+	}
+}
+
+func TestPartitionDetectorNoPeers(t *testing.T) {
+	d := newTestDetector(&fakeSignals{peers: 0, lastGossip: time.Now()})
+
+	status := d.Check(d.started.Add(2 * time.Minute))
+	if !status.Degraded {
+		t.Error("should be degraded with no peers beyond bootstrap")
+	}
+	if !d.Degraded() {
+		t.Error("Degraded() = false, want true")
+	}
+}
+
+func TestPartitionDetectorDHTAndGossip(t *testing.T) {
+	signals := &fakeSignals{peers: 2, dhtFailures: 5}
+	d := newTestDetector(signals)
+
+	// DHT failing alone is not enough while gossip is fresh
+	signals.lastGossip = d.started.Add(4 * time.Minute)
+	if status := d.Check(d.started.Add(5 * time.Minute)); status.Degraded {
+		t.Error("should not be degraded with recent gossip")
+	}
+
+	// Gossip silence alone is not enough while the DHT works
+	signals.dhtFailures = 0
+	if status := d.Check(d.started.Add(time.Hour)); status.Degraded {
+		t.Error("should not be degraded with a working DHT")
+	}
+
+	// Both together mean isolation
+	signals.dhtFailures = 3
+	status := d.Check(d.started.Add(time.Hour))
+	if !status.Degraded {
+		t.Error("should be degraded with failing DHT and gossip silence")
+	}
+	if len(status.Signals) != 2 {
+		t.Errorf("Signals = %v, want dht_failing and gossip_silent", status.Signals)
+	}
+}
+
+func TestPartitionDetectorOnChange(t *testing.T) {
+	signals := &fakeSignals{peers: 0}
+	d := newTestDetector(signals)
+
+	var changes []bool
+	d.OnChange(func(status PartitionStatus) {
+		changes = append(changes, status.Degraded)
+	})
+
+	now := d.started.Add(2 * time.Minute)
+	d.Check(now)
+	d.Check(now.Add(time.Second)) // No change, no callback
+
+	signals.peers = 1
+	signals.lastGossip = now
+	status := d.Check(now.Add(2 * time.Second))
+	if status.Degraded {
+		t.Error("should recover once peers are back")
+	}
+	if !status.Since.Equal(now.Add(2 * time.Second)) {
+		t.Errorf("Since = %v, want recovery time", status.Since)
+	}
+
+	if len(changes) != 2 || !changes[0] || changes[1] {
+		t.Errorf("changes = %v, want [true false]", changes)
+	}
+}
+
+func TestPartitionDetectorDefaults(t *testing.T) {
+	d := NewPartitionDetector(PartitionConfig{}, &fakeSignals{})
+	defaults := DefaultConfig().Partition
+	if d.cfg.CheckInterval != defaults.CheckInterval {
+		t.Errorf("CheckInterval = %v, want %v", d.cfg.CheckInterval, defaults.CheckInterval)
+	}
+	if d.cfg.DHTFailureThreshold != defaults.DHTFailureThreshold {
+		t.Errorf("DHTFailureThreshold = %d, want %d", d.cfg.DHTFailureThreshold, defaults.DHTFailureThreshold)
+	}
+}
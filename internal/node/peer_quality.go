@@ -0,0 +1,278 @@
+// Package node - Peer latency and path quality measurement.
+package node
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/p2p/protocol/ping"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// PeerQualityConfig holds peer quality measurement settings.
+type PeerQualityConfig struct {
+	// ProbeInterval is how often connected peers are pinged (0 disables probing).
+	ProbeInterval time.Duration `yaml:"probe_interval,omitempty"`
+
+	// MaxAge drops measurements of peers not seen for this long.
+	MaxAge time.Duration `yaml:"max_age,omitempty"`
+}
+
+// pingTimeout bounds a single latency probe.
+const pingTimeout = 10 * time.Second
+
+// ewmaWeight is the weight of the previous value in the smoothed averages
+// (new = (old*(ewmaWeight-1) + sample) / ewmaWeight).
+const ewmaWeight = 8
+
+// UnknownQualityScore ranks peers without measurements after all measured peers.
+const UnknownQualityScore = int64(math.MaxInt64)
+
+// PeerQuality holds the path quality measurements of a peer.
+type PeerQuality struct {
+	PeerID            string `json:"peer_id"`
+	RTTMicros         int64  `json:"rtt_us"`          // Smoothed ping round-trip time
+	StreamSetupMicros int64  `json:"stream_setup_us"` // Smoothed direct stream open time
+	MessagesSent      int64  `json:"messages_sent"`
+	MessagesLost      int64  `json:"messages_lost"` // Direct messages sent without an ACK
+	LossPermille      int64  `json:"loss_permille"`
+	UpdatedAt         int64  `json:"updated_at"`
+}
+
+// Score ranks the peer for time-sensitive messaging; lower is better. It is
+// the measured latency in microseconds, doubled for every 10% of messages
+// lost. Peers without a latency measurement score UnknownQualityScore.
+func (q *PeerQuality) Score() int64 {
+	if q == nil {
+		return UnknownQualityScore
+	}
+	latency := q.RTTMicros
+	if latency == 0 {
+		latency = q.StreamSetupMicros
+	}
+	if latency == 0 {
+		return UnknownQualityScore
+	}
+	return latency * (1000 + 10*q.LossPermille) / 1000
+}
+
+// PeerQualityTracker measures RTT, stream setup latency and message loss per
+// peer and persists the measurements.
+type PeerQualityTracker struct {
+	node  *Node
+	store *storage.Storage
+	cfg   PeerQualityConfig
+	log   *logging.Logger
+
+	mu    sync.RWMutex
+	peers map[string]*PeerQuality
+	dirty map[string]bool
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// NewPeerQualityTracker creates a tracker and loads persisted measurements.
+// store may be nil to keep measurements in memory only.
+func NewPeerQualityTracker(n *Node, store *storage.Storage, cfg PeerQualityConfig) *PeerQualityTracker {
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &PeerQualityTracker{
+		node:   n,
+		store:  store,
+		cfg:    cfg,
+		log:    logging.GetDefault().Component("peer-quality"),
+		peers:  make(map[string]*PeerQuality),
+		dirty:  make(map[string]bool),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	if store != nil {
+		if cfg.MaxAge > 0 {
+			if _, err := store.DeletePeerQualityBefore(time.Now().Add(-cfg.MaxAge)); err != nil {
+				t.log.Warn("Failed to prune peer quality", "error", err)
+			}
+		}
+		records, err := store.ListPeerQuality()
+		if err != nil {
+			t.log.Warn("Failed to load peer quality", "error", err)
+		}
+		for _, r := range records {
+			q := &PeerQuality{
+				PeerID:            r.PeerID,
+				RTTMicros:         r.RTTMicros,
+				StreamSetupMicros: r.StreamSetupMicros,
+				MessagesSent:      r.MessagesSent,
+				MessagesLost:      r.MessagesLost,
+				UpdatedAt:         r.UpdatedAt.Unix(),
+			}
+			q.LossPermille = lossPermille(q.MessagesSent, q.MessagesLost)
+			t.peers[r.PeerID] = q
+		}
+	}
+	return t
+}
+
+// Start probes connected peers every ProbeInterval and persists changes.
+func (t *PeerQualityTracker) Start() {
+	if t.cfg.ProbeInterval <= 0 || t.node == nil {
+		return
+	}
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.cfg.ProbeInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				t.probe()
+				t.Flush()
+			}
+		}
+	}()
+}
+
+// Stop stops probing and persists pending measurements.
+func (t *PeerQualityTracker) Stop() {
+	t.cancel()
+	t.wg.Wait()
+	t.Flush()
+}
+
+// probe pings every connected peer once.
+func (t *PeerQualityTracker) probe() {
+	for _, p := range t.node.Peers() {
+		ctx, cancel := context.WithTimeout(t.ctx, pingTimeout)
+		res := <-ping.Ping(ctx, t.node.Host(), p)
+		cancel()
+		if res.Error != nil {
+			t.log.Debug("Peer ping failed", "peer", shortID(p), "error", res.Error)
+			continue
+		}
+		t.RecordRTT(p, res.RTT)
+	}
+}
+
+// RecordRTT adds a round-trip time sample.
+func (t *PeerQualityTracker) RecordRTT(p peer.ID, rtt time.Duration) {
+	t.update(p, func(q *PeerQuality) {
+		q.RTTMicros = ewma(q.RTTMicros, rtt.Microseconds())
+	})
+}
+
+// RecordStreamSetup adds a direct stream open time sample.
+func (t *PeerQualityTracker) RecordStreamSetup(p peer.ID, d time.Duration) {
+	t.update(p, func(q *PeerQuality) {
+		q.StreamSetupMicros = ewma(q.StreamSetupMicros, d.Microseconds())
+	})
+}
+
+// RecordDelivery counts a direct message; lost means no ACK was received.
+func (t *PeerQualityTracker) RecordDelivery(p peer.ID, lost bool) {
+	t.update(p, func(q *PeerQuality) {
+		q.MessagesSent++
+		if lost {
+			q.MessagesLost++
+		}
+		q.LossPermille = lossPermille(q.MessagesSent, q.MessagesLost)
+	})
+}
+
+func (t *PeerQualityTracker) update(p peer.ID, fn func(*PeerQuality)) {
+	if t == nil {
+		return
+	}
+	id := p.String()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	q, ok := t.peers[id]
+	if !ok {
+		q = &PeerQuality{PeerID: id}
+		t.peers[id] = q
+	}
+	fn(q)
+	q.UpdatedAt = time.Now().Unix()
+	t.dirty[id] = true
+}
+
+// Get returns a copy of a peer's measurements, or nil if there are none.
+func (t *PeerQualityTracker) Get(peerID string) *PeerQuality {
+	if t == nil {
+		return nil
+	}
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	q, ok := t.peers[peerID]
+	if !ok {
+		return nil
+	}
+	cp := *q
+	return &cp
+}
+
+// Rank sorts peer IDs by Score, best first. Peers with equal scores keep
+// their order.
+func (t *PeerQualityTracker) Rank(peerIDs []string) {
+	scores := make(map[string]int64, len(peerIDs))
+	for _, id := range peerIDs {
+		scores[id] = t.Get(id).Score()
+	}
+	sort.SliceStable(peerIDs, func(i, j int) bool {
+		return scores[peerIDs[i]] < scores[peerIDs[j]]
+	})
+}
+
+// Flush persists measurements changed since the last flush.
+func (t *PeerQualityTracker) Flush() {
+	if t == nil || t.store == nil {
+		return
+	}
+
+	t.mu.Lock()
+	records := make([]*storage.PeerQualityRecord, 0, len(t.dirty))
+	for id := range t.dirty {
+		q := t.peers[id]
+		records = append(records, &storage.PeerQualityRecord{
+			PeerID:            q.PeerID,
+			RTTMicros:         q.RTTMicros,
+			StreamSetupMicros: q.StreamSetupMicros,
+			MessagesSent:      q.MessagesSent,
+			MessagesLost:      q.MessagesLost,
+			UpdatedAt:         time.Unix(q.UpdatedAt, 0),
+		})
+	}
+	t.dirty = make(map[string]bool)
+	t.mu.Unlock()
+
+	for _, r := range records {
+		if err := t.store.SavePeerQuality(r); err != nil {
+			t.log.Warn("Failed to save peer quality", "peer", r.PeerID, "error", err)
+		}
+	}
+}
+
+// ewma smooths a sample into the previous value; the first sample is taken as is.
+func ewma(prev, sample int64) int64 {
+	if prev == 0 {
+		return sample
+	}
+	return (prev*(ewmaWeight-1) + sample) / ewmaWeight
+}
+
+func lossPermille(sent, lost int64) int64 {
+	if sent == 0 {
+		return 0
+	}
+	return lost * 1000 / sent
+}
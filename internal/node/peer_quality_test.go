@@ -0,0 +1,90 @@
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestPeerQualityScore(t *testing.T) {
+	var unknown *PeerQuality
+	if got := unknown.Score(); got != UnknownQualityScore {
+		t.Errorf("nil Score() = %d, want UnknownQualityScore", got)
+	}
+
+	tests := []struct {
+		name string
+		q    PeerQuality
+		want int64
+	}{
+		{"no measurements", PeerQuality{}, UnknownQualityScore},
+		{"rtt", PeerQuality{RTTMicros: 20000, StreamSetupMicros: 90000}, 20000},
+		{"stream setup only", PeerQuality{StreamSetupMicros: 30000}, 30000},
+		{"10% loss doubles", PeerQuality{RTTMicros: 20000, LossPermille: 100}, 40000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.q.Score(); got != tt.want {
+				t.Errorf("Score() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPeerQualityTracker(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	fast, slow, lossy := peer.ID("fast"), peer.ID("slow"), peer.ID("lossy")
+	tracker := NewPeerQualityTracker(nil, store, PeerQualityConfig{})
+
+	tracker.RecordRTT(fast, 10*time.Millisecond)
+	tracker.RecordRTT(fast, 18*time.Millisecond)
+	tracker.RecordRTT(slow, 200*time.Millisecond)
+	tracker.RecordRTT(lossy, 15*time.Millisecond)
+	tracker.RecordStreamSetup(lossy, 30*time.Millisecond)
+	for i := 0; i < 4; i++ {
+		tracker.RecordDelivery(lossy, i%2 == 0)
+	}
+
+	q := tracker.Get(fast.String())
+	if q == nil || q.RTTMicros != (10000*7+18000)/8 {
+		t.Fatalf("fast quality = %+v, want smoothed RTT %d", q, (10000*7+18000)/8)
+	}
+	q = tracker.Get(lossy.String())
+	if q.MessagesSent != 4 || q.MessagesLost != 2 || q.LossPermille != 500 || q.StreamSetupMicros != 30000 {
+		t.Errorf("lossy quality = %+v", q)
+	}
+
+	peers := []string{"unknown", slow.String(), lossy.String(), fast.String()}
+	tracker.Rank(peers)
+	want := []string{fast.String(), lossy.String(), slow.String(), "unknown"}
+	for i := range want {
+		if peers[i] != want[i] {
+			t.Fatalf("Rank() = %v, want %v", peers, want)
+		}
+	}
+
+	// Measurements survive a restart
+	tracker.Flush()
+	reloaded := NewPeerQualityTracker(nil, store, PeerQualityConfig{MaxAge: time.Hour})
+	if got := reloaded.Get(lossy.String()); got == nil || got.LossPermille != 500 || got.RTTMicros != 15000 {
+		t.Errorf("reloaded lossy quality = %+v", got)
+	}
+}
+
+func TestPeerQualityTrackerNil(t *testing.T) {
+	var tracker *PeerQualityTracker
+	tracker.RecordRTT(peer.ID("p"), time.Millisecond)
+	tracker.RecordDelivery(peer.ID("p"), true)
+	tracker.Flush()
+	if tracker.Get("p") != nil {
+		t.Error("nil tracker Get() should return nil")
+	}
+}
@@ -0,0 +1,135 @@
+// Package node - Swap protocol versions of peers and the minimum we trade with.
+package node
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+)
+
+// Swap protocol versions. Every message we send carries ProtocolVersion;
+// nodes that predate versioning send none and count as LegacyProtocolVersion.
+const (
+	LegacyProtocolVersion = 1
+	ProtocolVersion       = 2
+)
+
+// RefusalProtocolTooOld is the TradeRefusedPayload code of a take refused
+// because the taker's protocol version is below our minimum.
+const RefusalProtocolTooOld = "protocol_version_too_old"
+
+// ProtocolConfig sets the oldest protocol version we start trades with.
+type ProtocolConfig struct {
+	// MinPeerVersion is the minimum counterparty protocol version (0: any).
+	// Takes from older peers are refused with TradeRefusedPayload, and we
+	// don't take orders of makers known to be older. Swaps already running
+	// are never interrupted.
+	MinPeerVersion int `yaml:"min_peer_version,omitempty"`
+
+	// UpgradeHint is sent to refused peers, e.g. a release URL.
+	UpgradeHint string `yaml:"upgrade_hint,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *ProtocolConfig) Validate() error {
+	if c.MinPeerVersion < 0 || c.MinPeerVersion > ProtocolVersion {
+		return fmt.Errorf("protocol.min_peer_version must be between 0 and %d", ProtocolVersion)
+	}
+	return nil
+}
+
+// TradeRefusedPayload tells a peer why its trade was refused.
+type TradeRefusedPayload struct {
+	OrderID     string `json:"order_id,omitempty"`
+	Code        string `json:"code"` // Machine-readable, e.g. RefusalProtocolTooOld
+	Reason      string `json:"reason"`
+	PeerVersion int    `json:"peer_version,omitempty"` // The refused peer's version
+	MinVersion  int    `json:"min_version,omitempty"`
+	OurVersion  int    `json:"our_version"`
+	UpgradeHint string `json:"upgrade_hint,omitempty"`
+}
+
+// SenderProtocolVersion returns the protocol version the sender speaks.
+func (m *SwapMessage) SenderProtocolVersion() int {
+	if m.ProtocolVersion <= 0 {
+		return LegacyProtocolVersion
+	}
+	return m.ProtocolVersion
+}
+
+// ProtocolTracker records the protocol version of each peer we received
+// messages from and checks versions against the configured minimum.
+type ProtocolTracker struct {
+	cfg ProtocolConfig
+
+	mu    sync.Mutex
+	peers map[peer.ID]int
+}
+
+// NewProtocolTracker creates a protocol tracker.
+func NewProtocolTracker(cfg ProtocolConfig) *ProtocolTracker {
+	return &ProtocolTracker{cfg: cfg, peers: make(map[peer.ID]int)}
+}
+
+// Observe records the version a peer's message was sent with.
+func (t *ProtocolTracker) Observe(id peer.ID, version int) {
+	if t == nil || id == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	prev, seen := t.peers[id]
+	if seen && prev == version {
+		return
+	}
+	t.peers[id] = version
+	if seen {
+		metrics.PeerProtocolVersions.WithLabelValues(strconv.Itoa(prev)).Dec()
+	}
+	metrics.PeerProtocolVersions.WithLabelValues(strconv.Itoa(version)).Inc()
+}
+
+// Version returns a peer's last seen protocol version.
+func (t *ProtocolTracker) Version(id peer.ID) (int, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	v, ok := t.peers[id]
+	return v, ok
+}
+
+// Distribution returns the number of peers seen per protocol version.
+func (t *ProtocolTracker) Distribution() map[int]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	dist := make(map[int]int)
+	for _, v := range t.peers {
+		dist[v]++
+	}
+	return dist
+}
+
+// MinPeerVersion returns the configured minimum peer version.
+func (t *ProtocolTracker) MinPeerVersion() int {
+	return t.cfg.MinPeerVersion
+}
+
+// Check returns the refusal to send a peer speaking version, or nil if we
+// trade with it.
+func (t *ProtocolTracker) Check(version int) *TradeRefusedPayload {
+	if version >= t.cfg.MinPeerVersion {
+		return nil
+	}
+	return &TradeRefusedPayload{
+		Code:        RefusalProtocolTooOld,
+		Reason:      fmt.Sprintf("protocol version %d is below the minimum %d", version, t.cfg.MinPeerVersion),
+		PeerVersion: version,
+		MinVersion:  t.cfg.MinPeerVersion,
+		OurVersion:  ProtocolVersion,
+		UpgradeHint: t.cfg.UpgradeHint,
+	}
+}
@@ -0,0 +1,74 @@
+package node
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+)
+
+func TestSenderProtocolVersion(t *testing.T) {
+	var legacy SwapMessage
+	if err := json.Unmarshal([]byte(`{"type":"order_take"}`), &legacy); err != nil {
+		t.Fatal(err)
+	}
+	if got := legacy.SenderProtocolVersion(); got != LegacyProtocolVersion {
+		t.Errorf("SenderProtocolVersion() of an unversioned message = %d, want %d", got, LegacyProtocolVersion)
+	}
+	msg := &SwapMessage{ProtocolVersion: 7}
+	if got := msg.SenderProtocolVersion(); got != 7 {
+		t.Errorf("SenderProtocolVersion() = %d, want 7", got)
+	}
+}
+
+func TestProtocolConfigValidate(t *testing.T) {
+	tests := []struct {
+		min     int
+		wantErr bool
+	}{
+		{0, false},
+		{LegacyProtocolVersion, false},
+		{ProtocolVersion, false},
+		{ProtocolVersion + 1, true},
+		{-1, true},
+	}
+	for _, tt := range tests {
+		cfg := ProtocolConfig{MinPeerVersion: tt.min}
+		if err := cfg.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("Validate(min %d) error = %v, wantErr %v", tt.min, err, tt.wantErr)
+		}
+	}
+}
+
+func TestProtocolTracker(t *testing.T) {
+	tracker := NewProtocolTracker(ProtocolConfig{MinPeerVersion: 2, UpgradeHint: "https://example.org/releases"})
+
+	tracker.Observe(peer.ID("a"), 1)
+	tracker.Observe(peer.ID("b"), 2)
+	tracker.Observe(peer.ID("c"), 2)
+	tracker.Observe(peer.ID("a"), 2) // Upgraded
+	tracker.Observe("", 1)
+
+	if dist := tracker.Distribution(); len(dist) != 1 || dist[2] != 3 {
+		t.Errorf("Distribution() = %v, want 3 peers on version 2", dist)
+	}
+	if v, ok := tracker.Version(peer.ID("a")); !ok || v != 2 {
+		t.Errorf("Version(a) = %d, %v", v, ok)
+	}
+	if _, ok := tracker.Version(peer.ID("d")); ok {
+		t.Error("Version() of an unseen peer")
+	}
+
+	if refusal := tracker.Check(2); refusal != nil {
+		t.Errorf("Check(2) = %+v, want nil", refusal)
+	}
+	refusal := tracker.Check(1)
+	if refusal == nil || refusal.Code != RefusalProtocolTooOld || refusal.MinVersion != 2 ||
+		refusal.OurVersion != ProtocolVersion || refusal.UpgradeHint != "https://example.org/releases" {
+		t.Errorf("Check(1) = %+v", refusal)
+	}
+
+	// A nil tracker ignores observations
+	var none *ProtocolTracker
+	none.Observe(peer.ID("a"), 1)
+}
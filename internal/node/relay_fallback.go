@@ -0,0 +1,192 @@
+// Package node - Circuit relay fallback for direct swap messages.
+//
+// When the counterparty can't be dialed directly mid-swap (a NAT change, a
+// lost port mapping), direct messages are routed through a circuit relay
+// before falling back to encrypted PubSub. The relayed connection carries the
+// same Noise/TLS session end to end, so the relay only forwards ciphertext.
+package node
+
+import (
+	"context"
+	"sync"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+)
+
+// Delivery paths reported in DeliveryPathEvent.Path.
+const (
+	DeliveryPathDirect = "direct" // Stream over a direct connection
+	DeliveryPathRelay  = "relay"  // Stream over a circuit relay connection
+	DeliveryPathPubSub = "pubsub" // Encrypted PubSub gossip
+)
+
+// relayHopProtocol is the circuit relay v2 protocol relays serve.
+const relayHopProtocol = "/libp2p/circuit/relay/0.2.0/hop"
+
+// DeliveryPathEvent reports that messages of a trade switched delivery path.
+type DeliveryPathEvent struct {
+	TradeID  string `json:"trade_id"`
+	PeerID   string `json:"peer_id"`
+	Path     string `json:"path"`
+	Previous string `json:"previous,omitempty"`
+	Relay    string `json:"relay,omitempty"` // Relay peer for DeliveryPathRelay
+}
+
+// Degraded returns true if the path is not a direct connection.
+func (e DeliveryPathEvent) Degraded() bool {
+	return e.Path != DeliveryPathDirect
+}
+
+// deliveryPaths tracks the last delivery path per trade.
+type deliveryPaths struct {
+	mu    sync.Mutex
+	paths map[string]string
+}
+
+// record stores the path of a trade and returns the previous one and
+// whether it changed.
+func (d *deliveryPaths) record(tradeID, path string) (string, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paths == nil {
+		d.paths = make(map[string]string)
+	}
+	prev := d.paths[tradeID]
+	d.paths[tradeID] = path
+	return prev, prev != path
+}
+
+// OnDeliveryPath registers a callback invoked when a trade's direct messages
+// switch delivery path. The first delivery of a trade is reported only if it
+// isn't direct.
+func (n *Node) OnDeliveryPath(fn func(DeliveryPathEvent)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.onDeliveryPath = append(n.onDeliveryPath, fn)
+}
+
+// recordDeliveryPath notes the path a trade's message was delivered on and
+// notifies the callbacks when it changed.
+func (s *MessageSender) recordDeliveryPath(tradeID string, peerID peer.ID, path string, relay peer.ID) {
+	if tradeID == "" {
+		return
+	}
+	prev, changed := s.paths.record(tradeID, path)
+	if !changed || (prev == "" && path == DeliveryPathDirect) {
+		return
+	}
+
+	event := DeliveryPathEvent{TradeID: tradeID, PeerID: peerID.String(), Path: path, Previous: prev}
+	if relay != "" {
+		event.Relay = relay.String()
+	}
+	if event.Degraded() {
+		s.log.Warn("Swap messages using a degraded path",
+			"trade_id", tradeID, "peer", shortPeerID(peerID), "path", path, "relay", event.Relay)
+	} else {
+		s.log.Info("Swap messages back on a direct connection", "trade_id", tradeID, "peer", shortPeerID(peerID))
+	}
+
+	s.node.mu.RLock()
+	callbacks := append([]func(DeliveryPathEvent){}, s.node.onDeliveryPath...)
+	s.node.mu.RUnlock()
+	for _, fn := range callbacks {
+		fn(event)
+	}
+}
+
+// isConnected returns true if we have a direct or relayed connection to p.
+func (s *MessageSender) isConnected(p peer.ID) bool {
+	c := s.node.Host().Network().Connectedness(p)
+	return c == network.Connected || c == network.Limited
+}
+
+// connectionPath returns how we are connected to p: DeliveryPathDirect if
+// any connection is direct, otherwise DeliveryPathRelay and the relay, or
+// empty when not connected.
+func (s *MessageSender) connectionPath(p peer.ID) (string, peer.ID) {
+	conns := s.node.Host().Network().ConnsToPeer(p)
+	if len(conns) == 0 {
+		return "", ""
+	}
+	var relay peer.ID
+	for _, conn := range conns {
+		addr := conn.RemoteMultiaddr()
+		if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err != nil {
+			return DeliveryPathDirect, ""
+		}
+		if relays := relaysFromAddrs([]multiaddr.Multiaddr{addr}); len(relays) > 0 {
+			relay = relays[0]
+		}
+	}
+	return DeliveryPathRelay, relay
+}
+
+// relaysFromAddrs returns the relays of the circuit addresses in addrs.
+func relaysFromAddrs(addrs []multiaddr.Multiaddr) []peer.ID {
+	var relays []peer.ID
+	for _, addr := range addrs {
+		if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err != nil {
+			continue
+		}
+		// The relay is the last /p2p component before /p2p-circuit
+		relayAddr, _ := multiaddr.SplitFunc(addr, func(c multiaddr.Component) bool {
+			return c.Protocol().Code == multiaddr.P_CIRCUIT
+		})
+		if relayAddr == nil {
+			continue
+		}
+		if id, err := peer.IDFromP2PAddr(relayAddr); err == nil {
+			relays = append(relays, id)
+		}
+	}
+	return relays
+}
+
+// relayCandidates returns the relays to try for target: those in the
+// target's known circuit addresses first, then connected peers serving the
+// relay protocol, at most MaxRelayCandidates.
+func (s *MessageSender) relayCandidates(target peer.ID) []peer.ID {
+	h := s.node.Host()
+	seen := map[peer.ID]bool{target: true, h.ID(): true}
+	var out []peer.ID
+	add := func(p peer.ID) {
+		if seen[p] || len(out) >= s.config.MaxRelayCandidates {
+			return
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+
+	for _, p := range relaysFromAddrs(h.Peerstore().Addrs(target)) {
+		add(p)
+	}
+	for _, p := range h.Network().Peers() {
+		if protos, err := h.Peerstore().SupportsProtocols(p, relayHopProtocol); err == nil && len(protos) > 0 {
+			add(p)
+		}
+	}
+	return out
+}
+
+// tryConnectViaRelay connects to target through a circuit relay and returns
+// the relay used.
+func (s *MessageSender) tryConnectViaRelay(ctx context.Context, target peer.ID) (peer.ID, bool) {
+	for _, relay := range s.relayCandidates(target) {
+		circuit, err := multiaddr.NewMultiaddr("/p2p/" + relay.String() + "/p2p-circuit")
+		if err != nil {
+			continue
+		}
+		connectCtx, cancel := context.WithTimeout(ctx, s.config.ConnectTimeout)
+		err = s.node.Host().Connect(connectCtx, peer.AddrInfo{ID: target, Addrs: []multiaddr.Multiaddr{circuit}})
+		cancel()
+		if err != nil {
+			s.log.Debug("Relay connection failed", "peer", shortPeerID(target), "relay", shortPeerID(relay), "error", err)
+			continue
+		}
+		return relay, true
+	}
+	return "", false
+}
@@ -0,0 +1,63 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+const (
+	testRelayID  = "12D3KooWGRUVh2GPzJ8BZrFYVrBgyNiuyCxUZXqAk5ZK3QqBxQzY"
+	testTargetID = "12D3KooWHHzSeKaY8xuZVzkLbKFfvNgPPeKhFBGrMbNzbm5akpqu"
+)
+
+func TestRelaysFromAddrs(t *testing.T) {
+	addrs := []multiaddr.Multiaddr{
+		multiaddr.StringCast("/ip4/1.2.3.4/tcp/4001"),
+		multiaddr.StringCast("/ip4/5.6.7.8/tcp/4001/p2p/" + testRelayID + "/p2p-circuit"),
+		multiaddr.StringCast("/p2p-circuit"),
+	}
+	relays := relaysFromAddrs(addrs)
+	if len(relays) != 1 || relays[0].String() != testRelayID {
+		t.Errorf("relaysFromAddrs() = %v, want [%s]", relays, testRelayID)
+	}
+}
+
+func TestRecordDeliveryPath(t *testing.T) {
+	n := &Node{}
+	var events []DeliveryPathEvent
+	n.OnDeliveryPath(func(e DeliveryPathEvent) { events = append(events, e) })
+	s := &MessageSender{node: n, log: logging.GetDefault().Component("test")}
+
+	target, _ := peer.Decode(testTargetID)
+	relay, _ := peer.Decode(testRelayID)
+
+	// A direct first delivery is the normal case and not reported
+	s.recordDeliveryPath("trade-1", target, DeliveryPathDirect, "")
+	s.recordDeliveryPath("trade-1", target, DeliveryPathDirect, "")
+	if len(events) != 0 {
+		t.Fatalf("events = %+v, want none", events)
+	}
+
+	s.recordDeliveryPath("trade-1", target, DeliveryPathRelay, relay)
+	s.recordDeliveryPath("trade-1", target, DeliveryPathRelay, relay)
+	s.recordDeliveryPath("trade-1", target, DeliveryPathDirect, "")
+	if len(events) != 2 {
+		t.Fatalf("events = %+v, want 2", events)
+	}
+	if e := events[0]; !e.Degraded() || e.Path != DeliveryPathRelay || e.Previous != DeliveryPathDirect || e.Relay != testRelayID {
+		t.Errorf("first event = %+v, want degraded to relay", e)
+	}
+	if e := events[1]; e.Degraded() || e.Previous != DeliveryPathRelay {
+		t.Errorf("second event = %+v, want back to direct", e)
+	}
+
+	// A trade starting over PubSub is reported
+	s.recordDeliveryPath("trade-2", target, DeliveryPathPubSub, "")
+	if len(events) != 3 || events[2].TradeID != "trade-2" || events[2].Previous != "" {
+		t.Errorf("events = %+v, want trade-2 on pubsub", events)
+	}
+}
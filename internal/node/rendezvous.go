@@ -0,0 +1,702 @@
+// Package node - Rendezvous discovery for private trading groups.
+//
+// Members of a private group register with designated rendezvous nodes and
+// ask them for the other members, so a closed OTC network runs without the
+// public DHT. Groups register under a namespace derived from the group key:
+// a rendezvous node learns neither the group's name nor its key. Records
+// carry an HMAC under the key, so members drop records of peers that don't
+// hold it, whoever registered them.
+//
+// Rendezvous nodes also serve circuit relay, and members with relaying
+// enabled use them as static relays, so members behind NAT are reachable
+// through the rendezvous node (and hole-punched from there when enabled).
+package node
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// RendezvousProtocol is the protocol ID of rendezvous registration and
+// discovery.
+const RendezvousProtocol protocol.ID = "/klingon/rendezvous/1.0.0"
+
+// Rendezvous limits.
+const (
+	maxRendezvousTTL      = 72 * time.Hour // Longest registration a point accepts
+	maxRendezvousAddrs    = 16             // Addresses of one record
+	maxRendezvousDiscover = 100            // Records of one discovery response
+	rendezvousTimeout     = 30 * time.Second
+)
+
+// Rendezvous request types.
+const (
+	rendezvousRegister   = "register"
+	rendezvousUnregister = "unregister"
+	rendezvousDiscover   = "discover"
+)
+
+// ErrRendezvousFull is returned by a rendezvous point keeping its maximum
+// number of registrations.
+var ErrRendezvousFull = errors.New("rendezvous point full")
+
+// RendezvousConfig configures rendezvous discovery of private trading
+// groups.
+type RendezvousConfig struct {
+	// Serve makes this node a rendezvous point: it keeps the registrations
+	// of any group and relays connections to members behind NAT.
+	Serve bool `yaml:"serve,omitempty"`
+
+	// Points are the multiaddrs, with /p2p/, of the rendezvous nodes our
+	// groups register with. A node that serves also registers with itself.
+	Points []string `yaml:"points,omitempty"`
+
+	// Groups are the private groups we are a member of.
+	Groups []RendezvousGroup `yaml:"groups,omitempty"`
+
+	// TTL is how long a registration lasts; it is renewed at half of it.
+	TTL time.Duration `yaml:"ttl,omitempty"`
+
+	// DiscoverInterval is how often the points are asked for members.
+	DiscoverInterval time.Duration `yaml:"discover_interval,omitempty"`
+
+	// MaxRegistrations bounds the registrations a rendezvous point keeps.
+	MaxRegistrations int `yaml:"max_registrations,omitempty"`
+}
+
+// RendezvousGroup is a private group and the key its members share.
+type RendezvousGroup struct {
+	Name string `yaml:"name"` // Local label, never sent
+	Key  string `yaml:"key"`  // 32 random bytes, hex
+}
+
+// Enabled returns true if the node serves or is a member of a group.
+func (c *RendezvousConfig) Enabled() bool {
+	return c.Serve || len(c.Groups) > 0
+}
+
+// Validate checks the configuration.
+func (c *RendezvousConfig) Validate() error {
+	if !c.Enabled() {
+		return nil
+	}
+	if _, err := c.pointInfos(); err != nil {
+		return err
+	}
+	if len(c.Groups) > 0 && len(c.Points) == 0 && !c.Serve {
+		return fmt.Errorf("rendezvous.groups need rendezvous.points or rendezvous.serve")
+	}
+	names := make(map[string]bool)
+	for i, g := range c.Groups {
+		if g.Name == "" {
+			return fmt.Errorf("rendezvous.groups[%d]: name is required", i)
+		}
+		if names[g.Name] {
+			return fmt.Errorf("rendezvous.groups: duplicate group %q", g.Name)
+		}
+		names[g.Name] = true
+		if key, err := hex.DecodeString(g.Key); err != nil || len(key) != 32 {
+			return fmt.Errorf("rendezvous.groups[%s]: key must be 32 bytes of hex", g.Name)
+		}
+	}
+	if c.TTL < time.Minute || c.TTL > maxRendezvousTTL {
+		return fmt.Errorf("rendezvous.ttl must be between 1m and %s", maxRendezvousTTL)
+	}
+	if c.DiscoverInterval < time.Second {
+		return fmt.Errorf("rendezvous.discover_interval must be at least 1s")
+	}
+	if c.Serve && c.MaxRegistrations <= 0 {
+		return fmt.Errorf("rendezvous.max_registrations must be positive")
+	}
+	return nil
+}
+
+// pointInfos parses the rendezvous points.
+func (c *RendezvousConfig) pointInfos() ([]peer.AddrInfo, error) {
+	infos := make([]peer.AddrInfo, 0, len(c.Points))
+	for _, addr := range c.Points {
+		ma, err := multiaddr.NewMultiaddr(addr)
+		if err != nil {
+			return nil, fmt.Errorf("rendezvous.points: invalid address %s: %w", addr, err)
+		}
+		pi, err := peer.AddrInfoFromP2pAddr(ma)
+		if err != nil {
+			return nil, fmt.Errorf("rendezvous.points: %s has no /p2p/ peer ID: %w", addr, err)
+		}
+		infos = append(infos, *pi)
+	}
+	return infos, nil
+}
+
+// RendezvousRecord is a member's registration.
+type RendezvousRecord struct {
+	Namespace string   `json:"ns"`
+	PeerID    string   `json:"peer_id"`
+	Addrs     []string `json:"addrs,omitempty"`
+	Expires   int64    `json:"expires"` // Unix seconds
+	Proof     string   `json:"proof"`   // HMAC-SHA256 under the group key, hex
+
+	// ObservedAddr is the address the point saw the member connect from,
+	// a hint for members behind NAT. The proof doesn't cover it.
+	ObservedAddr string `json:"observed_addr,omitempty"`
+}
+
+// rendezvousRequest is one request to a rendezvous point.
+type rendezvousRequest struct {
+	Type      string            `json:"type"`
+	Namespace string            `json:"ns,omitempty"`     // Unregister and discover
+	Record    *RendezvousRecord `json:"record,omitempty"` // Register
+}
+
+// rendezvousResponse answers a rendezvousRequest.
+type rendezvousResponse struct {
+	OK      bool                `json:"ok"`
+	Error   string              `json:"error,omitempty"`
+	Records []*RendezvousRecord `json:"records,omitempty"`
+}
+
+// RendezvousStatus reports the rendezvous service.
+type RendezvousStatus struct {
+	Serving       bool                    `json:"serving"`
+	Registrations int                     `json:"registrations,omitempty"` // Kept as a point
+	Groups        []RendezvousGroupStatus `json:"groups,omitempty"`
+}
+
+// RendezvousGroupStatus reports one group we are a member of.
+type RendezvousGroupStatus struct {
+	Name          string    `json:"name"`
+	Namespace     string    `json:"namespace"`
+	Registered    []string  `json:"registered,omitempty"` // Points holding our registration
+	Members       []string  `json:"members,omitempty"`    // Verified members last discovered
+	LastDiscovery time.Time `json:"last_discovery,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+}
+
+// rendezvousGroup is a group we are a member of.
+type rendezvousGroup struct {
+	name string
+	key  []byte
+	ns   string
+
+	// Guarded by RendezvousService.mu
+	registered    map[peer.ID]time.Time // Point -> when we registered
+	members       map[peer.ID]time.Time // Member -> record expiry
+	lastDiscovery time.Time
+	lastError     string
+}
+
+// rendezvousNamespace derives the namespace of a group key.
+func rendezvousNamespace(key []byte) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("klingdex/rendezvous/namespace/v1"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// proof returns the HMAC of a record under the group key.
+func (g *rendezvousGroup) proof(r *RendezvousRecord) string {
+	mac := hmac.New(sha256.New, g.key)
+	mac.Write([]byte("klingdex/rendezvous/record/v1\n"))
+	mac.Write([]byte(r.Namespace + "\n" + r.PeerID + "\n" + strconv.FormatInt(r.Expires, 10) + "\n"))
+	mac.Write([]byte(strings.Join(r.Addrs, "\n")))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a record was made by a holder of the group key.
+func (g *rendezvousGroup) verify(r *RendezvousRecord) bool {
+	if r.Namespace != g.ns {
+		return false
+	}
+	want, _ := hex.DecodeString(g.proof(r))
+	got, err := hex.DecodeString(r.Proof)
+	return err == nil && hmac.Equal(want, got)
+}
+
+// RendezvousService registers our groups with the rendezvous points,
+// connects to the members they know and, when serving, keeps the
+// registrations of others.
+type RendezvousService struct {
+	h      host.Host
+	cfg    RendezvousConfig
+	points []peer.AddrInfo
+	groups []*rendezvousGroup
+	log    *logging.Logger
+
+	mu   sync.Mutex
+	regs map[string]map[peer.ID]*RendezvousRecord // Served: namespace -> member -> record
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{} // Closed when run returns; nil when not running
+}
+
+// NewRendezvousService creates the rendezvous service of a host. The
+// configuration must be valid.
+func NewRendezvousService(h host.Host, cfg RendezvousConfig) (*RendezvousService, error) {
+	points, err := cfg.pointInfos()
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Serve {
+		points = append(points, peer.AddrInfo{ID: h.ID()})
+	}
+	s := &RendezvousService{
+		h:      h,
+		cfg:    cfg,
+		points: points,
+		log:    logging.GetDefault().Component("rendezvous"),
+		regs:   make(map[string]map[peer.ID]*RendezvousRecord),
+	}
+	for _, g := range cfg.Groups {
+		key, err := hex.DecodeString(g.Key)
+		if err != nil || len(key) != 32 {
+			return nil, fmt.Errorf("rendezvous group %s: key must be 32 bytes of hex", g.Name)
+		}
+		s.groups = append(s.groups, &rendezvousGroup{
+			name:       g.Name,
+			key:        key,
+			ns:         rendezvousNamespace(key),
+			registered: make(map[peer.ID]time.Time),
+			members:    make(map[peer.ID]time.Time),
+		})
+	}
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+	return s, nil
+}
+
+// Start serves registrations, if configured, and starts registering and
+// discovering our groups.
+func (s *RendezvousService) Start() {
+	if s.cfg.Serve {
+		s.h.SetStreamHandler(RendezvousProtocol, s.handleStream)
+	}
+	if len(s.groups) == 0 {
+		return
+	}
+	s.done = make(chan struct{})
+	go s.run()
+	s.log.Info("Rendezvous discovery started", "groups", len(s.groups), "points", len(s.points), "serving", s.cfg.Serve)
+}
+
+// Stop withdraws our registrations and stops the service.
+func (s *RendezvousService) Stop() {
+	s.cancel()
+	if s.done != nil {
+		<-s.done
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for _, g := range s.groups {
+		s.mu.Lock()
+		points := make([]peer.ID, 0, len(g.registered))
+		for p := range g.registered {
+			points = append(points, p)
+		}
+		s.mu.Unlock()
+		for _, p := range points {
+			req := &rendezvousRequest{Type: rendezvousUnregister, Namespace: g.ns}
+			if _, err := s.request(ctx, peer.AddrInfo{ID: p}, req); err != nil {
+				s.log.Debug("Rendezvous unregister failed", "point", shortID(p), "error", err)
+			}
+		}
+	}
+	if s.cfg.Serve {
+		s.h.RemoveStreamHandler(RendezvousProtocol)
+	}
+}
+
+// run refreshes our groups until the service stops.
+func (s *RendezvousService) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.cfg.DiscoverInterval)
+	defer ticker.Stop()
+	for {
+		s.Refresh(s.ctx)
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Refresh renews registrations due for renewal, discovers the members of
+// our groups and connects to those we aren't connected to.
+func (s *RendezvousService) Refresh(ctx context.Context) {
+	for _, g := range s.groups {
+		var errs []string
+		found := make(map[peer.ID]*RendezvousRecord)
+		for _, point := range s.points {
+			if err := s.register(ctx, g, point); err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", shortID(point.ID), err))
+				continue
+			}
+			records, err := s.discover(ctx, g, point)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s: %v", shortID(point.ID), err))
+				continue
+			}
+			for _, r := range records {
+				id, _ := peer.Decode(r.PeerID)
+				if prev, ok := found[id]; !ok || r.Expires > prev.Expires {
+					found[id] = r
+				}
+			}
+		}
+
+		now := time.Now()
+		s.mu.Lock()
+		g.lastDiscovery = now
+		g.lastError = strings.Join(errs, "; ")
+		for id, expires := range g.members {
+			if now.After(expires) {
+				delete(g.members, id)
+			}
+		}
+		for id, r := range found {
+			g.members[id] = time.Unix(r.Expires, 0)
+		}
+		s.mu.Unlock()
+
+		for id, r := range found {
+			s.connect(ctx, id, r)
+		}
+	}
+}
+
+// register registers us in a group with a point, unless a registration
+// made less than half a TTL ago stands.
+func (s *RendezvousService) register(ctx context.Context, g *rendezvousGroup, point peer.AddrInfo) error {
+	s.mu.Lock()
+	at, ok := g.registered[point.ID]
+	s.mu.Unlock()
+	if ok && time.Since(at) < s.cfg.TTL/2 {
+		return nil
+	}
+
+	rec := &RendezvousRecord{
+		Namespace: g.ns,
+		PeerID:    s.h.ID().String(),
+		Expires:   time.Now().Add(s.cfg.TTL).Unix(),
+	}
+	for _, addr := range s.h.Addrs() {
+		if len(rec.Addrs) == maxRendezvousAddrs {
+			break
+		}
+		rec.Addrs = append(rec.Addrs, addr.String())
+	}
+	rec.Proof = g.proof(rec)
+
+	if _, err := s.request(ctx, point, &rendezvousRequest{Type: rendezvousRegister, Record: rec}); err != nil {
+		s.mu.Lock()
+		delete(g.registered, point.ID)
+		s.mu.Unlock()
+		return fmt.Errorf("register: %w", err)
+	}
+	s.mu.Lock()
+	g.registered[point.ID] = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// discover asks a point for the members of a group and returns the records
+// proven by a key holder, other than ours.
+func (s *RendezvousService) discover(ctx context.Context, g *rendezvousGroup, point peer.AddrInfo) ([]*RendezvousRecord, error) {
+	resp, err := s.request(ctx, point, &rendezvousRequest{Type: rendezvousDiscover, Namespace: g.ns})
+	if err != nil {
+		return nil, fmt.Errorf("discover: %w", err)
+	}
+	now := time.Now().Unix()
+	var records []*RendezvousRecord
+	for _, r := range resp.Records {
+		id, err := peer.Decode(r.PeerID)
+		if err != nil || id == s.h.ID() || r.Expires <= now {
+			continue
+		}
+		if !g.verify(r) {
+			s.log.Warn("Dropping rendezvous record without a valid group proof", "group", g.name, "peer", shortID(id), "point", shortID(point.ID))
+			continue
+		}
+		records = append(records, r)
+	}
+	return records, nil
+}
+
+// connect adds a member's addresses and connects to it.
+func (s *RendezvousService) connect(ctx context.Context, id peer.ID, r *RendezvousRecord) {
+	addrs := append([]string{}, r.Addrs...)
+	if r.ObservedAddr != "" {
+		addrs = append(addrs, r.ObservedAddr)
+	}
+	info := peer.AddrInfo{ID: id}
+	for _, a := range addrs {
+		if ma, err := multiaddr.NewMultiaddr(a); err == nil {
+			info.Addrs = append(info.Addrs, ma)
+		}
+	}
+	if ttl := time.Until(time.Unix(r.Expires, 0)); ttl > 0 {
+		s.h.Peerstore().AddAddrs(id, info.Addrs, ttl)
+	}
+	if s.h.Network().Connectedness(id) == network.Connected {
+		return
+	}
+	connectCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if err := s.h.Connect(connectCtx, info); err != nil {
+		s.log.Debug("Failed to connect to group member", "peer", shortID(id), "error", err)
+		return
+	}
+	s.log.Info("Connected to group member", "peer", shortID(id))
+}
+
+// request sends a request to a point and returns its answer. Requests to
+// ourselves are served in process.
+func (s *RendezvousService) request(ctx context.Context, point peer.AddrInfo, req *rendezvousRequest) (*rendezvousResponse, error) {
+	var resp *rendezvousResponse
+	if point.ID == s.h.ID() {
+		resp = s.serve(s.h.ID(), nil, req)
+	} else {
+		var err error
+		if resp, err = s.roundTrip(ctx, point, req); err != nil {
+			return nil, err
+		}
+	}
+	if !resp.OK {
+		return nil, errors.New(resp.Error)
+	}
+	return resp, nil
+}
+
+// roundTrip sends a request over a new stream to a point.
+func (s *RendezvousService) roundTrip(ctx context.Context, point peer.AddrInfo, req *rendezvousRequest) (*rendezvousResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, rendezvousTimeout)
+	defer cancel()
+	if len(point.Addrs) > 0 {
+		if err := s.h.Connect(ctx, point); err != nil {
+			return nil, fmt.Errorf("connect: %w", err)
+		}
+	}
+	st, err := s.h.NewStream(ctx, point.ID, RendezvousProtocol)
+	if err != nil {
+		return nil, err
+	}
+	defer st.Close()
+	deadline, _ := ctx.Deadline()
+	st.SetDeadline(deadline)
+
+	data, err := json.Marshal(req)
+	if err != nil {
+		st.Reset()
+		return nil, err
+	}
+	if err := writeLengthPrefixed(st, data); err != nil {
+		st.Reset()
+		return nil, err
+	}
+	if data, err = readLengthPrefixed(st); err != nil {
+		st.Reset()
+		return nil, err
+	}
+	var resp rendezvousResponse
+	if err := RendezvousLimits.Unmarshal(data, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// handleStream serves one request of a member.
+func (s *RendezvousService) handleStream(st network.Stream) {
+	defer st.Close()
+	st.SetDeadline(time.Now().Add(rendezvousTimeout))
+	from := st.Conn().RemotePeer()
+
+	data, err := readLengthPrefixed(st)
+	if err != nil {
+		st.Reset()
+		return
+	}
+	var req rendezvousRequest
+	var resp *rendezvousResponse
+	if err := RendezvousLimits.Unmarshal(data, &req); err != nil {
+		resp = &rendezvousResponse{Error: err.Error()}
+	} else {
+		resp = s.serve(from, st.Conn().RemoteMultiaddr(), &req)
+	}
+	if data, err = json.Marshal(resp); err == nil {
+		if err := writeLengthPrefixed(st, data); err != nil {
+			s.log.Debug("Failed to answer rendezvous request", "peer", shortID(from), "error", err)
+		}
+	}
+}
+
+// serve handles a request of peer from, which connected from observed (nil
+// in process).
+func (s *RendezvousService) serve(from peer.ID, observed multiaddr.Multiaddr, req *rendezvousRequest) *rendezvousResponse {
+	if !s.cfg.Serve {
+		return &rendezvousResponse{Error: "not a rendezvous point"}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+
+	switch req.Type {
+	case rendezvousRegister:
+		r := req.Record
+		if r == nil || !validNamespace(r.Namespace) || r.PeerID != from.String() {
+			return &rendezvousResponse{Error: "invalid registration"}
+		}
+		if len(r.Addrs) > maxRendezvousAddrs {
+			return &rendezvousResponse{Error: "too many addresses"}
+		}
+		// The proof covers the expiry, so a point can't shorten it
+		now := time.Now()
+		if r.Expires <= now.Unix() {
+			return &rendezvousResponse{Error: "registration expired"}
+		}
+		if r.Expires > now.Add(maxRendezvousTTL).Unix() {
+			return &rendezvousResponse{Error: "registration too long"}
+		}
+		rec := *r
+		rec.ObservedAddr = ""
+		if observed != nil && !isCircuitAddr(observed) {
+			rec.ObservedAddr = observed.String()
+		}
+
+		members := s.regs[rec.Namespace]
+		if _, renewal := members[from]; !renewal && s.countLocked() >= s.cfg.MaxRegistrations {
+			return &rendezvousResponse{Error: ErrRendezvousFull.Error()}
+		}
+		if members == nil {
+			members = make(map[peer.ID]*RendezvousRecord)
+			s.regs[rec.Namespace] = members
+		}
+		members[from] = &rec
+		return &rendezvousResponse{OK: true}
+
+	case rendezvousUnregister:
+		if members, ok := s.regs[req.Namespace]; ok {
+			delete(members, from)
+			if len(members) == 0 {
+				delete(s.regs, req.Namespace)
+			}
+		}
+		return &rendezvousResponse{OK: true}
+
+	case rendezvousDiscover:
+		if !validNamespace(req.Namespace) {
+			return &rendezvousResponse{Error: "invalid namespace"}
+		}
+		resp := &rendezvousResponse{OK: true}
+		for id, r := range s.regs[req.Namespace] {
+			if id != from {
+				resp.Records = append(resp.Records, r)
+			}
+		}
+		sort.Slice(resp.Records, func(i, j int) bool { return resp.Records[i].PeerID < resp.Records[j].PeerID })
+		if len(resp.Records) > maxRendezvousDiscover {
+			resp.Records = resp.Records[:maxRendezvousDiscover]
+		}
+		return resp
+
+	default:
+		return &rendezvousResponse{Error: fmt.Sprintf("unknown request type %q", req.Type)}
+	}
+}
+
+// pruneLocked drops expired registrations. Caller must hold s.mu.
+func (s *RendezvousService) pruneLocked(now time.Time) {
+	for ns, members := range s.regs {
+		for id, r := range members {
+			if r.Expires <= now.Unix() {
+				delete(members, id)
+			}
+		}
+		if len(members) == 0 {
+			delete(s.regs, ns)
+		}
+	}
+}
+
+// countLocked returns the number of registrations kept. Caller must hold
+// s.mu.
+func (s *RendezvousService) countLocked() int {
+	n := 0
+	for _, members := range s.regs {
+		n += len(members)
+	}
+	return n
+}
+
+// Members returns the verified members of a group last discovered.
+func (s *RendezvousService) Members(group string) []peer.ID {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, g := range s.groups {
+		if g.name != group {
+			continue
+		}
+		ids := make([]peer.ID, 0, len(g.members))
+		for id := range g.members {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return ids
+	}
+	return nil
+}
+
+// Status reports the registrations kept and our groups.
+func (s *RendezvousService) Status() RendezvousStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pruneLocked(time.Now())
+	st := RendezvousStatus{Serving: s.cfg.Serve, Registrations: s.countLocked()}
+	for _, g := range s.groups {
+		gs := RendezvousGroupStatus{
+			Name:          g.name,
+			Namespace:     g.ns,
+			LastDiscovery: g.lastDiscovery,
+			LastError:     g.lastError,
+		}
+		for p := range g.registered {
+			gs.Registered = append(gs.Registered, p.String())
+		}
+		for id := range g.members {
+			gs.Members = append(gs.Members, id.String())
+		}
+		sort.Strings(gs.Registered)
+		sort.Strings(gs.Members)
+		st.Groups = append(st.Groups, gs)
+	}
+	return st
+}
+
+// validNamespace returns true for a derived namespace: 32 bytes of hex.
+func validNamespace(ns string) bool {
+	b, err := hex.DecodeString(ns)
+	return err == nil && len(b) == sha256.Size
+}
+
+// isCircuitAddr returns true for a circuit relay address.
+func isCircuitAddr(addr multiaddr.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT)
+	return err == nil
+}
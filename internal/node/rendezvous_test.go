@@ -0,0 +1,217 @@
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+)
+
+const (
+	testGroupKey  = "0101010101010101010101010101010101010101010101010101010101010101"
+	otherGroupKey = "0202020202020202020202020202020202020202020202020202020202020202"
+)
+
+// newTestRendezvous creates the rendezvous service of a host.
+func newTestRendezvous(t *testing.T, h host.Host, cfg RendezvousConfig) *RendezvousService {
+	t.Helper()
+	cfg.TTL = time.Hour
+	cfg.DiscoverInterval = time.Hour
+	if cfg.Serve {
+		cfg.MaxRegistrations = 4
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	s, err := NewRendezvousService(h, cfg)
+	if err != nil {
+		t.Fatalf("NewRendezvousService() error = %v", err)
+	}
+	return s
+}
+
+func TestRendezvousDiscovery(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(4)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	pointAddr := hosts[0].Addrs()[0].String() + "/p2p/" + hosts[0].ID().String()
+	member := func(h host.Host, key string) *RendezvousService {
+		return newTestRendezvous(t, h, RendezvousConfig{
+			Points: []string{pointAddr},
+			Groups: []RendezvousGroup{{Name: "otc", Key: key}},
+		})
+	}
+
+	point := newTestRendezvous(t, hosts[0], RendezvousConfig{Serve: true})
+	point.Start()
+	defer point.Stop()
+	alice := member(hosts[1], testGroupKey)
+	bob := member(hosts[2], testGroupKey)
+	mallory := member(hosts[3], otherGroupKey)
+	ctx := context.Background()
+
+	alice.Refresh(ctx)
+	mallory.Refresh(ctx)
+	if got := alice.Members("otc"); len(got) != 0 {
+		t.Fatalf("alice members = %v before anyone else registered", got)
+	}
+
+	// A peer that learned the namespace but not the key registers in it
+	ns := rendezvousNamespace(mustHex(t, testGroupKey))
+	forged := &RendezvousRecord{
+		Namespace: ns,
+		PeerID:    hosts[3].ID().String(),
+		Expires:   time.Now().Add(time.Hour).Unix(),
+		Proof:     strings.Repeat("00", 32),
+	}
+	if _, err := mallory.request(ctx, peer.AddrInfo{ID: hosts[0].ID()}, &rendezvousRequest{Type: rendezvousRegister, Record: forged}); err != nil {
+		t.Fatalf("forged register error = %v", err)
+	}
+
+	bob.Refresh(ctx)
+	if got := bob.Members("otc"); len(got) != 1 || got[0] != hosts[1].ID() {
+		t.Fatalf("bob members = %v, want alice only", got)
+	}
+	if hosts[2].Network().Connectedness(hosts[1].ID()) != network.Connected {
+		t.Error("bob not connected to alice")
+	}
+	if hosts[2].Network().Connectedness(hosts[3].ID()) == network.Connected {
+		t.Error("bob connected to mallory")
+	}
+
+	alice.Refresh(ctx)
+	if got := alice.Members("otc"); len(got) != 1 || got[0] != hosts[2].ID() {
+		t.Errorf("alice members = %v, want bob", got)
+	}
+	if got := mallory.Members("otc"); len(got) != 0 {
+		t.Errorf("mallory members = %v, want none in its own group", got)
+	}
+
+	st := bob.Status()
+	if len(st.Groups) != 1 || st.Groups[0].Namespace != ns || st.Groups[0].LastError != "" ||
+		len(st.Groups[0].Registered) != 1 || st.Groups[0].Registered[0] != hosts[0].ID().String() {
+		t.Errorf("bob status = %+v", st)
+	}
+	if st := point.Status(); !st.Serving || st.Registrations != 4 {
+		t.Errorf("point status = %+v, want 4 registrations", st)
+	}
+
+	// The point is full; renewals still pass
+	if err := bob.register(ctx, bob.groups[0], peer.AddrInfo{ID: hosts[0].ID()}); err != nil {
+		t.Errorf("renewal error = %v", err)
+	}
+
+	// Stopping withdraws the registration
+	bob.Start()
+	bob.Stop()
+	if st := point.Status(); st.Registrations != 3 {
+		t.Errorf("point registrations = %d after bob stopped, want 3", st.Registrations)
+	}
+}
+
+func TestRendezvousServe(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(1)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	h := mn.Hosts()[0]
+	s := newTestRendezvous(t, h, RendezvousConfig{
+		Serve:  true,
+		Groups: []RendezvousGroup{{Name: "otc", Key: testGroupKey}},
+	})
+	g := s.groups[0]
+	them, _ := peer.Decode("12D3KooWQYhTNQdmr3ArTeUHRYzFg94BKyTkoWBDWez9kSCVe2Xo")
+
+	record := func(id peer.ID, expires time.Time) *RendezvousRecord {
+		r := &RendezvousRecord{Namespace: g.ns, PeerID: id.String(), Expires: expires.Unix()}
+		r.Proof = g.proof(r)
+		return r
+	}
+	register := func(from peer.ID, r *RendezvousRecord) *rendezvousResponse {
+		return s.serve(from, nil, &rendezvousRequest{Type: rendezvousRegister, Record: r})
+	}
+
+	if resp := register(them, record(h.ID(), time.Now().Add(time.Hour))); resp.OK {
+		t.Error("registered a record of another peer")
+	}
+	if resp := register(them, record(them, time.Now().Add(-time.Minute))); resp.OK {
+		t.Error("registered an expired record")
+	}
+	if resp := register(them, record(them, time.Now().Add(2*maxRendezvousTTL))); resp.OK {
+		t.Error("registered a record past the longest TTL")
+	}
+	if resp := register(them, record(them, time.Now().Add(time.Hour))); !resp.OK {
+		t.Fatalf("register error = %s", resp.Error)
+	}
+	if resp := s.serve(them, nil, &rendezvousRequest{Type: rendezvousDiscover, Namespace: "nope"}); resp.OK {
+		t.Error("discovered an invalid namespace")
+	}
+
+	// We register with ourselves and find them
+	s.Refresh(context.Background())
+	if got := s.Members("otc"); len(got) != 1 || got[0] != them {
+		t.Errorf("Members() = %v, want them", got)
+	}
+	resp := s.serve(them, nil, &rendezvousRequest{Type: rendezvousDiscover, Namespace: g.ns})
+	if !resp.OK || len(resp.Records) != 1 || resp.Records[0].PeerID != h.ID().String() {
+		t.Errorf("discover = %+v, want our record only", resp)
+	}
+
+	if resp := s.serve(them, nil, &rendezvousRequest{Type: rendezvousUnregister, Namespace: g.ns}); !resp.OK {
+		t.Errorf("unregister error = %s", resp.Error)
+	}
+	if st := s.Status(); st.Registrations != 1 {
+		t.Errorf("registrations = %d after unregistering, want 1", st.Registrations)
+	}
+	if resp := s.serve(them, nil, &rendezvousRequest{Type: "flood"}); resp.OK {
+		t.Error("served an unknown request type")
+	}
+}
+
+func TestRendezvousRecordProof(t *testing.T) {
+	key := mustHex(t, testGroupKey)
+	g := &rendezvousGroup{key: key, ns: rendezvousNamespace(key)}
+	other := mustHex(t, otherGroupKey)
+	if g.ns == rendezvousNamespace(other) {
+		t.Fatal("two keys derive one namespace")
+	}
+
+	r := &RendezvousRecord{Namespace: g.ns, PeerID: "peer", Addrs: []string{"/ip4/1.2.3.4/tcp/1"}, Expires: 1}
+	r.Proof = g.proof(r)
+	if !g.verify(r) {
+		t.Fatal("verify() rejected a proven record")
+	}
+	r.ObservedAddr = "/ip4/5.6.7.8/tcp/2"
+	if !g.verify(r) {
+		t.Error("verify() rejected a record with an observed address")
+	}
+	r.Addrs = append(r.Addrs, "/ip4/6.6.6.6/tcp/6")
+	if g.verify(r) {
+		t.Error("verify() accepted changed addresses")
+	}
+	forged := &rendezvousGroup{key: other, ns: g.ns}
+	r.Addrs = r.Addrs[:1]
+	r.Proof = forged.proof(r)
+	if g.verify(r) {
+		t.Error("verify() accepted a proof under another key")
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
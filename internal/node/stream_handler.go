@@ -16,6 +16,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 
+	"github.com/Klingon-tech/klingdex/internal/chaos"
 	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
 )
@@ -68,7 +69,7 @@ func (h *StreamHandler) Stop() {
 func (h *StreamHandler) OnMessage(msgType string, handler SwapMessageHandler) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.handlers[msgType] = handler
+	h.handlers[msgType] = traceHandler(msgType, handler)
 }
 
 // handleStream handles an incoming direct stream.
@@ -91,11 +92,16 @@ func (h *StreamHandler) handleStream(s network.Stream) {
 
 	// Parse message
 	var msg SwapMessage
-	if err := json.Unmarshal(msgBytes, &msg); err != nil {
+	if err := SwapMessageLimits.Unmarshal(msgBytes, &msg); err != nil {
 		h.log.Warn("Failed to parse message", "peer", shortPeerID(remotePeer), "error", err)
 		return
 	}
 
+	// The stream's remote peer is authenticated by the transport; don't trust
+	// the sender claimed in the message body
+	msg.FromPeer = remotePeer.String()
+	h.node.protocol.Observe(remotePeer, msg.SenderProtocolVersion())
+
 	h.log.Debug("Received direct message",
 		"type", msg.Type,
 		"trade_id", msg.TradeID,
@@ -262,12 +268,21 @@ func writeLengthPrefixed(w io.Writer, data []byte) error {
 // SendDirectMessage sends a message directly to a peer and waits for ACK.
 // This is a blocking call that returns when ACK is received or timeout occurs.
 func (h *StreamHandler) SendDirectMessage(ctx context.Context, peerID peer.ID, msg *SwapMessage) error {
+	if chaos.DropMessage(msg.Type) {
+		return fmt.Errorf("failed to send message: %w", chaos.ErrInjected)
+	}
+
 	// Open stream to peer
+	quality := h.node.PeerQuality()
+	start := time.Now()
+	// Relayed connections are limited; swap messages are small enough for them
+	ctx = network.WithAllowLimitedConn(ctx, "swap message")
 	stream, err := h.node.Host().NewStream(ctx, peerID, SwapDirectProtocol)
 	if err != nil {
 		return fmt.Errorf("failed to open stream: %w", err)
 	}
 	defer stream.Close()
+	quality.RecordStreamSetup(peerID, time.Since(start))
 
 	// Set write deadline
 	stream.SetWriteDeadline(time.Now().Add(30 * time.Second))
@@ -280,6 +295,7 @@ func (h *StreamHandler) SendDirectMessage(ctx context.Context, peerID peer.ID, m
 		msg.Timestamp = time.Now().Unix()
 	}
 	msg.FromPeer = h.node.ID().String()
+	msg.ProtocolVersion = ProtocolVersion
 
 	// Marshal and send message
 	msgBytes, err := json.Marshal(msg)
@@ -287,7 +303,9 @@ func (h *StreamHandler) SendDirectMessage(ctx context.Context, peerID peer.ID, m
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
+	sent := time.Now()
 	if err := writeLengthPrefixed(stream, msgBytes); err != nil {
+		quality.RecordDelivery(peerID, true)
 		return fmt.Errorf("failed to send message: %w", err)
 	}
 
@@ -300,19 +318,25 @@ func (h *StreamHandler) SendDirectMessage(ctx context.Context, peerID peer.ID, m
 	stream.SetReadDeadline(time.Now().Add(30 * time.Second))
 	reader := bufio.NewReader(stream)
 	ackBytes, err := readLengthPrefixed(reader)
+	quality.RecordDelivery(peerID, err != nil)
 	if err != nil {
 		return fmt.Errorf("failed to read ACK: %w", err)
 	}
 
 	// Parse ACK
 	var ackMsg SwapMessage
-	if err := json.Unmarshal(ackBytes, &ackMsg); err != nil {
+	if err := SwapMessageLimits.Unmarshal(ackBytes, &ackMsg); err != nil {
 		return fmt.Errorf("failed to parse ACK: %w", err)
 	}
 
 	if ackMsg.Type != SwapMsgAck {
 		return fmt.Errorf("unexpected response type: %s", ackMsg.Type)
 	}
+	if ackMsg.Timestamp > 0 && h.node.clock != nil {
+		// ACK timestamps are whole seconds; take the middle of the second
+		remote := time.Unix(ackMsg.Timestamp, 0).Add(500 * time.Millisecond)
+		h.node.clock.RecordPeerTime(peerID, remote, sent, time.Now())
+	}
 
 	var ack AckPayload
 	if err := json.Unmarshal(ackMsg.Payload, &ack); err != nil {
@@ -296,6 +296,7 @@ func TestSwapMessageTypes(t *testing.T) {
 		SwapMsgComplete,
 		SwapMsgRefund,
 		SwapMsgAbort,
+		SwapMsgFundingTopUp,
 		SwapMsgHTLCSecretHash,
 		SwapMsgHTLCSecretReveal,
 		SwapMsgHTLCClaim,
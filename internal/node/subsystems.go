@@ -0,0 +1,177 @@
+// Package node - Runtime control of individual subsystems.
+package node
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Subsystem names.
+const (
+	SubsystemStorage     = "storage"
+	SubsystemOrderSync   = "order_sync"
+	SubsystemTradeSync   = "trade_sync"
+	SubsystemHistorySync = "history_sync"
+	SubsystemDHT         = "dht"
+	SubsystemMDNS        = "mdns"
+)
+
+// Subsystem is a service that can be stopped and started at runtime.
+type Subsystem struct {
+	Name string
+
+	// DependsOn must be running to start this subsystem, and can't be
+	// stopped while this subsystem runs.
+	DependsOn []string
+
+	// Essential subsystems can't be stopped at all.
+	Essential bool
+
+	Start func() error
+	Stop  func() error
+}
+
+// SubsystemStatus describes a registered subsystem.
+type SubsystemStatus struct {
+	Name      string   `json:"name"`
+	Running   bool     `json:"running"`
+	Essential bool     `json:"essential,omitempty"`
+	DependsOn []string `json:"depends_on,omitempty"`
+	Since     int64    `json:"since"` // Unix seconds of the last start or stop
+	LastError string   `json:"last_error,omitempty"`
+}
+
+type subsystemEntry struct {
+	Subsystem
+	running   bool
+	since     time.Time
+	lastError string
+}
+
+// Subsystems tracks the subsystems an operator can stop and start.
+type Subsystems struct {
+	mu      sync.Mutex
+	entries map[string]*subsystemEntry
+}
+
+// NewSubsystems creates an empty registry.
+func NewSubsystems() *Subsystems {
+	return &Subsystems{entries: make(map[string]*subsystemEntry)}
+}
+
+// Register adds a subsystem in its current state.
+func (s *Subsystems) Register(sub Subsystem, running bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[sub.Name] = &subsystemEntry{Subsystem: sub, running: running, since: time.Now()}
+}
+
+// Stop stops a running subsystem. Essential subsystems and subsystems
+// others running depend on are refused.
+func (s *Subsystems) Stop(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return fmt.Errorf("unknown subsystem %q", name)
+	}
+	if e.Essential {
+		return fmt.Errorf("subsystem %s is essential and can't be stopped", name)
+	}
+	if !e.running {
+		return fmt.Errorf("subsystem %s is not running", name)
+	}
+	if deps := s.runningDependentsLocked(name); len(deps) > 0 {
+		return fmt.Errorf("subsystem %s is needed by %v; stop them first", name, deps)
+	}
+	if e.Stop == nil {
+		return fmt.Errorf("subsystem %s can't be stopped", name)
+	}
+
+	if err := e.Stop(); err != nil {
+		e.lastError = err.Error()
+		return fmt.Errorf("failed to stop %s: %w", name, err)
+	}
+	e.running = false
+	e.since = time.Now()
+	e.lastError = ""
+	return nil
+}
+
+// Start starts a stopped subsystem once its dependencies run.
+func (s *Subsystems) Start(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return fmt.Errorf("unknown subsystem %q", name)
+	}
+	if e.running {
+		return fmt.Errorf("subsystem %s is already running", name)
+	}
+	for _, dep := range e.DependsOn {
+		if d, ok := s.entries[dep]; !ok || !d.running {
+			return fmt.Errorf("subsystem %s needs %s running", name, dep)
+		}
+	}
+	if e.Start == nil {
+		return fmt.Errorf("subsystem %s can't be started", name)
+	}
+
+	if err := e.Start(); err != nil {
+		e.lastError = err.Error()
+		return fmt.Errorf("failed to start %s: %w", name, err)
+	}
+	e.running = true
+	e.since = time.Now()
+	e.lastError = ""
+	return nil
+}
+
+// Running returns true if the named subsystem is registered and running.
+func (s *Subsystems) Running(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.entries[name]
+	return ok && e.running
+}
+
+// Status returns all subsystems sorted by name.
+func (s *Subsystems) Status() []SubsystemStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]SubsystemStatus, 0, len(s.entries))
+	for _, e := range s.entries {
+		out = append(out, SubsystemStatus{
+			Name:      e.Name,
+			Running:   e.running,
+			Essential: e.Essential,
+			DependsOn: e.DependsOn,
+			Since:     e.since.Unix(),
+			LastError: e.lastError,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+func (s *Subsystems) runningDependentsLocked(name string) []string {
+	var deps []string
+	for _, e := range s.entries {
+		if !e.running {
+			continue
+		}
+		for _, d := range e.DependsOn {
+			if d == name {
+				deps = append(deps, e.Name)
+			}
+		}
+	}
+	sort.Strings(deps)
+	return deps
+}
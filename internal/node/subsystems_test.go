@@ -0,0 +1,76 @@
+package node
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSubsystems(t *testing.T) {
+	var started, stopped int
+	subs := NewSubsystems()
+	subs.Register(Subsystem{Name: SubsystemStorage, Essential: true}, true)
+	subs.Register(Subsystem{
+		Name:      SubsystemOrderSync,
+		DependsOn: []string{SubsystemStorage},
+		Start:     func() error { started++; return nil },
+		Stop:      func() error { stopped++; return nil },
+	}, true)
+	subs.Register(Subsystem{
+		Name:  SubsystemDHT,
+		Start: func() error { return errors.New("bootstrap failed") },
+		Stop:  func() error { return nil },
+	}, false)
+
+	if err := subs.Stop(SubsystemStorage); err == nil || !strings.Contains(err.Error(), "essential") {
+		t.Errorf("Stop(storage) error = %v, want essential", err)
+	}
+	if err := subs.Stop("rfq"); err == nil {
+		t.Error("Stop() accepted an unknown subsystem")
+	}
+	if err := subs.Start(SubsystemOrderSync); err == nil {
+		t.Error("Start() accepted a running subsystem")
+	}
+
+	if err := subs.Stop(SubsystemOrderSync); err != nil {
+		t.Fatalf("Stop(order_sync) error = %v", err)
+	}
+	if stopped != 1 || subs.Running(SubsystemOrderSync) {
+		t.Errorf("order_sync still running after Stop (stopped %d)", stopped)
+	}
+	if err := subs.Stop(SubsystemOrderSync); err == nil {
+		t.Error("Stop() accepted a stopped subsystem")
+	}
+	if err := subs.Start(SubsystemOrderSync); err != nil || started != 1 || !subs.Running(SubsystemOrderSync) {
+		t.Errorf("Start(order_sync) error = %v, started %d", err, started)
+	}
+
+	if err := subs.Start(SubsystemDHT); err == nil || subs.Running(SubsystemDHT) {
+		t.Errorf("Start(dht) error = %v, want the start failure", err)
+	}
+
+	status := subs.Status()
+	if len(status) != 3 || status[0].Name != SubsystemDHT || status[0].LastError != "bootstrap failed" {
+		t.Errorf("Status() = %+v", status)
+	}
+}
+
+func TestSubsystemsDependencies(t *testing.T) {
+	noop := func() error { return nil }
+	subs := NewSubsystems()
+	subs.Register(Subsystem{Name: "base", Start: noop, Stop: noop}, true)
+	subs.Register(Subsystem{Name: "child", DependsOn: []string{"base"}, Start: noop, Stop: noop}, true)
+
+	if err := subs.Stop("base"); err == nil || !strings.Contains(err.Error(), "child") {
+		t.Errorf("Stop(base) error = %v, want child dependency", err)
+	}
+	if err := subs.Stop("child"); err != nil {
+		t.Fatalf("Stop(child) error = %v", err)
+	}
+	if err := subs.Stop("base"); err != nil {
+		t.Fatalf("Stop(base) error = %v", err)
+	}
+	if err := subs.Start("child"); err == nil || !strings.Contains(err.Error(), "needs base") {
+		t.Errorf("Start(child) error = %v, want missing dependency", err)
+	}
+}
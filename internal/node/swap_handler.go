@@ -10,7 +10,11 @@ import (
 
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // PubSub topics for swap messages.
@@ -40,6 +44,14 @@ type SwapMessage struct {
 	SequenceNum uint64 `json:"sequence_num,omitempty"` // Per-trade sequence number
 	RequiresAck bool   `json:"requires_ack,omitempty"` // Whether sender expects ACK
 	SwapTimeout int64  `json:"swap_timeout,omitempty"` // When swap expires (for retry decision)
+
+	// TermsDigest is the sender's hash of the trade terms (hex), checked
+	// against ours on receipt.
+	TermsDigest string `json:"terms_digest,omitempty"`
+
+	// ProtocolVersion is the sender's swap protocol version (empty before
+	// versioning, see SenderProtocolVersion).
+	ProtocolVersion int `json:"protocol_version,omitempty"`
 }
 
 // AckPayload is the acknowledgment message payload.
@@ -54,6 +66,7 @@ type AckPayload struct {
 const (
 	SwapMsgOrderAnnounce  = "order_announce"
 	SwapMsgOrderCancel    = "order_cancel"
+	SwapMsgOrderUpdate    = "order_update" // Maker's fill progress of a partially filled order
 	SwapMsgOrderTake      = "order_take"
 	SwapMsgOrderTaken     = "order_taken"
 	SwapMsgSwapInit       = "swap_init"
@@ -65,16 +78,33 @@ const (
 	SwapMsgComplete       = "complete"
 	SwapMsgRefund         = "refund"
 	SwapMsgAbort          = "abort"
+	SwapMsgTradeRefused   = "trade_refused"  // Take refused before the trade started
+	SwapMsgFundingTopUp   = "funding_top_up" // Escrow funded short, re-fund in full
+	SwapMsgFeeAdjustment  = "fee_adjustment" // Proposal or reply adjusting the request amount for fees
+	SwapMsgTradeAggregate = "aggregate"      // Proposal or reply combining trades into one swap
 
 	// HTLC-specific message types (Bitcoin-family)
 	SwapMsgHTLCSecretHash   = "htlc_secret_hash"   // Initiator sends secret hash to responder
 	SwapMsgHTLCSecretReveal = "htlc_secret_reveal" // Initiator reveals secret for claiming
 	SwapMsgHTLCClaim        = "htlc_claim"         // Notify counterparty of claim tx
+	SwapMsgLightningInvoice = "lightning_invoice"  // Maker's invoice settling the BTC leg over Lightning
 
 	// EVM HTLC-specific message types
 	SwapMsgEVMFundingInfo = "evm_funding_info" // EVM HTLC created on-chain (tx hash, swap ID)
 	SwapMsgEVMClaimed     = "evm_claimed"      // EVM HTLC claimed (includes secret)
 	SwapMsgEVMRefunded    = "evm_refunded"     // EVM HTLC refunded after timeout
+	SwapMsgEVMMetaClaim   = "evm_meta_claim"   // Signed claim for the counterparty to relay
+
+	// BTC/XMR adaptor swap message types
+	SwapMsgXMRKeyShare     = "xmr_key_share"     // Key share and BTC address, both parties
+	SwapMsgXMRLockInfo     = "xmr_lock_info"     // BTC party's unbroadcast lock and cancel signature
+	SwapMsgXMRRefundSigs   = "xmr_refund_sigs"   // XMR party's cancel signature and refund pre-signature
+	SwapMsgXMRLocked       = "xmr_locked"        // XMR party's transfer to the shared address
+	SwapMsgXMRRedeemPresig = "xmr_redeem_presig" // BTC party's redeem pre-signature
+
+	// Watchtower message types (client -> trusted watchtower)
+	SwapMsgWatchtowerRegister = "watchtower_register" // Hand over a pre-signed refund/claim bundle
+	SwapMsgWatchtowerCancel   = "watchtower_cancel"   // Release a bundle after the swap settled
 
 	// Acknowledgment message type
 	SwapMsgAck = "ack" // Acknowledgment of message receipt
@@ -83,6 +113,10 @@ const (
 // SwapMessageHandler handles incoming swap messages.
 type SwapMessageHandler func(ctx context.Context, msg *SwapMessage) error
 
+// SwapMessageValidator checks a public message before it is delivered or
+// relayed; from is the peer that published it.
+type SwapMessageValidator func(ctx context.Context, from string, msg *SwapMessage) error
+
 // SwapHandler manages swap-related PubSub messaging.
 type SwapHandler struct {
 	node *Node
@@ -97,8 +131,9 @@ type SwapHandler struct {
 	encryptedSub   *pubsub.Subscription
 	encryptor      *MessageEncryptor
 
-	handlers map[string]SwapMessageHandler
-	mu       sync.RWMutex
+	handlers   map[string]SwapMessageHandler
+	validators map[string]SwapMessageValidator
+	mu         sync.RWMutex
 
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -111,9 +146,10 @@ func NewSwapHandler(n *Node) (*SwapHandler, error) {
 	h := &SwapHandler{
 		node:     n,
 		log:      logging.GetDefault().Component("swap-handler"),
-		handlers: make(map[string]SwapMessageHandler),
-		ctx:      ctx,
-		cancel:   cancel,
+		handlers:   make(map[string]SwapMessageHandler),
+		validators: make(map[string]SwapMessageValidator),
+		ctx:        ctx,
+		cancel:     cancel,
 	}
 
 	return h, nil
@@ -125,6 +161,11 @@ func (h *SwapHandler) Start() error {
 		return fmt.Errorf("pubsub not initialized")
 	}
 
+	// Validate public messages before they are delivered or relayed
+	if err := h.node.pubsub.RegisterTopicValidator(SwapTopic, h.validate); err != nil {
+		return fmt.Errorf("failed to register swap topic validator: %w", err)
+	}
+
 	// Join the public swap topic (for order announcements)
 	topic, err := h.node.pubsub.Join(SwapTopic)
 	if err != nil {
@@ -204,23 +245,80 @@ func (h *SwapHandler) Stop() error {
 func (h *SwapHandler) OnMessage(msgType string, handler SwapMessageHandler) {
 	h.mu.Lock()
 	defer h.mu.Unlock()
-	h.handlers[msgType] = handler
+	h.handlers[msgType] = traceHandler(msgType, handler)
+}
+
+// traceHandler runs a message handler in a span of the message's trade.
+func traceHandler(msgType string, handler SwapMessageHandler) SwapMessageHandler {
+	return func(ctx context.Context, msg *SwapMessage) (err error) {
+		ctx, span := tracing.StartTrade(ctx, "p2p", msg.TradeID, "p2p.receive "+msgType,
+			trace.WithSpanKind(trace.SpanKindConsumer))
+		defer func() { tracing.End(span, err) }()
+		return handler(ctx, msg)
+	}
+}
+
+// SetValidator registers a validator for a public message type. Messages it
+// rejects are neither delivered nor relayed to other peers.
+func (h *SwapHandler) SetValidator(msgType string, validator SwapMessageValidator) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.validators[msgType] = validator
+}
+
+// validate is the PubSub validator of the public swap topic. Our own
+// messages are always accepted.
+func (h *SwapHandler) validate(ctx context.Context, from peer.ID, msg *pubsub.Message) pubsub.ValidationResult {
+	if from == h.node.ID() {
+		return pubsub.ValidationAccept
+	}
+
+	var swapMsg SwapMessage
+	if err := SwapMessageLimits.Unmarshal(msg.Data, &swapMsg); err != nil {
+		return pubsub.ValidationReject
+	}
+	h.mu.RLock()
+	validator, ok := h.validators[swapMsg.Type]
+	h.mu.RUnlock()
+	if !ok {
+		return pubsub.ValidationAccept
+	}
+
+	author := from.String()
+	if id, err := peer.IDFromBytes(msg.From); err == nil {
+		author = id.String()
+	}
+	if err := validator(ctx, author, &swapMsg); err != nil {
+		h.log.Debug("Rejected swap message", "type", swapMsg.Type, "from", shortPeerID(from), "error", err)
+		return pubsub.ValidationReject
+	}
+	return pubsub.ValidationAccept
 }
 
 // SendMessage sends a swap message to the network.
-func (h *SwapHandler) SendMessage(ctx context.Context, msg *SwapMessage) error {
+func (h *SwapHandler) SendMessage(ctx context.Context, msg *SwapMessage) (err error) {
+	ctx, span := tracing.StartTrade(ctx, "p2p", msg.TradeID, "p2p.publish "+msg.Type,
+		trace.WithSpanKind(trace.SpanKindProducer))
+	defer func() { tracing.End(span, err) }()
+
 	if h.topic == nil {
 		return fmt.Errorf("not connected to swap topic")
 	}
 
 	// Set sender
 	msg.FromPeer = h.node.ID().String()
+	msg.ProtocolVersion = ProtocolVersion
 
 	data, err := json.Marshal(msg)
 	if err != nil {
 		return fmt.Errorf("failed to marshal message: %w", err)
 	}
 
+	// Lost in the mesh as far as the sender can tell
+	if chaos.DropMessage(msg.Type) {
+		return nil
+	}
+
 	if err := h.topic.Publish(ctx, data); err != nil {
 		return fmt.Errorf("failed to publish message: %w", err)
 	}
@@ -245,13 +343,15 @@ func (h *SwapHandler) processMessages() {
 		if msg.ReceivedFrom == h.node.ID() {
 			continue
 		}
+		h.node.recordGossip()
 
 		// Parse message
 		var swapMsg SwapMessage
-		if err := json.Unmarshal(msg.Data, &swapMsg); err != nil {
+		if err := SwapMessageLimits.Unmarshal(msg.Data, &swapMsg); err != nil {
 			h.log.Warn("Failed to parse swap message", "error", err)
 			continue
 		}
+		h.node.protocol.Observe(msg.GetFrom(), swapMsg.SenderProtocolVersion())
 
 		// Get handler
 		h.mu.RLock()
@@ -291,10 +391,11 @@ func (h *SwapHandler) processEncryptedMessages() {
 		if msg.ReceivedFrom == h.node.ID() {
 			continue
 		}
+		h.node.recordGossip()
 
 		// Parse envelope
 		var envelope EncryptedEnvelope
-		if err := json.Unmarshal(msg.Data, &envelope); err != nil {
+		if err := SwapMessageLimits.Unmarshal(msg.Data, &envelope); err != nil {
 			h.log.Debug("Failed to parse encrypted envelope", "error", err)
 			continue
 		}
@@ -308,15 +409,19 @@ func (h *SwapHandler) processEncryptedMessages() {
 		// Decrypt the message
 		swapMsg, err := h.encryptor.Decrypt(&envelope)
 		if err != nil {
-			h.log.Warn("Failed to decrypt message", "error", err, "from", envelope.SenderPeerID[:12])
+			h.log.Warn("Failed to decrypt message", "error", err, "from", shortPeerIDString(envelope.SenderPeerID))
 			continue
 		}
 
+		if sender, err := peer.Decode(envelope.SenderPeerID); err == nil {
+			h.node.protocol.Observe(sender, swapMsg.SenderProtocolVersion())
+		}
+
 		h.log.Debug("Received encrypted message",
 			"type", swapMsg.Type,
 			"trade_id", swapMsg.TradeID,
 			"message_id", swapMsg.MessageID,
-			"from", envelope.SenderPeerID[:12])
+			"from", shortPeerIDString(envelope.SenderPeerID))
 
 		// Get handler for this message type
 		h.mu.RLock()
@@ -404,7 +509,12 @@ func (h *SwapHandler) sendEncryptedAck(senderPeerIDStr string, messageID string,
 }
 
 func shortPeerID(p peer.ID) string {
-	s := p.String()
+	return shortPeerIDString(p.String())
+}
+
+// shortPeerIDString shortens a peer ID taken from a message, which may be
+// shorter than a real one.
+func shortPeerIDString(s string) string {
 	if len(s) > 12 {
 		return s[:12]
 	}
@@ -458,6 +568,11 @@ type PubKeyExchangePayload struct {
 	PubKey            string `json:"pubkey"`              // Hex-encoded public key
 	OfferWalletAddr   string `json:"offer_wallet_addr"`   // Our address on offer chain for receiving
 	RequestWalletAddr string `json:"request_wallet_addr"` // Our address on request chain for receiving
+	TermsSig          string `json:"terms_sig,omitempty"` // Signature of the terms digest by PubKey (hex)
+
+	// EVMContracts is the HTLC contract of each of our EVM legs, by chain
+	// symbol. Both parties must use the same contract version.
+	EVMContracts map[string]string `json:"evm_contracts,omitempty"`
 }
 
 // NonceExchangePayload contains nonce exchange data for both chains.
@@ -472,6 +587,45 @@ type FundingInfoPayload struct {
 	Vout uint32 `json:"vout"`
 }
 
+// FundingTopUpPayload asks the counterparty to re-fund a short escrow in full.
+type FundingTopUpPayload struct {
+	TxID     string `json:"txid"`     // Short funding transaction
+	Chain    string `json:"chain"`    // Escrow chain
+	Expected uint64 `json:"expected"` // Negotiated escrow amount
+	Actual   uint64 `json:"actual"`   // Escrow output value
+	Deadline int64  `json:"deadline"` // Unix time after which the swap aborts
+}
+
+// FeeAdjustmentPayload proposes, accepts or rejects a change of the request
+// amount compensating the party bearing the higher mining fees.
+type FeeAdjustmentPayload struct {
+	Adjustment int64  `json:"adjustment"`         // Request chain smallest unit; positive: the taker pays more
+	MakerFees  uint64 `json:"maker_fees"`         // Proposer's estimate, request chain units
+	TakerFees  uint64 `json:"taker_fees"`         // Proposer's estimate, request chain units
+	Reply      bool   `json:"reply,omitempty"`    // Answer to a proposal
+	Accepted   bool   `json:"accepted,omitempty"` // Reply accepting the proposal
+	Reason     string `json:"reason,omitempty"`   // Reply rejecting the proposal
+
+	// QuoteDecimals is the sender's quote precision of the request leg
+	// (swap.Precision), the agreed one in a reply; 0 before negotiation.
+	QuoteDecimals uint8 `json:"quote_decimals,omitempty"`
+}
+
+// TradeAggregatePayload proposes, accepts or rejects combining trades
+// against the same maker into one aggregate trade.
+type TradeAggregatePayload struct {
+	AggregateID string   `json:"aggregate_id"`       // Derived from the member trade IDs
+	TradeIDs    []string `json:"trade_ids"`          // Member trades, sorted
+	Reply       bool     `json:"reply,omitempty"`    // Answer to a proposal
+	Accepted    bool     `json:"accepted,omitempty"` // Reply accepting the proposal
+	Reason      string   `json:"reason,omitempty"`   // Reply rejecting the proposal
+}
+
+// AbortPayload explains why a swap was aborted.
+type AbortPayload struct {
+	Reason string `json:"reason"`
+}
+
 // PartialSigPayload contains partial signature data for both chains.
 type PartialSigPayload struct {
 	OfferPartialSig   string `json:"offer_partial_sig"`   // Hex-encoded partial signature for offer chain
@@ -487,6 +641,52 @@ type HTLCSecretHashPayload struct {
 	PubKey            string `json:"pubkey"`              // Initiator's hex-encoded pubkey (for HTLC script)
 	OfferWalletAddr   string `json:"offer_wallet_addr"`   // Initiator's address on offer chain for receiving
 	RequestWalletAddr string `json:"request_wallet_addr"` // Initiator's address on request chain for receiving
+	TermsSig          string `json:"terms_sig,omitempty"` // Signature of the terms digest by PubKey (hex)
+
+	// EVMContracts is the HTLC contract of each of the initiator's EVM
+	// legs, by chain symbol (see PubKeyExchangePayload).
+	EVMContracts map[string]string `json:"evm_contracts,omitempty"`
+}
+
+// LightningInvoicePayload carries the maker's Lightning invoice for the BTC
+// leg, locked to the swap's secret hash.
+type LightningInvoicePayload struct {
+	Invoice string `json:"invoice"` // BOLT 11 payment request
+}
+
+// XMRKeySharePayload carries a party's key share of an adaptor swap and
+// its address on the BTC chain (refund or redeem destination).
+type XMRKeySharePayload struct {
+	Share   *swap.XMRKeyShare `json:"share"`
+	BTCAddr string            `json:"btc_addr"`
+}
+
+// XMRLockInfoPayload carries the BTC party's lock output, broadcast only
+// once the refund is pre-signed, and its cancel signature.
+type XMRLockInfoPayload struct {
+	Lock      swap.XMRLockInfo `json:"lock"`
+	CancelSig string           `json:"cancel_sig"` // Hex
+}
+
+// XMRRefundSigsPayload carries the XMR party's cancel signature and its
+// refund pre-signature under the BTC party's adaptor point.
+type XMRRefundSigsPayload struct {
+	CancelSig    string `json:"cancel_sig"`    // Hex
+	RefundPresig string `json:"refund_presig"` // Hex
+}
+
+// XMRLockedPayload carries the XMR party's transfer to the shared address;
+// the tx key proves the amount.
+type XMRLockedPayload struct {
+	TxID          string `json:"txid"`
+	TxKey         string `json:"tx_key"`
+	RestoreHeight int64  `json:"restore_height"`
+}
+
+// XMRRedeemPresigPayload carries the BTC party's redeem pre-signature under
+// the XMR party's adaptor point.
+type XMRRedeemPresigPayload struct {
+	Presig string `json:"presig"` // Hex
 }
 
 // HTLCSecretRevealPayload contains the secret for claiming HTLC outputs.
@@ -647,6 +847,20 @@ func NewEVMClaimMessage(tradeID, chain string, chainID uint64, txHash, swapID, s
 	}, nil
 }
 
+// NewEVMMetaClaimMessage asks the counterparty to relay our signed claim of
+// the EVM HTLC it funded.
+func NewEVMMetaClaimMessage(tradeID string, claim *swap.EVMMetaClaim) (*SwapMessage, error) {
+	data, err := json.Marshal(claim)
+	if err != nil {
+		return nil, err
+	}
+	return &SwapMessage{
+		Type:    SwapMsgEVMMetaClaim,
+		TradeID: tradeID,
+		Payload: data,
+	}, nil
+}
+
 // NewEVMRefundMessage creates an EVM HTLC refund notification message.
 func NewEVMRefundMessage(tradeID, chain string, chainID uint64, txHash, swapID string) (*SwapMessage, error) {
 	payload := EVMRefundPayload{
@@ -0,0 +1,388 @@
+package node
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/transport"
+	"github.com/libp2p/go-libp2p/p2p/net/swarm"
+	"github.com/libp2p/go-libp2p/p2p/transport/tcp"
+	"github.com/multiformats/go-multiaddr"
+	manet "github.com/multiformats/go-multiaddr/net"
+	"golang.org/x/net/proxy"
+)
+
+const (
+	// onionDialDelay is how long other addresses of a peer wait for its
+	// onion addresses to connect.
+	onionDialDelay = 5 * time.Second
+
+	// torDialTimeout bounds a dial through Tor, which builds a circuit first.
+	torDialTimeout = time.Minute
+
+	// onionKeyFile holds the onion service key, relative to the data dir.
+	onionKeyFile = "onion.key"
+)
+
+// TorConfig configures dialing peers through Tor and publishing an onion
+// service.
+type TorConfig struct {
+	// Enabled dials onion addresses through the SOCKS5 proxy of Tor and
+	// prefers them over other addresses of peers that have both.
+	Enabled bool `yaml:"enabled"`
+
+	// SocksAddr is the SOCKS5 proxy of the Tor daemon.
+	SocksAddr string `yaml:"socks_addr"`
+
+	// ControlAddr is the control port of the Tor daemon, used to publish
+	// the onion service.
+	ControlAddr string `yaml:"control_addr"`
+
+	// ControlPassword authenticates to the control port when it uses
+	// HashedControlPassword; otherwise the cookie file is used.
+	ControlPassword string `yaml:"control_password,omitempty"`
+
+	// OnionService publishes a v3 onion service forwarding to our TCP listen
+	// port and advertises its address. The key is kept in the data dir, so
+	// the address survives restarts.
+	OnionService bool `yaml:"onion_service"`
+
+	// OnionPort is the port of the onion address (default: the TCP listen
+	// port).
+	OnionPort int `yaml:"onion_port,omitempty"`
+
+	// Only routes all P2P traffic through Tor: every address is dialed
+	// through the proxy, we listen on loopback for the onion service only
+	// and advertise no other address. mDNS, NAT port mapping and hole
+	// punching are off.
+	Only bool `yaml:"only,omitempty"`
+}
+
+// Validate checks the configuration.
+func (c *TorConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.SocksAddr); err != nil {
+		return fmt.Errorf("network.tor.socks_addr must be host:port: %w", err)
+	}
+	if !c.OnionService {
+		return nil
+	}
+	if _, _, err := net.SplitHostPort(c.ControlAddr); err != nil {
+		return fmt.Errorf("network.tor.control_addr must be host:port: %w", err)
+	}
+	if c.OnionPort < 0 || c.OnionPort > 65535 {
+		return fmt.Errorf("network.tor.onion_port must be between 0 and 65535")
+	}
+	return nil
+}
+
+// torTransport dials peers through the SOCKS5 proxy of Tor. It dials onion
+// addresses; in Tor-only mode it also dials TCP addresses, so no connection
+// leaves the proxy, and listens through a TCP transport it wraps.
+type torTransport struct {
+	upgrader transport.Upgrader
+	rcmgr    network.ResourceManager
+	dialer   proxy.ContextDialer
+	only     bool
+	tcp      *tcp.TcpTransport // Listener of Tor-only mode
+}
+
+var (
+	_ transport.Transport    = (*torTransport)(nil)
+	_ transport.SkipResolver = (*torTransport)(nil)
+)
+
+// newTorTransport returns the constructor of a Tor transport, for
+// libp2p.Transport.
+func newTorTransport(cfg TorConfig) func(transport.Upgrader, network.ResourceManager) (*torTransport, error) {
+	return func(upgrader transport.Upgrader, rcmgr network.ResourceManager) (*torTransport, error) {
+		if rcmgr == nil {
+			rcmgr = &network.NullResourceManager{}
+		}
+		dialer, err := proxy.SOCKS5("tcp", cfg.SocksAddr, nil, &net.Dialer{Timeout: 10 * time.Second})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOCKS5 dialer: %w", err)
+		}
+		t := &torTransport{
+			upgrader: upgrader,
+			rcmgr:    rcmgr,
+			dialer:   dialer.(proxy.ContextDialer),
+			only:     cfg.Only,
+		}
+		if cfg.Only {
+			if t.tcp, err = tcp.NewTCPTransport(upgrader, rcmgr, nil); err != nil {
+				return nil, err
+			}
+		}
+		return t, nil
+	}
+}
+
+// Dial connects to a peer through the proxy. Tor resolves host names, so
+// nothing leaks to the local resolver.
+func (t *torTransport) Dial(ctx context.Context, raddr multiaddr.Multiaddr, p peer.ID) (transport.CapableConn, error) {
+	target, err := torDialTarget(raddr)
+	if err != nil {
+		return nil, err
+	}
+
+	connScope, err := t.rcmgr.OpenConnection(network.DirOutbound, true, raddr)
+	if err != nil {
+		return nil, err
+	}
+	if err := connScope.SetPeer(p); err != nil {
+		connScope.Done()
+		return nil, err
+	}
+
+	c, err := t.dialer.DialContext(ctx, "tcp", target)
+	if err != nil {
+		connScope.Done()
+		return nil, fmt.Errorf("tor dial %s: %w", target, err)
+	}
+	laddr, err := manet.FromNetAddr(c.LocalAddr())
+	if err != nil {
+		c.Close()
+		connScope.Done()
+		return nil, err
+	}
+
+	conn, err := t.upgrader.Upgrade(ctx, t, &torConn{Conn: c, laddr: laddr, raddr: raddr}, network.DirOutbound, p, connScope)
+	if err != nil {
+		connScope.Done()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// CanDial returns true for onion addresses, and in Tor-only mode for every
+// TCP address.
+func (t *torTransport) CanDial(addr multiaddr.Multiaddr) bool {
+	if _, err := torDialTarget(addr); err != nil {
+		return false
+	}
+	return t.only || isOnionAddr(addr)
+}
+
+// SkipResolve keeps DNS addresses for Tor to resolve.
+func (t *torTransport) SkipResolve(ctx context.Context, addr multiaddr.Multiaddr) bool {
+	return true
+}
+
+// Listen listens on TCP in Tor-only mode; peers reach us through the onion
+// service.
+func (t *torTransport) Listen(laddr multiaddr.Multiaddr) (transport.Listener, error) {
+	if t.tcp == nil {
+		return nil, fmt.Errorf("tor transport can't listen on %s", laddr)
+	}
+	return t.tcp.Listen(laddr)
+}
+
+// Protocols returns the protocols the transport handles; in Tor-only mode it
+// replaces the TCP transport.
+func (t *torTransport) Protocols() []int {
+	if t.only {
+		return []int{multiaddr.P_ONION3, multiaddr.P_TCP}
+	}
+	return []int{multiaddr.P_ONION3}
+}
+
+// Proxy returns true: connections go through Tor.
+func (t *torTransport) Proxy() bool {
+	return true
+}
+
+func (t *torTransport) String() string {
+	return "Tor"
+}
+
+// torConn is a connection through the proxy, with the peer's multiaddr as
+// its remote address.
+type torConn struct {
+	net.Conn
+	laddr multiaddr.Multiaddr
+	raddr multiaddr.Multiaddr
+}
+
+func (c *torConn) LocalMultiaddr() multiaddr.Multiaddr  { return c.laddr }
+func (c *torConn) RemoteMultiaddr() multiaddr.Multiaddr { return c.raddr }
+
+// torDialTarget returns the host:port to ask the proxy for: the .onion host
+// of an onion address, or the IP or DNS name of a TCP address.
+func torDialTarget(addr multiaddr.Multiaddr) (string, error) {
+	var host, port string
+	var n int
+	multiaddr.ForEach(addr, func(c multiaddr.Component) bool {
+		switch code := c.Protocol().Code; {
+		case n == 0 && code == multiaddr.P_ONION3:
+			id, p, _ := strings.Cut(c.Value(), ":")
+			host, port = id+".onion", p
+		case n == 0 && (code == multiaddr.P_IP4 || code == multiaddr.P_IP6 ||
+			code == multiaddr.P_DNS || code == multiaddr.P_DNS4 || code == multiaddr.P_DNS6):
+			host = c.Value()
+		case n == 1 && code == multiaddr.P_TCP && host != "" && port == "":
+			port = c.Value()
+		default:
+			host = ""
+			return false
+		}
+		n++
+		return true
+	})
+	if host == "" || port == "" {
+		return "", fmt.Errorf("not a Tor dialable address: %s", addr)
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// isOnionAddr returns true if addr is an onion address, with or without
+// /p2p.
+func isOnionAddr(addr multiaddr.Multiaddr) bool {
+	_, err := addr.ValueForProtocol(multiaddr.P_ONION3)
+	return err == nil
+}
+
+// onionMultiaddr returns the multiaddr of an onion service.
+func onionMultiaddr(serviceID string, port int) (multiaddr.Multiaddr, error) {
+	return multiaddr.NewMultiaddr("/onion3/" + serviceID + ":" + strconv.Itoa(port))
+}
+
+// torDialRanker dials the onion addresses of a peer first and its other
+// addresses onionDialDelay later, so peers that both run Tor connect through
+// it.
+func torDialRanker(addrs []multiaddr.Multiaddr) []network.AddrDelay {
+	var onion, other []multiaddr.Multiaddr
+	for _, addr := range addrs {
+		if isOnionAddr(addr) {
+			onion = append(onion, addr)
+		} else {
+			other = append(other, addr)
+		}
+	}
+	if len(onion) == 0 {
+		return swarm.DefaultDialRanker(addrs)
+	}
+
+	res := make([]network.AddrDelay, 0, len(addrs))
+	for _, addr := range onion {
+		res = append(res, network.AddrDelay{Addr: addr})
+	}
+	for _, d := range swarm.DefaultDialRanker(other) {
+		d.Delay += onionDialDelay
+		res = append(res, d)
+	}
+	return res
+}
+
+// torListenAddrs returns the listen addresses of Tor-only mode: the TCP
+// ports of addrs on loopback, where only the onion service reaches them.
+func torListenAddrs(addrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+	var out []multiaddr.Multiaddr
+	seen := make(map[string]bool)
+	for _, addr := range addrs {
+		port, err := addr.ValueForProtocol(multiaddr.P_TCP)
+		if err != nil || seen[port] {
+			continue
+		}
+		if _, err := addr.ValueForProtocol(multiaddr.P_WS); err == nil {
+			continue
+		}
+		seen[port] = true
+		out = append(out, multiaddr.StringCast("/ip4/127.0.0.1/tcp/"+port))
+	}
+	return out
+}
+
+// torAddrsFactory returns the addresses we advertise: our addresses and the
+// onion address once published, or in Tor-only mode the onion address and
+// relay addresses only.
+func (n *Node) torAddrsFactory(addrs []multiaddr.Multiaddr) []multiaddr.Multiaddr {
+	onion := n.OnionAddr()
+	if n.torOnly {
+		var out []multiaddr.Multiaddr
+		for _, addr := range addrs {
+			if _, err := addr.ValueForProtocol(multiaddr.P_CIRCUIT); err == nil {
+				out = append(out, addr)
+			}
+		}
+		if onion != nil {
+			out = append(out, onion)
+		}
+		return out
+	}
+	if onion != nil {
+		return append(addrs, onion)
+	}
+	return addrs
+}
+
+// OnionAddr returns the address of our onion service, or nil if none is
+// published.
+func (n *Node) OnionAddr() multiaddr.Multiaddr {
+	addr, _ := n.onionAddr.Load().(multiaddr.Multiaddr)
+	return addr
+}
+
+// startOnionService publishes the onion service forwarding to our TCP
+// listen port, with the key in the data dir.
+func (n *Node) startOnionService() error {
+	cfg := n.config.Network.Tor
+
+	var localPort int
+	for _, addr := range n.host.Network().ListenAddresses() {
+		if _, err := addr.ValueForProtocol(multiaddr.P_WS); err == nil {
+			continue
+		}
+		if port, err := addr.ValueForProtocol(multiaddr.P_TCP); err == nil {
+			localPort, _ = strconv.Atoi(port)
+			break
+		}
+	}
+	if localPort == 0 {
+		return fmt.Errorf("onion service needs a TCP listen address")
+	}
+	onionPort := cfg.OnionPort
+	if onionPort == 0 {
+		onionPort = localPort
+	}
+
+	ctrl, err := dialTorControl(n.ctx, cfg.ControlAddr)
+	if err != nil {
+		return err
+	}
+	if err := ctrl.authenticate(cfg.ControlPassword); err != nil {
+		ctrl.Close()
+		return err
+	}
+
+	keyPath := filepath.Join(expandPath(n.config.Storage.DataDir), onionKeyFile)
+	serviceID, err := ctrl.addOnion(keyPath, onionPort, net.JoinHostPort("127.0.0.1", strconv.Itoa(localPort)))
+	if err != nil {
+		ctrl.Close()
+		return err
+	}
+	addr, err := onionMultiaddr(serviceID, onionPort)
+	if err != nil {
+		ctrl.Close()
+		return fmt.Errorf("invalid onion address %s: %w", serviceID, err)
+	}
+
+	n.mu.Lock()
+	n.torControl = ctrl
+	n.mu.Unlock()
+	n.onionAddr.Store(addr)
+	if h, ok := n.host.(interface{ SignalAddressChange() }); ok {
+		h.SignalAddressChange()
+	}
+	n.log.Info("Onion service published", "addr", addr)
+	return nil
+}
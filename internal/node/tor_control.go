@@ -0,0 +1,138 @@
+package node
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/textproto"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// torControlTimeout bounds a command on the Tor control port.
+const torControlTimeout = 30 * time.Second
+
+// torControl is a connection to the control port of Tor. The onion service
+// it adds lives as long as the connection.
+type torControl struct {
+	conn net.Conn
+	text *textproto.Conn
+}
+
+// dialTorControl connects to the control port at addr.
+func dialTorControl(ctx context.Context, addr string) (*torControl, error) {
+	d := net.Dialer{Timeout: 10 * time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to tor control port: %w", err)
+	}
+	return &torControl{conn: conn, text: textproto.NewConn(conn)}, nil
+}
+
+// command sends a command and returns the lines of its 250 reply.
+func (c *torControl) command(format string, args ...interface{}) ([]string, error) {
+	c.conn.SetDeadline(time.Now().Add(torControlTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	if _, err := c.text.Cmd(format, args...); err != nil {
+		return nil, err
+	}
+	_, msg, err := c.text.ReadResponse(250)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Split(msg, "\n"), nil
+}
+
+// authenticate authenticates with password if set, or with the method Tor
+// offers: none or the cookie file.
+func (c *torControl) authenticate(password string) error {
+	lines, err := c.command("PROTOCOLINFO 1")
+	if err != nil {
+		return fmt.Errorf("tor PROTOCOLINFO: %w", err)
+	}
+	var methods []string
+	var cookieFile string
+	for _, line := range lines {
+		auth, ok := strings.CutPrefix(line, "AUTH METHODS=")
+		if !ok {
+			continue
+		}
+		list, rest, _ := strings.Cut(auth, " ")
+		methods = strings.Split(list, ",")
+		if quoted, ok := strings.CutPrefix(rest, "COOKIEFILE="); ok {
+			cookieFile, _ = strconv.Unquote(quoted)
+		}
+	}
+	has := func(method string) bool {
+		for _, m := range methods {
+			if m == method {
+				return true
+			}
+		}
+		return false
+	}
+
+	var auth string
+	switch {
+	case password != "":
+		auth = "AUTHENTICATE " + strconv.Quote(password)
+	case has("NULL"):
+		auth = "AUTHENTICATE"
+	case has("COOKIE") && cookieFile != "":
+		cookie, err := os.ReadFile(cookieFile)
+		if err != nil {
+			return fmt.Errorf("failed to read tor auth cookie: %w", err)
+		}
+		auth = "AUTHENTICATE " + hex.EncodeToString(cookie)
+	default:
+		return errors.New("tor control port needs network.tor.control_password")
+	}
+	if _, err := c.command("%s", auth); err != nil {
+		return fmt.Errorf("tor authentication failed: %w", err)
+	}
+	return nil
+}
+
+// addOnion adds a v3 onion service forwarding port to target and returns
+// its service ID. The key is loaded from keyPath, or created and saved there.
+func (c *torControl) addOnion(keyPath string, port int, target string) (string, error) {
+	key := "NEW:ED25519-V3"
+	data, err := os.ReadFile(keyPath)
+	if err == nil {
+		key = strings.TrimSpace(string(data))
+	} else if !os.IsNotExist(err) {
+		return "", fmt.Errorf("failed to read onion key: %w", err)
+	}
+
+	lines, err := c.command("ADD_ONION %s Port=%d,%s", key, port, target)
+	if err != nil {
+		return "", fmt.Errorf("tor ADD_ONION: %w", err)
+	}
+	var serviceID, privateKey string
+	for _, line := range lines {
+		if v, ok := strings.CutPrefix(line, "ServiceID="); ok {
+			serviceID = v
+		} else if v, ok := strings.CutPrefix(line, "PrivateKey="); ok {
+			privateKey = v
+		}
+	}
+	if serviceID == "" {
+		return "", errors.New("tor ADD_ONION returned no service ID")
+	}
+	if privateKey != "" {
+		if err := os.WriteFile(keyPath, []byte(privateKey+"\n"), 0600); err != nil {
+			return "", fmt.Errorf("failed to save onion key: %w", err)
+		}
+	}
+	return serviceID, nil
+}
+
+// Close closes the connection, which removes the onion service.
+func (c *torControl) Close() error {
+	return c.text.Close()
+}
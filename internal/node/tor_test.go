@@ -0,0 +1,451 @@
+package node
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/multiformats/go-multiaddr"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+const testServiceID = "vww6ybal4bd7szmgncyruucpgfkqahzddi37ktceo3ah7ngmcopnpyyd"
+
+// fakeSOCKS is a SOCKS5 proxy that connects every request to target and
+// records the requested host:port.
+type fakeSOCKS struct {
+	ln     net.Listener
+	target string
+
+	mu        sync.Mutex
+	requested []string
+}
+
+func newFakeSOCKS(t *testing.T, target string) *fakeSOCKS {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	s := &fakeSOCKS{ln: ln, target: target}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(c)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSOCKS) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+
+	// Greeting: version, methods
+	head := make([]byte, 2)
+	if _, err := io.ReadFull(r, head); err != nil {
+		return
+	}
+	io.ReadFull(r, make([]byte, head[1]))
+	c.Write([]byte{5, 0})
+
+	// Request: version, command, reserved, address type
+	req := make([]byte, 4)
+	if _, err := io.ReadFull(r, req); err != nil {
+		return
+	}
+	var host string
+	switch req[3] {
+	case 1:
+		ip := make([]byte, 4)
+		io.ReadFull(r, ip)
+		host = net.IP(ip).String()
+	case 3:
+		n, _ := r.ReadByte()
+		name := make([]byte, n)
+		io.ReadFull(r, name)
+		host = string(name)
+	default:
+		return
+	}
+	port := make([]byte, 2)
+	io.ReadFull(r, port)
+	s.mu.Lock()
+	s.requested = append(s.requested, net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))))
+	s.mu.Unlock()
+
+	up, err := net.Dial("tcp", s.target)
+	if err != nil {
+		c.Write([]byte{5, 5, 0, 1, 0, 0, 0, 0, 0, 0})
+		return
+	}
+	defer up.Close()
+	c.Write([]byte{5, 0, 0, 1, 0, 0, 0, 0, 0, 0})
+	go io.Copy(up, r)
+	io.Copy(c, up)
+}
+
+func (s *fakeSOCKS) Requested() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.requested...)
+}
+
+// fakeTorControl is a Tor control port with cookie authentication that
+// adds onion services.
+type fakeTorControl struct {
+	ln         net.Listener
+	cookieFile string
+	cookie     []byte
+
+	mu       sync.Mutex
+	commands []string
+}
+
+func newFakeTorControl(t *testing.T) *fakeTorControl {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error = %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	c := &fakeTorControl{
+		ln:         ln,
+		cookieFile: filepath.Join(t.TempDir(), "control_auth_cookie"),
+		cookie:     []byte("0123456789abcdef0123456789abcdef"),
+	}
+	if err := os.WriteFile(c.cookieFile, c.cookie, 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.serve(conn)
+		}
+	}()
+	return c
+}
+
+func (c *fakeTorControl) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	authenticated := false
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		c.mu.Lock()
+		c.commands = append(c.commands, line)
+		c.mu.Unlock()
+
+		cmd, args, _ := strings.Cut(line, " ")
+		switch {
+		case cmd == "PROTOCOLINFO":
+			fmt.Fprintf(conn, "250-PROTOCOLINFO 1\r\n250-AUTH METHODS=COOKIE,SAFECOOKIE COOKIEFILE=%q\r\n250-VERSION Tor=\"0.4.8.9\"\r\n250 OK\r\n", c.cookieFile)
+		case cmd == "AUTHENTICATE":
+			if args != hex.EncodeToString(c.cookie) {
+				fmt.Fprint(conn, "515 Authentication failed: Wrong length on authentication cookie.\r\n")
+				return
+			}
+			authenticated = true
+			fmt.Fprint(conn, "250 OK\r\n")
+		case !authenticated:
+			fmt.Fprint(conn, "514 Authentication required.\r\n")
+			return
+		case cmd == "ADD_ONION" && strings.HasPrefix(args, "NEW:ED25519-V3 "):
+			fmt.Fprintf(conn, "250-ServiceID=%s\r\n250-PrivateKey=ED25519-V3:c2VjcmV0\r\n250 OK\r\n", testServiceID)
+		case cmd == "ADD_ONION" && strings.HasPrefix(args, "ED25519-V3:c2VjcmV0 "):
+			fmt.Fprintf(conn, "250-ServiceID=%s\r\n250 OK\r\n", testServiceID)
+		default:
+			fmt.Fprint(conn, "510 Unrecognized command\r\n")
+		}
+	}
+}
+
+func (c *fakeTorControl) Commands() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]string(nil), c.commands...)
+}
+
+// newTestTCPHost creates a host listening on loopback TCP.
+func newTestTCPHost(t *testing.T, opts ...libp2p.Option) host.Host {
+	t.Helper()
+	opts = append([]libp2p.Option{libp2p.ListenAddrStrings("/ip4/127.0.0.1/tcp/0")}, opts...)
+	h, err := libp2p.New(opts...)
+	if err != nil {
+		t.Fatalf("libp2p.New() error = %v", err)
+	}
+	t.Cleanup(func() { h.Close() })
+	return h
+}
+
+// tcpPort returns the TCP port h listens on.
+func tcpPort(t *testing.T, h host.Host) string {
+	t.Helper()
+	for _, addr := range h.Network().ListenAddresses() {
+		if port, err := addr.ValueForProtocol(multiaddr.P_TCP); err == nil {
+			return port
+		}
+	}
+	t.Fatal("host has no TCP listen address")
+	return ""
+}
+
+func TestTorConfigValidate(t *testing.T) {
+	cfg := DefaultConfig().Network.Tor
+	if cfg.Enabled || cfg.SocksAddr != "127.0.0.1:9050" || !cfg.OnionService {
+		t.Errorf("default tor config = %+v", cfg)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("default Validate() error = %v", err)
+	}
+	cfg.Enabled = true
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("enabled Validate() error = %v", err)
+	}
+
+	bad := cfg
+	bad.SocksAddr = "9050"
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() accepted a socks_addr without host")
+	}
+	bad = cfg
+	bad.ControlAddr = ""
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() accepted an empty control_addr with onion_service")
+	}
+	bad.OnionService = false
+	if err := bad.Validate(); err != nil {
+		t.Errorf("Validate() without onion service error = %v", err)
+	}
+	bad = cfg
+	bad.OnionPort = 70000
+	if err := bad.Validate(); err == nil {
+		t.Error("Validate() accepted onion_port 70000")
+	}
+}
+
+func TestTorDialTarget(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"/onion3/" + testServiceID + ":4001", testServiceID + ".onion:4001"},
+		{"/dns4/seed.example.org/tcp/4001", "seed.example.org:4001"},
+		{"/ip4/203.0.113.7/tcp/4001", "203.0.113.7:4001"},
+		{"/ip6/2001:db8::1/tcp/4001", "[2001:db8::1]:4001"},
+		{"/ip4/203.0.113.7/udp/4001/quic-v1", ""},
+		{"/ip4/203.0.113.7/tcp/4001/ws", ""},
+		{"/onion3/" + testServiceID + ":4001/tcp/1", ""},
+	}
+	for _, tt := range tests {
+		got, err := torDialTarget(multiaddr.StringCast(tt.addr))
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("torDialTarget(%s) = %s, want error", tt.addr, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("torDialTarget(%s) = %s, %v, want %s", tt.addr, got, err, tt.want)
+		}
+	}
+}
+
+func TestTorDialRanker(t *testing.T) {
+	onion := multiaddr.StringCast("/onion3/" + testServiceID + ":4001")
+	tcpAddr := multiaddr.StringCast("/ip4/203.0.113.7/tcp/4001")
+	quicAddr := multiaddr.StringCast("/ip4/203.0.113.7/udp/4001/quic-v1")
+
+	ranked := torDialRanker([]multiaddr.Multiaddr{tcpAddr, quicAddr, onion})
+	if len(ranked) != 3 || !ranked[0].Addr.Equal(onion) || ranked[0].Delay != 0 {
+		t.Fatalf("torDialRanker() = %v, want the onion address first", ranked)
+	}
+	for _, d := range ranked[1:] {
+		if d.Delay < onionDialDelay {
+			t.Errorf("%s delay = %s, want at least %s", d.Addr, d.Delay, onionDialDelay)
+		}
+	}
+
+	// Peers without an onion address are ranked as usual
+	ranked = torDialRanker([]multiaddr.Multiaddr{tcpAddr, quicAddr})
+	if len(ranked) != 2 || ranked[0].Delay >= onionDialDelay {
+		t.Errorf("torDialRanker() without onion = %v", ranked)
+	}
+}
+
+func TestTorListenAddrs(t *testing.T) {
+	got := torListenAddrs([]multiaddr.Multiaddr{
+		multiaddr.StringCast("/ip4/0.0.0.0/tcp/4001"),
+		multiaddr.StringCast("/ip4/0.0.0.0/udp/4001/quic-v1"),
+		multiaddr.StringCast("/ip6/::/tcp/4001"),
+		multiaddr.StringCast("/ip4/0.0.0.0/tcp/4002/ws"),
+	})
+	if len(got) != 1 || got[0].String() != "/ip4/127.0.0.1/tcp/4001" {
+		t.Errorf("torListenAddrs() = %v, want /ip4/127.0.0.1/tcp/4001", got)
+	}
+}
+
+func TestTorTransportDial(t *testing.T) {
+	server := newTestTCPHost(t)
+	port := tcpPort(t, server)
+	socks := newFakeSOCKS(t, "127.0.0.1:"+port)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	newClient := func(cfg TorConfig) host.Host {
+		cfg.Enabled, cfg.SocksAddr = true, socks.ln.Addr().String()
+		h, err := libp2p.New(
+			libp2p.NoListenAddrs,
+			libp2p.Transport(newTorTransport(cfg)),
+			libp2p.DialRanker(torDialRanker),
+		)
+		if err != nil {
+			t.Fatalf("libp2p.New() error = %v", err)
+		}
+		t.Cleanup(func() { h.Close() })
+		return h
+	}
+
+	// Onion addresses go through the proxy; TCP addresses are not dialable
+	client := newClient(TorConfig{})
+	tcpAddr := multiaddr.StringCast("/ip4/127.0.0.1/tcp/" + port)
+	if err := client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: []multiaddr.Multiaddr{tcpAddr}}); err == nil {
+		t.Error("Connect() to a TCP address without tor-only should fail")
+	}
+	onion := multiaddr.StringCast("/onion3/" + testServiceID + ":" + port)
+	if err := client.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: []multiaddr.Multiaddr{onion}}); err != nil {
+		t.Fatalf("Connect() to onion address error = %v", err)
+	}
+	conns := client.Network().ConnsToPeer(server.ID())
+	if len(conns) != 1 || !conns[0].RemoteMultiaddr().Equal(onion) {
+		t.Errorf("connections = %v, want one to %s", conns, onion)
+	}
+
+	// Tor-only dials DNS names through the proxy without resolving them
+	only := newClient(TorConfig{Only: true})
+	dnsAddr := multiaddr.StringCast("/dns4/seed.klingon.invalid/tcp/" + port)
+	if err := only.Connect(ctx, peer.AddrInfo{ID: server.ID(), Addrs: []multiaddr.Multiaddr{dnsAddr}}); err != nil {
+		t.Fatalf("tor-only Connect() error = %v", err)
+	}
+
+	want := []string{testServiceID + ".onion:" + port, "seed.klingon.invalid:" + port}
+	if got := socks.Requested(); strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("proxy requests = %v, want %v", got, want)
+	}
+}
+
+func TestTorControlAddOnion(t *testing.T) {
+	ctrl := newFakeTorControl(t)
+	keyPath := filepath.Join(t.TempDir(), onionKeyFile)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		c, err := dialTorControl(ctx, ctrl.ln.Addr().String())
+		if err != nil {
+			t.Fatalf("dialTorControl() error = %v", err)
+		}
+		if err := c.authenticate(""); err != nil {
+			t.Fatalf("authenticate() error = %v", err)
+		}
+		id, err := c.addOnion(keyPath, 4001, "127.0.0.1:5001")
+		if err != nil || id != testServiceID {
+			t.Fatalf("addOnion() = %s, %v, want %s", id, err, testServiceID)
+		}
+		c.Close()
+	}
+
+	// The key created the first time is reused
+	var adds []string
+	for _, cmd := range ctrl.Commands() {
+		if strings.HasPrefix(cmd, "ADD_ONION") {
+			adds = append(adds, cmd)
+		}
+	}
+	want := []string{
+		"ADD_ONION NEW:ED25519-V3 Port=4001,127.0.0.1:5001",
+		"ADD_ONION ED25519-V3:c2VjcmV0 Port=4001,127.0.0.1:5001",
+	}
+	if strings.Join(adds, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ADD_ONION commands = %q, want %q", adds, want)
+	}
+	if info, err := os.Stat(keyPath); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("onion key file = %v, %v, want mode 0600", info, err)
+	}
+
+	// A wrong cookie is refused
+	os.WriteFile(ctrl.cookieFile, []byte("wrong"), 0600)
+	c, err := dialTorControl(ctx, ctrl.ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dialTorControl() error = %v", err)
+	}
+	defer c.Close()
+	if err := c.authenticate(""); err == nil {
+		t.Error("authenticate() with a wrong cookie should fail")
+	}
+}
+
+func TestNodeOnionService(t *testing.T) {
+	ctrl := newFakeTorControl(t)
+	cfg := DefaultConfig()
+	cfg.Storage.DataDir = t.TempDir()
+	cfg.Network.Tor.Enabled = true
+	cfg.Network.Tor.ControlAddr = ctrl.ln.Addr().String()
+	cfg.Network.Tor.OnionPort = 4001
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := &Node{config: cfg, ctx: ctx, cancel: cancel, log: logging.GetDefault().Component("node")}
+	n.host = newTestTCPHost(t, libp2p.AddrsFactory(n.torAddrsFactory))
+	if n.OnionAddr() != nil {
+		t.Fatal("OnionAddr() before the onion service is published should be nil")
+	}
+
+	if err := n.startOnionService(); err != nil {
+		t.Fatalf("startOnionService() error = %v", err)
+	}
+	defer n.torControl.Close()
+	want := multiaddr.StringCast("/onion3/" + testServiceID + ":4001")
+	if !want.Equal(n.OnionAddr()) {
+		t.Fatalf("OnionAddr() = %v, want %s", n.OnionAddr(), want)
+	}
+	if !multiaddr.Contains(n.Addrs(), want) || len(n.Addrs()) < 2 {
+		t.Errorf("Addrs() = %v, want the listen and onion addresses", n.Addrs())
+	}
+	if cmds := ctrl.Commands(); !strings.HasSuffix(cmds[len(cmds)-1], "Port=4001,127.0.0.1:"+tcpPort(t, n.host)) {
+		t.Errorf("ADD_ONION = %s, want forwarding to the listen port", cmds[len(cmds)-1])
+	}
+
+	// Tor-only advertises the onion address alone
+	only := &Node{config: cfg, torOnly: true}
+	only.onionAddr.Store(want)
+	only.host = newTestTCPHost(t, libp2p.AddrsFactory(only.torAddrsFactory))
+	if addrs := only.Addrs(); len(addrs) != 1 || !addrs[0].Equal(want) {
+		t.Errorf("tor-only Addrs() = %v, want %s", addrs, want)
+	}
+}
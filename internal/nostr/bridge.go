@@ -0,0 +1,328 @@
+package nostr
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// maxSeen bounds the event IDs remembered for deduplication before older
+// ones are pruned.
+const maxSeen = 10000
+
+// IngestFunc hands an order announcement from a relay to the node. It
+// reports whether the order was new; orders the node already has, such as
+// ones it received over gossip, are not.
+type IngestFunc func(ctx context.Context, payload []byte) (bool, error)
+
+// Status describes the bridge state.
+type Status struct {
+	Enabled    bool          `json:"enabled"`
+	PubKey     string        `json:"pubkey,omitempty"` // Per-run publishing key
+	Topic      string        `json:"topic"`
+	Relays     []RelayStatus `json:"relays"`
+	LiveOrders int           `json:"live_orders"` // Our orders currently published
+	Published  int           `json:"published"`
+	Ingested   int           `json:"ingested"`   // New orders received from relays
+	Duplicates int           `json:"duplicates"` // Orders we already had
+	Rejected   int           `json:"rejected"`   // Invalid events or orders
+}
+
+// RelayStatus describes one relay connection.
+type RelayStatus struct {
+	URL         string `json:"url"`
+	Connected   bool   `json:"connected"`
+	ConnectedAt int64  `json:"connected_at,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+// Bridge publishes our orders to Nostr relays and ingests other nodes'.
+type Bridge struct {
+	cfg    node.NostrConfig
+	topic  string
+	key    *btcec.PrivateKey
+	pubKey string
+	relays []*relay
+	log    *logging.Logger
+
+	mu         sync.Mutex
+	ingest     IngestFunc
+	live       map[string]*Event    // Order ID -> our order event
+	seen       map[string]time.Time // Event ID -> when first seen
+	published  int
+	ingested   int
+	duplicates int
+	rejected   int
+
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a bridge for network.
+func New(cfg node.NostrConfig, network string) (*Bridge, error) {
+	defaults := node.DefaultConfig().Nostr
+	if cfg.Lookback <= 0 {
+		cfg.Lookback = defaults.Lookback
+	}
+	if cfg.ReconnectInterval <= 0 {
+		cfg.ReconnectInterval = defaults.ReconnectInterval
+	}
+	if cfg.Enabled && len(cfg.Relays) == 0 {
+		return nil, fmt.Errorf("nostr is enabled but no relays are configured")
+	}
+	for _, u := range cfg.Relays {
+		if err := validateRelay(u); err != nil {
+			return nil, err
+		}
+	}
+
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate nostr key: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &Bridge{
+		cfg:    cfg,
+		topic:  Topic(network),
+		key:    key,
+		pubKey: hex.EncodeToString(schnorr.SerializePubKey(key.PubKey())),
+		log:    logging.GetDefault().Component("nostr"),
+		live:   make(map[string]*Event),
+		seen:   make(map[string]time.Time),
+		now:    time.Now,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+	for _, u := range cfg.Relays {
+		b.relays = append(b.relays, &relay{url: u, b: b})
+	}
+	return b, nil
+}
+
+// SetIngest sets where orders received from relays go.
+func (b *Bridge) SetIngest(fn IngestFunc) {
+	b.mu.Lock()
+	b.ingest = fn
+	b.mu.Unlock()
+}
+
+// Enabled reports whether the bridge publishes and ingests orders.
+func (b *Bridge) Enabled() bool {
+	return b.cfg.Enabled
+}
+
+// Start connects to every relay.
+func (b *Bridge) Start() {
+	if !b.cfg.Enabled {
+		return
+	}
+	for _, r := range b.relays {
+		b.wg.Add(1)
+		go func(r *relay) {
+			defer b.wg.Done()
+			r.run(b.ctx)
+		}(r)
+	}
+	b.log.Info("Nostr order bridge started", "relays", len(b.relays), "topic", b.topic)
+}
+
+// Stop disconnects from the relays.
+func (b *Bridge) Stop() {
+	b.cancel()
+	b.wg.Wait()
+}
+
+// filter selects order events of our network from Lookback ago.
+func (b *Bridge) filter() Filter {
+	return Filter{
+		Kinds:  []int{KindOrder},
+		Topics: []string{b.topic},
+		Since:  b.now().Add(-b.cfg.Lookback).Unix(),
+	}
+}
+
+// Publish mirrors our order announcement to the relays until expiresAt.
+// Relays not connected now receive it when they reconnect.
+func (b *Bridge) Publish(orderID string, payload []byte, expiresAt time.Time) error {
+	if !b.cfg.Enabled {
+		return nil
+	}
+	ev := &Event{
+		CreatedAt: b.now().Unix(),
+		Kind:      KindOrder,
+		Tags: [][]string{
+			{"d", orderID},
+			{"t", b.topic},
+			{"expiration", strconv.FormatInt(expiresAt.Unix(), 10)},
+		},
+		Content: string(payload),
+	}
+	if err := ev.Sign(b.key); err != nil {
+		return fmt.Errorf("failed to sign nostr event: %w", err)
+	}
+
+	b.mu.Lock()
+	b.live[orderID] = ev
+	b.markSeenLocked(ev.ID)
+	b.published++
+	b.mu.Unlock()
+
+	sent := b.broadcast(ev)
+	b.log.Debug("Order published to nostr", "id", orderID, "event", ev.ID, "relays", sent)
+	return nil
+}
+
+// Withdraw asks the relays to delete our event for orderID (NIP-09), such
+// as when the order is cancelled.
+func (b *Bridge) Withdraw(orderID string) error {
+	if !b.cfg.Enabled {
+		return nil
+	}
+	b.mu.Lock()
+	published, ok := b.live[orderID]
+	delete(b.live, orderID)
+	b.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	ev := &Event{
+		CreatedAt: b.now().Unix(),
+		Kind:      KindDeletion,
+		Tags: [][]string{
+			{"e", published.ID},
+			{"a", fmt.Sprintf("%d:%s:%s", KindOrder, b.pubKey, orderID)},
+			{"t", b.topic},
+		},
+		Content: "order cancelled",
+	}
+	if err := ev.Sign(b.key); err != nil {
+		return fmt.Errorf("failed to sign nostr event: %w", err)
+	}
+	b.broadcast(ev)
+	return nil
+}
+
+// broadcast sends ev to every connected relay and returns how many took it.
+func (b *Bridge) broadcast(ev *Event) int {
+	sent := 0
+	for _, r := range b.relays {
+		if err := r.send([]interface{}{"EVENT", ev}); err == nil {
+			sent++
+		}
+	}
+	return sent
+}
+
+// liveEvents returns our unexpired order events.
+func (b *Bridge) liveEvents() []*Event {
+	now := b.now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	events := make([]*Event, 0, len(b.live))
+	for id, ev := range b.live {
+		if ev.Expired(now) {
+			delete(b.live, id)
+			continue
+		}
+		events = append(events, ev)
+	}
+	return events
+}
+
+// receive ingests an order event from a relay. Every relay may deliver the
+// same event, and our own come back too; each is processed once.
+func (b *Bridge) receive(ctx context.Context, ev *Event, relayURL string) {
+	if ev.Kind != KindOrder || ev.Tag("t") != b.topic {
+		return
+	}
+	b.mu.Lock()
+	_, seen := b.seen[ev.ID]
+	ingest := b.ingest
+	b.mu.Unlock()
+	if seen {
+		return
+	}
+
+	if err := ev.Verify(); err != nil {
+		b.log.Debug("Ignoring invalid nostr event", "relay", relayURL, "error", err)
+		b.count(&b.rejected)
+		return
+	}
+	// Another relay may have delivered it meanwhile
+	b.mu.Lock()
+	if _, seen = b.seen[ev.ID]; !seen {
+		b.markSeenLocked(ev.ID)
+	}
+	b.mu.Unlock()
+	if seen || ev.Expired(b.now()) || ingest == nil {
+		return
+	}
+
+	added, err := ingest(ctx, []byte(ev.Content))
+	switch {
+	case err != nil:
+		b.log.Debug("Ignoring order from nostr", "relay", relayURL, "order", ev.Tag("d"), "error", err)
+		b.count(&b.rejected)
+	case added:
+		b.count(&b.ingested)
+	default:
+		b.count(&b.duplicates)
+	}
+}
+
+func (b *Bridge) count(n *int) {
+	b.mu.Lock()
+	*n++
+	b.mu.Unlock()
+}
+
+// markSeenLocked remembers an event ID, pruning IDs older than Lookback
+// once there are too many. Caller holds b.mu.
+func (b *Bridge) markSeenLocked(id string) {
+	now := b.now()
+	if len(b.seen) >= maxSeen {
+		cutoff := now.Add(-b.cfg.Lookback)
+		for seenID, at := range b.seen {
+			if at.Before(cutoff) {
+				delete(b.seen, seenID)
+			}
+		}
+	}
+	b.seen[id] = now
+}
+
+// Status returns the bridge state.
+func (b *Bridge) Status() *Status {
+	st := &Status{
+		Enabled: b.cfg.Enabled,
+		Topic:   b.topic,
+		Relays:  make([]RelayStatus, 0, len(b.relays)),
+	}
+	if b.cfg.Enabled {
+		st.PubKey = b.pubKey
+	}
+	for _, r := range b.relays {
+		st.Relays = append(st.Relays, r.status())
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	st.LiveOrders = len(b.live)
+	st.Published = b.published
+	st.Ingested = b.ingested
+	st.Duplicates = b.duplicates
+	st.Rejected = b.rejected
+	return st
+}
@@ -0,0 +1,178 @@
+package nostr
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// testRelay is a minimal relay: it stores events and sends every stored and
+// new event to all subscribers, ignoring filters.
+type testRelay struct {
+	mu     sync.Mutex
+	events []json.RawMessage
+	conns  []*websocket.Conn
+}
+
+func (tr *testRelay) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+	for {
+		var msg []json.RawMessage
+		if err := conn.ReadJSON(&msg); err != nil || len(msg) < 2 {
+			return
+		}
+		var typ string
+		json.Unmarshal(msg[0], &typ)
+		tr.mu.Lock()
+		switch typ {
+		case "REQ":
+			tr.conns = append(tr.conns, conn)
+			for _, ev := range tr.events {
+				conn.WriteJSON([]interface{}{"EVENT", subscriptionID, ev})
+			}
+			conn.WriteJSON([]interface{}{"EOSE", subscriptionID})
+		case "EVENT":
+			tr.events = append(tr.events, msg[1])
+			for _, c := range tr.conns {
+				c.WriteJSON([]interface{}{"EVENT", subscriptionID, msg[1]})
+			}
+		}
+		tr.mu.Unlock()
+	}
+}
+
+func newTestBridge(t *testing.T, relayURL string, ingest IngestFunc) *Bridge {
+	t.Helper()
+	b, err := New(node.NostrConfig{Enabled: true, Relays: []string{relayURL}, ReconnectInterval: 50 * time.Millisecond}, "testnet")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	b.SetIngest(ingest)
+	b.Start()
+	t.Cleanup(b.Stop)
+	return b
+}
+
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestBridgeMirrorsOrders(t *testing.T) {
+	srv := httptest.NewServer(&testRelay{})
+	defer srv.Close()
+	relayURL := "ws" + strings.TrimPrefix(srv.URL, "http")
+
+	var mu sync.Mutex
+	var received []string
+	known := map[string]bool{"gossiped": true}
+	ingest := func(ctx context.Context, payload []byte) (bool, error) {
+		var order struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(payload, &order); err != nil {
+			return false, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		received = append(received, order.ID)
+		if known[order.ID] {
+			return false, nil
+		}
+		known[order.ID] = true
+		return true, nil
+	}
+	ownIngest := func(ctx context.Context, payload []byte) (bool, error) {
+		t.Errorf("bridge ingested its own order %s", payload)
+		return false, nil
+	}
+
+	maker := newTestBridge(t, relayURL, ownIngest)
+	taker := newTestBridge(t, relayURL, ingest)
+	waitFor(t, "relay connections", func() bool {
+		return maker.Status().Relays[0].Connected && taker.Status().Relays[0].Connected
+	})
+
+	expires := time.Now().Add(time.Hour)
+	if err := maker.Publish("order-1", []byte(`{"id":"order-1"}`), expires); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if err := maker.Publish("gossiped", []byte(`{"id":"gossiped"}`), expires); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	// Expired orders are not ingested
+	if err := maker.Publish("expired", []byte(`{"id":"expired"}`), time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	waitFor(t, "ingested orders", func() bool {
+		st := taker.Status()
+		return st.Ingested == 1 && st.Duplicates == 1
+	})
+
+	mu.Lock()
+	if len(received) != 2 {
+		t.Errorf("received %v, want order-1 and gossiped once each", received)
+	}
+	mu.Unlock()
+
+	st := maker.Status()
+	if st.Published != 3 || st.LiveOrders != 3 || st.PubKey == "" || st.Topic != "klingdex-testnet" {
+		t.Errorf("maker Status() = %+v", st)
+	}
+	if err := maker.Withdraw("order-1"); err != nil {
+		t.Fatalf("Withdraw() error = %v", err)
+	}
+	if got := len(maker.liveEvents()); got != 1 {
+		t.Errorf("liveEvents() = %d, want 1 after withdrawal and expiry", got)
+	}
+}
+
+func TestBridgeConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     node.NostrConfig
+		wantErr bool
+	}{
+		{"disabled", node.NostrConfig{}, false},
+		{"wss relay", node.NostrConfig{Enabled: true, Relays: []string{"wss://relay.example.com"}}, false},
+		{"loopback ws relay", node.NostrConfig{Enabled: true, Relays: []string{"ws://127.0.0.1:7000"}}, false},
+		{"no relays", node.NostrConfig{Enabled: true}, true},
+		{"plain ws relay", node.NostrConfig{Enabled: true, Relays: []string{"ws://relay.example.com"}}, true},
+		{"not a url", node.NostrConfig{Relays: []string{"relay"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b, err := New(tt.cfg, "mainnet")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("New() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if b != nil && b.cfg.Lookback <= 0 {
+				t.Error("New() left Lookback unset")
+			}
+		})
+	}
+
+	b, _ := New(node.NostrConfig{}, "mainnet")
+	if err := b.Publish("order-1", []byte(`{}`), time.Now().Add(time.Hour)); err != nil || b.Status().Published != 0 {
+		t.Errorf("disabled Publish() error = %v, status %+v", err, b.Status())
+	}
+}
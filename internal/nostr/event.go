@@ -0,0 +1,132 @@
+// Package nostr mirrors signed order announcements to Nostr relays.
+//
+// Each of our signed orders is published as a NIP-78 application event (kind
+// 30078) tagged with the order ID ("d"), the network topic ("t") and a NIP-40
+// expiration; its content is the order announcement exactly as gossiped.
+// The bridge subscribes to the same kind and topic on every relay and hands
+// the orders other klingdex nodes published to the node, which applies the
+// same checks as for gossiped orders. The Nostr key is generated per run:
+// orders are authenticated by their identity signature and proof-of-work,
+// not by who published the event.
+package nostr
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// KindOrder is the event kind orders are published as (NIP-78).
+const KindOrder = 30078
+
+// KindDeletion withdraws an earlier event (NIP-09).
+const KindDeletion = 5
+
+// Topic returns the "t" tag value of orders on network.
+func Topic(network string) string {
+	return "klingdex-" + network
+}
+
+// Event is a Nostr event (NIP-01).
+type Event struct {
+	ID        string     `json:"id"`
+	PubKey    string     `json:"pubkey"`
+	CreatedAt int64      `json:"created_at"`
+	Kind      int        `json:"kind"`
+	Tags      [][]string `json:"tags"`
+	Content   string     `json:"content"`
+	Sig       string     `json:"sig"`
+}
+
+// Filter selects the events a subscription receives.
+type Filter struct {
+	Kinds  []int    `json:"kinds,omitempty"`
+	Topics []string `json:"#t,omitempty"`
+	Since  int64    `json:"since,omitempty"`
+}
+
+// hash returns the event ID: the SHA-256 of the serialized event.
+func (e *Event) hash() ([]byte, error) {
+	tags := e.Tags
+	if tags == nil {
+		tags = [][]string{}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode([]interface{}{0, e.PubKey, e.CreatedAt, e.Kind, tags, e.Content}); err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	return sum[:], nil
+}
+
+// Sign sets the event's public key, ID and signature.
+func (e *Event) Sign(key *btcec.PrivateKey) error {
+	e.PubKey = hex.EncodeToString(schnorr.SerializePubKey(key.PubKey()))
+	id, err := e.hash()
+	if err != nil {
+		return err
+	}
+	sig, err := schnorr.Sign(key, id)
+	if err != nil {
+		return err
+	}
+	e.ID = hex.EncodeToString(id)
+	e.Sig = hex.EncodeToString(sig.Serialize())
+	return nil
+}
+
+// Verify checks the event's ID and signature.
+func (e *Event) Verify() error {
+	id, err := e.hash()
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(id) != e.ID {
+		return fmt.Errorf("event id mismatch")
+	}
+	pubBytes, err := hex.DecodeString(e.PubKey)
+	if err != nil {
+		return fmt.Errorf("invalid pubkey: %w", err)
+	}
+	pub, err := schnorr.ParsePubKey(pubBytes)
+	if err != nil {
+		return fmt.Errorf("invalid pubkey: %w", err)
+	}
+	sigBytes, err := hex.DecodeString(e.Sig)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	sig, err := schnorr.ParseSignature(sigBytes)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !sig.Verify(id, pub) {
+		return fmt.Errorf("invalid signature")
+	}
+	return nil
+}
+
+// Tag returns the first value of the named tag, or "".
+func (e *Event) Tag(name string) string {
+	for _, tag := range e.Tags {
+		if len(tag) >= 2 && tag[0] == name {
+			return tag[1]
+		}
+	}
+	return ""
+}
+
+// Expired reports whether the event's NIP-40 expiration has passed.
+func (e *Event) Expired(now time.Time) bool {
+	exp, err := strconv.ParseInt(e.Tag("expiration"), 10, 64)
+	return err == nil && exp <= now.Unix()
+}
@@ -0,0 +1,68 @@
+package nostr
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func TestEventSignVerify(t *testing.T) {
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ev := &Event{
+		CreatedAt: 1700000000,
+		Kind:      KindOrder,
+		Tags:      [][]string{{"d", "order-1"}, {"t", Topic("testnet")}},
+		Content:   `{"id":"order-1","note":"<&>"}`,
+	}
+	if err := ev.Sign(key); err != nil {
+		t.Fatalf("Sign() error = %v", err)
+	}
+	if len(ev.ID) != 64 || len(ev.PubKey) != 64 || len(ev.Sig) != 128 {
+		t.Fatalf("Sign() = id %q, pubkey %q, sig %q", ev.ID, ev.PubKey, ev.Sig)
+	}
+	if err := ev.Verify(); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		edit func(e *Event)
+	}{
+		{"content", func(e *Event) { e.Content = `{"id":"order-2"}` }},
+		{"tags", func(e *Event) { e.Tags = append(e.Tags, []string{"t", "other"}) }},
+		{"created_at", func(e *Event) { e.CreatedAt++ }},
+		{"signature", func(e *Event) { e.Sig = strings.Repeat("0", 128) }},
+		{"pubkey", func(e *Event) { e.PubKey = "zz" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tampered := *ev
+			tampered.Tags = append([][]string(nil), ev.Tags...)
+			tt.edit(&tampered)
+			if err := tampered.Verify(); err == nil {
+				t.Error("Verify() accepted a tampered event")
+			}
+		})
+	}
+}
+
+func TestEventTags(t *testing.T) {
+	ev := &Event{Tags: [][]string{{"d", "order-1"}, {"t"}, {"expiration", "1700000000"}}}
+	if got := ev.Tag("d"); got != "order-1" {
+		t.Errorf("Tag(d) = %q", got)
+	}
+	if got := ev.Tag("t"); got != "" {
+		t.Errorf("Tag(t) = %q, want empty for a tag without value", got)
+	}
+	if !ev.Expired(time.Unix(1700000000, 0)) || ev.Expired(time.Unix(1699999999, 0)) {
+		t.Error("Expired() wrong around the expiration")
+	}
+	if (&Event{}).Expired(time.Now()) {
+		t.Error("Expired() true without an expiration tag")
+	}
+}
@@ -0,0 +1,194 @@
+package nostr
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// subscriptionID names our order subscription on every relay.
+	subscriptionID = "klingdex-orders"
+
+	dialTimeout  = 15 * time.Second
+	writeTimeout = 10 * time.Second
+
+	// maxMessageSize bounds one relay message; orders are well below it.
+	maxMessageSize = 256 * 1024
+)
+
+var errNotConnected = errors.New("relay not connected")
+
+// validateRelay requires wss://, except for a relay on loopback.
+func validateRelay(relayURL string) error {
+	u, err := url.Parse(relayURL)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid nostr relay %q", relayURL)
+	}
+	switch u.Scheme {
+	case "wss":
+		return nil
+	case "ws":
+		if ip := net.ParseIP(u.Hostname()); (ip != nil && ip.IsLoopback()) || u.Hostname() == "localhost" {
+			return nil
+		}
+	}
+	return fmt.Errorf("nostr relay %q must use wss", relayURL)
+}
+
+// relay keeps one relay connection with our subscription open.
+type relay struct {
+	url string
+	b   *Bridge
+
+	mu          sync.Mutex // Guards the fields below and writes to conn
+	conn        *websocket.Conn
+	connectedAt time.Time
+	lastError   string
+}
+
+// run connects until ctx is done, waiting ReconnectInterval between
+// attempts.
+func (r *relay) run(ctx context.Context) {
+	for {
+		err := r.session(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		r.mu.Lock()
+		r.lastError = err.Error()
+		r.mu.Unlock()
+		r.b.log.Debug("Nostr relay disconnected", "relay", r.url, "error", err)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.b.cfg.ReconnectInterval):
+		}
+	}
+}
+
+// session subscribes to orders, republishes our live orders and reads
+// until the connection fails.
+func (r *relay) session(ctx context.Context) error {
+	dialCtx, cancel := context.WithTimeout(ctx, dialTimeout)
+	conn, _, err := websocket.DefaultDialer.DialContext(dialCtx, r.url, nil)
+	cancel()
+	if err != nil {
+		return err
+	}
+	conn.SetReadLimit(maxMessageSize)
+
+	r.mu.Lock()
+	r.conn = conn
+	r.connectedAt = time.Now()
+	r.lastError = ""
+	r.mu.Unlock()
+
+	done := make(chan struct{})
+	defer func() {
+		close(done)
+		r.mu.Lock()
+		r.conn = nil
+		r.mu.Unlock()
+		conn.Close()
+	}()
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if err := r.send([]interface{}{"REQ", subscriptionID, r.b.filter()}); err != nil {
+		return err
+	}
+	// Relays that missed our orders while we were disconnected get them now
+	for _, ev := range r.b.liveEvents() {
+		if err := r.send([]interface{}{"EVENT", ev}); err != nil {
+			return err
+		}
+	}
+	r.b.log.Debug("Nostr relay connected", "relay", r.url)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		if err := r.handle(ctx, data); err != nil {
+			return err
+		}
+	}
+}
+
+// handle processes one relay message. An error ends the session.
+func (r *relay) handle(ctx context.Context, data []byte) error {
+	var msg []json.RawMessage
+	if err := json.Unmarshal(data, &msg); err != nil || len(msg) < 2 {
+		return nil
+	}
+	var typ string
+	if err := json.Unmarshal(msg[0], &typ); err != nil {
+		return nil
+	}
+
+	switch typ {
+	case "EVENT":
+		if len(msg) < 3 {
+			return nil
+		}
+		var ev Event
+		if err := json.Unmarshal(msg[2], &ev); err != nil {
+			return nil
+		}
+		r.b.receive(ctx, &ev, r.url)
+	case "OK":
+		var accepted bool
+		var reason string
+		if len(msg) >= 4 && json.Unmarshal(msg[2], &accepted) == nil && !accepted {
+			json.Unmarshal(msg[3], &reason)
+			r.b.log.Debug("Nostr relay rejected event", "relay", r.url, "reason", reason)
+		}
+	case "NOTICE":
+		var notice string
+		json.Unmarshal(msg[1], &notice)
+		r.b.log.Debug("Nostr relay notice", "relay", r.url, "notice", notice)
+	case "CLOSED":
+		var reason string
+		if len(msg) >= 3 {
+			json.Unmarshal(msg[2], &reason)
+		}
+		return fmt.Errorf("subscription closed by relay: %s", reason)
+	}
+	return nil
+}
+
+// send writes one message to the relay.
+func (r *relay) send(v interface{}) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return errNotConnected
+	}
+	r.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	return r.conn.WriteJSON(v)
+}
+
+func (r *relay) status() RelayStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := RelayStatus{URL: r.url, Connected: r.conn != nil, LastError: r.lastError}
+	if r.conn != nil {
+		st.ConnectedAt = r.connectedAt.Unix()
+	}
+	return st
+}
@@ -0,0 +1,357 @@
+// Package orderbook keeps an in-memory, price-sorted view of the open
+// orders by pair.
+//
+// A pair is named by its two assets in lexical order, "BTC/LTC": the first
+// is the base, the second the quote. Orders offering the base are asks,
+// orders offering the quote are bids; the price of both is quote per base.
+// Asks are sorted cheapest first and bids highest first, compared exactly
+// on the order amounts.
+//
+// The book is refreshed from storage when orders change (Refresh) and
+// reports each change as a Diff. Every diff carries the pair's sequence
+// number after it was applied; a Snapshot carries the sequence number it
+// reflects, so subscribers apply the diffs with a higher one. A gap in the
+// sequence means a diff was lost and the snapshot must be fetched again.
+package orderbook
+
+import (
+	"math/big"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Order sides.
+const (
+	SideAsk = "ask" // Offers the base asset
+	SideBid = "bid" // Offers the quote asset
+)
+
+// Diff actions.
+const (
+	ActionAdd    = "add"
+	ActionUpdate = "update"
+	ActionRemove = "remove"
+)
+
+// Entry is an open order in the book.
+type Entry struct {
+	ID      string `json:"id"`
+	PeerID  string `json:"peer_id"`
+	IsLocal bool   `json:"is_local"`
+	Side    string `json:"side"`
+
+	OfferChain    string `json:"offer_chain"`
+	OfferToken    string `json:"offer_token,omitempty"`
+	OfferAmount   uint64 `json:"offer_amount"`
+	RequestChain  string `json:"request_chain"`
+	RequestToken  string `json:"request_token,omitempty"`
+	RequestAmount uint64 `json:"request_amount"`
+
+	// Partial fills: the smallest fill, the part taken and what remains of
+	// OfferAmount (omitted for orders taken whole only)
+	MinFillAmount   uint64 `json:"min_fill_amount,omitempty"`
+	FilledAmount    uint64 `json:"filled_amount,omitempty"`
+	RemainingAmount uint64 `json:"remaining_amount,omitempty"`
+
+	// The amounts in the pair's terms; price = QuoteAmount / BaseAmount
+	BaseAmount  uint64 `json:"base_amount"`
+	QuoteAmount uint64 `json:"quote_amount"`
+
+	PreferredMethods []string `json:"preferred_methods"`
+	Settlement       []string `json:"settlement,omitempty"`
+	CreatedAt        int64    `json:"created_at"`
+	ExpiresAt        *int64   `json:"expires_at,omitempty"`
+}
+
+// Change is one change of a Diff. Removals carry only the ID.
+type Change struct {
+	Action string `json:"action"`
+	ID     string `json:"id"`
+	Entry  *Entry `json:"entry,omitempty"`
+}
+
+// Diff is a change of one pair.
+type Diff struct {
+	Pair    string   `json:"pair"`
+	Seq     uint64   `json:"seq"`
+	Changes []Change `json:"changes"`
+}
+
+// Snapshot is the book of one pair.
+type Snapshot struct {
+	Pair string  `json:"pair"`
+	Seq  uint64  `json:"seq"`
+	Asks []Entry `json:"asks"`
+	Bids []Entry `json:"bids"`
+}
+
+// Source reads orders, usually *storage.Storage.
+type Source interface {
+	GetOrder(id string) (*storage.Order, error)
+	ListOrders(filter storage.OrderFilter) ([]*storage.Order, error)
+}
+
+// Book is the order book of all pairs.
+type Book struct {
+	src    Source
+	onDiff func(*Diff)
+
+	mu      sync.Mutex
+	entries map[string]*bookEntry
+	pairs   map[string]map[string]*bookEntry // Pair -> ID -> entry
+	seqs    map[string]uint64                // Pair -> last diff; kept when the pair empties
+}
+
+type bookEntry struct {
+	Entry
+	pair string
+}
+
+// New creates an empty book reading orders from src. onDiff, if set, is
+// called with every change, under the book's lock and in sequence order.
+func New(src Source, onDiff func(*Diff)) *Book {
+	return &Book{
+		src:     src,
+		onDiff:  onDiff,
+		entries: make(map[string]*bookEntry),
+		pairs:   make(map[string]map[string]*bookEntry),
+		seqs:    make(map[string]uint64),
+	}
+}
+
+// asset names a chain's coin or one of its tokens.
+func asset(chain, token string) string {
+	if token == "" {
+		return chain
+	}
+	return chain + ":" + strings.ToLower(token)
+}
+
+// PairOf returns the pair of an order and its side.
+func PairOf(o *storage.Order) (pair, side string) {
+	offer, request := asset(o.OfferChain, o.OfferToken), asset(o.RequestChain, o.RequestToken)
+	if offer <= request {
+		return offer + "/" + request, SideAsk
+	}
+	return request + "/" + offer, SideBid
+}
+
+// newEntry returns the book entry of an order.
+func newEntry(o *storage.Order) *bookEntry {
+	pair, side := PairOf(o)
+	e := &bookEntry{
+		pair: pair,
+		Entry: Entry{
+			ID:               o.ID,
+			PeerID:           o.PeerID,
+			IsLocal:          o.IsLocal,
+			Side:             side,
+			OfferChain:       o.OfferChain,
+			OfferToken:       o.OfferToken,
+			OfferAmount:      o.OfferAmount,
+			RequestChain:     o.RequestChain,
+			RequestToken:     o.RequestToken,
+			RequestAmount:    o.RequestAmount,
+			MinFillAmount:    o.MinFillAmount,
+			FilledAmount:     o.FilledAmount,
+			PreferredMethods: o.PreferredMethods,
+			Settlement:       o.Settlement,
+			CreatedAt:        o.CreatedAt.Unix(),
+		},
+	}
+	if o.MinFillAmount > 0 || o.FilledAmount > 0 {
+		e.RemainingAmount = o.Remaining()
+	}
+	if side == SideAsk {
+		e.BaseAmount, e.QuoteAmount = o.OfferAmount, o.RequestAmount
+	} else {
+		e.BaseAmount, e.QuoteAmount = o.RequestAmount, o.OfferAmount
+	}
+	if o.ExpiresAt != nil {
+		ts := o.ExpiresAt.Unix()
+		e.ExpiresAt = &ts
+	}
+	return e
+}
+
+// Load reads all open orders, replacing the book. Orders that changed
+// since the last load are reported as diffs.
+func (b *Book) Load() error {
+	status := storage.OrderStatusOpen
+	orders, err := b.src.ListOrders(storage.OrderFilter{Status: &status})
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	open := make(map[string]bool, len(orders))
+	for _, o := range orders {
+		open[o.ID] = true
+		b.applyLocked(o.ID, o)
+	}
+	for id := range b.entries {
+		if !open[id] {
+			b.applyLocked(id, nil)
+		}
+	}
+	return nil
+}
+
+// Refresh re-reads the given orders; nil IDs reload the whole book.
+func (b *Book) Refresh(ids []string) error {
+	if ids == nil {
+		return b.Load()
+	}
+	for _, id := range ids {
+		o, err := b.src.GetOrder(id)
+		if err != nil {
+			o = nil // Deleted
+		}
+		if o != nil && o.Status != storage.OrderStatusOpen {
+			o = nil
+		}
+		b.mu.Lock()
+		b.applyLocked(id, o)
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// applyLocked puts an open order (nil: not open) into the book and reports
+// the change. Caller must hold b.mu.
+func (b *Book) applyLocked(id string, o *storage.Order) {
+	old, had := b.entries[id]
+	if o == nil {
+		if !had {
+			return
+		}
+		b.removeLocked(old)
+		b.emitLocked(old.pair, Change{Action: ActionRemove, ID: id})
+		return
+	}
+
+	e := newEntry(o)
+	if had && sameEntry(&old.Entry, &e.Entry) && old.pair == e.pair {
+		return
+	}
+	if had {
+		b.removeLocked(old)
+	}
+	b.entries[id] = e
+	if b.pairs[e.pair] == nil {
+		b.pairs[e.pair] = make(map[string]*bookEntry)
+	}
+	b.pairs[e.pair][id] = e
+
+	entry := e.Entry
+	if had && old.pair == e.pair {
+		b.emitLocked(e.pair, Change{Action: ActionUpdate, ID: id, Entry: &entry})
+		return
+	}
+	if had {
+		b.emitLocked(old.pair, Change{Action: ActionRemove, ID: id})
+	}
+	b.emitLocked(e.pair, Change{Action: ActionAdd, ID: id, Entry: &entry})
+}
+
+func (b *Book) removeLocked(e *bookEntry) {
+	delete(b.entries, e.ID)
+	delete(b.pairs[e.pair], e.ID)
+	if len(b.pairs[e.pair]) == 0 {
+		delete(b.pairs, e.pair)
+	}
+}
+
+func (b *Book) emitLocked(pair string, change Change) {
+	b.seqs[pair]++
+	if b.onDiff != nil {
+		b.onDiff(&Diff{Pair: pair, Seq: b.seqs[pair], Changes: []Change{change}})
+	}
+}
+
+// sameEntry reports whether two entries of an order show the same state.
+func sameEntry(a, b *Entry) bool {
+	if a.ExpiresAt == nil != (b.ExpiresAt == nil) || (a.ExpiresAt != nil && *a.ExpiresAt != *b.ExpiresAt) {
+		return false
+	}
+	return a.IsLocal == b.IsLocal && a.OfferAmount == b.OfferAmount && a.RequestAmount == b.RequestAmount &&
+		a.FilledAmount == b.FilledAmount &&
+		strings.Join(a.PreferredMethods, ",") == strings.Join(b.PreferredMethods, ",") &&
+		strings.Join(a.Settlement, ",") == strings.Join(b.Settlement, ",")
+}
+
+// Snapshot returns the book of a pair, in either asset order.
+func (b *Book) Snapshot(pair string) *Snapshot {
+	pair = NormalizePair(pair)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	snap := &Snapshot{Pair: pair, Seq: b.seqs[pair], Asks: []Entry{}, Bids: []Entry{}}
+	for _, e := range b.pairs[pair] {
+		if e.Side == SideAsk {
+			snap.Asks = append(snap.Asks, e.Entry)
+		} else {
+			snap.Bids = append(snap.Bids, e.Entry)
+		}
+	}
+	sort.Slice(snap.Asks, func(i, j int) bool { return less(&snap.Asks[i], &snap.Asks[j], 1) })
+	sort.Slice(snap.Bids, func(i, j int) bool { return less(&snap.Bids[i], &snap.Bids[j], -1) })
+	return snap
+}
+
+// less orders entries by price (dir 1: ascending, -1: descending), then by
+// age and ID.
+func less(a, b *Entry, dir int) bool {
+	if c := comparePrice(a, b); c != 0 {
+		return c == dir*-1
+	}
+	if a.CreatedAt != b.CreatedAt {
+		return a.CreatedAt < b.CreatedAt
+	}
+	return a.ID < b.ID
+}
+
+// comparePrice compares the prices (quote per base) of two entries exactly:
+// -1 if a is cheaper, 1 if dearer.
+func comparePrice(a, b *Entry) int {
+	x := new(big.Int).Mul(new(big.Int).SetUint64(a.QuoteAmount), new(big.Int).SetUint64(b.BaseAmount))
+	y := new(big.Int).Mul(new(big.Int).SetUint64(b.QuoteAmount), new(big.Int).SetUint64(a.BaseAmount))
+	return x.Cmp(y)
+}
+
+// Pairs returns the pairs with open orders, sorted.
+func (b *Book) Pairs() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	pairs := make([]string, 0, len(b.pairs))
+	for pair := range b.pairs {
+		pairs = append(pairs, pair)
+	}
+	sort.Strings(pairs)
+	return pairs
+}
+
+// NormalizePair puts a pair's assets in lexical order: "LTC/BTC" becomes
+// "BTC/LTC". Chain symbols are upper-cased, token addresses lower-cased.
+func NormalizePair(pair string) string {
+	a, b, ok := strings.Cut(pair, "/")
+	if !ok {
+		return pair
+	}
+	a, b = normalizeAsset(a), normalizeAsset(b)
+	if a > b {
+		a, b = b, a
+	}
+	return a + "/" + b
+}
+
+func normalizeAsset(s string) string {
+	chain, token, _ := strings.Cut(strings.TrimSpace(s), ":")
+	return asset(strings.ToUpper(chain), token)
+}
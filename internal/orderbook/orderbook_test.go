@@ -0,0 +1,221 @@
+package orderbook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newTestBook(t *testing.T) (*Book, *storage.Storage, *[]*Diff) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	diffs := &[]*Diff{}
+	book := New(store, func(d *Diff) { *diffs = append(*diffs, d) })
+	store.OnOrderChange(func(ids []string) { book.Refresh(ids) })
+	return book, store, diffs
+}
+
+func addOrder(t *testing.T, store *storage.Storage, id, offer string, offerAmount uint64, request string, requestAmount uint64, age time.Duration) {
+	t.Helper()
+	order := &storage.Order{
+		ID: id, PeerID: "12D3KooWMaker", Status: storage.OrderStatusOpen,
+		OfferChain: offer, OfferAmount: offerAmount, RequestChain: request, RequestAmount: requestAmount,
+		PreferredMethods: []string{"htlc"}, CreatedAt: time.Now().Add(-age),
+	}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+}
+
+func ids(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.ID
+	}
+	return out
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPairOf(t *testing.T) {
+	tests := []struct {
+		order      storage.Order
+		pair, side string
+	}{
+		{storage.Order{OfferChain: "BTC", RequestChain: "LTC"}, "BTC/LTC", SideAsk},
+		{storage.Order{OfferChain: "LTC", RequestChain: "BTC"}, "BTC/LTC", SideBid},
+		{storage.Order{OfferChain: "ETH", OfferToken: "0xABC", RequestChain: "BTC"}, "BTC/ETH:0xabc", SideBid},
+	}
+	for _, tt := range tests {
+		pair, side := PairOf(&tt.order)
+		if pair != tt.pair || side != tt.side {
+			t.Errorf("PairOf(%s/%s) = %s %s, want %s %s", tt.order.OfferChain, tt.order.RequestChain, pair, side, tt.pair, tt.side)
+		}
+	}
+}
+
+func TestNormalizePair(t *testing.T) {
+	tests := map[string]string{
+		"BTC/LTC":       "BTC/LTC",
+		"ltc/btc":       "BTC/LTC",
+		"eth:0xABC/BTC": "BTC/ETH:0xabc",
+		"BTC":           "BTC",
+	}
+	for in, want := range tests {
+		if got := NormalizePair(in); got != want {
+			t.Errorf("NormalizePair(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestSnapshotSorting(t *testing.T) {
+	book, store, _ := newTestBook(t)
+
+	// Asks offer BTC for LTC: prices 150, 100, 100 (older), 200 LTC/BTC
+	addOrder(t, store, "ask-150", "BTC", 2, "LTC", 300, time.Minute)
+	addOrder(t, store, "ask-100-new", "BTC", 1, "LTC", 100, time.Minute)
+	addOrder(t, store, "ask-100-old", "BTC", 3, "LTC", 300, time.Hour)
+	addOrder(t, store, "ask-200", "BTC", 1, "LTC", 200, time.Minute)
+	// Bids offer LTC for BTC: prices 90, 95 LTC/BTC
+	addOrder(t, store, "bid-90", "LTC", 90, "BTC", 1, time.Minute)
+	addOrder(t, store, "bid-95", "LTC", 190, "BTC", 2, time.Minute)
+	// Another pair
+	addOrder(t, store, "other", "ETH", 1, "BTC", 1, time.Minute)
+
+	snap := book.Snapshot("LTC/BTC")
+	if snap.Pair != "BTC/LTC" {
+		t.Errorf("Pair = %s, want BTC/LTC", snap.Pair)
+	}
+	if want := []string{"ask-100-old", "ask-100-new", "ask-150", "ask-200"}; !equal(ids(snap.Asks), want) {
+		t.Errorf("asks = %v, want %v", ids(snap.Asks), want)
+	}
+	if want := []string{"bid-95", "bid-90"}; !equal(ids(snap.Bids), want) {
+		t.Errorf("bids = %v, want %v", ids(snap.Bids), want)
+	}
+	if b := snap.Bids[0]; b.BaseAmount != 2 || b.QuoteAmount != 190 {
+		t.Errorf("bid amounts = %d/%d, want 2/190", b.BaseAmount, b.QuoteAmount)
+	}
+	if snap.Seq != 6 {
+		t.Errorf("Seq = %d, want 6", snap.Seq)
+	}
+
+	if want := []string{"BTC/ETH", "BTC/LTC"}; !equal(book.Pairs(), want) {
+		t.Errorf("Pairs() = %v, want %v", book.Pairs(), want)
+	}
+	if snap := book.Snapshot("BTC/XMR"); len(snap.Asks) != 0 || len(snap.Bids) != 0 {
+		t.Errorf("empty pair snapshot = %+v", snap)
+	}
+}
+
+func TestDiffs(t *testing.T) {
+	book, store, diffs := newTestBook(t)
+
+	addOrder(t, store, "o1", "BTC", 1, "LTC", 100, time.Minute)
+	if len(*diffs) != 1 {
+		t.Fatalf("got %d diffs, want 1", len(*diffs))
+	}
+	d := (*diffs)[0]
+	if d.Pair != "BTC/LTC" || d.Seq != 1 || d.Changes[0].Action != ActionAdd || d.Changes[0].Entry.Side != SideAsk {
+		t.Errorf("add diff = %+v", d)
+	}
+
+	// Saving an unchanged order is not a change
+	order, _ := store.GetOrder("o1")
+	if err := store.SaveOrder(order); err != nil {
+		t.Fatalf("SaveOrder() error = %v", err)
+	}
+	if len(*diffs) != 1 {
+		t.Errorf("unchanged save emitted %d diffs", len(*diffs)-1)
+	}
+
+	expires := time.Now().Add(time.Hour)
+	order.ExpiresAt = &expires
+	if err := store.SaveOrder(order); err != nil {
+		t.Fatalf("SaveOrder() error = %v", err)
+	}
+	d = (*diffs)[len(*diffs)-1]
+	if d.Seq != 2 || d.Changes[0].Action != ActionUpdate || d.Changes[0].Entry.ExpiresAt == nil || *d.Changes[0].Entry.ExpiresAt != expires.Unix() {
+		t.Errorf("update diff = %+v", d)
+	}
+
+	if err := store.UpdateOrderStatus("o1", storage.OrderStatusCancelled); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	d = (*diffs)[len(*diffs)-1]
+	if d.Seq != 3 || d.Changes[0].Action != ActionRemove || d.Changes[0].ID != "o1" || d.Changes[0].Entry != nil {
+		t.Errorf("remove diff = %+v", d)
+	}
+	if len(book.Pairs()) != 0 {
+		t.Errorf("Pairs() = %v, want none", book.Pairs())
+	}
+}
+
+func TestPartialFillDiffs(t *testing.T) {
+	_, store, diffs := newTestBook(t)
+
+	addOrder(t, store, "o1", "BTC", 1000, "LTC", 100000, time.Minute)
+	if err := store.UpdateOrderFill("o1", 400, 1, storage.OrderStatusOpen); err != nil {
+		t.Fatalf("UpdateOrderFill() error = %v", err)
+	}
+	d := (*diffs)[len(*diffs)-1]
+	if d.Changes[0].Action != ActionUpdate || d.Changes[0].Entry.FilledAmount != 400 || d.Changes[0].Entry.RemainingAmount != 600 {
+		t.Errorf("fill diff = %+v", d.Changes[0].Entry)
+	}
+
+	if _, err := store.FillOrder("o1", 600); err != nil {
+		t.Fatalf("FillOrder() error = %v", err)
+	}
+	if d := (*diffs)[len(*diffs)-1]; d.Changes[0].Action != ActionRemove {
+		t.Errorf("filled order diff = %+v, want a removal", d.Changes[0])
+	}
+}
+
+func TestLoad(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	addOrder(t, store, "o1", "BTC", 1, "LTC", 100, time.Minute)
+	addOrder(t, store, "o2", "LTC", 100, "BTC", 1, time.Minute)
+
+	var diffs []*Diff
+	book := New(store, func(d *Diff) { diffs = append(diffs, d) })
+	if err := book.Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Errorf("Load() emitted %d diffs, want 2", len(diffs))
+	}
+
+	// Orders changed behind the book's back are picked up by a full reload
+	if err := store.DeleteOrder("o2"); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+	if err := book.Refresh(nil); err != nil {
+		t.Fatalf("Refresh(nil) error = %v", err)
+	}
+	if len(diffs) != 3 || diffs[2].Changes[0].Action != ActionRemove || diffs[2].Changes[0].ID != "o2" {
+		t.Errorf("reload diffs = %+v", diffs)
+	}
+	if snap := book.Snapshot("BTC/LTC"); len(snap.Asks) != 1 || len(snap.Bids) != 0 || snap.Seq != 3 {
+		t.Errorf("snapshot = %+v", snap)
+	}
+}
@@ -0,0 +1,424 @@
+// Package pricefeed checks order prices against external market rates.
+//
+// USD prices per chain, and per ERC-20 token of the token registry, are
+// fetched periodically from a CoinGecko-compatible endpoint (or pinned in
+// config) and held as integers scaled by PriceScale. An order's rate is
+// compared with the market rate between its two assets, each leg counted in
+// its own decimals, and orders deviating beyond the pair's bound are reported as off-market so
+// listings can flag or hide them and order creation and takes can refuse
+// fat-finger prices.
+package pricefeed
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// PriceScale is the fixed-point scale of USD prices (8 decimals).
+const PriceScale = 100_000_000
+
+// Check results.
+const (
+	StatusOK        = "ok"
+	StatusOffMarket = "off_market"
+	StatusUnknown   = "unknown" // No fresh price for one of the chains
+)
+
+// Price is a chain's USD price per whole coin, scaled by PriceScale. For a
+// token, Chain is the token symbol (e.g. USDC).
+type Price struct {
+	Chain     string    `json:"chain"`
+	USD       uint64    `json:"usd"`
+	Static    bool      `json:"static"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Verdict is the result of checking an order's rate.
+type Verdict struct {
+	Status string `json:"status"`
+
+	// DeviationBps is how much more the order requests than it offers at
+	// market value, in basis points. Negative values mean the maker gives
+	// away value.
+	DeviationBps int64 `json:"deviation_bps"`
+
+	// MaxDeviationBps is the bound applied to the pair.
+	MaxDeviationBps uint32 `json:"max_deviation_bps"`
+}
+
+// OffMarket returns true if the order is priced beyond the pair's bound.
+func (v *Verdict) OffMarket() bool {
+	return v != nil && v.Status == StatusOffMarket
+}
+
+// Checker holds market prices and checks orders against them.
+type Checker struct {
+	cfg    node.PriceSanityConfig
+	client *http.Client
+	log    *logging.Logger
+
+	mu     sync.RWMutex
+	prices map[string]Price
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a checker. Static prices are loaded immediately.
+func New(cfg node.PriceSanityConfig) (*Checker, error) {
+	defaults := node.DefaultConfig().PriceSanity
+	if cfg.FeedURL == "" {
+		cfg.FeedURL = defaults.FeedURL
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = defaults.RefreshInterval
+	}
+	if cfg.MaxPriceAge <= 0 {
+		cfg.MaxPriceAge = defaults.MaxPriceAge
+	}
+	if cfg.MaxDeviationBps == 0 {
+		cfg.MaxDeviationBps = defaults.MaxDeviationBps
+	}
+	if cfg.Action == "" {
+		cfg.Action = defaults.Action
+	}
+	if cfg.Action != node.PriceActionFlag && cfg.Action != node.PriceActionHide {
+		return nil, fmt.Errorf("invalid price_sanity action: %q (use flag or hide)", cfg.Action)
+	}
+	for pair := range cfg.Pairs {
+		if _, _, ok := splitPair(pair); !ok {
+			return nil, fmt.Errorf("invalid price_sanity pair: %q (use BASE/QUOTE)", pair)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	c := &Checker{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		log:    logging.GetDefault().Component("pricefeed"),
+		prices: make(map[string]Price),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	for chain, value := range cfg.StaticPrices {
+		usd, err := parseUSD(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid static price for %s: %w", chain, err)
+		}
+		c.prices[strings.ToUpper(chain)] = Price{Chain: strings.ToUpper(chain), USD: usd, Static: true}
+	}
+	return c, nil
+}
+
+// Action returns what listings do with off-market orders.
+func (c *Checker) Action() string {
+	return c.cfg.Action
+}
+
+// Start fetches prices now and then every refresh interval.
+func (c *Checker) Start() {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		ticker := time.NewTicker(c.cfg.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			if err := c.Refresh(c.ctx); err != nil {
+				c.log.Warn("Price refresh failed", "error", err)
+			}
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	c.log.Info("Price sanity checks started", "feed", c.cfg.FeedURL, "action", c.cfg.Action, "max_deviation_bps", c.cfg.MaxDeviationBps)
+}
+
+// Stop stops refreshing prices.
+func (c *Checker) Stop() {
+	c.cancel()
+	c.wg.Wait()
+}
+
+// Refresh fetches USD prices for every chain and token with a known coin ID
+// that is not pinned by a static price.
+func (c *Checker) Refresh(ctx context.Context) error {
+	idChains := make(map[string][]string)
+	c.mu.RLock()
+	for _, ids := range []map[string]string{config.PriceFeedCoinIDs, config.PriceFeedTokenIDs} {
+		for chain, id := range ids {
+			if p, ok := c.prices[chain]; ok && p.Static {
+				continue
+			}
+			idChains[id] = append(idChains[id], chain)
+		}
+	}
+	c.mu.RUnlock()
+	if len(idChains) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(idChains))
+	for id := range idChains {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	query := url.Values{}
+	query.Set("ids", strings.Join(ids, ","))
+	query.Set("vs_currencies", "usd")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.cfg.FeedURL+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("price feed request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("price feed: %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	// Decode numbers as strings so prices never pass through floats
+	var quotes map[string]map[string]json.Number
+	dec := json.NewDecoder(io.LimitReader(resp.Body, 1<<20))
+	dec.UseNumber()
+	if err := dec.Decode(&quotes); err != nil {
+		return fmt.Errorf("invalid price feed response: %w", err)
+	}
+
+	now := time.Now()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, chains := range idChains {
+		value, ok := quotes[id]["usd"]
+		if !ok {
+			continue
+		}
+		usd, err := parseUSD(value.String())
+		if err != nil || usd == 0 {
+			c.log.Debug("Ignoring price", "id", id, "value", value, "error", err)
+			continue
+		}
+		for _, chain := range chains {
+			c.prices[chain] = Price{Chain: chain, USD: usd, UpdatedAt: now}
+		}
+	}
+	return nil
+}
+
+// Prices returns the current prices sorted by chain.
+func (c *Checker) Prices() []Price {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	prices := make([]Price, 0, len(c.prices))
+	for _, p := range c.prices {
+		prices = append(prices, p)
+	}
+	sort.Slice(prices, func(i, j int) bool { return prices[i].Chain < prices[j].Chain })
+	return prices
+}
+
+// price returns a fresh price for the chain.
+func (c *Checker) price(chain string, now time.Time) (Price, bool) {
+	c.mu.RLock()
+	p, ok := c.prices[chain]
+	c.mu.RUnlock()
+	if !ok || p.USD == 0 {
+		return Price{}, false
+	}
+	if !p.Static && now.Sub(p.UpdatedAt) > c.cfg.MaxPriceAge {
+		return Price{}, false
+	}
+	return p, true
+}
+
+// MaxDeviation returns the bound for a pair in basis points.
+func (c *Checker) MaxDeviation(chainA, chainB string) uint32 {
+	for pair, bps := range c.cfg.Pairs {
+		base, quote, _ := splitPair(pair)
+		if (base == chainA && quote == chainB) || (base == chainB && quote == chainA) {
+			return bps
+		}
+	}
+	return c.cfg.MaxDeviationBps
+}
+
+// Leg is one side of an order: an amount, in the smallest unit, of an asset
+// priced by the feed. Asset is a chain symbol for the native coin or a token
+// symbol (e.g. USDC), with the decimals of that coin or token.
+type Leg struct {
+	Asset    string
+	Decimals uint8
+	Amount   uint64
+}
+
+// ChainLeg returns the leg for an amount of a chain's native coin. It
+// returns false for an unknown chain.
+func ChainLeg(chain string, amount uint64) (Leg, bool) {
+	coin, ok := config.GetCoin(chain)
+	if !ok {
+		return Leg{}, false
+	}
+	return Leg{Asset: chain, Decimals: coin.Decimals, Amount: amount}, true
+}
+
+// Check compares an order's rate with the market rate between its chains.
+// Amounts are in each chain's smallest unit.
+func (c *Checker) Check(offerChain string, offerAmount uint64, requestChain string, requestAmount uint64) *Verdict {
+	offer, ok := ChainLeg(offerChain, offerAmount)
+	if !ok {
+		return &Verdict{Status: StatusUnknown, MaxDeviationBps: c.MaxDeviation(offerChain, requestChain)}
+	}
+	request, ok := ChainLeg(requestChain, requestAmount)
+	if !ok {
+		return &Verdict{Status: StatusUnknown, MaxDeviationBps: c.MaxDeviation(offerChain, requestChain)}
+	}
+	return c.CheckLegs(offer, request)
+}
+
+// CheckLegs compares an order's rate with the market rate between the
+// assets of its legs, each counted in its own decimals. Pair bounds are
+// looked up by asset, e.g. USDC/USDT.
+func (c *Checker) CheckLegs(offer, request Leg) *Verdict {
+	verdict := &Verdict{
+		Status:          StatusUnknown,
+		MaxDeviationBps: c.MaxDeviation(offer.Asset, request.Asset),
+	}
+
+	now := time.Now()
+	offerPrice, ok := c.price(offer.Asset, now)
+	if !ok {
+		return verdict
+	}
+	requestPrice, ok := c.price(request.Asset, now)
+	if !ok {
+		return verdict
+	}
+	if offer.Amount == 0 {
+		return verdict
+	}
+
+	// Compare market values over a common denominator:
+	// offer  = offerAmount * offerPrice / 10^offerDecimals
+	// request = requestAmount * requestPrice / 10^requestDecimals
+	offerValue := new(big.Int).SetUint64(offer.Amount)
+	offerValue.Mul(offerValue, new(big.Int).SetUint64(offerPrice.USD))
+	offerValue.Mul(offerValue, pow10(request.Decimals))
+
+	requestValue := new(big.Int).SetUint64(request.Amount)
+	requestValue.Mul(requestValue, new(big.Int).SetUint64(requestPrice.USD))
+	requestValue.Mul(requestValue, pow10(offer.Decimals))
+
+	deviation := new(big.Int).Sub(requestValue, offerValue)
+	deviation.Mul(deviation, big.NewInt(10000))
+	deviation.Quo(deviation, offerValue)
+
+	verdict.Status = StatusOK
+	if !deviation.IsInt64() {
+		verdict.Status = StatusOffMarket
+		if deviation.Sign() > 0 {
+			verdict.DeviationBps = 1<<63 - 1
+		} else {
+			verdict.DeviationBps = -1 << 63
+		}
+		return verdict
+	}
+	verdict.DeviationBps = deviation.Int64()
+
+	abs := verdict.DeviationBps
+	if abs < 0 {
+		abs = -abs
+	}
+	if abs > int64(verdict.MaxDeviationBps) {
+		verdict.Status = StatusOffMarket
+	}
+	return verdict
+}
+
+// USDValue returns the market value of an amount in the chain's smallest
+// unit, scaled by PriceScale. It returns false without a fresh price.
+func (c *Checker) USDValue(chain string, amount *big.Int) (uint64, bool) {
+	coin, ok := config.GetCoin(chain)
+	if !ok {
+		return 0, false
+	}
+	return c.AssetUSDValue(chain, coin.Decimals, amount)
+}
+
+// AssetUSDValue returns the market value of an amount of a chain's coin or a
+// token, in the smallest unit of an asset with the given decimals, scaled by
+// PriceScale. It returns false without a fresh price.
+func (c *Checker) AssetUSDValue(asset string, decimals uint8, amount *big.Int) (uint64, bool) {
+	p, ok := c.price(asset, time.Now())
+	if !ok {
+		return 0, false
+	}
+	value := new(big.Int).Mul(amount, new(big.Int).SetUint64(p.USD))
+	value.Quo(value, pow10(decimals))
+	if !value.IsUint64() {
+		return 0, false
+	}
+	return value.Uint64(), true
+}
+
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+func splitPair(pair string) (string, string, bool) {
+	base, quote, ok := strings.Cut(strings.ToUpper(pair), "/")
+	if !ok || base == "" || quote == "" {
+		return "", "", false
+	}
+	return base, quote, true
+}
+
+// parseUSD parses a decimal USD amount (e.g. "65432.1", "1.2e-05") into a
+// PriceScale fixed-point integer, truncating extra precision.
+func parseUSD(s string) (uint64, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("invalid price: %q", s)
+	}
+	if r.Sign() < 0 {
+		return 0, fmt.Errorf("negative price: %q", s)
+	}
+	r.Mul(r, new(big.Rat).SetInt64(PriceScale))
+	scaled := new(big.Int).Quo(r.Num(), r.Denom())
+	if !scaled.IsUint64() {
+		return 0, fmt.Errorf("price out of range: %q", s)
+	}
+	return scaled.Uint64(), nil
+}
+
+// FormatUSD formats a PriceScale fixed-point price as a decimal string.
+func FormatUSD(usd uint64) string {
+	whole := usd / PriceScale
+	frac := usd % PriceScale
+	if frac == 0 {
+		return fmt.Sprintf("%d", whole)
+	}
+	return strings.TrimRight(fmt.Sprintf("%d.%08d", whole, frac), "0")
+}
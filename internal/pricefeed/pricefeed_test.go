@@ -0,0 +1,221 @@
+package pricefeed
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func TestParseUSD(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    uint64
+		wantErr bool
+	}{
+		{"65000", 65000 * PriceScale, false},
+		{"65432.12", 6543212000000, false},
+		{"0.000012345678", 1234, false}, // truncated to 8 decimals
+		{"1.2e-05", 1200, false},
+		{"-1", 0, true},
+		{"abc", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseUSD(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseUSD(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseUSD(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestFormatUSD(t *testing.T) {
+	if got := FormatUSD(65000 * PriceScale); got != "65000" {
+		t.Errorf("FormatUSD() = %s, want 65000", got)
+	}
+	if got := FormatUSD(6543212000000); got != "65432.12" {
+		t.Errorf("FormatUSD() = %s, want 65432.12", got)
+	}
+}
+
+func newStaticChecker(t *testing.T, cfg node.PriceSanityConfig) *Checker {
+	t.Helper()
+	if cfg.StaticPrices == nil {
+		cfg.StaticPrices = map[string]string{"BTC": "60000", "LTC": "60", "ETH": "3000"}
+	}
+	c, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return c
+}
+
+func TestCheck(t *testing.T) {
+	c := newStaticChecker(t, node.PriceSanityConfig{MaxDeviationBps: 1000})
+
+	// 1 BTC for 1000 LTC is the market rate
+	v := c.Check("BTC", 100_000_000, "LTC", 1000*100_000_000)
+	if v.Status != StatusOK || v.DeviationBps != 0 {
+		t.Errorf("market order: %+v, want ok with 0 bps", v)
+	}
+
+	// Asking 5% more than market is within 10%
+	v = c.Check("BTC", 100_000_000, "LTC", 1050*100_000_000)
+	if v.Status != StatusOK || v.DeviationBps != 500 {
+		t.Errorf("5%% premium: %+v, want ok with 500 bps", v)
+	}
+
+	// Fat finger: 1 BTC for 100 LTC gives away 90%
+	v = c.Check("BTC", 100_000_000, "LTC", 100*100_000_000)
+	if v.Status != StatusOffMarket || v.DeviationBps != -9000 {
+		t.Errorf("fat finger: %+v, want off_market with -9000 bps", v)
+	}
+
+	// Different decimals: 1 ETH (18) for 0.05 BTC (8)
+	v = c.Check("ETH", 1_000_000_000_000_000_000, "BTC", 5_000_000)
+	if v.Status != StatusOK || v.DeviationBps != 0 {
+		t.Errorf("ETH/BTC: %+v, want ok with 0 bps", v)
+	}
+
+	// No price for XMR
+	v = c.Check("XMR", 1_000_000_000_000, "BTC", 100_000)
+	if v.Status != StatusUnknown {
+		t.Errorf("unpriced chain: status = %s, want unknown", v.Status)
+	}
+}
+
+func TestCheckLegs(t *testing.T) {
+	c := newStaticChecker(t, node.PriceSanityConfig{
+		MaxDeviationBps: 1000,
+		StaticPrices:    map[string]string{"USDC": "1", "USDT": "1", "ETH": "3000"},
+	})
+	usdc := func(amount uint64) Leg { return Leg{Asset: "USDC", Decimals: 6, Amount: amount} }
+	usdt := func(amount uint64) Leg { return Leg{Asset: "USDT", Decimals: 18, Amount: amount} }
+
+	// 10 USDC (6 decimals) for 10 USDT (18 decimals) is the market rate
+	v := c.CheckLegs(usdc(10_000_000), usdt(10_000_000_000_000_000_000))
+	if v.Status != StatusOK || v.DeviationBps != 0 {
+		t.Errorf("10 USDC for 10 USDT: %+v, want ok with 0 bps", v)
+	}
+
+	// Reading USDT in USDC's decimals would ask for 10^12 times too little
+	v = c.CheckLegs(usdc(10_000_000), usdt(10_000_000))
+	if v.Status != StatusOffMarket {
+		t.Errorf("decimals mix-up: %+v, want off_market", v)
+	}
+
+	// A token against a native coin: 3000 USDC for 1 ETH
+	eth, ok := ChainLeg("ETH", 1_000_000_000_000_000_000)
+	if !ok {
+		t.Fatal("ChainLeg(ETH) failed")
+	}
+	v = c.CheckLegs(usdc(3000_000_000), eth)
+	if v.Status != StatusOK || v.DeviationBps != 0 {
+		t.Errorf("USDC/ETH: %+v, want ok with 0 bps", v)
+	}
+
+	// No price for DAI
+	v = c.CheckLegs(Leg{Asset: "DAI", Decimals: 18, Amount: 1}, usdt(1))
+	if v.Status != StatusUnknown {
+		t.Errorf("unpriced token: status = %s, want unknown", v.Status)
+	}
+}
+
+func TestUSDValue(t *testing.T) {
+	c := newStaticChecker(t, node.PriceSanityConfig{})
+
+	// 0.5 BTC at 60000
+	if v, ok := c.USDValue("BTC", big.NewInt(50_000_000)); !ok || v != 30000*PriceScale {
+		t.Errorf("USDValue(BTC) = %d, %v, want 30000", v, ok)
+	}
+	// 0.1 ETH at 3000, 18 decimals
+	wei, _ := new(big.Int).SetString("100000000000000000", 10)
+	if v, ok := c.USDValue("ETH", wei); !ok || v != 300*PriceScale {
+		t.Errorf("USDValue(ETH) = %d, %v, want 300", v, ok)
+	}
+	if _, ok := c.USDValue("XMR", big.NewInt(1)); ok {
+		t.Error("USDValue() should fail without a price")
+	}
+}
+
+func TestCheckPairOverride(t *testing.T) {
+	c := newStaticChecker(t, node.PriceSanityConfig{
+		MaxDeviationBps: 1000,
+		Pairs:           map[string]uint32{"ltc/btc": 200},
+	})
+
+	v := c.Check("BTC", 100_000_000, "LTC", 1050*100_000_000)
+	if v.Status != StatusOffMarket || v.MaxDeviationBps != 200 {
+		t.Errorf("pair override: %+v, want off_market with max 200", v)
+	}
+	if got := c.MaxDeviation("BTC", "ETH"); got != 1000 {
+		t.Errorf("MaxDeviation(BTC, ETH) = %d, want default 1000", got)
+	}
+}
+
+func TestNewInvalidConfig(t *testing.T) {
+	if _, err := New(node.PriceSanityConfig{Action: "delete"}); err == nil {
+		t.Error("expected error for invalid action")
+	}
+	if _, err := New(node.PriceSanityConfig{Pairs: map[string]uint32{"BTC": 100}}); err == nil {
+		t.Error("expected error for invalid pair")
+	}
+	if _, err := New(node.PriceSanityConfig{StaticPrices: map[string]string{"BTC": "lots"}}); err == nil {
+		t.Error("expected error for invalid static price")
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	var query string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.RawQuery
+		w.Write([]byte(`{"bitcoin":{"usd":60000.5},"litecoin":{"usd":60}}`))
+	}))
+	defer srv.Close()
+
+	c, err := New(node.PriceSanityConfig{
+		FeedURL:      srv.URL,
+		StaticPrices: map[string]string{"ETH": "3000"},
+	})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	if err := c.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh() error = %v", err)
+	}
+
+	if !strings.Contains(query, "bitcoin") || !strings.Contains(query, "vs_currencies=usd") {
+		t.Errorf("query = %s, want bitcoin in usd", query)
+	}
+
+	prices := make(map[string]Price)
+	for _, p := range c.Prices() {
+		prices[p.Chain] = p
+	}
+	if prices["BTC"].USD != 6000050000000 {
+		t.Errorf("BTC price = %d, want 6000050000000", prices["BTC"].USD)
+	}
+	if !prices["ETH"].Static || prices["ETH"].USD != 3000*PriceScale {
+		t.Errorf("ETH price = %+v, want static 3000", prices["ETH"])
+	}
+	if _, ok := prices["DOGE"]; ok {
+		t.Error("DOGE missing from the feed should have no price")
+	}
+}
+
+func TestStalePrice(t *testing.T) {
+	c := newStaticChecker(t, node.PriceSanityConfig{MaxPriceAge: time.Minute})
+	c.prices["DOGE"] = Price{Chain: "DOGE", USD: 10_000_000, UpdatedAt: time.Now().Add(-time.Hour)}
+
+	if v := c.Check("DOGE", 100_000_000, "LTC", 100_000); v.Status != StatusUnknown {
+		t.Errorf("stale price: status = %s, want unknown", v.Status)
+	}
+}
@@ -0,0 +1,113 @@
+// Package rpc - Address validation handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// AddressValidateParams is the parameters for address_validate.
+type AddressValidateParams struct {
+	Symbol  string `json:"symbol"`
+	Address string `json:"address"`
+	Network string `json:"network,omitempty"` // "mainnet", "testnet" or a test network such as "signet" or "holesky", default: node network
+
+	// CheckContract queries the chain backend to tell contracts from EOAs (EVM only).
+	CheckContract bool `json:"check_contract,omitempty"`
+}
+
+// AddressValidateResult is the response for address_validate.
+type AddressValidateResult struct {
+	Valid          bool   `json:"valid"`
+	Symbol         string `json:"symbol"`
+	Network        string `json:"network"`
+	Testnet        string `json:"testnet,omitempty"` // Test network validated against, e.g. "signet"
+	ChainType      string `json:"chain_type"`
+	AddressType    string `json:"address_type,omitempty"`
+	Normalized     string `json:"normalized,omitempty"`
+	Error          string `json:"error,omitempty"`
+	HRP            string `json:"hrp,omitempty"`
+	WitnessVersion *uint8 `json:"witness_version,omitempty"`
+	HasChecksum    bool   `json:"has_checksum,omitempty"`   // EVM: mixed-case EIP-55 address
+	ChecksumValid  bool   `json:"checksum_valid,omitempty"` // EVM: EIP-55 checksum matched
+	IsContract     *bool  `json:"is_contract,omitempty"`    // EVM: set when check_contract succeeded
+}
+
+func (s *Server) addressValidate(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p AddressValidateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.Address == "" {
+		return nil, fmt.Errorf("address is required")
+	}
+
+	symbol := strings.ToUpper(p.Symbol)
+	network := chain.Mainnet
+	if s.wallet != nil {
+		network = s.wallet.Network()
+	}
+	// A named test network is validated against its own params
+	var testnet string
+	if p.Network != "" {
+		name := strings.ToLower(p.Network)
+		switch chain.Network(name) {
+		case chain.Mainnet, chain.Testnet:
+			network = chain.Network(name)
+		default:
+			if _, ok := chain.GetTestnet(symbol, name); !ok {
+				return nil, fmt.Errorf("invalid network: %s", p.Network)
+			}
+			network, testnet = chain.Testnet, name
+		}
+	}
+	if network == chain.Testnet && testnet == "" {
+		testnet = chain.ActiveTestnet(symbol)
+	}
+
+	chainParams, ok := chain.Get(symbol, network)
+	if testnet != "" {
+		chainParams, ok = chain.GetTestnet(symbol, testnet)
+	}
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", p.Symbol)
+	}
+
+	v := wallet.ValidateAddressDetailed(p.Address, chainParams)
+	result := &AddressValidateResult{
+		Valid:          v.Valid,
+		Symbol:         symbol,
+		Network:        string(network),
+		Testnet:        testnet,
+		ChainType:      string(v.ChainType),
+		AddressType:    string(v.Type),
+		Normalized:     v.Normalized,
+		Error:          v.Error,
+		HRP:            v.HRP,
+		WitnessVersion: v.WitnessVersion,
+		HasChecksum:    v.HasChecksum,
+		ChecksumValid:  v.ChecksumValid,
+	}
+
+	// Contract detection needs a backend for the node's own network
+	if p.CheckContract && v.Valid && v.ChainType == chain.ChainTypeEVM &&
+		s.wallet != nil && network == s.wallet.Network() && testnet == chain.ActiveTestnet(symbol) {
+		isContract, err := s.wallet.IsEVMContract(ctx, symbol, v.Normalized)
+		if err != nil {
+			s.log.Debug("Contract detection failed", "symbol", symbol, "error", err)
+		} else {
+			result.IsContract = &isContract
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestAddressValidateHandler(t *testing.T) {
+	s := &Server{handlers: make(map[string]Handler)}
+
+	tests := []struct {
+		name        string
+		params      string
+		wantValid   bool
+		wantType    string
+		errContains string
+	}{
+		{"btc mainnet", `{"symbol":"BTC","address":"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}`, true, "p2wpkh", ""},
+		{"btc testnet", `{"symbol":"btc","network":"testnet","address":"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"}`, true, "p2wpkh", ""},
+		{"wrong network", `{"symbol":"BTC","address":"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"}`, false, "", ""},
+		{"eth", `{"symbol":"ETH","address":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`, true, "evm", ""},
+		{"missing symbol", `{"address":"x"}`, false, "", "symbol is required"},
+		{"missing address", `{"symbol":"BTC"}`, false, "", "address is required"},
+		{"btc signet", `{"symbol":"BTC","network":"signet","address":"tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"}`, true, "p2wpkh", ""},
+		{"btc signet mainnet address", `{"symbol":"BTC","network":"Signet","address":"bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq"}`, false, "", ""},
+		{"eth holesky", `{"symbol":"ETH","network":"holesky","address":"0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"}`, true, "evm", ""},
+		{"bad network", `{"symbol":"BTC","address":"x","network":"regtest"}`, false, "", "invalid network"},
+		{"test network of another chain", `{"symbol":"LTC","address":"x","network":"signet"}`, false, "", "invalid network"},
+		{"unsupported chain", `{"symbol":"FAKE","address":"x"}`, false, "", "unsupported chain"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			res, err := s.addressValidate(context.Background(), json.RawMessage(tt.params))
+			if tt.errContains != "" {
+				if err == nil || !containsStr(err.Error(), tt.errContains) {
+					t.Fatalf("error = %v, want containing %q", err, tt.errContains)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			result := res.(*AddressValidateResult)
+			if result.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v (error: %s)", result.Valid, tt.wantValid, result.Error)
+			}
+			if result.AddressType != tt.wantType {
+				t.Errorf("AddressType = %s, want %s", result.AddressType, tt.wantType)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Package rpc - Human-readable EVM amount parsing and formatting.
+package rpc
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/pkg/helpers"
+)
+
+// evmChain returns the params of an EVM chain on the wallet's network.
+func (s *Server) evmChain(symbol string) (*chain.Params, error) {
+	network := chain.Mainnet
+	if s.wallet != nil {
+		network = s.wallet.Network()
+	}
+	params, ok := chain.Get(symbol, network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	if params.Type != chain.ChainTypeEVM {
+		return nil, fmt.Errorf("chain %s is not an EVM chain", symbol)
+	}
+	return params, nil
+}
+
+// resolveToken resolves a token given by contract address or registry symbol
+// (e.g. "USDC"). The returned info is nil for an unregistered address, whose
+// decimals are unknown.
+func resolveToken(params *chain.Params, token string) (string, *chain.TokenInfo, error) {
+	if strings.HasPrefix(token, "0x") || strings.HasPrefix(token, "0X") {
+		return token, chain.GetTokenByAddress(params.ChainID, token), nil
+	}
+	info := chain.GetToken(params.ChainID, strings.ToUpper(token))
+	if info == nil {
+		return "", nil, fmt.Errorf("token %s is not registered on %s", token, params.Symbol)
+	}
+	return info.Address, info, nil
+}
+
+// parseEVMAmount returns the amount in base units from either a raw amount or
+// a decimal amount. known is false when the asset's decimals are unknown, in
+// which case only raw amounts are accepted.
+func parseEVMAmount(raw, decimal string, decimals uint8, known bool) (*big.Int, error) {
+	switch {
+	case raw != "" && decimal != "":
+		return nil, fmt.Errorf("specify either amount or amount_decimal, not both")
+	case decimal != "":
+		if !known {
+			return nil, fmt.Errorf("amount_decimal requires a registered token; pass amount in base units")
+		}
+		amount, err := helpers.ParseBigAmount(decimal, decimals)
+		if err != nil {
+			return nil, fmt.Errorf("invalid amount_decimal: %w", err)
+		}
+		return amount, nil
+	case raw != "":
+		amount, ok := new(big.Int).SetString(raw, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount: %s", raw)
+		}
+		return amount, nil
+	default:
+		return nil, fmt.Errorf("amount or amount_decimal is required")
+	}
+}
+
+// tokenUnits returns the symbol and decimals for a token, if registered.
+func tokenUnits(info *chain.TokenInfo) (string, uint8, bool) {
+	if info == nil {
+		return "", 0, false
+	}
+	return info.Symbol, info.Decimals, true
+}
+
+// formatEVMAmount formats base units as a decimal string, or returns "" when
+// the decimals are unknown.
+func formatEVMAmount(amount *big.Int, decimals uint8, known bool) string {
+	if !known {
+		return ""
+	}
+	return helpers.FormatBigAmount(amount, decimals)
+}
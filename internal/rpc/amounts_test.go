@@ -0,0 +1,85 @@
+package rpc
+
+import (
+	"testing"
+)
+
+func TestParseEVMAmount(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		decimal string
+		known   bool
+		want    string
+		wantErr bool
+	}{
+		{"raw", "1500000", "", false, "1500000", false},
+		{"decimal", "", "1.5", true, "1500000", false},
+		{"both", "1", "1", true, "", true},
+		{"neither", "", "", true, "", true},
+		{"unknown decimals", "", "1.5", false, "", true},
+		{"excess precision", "", "0.0000001", true, "", true},
+		{"negative raw", "-1", "", true, "", true},
+		{"raw not integer", "1.5", "", true, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEVMAmount(tt.raw, tt.decimal, 6, tt.known)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("parseEVMAmount() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveToken(t *testing.T) {
+	s := &Server{}
+	eth, err := s.evmChain("ETH")
+	if err != nil {
+		t.Fatalf("evmChain(ETH) error = %v", err)
+	}
+	if _, err := s.evmChain("BTC"); err == nil {
+		t.Error("evmChain(BTC) should fail")
+	}
+
+	addr, token, err := resolveToken(eth, "usdc")
+	if err != nil || token == nil || token.Decimals != 6 || addr != token.Address {
+		t.Fatalf("resolveToken(usdc) = %s, %+v, %v", addr, token, err)
+	}
+
+	// Known address in any case resolves to the registry entry
+	_, token, err = resolveToken(eth, "0xDAC17F958D2EE523A2206206994597C13D831EC7")
+	if err != nil || token == nil || token.Symbol != "USDT" {
+		t.Errorf("resolveToken(USDT address) = %+v, %v", token, err)
+	}
+
+	// Unregistered address passes through without units
+	addr, token, err = resolveToken(eth, "0x0000000000000000000000000000000000000001")
+	if err != nil || token != nil || addr != "0x0000000000000000000000000000000000000001" {
+		t.Errorf("resolveToken(unknown address) = %s, %+v, %v", addr, token, err)
+	}
+
+	if _, _, err := resolveToken(eth, "NOPE"); err == nil {
+		t.Error("resolveToken(NOPE) should fail")
+	}
+}
+
+func TestFormatEVMAmount(t *testing.T) {
+	amount, _ := parseEVMAmount("", "1.5", 18, true)
+	if got := formatEVMAmount(amount, 18, true); got != "1.5" {
+		t.Errorf("formatEVMAmount() = %s, want 1.5", got)
+	}
+	if got := formatEVMAmount(amount, 0, false); got != "" {
+		t.Errorf("formatEVMAmount(unknown) = %s, want empty", got)
+	}
+}
@@ -0,0 +1,135 @@
+// Package rpc - Swap archive handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/archive"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// SetArchive enables the archive_* methods and the re-import of archived
+// swaps on lookup.
+func (s *Server) SetArchive(a *archive.Archiver) {
+	s.archive = a
+}
+
+// ArchiveSearchParams is the parameters for archive_search.
+type ArchiveSearchParams struct {
+	TradeID string `json:"trade_id,omitempty"`
+	OrderID string `json:"order_id,omitempty"`
+	PeerID  string `json:"peer_id,omitempty"` // Maker or taker
+	Chain   string `json:"chain,omitempty"`   // Offer or request chain
+	Since   int64  `json:"since,omitempty"`   // Completed at or after (unix seconds)
+	Until   int64  `json:"until,omitempty"`   // Completed before (unix seconds)
+	Limit   int    `json:"limit,omitempty"`   // Default 100
+}
+
+// ArchiveSearchResult is the response for archive_search.
+type ArchiveSearchResult struct {
+	Swaps []*storage.ArchivedSwap `json:"swaps"`
+	Count int                     `json:"count"`
+}
+
+// ArchiveRestoreParams is the parameters for archive_restore.
+type ArchiveRestoreParams struct {
+	TradeID string `json:"trade_id"`
+}
+
+// ArchiveRestoreResult is the response for archive_restore.
+type ArchiveRestoreResult struct {
+	TradeID string `json:"trade_id"`
+	State   string `json:"state"`
+	Legs    int    `json:"legs"`
+}
+
+// archiveSearch searches the index of archived swaps.
+func (s *Server) archiveSearch(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.archive == nil {
+		return nil, fmt.Errorf("archive not initialized")
+	}
+	var p ArchiveSearchParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if p.Limit <= 0 {
+		p.Limit = 100
+	}
+
+	filter := storage.ArchiveFilter{
+		TradeID: p.TradeID,
+		OrderID: p.OrderID,
+		PeerID:  p.PeerID,
+		Chain:   p.Chain,
+		Limit:   p.Limit,
+	}
+	if p.Since > 0 {
+		filter.Since = time.Unix(p.Since, 0)
+	}
+	if p.Until > 0 {
+		filter.Until = time.Unix(p.Until, 0)
+	}
+	swaps, err := s.archive.Search(filter)
+	if err != nil {
+		return nil, err
+	}
+	if swaps == nil {
+		swaps = []*storage.ArchivedSwap{}
+	}
+	return &ArchiveSearchResult{Swaps: swaps, Count: len(swaps)}, nil
+}
+
+// archiveRestore re-imports an archived swap into the database.
+func (s *Server) archiveRestore(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.archive == nil {
+		return nil, fmt.Errorf("archive not initialized")
+	}
+	var p ArchiveRestoreParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+
+	entry, err := s.archive.Restore(p.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to restore swap: %w", err)
+	}
+	return &ArchiveRestoreResult{TradeID: p.TradeID, State: string(entry.Swap.State), Legs: len(entry.Legs)}, nil
+}
+
+// archiveRun archives the swaps that are due now.
+func (s *Server) archiveRun(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.archive == nil {
+		return nil, fmt.Errorf("archive not initialized")
+	}
+	return s.archive.Run(ctx)
+}
+
+// restoreArchived re-imports tradeID if it was archived, and loads it into
+// the coordinator, so lookups of old swaps work transparently. It reports
+// whether the swap was restored.
+func (s *Server) restoreArchived(ctx context.Context, tradeID string) bool {
+	if s.archive == nil {
+		return false
+	}
+	if _, err := s.archive.Restore(tradeID); err != nil {
+		if !errors.Is(err, storage.ErrArchivedSwapNotFound) {
+			s.log.Warn("Failed to restore archived swap", "trade_id", tradeID, "error", err)
+		}
+		return false
+	}
+	if s.coordinator != nil {
+		if err := s.coordinator.RecoverSwap(ctx, tradeID); err != nil {
+			s.log.Warn("Failed to load restored swap", "trade_id", tradeID, "error", err)
+		}
+	}
+	return true
+}
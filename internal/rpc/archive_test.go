@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/archive"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestArchiveHandlers(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.archiveSearch(ctx, nil); err == nil {
+		t.Error("archiveSearch() should fail when the archive is not initialized")
+	}
+
+	a, err := archive.New(node.ArchiveConfig{Retention: time.Hour}, filepath.Join(t.TempDir(), "archive"), s.store)
+	if err != nil {
+		t.Fatalf("archive.New() error = %v", err)
+	}
+	s.SetArchive(a)
+
+	completed := time.Now().Add(-2 * time.Hour)
+	if err := s.store.CreateTrade(&storage.Trade{
+		ID: "trade-1", OrderID: "order-1", MakerPeerID: "makerA", TakerPeerID: "us",
+		OurRole: storage.TradeRoleTaker, Method: "htlc", State: storage.TradeStateRedeemed,
+		OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		CreatedAt: completed,
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+	if err := s.store.SaveSwap(&storage.SwapRecord{
+		TradeID: "trade-1", OrderID: "order-1", MakerPeerID: "makerA", TakerPeerID: "us",
+		OurRole: "taker", OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		State: storage.SwapStateRedeemed, MethodData: []byte(`{}`), CompletedAt: completed,
+	}); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+	if err := s.store.CreateSwapLeg(&storage.SwapLeg{
+		ID: "trade-1-offer", TradeID: "trade-1", LegType: storage.SwapLegTypeOffer, Chain: "BTC", Amount: 1000,
+		OurRole: storage.SwapLegRoleReceiver, State: storage.SwapLegStateRedeemed, CreatedAt: completed,
+	}); err != nil {
+		t.Fatalf("CreateSwapLeg() error = %v", err)
+	}
+
+	res, err := s.archiveRun(ctx, nil)
+	if err != nil {
+		t.Fatalf("archiveRun() error = %v", err)
+	}
+	if run := res.(*archive.RunResult); run.Archived != 1 {
+		t.Errorf("archived = %d, want 1", run.Archived)
+	}
+
+	res, err = s.archiveSearch(ctx, json.RawMessage(`{"peer_id":"makerA"}`))
+	if err != nil {
+		t.Fatalf("archiveSearch() error = %v", err)
+	}
+	if found := res.(*ArchiveSearchResult); found.Count != 1 || found.Swaps[0].TradeID != "trade-1" {
+		t.Errorf("archiveSearch() = %+v", found)
+	}
+	res, _ = s.archiveSearch(ctx, json.RawMessage(`{"chain":"ETH"}`))
+	if found := res.(*ArchiveSearchResult); found.Count != 0 {
+		t.Errorf("archiveSearch(ETH) = %d swaps, want 0", found.Count)
+	}
+
+	// Looking the trade up re-imports the archived swap
+	res, err = s.tradesGet(ctx, json.RawMessage(`{"id":"trade-1"}`))
+	if err != nil {
+		t.Fatalf("tradesGet() error = %v", err)
+	}
+	if info := res.(TradeInfo); len(info.Legs) != 1 {
+		t.Errorf("tradesGet() legs = %d, want 1 after re-import", len(info.Legs))
+	}
+	if _, err := s.store.GetSwap("trade-1"); err != nil {
+		t.Errorf("swap not re-imported: %v", err)
+	}
+	res, _ = s.archiveSearch(ctx, nil)
+	if found := res.(*ArchiveSearchResult); found.Count != 0 {
+		t.Errorf("archive index after re-import = %d, want 0", found.Count)
+	}
+
+	if _, err := s.archiveRestore(ctx, json.RawMessage(`{"trade_id":"trade-1"}`)); err == nil {
+		t.Error("archiveRestore() of a swap that is not archived should fail")
+	}
+}
@@ -0,0 +1,86 @@
+// Package rpc - API key authentication.
+package rpc
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// apiKeyQueryParam carries the key on WebSocket upgrades, which browsers
+// can't add headers to.
+const apiKeyQueryParam = "api_key"
+
+// SetAPIKeys sets the keys accepted by the API. No keys leaves the API
+// unauthenticated. It must be called before Start.
+func (s *Server) SetAPIKeys(keys []string) {
+	s.apiKeys = nil
+	for _, k := range keys {
+		if k != "" {
+			s.apiKeys = append(s.apiKeys, sha256.Sum256([]byte(k)))
+		}
+	}
+}
+
+// SetAdminKeys sets the keys granting operator access, such as log
+// streaming, on top of the API. It must be called before Start.
+func (s *Server) SetAdminKeys(keys []string) {
+	s.adminKeys = nil
+	for _, k := range keys {
+		if k != "" {
+			s.adminKeys = append(s.adminKeys, sha256.Sum256([]byte(k)))
+		}
+	}
+}
+
+// AuthEnabled returns true if API requests need a key.
+func (s *Server) AuthEnabled() bool {
+	return len(s.apiKeys) > 0 || len(s.adminKeys) > 0
+}
+
+// validAPIKey returns true for a configured API or admin key.
+func (s *Server) validAPIKey(key string) bool {
+	return matchKey(s.apiKeys, key) || matchKey(s.adminKeys, key)
+}
+
+// validAdminKey returns true for a configured admin key.
+func (s *Server) validAdminKey(key string) bool {
+	return matchKey(s.adminKeys, key)
+}
+
+// matchKey compares key with every key of keys in constant time.
+func matchKey(keys [][32]byte, key string) bool {
+	if key == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(key))
+	valid := 0
+	for _, k := range keys {
+		valid |= subtle.ConstantTimeCompare(sum[:], k[:])
+	}
+	return valid == 1
+}
+
+// requestAPIKey returns the key a request presents.
+func requestAPIKey(r *http.Request) string {
+	if auth := r.Header.Get("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(auth, "Bearer "))
+	}
+	if strings.HasPrefix(r.URL.Path, "/ws") {
+		return r.URL.Query().Get(apiKeyQueryParam)
+	}
+	return ""
+}
+
+// requireAPIKey rejects requests without a valid key when keys are set.
+func (s *Server) requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.AuthEnabled() && !s.validAPIKey(requestAPIKey(r)) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="klingdex"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
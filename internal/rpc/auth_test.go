@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAPIKeyAuth(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.handlers["dashboard_snapshot"] = s.dashboardSnapshot
+	s.SetAPIKeys([]string{"", "s3cret"})
+	if !s.AuthEnabled() {
+		t.Fatal("AuthEnabled() = false with a key set")
+	}
+	handler := s.routes()
+
+	call := func(key string) int {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"dashboard_snapshot"}`))
+		if key != "" {
+			req.Header.Set("Authorization", "Bearer "+key)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := call(""); code != http.StatusUnauthorized {
+		t.Errorf("no key: status %d, want 401", code)
+	}
+	if code := call("wrong"); code != http.StatusUnauthorized {
+		t.Errorf("wrong key: status %d, want 401", code)
+	}
+	if code := call("s3cret"); code != http.StatusOK {
+		t.Errorf("valid key: status %d, want 200", code)
+	}
+
+	// Health checks stay open for load balancers
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status %d, want 200", rec.Code)
+	}
+
+	// WebSocket clients may pass the key as a query parameter
+	req := httptest.NewRequest(http.MethodGet, "/ws?api_key=s3cret", nil)
+	if got := requestAPIKey(req); got != "s3cret" {
+		t.Errorf("requestAPIKey(/ws) = %q", got)
+	}
+	req = httptest.NewRequest(http.MethodPost, "/?api_key=s3cret", nil)
+	if got := requestAPIKey(req); got != "" {
+		t.Errorf("requestAPIKey(POST /) = %q, want the query ignored", got)
+	}
+}
+
+func TestAPIKeyAuthDisabled(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.handlers["dashboard_snapshot"] = s.dashboardSnapshot
+	s.SetAPIKeys(nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"dashboard_snapshot"}`))
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("status %d without configured keys, want 200", rec.Code)
+	}
+}
+
+func TestAdminKeyAuth(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.handlers["dashboard_snapshot"] = s.dashboardSnapshot
+	s.SetAdminKeys([]string{"root"})
+	if !s.AuthEnabled() {
+		t.Fatal("AuthEnabled() = false with an admin key set")
+	}
+	s.SetAPIKeys([]string{"s3cret"})
+
+	for key, code := range map[string]int{"root": http.StatusOK, "s3cret": http.StatusOK, "wrong": http.StatusUnauthorized} {
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"dashboard_snapshot"}`))
+		req.Header.Set("Authorization", "Bearer "+key)
+		rec := httptest.NewRecorder()
+		s.routes().ServeHTTP(rec, req)
+		if rec.Code != code {
+			t.Errorf("key %q: status %d, want %d", key, rec.Code, code)
+		}
+	}
+	if !s.validAdminKey("root") || s.validAdminKey("s3cret") {
+		t.Error("only admin keys grant operator access")
+	}
+}
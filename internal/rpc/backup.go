@@ -0,0 +1,86 @@
+// Package rpc - Encrypted backup replication RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/backup"
+)
+
+// SetBackup enables the backup_* methods. dir is where fetched backups are saved.
+func (s *Server) SetBackup(r *backup.Replicator, dir string) {
+	s.backup = r
+	s.backupDir = dir
+}
+
+// BackupRunParams is the parameters for backup_run.
+type BackupRunParams struct {
+	Force bool `json:"force,omitempty"` // Re-send even if the database is unchanged
+}
+
+// backupRun replicates a backup now.
+func (s *Server) backupRun(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.backup == nil {
+		return nil, fmt.Errorf("backup not initialized")
+	}
+	var p BackupRunParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	return s.backup.Run(ctx, p.Force)
+}
+
+// backupStatus returns replication state and the backups held for peers.
+func (s *Server) backupStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.backup == nil {
+		return nil, fmt.Errorf("backup not initialized")
+	}
+	return s.backup.Status(), nil
+}
+
+// BackupFetchParams is the parameters for backup_fetch.
+type BackupFetchParams struct {
+	PeerID string `json:"peer_id"` // Holder of our backup
+}
+
+// BackupFetchResult is the response for backup_fetch.
+type BackupFetchResult struct {
+	Path      string `json:"path"` // Pass to klingond -restore-backup
+	CreatedAt int64  `json:"created_at"`
+	Size      int    `json:"size"`
+}
+
+// backupFetch retrieves our latest backup from a holder peer and saves it
+// locally for klingond -restore-backup.
+func (s *Server) backupFetch(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.backup == nil {
+		return nil, fmt.Errorf("backup not initialized")
+	}
+	var p BackupFetchParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.PeerID == "" {
+		return nil, fmt.Errorf("peer_id is required")
+	}
+	holder, err := peer.Decode(p.PeerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer_id: %w", err)
+	}
+
+	env, data, err := s.backup.FetchFromPeer(ctx, holder)
+	if err != nil {
+		return nil, err
+	}
+	path, err := backup.SaveFetched(s.backupDir, env, data)
+	if err != nil {
+		return nil, err
+	}
+	return &BackupFetchResult{Path: path, CreatedAt: env.Header.CreatedAt, Size: len(data)}, nil
+}
@@ -0,0 +1,36 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func BenchmarkRPCCall(b *testing.B) {
+	s := newTestStoreServer(b)
+	s.registerHandlers()
+	ctx := context.Background()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := s.Call(ctx, "orders_list", nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRPCHTTP(b *testing.B) {
+	s := newTestStoreServer(b)
+	s.registerHandlers()
+	body := []byte(`{"jsonrpc":"2.0","method":"orders_list","params":{"limit":10},"id":1}`)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+		rec := httptest.NewRecorder()
+		s.handleRPC(rec, req)
+		if rec.Code != http.StatusOK {
+			b.Fatalf("status %d", rec.Code)
+		}
+	}
+}
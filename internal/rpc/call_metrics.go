@@ -0,0 +1,78 @@
+// Package rpc - Per-method call metrics and the slow-call log.
+package rpc
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+)
+
+// maxLoggedParams bounds the params logged with a slow call.
+const maxLoggedParams = 1024
+
+// sensitiveParams are params keys whose values are never logged.
+var sensitiveParams = map[string]bool{
+	"password": true, "passphrase": true, "recovery_passphrase": true, "share_passphrase": true,
+	"mnemonic": true, "shares": true, "seed": true, "wif": true, "private_key": true, "privkey": true,
+	"keystore": true, "secret": true, "preimage": true, "secret_key": true, "access_key": true,
+	"api_key": true, "token": true,
+}
+
+// SetSlowCallThreshold logs calls that take longer than d. Zero disables
+// the slow-call log.
+func (s *Server) SetSlowCallThreshold(d time.Duration) {
+	s.slowCall = d
+}
+
+// observeCall records a call in the per-method metrics and logs it if slow.
+func (s *Server) observeCall(method string, params json.RawMessage, elapsed time.Duration, err error) {
+	metrics.RPCCalls.WithLabelValues(method).Inc()
+	metrics.RPCDuration.WithLabelValues(method).Observe(elapsed.Seconds())
+	if err != nil {
+		metrics.RPCErrors.WithLabelValues(method).Inc()
+	}
+
+	if s.slowCall <= 0 || elapsed < s.slowCall {
+		return
+	}
+	metrics.RPCSlowCalls.WithLabelValues(method).Inc()
+	s.log.Warn("Slow RPC call", "method", method, "duration", elapsed.Round(time.Millisecond),
+		"params", redactParams(params), "error", err)
+}
+
+// redactParams returns params for the log, with sensitive values replaced
+// and long params cut short.
+func redactParams(params json.RawMessage) string {
+	if len(params) == 0 {
+		return ""
+	}
+	var v interface{}
+	if err := json.Unmarshal(params, &v); err != nil {
+		return "<invalid json>"
+	}
+	redactParamValue(v)
+	out, _ := json.Marshal(v)
+	if len(out) > maxLoggedParams {
+		return string(out[:maxLoggedParams]) + "..."
+	}
+	return string(out)
+}
+
+func redactParamValue(v interface{}) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if sensitiveParams[strings.ToLower(k)] {
+				x[k] = "[redacted]"
+				continue
+			}
+			redactParamValue(val)
+		}
+	case []interface{}:
+		for _, e := range x {
+			redactParamValue(e)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func TestCallMetricsAndSlowLog(t *testing.T) {
+	var logs bytes.Buffer
+	s := &Server{
+		log:      logging.New(&logging.Config{Level: "info", Output: &logs}),
+		handlers: make(map[string]Handler),
+	}
+	s.handlers["test_fast"] = func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return "ok", nil
+	}
+	s.handlers["test_slow"] = func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		time.Sleep(20 * time.Millisecond)
+		return nil, errors.New("backend down")
+	}
+	s.SetSlowCallThreshold(10 * time.Millisecond)
+
+	ctx := context.Background()
+	s.Call(ctx, "test_fast", nil)
+	s.Call(ctx, "test_fast", nil)
+	s.Call(ctx, "test_slow", json.RawMessage(`{"chain":"BTC","password":"hunter2hunter2","target":{"secret_key":"sk"}}`))
+	s.Call(ctx, "test_unknown", nil)
+
+	if got := testutil.ToFloat64(metrics.RPCCalls.WithLabelValues("test_fast")); got != 2 {
+		t.Errorf("test_fast calls = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.RPCErrors.WithLabelValues("test_slow")); got != 1 {
+		t.Errorf("test_slow errors = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.RPCSlowCalls.WithLabelValues("test_slow")); got != 1 {
+		t.Errorf("test_slow slow calls = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.RPCSlowCalls.WithLabelValues("test_fast")); got != 0 {
+		t.Errorf("test_fast slow calls = %v, want 0", got)
+	}
+	// Unknown methods must not add label values
+	if strings.Contains(rpcCallLabels(t), "test_unknown") {
+		t.Error("unknown method recorded in metrics")
+	}
+
+	out := logs.String()
+	if !strings.Contains(out, "Slow RPC call") || !strings.Contains(out, "test_slow") || !strings.Contains(out, "BTC") {
+		t.Errorf("slow-call log = %q", out)
+	}
+	if strings.Contains(out, "hunter2") || strings.Contains(out, `"sk"`) || strings.Contains(out, "test_fast") {
+		t.Errorf("slow-call log leaked params or logged a fast call: %q", out)
+	}
+}
+
+// rpcCallLabels returns the method labels of rpc_calls_total.
+func rpcCallLabels(t *testing.T) string {
+	t.Helper()
+	families, err := metrics.Registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() error = %v", err)
+	}
+	var b strings.Builder
+	for _, f := range families {
+		if f.GetName() != "klingdex_rpc_calls_total" {
+			continue
+		}
+		for _, m := range f.GetMetric() {
+			for _, l := range m.GetLabel() {
+				b.WriteString(l.GetValue() + " ")
+			}
+		}
+	}
+	return b.String()
+}
+
+func TestRedactParams(t *testing.T) {
+	got := redactParams(json.RawMessage(`{"Mnemonic":"abandon","list":[{"wif":"K1"}],"amount":5}`))
+	if strings.Contains(got, "abandon") || strings.Contains(got, "K1") || !strings.Contains(got, `"amount":5`) {
+		t.Errorf("redactParams() = %s", got)
+	}
+	if got := redactParams(json.RawMessage(`{"data":"` + strings.Repeat("a", 2*maxLoggedParams) + `"}`)); len(got) != maxLoggedParams+3 {
+		t.Errorf("long params logged as %d bytes", len(got))
+	}
+	if redactParams(nil) != "" || redactParams(json.RawMessage(`{`)) != "<invalid json>" {
+		t.Error("empty or invalid params")
+	}
+}
@@ -0,0 +1,89 @@
+// Package rpc - Backend trace capture RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+)
+
+// Capture windows.
+const (
+	defaultCaptureWindow      = 10 * time.Minute
+	defaultTradeCaptureWindow = time.Hour
+	maxCaptureWindow          = 24 * time.Hour
+)
+
+// SetCaptureDir sets where backend_captureStop writes capture bundles.
+func (s *Server) SetCaptureDir(dir string) {
+	s.captureDir = dir
+}
+
+// BackendCaptureStartParams is the parameters for backend_captureStart.
+type BackendCaptureStartParams struct {
+	TradeID  string `json:"trade_id,omitempty"` // Only record calls made for this trade
+	Duration int64  `json:"duration,omitempty"` // Window in seconds (default 600, 3600 with trade_id)
+}
+
+// backendCaptureStart starts recording backend requests and responses.
+func (s *Server) backendCaptureStart(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p BackendCaptureStartParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	window := defaultCaptureWindow
+	if p.TradeID != "" {
+		window = defaultTradeCaptureWindow
+	}
+	if p.Duration < 0 {
+		return nil, fmt.Errorf("duration must not be negative")
+	}
+	if p.Duration > 0 {
+		window = time.Duration(p.Duration) * time.Second
+	}
+	if window > maxCaptureWindow {
+		return nil, fmt.Errorf("duration exceeds %s", maxCaptureWindow)
+	}
+
+	filter := backend.CaptureFilter{TradeID: p.TradeID, Until: time.Now().Add(window)}
+	if err := backend.StartCapture(filter); err != nil {
+		return nil, err
+	}
+	s.log.Info("Backend capture started", "trade_id", p.TradeID, "until", filter.Until.Format(time.RFC3339))
+	return backend.GetCaptureStatus(), nil
+}
+
+// BackendCaptureStopResult is the response for backend_captureStop.
+type BackendCaptureStopResult struct {
+	Path    string `json:"path"` // Bundle for backend.LoadCaptureBundle
+	Entries int    `json:"entries"`
+	Dropped int    `json:"dropped,omitempty"`
+}
+
+// backendCaptureStop ends the capture and writes its bundle.
+func (s *Server) backendCaptureStop(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.captureDir == "" {
+		return nil, fmt.Errorf("capture dir not configured")
+	}
+	bundle, err := backend.StopCapture()
+	if err != nil {
+		return nil, err
+	}
+	path, err := backend.SaveCaptureBundle(s.captureDir, bundle)
+	if err != nil {
+		return nil, err
+	}
+	s.log.Info("Backend capture saved", "path", path, "entries", len(bundle.Entries))
+	return &BackendCaptureStopResult{Path: path, Entries: len(bundle.Entries), Dropped: bundle.Dropped}, nil
+}
+
+// backendCaptureStatus returns the state of the running capture.
+func (s *Server) backendCaptureStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return backend.GetCaptureStatus(), nil
+}
@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+)
+
+func TestBackendCapture(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.backendCaptureStop(ctx, nil); err == nil {
+		t.Error("backend_captureStop without a capture dir should fail")
+	}
+	s.SetCaptureDir(t.TempDir())
+
+	for _, params := range []string{`{"duration": -1}`, `{"duration": 86401}`} {
+		if _, err := s.backendCaptureStart(ctx, json.RawMessage(params)); err == nil {
+			t.Errorf("backend_captureStart(%s) should fail", params)
+		}
+	}
+
+	res, err := s.backendCaptureStart(ctx, json.RawMessage(`{"trade_id": "t1"}`))
+	if err != nil {
+		t.Fatalf("backend_captureStart error = %v", err)
+	}
+	if st := res.(*backend.CaptureStatus); !st.Active || st.Filter.TradeID != "t1" {
+		t.Errorf("status = %+v", st)
+	}
+	if _, err := s.backendCaptureStart(ctx, nil); err == nil {
+		t.Error("second backend_captureStart should fail")
+	}
+
+	res, err = s.backendCaptureStop(ctx, nil)
+	if err != nil {
+		t.Fatalf("backend_captureStop error = %v", err)
+	}
+	bundle, err := backend.LoadCaptureBundle(res.(*BackendCaptureStopResult).Path)
+	if err != nil || bundle.Filter.TradeID != "t1" {
+		t.Errorf("LoadCaptureBundle() = %+v, %v", bundle, err)
+	}
+
+	res, _ = s.backendCaptureStatus(ctx, nil)
+	if res.(*backend.CaptureStatus).Active {
+		t.Error("capture still active after stop")
+	}
+}
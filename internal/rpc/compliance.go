@@ -0,0 +1,83 @@
+// Package rpc - Compliance audit record RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/compliance"
+)
+
+// maxComplianceRecords bounds one compliance_records page.
+const maxComplianceRecords = 1000
+
+// SetCompliance enables the compliance_* methods.
+func (s *Server) SetCompliance(c *compliance.Streamer) {
+	s.compliance = c
+}
+
+// ComplianceRecordsParams is the parameters for compliance_records.
+type ComplianceRecordsParams struct {
+	TradeID  string `json:"trade_id,omitempty"`  // Empty returns every trade
+	AfterSeq int64  `json:"after_seq,omitempty"` // Page through the chain
+	Limit    int    `json:"limit,omitempty"`     // Default 100
+}
+
+// ComplianceRecordsResult is the response for compliance_records.
+type ComplianceRecordsResult struct {
+	Records []*compliance.Record `json:"records"`
+	Count   int                  `json:"count"`
+}
+
+// ComplianceVerifyResult is the response for compliance_verify.
+type ComplianceVerifyResult struct {
+	Valid    bool   `json:"valid"`
+	Verified int    `json:"verified"`
+	Error    string `json:"error,omitempty"`
+}
+
+// complianceStatus returns streaming state and queue totals.
+func (s *Server) complianceStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.compliance == nil {
+		return nil, fmt.Errorf("compliance not initialized")
+	}
+	return s.compliance.Status()
+}
+
+// complianceRecords returns stored audit records in chain order.
+func (s *Server) complianceRecords(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.compliance == nil {
+		return nil, fmt.Errorf("compliance not initialized")
+	}
+	var p ComplianceRecordsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if p.Limit <= 0 {
+		p.Limit = 100
+	}
+	if p.Limit > maxComplianceRecords {
+		return nil, fmt.Errorf("limit must be at most %d", maxComplianceRecords)
+	}
+
+	records, err := s.compliance.Records(p.TradeID, p.AfterSeq, p.Limit)
+	if err != nil {
+		return nil, err
+	}
+	return &ComplianceRecordsResult{Records: records, Count: len(records)}, nil
+}
+
+// complianceVerify checks the hash chain and signatures of every stored record.
+func (s *Server) complianceVerify(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.compliance == nil {
+		return nil, fmt.Errorf("compliance not initialized")
+	}
+	n, err := s.compliance.VerifyChain()
+	if err != nil {
+		return &ComplianceVerifyResult{Valid: false, Error: err.Error()}, nil
+	}
+	return &ComplianceVerifyResult{Valid: true, Verified: n}, nil
+}
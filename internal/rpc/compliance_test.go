@@ -0,0 +1,64 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+
+	"github.com/Klingon-tech/klingdex/internal/compliance"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestComplianceHandlers(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	if _, err := s.complianceStatus(context.Background(), nil); err == nil {
+		t.Error("complianceStatus() should fail when compliance is not initialized")
+	}
+
+	key, _, _ := crypto.GenerateEd25519Key(nil)
+	streamer, err := compliance.New(node.ComplianceConfig{Enabled: true, Endpoint: "http://127.0.0.1:1"}, s.store, nil, key)
+	if err != nil {
+		t.Fatalf("compliance.New() error = %v", err)
+	}
+	s.SetCompliance(streamer)
+
+	for _, ev := range []swap.SwapEvent{
+		{TradeID: "t1", EventType: "swap_initiated", Timestamp: time.Now()},
+		{TradeID: "t2", EventType: "swap_joined", Timestamp: time.Now()},
+		{TradeID: "t1", EventType: "swap_completed", Timestamp: time.Now()},
+	} {
+		streamer.HandleSwapEvent(ev)
+	}
+
+	res, err := s.complianceStatus(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("complianceStatus() error = %v", err)
+	}
+	if st := res.(*compliance.Status); st.Total != 3 || st.Pending != 3 || st.LastSeq != 3 {
+		t.Errorf("complianceStatus() = %+v", st)
+	}
+
+	res, err = s.complianceRecords(context.Background(), json.RawMessage(`{"trade_id":"t1"}`))
+	if err != nil {
+		t.Fatalf("complianceRecords() error = %v", err)
+	}
+	if r := res.(*ComplianceRecordsResult); r.Count != 2 || r.Records[1].Seq != 3 {
+		t.Errorf("complianceRecords(t1) = %+v", r)
+	}
+	if _, err := s.complianceRecords(context.Background(), json.RawMessage(`{"limit":5000}`)); err == nil {
+		t.Error("complianceRecords() should reject an oversized limit")
+	}
+
+	res, err = s.complianceVerify(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("complianceVerify() error = %v", err)
+	}
+	if v := res.(*ComplianceVerifyResult); !v.Valid || v.Verified != 3 {
+		t.Errorf("complianceVerify() = %+v", v)
+	}
+}
@@ -0,0 +1,170 @@
+// Package rpc - Read-only web dashboard.
+package rpc
+
+import (
+	"context"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// dashboardAssets are the dashboard's static files, compiled into the binary.
+//
+//go:embed dashboard
+var dashboardAssets embed.FS
+
+// dashboardMaxOrders bounds the orders in a snapshot.
+const dashboardMaxOrders = 200
+
+// DashboardSnapshot is the result of dashboard_snapshot.
+type DashboardSnapshot struct {
+	GeneratedAt int64              `json:"generated_at"`
+	PeerID      string             `json:"peer_id,omitempty"`
+	Status      *NodeStatusResult  `json:"status,omitempty"`
+	Config      DashboardConfig    `json:"config"`
+	Peers       []PeerInfo         `json:"peers"`
+	Orders      []OrderInfo        `json:"orders"`
+	Swaps       []DashboardSwap    `json:"swaps"`
+	Balances    []DashboardBalance `json:"balances"`
+}
+
+// DashboardConfig is the part of the configuration the dashboard shows.
+type DashboardConfig struct {
+	TLS      bool     `json:"tls"`
+	Auth     bool     `json:"auth"`
+	Features []string `json:"features"` // Optional services that are running
+}
+
+// DashboardSwap is an active swap with its refund and claim deadlines.
+type DashboardSwap struct {
+	SwapListItem
+	Deadlines []swap.Deadline `json:"deadlines,omitempty"`
+}
+
+// DashboardBalance is the wallet balance on one UTXO chain, as of the last
+// wallet sync.
+type DashboardBalance struct {
+	Chain       string `json:"chain"`
+	Confirmed   uint64 `json:"confirmed"`
+	Unconfirmed uint64 `json:"unconfirmed"`
+	Pending     uint64 `json:"pending"` // Reserved by unconfirmed spends
+	Final       uint64 `json:"final"`   // Part of Confirmed at least the finality depth deep
+}
+
+// SetDashboard enables the web dashboard at /dashboard. It must be called
+// before Start.
+func (s *Server) SetDashboard(enabled bool) {
+	s.dashboard = enabled
+}
+
+// dashboardHandler serves the dashboard's static files under /dashboard/.
+func dashboardHandler() http.Handler {
+	sub, err := fs.Sub(dashboardAssets, "dashboard")
+	if err != nil {
+		panic(err) // The directory is embedded at build time
+	}
+	return http.StripPrefix("/dashboard/", http.FileServer(http.FS(sub)))
+}
+
+// dashboardSnapshot returns everything the dashboard shows in one call.
+func (s *Server) dashboardSnapshot(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	snap := &DashboardSnapshot{
+		GeneratedAt: time.Now().Unix(),
+		Config:      s.dashboardConfig(),
+		Peers:       []PeerInfo{},
+		Orders:      []OrderInfo{},
+		Swaps:       []DashboardSwap{},
+		Balances:    []DashboardBalance{},
+	}
+
+	if s.node != nil {
+		snap.PeerID = s.node.ID().String()
+		if res, err := s.nodeStatus(ctx, nil); err == nil {
+			snap.Status = res.(*NodeStatusResult)
+		}
+		if res, err := s.peersList(ctx, nil); err == nil {
+			snap.Peers = res.(*PeersListResult).Peers
+		}
+	}
+
+	if s.store != nil {
+		filter, _ := json.Marshal(OrdersListParams{Status: string(storage.OrderStatusOpen), Limit: dashboardMaxOrders})
+		orders, err := s.ordersList(ctx, filter)
+		if err != nil {
+			return nil, err
+		}
+		snap.Orders = orders.(*OrdersListResult).Orders
+
+		for _, symbol := range chain.List() {
+			confirmed, unconfirmed, pending, err := s.store.GetBalanceByStatus(symbol)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s balance: %w", symbol, err)
+			}
+			if confirmed+unconfirmed+pending == 0 {
+				continue
+			}
+			final, err := s.finalBalance(symbol)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get %s balance: %w", symbol, err)
+			}
+			snap.Balances = append(snap.Balances, DashboardBalance{
+				Chain:       symbol,
+				Confirmed:   confirmed,
+				Unconfirmed: unconfirmed,
+				Pending:     pending,
+				Final:       final,
+			})
+		}
+	}
+
+	if s.coordinator != nil {
+		res, err := s.swapList(ctx, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range res.(*SwapListResult).Swaps {
+			ds := DashboardSwap{SwapListItem: item}
+			if deadlines, err := s.coordinator.GetSwapDeadlines(ctx, item.TradeID); err == nil {
+				ds.Deadlines = deadlines.Deadlines
+			}
+			snap.Swaps = append(snap.Swaps, ds)
+		}
+	}
+
+	return snap, nil
+}
+
+func (s *Server) dashboardConfig() DashboardConfig {
+	cfg := DashboardConfig{
+		TLS:      s.TLSEnabled(),
+		Auth:     s.AuthEnabled(),
+		Features: []string{},
+	}
+	optional := []struct {
+		name    string
+		running bool
+	}{
+		{"watchtower", s.watchtower != nil},
+		{"export", s.exporter != nil},
+		{"backup", s.backup != nil},
+		{"history_sync", s.history != nil},
+		{"compliance", s.compliance != nil},
+		{"telemetry", s.telemetry != nil},
+		{"nostr", s.nostr != nil},
+		{"archive", s.archive != nil},
+		{"price_check", s.prices != nil},
+	}
+	for _, o := range optional {
+		if o.running {
+			cfg.Features = append(cfg.Features, o.name)
+		}
+	}
+	return cfg
+}
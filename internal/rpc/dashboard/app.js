@@ -0,0 +1,197 @@
+// klingdex dashboard: polls dashboard_snapshot and renders it read-only.
+// The API key is kept for the browser session only.
+(function () {
+  "use strict";
+
+  var KEY_STORAGE = "klingdex.api_key";
+  var POLL_MS = 5000;
+
+  var snapshot = null;
+  var fetchedAt = 0;
+  var timer = null;
+
+  function $(id) { return document.getElementById(id); }
+
+  function short(s, n) {
+    if (!s) return "";
+    return s.length > n ? s.slice(0, n) + "…" : s;
+  }
+
+  function cell(text, cls) {
+    var td = document.createElement("td");
+    td.textContent = text;
+    if (cls) td.className = cls;
+    return td;
+  }
+
+  function fill(tableId, rows, emptyText) {
+    var body = $(tableId).querySelector("tbody");
+    body.textContent = "";
+    if (rows.length === 0) {
+      var tr = document.createElement("tr");
+      var td = cell(emptyText, "muted");
+      td.colSpan = $(tableId).querySelectorAll("th").length;
+      tr.appendChild(td);
+      body.appendChild(tr);
+      return;
+    }
+    rows.forEach(function (cells) {
+      var tr = document.createElement("tr");
+      cells.forEach(function (c) { tr.appendChild(c); });
+      body.appendChild(tr);
+    });
+  }
+
+  function duration(secs) {
+    if (secs <= 0) return "passed";
+    var h = Math.floor(secs / 3600);
+    var m = Math.floor((secs % 3600) / 60);
+    var s = secs % 60;
+    var out = (h > 0 ? h + "h " : "") + (h > 0 || m > 0 ? m + "m " : "") + s + "s";
+    return out;
+  }
+
+  // nextDeadline returns the earliest deadline that hasn't passed.
+  function nextDeadline(deadlines) {
+    var next = null;
+    (deadlines || []).forEach(function (d) {
+      if (d.passed) return;
+      if (next === null || d.at < next.at) next = d;
+    });
+    return next;
+  }
+
+  function renderCountdowns() {
+    if (!snapshot) return;
+    var now = Math.floor(Date.now() / 1000);
+    document.querySelectorAll("[data-deadline]").forEach(function (el) {
+      var left = parseInt(el.getAttribute("data-deadline"), 10) - now;
+      el.textContent = el.getAttribute("data-label") + " in " + duration(left);
+      el.className = left < 1800 ? "urgent" : "";
+    });
+    $("updated").textContent = "updated " + Math.round((Date.now() - fetchedAt) / 1000) + "s ago";
+  }
+
+  function render() {
+    var st = snapshot.status;
+    $("peer-id").textContent = snapshot.peer_id || "";
+
+    var dl = $("status");
+    dl.textContent = "";
+    var items = [
+      ["Peers", st ? st.peer_count + " connected, " + st.known_peers + " known" : "n/a"],
+      ["Uptime", st ? st.uptime : "n/a"],
+      ["WebSocket clients", st ? st.ws_clients : "n/a"],
+      ["TLS", snapshot.config.tls ? "on" : "off"],
+      ["API keys", snapshot.config.auth ? "required" : "not required"],
+      ["Services", snapshot.config.features.join(", ") || "none"]
+    ];
+    if (st && st.paused_contracts && st.paused_contracts.length) {
+      items.push(["Paused contracts", st.paused_contracts.join(", ")]);
+    }
+    items.forEach(function (it) {
+      var dt = document.createElement("dt");
+      dt.textContent = it[0];
+      var dd = document.createElement("dd");
+      dd.textContent = it[1];
+      dl.appendChild(dt);
+      dl.appendChild(dd);
+    });
+
+    fill("swaps", snapshot.swaps.map(function (s) {
+      var d = nextDeadline(s.deadlines);
+      var td = cell("");
+      if (d) {
+        var span = document.createElement("span");
+        span.setAttribute("data-deadline", d.at);
+        span.setAttribute("data-label", d.kind + " (" + d.chain + ")");
+        td.appendChild(span);
+      } else {
+        td.textContent = "–";
+      }
+      return [
+        cell(short(s.trade_id, 12), "mono"),
+        cell(s.state),
+        cell(s.role),
+        cell(s.offer_amount + " " + s.offer_chain),
+        cell(s.request_amount + " " + s.request_chain),
+        td
+      ];
+    }), "No active swaps");
+
+    fill("balances", snapshot.balances.map(function (b) {
+      return [cell(b.chain), cell(b.confirmed, "num"), cell(b.final, "num"), cell(b.unconfirmed, "num"), cell(b.pending, "num")];
+    }), "No synced wallet balances");
+
+    fill("orders", snapshot.orders.map(function (o) {
+      return [
+        cell(short(o.id, 12), "mono"),
+        cell(o.offer_amount + " " + o.offer_chain),
+        cell(o.request_amount + " " + o.request_chain),
+        cell(o.is_local ? "us" : short(o.peer_id, 16), "mono")
+      ];
+    }), "No open orders");
+
+    fill("peers", snapshot.peers.map(function (p) {
+      var rtt = p.quality && p.quality.rtt_us ? (p.quality.rtt_us / 1000).toFixed(1) + " ms" : "–";
+      return [cell(short(p.peer_id, 20), "mono"), cell(rtt, "num"), cell((p.addrs || [])[0] || "", "mono")];
+    }), "No connected peers");
+
+    renderCountdowns();
+  }
+
+  function showAuth(message) {
+    $("content").hidden = true;
+    $("auth").hidden = false;
+    $("auth-error").textContent = message || "";
+    $("api-key").focus();
+  }
+
+  function poll() {
+    var headers = { "Content-Type": "application/json" };
+    var key = sessionStorage.getItem(KEY_STORAGE);
+    if (key) headers["Authorization"] = "Bearer " + key;
+
+    fetch("/", {
+      method: "POST",
+      headers: headers,
+      body: JSON.stringify({ jsonrpc: "2.0", id: 1, method: "dashboard_snapshot" })
+    }).then(function (resp) {
+      if (resp.status === 401) {
+        sessionStorage.removeItem(KEY_STORAGE);
+        throw { auth: true, message: key ? "Invalid API key" : "" };
+      }
+      return resp.json();
+    }).then(function (body) {
+      if (body.error) throw { message: body.error.message };
+      snapshot = body.result;
+      fetchedAt = Date.now();
+      $("auth").hidden = true;
+      $("content").hidden = false;
+      render();
+      schedule();
+    }).catch(function (err) {
+      if (err && err.auth) {
+        showAuth(err.message);
+        return;
+      }
+      $("updated").textContent = "error: " + ((err && err.message) || err);
+      schedule();
+    });
+  }
+
+  function schedule() {
+    clearTimeout(timer);
+    timer = setTimeout(poll, POLL_MS);
+  }
+
+  $("auth").addEventListener("submit", function (e) {
+    e.preventDefault();
+    sessionStorage.setItem(KEY_STORAGE, $("api-key").value);
+    $("api-key").value = "";
+    poll();
+  });
+
+  setInterval(renderCountdowns, 1000);
+  poll();
+})();
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestDashboardAssets(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard/", nil))
+	if rec.Code == http.StatusOK {
+		t.Error("disabled dashboard was served")
+	}
+
+	s.SetDashboard(true)
+	s.SetAPIKeys([]string{"s3cret"})
+	handler := s.routes()
+
+	for path, want := range map[string]string{
+		"/dashboard/":          "app.js",
+		"/dashboard/app.js":    "dashboard_snapshot",
+		"/dashboard/style.css": "font-family",
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code != http.StatusOK {
+			t.Errorf("GET %s status %d, want 200 without a key", path, rec.Code)
+			continue
+		}
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("GET %s missing %q", path, want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/dashboard/" {
+		t.Errorf("GET /dashboard = %d to %q", rec.Code, rec.Header().Get("Location"))
+	}
+}
+
+func TestDashboardSnapshot(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetAPIKeys([]string{"s3cret"})
+
+	order := &storage.Order{ID: "o1", PeerID: "maker", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now()}
+	if err := s.store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	filled := &storage.Order{ID: "o2", PeerID: "maker", Status: storage.OrderStatusCompleted, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now()}
+	if err := s.store.CreateOrder(filled); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	res, err := s.dashboardSnapshot(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("dashboardSnapshot() error = %v", err)
+	}
+	snap := res.(*DashboardSnapshot)
+	if len(snap.Orders) != 1 || snap.Orders[0].ID != "o1" {
+		t.Errorf("Orders = %+v, want only the open order", snap.Orders)
+	}
+	if snap.Status != nil || len(snap.Peers) != 0 || len(snap.Swaps) != 0 || len(snap.Balances) != 0 {
+		t.Errorf("snapshot without node, coordinator or wallet = %+v", snap)
+	}
+	if !snap.Config.Auth || snap.Config.TLS {
+		t.Errorf("Config = %+v", snap.Config)
+	}
+}
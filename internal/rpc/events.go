@@ -0,0 +1,172 @@
+// Package rpc - WebSocket event audit log.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Retention is enforced every pruneEventsEvery recorded events.
+const pruneEventsEvery = 100
+
+// events_query limits.
+const (
+	defaultEventsQueryLimit = 100
+	maxEventsQueryLimit     = 1000
+)
+
+// eventLog persists hub events for events_query.
+type eventLog struct {
+	store      *storage.Storage
+	cfg        node.EventLogConfig
+	log        *logging.Logger
+	sincePrune int
+}
+
+// SetEventLog records every event broadcast to WebSocket clients. Must be
+// called before Start.
+func (s *Server) SetEventLog(cfg node.EventLogConfig) {
+	if !cfg.Enabled || s.store == nil {
+		s.eventLog = nil
+		return
+	}
+	s.eventLog = &eventLog{store: s.store, cfg: cfg, log: s.log}
+	s.eventLog.prune()
+}
+
+// record stores an event. It runs on the hub goroutine.
+func (l *eventLog) record(event *WSEvent) {
+	data, err := json.Marshal(event.Data)
+	if err != nil {
+		l.log.Warn("Failed to encode event for audit log", "type", event.Type, "error", err)
+		return
+	}
+	rec := &storage.EventRecord{
+		Type:      string(event.Type),
+		TradeID:   eventTradeID(data),
+		Data:      data,
+		CreatedAt: time.Unix(event.Timestamp, 0),
+	}
+	if err := l.store.SaveEvent(rec); err != nil {
+		l.log.Warn("Failed to record event", "type", event.Type, "error", err)
+		return
+	}
+
+	l.sincePrune++
+	if l.sincePrune >= pruneEventsEvery {
+		l.prune()
+	}
+}
+
+// prune applies the retention limits.
+func (l *eventLog) prune() {
+	l.sincePrune = 0
+	var cutoff time.Time
+	if l.cfg.MaxAge > 0 {
+		cutoff = time.Now().Add(-l.cfg.MaxAge)
+	}
+	if cutoff.IsZero() && l.cfg.MaxEvents <= 0 {
+		return
+	}
+	if deleted, err := l.store.PruneEvents(cutoff, l.cfg.MaxEvents); err != nil {
+		l.log.Warn("Failed to prune event log", "error", err)
+	} else if deleted > 0 {
+		l.log.Debug("Pruned event log", "deleted", deleted)
+	}
+}
+
+// eventTradeID returns the trade_id field of an event payload, if any.
+func eventTradeID(data []byte) string {
+	var v struct {
+		TradeID string `json:"trade_id"`
+	}
+	_ = json.Unmarshal(data, &v)
+	return v.TradeID
+}
+
+// EventsQueryParams is the parameters for events_query.
+type EventsQueryParams struct {
+	Types    []string `json:"types,omitempty"`     // Event types (topics)
+	TradeID  string   `json:"trade_id,omitempty"`  // Events about one trade
+	From     int64    `json:"from,omitempty"`      // Unix seconds, inclusive
+	To       int64    `json:"to,omitempty"`        // Unix seconds, inclusive
+	BeforeID int64    `json:"before_id,omitempty"` // Page: events older than this ID
+	Limit    int      `json:"limit,omitempty"`     // Default 100, max 1000
+}
+
+// EventLogEntry is a recorded event.
+type EventLogEntry struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	TradeID   string          `json:"trade_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// EventsQueryResult is the response for events_query.
+type EventsQueryResult struct {
+	Events       []EventLogEntry `json:"events"`
+	Count        int             `json:"count"`
+	NextBeforeID int64           `json:"next_before_id,omitempty"` // Set when more events may follow
+}
+
+// eventsQuery returns recorded events, newest first.
+func (s *Server) eventsQuery(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p EventsQueryParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	if p.From > 0 && p.To > 0 && p.From > p.To {
+		return nil, fmt.Errorf("from must not be after to")
+	}
+	if p.Limit <= 0 {
+		p.Limit = defaultEventsQueryLimit
+	}
+	if p.Limit > maxEventsQueryLimit {
+		p.Limit = maxEventsQueryLimit
+	}
+
+	filter := storage.EventFilter{
+		Types:    p.Types,
+		TradeID:  p.TradeID,
+		BeforeID: p.BeforeID,
+		Limit:    p.Limit,
+	}
+	if p.From > 0 {
+		filter.Since = time.Unix(p.From, 0)
+	}
+	if p.To > 0 {
+		filter.Until = time.Unix(p.To, 0)
+	}
+
+	records, err := s.store.QueryEvents(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &EventsQueryResult{Events: make([]EventLogEntry, 0, len(records)), Count: len(records)}
+	for _, r := range records {
+		result.Events = append(result.Events, EventLogEntry{
+			ID:        r.ID,
+			Type:      r.Type,
+			TradeID:   r.TradeID,
+			Data:      r.Data,
+			Timestamp: r.CreatedAt.Unix(),
+		})
+	}
+	if len(records) == p.Limit {
+		result.NextBeforeID = records[len(records)-1].ID
+	}
+	return result, nil
+}
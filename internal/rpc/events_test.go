@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func TestEventsQuery(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetEventLog(node.EventLogConfig{Enabled: true, MaxAge: time.Hour, MaxEvents: 100})
+	if s.eventLog == nil {
+		t.Fatal("SetEventLog() did not enable the event log")
+	}
+
+	now := time.Now().Unix()
+	s.eventLog.record(&WSEvent{Type: EventType("swap_started"), Data: map[string]string{"trade_id": "trade-1"}, Timestamp: now - 20})
+	s.eventLog.record(&WSEvent{Type: EventType("swap_redeemed"), Data: map[string]string{"trade_id": "trade-1"}, Timestamp: now - 10})
+	s.eventLog.record(&WSEvent{Type: EventType("swap_started"), Data: map[string]string{"trade_id": "trade-2"}, Timestamp: now})
+
+	query := func(p EventsQueryParams) *EventsQueryResult {
+		t.Helper()
+		params, _ := json.Marshal(p)
+		res, err := s.eventsQuery(context.Background(), params)
+		if err != nil {
+			t.Fatalf("eventsQuery() error = %v", err)
+		}
+		return res.(*EventsQueryResult)
+	}
+
+	all := query(EventsQueryParams{})
+	if all.Count != 3 {
+		t.Fatalf("Count = %d, want 3", all.Count)
+	}
+	if all.Events[0].TradeID != "trade-2" || all.Events[0].Timestamp != now {
+		t.Errorf("newest event = %+v, want trade-2 at %d", all.Events[0], now)
+	}
+
+	if got := query(EventsQueryParams{TradeID: "trade-1"}).Count; got != 2 {
+		t.Errorf("trade filter Count = %d, want 2", got)
+	}
+	if got := query(EventsQueryParams{Types: []string{"swap_started"}}).Count; got != 2 {
+		t.Errorf("type filter Count = %d, want 2", got)
+	}
+	if got := query(EventsQueryParams{From: now - 15, To: now - 5}).Count; got != 1 {
+		t.Errorf("time filter Count = %d, want 1", got)
+	}
+
+	page := query(EventsQueryParams{Limit: 2})
+	if page.Count != 2 || page.NextBeforeID == 0 {
+		t.Fatalf("page = %+v, want 2 events and a cursor", page)
+	}
+	if got := query(EventsQueryParams{Limit: 2, BeforeID: page.NextBeforeID}).Count; got != 1 {
+		t.Errorf("second page Count = %d, want 1", got)
+	}
+
+	if _, err := s.eventsQuery(context.Background(), json.RawMessage(`{"from":10,"to":5}`)); err == nil {
+		t.Error("eventsQuery() with from after to should fail")
+	}
+}
+
+func TestSetEventLogDisabled(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetEventLog(node.EventLogConfig{Enabled: false})
+	if s.eventLog != nil {
+		t.Error("SetEventLog() with Enabled=false should not record events")
+	}
+}
@@ -0,0 +1,55 @@
+// Package rpc - Analytics export RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/export"
+)
+
+// SetExporter enables the export_* methods.
+func (s *Server) SetExporter(e *export.Exporter) {
+	s.exporter = e
+}
+
+// ExportRunParams is the parameters for export_run.
+type ExportRunParams struct {
+	Datasets []string `json:"datasets,omitempty"` // orders, trades, swaps, fees (default: all)
+	Formats  []string `json:"formats,omitempty"`  // csv, parquet (default: configured formats)
+}
+
+// exportRun writes an export of the requested datasets now.
+func (s *Server) exportRun(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.exporter == nil {
+		return nil, fmt.Errorf("exporter not initialized")
+	}
+
+	var p ExportRunParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	return s.exporter.Run(ctx, p.Datasets, p.Formats)
+}
+
+// ExportSchema describes an exportable dataset.
+type ExportSchema struct {
+	Name    string          `json:"name"`
+	Version int             `json:"version"`
+	Columns []export.Column `json:"columns"`
+}
+
+// exportSchemas lists the exportable datasets and their schema versions.
+func (s *Server) exportSchemas(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var schemas []*ExportSchema
+	for _, ds := range export.Datasets() {
+		schemas = append(schemas, &ExportSchema{Name: ds.Name, Version: ds.Version, Columns: ds.Columns})
+	}
+	return map[string]interface{}{
+		"datasets": schemas,
+	}, nil
+}
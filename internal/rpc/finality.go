@@ -0,0 +1,116 @@
+// Package rpc - Finality labels of transactions and balances.
+//
+// Every transaction and balance item in wallet and swap responses carries a
+// finality label derived from its confirmations and the chain's finality
+// depth, so clients don't need their own table of how deep each chain must
+// be buried: pending (not mined yet), confirmed (mined, still within reorg
+// reach) or final (at least the finality depth deep). Depths default to the
+// network's chain parameters and can be overridden per chain with
+// finality.depths.
+package rpc
+
+import (
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Finality labels.
+const (
+	FinalityPending   = "pending"   // Not mined yet
+	FinalityConfirmed = "confirmed" // Mined, fewer confirmations than the finality depth
+	FinalityFinal     = "final"     // At least the finality depth deep
+	FinalityUnknown   = "unknown"   // Confirmations couldn't be read
+)
+
+// FinalityBalance is the part of a balance with one finality label.
+type FinalityBalance struct {
+	Finality string `json:"finality"`
+	Amount   uint64 `json:"amount"`
+	Outputs  int    `json:"outputs"`
+}
+
+// finalityOutput is an output counted in a balance.
+type finalityOutput struct {
+	confirmations int64
+	amount        uint64
+}
+
+// SetFinality sets the per-chain finality depths overriding the defaults.
+func (s *Server) SetFinality(cfg node.FinalityConfig) {
+	s.finality = cfg
+}
+
+// finalityDepth returns the confirmations after which a chain's
+// transactions are final.
+func (s *Server) finalityDepth(symbol string) uint32 {
+	symbol = strings.ToUpper(symbol)
+	if depth, ok := s.finality.Depths[symbol]; ok && depth > 0 {
+		return depth
+	}
+
+	params := config.MainnetChainParams
+	if s.wallet != nil && s.wallet.Network() == chain.Testnet {
+		params = config.SelectedTestnetChainParams()
+	}
+	if p, ok := params[symbol]; ok && p.Confirmations > 0 {
+		return p.Confirmations
+	}
+	return 1
+}
+
+// finalityOf labels a transaction of a chain by its confirmations.
+func (s *Server) finalityOf(symbol string, confirmations int64) string {
+	return finalityLabel(confirmations, s.finalityDepth(symbol))
+}
+
+// finalityLabel labels confirmations against a finality depth.
+func finalityLabel(confirmations int64, depth uint32) string {
+	switch {
+	case confirmations <= 0:
+		return FinalityPending
+	case confirmations < int64(depth):
+		return FinalityConfirmed
+	default:
+		return FinalityFinal
+	}
+}
+
+// finalBalance sums a chain's confirmed wallet UTXOs that are final, as of
+// the last wallet sync.
+func (s *Server) finalBalance(symbol string) (uint64, error) {
+	utxos, err := s.store.GetAllUTXOs(symbol)
+	if err != nil {
+		return 0, err
+	}
+	depth := s.finalityDepth(symbol)
+	var final uint64
+	for _, u := range utxos {
+		if u.Status == storage.UTXOStatusConfirmed && finalityLabel(u.Confirmations, depth) == FinalityFinal {
+			final += u.Amount
+		}
+	}
+	return final, nil
+}
+
+// balanceByFinality splits outputs by finality label, always listing
+// pending, confirmed and final in that order.
+func balanceByFinality(depth uint32, outputs []finalityOutput) []FinalityBalance {
+	split := []FinalityBalance{
+		{Finality: FinalityPending},
+		{Finality: FinalityConfirmed},
+		{Finality: FinalityFinal},
+	}
+	for _, out := range outputs {
+		for i := range split {
+			if split[i].Finality == finalityLabel(out.confirmations, depth) {
+				split[i].Amount += out.amount
+				split[i].Outputs++
+			}
+		}
+	}
+	return split
+}
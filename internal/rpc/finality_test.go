@@ -0,0 +1,161 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestFinalityLabel(t *testing.T) {
+	tests := []struct {
+		confirmations int64
+		depth         uint32
+		want          string
+	}{
+		{0, 3, FinalityPending},
+		{-1, 3, FinalityPending},
+		{1, 3, FinalityConfirmed},
+		{2, 3, FinalityConfirmed},
+		{3, 3, FinalityFinal},
+		{100, 3, FinalityFinal},
+		{1, 1, FinalityFinal},
+	}
+	for _, tt := range tests {
+		if got := finalityLabel(tt.confirmations, tt.depth); got != tt.want {
+			t.Errorf("finalityLabel(%d, %d) = %s, want %s", tt.confirmations, tt.depth, got, tt.want)
+		}
+	}
+}
+
+func TestFinalityDepth(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	// Network defaults
+	if got := s.finalityDepth("LTC"); got != 6 {
+		t.Errorf("LTC depth = %d, want 6", got)
+	}
+	if got := s.finalityDepth("eth"); got != 12 {
+		t.Errorf("eth depth = %d, want 12", got)
+	}
+	if got := s.finalityDepth("FOO"); got != 1 {
+		t.Errorf("unknown chain depth = %d, want 1", got)
+	}
+
+	s.SetFinality(node.FinalityConfig{Depths: map[string]uint32{"LTC": 3}})
+	if got := s.finalityDepth("LTC"); got != 3 {
+		t.Errorf("configured LTC depth = %d, want 3", got)
+	}
+	if got := s.finalityOf("LTC", 3); got != FinalityFinal {
+		t.Errorf("LTC at 3 confirmations = %s, want final", got)
+	}
+
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if got := s.finalityDepth("BTC"); got != 1 {
+		t.Errorf("testnet BTC depth = %d, want 1", got)
+	}
+}
+
+func TestBalanceByFinality(t *testing.T) {
+	split := balanceByFinality(3, []finalityOutput{
+		{confirmations: 0, amount: 100},
+		{confirmations: 1, amount: 200},
+		{confirmations: 2, amount: 300},
+		{confirmations: 5, amount: 400},
+	})
+	want := []FinalityBalance{
+		{Finality: FinalityPending, Amount: 100, Outputs: 1},
+		{Finality: FinalityConfirmed, Amount: 500, Outputs: 2},
+		{Finality: FinalityFinal, Amount: 400, Outputs: 1},
+	}
+	if len(split) != len(want) {
+		t.Fatalf("balanceByFinality() = %+v", split)
+	}
+	for i := range want {
+		if split[i] != want[i] {
+			t.Errorf("balanceByFinality()[%d] = %+v, want %+v", i, split[i], want[i])
+		}
+	}
+
+	if empty := balanceByFinality(3, nil); len(empty) != 3 || empty[2].Amount != 0 {
+		t.Errorf("balanceByFinality(nil) = %+v, want three empty labels", empty)
+	}
+}
+
+func TestWalletTransactionsFinality(t *testing.T) {
+	// A mempool API at height 101 that knows one transaction, mined at 100
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/blocks/tip/height":
+			w.Write([]byte("101"))
+		case "/tx/aa":
+			w.Write([]byte(`{"txid":"aa","status":{"confirmed":true,"block_height":100}}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	registry := backend.NewRegistry()
+	registry.Register("BTC", backend.NewMempoolBackend(srv.URL))
+
+	s := newTestStoreServer(t)
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Mainnet, Backends: registry})
+	for _, tx := range []*storage.WalletTransaction{
+		{Chain: "BTC", Kind: storage.WalletTxConsolidation, TxID: "aa", CreatedAt: 100},
+		{Chain: "BTC", Kind: storage.WalletTxConsolidation, TxID: "bb", CreatedAt: 200},
+	} {
+		if err := s.store.RecordWalletTransaction(tx); err != nil {
+			t.Fatalf("RecordWalletTransaction() error = %v", err)
+		}
+	}
+
+	result, err := s.walletTransactions(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("walletTransactions() error = %v", err)
+	}
+	items := result.([]WalletTransactionItem)
+	if len(items) != 2 {
+		t.Fatalf("walletTransactions() = %+v", items)
+	}
+	if items[0].TxID != "bb" || items[0].Finality != FinalityUnknown {
+		t.Errorf("unknown tx = %+v, want unknown finality", items[0])
+	}
+	if items[1].Confirmations != 2 || items[1].Finality != FinalityConfirmed {
+		t.Errorf("mined tx = %+v, want 2 confirmations, confirmed", items[1])
+	}
+
+	s.SetFinality(node.FinalityConfig{Depths: map[string]uint32{"BTC": 2}})
+	result, _ = s.walletTransactions(context.Background(), nil)
+	if items := result.([]WalletTransactionItem); items[1].Finality != FinalityFinal {
+		t.Errorf("mined tx at depth 2 = %+v, want final", items[1])
+	}
+}
+
+func TestFinalBalance(t *testing.T) {
+	s := newTestStoreServer(t)
+	for i, u := range []*storage.WalletUTXO{
+		{Chain: "BTC", TxID: "aa", Amount: 1000, Address: "addr", Status: storage.UTXOStatusConfirmed, Confirmations: 6},
+		{Chain: "BTC", TxID: "bb", Amount: 2000, Address: "addr", Status: storage.UTXOStatusConfirmed, Confirmations: 1},
+		{Chain: "BTC", TxID: "cc", Amount: 4000, Address: "addr", Status: storage.UTXOStatusUnconfirmed},
+	} {
+		u.Vout = uint32(i)
+		if err := s.store.SaveWalletUTXO(u); err != nil {
+			t.Fatalf("SaveWalletUTXO() error = %v", err)
+		}
+	}
+
+	final, err := s.finalBalance("BTC")
+	if err != nil {
+		t.Fatalf("finalBalance() error = %v", err)
+	}
+	if final != 1000 {
+		t.Errorf("finalBalance() = %d, want 1000", final)
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/Klingon-tech/klingdex/internal/node"
 	"github.com/libp2p/go-libp2p/core/peer"
 )
 
@@ -26,6 +27,10 @@ type NodeInfoResult struct {
 	DataDir  string   `json:"data_dir"`
 	MDNSEnabled bool  `json:"mdns_enabled"`
 	DHTEnabled  bool  `json:"dht_enabled"`
+
+	// Swap protocol version we speak and the oldest we trade with
+	ProtocolVersion        int `json:"protocol_version"`
+	MinPeerProtocolVersion int `json:"min_peer_protocol_version,omitempty"`
 }
 
 func (s *Server) nodeInfo(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -45,6 +50,9 @@ func (s *Server) nodeInfo(ctx context.Context, params json.RawMessage) (interfac
 		DataDir:     cfg.Storage.DataDir,
 		MDNSEnabled: cfg.Network.EnableMDNS,
 		DHTEnabled:  cfg.Network.EnableDHT,
+
+		ProtocolVersion:        node.ProtocolVersion,
+		MinPeerProtocolVersion: cfg.Protocol.MinPeerVersion,
 	}, nil
 }
 
@@ -55,6 +63,17 @@ type NodeStatusResult struct {
 	KnownPeers int    `json:"known_peers"`
 	Uptime     string `json:"uptime"`
 	WSClients  int    `json:"ws_clients"`
+
+	Partition       *node.PartitionStatus `json:"partition,omitempty"`
+	Clock           *node.ClockStatus     `json:"clock,omitempty"`
+	PausedContracts []string              `json:"paused_contracts,omitempty"` // EVM chains whose HTLC contract is paused
+	ColdMode        bool                  `json:"cold_mode,omitempty"`        // Claims and refunds only
+
+	// PeerProtocolVersions is the number of peers seen per protocol version
+	PeerProtocolVersions map[int]int `json:"peer_protocol_versions,omitempty"`
+
+	// Rendezvous reports private group discovery, when configured
+	Rendezvous *node.RendezvousStatus `json:"rendezvous,omitempty"`
 }
 
 func (s *Server) nodeStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -71,12 +90,49 @@ func (s *Server) nodeStatus(ctx context.Context, params json.RawMessage) (interf
 		wsClients = s.wsHub.ClientCount()
 	}
 
+	var partition *node.PartitionStatus
+	if s.partition != nil {
+		st := s.partition.Status()
+		partition = &st
+	}
+
+	var clock *node.ClockStatus
+	if s.clock != nil {
+		st := s.clock.Status()
+		clock = &st
+	}
+
+	var paused []string
+	var cold bool
+	if s.coordinator != nil {
+		paused = s.coordinator.PausedContracts()
+		cold = s.coordinator.IsColdMode()
+	}
+
+	var versions map[int]int
+	if s.protocol != nil {
+		versions = s.protocol.Distribution()
+	}
+
+	var rendezvous *node.RendezvousStatus
+	if s.rendezvous != nil {
+		st := s.rendezvous.Status()
+		rendezvous = &st
+	}
+
 	return &NodeStatusResult{
-		Running:    true,
-		PeerCount:  s.node.PeerCount(),
-		KnownPeers: knownPeers,
-		Uptime:     s.node.Uptime().Round(time.Second).String(),
-		WSClients:  wsClients,
+		Running:         true,
+		PeerCount:       s.node.PeerCount(),
+		KnownPeers:      knownPeers,
+		Uptime:          s.node.Uptime().Round(time.Second).String(),
+		WSClients:       wsClients,
+		Partition:       partition,
+		Clock:           clock,
+		PausedContracts: paused,
+		ColdMode:        cold,
+
+		PeerProtocolVersions: versions,
+		Rendezvous:           rendezvous,
 	}, nil
 }
 
@@ -86,8 +142,9 @@ func (s *Server) nodeStatus(ctx context.Context, params json.RawMessage) (interf
 
 // PeerInfo represents information about a connected peer.
 type PeerInfo struct {
-	PeerID string   `json:"peer_id"`
-	Addrs  []string `json:"addrs,omitempty"`
+	PeerID  string            `json:"peer_id"`
+	Addrs   []string          `json:"addrs,omitempty"`
+	Quality *node.PeerQuality `json:"quality,omitempty"` // Latency and message loss
 }
 
 // PeersListResult is the response for peers_list.
@@ -96,6 +153,14 @@ type PeersListResult struct {
 	Count int        `json:"count"`
 }
 
+// peerQuality returns the latency and loss measurements of a peer, if any.
+func (s *Server) peerQuality(peerID string) *node.PeerQuality {
+	if s.node == nil {
+		return nil
+	}
+	return s.node.PeerQuality().Get(peerID)
+}
+
 func (s *Server) peersList(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	peers := s.node.Peers()
 	result := make([]PeerInfo, 0, len(peers))
@@ -109,8 +174,9 @@ func (s *Server) peersList(ctx context.Context, params json.RawMessage) (interfa
 		}
 
 		result = append(result, PeerInfo{
-			PeerID: p.String(),
-			Addrs:  addrStrs,
+			PeerID:  p.String(),
+			Addrs:   addrStrs,
+			Quality: s.peerQuality(p.String()),
 		})
 	}
 
@@ -215,6 +281,8 @@ type KnownPeerInfo struct {
 	ConnectionCount int      `json:"connection_count"`
 	IsBootstrap     bool     `json:"is_bootstrap"`
 	IsConnected     bool     `json:"is_connected"`
+
+	Quality *node.PeerQuality `json:"quality,omitempty"` // Latency and message loss
 }
 
 // KnownPeersResult is the response for peers_known.
@@ -267,6 +335,7 @@ func (s *Server) peersKnown(ctx context.Context, params json.RawMessage) (interf
 			ConnectionCount: r.ConnectionCount,
 			IsBootstrap:     r.IsBootstrap,
 			IsConnected:     connectedPeers[r.PeerID],
+			Quality:         s.peerQuality(r.PeerID),
 		})
 	}
 
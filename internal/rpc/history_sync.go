@@ -0,0 +1,75 @@
+// Package rpc - Trade history sync RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	klsync "github.com/Klingon-tech/klingdex/internal/sync"
+)
+
+// SetHistorySync enables the sync_* methods.
+func (s *Server) SetHistorySync(hs *klsync.HistorySync) {
+	s.history = hs
+}
+
+// SyncPairParams is the parameters for sync_pair.
+type SyncPairParams struct {
+	PeerID string `json:"peer_id"`          // Another node of ours
+	Remove bool   `json:"remove,omitempty"` // Unpair instead
+}
+
+// SyncPairResult is the response for sync_pair.
+type SyncPairResult struct {
+	PeerID string `json:"peer_id"`
+	Paired bool   `json:"paired"`
+}
+
+// SyncStatusResult is the response for sync_status.
+type SyncStatusResult struct {
+	PeerID string                      `json:"peer_id"` // Ours, to pair from the other node
+	Peers  []*klsync.HistoryPeerStatus `json:"peers"`
+}
+
+// syncPair adds a node to (or removes it from) the history sync allow-list.
+// The other node has to pair with us as well.
+func (s *Server) syncPair(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.history == nil {
+		return nil, fmt.Errorf("history sync not initialized")
+	}
+	var p SyncPairParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.PeerID == "" {
+		return nil, fmt.Errorf("peer_id is required")
+	}
+	id, err := peer.Decode(p.PeerID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid peer_id: %w", err)
+	}
+
+	if p.Remove {
+		if err := s.history.Unpair(id); err != nil {
+			return nil, err
+		}
+	} else if err := s.history.Pair(id); err != nil {
+		return nil, err
+	}
+	return &SyncPairResult{PeerID: id.String(), Paired: !p.Remove}, nil
+}
+
+// syncStatus returns the history sync state with each paired node.
+func (s *Server) syncStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.history == nil {
+		return nil, fmt.Errorf("history sync not initialized")
+	}
+	result := &SyncStatusResult{Peers: s.history.Status()}
+	if s.node != nil {
+		result.PeerID = s.node.ID().String()
+	}
+	return result, nil
+}
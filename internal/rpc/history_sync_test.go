@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	klsync "github.com/Klingon-tech/klingdex/internal/sync"
+)
+
+func TestSyncPairAndStatus(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+	if _, err := s.syncStatus(ctx, nil); err == nil {
+		t.Error("sync_status without history sync succeeded")
+	}
+
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	hs, err := klsync.NewHistorySync(hosts[0], s.store, node.HistorySyncConfig{})
+	if err != nil {
+		t.Fatalf("NewHistorySync() error = %v", err)
+	}
+	s.SetHistorySync(hs)
+
+	other := hosts[1].ID().String()
+	for _, params := range []string{`{}`, `{"peer_id":"not-a-peer"}`} {
+		if _, err := s.syncPair(ctx, json.RawMessage(params)); err == nil {
+			t.Errorf("sync_pair(%s) succeeded", params)
+		}
+	}
+	result, err := s.syncPair(ctx, json.RawMessage(`{"peer_id":"`+other+`"}`))
+	if err != nil || !result.(*SyncPairResult).Paired {
+		t.Fatalf("sync_pair = %+v, %v", result, err)
+	}
+
+	status, err := s.syncStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("sync_status error = %v", err)
+	}
+	if peers := status.(*SyncStatusResult).Peers; len(peers) != 1 || peers[0].PeerID != other || peers[0].Configured {
+		t.Errorf("peers = %+v, want the paired node", peers)
+	}
+
+	if _, err := s.syncPair(ctx, json.RawMessage(`{"peer_id":"`+other+`","remove":true}`)); err != nil {
+		t.Fatalf("sync_pair remove error = %v", err)
+	}
+	if status, _ := s.syncStatus(ctx, nil); len(status.(*SyncStatusResult).Peers) != 0 {
+		t.Error("peer still paired after remove")
+	}
+}
@@ -0,0 +1,68 @@
+// Package rpc - Nostr order bridge.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/nostr"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// SetNostr enables the Nostr order bridge and nostr_status, and routes
+// orders from the relays into the order book.
+func (s *Server) SetNostr(b *nostr.Bridge) {
+	s.nostr = b
+	b.SetIngest(s.ingestNostrOrder)
+}
+
+// nostrStatus returns the bridge state and relay connections.
+func (s *Server) nostrStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.nostr == nil {
+		return nil, fmt.Errorf("nostr bridge not initialized")
+	}
+	return s.nostr.Status(), nil
+}
+
+// mirrorOrder publishes our signed order announcement to the Nostr relays.
+// Unsigned orders stay on gossip: nothing ties them to a maker off it.
+func (s *Server) mirrorOrder(order *storage.Order, payload []byte) {
+	if s.nostr == nil || order.Identity == "" || order.ExpiresAt == nil {
+		return
+	}
+	if err := s.nostr.Publish(order.ID, payload, *order.ExpiresAt); err != nil {
+		s.log.Warn("Failed to publish order to nostr", "id", order.ID, "error", err)
+	}
+}
+
+// ingestNostrOrder adds an order announcement from a Nostr relay. Relays
+// are not libp2p peers, so the proof-of-work the gossip validator checks is
+// checked here and only signed orders are accepted. Orders we already have,
+// from gossip or another relay, are skipped.
+func (s *Server) ingestNostrOrder(ctx context.Context, payload []byte) (bool, error) {
+	info, _, err := s.decodeOrderAnnouncement(payload)
+	if err != nil {
+		return false, fmt.Errorf("invalid order announcement: %w", err)
+	}
+	if info.Identity == nil {
+		return false, fmt.Errorf("order %s is unsigned", info.ID)
+	}
+	if s.node != nil && info.PeerID == s.node.ID().String() {
+		return false, nil
+	}
+	if existing, _ := s.store.GetOrder(info.ID); existing != nil {
+		return false, nil
+	}
+	if info.ExpiresAt != nil && time.Unix(*info.ExpiresAt, 0).Before(time.Now()) {
+		return false, fmt.Errorf("order %s has expired", info.ID)
+	}
+	if err := verifyOrderPoW(info, s.orderPoW.Bits); err != nil {
+		return false, err
+	}
+	if s.acceptOrderAnnouncement(info) == nil {
+		return false, fmt.Errorf("order %s rejected", info.ID)
+	}
+	return true, nil
+}
@@ -0,0 +1,67 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestIngestNostrOrder(t *testing.T) {
+	maker := newSigningServer(t, testMnemonic)
+	s := newSigningServer(t, testMnemonic)
+	maker.SetOrderPoW(node.OrderPoWConfig{Bits: 10})
+	s.SetOrderPoW(node.OrderPoWConfig{Bits: 10})
+	ctx := context.Background()
+
+	expires := time.Now().Add(time.Hour)
+	o := &storage.Order{
+		ID: "nostr-order", PeerID: "12D3KooWmaker", Status: storage.OrderStatusOpen, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 5000000,
+		PreferredMethods: []string{"htlc"}, CreatedAt: time.Now(), ExpiresAt: &expires,
+	}
+	if err := maker.signOrder(o); err != nil {
+		t.Fatalf("signOrder() error = %v", err)
+	}
+	info := orderToInfo(o)
+	payload := func(info OrderInfo) []byte {
+		data, err := json.Marshal(info)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	if _, err := s.ingestNostrOrder(ctx, payload(info)); err == nil {
+		t.Error("ingestNostrOrder() accepted an order without proof-of-work")
+	}
+	if err := maker.mineOrderPoW(ctx, &info); err != nil {
+		t.Fatalf("mineOrderPoW() error = %v", err)
+	}
+
+	unsigned := info
+	unsigned.Identity = nil
+	if _, err := s.ingestNostrOrder(ctx, payload(unsigned)); err == nil {
+		t.Error("ingestNostrOrder() accepted an unsigned order")
+	}
+	if _, err := s.ingestNostrOrder(ctx, []byte(`"junk"`)); err == nil {
+		t.Error("ingestNostrOrder() accepted an invalid payload")
+	}
+
+	added, err := s.ingestNostrOrder(ctx, payload(info))
+	if err != nil || !added {
+		t.Fatalf("ingestNostrOrder() = %v, %v, want added", added, err)
+	}
+	stored, err := s.store.GetOrder(info.ID)
+	if err != nil || stored.IsLocal || stored.Identity == "" {
+		t.Fatalf("stored order = %+v, %v", stored, err)
+	}
+
+	// The same order again, from gossip or another relay, is a duplicate
+	if added, err := s.ingestNostrOrder(ctx, payload(info)); err != nil || added {
+		t.Errorf("ingestNostrOrder() again = %v, %v, want a duplicate", added, err)
+	}
+}
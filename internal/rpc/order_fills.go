@@ -0,0 +1,180 @@
+// Package rpc - Partial fills of orders.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// OrderUpdatePayload is the maker's fill progress of an order, broadcast
+// after each take so other peers show and take only what remains, and
+// after a failed trade released its fill so they can take it again.
+type OrderUpdatePayload struct {
+	OrderID         string `json:"order_id"`
+	FilledAmount    uint64 `json:"filled_amount"`
+	RemainingAmount uint64 `json:"remaining_amount"`
+	Status          string `json:"status"`
+	FillSeq         uint64 `json:"fill_seq,omitempty"` // Order.FillSeq; orders the updates
+}
+
+// fillRequestAmount returns what a taker pays for amount of an order's
+// offer, at the order's rate. Both sides quote at the request chain's full
+// precision, so taker and maker compute the same amount regardless of
+// their configured quote precision.
+func (s *Server) fillRequestAmount(order *storage.Order, amount uint64) (uint64, error) {
+	if amount == order.OfferAmount {
+		return order.RequestAmount, nil
+	}
+	network := chain.Mainnet
+	switch {
+	case s.coordinator != nil:
+		network = s.coordinator.Network()
+	case s.wallet != nil:
+		network = s.wallet.Network()
+	}
+	precision, err := swap.AssetPrecision(strings.ToUpper(order.RequestChain), order.RequestToken, network, 0)
+	if err != nil {
+		return 0, err
+	}
+	return precision.Quote(amount, order.OfferAmount, order.RequestAmount)
+}
+
+// fillOrder returns a copy of order with the amounts of a fill, so the
+// usual offer checks apply to the part being traded.
+func fillOrder(order *storage.Order, offerAmount, requestAmount uint64) *storage.Order {
+	fill := *order
+	fill.OfferAmount = offerAmount
+	fill.RequestAmount = requestAmount
+	fill.FilledAmount = 0
+	return &fill
+}
+
+// takeFill resolves the amounts a take message proposes for one of our
+// orders. A take without an amount takes everything that remains.
+func (s *Server) takeFill(order *storage.Order, payload *OrderTakePayload) (offerAmount, requestAmount uint64, err error) {
+	offerAmount = payload.OfferAmount
+	if offerAmount == 0 {
+		offerAmount = order.Remaining()
+	}
+	if err := order.CheckFill(offerAmount); err != nil {
+		return 0, 0, err
+	}
+	if requestAmount, err = s.fillRequestAmount(order, offerAmount); err != nil {
+		return 0, 0, err
+	}
+	if payload.RequestAmount != 0 && payload.RequestAmount != requestAmount {
+		return 0, 0, fmt.Errorf("%w: request amount %d, expected %d", storage.ErrInvalidFill, payload.RequestAmount, requestAmount)
+	}
+	return offerAmount, requestAmount, nil
+}
+
+// takeOrderFill takes a fill of order and records the trade for it. The
+// fill is taken first, so concurrent takes can't overfill the order, and
+// given back when the trade can't be recorded.
+func (s *Server) takeOrderFill(order *storage.Order, trade *storage.Trade) (*storage.Order, error) {
+	filled, err := s.store.FillOrder(order.ID, trade.OfferAmount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update order fill: %w", err)
+	}
+	if err := s.store.CreateTrade(trade); err != nil {
+		if _, err := s.store.ReleaseOrderFill(order.ID, trade.OfferAmount); err != nil {
+			s.log.Warn("Failed to release order fill", "id", order.ID, "trade_id", trade.ID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to create trade: %w", err)
+	}
+	return filled, nil
+}
+
+// broadcastOrderFill announces the fill progress of one of our orders.
+func (s *Server) broadcastOrderFill(ctx context.Context, order *storage.Order) {
+	payload := OrderUpdatePayload{
+		OrderID:         order.ID,
+		FilledAmount:    order.FilledAmount,
+		RemainingAmount: order.Remaining(),
+		Status:          string(order.Status),
+		FillSeq:         order.FillSeq,
+	}
+	msg, err := node.NewSwapMessage(node.SwapMsgOrderUpdate, "", payload)
+	if err != nil {
+		return
+	}
+	msg.OrderID = order.ID
+	if err := s.broadcastToAll(ctx, msg); err != nil {
+		s.log.Warn("Failed to broadcast order fill", "id", order.ID, "error", err)
+	}
+}
+
+// relayOrderFillRelease announces the fill of one of our orders after an
+// unfinished trade released its part, so peers can take it again.
+func (s *Server) relayOrderFillRelease(event swap.SwapEvent) {
+	if event.EventType != swap.EventOrderFillReleased || s.store == nil {
+		return
+	}
+	data, _ := event.Data.(map[string]interface{})
+	orderID, _ := data["order_id"].(string)
+	order, err := s.store.GetOrder(orderID)
+	if err != nil || !order.IsLocal {
+		return // Peers hear about others' orders from their makers
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	s.broadcastOrderFill(ctx, order)
+}
+
+// handleOrderUpdate applies a maker's fill progress to our copy of its order.
+func (s *Server) handleOrderUpdate(ctx context.Context, msg *node.SwapMessage) error {
+	var payload OrderUpdatePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Debug("Failed to parse order update", "error", err)
+		return nil
+	}
+	if payload.OrderID == "" {
+		payload.OrderID = msg.OrderID
+	}
+
+	order, err := s.store.GetOrder(payload.OrderID)
+	if err != nil || order == nil || order.IsLocal {
+		return nil // Don't have this order, or it's ours
+	}
+
+	// Only process if it's from the order creator
+	if order.PeerID != msg.FromPeer {
+		return nil // Not authorized
+	}
+	// A matched order reopens when a release lowers its fill
+	if order.Status != storage.OrderStatusOpen && order.Status != storage.OrderStatusMatched {
+		return nil // Already closed
+	}
+	if !order.IsNewerFill(payload.FilledAmount, payload.FillSeq) {
+		return nil // A stale update
+	}
+
+	status := storage.OrderStatusOpen
+	if payload.FilledAmount >= order.OfferAmount {
+		status = storage.OrderStatusMatched
+	}
+	if err := s.store.UpdateOrderFill(order.ID, payload.FilledAmount, payload.FillSeq, status); err != nil {
+		s.log.Debug("Failed to apply order update", "id", order.ID, "error", err)
+		return nil
+	}
+
+	if s.wsHub != nil {
+		pair, _ := orderbook.PairOf(order)
+		s.wsHub.Broadcast("order_updated", map[string]interface{}{
+			"id":               order.ID,
+			"pair":             pair,
+			"filled_amount":    payload.FilledAmount,
+			"remaining_amount": order.OfferAmount - min(payload.FilledAmount, order.OfferAmount),
+		}, PairTopic(pair))
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newFillTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	for _, o := range []*storage.Order{
+		{ID: "local-1", PeerID: "us", Status: storage.OrderStatusOpen, IsLocal: true, OfferChain: "BTC", OfferAmount: 1000000, RequestChain: "LTC", RequestAmount: 3333333, MinFillAmount: 100000, CreatedAt: time.Now()},
+		{ID: "remote-1", PeerID: "maker", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000000, RequestChain: "LTC", RequestAmount: 3333333, MinFillAmount: 100000, CreatedAt: time.Now()},
+	} {
+		if err := s.store.CreateOrder(o); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+	return s
+}
+
+func TestFillRequestAmount(t *testing.T) {
+	s := newFillTestServer(t)
+	order, _ := s.store.GetOrder("local-1")
+
+	tests := []struct {
+		amount, want uint64
+	}{
+		{1000000, 3333333}, // The whole order pays exactly what it requests
+		{500000, 1666666},  // Half rounds to even
+		{300000, 1000000},
+		{100000, 333333},
+	}
+	for _, tt := range tests {
+		got, err := s.fillRequestAmount(order, tt.amount)
+		if err != nil || got != tt.want {
+			t.Errorf("fillRequestAmount(%d) = %d, %v, want %d", tt.amount, got, err, tt.want)
+		}
+	}
+}
+
+func TestTakeFill(t *testing.T) {
+	s := newFillTestServer(t)
+	order, _ := s.store.GetOrder("local-1")
+
+	offer, request, err := s.takeFill(order, &OrderTakePayload{OfferAmount: 300000, RequestAmount: 1000000})
+	if err != nil || offer != 300000 || request != 1000000 {
+		t.Errorf("takeFill() = %d, %d, %v", offer, request, err)
+	}
+
+	// A take without amounts takes the rest
+	if _, err := s.store.FillOrder(order.ID, 300000); err != nil {
+		t.Fatalf("FillOrder() error = %v", err)
+	}
+	order, _ = s.store.GetOrder("local-1")
+	offer, request, err = s.takeFill(order, &OrderTakePayload{})
+	if err != nil || offer != 700000 || request != 2333333 {
+		t.Errorf("takeFill(legacy) = %d, %d, %v", offer, request, err)
+	}
+
+	for name, payload := range map[string]*OrderTakePayload{
+		"wrong price":   {OfferAmount: 300000, RequestAmount: 999999},
+		"below minimum": {OfferAmount: 50000},
+		"overfill":      {OfferAmount: 800000},
+	} {
+		if _, _, err := s.takeFill(order, payload); !errors.Is(err, storage.ErrInvalidFill) {
+			t.Errorf("takeFill(%s) error = %v, want ErrInvalidFill", name, err)
+		}
+	}
+}
+
+func TestTakeOrderFill(t *testing.T) {
+	s := newFillTestServer(t)
+	order, _ := s.store.GetOrder("local-1")
+	newTrade := func(id string) *storage.Trade {
+		return &storage.Trade{
+			ID: id, OrderID: order.ID, MakerPeerID: "us", TakerPeerID: "taker", Method: "htlc",
+			State: storage.TradeStateInit, OfferAmount: 300000, RequestAmount: 1000000, CreatedAt: time.Now(),
+		}
+	}
+
+	filled, err := s.takeOrderFill(order, newTrade("t1"))
+	if err != nil {
+		t.Fatalf("takeOrderFill() error = %v", err)
+	}
+	if filled.Remaining() != 700000 {
+		t.Errorf("remaining = %d, want 700000", filled.Remaining())
+	}
+
+	// A take whose trade can't be recorded gives its fill back
+	if _, err := s.store.DB().Exec(`CREATE TRIGGER fail_trades BEFORE INSERT ON trades
+		BEGIN SELECT RAISE(FAIL, 'trades unavailable'); END`); err != nil {
+		t.Fatal(err)
+	}
+	order, _ = s.store.GetOrder("local-1")
+	if _, err := s.takeOrderFill(order, newTrade("t2")); err == nil {
+		t.Fatal("takeOrderFill() succeeded without recording the trade")
+	}
+	got, _ := s.store.GetOrder("local-1")
+	if got.Remaining() != 700000 || got.Status != storage.OrderStatusOpen {
+		t.Errorf("after a failed take: status %s, remaining %d, want open with 700000", got.Status, got.Remaining())
+	}
+}
+
+func TestHandleOrderUpdate(t *testing.T) {
+	s := newFillTestServer(t)
+	updateSeq := func(orderID, from string, filled, seq uint64) {
+		t.Helper()
+		data, _ := json.Marshal(OrderUpdatePayload{OrderID: orderID, FilledAmount: filled, FillSeq: seq})
+		msg := &node.SwapMessage{Type: node.SwapMsgOrderUpdate, OrderID: orderID, FromPeer: from, Payload: data}
+		if err := s.handleOrderUpdate(context.Background(), msg); err != nil {
+			t.Fatalf("handleOrderUpdate() error = %v", err)
+		}
+	}
+	update := func(orderID, from string, filled uint64) {
+		t.Helper()
+		updateSeq(orderID, from, filled, 0)
+	}
+	filled := func(id string) (uint64, storage.OrderStatus) {
+		order, _ := s.store.GetOrder(id)
+		return order.FilledAmount, order.Status
+	}
+
+	update("remote-1", "mallory", 900000)
+	if got, _ := filled("remote-1"); got != 0 {
+		t.Errorf("update from a stranger applied, filled = %d", got)
+	}
+	update("local-1", "us", 900000)
+	if got, _ := filled("local-1"); got != 0 {
+		t.Errorf("update of our own order applied, filled = %d", got)
+	}
+
+	update("remote-1", "maker", 400000)
+	if got, status := filled("remote-1"); got != 400000 || status != storage.OrderStatusOpen {
+		t.Errorf("filled = %d %s, want 400000 open", got, status)
+	}
+	update("remote-1", "maker", 200000) // Stale, arrived out of order
+	if got, _ := filled("remote-1"); got != 400000 {
+		t.Errorf("stale update applied, filled = %d", got)
+	}
+	update("remote-1", "maker", 1000000)
+	if got, status := filled("remote-1"); got != 1000000 || status != storage.OrderStatusMatched {
+		t.Errorf("filled = %d %s, want 1000000 matched", got, status)
+	}
+
+	// The maker released the take: the lower fill wins by sequence
+	updateSeq("remote-1", "maker", 0, 5)
+	if got, status := filled("remote-1"); got != 0 || status != storage.OrderStatusOpen {
+		t.Errorf("after release filled = %d %s, want 0 open", got, status)
+	}
+	updateSeq("remote-1", "maker", 1000000, 4)
+	if got, _ := filled("remote-1"); got != 0 {
+		t.Errorf("stale sequenced update applied, filled = %d", got)
+	}
+}
+
+func TestOrderInfoRemaining(t *testing.T) {
+	s := newFillTestServer(t)
+	if _, err := s.store.FillOrder("local-1", 250000); err != nil {
+		t.Fatalf("FillOrder() error = %v", err)
+	}
+	order, _ := s.store.GetOrder("local-1")
+	info := orderToInfo(order)
+	if info.FilledAmount != 250000 || info.RemainingAmount != 750000 || info.MinFillAmount != 100000 {
+		t.Errorf("orderToInfo() fills = %d/%d/%d", info.FilledAmount, info.RemainingAmount, info.MinFillAmount)
+	}
+
+	// Orders taken whole keep the announcement format of older peers
+	whole := orderToInfo(&storage.Order{OfferAmount: 1000, RequestAmount: 2000})
+	if whole.RemainingAmount != 0 {
+		t.Errorf("whole-only order has remaining_amount %d", whole.RemainingAmount)
+	}
+}
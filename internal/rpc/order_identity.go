@@ -0,0 +1,257 @@
+// Package rpc - Order signing with the maker's wallet identity key.
+//
+// Makers sign orders with a wallet-derived identity key and prove control of
+// their receive addresses (the account 0, index 0 addresses claims and
+// refunds pay to) on both chains. Takers verify the signature and the proofs
+// before storing an order, so a spoofed re-publication of someone else's
+// order under another peer ID or with other funds destinations is rejected.
+package rpc
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+)
+
+// Domain tags of the signed messages.
+const (
+	orderDigestTag      = "klingdex/order/v1"
+	addressChallengeTag = "klingdex/address/v1"
+)
+
+// OrderIdentity binds an order to the maker's wallet identity key.
+type OrderIdentity struct {
+	PubKey    string         `json:"pubkey"`              // Compressed identity public key (hex)
+	Signature string         `json:"signature,omitempty"` // BIP-340 signature of the order digest (hex)
+	Addresses []AddressProof `json:"addresses,omitempty"`
+}
+
+// AddressProof proves the maker controls a receive address: the address key
+// signs a challenge committing to the identity key and the order.
+type AddressProof struct {
+	Chain     string `json:"chain"`
+	Address   string `json:"address"`
+	PubKey    string `json:"pubkey"`    // Compressed address public key (hex)
+	Signature string `json:"signature"` // BIP-340 signature of the challenge (hex)
+}
+
+// SetRequireSignedOrders drops remote orders without a wallet identity.
+func (s *Server) SetRequireSignedOrders(require bool) {
+	s.requireSignedOrders = require
+}
+
+// signOrder signs a local order with the wallet identity key and proves the
+// receive addresses on both of its chains.
+func (s *Server) signOrder(o *storage.Order) error {
+	if s.wallet == nil || !s.wallet.IsUnlocked() {
+		return fmt.Errorf("wallet is locked")
+	}
+	key, err := s.wallet.IdentityKey()
+	if err != nil {
+		return fmt.Errorf("failed to derive identity key: %w", err)
+	}
+	identity := &OrderIdentity{PubKey: hex.EncodeToString(key.PubKey().SerializeCompressed())}
+
+	for _, symbol := range []string{o.OfferChain, o.RequestChain} {
+		address, err := s.wallet.GetAddress(symbol, 0, 0)
+		if err != nil {
+			continue // No address for this chain type
+		}
+		addrKey, err := s.wallet.GetPrivateKey(symbol, 0, 0)
+		if err != nil {
+			return fmt.Errorf("failed to derive %s address key: %w", symbol, err)
+		}
+		challenge := addressChallenge(identity.PubKey, o.ID, symbol, address)
+		sig, err := schnorr.Sign(addrKey, challenge)
+		if err != nil {
+			return fmt.Errorf("failed to sign %s address proof: %w", symbol, err)
+		}
+		identity.Addresses = append(identity.Addresses, AddressProof{
+			Chain:     symbol,
+			Address:   address,
+			PubKey:    hex.EncodeToString(addrKey.PubKey().SerializeCompressed()),
+			Signature: hex.EncodeToString(sig.Serialize()),
+		})
+	}
+
+	sig, err := schnorr.Sign(key, orderDigest(o, identity))
+	if err != nil {
+		return fmt.Errorf("failed to sign order: %w", err)
+	}
+	data, err := json.Marshal(identity)
+	if err != nil {
+		return err
+	}
+	o.Identity = string(data)
+	o.Signature = hex.EncodeToString(sig.Serialize())
+	return nil
+}
+
+// checkOrderIdentity verifies a remote order's identity and, when signed
+// orders are required, refuses unsigned ones.
+func (s *Server) checkOrderIdentity(o *storage.Order) (*OrderIdentity, error) {
+	network := chain.Mainnet
+	if s.wallet != nil {
+		network = s.wallet.Network()
+	}
+	identity, err := verifyOrderIdentity(o, network)
+	if err != nil {
+		return nil, err
+	}
+	if identity == nil && s.requireSignedOrders {
+		return nil, fmt.Errorf("order is not signed by a wallet identity")
+	}
+	return identity, nil
+}
+
+// verifyOrderIdentity checks an order's signature and address proofs. It
+// returns nil for an unsigned order.
+func verifyOrderIdentity(o *storage.Order, network chain.Network) (*OrderIdentity, error) {
+	identity := orderIdentity(o)
+	if identity == nil {
+		return nil, nil
+	}
+
+	pubKey, err := parseIdentityPubKey(identity.PubKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid identity pubkey: %w", err)
+	}
+	if !verifySchnorrHex(pubKey, identity.Signature, orderDigest(o, identity)) {
+		return nil, fmt.Errorf("invalid order signature")
+	}
+
+	for _, proof := range identity.Addresses {
+		if proof.Chain != o.OfferChain && proof.Chain != o.RequestChain {
+			return nil, fmt.Errorf("address proof for %s, not a chain of the order", proof.Chain)
+		}
+		addrKey, err := parseIdentityPubKey(proof.PubKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s address pubkey: %w", proof.Chain, err)
+		}
+		if !wallet.AddressMatchesPubKey(proof.Chain, network, proof.Address, addrKey) {
+			return nil, fmt.Errorf("%s address %s does not belong to its pubkey", proof.Chain, proof.Address)
+		}
+		challenge := addressChallenge(identity.PubKey, o.ID, proof.Chain, proof.Address)
+		if !verifySchnorrHex(addrKey, proof.Signature, challenge) {
+			return nil, fmt.Errorf("invalid %s address proof", proof.Chain)
+		}
+	}
+	return identity, nil
+}
+
+// orderIdentity decodes an order's identity with its signature, or returns
+// nil for an unsigned order.
+func orderIdentity(o *storage.Order) *OrderIdentity {
+	if o.Identity == "" {
+		return nil
+	}
+	var identity OrderIdentity
+	if err := json.Unmarshal([]byte(o.Identity), &identity); err != nil {
+		return &OrderIdentity{} // Fails verification
+	}
+	identity.Signature = o.Signature
+	return &identity
+}
+
+// setOrderIdentity stores a received identity on an order.
+func setOrderIdentity(o *storage.Order, identity *OrderIdentity) error {
+	if identity == nil {
+		return nil
+	}
+	stored := *identity
+	stored.Signature = ""
+	data, err := json.Marshal(&stored)
+	if err != nil {
+		return err
+	}
+	o.Identity = string(data)
+	o.Signature = identity.Signature
+	return nil
+}
+
+// orderDigest is the message signed by the identity key. It covers the
+// order terms, the maker peer ID and the proven addresses.
+func orderDigest(o *storage.Order, identity *OrderIdentity) []byte {
+	h := sha256.New()
+	field := func(s string) {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(s)))
+		h.Write(n[:])
+		h.Write([]byte(s))
+	}
+	number := func(v uint64) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], v)
+		h.Write(b[:])
+	}
+
+	field(orderDigestTag)
+	field(o.ID)
+	field(o.PeerID)
+	field(o.OfferChain)
+	number(o.OfferAmount)
+	field(o.RequestChain)
+	number(o.RequestAmount)
+	field(strings.Join(o.PreferredMethods, ","))
+	field(o.FeeTerms)
+	// Token legs are covered only when set, so native orders keep their digest
+	if o.OfferToken != "" || o.RequestToken != "" {
+		field("tokens")
+		field(strings.ToLower(o.OfferToken))
+		field(strings.ToLower(o.RequestToken))
+	}
+	if len(o.Settlement) > 0 {
+		field("settlement")
+		field(strings.Join(o.Settlement, ","))
+	}
+	number(uint64(o.CreatedAt.Unix()))
+	if o.ExpiresAt != nil {
+		number(uint64(o.ExpiresAt.Unix()))
+	} else {
+		number(0)
+	}
+	field(identity.PubKey)
+	for _, proof := range identity.Addresses {
+		field(proof.Chain)
+		field(proof.Address)
+		field(proof.PubKey)
+	}
+	return h.Sum(nil)
+}
+
+// addressChallenge is the message signed by a receive address key.
+func addressChallenge(identityPubKey, orderID, symbol, address string) []byte {
+	h := sha256.Sum256([]byte(addressChallengeTag + "|" + identityPubKey + "|" + orderID + "|" + symbol + "|" + address))
+	return h[:]
+}
+
+// parseIdentityPubKey parses a hex compressed public key.
+func parseIdentityPubKey(s string) (*btcec.PublicKey, error) {
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	return btcec.ParsePubKey(data)
+}
+
+// verifySchnorrHex verifies a hex BIP-340 signature.
+func verifySchnorrHex(pubKey *btcec.PublicKey, sigHex string, msg []byte) bool {
+	data, err := hex.DecodeString(sigHex)
+	if err != nil {
+		return false
+	}
+	sig, err := schnorr.ParseSignature(data)
+	if err != nil {
+		return false
+	}
+	return sig.Verify(msg, pubKey)
+}
@@ -0,0 +1,151 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// newSigningServer returns a test server with an unlocked testnet wallet.
+func newSigningServer(t *testing.T, mnemonic string) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: s.store})
+	if err := s.wallet.CreateWallet(mnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	return s
+}
+
+func newSignedOrder(t *testing.T, s *Server) *storage.Order {
+	t.Helper()
+	expires := time.Unix(1700086400, 0)
+	o := &storage.Order{
+		ID: "order-1", PeerID: "12D3KooWmaker", Status: storage.OrderStatusOpen, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "ETH", RequestAmount: 2000000000000000,
+		PreferredMethods: []string{"htlc"}, CreatedAt: time.Unix(1700000000, 0), ExpiresAt: &expires,
+	}
+	if err := s.signOrder(o); err != nil {
+		t.Fatalf("signOrder() error = %v", err)
+	}
+	return o
+}
+
+// received returns the order as a taker stores it from the announcement.
+func received(t *testing.T, o *storage.Order) *storage.Order {
+	t.Helper()
+	data, _ := json.Marshal(orderToInfo(o))
+	var info OrderInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	expires := time.Unix(*info.ExpiresAt, 0)
+	r := &storage.Order{
+		ID: info.ID, PeerID: info.PeerID, Status: storage.OrderStatus(info.Status),
+		OfferChain: info.OfferChain, OfferAmount: info.OfferAmount,
+		RequestChain: info.RequestChain, RequestAmount: info.RequestAmount,
+		PreferredMethods: info.PreferredMethods, CreatedAt: time.Unix(info.CreatedAt, 0), ExpiresAt: &expires,
+	}
+	if err := setOrderIdentity(r, info.Identity); err != nil {
+		t.Fatalf("setOrderIdentity() error = %v", err)
+	}
+	return r
+}
+
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+func TestOrderIdentityRoundTrip(t *testing.T) {
+	maker := newSigningServer(t, testMnemonic)
+	o := newSignedOrder(t, maker)
+
+	identity, err := verifyOrderIdentity(received(t, o), chain.Testnet)
+	if err != nil {
+		t.Fatalf("verifyOrderIdentity() error = %v", err)
+	}
+	if identity == nil || len(identity.Addresses) != 2 {
+		t.Fatalf("identity = %+v, want proofs for both chains", identity)
+	}
+	btcAddr, _ := maker.wallet.GetAddress("BTC", 0, 0)
+	ethAddr, _ := maker.wallet.GetAddress("ETH", 0, 0)
+	if identity.Addresses[0].Address != btcAddr || identity.Addresses[1].Address != ethAddr {
+		t.Errorf("proven addresses = %+v, want %s and %s", identity.Addresses, btcAddr, ethAddr)
+	}
+
+	// The identity is stable across orders and independent of the peer key
+	if other := newSignedOrder(t, maker); orderIdentity(other).PubKey != identity.PubKey {
+		t.Error("identity key changed between orders")
+	}
+}
+
+func TestOrderIdentityRejectsTampering(t *testing.T) {
+	maker := newSigningServer(t, testMnemonic)
+	attacker := newSigningServer(t, "legal winner thank year wave sausage worth useful legal winner thank yellow")
+
+	tests := []struct {
+		name   string
+		modify func(o *storage.Order)
+	}{
+		{"amount", func(o *storage.Order) { o.RequestAmount++ }},
+		{"settlement", func(o *storage.Order) { o.Settlement = []string{swap.SettlementLightning} }},
+		{"re-published by another peer", func(o *storage.Order) { o.PeerID = "12D3KooWspoofer" }},
+		{"swapped address", func(o *storage.Order) {
+			identity := orderIdentity(o)
+			identity.Addresses[0].Address, _ = attacker.wallet.GetAddress("BTC", 0, 0)
+			_ = setOrderIdentity(o, identity)
+		}},
+		{"proof from another order", func(o *storage.Order) {
+			forged := newSignedOrder(t, attacker)
+			identity := orderIdentity(o)
+			identity.Addresses[0] = orderIdentity(forged).Addresses[0]
+			_ = setOrderIdentity(o, identity)
+		}},
+		{"garbage", func(o *storage.Order) { o.Identity = "{" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := received(t, newSignedOrder(t, maker))
+			tt.modify(o)
+			if _, err := verifyOrderIdentity(o, chain.Testnet); err == nil {
+				t.Error("verifyOrderIdentity() accepted a tampered order")
+			}
+		})
+	}
+}
+
+func TestCheckOrderIdentityRequireSigned(t *testing.T) {
+	s := newTestStoreServer(t)
+	unsigned := &storage.Order{ID: "o", OfferChain: "BTC", RequestChain: "LTC", CreatedAt: time.Now()}
+
+	if identity, err := s.checkOrderIdentity(unsigned); err != nil || identity != nil {
+		t.Errorf("checkOrderIdentity() = %v, %v, want unsigned accepted", identity, err)
+	}
+	s.SetRequireSignedOrders(true)
+	if _, err := s.checkOrderIdentity(unsigned); err == nil {
+		t.Error("unsigned order accepted with require_signed_orders")
+	}
+
+	// A locked wallet publishes unsigned orders
+	if err := s.signOrder(unsigned); err == nil || unsigned.Identity != "" {
+		t.Errorf("signOrder() without wallet = %v", err)
+	}
+}
+
+func TestOrderIdentityStored(t *testing.T) {
+	maker := newSigningServer(t, testMnemonic)
+	o := newSignedOrder(t, maker)
+	if err := maker.store.CreateOrder(o); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	stored, err := maker.store.GetOrder(o.ID)
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if _, err := verifyOrderIdentity(stored, chain.Testnet); err != nil || stored.Signature == "" {
+		t.Errorf("stored order identity = %q, %v", stored.Identity, err)
+	}
+}
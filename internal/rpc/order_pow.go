@@ -0,0 +1,167 @@
+// Package rpc - Proof-of-work on order announcements.
+//
+// Publishing an order costs the maker a small hashcash-style proof-of-work
+// over the order terms. Every node checks it in the PubSub validator, before
+// the order is stored or relayed, so flooding the network with junk orders
+// costs the attacker CPU time per order instead of filling every node's
+// storage for free. Makers we completed enough trades with are exempt.
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/bits"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// orderPoWTag is the domain tag of the proof-of-work digest.
+const orderPoWTag = "klingdex/order-pow/v1"
+
+// OrderPoW is the proof-of-work of an order announcement.
+type OrderPoW struct {
+	Bits  uint8  `json:"bits"`  // Leading zero bits of the hash
+	Nonce uint64 `json:"nonce"` // Nonce found by the maker
+}
+
+// SetOrderPoW sets the proof-of-work remote orders must carry and our
+// orders are mined at.
+func (s *Server) SetOrderPoW(cfg node.OrderPoWConfig) {
+	s.orderPoW = cfg
+}
+
+// orderPoWDigest commits to the order terms and the maker, so a proof can't
+// be reused for another order.
+func orderPoWDigest(info *OrderInfo) []byte {
+	h := sha256.New()
+	field := func(s string) {
+		var n [4]byte
+		binary.BigEndian.PutUint32(n[:], uint32(len(s)))
+		h.Write(n[:])
+		h.Write([]byte(s))
+	}
+	number := func(v uint64) {
+		var b [8]byte
+		binary.BigEndian.PutUint64(b[:], v)
+		h.Write(b[:])
+	}
+
+	field(orderPoWTag)
+	field(info.ID)
+	field(info.PeerID)
+	field(info.OfferChain)
+	number(info.OfferAmount)
+	field(info.RequestChain)
+	number(info.RequestAmount)
+	field(strings.Join(info.PreferredMethods, ","))
+	var feeTerms string
+	if info.FeeTerms != nil {
+		data, _ := json.Marshal(info.FeeTerms)
+		feeTerms = string(data)
+	}
+	field(feeTerms)
+	if info.OfferToken != "" || info.RequestToken != "" {
+		field("tokens")
+		field(strings.ToLower(info.OfferToken))
+		field(strings.ToLower(info.RequestToken))
+	}
+	if len(info.Settlement) > 0 {
+		field("settlement")
+		field(strings.Join(info.Settlement, ","))
+	}
+	number(uint64(info.CreatedAt))
+	if info.ExpiresAt != nil {
+		number(uint64(*info.ExpiresAt))
+	} else {
+		number(0)
+	}
+	if info.Identity != nil {
+		field(info.Identity.PubKey)
+	} else {
+		field("")
+	}
+	return h.Sum(nil)
+}
+
+// powZeroBits returns the leading zero bits of sha256(digest || nonce).
+func powZeroBits(digest []byte, nonce uint64) int {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], nonce)
+	hash := sha256.Sum256(append(digest[:len(digest):len(digest)], buf[:]...))
+
+	zeros := 0
+	for _, b := range hash {
+		if b != 0 {
+			return zeros + bits.LeadingZeros8(b)
+		}
+		zeros += 8
+	}
+	return zeros
+}
+
+// solveOrderPoW finds a nonce giving the order's digest at least difficulty
+// leading zero bits. Each extra bit doubles the expected work.
+func solveOrderPoW(ctx context.Context, info *OrderInfo, difficulty uint8) (*OrderPoW, error) {
+	digest := orderPoWDigest(info)
+	for nonce := uint64(0); ; nonce++ {
+		if nonce%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("order proof-of-work cancelled: %w", err)
+			}
+		}
+		if powZeroBits(digest, nonce) >= int(difficulty) {
+			return &OrderPoW{Bits: difficulty, Nonce: nonce}, nil
+		}
+	}
+}
+
+// verifyOrderPoW checks that an order carries a proof-of-work of at least
+// difficulty bits.
+func verifyOrderPoW(info *OrderInfo, difficulty uint8) error {
+	if difficulty == 0 {
+		return nil
+	}
+	if info.PoW == nil {
+		return fmt.Errorf("order has no proof-of-work")
+	}
+	if info.PoW.Bits < difficulty {
+		return fmt.Errorf("order proof-of-work of %d bits, %d required", info.PoW.Bits, difficulty)
+	}
+	if powZeroBits(orderPoWDigest(info), info.PoW.Nonce) < int(info.PoW.Bits) {
+		return fmt.Errorf("invalid order proof-of-work")
+	}
+	return nil
+}
+
+// mineOrderPoW attaches a proof-of-work to one of our order announcements.
+func (s *Server) mineOrderPoW(ctx context.Context, info *OrderInfo) error {
+	if s.orderPoW.Bits == 0 {
+		return nil
+	}
+	pow, err := solveOrderPoW(ctx, info, s.orderPoW.Bits)
+	if err != nil {
+		return err
+	}
+	info.PoW = pow
+	return nil
+}
+
+// validateOrderAnnounce is the PubSub validator of order announcements:
+// orders without enough proof-of-work are neither stored nor relayed, unless
+// the maker published them and we completed enough trades with it.
+func (s *Server) validateOrderAnnounce(ctx context.Context, from string, msg *node.SwapMessage) error {
+	info, _, err := s.decodeOrderAnnouncement(msg.Payload)
+	if err != nil {
+		return fmt.Errorf("invalid order announcement: %w", err)
+	}
+	if s.orderPoW.ExemptCompletedTrades > 0 && info.PeerID == from && s.store != nil {
+		if stats, err := s.store.GetPeerTradeStats(from); err == nil && stats.CompletedTrades >= s.orderPoW.ExemptCompletedTrades {
+			return nil
+		}
+	}
+	return verifyOrderPoW(info, s.orderPoW.Bits)
+}
@@ -0,0 +1,139 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func newPoWOrderInfo() OrderInfo {
+	expires := int64(1700086400)
+	return OrderInfo{
+		ID: "order-1", PeerID: "12D3KooWmaker", Status: "open",
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 5000000,
+		PreferredMethods: []string{"htlc"}, CreatedAt: 1700000000, ExpiresAt: &expires,
+	}
+}
+
+// orderAnnounce returns the announcement message of an order.
+func orderAnnounce(t *testing.T, info OrderInfo) *node.SwapMessage {
+	t.Helper()
+	msg, err := node.NewOrderAnnounceMessage(info.ID, info)
+	if err != nil {
+		t.Fatalf("NewOrderAnnounceMessage() error = %v", err)
+	}
+	return msg
+}
+
+func TestOrderPoWSolveVerify(t *testing.T) {
+	info := newPoWOrderInfo()
+	pow, err := solveOrderPoW(context.Background(), &info, 12)
+	if err != nil {
+		t.Fatalf("solveOrderPoW() error = %v", err)
+	}
+	info.PoW = pow
+	if err := verifyOrderPoW(&info, 12); err != nil {
+		t.Errorf("verifyOrderPoW() error = %v", err)
+	}
+	if err := verifyOrderPoW(&info, 0); err != nil {
+		t.Errorf("verifyOrderPoW(0) error = %v", err)
+	}
+	if err := verifyOrderPoW(&info, 16); err == nil {
+		t.Error("verifyOrderPoW() accepted a proof below the required bits")
+	}
+
+	// The proof is bound to the order terms
+	tampered := info
+	tampered.RequestAmount++
+	if err := verifyOrderPoW(&tampered, 12); err == nil {
+		t.Error("verifyOrderPoW() accepted a proof for changed terms")
+	}
+	settled := info
+	settled.Settlement = []string{swap.SettlementLightning}
+	if err := verifyOrderPoW(&settled, 12); err == nil {
+		t.Error("verifyOrderPoW() accepted a proof for an added settlement option")
+	}
+	claimed := info
+	claimed.PoW = &OrderPoW{Bits: 30, Nonce: pow.Nonce}
+	if err := verifyOrderPoW(&claimed, 12); err == nil {
+		t.Error("verifyOrderPoW() accepted overstated bits")
+	}
+
+	missing := newPoWOrderInfo()
+	if err := verifyOrderPoW(&missing, 1); err == nil {
+		t.Error("verifyOrderPoW() accepted an order without proof-of-work")
+	}
+}
+
+func TestOrderPoWSolveCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	info := newPoWOrderInfo()
+	if _, err := solveOrderPoW(ctx, &info, 64); err == nil {
+		t.Error("solveOrderPoW() with a cancelled context should fail")
+	}
+}
+
+func TestValidateOrderAnnounce(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetOrderPoW(node.OrderPoWConfig{Bits: 10, ExemptCompletedTrades: 2})
+	ctx := context.Background()
+
+	info := newPoWOrderInfo()
+	if err := s.validateOrderAnnounce(ctx, info.PeerID, orderAnnounce(t, info)); err == nil {
+		t.Error("validateOrderAnnounce() accepted an order without proof-of-work")
+	}
+
+	if err := s.mineOrderPoW(ctx, &info); err != nil {
+		t.Fatalf("mineOrderPoW() error = %v", err)
+	}
+	// Relayed copies carry the maker's proof, whoever forwarded them
+	if err := s.validateOrderAnnounce(ctx, "12D3KooWother", orderAnnounce(t, info)); err != nil {
+		t.Errorf("validateOrderAnnounce() error = %v", err)
+	}
+
+	if err := s.validateOrderAnnounce(ctx, info.PeerID, &node.SwapMessage{Type: node.SwapMsgOrderAnnounce, Payload: json.RawMessage(`"junk"`)}); err == nil {
+		t.Error("validateOrderAnnounce() accepted an invalid payload")
+	}
+}
+
+func TestValidateOrderAnnounceExempt(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetOrderPoW(node.OrderPoWConfig{Bits: 10, ExemptCompletedTrades: 2})
+	ctx := context.Background()
+	info := newPoWOrderInfo()
+
+	for i := 0; i < 2; i++ {
+		trade := &storage.Trade{
+			ID: fmt.Sprintf("trade-%d", i), OrderID: "old-order", MakerPeerID: info.PeerID, TakerPeerID: "us",
+			OurRole: storage.TradeRoleTaker, Method: "htlc", State: storage.TradeStateInit,
+			OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+			CreatedAt: time.Now(),
+		}
+		if err := s.store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+		if i == 0 {
+			if err := s.validateOrderAnnounce(ctx, info.PeerID, orderAnnounce(t, info)); err == nil {
+				t.Error("validateOrderAnnounce() exempted a maker without completed trades")
+			}
+		}
+		if err := s.store.UpdateTradeState(trade.ID, storage.TradeStateRedeemed); err != nil {
+			t.Fatalf("UpdateTradeState() error = %v", err)
+		}
+	}
+
+	if err := s.validateOrderAnnounce(ctx, info.PeerID, orderAnnounce(t, info)); err != nil {
+		t.Errorf("validateOrderAnnounce() for a trusted maker error = %v", err)
+	}
+	// The exemption only covers orders the maker published itself
+	if err := s.validateOrderAnnounce(ctx, "12D3KooWother", orderAnnounce(t, info)); err == nil {
+		t.Error("validateOrderAnnounce() exempted an order published by another peer")
+	}
+}
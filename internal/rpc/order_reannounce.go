@@ -0,0 +1,210 @@
+// Package rpc - Re-announcement of our open orders.
+//
+// Orders are announced once, when created. Peers that joined later, or
+// dropped our orders while we were offline or partitioned, only learn of
+// them again through order sync. After a restart, and when the node
+// recovers from a partition, each of our open orders is re-validated and
+// announced again: orders whose rate drifted off-market or whose funds are
+// no longer available are cancelled (or kept unannounced with keep_stale),
+// and broadcasts that fail are retried.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// EventOrdersReannounced is broadcast after each re-announcement round.
+const EventOrdersReannounced EventType = "orders_reannounced"
+
+// OrdersReannounceParams is the parameters for orders_reannounce.
+type OrdersReannounceParams struct {
+	IDs []string `json:"ids,omitempty"` // Empty for all our open orders
+}
+
+// StaleOrder is an order that failed re-validation.
+type StaleOrder struct {
+	ID        string `json:"id"`
+	Reason    string `json:"reason"`
+	Cancelled bool   `json:"cancelled"`
+}
+
+// OrdersReannounceResult is the response for orders_reannounce.
+type OrdersReannounceResult struct {
+	Announced []string     `json:"announced"`
+	Failed    []string     `json:"failed"` // Broadcast failed
+	Stale     []StaleOrder `json:"stale"`
+}
+
+// SetReannounce sets the re-announcement configuration.
+func (s *Server) SetReannounce(cfg node.ReannounceConfig) {
+	s.reannounce = cfg
+}
+
+// ReannounceOrders re-announces our open orders in the background once the
+// node has a peer, retrying failed broadcasts. It does nothing when
+// re-announcement is disabled or a run is already going.
+func (s *Server) ReannounceOrders(ctx context.Context) {
+	if !s.reannounce.Enabled || s.node == nil {
+		return
+	}
+	if !s.reannouncing.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		defer s.reannouncing.Store(false)
+		s.runReannounce(ctx)
+	}()
+}
+
+// runReannounce waits for a peer, then announces until every order went out
+// or the attempts run out.
+func (s *Server) runReannounce(ctx context.Context) {
+	cfg := s.reannounce
+
+	// Broadcasts before the first peer reach nobody
+	deadline := time.Now().Add(cfg.PeerWait)
+	for s.node.PeerCount() == 0 && time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Second):
+		}
+	}
+
+	var ids []string
+	for attempt := 1; ; attempt++ {
+		result, err := s.reannounceOrders(ctx, ids)
+		if err != nil {
+			s.log.Warn("Failed to re-announce orders", "error", err)
+		} else if len(result.Failed) == 0 {
+			return
+		} else {
+			ids = result.Failed
+		}
+		if attempt >= cfg.MaxAttempts {
+			s.log.Warn("Giving up re-announcing orders", "attempts", attempt, "unannounced", len(ids))
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(cfg.RetryInterval):
+		}
+	}
+}
+
+// ordersReannounce re-validates and re-announces our open orders now.
+func (s *Server) ordersReannounce(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p OrdersReannounceParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if s.coordinator != nil && s.coordinator.IsColdMode() {
+		return nil, swap.ErrColdMode
+	}
+	return s.reannounceOrders(ctx, p.IDs)
+}
+
+// reannounceOrders re-validates and announces our open orders, or those of
+// ids. Stale orders are cancelled unless keep_stale is set.
+func (s *Server) reannounceOrders(ctx context.Context, ids []string) (*OrdersReannounceResult, error) {
+	result := &OrdersReannounceResult{Announced: []string{}, Failed: []string{}, Stale: []StaleOrder{}}
+	if s.coordinator != nil && s.coordinator.IsColdMode() {
+		s.log.Info("Cold mode, not re-announcing orders")
+		return result, nil
+	}
+
+	orders, err := s.reannounceCandidates(ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if reason := s.staleReason(ctx, order); reason != "" {
+			stale := StaleOrder{ID: order.ID, Reason: reason}
+			if !s.reannounce.KeepStale {
+				if err := s.cancelLocalOrder(ctx, order.ID, reason); err != nil {
+					s.log.Warn("Failed to cancel stale order", "id", order.ID, "error", err)
+				} else {
+					stale.Cancelled = true
+				}
+			}
+			result.Stale = append(result.Stale, stale)
+			continue
+		}
+		if err := s.announceOrder(ctx, order); err != nil {
+			s.log.Warn("Failed to re-announce order", "id", order.ID, "error", err)
+			result.Failed = append(result.Failed, order.ID)
+			continue
+		}
+		result.Announced = append(result.Announced, order.ID)
+	}
+
+	s.log.Info("Orders re-announced", "announced", len(result.Announced), "failed", len(result.Failed), "stale", len(result.Stale))
+	if s.wsHub != nil && len(orders) > 0 {
+		s.wsHub.Broadcast(EventOrdersReannounced, result)
+	}
+	return result, nil
+}
+
+// reannounceCandidates returns our open, unexpired orders, or those of ids.
+func (s *Server) reannounceCandidates(ids []string) ([]*storage.Order, error) {
+	var orders []*storage.Order
+	if ids == nil {
+		status := storage.OrderStatusOpen
+		local := true
+		var err error
+		if orders, err = s.store.ListOrders(storage.OrderFilter{Status: &status, IsLocal: &local}); err != nil {
+			return nil, fmt.Errorf("failed to list orders: %w", err)
+		}
+	} else {
+		for _, id := range ids {
+			order, err := s.store.GetOrder(id)
+			if err != nil {
+				continue // Deleted since
+			}
+			orders = append(orders, order)
+		}
+	}
+
+	now := time.Now()
+	open := orders[:0]
+	for _, o := range orders {
+		if !o.IsLocal || o.Status != storage.OrderStatusOpen || (o.ExpiresAt != nil && !o.ExpiresAt.After(now)) {
+			continue
+		}
+		open = append(open, o)
+	}
+	return open, nil
+}
+
+// staleReason returns why an order should no longer be offered, or "".
+// Checks that can't complete (no price, wallet unreachable) keep the order.
+func (s *Server) staleReason(ctx context.Context, order *storage.Order) string {
+	if verdict := s.priceCheck(order); verdict.OffMarket() {
+		return fmt.Sprintf("rate deviates %d bps from market (max %d)", verdict.DeviationBps, verdict.MaxDeviationBps)
+	}
+	if s.coordinator == nil {
+		return ""
+	}
+	offer, _, err := tradeOffer(&storage.Trade{}, order)
+	if err != nil {
+		return fmt.Sprintf("invalid order terms: %v", err)
+	}
+	if err := s.coordinator.CheckAffordable(ctx, offer, true); err != nil {
+		if errors.Is(err, swap.ErrInsufficientAvailableBalance) {
+			return err.Error()
+		}
+		s.log.Warn("Could not check order funds", "id", order.ID, "error", err)
+	}
+	return ""
+}
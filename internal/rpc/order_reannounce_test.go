@@ -0,0 +1,95 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func newReannounceServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	checker, err := pricefeed.New(node.PriceSanityConfig{
+		Action:       node.PriceActionFlag,
+		StaticPrices: map[string]string{"BTC": "60000", "LTC": "60"},
+	})
+	if err != nil {
+		t.Fatalf("pricefeed.New() error = %v", err)
+	}
+	s.SetPriceChecker(checker)
+
+	past := time.Now().Add(-time.Hour)
+	for _, o := range []*storage.Order{
+		{ID: "fair", PeerID: "us", IsLocal: true, OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 100000000000},
+		{ID: "drifted", PeerID: "us", IsLocal: true, OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 10000000000},
+		{ID: "expired", PeerID: "us", IsLocal: true, OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 100000000000, ExpiresAt: &past},
+		{ID: "remote", PeerID: "maker", OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 10000000000},
+	} {
+		o.Status, o.CreatedAt = storage.OrderStatusOpen, time.Now()
+		if err := s.store.CreateOrder(o); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+	return s
+}
+
+func TestReannounceOrders(t *testing.T) {
+	s := newReannounceServer(t)
+
+	// Without a running node the broadcast fails and is left for a retry
+	result, err := s.reannounceOrders(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("reannounceOrders() error = %v", err)
+	}
+	if len(result.Announced) != 0 || len(result.Failed) != 1 || result.Failed[0] != "fair" {
+		t.Errorf("announced %v, failed %v; want fair to fail", result.Announced, result.Failed)
+	}
+	if len(result.Stale) != 1 || result.Stale[0].ID != "drifted" || !result.Stale[0].Cancelled {
+		t.Fatalf("stale = %+v, want drifted cancelled", result.Stale)
+	}
+	if o, _ := s.store.GetOrder("drifted"); o.Status != storage.OrderStatusCancelled {
+		t.Errorf("drifted status = %s, want cancelled", o.Status)
+	}
+	if o, _ := s.store.GetOrder("remote"); o.Status != storage.OrderStatusOpen {
+		t.Errorf("remote order status = %s, want untouched", o.Status)
+	}
+
+	// Retries only cover the given orders
+	result, err = s.reannounceOrders(context.Background(), []string{"fair", "missing"})
+	if err != nil {
+		t.Fatalf("reannounceOrders(ids) error = %v", err)
+	}
+	if len(result.Failed) != 1 || len(result.Stale) != 0 {
+		t.Errorf("retry result = %+v", result)
+	}
+}
+
+func TestReannounceKeepStale(t *testing.T) {
+	s := newReannounceServer(t)
+	s.SetReannounce(node.ReannounceConfig{KeepStale: true})
+
+	result, err := s.ordersReannounce(context.Background(), json.RawMessage(`{"ids":["drifted"]}`))
+	if err != nil {
+		t.Fatalf("ordersReannounce() error = %v", err)
+	}
+	r := result.(*OrdersReannounceResult)
+	if len(r.Stale) != 1 || r.Stale[0].Cancelled || len(r.Failed) != 0 {
+		t.Errorf("result = %+v, want drifted stale but kept", r)
+	}
+	if o, _ := s.store.GetOrder("drifted"); o.Status != storage.OrderStatusOpen {
+		t.Errorf("drifted status = %s, want open", o.Status)
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	s.coordinator.SetColdMode(true)
+	if _, err := s.ordersReannounce(context.Background(), nil); !errors.Is(err, swap.ErrColdMode) {
+		t.Errorf("ordersReannounce() in cold mode error = %v, want ErrColdMode", err)
+	}
+}
@@ -0,0 +1,527 @@
+// Package rpc - Versioned order format and its JSON Schema.
+//
+// Orders are announced and listed in the format described by OrderInfo. The
+// format is versioned by the "schema" field, and its JSON Schema is generated
+// from the Go types (orders_schema), so third-party bots don't have to guess
+// field semantics. Announcements are decoded strictly: unknown fields, wrong
+// types and invalid values are rejected with the JSON path of each problem.
+// Announcements in the previous, unversioned format are accepted with the old
+// lenient decoding until the configured deprecation date.
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// OrderSchemaVersion is the version of the order format we publish and
+// decode strictly.
+const OrderSchemaVersion = "klingdex/order/v1"
+
+var (
+	orderIDPattern     = regexp.MustCompile(`^[A-Za-z0-9-]{1,64}$`)
+	orderPeerIDPattern = regexp.MustCompile(`^[1-9A-HJ-NP-Za-km-z]{1,128}$`) // base58
+	orderChainPattern  = regexp.MustCompile(`^[A-Z][A-Z0-9]{1,15}$`)
+	orderTokenPattern  = regexp.MustCompile(`^0x[0-9a-fA-F]{40}$`)
+)
+
+// orderMethods are the swap methods an order may prefer.
+var orderMethods = []string{
+	string(swap.MethodMuSig2), string(swap.MethodHTLC), string(swap.MethodAdaptor), string(swap.MethodContract),
+}
+
+// orderStatuses are the order statuses in listings; announcements are open.
+var orderStatuses = []string{
+	string(storage.OrderStatusOpen), string(storage.OrderStatusMatched), string(storage.OrderStatusCompleted),
+	string(storage.OrderStatusCancelled), string(storage.OrderStatusExpired), string(storage.OrderStatusFailed),
+}
+
+// schemaDoc documents a field of the order format. Paths are the JSON field
+// names joined with dots, with "[]" for array items.
+type schemaDoc struct {
+	Description string
+	Enum        []string
+	Pattern     string
+	Minimum     *int64
+}
+
+func schemaMin(v int64) *int64 { return &v }
+
+// orderSchemaDocs documents every field of OrderInfo. TestOrderSchemaDocumented
+// checks that no field is left out.
+var orderSchemaDocs = map[string]schemaDoc{
+	"":                    {Description: "An order: a maker offers an amount on one chain for an amount on another."},
+	"schema":              {Description: "Format version. Announcements without it use the deprecated unversioned format.", Enum: []string{OrderSchemaVersion}},
+	"id":                  {Description: "Order ID chosen by the maker (a UUID for orders created by klingond).", Pattern: orderIDPattern.String()},
+	"peer_id":             {Description: "libp2p peer ID of the maker (base58).", Pattern: orderPeerIDPattern.String()},
+	"status":              {Description: "Order status. Announced orders are always open.", Enum: orderStatuses},
+	"is_local":            {Description: "True for orders created by this node. Relative to the node reporting it; ignored on ingest."},
+	"offer_chain":         {Description: "Chain symbol of the asset the maker offers, e.g. BTC.", Pattern: orderChainPattern.String()},
+	"offer_amount":        {Description: "Offered amount in the smallest unit of offer_chain (satoshis, wei, ...), or of offer_token when set.", Minimum: schemaMin(1)},
+	"request_chain":       {Description: "Chain symbol of the asset the maker requests; differs from offer_chain.", Pattern: orderChainPattern.String()},
+	"request_amount":      {Description: "Requested amount in the smallest unit of request_chain, or of request_token when set.", Minimum: schemaMin(1)},
+	"offer_token":         {Description: "Contract address of the offered ERC-20 token on offer_chain (an EVM chain); it must be in the token registry. Omitted for the native coin.", Pattern: orderTokenPattern.String()},
+	"request_token":       {Description: "Contract address of the requested ERC-20 token on request_chain (an EVM chain); it must be in the token registry. Omitted for the native coin.", Pattern: orderTokenPattern.String()},
+	"preferred_methods":   {Description: "Swap methods the maker accepts, most preferred first."},
+	"preferred_methods[]": {Description: "Swap method.", Enum: orderMethods},
+	"created_at":          {Description: "Creation time (Unix seconds).", Minimum: schemaMin(1)},
+	"expires_at":          {Description: "Expiry time (Unix seconds), after created_at. Omitted for orders that don't expire."},
+	"settlement":          {Description: "Alternative ways the maker settles the request leg. Omitted when it settles on chain only."},
+	"min_fill_amount":     {Description: "Smallest part of offer_amount a taker may take, at the order's rate. Omitted for orders taken whole only."},
+	"filled_amount":       {Description: "Part of offer_amount already taken by trades. Omitted while nothing is taken."},
+	"remaining_amount":    {Description: "Part of offer_amount still available to takers. Omitted for orders taken whole only; ignored on ingest."},
+	"settlement[]":        {Description: "Settlement option: lightning accepts a BTC request leg over Lightning (submarine swap).", Enum: []string{swap.SettlementLightning}},
+
+	"fee_terms":                     {Description: "Negotiated fee split. Omitted when each party pays its own DAO and claim fees."},
+	"fee_terms.dao_fee_payer":       {Description: "Party paying both parties' DAO fees; empty for each party paying its own.", Enum: []string{"", string(swap.FeePayerMaker), string(swap.FeePayerTaker)}},
+	"fee_terms.claim_fee_payer":     {Description: "Party paying the mining fees of both claims; empty for each party paying its own.", Enum: []string{"", string(swap.FeePayerMaker), string(swap.FeePayerTaker)}},
+	"fee_terms.meta_claim_fee_bps":  {Description: "Relayer fee in basis points (at most 1000) a receiver without gas pays out of its EVM claim for a relayed claim. Omitted when claims aren't relayed."},
+	"fee_terms.claim_fee_allowance": {Description: "Extra escrow (smallest unit of the claim fee payer's funding chain) covering the counterparty's claim fee."},
+
+	"maker_stats":                     {Description: "Our trade history with the maker (listings of remote orders only; ignored on ingest)."},
+	"maker_stats.total_trades":        {Description: "Trades with the maker."},
+	"maker_stats.completed_trades":    {Description: "Completed trades with the maker."},
+	"maker_stats.failed_trades":       {Description: "Failed or refunded trades with the maker."},
+	"maker_stats.active_trades":       {Description: "Trades with the maker in progress."},
+	"maker_stats.avg_settlement_secs": {Description: "Average time to complete a trade, in seconds."},
+	"maker_stats.failure_rate_bps":    {Description: "Failed trades in basis points (100 = 1%)."},
+
+	"maker_quality":                 {Description: "Measured link quality to the maker (listings of remote orders only; ignored on ingest)."},
+	"maker_quality.peer_id":         {Description: "Maker peer ID."},
+	"maker_quality.rtt_us":          {Description: "Smoothed ping round-trip time in microseconds."},
+	"maker_quality.stream_setup_us": {Description: "Smoothed time to open a direct stream in microseconds."},
+	"maker_quality.messages_sent":   {Description: "Direct messages sent to the maker."},
+	"maker_quality.messages_lost":   {Description: "Direct messages sent without an acknowledgement."},
+	"maker_quality.loss_permille":   {Description: "Message loss in permille."},
+	"maker_quality.updated_at":      {Description: "Time of the last measurement (Unix seconds)."},
+
+	"price_check":                   {Description: "Rate check against the market price feed (listings only; ignored on ingest)."},
+	"price_check.status":            {Description: "Price check result.", Enum: []string{pricefeed.StatusOK, pricefeed.StatusOffMarket, pricefeed.StatusUnknown}},
+	"price_check.deviation_bps":     {Description: "How much more the order requests than it offers at market value, in basis points."},
+	"price_check.max_deviation_bps": {Description: "Deviation bound of the pair in basis points."},
+
+	"same_underlying":               {Description: "Set when both legs represent the same asset, e.g. BTC and WBTC (listings only; ignored on ingest)."},
+	"same_underlying.asset":         {Description: "The underlying asset of both legs."},
+	"same_underlying.deviation_bps": {Description: "How much more the order requests than it offers, counted 1:1 in the asset, in basis points."},
+
+	"identity":                       {Description: "Maker wallet identity and receive address proofs. Omitted for unsigned orders."},
+	"identity.pubkey":                {Description: "Compressed identity public key (hex).", Pattern: "^[0-9a-fA-F]{66}$"},
+	"identity.signature":             {Description: "BIP-340 signature of the order digest (hex).", Pattern: "^[0-9a-fA-F]{128}$"},
+	"identity.addresses":             {Description: "Receive addresses the maker proved control of."},
+	"identity.addresses[]":           {Description: "Address proof: the address key signs a challenge bound to the identity key and the order."},
+	"identity.addresses[].chain":     {Description: "Chain symbol of the address.", Pattern: orderChainPattern.String()},
+	"identity.addresses[].address":   {Description: "Receive address."},
+	"identity.addresses[].pubkey":    {Description: "Compressed address public key (hex).", Pattern: "^[0-9a-fA-F]{66}$"},
+	"identity.addresses[].signature": {Description: "BIP-340 signature of the challenge (hex).", Pattern: "^[0-9a-fA-F]{128}$"},
+
+	"pow":       {Description: "Anti-spam proof-of-work over the order terms. Omitted when not mined."},
+	"pow.bits":  {Description: "Leading zero bits of sha256(digest || nonce)."},
+	"pow.nonce": {Description: "Nonce found by the maker."},
+}
+
+// OrderSchema returns the JSON Schema of the order format, generated from
+// OrderInfo.
+func OrderSchema() map[string]interface{} {
+	schema := typeSchema(reflect.TypeOf(OrderInfo{}), "")
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	schema["title"] = OrderSchemaVersion
+	return schema
+}
+
+// typeSchema returns the JSON Schema of a Go type at a documented path.
+func typeSchema(t reflect.Type, path string) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	schema := make(map[string]interface{})
+	switch t.Kind() {
+	case reflect.Struct:
+		schema["type"] = "object"
+		props := make(map[string]interface{})
+		var required []string
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			name, omitempty := jsonFieldName(f)
+			if name == "" {
+				continue
+			}
+			props[name] = typeSchema(f.Type, joinSchemaPath(path, name))
+			if !omitempty && f.Type.Kind() != reflect.Ptr {
+				required = append(required, name)
+			}
+		}
+		schema["properties"] = props
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		schema["additionalProperties"] = false
+	case reflect.Slice:
+		schema["type"] = "array"
+		schema["items"] = typeSchema(t.Elem(), path+"[]")
+	case reflect.String:
+		schema["type"] = "string"
+	case reflect.Bool:
+		schema["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		schema["type"] = "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		schema["type"] = "integer"
+		schema["minimum"] = 0
+		if t.Kind() == reflect.Uint8 {
+			schema["maximum"] = 255
+		}
+	}
+
+	if doc, ok := orderSchemaDocs[path]; ok {
+		if doc.Description != "" {
+			schema["description"] = doc.Description
+		}
+		if len(doc.Enum) > 0 {
+			schema["enum"] = doc.Enum
+		}
+		if doc.Pattern != "" {
+			schema["pattern"] = doc.Pattern
+		}
+		if doc.Minimum != nil {
+			schema["minimum"] = *doc.Minimum
+		}
+	}
+	return schema
+}
+
+// jsonFieldName returns the JSON name of a struct field and whether it is
+// omitted when empty; the name is empty for fields not in the JSON.
+func jsonFieldName(f reflect.StructField) (string, bool) {
+	if !f.IsExported() {
+		return "", false
+	}
+	tag := f.Tag.Get("json")
+	if tag == "-" {
+		return "", false
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	if name == "" {
+		name = f.Name
+	}
+	return name, strings.Contains(opts, "omitempty")
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+// =============================================================================
+// Strict decoding and validation
+// =============================================================================
+
+// OrderFieldError is a problem with one field of an order.
+type OrderFieldError struct {
+	Path    string `json:"path"` // JSON path, e.g. "identity.addresses[0].pubkey"; empty for the whole order
+	Message string `json:"message"`
+}
+
+// OrderValidationError lists the problems found in an order.
+type OrderValidationError struct {
+	Errors []OrderFieldError
+}
+
+func (e *OrderValidationError) Error() string {
+	parts := make([]string, len(e.Errors))
+	for i, fe := range e.Errors {
+		if fe.Path == "" {
+			parts[i] = fe.Message
+		} else {
+			parts[i] = fe.Path + ": " + fe.Message
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+func (e *OrderValidationError) add(path, format string, args ...interface{}) {
+	e.Errors = append(e.Errors, OrderFieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// orderInvalid returns a validation error with a single problem.
+func orderInvalid(path, format string, args ...interface{}) *OrderValidationError {
+	e := &OrderValidationError{}
+	e.add(path, format, args...)
+	return e
+}
+
+// decodeOrderInfo decodes an announced order. Orders of OrderSchemaVersion
+// are decoded strictly; unversioned orders are decoded leniently, as before,
+// until legacyUntil (zero accepts them indefinitely). legacy reports an
+// unversioned order. Errors are *OrderValidationError.
+func decodeOrderInfo(data []byte, legacyUntil, now time.Time) (info *OrderInfo, legacy bool, err error) {
+	var probe struct {
+		Schema json.RawMessage `json:"schema"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, false, orderInvalid("", "must be a JSON object")
+	}
+
+	info = &OrderInfo{}
+	if len(probe.Schema) == 0 || string(probe.Schema) == `""` || string(probe.Schema) == "null" {
+		if !legacyUntil.IsZero() && now.After(legacyUntil) {
+			return nil, true, orderInvalid("schema", "required: the unversioned order format is no longer accepted, use %q", OrderSchemaVersion)
+		}
+		if err := json.Unmarshal(data, info); err != nil {
+			return nil, true, decodeError(err)
+		}
+		if verr := validateOrderInfo(info); verr != nil {
+			return nil, true, verr
+		}
+		return info, true, nil
+	}
+
+	var version string
+	if err := json.Unmarshal(probe.Schema, &version); err != nil {
+		return nil, false, orderInvalid("schema", "expected string")
+	}
+	if version != OrderSchemaVersion {
+		return nil, false, orderInvalid("schema", "unsupported version %q, expected %q", version, OrderSchemaVersion)
+	}
+
+	dec := json.NewDecoder(strings.NewReader(string(data)))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(info); err != nil {
+		return nil, false, decodeError(err)
+	}
+	if dec.More() {
+		return nil, false, orderInvalid("", "unexpected data after the order")
+	}
+	if verr := validateOrderInfo(info); verr != nil {
+		return nil, false, verr
+	}
+	return info, false, nil
+}
+
+// decodeError converts a JSON decoding error to a validation error.
+func decodeError(err error) *OrderValidationError {
+	var typeErr *json.UnmarshalTypeError
+	var syntaxErr *json.SyntaxError
+	switch {
+	case errors.As(err, &typeErr):
+		return orderInvalid(typeErr.Field, "expected %s, got %s", jsonTypeName(typeErr.Type), typeErr.Value)
+	case errors.As(err, &syntaxErr):
+		return orderInvalid("", "invalid JSON at offset %d: %v", syntaxErr.Offset, syntaxErr)
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return orderInvalid(field, "unknown field")
+	}
+	return orderInvalid("", "%v", err)
+}
+
+// jsonTypeName returns the JSON type a Go type decodes from.
+func jsonTypeName(t reflect.Type) string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Bool:
+		return "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "integer"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "non-negative integer"
+	case reflect.Slice:
+		return "array"
+	case reflect.Struct, reflect.Map:
+		return "object"
+	}
+	return t.String()
+}
+
+// validateOrderInfo checks the values of an announced order. It returns nil
+// if the order is valid.
+func validateOrderInfo(info *OrderInfo) *OrderValidationError {
+	e := &OrderValidationError{}
+
+	if !orderIDPattern.MatchString(info.ID) {
+		e.add("id", "must be 1-64 letters, digits or dashes")
+	}
+	if !orderPeerIDPattern.MatchString(info.PeerID) {
+		e.add("peer_id", "must be a base58 peer ID")
+	}
+	if info.Status != string(storage.OrderStatusOpen) {
+		e.add("status", "announced orders must be %q, got %q", storage.OrderStatusOpen, info.Status)
+	}
+	if !orderChainPattern.MatchString(info.OfferChain) {
+		e.add("offer_chain", "must be an upper-case chain symbol, got %q", info.OfferChain)
+	}
+	if !orderChainPattern.MatchString(info.RequestChain) {
+		e.add("request_chain", "must be an upper-case chain symbol, got %q", info.RequestChain)
+	} else if info.RequestChain == info.OfferChain {
+		e.add("request_chain", "must differ from offer_chain")
+	}
+	if info.OfferAmount == 0 {
+		e.add("offer_amount", "must be greater than 0")
+	}
+	if info.RequestAmount == 0 {
+		e.add("request_amount", "must be greater than 0")
+	}
+	if info.OfferToken != "" && !orderTokenPattern.MatchString(info.OfferToken) {
+		e.add("offer_token", "must be a 0x-prefixed 20-byte hex address, got %q", info.OfferToken)
+	}
+	if info.RequestToken != "" && !orderTokenPattern.MatchString(info.RequestToken) {
+		e.add("request_token", "must be a 0x-prefixed 20-byte hex address, got %q", info.RequestToken)
+	}
+	if len(info.PreferredMethods) == 0 {
+		e.add("preferred_methods", "must not be empty")
+	}
+	for i, m := range info.PreferredMethods {
+		if !containsString(orderMethods, m) {
+			e.add(fmt.Sprintf("preferred_methods[%d]", i), "unknown method %q, expected one of %s", m, strings.Join(orderMethods, ", "))
+		}
+	}
+	if info.CreatedAt <= 0 {
+		e.add("created_at", "must be a positive Unix time")
+	}
+	if info.ExpiresAt != nil && *info.ExpiresAt <= info.CreatedAt {
+		e.add("expires_at", "must be after created_at")
+	}
+	if info.MinFillAmount > info.OfferAmount {
+		e.add("min_fill_amount", "must not exceed offer_amount")
+	}
+	if info.FilledAmount >= info.OfferAmount && info.FilledAmount > 0 {
+		e.add("filled_amount", "must be less than offer_amount")
+	}
+	for i, opt := range info.Settlement {
+		path := fmt.Sprintf("settlement[%d]", i)
+		if opt != swap.SettlementLightning {
+			e.add(path, "unknown settlement %q, expected %s", opt, swap.SettlementLightning)
+		} else if info.RequestChain != swap.LightningChain {
+			e.add(path, "lightning settles a %s request leg only", swap.LightningChain)
+		}
+	}
+	if info.FeeTerms != nil {
+		if err := info.FeeTerms.Validate(info.OfferAmount, info.RequestAmount); err != nil {
+			e.add("fee_terms", "%v", err)
+		}
+	}
+	if id := info.Identity; id != nil {
+		if !isHexLen(id.PubKey, 33) {
+			e.add("identity.pubkey", "must be a 33-byte compressed public key in hex")
+		}
+		if id.Signature != "" && !isHexLen(id.Signature, 64) {
+			e.add("identity.signature", "must be a 64-byte signature in hex")
+		}
+		for i, proof := range id.Addresses {
+			path := fmt.Sprintf("identity.addresses[%d]", i)
+			if !orderChainPattern.MatchString(proof.Chain) {
+				e.add(path+".chain", "must be an upper-case chain symbol, got %q", proof.Chain)
+			}
+			if proof.Address == "" {
+				e.add(path+".address", "is required")
+			}
+			if !isHexLen(proof.PubKey, 33) {
+				e.add(path+".pubkey", "must be a 33-byte compressed public key in hex")
+			}
+			if !isHexLen(proof.Signature, 64) {
+				e.add(path+".signature", "must be a 64-byte signature in hex")
+			}
+		}
+	}
+
+	if len(e.Errors) == 0 {
+		return nil
+	}
+	return e
+}
+
+func isHexLen(s string, n int) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == n
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// =============================================================================
+// Server
+// =============================================================================
+
+// SetOrderSchema sets how long unversioned order announcements are accepted.
+func (s *Server) SetOrderSchema(cfg node.OrderSchemaConfig) {
+	s.orderSchema = cfg
+}
+
+// decodeOrderAnnouncement decodes the order of an announcement.
+func (s *Server) decodeOrderAnnouncement(payload []byte) (*OrderInfo, bool, error) {
+	return decodeOrderInfo(payload, s.orderSchema.LegacyUntil, time.Now())
+}
+
+// OrderSchemaResult is the response for orders_schema.
+type OrderSchemaResult struct {
+	Version string                 `json:"version"`
+	Schema  map[string]interface{} `json:"schema"`
+
+	// LegacyAcceptedUntil is when unversioned announcements stop being
+	// accepted (Unix seconds; omitted when they are accepted indefinitely).
+	LegacyAcceptedUntil *int64 `json:"legacy_accepted_until,omitempty"`
+}
+
+// ordersSchema returns the JSON Schema of the order format.
+func (s *Server) ordersSchema(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	result := &OrderSchemaResult{Version: OrderSchemaVersion, Schema: OrderSchema()}
+	if !s.orderSchema.LegacyUntil.IsZero() {
+		until := s.orderSchema.LegacyUntil.Unix()
+		result.LegacyAcceptedUntil = &until
+	}
+	return result, nil
+}
+
+// OrdersValidateParams is the parameters for orders_validate.
+type OrdersValidateParams struct {
+	Order json.RawMessage `json:"order"`
+}
+
+// OrdersValidateResult is the response for orders_validate.
+type OrdersValidateResult struct {
+	Valid  bool              `json:"valid"`
+	Legacy bool              `json:"legacy,omitempty"` // Unversioned, deprecated format
+	Errors []OrderFieldError `json:"errors,omitempty"`
+}
+
+// ordersValidate checks an order as it would be checked on ingest and
+// reports every problem with its JSON path.
+func (s *Server) ordersValidate(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p OrdersValidateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if len(p.Order) == 0 {
+		return nil, fmt.Errorf("order is required")
+	}
+
+	_, legacy, err := s.decodeOrderAnnouncement(p.Order)
+	result := &OrdersValidateResult{Valid: err == nil, Legacy: legacy}
+	var verr *OrderValidationError
+	if errors.As(err, &verr) {
+		result.Errors = verr.Errors
+	}
+	return result, nil
+}
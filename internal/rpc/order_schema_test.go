@@ -0,0 +1,222 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func newSchemaOrderInfo() OrderInfo {
+	info := newPoWOrderInfo()
+	info.Schema = OrderSchemaVersion
+	return info
+}
+
+// schemaPaths collects the documented paths of a generated schema.
+func schemaPaths(t *testing.T, schema map[string]interface{}, path string, seen map[string]bool) {
+	t.Helper()
+	seen[path] = true
+	if _, ok := schema["description"]; !ok {
+		t.Errorf("schema of %q has no description", path)
+	}
+	if props, ok := schema["properties"].(map[string]interface{}); ok {
+		for name, prop := range props {
+			schemaPaths(t, prop.(map[string]interface{}), joinSchemaPath(path, name), seen)
+		}
+	}
+	if items, ok := schema["items"].(map[string]interface{}); ok {
+		schemaPaths(t, items, path+"[]", seen)
+	}
+}
+
+func TestOrderSchemaDocumented(t *testing.T) {
+	schema := OrderSchema()
+	seen := make(map[string]bool)
+	schemaPaths(t, schema, "", seen)
+	for path := range orderSchemaDocs {
+		if !seen[path] {
+			t.Errorf("orderSchemaDocs has %q, which is not an order field", path)
+		}
+	}
+
+	if schema["title"] != OrderSchemaVersion {
+		t.Errorf("title = %v, want %s", schema["title"], OrderSchemaVersion)
+	}
+	if _, err := json.Marshal(schema); err != nil {
+		t.Fatalf("schema does not marshal: %v", err)
+	}
+	required := schema["required"].([]string)
+	for _, name := range []string{"schema", "id", "peer_id", "offer_chain", "offer_amount", "request_chain", "request_amount", "created_at"} {
+		if !containsString(required, name) {
+			t.Errorf("%s is not required", name)
+		}
+	}
+	for _, name := range []string{"expires_at", "offer_token", "request_token", "fee_terms", "settlement", "identity", "pow"} {
+		if containsString(required, name) {
+			t.Errorf("optional %s is required", name)
+		}
+	}
+}
+
+func TestDecodeOrderInfo(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	info := newSchemaOrderInfo()
+	data, _ := json.Marshal(info)
+
+	got, legacy, err := decodeOrderInfo(data, time.Time{}, now)
+	if err != nil {
+		t.Fatalf("decodeOrderInfo() error = %v", err)
+	}
+	if legacy || got.ID != info.ID || got.OfferAmount != info.OfferAmount {
+		t.Errorf("decodeOrderInfo() = %+v, legacy %v", got, legacy)
+	}
+
+	tests := []struct {
+		name  string
+		edit  func(m map[string]interface{})
+		paths []string
+	}{
+		{"unknown field", func(m map[string]interface{}) { m["price"] = 1 }, []string{"price"}},
+		{"wrong type", func(m map[string]interface{}) { m["offer_amount"] = "100000" }, []string{"offer_amount"}},
+		{"negative amount", func(m map[string]interface{}) { m["request_amount"] = -5 }, []string{"request_amount"}},
+		{"unsupported version", func(m map[string]interface{}) { m["schema"] = "klingdex/order/v9" }, []string{"schema"}},
+		{"nested unknown field", func(m map[string]interface{}) {
+			m["pow"] = map[string]interface{}{"bits": 20, "nonce": 1, "hash": "00"}
+		}, []string{"hash"}},
+		{"invalid values", func(m map[string]interface{}) {
+			m["offer_amount"] = 0
+			m["request_chain"] = "BTC"
+			m["status"] = "matched"
+			m["preferred_methods"] = []string{"htlc", "teleport"}
+			m["expires_at"] = 1699999999
+		}, []string{"status", "request_chain", "offer_amount", "preferred_methods[1]", "expires_at"}},
+		{"invalid token", func(m map[string]interface{}) {
+			m["offer_token"] = "USDC"
+			m["request_token"] = "0x55d398326f99059fF775485246999027B31979"
+		}, []string{"offer_token", "request_token"}},
+		{"invalid settlement", func(m map[string]interface{}) {
+			m["settlement"] = []string{"lightning", "teleport"} // Request chain is LTC
+		}, []string{"settlement[0]", "settlement[1]"}},
+		{"invalid identity", func(m map[string]interface{}) {
+			m["identity"] = map[string]interface{}{
+				"pubkey":    "02zz",
+				"addresses": []map[string]interface{}{{"chain": "btc", "address": "", "pubkey": "", "signature": ""}},
+			}
+		}, []string{"identity.pubkey", "identity.addresses[0].chain", "identity.addresses[0].address", "identity.addresses[0].pubkey", "identity.addresses[0].signature"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var m map[string]interface{}
+			json.Unmarshal(data, &m)
+			tt.edit(m)
+			payload, _ := json.Marshal(m)
+
+			_, _, err := decodeOrderInfo(payload, time.Time{}, now)
+			verr, ok := err.(*OrderValidationError)
+			if !ok {
+				t.Fatalf("decodeOrderInfo() error = %v, want *OrderValidationError", err)
+			}
+			var paths []string
+			for _, fe := range verr.Errors {
+				paths = append(paths, fe.Path)
+			}
+			if strings.Join(paths, ",") != strings.Join(tt.paths, ",") {
+				t.Errorf("error paths = %v, want %v (%v)", paths, tt.paths, err)
+			}
+		})
+	}
+
+	if _, _, err := decodeOrderInfo([]byte(`"junk"`), time.Time{}, now); err == nil {
+		t.Error("decodeOrderInfo() accepted a non-object")
+	}
+}
+
+func TestDecodeOrderInfoLegacy(t *testing.T) {
+	now := time.Unix(1700000100, 0)
+	info := newPoWOrderInfo()
+	var m map[string]interface{}
+	data, _ := json.Marshal(info)
+	json.Unmarshal(data, &m)
+	delete(m, "schema")
+	m["extra"] = "ignored by the old decoding"
+	legacyData, _ := json.Marshal(m)
+
+	got, legacy, err := decodeOrderInfo(legacyData, now.Add(time.Hour), now)
+	if err != nil || !legacy || got.ID != info.ID {
+		t.Fatalf("decodeOrderInfo(legacy) = %+v, %v, %v; want accepted legacy order", got, legacy, err)
+	}
+	if _, _, err := decodeOrderInfo(legacyData, time.Time{}, now); err != nil {
+		t.Errorf("decodeOrderInfo(legacy) without a deprecation date error = %v", err)
+	}
+
+	// Values are still validated
+	m["offer_amount"] = 0
+	badData, _ := json.Marshal(m)
+	if _, _, err := decodeOrderInfo(badData, now.Add(time.Hour), now); err == nil {
+		t.Error("decodeOrderInfo(legacy) accepted a zero amount")
+	}
+
+	// Rejected after the deprecation window
+	_, legacy, err = decodeOrderInfo(legacyData, now.Add(-time.Hour), now)
+	verr, ok := err.(*OrderValidationError)
+	if !ok || !legacy || verr.Errors[0].Path != "schema" {
+		t.Errorf("decodeOrderInfo(legacy) after the window = %v, legacy %v; want a schema error", err, legacy)
+	}
+}
+
+func TestOrdersValidateRPC(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetOrderSchema(node.OrderSchemaConfig{LegacyUntil: time.Now().Add(-time.Hour)})
+	ctx := context.Background()
+
+	order, _ := json.Marshal(newSchemaOrderInfo())
+	params, _ := json.Marshal(OrdersValidateParams{Order: order})
+	result, err := s.ordersValidate(ctx, params)
+	if err != nil {
+		t.Fatalf("orders_validate error = %v", err)
+	}
+	if r := result.(*OrdersValidateResult); !r.Valid || len(r.Errors) != 0 {
+		t.Errorf("orders_validate = %+v, want valid", r)
+	}
+
+	legacy := newPoWOrderInfo()
+	legacy.RequestAmount = 0
+	order, _ = json.Marshal(legacy)
+	params, _ = json.Marshal(OrdersValidateParams{Order: order})
+	result, _ = s.ordersValidate(ctx, params)
+	if r := result.(*OrdersValidateResult); r.Valid || !r.Legacy || len(r.Errors) != 1 || r.Errors[0].Path != "schema" {
+		t.Errorf("orders_validate(legacy) = %+v, want the deprecated format rejected", r)
+	}
+
+	if _, err := s.ordersValidate(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("orders_validate without an order should fail")
+	}
+
+	schema, err := s.ordersSchema(ctx, nil)
+	if err != nil {
+		t.Fatalf("orders_schema error = %v", err)
+	}
+	if r := schema.(*OrderSchemaResult); r.Version != OrderSchemaVersion || r.Schema == nil || r.LegacyAcceptedUntil == nil {
+		t.Errorf("orders_schema = %+v", r)
+	}
+}
+
+func TestOrderAnnouncementVersioned(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetOrderSchema(node.OrderSchemaConfig{LegacyUntil: time.Now().Add(-time.Hour)})
+
+	// Announcements we publish are in the current format
+	info := newSchemaOrderInfo()
+	if err := s.validateOrderAnnounce(context.Background(), info.PeerID, orderAnnounce(t, info)); err != nil {
+		t.Errorf("validateOrderAnnounce() error = %v", err)
+	}
+
+	unversioned := newPoWOrderInfo()
+	if err := s.validateOrderAnnounce(context.Background(), info.PeerID, orderAnnounce(t, unversioned)); err == nil {
+		t.Error("validateOrderAnnounce() accepted the unversioned format after its deprecation date")
+	}
+}
@@ -0,0 +1,59 @@
+// Package rpc - Live order book for UI clients.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// OrderBookGetParams is the parameters for orderbook_get.
+type OrderBookGetParams struct {
+	Pair string `json:"pair"` // e.g. "BTC/LTC", in either order
+}
+
+// OrderBookGetResult is the result of orderbook_get.
+type OrderBookGetResult struct {
+	*orderbook.Snapshot
+	Pairs []string `json:"pairs"` // Pairs with open orders
+}
+
+// newOrderBook loads the order book and keeps it updated from order writes,
+// broadcasting its diffs to WebSocket subscribers.
+func (s *Server) newOrderBook(store *storage.Storage) *orderbook.Book {
+	book := orderbook.New(store, func(diff *orderbook.Diff) {
+		if s.wsHub != nil {
+			s.wsHub.Broadcast(EventOrderBookDiff, diff)
+		}
+	})
+	store.OnOrderChange(func(ids []string) {
+		if err := book.Refresh(ids); err != nil {
+			s.log.Warn("Failed to refresh order book", "error", err)
+		}
+	})
+	if err := book.Load(); err != nil {
+		s.log.Warn("Failed to load order book", "error", err)
+	}
+	return book
+}
+
+// orderbookGet returns the price-sorted order book of a pair.
+func (s *Server) orderbookGet(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p OrderBookGetParams
+	if params != nil {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if s.book == nil {
+		return nil, fmt.Errorf("order book not available")
+	}
+	result := &OrderBookGetResult{Pairs: s.book.Pairs()}
+	if p.Pair != "" {
+		result.Snapshot = s.book.Snapshot(p.Pair)
+	}
+	return result, nil
+}
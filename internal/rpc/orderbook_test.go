@@ -0,0 +1,132 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func createBookOrder(t *testing.T, s *Server, id, offer string, offerAmount uint64, request string, requestAmount uint64) {
+	t.Helper()
+	o := &storage.Order{
+		ID: id, PeerID: "12D3KooWMaker", Status: storage.OrderStatusOpen,
+		OfferChain: offer, OfferAmount: offerAmount, RequestChain: request, RequestAmount: requestAmount,
+		PreferredMethods: []string{"htlc"}, CreatedAt: time.Now(),
+	}
+	if err := s.store.CreateOrder(o); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+}
+
+// readBookEvent returns the next event queued for a client.
+func readBookEvent(t *testing.T, client *WSClient) (EventType, json.RawMessage) {
+	t.Helper()
+	select {
+	case data := <-client.send:
+		var ev struct {
+			Type EventType       `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		return ev.Type, ev.Data
+	case <-time.After(2 * time.Second):
+		t.Fatal("no event")
+	}
+	return "", nil
+}
+
+func TestOrderbookGet(t *testing.T) {
+	s := newTestStoreServer(t)
+	createBookOrder(t, s, "ask", "BTC", 1, "LTC", 100)
+	s.book = s.newOrderBook(s.store)
+	createBookOrder(t, s, "bid", "LTC", 90, "BTC", 1)
+
+	result, err := s.orderbookGet(context.Background(), json.RawMessage(`{"pair":"LTC/BTC"}`))
+	if err != nil {
+		t.Fatalf("orderbookGet() error = %v", err)
+	}
+	r := result.(*OrderBookGetResult)
+	if r.Pair != "BTC/LTC" || len(r.Asks) != 1 || r.Asks[0].ID != "ask" || len(r.Bids) != 1 || r.Bids[0].ID != "bid" {
+		t.Errorf("result = %+v", r.Snapshot)
+	}
+	if len(r.Pairs) != 1 || r.Pairs[0] != "BTC/LTC" {
+		t.Errorf("Pairs = %v", r.Pairs)
+	}
+
+	// Without a pair only the pairs are listed
+	result, err = s.orderbookGet(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("orderbookGet() error = %v", err)
+	}
+	if r := result.(*OrderBookGetResult); r.Snapshot != nil || len(r.Pairs) != 1 {
+		t.Errorf("result = %+v", r)
+	}
+}
+
+func TestOrderbookSubscribe(t *testing.T) {
+	s := newTestStoreServer(t)
+	createBookOrder(t, s, "o1", "BTC", 1, "LTC", 100)
+	s.book = s.newOrderBook(s.store)
+
+	s.wsHub = NewWSHub()
+	s.wsHub.SetConfig(node.DefaultWebSocketConfig())
+	s.wsHub.SetOrderBook(s.book.Snapshot)
+	go s.wsHub.Run()
+
+	subscriber := s.wsHub.newClient(nil, "subscriber", false)
+	other := s.wsHub.newClient(nil, "other", false) // All events, no order book
+	s.wsHub.register <- subscriber
+	s.wsHub.register <- other
+
+	subscriber.handleSubscription(&WSSubscription{Action: "orderbook_subscribe", Pair: "ltc/btc"})
+	typ, data := readBookEvent(t, subscriber)
+	var snap orderbook.Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil || typ != EventOrderBookSnapshot {
+		t.Fatalf("event %s %s", typ, data)
+	}
+	if snap.Pair != "BTC/LTC" || len(snap.Asks) != 1 || snap.Asks[0].ID != "o1" {
+		t.Errorf("snapshot = %+v", snap)
+	}
+
+	// Diffs follow the snapshot in sequence
+	createBookOrder(t, s, "o2", "LTC", 90, "BTC", 1)
+	createBookOrder(t, s, "o3", "ETH", 1, "BTC", 1) // Another pair
+	if err := s.store.UpdateOrderStatus("o1", storage.OrderStatusCancelled); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+
+	var diffs []orderbook.Diff
+	for len(diffs) < 2 {
+		typ, data := readBookEvent(t, subscriber)
+		var diff orderbook.Diff
+		if err := json.Unmarshal(data, &diff); err != nil || typ != EventOrderBookDiff {
+			t.Fatalf("event %s %s", typ, data)
+		}
+		diffs = append(diffs, diff)
+	}
+	if d := diffs[0]; d.Seq != snap.Seq+1 || d.Changes[0].Action != orderbook.ActionAdd || d.Changes[0].Entry.Side != orderbook.SideBid {
+		t.Errorf("first diff = %+v", d)
+	}
+	if d := diffs[1]; d.Seq != snap.Seq+2 || d.Changes[0].Action != orderbook.ActionRemove || d.Changes[0].ID != "o1" {
+		t.Errorf("second diff = %+v", d)
+	}
+	select {
+	case data := <-other.send:
+		t.Errorf("unsubscribed client got %s", data)
+	default:
+	}
+
+	subscriber.handleSubscription(&WSSubscription{Action: "orderbook_unsubscribe", Pair: "BTC/LTC"})
+	createBookOrder(t, s, "o4", "BTC", 1, "LTC", 100)
+	s.wsHub.Broadcast("order_created", map[string]int{"i": 1})
+	if typ, _ := readBookEvent(t, subscriber); typ != "order_created" {
+		t.Errorf("event after unsubscribe = %s, want order_created", typ)
+	}
+}
@@ -4,11 +4,16 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
 	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
 )
 
 // ========================================
@@ -23,10 +28,32 @@ type OrderCreateParams struct {
 	RequestAmount    uint64   `json:"request_amount"`    // In smallest unit
 	PreferredMethods []string `json:"preferred_methods"` // e.g., ["musig2", "htlc"]
 	ExpiresInHours   int      `json:"expires_in_hours"`  // Optional, default 24
+
+	// OfferToken and RequestToken make a leg an ERC-20 token on its EVM
+	// chain: a registered contract address or symbol (e.g. "USDC"). Amounts
+	// are then in the token's smallest unit. Omit for the native coin.
+	OfferToken   string `json:"offer_token,omitempty"`
+	RequestToken string `json:"request_token,omitempty"`
+
+	// FeeTerms optionally overrides who pays the DAO and claim mining fees
+	FeeTerms *swap.FeeTerms `json:"fee_terms,omitempty"`
+
+	// AllowOffMarket confirms a rate beyond the pair's price sanity bound
+	AllowOffMarket bool `json:"allow_off_market,omitempty"`
+
+	// Settlement advertises alternative settlement of the request leg:
+	// "lightning" accepts a BTC request leg over Lightning. Needs a
+	// configured Lightning node.
+	Settlement []string `json:"settlement,omitempty"`
+
+	// MinFillAmount lets takers fill the order in parts of at least this
+	// much of the offer (0 = the order can only be taken whole)
+	MinFillAmount uint64 `json:"min_fill_amount,omitempty"`
 }
 
 // OrderInfo represents order information in RPC responses.
 type OrderInfo struct {
+	Schema           string   `json:"schema"` // OrderSchemaVersion; empty in the deprecated unversioned format
 	ID               string   `json:"id"`
 	PeerID           string   `json:"peer_id"`
 	Status           string   `json:"status"`
@@ -38,10 +65,55 @@ type OrderInfo struct {
 	PreferredMethods []string `json:"preferred_methods"`
 	CreatedAt        int64    `json:"created_at"`
 	ExpiresAt        *int64   `json:"expires_at,omitempty"`
+
+	// ERC-20 contract addresses of the legs (omitted for native coins)
+	OfferToken   string `json:"offer_token,omitempty"`
+	RequestToken string `json:"request_token,omitempty"`
+
+	// Negotiated fee split (omitted when the protocol defaults apply)
+	FeeTerms *swap.FeeTerms `json:"fee_terms,omitempty"`
+
+	// Alternative settlement of the request leg (omitted: on chain only)
+	Settlement []string `json:"settlement,omitempty"`
+
+	// Partial fills: the smallest fill takers may take, the part taken so
+	// far and what remains (all omitted for orders taken whole only)
+	MinFillAmount   uint64 `json:"min_fill_amount,omitempty"`
+	FilledAmount    uint64 `json:"filled_amount,omitempty"`
+	RemainingAmount uint64 `json:"remaining_amount,omitempty"`
+
+	// Maker statistics from our local trade history (remote orders only)
+	MakerStats *MakerStatsInfo `json:"maker_stats,omitempty"`
+
+	// Measured latency and message loss to the maker (remote orders only)
+	MakerQuality *node.PeerQuality `json:"maker_quality,omitempty"`
+
+	// Rate check against the market price feed (omitted when disabled)
+	PriceCheck *pricefeed.Verdict `json:"price_check,omitempty"`
+
+	// Set when both legs represent the same asset, e.g. BTC and WBTC
+	SameUnderlying *SameUnderlying `json:"same_underlying,omitempty"`
+
+	// Maker wallet identity and receive address proofs (omitted when unsigned)
+	Identity *OrderIdentity `json:"identity,omitempty"`
+
+	// Anti-spam proof-of-work of the announcement (omitted when not mined)
+	PoW *OrderPoW `json:"pow,omitempty"`
+}
+
+// MakerStatsInfo summarizes our own experience trading with an order's maker.
+type MakerStatsInfo struct {
+	TotalTrades       int    `json:"total_trades"`
+	CompletedTrades   int    `json:"completed_trades"`
+	FailedTrades      int    `json:"failed_trades"`
+	ActiveTrades      int    `json:"active_trades"`
+	AvgSettlementSecs int64  `json:"avg_settlement_secs"`
+	FailureRateBps    uint32 `json:"failure_rate_bps"` // Basis points (100 = 1%)
 }
 
 func orderToInfo(o *storage.Order) OrderInfo {
 	info := OrderInfo{
+		Schema:           OrderSchemaVersion,
 		ID:               o.ID,
 		PeerID:           o.PeerID,
 		Status:           string(o.Status),
@@ -50,16 +122,91 @@ func orderToInfo(o *storage.Order) OrderInfo {
 		OfferAmount:      o.OfferAmount,
 		RequestChain:     o.RequestChain,
 		RequestAmount:    o.RequestAmount,
+		OfferToken:       o.OfferToken,
+		RequestToken:     o.RequestToken,
 		PreferredMethods: o.PreferredMethods,
+		Settlement:       o.Settlement,
 		CreatedAt:        o.CreatedAt.Unix(),
+		MinFillAmount:    o.MinFillAmount,
+		FilledAmount:     o.FilledAmount,
+	}
+	if o.MinFillAmount > 0 || o.FilledAmount > 0 {
+		info.RemainingAmount = o.Remaining() // Orders taken whole keep the format older peers decode
 	}
 	if o.ExpiresAt != nil {
 		ts := o.ExpiresAt.Unix()
 		info.ExpiresAt = &ts
 	}
+	if terms, err := swap.ParseFeeTerms([]byte(o.FeeTerms)); err == nil && !terms.IsDefault() {
+		info.FeeTerms = &terms
+	}
+	info.Identity = orderIdentity(o)
 	return info
 }
 
+// encodeFeeTerms validates fee terms against the order amounts and encodes them for storage.
+// Default (or nil) terms encode to an empty string.
+func encodeFeeTerms(terms *swap.FeeTerms, offerAmount, requestAmount uint64) (string, error) {
+	if terms == nil || terms.IsDefault() {
+		return "", nil
+	}
+	if err := terms.Validate(offerAmount, requestAmount); err != nil {
+		return "", fmt.Errorf("invalid fee_terms: %w", err)
+	}
+	data, err := json.Marshal(terms)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// checkOrderSettlement checks the settlement options of a new order: we
+// must be able to settle over Lightning to advertise it.
+func (s *Server) checkOrderSettlement(requestChain string, settlement []string) error {
+	for _, opt := range settlement {
+		if opt != swap.SettlementLightning {
+			return fmt.Errorf("unsupported settlement %q", opt)
+		}
+		if requestChain != swap.LightningChain {
+			return fmt.Errorf("lightning settlement needs request_chain %s", swap.LightningChain)
+		}
+		if s.coordinator == nil || !s.coordinator.LightningEnabled() {
+			return fmt.Errorf("lightning settlement needs lightning enabled in the config")
+		}
+	}
+	return nil
+}
+
+// orderToken resolves the token of an order leg on chainSymbol, given by
+// registered contract address or symbol, to its contract address. Empty is
+// the native coin.
+func (s *Server) orderToken(chainSymbol, token string) (string, error) {
+	if token == "" {
+		return "", nil
+	}
+	params, err := s.evmChain(chainSymbol)
+	if err != nil {
+		return "", err
+	}
+	_, info, err := resolveToken(params, token)
+	if err != nil {
+		return "", err
+	}
+	if info == nil {
+		return "", fmt.Errorf("token %s is not registered on %s", token, chainSymbol)
+	}
+	return info.Address, nil
+}
+
+// orderTokenInfo returns the registry entry of a token leg, or nil.
+func (s *Server) orderTokenInfo(chainSymbol, token string) *chain.TokenInfo {
+	params, err := s.evmChain(chainSymbol)
+	if err != nil {
+		return nil
+	}
+	return chain.GetTokenByAddress(params.ChainID, token)
+}
+
 func (s *Server) ordersCreate(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var p OrderCreateParams
 	if err := json.Unmarshal(params, &p); err != nil {
@@ -73,12 +220,43 @@ func (s *Server) ordersCreate(ctx context.Context, params json.RawMessage) (inte
 	if p.OfferAmount == 0 || p.RequestAmount == 0 {
 		return nil, fmt.Errorf("offer_amount and request_amount must be positive")
 	}
+	if p.MinFillAmount > p.OfferAmount {
+		return nil, fmt.Errorf("min_fill_amount must not exceed offer_amount")
+	}
+	if s.coordinator != nil && s.coordinator.IsColdMode() {
+		return nil, swap.ErrColdMode
+	}
 	if len(p.PreferredMethods) == 0 {
 		p.PreferredMethods = []string{"musig2"} // Default to MuSig2
 	}
 	if p.ExpiresInHours == 0 {
 		p.ExpiresInHours = 24 // Default 24 hours
 	}
+	offerToken, err := s.orderToken(p.OfferChain, p.OfferToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid offer_token: %w", err)
+	}
+	requestToken, err := s.orderToken(p.RequestChain, p.RequestToken)
+	if err != nil {
+		return nil, fmt.Errorf("invalid request_token: %w", err)
+	}
+	feeTerms, err := encodeFeeTerms(p.FeeTerms, p.OfferAmount, p.RequestAmount)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkOrderSettlement(p.RequestChain, p.Settlement); err != nil {
+		return nil, err
+	}
+	terms := &storage.Order{
+		OfferChain: p.OfferChain, OfferAmount: p.OfferAmount, OfferToken: offerToken,
+		RequestChain: p.RequestChain, RequestAmount: p.RequestAmount, RequestToken: requestToken,
+	}
+	if err := requireMarketPrice(s.priceCheck(terms), p.AllowOffMarket); err != nil {
+		return nil, err
+	}
+	if err := s.requireFavorableWrap(terms, true); err != nil {
+		return nil, err
+	}
 
 	// Generate order ID
 	orderID := uuid.New().String()
@@ -97,21 +275,28 @@ func (s *Server) ordersCreate(ctx context.Context, params json.RawMessage) (inte
 		OfferAmount:      p.OfferAmount,
 		RequestChain:     p.RequestChain,
 		RequestAmount:    p.RequestAmount,
+		OfferToken:       offerToken,
+		RequestToken:     requestToken,
 		PreferredMethods: p.PreferredMethods,
+		Settlement:       p.Settlement,
+		MinFillAmount:    p.MinFillAmount,
+		FeeTerms:         feeTerms,
 		CreatedAt:        now,
 		ExpiresAt:        &expiresAt,
 	}
 
+	// Sign with the wallet identity so takers can verify our addresses
+	if err := s.signOrder(order); err != nil {
+		s.log.Warn("Publishing unsigned order", "id", orderID, "error", err)
+	}
+
 	if err := s.store.CreateOrder(order); err != nil {
 		return nil, fmt.Errorf("failed to create order: %w", err)
 	}
 
 	// Broadcast order to network via PubSub (public announcement)
-	msg, err := node.NewOrderAnnounceMessage(orderID, orderToInfo(order))
-	if err == nil {
-		if err := s.broadcastToAll(ctx, msg); err != nil {
-			s.log.Warn("Failed to broadcast order", "id", orderID, "error", err)
-		}
+	if err := s.announceOrder(ctx, order); err != nil {
+		s.log.Warn("Failed to broadcast order", "id", orderID, "error", err)
 	}
 
 	s.log.Info("Order created",
@@ -122,7 +307,7 @@ func (s *Server) ordersCreate(ctx context.Context, params json.RawMessage) (inte
 
 	// Emit WebSocket event
 	if s.wsHub != nil {
-		s.wsHub.Broadcast("order_created", orderToInfo(order))
+		s.wsHub.Broadcast("order_created", orderToInfo(order), orderTopic(order))
 	}
 
 	return orderToInfo(order), nil
@@ -135,6 +320,14 @@ type OrdersListParams struct {
 	RequestChain string `json:"request_chain,omitempty"` // Filter by request chain
 	LocalOnly    bool   `json:"local_only,omitempty"`    // Only show our orders
 	Limit        int    `json:"limit,omitempty"`         // Max results
+
+	// IncludeOffMarket shows orders hidden by the price sanity "hide" action
+	IncludeOffMarket bool `json:"include_off_market,omitempty"`
+
+	// PreferLowLatency sorts remote orders by the maker's path quality
+	// (latency, message loss), best first, so the swap negotiation steps run
+	// over fast links. Unmeasured makers and our own orders come last.
+	PreferLowLatency bool `json:"prefer_low_latency,omitempty"`
 }
 
 // OrdersListResult is the response for orders_list.
@@ -143,6 +336,34 @@ type OrdersListResult struct {
 	Count  int         `json:"count"`
 }
 
+// makerStats returns local trade statistics for a remote order's maker.
+// The cache avoids recomputing stats for makers with several orders.
+func (s *Server) makerStats(o *storage.Order, cache map[string]*MakerStatsInfo) *MakerStatsInfo {
+	if o.IsLocal {
+		return nil
+	}
+	if info, ok := cache[o.PeerID]; ok {
+		return info
+	}
+
+	stats, err := s.store.GetPeerTradeStats(o.PeerID)
+	if err != nil {
+		s.log.Debug("Failed to get maker stats", "peer", o.PeerID, "error", err)
+		return nil
+	}
+
+	info := &MakerStatsInfo{
+		TotalTrades:       stats.TotalTrades,
+		CompletedTrades:   stats.CompletedTrades,
+		FailedTrades:      stats.FailedTrades,
+		ActiveTrades:      stats.ActiveTrades,
+		AvgSettlementSecs: stats.AvgSettlementSecs,
+		FailureRateBps:    stats.FailureRateBps,
+	}
+	cache[o.PeerID] = info
+	return info
+}
+
 func (s *Server) ordersList(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var p OrdersListParams
 	if params != nil {
@@ -175,8 +396,26 @@ func (s *Server) ordersList(ctx context.Context, params json.RawMessage) (interf
 	}
 
 	result := make([]OrderInfo, 0, len(orders))
+	statsCache := make(map[string]*MakerStatsInfo)
 	for _, o := range orders {
-		result = append(result, orderToInfo(o))
+		verdict := s.priceCheck(o)
+		if !p.IncludeOffMarket && s.hideOffMarket(o, verdict) {
+			continue
+		}
+		info := orderToInfo(o)
+		info.MakerStats = s.makerStats(o, statsCache)
+		info.PriceCheck = verdict
+		info.SameUnderlying = s.sameUnderlying(o)
+		if !o.IsLocal {
+			info.MakerQuality = s.peerQuality(o.PeerID)
+		}
+		result = append(result, info)
+	}
+
+	if p.PreferLowLatency {
+		sort.SliceStable(result, func(i, j int) bool {
+			return result[i].MakerQuality.Score() < result[j].MakerQuality.Score()
+		})
 	}
 
 	return &OrdersListResult{
@@ -205,7 +444,14 @@ func (s *Server) ordersGet(ctx context.Context, params json.RawMessage) (interfa
 		return nil, fmt.Errorf("order not found: %w", err)
 	}
 
-	return orderToInfo(order), nil
+	info := orderToInfo(order)
+	info.MakerStats = s.makerStats(order, make(map[string]*MakerStatsInfo))
+	info.PriceCheck = s.priceCheck(order)
+	info.SameUnderlying = s.sameUnderlying(order)
+	if !order.IsLocal {
+		info.MakerQuality = s.peerQuality(order.PeerID)
+	}
+	return info, nil
 }
 
 // OrdersCancelParams is the parameters for orders_cancel.
@@ -237,40 +483,88 @@ func (s *Server) ordersCancel(ctx context.Context, params json.RawMessage) (inte
 		return nil, fmt.Errorf("can only cancel open orders, current status: %s", order.Status)
 	}
 
-	if err := s.store.UpdateOrderStatus(p.ID, storage.OrderStatusCancelled); err != nil {
-		return nil, fmt.Errorf("failed to cancel order: %w", err)
+	if err := s.cancelLocalOrder(ctx, p.ID, ""); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"id":      p.ID,
+	}, nil
+}
+
+// announceOrder broadcasts one of our orders to the network via PubSub, with
+// the proof-of-work relaying peers require, and mirrors it to Nostr.
+func (s *Server) announceOrder(ctx context.Context, order *storage.Order) error {
+	announcement := orderToInfo(order)
+	if err := s.mineOrderPoW(ctx, &announcement); err != nil {
+		s.log.Warn("Failed to mine order proof-of-work", "id", order.ID, "error", err)
+	}
+	msg, err := node.NewOrderAnnounceMessage(order.ID, announcement)
+	if err != nil {
+		return err
+	}
+	s.mirrorOrder(order, msg.Payload)
+	return s.broadcastToAll(ctx, msg)
+}
+
+// cancelLocalOrder cancels one of our open orders and withdraws it from the
+// network. reason, if set, is logged and sent to WebSocket clients.
+func (s *Server) cancelLocalOrder(ctx context.Context, id, reason string) error {
+	if err := s.store.UpdateOrderStatus(id, storage.OrderStatusCancelled); err != nil {
+		return fmt.Errorf("failed to cancel order: %w", err)
 	}
 
 	// Broadcast cancellation to network via PubSub
 	cancelPayload := map[string]interface{}{
-		"order_id":  p.ID,
+		"order_id":  id,
 		"cancelled": true,
 	}
 	cancelMsg, err := node.NewSwapMessage(node.SwapMsgOrderCancel, "", cancelPayload)
 	if err == nil {
-		cancelMsg.OrderID = p.ID
+		cancelMsg.OrderID = id
 		if err := s.broadcastToAll(ctx, cancelMsg); err != nil {
-			s.log.Warn("Failed to broadcast order cancellation", "id", p.ID, "error", err)
+			s.log.Warn("Failed to broadcast order cancellation", "id", id, "error", err)
+		}
+	}
+	if s.nostr != nil {
+		if err := s.nostr.Withdraw(id); err != nil {
+			s.log.Warn("Failed to withdraw order from nostr", "id", id, "error", err)
 		}
 	}
 
-	s.log.Info("Order cancelled", "id", p.ID)
+	s.log.Info("Order cancelled", "id", id, "reason", reason)
 
 	// Emit WebSocket event
 	if s.wsHub != nil {
-		s.wsHub.Broadcast("order_cancelled", map[string]string{"id": p.ID})
+		event := map[string]string{"id": id}
+		if reason != "" {
+			event["reason"] = reason
+		}
+		var topics []string
+		if order, err := s.store.GetOrder(id); err == nil && order != nil {
+			event["pair"], _ = orderbook.PairOf(order)
+			topics = append(topics, PairTopic(event["pair"]))
+		}
+		s.wsHub.Broadcast("order_cancelled", event, topics...)
 	}
-
-	return map[string]interface{}{
-		"success": true,
-		"id":      p.ID,
-	}, nil
+	return nil
 }
 
 // OrdersTakeParams is the parameters for orders_take.
 type OrdersTakeParams struct {
 	OrderID         string `json:"order_id"`
 	PreferredMethod string `json:"preferred_method,omitempty"` // Override method if supported
+
+	// Amount is the part of the order's offer to take (0 = all that remains)
+	Amount uint64 `json:"amount,omitempty"`
+
+	// AllowOffMarket confirms taking an order beyond the pair's price sanity bound
+	AllowOffMarket bool `json:"allow_off_market,omitempty"`
+
+	// Aggregate collects the trade with others taken against the same maker
+	// during the aggregation window, proposed as one swap
+	Aggregate bool `json:"aggregate,omitempty"`
 }
 
 // OrdersTakeResult is the response for orders_take.
@@ -280,6 +574,11 @@ type OrdersTakeResult struct {
 	Method     string `json:"method"`
 	Status     string `json:"status"`
 	NextAction string `json:"next_action"`
+	Batched    bool   `json:"batched,omitempty"` // Queued for aggregation
+
+	// Amounts of the fill taken
+	OfferAmount   uint64 `json:"offer_amount"`
+	RequestAmount uint64 `json:"request_amount"`
 }
 
 func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -297,6 +596,16 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 		return nil, fmt.Errorf("wallet must be unlocked to take orders")
 	}
 
+	if s.coordinator != nil && s.coordinator.IsDegraded() {
+		return nil, swap.ErrDegradedMode
+	}
+	if s.coordinator != nil && s.coordinator.IsClockSkewed() {
+		return nil, swap.ErrClockSkewed
+	}
+	if s.coordinator != nil && s.coordinator.IsColdMode() {
+		return nil, swap.ErrColdMode
+	}
+
 	// Get order
 	order, err := s.store.GetOrder(p.OrderID)
 	if err != nil {
@@ -306,10 +615,43 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 	if order.Status != storage.OrderStatusOpen {
 		return nil, fmt.Errorf("order is not open, status: %s", order.Status)
 	}
+	amount := p.Amount
+	if amount == 0 {
+		amount = order.Remaining()
+	}
+	if err := order.CheckFill(amount); err != nil {
+		return nil, fmt.Errorf("cannot take order: %w", err)
+	}
+	requestAmount, err := s.fillRequestAmount(order, amount)
+	if err != nil {
+		return nil, fmt.Errorf("cannot take order: %w", err)
+	}
+	fill := fillOrder(order, amount, requestAmount)
+	if s.coordinator != nil {
+		for _, symbol := range []string{order.OfferChain, order.RequestChain} {
+			if s.coordinator.IsContractPaused(symbol) {
+				return nil, fmt.Errorf("%s: %w", symbol, swap.ErrContractPaused)
+			}
+		}
+	}
 
 	if order.IsLocal {
 		return nil, fmt.Errorf("cannot take your own order")
 	}
+	if err := s.checkMakerProtocol(order); err != nil {
+		return nil, fmt.Errorf("cannot take order: %w", err)
+	}
+
+	if err := requireMarketPrice(s.priceCheck(order), p.AllowOffMarket); err != nil {
+		return nil, err
+	}
+	if err := s.requireFavorableWrap(order, false); err != nil {
+		return nil, fmt.Errorf("cannot take order: %w", err)
+	}
+
+	if _, err := s.checkOrderIdentity(order); err != nil {
+		return nil, fmt.Errorf("cannot take order: %w", err)
+	}
 
 	// Determine method to use
 	method := p.PreferredMethod
@@ -320,29 +662,36 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 		method = "musig2"
 	}
 
+	// Refuse orders we couldn't fund alongside our pending swaps
+	if s.coordinator != nil {
+		offer, _, err := tradeOffer(&storage.Trade{Method: method}, fill)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.coordinator.CheckAffordable(ctx, offer, false); err != nil {
+			return nil, fmt.Errorf("cannot take order: %w", err)
+		}
+	}
+
 	// Generate trade ID
 	tradeID := uuid.New().String()
 
 	// Create trade record
 	trade := &storage.Trade{
-		ID:          tradeID,
-		OrderID:     order.ID,
-		MakerPeerID: order.PeerID,
-		TakerPeerID: s.node.ID().String(),
-		Method:      method,
-		State:       storage.TradeStateInit,
-		OfferAmount:   order.OfferAmount,
-		RequestAmount: order.RequestAmount,
-		CreatedAt:   time.Now(),
-	}
-
-	if err := s.store.CreateTrade(trade); err != nil {
-		return nil, fmt.Errorf("failed to create trade: %w", err)
+		ID:            tradeID,
+		OrderID:       order.ID,
+		MakerPeerID:   order.PeerID,
+		TakerPeerID:   s.node.ID().String(),
+		Method:        method,
+		State:         storage.TradeStateInit,
+		OfferAmount:   amount,
+		RequestAmount: requestAmount,
+		CreatedAt:     time.Now(),
 	}
 
-	// Update order status
-	if err := s.store.UpdateOrderStatus(order.ID, storage.OrderStatusMatched); err != nil {
-		return nil, fmt.Errorf("failed to update order status: %w", err)
+	// Take our fill of the order; the maker's order_update confirms it
+	if _, err := s.takeOrderFill(order, trade); err != nil {
+		return nil, err
 	}
 
 	// Broadcast order take message to network via PubSub (public announcement)
@@ -352,8 +701,8 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 		"order_id":       order.ID,
 		"taker_peer_id":  s.node.ID().String(),
 		"method":         method,
-		"offer_amount":   order.OfferAmount,
-		"request_amount": order.RequestAmount,
+		"offer_amount":   amount,
+		"request_amount": requestAmount,
 	}
 	takeMsg, err := node.NewOrderTakeMessage(order.ID, tradeID, takePayload)
 	if err == nil {
@@ -366,6 +715,7 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 		"trade_id", tradeID,
 		"order_id", order.ID,
 		"method", method,
+		"amount", amount,
 	)
 
 	// Emit WebSocket event
@@ -374,14 +724,23 @@ func (s *Server) ordersTake(ctx context.Context, params json.RawMessage) (interf
 			"trade_id": tradeID,
 			"order_id": order.ID,
 			"method":   method,
-		})
+		}, SwapTopic(tradeID))
 	}
 
-	return &OrdersTakeResult{
+	result := &OrdersTakeResult{
 		TradeID:    tradeID,
 		OrderID:    order.ID,
 		Method:     method,
 		Status:     string(storage.TradeStateInit),
 		NextAction: "waiting_for_maker_response",
-	}, nil
+
+		OfferAmount:   amount,
+		RequestAmount: requestAmount,
+	}
+	if p.Aggregate && s.aggregation.Window > 0 {
+		s.queueAggregate(trade, order)
+		result.Batched = true
+		result.NextAction = "waiting_for_aggregation"
+	}
+	return result, nil
 }
@@ -0,0 +1,189 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func newTestStoreServer(t testing.TB) *Server {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	return &Server{
+		store:    store,
+		log:      logging.GetDefault().Component("rpc-test"),
+		handlers: make(map[string]Handler),
+	}
+}
+
+func TestOrdersListMakerStats(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	orders := []*storage.Order{
+		{ID: "remote-1", PeerID: "makerA", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now()},
+		{ID: "local-1", PeerID: "us", Status: storage.OrderStatusOpen, IsLocal: true, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now()},
+	}
+	for _, o := range orders {
+		if err := s.store.CreateOrder(o); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+
+	for i, final := range []storage.TradeState{storage.TradeStateRedeemed, storage.TradeStateFailed} {
+		trade := &storage.Trade{
+			ID: string(rune('a' + i)), OrderID: "old-order", MakerPeerID: "makerA", TakerPeerID: "us",
+			OurRole: storage.TradeRoleTaker, Method: "htlc", State: storage.TradeStateInit,
+			OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+			CreatedAt: time.Now(),
+		}
+		if err := s.store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+		if err := s.store.UpdateTradeState(trade.ID, final); err != nil {
+			t.Fatalf("UpdateTradeState() error = %v", err)
+		}
+	}
+
+	res, err := s.ordersList(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("ordersList() error = %v", err)
+	}
+	list := res.(*OrdersListResult)
+
+	for _, o := range list.Orders {
+		switch o.ID {
+		case "remote-1":
+			if o.MakerStats == nil {
+				t.Fatal("remote order should include maker stats")
+			}
+			if o.MakerStats.CompletedTrades != 1 || o.MakerStats.FailedTrades != 1 {
+				t.Errorf("MakerStats = %+v, want 1 completed and 1 failed", o.MakerStats)
+			}
+			if o.MakerStats.FailureRateBps != 5000 {
+				t.Errorf("FailureRateBps = %d, want 5000", o.MakerStats.FailureRateBps)
+			}
+		case "local-1":
+			if o.MakerStats != nil {
+				t.Error("local order should not include maker stats")
+			}
+		}
+	}
+
+	// orders_get includes stats as well
+	got, err := s.ordersGet(context.Background(), json.RawMessage(`{"id":"remote-1"}`))
+	if err != nil {
+		t.Fatalf("ordersGet() error = %v", err)
+	}
+	if got.(OrderInfo).MakerStats == nil {
+		t.Error("orders_get should include maker stats for remote orders")
+	}
+}
+
+func TestEncodeFeeTerms(t *testing.T) {
+	encoded, err := encodeFeeTerms(nil, 1000, 5000)
+	if err != nil || encoded != "" {
+		t.Errorf("encodeFeeTerms(nil) = %q, %v", encoded, err)
+	}
+
+	terms := &swap.FeeTerms{DAOFeePayer: swap.FeePayerMaker}
+	encoded, err = encodeFeeTerms(terms, 1000, 5000)
+	if err != nil {
+		t.Fatalf("encodeFeeTerms() error = %v", err)
+	}
+
+	info := orderToInfo(&storage.Order{ID: "o1", FeeTerms: encoded, CreatedAt: time.Now()})
+	if info.FeeTerms == nil || info.FeeTerms.DAOFeePayer != swap.FeePayerMaker {
+		t.Errorf("OrderInfo.FeeTerms = %+v, want maker DAO payer", info.FeeTerms)
+	}
+	if orderToInfo(&storage.Order{ID: "o2", CreatedAt: time.Now()}).FeeTerms != nil {
+		t.Error("default terms should be omitted from OrderInfo")
+	}
+
+	if _, err := encodeFeeTerms(&swap.FeeTerms{ClaimFeePayer: swap.FeePayerTaker}, 1000, 5000); err == nil {
+		t.Error("expected error for claim payer without allowance")
+	}
+}
+
+func TestOrdersPriceSanity(t *testing.T) {
+	s := newTestStoreServer(t)
+	checker, err := pricefeed.New(node.PriceSanityConfig{
+		Action:       node.PriceActionHide,
+		StaticPrices: map[string]string{"BTC": "60000", "LTC": "60"},
+	})
+	if err != nil {
+		t.Fatalf("pricefeed.New() error = %v", err)
+	}
+	s.SetPriceChecker(checker)
+
+	// Creating a fat-finger order needs confirmation
+	_, err = s.ordersCreate(context.Background(), json.RawMessage(
+		`{"offer_chain":"BTC","offer_amount":100000000,"request_chain":"LTC","request_amount":10000000000}`))
+	if err == nil {
+		t.Fatal("ordersCreate() should refuse an off-market rate")
+	}
+
+	orders := []*storage.Order{
+		{ID: "fair", PeerID: "makerA", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 100000000000, CreatedAt: time.Now()},
+		{ID: "grief", PeerID: "makerB", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 100000000000, CreatedAt: time.Now()},
+	}
+	for _, o := range orders {
+		if err := s.store.CreateOrder(o); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+
+	res, err := s.ordersList(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("ordersList() error = %v", err)
+	}
+	list := res.(*OrdersListResult)
+	if list.Count != 1 || list.Orders[0].ID != "fair" {
+		t.Fatalf("orders = %+v, want only the fair order", list.Orders)
+	}
+	if list.Orders[0].PriceCheck == nil || list.Orders[0].PriceCheck.Status != pricefeed.StatusOK {
+		t.Errorf("PriceCheck = %+v, want ok", list.Orders[0].PriceCheck)
+	}
+
+	res, err = s.ordersList(context.Background(), json.RawMessage(`{"include_off_market":true}`))
+	if err != nil {
+		t.Fatalf("ordersList() error = %v", err)
+	}
+	if list := res.(*OrdersListResult); list.Count != 2 {
+		t.Errorf("include_off_market: count = %d, want 2", list.Count)
+	}
+
+	res, err = s.pricesList(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("pricesList() error = %v", err)
+	}
+	prices := res.(*PricesListResult)
+	if !prices.Enabled || len(prices.Prices) != 2 || prices.Prices[0].USD != "60000" {
+		t.Errorf("prices = %+v, want BTC 60000 and LTC 60", prices)
+	}
+}
+
+func TestOrdersCreateColdMode(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+	s.coordinator.SetColdMode(true)
+
+	_, err := s.ordersCreate(context.Background(), json.RawMessage(
+		`{"offer_chain":"BTC","offer_amount":100000,"request_chain":"LTC","request_amount":5000000}`))
+	if !errors.Is(err, swap.ErrColdMode) {
+		t.Errorf("ordersCreate() error = %v, want ErrColdMode", err)
+	}
+}
@@ -0,0 +1,45 @@
+// Package rpc - Peer connectivity diagnostics handler.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	klsync "github.com/Klingon-tech/klingdex/internal/sync"
+)
+
+// diagnoseProtocols are the protocols a peer must serve for us to trade.
+var diagnoseProtocols = []protocol.ID{
+	node.SwapDirectProtocol,
+	protocol.ID(klsync.OrderSyncProtocol),
+}
+
+// PeerDiagnoseParams is the parameters for peer_diagnose.
+type PeerDiagnoseParams struct {
+	Target string `json:"target"` // Peer ID or multiaddr with /p2p/<id>
+}
+
+// peerDiagnose dials a peer and reports on each step trading with it needs.
+func (s *Server) peerDiagnose(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p PeerDiagnoseParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+	if s.node == nil {
+		return nil, fmt.Errorf("node not started")
+	}
+
+	d, err := s.node.Diagnose(ctx, p.Target, diagnoseProtocols)
+	if err != nil {
+		return nil, fmt.Errorf("invalid target: %w", err)
+	}
+	s.log.Info("Peer diagnosed", "peer", short(d.PeerID, 16), "connected", d.Connected, "problems", len(d.Problems))
+	return d, nil
+}
@@ -0,0 +1,21 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+)
+
+func TestPeerDiagnoseParams(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	for _, params := range []string{`{}`, `{"target":""}`, `[`} {
+		if _, err := s.peerDiagnose(context.Background(), json.RawMessage(params)); err == nil {
+			t.Errorf("peerDiagnose(%s) should fail", params)
+		}
+	}
+	// A valid target still needs a running node
+	if _, err := s.peerDiagnose(context.Background(), json.RawMessage(`{"target":"12D3KooWEyoppNCUx8Yx66oV9fJnriXwCcXwDDUA2kj6vnc6iDEp"}`)); err == nil {
+		t.Error("peerDiagnose() without a node should fail")
+	}
+}
@@ -0,0 +1,28 @@
+// Package rpc - Quote precision of trade legs.
+package rpc
+
+import (
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SetPrecision sets the configured quote precisions.
+func (s *Server) SetPrecision(cfg node.PrecisionConfig) {
+	s.precision = cfg
+}
+
+// legPrecision returns our quote precision of a trade leg.
+func (s *Server) legPrecision(symbol, token string) (swap.Precision, error) {
+	network := chain.Mainnet
+	switch {
+	case s.coordinator != nil:
+		network = s.coordinator.Network()
+	case s.wallet != nil:
+		network = s.wallet.Network()
+	}
+	symbol = strings.ToUpper(symbol)
+	return swap.AssetPrecision(symbol, token, network, s.precision.QuoteDecimals[symbol])
+}
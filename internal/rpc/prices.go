@@ -0,0 +1,96 @@
+// Package rpc - Market price and order price sanity handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// SetPriceChecker enables price sanity checks on orders.
+func (s *Server) SetPriceChecker(c *pricefeed.Checker) {
+	s.prices = c
+}
+
+// priceCheck checks an order's rate against the market (nil when disabled).
+// Token legs are priced as their token, in the token's decimals.
+func (s *Server) priceCheck(o *storage.Order) *pricefeed.Verdict {
+	if s.prices == nil {
+		return nil
+	}
+	offer, ok := s.priceLeg(o.OfferChain, o.OfferToken, o.OfferAmount)
+	if !ok {
+		return &pricefeed.Verdict{Status: pricefeed.StatusUnknown, MaxDeviationBps: s.prices.MaxDeviation(o.OfferChain, o.RequestChain)}
+	}
+	request, ok := s.priceLeg(o.RequestChain, o.RequestToken, o.RequestAmount)
+	if !ok {
+		return &pricefeed.Verdict{Status: pricefeed.StatusUnknown, MaxDeviationBps: s.prices.MaxDeviation(o.OfferChain, o.RequestChain)}
+	}
+	return s.prices.CheckLegs(offer, request)
+}
+
+// priceLeg returns the price check leg of an order side; token is the
+// side's ERC-20 contract address, empty for the native coin.
+func (s *Server) priceLeg(chainSymbol, token string, amount uint64) (pricefeed.Leg, bool) {
+	if token == "" {
+		return pricefeed.ChainLeg(chainSymbol, amount)
+	}
+	info := s.orderTokenInfo(chainSymbol, token)
+	if info == nil {
+		return pricefeed.Leg{}, false
+	}
+	return pricefeed.Leg{Asset: info.Symbol, Decimals: info.Decimals, Amount: amount}, true
+}
+
+// hideOffMarket returns true if a listing should leave the order out.
+// Our own orders are always shown.
+func (s *Server) hideOffMarket(o *storage.Order, verdict *pricefeed.Verdict) bool {
+	return s.prices != nil && s.prices.Action() == node.PriceActionHide && !o.IsLocal && verdict.OffMarket()
+}
+
+// requireMarketPrice refuses an off-market order unless the caller confirmed it.
+func requireMarketPrice(verdict *pricefeed.Verdict, allowOffMarket bool) error {
+	if !verdict.OffMarket() || allowOffMarket {
+		return nil
+	}
+	return fmt.Errorf("order rate deviates %d bps from market (max %d); set allow_off_market to confirm",
+		verdict.DeviationBps, verdict.MaxDeviationBps)
+}
+
+// PriceInfo is a market price in RPC responses.
+type PriceInfo struct {
+	Chain     string `json:"chain"`
+	USD       string `json:"usd"` // Decimal USD per whole coin
+	Static    bool   `json:"static"`
+	UpdatedAt int64  `json:"updated_at,omitempty"`
+}
+
+// PricesListResult is the response for prices_list.
+type PricesListResult struct {
+	Enabled bool        `json:"enabled"`
+	Action  string      `json:"action,omitempty"`
+	Prices  []PriceInfo `json:"prices"`
+}
+
+// pricesList returns the market prices used for order sanity checks.
+func (s *Server) pricesList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	result := &PricesListResult{Prices: []PriceInfo{}}
+	if s.prices == nil {
+		return result, nil
+	}
+
+	result.Enabled = true
+	result.Action = s.prices.Action()
+	for _, p := range s.prices.Prices() {
+		info := PriceInfo{Chain: p.Chain, USD: pricefeed.FormatUSD(p.USD), Static: p.Static}
+		if !p.UpdatedAt.IsZero() {
+			info.UpdatedAt = p.UpdatedAt.Unix()
+		}
+		result.Prices = append(result.Prices, info)
+	}
+	return result, nil
+}
@@ -0,0 +1,203 @@
+// Package rpc - Address reuse warnings, enforcement and the privacy report.
+//
+// Paying an address twice links both payments on-chain. Wallet sends and
+// swap payout addresses are checked against the earlier sends, payouts and
+// receives the node knows of: in warn mode the result carries warnings and
+// an address_reuse event is broadcast, in enforce mode the request is
+// refused unless it sets allow_address_reuse.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// EventAddressReuse is broadcast when a send or payout reuses an address.
+const EventAddressReuse EventType = "address_reuse"
+
+// ErrAddressReuse is returned in enforce mode for an address already used.
+var ErrAddressReuse = errors.New("address already used")
+
+// AddressReuseEvent is the data of an address_reuse event.
+type AddressReuseEvent struct {
+	Chain    string                `json:"chain"`
+	Address  string                `json:"address"`
+	Use      string                `json:"use"` // "send" or "payout"
+	Previous []*storage.AddressUse `json:"previous"`
+}
+
+// WalletPrivacyReportParams is the parameters for wallet_privacyReport.
+type WalletPrivacyReportParams struct {
+	Symbol string `json:"symbol,omitempty"` // Empty for all chains
+}
+
+// LinkedAddress is an address used in more than one way, linking e.g. a
+// wallet send with a swap payout.
+type LinkedAddress struct {
+	Chain   string   `json:"chain"`
+	Address string   `json:"address"`
+	Kinds   []string `json:"kinds"`
+}
+
+// WalletPrivacyReportResult is the response for wallet_privacyReport.
+type WalletPrivacyReportResult struct {
+	Mode     string                `json:"mode"`     // warn or enforce
+	Reused   []*storage.AddressUse `json:"reused"`   // Used more than once in the same way
+	Linked   []*LinkedAddress      `json:"linked"`   // Used in more than one way
+	Counts   map[string]int        `json:"counts"`   // Reused addresses by kind
+	Warnings []string              `json:"warnings"` // One line per finding
+}
+
+// SetPrivacy sets the address reuse mode.
+func (s *Server) SetPrivacy(cfg node.PrivacyConfig) {
+	s.privacy = cfg
+}
+
+// privacyMode returns the address reuse mode.
+func (s *Server) privacyMode() string {
+	if s.privacy.AddressReuse == "" {
+		return node.AddressReuseWarn
+	}
+	return s.privacy.AddressReuse
+}
+
+// describeAddressUse says how an address was used, for warnings.
+func describeAddressUse(u *storage.AddressUse) string {
+	switch u.Kind {
+	case storage.AddressUseSend:
+		return fmt.Sprintf("%s address %s was paid by %d wallet send(s)", u.Chain, u.Address, u.Count)
+	case storage.AddressUsePayout:
+		return fmt.Sprintf("%s address %s was the payout address of %d swap(s)", u.Chain, u.Address, u.Count)
+	default:
+		return fmt.Sprintf("%s address %s of our wallet received %d transaction(s)", u.Chain, u.Address, u.Count)
+	}
+}
+
+// checkAddressReuse looks up the earlier uses of an address about to be
+// paid (use: "send" or "payout"). Reuse is refused in enforce mode unless
+// allowed; otherwise it is returned as warnings and broadcast.
+func (s *Server) checkAddressReuse(chain, address, use string, allow bool) ([]string, error) {
+	if s.store == nil {
+		return nil, nil
+	}
+	previous, err := s.store.AddressUses(chain, address)
+	if err != nil {
+		s.log.Warn("Failed to check address reuse", "chain", chain, "error", err)
+		return nil, nil
+	}
+	if len(previous) == 0 {
+		return nil, nil
+	}
+
+	warnings := make([]string, len(previous))
+	for i, u := range previous {
+		warnings[i] = describeAddressUse(u)
+	}
+	if s.privacy.Enforce() && !allow {
+		return nil, fmt.Errorf("%w: %s (set allow_address_reuse to proceed)", ErrAddressReuse, warnings[0])
+	}
+
+	s.log.Warn("Address reuse", "chain", chain, "address", address, "use", use)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(EventAddressReuse, &AddressReuseEvent{
+			Chain:    chain,
+			Address:  address,
+			Use:      use,
+			Previous: previous,
+		})
+	}
+	return warnings, nil
+}
+
+// reuseAddress returns the form of an EVM address that reuse is checked
+// and recorded on, so a payee given in another case still matches.
+func reuseAddress(address string) string {
+	if common.IsHexAddress(address) {
+		return common.HexToAddress(address).Hex()
+	}
+	return address
+}
+
+// recordSend adds a wallet send to the history reuse is checked against.
+func (s *Server) recordSend(chain, txid, to string, amount, fee uint64, inputs int) {
+	if s.store == nil {
+		return
+	}
+	if err := s.store.RecordWalletTransaction(&storage.WalletTransaction{
+		Chain:   chain,
+		Kind:    storage.WalletTxSend,
+		TxID:    txid,
+		Inputs:  inputs,
+		Amount:  amount,
+		Fee:     fee,
+		Address: to,
+	}); err != nil {
+		s.log.Warn("Failed to record wallet send", "txid", txid, "error", err)
+	}
+}
+
+// walletPrivacyReport summarizes the address reuse and linkage in the
+// wallet's history.
+func (s *Server) walletPrivacyReport(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	var p WalletPrivacyReportParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	uses, err := s.store.AddressUses(p.Symbol, "")
+	if err != nil {
+		return nil, err
+	}
+
+	result := &WalletPrivacyReportResult{
+		Mode:     s.privacyMode(),
+		Reused:   []*storage.AddressUse{},
+		Linked:   []*LinkedAddress{},
+		Counts:   make(map[string]int),
+		Warnings: []string{},
+	}
+	linked := make(map[string]*LinkedAddress)
+	for _, u := range uses {
+		if u.Count > 1 {
+			result.Reused = append(result.Reused, u)
+			result.Counts[u.Kind]++
+			result.Warnings = append(result.Warnings, describeAddressUse(u))
+		}
+		key := u.Chain + ":" + u.Address
+		if linked[key] == nil {
+			linked[key] = &LinkedAddress{Chain: u.Chain, Address: u.Address}
+		}
+		linked[key].Kinds = append(linked[key].Kinds, u.Kind)
+	}
+	for _, l := range linked {
+		if len(l.Kinds) > 1 {
+			sort.Strings(l.Kinds)
+			result.Linked = append(result.Linked, l)
+		}
+	}
+	sort.Slice(result.Linked, func(i, j int) bool {
+		if result.Linked[i].Chain != result.Linked[j].Chain {
+			return result.Linked[i].Chain < result.Linked[j].Chain
+		}
+		return result.Linked[i].Address < result.Linked[j].Address
+	})
+	for _, l := range result.Linked {
+		result.Warnings = append(result.Warnings, fmt.Sprintf("%s address %s is linked across uses: %s",
+			l.Chain, l.Address, strings.Join(l.Kinds, ", ")))
+	}
+	return result, nil
+}
@@ -0,0 +1,109 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestCheckAddressReuse(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.wsHub = NewWSHub()
+	s.wsHub.SetConfig(node.DefaultWebSocketConfig())
+	go s.wsHub.Run()
+	client := s.wsHub.newClient(nil, "client", false)
+	s.wsHub.register <- client
+
+	// A fresh address is fine and recorded once sent to
+	if warnings, err := s.checkAddressReuse("BTC", "bc1qshop", storage.AddressUseSend, false); err != nil || len(warnings) != 0 {
+		t.Fatalf("checkAddressReuse(fresh) = %v, %v", warnings, err)
+	}
+	s.recordSend("BTC", "tx1", "bc1qshop", 1000, 200, 1)
+
+	// Warn mode: warnings and an event
+	warnings, err := s.checkAddressReuse("BTC", "bc1qshop", storage.AddressUseSend, false)
+	if err != nil || len(warnings) != 1 {
+		t.Fatalf("checkAddressReuse(reused) = %v, %v; want one warning", warnings, err)
+	}
+	typ, data := readBookEvent(t, client)
+	var ev AddressReuseEvent
+	if err := json.Unmarshal(data, &ev); err != nil || typ != EventAddressReuse {
+		t.Fatalf("event %s %s", typ, data)
+	}
+	if ev.Address != "bc1qshop" || ev.Use != storage.AddressUseSend || len(ev.Previous) != 1 || ev.Previous[0].Refs[0] != "tx1" {
+		t.Errorf("event = %+v", ev)
+	}
+
+	// Other chains don't count
+	if warnings, _ := s.checkAddressReuse("LTC", "bc1qshop", storage.AddressUseSend, false); len(warnings) != 0 {
+		t.Errorf("checkAddressReuse(LTC) = %v", warnings)
+	}
+
+	// Enforce mode: refused unless allowed
+	s.SetPrivacy(node.PrivacyConfig{AddressReuse: node.AddressReuseEnforce})
+	if _, err := s.checkAddressReuse("BTC", "bc1qshop", storage.AddressUseSend, false); !errors.Is(err, ErrAddressReuse) {
+		t.Errorf("enforce error = %v, want ErrAddressReuse", err)
+	}
+	if warnings, err := s.checkAddressReuse("BTC", "bc1qshop", storage.AddressUseSend, true); err != nil || len(warnings) != 1 {
+		t.Errorf("allowed reuse = %v, %v", warnings, err)
+	}
+}
+
+func TestReuseAddress(t *testing.T) {
+	lower := "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"
+	checksummed := "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed"
+	if got := reuseAddress(lower); got != checksummed {
+		t.Errorf("reuseAddress(%s) = %s, want %s", lower, got, checksummed)
+	}
+	if got := reuseAddress("bc1qshop"); got != "bc1qshop" {
+		t.Errorf("reuseAddress(bc1qshop) = %s", got)
+	}
+}
+
+func TestWalletPrivacyReport(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	s.recordSend("BTC", "tx1", "bc1qshop", 1000, 200, 1)
+	s.recordSend("BTC", "tx2", "bc1qshop", 1000, 200, 1)
+	s.recordSend("BTC", "tx3", "bc1qcold", 1000, 200, 1)
+	s.recordSend("LTC", "tx4", "ltc1qonce", 1000, 200, 1)
+	// A swap paid out to an address we also sent to
+	if err := s.store.CreateTrade(&storage.Trade{
+		ID: "t1", OrderID: "o", MakerPeerID: "maker", TakerPeerID: "taker", OurRole: storage.TradeRoleTaker,
+		Method: "htlc_bitcoin", State: storage.TradeStateInit, OfferChain: "BTC", RequestChain: "LTC",
+		PayoutAddress: "bc1qcold",
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+
+	result, err := s.walletPrivacyReport(ctx, nil)
+	if err != nil {
+		t.Fatalf("walletPrivacyReport() error = %v", err)
+	}
+	r := result.(*WalletPrivacyReportResult)
+	if r.Mode != node.AddressReuseWarn {
+		t.Errorf("Mode = %s, want warn", r.Mode)
+	}
+	if len(r.Reused) != 1 || r.Reused[0].Address != "bc1qshop" || r.Counts[storage.AddressUseSend] != 1 {
+		t.Errorf("Reused = %+v, Counts = %v", r.Reused, r.Counts)
+	}
+	if len(r.Linked) != 1 || r.Linked[0].Address != "bc1qcold" || len(r.Linked[0].Kinds) != 2 {
+		t.Errorf("Linked = %+v", r.Linked)
+	}
+	if len(r.Warnings) != 2 {
+		t.Errorf("Warnings = %v, want 2", r.Warnings)
+	}
+
+	result, err = s.walletPrivacyReport(ctx, json.RawMessage(`{"symbol":"LTC"}`))
+	if err != nil {
+		t.Fatalf("walletPrivacyReport(LTC) error = %v", err)
+	}
+	if r := result.(*WalletPrivacyReportResult); len(r.Reused) != 0 || len(r.Linked) != 0 || len(r.Warnings) != 0 {
+		t.Errorf("LTC report = %+v", r)
+	}
+}
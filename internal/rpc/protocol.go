@@ -0,0 +1,142 @@
+// Package rpc - Minimum protocol version of trade counterparties.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// ErrPeerProtocolTooOld is returned when taking an order of a maker whose
+// protocol version is below our minimum.
+var ErrPeerProtocolTooOld = errors.New("peer protocol version too old")
+
+// checkPeerProtocol returns the refusal to send a peer speaking version, or
+// nil if we trade with it.
+func (s *Server) checkPeerProtocol(version int) *node.TradeRefusedPayload {
+	if s.protocol == nil {
+		return nil
+	}
+	return s.protocol.Check(version)
+}
+
+// checkMakerProtocol refuses orders of makers last seen speaking a protocol
+// older than our minimum. Makers we haven't received messages from (orders
+// from the Nostr bridge) are not checked.
+func (s *Server) checkMakerProtocol(order *storage.Order) error {
+	if s.protocol == nil {
+		return nil
+	}
+	id, err := peer.Decode(order.PeerID)
+	if err != nil {
+		return nil
+	}
+	version, ok := s.protocol.Version(id)
+	if !ok {
+		return nil
+	}
+	refusal := s.protocol.Check(version)
+	if refusal == nil {
+		return nil
+	}
+	metrics.TradesRefused.WithLabelValues(refusal.Code).Inc()
+	return fmt.Errorf("%w: maker speaks protocol version %d, our minimum is %d", ErrPeerProtocolTooOld, version, refusal.MinVersion)
+}
+
+// refuseTake tells a taker why we won't start its trade. The order stays
+// open for other takers.
+func (s *Server) refuseTake(ctx context.Context, take *OrderTakePayload, refusal *node.TradeRefusedPayload) {
+	refusal.OrderID = take.OrderID
+	metrics.TradesRefused.WithLabelValues(refusal.Code).Inc()
+	s.log.Warn("Refusing take from outdated peer",
+		"order_id", take.OrderID,
+		"trade_id", short(take.TradeID, 8),
+		"taker", short(take.TakerPeerID, 12),
+		"peer_version", refusal.PeerVersion,
+		"min_version", refusal.MinVersion,
+	)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(EventTradeRefused, map[string]interface{}{
+			"trade_id": take.TradeID,
+			"order_id": take.OrderID,
+			"peer":     take.TakerPeerID,
+			"code":     refusal.Code,
+			"reason":   refusal.Reason,
+			"by_us":    true,
+		}, SwapTopic(take.TradeID))
+	}
+	s.recordSwapFailure(take.TradeID, refusalCategory(refusal.Code), refusal.Reason)
+
+	peerID, err := peer.Decode(take.TakerPeerID)
+	if err != nil || s.node == nil {
+		return
+	}
+	msg, err := node.NewSwapMessage(node.SwapMsgTradeRefused, take.TradeID, refusal)
+	if err != nil {
+		return
+	}
+	msg.OrderID = take.OrderID
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if err := s.node.SendDirect(ctx, peerID, take.TradeID, time.Now().Add(time.Hour).Unix(), msg); err != nil {
+		s.log.Debug("Failed to send trade refusal", "trade_id", short(take.TradeID, 8), "error", err)
+	}
+}
+
+// handleTradeRefused processes a maker's refusal of our take.
+func (s *Server) handleTradeRefused(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.TradeRefusedPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse trade refusal payload", "error", err)
+		return nil
+	}
+	s.recordTradeRefused(msg.TradeID, msg.FromPeer, &payload)
+	return nil
+}
+
+// recordTradeRefused aborts our taken trade that its maker refused. Only
+// the maker can refuse, and only before the trade started.
+func (s *Server) recordTradeRefused(tradeID, from string, payload *node.TradeRefusedPayload) {
+	trade, err := s.store.GetTrade(tradeID)
+	if err != nil || trade == nil || trade.MakerPeerID != from || trade.State != storage.TradeStateInit {
+		return
+	}
+	if err := s.store.UpdateTradeState(tradeID, storage.TradeStateAborted); err != nil {
+		s.log.Warn("Failed to update trade state", "error", err)
+	}
+	if _, err := s.store.ReleaseTradeFill(tradeID); err != nil {
+		s.log.Warn("Failed to release order fill", "trade_id", short(tradeID, 8), "error", err)
+	}
+
+	s.log.Warn("Maker refused our trade",
+		"trade_id", short(tradeID, 8),
+		"code", payload.Code,
+		"reason", payload.Reason,
+		"upgrade_hint", payload.UpgradeHint,
+	)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(EventTradeRefused, map[string]interface{}{
+			"trade_id":     tradeID,
+			"order_id":     trade.OrderID,
+			"peer":         from,
+			"code":         payload.Code,
+			"reason":       payload.Reason,
+			"min_version":  payload.MinVersion,
+			"upgrade_hint": payload.UpgradeHint,
+			"by_us":        false,
+		}, SwapTopic(tradeID))
+	}
+	s.recordSwapFailure(tradeID, refusalCategory(payload.Code), payload.Reason)
+}
@@ -0,0 +1,99 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/crypto"
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func testPeerID(t *testing.T) peer.ID {
+	t.Helper()
+	key, _, err := crypto.GenerateEd25519Key(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, err := peer.IDFromPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestCheckMakerProtocol(t *testing.T) {
+	s := newTestStoreServer(t)
+	maker := testPeerID(t)
+	order := &storage.Order{ID: "o1", PeerID: maker.String()}
+
+	// No minimum configured
+	if err := s.checkMakerProtocol(order); err != nil {
+		t.Errorf("checkMakerProtocol() without a tracker error = %v", err)
+	}
+
+	s.protocol = node.NewProtocolTracker(node.ProtocolConfig{MinPeerVersion: node.ProtocolVersion})
+	if err := s.checkMakerProtocol(order); err != nil {
+		t.Errorf("checkMakerProtocol() of an unseen maker error = %v", err)
+	}
+	s.protocol.Observe(maker, node.LegacyProtocolVersion)
+	if err := s.checkMakerProtocol(order); !errors.Is(err, ErrPeerProtocolTooOld) {
+		t.Errorf("checkMakerProtocol() of an outdated maker error = %v, want ErrPeerProtocolTooOld", err)
+	}
+	s.protocol.Observe(maker, node.ProtocolVersion)
+	if err := s.checkMakerProtocol(order); err != nil {
+		t.Errorf("checkMakerProtocol() of an upgraded maker error = %v", err)
+	}
+}
+
+func TestRefuseTake(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.wsHub = NewWSHub()
+	s.protocol = node.NewProtocolTracker(node.ProtocolConfig{MinPeerVersion: node.ProtocolVersion, UpgradeHint: "upgrade"})
+
+	if refusal := s.checkPeerProtocol(node.ProtocolVersion); refusal != nil {
+		t.Fatalf("checkPeerProtocol(current) = %+v", refusal)
+	}
+	refusal := s.checkPeerProtocol(node.LegacyProtocolVersion)
+	if refusal == nil {
+		t.Fatal("checkPeerProtocol(legacy) = nil, want a refusal")
+	}
+
+	// Without a node only the local event is emitted
+	s.refuseTake(context.Background(), &OrderTakePayload{TradeID: "t1", OrderID: "o1", TakerPeerID: "taker"}, refusal)
+	if refusal.OrderID != "o1" {
+		t.Errorf("refusal order = %q, want o1", refusal.OrderID)
+	}
+	ev := <-s.wsHub.broadcast
+	data, _ := ev.Data.(map[string]interface{})
+	if ev.Type != EventTradeRefused || data["code"] != node.RefusalProtocolTooOld || data["by_us"] != true {
+		t.Errorf("event = %+v", ev)
+	}
+}
+
+func TestRecordTradeRefused(t *testing.T) {
+	s := newTestStoreServer(t)
+	trade := &storage.Trade{ID: "t1", OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "us", State: storage.TradeStateInit, CreatedAt: time.Now()}
+	if err := s.store.CreateTrade(trade); err != nil {
+		t.Fatal(err)
+	}
+	payload := &node.TradeRefusedPayload{Code: node.RefusalProtocolTooOld, MinVersion: 2}
+
+	// Only the maker can refuse
+	s.recordTradeRefused("t1", "someone", payload)
+	if got, _ := s.store.GetTrade("t1"); got.State != storage.TradeStateInit {
+		t.Errorf("state after a refusal by another peer = %s, want init", got.State)
+	}
+
+	s.recordTradeRefused("t1", "maker", payload)
+	if got, _ := s.store.GetTrade("t1"); got.State != storage.TradeStateAborted {
+		t.Errorf("state after the maker's refusal = %s, want aborted", got.State)
+	}
+	if failures, _ := s.store.ListSwapFailures(0, "", 0); len(failures) != 1 || failures[0].Category != "validation_mismatch" {
+		t.Errorf("failures after the maker's refusal = %+v, want one validation mismatch", failures)
+	}
+}
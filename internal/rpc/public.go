@@ -0,0 +1,53 @@
+// Package rpc - Read-only public API mode for explorer deployments.
+package rpc
+
+import "sort"
+
+// publicMethods are the read-only methods a public node serves: the order
+// book, network stats and aggregates of completed trades.
+var publicMethods = map[string]bool{
+	"node_status":          true,
+	"peers_count":          true,
+	"orders_list":          true,
+	"orders_get":           true,
+	"orders_schema":        true,
+	"orders_validate":      true,
+	"orderbook_get":        true,
+	"prices_list":          true,
+	"telemetry_preview":    true, // Anonymized aggregates of completed trades
+	"address_validate":     true,
+	"swap_evmGetContracts": true,
+	"swap_evmGetContract":  true,
+}
+
+// publicEvents are the WebSocket events a public node sends.
+var publicEvents = []EventType{"order_received", "order_cancelled", EventOrderBookDiff}
+
+// SetPublic restricts the API to the read-only public methods. Every other
+// handler is unregistered rather than gated, so no wallet or swap handler
+// is reachable however a request is crafted. The WebSocket carries only
+// order book events, and the dashboard and /metrics are not served. It
+// must be called before Start.
+func (s *Server) SetPublic() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for method := range s.handlers {
+		if !publicMethods[method] {
+			delete(s.handlers, method)
+		}
+	}
+	s.public = true
+	s.log.Info("Public read-only API mode", "methods", len(s.handlers))
+}
+
+// Methods returns the registered JSON-RPC methods, sorted.
+func (s *Server) Methods() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	methods := make([]string, 0, len(s.handlers))
+	for method := range s.handlers {
+		methods = append(methods, method)
+	}
+	sort.Strings(methods)
+	return methods
+}
@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestSetPublic(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	s := NewServer(nil, store, nil, nil)
+	s.SetDashboard(true)
+	s.SetPublic()
+
+	methods := s.Methods()
+	if len(methods) != len(publicMethods) {
+		t.Errorf("Methods() = %v, want the %d public methods", methods, len(publicMethods))
+	}
+	for _, m := range methods {
+		if !publicMethods[m] {
+			t.Errorf("non-public method %s still registered", m)
+		}
+	}
+	for _, m := range []string{"wallet_send", "swap_fund", "orders_create", "subsystem_stop", "node_info"} {
+		if _, err := s.Call(context.Background(), m, nil); !errors.Is(err, ErrMethodNotFound) {
+			t.Errorf("Call(%s) error = %v, want ErrMethodNotFound", m, err)
+		}
+	}
+	if _, err := s.Call(context.Background(), "orders_list", nil); err != nil {
+		t.Errorf("Call(orders_list) error = %v", err)
+	}
+
+	handler := s.routes()
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"wallet_send","params":{}}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == nil || resp.Error.Code != MethodNotFound {
+		t.Errorf("wallet_send response = %s", rec.Body.String())
+	}
+	for _, path := range []string{"/metrics", "/dashboard/"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code == http.StatusOK {
+			t.Errorf("GET %s served in public mode", path)
+		}
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/healthz status %d, want 200", rec.Code)
+	}
+}
+
+func TestWSHubAllowedEvents(t *testing.T) {
+	hub := NewWSHub()
+	hub.SetConfig(node.DefaultWebSocketConfig())
+	hub.SetAllowedEvents(publicEvents)
+	go hub.Run()
+	client := hub.newClient(nil, "explorer", false)
+	hub.register <- client
+
+	hub.Broadcast("swap_refunded", map[string]int{"i": 0})
+	hub.Broadcast("order_received", map[string]int{"i": 1})
+
+	if got := eventIndex(t, <-client.send); got != 1 {
+		t.Errorf("first delivered event = %v, want the order event", got)
+	}
+}
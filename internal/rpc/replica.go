@@ -0,0 +1,115 @@
+// Package rpc - Read replica mode serving read-only traffic off a primary.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// replicaMethods are the methods a replica serves. They only read storage,
+// which the replica opens read-only; everything reaching the network, a
+// wallet or the swap coordinator stays on the primary.
+var replicaMethods = map[string]bool{
+	"replica_status":    true,
+	"orders_list":       true,
+	"orders_get":        true,
+	"orders_schema":     true,
+	"orders_validate":   true,
+	"orderbook_get":     true,
+	"trades_list":       true,
+	"trades_get":        true,
+	"swap_list":         true,
+	"swap_failureStats": true,
+	"events_query":      true,
+	"export_schemas":    true,
+	"address_validate":  true,
+}
+
+// replicaState tracks how closely a replica follows its primary.
+type replicaState struct {
+	interval    time.Duration
+	stop        context.CancelFunc
+	version     atomic.Int64
+	refreshedAt atomic.Int64 // Unix time of the last order book refresh
+	refreshes   atomic.Uint64
+}
+
+// ReplicaStatusResult is the result of replica_status.
+type ReplicaStatusResult struct {
+	ReadOnly     bool   `json:"read_only"`
+	DataVersion  int64  `json:"data_version"`
+	PollInterval string `json:"poll_interval"`
+	RefreshedAt  int64  `json:"refreshed_at,omitempty"` // Unix time of the last refresh from the primary
+	Refreshes    uint64 `json:"refreshes"`
+}
+
+// SetReplica restricts the API to the methods a read replica serves and
+// follows the primary's writes every interval, refreshing the order book
+// and broadcasting its diffs. As with SetPublic, other handlers are
+// unregistered. It must be called before Start.
+func (s *Server) SetReplica(interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers["replica_status"] = s.replicaStatus
+	for method := range s.handlers {
+		if !replicaMethods[method] {
+			delete(s.handlers, method)
+		}
+	}
+	s.replica = &replicaState{interval: interval}
+	s.log.Info("Read replica API mode", "methods", len(s.handlers), "poll", interval)
+}
+
+// followPrimary refreshes the order book whenever the primary has written
+// to the database, until ctx is done.
+func (s *Server) followPrimary(ctx context.Context) {
+	ticker := time.NewTicker(s.replica.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.refreshReplica(ctx)
+		}
+	}
+}
+
+// refreshReplica reloads the order book if the primary's data changed since
+// the last check.
+func (s *Server) refreshReplica(ctx context.Context) {
+	version, err := s.store.DataVersion(ctx)
+	if err != nil {
+		s.log.Warn("Failed to read the primary's data version", "error", err)
+		return
+	}
+	if version == s.replica.version.Load() {
+		return
+	}
+	if s.book != nil {
+		if err := s.book.Load(); err != nil {
+			s.log.Warn("Failed to refresh order book", "error", err)
+			return
+		}
+	}
+	s.replica.version.Store(version)
+	s.replica.refreshedAt.Store(time.Now().Unix())
+	s.replica.refreshes.Add(1)
+}
+
+// replicaStatus reports how closely the replica follows its primary.
+func (s *Server) replicaStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	version, err := s.store.DataVersion(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplicaStatusResult{
+		ReadOnly:     s.store.ReadOnly(),
+		DataVersion:  version,
+		PollInterval: s.replica.interval.String(),
+		RefreshedAt:  s.replica.refreshedAt.Load(),
+		Refreshes:    s.replica.refreshes.Load(),
+	}, nil
+}
@@ -0,0 +1,71 @@
+package rpc
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestSetReplica(t *testing.T) {
+	dir := t.TempDir()
+	primary, err := storage.New(&storage.Config{DataDir: dir})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer primary.Close()
+	store, err := storage.New(&storage.Config{DataDir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("storage.New(read-only) error = %v", err)
+	}
+	defer store.Close()
+
+	s := NewServer(nil, store, nil, nil)
+	s.SetReplica(time.Hour)
+	ctx := context.Background()
+
+	methods := s.Methods()
+	if len(methods) != len(replicaMethods) {
+		t.Errorf("Methods() = %v, want the %d replica methods", methods, len(replicaMethods))
+	}
+	for _, m := range []string{"orders_create", "swap_fund", "wallet_send", "trades_status", "storage_fsck"} {
+		if _, err := s.Call(ctx, m, nil); !errors.Is(err, ErrMethodNotFound) {
+			t.Errorf("Call(%s) error = %v, want ErrMethodNotFound", m, err)
+		}
+	}
+	if _, err := s.Call(ctx, "swap_list", nil); err != nil {
+		t.Errorf("Call(swap_list) error = %v without a coordinator", err)
+	}
+
+	// The primary takes an order; the replica's book follows
+	order := &storage.Order{
+		ID: "order-1", PeerID: "maker", Status: storage.OrderStatusOpen,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000,
+		CreatedAt: time.Now(),
+	}
+	if err := primary.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if got := s.book.Pairs(); len(got) != 0 {
+		t.Fatalf("book pairs = %v before refreshing", got)
+	}
+	s.refreshReplica(ctx)
+	if got := s.book.Pairs(); len(got) != 1 {
+		t.Errorf("book pairs = %v after refreshing, want one", got)
+	}
+	s.refreshReplica(ctx)
+
+	res, err := s.Call(ctx, "replica_status", nil)
+	if err != nil {
+		t.Fatalf("Call(replica_status) error = %v", err)
+	}
+	st := res.(*ReplicaStatusResult)
+	if !st.ReadOnly || st.Refreshes != 1 || st.RefreshedAt == 0 {
+		t.Errorf("replica_status = %+v, want one refresh of a read-only store", st)
+	}
+	if _, err := s.Call(ctx, "orders_get", []byte(`{"id":"order-1"}`)); err != nil {
+		t.Errorf("Call(orders_get) error = %v", err)
+	}
+}
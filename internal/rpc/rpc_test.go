@@ -6,6 +6,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
 )
 
 func TestRequest(t *testing.T) {
@@ -665,3 +668,53 @@ func TestErrorConstants(t *testing.T) {
 		t.Errorf("InternalError = %d, want -32603", InternalError)
 	}
 }
+
+type isolatedSignals struct{}
+
+func (isolatedSignals) NonBootstrapPeerCount() int { return 0 }
+func (isolatedSignals) DHTFailures() int           { return 0 }
+func (isolatedSignals) LastGossipAt() time.Time    { return time.Time{} }
+
+func TestHandleReadyz(t *testing.T) {
+	s := &Server{handlers: make(map[string]Handler)}
+
+	// No detector: ready
+	w := httptest.NewRecorder()
+	s.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("readyz without detector: status = %d, want 200", w.Code)
+	}
+
+	s.partition = node.NewPartitionDetector(node.PartitionConfig{}, isolatedSignals{})
+	s.partition.Check(time.Now().Add(time.Hour))
+
+	w = httptest.NewRecorder()
+	s.handleReadyz(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Errorf("readyz while degraded: status = %d, want 503", w.Code)
+	}
+
+	var status node.PartitionStatus
+	if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+		t.Fatalf("failed to decode readyz body: %v", err)
+	}
+	if !status.Degraded || len(status.Signals) == 0 {
+		t.Errorf("readyz body = %+v, want degraded with signals", status)
+	}
+
+	w = httptest.NewRecorder()
+	s.handleHealthz(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("healthz while degraded: status = %d, want 200", w.Code)
+	}
+}
+
+func TestShort(t *testing.T) {
+	if got := short("0123456789abcdef", 8); got != "01234567" {
+		t.Errorf("short(long) = %q", got)
+	}
+	// IDs from peers may be shorter than expected
+	if got := short("abc", 12); got != "abc" {
+		t.Errorf("short(short) = %q", got)
+	}
+}
@@ -3,18 +3,36 @@ package rpc
 
 import (
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Klingon-tech/klingdex/internal/archive"
+	"github.com/Klingon-tech/klingdex/internal/backup"
+	"github.com/Klingon-tech/klingdex/internal/compliance"
+	"github.com/Klingon-tech/klingdex/internal/consolidate"
+	"github.com/Klingon-tech/klingdex/internal/export"
+	"github.com/Klingon-tech/klingdex/internal/metrics"
 	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/nostr"
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
 	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/internal/swap"
+	klsync "github.com/Klingon-tech/klingdex/internal/sync"
+	"github.com/Klingon-tech/klingdex/internal/telemetry"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/Klingon-tech/klingdex/internal/watchtower"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // Server is a JSON-RPC 2.0 server.
@@ -29,6 +47,56 @@ type Server struct {
 	server   *http.Server
 	listener net.Listener
 
+	tlsCfg     node.TLSConfig
+	apiKeys    [][32]byte // SHA-256 of the accepted API keys
+	adminKeys  [][32]byte // SHA-256 of the keys also granting operator access
+	dashboard  bool
+	public     bool          // Read-only public methods only
+	replica    *replicaState // Read replica of a primary's database; nil on a primary
+	acmeServer *http.Server
+	wsCfg      *node.WebSocketConfig
+
+	watchtower   *watchtower.Tower
+	exporter     *export.Exporter
+	partition    *node.PartitionDetector
+	clock        *node.ClockMonitor
+	protocol     *node.ProtocolTracker
+	rendezvous   *node.RendezvousService
+	book         *orderbook.Book
+	prices       *pricefeed.Checker
+	backup       *backup.Replicator
+	backupDir    string
+	history      *klsync.HistorySync
+	eventLog     *eventLog
+	compliance   *compliance.Streamer
+	telemetry    *telemetry.Reporter
+	tracer       *tracing.Provider
+	nostr        *nostr.Bridge
+	consolidator *consolidate.Consolidator
+	archive      *archive.Archiver
+	captureDir   string
+	slowCall     time.Duration // Zero disables the slow-call log
+
+	requireSignedOrders bool
+	orderPoW            node.OrderPoWConfig
+	orderSchema         node.OrderSchemaConfig
+	metaClaim           node.MetaClaimConfig
+	privacy             node.PrivacyConfig
+	reannounce          node.ReannounceConfig
+	wrappedAssets       node.WrappedAssetsConfig
+	feeNegotiation      node.FeeNegotiationConfig
+	feeOracle           feeOracle // nil: priced from the wallet's backends
+	feeProposals        sync.Map  // trade ID -> our proposed adjustment awaiting a reply
+	precision           node.PrecisionConfig
+	aggregation         node.AggregationConfig
+	aggregateProposals  sync.Map // aggregate ID -> member trade IDs awaiting the maker's reply
+	aggregateBatches    map[string]*aggregateBatch
+	aggregateMu         sync.Mutex
+	finality            node.FinalityConfig
+	failureCauses       sync.Map // trade ID -> failureCause of its last classified swap_* error
+	reannouncing        atomic.Bool
+	subsystems          *node.Subsystems
+
 	handlers map[string]Handler
 	mu       sync.RWMutex
 }
@@ -42,6 +110,9 @@ type Request struct {
 	Method  string          `json:"method"`
 	Params  json.RawMessage `json:"params,omitempty"`
 	ID      interface{}     `json:"id,omitempty"`
+
+	// Units adds units metadata next to amounts in the result (extension).
+	Units *UnitsOptions `json:"units,omitempty"`
 }
 
 // Response represents a JSON-RPC 2.0 response.
@@ -59,6 +130,9 @@ type Error struct {
 	Data    interface{} `json:"data,omitempty"`
 }
 
+// ErrMethodNotFound is returned by Call for unknown methods.
+var ErrMethodNotFound = errors.New("method not found")
+
 // Standard error codes.
 const (
 	ParseError     = -32700
@@ -78,6 +152,25 @@ func NewServer(n *node.Node, store *storage.Storage, w *wallet.Service, coord *s
 		log:         logging.GetDefault().Component("rpc"),
 		handlers:    make(map[string]Handler),
 	}
+	if n != nil {
+		s.partition = n.Partition()
+		s.clock = n.Clock()
+		s.protocol = n.Protocol()
+		s.rendezvous = n.Rendezvous()
+	}
+	if store != nil {
+		s.book = s.newOrderBook(store)
+	}
+	if coord != nil {
+		// WebSocket clients see every event; a lagging hub loses the oldest
+		coord.Subscribe(s.forwardSwapEvent, swap.SubscriberOptions{Name: "websocket", QueueSize: 1024})
+		coord.Subscribe(s.relayFundingVariance, swap.SubscriberOptions{Name: "funding_variance"})
+		coord.Subscribe(s.relayExternalFunding, swap.SubscriberOptions{Name: "external_funding"})
+		coord.Subscribe(s.relayLightningInvoice, swap.SubscriberOptions{Name: "lightning"})
+		coord.Subscribe(s.relayXMR, swap.SubscriberOptions{Name: "xmr"})
+		coord.Subscribe(s.classifySwapEvent, swap.SubscriberOptions{Name: "failures"})
+		coord.Subscribe(s.relayOrderFillRelease, swap.SubscriberOptions{Name: "order_fills"})
+	}
 
 	// Register handlers
 	s.registerHandlers()
@@ -90,6 +183,7 @@ func (s *Server) registerHandlers() {
 	// Node methods
 	s.handlers["node_info"] = s.nodeInfo
 	s.handlers["node_status"] = s.nodeStatus
+	s.handlers["ws_stats"] = s.wsStats
 
 	// Peer methods
 	s.handlers["peers_list"] = s.peersList
@@ -97,6 +191,7 @@ func (s *Server) registerHandlers() {
 	s.handlers["peers_connect"] = s.peersConnect
 	s.handlers["peers_disconnect"] = s.peersDisconnect
 	s.handlers["peers_known"] = s.peersKnown
+	s.handlers["peer_diagnose"] = s.peerDiagnose
 
 	// Wallet methods
 	s.handlers["wallet_status"] = s.walletStatus
@@ -109,6 +204,10 @@ func (s *Server) registerHandlers() {
 	s.handlers["wallet_getPublicKey"] = s.walletGetPublicKey
 	s.handlers["wallet_supportedChains"] = s.walletSupportedChains
 	s.handlers["wallet_validateMnemonic"] = s.walletValidateMnemonic
+	s.handlers["wallet_generateShares"] = s.walletGenerateShares
+	s.handlers["wallet_recoverFromShares"] = s.walletRecoverFromShares
+	s.handlers["wallet_backupRemote"] = s.walletBackupRemote
+	s.handlers["wallet_restoreRemote"] = s.walletRestoreRemote
 	s.handlers["wallet_getBalance"] = s.walletGetBalance
 	s.handlers["wallet_getFeeEstimates"] = s.walletGetFeeEstimates
 	s.handlers["wallet_send"] = s.walletSend
@@ -122,6 +221,31 @@ func (s *Server) registerHandlers() {
 	s.handlers["wallet_getAggregatedBalance"] = s.walletGetAggregatedBalance
 	s.handlers["wallet_listAllUTXOs"] = s.walletListAllUTXOs
 	s.handlers["wallet_syncUTXOs"] = s.walletSyncUTXOs
+	s.handlers["wallet_rescan"] = s.walletRescan
+	s.handlers["wallet_consolidate"] = s.walletConsolidate
+	s.handlers["wallet_consolidationStatus"] = s.walletConsolidationStatus
+	s.handlers["wallet_transactions"] = s.walletTransactions
+	s.handlers["wallet_privacyReport"] = s.walletPrivacyReport
+
+	// Output descriptor wallet methods (watch-only or signing imports)
+	s.handlers["wallet_importDescriptor"] = s.walletImportDescriptor
+	s.handlers["wallet_listDescriptors"] = s.walletListDescriptors
+	s.handlers["wallet_removeDescriptor"] = s.walletRemoveDescriptor
+	s.handlers["wallet_deriveDescriptorAddresses"] = s.walletDeriveDescriptorAddresses
+	s.handlers["wallet_scanDescriptor"] = s.walletScanDescriptor
+	s.handlers["wallet_sendFromDescriptor"] = s.walletSendFromDescriptor
+
+	// Hardware wallet methods (Ledger/Trezor signing of UTXO chains)
+	s.handlers["wallet_listDevices"] = s.walletListDevices
+	s.handlers["wallet_setSigner"] = s.walletSetSigner
+	s.handlers["wallet_getSigners"] = s.walletGetSigners
+	s.handlers["wallet_verifyAddress"] = s.walletVerifyAddress
+
+	// Imports from other wallets (Electrum seeds, WIF keys, Ethereum keystores)
+	s.handlers["wallet_importElectrumSeed"] = s.walletImportElectrumSeed
+	s.handlers["wallet_sweepWIF"] = s.walletSweepWIF
+	s.handlers["wallet_sweepDescriptor"] = s.walletSweepDescriptor
+	s.handlers["wallet_sweepKeystore"] = s.walletSweepKeystore
 
 	// EVM wallet methods
 	s.handlers["wallet_sendEVM"] = s.walletSendEVM
@@ -129,13 +253,21 @@ func (s *Server) registerHandlers() {
 	s.handlers["wallet_getERC20Balance"] = s.walletGetERC20Balance
 	s.handlers["wallet_getChainType"] = s.walletGetChainType
 	s.handlers["wallet_listTokens"] = s.walletListTokens
+	s.handlers["wallet_getSpendingPolicy"] = s.walletGetSpendingPolicy
+
+	// Address methods
+	s.handlers["address_validate"] = s.addressValidate
 
 	// Order methods
 	s.handlers["orders_create"] = s.ordersCreate
 	s.handlers["orders_list"] = s.ordersList
 	s.handlers["orders_get"] = s.ordersGet
 	s.handlers["orders_cancel"] = s.ordersCancel
+	s.handlers["orders_reannounce"] = s.ordersReannounce
 	s.handlers["orders_take"] = s.ordersTake
+	s.handlers["orders_schema"] = s.ordersSchema
+	s.handlers["orders_validate"] = s.ordersValidate
+	s.handlers["orderbook_get"] = s.orderbookGet
 
 	// Trade methods
 	s.handlers["trades_list"] = s.tradesList
@@ -149,62 +281,170 @@ func (s *Server) registerHandlers() {
 	s.handlers["swap_setFunding"] = s.swapSetFunding
 	s.handlers["swap_checkFunding"] = s.swapCheckFunding
 	s.handlers["swap_fund"] = s.swapFund // Auto-fund: scan wallet, sign, broadcast, set funding
+	s.handlers["swap_bumpFee"] = s.swapBumpFee
+	s.handlers["swap_availableBalance"] = s.swapAvailableBalance
+	s.handlers["swap_requestExternalFunding"] = s.swapRequestExternalFunding
+	s.handlers["swap_externalFundingStatus"] = s.swapExternalFundingStatus
+	s.handlers["swap_confirmStep"] = s.swapConfirmStep
+	s.handlers["swap_pendingSteps"] = s.swapPendingSteps
+	s.handlers["swap_lightningInvoice"] = s.swapLightningInvoice
+	s.handlers["swap_lightningPay"] = s.swapLightningPay
+	s.handlers["swap_lightningStatus"] = s.swapLightningStatus
 	s.handlers["swap_sign"] = s.swapSign
 	s.handlers["swap_redeem"] = s.swapRedeem
 	s.handlers["swap_status"] = s.swapStatus
+	s.handlers["swap_negotiateFees"] = s.swapNegotiateFees
+	s.handlers["swap_aggregate"] = s.swapAggregate
 
 	// Swap recovery and timeout methods
 	s.handlers["swap_list"] = s.swapList
 	s.handlers["swap_recover"] = s.swapRecover
+	s.handlers["swap_reconcile"] = s.swapReconcile
 	s.handlers["swap_timeout"] = s.swapTimeout
 	s.handlers["swap_refund"] = s.swapRefund
 	s.handlers["swap_checkTimeouts"] = s.swapCheckTimeouts
 
+	// In-flight swap operations
+	s.handlers["swap_operations"] = s.swapOperations
+	s.handlers["swap_cancelOperation"] = s.swapCancelOperation
+	s.handlers["swap_eventSubscribers"] = s.swapEventSubscribers
+	s.handlers["swap_failureStats"] = s.swapFailureStats
+
+	// Key and derivation path audit
+	s.handlers["swap_getKeyUsage"] = s.swapGetKeyUsage
+	s.handlers["swap_keyHygiene"] = s.swapKeyHygiene
+
 	// HTLC-specific methods (Bitcoin-family)
 	s.handlers["swap_htlcRevealSecret"] = s.swapHTLCRevealSecret
 	s.handlers["swap_htlcGetSecret"] = s.swapHTLCGetSecret
 	s.handlers["swap_htlcClaim"] = s.swapHTLCClaim
 	s.handlers["swap_htlcRefund"] = s.swapHTLCRefund
+	s.handlers["swap_htlcBatchSettle"] = s.swapHTLCBatchSettle
 	s.handlers["swap_htlcExtractSecret"] = s.swapHTLCExtractSecret
 
+	// Auto-claim policy per trade
+	s.handlers["swap_setAutoClaim"] = s.swapSetAutoClaim
+	s.handlers["swap_getAutoClaim"] = s.swapGetAutoClaim
+
 	// EVM HTLC methods
 	s.handlers["swap_evmCreate"] = s.swapEVMCreate
 	s.handlers["swap_evmClaim"] = s.swapEVMClaim
 	s.handlers["swap_evmRefund"] = s.swapEVMRefund
+	s.handlers["swap_evmCancelCreate"] = s.swapEVMCancelCreate
+	s.handlers["swap_evmCancelStatus"] = s.swapEVMCancelStatus
+	s.handlers["swap_evmMetaClaim"] = s.swapEVMMetaClaim
 	s.handlers["swap_evmStatus"] = s.swapEVMStatus
 	s.handlers["swap_evmWaitSecret"] = s.swapEVMWaitSecret
 	s.handlers["swap_evmSetSecret"] = s.swapEVMSetSecret
 	s.handlers["swap_evmGetContracts"] = s.swapEVMGetContracts
 	s.handlers["swap_evmGetContract"] = s.swapEVMGetContract
+	s.handlers["swap_evmContractMigration"] = s.swapEVMContractMigration
 	s.handlers["swap_evmComputeSwapID"] = s.swapEVMComputeSwapID
 
 	// Cross-chain swap methods
 	s.handlers["swap_initCrossChain"] = s.swapInitCrossChain
 	s.handlers["swap_getSwapType"] = s.swapGetSwapType
+
+	// Watchtower methods
+	s.handlers["watchtower_register"] = s.watchtowerRegister
+	s.handlers["watchtower_cancel"] = s.watchtowerCancel
+	s.handlers["watchtower_obligations"] = s.watchtowerObligations
+
+	// Analytics export
+	s.handlers["export_run"] = s.exportRun
+	s.handlers["export_schemas"] = s.exportSchemas
+
+	// Market prices
+	s.handlers["prices_list"] = s.pricesList
+
+	// Backup methods
+	s.handlers["backup_run"] = s.backupRun
+	s.handlers["backup_status"] = s.backupStatus
+	s.handlers["backup_fetch"] = s.backupFetch
+
+	// Storage integrity
+	s.handlers["storage_fsck"] = s.storageFsck
+	s.handlers["storage_quarantine"] = s.storageQuarantine
+
+	// Trade history sync between the user's own nodes
+	s.handlers["sync_pair"] = s.syncPair
+	s.handlers["sync_status"] = s.syncStatus
+
+	// Event audit log
+	s.handlers["events_query"] = s.eventsQuery
+
+	// Compliance audit records
+	s.handlers["compliance_status"] = s.complianceStatus
+	s.handlers["compliance_records"] = s.complianceRecords
+	s.handlers["compliance_verify"] = s.complianceVerify
+
+	// Anonymized network statistics
+	s.handlers["telemetry_status"] = s.telemetryStatus
+	s.handlers["telemetry_preview"] = s.telemetryPreview
+
+	// Tracing
+	s.handlers["tracing_status"] = s.tracingStatus
+
+	// Nostr order bridge
+	s.handlers["nostr_status"] = s.nostrStatus
+
+	// Runtime subsystem control
+	s.handlers["subsystem_list"] = s.subsystemList
+	s.handlers["subsystem_stop"] = s.subsystemStop
+	s.handlers["subsystem_start"] = s.subsystemStart
+
+	// Web dashboard
+	s.handlers["dashboard_snapshot"] = s.dashboardSnapshot
+
+	// Backend trace capture
+	s.handlers["backend_captureStart"] = s.backendCaptureStart
+	s.handlers["backend_captureStop"] = s.backendCaptureStop
+	s.handlers["backend_captureStatus"] = s.backendCaptureStatus
+
+	// Archived swaps
+	s.handlers["archive_search"] = s.archiveSearch
+	s.handlers["archive_restore"] = s.archiveRestore
+	s.handlers["archive_run"] = s.archiveRun
 }
 
 // Start starts the RPC server.
 func (s *Server) Start(addr string) error {
+	if err := checkTLSRequired(addr, &s.tlsCfg); err != nil {
+		return err
+	}
+	tlsConfig, err := s.buildTLSConfig()
+	if err != nil {
+		return err
+	}
+
 	listener, err := net.Listen("tcp", addr)
 	if err != nil {
 		return fmt.Errorf("failed to listen on %s: %w", addr, err)
 	}
+	if tlsConfig != nil {
+		listener = tls.NewListener(listener, tlsConfig)
+	}
 	s.listener = listener
 
 	// Initialize WebSocket hub
 	s.wsHub = NewWSHub()
+	if s.wsCfg != nil {
+		s.wsHub.SetConfig(*s.wsCfg)
+	}
+	if s.eventLog != nil {
+		s.wsHub.SetRecorder(s.eventLog.record)
+	}
+	s.wsHub.SetUnitsAnnotator(s.annotateUnits)
+	if s.book != nil {
+		s.wsHub.SetOrderBook(s.book.Snapshot)
+	}
+	if s.public {
+		s.wsHub.SetAllowedEvents(publicEvents)
+	}
 	go s.wsHub.Run()
 
-	mux := http.NewServeMux()
-	mux.HandleFunc("POST /", s.handleRPC)
-	mux.HandleFunc("POST /{$}", s.handleRPC)
-	mux.HandleFunc("OPTIONS /", s.handleCORS)
-	mux.HandleFunc("OPTIONS /{$}", s.handleCORS)
-	mux.HandleFunc("GET /ws", s.handleWS)
-	mux.HandleFunc("GET /ws/", s.handleWS)
-
 	s.server = &http.Server{
-		Handler:      corsMiddleware(mux),
+		Handler:      s.routes(),
 		ReadTimeout:  30 * time.Second,
 		WriteTimeout: 30 * time.Second,
 	}
@@ -214,13 +454,58 @@ func (s *Server) Start(addr string) error {
 			s.log.Error("RPC server error", "error", err)
 		}
 	}()
+	if s.replica != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		s.replica.stop = cancel
+		go s.followPrimary(ctx)
+	}
 
-	s.log.Info("RPC server started", "addr", addr, "ws", "ws://"+addr+"/ws")
+	wsScheme := "ws://"
+	if tlsConfig != nil {
+		wsScheme = "wss://"
+	}
+	s.log.Info("RPC server started", "addr", addr, "ws", wsScheme+addr+"/ws", "tls", tlsConfig != nil)
 	return nil
 }
 
+// Handler returns the API's HTTP handler, as Start serves it.
+func (s *Server) Handler() http.Handler {
+	return s.routes()
+}
+
+// routes returns the API's HTTP handler. Health checks, metrics and the
+// dashboard's static assets don't need an API key; the dashboard loads its
+// data over JSON-RPC with one.
+func (s *Server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /", s.requireAPIKey(s.handleRPC))
+	mux.HandleFunc("POST /{$}", s.requireAPIKey(s.handleRPC))
+	mux.HandleFunc("OPTIONS /", s.handleCORS)
+	mux.HandleFunc("OPTIONS /{$}", s.handleCORS)
+	mux.HandleFunc("GET /ws", s.requireAPIKey(s.handleWS))
+	mux.HandleFunc("GET /ws/", s.requireAPIKey(s.handleWS))
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	if s.public {
+		// Nothing beyond the public methods and health checks
+		return corsMiddleware(mux)
+	}
+	mux.Handle("GET /metrics", metrics.Handler())
+	if s.dashboard {
+		mux.Handle("GET /dashboard/", dashboardHandler())
+		mux.Handle("GET /dashboard", http.RedirectHandler("/dashboard/", http.StatusMovedPermanently))
+	}
+	return corsMiddleware(mux)
+}
+
 // Stop stops the RPC server.
 func (s *Server) Stop() error {
+	if s.replica != nil && s.replica.stop != nil {
+		s.replica.stop()
+	}
+	if s.acmeServer != nil {
+		s.acmeServer.Close()
+	}
 	if s.server != nil {
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -238,17 +523,41 @@ func (s *Server) SetupSwapHandlers() {
 		// Handle incoming order announcements (public, via PubSub)
 		swapHandler.OnMessage(node.SwapMsgOrderAnnounce, s.handleOrderAnnounce)
 		swapHandler.OnMessage(node.SwapMsgOrderCancel, s.handleOrderCancel)
+		swapHandler.OnMessage(node.SwapMsgOrderUpdate, s.handleOrderUpdate)
 		swapHandler.OnMessage(node.SwapMsgOrderTake, s.handleOrderTake)
-	}
 
-	// Register handlers on direct stream handler (for private swap messages)
-	s.node.RegisterDirectHandler(node.SwapMsgPubKeyExchange, s.handlePubKeyExchange)
-	s.node.RegisterDirectHandler(node.SwapMsgNonceExchange, s.handleNonceExchange)
-	s.node.RegisterDirectHandler(node.SwapMsgFundingInfo, s.handleFundingInfo)
-	s.node.RegisterDirectHandler(node.SwapMsgPartialSig, s.handlePartialSig)
-	s.node.RegisterDirectHandler(node.SwapMsgHTLCSecretHash, s.handleHTLCSecretHash)
-	s.node.RegisterDirectHandler(node.SwapMsgHTLCSecretReveal, s.handleHTLCSecretReveal)
-	s.node.RegisterDirectHandler(node.SwapMsgHTLCClaim, s.handleHTLCClaim)
+		// Drop orders without enough proof-of-work before they are relayed
+		swapHandler.SetValidator(node.SwapMsgOrderAnnounce, s.validateOrderAnnounce)
+	}
+
+	// Register handlers on direct stream handler (for private swap messages).
+	// Messages are checked against our trade terms first.
+	s.node.RegisterDirectHandler(node.SwapMsgPubKeyExchange, s.checkTerms(s.checkEVMContracts(s.handlePubKeyExchange)))
+	s.node.RegisterDirectHandler(node.SwapMsgNonceExchange, s.checkTerms(s.handleNonceExchange))
+	s.node.RegisterDirectHandler(node.SwapMsgFundingInfo, s.checkTerms(s.handleFundingInfo))
+	s.node.RegisterDirectHandler(node.SwapMsgPartialSig, s.checkTerms(s.handlePartialSig))
+	s.node.RegisterDirectHandler(node.SwapMsgHTLCSecretHash, s.checkTerms(s.checkEVMContracts(s.handleHTLCSecretHash)))
+	s.node.RegisterDirectHandler(node.SwapMsgHTLCSecretReveal, s.checkTerms(s.handleHTLCSecretReveal))
+	s.node.RegisterDirectHandler(node.SwapMsgHTLCClaim, s.checkTerms(s.handleHTLCClaim))
+	s.node.RegisterDirectHandler(node.SwapMsgLightningInvoice, s.checkTerms(s.handleLightningInvoice))
+	s.node.RegisterDirectHandler(node.SwapMsgXMRKeyShare, s.checkTerms(s.handleXMRKeyShare))
+	s.node.RegisterDirectHandler(node.SwapMsgXMRLockInfo, s.checkTerms(s.handleXMRLockInfo))
+	s.node.RegisterDirectHandler(node.SwapMsgXMRRefundSigs, s.checkTerms(s.handleXMRRefundSigs))
+	s.node.RegisterDirectHandler(node.SwapMsgXMRLocked, s.checkTerms(s.handleXMRLocked))
+	s.node.RegisterDirectHandler(node.SwapMsgXMRRedeemPresig, s.checkTerms(s.handleXMRRedeemPresig))
+	s.node.RegisterDirectHandler(node.SwapMsgFundingTopUp, s.checkTerms(s.handleFundingTopUp))
+	s.node.RegisterDirectHandler(node.SwapMsgAbort, s.checkTerms(s.handleSwapAbort))
+	s.node.RegisterDirectHandler(node.SwapMsgTradeRefused, s.handleTradeRefused)
+	s.node.RegisterDirectHandler(node.SwapMsgFeeAdjustment, s.handleFeeAdjustment)
+	s.node.RegisterDirectHandler(node.SwapMsgTradeAggregate, s.handleTradeAggregate)
+	s.node.RegisterDirectHandler(node.SwapMsgEVMMetaClaim, s.checkTerms(s.handleEVMMetaClaim))
+	s.node.RegisterDirectHandler(node.SwapMsgEVMClaimed, s.checkTerms(s.handleEVMClaimed))
+
+	// Watchtower bundles from trusted peers (tower side)
+	if s.watchtower != nil {
+		s.node.RegisterDirectHandler(node.SwapMsgWatchtowerRegister, s.watchtower.HandleRegister)
+		s.node.RegisterDirectHandler(node.SwapMsgWatchtowerCancel, s.watchtower.HandleCancel)
+	}
 
 	s.log.Info("Swap message handlers registered")
 }
@@ -260,18 +569,28 @@ func (s *Server) handleOrderAnnounce(ctx context.Context, msg *node.SwapMessage)
 		return nil
 	}
 
-	// Parse order info from payload
-	var orderInfo OrderInfo
-	if err := json.Unmarshal(msg.Payload, &orderInfo); err != nil {
-		s.log.Warn("Failed to parse order announcement", "error", err)
+	// Parse and validate order info from payload
+	orderInfo, legacy, err := s.decodeOrderAnnouncement(msg.Payload)
+	if err != nil {
+		s.log.Warn("Ignoring invalid order announcement", "from", short(msg.FromPeer, 12), "error", err)
 		return nil // Don't return error - just log and continue
 	}
+	if legacy {
+		s.log.Debug("Order announced in the deprecated unversioned format", "id", orderInfo.ID, "from", short(msg.FromPeer, 12))
+	}
 
 	// Check if we already have this order
 	if existing, _ := s.store.GetOrder(orderInfo.ID); existing != nil {
 		return nil // Already have it
 	}
 
+	s.acceptOrderAnnouncement(orderInfo)
+	return nil
+}
+
+// acceptOrderAnnouncement validates and stores another peer's order, from
+// gossip or the Nostr bridge. It returns nil if the order was rejected.
+func (s *Server) acceptOrderAnnouncement(orderInfo *OrderInfo) *storage.Order {
 	// Create order record (as non-local)
 	var expiresAt *time.Time
 	if orderInfo.ExpiresAt != nil {
@@ -279,6 +598,20 @@ func (s *Server) handleOrderAnnounce(ctx context.Context, msg *node.SwapMessage)
 		expiresAt = &t
 	}
 
+	feeTerms, err := encodeFeeTerms(orderInfo.FeeTerms, orderInfo.OfferAmount, orderInfo.RequestAmount)
+	if err != nil {
+		s.log.Warn("Ignoring order with invalid fee terms", "id", orderInfo.ID, "error", err)
+		return nil
+	}
+	if _, err := s.orderToken(orderInfo.OfferChain, orderInfo.OfferToken); err != nil {
+		s.log.Warn("Ignoring order with unsupported token", "id", orderInfo.ID, "error", err)
+		return nil
+	}
+	if _, err := s.orderToken(orderInfo.RequestChain, orderInfo.RequestToken); err != nil {
+		s.log.Warn("Ignoring order with unsupported token", "id", orderInfo.ID, "error", err)
+		return nil
+	}
+
 	order := &storage.Order{
 		ID:               orderInfo.ID,
 		PeerID:           orderInfo.PeerID,
@@ -288,11 +621,26 @@ func (s *Server) handleOrderAnnounce(ctx context.Context, msg *node.SwapMessage)
 		OfferAmount:      orderInfo.OfferAmount,
 		RequestChain:     orderInfo.RequestChain,
 		RequestAmount:    orderInfo.RequestAmount,
+		OfferToken:       orderInfo.OfferToken,
+		RequestToken:     orderInfo.RequestToken,
 		PreferredMethods: orderInfo.PreferredMethods,
+		Settlement:       orderInfo.Settlement,
+		MinFillAmount:    orderInfo.MinFillAmount,
+		FilledAmount:     orderInfo.FilledAmount,
+		FeeTerms:         feeTerms,
 		CreatedAt:        time.Unix(orderInfo.CreatedAt, 0),
 		ExpiresAt:        expiresAt,
 	}
 
+	if err := setOrderIdentity(order, orderInfo.Identity); err != nil {
+		return nil
+	}
+	identity, err := s.checkOrderIdentity(order)
+	if err != nil {
+		s.log.Warn("Ignoring order with invalid identity", "id", orderInfo.ID, "from", short(orderInfo.PeerID, 12), "error", err)
+		return nil
+	}
+
 	if err := s.store.CreateOrder(order); err != nil {
 		s.log.Debug("Failed to store order", "id", order.ID, "error", err)
 		return nil
@@ -300,17 +648,18 @@ func (s *Server) handleOrderAnnounce(ctx context.Context, msg *node.SwapMessage)
 
 	s.log.Info("Received order announcement",
 		"id", order.ID,
-		"from", order.PeerID[:12],
+		"from", short(order.PeerID, 12),
+		"signed", identity != nil,
 		"offer", fmt.Sprintf("%d %s", order.OfferAmount, order.OfferChain),
 		"request", fmt.Sprintf("%d %s", order.RequestAmount, order.RequestChain),
 	)
 
 	// Emit WebSocket event
 	if s.wsHub != nil {
-		s.wsHub.Broadcast("order_received", orderToInfo(order))
+		s.wsHub.Broadcast("order_received", orderToInfo(order), orderTopic(order))
 	}
 
-	return nil
+	return order
 }
 
 // handleOrderCancel processes incoming order cancellation messages.
@@ -336,7 +685,8 @@ func (s *Server) handleOrderCancel(ctx context.Context, msg *node.SwapMessage) e
 	s.log.Info("Order cancelled by peer", "id", msg.OrderID)
 
 	if s.wsHub != nil {
-		s.wsHub.Broadcast("order_cancelled", map[string]string{"id": msg.OrderID})
+		pair, _ := orderbook.PairOf(order)
+		s.wsHub.Broadcast("order_cancelled", map[string]string{"id": msg.OrderID, "pair": pair}, PairTopic(pair))
 	}
 
 	return nil
@@ -381,11 +731,47 @@ func (s *Server) handleOrderTake(ctx context.Context, msg *node.SwapMessage) err
 		return nil
 	}
 
+	// Don't start new trades while isolated; the order stays open
+	if s.coordinator != nil && s.coordinator.IsDegraded() {
+		s.log.Warn("Degraded mode, ignoring take", "id", payload.OrderID)
+		return nil
+	}
+	if s.coordinator != nil && s.coordinator.IsClockSkewed() {
+		s.log.Warn("Local clock skewed, ignoring take", "id", payload.OrderID)
+		return nil
+	}
+	if s.coordinator != nil && s.coordinator.IsColdMode() {
+		s.log.Warn("Cold mode, ignoring take", "id", payload.OrderID)
+		return nil
+	}
+	if s.coordinator != nil && (s.coordinator.IsContractPaused(order.OfferChain) || s.coordinator.IsContractPaused(order.RequestChain)) {
+		s.log.Warn("HTLC contract paused, ignoring take", "id", payload.OrderID)
+		return nil
+	}
+
 	// Check if we already have a trade for this order
 	if existing, _ := s.store.GetTrade(payload.TradeID); existing != nil {
 		return nil // Already have this trade
 	}
 
+	// Politely refuse takers older than the minimum protocol version
+	if refusal := s.checkPeerProtocol(msg.SenderProtocolVersion()); refusal != nil {
+		s.refuseTake(ctx, &payload, refusal)
+		return nil
+	}
+
+	offerAmount, requestAmount, err := s.takeFill(order, &payload)
+	if err != nil {
+		s.log.Warn("Ignoring take with invalid fill", "id", payload.OrderID, "trade_id", payload.TradeID, "error", err)
+		return nil
+	}
+
+	// Refuse proposals we could never initiate
+	if err := s.validateTakeOffer(fillOrder(order, offerAmount, requestAmount), payload.Method); err != nil {
+		s.log.Warn("Ignoring take with invalid offer", "id", payload.OrderID, "trade_id", payload.TradeID, "error", err)
+		return nil
+	}
+
 	// Create trade on the maker side
 	trade := &storage.Trade{
 		ID:            payload.TradeID,
@@ -394,26 +780,25 @@ func (s *Server) handleOrderTake(ctx context.Context, msg *node.SwapMessage) err
 		TakerPeerID:   payload.TakerPeerID,
 		Method:        payload.Method,
 		State:         storage.TradeStateInit,
-		OfferAmount:   payload.OfferAmount,
-		RequestAmount: payload.RequestAmount,
+		OfferAmount:   offerAmount,
+		RequestAmount: requestAmount,
 		CreatedAt:     time.Now(),
 	}
 
-	if err := s.store.CreateTrade(trade); err != nil {
-		s.log.Warn("Failed to create trade from take message", "error", err)
+	filled, err := s.takeOrderFill(order, trade)
+	if err != nil {
+		s.log.Warn("Failed to take order", "id", payload.OrderID, "trade_id", payload.TradeID, "error", err)
 		return nil
 	}
-
-	// Update order status
-	if err := s.store.UpdateOrderStatus(payload.OrderID, storage.OrderStatusMatched); err != nil {
-		s.log.Warn("Failed to update order status", "error", err)
-	}
+	s.broadcastOrderFill(ctx, filled)
 
 	s.log.Info("Order taken by peer",
 		"trade_id", payload.TradeID,
 		"order_id", payload.OrderID,
-		"taker", payload.TakerPeerID[:12],
+		"taker", short(payload.TakerPeerID, 12),
 		"method", payload.Method,
+		"amount", offerAmount,
+		"remaining", filled.Remaining(),
 	)
 
 	// Emit WebSocket event
@@ -423,7 +808,7 @@ func (s *Server) handleOrderTake(ctx context.Context, msg *node.SwapMessage) err
 			"order_id": payload.OrderID,
 			"taker":    payload.TakerPeerID,
 			"method":   payload.Method,
-		})
+		}, SwapTopic(payload.TradeID))
 	}
 
 	return nil
@@ -447,24 +832,66 @@ func (s *Server) handleRPC(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	s.mu.RLock()
-	handler, ok := s.handlers[req.Method]
-	s.mu.RUnlock()
+	if req.Units != nil {
+		if _, err := localeFormat(req.Units.Locale); err != nil {
+			s.writeError(w, req.ID, InvalidParams, err.Error(), nil)
+			return
+		}
+	}
 
-	if !ok {
+	result, err := s.Call(r.Context(), req.Method, req.Params)
+	if errors.Is(err, ErrMethodNotFound) {
 		s.writeError(w, req.ID, MethodNotFound, "Method not found", req.Method)
 		return
 	}
-
-	result, err := handler(r.Context(), req.Params)
+	var offerErr *swap.OfferValidationError
+	if errors.As(err, &offerErr) {
+		s.writeError(w, req.ID, InvalidParams, err.Error(), offerErr.Violations)
+		return
+	}
 	if err != nil {
 		s.writeError(w, req.ID, InternalError, err.Error(), nil)
 		return
 	}
+	if req.Units != nil {
+		if result, err = s.annotateUnits(result, req.Units); err != nil {
+			s.writeError(w, req.ID, InternalError, err.Error(), nil)
+			return
+		}
+	}
 
 	s.writeResult(w, req.ID, result)
 }
 
+// Call invokes a JSON-RPC method in-process, without the HTTP server.
+func (s *Server) Call(ctx context.Context, method string, params json.RawMessage) (_ interface{}, err error) {
+	s.mu.RLock()
+	handler, ok := s.handlers[method]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrMethodNotFound, method)
+	}
+
+	// Calls about a trade join its trace
+	var trade struct {
+		TradeID string `json:"trade_id"`
+	}
+	if len(params) > 0 && params[0] == '{' {
+		json.Unmarshal(params, &trade)
+	}
+	ctx, span := tracing.StartTrade(ctx, "rpc", trade.TradeID, "rpc "+method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(attribute.String("rpc.method", method)))
+	defer func() { tracing.End(span, err) }()
+
+	start := time.Now()
+	defer func() { s.observeCall(method, params, time.Since(start), err) }()
+	defer func() { s.noteSwapError(method, trade.TradeID, err) }()
+
+	return handler(ctx, params)
+}
+
 // writeResult writes a successful response.
 func (s *Server) writeResult(w http.ResponseWriter, id interface{}, result interface{}) {
 	resp := Response{
@@ -501,6 +928,27 @@ func (s *Server) handleCORS(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// handleHealthz reports that the process is up.
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain")
+	w.Write([]byte("ok\n"))
+}
+
+// handleReadyz reports whether the node accepts new trades. It returns 503
+// with the partition status while the node is in degraded mode.
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	status := node.PartitionStatus{Signals: []string{}}
+	if s.partition != nil {
+		status = s.partition.Status()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if status.Degraded {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
+
 // corsMiddleware adds CORS headers to all responses.
 func corsMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,28 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestServerCall(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.Call(ctx, "no_such_method", nil); !errors.Is(err, ErrMethodNotFound) {
+		t.Errorf("Call(unknown) error = %v, want ErrMethodNotFound", err)
+	}
+
+	s.handlers["test_echo"] = func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return string(params), nil
+	}
+	got, err := s.Call(ctx, "test_echo", json.RawMessage(`{"a":1}`))
+	if err != nil {
+		t.Fatalf("Call() error = %v", err)
+	}
+	if got != `{"a":1}` {
+		t.Errorf("Call() = %v, want params echoed", got)
+	}
+}
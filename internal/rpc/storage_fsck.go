@@ -0,0 +1,55 @@
+// Package rpc - Storage integrity checking handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// StorageFsckParams is the parameters for storage_fsck.
+type StorageFsckParams struct {
+	Repair bool `json:"repair,omitempty"` // Quarantine or delete bad records
+}
+
+// StorageQuarantineResult is the response for storage_quarantine.
+type StorageQuarantineResult struct {
+	Records []*storage.QuarantinedRecord `json:"records"`
+	Count   int                          `json:"count"`
+}
+
+// storageFsck checks the database and, with repair, sets bad records aside.
+func (s *Server) storageFsck(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	var p StorageFsckParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	report, err := s.store.Fsck(p.Repair)
+	if err != nil {
+		return nil, fmt.Errorf("storage check failed: %w", err)
+	}
+	if !report.OK() {
+		s.log.Warn("Storage check found problems", "issues", len(report.Issues), "repaired", report.Repaired, "integrity", report.Integrity)
+	}
+	return report, nil
+}
+
+// storageQuarantine lists the records set aside by storage_fsck.
+func (s *Server) storageQuarantine(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+	records, err := s.store.ListQuarantined()
+	if err != nil {
+		return nil, err
+	}
+	return &StorageQuarantineResult{Records: records, Count: len(records)}, nil
+}
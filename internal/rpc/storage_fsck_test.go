@@ -0,0 +1,42 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestStorageFsck(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	if err := s.store.CreateOrder(&storage.Order{ID: "o1", PeerID: "", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	res, err := s.storageFsck(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("storageFsck() error = %v", err)
+	}
+	if report := res.(*storage.FsckReport); len(report.Issues) != 1 || report.Repaired != 0 {
+		t.Fatalf("report = %+v", report)
+	}
+
+	res, err = s.storageFsck(context.Background(), json.RawMessage(`{"repair":true}`))
+	if err != nil {
+		t.Fatalf("storageFsck(repair) error = %v", err)
+	}
+	if report := res.(*storage.FsckReport); report.Repaired != 1 {
+		t.Errorf("Repaired = %d, want 1", report.Repaired)
+	}
+
+	res, err = s.storageQuarantine(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("storageQuarantine() error = %v", err)
+	}
+	if q := res.(*StorageQuarantineResult); q.Count != 1 || q.Records[0].Key != "o1" {
+		t.Errorf("quarantine = %+v", q)
+	}
+}
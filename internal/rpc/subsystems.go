@@ -0,0 +1,75 @@
+// Package rpc - Runtime stop/start of individual subsystems.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// Subsystem control events.
+const (
+	EventSubsystemStopped EventType = "subsystem_stopped"
+	EventSubsystemStarted EventType = "subsystem_started"
+)
+
+// SetSubsystems enables the subsystem_* methods.
+func (s *Server) SetSubsystems(subs *node.Subsystems) {
+	s.subsystems = subs
+}
+
+// SubsystemParams is the parameters for subsystem_stop and subsystem_start.
+type SubsystemParams struct {
+	Name string `json:"name"`
+}
+
+// SubsystemListResult is the response for subsystem_list.
+type SubsystemListResult struct {
+	Subsystems []node.SubsystemStatus `json:"subsystems"`
+}
+
+// subsystemList returns every subsystem and whether it runs.
+func (s *Server) subsystemList(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.subsystems == nil {
+		return nil, fmt.Errorf("subsystem control not initialized")
+	}
+	return &SubsystemListResult{Subsystems: s.subsystems.Status()}, nil
+}
+
+// subsystemStop stops a subsystem without restarting the daemon.
+func (s *Server) subsystemStop(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return s.subsystemControl(params, EventSubsystemStopped, func(name string) error {
+		return s.subsystems.Stop(name)
+	})
+}
+
+// subsystemStart starts a subsystem stopped with subsystem_stop.
+func (s *Server) subsystemStart(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	return s.subsystemControl(params, EventSubsystemStarted, func(name string) error {
+		return s.subsystems.Start(name)
+	})
+}
+
+func (s *Server) subsystemControl(params json.RawMessage, event EventType, fn func(string) error) (interface{}, error) {
+	if s.subsystems == nil {
+		return nil, fmt.Errorf("subsystem control not initialized")
+	}
+	var p SubsystemParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Name == "" {
+		return nil, fmt.Errorf("name is required")
+	}
+	if err := fn(p.Name); err != nil {
+		return nil, err
+	}
+
+	s.log.Warn("Subsystem changed by operator", "subsystem", p.Name, "event", event)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(event, map[string]string{"name": p.Name})
+	}
+	return &SubsystemListResult{Subsystems: s.subsystems.Status()}, nil
+}
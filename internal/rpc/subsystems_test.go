@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func TestSubsystemControl(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+	if _, err := s.subsystemStop(ctx, json.RawMessage(`{"name":"order_sync"}`)); err == nil {
+		t.Error("subsystem_stop without a registry succeeded")
+	}
+
+	running := true
+	subs := node.NewSubsystems()
+	subs.Register(node.Subsystem{Name: node.SubsystemStorage, Essential: true}, true)
+	subs.Register(node.Subsystem{
+		Name:      node.SubsystemOrderSync,
+		DependsOn: []string{node.SubsystemStorage},
+		Start:     func() error { running = true; return nil },
+		Stop:      func() error { running = false; return nil },
+	}, true)
+	s.SetSubsystems(subs)
+
+	if _, err := s.subsystemStop(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("subsystem_stop without a name succeeded")
+	}
+	if _, err := s.subsystemStop(ctx, json.RawMessage(`{"name":"storage"}`)); err == nil {
+		t.Error("subsystem_stop stopped storage")
+	}
+
+	res, err := s.subsystemStop(ctx, json.RawMessage(`{"name":"order_sync"}`))
+	if err != nil {
+		t.Fatalf("subsystem_stop error = %v", err)
+	}
+	list := res.(*SubsystemListResult).Subsystems
+	if running || len(list) != 2 || list[0].Name != node.SubsystemOrderSync || list[0].Running {
+		t.Errorf("after stop: running %v, list %+v", running, list)
+	}
+
+	if _, err := s.subsystemStart(ctx, json.RawMessage(`{"name":"order_sync"}`)); err != nil || !running {
+		t.Errorf("subsystem_start error = %v, running %v", err, running)
+	}
+}
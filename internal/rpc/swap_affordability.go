@@ -0,0 +1,30 @@
+// Package rpc - Balance available to new swaps.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// SwapAvailableBalanceParams is the parameters for swap_availableBalance.
+type SwapAvailableBalanceParams struct {
+	Chain string `json:"chain"`
+}
+
+// swapAvailableBalance returns what the wallet can still commit to new swaps
+// on a chain: confirmed UTXOs minus the funding of pending swaps.
+func (s *Server) swapAvailableBalance(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapAvailableBalanceParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Chain == "" {
+		return nil, fmt.Errorf("chain is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+	return s.coordinator.GetAvailableBalance(ctx, strings.ToUpper(p.Chain))
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapAvailableBalance(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.swapAvailableBalance(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("swapAvailableBalance() accepted a missing chain")
+	}
+	if _, err := s.swapAvailableBalance(ctx, json.RawMessage(`{"chain":"btc"}`)); err == nil {
+		t.Error("swapAvailableBalance() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+	if _, err := s.swapAvailableBalance(ctx, json.RawMessage(`{"chain":"eth"}`)); err == nil {
+		t.Error("swapAvailableBalance() succeeded for an EVM chain")
+	}
+	if _, err := s.swapAvailableBalance(ctx, json.RawMessage(`{"chain":"btc"}`)); err == nil {
+		t.Error("swapAvailableBalance() succeeded without a wallet service")
+	}
+}
@@ -0,0 +1,64 @@
+// Package rpc - Auto-claim policy handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SwapSetAutoClaimParams is the request for swap_setAutoClaim.
+type SwapSetAutoClaimParams struct {
+	TradeID       string `json:"trade_id"`
+	Mode          string `json:"mode,omitempty"`          // immediate, confirmations, manual
+	Confirmations uint32 `json:"confirmations,omitempty"` // Confirmations mode only
+	Clear         bool   `json:"clear,omitempty"`         // Remove the override, use the node policy
+}
+
+// SwapGetAutoClaimParams is the request for swap_getAutoClaim.
+type SwapGetAutoClaimParams struct {
+	TradeID string `json:"trade_id"`
+}
+
+// swapSetAutoClaim overrides the auto-claim policy for one trade.
+func (s *Server) swapSetAutoClaim(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapSetAutoClaimParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if !p.Clear && p.Mode == "" {
+		return nil, fmt.Errorf("mode or clear is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not initialized")
+	}
+
+	var rule *swap.AutoClaimRule
+	if !p.Clear {
+		rule = &swap.AutoClaimRule{Mode: swap.AutoClaimMode(p.Mode), Confirmations: p.Confirmations}
+	}
+	if err := s.coordinator.SetAutoClaimOverride(p.TradeID, rule); err != nil {
+		return nil, err
+	}
+	return s.coordinator.GetAutoClaimStatus(p.TradeID)
+}
+
+// swapGetAutoClaim returns the effective auto-claim rule and last decision for a trade.
+func (s *Server) swapGetAutoClaim(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapGetAutoClaimParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not initialized")
+	}
+	return s.coordinator.GetAutoClaimStatus(p.TradeID)
+}
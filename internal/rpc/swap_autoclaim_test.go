@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapSetAutoClaimValidation(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"missing trade", `{"mode":"manual"}`},
+		{"missing mode", `{"trade_id":"t1"}`},
+		{"no coordinator", `{"trade_id":"t1","mode":"manual"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.swapSetAutoClaim(ctx, json.RawMessage(tt.params)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+
+	_, err := s.swapSetAutoClaim(ctx, json.RawMessage(`{"trade_id":"t1","mode":"manual"}`))
+	if !errors.Is(err, swap.ErrSwapNotFound) {
+		t.Errorf("swapSetAutoClaim(unknown trade) error = %v, want ErrSwapNotFound", err)
+	}
+	if _, err := s.swapSetAutoClaim(ctx, json.RawMessage(`{"trade_id":"t1","mode":"soon"}`)); err == nil {
+		t.Error("swapSetAutoClaim() accepted an invalid mode")
+	}
+	if _, err := s.swapGetAutoClaim(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("swapGetAutoClaim() accepted a missing trade_id")
+	}
+	if _, err := s.swapGetAutoClaim(ctx, json.RawMessage(`{"trade_id":"t1"}`)); !errors.Is(err, swap.ErrSwapNotFound) {
+		t.Errorf("swapGetAutoClaim(unknown trade) error = %v, want ErrSwapNotFound", err)
+	}
+}
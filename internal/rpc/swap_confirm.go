@@ -0,0 +1,71 @@
+// Package rpc - Confirmation of fund-moving swap steps.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SwapConfirmStepParams is the parameters for swap_confirmStep.
+type SwapConfirmStepParams struct {
+	StepID  string `json:"step_id"`
+	Approve bool   `json:"approve"`
+}
+
+// SwapConfirmStepResult is the result of swap_confirmStep.
+type SwapConfirmStepResult struct {
+	StepID   string          `json:"step_id"`
+	TradeID  string          `json:"trade_id"`
+	Approved bool            `json:"approved"`
+	Funding  *SwapFundResult `json:"funding,omitempty"` // Set for approved funding steps
+
+	// Set for approved EVM HTLC creation steps
+	EVMCreate *SwapEVMCreateResult `json:"evm_create,omitempty"`
+}
+
+// SwapPendingStepsResult is the result of swap_pendingSteps.
+type SwapPendingStepsResult struct {
+	Steps []swap.StepPreview `json:"steps"`
+}
+
+// swapConfirmStep approves or rejects a step held for confirmation. An
+// approved funding step broadcasts exactly the previewed transaction; an
+// approved EVM step creates the previewed HTLC.
+func (s *Server) swapConfirmStep(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapConfirmStepParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.StepID == "" {
+		return nil, fmt.Errorf("step_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+
+	tradeID, fundResult, err := s.coordinator.ConfirmStep(ctx, p.StepID, p.Approve)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm step: %w", err)
+	}
+	result := &SwapConfirmStepResult{StepID: p.StepID, TradeID: tradeID, Approved: p.Approve}
+	switch {
+	case fundResult == nil:
+	case strings.HasSuffix(p.StepID, ":"+swap.StepEVMCreate):
+		result.EVMCreate = s.announceEVMCreate(tradeID, fundResult.Chain, fundResult.TxID)
+	default:
+		result.Funding = s.announceFunding(ctx, tradeID, fundResult)
+	}
+	return result, nil
+}
+
+// swapPendingSteps lists the steps waiting for swap_confirmStep.
+func (s *Server) swapPendingSteps(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+	return &SwapPendingStepsResult{Steps: s.coordinator.PendingSteps()}, nil
+}
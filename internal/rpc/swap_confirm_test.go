@@ -0,0 +1,50 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapConfirmStepParams(t *testing.T) {
+	s := newTestStoreServer(t)
+	params, _ := json.Marshal(SwapConfirmStepParams{Approve: true})
+	if _, err := s.swapConfirmStep(context.Background(), params); err == nil || !strings.Contains(err.Error(), "step_id") {
+		t.Errorf("swap_confirmStep without step_id error = %v", err)
+	}
+	params, _ = json.Marshal(SwapConfirmStepParams{StepID: "t1:fund"})
+	if _, err := s.swapConfirmStep(context.Background(), params); err == nil || !strings.Contains(err.Error(), "coordinator") {
+		t.Errorf("swap_confirmStep without a coordinator error = %v", err)
+	}
+	if _, err := s.swapPendingSteps(context.Background(), nil); err == nil {
+		t.Error("swap_pendingSteps without a coordinator succeeded")
+	}
+}
+
+func TestSwapConfirmStepUnknown(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Network: chain.Testnet})
+	defer s.coordinator.Close()
+	if err := s.coordinator.SetConfirmationPolicy(swap.ConfirmationPolicy{Mode: swap.ConfirmManual, Timeout: time.Minute}); err != nil {
+		t.Fatalf("SetConfirmationPolicy() error = %v", err)
+	}
+
+	result, err := s.swapPendingSteps(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("swap_pendingSteps error = %v", err)
+	}
+	if steps := result.(*SwapPendingStepsResult).Steps; len(steps) != 0 {
+		t.Errorf("swap_pendingSteps = %+v, want none", steps)
+	}
+
+	params, _ := json.Marshal(SwapConfirmStepParams{StepID: "t1:fund", Approve: true})
+	if _, err := s.swapConfirmStep(context.Background(), params); !errors.Is(err, swap.ErrStepNotFound) {
+		t.Errorf("swap_confirmStep error = %v, want ErrStepNotFound", err)
+	}
+}
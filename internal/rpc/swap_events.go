@@ -0,0 +1,52 @@
+// Package rpc - Coordinator event forwarding to WebSocket clients.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// forwardSwapEvent broadcasts the coordinator events that clients subscribe
+// to: auto-claim, zero-conf and funding variance decisions, external funding,
+// deadline updates, contract pause alerts and steps awaiting confirmation.
+func (s *Server) forwardSwapEvent(event swap.SwapEvent) {
+	if s.wsHub == nil {
+		return
+	}
+	var topics []string
+	if event.TradeID != "" {
+		topics = append(topics, SwapTopic(event.TradeID))
+	}
+
+	switch {
+	case event.EventType == swap.EventSwapDeadlines, event.EventType == swap.EventContractPaused,
+		event.EventType == swap.EventContractUnpaused, event.EventType == swap.EventContractPauseImpact,
+		event.EventType == swap.EventStepConfirmationRequired:
+		s.wsHub.Broadcast(EventType(event.EventType), event.Data, topics...)
+
+	case strings.HasPrefix(event.EventType, "auto_claim_"), strings.HasPrefix(event.EventType, "zero_conf_"),
+		strings.HasPrefix(event.EventType, "funding_variance_"), strings.HasPrefix(event.EventType, "external_funding_"),
+		event.EventType == swap.EventSwapReconciled, event.EventType == swap.EventOperationCancelled,
+		event.EventType == swap.EventStepConfirmed, event.EventType == swap.EventStepRejected:
+		data := map[string]interface{}{"trade_id": event.TradeID}
+		if fields, ok := event.Data.(map[string]interface{}); ok {
+			for k, v := range fields {
+				data[k] = v
+			}
+		}
+		s.wsHub.Broadcast(EventType(event.EventType), data, topics...)
+	}
+}
+
+// swapEventSubscribers lists the coordinator's event subscribers with their
+// queue depth and the events each has lost to falling behind.
+func (s *Server) swapEventSubscribers(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+	return s.coordinator.EventSubscribers(), nil
+}
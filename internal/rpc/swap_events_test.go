@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestForwardSwapEvent(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	// Without a WebSocket hub events are dropped
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventAutoClaimManual})
+
+	s.wsHub = NewWSHub()
+	deadlines := &swap.SwapDeadlines{TradeID: "t1"}
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: "state_change"})
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventAutoClaimTriggered, Data: map[string]interface{}{"chain": "BTC"}})
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventSwapDeadlines, Data: deadlines})
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventZeroConfAccepted, Data: map[string]interface{}{"txid": "abc"}})
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventFundingVarianceTopUp, Data: map[string]interface{}{"actual": uint64(980000)}})
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventSwapReconciled, Data: map[string]interface{}{"state": "redeemed"}})
+	alert := &swap.ContractPauseAlert{Chain: "ETH", Paused: true}
+	s.forwardSwapEvent(swap.SwapEvent{EventType: swap.EventContractPaused, Data: alert})
+	step := &swap.StepPreview{StepID: "t1:fund", TradeID: "t1", Summary: "lock 0.5 BTC in the swap escrow"}
+	s.forwardSwapEvent(swap.SwapEvent{TradeID: "t1", EventType: swap.EventStepConfirmationRequired, Data: step})
+
+	if got := len(s.wsHub.broadcast); got != 7 {
+		t.Fatalf("broadcast %d events, want 7", got)
+	}
+
+	claim := <-s.wsHub.broadcast
+	data, ok := claim.Data.(map[string]interface{})
+	if claim.Type != EventType(swap.EventAutoClaimTriggered) || !ok || data["trade_id"] != "t1" || data["chain"] != "BTC" {
+		t.Errorf("auto-claim event = %+v", claim)
+	}
+	update := <-s.wsHub.broadcast
+	if update.Type != EventType(swap.EventSwapDeadlines) || update.Data != deadlines {
+		t.Errorf("deadlines event = %+v", update)
+	}
+	zc := <-s.wsHub.broadcast
+	if data, ok := zc.Data.(map[string]interface{}); zc.Type != EventType(swap.EventZeroConfAccepted) || !ok || data["trade_id"] != "t1" || data["txid"] != "abc" {
+		t.Errorf("zero-conf event = %+v", zc)
+	}
+	fv := <-s.wsHub.broadcast
+	if data, ok := fv.Data.(map[string]interface{}); fv.Type != EventType(swap.EventFundingVarianceTopUp) || !ok || data["actual"] != uint64(980000) {
+		t.Errorf("funding variance event = %+v", fv)
+	}
+	rc := <-s.wsHub.broadcast
+	if data, ok := rc.Data.(map[string]interface{}); rc.Type != EventType(swap.EventSwapReconciled) || !ok || data["trade_id"] != "t1" || data["state"] != "redeemed" {
+		t.Errorf("reconciled event = %+v", rc)
+	}
+	paused := <-s.wsHub.broadcast
+	if paused.Type != EventType(swap.EventContractPaused) || paused.Data != alert {
+		t.Errorf("contract paused event = %+v", paused)
+	}
+	held := <-s.wsHub.broadcast
+	if held.Type != EventType(swap.EventStepConfirmationRequired) || held.Data != step {
+		t.Errorf("step confirmation event = %+v", held)
+	}
+}
+
+func TestSwapEventSubscribers(t *testing.T) {
+	s := newTestStoreServer(t)
+	if _, err := s.swapEventSubscribers(context.Background(), nil); err == nil {
+		t.Error("swapEventSubscribers() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+	s.coordinator.Subscribe(func(swap.SwapEvent) {}, swap.SubscriberOptions{Name: "websocket", QueueSize: 8})
+
+	result, err := s.swapEventSubscribers(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("swapEventSubscribers() error = %v", err)
+	}
+	subs := result.([]swap.EventSubscriberStats)
+	if len(subs) != 1 || subs[0].Name != "websocket" || subs[0].QueueSize != 8 || subs[0].Overflow != swap.OverflowDropOldest {
+		t.Errorf("swapEventSubscribers() = %+v", subs)
+	}
+}
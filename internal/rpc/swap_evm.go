@@ -5,10 +5,12 @@ import (
 	"context"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"math/big"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/Klingon-tech/klingdex/internal/config"
 	"github.com/Klingon-tech/klingdex/internal/node"
 	"github.com/Klingon-tech/klingdex/internal/swap"
@@ -48,11 +50,21 @@ func (s *Server) swapInitCrossChain(ctx context.Context, params json.RawMessage)
 	}
 
 	// Build offer from trade/order
+	offerAmount, requestAmount := tradeAmounts(trade, order)
 	offer := swap.Offer{
 		OfferChain:    order.OfferChain,
-		OfferAmount:   order.OfferAmount,
+		OfferAmount:   offerAmount,
 		RequestChain:  order.RequestChain,
-		RequestAmount: order.RequestAmount,
+		RequestAmount: requestAmount,
+		OfferToken:    order.OfferToken,
+		RequestToken:  order.RequestToken,
+		Method:        swap.MethodHTLC, // Cross-chain swaps always use HTLCs
+	}
+	if err := swap.ApplyFeeAdjustment(&offer, trade.FeeAdjustment); err != nil {
+		return nil, err
+	}
+	if err := swap.ValidateOffer(offer, s.coordinator.Network()); err != nil {
+		return nil, err
 	}
 
 	var activeSwap *swap.ActiveSwap
@@ -84,7 +96,7 @@ func (s *Server) swapInitCrossChain(ctx context.Context, params json.RawMessage)
 			return nil, fmt.Errorf("responder needs secret_hash from initiator - not yet received via P2P")
 		}
 
-		secretHashBytes, err := hex.DecodeString(storedSecret.SecretHash)
+		secretHashBytes, err := swap.ParseSecretHash(storedSecret.SecretHash)
 		if err != nil {
 			return nil, fmt.Errorf("invalid stored secret hash: %w", err)
 		}
@@ -110,7 +122,7 @@ func (s *Server) swapInitCrossChain(ctx context.Context, params json.RawMessage)
 			}
 			s.log.Info("Retrieved maker pubkey from trade",
 				"trade_id", p.TradeID,
-				"pubkey", trade.MakerPubKey[:16]+"...",
+				"pubkey", short(trade.MakerPubKey, 16)+"...",
 			)
 		}
 
@@ -178,7 +190,7 @@ func (s *Server) swapInitCrossChain(ctx context.Context, params json.RawMessage)
 			"trade_id":  p.TradeID,
 			"role":      p.Role,
 			"swap_type": swapTypeStr,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapInitCrossChainResult{
@@ -261,32 +273,46 @@ func (s *Server) swapEVMCreate(ctx context.Context, params json.RawMessage) (int
 	}
 
 	txHash, err := s.coordinator.CreateEVMHTLC(ctx, p.TradeID, p.Chain)
+	var held *swap.ConfirmationRequiredError
+	if errors.As(err, &held) {
+		return &SwapEVMCreateResult{
+			TradeID:     p.TradeID,
+			Chain:       p.Chain,
+			State:       "awaiting_confirmation",
+			Message:     held.Step.Summary + ": confirm with swap_confirmStep",
+			PendingStep: held.Step,
+		}, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create EVM HTLC: %w", err)
 	}
+	return s.announceEVMCreate(p.TradeID, p.Chain, txHash.Hex()), nil
+}
 
+// announceEVMCreate logs and broadcasts a created EVM HTLC.
+func (s *Server) announceEVMCreate(tradeID, chainSymbol, txHash string) *SwapEVMCreateResult {
 	s.log.Info("EVM HTLC created",
-		"trade_id", p.TradeID,
-		"chain", p.Chain,
-		"tx_hash", txHash.Hex(),
+		"trade_id", tradeID,
+		"chain", chainSymbol,
+		"tx_hash", txHash,
 	)
 
 	// Emit WebSocket event
 	if s.wsHub != nil {
 		s.wsHub.Broadcast("evm_htlc_created", map[string]string{
-			"trade_id": p.TradeID,
-			"chain":    p.Chain,
-			"tx_hash":  txHash.Hex(),
-		})
+			"trade_id": tradeID,
+			"chain":    chainSymbol,
+			"tx_hash":  txHash,
+		}, SwapTopic(tradeID))
 	}
 
 	return &SwapEVMCreateResult{
-		TradeID:  p.TradeID,
-		Chain:    p.Chain,
-		TxHash:   txHash.Hex(),
-		State:    "created",
-		Message:  "EVM HTLC created successfully",
-	}, nil
+		TradeID: tradeID,
+		Chain:   chainSymbol,
+		TxHash:  txHash,
+		State:   "created",
+		Message: "EVM HTLC created successfully",
+	}
 }
 
 // =============================================================================
@@ -324,7 +350,7 @@ func (s *Server) swapEVMClaim(ctx context.Context, params json.RawMessage) (inte
 			"trade_id": p.TradeID,
 			"chain":    p.Chain,
 			"tx_hash":  txHash.Hex(),
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapEVMClaimResult{
@@ -370,7 +396,7 @@ func (s *Server) swapEVMRefund(ctx context.Context, params json.RawMessage) (int
 			"trade_id": p.TradeID,
 			"chain":    p.Chain,
 			"tx_hash":  txHash.Hex(),
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapEVMRefundResult{
@@ -381,6 +407,61 @@ func (s *Server) swapEVMRefund(ctx context.Context, params json.RawMessage) (int
 	}, nil
 }
 
+// =============================================================================
+// EVM HTLC Create Cancellation
+// =============================================================================
+
+// swapEVMCancelCreate cancels our pending HTLC create on an EVM chain of an
+// aborted trade: the create is replaced by a self-transfer at higher gas, or
+// refunded at the timelock if it was mined first.
+func (s *Server) swapEVMCancelCreate(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapEVMCancelCreateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if p.Chain == "" {
+		return nil, fmt.Errorf("chain is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+
+	cancel, err := s.coordinator.CancelEVMHTLCCreate(ctx, p.TradeID, p.Chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel EVM HTLC create: %w", err)
+	}
+	return &SwapEVMCancelCreateResult{TradeID: p.TradeID, EVMCreateCancellation: cancel}, nil
+}
+
+// swapEVMCancelStatus reports how the cancellation of an HTLC create went.
+func (s *Server) swapEVMCancelStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapEVMCancelCreateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if p.Chain == "" {
+		return nil, fmt.Errorf("chain is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+
+	cancel, err := s.coordinator.GetEVMCreateCancellation(p.TradeID, p.Chain)
+	if err != nil {
+		return nil, err
+	}
+	if cancel == nil {
+		return nil, fmt.Errorf("no HTLC create cancellation on %s for trade %s", p.Chain, p.TradeID)
+	}
+	return &SwapEVMCancelCreateResult{TradeID: p.TradeID, EVMCreateCancellation: cancel}, nil
+}
+
 // =============================================================================
 // EVM HTLC Status
 // =============================================================================
@@ -417,7 +498,7 @@ func (s *Server) swapEVMStatus(ctx context.Context, params json.RawMessage) (int
 		stateStr = "refunded"
 	}
 
-	return &SwapEVMStatusResult{
+	result := &SwapEVMStatusResult{
 		TradeID:      p.TradeID,
 		Chain:        p.Chain,
 		State:        stateStr,
@@ -428,7 +509,18 @@ func (s *Server) swapEVMStatus(ctx context.Context, params json.RawMessage) (int
 		SecretHash:   hex.EncodeToString(swap.SecretHash[:]),
 		Timelock:     swap.Timelock.Int64(),
 		IsNative:     swap.IsNativeToken(),
-	}, nil
+	}
+	if chainParams, err := s.evmChain(p.Chain); err == nil {
+		if result.IsNative {
+			result.AssetSymbol = chainParams.Symbol
+			result.AmountDecimal = formatEVMAmount(swap.Amount, chainParams.Decimals, true)
+		} else {
+			symbol, decimals, known := tokenUnits(chain.GetTokenByAddress(chainParams.ChainID, result.TokenAddress))
+			result.AssetSymbol = symbol
+			result.AmountDecimal = formatEVMAmount(swap.Amount, decimals, known)
+		}
+	}
+	return result, nil
 }
 
 // =============================================================================
@@ -528,6 +620,7 @@ func (s *Server) swapEVMGetContracts(ctx context.Context, params json.RawMessage
 		contracts = append(contracts, EVMContractInfo{
 			ChainID:         chainID,
 			ContractAddress: addr.Hex(),
+			LegacyContracts: legacyHTLCContracts(chainID),
 		})
 	}
 
@@ -557,10 +650,21 @@ func (s *Server) swapEVMGetContract(ctx context.Context, params json.RawMessage)
 	return &SwapEVMGetContractResult{
 		ChainID:         p.ChainID,
 		ContractAddress: addr.Hex(),
+		LegacyContracts: legacyHTLCContracts(p.ChainID),
 		Deployed:        true,
 	}, nil
 }
 
+// legacyHTLCContracts returns the previous HTLC contract versions of a
+// chain as hex.
+func legacyHTLCContracts(chainID uint64) []string {
+	var legacy []string
+	for _, addr := range config.GetLegacyHTLCContracts(chainID) {
+		legacy = append(legacy, addr.Hex())
+	}
+	return legacy
+}
+
 // =============================================================================
 // EVM Compute Swap ID
 // =============================================================================
@@ -589,13 +693,10 @@ func (s *Server) swapEVMComputeSwapID(ctx context.Context, params json.RawMessag
 	receiver := common.HexToAddress(p.Receiver)
 	token := common.HexToAddress(p.TokenAddress)
 
-	secretHashBytes, err := hex.DecodeString(p.SecretHash)
+	secretHashBytes, err := swap.ParseSecretHash(p.SecretHash)
 	if err != nil {
 		return nil, fmt.Errorf("invalid secret_hash: %w", err)
 	}
-	if len(secretHashBytes) != 32 {
-		return nil, fmt.Errorf("secret_hash must be 32 bytes")
-	}
 	var secretHash [32]byte
 	copy(secretHash[:], secretHashBytes)
 
@@ -0,0 +1,92 @@
+// Package rpc - HTLC contract version checks between trading parties.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// EventEVMContractMismatch is broadcast when a counterparty's key exchange
+// is refused because it uses another HTLC contract version.
+const EventEVMContractMismatch EventType = "evm_contract_mismatch"
+
+// SwapEVMContractMigrationResult is the result of swap_evmContractMigration.
+type SwapEVMContractMigrationResult struct {
+	Chains []*swap.EVMContractMigration `json:"chains"`
+}
+
+// isKeyExchange reports whether a message carries the sender's keys,
+// addresses and HTLC contracts.
+func isKeyExchange(msg *node.SwapMessage) bool {
+	return msg.Type == node.SwapMsgPubKeyExchange || msg.Type == node.SwapMsgHTLCSecretHash
+}
+
+// stampEVMContracts adds the trade's HTLC contracts to an outgoing key
+// exchange message.
+func (s *Server) stampEVMContracts(tradeID string, msg *node.SwapMessage) {
+	if !isKeyExchange(msg) || s.coordinator == nil {
+		return
+	}
+	contracts, err := s.coordinator.EVMContracts(tradeID)
+	if err != nil || len(contracts) == 0 {
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return
+	}
+	payload["evm_contracts"] = contracts
+	if data, err := json.Marshal(payload); err == nil {
+		msg.Payload = data
+	}
+}
+
+// checkEVMContracts refuses a key exchange message whose sender locks an
+// EVM leg in another HTLC contract version than ours. Senders that don't
+// list their contracts pass.
+func (s *Server) checkEVMContracts(handler node.SwapMessageHandler) node.SwapMessageHandler {
+	return func(ctx context.Context, msg *node.SwapMessage) error {
+		if !isKeyExchange(msg) || msg.TradeID == "" || s.coordinator == nil {
+			return handler(ctx, msg)
+		}
+		var payload struct {
+			EVMContracts map[string]string `json:"evm_contracts"`
+		}
+		if err := json.Unmarshal(msg.Payload, &payload); err != nil || len(payload.EVMContracts) == 0 {
+			return handler(ctx, msg)
+		}
+		if err := s.coordinator.CheckEVMContracts(msg.TradeID, payload.EVMContracts); err != nil {
+			s.log.Warn("Refusing swap message with another HTLC contract version",
+				"trade_id", short(msg.TradeID, 8),
+				"type", msg.Type,
+				"from", short(msg.FromPeer, 12),
+				"error", err,
+			)
+			s.failureCauses.Store(msg.TradeID, failureCause{category: swap.FailureValidationMismatch, reason: err.Error()})
+			if s.wsHub != nil {
+				s.wsHub.Broadcast(EventEVMContractMismatch, map[string]string{
+					"trade_id":  msg.TradeID,
+					"from_peer": msg.FromPeer,
+					"type":      msg.Type,
+					"error":     err.Error(),
+				}, SwapTopic(msg.TradeID))
+			}
+			return nil
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// swapEVMContractMigration reports the HTLC contract versions of each EVM
+// chain and the unfinished trades still on each.
+func (s *Server) swapEVMContractMigration(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+	return &SwapEVMContractMigrationResult{Chains: s.coordinator.EVMContractMigrations()}, nil
+}
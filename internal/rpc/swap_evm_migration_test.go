@@ -0,0 +1,81 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestCheckEVMContracts(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store, Network: chain.Testnet})
+	defer s.coordinator.Close()
+	ctx := context.Background()
+
+	handled := 0
+	handler := s.checkEVMContracts(func(ctx context.Context, msg *node.SwapMessage) error {
+		handled++
+		return nil
+	})
+	message := func(contracts string) *node.SwapMessage {
+		return &node.SwapMessage{
+			Type:    node.SwapMsgHTLCSecretHash,
+			TradeID: "trade-1",
+			Payload: json.RawMessage(`{"secret_hash":"00","evm_contracts":` + contracts + `}`),
+		}
+	}
+
+	sepolia := config.GetHTLCContract(11155111).Hex()
+	for _, contracts := range []string{`{"ETH":"` + sepolia + `"}`, `null`} {
+		if err := handler(ctx, message(contracts)); err != nil || handled != 1 {
+			t.Errorf("contracts %s: handled = %d, error = %v, want passed", contracts, handled, err)
+		}
+		handled = 0
+	}
+
+	if err := handler(ctx, message(`{"ETH":"0x00000000000000000000000000000000000000c2"}`)); err != nil || handled != 0 {
+		t.Errorf("another contract version: handled = %d, error = %v, want refused", handled, err)
+	}
+	if cause, ok := s.failureCauses.Load("trade-1"); !ok || cause.(failureCause).category != swap.FailureValidationMismatch {
+		t.Errorf("failure cause = %+v, want a validation mismatch", cause)
+	}
+
+	// Other messages aren't checked
+	msg := message(`{"ETH":"0x00000000000000000000000000000000000000c2"}`)
+	msg.Type = node.SwapMsgFundingInfo
+	if err := handler(ctx, msg); err != nil || handled != 1 {
+		t.Errorf("funding info: handled = %d, error = %v", handled, err)
+	}
+}
+
+func TestSwapEVMContractMigration(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+	if _, err := s.swapEVMContractMigration(ctx, nil); err == nil {
+		t.Error("swapEVMContractMigration() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store, Network: chain.Testnet})
+	defer s.coordinator.Close()
+	res, err := s.swapEVMContractMigration(ctx, nil)
+	if err != nil {
+		t.Fatalf("swapEVMContractMigration() error = %v", err)
+	}
+	found := false
+	for _, m := range res.(*SwapEVMContractMigrationResult).Chains {
+		if m.Chain == "ETH" {
+			found = true
+			if !m.Drained || len(m.Contracts) == 0 || !m.Contracts[0].Current {
+				t.Errorf("ETH = %+v, want the current contract, drained", m)
+			}
+		}
+	}
+	if !found {
+		t.Error("no ETH chain in the migration report")
+	}
+}
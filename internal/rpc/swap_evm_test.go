@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapEVMCancelCreate(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.swapEVMCancelCreate(ctx, json.RawMessage(`{"chain":"ETH"}`)); err == nil {
+		t.Error("swapEVMCancelCreate() accepted a missing trade_id")
+	}
+	if _, err := s.swapEVMCancelCreate(ctx, json.RawMessage(`{"trade_id":"t1"}`)); err == nil {
+		t.Error("swapEVMCancelCreate() accepted a missing chain")
+	}
+	if _, err := s.swapEVMCancelStatus(ctx, json.RawMessage(`{"trade_id":"t1","chain":"ETH"}`)); err == nil {
+		t.Error("swapEVMCancelStatus() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+
+	if _, err := s.swapEVMCancelCreate(ctx, json.RawMessage(`{"trade_id":"t1","chain":"ETH"}`)); !errors.Is(err, swap.ErrSwapNotFound) {
+		t.Errorf("swapEVMCancelCreate() error = %v, want ErrSwapNotFound", err)
+	}
+	if _, err := s.swapEVMCancelStatus(ctx, json.RawMessage(`{"trade_id":"t1","chain":"ETH"}`)); !errors.Is(err, swap.ErrSwapNotFound) {
+		t.Errorf("swapEVMCancelStatus() error = %v, want ErrSwapNotFound", err)
+	}
+}
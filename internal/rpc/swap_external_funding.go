@@ -0,0 +1,87 @@
+// Package rpc - Swap funding from external wallets.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SwapExternalFundingParams is the parameters for swap_requestExternalFunding
+// and swap_externalFundingStatus.
+type SwapExternalFundingParams struct {
+	TradeID string `json:"trade_id"`
+}
+
+// swapRequestExternalFunding returns what an external wallet must pay to fund
+// our leg and starts watching the chain for it.
+func (s *Server) swapRequestExternalFunding(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapExternalFundingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+
+	ef, err := s.coordinator.RequestExternalFunding(ctx, p.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request external funding: %w", err)
+	}
+	return ef, nil
+}
+
+// swapExternalFundingStatus returns the funding instructions of a swap and
+// whether the funding was found on chain.
+func (s *Server) swapExternalFundingStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapExternalFundingParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+	return s.coordinator.GetExternalFunding(p.TradeID)
+}
+
+// relayExternalFunding tells the counterparty about a UTXO funding found on
+// chain, as swap_setFunding does for funding the user reports. EVM legs are
+// found on the contract by the counterparty.
+func (s *Server) relayExternalFunding(event swap.SwapEvent) {
+	if event.EventType != swap.EventExternalFundingDetected {
+		return
+	}
+	data, _ := event.Data.(map[string]interface{})
+	txID, _ := data["txid"].(string)
+	if txID == "" {
+		return
+	}
+	vout, _ := data["vout"].(uint32)
+
+	if err := s.store.UpdateTradeState(event.TradeID, storage.TradeStateFunding); err != nil {
+		s.log.Warn("Failed to update trade state", "trade_id", event.TradeID, "error", err)
+	}
+
+	msg, err := node.NewSwapMessage(node.SwapMsgFundingInfo, event.TradeID, &node.FundingInfoPayload{TxID: txID, Vout: vout})
+	if err != nil || s.node == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.sendDirectToCounterparty(ctx, event.TradeID, msg); err != nil {
+		s.log.Warn("Failed to send funding info", "trade_id", event.TradeID, "error", err)
+		return
+	}
+	s.log.Info("Sent external funding info to counterparty", "trade_id", short(event.TradeID, 8), "txid", short(txID, 16))
+}
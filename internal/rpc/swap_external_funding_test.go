@@ -0,0 +1,44 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestRelayExternalFundingUpdatesTrade(t *testing.T) {
+	s := newTestStoreServer(t)
+	if err := s.store.CreateTrade(&storage.Trade{
+		ID: "t1", OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "taker",
+		OurRole: storage.TradeRoleMaker, State: storage.TradeStateInit, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+
+	// Requests alone don't change the trade
+	s.relayExternalFunding(swap.SwapEvent{TradeID: "t1", EventType: swap.EventExternalFundingRequested})
+	if trade, _ := s.store.GetTrade("t1"); trade.State != storage.TradeStateInit {
+		t.Errorf("trade state = %s, want init", trade.State)
+	}
+
+	s.relayExternalFunding(swap.SwapEvent{TradeID: "t1", EventType: swap.EventExternalFundingDetected,
+		Data: map[string]interface{}{"txid": "ext-funding", "vout": uint32(1)}})
+	if trade, _ := s.store.GetTrade("t1"); trade.State != storage.TradeStateFunding {
+		t.Errorf("trade state = %s, want funding", trade.State)
+	}
+}
+
+func TestSwapRequestExternalFundingParams(t *testing.T) {
+	s := newTestStoreServer(t)
+	params, _ := json.Marshal(SwapExternalFundingParams{})
+	if _, err := s.swapRequestExternalFunding(context.Background(), params); err == nil {
+		t.Error("expected an error without trade_id")
+	}
+	if _, err := s.swapExternalFundingStatus(context.Background(), params); err == nil {
+		t.Error("expected an error without trade_id")
+	}
+}
@@ -0,0 +1,179 @@
+// Package rpc - Categorized swap failure reporting.
+//
+// Every swap that is refused, aborted or refunded is filed under a
+// swap.FailureCategory, persisted once per trade, counted in the
+// swap_failures_total metric and broadcast as swap_failed. Aborts and
+// refusals are filed when they happen. A refund is filed under the last
+// classified error a swap_* call on the trade returned, or a dropped
+// message with mismatched terms, and as a peer timeout when there was none:
+// the counterparty didn't complete before the timelock. swap_failureStats
+// reports the counts so operators can see why their fill rate is poor.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// EventSwapFailed is broadcast when a swap's failure is categorized.
+const EventSwapFailed EventType = "swap_failed"
+
+// swap_failureStats limits.
+const (
+	defaultFailureStatsLimit = 20
+	maxFailureStatsLimit     = 500
+)
+
+// failureCause is a classified error seen on a trade before it failed.
+type failureCause struct {
+	category swap.FailureCategory
+	reason   string
+}
+
+// SwapFailureStatsParams are the parameters for swap_failureStats.
+type SwapFailureStatsParams struct {
+	Since    int64  `json:"since,omitempty"`    // Unix seconds, 0: all recorded failures
+	Category string `json:"category,omitempty"` // Filters the recent failures
+	Limit    int    `json:"limit,omitempty"`    // Recent failures listed, default 20
+}
+
+// SwapFailureCount is the number of failures in one category.
+type SwapFailureCount struct {
+	Category swap.FailureCategory `json:"category"`
+	Count    int                  `json:"count"`
+	ShareBps int                  `json:"share_bps"` // Of all failures, in basis points
+}
+
+// SwapFailureStatsResult is the result of swap_failureStats.
+type SwapFailureStatsResult struct {
+	Since      int64                  `json:"since"`
+	Total      int                    `json:"total"`
+	Categories []SwapFailureCount     `json:"categories"` // Every category, in taxonomy order
+	Recent     []*storage.SwapFailure `json:"recent"`
+}
+
+// recordSwapFailure files a trade's failure under a category. Only the
+// first failure of a trade is counted.
+func (s *Server) recordSwapFailure(tradeID string, category swap.FailureCategory, reason string) {
+	s.failureCauses.Delete(tradeID)
+	if s.store == nil || tradeID == "" {
+		return
+	}
+	recorded, err := s.store.RecordSwapFailure(&storage.SwapFailure{
+		TradeID:  tradeID,
+		Category: string(category),
+		Reason:   reason,
+	})
+	if err != nil {
+		s.log.Warn("Failed to record swap failure", "trade_id", short(tradeID, 8), "error", err)
+		return
+	}
+	if !recorded {
+		return
+	}
+
+	metrics.SwapFailures.WithLabelValues(string(category)).Inc()
+	s.log.Info("Swap failed", "trade_id", short(tradeID, 8), "category", category, "reason", reason)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(EventSwapFailed, map[string]interface{}{
+			"trade_id": tradeID,
+			"category": category,
+			"reason":   reason,
+		}, SwapTopic(tradeID))
+	}
+}
+
+// noteSwapError remembers the category of an error a swap_* call on a trade
+// returned, to file the trade under if it's refunded later.
+func (s *Server) noteSwapError(method, tradeID string, err error) {
+	if err == nil || tradeID == "" || !strings.HasPrefix(method, "swap_") {
+		return
+	}
+	if category := swap.ClassifyFailure(err); category != swap.FailureUnknown {
+		s.failureCauses.Store(tradeID, failureCause{category: category, reason: err.Error()})
+	}
+}
+
+// classifySwapEvent files refunded and aborted swaps as the coordinator
+// reports them.
+func (s *Server) classifySwapEvent(event swap.SwapEvent) {
+	switch event.EventType {
+	case "swap_refunded", "htlc_refunded", "evm_htlc_refunded":
+		cause := failureCause{category: swap.FailurePeerTimeout, reason: "refunded at timeout"}
+		if noted, ok := s.failureCauses.Load(event.TradeID); ok {
+			cause = noted.(failureCause)
+		}
+		s.recordSwapFailure(event.TradeID, cause.category, cause.reason)
+
+	case "swap_completed", "htlc_claimed", "evm_htlc_claimed":
+		s.failureCauses.Delete(event.TradeID)
+
+	case swap.EventFundingVarianceAborted:
+		data, _ := event.Data.(map[string]interface{})
+		reason, _ := data["reason"].(string)
+		s.recordSwapFailure(event.TradeID, swap.FailureFundingInsufficient, "funding variance: "+reason)
+	}
+}
+
+// refusalCategory files a refused take by its refusal code.
+func refusalCategory(code string) swap.FailureCategory {
+	switch code {
+	case node.RefusalProtocolTooOld:
+		return swap.FailureValidationMismatch
+	default:
+		return swap.FailureUnknown
+	}
+}
+
+// swapFailureStats counts the categorized swap failures.
+func (s *Server) swapFailureStats(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapFailureStatsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if p.Since < 0 || p.Since > time.Now().Unix() {
+		return nil, fmt.Errorf("since must be a past unix time")
+	}
+	if p.Category != "" && !swap.FailureCategory(p.Category).IsValid() {
+		return nil, fmt.Errorf("unknown category %q", p.Category)
+	}
+	if p.Limit <= 0 {
+		p.Limit = defaultFailureStatsLimit
+	}
+	if p.Limit > maxFailureStatsLimit {
+		p.Limit = maxFailureStatsLimit
+	}
+
+	counts, err := s.store.CountSwapFailures(p.Since)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.store.ListSwapFailures(p.Since, p.Category, p.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SwapFailureStatsResult{Since: p.Since, Recent: recent}
+	for _, count := range counts {
+		result.Total += count
+	}
+	for _, category := range swap.FailureCategories() {
+		count := counts[string(category)]
+		share := 0
+		if result.Total > 0 {
+			share = count * 10000 / result.Total
+		}
+		result.Categories = append(result.Categories, SwapFailureCount{Category: category, Count: count, ShareBps: share})
+	}
+	return result, nil
+}
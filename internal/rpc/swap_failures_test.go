@@ -0,0 +1,122 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/Klingon-tech/klingdex/internal/metrics"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestClassifySwapEvent(t *testing.T) {
+	s := newTestStoreServer(t)
+	timeouts := testutil.ToFloat64(metrics.SwapFailures.WithLabelValues(string(swap.FailurePeerTimeout)))
+
+	// A refund with no earlier error is a peer timeout
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t1", EventType: "swap_refunded"})
+
+	// A refund after the safety margin stopped our signing
+	s.noteSwapError("swap_sign", "t2", fmt.Errorf("sign: %w", swap.ErrTimeoutRace))
+	s.noteSwapError("swap_sign", "t2", errors.New("unclassified"))
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t2", EventType: "htlc_refunded"})
+
+	// Causes noted by non-swap methods and of completed swaps are dropped
+	s.noteSwapError("wallet_send", "t3", swap.ErrNoBackend)
+	s.noteSwapError("swap_fund", "t4", swap.ErrNoBackend)
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t4", EventType: "swap_completed"})
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t3", EventType: "evm_htlc_refunded"})
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t4", EventType: "swap_refunded"})
+
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t5", EventType: swap.EventFundingVarianceAborted,
+		Data: map[string]interface{}{"reason": "escrow 10% short"}})
+	// The first cause is kept
+	s.classifySwapEvent(swap.SwapEvent{TradeID: "t5", EventType: "swap_refunded"})
+
+	failures, err := s.store.ListSwapFailures(0, "", 0)
+	if err != nil {
+		t.Fatalf("ListSwapFailures() error = %v", err)
+	}
+	want := map[string]swap.FailureCategory{
+		"t1": swap.FailurePeerTimeout,
+		"t2": swap.FailureTimelockRaced,
+		"t3": swap.FailurePeerTimeout,
+		"t4": swap.FailurePeerTimeout,
+		"t5": swap.FailureFundingInsufficient,
+	}
+	if len(failures) != len(want) {
+		t.Fatalf("ListSwapFailures() = %+v", failures)
+	}
+	for _, f := range failures {
+		if swap.FailureCategory(f.Category) != want[f.TradeID] {
+			t.Errorf("%s filed under %s, want %s", f.TradeID, f.Category, want[f.TradeID])
+		}
+		if f.TradeID == "t5" && f.Reason != "funding variance: escrow 10% short" {
+			t.Errorf("t5 reason = %q", f.Reason)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.SwapFailures.WithLabelValues(string(swap.FailurePeerTimeout))) - timeouts; got != 3 {
+		t.Errorf("peer timeouts counted = %v, want 3", got)
+	}
+}
+
+func TestRefusalCategory(t *testing.T) {
+	if got := refusalCategory(node.RefusalProtocolTooOld); got != swap.FailureValidationMismatch {
+		t.Errorf("refusalCategory(too old) = %s", got)
+	}
+	if got := refusalCategory("other"); got != swap.FailureUnknown {
+		t.Errorf("refusalCategory(other) = %s", got)
+	}
+}
+
+func TestSwapFailureStats(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.recordSwapFailure("t1", swap.FailurePeerTimeout, "")
+	s.recordSwapFailure("t2", swap.FailurePeerTimeout, "")
+	s.recordSwapFailure("t3", swap.FailurePeerTimeout, "")
+	s.recordSwapFailure("t4", swap.FailureBackendError, "broadcast failed")
+
+	result, err := s.swapFailureStats(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("swapFailureStats() error = %v", err)
+	}
+	stats := result.(*SwapFailureStatsResult)
+	if stats.Total != 4 || len(stats.Categories) != len(swap.FailureCategories()) || len(stats.Recent) != 4 {
+		t.Fatalf("swapFailureStats() = %+v", stats)
+	}
+	for _, c := range stats.Categories {
+		switch c.Category {
+		case swap.FailurePeerTimeout:
+			if c.Count != 3 || c.ShareBps != 7500 {
+				t.Errorf("peer timeouts = %+v, want 3 at 7500 bps", c)
+			}
+		case swap.FailureBackendError:
+			if c.Count != 1 || c.ShareBps != 2500 {
+				t.Errorf("backend errors = %+v, want 1 at 2500 bps", c)
+			}
+		default:
+			if c.Count != 0 {
+				t.Errorf("%s = %+v, want none", c.Category, c)
+			}
+		}
+	}
+
+	result, err = s.swapFailureStats(context.Background(), json.RawMessage(`{"category":"backend_error"}`))
+	if err != nil {
+		t.Fatalf("swapFailureStats(backend_error) error = %v", err)
+	}
+	if recent := result.(*SwapFailureStatsResult).Recent; len(recent) != 1 || recent[0].TradeID != "t4" {
+		t.Errorf("recent backend errors = %+v", recent)
+	}
+
+	for _, params := range []string{`{"category":"bad_luck"}`, `{"since":-1}`, `{"since":99999999999}`} {
+		if _, err := s.swapFailureStats(context.Background(), json.RawMessage(params)); err == nil {
+			t.Errorf("swapFailureStats(%s) accepted", params)
+		}
+	}
+}
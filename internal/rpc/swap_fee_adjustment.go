@@ -0,0 +1,363 @@
+// Package rpc - In-band negotiation of fee adjustments.
+//
+// When one chain's mining fees dominate, either party may propose, before
+// swap_init, a change of the request amount that splits the expected fees
+// evenly (swap_negotiateFees). Fees are priced from the fee oracle: the fee
+// rate estimates of UTXO chains and the gas price of EVM chains. The
+// counterparty accepts a proposal within fee_negotiation.max_adjustment_bps
+// that doesn't favor the proposer beyond its own estimate by more than
+// tolerance_bps. Both record the adjustment on the trade, where it changes
+// the request amount and the terms digest. Adjustments are rounded to the
+// request leg's quote precision, the lower of the two parties' (see
+// swap.Precision), so estimates on chains with many decimals don't differ
+// in digits neither party quotes.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/big"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// EventFeeAdjustment is broadcast when a fee adjustment proposal is
+// accepted or rejected, by us or the counterparty.
+const EventFeeAdjustment EventType = "fee_adjustment"
+
+// feeOracle prices the swap transactions of a chain; token is the ERC-20
+// contract address of the leg, empty for the native coin.
+type feeOracle func(ctx context.Context, chainSymbol, token string) (swap.ChainFees, error)
+
+// SwapNegotiateFeesParams is the parameters for swap_negotiateFees.
+type SwapNegotiateFeesParams struct {
+	TradeID string `json:"trade_id"`
+	DryRun  bool   `json:"dry_run,omitempty"` // Only return the estimate
+}
+
+// SwapNegotiateFeesResult is the response for swap_negotiateFees. Fees and
+// the adjustment are in the smallest unit of the request chain.
+type SwapNegotiateFeesResult struct {
+	TradeID    string `json:"trade_id"`
+	MakerFees  uint64 `json:"maker_fees"`
+	TakerFees  uint64 `json:"taker_fees"`
+	Adjustment int64  `json:"adjustment"`
+	Current    int64  `json:"current"`  // Adjustment agreed so far
+	Proposed   bool   `json:"proposed"` // Sent; the reply arrives as a fee_adjustment event
+
+	// QuoteDecimals is the precision the adjustment is rounded to.
+	QuoteDecimals uint8 `json:"quote_decimals"`
+}
+
+// FeeAdjustmentEvent is the data of a fee_adjustment event.
+type FeeAdjustmentEvent struct {
+	TradeID      string `json:"trade_id"`
+	Adjustment   int64  `json:"adjustment"`
+	Accepted     bool   `json:"accepted"`
+	Reason       string `json:"reason,omitempty"`
+	ProposedByUs bool   `json:"proposed_by_us"`
+}
+
+// SetFeeNegotiation sets the fee negotiation configuration.
+func (s *Server) SetFeeNegotiation(cfg node.FeeNegotiationConfig) {
+	s.feeNegotiation = cfg
+}
+
+// chainFees prices the swap transactions of a chain from the fee oracle.
+func (s *Server) chainFees(ctx context.Context, chainSymbol, token string) (swap.ChainFees, error) {
+	if s.feeOracle != nil {
+		return s.feeOracle(ctx, chainSymbol, token)
+	}
+	if s.wallet == nil {
+		return swap.ChainFees{}, fmt.Errorf("wallet service not initialized")
+	}
+
+	if s.wallet.IsEVMChain(chainSymbol) {
+		gasPrice, err := s.wallet.GetEVMGasPrice(ctx, chainSymbol)
+		if err != nil {
+			return swap.ChainFees{}, err
+		}
+		if !gasPrice.IsUint64() {
+			return swap.ChainFees{}, fmt.Errorf("%s gas price out of range", chainSymbol)
+		}
+		return swap.EVMChainFees(gasPrice.Uint64(), token != ""), nil
+	}
+
+	estimate, err := s.wallet.GetFeeEstimates(ctx, chainSymbol)
+	if err != nil {
+		return swap.ChainFees{}, err
+	}
+	rate := estimate.HalfHourFee
+	if rate == 0 {
+		rate = estimate.HourFee
+	}
+	if rate == 0 {
+		return swap.ChainFees{}, fmt.Errorf("no fee estimate for %s", chainSymbol)
+	}
+	return swap.UTXOChainFees(rate), nil
+}
+
+// feeSplit estimates each party's mining fees of a trade, before any
+// adjustment, and the adjustment evening them out, not yet rounded. The
+// split's precision is our quote precision of the request leg.
+func (s *Server) feeSplit(ctx context.Context, trade *storage.Trade) (*swap.FeeSplit, *swap.Offer, error) {
+	order, err := s.store.GetOrder(trade.OrderID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("order not found: %w", err)
+	}
+	offer, _, err := tradeOffer(&storage.Trade{}, order)
+	if err != nil {
+		return nil, nil, err
+	}
+	offerFees, err := s.chainFees(ctx, offer.OfferChain, offer.OfferToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	requestFees, err := s.chainFees(ctx, offer.RequestChain, offer.RequestToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	precision, err := s.legPrecision(offer.RequestChain, offer.RequestToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	split := swap.SplitFees(offer, offerFees, requestFees, s.feeNegotiation.MaxAdjustmentBps)
+	split.Precision = precision
+	return &split, &offer, nil
+}
+
+// checkFeeNegotiable refuses to change the terms of a trade already started.
+func (s *Server) checkFeeNegotiable(trade *storage.Trade) error {
+	if trade.State != storage.TradeStateInit {
+		return fmt.Errorf("trade is %s", trade.State)
+	}
+	if s.coordinator != nil {
+		if active, _ := s.coordinator.GetSwap(trade.ID); active != nil {
+			return fmt.Errorf("swap already initialized")
+		}
+	}
+	return nil
+}
+
+// bpsOf returns bps basis points of an amount.
+func bpsOf(amount uint64, bps uint32) int64 {
+	v := new(big.Int).SetUint64(amount)
+	v.Mul(v, big.NewInt(int64(bps)))
+	v.Quo(v, big.NewInt(10000))
+	if !v.IsInt64() {
+		return 1<<63 - 1
+	}
+	return v.Int64()
+}
+
+// swapNegotiateFees estimates the fee adjustment of a trade and proposes it
+// to the counterparty.
+func (s *Server) swapNegotiateFees(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapNegotiateFeesParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	trade, err := s.store.GetTrade(p.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("trade not found: %w", err)
+	}
+	if err := s.checkFeeNegotiable(trade); err != nil {
+		return nil, fmt.Errorf("cannot negotiate fees: %w", err)
+	}
+	split, _, err := s.feeSplit(ctx, trade)
+	if err != nil {
+		return nil, fmt.Errorf("failed to estimate fees: %w", err)
+	}
+	split.Round(split.Precision)
+
+	result := &SwapNegotiateFeesResult{
+		TradeID:       trade.ID,
+		MakerFees:     split.MakerFees,
+		TakerFees:     split.TakerFees,
+		Adjustment:    split.Adjustment,
+		Current:       trade.FeeAdjustment,
+		QuoteDecimals: split.Precision.QuoteDecimals,
+	}
+	if p.DryRun || split.Adjustment == trade.FeeAdjustment {
+		return result, nil
+	}
+
+	s.feeProposals.Store(trade.ID, split.Adjustment)
+	if err := s.sendFeeAdjustment(ctx, trade.ID, &node.FeeAdjustmentPayload{
+		Adjustment:    split.Adjustment,
+		MakerFees:     split.MakerFees,
+		TakerFees:     split.TakerFees,
+		QuoteDecimals: split.Precision.QuoteDecimals,
+	}); err != nil {
+		s.feeProposals.Delete(trade.ID)
+		return nil, fmt.Errorf("failed to send proposal: %w", err)
+	}
+	result.Proposed = true
+	return result, nil
+}
+
+// sendFeeAdjustment sends a proposal or reply to the counterparty.
+func (s *Server) sendFeeAdjustment(ctx context.Context, tradeID string, payload *node.FeeAdjustmentPayload) error {
+	if s.node == nil || s.coordinator == nil {
+		return fmt.Errorf("node not started")
+	}
+	msg, err := node.NewSwapMessage(node.SwapMsgFeeAdjustment, tradeID, payload)
+	if err != nil {
+		return err
+	}
+	return s.sendDirectToCounterparty(ctx, tradeID, msg)
+}
+
+// handleFeeAdjustment processes a counterparty's proposal or its reply to
+// ours.
+func (s *Server) handleFeeAdjustment(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.FeeAdjustmentPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse fee adjustment payload", "error", err)
+		return nil
+	}
+	if payload.Reply {
+		s.recordFeeReply(msg.TradeID, msg.FromPeer, &payload)
+		return nil
+	}
+	reply := s.reviewFeeProposal(ctx, msg.TradeID, msg.FromPeer, &payload)
+	if reply == nil {
+		return nil
+	}
+	if err := s.sendFeeAdjustment(ctx, msg.TradeID, reply); err != nil {
+		s.log.Warn("Failed to reply to fee adjustment", "trade_id", short(msg.TradeID, 8), "error", err)
+	}
+	return nil
+}
+
+// reviewFeeProposal accepts or rejects a counterparty's proposal, recording
+// it when accepted, and returns the reply (nil for a stranger's message).
+func (s *Server) reviewFeeProposal(ctx context.Context, tradeID, from string, p *node.FeeAdjustmentPayload) *node.FeeAdjustmentPayload {
+	trade, err := s.store.GetTrade(tradeID)
+	if err != nil || (from != trade.MakerPeerID && from != trade.TakerPeerID) {
+		return nil
+	}
+
+	reply := &node.FeeAdjustmentPayload{Adjustment: p.Adjustment, Reply: true}
+	split, reason := s.feeProposalRefusal(ctx, trade, from == trade.MakerPeerID, p)
+	if split != nil {
+		reply.MakerFees, reply.TakerFees = split.MakerFees, split.TakerFees
+		reply.QuoteDecimals = split.Precision.QuoteDecimals
+	}
+	if reason == "" {
+		if err := s.store.UpdateTradeFeeAdjustment(trade.ID, p.Adjustment); err != nil {
+			s.log.Warn("Failed to record fee adjustment", "trade_id", short(tradeID, 8), "error", err)
+			reason = "failed to record the adjustment"
+		}
+	}
+	reply.Accepted = reason == ""
+	reply.Reason = reason
+
+	s.log.Info("Fee adjustment proposed",
+		"trade_id", short(tradeID, 8),
+		"adjustment", p.Adjustment,
+		"accepted", reply.Accepted,
+		"reason", reason,
+	)
+	s.broadcastFeeAdjustment(&FeeAdjustmentEvent{
+		TradeID:    tradeID,
+		Adjustment: p.Adjustment,
+		Accepted:   reply.Accepted,
+		Reason:     reason,
+	})
+	return reply
+}
+
+// feeProposalRefusal returns why we reject a proposed adjustment, or "",
+// with our own estimate at the agreed precision when we could make one.
+func (s *Server) feeProposalRefusal(ctx context.Context, trade *storage.Trade, byMaker bool, p *node.FeeAdjustmentPayload) (*swap.FeeSplit, string) {
+	if !s.feeNegotiation.Enabled {
+		return nil, "fee negotiation disabled"
+	}
+	if err := s.checkFeeNegotiable(trade); err != nil {
+		return nil, err.Error()
+	}
+	split, offer, err := s.feeSplit(ctx, trade)
+	if err != nil {
+		return nil, fmt.Sprintf("no fee estimate: %v", err)
+	}
+	if p.QuoteDecimals != 0 {
+		// The proposer rounds at its own precision, which may be finer
+		announced := swap.Precision{Decimals: split.Precision.Decimals, QuoteDecimals: p.QuoteDecimals}
+		if err := announced.CheckAmount(p.Adjustment); err != nil {
+			return nil, err.Error()
+		}
+	}
+	split.Round(split.Precision.Negotiate(p.QuoteDecimals))
+
+	adjustment := p.Adjustment
+	bound := bpsOf(offer.RequestAmount, s.feeNegotiation.MaxAdjustmentBps)
+	tolerance := bpsOf(offer.RequestAmount, s.feeNegotiation.ToleranceBps)
+	if step := split.Precision.Step(); step > 1 {
+		// Estimates a hair apart may still round to neighbouring steps
+		tolerance += int64(step)
+	}
+	switch {
+	case adjustment > bound || adjustment < -bound:
+		return split, fmt.Sprintf("adjustment %d exceeds the maximum %d", adjustment, bound)
+	case byMaker && adjustment > split.Adjustment+tolerance:
+		return split, fmt.Sprintf("adjustment %d favors the maker beyond our estimate %d", adjustment, split.Adjustment)
+	case !byMaker && adjustment < split.Adjustment-tolerance:
+		return split, fmt.Sprintf("adjustment %d favors the taker beyond our estimate %d", adjustment, split.Adjustment)
+	}
+	return split, ""
+}
+
+// recordFeeReply records the counterparty's answer to our pending proposal.
+func (s *Server) recordFeeReply(tradeID, from string, p *node.FeeAdjustmentPayload) {
+	pending, ok := s.feeProposals.Load(tradeID)
+	if !ok || pending.(int64) != p.Adjustment {
+		return
+	}
+	trade, err := s.store.GetTrade(tradeID)
+	if err != nil || (from != trade.MakerPeerID && from != trade.TakerPeerID) {
+		return
+	}
+	s.feeProposals.Delete(tradeID)
+
+	reason := p.Reason
+	if p.Accepted {
+		if err := s.checkFeeNegotiable(trade); err != nil {
+			// The counterparty recorded it: the terms digests will differ
+			s.log.Warn("Fee adjustment accepted too late", "trade_id", short(tradeID, 8), "error", err)
+		} else if err := s.store.UpdateTradeFeeAdjustment(tradeID, p.Adjustment); err != nil {
+			s.log.Warn("Failed to record fee adjustment", "trade_id", short(tradeID, 8), "error", err)
+		}
+	}
+
+	s.log.Info("Fee adjustment answered",
+		"trade_id", short(tradeID, 8),
+		"adjustment", p.Adjustment,
+		"accepted", p.Accepted,
+		"reason", reason,
+	)
+	s.broadcastFeeAdjustment(&FeeAdjustmentEvent{
+		TradeID:      tradeID,
+		Adjustment:   p.Adjustment,
+		Accepted:     p.Accepted,
+		Reason:       reason,
+		ProposedByUs: true,
+	})
+}
+
+// broadcastFeeAdjustment notifies WebSocket clients of an outcome.
+func (s *Server) broadcastFeeAdjustment(event *FeeAdjustmentEvent) {
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(EventFeeAdjustment, event, SwapTopic(event.TradeID))
+	}
+}
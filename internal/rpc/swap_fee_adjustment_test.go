@@ -0,0 +1,205 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// newFeeNegotiationServer returns a server with a maker offering 1 ETH for
+// 0.05 BTC and fixed fee estimates: converted at the order's rate, the maker
+// expects 16500 sats of fees and the taker 7000, an adjustment of 4750.
+func newFeeNegotiationServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	s.SetFeeNegotiation(node.FeeNegotiationConfig{Enabled: true, MaxAdjustmentBps: 100, ToleranceBps: 1})
+	s.feeOracle = func(ctx context.Context, chainSymbol, token string) (swap.ChainFees, error) {
+		if chainSymbol == "ETH" {
+			return swap.ChainFees{Funding: 3000000000000000, Claim: 1000000000000000}, nil
+		}
+		return swap.ChainFees{Funding: 2000, Claim: 1500}, nil
+	}
+
+	order := &storage.Order{ID: "o1", PeerID: "maker", Status: storage.OrderStatusOpen, OfferChain: "ETH", OfferAmount: 1000000000000000000, RequestChain: "BTC", RequestAmount: 5000000, CreatedAt: time.Now()}
+	if err := s.store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	trade := &storage.Trade{ID: "t1", OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "taker", OfferChain: "ETH", OfferAmount: order.OfferAmount, RequestChain: "BTC", RequestAmount: order.RequestAmount, Method: "htlc", State: storage.TradeStateInit, CreatedAt: time.Now()}
+	if err := s.store.CreateTrade(trade); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+	return s
+}
+
+func TestSwapNegotiateFeesDryRun(t *testing.T) {
+	s := newFeeNegotiationServer(t)
+
+	res, err := s.swapNegotiateFees(context.Background(), json.RawMessage(`{"trade_id":"t1","dry_run":true}`))
+	if err != nil {
+		t.Fatalf("swapNegotiateFees() error = %v", err)
+	}
+	got := res.(*SwapNegotiateFeesResult)
+	if got.MakerFees != 16500 || got.TakerFees != 7000 || got.Adjustment != 4750 || got.Proposed {
+		t.Errorf("swapNegotiateFees() = %+v", got)
+	}
+
+	// Sending needs a running node
+	if _, err := s.swapNegotiateFees(context.Background(), json.RawMessage(`{"trade_id":"t1"}`)); err == nil {
+		t.Error("proposing without a node should fail")
+	}
+	if _, ok := s.feeProposals.Load("t1"); ok {
+		t.Error("a failed proposal should not stay pending")
+	}
+	if _, err := s.swapNegotiateFees(context.Background(), json.RawMessage(`{"trade_id":"missing"}`)); err == nil {
+		t.Error("unknown trade should fail")
+	}
+}
+
+func TestReviewFeeProposal(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name       string
+		from       string
+		adjustment int64
+		accepted   bool
+	}{
+		{"maker at our estimate", "maker", 4750, true},
+		{"maker within tolerance", "maker", 5250, true},
+		{"maker beyond tolerance", "maker", 5251, false},
+		{"taker below our estimate", "taker", 4249, false},
+		{"taker favoring the maker", "taker", 6000, true},
+		{"beyond the maximum", "taker", 50001, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFeeNegotiationServer(t)
+			reply := s.reviewFeeProposal(ctx, "t1", tt.from, &node.FeeAdjustmentPayload{Adjustment: tt.adjustment})
+			if reply == nil || !reply.Reply || reply.Accepted != tt.accepted || reply.Adjustment != tt.adjustment {
+				t.Fatalf("reviewFeeProposal() = %+v, want accepted %v", reply, tt.accepted)
+			}
+			if !tt.accepted && reply.Reason == "" {
+				t.Error("a rejection should say why")
+			}
+			trade, _ := s.store.GetTrade("t1")
+			want := int64(0)
+			if tt.accepted {
+				want = tt.adjustment
+			}
+			if trade.FeeAdjustment != want {
+				t.Errorf("FeeAdjustment = %d, want %d", trade.FeeAdjustment, want)
+			}
+		})
+	}
+
+	s := newFeeNegotiationServer(t)
+	if reply := s.reviewFeeProposal(ctx, "t1", "stranger", &node.FeeAdjustmentPayload{Adjustment: 1}); reply != nil {
+		t.Errorf("stranger's proposal answered: %+v", reply)
+	}
+
+	s.SetFeeNegotiation(node.FeeNegotiationConfig{})
+	if reply := s.reviewFeeProposal(ctx, "t1", "maker", &node.FeeAdjustmentPayload{Adjustment: 4750}); reply.Accepted {
+		t.Error("negotiation disabled: proposal accepted")
+	}
+
+	s = newFeeNegotiationServer(t)
+	s.store.UpdateTradeState("t1", storage.TradeStateAccepted)
+	if reply := s.reviewFeeProposal(ctx, "t1", "maker", &node.FeeAdjustmentPayload{Adjustment: 4750}); reply.Accepted {
+		t.Error("started trade: proposal accepted")
+	}
+}
+
+func TestRecordFeeReply(t *testing.T) {
+	s := newFeeNegotiationServer(t)
+
+	// Not ours: ignored
+	s.recordFeeReply("t1", "taker", &node.FeeAdjustmentPayload{Adjustment: 3000, Reply: true, Accepted: true})
+	if trade, _ := s.store.GetTrade("t1"); trade.FeeAdjustment != 0 {
+		t.Errorf("unsolicited reply recorded: %d", trade.FeeAdjustment)
+	}
+
+	s.feeProposals.Store("t1", int64(3000))
+	s.recordFeeReply("t1", "taker", &node.FeeAdjustmentPayload{Adjustment: 3000, Reply: true, Accepted: true})
+	if trade, _ := s.store.GetTrade("t1"); trade.FeeAdjustment != 3000 {
+		t.Errorf("FeeAdjustment = %d, want 3000", trade.FeeAdjustment)
+	}
+	if _, ok := s.feeProposals.Load("t1"); ok {
+		t.Error("answered proposal still pending")
+	}
+
+	// The adjustment changes the request amount and the terms
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	terms, err := s.tradeTerms("t1")
+	if err != nil {
+		t.Fatalf("tradeTerms() error = %v", err)
+	}
+	if terms.FeeAdjustment != 3000 || terms.RequestAmount != 5003000 {
+		t.Errorf("terms = %+v, want the adjusted request amount", terms)
+	}
+}
+
+func TestFeeAdjustmentPrecision(t *testing.T) {
+	ctx := context.Background()
+	// 0.05 BTC for 1 ETH: the adjustment is in wei, -950000000000061.5
+	// before rounding, -95000 steps of 10^10 wei at 8 quote decimals
+	newServer := func(t *testing.T) *Server {
+		s := newTestStoreServer(t)
+		s.SetFeeNegotiation(node.FeeNegotiationConfig{Enabled: true, MaxAdjustmentBps: 100})
+		s.feeOracle = func(ctx context.Context, chainSymbol, token string) (swap.ChainFees, error) {
+			if chainSymbol == "ETH" {
+				return swap.ChainFees{Funding: 3000000000000123, Claim: 1000000000000000}, nil
+			}
+			return swap.ChainFees{Funding: 2000, Claim: 1500}, nil
+		}
+		order := &storage.Order{ID: "o1", PeerID: "maker", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 5000000, RequestChain: "ETH", RequestAmount: 1000000000000000000, CreatedAt: time.Now()}
+		if err := s.store.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		trade := &storage.Trade{ID: "t1", OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "taker", OfferChain: "BTC", OfferAmount: order.OfferAmount, RequestChain: "ETH", RequestAmount: order.RequestAmount, Method: "htlc", State: storage.TradeStateInit, CreatedAt: time.Now()}
+		if err := s.store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+		return s
+	}
+
+	s := newServer(t)
+	res, err := s.swapNegotiateFees(ctx, json.RawMessage(`{"trade_id":"t1","dry_run":true}`))
+	if err != nil {
+		t.Fatalf("swapNegotiateFees() error = %v", err)
+	}
+	if got := res.(*SwapNegotiateFeesResult); got.Adjustment != -950000000000000 || got.QuoteDecimals != 8 {
+		t.Errorf("swapNegotiateFees() = %+v, want -950000000000000 at 8 decimals", got)
+	}
+
+	tests := []struct {
+		name          string
+		adjustment    int64
+		quoteDecimals uint8
+		precision     uint8 // Our configured quote decimals for ETH
+		accepted      bool
+		replyDecimals uint8
+	}{
+		{"at our estimate", -950000000000000, 8, 0, true, 8},
+		{"one step off", -950010000000000, 8, 0, true, 8},
+		{"two steps off", -950020000000000, 8, 0, false, 8},
+		{"off the proposer's grid", -950000000000061, 8, 0, false, 0},
+		{"unrounded from an older peer", -950000000000061, 0, 0, true, 18},
+		{"finer than ours", -950020000000000, 8, 6, true, 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t)
+			if tt.precision != 0 {
+				s.SetPrecision(node.PrecisionConfig{QuoteDecimals: map[string]uint8{"ETH": tt.precision}})
+			}
+			reply := s.reviewFeeProposal(ctx, "t1", "taker", &node.FeeAdjustmentPayload{Adjustment: tt.adjustment, QuoteDecimals: tt.quoteDecimals})
+			if reply == nil || reply.Accepted != tt.accepted || reply.QuoteDecimals != tt.replyDecimals {
+				t.Fatalf("reviewFeeProposal() = %+v, want accepted %v at %d decimals", reply, tt.accepted, tt.replyDecimals)
+			}
+		})
+	}
+}
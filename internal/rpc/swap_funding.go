@@ -4,6 +4,7 @@ package rpc
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 
 	"github.com/Klingon-tech/klingdex/internal/node"
@@ -132,7 +133,7 @@ func (s *Server) swapSetFunding(ctx context.Context, params json.RawMessage) (in
 		if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 			s.log.Warn("Failed to send funding info", "trade_id", p.TradeID, "error", err)
 		} else {
-			s.log.Info("Sent funding info to counterparty", "trade_id", p.TradeID[:8], "txid", p.TxID[:16])
+			s.log.Info("Sent funding info to counterparty", "trade_id", short(p.TradeID, 8), "txid", short(p.TxID, 16))
 		}
 	}
 
@@ -154,7 +155,7 @@ func (s *Server) swapSetFunding(ctx context.Context, params json.RawMessage) (in
 			"trade_id": p.TradeID,
 			"txid":     p.TxID,
 			"vout":     p.Vout,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapSetFundingResult{
@@ -196,6 +197,17 @@ func (s *Server) swapCheckFunding(ctx context.Context, params json.RawMessage) (
 	// Ready for nonce exchange if both sides are funded
 	result.ReadyForNonceExchange = result.BothFunded
 
+	localChain, remoteChain := activeSwap.Swap.Offer.RequestChain, activeSwap.Swap.Offer.OfferChain
+	if activeSwap.Swap.Role == swap.RoleInitiator {
+		localChain, remoteChain = remoteChain, localChain
+	}
+	if result.LocalFunded {
+		result.LocalFinality = s.finalityOf(localChain, int64(result.LocalConfirmations))
+	}
+	if result.RemoteFunded {
+		result.RemoteFinality = s.finalityOf(remoteChain, int64(result.RemoteConfirmations))
+	}
+
 	return result, nil
 }
 
@@ -213,31 +225,102 @@ func (s *Server) swapFund(ctx context.Context, params json.RawMessage) (interfac
 
 	// Call the coordinator's FundSwap method
 	fundResult, err := s.coordinator.FundSwap(ctx, p.TradeID)
+	var held *swap.ConfirmationRequiredError
+	if errors.As(err, &held) {
+		step := held.Step
+		return &SwapFundResult{
+			TradeID:     p.TradeID,
+			Chain:       step.Chain,
+			Amount:      step.Amount,
+			Fee:         step.Fee,
+			EscrowVout:  step.EscrowVout,
+			EscrowAddr:  step.EscrowAddr,
+			InputCount:  step.InputCount,
+			TotalInput:  step.TotalInput,
+			Change:      step.Change,
+			State:       "awaiting_confirmation",
+			PendingStep: step,
+		}, nil
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to fund swap: %w", err)
 	}
 
+	return s.announceFunding(ctx, p.TradeID, fundResult), nil
+}
+
+// swapBumpFee raises the fee of our unconfirmed funding transaction by
+// replacing it (RBF) or spending its change (CPFP).
+func (s *Server) swapBumpFee(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapBumpFeeParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+
+	result, err := s.coordinator.BumpFundingFee(ctx, p.TradeID, p.Method, p.FeeRate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to bump funding fee: %w", err)
+	}
+
+	// A replacement changes the funding txid the counterparty watches
+	if result.FundingTxID != result.BumpedTxID {
+		payload := &node.FundingInfoPayload{
+			TxID: result.FundingTxID,
+			Vout: result.EscrowVout,
+		}
+		msg, err := node.NewSwapMessage(node.SwapMsgFundingInfo, p.TradeID, payload)
+		if err == nil {
+			if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
+				s.log.Warn("Failed to send replaced funding info", "trade_id", p.TradeID, "error", err)
+			} else {
+				s.log.Info("Sent replaced funding info to counterparty", "trade_id", short(p.TradeID, 8), "txid", short(result.FundingTxID, 16))
+			}
+		}
+	}
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast(swap.EventFundingFeeBumped, map[string]interface{}{
+			"trade_id":     p.TradeID,
+			"chain":        result.Chain,
+			"method":       result.Method,
+			"txid":         result.TxID,
+			"bumped_txid":  result.BumpedTxID,
+			"funding_txid": result.FundingTxID,
+			"fee_rate":     result.FeeRate,
+		}, SwapTopic(p.TradeID))
+	}
+
+	return result, nil
+}
+
+// announceFunding tells the counterparty and subscribers about our
+// broadcast funding transaction.
+func (s *Server) announceFunding(ctx context.Context, tradeID string, fundResult *swap.FundSwapResult) *SwapFundResult {
 	// Send funding info to counterparty (direct P2P)
 	fundPayload := &node.FundingInfoPayload{
 		TxID: fundResult.TxID,
 		Vout: fundResult.EscrowVout,
 	}
-	fundMsg, err := node.NewSwapMessage(node.SwapMsgFundingInfo, p.TradeID, fundPayload)
+	fundMsg, err := node.NewSwapMessage(node.SwapMsgFundingInfo, tradeID, fundPayload)
 	if err == nil {
-		if err := s.sendDirectToCounterparty(ctx, p.TradeID, fundMsg); err != nil {
-			s.log.Warn("Failed to send funding info", "trade_id", p.TradeID, "error", err)
+		if err := s.sendDirectToCounterparty(ctx, tradeID, fundMsg); err != nil {
+			s.log.Warn("Failed to send funding info", "trade_id", tradeID, "error", err)
 		} else {
-			s.log.Info("Sent funding info to counterparty", "trade_id", p.TradeID[:8], "txid", fundResult.TxID[:16])
+			s.log.Info("Sent funding info to counterparty", "trade_id", short(tradeID, 8), "txid", short(fundResult.TxID, 16))
 		}
 	}
 
 	// Update trade state
-	if err := s.store.UpdateTradeState(p.TradeID, storage.TradeStateFunding); err != nil {
+	if err := s.store.UpdateTradeState(tradeID, storage.TradeStateFunding); err != nil {
 		s.log.Warn("Failed to update trade state", "error", err)
 	}
 
 	// Get current swap state
-	activeSwap, _ := s.coordinator.GetSwap(p.TradeID)
+	activeSwap, _ := s.coordinator.GetSwap(tradeID)
 	state := "funding"
 	if activeSwap != nil {
 		state = string(activeSwap.Swap.State)
@@ -246,16 +329,16 @@ func (s *Server) swapFund(ctx context.Context, params json.RawMessage) (interfac
 	// Emit WebSocket event
 	if s.wsHub != nil {
 		s.wsHub.Broadcast("funding_broadcast", map[string]interface{}{
-			"trade_id":    p.TradeID,
+			"trade_id":    tradeID,
 			"txid":        fundResult.TxID,
 			"chain":       fundResult.Chain,
 			"amount":      fundResult.Amount,
 			"escrow_vout": fundResult.EscrowVout,
-		})
+		}, SwapTopic(tradeID))
 	}
 
 	return &SwapFundResult{
-		TradeID:    p.TradeID,
+		TradeID:    tradeID,
 		TxID:       fundResult.TxID,
 		Chain:      fundResult.Chain,
 		Amount:     fundResult.Amount,
@@ -266,5 +349,5 @@ func (s *Server) swapFund(ctx context.Context, params json.RawMessage) (interfac
 		TotalInput: fundResult.TotalInput,
 		Change:     fundResult.Change,
 		State:      state,
-	}, nil
+	}
 }
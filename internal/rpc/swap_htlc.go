@@ -40,7 +40,7 @@ func (s *Server) swapHTLCRevealSecret(ctx context.Context, params json.RawMessag
 		if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 			s.log.Warn("Failed to send secret", "trade_id", p.TradeID, "error", err)
 		} else {
-			s.log.Info("Sent HTLC secret to counterparty", "trade_id", p.TradeID[:8])
+			s.log.Info("Sent HTLC secret to counterparty", "trade_id", short(p.TradeID, 8))
 		}
 	}
 
@@ -50,7 +50,7 @@ func (s *Server) swapHTLCRevealSecret(ctx context.Context, params json.RawMessag
 			"trade_id":    p.TradeID,
 			"secret":      secretHex,
 			"secret_hash": secretHashHex,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapHTLCRevealSecretResult{
@@ -151,6 +151,34 @@ func (s *Server) swapHTLCRefund(ctx context.Context, params json.RawMessage) (in
 	}, nil
 }
 
+// swapHTLCBatchSettle claims and refunds all eligible HTLCs on a chain in one transaction.
+func (s *Server) swapHTLCBatchSettle(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapHTLCBatchSettleParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	if p.Chain == "" {
+		return nil, fmt.Errorf("chain is required")
+	}
+
+	result, err := s.coordinator.BatchSettleHTLCs(ctx, p.Chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to batch settle HTLCs: %w", err)
+	}
+
+	s.log.Info("HTLC batch settled", "chain", p.Chain, "txid", result.TxID,
+		"claimed", len(result.Claimed), "refunded", len(result.Refunded))
+
+	return &SwapHTLCBatchSettleResult{
+		Chain:    result.Chain,
+		TxID:     result.TxID,
+		Claimed:  result.Claimed,
+		Refunded: result.Refunded,
+		Fee:      result.Fee,
+	}, nil
+}
+
 // swapHTLCExtractSecret extracts the secret from an HTLC claim transaction.
 func (s *Server) swapHTLCExtractSecret(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var p SwapHTLCExtractSecretParams
@@ -59,23 +59,34 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 		}, nil
 	}
 
-	// Create offer struct for coordinator
-	offer := swap.Offer{
-		OfferChain:    order.OfferChain,
-		OfferAmount:   order.OfferAmount,
-		RequestChain:  order.RequestChain,
-		RequestAmount: order.RequestAmount,
-		Method:        swap.Method(trade.Method),
+	// Create offer struct for coordinator (method defaults to MuSig2)
+	offer, method, err := tradeOffer(trade, order)
+	if err != nil {
+		return nil, err
+	}
+	offer.Method = method
+	if err := swap.ValidateOffer(offer, s.coordinator.Network()); err != nil {
+		return nil, err
 	}
 
 	// Determine if we're maker or taker
 	isMaker := trade.MakerPeerID == s.node.ID().String()
 	s.log.Info("swap_init calling coordinator", "isMaker", isMaker, "trade_id", p.TradeID)
 
-	// Determine swap method (default to MuSig2 if not specified)
-	method := offer.Method
-	if method == "" {
-		method = swap.MethodMuSig2
+	// Validate the payout address before creating the swap
+	var warnings []string
+	if p.PayoutAddress != "" {
+		role := swap.RoleResponder
+		if isMaker {
+			role = swap.RoleInitiator
+		}
+		payoutChain := swap.ReceivingChain(offer, role)
+		if _, err := swap.ValidatePayoutAddress(payoutChain, s.coordinator.Network(), p.PayoutAddress); err != nil {
+			return nil, fmt.Errorf("invalid payout_address: %w", err)
+		}
+		if warnings, err = s.checkAddressReuse(payoutChain, p.PayoutAddress, storage.AddressUsePayout, p.AllowAddressReuse); err != nil {
+			return nil, err
+		}
 	}
 
 	var activeSwap *swap.ActiveSwap
@@ -91,7 +102,7 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 			if err != nil {
 				return nil, fmt.Errorf("invalid maker pubkey in trade: %w", err)
 			}
-			s.log.Info("swap_init: using maker pubkey from trade", "pubkey", trade.MakerPubKey[:16])
+			s.log.Info("swap_init: using maker pubkey from trade", "pubkey", short(trade.MakerPubKey, 16))
 		} else {
 			// Wait for maker's pubkey - return error asking to retry
 			return nil, fmt.Errorf("maker pubkey not yet received - the maker needs to call swap_init first, then retry")
@@ -104,8 +115,10 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 			if err == nil && len(secrets) > 0 {
 				for _, secret := range secrets {
 					if secret.CreatedBy == storage.SecretCreatorThem && secret.SecretHash != "" {
-						secretHash, _ = hex.DecodeString(secret.SecretHash)
-						s.log.Info("swap_init: using secret hash from storage", "hash", secret.SecretHash[:16])
+						if secretHash, err = swap.ParseSecretHash(secret.SecretHash); err != nil {
+							return nil, fmt.Errorf("invalid stored secret hash: %w", err)
+						}
+						s.log.Info("swap_init: using secret hash from storage", "hash", short(secret.SecretHash, 16))
 						break
 					}
 				}
@@ -136,23 +149,38 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 		s.log.Warn("Failed to store local pubkey in trade", "error", err)
 	}
 
-	// Get our wallet addresses for both chains (using proper index management)
+	// Get our wallet addresses for both chains (using proper index management).
+	// XMR legs pay to the monero wallet.
 	var offerWalletAddr, requestWalletAddr string
 	if s.wallet != nil {
 		var err error
-		offerWalletAddr, _, err = s.getNextWalletAddress(activeSwap.Swap.Offer.OfferChain)
-		if err != nil {
-			s.log.Warn("Failed to get offer wallet address", "error", err)
+		network := s.coordinator.Network()
+		if !swap.IsMoneroChain(activeSwap.Swap.Offer.OfferChain, network) {
+			offerWalletAddr, _, err = s.getNextWalletAddress(activeSwap.Swap.Offer.OfferChain)
+			if err != nil {
+				s.log.Warn("Failed to get offer wallet address", "error", err)
+			}
 		}
-		requestWalletAddr, _, err = s.getNextWalletAddress(activeSwap.Swap.Offer.RequestChain)
-		if err != nil {
-			s.log.Warn("Failed to get request wallet address", "error", err)
+		if !swap.IsMoneroChain(activeSwap.Swap.Offer.RequestChain, network) {
+			requestWalletAddr, _, err = s.getNextWalletAddress(activeSwap.Swap.Offer.RequestChain)
+			if err != nil {
+				s.log.Warn("Failed to get request wallet address", "error", err)
+			}
 		}
 		// Store our own wallet addresses in the swap
 		activeSwap.Swap.LocalOfferWalletAddr = offerWalletAddr
 		activeSwap.Swap.LocalRequestWalletAddr = requestWalletAddr
 	}
 
+	// An external payout address replaces our wallet address on the receiving chain
+	if p.PayoutAddress != "" {
+		if err := s.coordinator.SetPayoutAddress(p.TradeID, p.PayoutAddress); err != nil {
+			return nil, fmt.Errorf("failed to set payout address: %w", err)
+		}
+		offerWalletAddr = activeSwap.Swap.LocalOfferWalletAddr
+		requestWalletAddr = activeSwap.Swap.LocalRequestWalletAddr
+	}
+
 	// Send our public key and wallet addresses to the counterparty (direct P2P)
 	// For MuSig2: send pubkey exchange
 	if activeSwap.IsMuSig2() {
@@ -166,7 +194,7 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 			if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 				s.log.Warn("Failed to send pubkey", "trade_id", p.TradeID, "error", err)
 			} else {
-				s.log.Info("Sent pubkey to counterparty", "trade_id", p.TradeID[:8])
+				s.log.Info("Sent pubkey to counterparty", "trade_id", short(p.TradeID, 8))
 			}
 		}
 	}
@@ -181,7 +209,7 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 				if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 					s.log.Warn("Failed to send secret hash", "trade_id", p.TradeID, "error", err)
 				} else {
-					s.log.Info("Sent HTLC secret hash to counterparty", "trade_id", p.TradeID[:8])
+					s.log.Info("Sent HTLC secret hash to counterparty", "trade_id", short(p.TradeID, 8))
 				}
 			}
 		} else {
@@ -196,12 +224,19 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 				if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 					s.log.Warn("Failed to send pubkey", "trade_id", p.TradeID, "error", err)
 				} else {
-					s.log.Info("Sent pubkey to counterparty", "trade_id", p.TradeID[:8])
+					s.log.Info("Sent pubkey to counterparty", "trade_id", short(p.TradeID, 8))
 				}
 			}
 		}
 	}
 
+	// For adaptor swaps: send our key share, which carries the pubkey
+	if activeSwap.IsXMR() {
+		if err := s.sendXMRKeyShare(ctx, p.TradeID); err != nil {
+			s.log.Warn("Failed to send XMR key share", "trade_id", p.TradeID, "error", err)
+		}
+	}
+
 	// Update trade state to accepted
 	if err := s.store.UpdateTradeState(p.TradeID, storage.TradeStateAccepted); err != nil {
 		s.log.Warn("Failed to update trade state", "error", err)
@@ -212,12 +247,13 @@ func (s *Server) swapInit(ctx context.Context, params json.RawMessage) (interfac
 		s.wsHub.Broadcast("swap_initialized", map[string]string{
 			"trade_id":     p.TradeID,
 			"local_pubkey": pubKeyHex,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapInitResult{
 		TradeID:     p.TradeID,
 		LocalPubKey: pubKeyHex,
 		State:       string(activeSwap.Swap.State),
+		Warnings:    warnings,
 	}, nil
 }
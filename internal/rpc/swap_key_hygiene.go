@@ -0,0 +1,35 @@
+// Package rpc - Key hygiene audit of finished swaps.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// SwapKeyHygieneResult is the result of swap_keyHygiene.
+type SwapKeyHygieneResult struct {
+	*swap.KeyHygieneReport
+	Clean         bool                   `json:"clean"`
+	MemoryLocking wallet.MemoryLockStats `json:"memory_locking"`
+}
+
+// swapKeyHygiene audits the finished swaps for private keys and secrets
+// left in memory or in the database, scrubbing what it finds.
+func (s *Server) swapKeyHygiene(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+	report, err := s.coordinator.AuditKeyHygiene()
+	if err != nil {
+		return nil, err
+	}
+	return &SwapKeyHygieneResult{
+		KeyHygieneReport: report,
+		Clean:            report.Clean(),
+		MemoryLocking:    wallet.MemoryLocking(),
+	}, nil
+}
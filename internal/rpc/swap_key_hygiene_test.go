@@ -0,0 +1,29 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapKeyHygiene(t *testing.T) {
+	s := newTestStoreServer(t)
+	if _, err := s.swapKeyHygiene(context.Background(), nil); err == nil {
+		t.Error("swapKeyHygiene() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+	result, err := s.swapKeyHygiene(context.Background(), nil)
+	if err != nil {
+		t.Fatalf("swapKeyHygiene() error = %v", err)
+	}
+	r := result.(*SwapKeyHygieneResult)
+	if !r.Clean || r.Swaps != 0 || r.CheckedAt.IsZero() {
+		t.Errorf("swapKeyHygiene() = %+v, want a clean audit of no swaps", r)
+	}
+	if s.coordinator.LastKeyHygieneReport() != r.KeyHygieneReport {
+		t.Error("audit report not kept")
+	}
+}
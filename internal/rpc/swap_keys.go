@@ -0,0 +1,57 @@
+// Package rpc - Audit of the keys and wallet paths used by a swap.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// SwapGetKeyUsageParams is the parameters for swap_getKeyUsage.
+type SwapGetKeyUsageParams struct {
+	TradeID string `json:"trade_id"`
+}
+
+// SwapGetKeyUsageResult is the result of swap_getKeyUsage.
+type SwapGetKeyUsageResult struct {
+	TradeID string                  `json:"trade_id"`
+	Keys    []*storage.SwapKeyUsage `json:"keys"`
+	// The swap used a key that the seed alone can't restore: funds in its
+	// escrows are recoverable only from the database or a backup
+	BackupRequired bool `json:"backup_required"`
+}
+
+// swapGetKeyUsage lists the ephemeral keys and wallet derivation paths a
+// swap used, without private material.
+func (s *Server) swapGetKeyUsage(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapGetKeyUsageParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+
+	keys, err := s.store.GetSwapKeyUsage(p.TradeID)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		if _, err := s.store.GetTrade(p.TradeID); err != nil {
+			return nil, fmt.Errorf("trade not found: %s", p.TradeID)
+		}
+	}
+
+	result := &SwapGetKeyUsageResult{TradeID: p.TradeID, Keys: keys}
+	for _, k := range keys {
+		if k.Recovery == storage.KeyRecoveryBackup {
+			result.BackupRequired = true
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,48 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestSwapGetKeyUsage(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.swapGetKeyUsage(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("swapGetKeyUsage() accepted a missing trade_id")
+	}
+	if _, err := s.swapGetKeyUsage(ctx, json.RawMessage(`{"trade_id":"missing"}`)); err == nil {
+		t.Error("swapGetKeyUsage() succeeded for an unknown trade")
+	}
+
+	for _, u := range []*storage.SwapKeyUsage{
+		{TradeID: "t1", Purpose: storage.KeyPurposeReceive, Chain: "LTC", Address: "tltc1q",
+			DerivationPath: "m/84'/1'/0'/0/1", Recovery: storage.KeyRecoverySeed},
+		{TradeID: "t2", Purpose: storage.KeyPurposeSwapKey, Chain: "BTC", PubKey: "02aa", Recovery: storage.KeyRecoveryBackup},
+	} {
+		if err := s.store.RecordSwapKeyUsage(u); err != nil {
+			t.Fatalf("RecordSwapKeyUsage() error = %v", err)
+		}
+	}
+
+	result, err := s.swapGetKeyUsage(ctx, json.RawMessage(`{"trade_id":"t1"}`))
+	if err != nil {
+		t.Fatalf("swapGetKeyUsage() error = %v", err)
+	}
+	got := result.(*SwapGetKeyUsageResult)
+	if len(got.Keys) != 1 || got.Keys[0].DerivationPath != "m/84'/1'/0'/0/1" || got.BackupRequired {
+		t.Errorf("swapGetKeyUsage(t1) = %+v, want one seed-derived key", got)
+	}
+
+	result, err = s.swapGetKeyUsage(ctx, json.RawMessage(`{"trade_id":"t2"}`))
+	if err != nil {
+		t.Fatalf("swapGetKeyUsage() error = %v", err)
+	}
+	if got := result.(*SwapGetKeyUsageResult); !got.BackupRequired {
+		t.Errorf("swapGetKeyUsage(t2) = %+v, want backup_required", got)
+	}
+}
@@ -0,0 +1,124 @@
+// Package rpc - Lightning settlement of BTC swap legs.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SwapLightningParams is the parameters for swap_lightningInvoice,
+// swap_lightningPay and swap_lightningStatus.
+type SwapLightningParams struct {
+	TradeID string `json:"trade_id"`
+}
+
+// SwapLightningPayResult is the result of swap_lightningPay.
+type SwapLightningPayResult struct {
+	*swap.LightningLeg
+	ClaimTxID string `json:"claim_txid,omitempty"` // Empty if the claim must be retried
+}
+
+func (s *Server) parseSwapLightningParams(params json.RawMessage) (*SwapLightningParams, error) {
+	var p SwapLightningParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+	return &p, nil
+}
+
+// swapLightningInvoice issues the maker's invoice for the BTC leg and sends
+// it to the taker.
+func (s *Server) swapLightningInvoice(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p, err := s.parseSwapLightningParams(params)
+	if err != nil {
+		return nil, err
+	}
+	leg, err := s.coordinator.CreateLightningInvoice(ctx, p.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create lightning invoice: %w", err)
+	}
+	return leg, nil
+}
+
+// swapLightningPay pays the maker's invoice instead of funding the BTC leg
+// and claims the maker's leg with the secret it returns.
+func (s *Server) swapLightningPay(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p, err := s.parseSwapLightningParams(params)
+	if err != nil {
+		return nil, err
+	}
+	leg, claimTxID, err := s.coordinator.PayLightningInvoice(ctx, p.TradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to pay lightning invoice: %w", err)
+	}
+	return &SwapLightningPayResult{LightningLeg: leg, ClaimTxID: claimTxID}, nil
+}
+
+// swapLightningStatus returns the Lightning leg of a swap.
+func (s *Server) swapLightningStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	p, err := s.parseSwapLightningParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.coordinator.GetLightningLeg(p.TradeID)
+}
+
+// relayLightningInvoice sends the maker's invoice to the taker.
+func (s *Server) relayLightningInvoice(event swap.SwapEvent) {
+	if event.EventType != swap.EventLightningInvoiceCreated {
+		return
+	}
+	data, _ := event.Data.(map[string]interface{})
+	invoice, _ := data["invoice"].(string)
+	if invoice == "" {
+		return
+	}
+
+	msg, err := node.NewSwapMessage(node.SwapMsgLightningInvoice, event.TradeID, &node.LightningInvoicePayload{Invoice: invoice})
+	if err != nil || s.node == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.sendDirectToCounterparty(ctx, event.TradeID, msg); err != nil {
+		s.log.Warn("Failed to send lightning invoice", "trade_id", event.TradeID, "error", err)
+		return
+	}
+	s.log.Info("Sent lightning invoice to counterparty", "trade_id", short(event.TradeID, 8))
+}
+
+// handleLightningInvoice records the maker's invoice. Paying it is manual
+// (swap_lightningPay), after the maker's leg confirmed.
+func (s *Server) handleLightningInvoice(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.LightningInvoicePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.Invoice == "" {
+		s.log.Warn("Failed to parse lightning invoice payload", "error", err)
+		return nil
+	}
+	if s.coordinator == nil {
+		return nil
+	}
+
+	// The invoice is checked against the swap terms when it is paid
+	if err := s.coordinator.SetLightningInvoice(msg.TradeID, payload.Invoice); err != nil {
+		s.log.Warn("Ignoring lightning invoice", "trade_id", short(msg.TradeID, 8), "error", err)
+		return nil
+	}
+	s.log.Info("Received lightning invoice for the BTC leg", "trade_id", short(msg.TradeID, 8))
+	return nil
+}
@@ -0,0 +1,59 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapLightningParams(t *testing.T) {
+	s := newTestStoreServer(t)
+	params, _ := json.Marshal(SwapLightningParams{})
+	for name, h := range map[string]Handler{
+		"swap_lightningInvoice": s.swapLightningInvoice,
+		"swap_lightningPay":     s.swapLightningPay,
+		"swap_lightningStatus":  s.swapLightningStatus,
+	} {
+		if _, err := h(context.Background(), params); err == nil {
+			t.Errorf("%s: expected an error without trade_id", name)
+		}
+	}
+
+	params, _ = json.Marshal(SwapLightningParams{TradeID: "t1"})
+	if _, err := s.swapLightningPay(context.Background(), params); err == nil || !strings.Contains(err.Error(), "coordinator") {
+		t.Errorf("swap_lightningPay without a coordinator error = %v", err)
+	}
+}
+
+func TestCheckOrderSettlement(t *testing.T) {
+	s := newTestStoreServer(t)
+	if err := s.checkOrderSettlement("BTC", nil); err != nil {
+		t.Errorf("checkOrderSettlement(none) error = %v", err)
+	}
+	if err := s.checkOrderSettlement("BTC", []string{"teleport"}); err == nil {
+		t.Error("checkOrderSettlement() accepted an unknown option")
+	}
+	if err := s.checkOrderSettlement("LTC", []string{swap.SettlementLightning}); err == nil {
+		t.Error("checkOrderSettlement() accepted lightning for an LTC request leg")
+	}
+
+	// Lightning must be configured to advertise it
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Network: chain.Testnet})
+	defer s.coordinator.Close()
+	if err := s.checkOrderSettlement("BTC", []string{swap.SettlementLightning}); err == nil || !strings.Contains(err.Error(), "enabled") {
+		t.Errorf("checkOrderSettlement() without lightning error = %v", err)
+	}
+}
+
+func TestRelayLightningInvoiceIgnoresOtherEvents(t *testing.T) {
+	s := newTestStoreServer(t)
+	// No node: nothing is sent, and other events and empty invoices are skipped
+	s.relayLightningInvoice(swap.SwapEvent{TradeID: "t1", EventType: swap.EventLightningPaid})
+	s.relayLightningInvoice(swap.SwapEvent{TradeID: "t1", EventType: swap.EventLightningInvoiceCreated})
+	s.relayLightningInvoice(swap.SwapEvent{TradeID: "t1", EventType: swap.EventLightningInvoiceCreated,
+		Data: map[string]interface{}{"invoice": "lntb1"}})
+}
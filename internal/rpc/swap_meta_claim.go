@@ -0,0 +1,234 @@
+// Package rpc - Relayed EVM claims (meta-claims) for receivers without gas.
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// Relayer choices of swap_evmMetaClaim besides an explicit address.
+const (
+	metaClaimRelayerCounterparty = "counterparty" // The HTLC sender, over P2P (default)
+	metaClaimRelayerConfigured   = "configured"   // meta_claim.relayers for the chain
+	metaClaimRelayerAny          = "any"          // Anyone holding the authorization
+)
+
+// SwapEVMMetaClaimParams is the parameters for swap_evmMetaClaim.
+type SwapEVMMetaClaimParams struct {
+	TradeID    string `json:"trade_id"`
+	Chain      string `json:"chain"`
+	Relayer    string `json:"relayer,omitempty"`     // counterparty (default), configured, any or an address
+	RelayerFee string `json:"relayer_fee,omitempty"` // Smallest unit; defaults to the negotiated fee
+	ValidSecs  int64  `json:"valid_secs,omitempty"`  // Defaults to meta_claim.valid_for
+}
+
+// SwapEVMMetaClaimResult is the result of swap_evmMetaClaim.
+type SwapEVMMetaClaimResult struct {
+	TradeID string             `json:"trade_id"`
+	Chain   string             `json:"chain"`
+	Sent    bool               `json:"sent"`  // Sent to the counterparty to relay
+	Claim   *swap.EVMMetaClaim `json:"claim"` // Hand to a third-party relayer when not sent
+}
+
+// SetMetaClaim sets the relayed EVM claim settings.
+func (s *Server) SetMetaClaim(cfg node.MetaClaimConfig) {
+	s.metaClaim = cfg
+}
+
+// swapEVMMetaClaim authorizes a relayer to claim our EVM leg and keep a fee
+// out of it, for when we hold no gas on the chain.
+func (s *Server) swapEVMMetaClaim(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapEVMMetaClaimParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if p.Chain == "" {
+		return nil, fmt.Errorf("chain is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not initialized")
+	}
+
+	var relayer *common.Address
+	switch p.Relayer {
+	case "", metaClaimRelayerCounterparty:
+	case metaClaimRelayerAny:
+		relayer = &common.Address{}
+	case metaClaimRelayerConfigured:
+		addr, ok := s.metaClaim.Relayers[strings.ToUpper(p.Chain)]
+		if !ok || !common.IsHexAddress(addr) {
+			return nil, fmt.Errorf("no relayer configured for %s", p.Chain)
+		}
+		a := common.HexToAddress(addr)
+		relayer = &a
+	default:
+		if !common.IsHexAddress(p.Relayer) {
+			return nil, fmt.Errorf("invalid relayer %q", p.Relayer)
+		}
+		a := common.HexToAddress(p.Relayer)
+		relayer = &a
+	}
+
+	var fee *big.Int
+	if p.RelayerFee != "" {
+		var ok bool
+		if fee, ok = new(big.Int).SetString(p.RelayerFee, 10); !ok || fee.Sign() < 0 {
+			return nil, fmt.Errorf("invalid relayer_fee %q", p.RelayerFee)
+		}
+	}
+	validFor := s.metaClaim.ValidFor
+	if p.ValidSecs > 0 {
+		validFor = time.Duration(p.ValidSecs) * time.Second
+	}
+	if validFor <= 0 {
+		validFor = time.Hour
+	}
+
+	claim, err := s.coordinator.AuthorizeEVMMetaClaim(ctx, p.TradeID, p.Chain, relayer, fee, validFor)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize claim: %w", err)
+	}
+
+	result := &SwapEVMMetaClaimResult{TradeID: p.TradeID, Chain: p.Chain, Claim: claim}
+	if relayer == nil {
+		msg, err := node.NewEVMMetaClaimMessage(p.TradeID, claim)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create meta-claim message: %w", err)
+		}
+		if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
+			return nil, fmt.Errorf("failed to send meta-claim to counterparty: %w", err)
+		}
+		result.Sent = true
+	}
+
+	s.log.Info("EVM claim authorized for relaying",
+		"trade_id", p.TradeID,
+		"chain", p.Chain,
+		"relayer", claim.Relayer,
+		"relayer_fee", claim.RelayerFee,
+	)
+	return result, nil
+}
+
+// handleEVMMetaClaim relays the counterparty's signed claim of the EVM HTLC
+// we funded and tells it the claim transaction.
+func (s *Server) handleEVMMetaClaim(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() {
+		return nil
+	}
+	if msg.TradeID == "" {
+		s.log.Warn("EVM meta-claim missing trade_id")
+		return nil
+	}
+
+	var claim swap.EVMMetaClaim
+	if err := json.Unmarshal(msg.Payload, &claim); err != nil {
+		s.log.Warn("Failed to parse EVM meta-claim payload", "error", err)
+		return nil
+	}
+
+	txHash, err := s.coordinator.RelayEVMMetaClaim(ctx, msg.TradeID, &claim)
+	if err != nil {
+		s.log.Warn("Refused to relay EVM claim",
+			"trade_id", short(msg.TradeID, 8),
+			"chain", claim.Chain,
+			"error", err,
+		)
+		if s.wsHub != nil {
+			s.wsHub.Broadcast("evm_meta_claim_refused", map[string]string{
+				"trade_id": msg.TradeID,
+				"chain":    claim.Chain,
+				"error":    err.Error(),
+			}, SwapTopic(msg.TradeID))
+		}
+		return nil
+	}
+
+	// The relayed claim revealed the secret to us
+	if secret, err := hex.DecodeString(claim.Secret); err == nil {
+		if err := s.coordinator.SetRevealedSecret(msg.TradeID, secret); err != nil {
+			s.log.Warn("Failed to set secret from relayed claim", "error", err)
+		}
+	}
+
+	s.log.Info("Relayed EVM claim",
+		"trade_id", short(msg.TradeID, 8),
+		"chain", claim.Chain,
+		"tx_hash", txHash.Hex(),
+	)
+	if s.wsHub != nil {
+		s.wsHub.Broadcast("evm_meta_claim_relayed", map[string]string{
+			"trade_id":    msg.TradeID,
+			"chain":       claim.Chain,
+			"tx_hash":     txHash.Hex(),
+			"relayer_fee": claim.RelayerFee,
+		}, SwapTopic(msg.TradeID))
+	}
+
+	var chainID uint64
+	if params, err := s.evmChain(claim.Chain); err == nil {
+		chainID = params.ChainID
+	}
+	reply, err := node.NewEVMClaimMessage(msg.TradeID, claim.Chain, chainID, txHash.Hex(), claim.SwapID, claim.Secret)
+	if err != nil {
+		return nil
+	}
+	if err := s.sendDirectToCounterparty(ctx, msg.TradeID, reply); err != nil {
+		s.log.Warn("Failed to send relayed claim to counterparty", "error", err)
+	}
+	return nil
+}
+
+// handleEVMClaimed records a claim of an EVM HTLC the counterparty reports,
+// such as one it relayed for us.
+func (s *Server) handleEVMClaimed(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() {
+		return nil
+	}
+	if msg.TradeID == "" {
+		s.log.Warn("EVM claim missing trade_id")
+		return nil
+	}
+
+	var payload node.EVMClaimPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse EVM claim payload", "error", err)
+		return nil
+	}
+
+	s.log.Info("Received EVM claim notification",
+		"trade_id", short(msg.TradeID, 8),
+		"chain", payload.Chain,
+		"tx_hash", payload.TxHash,
+	)
+	if payload.Secret != "" {
+		if secret, err := hex.DecodeString(payload.Secret); err == nil && len(secret) == 32 {
+			if err := s.coordinator.SetRevealedSecret(msg.TradeID, secret); err != nil {
+				s.log.Debug("Failed to set secret from EVM claim", "error", err)
+			}
+		}
+	}
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast("evm_claim_received", map[string]string{
+			"trade_id":  msg.TradeID,
+			"from_peer": msg.FromPeer,
+			"chain":     payload.Chain,
+			"tx_hash":   payload.TxHash,
+		}, SwapTopic(msg.TradeID))
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapEVMMetaClaimParams(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetMetaClaim(node.MetaClaimConfig{Relayers: map[string]string{"BSC": "not-an-address"}})
+
+	params, _ := json.Marshal(SwapEVMMetaClaimParams{TradeID: "t1", Chain: "ETH"})
+	if _, err := s.swapEVMMetaClaim(context.Background(), params); err == nil || !strings.Contains(err.Error(), "not initialized") {
+		t.Errorf("swap_evmMetaClaim without a coordinator error = %v", err)
+	}
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store, Network: chain.Testnet})
+
+	tests := []struct {
+		name   string
+		params SwapEVMMetaClaimParams
+		want   string
+	}{
+		{"missing trade", SwapEVMMetaClaimParams{Chain: "ETH"}, "trade_id is required"},
+		{"missing chain", SwapEVMMetaClaimParams{TradeID: "t1"}, "chain is required"},
+		{"invalid relayer", SwapEVMMetaClaimParams{TradeID: "t1", Chain: "ETH", Relayer: "0x12"}, "invalid relayer"},
+		{"unconfigured relayer", SwapEVMMetaClaimParams{TradeID: "t1", Chain: "ETH", Relayer: "configured"}, "no relayer configured"},
+		{"bad configured relayer", SwapEVMMetaClaimParams{TradeID: "t1", Chain: "bsc", Relayer: "configured"}, "no relayer configured"},
+		{"invalid fee", SwapEVMMetaClaimParams{TradeID: "t1", Chain: "ETH", Relayer: "any", RelayerFee: "1.5"}, "invalid relayer_fee"},
+		{"unknown trade", SwapEVMMetaClaimParams{TradeID: "t1", Chain: "ETH"}, "failed to authorize claim"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params, _ := json.Marshal(tt.params)
+			_, err := s.swapEVMMetaClaim(context.Background(), params)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("swap_evmMetaClaim error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
@@ -41,7 +41,7 @@ func (s *Server) swapExchangeNonce(ctx context.Context, params json.RawMessage)
 			if sendErr := s.sendDirectToCounterparty(ctx, p.TradeID, msg); sendErr != nil {
 				s.log.Warn("Failed to re-send pubkey", "error", sendErr)
 			} else {
-				s.log.Debug("Re-sent pubkey with wallet addresses", "trade_id", p.TradeID[:8])
+				s.log.Debug("Re-sent pubkey with wallet addresses", "trade_id", short(p.TradeID, 8))
 			}
 		}
 	}
@@ -62,7 +62,7 @@ func (s *Server) swapExchangeNonce(ctx context.Context, params json.RawMessage)
 		if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 			s.log.Warn("Failed to send nonces", "trade_id", p.TradeID, "error", err)
 		} else {
-			s.log.Info("Sent nonces for both chains to counterparty", "trade_id", p.TradeID[:8])
+			s.log.Info("Sent nonces for both chains to counterparty", "trade_id", short(p.TradeID, 8))
 		}
 	}
 
@@ -77,7 +77,7 @@ func (s *Server) swapExchangeNonce(ctx context.Context, params json.RawMessage)
 		s.wsHub.Broadcast("nonces_generated", map[string]interface{}{
 			"trade_id":          p.TradeID,
 			"has_remote_nonces": hasRemoteNonces,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapExchangeNonceResult{
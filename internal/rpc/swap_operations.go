@@ -0,0 +1,67 @@
+// Package rpc - Listing and cancelling in-flight swap operations.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// SwapOperationsParams is the parameters for swap_operations.
+type SwapOperationsParams struct {
+	TradeID string `json:"trade_id,omitempty"` // Empty lists every trade's
+}
+
+// SwapCancelOperationParams is the parameters for swap_cancelOperation.
+type SwapCancelOperationParams struct {
+	TradeID string `json:"trade_id"`
+	ID      uint64 `json:"id,omitempty"` // One operation; 0 cancels all of the trade's
+}
+
+// SwapCancelOperationResult is the result of swap_cancelOperation.
+type SwapCancelOperationResult struct {
+	TradeID   string           `json:"trade_id"`
+	Cancelled []swap.Operation `json:"cancelled"`
+}
+
+// swapOperations lists the in-flight swap operations.
+func (s *Server) swapOperations(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapOperationsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+	return s.coordinator.Operations(p.TradeID), nil
+}
+
+// swapCancelOperation aborts a stuck in-flight step of a swap, such as a
+// broadcast to an unresponsive backend. The swap itself continues: the step
+// can be retried, and a broadcast already recorded as a job re-sends the
+// same transaction.
+func (s *Server) swapCancelOperation(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapCancelOperationParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+
+	cancelled := s.coordinator.CancelOperations(p.TradeID, p.ID)
+	if len(cancelled) == 0 {
+		if p.ID != 0 {
+			return nil, fmt.Errorf("no operation %d in flight for trade %s", p.ID, p.TradeID)
+		}
+		return nil, fmt.Errorf("no operation in flight for trade %s", p.TradeID)
+	}
+	return &SwapCancelOperationResult{TradeID: p.TradeID, Cancelled: cancelled}, nil
+}
@@ -0,0 +1,35 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestSwapCancelOperation(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.swapCancelOperation(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("swapCancelOperation() accepted a missing trade_id")
+	}
+	if _, err := s.swapCancelOperation(ctx, json.RawMessage(`{"trade_id":"t1"}`)); err == nil {
+		t.Error("swapCancelOperation() succeeded without a coordinator")
+	}
+
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store})
+	defer s.coordinator.Close()
+
+	if _, err := s.swapCancelOperation(ctx, json.RawMessage(`{"trade_id":"t1","id":3}`)); err == nil {
+		t.Error("swapCancelOperation() succeeded with nothing in flight")
+	}
+	result, err := s.swapOperations(ctx, nil)
+	if err != nil {
+		t.Fatalf("swapOperations() error = %v", err)
+	}
+	if ops := result.([]swap.Operation); len(ops) != 0 {
+		t.Errorf("swapOperations() = %+v, want none", ops)
+	}
+}
@@ -12,6 +12,7 @@ import (
 
 	"github.com/Klingon-tech/klingdex/internal/node"
 	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
 )
 
 // =============================================================================
@@ -56,12 +57,16 @@ func (s *Server) sendDirectToCounterparty(ctx context.Context, tradeID string, m
 		}
 	}
 
+	// Embed our terms digest so the counterparty can check it agrees
+	s.stampTerms(tradeID, msg)
+	s.stampEVMContracts(tradeID, msg)
+
 	// Try direct messaging first (preferred - private and persistent)
 	if s.node.MessageSender() != nil {
 		s.log.Debug("Sending direct message",
 			"type", msg.Type,
-			"trade_id", tradeID[:8],
-			"peer", counterpartyID[:12])
+			"trade_id", short(tradeID, 8),
+			"peer", short(counterpartyID, 12))
 
 		if err := s.node.SendDirect(ctx, peerID, tradeID, swapTimeout, msg); err != nil {
 			s.log.Warn("Direct send failed, falling back to PubSub",
@@ -75,7 +80,7 @@ func (s *Server) sendDirectToCounterparty(ctx context.Context, tradeID string, m
 
 	// Fallback to PubSub broadcast (for backward compatibility)
 	if swapHandler := s.node.SwapHandler(); swapHandler != nil {
-		s.log.Debug("Using PubSub broadcast fallback", "type", msg.Type, "trade_id", tradeID[:8])
+		s.log.Debug("Using PubSub broadcast fallback", "type", msg.Type, "trade_id", short(tradeID, 8))
 		return swapHandler.SendMessage(ctx, msg)
 	}
 
@@ -84,12 +89,24 @@ func (s *Server) sendDirectToCounterparty(ctx context.Context, tradeID string, m
 
 // broadcastToAll sends a message via PubSub broadcast (for public messages like orders).
 func (s *Server) broadcastToAll(ctx context.Context, msg *node.SwapMessage) error {
+	if s.node == nil {
+		return fmt.Errorf("node not started")
+	}
 	if swapHandler := s.node.SwapHandler(); swapHandler != nil {
 		return swapHandler.SendMessage(ctx, msg)
 	}
 	return fmt.Errorf("no pubsub handler available")
 }
 
+// short truncates an ID for logging. IDs from peers may be shorter than
+// expected, so never slice them directly.
+func short(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
+
 // ========================================
 // P2P Message Handlers for Swap Protocol
 // ========================================
@@ -139,7 +156,7 @@ func (s *Server) handlePubKeyExchange(ctx context.Context, msg *node.SwapMessage
 		fromMaker = true
 	}
 	if msg.FromPeer != expectedPeer {
-		s.log.Warn("PubKey from unexpected peer", "expected", expectedPeer[:12], "got", msg.FromPeer[:12])
+		s.log.Warn("PubKey from unexpected peer", "expected", short(expectedPeer, 12), "got", short(msg.FromPeer, 12))
 		return nil
 	}
 
@@ -164,7 +181,7 @@ func (s *Server) handlePubKeyExchange(ctx context.Context, msg *node.SwapMessage
 			s.log.Warn("Failed to set remote wallet addresses", "error", err)
 		} else {
 			s.log.Info("Stored remote wallet addresses",
-				"trade_id", msg.TradeID[:8],
+				"trade_id", short(msg.TradeID, 8),
 				"offer_addr", payload.OfferWalletAddr,
 				"request_addr", payload.RequestWalletAddr,
 			)
@@ -179,8 +196,8 @@ func (s *Server) handlePubKeyExchange(ctx context.Context, msg *node.SwapMessage
 	}
 
 	s.log.Info("Received counterparty pubkey",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 		"offer_addr", offerAddr,
 		"request_addr", requestAddr,
 	)
@@ -192,7 +209,7 @@ func (s *Server) handlePubKeyExchange(ctx context.Context, msg *node.SwapMessage
 			"from_peer":    msg.FromPeer,
 			"offer_addr":   offerAddr,
 			"request_addr": requestAddr,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -246,8 +263,8 @@ func (s *Server) handleNonceExchange(ctx context.Context, msg *node.SwapMessage)
 	}
 
 	s.log.Info("Received counterparty nonces for both chains",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 	)
 
 	// Check if we have both nonces for both chains and can proceed to funding
@@ -272,7 +289,7 @@ func (s *Server) handleNonceExchange(ctx context.Context, msg *node.SwapMessage)
 		s.wsHub.Broadcast("nonces_received", map[string]string{
 			"trade_id":  msg.TradeID,
 			"from_peer": msg.FromPeer,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -304,8 +321,8 @@ func (s *Server) handleFundingInfo(ctx context.Context, msg *node.SwapMessage) e
 	}
 
 	s.log.Info("Received counterparty funding info",
-		"trade_id", msg.TradeID[:8],
-		"txid", payload.TxID[:16],
+		"trade_id", short(msg.TradeID, 8),
+		"txid", short(payload.TxID, 16),
 		"vout", payload.Vout,
 	)
 
@@ -316,7 +333,7 @@ func (s *Server) handleFundingInfo(ctx context.Context, msg *node.SwapMessage) e
 			"txid":      payload.TxID,
 			"vout":      payload.Vout,
 			"from_peer": msg.FromPeer,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -356,8 +373,8 @@ func (s *Server) handlePartialSig(ctx context.Context, msg *node.SwapMessage) er
 	}
 
 	s.log.Info("Received counterparty partial signatures for both chains",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 	)
 
 	// Store both remote partial signatures
@@ -367,7 +384,7 @@ func (s *Server) handlePartialSig(ctx context.Context, msg *node.SwapMessage) er
 	}
 
 	s.log.Info("Stored remote partial signatures for both chains",
-		"trade_id", msg.TradeID[:8],
+		"trade_id", short(msg.TradeID, 8),
 	)
 
 	// Emit WebSocket event
@@ -376,7 +393,7 @@ func (s *Server) handlePartialSig(ctx context.Context, msg *node.SwapMessage) er
 			"trade_id":            msg.TradeID,
 			"offer_partial_sig":   payload.OfferPartialSig,
 			"request_partial_sig": payload.RequestPartialSig,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -406,14 +423,22 @@ func (s *Server) handleHTLCSecretHash(ctx context.Context, msg *node.SwapMessage
 	}
 
 	// Decode secret hash
-	secretHash, err := hex.DecodeString(payload.SecretHash)
+	secretHash, err := swap.ParseSecretHash(payload.SecretHash)
 	if err != nil {
-		s.log.Warn("Invalid secret hash hex", "error", err)
+		s.log.Warn("Invalid secret hash", "trade_id", short(msg.TradeID, 8), "error", err)
 		return nil
 	}
 
-	if len(secretHash) != 32 {
-		s.log.Warn("Invalid secret hash size", "expected", 32, "got", len(secretHash))
+	// Refuse a hash another trade uses: its revealed secret would unlock this one
+	if err := s.coordinator.ClaimSecretHash(msg.TradeID, secretHash); err != nil {
+		s.log.Warn("Refusing HTLC secret hash", "trade_id", short(msg.TradeID, 8), "from", short(msg.FromPeer, 12), "error", err)
+		if s.wsHub != nil {
+			s.wsHub.Broadcast("htlc_secret_hash_rejected", map[string]string{
+				"trade_id":  msg.TradeID,
+				"from_peer": msg.FromPeer,
+				"error":     err.Error(),
+			}, SwapTopic(msg.TradeID))
+		}
 		return nil
 	}
 
@@ -450,13 +475,13 @@ func (s *Server) handleHTLCSecretHash(ctx context.Context, msg *node.SwapMessage
 		if err := s.store.UpdateTradePubKey(msg.TradeID, true, payload.PubKey); err != nil {
 			s.log.Warn("Failed to store maker pubkey from secret hash", "error", err)
 		} else {
-			s.log.Info("Stored maker pubkey from HTLC secret hash", "pubkey", payload.PubKey[:16])
+			s.log.Info("Stored maker pubkey from HTLC secret hash", "pubkey", short(payload.PubKey, 16))
 		}
 	}
 
 	s.log.Info("Received HTLC secret hash from initiator",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 	)
 
 	// Emit WebSocket event
@@ -465,7 +490,7 @@ func (s *Server) handleHTLCSecretHash(ctx context.Context, msg *node.SwapMessage
 			"trade_id":    msg.TradeID,
 			"from_peer":   msg.FromPeer,
 			"secret_hash": payload.SecretHash,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -509,8 +534,8 @@ func (s *Server) handleHTLCSecretReveal(ctx context.Context, msg *node.SwapMessa
 	}
 
 	s.log.Info("Received HTLC secret from initiator",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 	)
 
 	// Emit WebSocket event
@@ -519,7 +544,7 @@ func (s *Server) handleHTLCSecretReveal(ctx context.Context, msg *node.SwapMessa
 			"trade_id":  msg.TradeID,
 			"from_peer": msg.FromPeer,
 			"secret":    payload.Secret,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -545,12 +570,19 @@ func (s *Server) handleHTLCClaim(ctx context.Context, msg *node.SwapMessage) err
 	}
 
 	s.log.Info("Received HTLC claim notification",
-		"trade_id", msg.TradeID[:8],
-		"from", msg.FromPeer[:12],
+		"trade_id", short(msg.TradeID, 8),
+		"from", short(msg.FromPeer, 12),
 		"chain", payload.Chain,
 		"txid", payload.TxID,
 	)
 
+	// Their claim's confirmations gate our claim in confirmations mode
+	if payload.Chain != "" && payload.TxID != "" {
+		if err := s.coordinator.RecordCounterpartyClaim(msg.TradeID, payload.Chain, payload.TxID); err != nil {
+			s.log.Debug("Failed to record counterparty claim", "error", err)
+		}
+	}
+
 	// If the claim includes the secret, set it in coordinator
 	if payload.Secret != "" {
 		secret, err := hex.DecodeString(payload.Secret)
@@ -568,7 +600,7 @@ func (s *Server) handleHTLCClaim(ctx context.Context, msg *node.SwapMessage) err
 			"from_peer": msg.FromPeer,
 			"chain":     payload.Chain,
 			"txid":      payload.TxID,
-		})
+		}, SwapTopic(msg.TradeID))
 	}
 
 	return nil
@@ -39,14 +39,14 @@ func (s *Server) swapRedeem(ctx context.Context, params json.RawMessage) (interf
 		// Initiator redeems from request chain (responder's funds)
 		redeemChain = activeSwap.Swap.Offer.RequestChain
 		redeemChainData = activeSwap.MuSig2.RequestChain
-		redeemAmount = activeSwap.Swap.Offer.RequestAmount
+		redeemAmount = activeSwap.Swap.RequestFundingAmount()
 		fundingTxID = activeSwap.Swap.RemoteFundingTxID
 		fundingVout = activeSwap.Swap.RemoteFundingVout
 	} else {
 		// Responder redeems from offer chain (initiator's funds)
 		redeemChain = activeSwap.Swap.Offer.OfferChain
 		redeemChainData = activeSwap.MuSig2.OfferChain
-		redeemAmount = activeSwap.Swap.Offer.OfferAmount
+		redeemAmount = activeSwap.Swap.OfferFundingAmount()
 		fundingTxID = activeSwap.Swap.RemoteFundingTxID
 		fundingVout = activeSwap.Swap.RemoteFundingVout
 	}
@@ -81,13 +81,9 @@ func (s *Server) swapRedeem(ctx context.Context, params json.RawMessage) (interf
 		return nil, fmt.Errorf("failed to get destination address: %w", err)
 	}
 
-	// Calculate DAO fee
-	var daoFee uint64
-	if redeemChain == activeSwap.Swap.Offer.OfferChain {
-		daoFee = swap.CalculateDAOFee(redeemAmount, false) // taker
-	} else {
-		daoFee = swap.CalculateDAOFee(redeemAmount, true) // maker
-	}
+	// Calculate DAO fee per the negotiated fee terms
+	// (taker redeems offer chain, maker redeems request chain)
+	daoFee := activeSwap.Swap.Offer.DAOFeeOnChain(redeemChain, redeemChain == activeSwap.Swap.Offer.RequestChain)
 
 	// Get DAO address from config
 	exchangeCfg := config.NewExchangeConfig(config.NetworkType(s.coordinator.Network()))
@@ -135,7 +131,7 @@ func (s *Server) swapRedeem(ctx context.Context, params json.RawMessage) (interf
 		return nil, fmt.Errorf("backend not available for chain: %s", redeemChain)
 	}
 
-	redeemTxID, err := b.BroadcastTransaction(ctx, redeemTxHex)
+	redeemTxID, err := s.coordinator.BroadcastOnce(ctx, b, swap.JobActionRedeem, p.TradeID, redeemChain, redeemTxHex)
 	if err != nil {
 		return nil, fmt.Errorf("failed to broadcast redeem tx: %w", err)
 	}
@@ -156,11 +152,11 @@ func (s *Server) swapRedeem(ctx context.Context, params json.RawMessage) (interf
 			"trade_id":     p.TradeID,
 			"redeem_txid":  redeemTxID,
 			"redeem_chain": redeemChain,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	s.log.Info("Swap redeemed successfully",
-		"trade_id", p.TradeID[:8],
+		"trade_id", short(p.TradeID, 8),
 		"redeem_txid", redeemTxID,
 		"chain", redeemChain,
 	)
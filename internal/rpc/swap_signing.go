@@ -69,9 +69,9 @@ func (s *Server) swapSign(ctx context.Context, params json.RawMessage) (interfac
 	offerDAOAddr := exchangeCfg.GetDAOAddress(activeSwap.Swap.Offer.OfferChain)
 	requestDAOAddr := exchangeCfg.GetDAOAddress(activeSwap.Swap.Offer.RequestChain)
 
-	// Calculate DAO fees
-	offerDAOFee := swap.CalculateDAOFee(activeSwap.Swap.Offer.OfferAmount, false)
-	requestDAOFee := swap.CalculateDAOFee(activeSwap.Swap.Offer.RequestAmount, true)
+	// Calculate DAO fees per the negotiated fee terms (taker redeems offer chain, maker redeems request chain)
+	offerDAOFee := activeSwap.Swap.Offer.FeeTerms.DAOFee(activeSwap.Swap.Offer.OfferAmount, false)
+	requestDAOFee := activeSwap.Swap.Offer.FeeTerms.DAOFee(activeSwap.Swap.Offer.RequestAmount, true)
 
 	s.log.Info("swap_sign: offer chain dest", "chain", activeSwap.Swap.Offer.OfferChain, "dest", offerDestAddr, "role", activeSwap.Swap.Role, "daoFee", offerDAOFee, "feeRate", offerFeeRate)
 	offerSpendParams := &swap.SpendingTxParams{
@@ -79,7 +79,7 @@ func (s *Server) swapSign(ctx context.Context, params json.RawMessage) (interfac
 		Network:        s.coordinator.Network(),
 		FundingTxID:    activeSwap.Swap.LocalFundingTxID,
 		FundingVout:    activeSwap.Swap.LocalFundingVout,
-		FundingAmount:  activeSwap.Swap.Offer.OfferAmount,
+		FundingAmount:  activeSwap.Swap.OfferFundingAmount(),
 		TaprootAddress: activeSwap.MuSig2.OfferChain.TaprootAddress,
 		DestAddress:    offerDestAddr,
 		DAOAddress:     offerDAOAddr,
@@ -106,7 +106,7 @@ func (s *Server) swapSign(ctx context.Context, params json.RawMessage) (interfac
 		Network:        s.coordinator.Network(),
 		FundingTxID:    activeSwap.Swap.RemoteFundingTxID,
 		FundingVout:    activeSwap.Swap.RemoteFundingVout,
-		FundingAmount:  activeSwap.Swap.Offer.RequestAmount,
+		FundingAmount:  activeSwap.Swap.RequestFundingAmount(),
 		TaprootAddress: activeSwap.MuSig2.RequestChain.TaprootAddress,
 		DestAddress:    requestDestAddr,
 		DAOAddress:     requestDAOAddr,
@@ -144,7 +144,7 @@ func (s *Server) swapSign(ctx context.Context, params json.RawMessage) (interfac
 		if err := s.sendDirectToCounterparty(ctx, p.TradeID, msg); err != nil {
 			s.log.Warn("Failed to send partial sigs", "trade_id", p.TradeID, "error", err)
 		} else {
-			s.log.Info("Sent partial signatures for both chains to counterparty", "trade_id", p.TradeID[:8])
+			s.log.Info("Sent partial signatures for both chains to counterparty", "trade_id", short(p.TradeID, 8))
 		}
 	}
 
@@ -169,7 +169,7 @@ func (s *Server) swapSign(ctx context.Context, params json.RawMessage) (interfac
 			"trade_id":            p.TradeID,
 			"offer_partial_sig":   offerSigHex,
 			"request_partial_sig": requestSigHex,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return result, nil
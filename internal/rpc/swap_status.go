@@ -7,6 +7,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/internal/swap"
 )
 
@@ -21,24 +22,34 @@ func (s *Server) swapStatus(ctx context.Context, params json.RawMessage) (interf
 		return nil, fmt.Errorf("trade_id is required")
 	}
 
-	activeSwap, err := s.coordinator.GetSwap(p.TradeID)
+	// Everything comes from the swap's published view; the monitor keeps
+	// its confirmations current
+	view, err := s.coordinator.SwapSnapshot(p.TradeID)
+	if err != nil && s.restoreArchived(ctx, p.TradeID) {
+		view, err = s.coordinator.SwapSnapshot(p.TradeID)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("swap not found: %w", err)
 	}
 
-	// Update confirmations
-	_ = s.coordinator.UpdateConfirmations(ctx, p.TradeID)
-
 	result := &SwapStatusResult{
-		TradeID:     p.TradeID,
-		State:       string(activeSwap.Swap.State),
-		Role:        string(activeSwap.Swap.Role),
-		LocalPubKey: hex.EncodeToString(activeSwap.Swap.LocalPubKey),
+		TradeID:           p.TradeID,
+		State:             string(view.State),
+		Role:              string(view.Role),
+		LocalPubKey:       hex.EncodeToString(view.LocalPubKey),
+		OfferEVMAddress:   view.LocalOfferWalletAddr,
+		RequestEVMAddress: view.LocalRequestWalletAddr,
+		PayoutAddress:     view.PayoutAddress,
+		HasOfferNonces:    view.HasOfferNonces,
+		HasRequestNonces:  view.HasRequestNonces,
+		HasOfferSigs:      view.HasOfferSigs,
+		HasRequestSigs:    view.HasRequestSigs,
+		ZeroConf:          view.ZeroConf,
+		FundingVariance:   view.FundingVariance,
 	}
 
 	// Set swap type
-	swapType, _ := s.coordinator.GetSwapType(p.TradeID)
-	switch swapType {
+	switch view.SwapType {
 	case swap.CrossChainTypeEVMToEVM:
 		result.SwapType = "evm_to_evm"
 	case swap.CrossChainTypeBitcoinToEVM:
@@ -50,91 +61,60 @@ func (s *Server) swapStatus(ctx context.Context, params json.RawMessage) (interf
 	}
 
 	// Set method
-	if activeSwap.IsMuSig2() {
+	if view.Method == swap.MethodMuSig2 {
 		result.Method = "musig2"
 	} else {
 		result.Method = "htlc"
 	}
 
-	// Set addresses and method-specific status based on swap method
-	if activeSwap.IsMuSig2() && activeSwap.MuSig2 != nil {
-		offerChain := activeSwap.MuSig2.OfferChain
-		requestChain := activeSwap.MuSig2.RequestChain
-		if offerChain != nil {
-			result.OfferTaprootAddress = offerChain.TaprootAddress
-			result.HasOfferNonces = offerChain.LocalNonce != nil && offerChain.RemoteNonce != nil
-			result.HasOfferSigs = offerChain.PartialSig != nil && offerChain.RemotePartialSig != nil
-		}
-		if requestChain != nil {
-			result.RequestTaprootAddress = requestChain.TaprootAddress
-			result.HasRequestNonces = requestChain.LocalNonce != nil && requestChain.RemoteNonce != nil
-			result.HasRequestSigs = requestChain.PartialSig != nil && requestChain.RemotePartialSig != nil
-		}
-	} else if activeSwap.IsHTLC() {
-		// Bitcoin HTLC addresses
-		if activeSwap.HTLC != nil {
-			if activeSwap.HTLC.OfferChain != nil && activeSwap.HTLC.OfferChain.HTLCAddress != "" {
-				result.OfferHTLCAddress = activeSwap.HTLC.OfferChain.HTLCAddress
-				// Also set OfferTaprootAddress for backward compatibility with BTC-BTC swaps
-				result.OfferTaprootAddress = activeSwap.HTLC.OfferChain.HTLCAddress
-			}
-			if activeSwap.HTLC.RequestChain != nil && activeSwap.HTLC.RequestChain.HTLCAddress != "" {
-				result.RequestHTLCAddress = activeSwap.HTLC.RequestChain.HTLCAddress
-				// Also set RequestTaprootAddress for backward compatibility
-				result.RequestTaprootAddress = activeSwap.HTLC.RequestChain.HTLCAddress
-			}
-		}
-	}
-
-	// EVM wallet addresses (from swap state)
-	if activeSwap.Swap.LocalOfferWalletAddr != "" {
-		result.OfferEVMAddress = activeSwap.Swap.LocalOfferWalletAddr
-	}
-	if activeSwap.Swap.LocalRequestWalletAddr != "" {
-		result.RequestEVMAddress = activeSwap.Swap.LocalRequestWalletAddr
+	// Escrow addresses; HTLC swaps also report theirs as taproot addresses
+	// for backward compatibility with BTC-BTC swaps
+	result.OfferTaprootAddress = view.OfferEscrowAddress
+	result.RequestTaprootAddress = view.RequestEscrowAddress
+	if view.Method == swap.MethodHTLC {
+		result.OfferHTLCAddress = view.OfferEscrowAddress
+		result.RequestHTLCAddress = view.RequestEscrowAddress
 	}
 
-	if len(activeSwap.Swap.RemotePubKey) > 0 {
-		result.RemotePubKey = hex.EncodeToString(activeSwap.Swap.RemotePubKey)
+	if len(view.RemotePubKey) > 0 {
+		result.RemotePubKey = hex.EncodeToString(view.RemotePubKey)
 	}
 
 	// Local funding status
-	if activeSwap.Swap.LocalFundingTxID != "" {
-		var amount uint64
-		if activeSwap.Swap.Role == swap.RoleInitiator {
-			amount = activeSwap.Swap.Offer.OfferAmount
-		} else {
-			amount = activeSwap.Swap.Offer.RequestAmount
-		}
+	if view.LocalFundingTxID != "" {
 		result.LocalFunding = &FundingStatus{
-			TxID:          activeSwap.Swap.LocalFundingTxID,
-			Vout:          activeSwap.Swap.LocalFundingVout,
-			Amount:        amount,
-			Confirmations: activeSwap.Swap.LocalFundingConfirms,
-			Confirmed:     activeSwap.Swap.LocalFundingConfirms >= 1,
+			TxID:          view.LocalFundingTxID,
+			Vout:          view.LocalFundingVout,
+			Amount:        view.LocalFundingAmount,
+			Confirmations: view.LocalFundingConfirms,
+			Confirmed:     view.LocalFundingConfirms >= 1,
+			Finality:      s.finalityOf(view.LocalFundingChain, int64(view.LocalFundingConfirms)),
 		}
 	}
 
 	// Remote funding status
-	if activeSwap.Swap.RemoteFundingTxID != "" {
-		var amount uint64
-		if activeSwap.Swap.Role == swap.RoleInitiator {
-			amount = activeSwap.Swap.Offer.RequestAmount
-		} else {
-			amount = activeSwap.Swap.Offer.OfferAmount
-		}
+	if view.RemoteFundingTxID != "" {
 		result.RemoteFunding = &FundingStatus{
-			TxID:          activeSwap.Swap.RemoteFundingTxID,
-			Vout:          activeSwap.Swap.RemoteFundingVout,
-			Amount:        amount,
-			Confirmations: activeSwap.Swap.RemoteFundingConfirms,
-			Confirmed:     activeSwap.Swap.RemoteFundingConfirms >= 1,
+			TxID:          view.RemoteFundingTxID,
+			Vout:          view.RemoteFundingVout,
+			Amount:        view.RemoteFundingAmount,
+			Confirmations: view.RemoteFundingConfirms,
+			Confirmed:     view.RemoteFundingConfirms >= 1,
+			Finality:      s.finalityOf(view.RemoteFundingChain, int64(view.RemoteFundingConfirms)),
 		}
 	}
 
 	// Ready to redeem if we have all signatures for both chains
 	result.ReadyToRedeem = result.HasOfferSigs && result.HasRequestSigs
 
+	// Refund and claim countdowns
+	if !view.IsTerminal() {
+		result.Deadlines = s.coordinator.ViewDeadlines(ctx, view).Deadlines
+	}
+	if terms, err := s.tradeTerms(p.TradeID); err == nil {
+		result.TermsDigest = hex.EncodeToString(terms.Digest())
+	}
+
 	return result, nil
 }
 
@@ -147,7 +127,14 @@ func (s *Server) swapList(ctx context.Context, params json.RawMessage) (interfac
 		}
 	}
 
-	records, err := s.coordinator.ListSwaps(p.IncludeCompleted)
+	// A read replica has no coordinator; it lists the primary's records
+	var records []*storage.SwapRecord
+	var err error
+	if s.coordinator != nil {
+		records, err = s.coordinator.ListSwaps(p.IncludeCompleted)
+	} else {
+		records, err = s.store.ListSwaps(100, p.IncludeCompleted)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to list swaps: %w", err)
 	}
@@ -0,0 +1,192 @@
+// Package rpc - Trade terms digest in swap protocol messages.
+//
+// Every direct swap message carries the sender's terms digest and the key
+// exchange messages (pubkey_exchange, htlc_secret_hash) sign it with the swap
+// key. Incoming messages whose digest or signature doesn't match our view of
+// the trade are dropped and reported as a terms_mismatch event. Messages
+// from peers that don't send a digest are accepted.
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// EventTermsMismatch is broadcast when a counterparty message is dropped
+// because its trade terms differ from ours.
+const EventTermsMismatch EventType = "terms_mismatch"
+
+// tradeAmounts returns the amounts a trade exchanges: its own for a fill of
+// the order, or the whole order's for trades recorded without amounts.
+func tradeAmounts(trade *storage.Trade, order *storage.Order) (offerAmount, requestAmount uint64) {
+	if trade.OfferAmount != 0 && trade.RequestAmount != 0 {
+		return trade.OfferAmount, trade.RequestAmount
+	}
+	return order.OfferAmount, order.RequestAmount
+}
+
+// tradeOffer builds the swap offer and method of a trade from its order.
+func tradeOffer(trade *storage.Trade, order *storage.Order) (swap.Offer, swap.Method, error) {
+	offerAmount, requestAmount := tradeAmounts(trade, order)
+	offer := swap.Offer{
+		OfferChain:    order.OfferChain,
+		OfferAmount:   offerAmount,
+		RequestChain:  order.RequestChain,
+		RequestAmount: requestAmount,
+		OfferToken:    order.OfferToken,
+		RequestToken:  order.RequestToken,
+		Method:        swap.Method(trade.Method),
+	}
+	var err error
+	if offer.FeeTerms, err = swap.ParseFeeTerms([]byte(order.FeeTerms)); err != nil {
+		return offer, "", err
+	}
+	if err := swap.ApplyFeeAdjustment(&offer, trade.FeeAdjustment); err != nil {
+		return offer, "", err
+	}
+
+	// Default to MuSig2 if not specified
+	method := offer.Method
+	if method == "" {
+		method = swap.MethodMuSig2
+	}
+	return offer, method, nil
+}
+
+// validateTakeOffer checks the offer a peer proposes by taking one of our
+// orders with method, as swap_init and swap_initCrossChain will check it.
+func (s *Server) validateTakeOffer(order *storage.Order, method string) error {
+	if s.coordinator == nil {
+		return nil
+	}
+	offer, m, err := tradeOffer(&storage.Trade{Method: method}, order)
+	if err != nil {
+		return err
+	}
+	offer.Method = m
+	network := s.coordinator.Network()
+	if swap.IsEVMChain(offer.OfferChain, network) || swap.IsEVMChain(offer.RequestChain, network) {
+		offer.Method = swap.MethodHTLC // Cross-chain swaps always use HTLCs
+	}
+	return swap.ValidateOffer(offer, network)
+}
+
+// tradeTerms returns our view of a trade's terms.
+func (s *Server) tradeTerms(tradeID string) (*swap.TradeTerms, error) {
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not available")
+	}
+	trade, err := s.store.GetTrade(tradeID)
+	if err != nil {
+		return nil, fmt.Errorf("trade not found: %w", err)
+	}
+	order, err := s.store.GetOrder(trade.OrderID)
+	if err != nil {
+		return nil, fmt.Errorf("order not found: %w", err)
+	}
+	offer, method, err := tradeOffer(trade, order)
+	if err != nil {
+		return nil, err
+	}
+	terms := swap.NewTradeTerms(s.coordinator.Network(), trade.ID, trade.OrderID, offer, method)
+	terms.FeeAdjustment = trade.FeeAdjustment
+	return terms, nil
+}
+
+// stampTerms embeds our terms digest in an outgoing message and signs it in
+// the key exchange messages.
+func (s *Server) stampTerms(tradeID string, msg *node.SwapMessage) {
+	terms, err := s.tradeTerms(tradeID)
+	if err != nil {
+		s.log.Debug("No terms digest for message", "trade_id", short(tradeID, 8), "error", err)
+		return
+	}
+	digest := terms.Digest()
+	msg.TermsDigest = hex.EncodeToString(digest)
+
+	if msg.Type != node.SwapMsgPubKeyExchange && msg.Type != node.SwapMsgHTLCSecretHash {
+		return
+	}
+	sig, err := s.coordinator.SignTerms(tradeID, digest)
+	if err != nil {
+		s.log.Debug("Terms not signed", "trade_id", short(tradeID, 8), "error", err)
+		return
+	}
+
+	// Both payloads carry the swap pubkey next to terms_sig
+	var payload map[string]interface{}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		return
+	}
+	payload["terms_sig"] = hex.EncodeToString(sig)
+	if data, err := json.Marshal(payload); err == nil {
+		msg.Payload = data
+	}
+}
+
+// checkTerms verifies an incoming message against our terms before handing
+// it to the handler.
+func (s *Server) checkTerms(handler node.SwapMessageHandler) node.SwapMessageHandler {
+	return func(ctx context.Context, msg *node.SwapMessage) error {
+		if msg.TermsDigest == "" || msg.TradeID == "" {
+			return handler(ctx, msg)
+		}
+		terms, err := s.tradeTerms(msg.TradeID)
+		if err != nil {
+			return handler(ctx, msg) // Unknown trade, the handler decides
+		}
+		if err := verifyMessageTerms(msg, terms.Digest()); err != nil {
+			s.log.Warn("Dropping swap message with mismatched terms",
+				"trade_id", short(msg.TradeID, 8),
+				"type", msg.Type,
+				"from", short(msg.FromPeer, 12),
+				"error", err,
+			)
+			s.failureCauses.Store(msg.TradeID, failureCause{category: swap.FailureValidationMismatch, reason: err.Error()})
+			if s.wsHub != nil {
+				s.wsHub.Broadcast(EventTermsMismatch, map[string]string{
+					"trade_id":  msg.TradeID,
+					"from_peer": msg.FromPeer,
+					"type":      msg.Type,
+					"error":     err.Error(),
+				}, SwapTopic(msg.TradeID))
+			}
+			return nil
+		}
+		return handler(ctx, msg)
+	}
+}
+
+// verifyMessageTerms checks a message's terms digest and, for key exchange
+// messages, the signature of the digest by the sender's swap key.
+func verifyMessageTerms(msg *node.SwapMessage, digest []byte) error {
+	if msg.TermsDigest != hex.EncodeToString(digest) {
+		return fmt.Errorf("%w: digest %s, ours %x", swap.ErrTermsMismatch, short(msg.TermsDigest, 16), digest[:8])
+	}
+	if msg.Type != node.SwapMsgPubKeyExchange && msg.Type != node.SwapMsgHTLCSecretHash {
+		return nil
+	}
+
+	var payload struct {
+		PubKey   string `json:"pubkey"`
+		TermsSig string `json:"terms_sig"`
+	}
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.PubKey == "" || payload.TermsSig == "" {
+		return nil // EVM-only swaps have no swap key
+	}
+	pubKey, err := hex.DecodeString(payload.PubKey)
+	if err != nil {
+		return fmt.Errorf("%w: invalid pubkey", swap.ErrTermsMismatch)
+	}
+	sig, err := hex.DecodeString(payload.TermsSig)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature", swap.ErrTermsMismatch)
+	}
+	return swap.VerifyTermsSignature(pubKey, digest, sig)
+}
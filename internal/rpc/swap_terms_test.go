@@ -0,0 +1,216 @@
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// heightBackend serves a fixed block height.
+type heightBackend struct {
+	backend.Backend
+}
+
+func (heightBackend) GetBlockHeight(ctx context.Context) (int64, error) {
+	return 800000, nil
+}
+
+func newTermsTestServer(t *testing.T) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	s.coordinator = swap.NewCoordinator(&swap.CoordinatorConfig{Store: s.store, Network: chain.Testnet})
+	t.Cleanup(func() { s.coordinator.Close() })
+	s.wsHub = NewWSHub()
+
+	if err := s.store.CreateOrder(&storage.Order{
+		ID: "order-1", PeerID: "maker", Status: storage.OrderStatusMatched, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 5000000, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := s.store.CreateTrade(&storage.Trade{
+		ID: "trade-1", OrderID: "order-1", MakerPeerID: "maker", TakerPeerID: "taker",
+		OurRole: storage.TradeRoleMaker, Method: "htlc", State: storage.TradeStateInit, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+	return s
+}
+
+func TestCheckTerms(t *testing.T) {
+	s := newTermsTestServer(t)
+	terms, err := s.tradeTerms("trade-1")
+	if err != nil {
+		t.Fatalf("tradeTerms() error = %v", err)
+	}
+	digest := terms.Digest()
+
+	handled := 0
+	handler := s.checkTerms(func(ctx context.Context, msg *node.SwapMessage) error {
+		handled++
+		return nil
+	})
+	deliver := func(msg *node.SwapMessage) bool {
+		before := handled
+		handler(context.Background(), msg)
+		return handled > before
+	}
+
+	// Our own stamp matches
+	msg, _ := node.NewSwapMessage(node.SwapMsgNonceExchange, "trade-1", &node.NonceExchangePayload{})
+	s.stampTerms("trade-1", msg)
+	if msg.TermsDigest != hex.EncodeToString(digest) {
+		t.Fatalf("stamped digest = %q", msg.TermsDigest)
+	}
+	if !deliver(msg) {
+		t.Error("message with matching terms dropped")
+	}
+
+	// Peers without a digest are accepted
+	legacy, _ := node.NewSwapMessage(node.SwapMsgNonceExchange, "trade-1", &node.NonceExchangePayload{})
+	if !deliver(legacy) {
+		t.Error("message without a digest dropped")
+	}
+
+	// A different view of the amounts is dropped and reported
+	other := *terms
+	other.RequestAmount = 4000000
+	msg.TermsDigest = hex.EncodeToString(other.Digest())
+	if deliver(msg) {
+		t.Error("message with mismatched terms handled")
+	}
+	if got := len(s.wsHub.broadcast); got != 1 {
+		t.Fatalf("broadcast %d events, want 1", got)
+	}
+	if event := <-s.wsHub.broadcast; event.Type != EventTermsMismatch {
+		t.Errorf("event = %s, want %s", event.Type, EventTermsMismatch)
+	}
+
+	// The key exchange signature must be by the sent pubkey over our digest
+	key, _ := btcec.NewPrivateKey()
+	signed := func(signDigest []byte) *node.SwapMessage {
+		sig, err := swap.SignTermsDigest(key, signDigest)
+		if err != nil {
+			t.Fatalf("SignTermsDigest() error = %v", err)
+		}
+		m, _ := node.NewSwapMessage(node.SwapMsgPubKeyExchange, "trade-1", &node.PubKeyExchangePayload{
+			PubKey:   hex.EncodeToString(key.PubKey().SerializeCompressed()),
+			TermsSig: hex.EncodeToString(sig),
+		})
+		m.TermsDigest = hex.EncodeToString(digest)
+		return m
+	}
+	if !deliver(signed(digest)) {
+		t.Error("correctly signed pubkey exchange dropped")
+	}
+	if deliver(signed(other.Digest())) {
+		t.Error("pubkey exchange signing other terms handled")
+	}
+}
+
+func TestStampTermsSignsKeyExchange(t *testing.T) {
+	s := newTermsTestServer(t)
+	s.coordinator.SetBackend("BTC", heightBackend{})
+	s.coordinator.SetBackend("LTC", heightBackend{})
+	offer := swap.Offer{OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 5000000, Method: swap.MethodHTLC}
+	if _, err := s.coordinator.InitiateSwap(context.Background(), "trade-1", "order-1", offer, swap.MethodHTLC); err != nil {
+		t.Fatalf("InitiateSwap() error = %v", err)
+	}
+	pubKey, _ := s.coordinator.GetLocalPubKey("trade-1")
+
+	msg, _ := node.NewSwapMessage(node.SwapMsgPubKeyExchange, "trade-1", &node.PubKeyExchangePayload{PubKey: hex.EncodeToString(pubKey)})
+	s.stampTerms("trade-1", msg)
+
+	var payload node.PubKeyExchangePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.TermsSig == "" {
+		t.Fatalf("payload = %+v, %v, want terms_sig", payload, err)
+	}
+	terms, _ := s.tradeTerms("trade-1")
+	if err := verifyMessageTerms(msg, terms.Digest()); err != nil {
+		t.Errorf("verifyMessageTerms() error = %v", err)
+	}
+}
+
+func TestValidateTakeOffer(t *testing.T) {
+	s := newTermsTestServer(t)
+	order, err := s.store.GetOrder("order-1")
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if err := s.validateTakeOffer(order, "htlc"); err != nil {
+		t.Errorf("validateTakeOffer(htlc) error = %v", err)
+	}
+
+	order.OfferChain, order.RequestChain, order.RequestAmount = "BTC", "BSC", 10_000_000_000_000_000
+	// Cross-chain swaps use HTLCs whatever the taker asked for
+	if err := s.validateTakeOffer(order, "musig2"); err != nil {
+		t.Errorf("validateTakeOffer(BTC/BSC musig2) error = %v", err)
+	}
+
+	order.OfferChain, order.RequestChain, order.RequestAmount = "DOGE", "BTC", 100000
+	if err := s.validateTakeOffer(order, "musig2"); !errors.Is(err, swap.ErrInvalidOffer) {
+		t.Errorf("validateTakeOffer(DOGE/BTC musig2) = %v, want unsupported method", err)
+	}
+
+	order.OfferChain, order.OfferAmount = "BTC", 1
+	err = s.validateTakeOffer(order, "htlc")
+	var verr *swap.OfferValidationError
+	if !errors.As(err, &verr) || len(verr.Violations) != 2 {
+		t.Fatalf("validateTakeOffer(BTC/BTC dust) = %v, want same chain and amount violations", err)
+	}
+}
+
+func TestSwapInitOfferViolations(t *testing.T) {
+	s := newTermsTestServer(t)
+	if err := s.store.CreateOrder(&storage.Order{
+		ID: "order-bad", PeerID: "maker", Status: storage.OrderStatusMatched, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 1, RequestChain: "BTC", RequestAmount: 5000000, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := s.store.CreateTrade(&storage.Trade{
+		ID: "trade-bad", OrderID: "order-bad", MakerPeerID: "maker", TakerPeerID: "taker",
+		OurRole: storage.TradeRoleMaker, Method: "htlc", State: storage.TradeStateInit, CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+	s.handlers["swap_init"] = s.swapInit
+
+	rec := httptest.NewRecorder()
+	s.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/",
+		strings.NewReader(`{"jsonrpc":"2.0","id":1,"method":"swap_init","params":{"trade_id":"trade-bad"}}`)))
+
+	var resp struct {
+		Error *struct {
+			Code int                   `json:"code"`
+			Data []swap.OfferViolation `json:"data"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error == nil {
+		t.Fatalf("swap_init response = %s", rec.Body.String())
+	}
+	if resp.Error.Code != InvalidParams || len(resp.Error.Data) != 2 {
+		t.Fatalf("swap_init error = %+v, want both violations as data", resp.Error)
+	}
+	codes := map[string]bool{}
+	for _, v := range resp.Error.Data {
+		codes[v.Code] = true
+	}
+	if !codes[swap.ViolationSameChain] || !codes[swap.ViolationAmountTooLow] {
+		t.Errorf("violations = %+v", resp.Error.Data)
+	}
+}
@@ -45,6 +45,24 @@ func (s *Server) swapRecover(ctx context.Context, params json.RawMessage) (inter
 	}, nil
 }
 
+// swapReconcile checks a swap against the chains, as done on startup.
+func (s *Server) swapReconcile(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapReconcileParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+
+	if p.TradeID == "" {
+		return nil, fmt.Errorf("trade_id is required")
+	}
+
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("coordinator not available")
+	}
+
+	return s.coordinator.ReconcileSwap(ctx, p.TradeID)
+}
+
 // swapTimeout returns timeout information for a swap.
 func (s *Server) swapTimeout(ctx context.Context, params json.RawMessage) (interface{}, error) {
 	var p SwapTimeoutParams
@@ -114,7 +132,7 @@ func (s *Server) swapRefund(ctx context.Context, params json.RawMessage) (interf
 			"trade_id": p.TradeID,
 			"chain":    chain,
 			"txid":     txID,
-		})
+		}, SwapTopic(p.TradeID))
 	}
 
 	return &SwapRefundResult{
@@ -1,6 +1,8 @@
 // Package rpc - Type definitions for swap RPC handlers.
 package rpc
 
+import "github.com/Klingon-tech/klingdex/internal/swap"
+
 // =============================================================================
 // Swap Init Types
 // =============================================================================
@@ -8,14 +10,23 @@ package rpc
 // SwapInitParams is the parameters for swap_init.
 type SwapInitParams struct {
 	TradeID string `json:"trade_id"`
+
+	// PayoutAddress sends our receiving leg to an external address instead
+	// of the node's wallet (Bitcoin-family chains only).
+	PayoutAddress string `json:"payout_address,omitempty"`
+
+	// AllowAddressReuse accepts a payout address already used, in the
+	// enforce address reuse mode.
+	AllowAddressReuse bool `json:"allow_address_reuse,omitempty"`
 }
 
 // SwapInitResult is the response for swap_init.
 type SwapInitResult struct {
-	TradeID        string `json:"trade_id"`
-	LocalPubKey    string `json:"local_pubkey"`    // Hex-encoded
-	TaprootAddress string `json:"taproot_address"` // Only if remote pubkey already set
-	State          string `json:"state"`
+	TradeID        string   `json:"trade_id"`
+	LocalPubKey    string   `json:"local_pubkey"`    // Hex-encoded
+	TaprootAddress string   `json:"taproot_address"` // Only if remote pubkey already set
+	State          string   `json:"state"`
+	Warnings       []string `json:"warnings,omitempty"` // Payout address reuse
 }
 
 // =============================================================================
@@ -85,6 +96,8 @@ type SwapCheckFundingResult struct {
 	LocalConfirmations   uint32 `json:"local_confirmations"`
 	RemoteFunded         bool   `json:"remote_funded"`
 	RemoteConfirmations  uint32 `json:"remote_confirmations"`
+	LocalFinality        string `json:"local_finality,omitempty"`
+	RemoteFinality       string `json:"remote_finality,omitempty"`
 	BothFunded           bool   `json:"both_funded"`
 	ReadyForNonceExchange bool  `json:"ready_for_nonce_exchange"`
 	State                string `json:"state"`
@@ -108,6 +121,17 @@ type SwapFundResult struct {
 	TotalInput   uint64 `json:"total_input"`
 	Change       uint64 `json:"change"`
 	State        string `json:"state"`
+
+	// PendingStep is set instead of TxID when the funding transaction waits
+	// for swap_confirmStep (state "awaiting_confirmation").
+	PendingStep *swap.StepPreview `json:"pending_step,omitempty"`
+}
+
+// SwapBumpFeeParams is the parameters for swap_bumpFee.
+type SwapBumpFeeParams struct {
+	TradeID string `json:"trade_id"`
+	Method  string `json:"method,omitempty"`   // "rbf" (default) or "cpfp"
+	FeeRate uint64 `json:"fee_rate,omitempty"` // sat/vB, 0 for the fee estimate
 }
 
 // =============================================================================
@@ -170,6 +194,7 @@ type SwapStatusResult struct {
 	RequestHTLCAddress    string         `json:"request_htlc_address,omitempty"` // HTLC address for request chain (Bitcoin)
 	OfferEVMAddress       string         `json:"offer_evm_address,omitempty"`    // Local wallet address for offer chain (EVM)
 	RequestEVMAddress     string         `json:"request_evm_address,omitempty"`  // Local wallet address for request chain (EVM)
+	PayoutAddress         string         `json:"payout_address,omitempty"`       // External address for our receiving leg
 	LocalPubKey           string         `json:"local_pubkey,omitempty"`
 	RemotePubKey          string         `json:"remote_pubkey,omitempty"`
 	LocalFunding          *FundingStatus `json:"local_funding,omitempty"`
@@ -179,6 +204,21 @@ type SwapStatusResult struct {
 	HasOfferSigs          bool           `json:"has_offer_sigs"`
 	HasRequestSigs        bool           `json:"has_request_sigs"`
 	ReadyToRedeem         bool           `json:"ready_to_redeem"`
+
+	// Deadlines are omitted once the swap is terminal.
+	Deadlines []swap.Deadline `json:"deadlines,omitempty"`
+
+	// ZeroConf is set while the zero-conf policy monitors or decided on
+	// the counterparty's unconfirmed funding.
+	ZeroConf *swap.ZeroConfStatus `json:"zero_conf,omitempty"`
+
+	// FundingVariance is set when the counterparty's escrow differs from
+	// the negotiated amount.
+	FundingVariance *swap.FundingVarianceStatus `json:"funding_variance,omitempty"`
+
+	// TermsDigest is our hash of the trade terms, embedded in every
+	// protocol message we send.
+	TermsDigest string `json:"terms_digest,omitempty"`
 }
 
 // FundingStatus represents the status of a funding transaction.
@@ -188,6 +228,7 @@ type FundingStatus struct {
 	Amount        uint64 `json:"amount"`
 	Confirmations uint32 `json:"confirmations"`
 	Confirmed     bool   `json:"confirmed"`
+	Finality      string `json:"finality"`
 }
 
 // =============================================================================
@@ -238,6 +279,11 @@ type SwapRecoverResult struct {
 // Timeout and Refund Types
 // =============================================================================
 
+// SwapReconcileParams is the parameters for swap_reconcile.
+type SwapReconcileParams struct {
+	TradeID string `json:"trade_id"`
+}
+
 // SwapTimeoutParams is the parameters for swap_timeout.
 type SwapTimeoutParams struct {
 	TradeID string `json:"trade_id"`
@@ -321,6 +367,20 @@ type SwapHTLCRefundResult struct {
 	State      string `json:"state"`
 }
 
+// SwapHTLCBatchSettleParams is the parameters for swap_htlcBatchSettle.
+type SwapHTLCBatchSettleParams struct {
+	Chain string `json:"chain"` // Which chain to settle on
+}
+
+// SwapHTLCBatchSettleResult is the result of swap_htlcBatchSettle.
+type SwapHTLCBatchSettleResult struct {
+	Chain    string   `json:"chain"`
+	TxID     string   `json:"txid"`
+	Claimed  []string `json:"claimed"`  // Trade IDs claimed in the batch
+	Refunded []string `json:"refunded"` // Trade IDs refunded in the batch
+	Fee      uint64   `json:"fee"`
+}
+
 // SwapHTLCExtractSecretParams is the parameters for swap_htlcExtractSecret.
 type SwapHTLCExtractSecretParams struct {
 	TradeID string `json:"trade_id"`
@@ -349,9 +409,12 @@ type SwapEVMCreateParams struct {
 type SwapEVMCreateResult struct {
 	TradeID string `json:"trade_id"`
 	Chain   string `json:"chain"`
-	TxHash  string `json:"tx_hash"`
+	TxHash  string `json:"tx_hash,omitempty"`
 	State   string `json:"state"`
 	Message string `json:"message"`
+
+	// Set when the creation waits for swap_confirmStep
+	PendingStep *swap.StepPreview `json:"pending_step,omitempty"`
 }
 
 // SwapEVMClaimParams is the parameters for swap_evmClaim.
@@ -382,6 +445,20 @@ type SwapEVMRefundResult struct {
 	State        string `json:"state"`
 }
 
+// SwapEVMCancelCreateParams is the parameters for swap_evmCancelCreate and
+// swap_evmCancelStatus.
+type SwapEVMCancelCreateParams struct {
+	TradeID string `json:"trade_id"`
+	Chain   string `json:"chain"`
+}
+
+// SwapEVMCancelCreateResult is the result of swap_evmCancelCreate and
+// swap_evmCancelStatus.
+type SwapEVMCancelCreateResult struct {
+	TradeID string `json:"trade_id"`
+	*swap.EVMCreateCancellation
+}
+
 // SwapEVMStatusParams is the parameters for swap_evmStatus.
 type SwapEVMStatusParams struct {
 	TradeID string `json:"trade_id"`
@@ -400,6 +477,9 @@ type SwapEVMStatusResult struct {
 	SecretHash   string `json:"secret_hash"`
 	Timelock     int64  `json:"timelock"`
 	IsNative     bool   `json:"is_native"`
+
+	AssetSymbol   string `json:"asset_symbol,omitempty"`   // Native symbol or registered token symbol
+	AmountDecimal string `json:"amount_decimal,omitempty"` // Empty for unregistered tokens
 }
 
 // SwapEVMWaitSecretParams is the parameters for swap_evmWaitSecret.
@@ -436,8 +516,9 @@ type SwapEVMGetContractsResult struct {
 
 // EVMContractInfo holds info about a deployed EVM contract.
 type EVMContractInfo struct {
-	ChainID         uint64 `json:"chain_id"`
-	ContractAddress string `json:"contract_address"`
+	ChainID         uint64   `json:"chain_id"`
+	ContractAddress string   `json:"contract_address"`
+	LegacyContracts []string `json:"legacy_contracts,omitempty"` // Previous versions, in-flight swaps only
 }
 
 // SwapEVMGetContractParams is the parameters for swap_evmGetContract.
@@ -447,9 +528,10 @@ type SwapEVMGetContractParams struct {
 
 // SwapEVMGetContractResult is the result of swap_evmGetContract.
 type SwapEVMGetContractResult struct {
-	ChainID         uint64 `json:"chain_id"`
-	ContractAddress string `json:"contract_address"`
-	Deployed        bool   `json:"deployed"`
+	ChainID         uint64   `json:"chain_id"`
+	ContractAddress string   `json:"contract_address"`
+	LegacyContracts []string `json:"legacy_contracts,omitempty"` // Previous versions, in-flight swaps only
+	Deployed        bool     `json:"deployed"`
 }
 
 // SwapEVMComputeSwapIDParams is the parameters for swap_evmComputeSwapID.
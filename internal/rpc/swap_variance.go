@@ -0,0 +1,117 @@
+// Package rpc - Funding variance protocol messages.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// relayFundingVariance tells the counterparty about top-up requests and
+// aborts decided by the coordinator's funding variance check.
+func (s *Server) relayFundingVariance(event swap.SwapEvent) {
+	data, _ := event.Data.(map[string]interface{})
+
+	var msg *node.SwapMessage
+	var err error
+	switch event.EventType {
+	case swap.EventFundingVarianceTopUp:
+		payload := &node.FundingTopUpPayload{}
+		payload.TxID, _ = data["txid"].(string)
+		payload.Chain, _ = data["chain"].(string)
+		payload.Expected, _ = data["expected"].(uint64)
+		payload.Actual, _ = data["actual"].(uint64)
+		payload.Deadline, _ = data["top_up_deadline"].(int64)
+		msg, err = node.NewSwapMessage(node.SwapMsgFundingTopUp, event.TradeID, payload)
+
+	case swap.EventFundingVarianceAborted:
+		reason, _ := data["reason"].(string)
+		if localFunded, _ := data["local_funded"].(bool); !localFunded {
+			if err := s.store.UpdateTradeFailure(event.TradeID, "funding variance: "+reason); err != nil {
+				s.log.Warn("Failed to update trade failure", "trade_id", event.TradeID, "error", err)
+			}
+		}
+		msg, err = node.NewSwapMessage(node.SwapMsgAbort, event.TradeID, &node.AbortPayload{Reason: reason})
+
+	default:
+		return
+	}
+	if err != nil || s.node == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.sendDirectToCounterparty(ctx, event.TradeID, msg); err != nil {
+		s.log.Warn("Failed to send funding variance message", "trade_id", event.TradeID, "type", msg.Type, "error", err)
+	}
+}
+
+// handleFundingTopUp processes a counterparty's request to re-fund our escrow.
+func (s *Server) handleFundingTopUp(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.FundingTopUpPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse funding top-up payload", "error", err)
+		return nil
+	}
+
+	// Re-funding is manual: broadcast a full escrow and call swap_setFunding
+	s.log.Warn("Counterparty requests an escrow top-up",
+		"trade_id", short(msg.TradeID, 8),
+		"txid", short(payload.TxID, 16),
+		"expected", payload.Expected,
+		"actual", payload.Actual,
+		"deadline", payload.Deadline,
+	)
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast("funding_top_up_requested", map[string]interface{}{
+			"trade_id":  msg.TradeID,
+			"txid":      payload.TxID,
+			"chain":     payload.Chain,
+			"expected":  payload.Expected,
+			"actual":    payload.Actual,
+			"deadline":  payload.Deadline,
+			"from_peer": msg.FromPeer,
+		}, SwapTopic(msg.TradeID))
+	}
+
+	return nil
+}
+
+// handleSwapAbort processes a counterparty's abort notice.
+func (s *Server) handleSwapAbort(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.AbortPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse abort payload", "error", err)
+		return nil
+	}
+
+	// Funds we locked come back through the normal refund path at timeout
+	s.log.Warn("Counterparty aborted swap", "trade_id", short(msg.TradeID, 8), "reason", payload.Reason)
+	if trade, err := s.store.GetTrade(msg.TradeID); err == nil && (trade.MakerPeerID == msg.FromPeer || trade.TakerPeerID == msg.FromPeer) {
+		// Aborts are sent for short escrows (funding variance)
+		s.recordSwapFailure(msg.TradeID, swap.FailureFundingInsufficient, "aborted by counterparty: "+payload.Reason)
+	}
+
+	if s.wsHub != nil {
+		s.wsHub.Broadcast("swap_aborted", map[string]interface{}{
+			"trade_id":  msg.TradeID,
+			"reason":    payload.Reason,
+			"from_peer": msg.FromPeer,
+		}, SwapTopic(msg.TradeID))
+	}
+
+	return nil
+}
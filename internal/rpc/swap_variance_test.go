@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestRelayFundingVarianceAbort(t *testing.T) {
+	s := newTestStoreServer(t)
+	for _, id := range []string{"unfunded", "funded"} {
+		if err := s.store.CreateTrade(&storage.Trade{
+			ID: id, OrderID: "o-" + id, MakerPeerID: "maker", TakerPeerID: "taker",
+			OurRole: storage.TradeRoleMaker, State: storage.TradeStateFunding, CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+
+	s.relayFundingVariance(swap.SwapEvent{TradeID: "unfunded", EventType: swap.EventFundingVarianceAborted,
+		Data: map[string]interface{}{"reason": "shortfall 1000 bps above limit", "local_funded": false}})
+	s.relayFundingVariance(swap.SwapEvent{TradeID: "funded", EventType: swap.EventFundingVarianceAborted,
+		Data: map[string]interface{}{"reason": "top-up not received in time", "local_funded": true}})
+
+	trade, err := s.store.GetTrade("unfunded")
+	if err != nil {
+		t.Fatalf("GetTrade() error = %v", err)
+	}
+	if trade.State != storage.TradeStateFailed || !strings.Contains(trade.FailureReason, "1000 bps") {
+		t.Errorf("unfunded trade = %s (%q), want failed", trade.State, trade.FailureReason)
+	}
+
+	// A funded trade keeps waiting for its refund
+	if trade, _ := s.store.GetTrade("funded"); trade.State != storage.TradeStateFunding {
+		t.Errorf("funded trade state = %s, want funding", trade.State)
+	}
+}
@@ -0,0 +1,186 @@
+// Package rpc - P2P messages of BTC/XMR adaptor swaps.
+//
+// The coordinator drives adaptor swaps itself (swap/coordinator_xmr.go).
+// This file carries its messages: the key shares sent by swap_init, and the
+// lock info, signatures and XMR lock relayed from coordinator events.
+package rpc
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// sendXMRKeyShare sends our key share and BTC address to the counterparty.
+func (s *Server) sendXMRKeyShare(ctx context.Context, tradeID string) error {
+	share, btcAddr, err := s.coordinator.LocalXMRKeyShare(tradeID)
+	if err != nil {
+		return err
+	}
+	msg, err := node.NewSwapMessage(node.SwapMsgXMRKeyShare, tradeID, &node.XMRKeySharePayload{Share: share, BTCAddr: btcAddr})
+	if err != nil {
+		return err
+	}
+	if err := s.sendDirectToCounterparty(ctx, tradeID, msg); err != nil {
+		return err
+	}
+	s.log.Info("Sent XMR key share to counterparty", "trade_id", short(tradeID, 8))
+	return nil
+}
+
+// handleXMRKeyShare records the counterparty's key share. The maker's first
+// share reaches the taker before it joined the swap, so only its key is
+// kept for swap_init, and the maker sends it again on the taker's share.
+func (s *Server) handleXMRKeyShare(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" {
+		return nil
+	}
+
+	var payload node.XMRKeySharePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil || payload.Share == nil || payload.Share.BTCKey == nil {
+		s.log.Warn("Failed to parse XMR key share payload", "error", err)
+		return nil
+	}
+	trade, err := s.store.GetTrade(msg.TradeID)
+	if err != nil {
+		s.log.Debug("Trade not found for XMR key share", "trade_id", msg.TradeID)
+		return nil
+	}
+	fromMaker := msg.FromPeer == trade.MakerPeerID
+	if !fromMaker && msg.FromPeer != trade.TakerPeerID {
+		s.log.Warn("XMR key share from unexpected peer", "trade_id", short(msg.TradeID, 8), "from", short(msg.FromPeer, 12))
+		return nil
+	}
+	pubKeyHex := hex.EncodeToString(payload.Share.BTCKey.SerializeCompressed())
+	if err := s.store.UpdateTradePubKey(msg.TradeID, fromMaker, pubKeyHex); err != nil {
+		s.log.Warn("Failed to store remote pubkey in trade", "error", err)
+	}
+	if s.coordinator == nil {
+		return nil
+	}
+
+	if err := s.coordinator.SetXMRKeyShare(msg.TradeID, payload.Share, payload.BTCAddr); err != nil {
+		if errors.Is(err, swap.ErrSwapNotFound) {
+			s.log.Debug("Swap not initialized yet, kept the XMR key share's pubkey", "trade_id", short(msg.TradeID, 8))
+			return nil
+		}
+		s.log.Warn("Ignoring XMR key share", "trade_id", short(msg.TradeID, 8), "error", err)
+		return nil
+	}
+	s.log.Info("Received XMR key share", "trade_id", short(msg.TradeID, 8), "from_maker", fromMaker)
+
+	if !fromMaker {
+		if err := s.sendXMRKeyShare(ctx, msg.TradeID); err != nil {
+			s.log.Warn("Failed to send XMR key share", "trade_id", short(msg.TradeID, 8), "error", err)
+		}
+	}
+	return nil
+}
+
+// relayXMR sends the counterparty the steps of an adaptor swap.
+func (s *Server) relayXMR(event swap.SwapEvent) {
+	data, _ := event.Data.(map[string]interface{})
+	var msgType string
+	var payload interface{}
+	switch event.EventType {
+	case swap.EventXMRLockPrepared:
+		lock, _ := data["lock"].(swap.XMRLockInfo)
+		cancelSig, _ := data["cancel_sig"].(string)
+		msgType, payload = node.SwapMsgXMRLockInfo, &node.XMRLockInfoPayload{Lock: lock, CancelSig: cancelSig}
+	case swap.EventXMRRefundSigned:
+		cancelSig, _ := data["cancel_sig"].(string)
+		presig, _ := data["refund_presig"].(string)
+		msgType, payload = node.SwapMsgXMRRefundSigs, &node.XMRRefundSigsPayload{CancelSig: cancelSig, RefundPresig: presig}
+	case swap.EventXMRLocked:
+		txID, _ := data["txid"].(string)
+		txKey, _ := data["tx_key"].(string)
+		height, _ := data["restore_height"].(int64)
+		msgType, payload = node.SwapMsgXMRLocked, &node.XMRLockedPayload{TxID: txID, TxKey: txKey, RestoreHeight: height}
+	case swap.EventXMRRedeemPresig:
+		presig, _ := data["presig"].(string)
+		msgType, payload = node.SwapMsgXMRRedeemPresig, &node.XMRRedeemPresigPayload{Presig: presig}
+	default:
+		return
+	}
+
+	msg, err := node.NewSwapMessage(msgType, event.TradeID, payload)
+	if err != nil || s.node == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := s.sendDirectToCounterparty(ctx, event.TradeID, msg); err != nil {
+		s.log.Warn("Failed to send adaptor swap message", "trade_id", event.TradeID, "type", msgType, "error", err)
+		return
+	}
+	s.log.Info("Sent adaptor swap message to counterparty", "trade_id", short(event.TradeID, 8), "type", msgType)
+}
+
+// handleXMRLockInfo has the XMR party sign the cancel and pre-sign the
+// refund of the BTC party's lock.
+func (s *Server) handleXMRLockInfo(ctx context.Context, msg *node.SwapMessage) error {
+	var payload node.XMRLockInfoPayload
+	if !s.parseXMRMessage(msg, &payload) {
+		return nil
+	}
+	s.logXMRMessage(msg, s.coordinator.SetXMRLockInfo(msg.TradeID, payload.Lock, payload.CancelSig))
+	return nil
+}
+
+// handleXMRRefundSigs lets the BTC party broadcast its lock.
+func (s *Server) handleXMRRefundSigs(ctx context.Context, msg *node.SwapMessage) error {
+	var payload node.XMRRefundSigsPayload
+	if !s.parseXMRMessage(msg, &payload) {
+		return nil
+	}
+	s.logXMRMessage(msg, s.coordinator.SetXMRRefundSigs(msg.TradeID, payload.CancelSig, payload.RefundPresig))
+	return nil
+}
+
+// handleXMRLocked records the XMR party's lock, which the BTC party checks
+// with its tx key.
+func (s *Server) handleXMRLocked(ctx context.Context, msg *node.SwapMessage) error {
+	var payload node.XMRLockedPayload
+	if !s.parseXMRMessage(msg, &payload) {
+		return nil
+	}
+	s.logXMRMessage(msg, s.coordinator.SetXMRLocked(msg.TradeID, payload.TxID, payload.TxKey, payload.RestoreHeight))
+	return nil
+}
+
+// handleXMRRedeemPresig lets the XMR party redeem the BTC.
+func (s *Server) handleXMRRedeemPresig(ctx context.Context, msg *node.SwapMessage) error {
+	var payload node.XMRRedeemPresigPayload
+	if !s.parseXMRMessage(msg, &payload) {
+		return nil
+	}
+	s.logXMRMessage(msg, s.coordinator.SetXMRRedeemPresig(msg.TradeID, payload.Presig))
+	return nil
+}
+
+// parseXMRMessage decodes the payload of a counterparty's adaptor swap
+// message, reporting whether it should be handled.
+func (s *Server) parseXMRMessage(msg *node.SwapMessage, payload interface{}) bool {
+	if msg.FromPeer == s.node.ID().String() || msg.TradeID == "" || s.coordinator == nil {
+		return false
+	}
+	if err := json.Unmarshal(msg.Payload, payload); err != nil {
+		s.log.Warn("Failed to parse adaptor swap payload", "type", msg.Type, "error", err)
+		return false
+	}
+	return true
+}
+
+// logXMRMessage logs the outcome of an adaptor swap message.
+func (s *Server) logXMRMessage(msg *node.SwapMessage, err error) {
+	if err != nil {
+		s.log.Warn("Ignoring adaptor swap message", "trade_id", short(msg.TradeID, 8), "type", msg.Type, "error", err)
+		return
+	}
+	s.log.Info("Received adaptor swap message", "trade_id", short(msg.TradeID, 8), "type", msg.Type)
+}
@@ -0,0 +1,33 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+func TestRelayXMRWithoutNode(t *testing.T) {
+	s := newTestStoreServer(t)
+	// No node: nothing is sent, and other events are skipped
+	s.relayXMR(swap.SwapEvent{TradeID: "t1", EventType: swap.EventXMRAction})
+	s.relayXMR(swap.SwapEvent{TradeID: "t1", EventType: swap.EventXMRLockPrepared,
+		Data: map[string]interface{}{"lock": swap.XMRLockInfo{TxID: "ab", Amount: 1000, SpendFee: 10}, "cancel_sig": "00"}})
+	s.relayXMR(swap.SwapEvent{TradeID: "t1", EventType: swap.EventXMRLocked})
+}
+
+func TestXMRLockInfoPayload(t *testing.T) {
+	lock := swap.XMRLockInfo{TxID: "ab", Vout: 1, Amount: 1000, SpendFee: 10}
+	msg, err := node.NewSwapMessage(node.SwapMsgXMRLockInfo, "t1", &node.XMRLockInfoPayload{Lock: lock, CancelSig: "00"})
+	if err != nil {
+		t.Fatalf("NewSwapMessage() error = %v", err)
+	}
+	var payload node.XMRLockInfoPayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if payload.Lock != lock || payload.CancelSig != "00" {
+		t.Errorf("payload = %+v, want %+v", payload, lock)
+	}
+}
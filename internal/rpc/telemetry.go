@@ -0,0 +1,47 @@
+// Package rpc - Anonymized telemetry RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/telemetry"
+)
+
+// SetTelemetry enables the telemetry_* methods.
+func (s *Server) SetTelemetry(r *telemetry.Reporter) {
+	s.telemetry = r
+}
+
+// TelemetryPreviewResult is the response for telemetry_preview.
+type TelemetryPreviewResult struct {
+	Enabled     bool              `json:"enabled"`
+	Destination string            `json:"destination"`
+	Report      *telemetry.Report `json:"report"` // Sent as is, apart from a new report_id
+}
+
+// telemetryStatus returns the reporter state and the last report sent.
+func (s *Server) telemetryStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.telemetry == nil {
+		return nil, fmt.Errorf("telemetry not initialized")
+	}
+	return s.telemetry.Status(), nil
+}
+
+// telemetryPreview returns the report that would be sent now.
+func (s *Server) telemetryPreview(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.telemetry == nil {
+		return nil, fmt.Errorf("telemetry not initialized")
+	}
+	report, err := s.telemetry.Preview()
+	if err != nil {
+		return nil, err
+	}
+	st := s.telemetry.Status()
+	return &TelemetryPreviewResult{
+		Enabled:     st.Enabled,
+		Destination: st.Destination,
+		Report:      report,
+	}, nil
+}
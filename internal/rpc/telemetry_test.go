@@ -0,0 +1,40 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/telemetry"
+)
+
+func TestTelemetryRPC(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+	if _, err := s.telemetryPreview(ctx, nil); err == nil {
+		t.Error("telemetry_preview without a reporter should fail")
+	}
+
+	reporter, err := telemetry.New(node.TelemetryConfig{}, s.store, "testnet")
+	if err != nil {
+		t.Fatalf("telemetry.New() error = %v", err)
+	}
+	s.SetTelemetry(reporter)
+
+	result, err := s.telemetryPreview(ctx, nil)
+	if err != nil {
+		t.Fatalf("telemetry_preview error = %v", err)
+	}
+	preview := result.(*TelemetryPreviewResult)
+	if preview.Enabled || preview.Destination != telemetry.Topic || preview.Report == nil || preview.Report.Network != "testnet" {
+		t.Errorf("telemetry_preview = %+v", preview)
+	}
+
+	status, err := s.telemetryStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("telemetry_status error = %v", err)
+	}
+	if st := status.(*telemetry.Status); st.Enabled || st.Interval != "24h0m0s" {
+		t.Errorf("telemetry_status = %+v", st)
+	}
+}
@@ -0,0 +1,141 @@
+// Package rpc - API server TLS (static certificates and ACME).
+package rpc
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// SetTLS configures TLS for the API server. It must be called before Start.
+func (s *Server) SetTLS(cfg node.TLSConfig) {
+	s.tlsCfg = cfg
+}
+
+// TLSEnabled returns true if the API server serves HTTPS/WSS.
+func (s *Server) TLSEnabled() bool {
+	return s.tlsCfg.Enabled()
+}
+
+// checkTLSRequired refuses plaintext on non-loopback addresses unless explicitly allowed.
+func checkTLSRequired(addr string, cfg *node.TLSConfig) error {
+	if cfg.Enabled() || cfg.AllowInsecure || isLoopbackAddr(addr) {
+		return nil
+	}
+	return fmt.Errorf("refusing to serve plaintext API on non-loopback address %s: configure api.tls or set api.tls.allow_insecure", addr)
+}
+
+// isLoopbackAddr returns true if a host:port address only binds loopback.
+// An empty host binds all interfaces and is not loopback.
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// buildTLSConfig returns the TLS config for the API server, or nil when TLS is disabled.
+func (s *Server) buildTLSConfig() (*tls.Config, error) {
+	cfg := &s.tlsCfg
+	switch {
+	case cfg.UsesStaticCert():
+		if cfg.CertFile == "" || cfg.KeyFile == "" {
+			return nil, fmt.Errorf("api.tls requires both cert_file and key_file")
+		}
+		reloader, err := newCertReloader(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		return &tls.Config{
+			MinVersion:     tls.VersionTLS12,
+			GetCertificate: reloader.GetCertificate,
+		}, nil
+
+	case cfg.UsesACME():
+		if cfg.ACMECacheDir == "" {
+			return nil, fmt.Errorf("api.tls.acme_cache_dir is required for ACME")
+		}
+		manager := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(cfg.ACMEDomains...),
+			Cache:      autocert.DirCache(cfg.ACMECacheDir),
+			Email:      cfg.ACMEEmail,
+		}
+		if cfg.ACMEHTTPAddr != "" {
+			s.acmeServer = &http.Server{
+				Addr:              cfg.ACMEHTTPAddr,
+				Handler:           manager.HTTPHandler(nil),
+				ReadHeaderTimeout: 10 * time.Second,
+			}
+			go func() {
+				if err := s.acmeServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+					s.log.Error("ACME HTTP challenge server error", "error", err)
+				}
+			}()
+		}
+		tlsConfig := manager.TLSConfig()
+		tlsConfig.MinVersion = tls.VersionTLS12
+		return tlsConfig, nil
+	}
+	return nil, nil
+}
+
+// certReloader serves a certificate from disk and reloads it when the files change,
+// so renewed certificates are picked up without restarting the node.
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	r := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := r.reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// reload loads the key pair if the certificate file changed since the last load.
+func (r *certReloader) reload() error {
+	info, err := os.Stat(r.certFile)
+	if err != nil {
+		return fmt.Errorf("failed to stat TLS certificate: %w", err)
+	}
+	if r.cert != nil && !info.ModTime().After(r.modTime) {
+		return nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+	r.cert = &cert
+	r.modTime = info.ModTime()
+	return nil
+}
+
+// GetCertificate implements tls.Config.GetCertificate.
+func (r *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// Keep serving the previous certificate if a reload fails mid-rotation
+	_ = r.reload()
+	return r.cert, nil
+}
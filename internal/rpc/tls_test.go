@@ -0,0 +1,145 @@
+package rpc
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// writeSelfSignedCert writes a self-signed localhost certificate and key to dir.
+func writeSelfSignedCert(t *testing.T, dir string) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		DNSNames:     []string{"localhost"},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error = %v", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("MarshalECPrivateKey() error = %v", err)
+	}
+
+	certFile = filepath.Join(dir, "cert.pem")
+	keyFile = filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{"127.0.0.1:8080", true},
+		{"localhost:8080", true},
+		{"[::1]:8080", true},
+		{"0.0.0.0:8080", false},
+		{":8080", false},
+		{"192.168.1.10:8080", false},
+		{"invalid", false},
+	}
+	for _, tt := range tests {
+		if got := isLoopbackAddr(tt.addr); got != tt.want {
+			t.Errorf("isLoopbackAddr(%q) = %v, want %v", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestCheckTLSRequired(t *testing.T) {
+	if err := checkTLSRequired("127.0.0.1:8080", &node.TLSConfig{}); err != nil {
+		t.Errorf("loopback plaintext should be allowed: %v", err)
+	}
+	if err := checkTLSRequired("0.0.0.0:8080", &node.TLSConfig{}); err == nil {
+		t.Error("non-loopback plaintext should be refused")
+	}
+	if err := checkTLSRequired("0.0.0.0:8080", &node.TLSConfig{AllowInsecure: true}); err != nil {
+		t.Errorf("allow_insecure should permit plaintext: %v", err)
+	}
+	if err := checkTLSRequired("0.0.0.0:8080", &node.TLSConfig{ACMEDomains: []string{"api.example.com"}}); err != nil {
+		t.Errorf("TLS-enabled config should be allowed: %v", err)
+	}
+}
+
+func TestServerStartRefusesPlaintextPublic(t *testing.T) {
+	s := newTestStoreServer(t)
+	if err := s.Start("0.0.0.0:0"); err == nil {
+		s.Stop()
+		t.Fatal("expected Start to refuse plaintext on 0.0.0.0")
+	}
+}
+
+func TestServerStartTLSStaticCert(t *testing.T) {
+	certFile, keyFile := writeSelfSignedCert(t, t.TempDir())
+
+	s := newTestStoreServer(t)
+	s.SetTLS(node.TLSConfig{CertFile: certFile, KeyFile: keyFile})
+	if !s.TLSEnabled() {
+		t.Fatal("TLSEnabled() = false")
+	}
+	if err := s.Start("127.0.0.1:0"); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer s.Stop()
+
+	client := &http.Client{
+		Timeout:   5 * time.Second,
+		Transport: &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}},
+	}
+	body := `{"jsonrpc":"2.0","method":"does_notExist","id":1}`
+	resp, err := client.Post("https://"+s.listener.Addr().String()+"/", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("HTTPS request error = %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.TLS == nil {
+		t.Fatal("response was not served over TLS")
+	}
+	var rpcResp Response
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		t.Fatalf("decode error = %v", err)
+	}
+	if rpcResp.Error == nil || rpcResp.Error.Code != MethodNotFound {
+		t.Errorf("expected MethodNotFound, got %+v", rpcResp.Error)
+	}
+}
+
+func TestServerStartTLSMissingKey(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.SetTLS(node.TLSConfig{CertFile: "/nonexistent/cert.pem"})
+	if err := s.Start("127.0.0.1:0"); err == nil {
+		s.Stop()
+		t.Fatal("expected error when key_file is missing")
+	}
+}
@@ -0,0 +1,23 @@
+// Package rpc - Tracing RPC handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+)
+
+// SetTracer enables the tracing_status method.
+func (s *Server) SetTracer(p *tracing.Provider) {
+	s.tracer = p
+}
+
+// tracingStatus returns the span exporter state.
+func (s *Server) tracingStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.tracer == nil {
+		return nil, fmt.Errorf("tracing not initialized")
+	}
+	return s.tracer.Status(), nil
+}
@@ -0,0 +1,30 @@
+package rpc
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+)
+
+func TestTracingStatusRPC(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+	if _, err := s.tracingStatus(ctx, nil); err == nil {
+		t.Error("tracing_status without a provider should fail")
+	}
+
+	p, err := tracing.New(tracing.Config{SamplePercent: 25}, "test")
+	if err != nil {
+		t.Fatalf("tracing.New() error = %v", err)
+	}
+	s.SetTracer(p)
+
+	result, err := s.tracingStatus(ctx, nil)
+	if err != nil {
+		t.Fatalf("tracing_status error = %v", err)
+	}
+	if st := result.(*tracing.Status); st.Enabled || st.SamplePercent != 25 || st.Endpoint != "" {
+		t.Errorf("tracing_status = %+v", st)
+	}
+}
@@ -0,0 +1,446 @@
+// Package rpc - Aggregation of small trades into one swap.
+//
+// A taker filling many small orders of the same maker pays the on-chain
+// overhead of every swap. Trades taken with aggregate set are collected for
+// aggregation.window, then proposed to the maker as one aggregate trade
+// (swap_aggregate proposes trades explicitly). The maker accepts when
+// aggregation.accept is set and the trades are still in the init state,
+// share chains, tokens, method and fee terms, and were created within its
+// own window. Both sides then record the same aggregate: an order and a
+// trade summing the members' amounts, identified by a hash of the member
+// trade IDs, and abort the members. The aggregate is an ordinary trade:
+// swap_init runs it as one swap per chain leg with a single secret.
+// Proposals to nodes without aggregation support go unanswered and leave
+// the trades as they were.
+// TODO: trades whose fee adjustments were negotiated separately are summed
+// as is; renegotiate on the aggregate instead.
+package rpc
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// EventTradesAggregated is broadcast when an aggregation proposal is
+// accepted or rejected, by us or the maker.
+const EventTradesAggregated EventType = "trades_aggregated"
+
+// SwapAggregateParams is the parameters for swap_aggregate.
+type SwapAggregateParams struct {
+	TradeIDs []string `json:"trade_ids"`
+	DryRun   bool     `json:"dry_run,omitempty"` // Only check the trades combine
+}
+
+// SwapAggregateResult is the response for swap_aggregate.
+type SwapAggregateResult struct {
+	AggregateID   string   `json:"aggregate_id"`
+	TradeIDs      []string `json:"trade_ids"`
+	OfferAmount   uint64   `json:"offer_amount"`
+	RequestAmount uint64   `json:"request_amount"`
+	Proposed      bool     `json:"proposed"` // Sent; the reply arrives as a trades_aggregated event
+}
+
+// TradesAggregatedEvent is the data of a trades_aggregated event.
+type TradesAggregatedEvent struct {
+	AggregateID  string   `json:"aggregate_id"`
+	TradeIDs     []string `json:"trade_ids"`
+	Accepted     bool     `json:"accepted"`
+	Reason       string   `json:"reason,omitempty"`
+	ProposedByUs bool     `json:"proposed_by_us"`
+}
+
+// aggregatePlan is an aggregate trade and its order, before they are
+// recorded.
+type aggregatePlan struct {
+	members []string
+	order   *storage.Order
+	trade   *storage.Trade
+}
+
+// aggregateBatch collects the trades taken against one maker and pair
+// during the window.
+type aggregateBatch struct {
+	tradeIDs []string
+	timer    *time.Timer
+}
+
+// SetAggregation sets the trade aggregation configuration.
+func (s *Server) SetAggregation(cfg node.AggregationConfig) {
+	s.aggregation = cfg
+}
+
+// aggregateID derives the ID of the aggregate of trades, the same on both
+// sides.
+func aggregateID(tradeIDs []string) string {
+	ids := append([]string(nil), tradeIDs...)
+	sort.Strings(ids)
+	sum := sha256.Sum256([]byte("klingdex-aggregate\n" + strings.Join(ids, "\n")))
+	return hex.EncodeToString(sum[:16])
+}
+
+// planAggregate checks that trades can be combined and builds their
+// aggregate.
+func (s *Server) planAggregate(tradeIDs []string) (*aggregatePlan, error) {
+	if len(tradeIDs) < 2 {
+		return nil, fmt.Errorf("at least two trades are required")
+	}
+	if limit := s.aggregation.MaxTrades; limit > 0 && len(tradeIDs) > limit {
+		return nil, fmt.Errorf("%d trades exceed the maximum %d", len(tradeIDs), limit)
+	}
+	members := append([]string(nil), tradeIDs...)
+	sort.Strings(members)
+
+	var first *storage.Trade
+	var firstOrder *storage.Order
+	var offerSum, requestSum uint64
+	var adjustment int64
+	var earliest, latest time.Time
+	for i, id := range members {
+		if i > 0 && id == members[i-1] {
+			return nil, fmt.Errorf("trade %s listed twice", short(id, 8))
+		}
+		trade, err := s.store.GetTrade(id)
+		if err != nil {
+			return nil, fmt.Errorf("trade %s not found: %w", short(id, 8), err)
+		}
+		if err := s.checkFeeNegotiable(trade); err != nil {
+			return nil, fmt.Errorf("trade %s: %w", short(id, 8), err)
+		}
+		order, err := s.store.GetOrder(trade.OrderID)
+		if err != nil {
+			return nil, fmt.Errorf("order of trade %s not found: %w", short(id, 8), err)
+		}
+
+		if first == nil {
+			first, firstOrder = trade, order
+			earliest, latest = trade.CreatedAt, trade.CreatedAt
+		} else if reason := aggregateMismatch(first, firstOrder, trade, order); reason != "" {
+			return nil, fmt.Errorf("trade %s: %s", short(id, 8), reason)
+		}
+		if trade.CreatedAt.Before(earliest) {
+			earliest = trade.CreatedAt
+		}
+		if trade.CreatedAt.After(latest) {
+			latest = trade.CreatedAt
+		}
+
+		offerAmount, requestAmount := tradeAmounts(trade, order)
+		if offerAmount > math.MaxUint64-offerSum || requestAmount > math.MaxUint64-requestSum {
+			return nil, fmt.Errorf("combined amounts overflow")
+		}
+		offerSum += offerAmount
+		requestSum += requestAmount
+		adjustment += trade.FeeAdjustment
+	}
+	if window := s.aggregation.Window; window > 0 && latest.Sub(earliest) > window {
+		return nil, fmt.Errorf("trades were created %s apart, more than the window %s", latest.Sub(earliest).Round(time.Second), window)
+	}
+
+	id := aggregateID(members)
+	now := time.Now()
+	order := &storage.Order{
+		ID:               id,
+		PeerID:           firstOrder.PeerID,
+		Status:           storage.OrderStatusMatched,
+		IsLocal:          firstOrder.IsLocal,
+		OfferChain:       firstOrder.OfferChain,
+		OfferAmount:      offerSum,
+		RequestChain:     firstOrder.RequestChain,
+		RequestAmount:    requestSum,
+		OfferToken:       firstOrder.OfferToken,
+		RequestToken:     firstOrder.RequestToken,
+		PreferredMethods: []string{first.Method},
+		FeeTerms:         firstOrder.FeeTerms,
+		CreatedAt:        now,
+	}
+	trade := &storage.Trade{
+		ID:            id,
+		OrderID:       id,
+		MakerPeerID:   first.MakerPeerID,
+		TakerPeerID:   first.TakerPeerID,
+		OurRole:       first.OurRole,
+		Method:        first.Method,
+		State:         storage.TradeStateInit,
+		OfferAmount:   offerSum,
+		RequestAmount: requestSum,
+		CreatedAt:     now,
+		FeeAdjustment: adjustment,
+	}
+	return &aggregatePlan{members: members, order: order, trade: trade}, nil
+}
+
+// aggregateMismatch returns why a trade can't join the aggregate of first,
+// or "".
+func aggregateMismatch(first *storage.Trade, firstOrder *storage.Order, trade *storage.Trade, order *storage.Order) string {
+	switch {
+	case trade.MakerPeerID != first.MakerPeerID || trade.TakerPeerID != first.TakerPeerID:
+		return "different counterparty"
+	case trade.Method != first.Method:
+		return "different method"
+	case order.OfferChain != firstOrder.OfferChain || order.RequestChain != firstOrder.RequestChain ||
+		order.OfferToken != firstOrder.OfferToken || order.RequestToken != firstOrder.RequestToken:
+		return "different pair"
+	case order.FeeTerms != firstOrder.FeeTerms:
+		return "different fee terms"
+	case order.IsLocal != firstOrder.IsLocal:
+		return "different order owner"
+	}
+	return ""
+}
+
+// applyAggregate records an aggregate and aborts its members.
+func (s *Server) applyAggregate(plan *aggregatePlan) error {
+	if err := s.store.CreateOrder(plan.order); err != nil {
+		return fmt.Errorf("failed to create aggregate order: %w", err)
+	}
+	if err := s.store.CreateTrade(plan.trade); err != nil {
+		s.store.DeleteOrder(plan.order.ID)
+		return fmt.Errorf("failed to create aggregate trade: %w", err)
+	}
+	if err := s.store.AggregateTrades(plan.trade.ID, plan.members); err != nil {
+		s.store.DeleteTrade(plan.trade.ID)
+		s.store.DeleteOrder(plan.order.ID)
+		return err
+	}
+	return nil
+}
+
+// swapAggregate proposes to combine trades against the same maker into one
+// aggregate trade.
+func (s *Server) swapAggregate(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p SwapAggregateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	plan, err := s.planAggregate(p.TradeIDs)
+	if err != nil {
+		return nil, fmt.Errorf("cannot aggregate trades: %w", err)
+	}
+	if s.node != nil && plan.trade.TakerPeerID != s.node.ID().String() {
+		return nil, fmt.Errorf("cannot aggregate trades: only the taker proposes an aggregate")
+	}
+
+	result := &SwapAggregateResult{
+		AggregateID:   plan.trade.ID,
+		TradeIDs:      plan.members,
+		OfferAmount:   plan.trade.OfferAmount,
+		RequestAmount: plan.trade.RequestAmount,
+	}
+	if p.DryRun {
+		return result, nil
+	}
+	if err := s.proposeAggregate(ctx, plan); err != nil {
+		return nil, fmt.Errorf("failed to send proposal: %w", err)
+	}
+	result.Proposed = true
+	return result, nil
+}
+
+// proposeAggregate sends an aggregation proposal to the maker.
+func (s *Server) proposeAggregate(ctx context.Context, plan *aggregatePlan) error {
+	s.aggregateProposals.Store(plan.trade.ID, plan.members)
+	err := s.sendTradeAggregate(ctx, &node.TradeAggregatePayload{
+		AggregateID: plan.trade.ID,
+		TradeIDs:    plan.members,
+	})
+	if err != nil {
+		s.aggregateProposals.Delete(plan.trade.ID)
+		return err
+	}
+	s.log.Info("Trade aggregation proposed", "aggregate_id", short(plan.trade.ID, 8), "trades", len(plan.members))
+	return nil
+}
+
+// sendTradeAggregate sends a proposal or reply to the counterparty of the
+// member trades.
+func (s *Server) sendTradeAggregate(ctx context.Context, payload *node.TradeAggregatePayload) error {
+	if s.node == nil || s.coordinator == nil {
+		return fmt.Errorf("node not started")
+	}
+	msg, err := node.NewSwapMessage(node.SwapMsgTradeAggregate, payload.TradeIDs[0], payload)
+	if err != nil {
+		return err
+	}
+	return s.sendDirectToCounterparty(ctx, payload.TradeIDs[0], msg)
+}
+
+// handleTradeAggregate processes a taker's proposal or the maker's reply
+// to ours.
+func (s *Server) handleTradeAggregate(ctx context.Context, msg *node.SwapMessage) error {
+	if msg.FromPeer == s.node.ID().String() {
+		return nil
+	}
+
+	var payload node.TradeAggregatePayload
+	if err := json.Unmarshal(msg.Payload, &payload); err != nil {
+		s.log.Warn("Failed to parse trade aggregate payload", "error", err)
+		return nil
+	}
+	if len(payload.TradeIDs) == 0 {
+		return nil
+	}
+	if payload.Reply {
+		s.recordAggregateReply(msg.FromPeer, &payload)
+		return nil
+	}
+	reply := s.reviewAggregate(msg.FromPeer, s.node.ID().String(), &payload)
+	if reply == nil {
+		return nil
+	}
+	if err := s.sendTradeAggregate(ctx, reply); err != nil {
+		s.log.Warn("Failed to reply to trade aggregation", "aggregate_id", short(payload.AggregateID, 8), "error", err)
+	}
+	return nil
+}
+
+// reviewAggregate accepts or rejects a taker's proposal to us, self,
+// recording the aggregate when accepted, and returns the reply (nil for a
+// stranger's message).
+func (s *Server) reviewAggregate(from, self string, p *node.TradeAggregatePayload) *node.TradeAggregatePayload {
+	for _, id := range p.TradeIDs {
+		trade, err := s.store.GetTrade(id)
+		if err != nil || from != trade.TakerPeerID || trade.MakerPeerID != self {
+			return nil
+		}
+	}
+
+	reply := &node.TradeAggregatePayload{AggregateID: p.AggregateID, TradeIDs: p.TradeIDs, Reply: true}
+	var reason string
+	if !s.aggregation.Accept {
+		reason = "aggregation disabled"
+	} else if plan, err := s.planAggregate(p.TradeIDs); err != nil {
+		reason = err.Error()
+	} else if plan.trade.ID != p.AggregateID {
+		reason = "aggregate id mismatch"
+	} else if err := s.applyAggregate(plan); err != nil {
+		s.log.Warn("Failed to record aggregate", "aggregate_id", short(p.AggregateID, 8), "error", err)
+		reason = "failed to record the aggregate"
+	}
+	reply.Accepted = reason == ""
+	reply.Reason = reason
+
+	s.log.Info("Trade aggregation proposed",
+		"aggregate_id", short(p.AggregateID, 8),
+		"trades", len(p.TradeIDs),
+		"accepted", reply.Accepted,
+		"reason", reason,
+	)
+	s.broadcastTradesAggregated(&TradesAggregatedEvent{
+		AggregateID: p.AggregateID,
+		TradeIDs:    p.TradeIDs,
+		Accepted:    reply.Accepted,
+		Reason:      reason,
+	})
+	return reply
+}
+
+// recordAggregateReply records the maker's answer to our pending proposal.
+func (s *Server) recordAggregateReply(from string, p *node.TradeAggregatePayload) {
+	if _, ok := s.aggregateProposals.Load(p.AggregateID); !ok {
+		return
+	}
+	trade, err := s.store.GetTrade(p.TradeIDs[0])
+	if err != nil || from != trade.MakerPeerID {
+		return
+	}
+	s.aggregateProposals.Delete(p.AggregateID)
+
+	if p.Accepted {
+		plan, err := s.planAggregate(p.TradeIDs)
+		if err == nil && plan.trade.ID != p.AggregateID {
+			err = fmt.Errorf("aggregate id mismatch")
+		}
+		if err == nil {
+			err = s.applyAggregate(plan)
+		}
+		if err != nil {
+			// The maker recorded it: the member trades can't complete
+			s.log.Warn("Trade aggregation accepted too late", "aggregate_id", short(p.AggregateID, 8), "error", err)
+		}
+	}
+
+	s.log.Info("Trade aggregation answered",
+		"aggregate_id", short(p.AggregateID, 8),
+		"trades", len(p.TradeIDs),
+		"accepted", p.Accepted,
+		"reason", p.Reason,
+	)
+	s.broadcastTradesAggregated(&TradesAggregatedEvent{
+		AggregateID:  p.AggregateID,
+		TradeIDs:     p.TradeIDs,
+		Accepted:     p.Accepted,
+		Reason:       p.Reason,
+		ProposedByUs: true,
+	})
+}
+
+// broadcastTradesAggregated notifies WebSocket clients of an outcome.
+func (s *Server) broadcastTradesAggregated(event *TradesAggregatedEvent) {
+	if s.wsHub != nil {
+		topics := make([]string, len(event.TradeIDs))
+		for i, tradeID := range event.TradeIDs {
+			topics[i] = SwapTopic(tradeID)
+		}
+		s.wsHub.Broadcast(EventTradesAggregated, event, topics...)
+	}
+}
+
+// queueAggregate adds a trade we took to the batch of its maker and pair,
+// proposed when the window closes or the batch is full.
+func (s *Server) queueAggregate(trade *storage.Trade, order *storage.Order) {
+	key := strings.Join([]string{trade.MakerPeerID, order.OfferChain, order.OfferToken,
+		order.RequestChain, order.RequestToken, trade.Method, order.FeeTerms}, "|")
+
+	s.aggregateMu.Lock()
+	defer s.aggregateMu.Unlock()
+	if s.aggregateBatches == nil {
+		s.aggregateBatches = make(map[string]*aggregateBatch)
+	}
+	batch := s.aggregateBatches[key]
+	if batch == nil {
+		batch = &aggregateBatch{}
+		batch.timer = time.AfterFunc(s.aggregation.Window, func() { s.flushAggregate(key, batch) })
+		s.aggregateBatches[key] = batch
+	}
+	batch.tradeIDs = append(batch.tradeIDs, trade.ID)
+	if len(batch.tradeIDs) >= s.aggregation.MaxTrades {
+		delete(s.aggregateBatches, key)
+		if batch.timer.Stop() {
+			go s.flushAggregate(key, batch)
+		}
+	}
+}
+
+// flushAggregate proposes a batch as one aggregate. A batch of one trade
+// stays as it is.
+func (s *Server) flushAggregate(key string, batch *aggregateBatch) {
+	s.aggregateMu.Lock()
+	if s.aggregateBatches[key] == batch {
+		delete(s.aggregateBatches, key)
+	}
+	ids := batch.tradeIDs
+	s.aggregateMu.Unlock()
+	if len(ids) < 2 {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	plan, err := s.planAggregate(ids)
+	if err == nil {
+		err = s.proposeAggregate(ctx, plan)
+	}
+	if err != nil {
+		s.log.Warn("Failed to propose trade aggregation", "trades", len(ids), "error", err)
+	}
+}
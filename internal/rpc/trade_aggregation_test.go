@@ -0,0 +1,228 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// newAggregationServer returns a server holding three small BTC/LTC trades
+// taken by "taker" against "maker", from the maker's view when local is set.
+func newAggregationServer(t *testing.T, local bool) *Server {
+	t.Helper()
+	s := newTestStoreServer(t)
+	s.SetAggregation(node.AggregationConfig{Accept: true, Window: time.Minute, MaxTrades: 3})
+
+	now := time.Now()
+	for i, id := range []string{"t1", "t2", "t3"} {
+		order := &storage.Order{ID: "o-" + id, PeerID: "maker", Status: storage.OrderStatusMatched, IsLocal: local, OfferChain: "BTC", OfferAmount: uint64(1000 * (i + 1)), RequestChain: "LTC", RequestAmount: uint64(5000 * (i + 1)), CreatedAt: now}
+		if err := s.store.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		trade := &storage.Trade{ID: id, OrderID: order.ID, MakerPeerID: "maker", TakerPeerID: "taker", Method: "htlc_bitcoin", State: storage.TradeStateInit, OfferAmount: order.OfferAmount, RequestAmount: order.RequestAmount, CreatedAt: now.Add(time.Duration(i) * time.Second)}
+		if err := s.store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+	return s
+}
+
+func TestPlanAggregate(t *testing.T) {
+	s := newAggregationServer(t, false)
+
+	plan, err := s.planAggregate([]string{"t3", "t1", "t2"})
+	if err != nil {
+		t.Fatalf("planAggregate() error = %v", err)
+	}
+	if plan.trade.ID != aggregateID([]string{"t1", "t2", "t3"}) || plan.order.ID != plan.trade.ID || plan.trade.OrderID != plan.order.ID {
+		t.Errorf("aggregate IDs = trade %s order %s", plan.trade.ID, plan.order.ID)
+	}
+	if plan.order.OfferAmount != 6000 || plan.order.RequestAmount != 30000 || plan.trade.OfferAmount != 6000 {
+		t.Errorf("aggregate amounts = %d/%d", plan.order.OfferAmount, plan.order.RequestAmount)
+	}
+	if plan.trade.MakerPeerID != "maker" || plan.trade.TakerPeerID != "taker" || plan.trade.Method != "htlc_bitcoin" || plan.order.Status != storage.OrderStatusMatched {
+		t.Errorf("aggregate trade = %+v", plan.trade)
+	}
+	if len(plan.members) != 3 || plan.members[0] != "t1" {
+		t.Errorf("members = %v, want sorted", plan.members)
+	}
+
+	for name, ids := range map[string][]string{
+		"single trade": {"t1"},
+		"duplicate":    {"t1", "t1"},
+		"unknown":      {"t1", "t9"},
+		"too many":     {"t1", "t2", "t3", "t4"},
+	} {
+		if _, err := s.planAggregate(ids); err == nil {
+			t.Errorf("%s: planAggregate() succeeded", name)
+		}
+	}
+
+	s.SetAggregation(node.AggregationConfig{Window: time.Second, MaxTrades: 3})
+	if _, err := s.planAggregate([]string{"t1", "t3"}); err == nil {
+		t.Error("trades beyond the window aggregated")
+	}
+
+	s.store.CreateOrder(&storage.Order{ID: "o-eth", PeerID: "maker", Status: storage.OrderStatusMatched, OfferChain: "ETH", OfferAmount: 1, RequestChain: "LTC", RequestAmount: 1, CreatedAt: time.Now()})
+	s.store.CreateTrade(&storage.Trade{ID: "t4", OrderID: "o-eth", MakerPeerID: "maker", TakerPeerID: "taker", Method: "htlc_bitcoin", State: storage.TradeStateInit, CreatedAt: time.Now()})
+	if _, err := s.planAggregate([]string{"t1", "t4"}); err == nil {
+		t.Error("trades of different pairs aggregated")
+	}
+	s.store.UpdateTradeState("t2", storage.TradeStateFunding)
+	if _, err := s.planAggregate([]string{"t1", "t2"}); err == nil {
+		t.Error("started trade aggregated")
+	}
+}
+
+func TestReviewAggregate(t *testing.T) {
+	ids := []string{"t1", "t2", "t3"}
+	id := aggregateID(ids)
+
+	s := newAggregationServer(t, true)
+	if reply := s.reviewAggregate("stranger", "maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids}); reply != nil {
+		t.Errorf("stranger's proposal answered: %+v", reply)
+	}
+	if reply := s.reviewAggregate("taker", "maker", &node.TradeAggregatePayload{AggregateID: "bogus", TradeIDs: ids}); reply.Accepted {
+		t.Error("proposal with a wrong aggregate ID accepted")
+	}
+
+	reply := s.reviewAggregate("taker", "maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids})
+	if reply == nil || !reply.Reply || !reply.Accepted || reply.AggregateID != id {
+		t.Fatalf("reviewAggregate() = %+v, want accepted", reply)
+	}
+	agg, err := s.store.GetTrade(id)
+	if err != nil {
+		t.Fatalf("aggregate trade not recorded: %v", err)
+	}
+	if agg.State != storage.TradeStateInit || agg.RequestAmount != 30000 {
+		t.Errorf("aggregate trade = %+v", agg)
+	}
+	order, err := s.store.GetOrder(id)
+	if err != nil || !order.IsLocal || order.OfferAmount != 6000 {
+		t.Errorf("aggregate order = %+v, %v", order, err)
+	}
+	for _, member := range ids {
+		trade, _ := s.store.GetTrade(member)
+		if trade.State != storage.TradeStateAborted {
+			t.Errorf("member %s = %s, want aborted", member, trade.State)
+		}
+	}
+
+	// Members already aggregated
+	if reply := s.reviewAggregate("taker", "maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids}); reply.Accepted {
+		t.Error("proposal accepted twice")
+	}
+
+	s = newAggregationServer(t, true)
+	s.SetAggregation(node.AggregationConfig{Window: time.Minute, MaxTrades: 3})
+	if reply := s.reviewAggregate("taker", "maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids}); reply.Accepted || reply.Reason == "" {
+		t.Errorf("aggregation disabled: reply = %+v", reply)
+	}
+}
+
+func TestRecordAggregateReply(t *testing.T) {
+	ids := []string{"t1", "t2"}
+	id := aggregateID(ids)
+	s := newAggregationServer(t, false)
+
+	// Not ours: ignored
+	s.recordAggregateReply("maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids, Reply: true, Accepted: true})
+	if _, err := s.store.GetTrade(id); err == nil {
+		t.Fatal("unsolicited reply recorded")
+	}
+
+	s.aggregateProposals.Store(id, ids)
+	s.recordAggregateReply("stranger", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids, Reply: true, Accepted: true})
+	if _, ok := s.aggregateProposals.Load(id); !ok {
+		t.Fatal("stranger's reply consumed the proposal")
+	}
+
+	s.recordAggregateReply("maker", &node.TradeAggregatePayload{AggregateID: id, TradeIDs: ids, Reply: true, Accepted: true})
+	if _, ok := s.aggregateProposals.Load(id); ok {
+		t.Error("answered proposal still pending")
+	}
+	agg, err := s.store.GetTrade(id)
+	if err != nil || agg.OfferAmount != 3000 || agg.RequestAmount != 15000 {
+		t.Fatalf("aggregate trade = %+v, %v", agg, err)
+	}
+	if members, _ := s.store.GetAggregateMembers(id); len(members) != 2 {
+		t.Errorf("aggregate members = %v", members)
+	}
+	if trade, _ := s.store.GetTrade("t3"); trade.State != storage.TradeStateInit {
+		t.Errorf("t3 = %s, want untouched", trade.State)
+	}
+
+	// A rejection leaves the trades as they were
+	ids = []string{"t2", "t3"}
+	s = newAggregationServer(t, false)
+	s.aggregateProposals.Store(aggregateID(ids), ids)
+	s.recordAggregateReply("maker", &node.TradeAggregatePayload{AggregateID: aggregateID(ids), TradeIDs: ids, Reply: true, Reason: "aggregation disabled"})
+	if trade, _ := s.store.GetTrade("t2"); trade.State != storage.TradeStateInit {
+		t.Errorf("t2 after rejection = %s, want init", trade.State)
+	}
+}
+
+func TestSwapAggregateDryRun(t *testing.T) {
+	s := newAggregationServer(t, false)
+
+	res, err := s.swapAggregate(context.Background(), json.RawMessage(`{"trade_ids":["t2","t1"],"dry_run":true}`))
+	if err != nil {
+		t.Fatalf("swapAggregate() error = %v", err)
+	}
+	got := res.(*SwapAggregateResult)
+	if got.AggregateID != aggregateID([]string{"t1", "t2"}) || got.OfferAmount != 3000 || got.RequestAmount != 15000 || got.Proposed {
+		t.Errorf("swapAggregate() = %+v", got)
+	}
+
+	// Sending needs a running node
+	if _, err := s.swapAggregate(context.Background(), json.RawMessage(`{"trade_ids":["t1","t2"]}`)); err == nil {
+		t.Error("proposing without a node should fail")
+	}
+	if _, ok := s.aggregateProposals.Load(got.AggregateID); ok {
+		t.Error("a failed proposal should not stay pending")
+	}
+}
+
+func TestQueueAggregate(t *testing.T) {
+	s := newAggregationServer(t, false)
+	s.SetAggregation(node.AggregationConfig{Window: time.Hour, MaxTrades: 2})
+	order, _ := s.store.GetOrder("o-t1")
+
+	// A full batch is proposed right away
+	for _, id := range []string{"t1", "t2"} {
+		trade, _ := s.store.GetTrade(id)
+		s.queueAggregate(trade, order)
+	}
+	s.aggregateMu.Lock()
+	pending := len(s.aggregateBatches)
+	s.aggregateMu.Unlock()
+	if pending != 0 {
+		t.Errorf("full batch still collecting: %d batches", pending)
+	}
+
+	// The window closes on a single trade, left as it is
+	s = newAggregationServer(t, false)
+	s.SetAggregation(node.AggregationConfig{Window: 10 * time.Millisecond, MaxTrades: 2})
+	trade, _ := s.store.GetTrade("t3")
+	s.queueAggregate(trade, order)
+	deadline := time.Now().Add(time.Second)
+	for {
+		s.aggregateMu.Lock()
+		pending = len(s.aggregateBatches)
+		s.aggregateMu.Unlock()
+		if pending == 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if pending != 0 {
+		t.Error("batch not flushed after the window")
+	}
+	if trade, _ := s.store.GetTrade("t3"); trade.State != storage.TradeStateInit {
+		t.Errorf("t3 = %s, want untouched", trade.State)
+	}
+}
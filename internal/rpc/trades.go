@@ -25,6 +25,7 @@ type TradeInfo struct {
 	CreatedAt     int64         `json:"created_at"`
 	CompletedAt   *int64        `json:"completed_at,omitempty"`
 	FailureReason string        `json:"failure_reason,omitempty"`
+	FeeAdjustment int64         `json:"fee_adjustment,omitempty"` // Negotiated change of the request amount
 	Legs          []SwapLegInfo `json:"legs,omitempty"`
 }
 
@@ -56,6 +57,7 @@ func tradeToInfo(t *storage.Trade, legs []*storage.SwapLeg) TradeInfo {
 		RequestAmount: t.RequestAmount,
 		CreatedAt:     t.CreatedAt.Unix(),
 		FailureReason: t.FailureReason,
+		FeeAdjustment: t.FeeAdjustment,
 	}
 	if t.CompletedAt != nil {
 		ts := t.CompletedAt.Unix()
@@ -152,10 +154,12 @@ func (s *Server) tradesGet(ctx context.Context, params json.RawMessage) (interfa
 		return nil, fmt.Errorf("trade not found: %w", err)
 	}
 
-	// Get swap legs
+	// Get swap legs (re-importing an archived swap)
 	legs, err := s.store.GetSwapLegsByTradeID(p.ID)
 	if err != nil {
 		s.log.Warn("Failed to get swap legs", "trade_id", p.ID, "error", err)
+	} else if len(legs) == 0 && s.restoreArchived(ctx, p.ID) {
+		legs, _ = s.store.GetSwapLegsByTradeID(p.ID)
 	}
 
 	return tradeToInfo(trade, legs), nil
@@ -195,10 +199,12 @@ func (s *Server) tradesStatus(ctx context.Context, params json.RawMessage) (inte
 		return nil, fmt.Errorf("trade not found: %w", err)
 	}
 
-	// Get swap legs
+	// Get swap legs (re-importing an archived swap)
 	legs, err := s.store.GetSwapLegsByTradeID(p.ID)
 	if err != nil {
 		s.log.Warn("Failed to get swap legs", "trade_id", p.ID, "error", err)
+	} else if len(legs) == 0 && s.restoreArchived(ctx, p.ID) {
+		legs, _ = s.store.GetSwapLegsByTradeID(p.ID)
 	}
 
 	result := TradesStatusResult{
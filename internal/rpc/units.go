@@ -0,0 +1,286 @@
+// Package rpc - Units metadata and localized formatting of amounts.
+//
+// A request with a "units" member gets every recognized amount in its result
+// accompanied by a "<field>_units" object carrying the chain symbol, the
+// decimals, a formatted string in the requested locale and, with the price
+// feed enabled, a USD estimate. WebSocket clients opt in per connection.
+//
+// Amounts are integer fields (or decimal strings of digits, for EVM wei)
+// named amount, balance, fee, value, confirmed or unconfirmed, or ending in
+// _amount, _balance, _fee or _value. The chain is taken from the matching
+// <prefix>_chain field (offer_amount -> offer_chain), else from a chain,
+// symbol or coin field of the object or its nearest ancestor, or from an
+// enclosing map key that is a chain symbol. A non-empty <prefix>_token field
+// (offer_token) makes the amount one of that registered ERC-20 token. Objects
+// with their own decimals field (ERC-20 balances) are left alone.
+package rpc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+	"github.com/Klingon-tech/klingdex/pkg/helpers"
+)
+
+// UnitsOptions asks for units metadata next to amounts in a response.
+type UnitsOptions struct {
+	// Locale is a BCP 47 tag (e.g. "de-DE") for the formatted strings.
+	// Empty formats plainly, e.g. "1234.5".
+	Locale string `json:"locale,omitempty"`
+}
+
+// AmountUnits describes an amount; added as "<field>_units".
+type AmountUnits struct {
+	Symbol    string `json:"symbol"`
+	Decimals  uint8  `json:"decimals"`
+	Formatted string `json:"formatted"`
+	FiatUSD   string `json:"fiat_usd,omitempty"` // Estimate at the price feed's rate
+}
+
+// numberFormat holds a locale's separators.
+type numberFormat struct {
+	decimal string
+	group   string // Thousands separator, empty for none
+}
+
+// localeFormats maps lowercase locale tags and languages to separators.
+var localeFormats = map[string]numberFormat{
+	"en":    {".", ","},
+	"ja":    {".", ","},
+	"ko":    {".", ","},
+	"zh":    {".", ","},
+	"de":    {",", "."},
+	"de-ch": {".", "\u2019"},
+	"es":    {",", "."},
+	"id":    {",", "."},
+	"it":    {",", "."},
+	"nl":    {",", "."},
+	"pt":    {",", "."},
+	"tr":    {",", "."},
+	"cs":    {",", "\u00a0"},
+	"fr":    {",", "\u00a0"},
+	"pl":    {",", "\u00a0"},
+	"ru":    {",", "\u00a0"},
+	"sv":    {",", "\u00a0"},
+	"uk":    {",", "\u00a0"},
+}
+
+// amountKeys are field names that hold amounts.
+var amountKeys = map[string]bool{
+	"amount": true, "balance": true, "fee": true, "value": true,
+	"confirmed": true, "unconfirmed": true,
+}
+
+// amountSuffixes are field name suffixes that mark amounts.
+var amountSuffixes = []string{"_amount", "_balance", "_fee", "_value"}
+
+// chainKeys are fields naming the chain of the amounts around them.
+var chainKeys = []string{"chain", "symbol", "coin"}
+
+// localeFormat returns the separators for a locale tag.
+func localeFormat(locale string) (numberFormat, error) {
+	if locale == "" {
+		return numberFormat{decimal: "."}, nil
+	}
+	tag := strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if f, ok := localeFormats[tag]; ok {
+		return f, nil
+	}
+	lang, _, _ := strings.Cut(tag, "-")
+	if f, ok := localeFormats[lang]; ok {
+		return f, nil
+	}
+	return numberFormat{}, fmt.Errorf("unsupported locale: %q", locale)
+}
+
+// format localizes a plain decimal string such as "1234.5".
+func (f numberFormat) format(plain string) string {
+	whole, frac, hasFrac := strings.Cut(plain, ".")
+	if f.group != "" && len(whole) > 3 {
+		var b strings.Builder
+		lead := len(whole) % 3
+		if lead > 0 {
+			b.WriteString(whole[:lead])
+		}
+		for i := lead; i < len(whole); i += 3 {
+			if b.Len() > 0 {
+				b.WriteString(f.group)
+			}
+			b.WriteString(whole[i : i+3])
+		}
+		whole = b.String()
+	}
+	if !hasFrac {
+		return whole
+	}
+	return whole + f.decimal + frac
+}
+
+// unitsAnnotator adds units metadata to a decoded JSON value.
+type unitsAnnotator struct {
+	format numberFormat
+	prices *pricefeed.Checker
+	token  func(chainSymbol, address string) *chain.TokenInfo
+}
+
+// annotateUnits returns result with units metadata added next to its amounts.
+func (s *Server) annotateUnits(result interface{}, opts *UnitsOptions) (interface{}, error) {
+	f, err := localeFormat(opts.Locale)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(result)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+
+	a := &unitsAnnotator{format: f, prices: s.prices, token: s.orderTokenInfo}
+	a.walk(v, "")
+	return v, nil
+}
+
+// walk annotates objects in v; chain is the chain inherited from ancestors.
+func (a *unitsAnnotator) walk(v interface{}, chain string) {
+	switch x := v.(type) {
+	case []interface{}:
+		for _, e := range x {
+			a.walk(e, chain)
+		}
+
+	case map[string]interface{}:
+		for _, k := range chainKeys {
+			if symbol, ok := knownSymbol(x[k]); ok {
+				chain = symbol
+				break
+			}
+		}
+		_, selfDescribed := x["decimals"]
+
+		added := make(map[string]interface{})
+		for k, val := range x {
+			if prefix, ok := amountKey(k); ok && !selfDescribed {
+				if units := a.units(val, chainFor(x, prefix, chain), tokenFor(x, prefix)); units != nil {
+					added[k+"_units"] = units
+					continue
+				}
+			}
+			child := chain
+			if _, ok := config.GetCoin(k); ok {
+				child = k // Maps keyed by chain symbol
+			}
+			a.walk(val, child)
+		}
+		for k, units := range added {
+			if _, exists := x[k]; !exists {
+				x[k] = units
+			}
+		}
+	}
+}
+
+// units returns the metadata of an amount, or nil if v is not one. token is
+// the contract address of a token amount, empty for the native coin.
+func (a *unitsAnnotator) units(v interface{}, chain, token string) *AmountUnits {
+	if chain == "" {
+		return nil
+	}
+	coin, ok := config.GetCoin(chain)
+	if !ok {
+		return nil
+	}
+	symbol, decimals := coin.Symbol, coin.Decimals
+	if token != "" {
+		info := a.token(chain, token)
+		if info == nil {
+			return nil
+		}
+		symbol, decimals = info.Symbol, info.Decimals
+	}
+
+	var digits string
+	switch x := v.(type) {
+	case json.Number:
+		digits = x.String()
+	case string:
+		digits = x
+	default:
+		return nil
+	}
+	amount, ok := new(big.Int).SetString(digits, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil
+	}
+
+	units := &AmountUnits{
+		Symbol:    symbol,
+		Decimals:  decimals,
+		Formatted: a.format.format(helpers.FormatBigAmount(amount, decimals)),
+	}
+	if a.prices != nil {
+		if usd, ok := a.prices.AssetUSDValue(symbol, decimals, amount); ok {
+			units.FiatUSD = pricefeed.FormatUSD(usd - usd%(pricefeed.PriceScale/100)) // Whole cents
+		}
+	}
+	return units
+}
+
+// amountKey reports whether a field holds an amount, returning the prefix
+// of suffixed names (offer_amount -> offer).
+func amountKey(key string) (string, bool) {
+	if amountKeys[key] {
+		return "", true
+	}
+	for _, suffix := range amountSuffixes {
+		if prefix, ok := strings.CutSuffix(key, suffix); ok && prefix != "" {
+			return prefix, true
+		}
+	}
+	return "", false
+}
+
+// chainFor returns the chain of an amount field in obj.
+func chainFor(obj map[string]interface{}, prefix, inherited string) string {
+	if prefix != "" {
+		for _, k := range []string{prefix + "_chain", prefix + "_symbol"} {
+			if symbol, ok := knownSymbol(obj[k]); ok {
+				return symbol
+			}
+		}
+	}
+	return inherited
+}
+
+// tokenFor returns the token contract address of a prefixed amount field in
+// obj, empty for the native coin.
+func tokenFor(obj map[string]interface{}, prefix string) string {
+	if prefix == "" {
+		return ""
+	}
+	token, _ := obj[prefix+"_token"].(string)
+	return token
+}
+
+// knownSymbol returns v as a supported chain symbol.
+func knownSymbol(v interface{}) (string, bool) {
+	s, ok := v.(string)
+	if !ok {
+		return "", false
+	}
+	s = strings.ToUpper(s)
+	if _, ok := config.GetCoin(s); !ok {
+		return "", false
+	}
+	return s, true
+}
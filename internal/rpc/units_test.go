@@ -0,0 +1,224 @@
+package rpc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/pricefeed"
+)
+
+func TestLocaleFormat(t *testing.T) {
+	tests := []struct {
+		locale string
+		plain  string
+		want   string
+	}{
+		{"", "1234567.891", "1234567.891"},
+		{"en-US", "1234567.891", "1,234,567.891"},
+		{"de-DE", "1234567.891", "1.234.567,891"},
+		{"de_CH", "1234.5", "1’234.5"},
+		{"fr", "123456", "123 456"},
+		{"en", "999", "999"},
+		{"pt-BR", "0.00000001", "0,00000001"},
+	}
+	for _, tt := range tests {
+		f, err := localeFormat(tt.locale)
+		if err != nil {
+			t.Fatalf("localeFormat(%q) error = %v", tt.locale, err)
+		}
+		if got := f.format(tt.plain); got != tt.want {
+			t.Errorf("format(%q, %q) = %q, want %q", tt.locale, tt.plain, got, tt.want)
+		}
+	}
+	if _, err := localeFormat("xx-YY"); err == nil {
+		t.Error("localeFormat() should reject unknown locales")
+	}
+}
+
+func TestAnnotateUnits(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	result := map[string]interface{}{
+		"offer_chain":    "BTC",
+		"offer_amount":   150000000,
+		"request_chain":  "ltc",
+		"request_amount": 1000,
+		"count":          2,
+		"legs": []interface{}{
+			map[string]interface{}{"chain": "ETH", "amount": "1500000000000000000"},
+		},
+		"balances": map[string]interface{}{
+			"BTC": map[string]interface{}{"confirmed": 2100, "unconfirmed": 0},
+		},
+		"token": map[string]interface{}{"symbol": "ETH", "balance": "1000000", "decimals": 6},
+		"fee":   10, // No chain in scope
+	}
+
+	out, err := s.annotateUnits(result, &UnitsOptions{Locale: "de"})
+	if err != nil {
+		t.Fatalf("annotateUnits() error = %v", err)
+	}
+	data, _ := json.Marshal(out)
+	var got struct {
+		OfferUnits   *AmountUnits `json:"offer_amount_units"`
+		RequestUnits *AmountUnits `json:"request_amount_units"`
+		FeeUnits     *AmountUnits `json:"fee_units"`
+		CountUnits   *AmountUnits `json:"count_units"`
+		Legs         []struct {
+			Units *AmountUnits `json:"amount_units"`
+		} `json:"legs"`
+		Balances map[string]struct {
+			Confirmed *AmountUnits `json:"confirmed_units"`
+		} `json:"balances"`
+		Token struct {
+			Units *AmountUnits `json:"balance_units"`
+		} `json:"token"`
+	}
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+
+	if u := got.OfferUnits; u == nil || u.Symbol != "BTC" || u.Decimals != 8 || u.Formatted != "1,5" || u.FiatUSD != "" {
+		t.Errorf("offer_amount_units = %+v", u)
+	}
+	if u := got.RequestUnits; u == nil || u.Symbol != "LTC" || u.Formatted != "0,00001" {
+		t.Errorf("request_amount_units = %+v", u)
+	}
+	if u := got.Legs[0].Units; u == nil || u.Symbol != "ETH" || u.Decimals != 18 || u.Formatted != "1,5" {
+		t.Errorf("leg amount_units = %+v", u)
+	}
+	if u := got.Balances["BTC"].Confirmed; u == nil || u.Formatted != "0,000021" {
+		t.Errorf("confirmed_units under a chain key = %+v", u)
+	}
+	if got.Token.Units != nil {
+		t.Error("objects with their own decimals must not be annotated")
+	}
+	if got.FeeUnits != nil || got.CountUnits != nil {
+		t.Error("amounts without a chain and non-amount fields must not be annotated")
+	}
+}
+
+func TestAnnotateUnitsFiat(t *testing.T) {
+	s := newTestStoreServer(t)
+	prices, err := pricefeed.New(node.PriceSanityConfig{StaticPrices: map[string]string{"BTC": "60000.5"}})
+	if err != nil {
+		t.Fatalf("pricefeed.New() error = %v", err)
+	}
+	s.SetPriceChecker(prices)
+
+	out, err := s.annotateUnits(map[string]interface{}{"symbol": "BTC", "balance": 12345678}, &UnitsOptions{})
+	if err != nil {
+		t.Fatalf("annotateUnits() error = %v", err)
+	}
+	units := out.(map[string]interface{})["balance_units"].(*AmountUnits)
+	// 0.12345678 BTC * 60000.5 = 7407.468... USD, truncated to cents
+	if units.Formatted != "0.12345678" || units.FiatUSD != "7407.46" {
+		t.Errorf("balance_units = %+v", units)
+	}
+}
+
+func TestAnnotateUnitsTokens(t *testing.T) {
+	s := newTestStoreServer(t)
+	prices, err := pricefeed.New(node.PriceSanityConfig{StaticPrices: map[string]string{"USDC": "1", "USDT": "0.999"}})
+	if err != nil {
+		t.Fatalf("pricefeed.New() error = %v", err)
+	}
+	s.SetPriceChecker(prices)
+
+	order := OrderInfo{
+		OfferChain: "ARBITRUM", OfferAmount: 2_500_000, OfferToken: "0xaf88d065e77c8cC2239327C5EDb3A432268e5831",
+		RequestChain: "BSC", RequestAmount: 2_500_000_000_000_000_000, RequestToken: "0x55d398326f99059fF775485246999027B3197955",
+	}
+	out, err := s.annotateUnits(order, &UnitsOptions{})
+	if err != nil {
+		t.Fatalf("annotateUnits() error = %v", err)
+	}
+	m := out.(map[string]interface{})
+	offer := m["offer_amount_units"].(*AmountUnits)
+	if offer.Symbol != "USDC" || offer.Decimals != 6 || offer.Formatted != "2.5" || offer.FiatUSD != "2.5" {
+		t.Errorf("offer_amount_units = %+v", offer)
+	}
+	request := m["request_amount_units"].(*AmountUnits)
+	if request.Symbol != "USDT" || request.Decimals != 18 || request.Formatted != "2.5" || request.FiatUSD != "2.49" {
+		t.Errorf("request_amount_units = %+v", request)
+	}
+
+	// Unregistered tokens are left alone rather than shown in coin units
+	order.OfferToken = "0x000000000000000000000000000000000000dEaD"
+	out, _ = s.annotateUnits(order, &UnitsOptions{})
+	if _, ok := out.(map[string]interface{})["offer_amount_units"]; ok {
+		t.Error("an unregistered token amount must not be annotated")
+	}
+}
+
+func TestHandleRPCUnits(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.handlers["test_balance"] = func(ctx context.Context, params json.RawMessage) (interface{}, error) {
+		return &WalletGetBalanceResult{Balance: 100000000, Symbol: "BTC"}, nil
+	}
+
+	call := func(body string) map[string]interface{} {
+		t.Helper()
+		w := httptest.NewRecorder()
+		s.handleRPC(w, httptest.NewRequest(http.MethodPost, "/", bytes.NewBufferString(body)))
+		var resp map[string]interface{}
+		if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+			t.Fatalf("Unmarshal(response) error = %v", err)
+		}
+		return resp
+	}
+
+	plain := call(`{"jsonrpc":"2.0","method":"test_balance","id":1}`)
+	if _, ok := plain["result"].(map[string]interface{})["balance_units"]; ok {
+		t.Error("units added without the request flag")
+	}
+
+	resp := call(`{"jsonrpc":"2.0","method":"test_balance","id":1,"units":{"locale":"en"}}`)
+	units, ok := resp["result"].(map[string]interface{})["balance_units"].(map[string]interface{})
+	if !ok || units["formatted"] != "1" || units["symbol"] != "BTC" {
+		t.Errorf("result = %v, want balance_units", resp["result"])
+	}
+
+	bad := call(`{"jsonrpc":"2.0","method":"test_balance","id":1,"units":{"locale":"tlh"}}`)
+	if errObj, ok := bad["error"].(map[string]interface{}); !ok || errObj["code"] != float64(InvalidParams) {
+		t.Errorf("unsupported locale response = %v, want invalid params", bad)
+	}
+}
+
+func TestWSUnits(t *testing.T) {
+	s := newTestStoreServer(t)
+	hub := NewWSHub()
+	hub.SetUnitsAnnotator(s.annotateUnits)
+	go hub.Run()
+
+	plain := &WSClient{send: make(chan []byte, 4), subscriptions: make(map[EventType]bool), hub: hub}
+	withUnits := &WSClient{send: make(chan []byte, 4), subscriptions: make(map[EventType]bool), hub: hub}
+	withUnits.handleSubscription(&WSSubscription{Action: "units", Units: &UnitsOptions{Locale: "de"}})
+	hub.register <- plain
+	hub.register <- withUnits
+
+	hub.Broadcast("order_created", map[string]interface{}{"offer_chain": "BTC", "offer_amount": 150000000})
+
+	var ev struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	json.Unmarshal(<-plain.send, &ev)
+	if _, ok := ev.Data["offer_amount_units"]; ok {
+		t.Error("plain client received units")
+	}
+	json.Unmarshal(<-withUnits.send, &ev)
+	if units, ok := ev.Data["offer_amount_units"].(map[string]interface{}); !ok || units["formatted"] != "1,5" {
+		t.Errorf("units client data = %v", ev.Data)
+	}
+
+	// Turning units off
+	withUnits.handleSubscription(&WSSubscription{Action: "units"})
+	if withUnits.units != nil {
+		t.Error("units not cleared")
+	}
+}
@@ -0,0 +1,115 @@
+// Package rpc - UTXO consolidation and wallet transaction history handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/Klingon-tech/klingdex/internal/consolidate"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// finalityLookups bounds the concurrent backend lookups labeling wallet
+// transactions.
+const finalityLookups = 8
+
+// SetConsolidator enables the consolidation methods.
+func (s *Server) SetConsolidator(c *consolidate.Consolidator) {
+	s.consolidator = c
+}
+
+// WalletConsolidateParams is the parameters for wallet_consolidate.
+type WalletConsolidateParams struct {
+	Symbol string `json:"symbol"`
+	Force  bool   `json:"force,omitempty"` // Ignore the fee rate threshold
+}
+
+// WalletTransactionsParams is the parameters for wallet_transactions.
+type WalletTransactionsParams struct {
+	Symbol string `json:"symbol,omitempty"` // Empty lists every chain's
+	Limit  int    `json:"limit,omitempty"`
+}
+
+// WalletTransactionItem is a wallet transaction with its confirmations as
+// read from the chain's backend.
+type WalletTransactionItem struct {
+	*storage.WalletTransaction
+	Confirmations int64  `json:"confirmations"`
+	Finality      string `json:"finality"` // unknown if the backend couldn't tell
+}
+
+// walletConsolidate checks a chain and consolidates its small UTXOs now if
+// it qualifies. The result says why when nothing was consolidated.
+func (s *Server) walletConsolidate(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p WalletConsolidateParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if s.consolidator == nil {
+		return nil, fmt.Errorf("consolidation not initialized")
+	}
+	return s.consolidator.Run(ctx, p.Symbol, p.Force)
+}
+
+// walletConsolidationStatus returns the last consolidation check per chain.
+func (s *Server) walletConsolidationStatus(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.consolidator == nil {
+		return nil, fmt.Errorf("consolidation not initialized")
+	}
+	return s.consolidator.Status(), nil
+}
+
+// walletTransactions lists the wallet transactions the node made on its own,
+// newest first, labeled with their finality.
+func (s *Server) walletTransactions(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p WalletTransactionsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not available")
+	}
+	if p.Limit <= 0 || p.Limit > 1000 {
+		p.Limit = 100
+	}
+	txs, err := s.store.ListWalletTransactions(p.Symbol, p.Limit)
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]WalletTransactionItem, len(txs))
+	sem := make(chan struct{}, finalityLookups)
+	var wg sync.WaitGroup
+	for i, tx := range txs {
+		items[i] = WalletTransactionItem{WalletTransaction: tx, Finality: FinalityUnknown}
+		if s.wallet == nil {
+			continue
+		}
+		wg.Add(1)
+		go func(item *WalletTransactionItem) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			chainTx, err := s.wallet.GetTransaction(ctx, item.Chain, item.TxID)
+			if err != nil {
+				return
+			}
+			item.Confirmations = chainTx.Confirmations
+			if chainTx.Confirmed && item.Confirmations == 0 {
+				// Mined, but the backend didn't count its confirmations
+				item.Confirmations = 1
+			}
+			item.Finality = s.finalityOf(item.Chain, item.Confirmations)
+		}(&items[i])
+	}
+	wg.Wait()
+	return items, nil
+}
@@ -0,0 +1,57 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestWalletConsolidateNotInitialized(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.walletConsolidate(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("walletConsolidate() accepted a missing symbol")
+	}
+	if _, err := s.walletConsolidate(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletConsolidate() succeeded without a consolidator")
+	}
+	if _, err := s.walletConsolidationStatus(ctx, nil); err == nil {
+		t.Error("walletConsolidationStatus() succeeded without a consolidator")
+	}
+}
+
+func TestWalletTransactions(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	for _, tx := range []*storage.WalletTransaction{
+		{Chain: "BTC", Kind: storage.WalletTxConsolidation, TxID: "aa", Inputs: 10, CreatedAt: 100},
+		{Chain: "LTC", Kind: storage.WalletTxConsolidation, TxID: "bb", Inputs: 12, CreatedAt: 200},
+	} {
+		if err := s.store.RecordWalletTransaction(tx); err != nil {
+			t.Fatalf("RecordWalletTransaction() error = %v", err)
+		}
+	}
+
+	result, err := s.walletTransactions(ctx, nil)
+	if err != nil {
+		t.Fatalf("walletTransactions() error = %v", err)
+	}
+	if txs := result.([]WalletTransactionItem); len(txs) != 2 || txs[0].TxID != "bb" {
+		t.Errorf("walletTransactions() = %+v, want both, newest first", txs)
+	}
+
+	result, err = s.walletTransactions(ctx, json.RawMessage(`{"symbol":"BTC"}`))
+	if err != nil {
+		t.Fatalf("walletTransactions(BTC) error = %v", err)
+	}
+	if txs := result.([]WalletTransactionItem); len(txs) != 1 || txs[0].TxID != "aa" {
+		t.Errorf("walletTransactions(BTC) = %+v", txs)
+	}
+	if txs := result.([]WalletTransactionItem); txs[0].Finality != FinalityUnknown {
+		t.Errorf("finality without a wallet = %s, want unknown", txs[0].Finality)
+	}
+}
@@ -0,0 +1,230 @@
+// Package rpc - Output descriptor wallet handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// maxDescriptorAddresses bounds wallet_deriveDescriptorAddresses.
+const maxDescriptorAddresses = 1000
+
+// WalletImportDescriptorParams is the request for wallet_importDescriptor.
+type WalletImportDescriptorParams struct {
+	Symbol     string `json:"symbol"`          // Chain symbol (BTC, LTC, etc.)
+	Descriptor string `json:"descriptor"`      // wpkh(), tr(), pkh() or sh(wpkh()), checksum optional
+	Label      string `json:"label,omitempty"` // Free-form name
+}
+
+// WalletListDescriptorsParams is the request for wallet_listDescriptors.
+type WalletListDescriptorsParams struct {
+	Symbol string `json:"symbol,omitempty"` // Empty lists all chains
+}
+
+// WalletDescriptorIDParams identifies an imported descriptor.
+type WalletDescriptorIDParams struct {
+	ID string `json:"id"`
+}
+
+// WalletDeriveDescriptorAddressesParams is the request for wallet_deriveDescriptorAddresses.
+type WalletDeriveDescriptorAddressesParams struct {
+	ID     string `json:"id"`
+	Branch int    `json:"branch,omitempty"` // 0=receive, 1=change (multipath descriptors)
+	Start  uint32 `json:"start,omitempty"`
+	Count  uint32 `json:"count,omitempty"` // Default 1
+}
+
+// WalletScanDescriptorParams is the request for wallet_scanDescriptor.
+type WalletScanDescriptorParams struct {
+	ID       string `json:"id"`
+	GapLimit uint32 `json:"gap_limit,omitempty"` // Default 20
+}
+
+// WalletSendFromDescriptorParams is the request for wallet_sendFromDescriptor.
+type WalletSendFromDescriptorParams struct {
+	ID     string `json:"id"`
+	To     string `json:"to"`     // Destination address
+	Amount uint64 `json:"amount"` // Amount in smallest units
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
+}
+
+// WalletSendFromDescriptorResult is the response for wallet_sendFromDescriptor.
+type WalletSendFromDescriptorResult struct {
+	*wallet.MultiAddressTxResult
+	Warnings []string `json:"warnings,omitempty"` // Address reuse
+}
+
+// WalletListDescriptorsResult is the response for wallet_listDescriptors.
+type WalletListDescriptorsResult struct {
+	Descriptors []*wallet.DescriptorInfo `json:"descriptors"`
+	Count       int                      `json:"count"`
+}
+
+// walletImportDescriptor imports an output descriptor as a watch-only or signing wallet.
+func (s *Server) walletImportDescriptor(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletImportDescriptorParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.Descriptor == "" {
+		return nil, fmt.Errorf("descriptor is required")
+	}
+
+	info, err := s.wallet.ImportDescriptor(p.Symbol, p.Descriptor, p.Label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import descriptor: %w", err)
+	}
+	return info, nil
+}
+
+// walletListDescriptors lists imported descriptors.
+func (s *Server) walletListDescriptors(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletListDescriptorsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	descriptors, err := s.wallet.ListDescriptors(p.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return &WalletListDescriptorsResult{Descriptors: descriptors, Count: len(descriptors)}, nil
+}
+
+// walletRemoveDescriptor deletes an imported descriptor.
+func (s *Server) walletRemoveDescriptor(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletDescriptorIDParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	if err := s.wallet.RemoveDescriptor(p.ID); err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"removed": true, "id": p.ID}, nil
+}
+
+// walletDeriveDescriptorAddresses derives addresses of an imported descriptor.
+func (s *Server) walletDeriveDescriptorAddresses(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletDeriveDescriptorAddressesParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if p.Count == 0 {
+		p.Count = 1
+	}
+	if p.Count > maxDescriptorAddresses {
+		return nil, fmt.Errorf("count must be at most %d", maxDescriptorAddresses)
+	}
+
+	addresses, err := s.wallet.DescriptorAddresses(p.ID, p.Branch, p.Start, p.Count)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"id": p.ID, "addresses": addresses}, nil
+}
+
+// walletScanDescriptor scans the balance of an imported descriptor.
+func (s *Server) walletScanDescriptor(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletScanDescriptorParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	result, err := s.wallet.ScanDescriptor(ctx, p.ID, p.GapLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan descriptor: %w", err)
+	}
+	return result, nil
+}
+
+// walletSendFromDescriptor spends from a signing descriptor.
+func (s *Server) walletSendFromDescriptor(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletSendFromDescriptorParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+	if p.To == "" {
+		return nil, fmt.Errorf("to address is required")
+	}
+	if p.Amount == 0 {
+		return nil, fmt.Errorf("amount must be greater than 0")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	// The descriptor's chain, which reuse is checked on
+	rec, err := s.store.GetWalletDescriptor(p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load descriptor: %w", err)
+	}
+	if rec == nil {
+		return nil, storage.ErrWalletDescriptorNotFound
+	}
+	warnings, err := s.checkAddressReuse(rec.Symbol, p.To, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.wallet.SendFromDescriptor(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.ID, p.To, p.Amount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send: %w", err)
+	}
+	s.recordSend(rec.Symbol, result.TxID, p.To, p.Amount, result.Fee, result.InputCount)
+
+	return &WalletSendFromDescriptorResult{MultiAddressTxResult: result, Warnings: warnings}, nil
+}
@@ -0,0 +1,79 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+func TestWalletDescriptorHandlers(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.walletImportDescriptor(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("walletImportDescriptor() without wallet service should fail")
+	}
+
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: s.store})
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := s.wallet.CreateWallet(mnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	other, _ := wallet.NewFromMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow", "", chain.Testnet)
+	key, _ := other.DeriveKeyAtPath([]uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart + 1, hdkeychain.HardenedKeyStart})
+	pub, _ := key.Neuter()
+
+	if _, err := s.walletImportDescriptor(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletImportDescriptor() without descriptor should fail")
+	}
+	params, _ := json.Marshal(WalletImportDescriptorParams{Symbol: "BTC", Descriptor: "wpkh(" + pub.String() + "/<0;1>/*)", Label: "sparrow"})
+	res, err := s.walletImportDescriptor(ctx, params)
+	if err != nil {
+		t.Fatalf("walletImportDescriptor() error = %v", err)
+	}
+	info := res.(*wallet.DescriptorInfo)
+	if !info.WatchOnly || info.Label != "sparrow" || info.Branches != 2 {
+		t.Errorf("walletImportDescriptor() = %+v", info)
+	}
+
+	res, err = s.walletListDescriptors(ctx, nil)
+	if err != nil || res.(*WalletListDescriptorsResult).Count != 1 {
+		t.Fatalf("walletListDescriptors() = %+v, %v", res, err)
+	}
+
+	if _, err := s.walletDeriveDescriptorAddresses(ctx, json.RawMessage(`{"id":"`+info.ID+`","count":1001}`)); err == nil {
+		t.Error("walletDeriveDescriptorAddresses() should bound count")
+	}
+	res, err = s.walletDeriveDescriptorAddresses(ctx, json.RawMessage(`{"id":"`+info.ID+`","branch":1,"count":2}`))
+	if err != nil {
+		t.Fatalf("walletDeriveDescriptorAddresses() error = %v", err)
+	}
+	if addrs := res.(map[string]interface{})["addresses"].([]wallet.DescriptorAddress); len(addrs) != 2 || addrs[0].Path != "k/1/0" {
+		t.Errorf("walletDeriveDescriptorAddresses() = %+v", addrs)
+	}
+
+	if _, err := s.walletSendFromDescriptor(ctx, json.RawMessage(`{"id":"`+info.ID+`","to":"tb1qdest"}`)); err == nil {
+		t.Error("walletSendFromDescriptor() without amount should fail")
+	}
+
+	// Sends from a descriptor are checked for address reuse
+	s.recordSend("BTC", "tx1", "tb1qdest", 1000, 200, 1)
+	s.SetPrivacy(node.PrivacyConfig{AddressReuse: node.AddressReuseEnforce})
+	if _, err := s.walletSendFromDescriptor(ctx, json.RawMessage(`{"id":"`+info.ID+`","to":"tb1qdest","amount":1000}`)); !errors.Is(err, ErrAddressReuse) {
+		t.Errorf("walletSendFromDescriptor(reused) error = %v, want ErrAddressReuse", err)
+	}
+
+	if _, err := s.walletRemoveDescriptor(ctx, json.RawMessage(`{"id":"`+info.ID+`"}`)); err != nil {
+		t.Fatalf("walletRemoveDescriptor() error = %v", err)
+	}
+	if _, err := s.walletRemoveDescriptor(ctx, json.RawMessage(`{"id":"`+info.ID+`"}`)); err == nil {
+		t.Error("walletRemoveDescriptor() twice should fail")
+	}
+}
@@ -7,7 +7,10 @@ import (
 	"fmt"
 	"math/big"
 
+	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
 )
 
 // Note: wallet_scanBalance and wallet_getAddressWithChange handlers are registered in server.go
@@ -35,9 +38,15 @@ func (s *Server) walletStatus(ctx context.Context, params json.RawMessage) (inte
 	}, nil
 }
 
+// WalletGenerateParams is the parameters for wallet_generate.
+type WalletGenerateParams struct {
+	Language string `json:"language,omitempty"` // BIP39 wordlist (default english)
+}
+
 // WalletGenerateResult is the response for wallet_generate.
 type WalletGenerateResult struct {
 	Mnemonic string `json:"mnemonic"`
+	Language string `json:"language"`
 }
 
 func (s *Server) walletGenerate(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -45,13 +54,25 @@ func (s *Server) walletGenerate(ctx context.Context, params json.RawMessage) (in
 		return nil, fmt.Errorf("wallet service not initialized")
 	}
 
-	mnemonic, err := s.wallet.GenerateMnemonic()
+	var p WalletGenerateParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+	lang, err := wallet.ParseLanguage(p.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	mnemonic, err := s.wallet.GenerateMnemonicInLanguage(lang)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate mnemonic: %w", err)
 	}
 
 	return &WalletGenerateResult{
 		Mnemonic: mnemonic,
+		Language: string(lang),
 	}, nil
 }
 
@@ -359,10 +380,16 @@ func (s *Server) walletValidateMnemonic(ctx context.Context, params json.RawMess
 		return nil, fmt.Errorf("invalid params: %w", err)
 	}
 
-	valid := s.wallet.ValidateMnemonic(p.Mnemonic)
+	lang, err := wallet.MnemonicLanguage(p.Mnemonic)
+	if err != nil {
+		return map[string]interface{}{
+			"valid": false,
+		}, nil
+	}
 
 	return map[string]interface{}{
-		"valid": valid,
+		"valid":    true,
+		"language": string(lang),
 	}, nil
 }
 
@@ -377,6 +404,10 @@ type WalletGetBalanceResult struct {
 	Balance uint64 `json:"balance"`
 	Symbol  string `json:"symbol"`
 	Address string `json:"address"`
+
+	// ByFinality splits the address's UTXOs, unconfirmed ones included, by
+	// finality. Omitted on chains without UTXOs.
+	ByFinality []FinalityBalance `json:"by_finality,omitempty"`
 }
 
 func (s *Server) walletGetBalance(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -401,11 +432,19 @@ func (s *Server) walletGetBalance(ctx context.Context, params json.RawMessage) (
 		return nil, fmt.Errorf("failed to get balance: %w", err)
 	}
 
-	return &WalletGetBalanceResult{
+	result := &WalletGetBalanceResult{
 		Balance: balance,
 		Symbol:  p.Symbol,
 		Address: p.Address,
-	}, nil
+	}
+	if utxos, err := s.wallet.GetUTXOs(ctx, p.Symbol, p.Address); err == nil && len(utxos) > 0 {
+		outputs := make([]finalityOutput, len(utxos))
+		for i, u := range utxos {
+			outputs[i] = finalityOutput{confirmations: u.Confirmations, amount: u.Amount}
+		}
+		result.ByFinality = balanceByFinality(s.finalityDepth(p.Symbol), outputs)
+	}
+	return result, nil
 }
 
 // WalletGetFeeEstimatesParams is the parameters for wallet_getFeeEstimates.
@@ -443,14 +482,18 @@ type WalletSendParams struct {
 	Account uint32 `json:"account,omitempty"` // BIP44 account (default 0)
 	Change  uint32 `json:"change,omitempty"`  // 0=external, 1=change (default 0)
 	Index   uint32 `json:"index,omitempty"`   // Address index (default 0)
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
 }
 
 // WalletSendResult is the response for wallet_send.
 type WalletSendResult struct {
-	TxID   string `json:"txid"`
-	Symbol string `json:"symbol"`
-	To     string `json:"to"`
-	Amount uint64 `json:"amount"`
+	TxID     string   `json:"txid"`
+	Symbol   string   `json:"symbol"`
+	To       string   `json:"to"`
+	Amount   uint64   `json:"amount"`
+	Warnings []string `json:"warnings,omitempty"` // Address reuse
 }
 
 func (s *Server) walletSend(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -476,17 +519,24 @@ func (s *Server) walletSend(ctx context.Context, params json.RawMessage) (interf
 		return nil, fmt.Errorf("amount must be greater than 0")
 	}
 
+	warnings, err := s.checkAddressReuse(p.Symbol, p.To, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
+	}
+
 	// Use SendTransactionFromPath to support change addresses (change=0 or change=1)
-	txid, err := s.wallet.SendTransactionFromPath(ctx, p.Symbol, p.To, p.Amount, p.Account, p.Change, p.Index)
+	txid, err := s.wallet.SendTransactionFromPath(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.Symbol, p.To, p.Amount, p.Account, p.Change, p.Index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send transaction: %w", err)
 	}
+	s.recordSend(p.Symbol, txid, p.To, p.Amount, 0, 1)
 
 	return &WalletSendResult{
-		TxID:   txid,
-		Symbol: p.Symbol,
-		To:     p.To,
-		Amount: p.Amount,
+		TxID:     txid,
+		Symbol:   p.Symbol,
+		To:       p.To,
+		Amount:   p.Amount,
+		Warnings: warnings,
 	}, nil
 }
 
@@ -518,14 +568,27 @@ func (s *Server) walletGetUTXOs(ctx context.Context, params json.RawMessage) (in
 		return nil, fmt.Errorf("failed to get UTXOs: %w", err)
 	}
 
+	depth := s.finalityDepth(p.Symbol)
+	labeled := make([]LabeledUTXO, len(utxos))
+	for i, u := range utxos {
+		labeled[i] = LabeledUTXO{UTXO: u, Finality: finalityLabel(u.Confirmations, depth)}
+	}
+
 	return map[string]interface{}{
-		"utxos":   utxos,
-		"count":   len(utxos),
-		"symbol":  p.Symbol,
-		"address": p.Address,
+		"utxos":          labeled,
+		"count":          len(utxos),
+		"symbol":         p.Symbol,
+		"address":        p.Address,
+		"finality_depth": depth,
 	}, nil
 }
 
+// LabeledUTXO is a backend UTXO with its finality label.
+type LabeledUTXO struct {
+	backend.UTXO
+	Finality string `json:"finality"`
+}
+
 // WalletScanBalanceParams is the parameters for wallet_scanBalance.
 type WalletScanBalanceParams struct {
 	Symbol   string `json:"symbol"`            // Chain symbol (BTC, LTC, etc.)
@@ -620,6 +683,9 @@ type WalletSendAllParams struct {
 	Symbol string `json:"symbol"` // Chain symbol (BTC, LTC, etc.)
 	To     string `json:"to"`     // Destination address
 	Amount uint64 `json:"amount"` // Amount in smallest units (satoshis, etc.)
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
 }
 
 // WalletSendAllResult is the response for wallet_sendAll.
@@ -634,6 +700,7 @@ type WalletSendAllResult struct {
 	InputCount  int      `json:"input_count"`
 	OutputCount int      `json:"output_count"`
 	UsedUTXOs   []string `json:"used_utxos"`
+	Warnings    []string `json:"warnings,omitempty"` // Address reuse
 }
 
 func (s *Server) walletSendAll(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -662,10 +729,16 @@ func (s *Server) walletSendAll(ctx context.Context, params json.RawMessage) (int
 		return nil, fmt.Errorf("amount must be greater than 0")
 	}
 
-	result, err := s.wallet.SendFromAllAddresses(ctx, p.Symbol, p.To, p.Amount, s.store)
+	warnings, err := s.checkAddressReuse(p.Symbol, p.To, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.wallet.SendFromAllAddresses(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.Symbol, p.To, p.Amount, s.store)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send: %w", err)
 	}
+	s.recordSend(p.Symbol, result.TxID, p.To, p.Amount, result.Fee, result.InputCount)
 
 	return &WalletSendAllResult{
 		TxID:        result.TxID,
@@ -678,6 +751,7 @@ func (s *Server) walletSendAll(ctx context.Context, params json.RawMessage) (int
 		InputCount:  result.InputCount,
 		OutputCount: result.OutputCount,
 		UsedUTXOs:   result.UsedUTXOs,
+		Warnings:    warnings,
 	}, nil
 }
 
@@ -685,6 +759,9 @@ func (s *Server) walletSendAll(ctx context.Context, params json.RawMessage) (int
 type WalletSendMaxParams struct {
 	Symbol string `json:"symbol"` // Chain symbol (BTC, LTC, etc.)
 	To     string `json:"to"`     // Destination address
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
 }
 
 func (s *Server) walletSendMax(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -710,10 +787,16 @@ func (s *Server) walletSendMax(ctx context.Context, params json.RawMessage) (int
 		return nil, fmt.Errorf("to address is required")
 	}
 
-	result, err := s.wallet.SendMaxFromAllAddresses(ctx, p.Symbol, p.To, s.store)
+	warnings, err := s.checkAddressReuse(p.Symbol, p.To, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.wallet.SendMaxFromAllAddresses(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.Symbol, p.To, s.store)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send max: %w", err)
 	}
+	s.recordSend(p.Symbol, result.TxID, p.To, result.TotalOutput, result.Fee, result.InputCount)
 
 	return &WalletSendAllResult{
 		TxID:        result.TxID,
@@ -726,6 +809,7 @@ func (s *Server) walletSendMax(ctx context.Context, params json.RawMessage) (int
 		InputCount:  result.InputCount,
 		OutputCount: result.OutputCount,
 		UsedUTXOs:   result.UsedUTXOs,
+		Warnings:    warnings,
 	}, nil
 }
 
@@ -736,10 +820,12 @@ type WalletAggregatedBalanceParams struct {
 
 // WalletAggregatedBalanceResult is the response for wallet_getAggregatedBalance.
 type WalletAggregatedBalanceResult struct {
-	Symbol      string `json:"symbol"`
-	Confirmed   uint64 `json:"confirmed"`
-	Unconfirmed uint64 `json:"unconfirmed"`
-	Total       uint64 `json:"total"`
+	Symbol        string            `json:"symbol"`
+	Confirmed     uint64            `json:"confirmed"`
+	Unconfirmed   uint64            `json:"unconfirmed"`
+	Total         uint64            `json:"total"`
+	FinalityDepth uint32            `json:"finality_depth"`
+	ByFinality    []FinalityBalance `json:"by_finality"`
 }
 
 func (s *Server) walletGetAggregatedBalance(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -762,17 +848,27 @@ func (s *Server) walletGetAggregatedBalance(ctx context.Context, params json.Raw
 		return nil, fmt.Errorf("symbol is required")
 	}
 
-	confirmed, unconfirmed, err := s.wallet.GetAggregatedBalance(ctx, p.Symbol, s.store)
+	utxos, err := s.wallet.ListAllUTXOs(ctx, p.Symbol, s.store)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get aggregated balance: %w", err)
 	}
 
-	return &WalletAggregatedBalanceResult{
-		Symbol:      p.Symbol,
-		Confirmed:   confirmed,
-		Unconfirmed: unconfirmed,
-		Total:       confirmed + unconfirmed,
-	}, nil
+	result := &WalletAggregatedBalanceResult{
+		Symbol:        p.Symbol,
+		FinalityDepth: s.finalityDepth(p.Symbol),
+	}
+	outputs := make([]finalityOutput, len(utxos))
+	for i, u := range utxos {
+		if u.Confirmations > 0 {
+			result.Confirmed += u.Amount
+		} else {
+			result.Unconfirmed += u.Amount
+		}
+		outputs[i] = finalityOutput{confirmations: u.Confirmations, amount: u.Amount}
+	}
+	result.Total = result.Confirmed + result.Unconfirmed
+	result.ByFinality = balanceByFinality(result.FinalityDepth, outputs)
+	return result, nil
 }
 
 // WalletListAllUTXOsParams is the parameters for wallet_listAllUTXOs.
@@ -782,10 +878,12 @@ type WalletListAllUTXOsParams struct {
 
 // WalletListAllUTXOsResult is the response for wallet_listAllUTXOs.
 type WalletListAllUTXOsResult struct {
-	Symbol string         `json:"symbol"`
-	UTXOs  []UTXOWithPath `json:"utxos"`
-	Count  int            `json:"count"`
-	Total  uint64         `json:"total"`
+	Symbol        string            `json:"symbol"`
+	UTXOs         []UTXOWithPath    `json:"utxos"`
+	Count         int               `json:"count"`
+	Total         uint64            `json:"total"`
+	FinalityDepth uint32            `json:"finality_depth"`
+	ByFinality    []FinalityBalance `json:"by_finality"`
 }
 
 // UTXOWithPath represents a UTXO with its derivation path.
@@ -799,6 +897,9 @@ type UTXOWithPath struct {
 	AddressIndex uint32 `json:"address_index"`
 	AddressType  string `json:"address_type"`
 	Path         string `json:"path"`
+
+	Confirmations int64  `json:"confirmations"`
+	Finality      string `json:"finality"`
 }
 
 func (s *Server) walletListAllUTXOs(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -831,7 +932,9 @@ func (s *Server) walletListAllUTXOs(ctx context.Context, params json.RawMessage)
 		return nil, fmt.Errorf("unsupported chain: %s", p.Symbol)
 	}
 
+	depth := s.finalityDepth(p.Symbol)
 	result := make([]UTXOWithPath, len(utxos))
+	outputs := make([]finalityOutput, len(utxos))
 	var total uint64
 	for i, u := range utxos {
 		path := fmt.Sprintf("m/%d'/%d'/%d'/%d/%d",
@@ -847,15 +950,21 @@ func (s *Server) walletListAllUTXOs(ctx context.Context, params json.RawMessage)
 			AddressIndex: u.AddressIndex,
 			AddressType:  u.AddressType,
 			Path:         path,
+
+			Confirmations: u.Confirmations,
+			Finality:      finalityLabel(u.Confirmations, depth),
 		}
+		outputs[i] = finalityOutput{confirmations: u.Confirmations, amount: u.Amount}
 		total += u.Amount
 	}
 
 	return &WalletListAllUTXOsResult{
-		Symbol: p.Symbol,
-		UTXOs:  result,
-		Count:  len(result),
-		Total:  total,
+		Symbol:        p.Symbol,
+		UTXOs:         result,
+		Count:         len(result),
+		Total:         total,
+		FinalityDepth: depth,
+		ByFinality:    balanceByFinality(depth, outputs),
 	}, nil
 }
 
@@ -915,17 +1024,24 @@ type WalletSendEVMParams struct {
 	Amount  string `json:"amount"`            // Amount in wei (as string to handle big numbers)
 	Account uint32 `json:"account,omitempty"` // BIP44 account (default 0)
 	Index   uint32 `json:"index,omitempty"`   // Address index (default 0)
+
+	AmountDecimal string `json:"amount_decimal,omitempty"` // Alternative to amount, e.g. "1.5" (ETH)
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
 }
 
 // WalletSendEVMResult is the response for wallet_sendEVM.
 type WalletSendEVMResult struct {
-	TxHash   string `json:"tx_hash"`
-	Symbol   string `json:"symbol"`
-	To       string `json:"to"`
-	Amount   string `json:"amount"`
+	TxHash        string `json:"tx_hash"`
+	Symbol        string `json:"symbol"`
+	To            string `json:"to"`
+	Amount        string `json:"amount"`
+	AmountDecimal string `json:"amount_decimal"`
 	Nonce    uint64 `json:"nonce"`
 	GasLimit uint64 `json:"gas_limit"`
 	GasPrice string `json:"gas_price"`
+	Warnings []string `json:"warnings,omitempty"` // Address reuse
 }
 
 func (s *Server) walletSendEVM(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -947,57 +1063,80 @@ func (s *Server) walletSendEVM(ctx context.Context, params json.RawMessage) (int
 	if p.To == "" {
 		return nil, fmt.Errorf("to address is required")
 	}
-	if p.Amount == "" {
-		return nil, fmt.Errorf("amount is required")
-	}
 
 	// Check if it's an EVM chain
 	if !s.wallet.IsEVMChain(p.Symbol) {
 		return nil, fmt.Errorf("chain %s is not an EVM chain, use wallet_send instead", p.Symbol)
 	}
+	chainParams, err := s.evmChain(p.Symbol)
+	if err != nil {
+		return nil, err
+	}
 
 	// Parse amount (wei as string to handle big numbers)
-	amount, ok := new(big.Int).SetString(p.Amount, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid amount: %s", p.Amount)
+	amount, err := parseEVMAmount(p.Amount, p.AmountDecimal, chainParams.Decimals, true)
+	if err != nil {
+		return nil, err
+	}
+
+	to := reuseAddress(p.To)
+	warnings, err := s.checkAddressReuse(p.Symbol, to, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := s.wallet.SendEVMTransaction(ctx, p.Symbol, p.To, amount, p.Account, p.Index)
+	result, err := s.wallet.SendEVMTransaction(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.Symbol, p.To, amount, p.Account, p.Index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send EVM transaction: %w", err)
 	}
+	var sent uint64
+	if amount.IsUint64() {
+		sent = amount.Uint64()
+	}
+	s.recordSend(p.Symbol, result.TxHash, to, sent, 0, 1)
 
 	return &WalletSendEVMResult{
-		TxHash:   result.TxHash,
-		Symbol:   p.Symbol,
-		To:       p.To,
-		Amount:   p.Amount,
+		TxHash:        result.TxHash,
+		Symbol:        p.Symbol,
+		To:            p.To,
+		Amount:        amount.String(),
+		AmountDecimal: formatEVMAmount(amount, chainParams.Decimals, true),
 		Nonce:    result.Nonce,
 		GasLimit: result.GasLimit,
 		GasPrice: result.GasPrice.String(),
+		Warnings: warnings,
 	}, nil
 }
 
 // WalletSendERC20Params is the parameters for wallet_sendERC20.
 type WalletSendERC20Params struct {
 	Symbol   string `json:"symbol"`            // EVM chain symbol (ETH, BSC, MATIC, ARB)
-	Token    string `json:"token"`             // ERC-20 token contract address
+	Token    string `json:"token"`             // ERC-20 token contract address or registered symbol (USDC)
 	To       string `json:"to"`                // Recipient address
 	Amount   string `json:"amount"`            // Amount in token's smallest unit (as string)
 	Account  uint32 `json:"account,omitempty"` // BIP44 account (default 0)
 	Index    uint32 `json:"index,omitempty"`   // Address index (default 0)
+
+	AmountDecimal string `json:"amount_decimal,omitempty"` // Alternative to amount for registered tokens, e.g. "250.5"
+
+	ApprovalToken     string `json:"approval_token,omitempty"`      // Required above the spending policy threshold
+	AllowAddressReuse bool   `json:"allow_address_reuse,omitempty"` // Send to a used address in enforce mode
 }
 
 // WalletSendERC20Result is the response for wallet_sendERC20.
 type WalletSendERC20Result struct {
-	TxHash   string `json:"tx_hash"`
-	Symbol   string `json:"symbol"`
-	Token    string `json:"token"`
-	To       string `json:"to"`
-	Amount   string `json:"amount"`
+	TxHash        string `json:"tx_hash"`
+	Symbol        string `json:"symbol"`
+	Token         string `json:"token"`
+	TokenSymbol   string `json:"token_symbol,omitempty"`   // Empty for unregistered tokens
+	Decimals      uint8  `json:"decimals,omitempty"`       // Token decimals, if registered
+	To            string `json:"to"`
+	Amount        string `json:"amount"`
+	AmountDecimal string `json:"amount_decimal,omitempty"` // Empty for unregistered tokens
 	Nonce    uint64 `json:"nonce"`
 	GasLimit uint64 `json:"gas_limit"`
 	GasPrice string `json:"gas_price"`
+	Warnings []string `json:"warnings,omitempty"` // Address reuse
 }
 
 func (s *Server) walletSendERC20(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -1022,42 +1161,60 @@ func (s *Server) walletSendERC20(ctx context.Context, params json.RawMessage) (i
 	if p.To == "" {
 		return nil, fmt.Errorf("to address is required")
 	}
-	if p.Amount == "" {
-		return nil, fmt.Errorf("amount is required")
-	}
 
 	// Check if it's an EVM chain
 	if !s.wallet.IsEVMChain(p.Symbol) {
 		return nil, fmt.Errorf("chain %s is not an EVM chain", p.Symbol)
 	}
+	chainParams, err := s.evmChain(p.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	tokenAddr, token, err := resolveToken(chainParams, p.Token)
+	if err != nil {
+		return nil, err
+	}
+	tokenSymbol, decimals, known := tokenUnits(token)
 
 	// Parse amount
-	amount, ok := new(big.Int).SetString(p.Amount, 10)
-	if !ok {
-		return nil, fmt.Errorf("invalid amount: %s", p.Amount)
+	amount, err := parseEVMAmount(p.Amount, p.AmountDecimal, decimals, known)
+	if err != nil {
+		return nil, err
+	}
+
+	to := reuseAddress(p.To)
+	warnings, err := s.checkAddressReuse(p.Symbol, to, storage.AddressUseSend, p.AllowAddressReuse)
+	if err != nil {
+		return nil, err
 	}
 
-	result, err := s.wallet.SendERC20Transaction(ctx, p.Symbol, p.Token, p.To, amount, p.Account, p.Index)
+	result, err := s.wallet.SendERC20Transaction(wallet.WithApprovalToken(ctx, p.ApprovalToken), p.Symbol, tokenAddr, p.To, amount, p.Account, p.Index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to send ERC-20 transaction: %w", err)
 	}
+	// The history holds amounts in the chain's units; a token amount isn't one
+	s.recordSend(p.Symbol, result.TxHash, to, 0, 0, 1)
 
 	return &WalletSendERC20Result{
-		TxHash:   result.TxHash,
-		Symbol:   p.Symbol,
-		Token:    p.Token,
-		To:       p.To,
-		Amount:   p.Amount,
+		TxHash:        result.TxHash,
+		Symbol:        p.Symbol,
+		Token:         tokenAddr,
+		TokenSymbol:   tokenSymbol,
+		Decimals:      decimals,
+		To:            p.To,
+		Amount:        amount.String(),
+		AmountDecimal: formatEVMAmount(amount, decimals, known),
 		Nonce:    result.Nonce,
 		GasLimit: result.GasLimit,
 		GasPrice: result.GasPrice.String(),
+		Warnings: warnings,
 	}, nil
 }
 
 // WalletGetERC20BalanceParams is the parameters for wallet_getERC20Balance.
 type WalletGetERC20BalanceParams struct {
 	Symbol  string `json:"symbol"`            // EVM chain symbol
-	Token   string `json:"token"`             // ERC-20 token contract address
+	Token   string `json:"token"`             // ERC-20 token contract address or registered symbol (USDC)
 	Address string `json:"address,omitempty"` // Optional: specific address (otherwise uses wallet)
 	Account uint32 `json:"account,omitempty"` // BIP44 account (if no address specified)
 	Index   uint32 `json:"index,omitempty"`   // Address index (if no address specified)
@@ -1069,6 +1226,10 @@ type WalletGetERC20BalanceResult struct {
 	Token   string `json:"token"`
 	Address string `json:"address"`
 	Balance string `json:"balance"` // Balance in smallest unit (as string for big numbers)
+
+	TokenSymbol    string `json:"token_symbol,omitempty"`    // Empty for unregistered tokens
+	Decimals       uint8  `json:"decimals,omitempty"`        // Token decimals, if registered
+	BalanceDecimal string `json:"balance_decimal,omitempty"` // Empty for unregistered tokens
 }
 
 func (s *Server) walletGetERC20Balance(ctx context.Context, params json.RawMessage) (interface{}, error) {
@@ -1092,15 +1253,22 @@ func (s *Server) walletGetERC20Balance(ctx context.Context, params json.RawMessa
 	if !s.wallet.IsEVMChain(p.Symbol) {
 		return nil, fmt.Errorf("chain %s is not an EVM chain", p.Symbol)
 	}
+	chainParams, err := s.evmChain(p.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	tokenAddr, token, err := resolveToken(chainParams, p.Token)
+	if err != nil {
+		return nil, err
+	}
 
 	var balance *big.Int
 	var address string
-	var err error
 
 	if p.Address != "" {
 		// Query specific address
 		address = p.Address
-		balance, err = s.wallet.GetERC20BalanceForAddress(ctx, p.Symbol, p.Token, p.Address)
+		balance, err = s.wallet.GetERC20BalanceForAddress(ctx, p.Symbol, tokenAddr, p.Address)
 	} else {
 		// Query wallet address
 		if !s.wallet.IsUnlocked() {
@@ -1110,18 +1278,22 @@ func (s *Server) walletGetERC20Balance(ctx context.Context, params json.RawMessa
 		if err != nil {
 			return nil, fmt.Errorf("failed to get wallet address: %w", err)
 		}
-		balance, err = s.wallet.GetERC20Balance(ctx, p.Symbol, p.Token, p.Account, p.Index)
+		balance, err = s.wallet.GetERC20Balance(ctx, p.Symbol, tokenAddr, p.Account, p.Index)
 	}
 
 	if err != nil {
 		return nil, fmt.Errorf("failed to get ERC-20 balance: %w", err)
 	}
 
+	tokenSymbol, decimals, known := tokenUnits(token)
 	return &WalletGetERC20BalanceResult{
-		Symbol:  p.Symbol,
-		Token:   p.Token,
-		Address: address,
-		Balance: balance.String(),
+		Symbol:         p.Symbol,
+		Token:          tokenAddr,
+		Address:        address,
+		Balance:        balance.String(),
+		TokenSymbol:    tokenSymbol,
+		Decimals:       decimals,
+		BalanceDecimal: formatEVMAmount(balance, decimals, known),
 	}, nil
 }
 
@@ -1222,3 +1394,26 @@ func (s *Server) walletGetChainType(ctx context.Context, params json.RawMessage)
 		"is_bitcoin": chainType == chain.ChainTypeBitcoin,
 	}, nil
 }
+
+// WalletGetSpendingPolicyParams is the parameters for wallet_getSpendingPolicy.
+type WalletGetSpendingPolicyParams struct {
+	Symbol string `json:"symbol"`
+	Token  string `json:"token,omitempty"` // ERC-20 contract; empty for the native coin
+}
+
+// walletGetSpendingPolicy returns the spending rules and rolling 24h usage for a chain or token.
+func (s *Server) walletGetSpendingPolicy(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletGetSpendingPolicyParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+
+	return s.wallet.GetSpendingStatus(p.Symbol, p.Token)
+}
@@ -0,0 +1,134 @@
+// Package rpc - Hardware wallet handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// WalletSetSignerParams is the request for wallet_setSigner.
+type WalletSetSignerParams struct {
+	Symbol  string `json:"symbol"`            // Chain symbol (BTC, LTC, DOGE)
+	Type    string `json:"type"`              // software, ledger or trezor
+	Path    string `json:"path,omitempty"`    // Device from wallet_listDevices; default the first of the type
+	Account uint32 `json:"account,omitempty"` // Account on the device
+}
+
+// WalletVerifyAddressParams is the request for wallet_verifyAddress.
+type WalletVerifyAddressParams struct {
+	Symbol string `json:"symbol"`
+	Change uint32 `json:"change,omitempty"` // 0=receive, 1=change
+	Index  uint32 `json:"index,omitempty"`
+}
+
+// WalletSignerInfo is a chain's signer in wallet_getSigners.
+type WalletSignerInfo struct {
+	Symbol  string                  `json:"symbol"`
+	Type    string                  `json:"type"`
+	Account *wallet.HardwareAccount `json:"account,omitempty"` // Hardware signers only
+}
+
+// walletListDevices lists the connected hardware wallets.
+func (s *Server) walletListDevices(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	devices, err := s.wallet.ListHardwareWallets()
+	if err != nil {
+		return nil, err
+	}
+	if devices == nil {
+		devices = []wallet.DeviceInfo{}
+	}
+	return map[string]interface{}{"devices": devices, "count": len(devices)}, nil
+}
+
+// walletSetSigner selects the software or hardware signer of a chain.
+func (s *Server) walletSetSigner(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletSetSignerParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.Type == "" {
+		return nil, fmt.Errorf("type is required")
+	}
+
+	acct, err := s.wallet.SetSigner(ctx, p.Symbol, p.Type, p.Path, p.Account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set signer: %w", err)
+	}
+	return &WalletSignerInfo{Symbol: p.Symbol, Type: p.Type, Account: acct}, nil
+}
+
+// walletGetSigners lists the signer of every Bitcoin-family chain.
+func (s *Server) walletGetSigners(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	hardware := make(map[string]*wallet.HardwareAccount)
+	for _, acct := range s.wallet.Signers() {
+		hardware[acct.Symbol] = acct
+	}
+
+	symbols := chain.ListByType(chain.ChainTypeBitcoin)
+	sort.Strings(symbols)
+
+	var signers []*WalletSignerInfo
+	for _, symbol := range symbols {
+		if _, ok := chain.Get(symbol, s.wallet.Network()); !ok {
+			continue
+		}
+		info := &WalletSignerInfo{Symbol: symbol, Type: wallet.SignerSoftware}
+		if acct, ok := hardware[symbol]; ok {
+			info.Type = acct.Type
+			info.Account = acct
+		}
+		signers = append(signers, info)
+	}
+	return map[string]interface{}{"signers": signers, "count": len(signers)}, nil
+}
+
+// walletVerifyAddress shows an address on the hardware wallet signing its
+// chain and compares it with the wallet's.
+func (s *Server) walletVerifyAddress(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletVerifyAddressParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+
+	result, err := s.wallet.VerifyAddress(ctx, p.Symbol, p.Change, p.Index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to verify address: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,60 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestWalletHardwareHandlers(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.walletGetSigners(ctx, nil); err == nil {
+		t.Error("walletGetSigners() without wallet service should fail")
+	}
+
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: s.store})
+	if _, err := s.walletSetSigner(ctx, json.RawMessage(`{"symbol":"BTC","type":"software"}`)); err == nil {
+		t.Error("walletSetSigner() with a locked wallet should fail")
+	}
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := s.wallet.CreateWallet(mnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	res, err := s.walletGetSigners(ctx, nil)
+	if err != nil {
+		t.Fatalf("walletGetSigners() error = %v", err)
+	}
+	signers := res.(map[string]interface{})["signers"].([]*WalletSignerInfo)
+	if len(signers) == 0 {
+		t.Fatal("walletGetSigners() returned no chains")
+	}
+	for _, signer := range signers {
+		if signer.Type != wallet.SignerSoftware || signer.Account != nil {
+			t.Errorf("walletGetSigners() %s = %+v, want software", signer.Symbol, signer)
+		}
+	}
+
+	if _, err := s.walletSetSigner(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletSetSigner() without type should fail")
+	}
+	if _, err := s.walletSetSigner(ctx, json.RawMessage(`{"symbol":"ETH","type":"ledger"}`)); err == nil {
+		t.Error("walletSetSigner() of an EVM chain should fail")
+	}
+	res, err = s.walletSetSigner(ctx, json.RawMessage(`{"symbol":"BTC","type":"software"}`))
+	if err != nil || res.(*WalletSignerInfo).Account != nil {
+		t.Errorf("walletSetSigner(software) = %+v, %v", res, err)
+	}
+
+	if _, err := s.walletVerifyAddress(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("walletVerifyAddress() without symbol should fail")
+	}
+	if _, err := s.walletVerifyAddress(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletVerifyAddress() of a software chain should fail")
+	}
+}
@@ -0,0 +1,141 @@
+// Package rpc - Wallet import handlers for Electrum seeds, WIF keys and
+// Ethereum keystore files.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// WalletImportElectrumSeedParams is the request for wallet_importElectrumSeed.
+type WalletImportElectrumSeedParams struct {
+	Symbol     string `json:"symbol"`               // Bitcoin-family chain
+	Mnemonic   string `json:"mnemonic"`             // Electrum standard or segwit seed
+	Passphrase string `json:"passphrase,omitempty"` // Seed extension, if any
+	Label      string `json:"label,omitempty"`
+}
+
+// WalletSweepWIFParams is the request for wallet_sweepWIF.
+type WalletSweepWIFParams struct {
+	Symbol string `json:"symbol"`
+	WIF    string `json:"wif"`
+}
+
+// WalletSweepKeystoreParams is the request for wallet_sweepKeystore.
+type WalletSweepKeystoreParams struct {
+	Symbol     string          `json:"symbol"`   // EVM chain
+	Keystore   json.RawMessage `json:"keystore"` // Keystore file, as an object or a string
+	Passphrase string          `json:"passphrase"`
+}
+
+// walletImportElectrumSeed imports an Electrum wallet as a signing descriptor.
+func (s *Server) walletImportElectrumSeed(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletImportElectrumSeedParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.Mnemonic == "" {
+		return nil, fmt.Errorf("mnemonic is required")
+	}
+
+	info, err := s.wallet.ImportElectrumSeed(p.Symbol, p.Mnemonic, p.Passphrase, p.Label)
+	if err != nil {
+		return nil, fmt.Errorf("failed to import Electrum seed: %w", err)
+	}
+	return info, nil
+}
+
+// walletSweepWIF moves the coins of a WIF private key into the wallet.
+func (s *Server) walletSweepWIF(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletSweepWIFParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.WIF == "" {
+		return nil, fmt.Errorf("wif is required")
+	}
+
+	result, err := s.wallet.SweepWIF(ctx, p.Symbol, p.WIF)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sweep key: %w", err)
+	}
+	return result, nil
+}
+
+// walletSweepDescriptor moves the coins of an imported descriptor into the wallet.
+func (s *Server) walletSweepDescriptor(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletDescriptorIDParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.ID == "" {
+		return nil, fmt.Errorf("id is required")
+	}
+
+	result, err := s.wallet.SweepDescriptor(ctx, p.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sweep descriptor: %w", err)
+	}
+	return result, nil
+}
+
+// walletSweepKeystore moves the registered token and native balances of an
+// Ethereum keystore account into the wallet.
+func (s *Server) walletSweepKeystore(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	var p WalletSweepKeystoreParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	keystore := []byte(p.Keystore)
+	var encoded string
+	if err := json.Unmarshal(p.Keystore, &encoded); err == nil {
+		keystore = []byte(encoded)
+	}
+	if len(keystore) == 0 {
+		return nil, fmt.Errorf("keystore is required")
+	}
+
+	result, err := s.wallet.SweepEVMKeystore(ctx, p.Symbol, keystore, p.Passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sweep keystore: %w", err)
+	}
+	return result, nil
+}
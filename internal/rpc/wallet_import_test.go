@@ -0,0 +1,62 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestWalletImportHandlers(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.walletImportElectrumSeed(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("walletImportElectrumSeed() without wallet service should fail")
+	}
+
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: s.store})
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := s.wallet.CreateWallet(mnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	if _, err := s.walletImportElectrumSeed(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletImportElectrumSeed() without mnemonic should fail")
+	}
+	params, _ := json.Marshal(WalletImportElectrumSeedParams{
+		Symbol:   "BTC",
+		Mnemonic: "bitter grass shiver impose acquire brush forget axis eager alone wine silver",
+		Label:    "electrum",
+	})
+	res, err := s.walletImportElectrumSeed(ctx, params)
+	if err != nil {
+		t.Fatalf("walletImportElectrumSeed() error = %v", err)
+	}
+	info := res.(*wallet.DescriptorInfo)
+	if info.WatchOnly || info.Label != "electrum" || !strings.HasPrefix(info.Descriptor, "wpkh(") {
+		t.Errorf("walletImportElectrumSeed() = %+v", info)
+	}
+
+	if _, err := s.walletSweepWIF(ctx, json.RawMessage(`{"symbol":"BTC"}`)); err == nil {
+		t.Error("walletSweepWIF() without wif should fail")
+	}
+	if _, err := s.walletSweepDescriptor(ctx, json.RawMessage(`{}`)); err == nil {
+		t.Error("walletSweepDescriptor() without id should fail")
+	}
+	if _, err := s.walletSweepKeystore(ctx, json.RawMessage(`{"symbol":"ETH"}`)); err == nil || !strings.Contains(err.Error(), "keystore is required") {
+		t.Errorf("walletSweepKeystore() without keystore error = %v", err)
+	}
+	// The keystore may be sent as a string; without backends the sweep fails after decoding
+	if _, err := s.walletSweepKeystore(ctx, json.RawMessage(`{"symbol":"ETH","keystore":"{}"}`)); err == nil || strings.Contains(err.Error(), "required") {
+		t.Errorf("walletSweepKeystore() error = %v", err)
+	}
+
+	s.wallet.Lock()
+	if _, err := s.walletSweepWIF(ctx, json.RawMessage(`{"symbol":"BTC","wif":"x"}`)); err == nil {
+		t.Error("walletSweepWIF() with a locked wallet should fail")
+	}
+}
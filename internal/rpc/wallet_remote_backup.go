@@ -0,0 +1,162 @@
+// Package rpc - Remote seed backup handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/backup"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// RemoteTargetParams is a seed backup target.
+type RemoteTargetParams struct {
+	Type   string               `json:"type"` // s3 or webdav
+	S3     *S3TargetParams      `json:"s3,omitempty"`
+	WebDAV *backup.WebDAVConfig `json:"webdav,omitempty"`
+}
+
+// S3TargetParams is an S3-compatible bucket.
+type S3TargetParams struct {
+	Endpoint  string `json:"endpoint"`
+	Bucket    string `json:"bucket"`
+	Region    string `json:"region,omitempty"`
+	Prefix    string `json:"prefix,omitempty"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+func (p *RemoteTargetParams) target() (backup.RemoteTarget, error) {
+	t := backup.RemoteTarget{Type: p.Type}
+	switch p.Type {
+	case backup.RemoteTargetS3:
+		if p.S3 == nil {
+			return t, fmt.Errorf("target.s3 is required")
+		}
+		t.S3 = node.S3Config{
+			Endpoint: p.S3.Endpoint, Bucket: p.S3.Bucket, Region: p.S3.Region, Prefix: p.S3.Prefix,
+			AccessKey: p.S3.AccessKey, SecretKey: p.S3.SecretKey,
+		}
+	case backup.RemoteTargetWebDAV:
+		if p.WebDAV == nil {
+			return t, fmt.Errorf("target.webdav is required")
+		}
+		t.WebDAV = *p.WebDAV
+	default:
+		return t, fmt.Errorf("target.type must be s3 or webdav")
+	}
+	return t, nil
+}
+
+// WalletBackupRemoteParams is the parameters for wallet_backupRemote.
+type WalletBackupRemoteParams struct {
+	Password           string             `json:"password"`            // Wallet encryption password (required)
+	RecoveryPassphrase string             `json:"recovery_passphrase"` // Encrypts the backup, not the password (required)
+	Target             RemoteTargetParams `json:"target"`
+}
+
+// WalletBackupRemoteResult is the response for wallet_backupRemote.
+type WalletBackupRemoteResult struct {
+	Verified          bool   `json:"verified"` // Read back and decrypted with the recovery passphrase
+	CreatedAt         int64  `json:"created_at"`
+	BackupSHA256      string `json:"backup_sha256"`
+	WalletFingerprint string `json:"wallet_fingerprint"`
+	Message           string `json:"message"`
+}
+
+func (s *Server) walletBackupRemote(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletBackupRemoteParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	target, err := p.Target.target()
+	if err != nil {
+		return nil, err
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+
+	data, v, err := s.wallet.ExportSeedBackup(p.Password, p.RecoveryPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to export seed: %w", err)
+	}
+	if err := backup.UploadSeed(ctx, target, data, v); err != nil {
+		return nil, fmt.Errorf("failed to upload seed backup: %w", err)
+	}
+
+	// Prove the recovery passphrase opens what was uploaded
+	mnemonic, err := wallet.OpenSeedBackup(data, v, p.RecoveryPassphrase)
+	if err != nil {
+		return nil, fmt.Errorf("uploaded seed backup does not open: %w", err)
+	}
+	wallet.SecureClear([]byte(mnemonic))
+
+	return &WalletBackupRemoteResult{
+		Verified:          true,
+		CreatedAt:         v.CreatedAt,
+		BackupSHA256:      v.BackupSHA256,
+		WalletFingerprint: v.WalletFingerprint,
+		Message: "Seed backup uploaded and verified. Write the recovery passphrase down and keep it " +
+			"away from the storage account: the backup can't be restored without it.",
+	}, nil
+}
+
+// WalletRestoreRemoteParams is the parameters for wallet_restoreRemote.
+type WalletRestoreRemoteParams struct {
+	Target             RemoteTargetParams `json:"target"`
+	RecoveryPassphrase string             `json:"recovery_passphrase"`
+	Passphrase         string             `json:"passphrase,omitempty"` // BIP39 passphrase (optional)
+	Password           string             `json:"password"`             // New encryption password (required)
+}
+
+func (s *Server) walletRestoreRemote(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletRestoreRemoteParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.RecoveryPassphrase == "" {
+		return nil, fmt.Errorf("recovery_passphrase is required")
+	}
+	if p.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	target, err := p.Target.target()
+	if err != nil {
+		return nil, err
+	}
+
+	data, v, err := backup.DownloadSeed(ctx, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download seed backup: %w", err)
+	}
+	if err := s.wallet.RestoreSeedBackup(data, v, p.RecoveryPassphrase, p.Passphrase, p.Password); err != nil {
+		return nil, fmt.Errorf("failed to restore wallet: %w", err)
+	}
+
+	// Set wallet on coordinator for swap operations (refunds, etc.)
+	if s.coordinator != nil {
+		if w := s.wallet.GetWallet(); w != nil {
+			s.coordinator.SetWallet(w)
+		}
+	}
+
+	return map[string]interface{}{
+		"success":    true,
+		"message":    "Wallet restored from remote backup",
+		"created_at": v.CreatedAt,
+	}, nil
+}
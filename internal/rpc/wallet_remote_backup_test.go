@@ -0,0 +1,82 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backup"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestWalletRemoteBackupHandlers(t *testing.T) {
+	const password = "TestPassword123!"
+	var mu sync.Mutex
+	objects := make(map[string][]byte)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch r.Method {
+		case http.MethodPut:
+			objects[r.URL.Path], _ = io.ReadAll(r.Body)
+		case http.MethodGet:
+			obj, ok := objects[r.URL.Path]
+			if !ok {
+				http.NotFound(w, r)
+				return
+			}
+			w.Write(obj)
+		}
+	}))
+	defer srv.Close()
+	target := RemoteTargetParams{Type: "webdav", WebDAV: &backup.WebDAVConfig{URL: srv.URL + "/klingdex"}}
+
+	s := &Server{wallet: wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})}
+	ctx := context.Background()
+
+	params, _ := json.Marshal(WalletBackupRemoteParams{Password: password, RecoveryPassphrase: "Recovery-Passphrase-42", Target: target})
+	if _, err := s.walletBackupRemote(ctx, params); err == nil {
+		t.Error("backup of a locked wallet should fail")
+	}
+	if err := s.wallet.CreateWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", password); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	want, _ := s.wallet.GetAddress("BTC", 0, 0)
+
+	for _, bad := range []string{
+		`{"recovery_passphrase":"Recovery-Passphrase-42","target":{"type":"webdav","webdav":{"url":"http://x"}}}`,
+		`{"password":"TestPassword123!","recovery_passphrase":"Recovery-Passphrase-42","target":{"type":"ftp"}}`,
+		`{"password":"TestPassword123!","recovery_passphrase":"Recovery-Passphrase-42","target":{"type":"s3"}}`,
+		`{"password":"TestPassword123!","recovery_passphrase":"TestPassword123!","target":{"type":"webdav","webdav":{"url":"http://x"}}}`,
+	} {
+		if _, err := s.walletBackupRemote(ctx, json.RawMessage(bad)); err == nil {
+			t.Errorf("walletBackupRemote(%s) should fail", bad)
+		}
+	}
+
+	result, err := s.walletBackupRemote(ctx, params)
+	if err != nil {
+		t.Fatalf("walletBackupRemote() error = %v", err)
+	}
+	if res := result.(*WalletBackupRemoteResult); !res.Verified || res.WalletFingerprint == "" {
+		t.Errorf("result = %+v", res)
+	}
+
+	restored := &Server{wallet: wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})}
+	params, _ = json.Marshal(WalletRestoreRemoteParams{Target: target, RecoveryPassphrase: "Wrong-Passphrase-42", Password: "NewPassword456!"})
+	if _, err := restored.walletRestoreRemote(ctx, params); err == nil {
+		t.Error("wrong recovery passphrase should fail")
+	}
+	params, _ = json.Marshal(WalletRestoreRemoteParams{Target: target, RecoveryPassphrase: "Recovery-Passphrase-42", Password: "NewPassword456!"})
+	if _, err := restored.walletRestoreRemote(ctx, params); err != nil {
+		t.Fatalf("walletRestoreRemote() error = %v", err)
+	}
+	if addr, _ := restored.wallet.GetAddress("BTC", 0, 0); addr != want {
+		t.Errorf("restored address = %s, want %s", addr, want)
+	}
+}
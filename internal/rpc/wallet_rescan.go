@@ -0,0 +1,152 @@
+// Package rpc - Wallet rescan from a block height.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Rescan WebSocket events.
+const (
+	EventWalletRescanProgress  EventType = "wallet_rescan_progress"
+	EventWalletRescanCompleted EventType = "wallet_rescan_completed"
+	EventWalletRescanFailed    EventType = "wallet_rescan_failed"
+)
+
+// rescanProgressEvery is how many scanned addresses pass between progress
+// events; phase changes are always sent.
+const rescanProgressEvery = 10
+
+// rescanTimeout bounds a rescan in the background.
+const rescanTimeout = time.Hour
+
+// rescans tracks the chains being rescanned, one rescan per chain.
+var rescans = struct {
+	sync.Mutex
+	active map[string]bool
+}{active: make(map[string]bool)}
+
+// WalletRescanParams is the parameters for wallet_rescan.
+type WalletRescanParams struct {
+	Symbol      string `json:"symbol"`
+	StartHeight *int64 `json:"start_height,omitempty"`
+	Birthday    int64  `json:"birthday,omitempty"` // Unix seconds; used when start_height is not set
+	Wait        bool   `json:"wait,omitempty"`     // Return the result instead of running in the background
+}
+
+// WalletRescanStarted is the response for wallet_rescan in the background.
+type WalletRescanStarted struct {
+	Symbol      string `json:"symbol"`
+	StartHeight int64  `json:"start_height"`
+	Started     bool   `json:"started"`
+}
+
+// walletRescan rescans a chain's wallet addresses from a block height or
+// wallet birthday and reconciles the UTXO cache. Progress is sent as
+// wallet_rescan_progress events; unless wait is set it runs in the
+// background and finishes with wallet_rescan_completed or
+// wallet_rescan_failed.
+func (s *Server) walletRescan(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+	if !s.wallet.IsUnlocked() {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("storage not initialized")
+	}
+
+	var p WalletRescanParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Symbol == "" {
+		return nil, fmt.Errorf("symbol is required")
+	}
+	if p.StartHeight == nil && p.Birthday <= 0 {
+		return nil, fmt.Errorf("start_height or birthday is required")
+	}
+
+	var startHeight int64
+	if p.StartHeight != nil {
+		if *p.StartHeight < 0 {
+			return nil, fmt.Errorf("start_height must not be negative")
+		}
+		startHeight = *p.StartHeight
+	} else {
+		height, err := s.wallet.HeightAtTime(ctx, p.Symbol, time.Unix(p.Birthday, 0), s.store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find the birthday height: %w", err)
+		}
+		startHeight = height
+	}
+
+	rescans.Lock()
+	if rescans.active[p.Symbol] {
+		rescans.Unlock()
+		return nil, fmt.Errorf("a rescan of %s is already in progress", p.Symbol)
+	}
+	rescans.active[p.Symbol] = true
+	rescans.Unlock()
+
+	run := func(ctx context.Context) (*wallet.RescanResult, error) {
+		defer func() {
+			rescans.Lock()
+			delete(rescans.active, p.Symbol)
+			rescans.Unlock()
+		}()
+		return s.wallet.Rescan(ctx, p.Symbol, startHeight, s.store, s.rescanProgress())
+	}
+
+	if p.Wait {
+		result, err := run(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("rescan failed: %w", err)
+		}
+		return result, nil
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), rescanTimeout)
+		defer cancel()
+		result, err := run(ctx)
+		if err != nil {
+			s.log.Warn("Wallet rescan failed", "symbol", p.Symbol, "error", err)
+			if s.wsHub != nil {
+				s.wsHub.Broadcast(EventWalletRescanFailed, map[string]interface{}{
+					"symbol":       p.Symbol,
+					"start_height": startHeight,
+					"error":        err.Error(),
+				})
+			}
+			return
+		}
+		if s.wsHub != nil {
+			s.wsHub.Broadcast(EventWalletRescanCompleted, result)
+		}
+	}()
+
+	return &WalletRescanStarted{Symbol: p.Symbol, StartHeight: startHeight, Started: true}, nil
+}
+
+// rescanProgress returns a progress callback sending wallet_rescan_progress
+// events on phase changes and every rescanProgressEvery addresses.
+func (s *Server) rescanProgress() func(wallet.RescanProgress) {
+	lastPhase := ""
+	return func(p wallet.RescanProgress) {
+		if s.wsHub == nil {
+			return
+		}
+		if p.Phase == lastPhase && p.AddressesScanned%rescanProgressEvery != 0 {
+			return
+		}
+		lastPhase = p.Phase
+		s.wsHub.Broadcast(EventWalletRescanProgress, p)
+	}
+}
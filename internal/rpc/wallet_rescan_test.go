@@ -0,0 +1,54 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestWalletRescanParams(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.walletRescan(ctx, json.RawMessage(`{"symbol":"BTC","start_height":1}`)); err == nil {
+		t.Error("walletRescan() without wallet service should fail")
+	}
+
+	s.wallet = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: s.store})
+	mnemonic := "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+	if err := s.wallet.CreateWallet(mnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+
+	for _, params := range []string{
+		`{"start_height":1}`,
+		`{"symbol":"BTC"}`,
+		`{"symbol":"BTC","start_height":-1}`,
+	} {
+		if _, err := s.walletRescan(ctx, json.RawMessage(params)); err == nil {
+			t.Errorf("walletRescan(%s) should fail", params)
+		}
+	}
+
+	// No backend: the rescan itself fails
+	if _, err := s.walletRescan(ctx, json.RawMessage(`{"symbol":"BTC","start_height":0,"wait":true}`)); err == nil {
+		t.Error("walletRescan() without backend should fail")
+	}
+
+	rescans.Lock()
+	rescans.active["BTC"] = true
+	rescans.Unlock()
+	defer func() {
+		rescans.Lock()
+		delete(rescans.active, "BTC")
+		rescans.Unlock()
+	}()
+	_, err := s.walletRescan(ctx, json.RawMessage(`{"symbol":"BTC","start_height":0}`))
+	if err == nil || !strings.Contains(err.Error(), "already in progress") {
+		t.Errorf("walletRescan() during a rescan error = %v, want already in progress", err)
+	}
+}
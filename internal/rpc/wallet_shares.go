@@ -0,0 +1,102 @@
+// Package rpc - SLIP-39 share backup handlers.
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// WalletGenerateSharesParams is the parameters for wallet_generateShares.
+type WalletGenerateSharesParams struct {
+	Password        string              `json:"password"`                   // Wallet encryption password (required)
+	SharePassphrase string              `json:"share_passphrase,omitempty"` // Encrypts the shares (optional)
+	GroupThreshold  int                 `json:"group_threshold"`
+	Groups          []wallet.ShareGroup `json:"groups"`
+}
+
+// WalletGenerateSharesResult is the response for wallet_generateShares.
+type WalletGenerateSharesResult struct {
+	GroupThreshold int        `json:"group_threshold"`
+	Groups         [][]string `json:"groups"`
+	Language       string     `json:"language"` // Wordlist to restore the mnemonic in
+}
+
+func (s *Server) walletGenerateShares(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletGenerateSharesParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	if len(p.Groups) == 0 {
+		return nil, fmt.Errorf("groups are required")
+	}
+	if p.GroupThreshold == 0 {
+		p.GroupThreshold = 1
+	}
+
+	groups, lang, err := s.wallet.GenerateShares(p.Password, p.SharePassphrase, p.GroupThreshold, p.Groups)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate shares: %w", err)
+	}
+
+	return &WalletGenerateSharesResult{
+		GroupThreshold: p.GroupThreshold,
+		Groups:         groups,
+		Language:       string(lang),
+	}, nil
+}
+
+// WalletRecoverFromSharesParams is the parameters for wallet_recoverFromShares.
+type WalletRecoverFromSharesParams struct {
+	Shares          []string `json:"shares"`
+	SharePassphrase string   `json:"share_passphrase,omitempty"`
+	Language        string   `json:"language,omitempty"`   // BIP39 wordlist of the mnemonic (default english)
+	Passphrase      string   `json:"passphrase,omitempty"` // BIP39 passphrase (optional)
+	Password        string   `json:"password"`             // Encryption password (required)
+}
+
+func (s *Server) walletRecoverFromShares(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet service not initialized")
+	}
+
+	var p WalletRecoverFromSharesParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if len(p.Shares) == 0 {
+		return nil, fmt.Errorf("shares are required")
+	}
+	if p.Password == "" {
+		return nil, fmt.Errorf("password is required")
+	}
+	lang, err := wallet.ParseLanguage(p.Language)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.wallet.RecoverFromShares(p.Shares, p.SharePassphrase, lang, p.Passphrase, p.Password); err != nil {
+		return nil, fmt.Errorf("failed to recover wallet: %w", err)
+	}
+
+	// Set wallet on coordinator for swap operations (refunds, etc.)
+	if s.coordinator != nil {
+		if w := s.wallet.GetWallet(); w != nil {
+			s.coordinator.SetWallet(w)
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"message": "Wallet recovered successfully",
+	}, nil
+}
@@ -0,0 +1,77 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestWalletGenerateLanguage(t *testing.T) {
+	s := &Server{wallet: wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir()})}
+
+	result, err := s.walletGenerate(context.Background(), json.RawMessage(`{"language":"japanese"}`))
+	if err != nil {
+		t.Fatalf("walletGenerate() error = %v", err)
+	}
+	gen := result.(*WalletGenerateResult)
+	if gen.Language != "japanese" {
+		t.Errorf("language = %s, want japanese", gen.Language)
+	}
+
+	params, _ := json.Marshal(WalletValidateMnemonicParams{Mnemonic: gen.Mnemonic})
+	valid, err := s.walletValidateMnemonic(context.Background(), params)
+	if err != nil {
+		t.Fatalf("walletValidateMnemonic() error = %v", err)
+	}
+	if m := valid.(map[string]interface{}); m["valid"] != true || m["language"] != "japanese" {
+		t.Errorf("walletValidateMnemonic() = %v", m)
+	}
+
+	if _, err := s.walletGenerate(context.Background(), json.RawMessage(`{"language":"klingon"}`)); err == nil {
+		t.Error("unknown language should fail")
+	}
+}
+
+func TestWalletSharesHandlers(t *testing.T) {
+	password := "TestPassword123!"
+	s := &Server{wallet: wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})}
+	if err := s.wallet.CreateWallet("abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about", "", password); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	want, _ := s.wallet.GetAddress("BTC", 0, 0)
+
+	if _, err := s.walletGenerateShares(context.Background(), json.RawMessage(`{"groups":[{"threshold":2,"count":3}]}`)); err == nil {
+		t.Error("missing password should fail")
+	}
+	params, _ := json.Marshal(WalletGenerateSharesParams{
+		Password: password,
+		Groups:   []wallet.ShareGroup{{Threshold: 2, Count: 3}},
+	})
+	result, err := s.walletGenerateShares(context.Background(), params)
+	if err != nil {
+		t.Fatalf("walletGenerateShares() error = %v", err)
+	}
+	shares := result.(*WalletGenerateSharesResult)
+	if shares.GroupThreshold != 1 || len(shares.Groups) != 1 || len(shares.Groups[0]) != 3 || shares.Language != "english" {
+		t.Fatalf("walletGenerateShares() = %+v", shares)
+	}
+
+	restored := &Server{wallet: wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})}
+	params, _ = json.Marshal(WalletRecoverFromSharesParams{
+		Shares:   []string{shares.Groups[0][2], shares.Groups[0][0]},
+		Password: password,
+	})
+	if _, err := restored.walletRecoverFromShares(context.Background(), params); err != nil {
+		t.Fatalf("walletRecoverFromShares() error = %v", err)
+	}
+	if got, _ := restored.wallet.GetAddress("BTC", 0, 0); got != want {
+		t.Errorf("restored address = %s, want %s", got, want)
+	}
+
+	if _, err := restored.walletRecoverFromShares(context.Background(), json.RawMessage(`{"password":"x"}`)); err == nil {
+		t.Error("missing shares should fail")
+	}
+}
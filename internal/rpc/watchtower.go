@@ -0,0 +1,181 @@
+// Package rpc - Watchtower RPC handlers.
+//
+// Client side (the node delegating its swaps):
+//   - watchtower_register: pre-sign an HTLC refund/claim and hand it to a tower
+//   - watchtower_cancel:   release a bundle once the swap has settled
+//
+// Tower side (node with watchtower.enabled):
+//   - watchtower_obligations: list bundles held for clients
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/peer"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/watchtower"
+)
+
+// watchtowerMessageTTL is how long a bundle message is retried while the tower is offline.
+const watchtowerMessageTTL = 24 * time.Hour
+
+// SetWatchtower enables the tower side of the watchtower protocol.
+// It must be called before SetupSwapHandlers.
+func (s *Server) SetWatchtower(t *watchtower.Tower) {
+	s.watchtower = t
+}
+
+// WatchtowerRegisterParams is the parameters for watchtower_register.
+type WatchtowerRegisterParams struct {
+	TowerPeer string `json:"tower_peer"`
+	TradeID   string `json:"trade_id"`
+	Chain     string `json:"chain"`
+	Kind      string `json:"kind"` // refund, claim
+}
+
+// WatchtowerRegisterResult is the result of watchtower_register.
+type WatchtowerRegisterResult struct {
+	BundleID  string `json:"bundle_id"`
+	TowerPeer string `json:"tower_peer"`
+	Kind      string `json:"kind"`
+	Chain     string `json:"chain"`
+}
+
+// watchtowerRegister pre-signs an HTLC spend and sends it to a watchtower.
+func (s *Server) watchtowerRegister(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p WatchtowerRegisterParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" || p.Chain == "" {
+		return nil, fmt.Errorf("trade_id and chain are required")
+	}
+	towerID, err := s.watchtowerPeer(p.TowerPeer)
+	if err != nil {
+		return nil, err
+	}
+	if s.coordinator == nil {
+		return nil, fmt.Errorf("swap coordinator not initialized")
+	}
+
+	var spend *swap.PresignedSpend
+	switch p.Kind {
+	case swap.PresignedRefund:
+		spend, err = s.coordinator.PresignHTLCRefund(ctx, p.TradeID, p.Chain)
+	case swap.PresignedClaim:
+		spend, err = s.coordinator.PresignHTLCClaim(ctx, p.TradeID, p.Chain)
+	default:
+		return nil, fmt.Errorf("kind must be refund or claim")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to pre-sign %s: %w", p.Kind, err)
+	}
+
+	payload := watchtower.NewBundlePayload(spend)
+	msg, err := node.NewSwapMessage(node.SwapMsgWatchtowerRegister, p.TradeID, payload)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.node.SendDirect(ctx, towerID, p.TradeID, time.Now().Add(watchtowerMessageTTL).Unix(), msg); err != nil {
+		return nil, fmt.Errorf("failed to send bundle: %w", err)
+	}
+
+	return &WatchtowerRegisterResult{
+		BundleID:  payload.BundleID,
+		TowerPeer: towerID.String(),
+		Kind:      p.Kind,
+		Chain:     p.Chain,
+	}, nil
+}
+
+// watchtowerCancel releases a bundle held by a watchtower.
+func (s *Server) watchtowerCancel(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	var p WatchtowerRegisterParams
+	if err := json.Unmarshal(params, &p); err != nil {
+		return nil, fmt.Errorf("invalid params: %w", err)
+	}
+	if p.TradeID == "" || p.Chain == "" || p.Kind == "" {
+		return nil, fmt.Errorf("trade_id, chain and kind are required")
+	}
+	towerID, err := s.watchtowerPeer(p.TowerPeer)
+	if err != nil {
+		return nil, err
+	}
+
+	bundleID := watchtower.BundleID(p.Kind, p.TradeID, p.Chain)
+	msg, err := node.NewSwapMessage(node.SwapMsgWatchtowerCancel, p.TradeID, &watchtower.CancelPayload{BundleID: bundleID})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.node.SendDirect(ctx, towerID, p.TradeID, time.Now().Add(watchtowerMessageTTL).Unix(), msg); err != nil {
+		return nil, fmt.Errorf("failed to send cancel: %w", err)
+	}
+
+	return map[string]interface{}{
+		"bundle_id": bundleID,
+		"cancelled": true,
+	}, nil
+}
+
+// watchtowerPeer parses the tower peer ID and checks direct messaging is available.
+func (s *Server) watchtowerPeer(towerPeer string) (peer.ID, error) {
+	if towerPeer == "" {
+		return "", fmt.Errorf("tower_peer is required")
+	}
+	towerID, err := peer.Decode(towerPeer)
+	if err != nil {
+		return "", fmt.Errorf("invalid tower_peer: %w", err)
+	}
+	if s.node == nil || s.node.MessageSender() == nil {
+		return "", fmt.Errorf("direct messaging not available")
+	}
+	return towerID, nil
+}
+
+// WatchtowerObligationsParams is the parameters for watchtower_obligations.
+type WatchtowerObligationsParams struct {
+	ClientPeer string `json:"client_peer,omitempty"`
+	Status     string `json:"status,omitempty"` // active, broadcast, cancelled
+}
+
+// WatchtowerObligationsResult is the result of watchtower_obligations.
+type WatchtowerObligationsResult struct {
+	Bundles []*storage.WatchtowerBundle `json:"bundles"`
+	Active  int                         `json:"active"`
+}
+
+// watchtowerObligations lists the bundles this node holds as a watchtower.
+func (s *Server) watchtowerObligations(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.watchtower == nil {
+		return nil, fmt.Errorf("watchtower is not enabled")
+	}
+
+	var p WatchtowerObligationsParams
+	if len(params) > 0 {
+		if err := json.Unmarshal(params, &p); err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+	}
+
+	bundles, err := s.watchtower.Obligations(p.ClientPeer, storage.BundleStatus(p.Status))
+	if err != nil {
+		return nil, err
+	}
+	if bundles == nil {
+		bundles = []*storage.WatchtowerBundle{}
+	}
+
+	result := &WatchtowerObligationsResult{Bundles: bundles}
+	for _, b := range bundles {
+		if b.Status == storage.BundleStatusActive {
+			result.Active++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,58 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/watchtower"
+)
+
+func TestWatchtowerRegisterValidation(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	tests := []struct {
+		name   string
+		params string
+	}{
+		{"missing trade", `{"tower_peer":"12D3KooW","chain":"BTC","kind":"refund"}`},
+		{"missing tower", `{"trade_id":"t1","chain":"BTC","kind":"refund"}`},
+		{"invalid tower", `{"tower_peer":"not-a-peer","trade_id":"t1","chain":"BTC","kind":"refund"}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.watchtowerRegister(ctx, json.RawMessage(tt.params)); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestWatchtowerObligations(t *testing.T) {
+	s := newTestStoreServer(t)
+	ctx := context.Background()
+
+	if _, err := s.watchtowerObligations(ctx, nil); err == nil {
+		t.Error("expected error when watchtower is disabled")
+	}
+
+	s.SetWatchtower(watchtower.New(node.WatchtowerConfig{Enabled: true}, s.store, nil))
+	if err := s.store.SaveWatchtowerBundle(&storage.WatchtowerBundle{
+		ID: "refund:t1:BTC", ClientPeer: "12D3KooWMobile", TradeID: "t1", Chain: "BTC",
+		Kind: "refund", TxHex: "aa", FundingTxID: "f1", TimeoutBlocks: 144,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	result, err := s.watchtowerObligations(ctx, json.RawMessage(`{"client_peer":"12D3KooWMobile"}`))
+	if err != nil {
+		t.Fatalf("watchtowerObligations() error = %v", err)
+	}
+	res := result.(*WatchtowerObligationsResult)
+	if len(res.Bundles) != 1 || res.Active != 1 {
+		t.Errorf("watchtowerObligations() = %+v", res)
+	}
+}
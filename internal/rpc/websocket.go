@@ -1,24 +1,21 @@
 package rpc
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
 )
 
-// WebSocket configuration
-var upgrader = websocket.Upgrader{
-	ReadBufferSize:  1024,
-	WriteBufferSize: 1024,
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all origins
-	},
-}
-
 // EventType represents the type of WebSocket event.
 type EventType string
 
@@ -28,7 +25,22 @@ const (
 	EventPeerDisconnected EventType = "peer_disconnected"
 
 	// System events
-	EventNodeStatus EventType = "node_status"
+	EventNodeStatus    EventType = "node_status"
+	EventNodeDegraded  EventType = "node_degraded"  // Node isolated, new trades paused
+	EventNodeRecovered EventType = "node_recovered" // Connectivity restored
+	EventClockSkewed   EventType = "clock_skewed"   // Local clock off by more than the allowed skew
+	EventClockSynced   EventType = "clock_synced"   // Local clock back within the allowed skew
+
+	// Swap message delivery
+	EventSwapPathDegraded EventType = "swap_path_degraded" // Direct messages routed via a relay or PubSub
+	EventSwapPathRestored EventType = "swap_path_restored" // Direct messages back on a direct connection
+
+	// Trades refused before they started (e.g. a peer's protocol too old)
+	EventTradeRefused EventType = "trade_refused"
+
+	// Order book of a pair, sent only to clients subscribed to the pair
+	EventOrderBookSnapshot EventType = "orderbook_snapshot"
+	EventOrderBookDiff     EventType = "orderbook_diff"
 )
 
 // WSEvent is a WebSocket event message.
@@ -36,21 +48,54 @@ type WSEvent struct {
 	Type      EventType   `json:"type"`
 	Data      interface{} `json:"data"`
 	Timestamp int64       `json:"timestamp"`
+
+	topics []string // Topics the event was broadcast to
 }
 
 // WSSubscription represents a subscription request.
 type WSSubscription struct {
-	Action string   `json:"action"` // "subscribe" or "unsubscribe"
+	Action string   `json:"action"` // "subscribe", "unsubscribe", "units", "orderbook_subscribe", "orderbook_unsubscribe", "logs_subscribe" or "logs_unsubscribe"
 	Events []string `json:"events"` // Event types to subscribe to
+
+	// Pair is the order book pair of the orderbook actions, e.g. "BTC/LTC".
+	Pair string `json:"pair,omitempty"`
+
+	// Level and Components filter the records of logs_subscribe: the
+	// minimum level (default info) and the logger components (empty: all).
+	Level      string   `json:"level,omitempty"`
+	Components []string `json:"components,omitempty"`
+
+	// Units sets the units options of the connection with action "units";
+	// omitting it turns units metadata off.
+	Units *UnitsOptions `json:"units,omitempty"`
+
+	// Subscribe and Unsubscribe add and remove topics, without an action:
+	// "swap:<trade_id>", "peers" or "orders:<pair>".
+	Subscribe   []string `json:"subscribe,omitempty"`
+	Unsubscribe []string `json:"unsubscribe,omitempty"`
 }
 
 // WSClient represents a connected WebSocket client.
 type WSClient struct {
 	conn          *websocket.Conn
-	send          chan []byte
+	send          chan []byte // Outbound queue, drained by writePump
 	subscriptions map[EventType]bool
+	books         map[string]bool // Order book pairs receiving diffs
+	topics        map[string]bool // Topics receiving events
+	topicFilter   bool            // Subscribed to topics: only their events are received
+	units         *UnitsOptions   // Units metadata for event data, nil for none
+	logs          *logFilter      // Log subscription, nil for none
+	admin         bool            // Connected with an admin key
 	mu            sync.RWMutex
 	hub           *WSHub
+
+	id          uint64
+	remoteAddr  string
+	connectedAt time.Time
+	joined      uint64        // Hub event seq when the client registered
+	compressed  bool          // permessage-deflate negotiated
+	sent        atomic.Uint64 // Events written to the connection
+	dropped     atomic.Uint64 // Events dropped while the queue was full
 }
 
 // WSHub manages all WebSocket connections.
@@ -59,19 +104,121 @@ type WSHub struct {
 	broadcast  chan *WSEvent
 	register   chan *WSClient
 	unregister chan *WSClient
+	bookSubs   chan bookSubscription
+	topicSubs  chan topicSubscription
+	logs       chan *logging.Record
+	recorder   func(*WSEvent)
+	book       func(pair string) *orderbook.Snapshot
+	allowed    map[EventType]bool // nil: every event
+	units      func(interface{}, *UnitsOptions) (interface{}, error)
+	cfg        node.WebSocketConfig
+	upgrader   websocket.Upgrader
 	log        *logging.Logger
 	mu         sync.RWMutex
+
+	nextID           atomic.Uint64
+	dropped          atomic.Uint64 // Events dropped from full client queues
+	slowDisconnects  atomic.Uint64 // Clients disconnected for a full queue
+	broadcastDropped atomic.Uint64 // Events dropped before reaching the hub
+	logSubs          atomic.Int32  // Clients subscribed to logs
+	logsDropped      atomic.Uint64 // Log records dropped before reaching the hub
+	logTap           sync.Once
+
+	// Events kept for topic replay; owned by the Run goroutine
+	seq    uint64
+	replay map[string]*topicReplay
+}
+
+// bookSubscription asks the hub to send a client the snapshot of a pair
+// and then its diffs.
+type bookSubscription struct {
+	client *WSClient
+	pair   string
+}
+
+// WSStats is the result of ws_stats.
+type WSStats struct {
+	Clients          int             `json:"clients"`
+	QueueSize        int             `json:"queue_size"`
+	SlowClient       string          `json:"slow_client"`
+	Compression      bool            `json:"compression"`
+	Sent             uint64          `json:"sent"`
+	Dropped          uint64          `json:"dropped"`
+	SlowDisconnects  uint64          `json:"slow_disconnects"`
+	BroadcastDropped uint64          `json:"broadcast_dropped"`
+	ClientStats      []WSClientStats `json:"client_stats"`
+}
+
+// WSClientStats describes one connected client.
+type WSClientStats struct {
+	ID            uint64 `json:"id"`
+	RemoteAddr    string `json:"remote_addr,omitempty"`
+	ConnectedAt   int64  `json:"connected_at"`
+	Queued        int    `json:"queued"`
+	Sent          uint64 `json:"sent"`
+	Dropped       uint64 `json:"dropped"`
+	Subscriptions int    `json:"subscriptions"` // 0: all events
+	Topics        int    `json:"topics"`
+	TopicFilter   bool   `json:"topic_filter"` // Only events of Topics are received
+	Compressed    bool   `json:"compressed"`
 }
 
-// NewWSHub creates a new WebSocket hub.
+// NewWSHub creates a new WebSocket hub with the default settings.
 func NewWSHub() *WSHub {
-	return &WSHub{
+	h := &WSHub{
 		clients:    make(map[*WSClient]bool),
 		broadcast:  make(chan *WSEvent, 256),
 		register:   make(chan *WSClient),
 		unregister: make(chan *WSClient),
+		bookSubs:   make(chan bookSubscription, 16),
+		topicSubs:  make(chan topicSubscription, 16),
+		replay:     make(map[string]*topicReplay),
+		logs:       make(chan *logging.Record, logQueueSize),
 		log:        logging.GetDefault().Component("ws"),
 	}
+	h.SetConfig(node.DefaultWebSocketConfig())
+	return h
+}
+
+// SetConfig sets the client queue, slow client and compression settings.
+// Must be called before Run.
+func (h *WSHub) SetConfig(cfg node.WebSocketConfig) {
+	h.cfg = cfg
+	h.upgrader = websocket.Upgrader{
+		ReadBufferSize:    1024,
+		WriteBufferSize:   1024,
+		EnableCompression: cfg.Compression,
+		CheckOrigin: func(r *http.Request) bool {
+			return true // Allow all origins
+		},
+	}
+}
+
+// SetRecorder sets a function called with every broadcast event, whether or
+// not a client is subscribed. Must be called before Run.
+func (h *WSHub) SetRecorder(recorder func(*WSEvent)) {
+	h.recorder = recorder
+}
+
+// SetAllowedEvents limits the events sent to clients to types. Must be
+// called before Run.
+func (h *WSHub) SetAllowedEvents(types []EventType) {
+	h.allowed = make(map[EventType]bool, len(types))
+	for _, t := range types {
+		h.allowed[t] = true
+	}
+}
+
+// SetOrderBook sets the function returning the order book snapshot of a
+// pair, enabling orderbook_subscribe. Must be called before Run.
+func (h *WSHub) SetOrderBook(snapshot func(pair string) *orderbook.Snapshot) {
+	h.book = snapshot
+}
+
+// SetUnitsAnnotator sets the function adding units metadata to event data
+// for clients that asked for it. Must be called before Run.
+func (h *WSHub) SetUnitsAnnotator(annotate func(interface{}, *UnitsOptions) (interface{}, error)) {
+	h.units = annotate
 }
 
 // Run starts the hub event loop.
@@ -81,6 +228,7 @@ func (h *WSHub) Run() {
 		case client := <-h.register:
 			h.mu.Lock()
 			h.clients[client] = true
+			client.joined = h.seq
 			h.mu.Unlock()
 			h.log.Debug("WebSocket client connected", "clients", len(h.clients))
 
@@ -93,52 +241,167 @@ func (h *WSHub) Run() {
 			h.mu.Unlock()
 			h.log.Debug("WebSocket client disconnected", "clients", len(h.clients))
 
+		case rec := <-h.logs:
+			h.sendLog(rec)
+
+		case sub := <-h.bookSubs:
+			// Diffs broadcast before the snapshot was taken are queued
+			// ahead of it, later ones after it: the client applies those
+			// with a higher seq than the snapshot's.
+			h.subscribeBook(sub)
+
+		case sub := <-h.topicSubs:
+			h.subscribeTopics(sub)
+
 		case event := <-h.broadcast:
 			data, err := json.Marshal(event)
 			if err != nil {
 				h.log.Error("Failed to marshal event", "error", err)
 				continue
 			}
+			// Order book diffs are replayed from a fresh snapshot, not the log
+			if h.recorder != nil && event.Type != EventOrderBookDiff {
+				h.recorder(event)
+			}
+			if h.allowed != nil && !h.allowed[event.Type] {
+				continue
+			}
+			var bookPair string
+			if diff, ok := event.Data.(*orderbook.Diff); ok {
+				bookPair = diff.Pair
+			}
+			h.seq++
+			h.recordTopics(h.seq, event, data)
 
+			var slow []*WSClient
 			h.mu.RLock()
 			for client := range h.clients {
 				// Check if client is subscribed to this event
 				client.mu.RLock()
 				subscribed := client.subscriptions[event.Type] || len(client.subscriptions) == 0
+				subscribed = subscribed && matchesTopics(client.topicFilter, client.topics, event.topics)
+				if event.Type == EventOrderBookDiff {
+					subscribed = client.books[bookPair]
+				}
+				units := client.units
 				client.mu.RUnlock()
 
 				if !subscribed {
 					continue
 				}
 
-				select {
-				case client.send <- data:
-				default:
-					// Client's buffer is full, disconnect
-					h.mu.RUnlock()
-					h.mu.Lock()
-					delete(h.clients, client)
-					close(client.send)
-					h.mu.Unlock()
-					h.mu.RLock()
+				payload := data
+				if units != nil && h.units != nil {
+					payload = h.annotateEvent(event, units, data)
+				}
+
+				if !h.enqueue(client, payload) {
+					slow = append(slow, client)
 				}
 			}
 			h.mu.RUnlock()
+
+			if len(slow) > 0 {
+				h.mu.Lock()
+				for _, client := range slow {
+					if _, ok := h.clients[client]; ok {
+						delete(h.clients, client)
+						close(client.send)
+						h.log.Warn("Disconnecting slow WebSocket client", "id", client.id, "remote", client.remoteAddr)
+					}
+				}
+				h.mu.Unlock()
+			}
 		}
 	}
 }
 
-// Broadcast sends an event to all subscribed clients.
-func (h *WSHub) Broadcast(eventType EventType, data interface{}) {
+// subscribeBook sends a client the snapshot of a pair and marks the pair so
+// the client receives its diffs.
+func (h *WSHub) subscribeBook(sub bookSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[sub.client] {
+		return
+	}
+
+	snap := h.book(sub.pair)
+	data, err := json.Marshal(&WSEvent{Type: EventOrderBookSnapshot, Data: snap, Timestamp: time.Now().Unix()})
+	if err != nil {
+		h.log.Error("Failed to marshal order book snapshot", "error", err)
+		return
+	}
+	sub.client.mu.Lock()
+	sub.client.books[snap.Pair] = true
+	sub.client.mu.Unlock()
+
+	if !h.enqueue(sub.client, data) {
+		delete(h.clients, sub.client)
+		close(sub.client.send)
+		h.log.Warn("Disconnecting slow WebSocket client", "id", sub.client.id, "remote", sub.client.remoteAddr)
+	}
+}
+
+// enqueue queues an event for a client without blocking. When the queue is
+// full it applies the slow client policy, returning false if the client must
+// be disconnected.
+func (h *WSHub) enqueue(client *WSClient, payload []byte) bool {
+	select {
+	case client.send <- payload:
+		return true
+	default:
+	}
+
+	if h.cfg.SlowClient == node.WSSlowClientDisconnect {
+		h.slowDisconnects.Add(1)
+		return false
+	}
+
+	// Drop the oldest queued event to make room. writePump may drain the
+	// queue concurrently, so neither step blocks.
+	select {
+	case <-client.send:
+		client.dropped.Add(1)
+		h.dropped.Add(1)
+	default:
+	}
+	select {
+	case client.send <- payload:
+	default:
+		client.dropped.Add(1)
+		h.dropped.Add(1)
+	}
+	return true
+}
+
+// annotateEvent encodes an event with units metadata in its data, falling
+// back to the plain encoding.
+func (h *WSHub) annotateEvent(event *WSEvent, opts *UnitsOptions, plain []byte) []byte {
+	annotated, err := h.units(event.Data, opts)
+	if err != nil {
+		return plain
+	}
+	data, err := json.Marshal(&WSEvent{Type: event.Type, Data: annotated, Timestamp: event.Timestamp})
+	if err != nil {
+		return plain
+	}
+	return data
+}
+
+// Broadcast sends an event of topics to all subscribed clients. Events
+// without topics reach only the clients that never subscribed to one.
+func (h *WSHub) Broadcast(eventType EventType, data interface{}, topics ...string) {
 	event := &WSEvent{
 		Type:      eventType,
 		Data:      data,
 		Timestamp: time.Now().Unix(),
+		topics:    topics,
 	}
 
 	select {
 	case h.broadcast <- event:
 	default:
+		h.broadcastDropped.Add(1)
 		h.log.Warn("Broadcast channel full, dropping event", "type", eventType)
 	}
 }
@@ -150,20 +413,91 @@ func (h *WSHub) ClientCount() int {
 	return len(h.clients)
 }
 
+// Stats returns the hub and per-client delivery statistics.
+func (h *WSHub) Stats() *WSStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	stats := &WSStats{
+		Clients:          len(h.clients),
+		QueueSize:        h.cfg.QueueSize,
+		SlowClient:       h.cfg.SlowClient,
+		Compression:      h.cfg.Compression,
+		Dropped:          h.dropped.Load(),
+		SlowDisconnects:  h.slowDisconnects.Load(),
+		BroadcastDropped: h.broadcastDropped.Load(),
+		ClientStats:      make([]WSClientStats, 0, len(h.clients)),
+	}
+	for client := range h.clients {
+		client.mu.RLock()
+		subscriptions, topics, filtered := len(client.subscriptions), len(client.topics), client.topicFilter
+		client.mu.RUnlock()
+
+		sent := client.sent.Load()
+		stats.Sent += sent
+		stats.ClientStats = append(stats.ClientStats, WSClientStats{
+			ID:            client.id,
+			RemoteAddr:    client.remoteAddr,
+			ConnectedAt:   client.connectedAt.Unix(),
+			Queued:        len(client.send),
+			Sent:          sent,
+			Dropped:       client.dropped.Load(),
+			Subscriptions: subscriptions,
+			Topics:        topics,
+			TopicFilter:   filtered,
+			Compressed:    client.compressed,
+		})
+	}
+	sort.Slice(stats.ClientStats, func(i, j int) bool { return stats.ClientStats[i].ID < stats.ClientStats[j].ID })
+	return stats
+}
+
+// newClient creates a client with its own outbound queue.
+func (h *WSHub) newClient(conn *websocket.Conn, remoteAddr string, compressed bool) *WSClient {
+	return &WSClient{
+		conn:          conn,
+		send:          make(chan []byte, h.cfg.QueueSize),
+		subscriptions: make(map[EventType]bool),
+		books:         make(map[string]bool),
+		topics:        make(map[string]bool),
+		hub:           h,
+		id:            h.nextID.Add(1),
+		remoteAddr:    remoteAddr,
+		connectedAt:   time.Now(),
+		compressed:    compressed,
+	}
+}
+
+// SetWebSocket configures WebSocket event delivery. It must be called
+// before Start.
+func (s *Server) SetWebSocket(cfg node.WebSocketConfig) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	s.wsCfg = &cfg
+	return nil
+}
+
+// wsStats returns the WebSocket hub statistics.
+func (s *Server) wsStats(ctx context.Context, params json.RawMessage) (interface{}, error) {
+	if s.wsHub == nil {
+		return &WSStats{ClientStats: []WSClientStats{}}, nil
+	}
+	return s.wsHub.Stats(), nil
+}
+
 // handleWS handles WebSocket connections.
 func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+	conn, err := s.wsHub.upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		s.log.Error("WebSocket upgrade failed", "error", err)
 		return
 	}
 
-	client := &WSClient{
-		conn:          conn,
-		send:          make(chan []byte, 256),
-		subscriptions: make(map[EventType]bool),
-		hub:           s.wsHub,
-	}
+	// The upgrader accepts permessage-deflate whenever the client offers it
+	compressed := s.wsHub.cfg.Compression && strings.Contains(r.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+	client := s.wsHub.newClient(conn, r.RemoteAddr, compressed)
+	client.admin = s.validAdminKey(requestAPIKey(r))
 
 	s.wsHub.register <- client
 
@@ -174,11 +508,14 @@ func (s *Server) handleWS(w http.ResponseWriter, r *http.Request) {
 // readPump reads messages from the WebSocket connection.
 func (c *WSClient) readPump() {
 	defer func() {
+		c.mu.Lock()
+		c.unsubscribeLogs()
+		c.mu.Unlock()
 		c.hub.unregister <- c
 		c.conn.Close()
 	}()
 
-	c.conn.SetReadLimit(4096)
+	c.conn.SetReadLimit(c.hub.cfg.MaxMessageSize)
 	c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
 	c.conn.SetPongHandler(func(string) error {
 		c.conn.SetReadDeadline(time.Now().Add(60 * time.Second))
@@ -213,7 +550,7 @@ func (c *WSClient) writePump() {
 	for {
 		select {
 		case message, ok := <-c.send:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.cfg.WriteTimeout))
 			if !ok {
 				c.conn.WriteMessage(websocket.CloseMessage, []byte{})
 				return
@@ -224,20 +561,33 @@ func (c *WSClient) writePump() {
 				return
 			}
 			w.Write(message)
+			sent := uint64(1)
 
-			// Add queued messages
+			// Add queued messages. The hub may drop the oldest ones
+			// meanwhile, so don't block on the queue.
 			n := len(c.send)
+		batch:
 			for i := 0; i < n; i++ {
-				w.Write([]byte{'\n'})
-				w.Write(<-c.send)
+				select {
+				case queued, ok := <-c.send:
+					if !ok {
+						break batch
+					}
+					w.Write([]byte{'\n'})
+					w.Write(queued)
+					sent++
+				default:
+					break batch
+				}
 			}
 
 			if err := w.Close(); err != nil {
 				return
 			}
+			c.sent.Add(sent)
 
 		case <-ticker.C:
-			c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			c.conn.SetWriteDeadline(time.Now().Add(c.hub.cfg.WriteTimeout))
 			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
@@ -250,6 +600,45 @@ func (c *WSClient) handleSubscription(sub *WSSubscription) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if sub.Subscribe != nil || sub.Unsubscribe != nil {
+		c.subscribeTopics(sub)
+		return
+	}
+
+	if sub.Action == "units" {
+		if sub.Units != nil {
+			if _, err := localeFormat(sub.Units.Locale); err != nil {
+				c.hub.log.Debug("Ignoring WebSocket units request", "error", err)
+				return
+			}
+		}
+		c.units = sub.Units
+		return
+	}
+
+	switch sub.Action {
+	case "orderbook_subscribe":
+		if c.hub.book == nil || sub.Pair == "" {
+			return
+		}
+		// The hub sends the snapshot; don't block the read loop on it
+		select {
+		case c.hub.bookSubs <- bookSubscription{client: c, pair: sub.Pair}:
+		default:
+			c.hub.log.Debug("Order book subscription queue full", "id", c.id)
+		}
+		return
+	case "orderbook_unsubscribe":
+		delete(c.books, orderbook.NormalizePair(sub.Pair))
+		return
+	case "logs_subscribe":
+		c.subscribeLogs(sub)
+		return
+	case "logs_unsubscribe":
+		c.unsubscribeLogs()
+		return
+	}
+
 	for _, eventStr := range sub.Events {
 		eventType := EventType(eventStr)
 		switch sub.Action {
@@ -0,0 +1,143 @@
+package rpc
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// newTestHub returns a running hub with a small per-client queue.
+func newTestHub(t *testing.T, policy string) *WSHub {
+	t.Helper()
+	cfg := node.DefaultWebSocketConfig()
+	cfg.QueueSize = 2
+	cfg.SlowClient = policy
+	hub := NewWSHub()
+	hub.SetConfig(cfg)
+	go hub.Run()
+	return hub
+}
+
+// waitFor polls until cond holds.
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func eventIndex(t *testing.T, data []byte) float64 {
+	t.Helper()
+	var ev WSEvent
+	if err := json.Unmarshal(data, &ev); err != nil {
+		t.Fatalf("invalid event: %v", err)
+	}
+	return ev.Data.(map[string]interface{})["i"].(float64)
+}
+
+func TestWSHubDropOldest(t *testing.T) {
+	hub := newTestHub(t, node.WSSlowClientDropOldest)
+	stalled := hub.newClient(nil, "stalled", false)
+	hub.register <- stalled
+
+	for i := 0; i < 5; i++ {
+		hub.Broadcast("order_created", map[string]int{"i": i})
+	}
+	waitFor(t, func() bool { return hub.Stats().Dropped == 3 })
+
+	// The stalled client keeps the newest events and stays connected
+	if got := eventIndex(t, <-stalled.send); got != 3 {
+		t.Errorf("first queued event = %v, want 3", got)
+	}
+	if got := eventIndex(t, <-stalled.send); got != 4 {
+		t.Errorf("second queued event = %v, want 4", got)
+	}
+	stats := hub.Stats()
+	if stats.Clients != 1 || stats.SlowDisconnects != 0 || stats.ClientStats[0].Dropped != 3 {
+		t.Errorf("stats = %+v", stats)
+	}
+}
+
+func TestWSHubDisconnectSlowClient(t *testing.T) {
+	hub := newTestHub(t, node.WSSlowClientDisconnect)
+	stalled := hub.newClient(nil, "stalled", false)
+	hub.register <- stalled
+
+	for i := 0; i < 3; i++ {
+		hub.Broadcast("order_created", map[string]int{"i": i})
+	}
+	waitFor(t, func() bool { return hub.ClientCount() == 0 })
+
+	// The queue is closed after the events it held
+	<-stalled.send
+	<-stalled.send
+	if _, ok := <-stalled.send; ok {
+		t.Error("slow client queue not closed")
+	}
+	if stats := hub.Stats(); stats.SlowDisconnects != 1 {
+		t.Errorf("SlowDisconnects = %d, want 1", stats.SlowDisconnects)
+	}
+
+	// Other clients keep receiving
+	live := hub.newClient(nil, "live", false)
+	hub.register <- live
+	hub.Broadcast("order_created", map[string]int{"i": 9})
+	select {
+	case data := <-live.send:
+		if got := eventIndex(t, data); got != 9 {
+			t.Errorf("event = %v, want 9", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("live client got no event")
+	}
+}
+
+func TestWSCompressionAndStats(t *testing.T) {
+	s := newTestStoreServer(t)
+	s.wsHub = NewWSHub()
+	go s.wsHub.Run()
+	srv := httptest.NewServer(http.HandlerFunc(s.handleWS))
+	defer srv.Close()
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	conn, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	defer conn.Close()
+	if !strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate") {
+		t.Errorf("compression not negotiated: %q", resp.Header.Get("Sec-Websocket-Extensions"))
+	}
+	waitFor(t, func() bool { return s.wsHub.ClientCount() == 1 })
+
+	s.wsHub.Broadcast("order_created", map[string]int{"i": 1})
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage() error = %v", err)
+	}
+	if got := eventIndex(t, data); got != 1 {
+		t.Errorf("event = %v, want 1", got)
+	}
+
+	waitFor(t, func() bool { return s.wsHub.Stats().Sent == 1 })
+	result, err := s.wsStats(nil, nil)
+	if err != nil {
+		t.Fatalf("wsStats() error = %v", err)
+	}
+	stats := result.(*WSStats)
+	if stats.Clients != 1 || !stats.ClientStats[0].Compressed || stats.ClientStats[0].RemoteAddr == "" {
+		t.Errorf("stats = %+v", stats)
+	}
+}
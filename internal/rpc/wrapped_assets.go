@@ -0,0 +1,116 @@
+// Package rpc - Orders between an asset and its wrapped or bridged form.
+//
+// The token registry links wrapped and bridged tokens to the asset they
+// stand for (WBTC to BTC, WETH to ETH, Binance-Peg USDC to USDC). Orders
+// whose two legs represent the same asset are flagged in listings, and with
+// wrapped_assets.reject_unfavorable we refuse to create or take one that
+// pays us less than we give, decimals adjusted, beyond max_discount_bps.
+package rpc
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// SameUnderlying describes an order whose legs represent the same asset.
+type SameUnderlying struct {
+	Asset string `json:"asset"`
+
+	// DeviationBps is how much more the order requests than it offers,
+	// counted 1:1 in the asset, in basis points. Negative values mean the
+	// maker gives away value.
+	DeviationBps int64 `json:"deviation_bps"`
+}
+
+// underlyingLeg is one side of an order counted in its underlying asset.
+type underlyingLeg struct {
+	asset    string
+	decimals uint8
+	amount   uint64
+}
+
+// SetWrappedAssets sets the policy for same-underlying orders.
+func (s *Server) SetWrappedAssets(cfg node.WrappedAssetsConfig) {
+	s.wrappedAssets = cfg
+}
+
+// underlyingLegs returns an order's legs counted in their underlying
+// assets, or false when either is unknown or they differ.
+func (s *Server) underlyingLegs(o *storage.Order) (offer, request underlyingLeg, ok bool) {
+	network := chain.Mainnet
+	if s.wallet != nil {
+		network = s.wallet.Network()
+	}
+	offer.amount, request.amount = o.OfferAmount, o.RequestAmount
+	if offer.asset, offer.decimals, ok = chain.Underlying(o.OfferChain, network, o.OfferToken); !ok {
+		return offer, request, false
+	}
+	if request.asset, request.decimals, ok = chain.Underlying(o.RequestChain, network, o.RequestToken); !ok {
+		return offer, request, false
+	}
+	return offer, request, offer.asset == request.asset
+}
+
+// wrapDeviationBps returns how much more receive is worth than give, in
+// basis points of give. Both legs represent the same asset.
+func wrapDeviationBps(give, receive underlyingLeg) int64 {
+	if give.amount == 0 {
+		return 0
+	}
+	giveValue := new(big.Int).SetUint64(give.amount)
+	giveValue.Mul(giveValue, pow10(receive.decimals))
+	receiveValue := new(big.Int).SetUint64(receive.amount)
+	receiveValue.Mul(receiveValue, pow10(give.decimals))
+
+	deviation := new(big.Int).Sub(receiveValue, giveValue)
+	deviation.Mul(deviation, big.NewInt(10000))
+	deviation.Quo(deviation, giveValue)
+	if !deviation.IsInt64() {
+		if deviation.Sign() > 0 {
+			return 1<<63 - 1
+		}
+		return -1 << 63
+	}
+	return deviation.Int64()
+}
+
+// pow10 returns 10^n.
+func pow10(n uint8) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// sameUnderlying flags an order between two forms of one asset (nil
+// otherwise).
+func (s *Server) sameUnderlying(o *storage.Order) *SameUnderlying {
+	offer, request, ok := s.underlyingLegs(o)
+	if !ok {
+		return nil
+	}
+	return &SameUnderlying{Asset: offer.asset, DeviationBps: wrapDeviationBps(offer, request)}
+}
+
+// requireFavorableWrap refuses, when the policy is on, a same-underlying
+// order that pays us less than we give beyond the accepted discount. As
+// maker we give the offer; as taker, the request.
+func (s *Server) requireFavorableWrap(o *storage.Order, maker bool) error {
+	if !s.wrappedAssets.RejectUnfavorable {
+		return nil
+	}
+	offer, request, ok := s.underlyingLegs(o)
+	if !ok {
+		return nil
+	}
+	give, receive := request, offer
+	if maker {
+		give, receive = offer, request
+	}
+	if deviation := wrapDeviationBps(give, receive); deviation < -int64(s.wrappedAssets.MaxDiscountBps) {
+		return fmt.Errorf("both legs are %s and we would receive %d bps less than we give (max discount %d)",
+			offer.asset, -deviation, s.wrappedAssets.MaxDiscountBps)
+	}
+	return nil
+}
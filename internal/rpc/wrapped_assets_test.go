@@ -0,0 +1,94 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+const (
+	testWBTC    = "0x2260FAC5E5542a773Aa44fBCfeDf7C193bc2C599" // Ethereum WBTC, 8 decimals
+	testUSDC    = "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48" // Ethereum USDC, 6 decimals
+	testBSCUSDC = "0x8AC76a51cc950d9822D68b83fE1Ad97B32Cd580d" // Binance-Peg USDC, 18 decimals
+)
+
+func TestOrdersSameUnderlying(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	orders := []*storage.Order{
+		{ID: "wrap", PeerID: "makerA", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "ETH", RequestToken: testWBTC, RequestAmount: 99000000, CreatedAt: time.Now()},
+		{ID: "bridge", PeerID: "makerB", Status: storage.OrderStatusOpen, OfferChain: "ETH", OfferToken: testUSDC, OfferAmount: 1000000, RequestChain: "BSC", RequestToken: testBSCUSDC, RequestAmount: 1000000000000000000, CreatedAt: time.Now().Add(time.Second)},
+		{ID: "plain", PeerID: "makerC", Status: storage.OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: time.Now().Add(2 * time.Second)},
+	}
+	for _, o := range orders {
+		if err := s.store.CreateOrder(o); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+
+	res, err := s.ordersList(context.Background(), json.RawMessage(`{}`))
+	if err != nil {
+		t.Fatalf("ordersList() error = %v", err)
+	}
+	want := map[string]*SameUnderlying{
+		"wrap":   {Asset: "BTC", DeviationBps: -100},
+		"bridge": {Asset: "USDC", DeviationBps: 0}, // 1 USDC each, in 6 and 18 decimals
+		"plain":  nil,
+	}
+	for _, o := range res.(*OrdersListResult).Orders {
+		w, got := want[o.ID], o.SameUnderlying
+		if (w == nil) != (got == nil) || (w != nil && *got != *w) {
+			t.Errorf("%s SameUnderlying = %+v, want %+v", o.ID, got, w)
+		}
+	}
+
+	got, err := s.ordersGet(context.Background(), json.RawMessage(`{"id":"wrap"}`))
+	if err != nil {
+		t.Fatalf("ordersGet() error = %v", err)
+	}
+	if got.(OrderInfo).SameUnderlying == nil {
+		t.Error("orders_get should flag same-underlying orders")
+	}
+}
+
+func TestRequireFavorableWrap(t *testing.T) {
+	s := newTestStoreServer(t)
+
+	// The maker gives 1 BTC for 0.99 WBTC: 100 bps to the taker's benefit
+	order := &storage.Order{OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "ETH", RequestToken: testWBTC, RequestAmount: 99000000}
+	if err := s.requireFavorableWrap(order, true); err != nil {
+		t.Errorf("policy off: requireFavorableWrap() error = %v", err)
+	}
+
+	s.SetWrappedAssets(node.WrappedAssetsConfig{RejectUnfavorable: true, MaxDiscountBps: 50})
+	if err := s.requireFavorableWrap(order, true); err == nil {
+		t.Error("maker giving 100 bps should be refused")
+	}
+	if err := s.requireFavorableWrap(order, false); err != nil {
+		t.Errorf("taker receiving more: requireFavorableWrap() error = %v", err)
+	}
+
+	// Within the discount for wrapping fees
+	order.RequestAmount = 99600000
+	if err := s.requireFavorableWrap(order, true); err != nil {
+		t.Errorf("40 bps discount: requireFavorableWrap() error = %v", err)
+	}
+
+	// Different underlying assets are left to the price check
+	plain := &storage.Order{OfferChain: "BTC", OfferAmount: 100000000, RequestChain: "LTC", RequestAmount: 1}
+	if err := s.requireFavorableWrap(plain, true); err != nil {
+		t.Errorf("different assets: requireFavorableWrap() error = %v", err)
+	}
+
+	// Creating an unfavorable order is refused before anything is stored
+	_, err := s.ordersCreate(context.Background(), json.RawMessage(
+		`{"offer_chain":"BTC","offer_amount":100000000,"request_chain":"ETH","request_token":"WBTC","request_amount":90000000}`))
+	if err == nil || !strings.Contains(err.Error(), "both legs are BTC") {
+		t.Errorf("ordersCreate() error = %v, want unfavorable wrap refusal", err)
+	}
+}
@@ -0,0 +1,156 @@
+// Package rpc - Log streaming to WebSocket clients.
+//
+// Clients connected with an admin key may send logs_subscribe to receive
+// the node's log records as log events, filtered by minimum level and
+// component, so headless nodes can be tailed from a dashboard. Each client
+// gets at most api.websocket.log_rate records per second; records beyond
+// it or that find the client's queue full are skipped and counted in the
+// next record sent, as are records lost while the hub was busy (counted for
+// every subscriber). Log events bypass the event log and the subscriptions
+// of other events.
+package rpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// EventLogRecord carries a log record to logs subscribers.
+const EventLogRecord EventType = "log"
+
+// logQueueSize is the number of records waiting for the hub; more are
+// dropped rather than holding up the logging goroutine.
+const logQueueSize = 1024
+
+// LogRecordEvent is the data of a log event.
+type LogRecordEvent struct {
+	*logging.Record
+	Skipped uint64 `json:"skipped,omitempty"` // Records skipped since the previous one
+}
+
+// logFilter is a client's log subscription.
+type logFilter struct {
+	level      logging.Level
+	components map[string]bool // Empty: all
+
+	window  time.Time // Start of the current rate window
+	sent    int       // Records sent in the window
+	skipped uint64    // Records skipped since the last one sent
+}
+
+// matches returns true if the filter selects a record.
+func (f *logFilter) matches(rec *logging.Record) bool {
+	if rec.Severity() < f.level {
+		return false
+	}
+	return len(f.components) == 0 || f.components[rec.Component]
+}
+
+// allow counts a record against the rate cap of one-second windows.
+func (f *logFilter) allow(now time.Time, rate int) bool {
+	if now.Sub(f.window) >= time.Second {
+		f.window = now
+		f.sent = 0
+	}
+	if f.sent >= rate {
+		f.skipped++
+		return false
+	}
+	f.sent++
+	return true
+}
+
+// subscribeLogs starts or updates the client's log subscription. Clients
+// without an admin key, and public APIs, are refused. The caller holds
+// c.mu.
+func (c *WSClient) subscribeLogs(sub *WSSubscription) {
+	if !c.admin || c.hub.allowed != nil {
+		c.hub.log.Debug("Refusing WebSocket logs subscription", "id", c.id, "remote", c.remoteAddr)
+		return
+	}
+
+	filter := &logFilter{level: logging.InfoLevel, components: make(map[string]bool)}
+	if sub.Level != "" {
+		filter.level = logging.ParseLevel(sub.Level)
+	}
+	for _, name := range sub.Components {
+		filter.components[name] = true
+	}
+	if c.logs == nil {
+		c.hub.logSubs.Add(1)
+	}
+	c.logs = filter
+	c.hub.tapLogs()
+}
+
+// unsubscribeLogs ends the client's log subscription. The caller holds
+// c.mu.
+func (c *WSClient) unsubscribeLogs() {
+	if c.logs != nil {
+		c.logs = nil
+		c.hub.logSubs.Add(-1)
+	}
+}
+
+// tapLogs starts feeding log records to the hub, once.
+func (h *WSHub) tapLogs() {
+	h.logTap.Do(func() {
+		logging.Tap(func(rec *logging.Record) {
+			if h.logSubs.Load() == 0 {
+				return
+			}
+			// Never block or log here: the caller may hold the hub's locks
+			select {
+			case h.logs <- rec:
+			default:
+				h.logsDropped.Add(1)
+			}
+		})
+	})
+}
+
+// sendLog queues a log record for the clients subscribed to it. Full
+// queues skip the record instead of applying the slow client policy.
+func (h *WSHub) sendLog(rec *logging.Record) {
+	now := time.Now()
+	lost := h.logsDropped.Swap(0)
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	for client := range h.clients {
+		client.mu.Lock()
+		filter := client.logs
+		if filter == nil || !filter.matches(rec) {
+			client.mu.Unlock()
+			continue
+		}
+		filter.skipped += lost
+		if !filter.allow(now, h.cfg.LogRate) {
+			client.mu.Unlock()
+			continue
+		}
+		skipped := filter.skipped
+		filter.skipped = 0
+		client.mu.Unlock()
+
+		data, err := json.Marshal(&WSEvent{
+			Type:      EventLogRecord,
+			Data:      &LogRecordEvent{Record: rec, Skipped: skipped},
+			Timestamp: now.Unix(),
+		})
+		if err != nil {
+			continue
+		}
+		select {
+		case client.send <- data:
+		default:
+			client.mu.Lock()
+			if client.logs != nil {
+				client.logs.skipped += skipped + 1
+			}
+			client.mu.Unlock()
+		}
+	}
+}
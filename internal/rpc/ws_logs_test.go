@@ -0,0 +1,90 @@
+package rpc
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func TestLogFilterRate(t *testing.T) {
+	f := &logFilter{}
+	now := time.Now()
+	for i := 0; i < 3; i++ {
+		if !f.allow(now, 3) {
+			t.Fatalf("record %d refused within the rate", i)
+		}
+	}
+	if f.allow(now.Add(500*time.Millisecond), 3) || f.skipped != 1 {
+		t.Errorf("record beyond the rate allowed, skipped = %d", f.skipped)
+	}
+	if !f.allow(now.Add(time.Second), 3) {
+		t.Error("record refused in the next window")
+	}
+}
+
+func TestWSHubLogs(t *testing.T) {
+	cfg := node.DefaultWebSocketConfig()
+	cfg.LogRate = 2
+	hub := NewWSHub()
+	hub.SetConfig(cfg)
+	go hub.Run()
+
+	admin := hub.newClient(nil, "admin", false)
+	admin.admin = true
+	other := hub.newClient(nil, "other", false)
+	hub.register <- admin
+	hub.register <- other
+
+	sub := &WSSubscription{Action: "logs_subscribe", Level: "warn", Components: []string{"ws-logs-test"}}
+	admin.handleSubscription(sub)
+	other.handleSubscription(sub)
+	if admin.logs == nil || other.logs != nil {
+		t.Fatalf("subscriptions: admin %v, other %v; want only the admin's", admin.logs, other.logs)
+	}
+	defer admin.handleSubscription(&WSSubscription{Action: "logs_unsubscribe"})
+
+	log := logging.GetDefault().Component("ws-logs-test")
+	log.Info("below the level")
+	logging.GetDefault().Component("ws-logs-other").Warn("other component")
+	log.Warn("funding late", "trade_id", "t1")
+
+	select {
+	case data := <-admin.send:
+		var ev struct {
+			Type EventType      `json:"type"`
+			Data LogRecordEvent `json:"data"`
+		}
+		if err := json.Unmarshal(data, &ev); err != nil {
+			t.Fatalf("invalid event: %v", err)
+		}
+		if ev.Type != EventLogRecord || ev.Data.Message != "funding late" || ev.Data.Level != "warn" ||
+			ev.Data.Component != "ws-logs-test" || ev.Data.Fields["trade_id"] != "t1" {
+			t.Errorf("log event = %s", data)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("admin got no log event")
+	}
+	select {
+	case data := <-admin.send:
+		t.Errorf("unexpected event %s", data)
+	case data := <-other.send:
+		t.Errorf("client without an admin key got %s", data)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// Beyond the rate, records are skipped and counted
+	for i := 0; i < 4; i++ {
+		log.Warn("burst")
+	}
+	waitFor(t, func() bool {
+		admin.mu.RLock()
+		defer admin.mu.RUnlock()
+		return admin.logs.skipped > 0
+	})
+	if n := len(admin.send); n > 1 {
+		t.Errorf("%d records queued in the window, want at most the rate", n+1)
+	}
+}
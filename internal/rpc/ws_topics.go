@@ -0,0 +1,236 @@
+// Package rpc - Topic subscriptions of WebSocket clients.
+//
+// Besides subscribing to event types, a client may narrow its stream to
+// topics: {"subscribe": ["swap:<trade_id>", "peers", "orders:BTC-LTC"]}.
+// Each event is tagged with its topics where it is broadcast: the trade of
+// a swap event, the peer events, and the pair of order events. A client
+// that subscribed to topics receives only the events of one of them, still
+// filtered by its event type subscriptions; one that unsubscribed from all
+// of them receives none. The hub keeps the last api.websocket.topic_replay
+// events of every topic and replays them, oldest first, to a client
+// subscribing to it, skipping the ones it already received.
+package rpc
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/orderbook"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Topics of WebSocket events.
+const (
+	TopicPeers       = "peers"   // peer_connected and peer_disconnected
+	TopicSwapPrefix  = "swap:"   // Events of one trade, by trade ID
+	TopicOrderPrefix = "orders:" // Order events of one pair, e.g. orders:BTC-LTC
+)
+
+// maxReplayTopics bounds the topics the hub keeps events of; the least
+// recently used one is forgotten first.
+const maxReplayTopics = 1024
+
+// topicSubscription asks the hub to add topics to a client and replay
+// their recent events.
+type topicSubscription struct {
+	client *WSClient
+	topics []string
+}
+
+// replayedEvent is an event kept for replay to new topic subscribers.
+type replayedEvent struct {
+	seq   uint64
+	event *WSEvent
+	data  []byte
+}
+
+// topicReplay holds the recent events of a topic, oldest first.
+type topicReplay struct {
+	events []*replayedEvent
+	last   uint64 // seq of the newest event
+}
+
+// normalizeTopic returns the canonical form of a topic a client sent. Pairs
+// are separated by "-" or "/" in either order, e.g. orders:ltc-btc is
+// orders:BTC/LTC.
+func normalizeTopic(topic string) (string, bool) {
+	topic = strings.TrimSpace(topic)
+	switch {
+	case topic == TopicPeers:
+		return topic, true
+	case strings.HasPrefix(topic, TopicSwapPrefix) && len(topic) > len(TopicSwapPrefix):
+		return topic, true
+	case strings.HasPrefix(topic, TopicOrderPrefix):
+		pair := strings.ReplaceAll(strings.TrimPrefix(topic, TopicOrderPrefix), "-", "/")
+		if strings.Count(pair, "/") != 1 {
+			return "", false
+		}
+		return TopicOrderPrefix + orderbook.NormalizePair(pair), true
+	}
+	return "", false
+}
+
+// SwapTopic returns the topic of the events of a trade.
+func SwapTopic(tradeID string) string {
+	return TopicSwapPrefix + tradeID
+}
+
+// PairTopic returns the topic of the order events of a normalized pair.
+func PairTopic(pair string) string {
+	return TopicOrderPrefix + pair
+}
+
+// orderTopic returns the topic of the events of an order.
+func orderTopic(order *storage.Order) string {
+	pair, _ := orderbook.PairOf(order)
+	return PairTopic(pair)
+}
+
+// matchesTopics returns true if a client receives an event of topics. A
+// client that never subscribed to a topic receives every event.
+func matchesTopics(filtered bool, subscribed map[string]bool, topics []string) bool {
+	if !filtered {
+		return true
+	}
+	for _, topic := range topics {
+		if subscribed[topic] {
+			return true
+		}
+	}
+	return false
+}
+
+// subscribeTopics applies the subscribe and unsubscribe lists of a
+// request. Unsubscribing is immediate; the hub adds subscribed topics with
+// their replay. From its first subscribe list on, even an empty one, the
+// client receives only the events of its topics. The caller holds c.mu.
+func (c *WSClient) subscribeTopics(sub *WSSubscription) {
+	for _, topic := range sub.Unsubscribe {
+		if topic, ok := normalizeTopic(topic); ok {
+			delete(c.topics, topic)
+		}
+	}
+
+	var topics []string
+	for _, topic := range sub.Subscribe {
+		normalized, ok := normalizeTopic(topic)
+		if !ok {
+			c.hub.log.Debug("Ignoring unknown WebSocket topic", "id", c.id, "topic", topic)
+			continue
+		}
+		topics = append(topics, normalized)
+	}
+	if len(topics) == 0 {
+		if sub.Subscribe != nil {
+			c.topicFilter = true
+		}
+		return
+	}
+	// The hub sends the replay; don't block the read loop on it
+	select {
+	case c.hub.topicSubs <- topicSubscription{client: c, topics: topics}:
+	default:
+		c.hub.log.Debug("Topic subscription queue full", "id", c.id)
+	}
+}
+
+// recordTopics keeps an event for replay to its topics' subscribers. It
+// runs on the hub goroutine.
+func (h *WSHub) recordTopics(seq uint64, event *WSEvent, data []byte) {
+	if h.cfg.TopicReplay <= 0 || len(event.topics) == 0 {
+		return
+	}
+	ev := &replayedEvent{seq: seq, event: event, data: data}
+	for _, topic := range event.topics {
+		r := h.replay[topic]
+		if r == nil {
+			if len(h.replay) >= maxReplayTopics {
+				h.evictReplayTopic()
+			}
+			r = &topicReplay{}
+			h.replay[topic] = r
+		}
+		r.events = append(r.events, ev)
+		if len(r.events) > h.cfg.TopicReplay {
+			r.events = r.events[len(r.events)-h.cfg.TopicReplay:]
+		}
+		r.last = seq
+	}
+}
+
+// evictReplayTopic forgets the topic with the oldest newest event.
+func (h *WSHub) evictReplayTopic() {
+	var oldest string
+	var last uint64
+	for topic, r := range h.replay {
+		if oldest == "" || r.last < last {
+			oldest, last = topic, r.last
+		}
+	}
+	delete(h.replay, oldest)
+}
+
+// subscribeTopics adds topics to a client and queues their recent events
+// it hasn't received: those from before it connected, and those its
+// previous topics filtered out. It runs on the hub goroutine.
+func (h *WSHub) subscribeTopics(sub topicSubscription) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.clients[sub.client] {
+		return
+	}
+
+	client := sub.client
+	client.mu.Lock()
+	filtered := client.topicFilter
+	previous := make(map[string]bool, len(client.topics))
+	for topic := range client.topics {
+		previous[topic] = true
+	}
+	for _, topic := range sub.topics {
+		client.topics[topic] = true
+	}
+	client.topicFilter = true
+	types := make(map[EventType]bool, len(client.subscriptions))
+	for t := range client.subscriptions {
+		types[t] = true
+	}
+	units := client.units
+	client.mu.Unlock()
+
+	seen := make(map[uint64]bool)
+	var events []*replayedEvent
+	for _, topic := range sub.topics {
+		r := h.replay[topic]
+		if r == nil || previous[topic] {
+			continue
+		}
+		for _, ev := range r.events {
+			if seen[ev.seq] {
+				continue
+			}
+			seen[ev.seq] = true
+			if ev.seq > client.joined && matchesTopics(filtered, previous, ev.event.topics) {
+				continue // Already received
+			}
+			if len(types) > 0 && !types[ev.event.Type] {
+				continue
+			}
+			events = append(events, ev)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].seq < events[j].seq })
+
+	for _, ev := range events {
+		payload := ev.data
+		if units != nil && h.units != nil {
+			payload = h.annotateEvent(ev.event, units, ev.data)
+		}
+		if !h.enqueue(client, payload) {
+			delete(h.clients, client)
+			close(client.send)
+			h.log.Warn("Disconnecting slow WebSocket client", "id", client.id, "remote", client.remoteAddr)
+			return
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func TestNormalizeTopic(t *testing.T) {
+	tests := map[string]string{
+		"peers":                "peers",
+		"swap:t1":              "swap:t1",
+		"orders:BTC-LTC":       "orders:BTC/LTC",
+		"orders:ltc/btc":       "orders:BTC/LTC",
+		"orders:eth:0xABC-btc": "orders:BTC/ETH:0xabc",
+		"swap:":                "",
+		"orders:BTC":           "",
+		"trades":               "",
+	}
+	for in, want := range tests {
+		got, ok := normalizeTopic(in)
+		if got != want || ok != (want != "") {
+			t.Errorf("normalizeTopic(%q) = %q, %v, want %q", in, got, ok, want)
+		}
+	}
+}
+
+func TestMatchesTopics(t *testing.T) {
+	tests := []struct {
+		name       string
+		filtered   bool
+		subscribed map[string]bool
+		topics     []string
+		want       bool
+	}{
+		{"never subscribed", false, nil, []string{"swap:t1"}, true},
+		{"never subscribed, no topics", false, nil, nil, true},
+		{"subscribed topic", true, map[string]bool{"swap:t1": true}, []string{"peers", "swap:t1"}, true},
+		{"other topic", true, map[string]bool{"swap:t1": true}, []string{"swap:t2"}, false},
+		{"event without topics", true, map[string]bool{"swap:t1": true}, nil, false},
+		{"empty set", true, map[string]bool{}, []string{"swap:t1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchesTopics(tt.filtered, tt.subscribed, tt.topics); got != tt.want {
+				t.Errorf("matchesTopics() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// receive returns the trade IDs of the events queued for a client.
+func receive(t *testing.T, client *WSClient, n int) []string {
+	t.Helper()
+	var got []string
+	for len(got) < n {
+		select {
+		case data := <-client.send:
+			var ev struct {
+				Data struct {
+					TradeID string `json:"trade_id"`
+				} `json:"data"`
+			}
+			json.Unmarshal(data, &ev)
+			got = append(got, ev.Data.TradeID)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("received %v, want %d events", got, n)
+		}
+	}
+	return got
+}
+
+func TestWSHubTopics(t *testing.T) {
+	cfg := node.DefaultWebSocketConfig()
+	cfg.TopicReplay = 2
+	hub := NewWSHub()
+	hub.SetConfig(cfg)
+	go hub.Run()
+
+	// The probe receives the events once the hub handled them
+	probe := hub.newClient(nil, "probe", false)
+	hub.register <- probe
+	for _, id := range []string{"t1", "t2", "t1", "t1"} {
+		hub.Broadcast("swap_refunded", map[string]string{"trade_id": id}, SwapTopic(id))
+	}
+	receive(t, probe, 4)
+
+	client := hub.newClient(nil, "client", false)
+	hub.register <- client
+
+	// Subscribing replays the last events of the topic
+	client.handleSubscription(&WSSubscription{Subscribe: []string{"swap:t1", "nonsense"}})
+	if got := receive(t, client, 2); !reflect.DeepEqual(got, []string{"t1", "t1"}) {
+		t.Errorf("replay = %v", got)
+	}
+
+	// Only the topic's events follow
+	hub.Broadcast("swap_refunded", map[string]string{"trade_id": "t2"}, SwapTopic("t2"))
+	hub.Broadcast("swap_refunded", map[string]string{"trade_id": "t1"}, SwapTopic("t1"))
+	if got := receive(t, client, 1); got[0] != "t1" {
+		t.Errorf("event of trade %s delivered", got[0])
+	}
+
+	// A second topic replays its own events, not the ones already sent
+	client.handleSubscription(&WSSubscription{Subscribe: []string{"swap:t2", "swap:t1"}})
+	if got := receive(t, client, 2); !reflect.DeepEqual(got, []string{"t2", "t2"}) {
+		t.Errorf("replay = %v", got)
+	}
+
+	client.handleSubscription(&WSSubscription{Unsubscribe: []string{"swap:t1"}})
+	hub.Broadcast("swap_refunded", map[string]string{"trade_id": "t1"}, SwapTopic("t1"))
+	hub.Broadcast("swap_refunded", map[string]string{"trade_id": "t2"}, SwapTopic("t2"))
+	if got := receive(t, client, 1); got[0] != "t2" {
+		t.Errorf("event of unsubscribed trade %s delivered", got[0])
+	}
+	if stats := hub.Stats(); stats.ClientStats[1].Topics != 1 {
+		t.Errorf("Topics = %d, want 1", stats.ClientStats[1].Topics)
+	}
+
+	// Without topics left, or with an explicit empty set, nothing arrives
+	client.handleSubscription(&WSSubscription{Unsubscribe: []string{"swap:t2"}})
+	empty := hub.newClient(nil, "empty", false)
+	hub.register <- empty
+	empty.handleSubscription(&WSSubscription{Subscribe: []string{}})
+	hub.Broadcast("swap_refunded", map[string]string{"trade_id": "t2"}, SwapTopic("t2"))
+	hub.Broadcast(EventNodeStatus, map[string]string{"trade_id": "status"})
+	receive(t, probe, 2)
+	for _, c := range []*WSClient{client, empty} {
+		select {
+		case data := <-c.send:
+			t.Errorf("client %s received %s", c.remoteAddr, data)
+		default:
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+func TestPut(t *testing.T) {
+	var gotPath, gotAuth, gotDate, gotHash string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut {
+			t.Errorf("method = %s, want PUT", r.Method)
+		}
+		gotPath = r.URL.Path
+		gotAuth = r.Header.Get("Authorization")
+		gotDate = r.Header.Get("x-amz-date")
+		gotHash = r.Header.Get("x-amz-content-sha256")
+		gotBody, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	c := New(node.S3Config{
+		Endpoint: srv.URL, Bucket: "analytics", Region: "eu-west-1", Prefix: "/klingdex/",
+		AccessKey: "AKIDEXAMPLE", SecretKey: "secret",
+	})
+	c.now = func() time.Time { return time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC) }
+
+	if err := c.Put(context.Background(), "orders.v1.20260102T030405Z.csv", []byte("id\n")); err != nil {
+		t.Fatalf("Put() error = %v", err)
+	}
+	if gotPath != "/analytics/klingdex/orders.v1.20260102T030405Z.csv" {
+		t.Errorf("path = %s", gotPath)
+	}
+	if string(gotBody) != "id\n" {
+		t.Errorf("body = %q", gotBody)
+	}
+	if gotDate != "20260102T030405Z" || gotHash != sha256Hex([]byte("id\n")) {
+		t.Errorf("x-amz-date = %s, x-amz-content-sha256 = %s", gotDate, gotHash)
+	}
+	wantPrefix := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20260102/eu-west-1/s3/aws4_request, SignedHeaders=host;x-amz-content-sha256;x-amz-date, Signature="
+	if !strings.HasPrefix(gotAuth, wantPrefix) || len(gotAuth) != len(wantPrefix)+64 {
+		t.Errorf("Authorization = %s", gotAuth)
+	}
+}
+
+func TestPutError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "AccessDenied", http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	c := New(node.S3Config{Endpoint: srv.URL, Bucket: "analytics"})
+	err := c.Put(context.Background(), "fees.csv", nil)
+	if err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put() error = %v, want AccessDenied", err)
+	}
+}
+
+func TestGet(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			t.Errorf("method = %s, want GET", r.Method)
+		}
+		if r.Header.Get("x-amz-content-sha256") != sha256Hex(nil) {
+			t.Errorf("x-amz-content-sha256 = %s, want empty payload hash", r.Header.Get("x-amz-content-sha256"))
+		}
+		if r.URL.Path == "/backups/node/missing" {
+			http.Error(w, "NoSuchKey", http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("payload"))
+	}))
+	defer srv.Close()
+
+	c := New(node.S3Config{Endpoint: srv.URL, Bucket: "backups", Prefix: "node"})
+	data, err := c.Get(context.Background(), "latest")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if string(data) != "payload" {
+		t.Errorf("Get() = %q, want payload", data)
+	}
+
+	if _, err := c.Get(context.Background(), "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+	}
+}
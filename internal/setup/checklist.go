@@ -0,0 +1,277 @@
+// Package setup prepares a node to run: a first-run wizard that writes the
+// configuration and wallet, and the checklist verified before every start.
+//
+// The checklist covers what makes a node unsafe or unable to trade: a data
+// directory it can't write, listen ports taken by another process, a skewed
+// clock (swap deadlines are measured against it), unreachable chain
+// backends, an API open to the network without keys, and a missing wallet.
+// Each check passes, warns or fails; what a failure does is up to the
+// caller (see node.StartupChecksConfig).
+package setup
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"syscall"
+	"time"
+
+	ma "github.com/multiformats/go-multiaddr"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// Check statuses.
+const (
+	StatusOK   = "ok"
+	StatusWarn = "warn"
+	StatusFail = "fail"
+)
+
+// Check is the outcome of one startup check.
+type Check struct {
+	Name   string `json:"name"`
+	Status string `json:"status"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// Checklist is the outcome of all startup checks.
+type Checklist struct {
+	Checks []Check `json:"checks"`
+}
+
+// OK returns true if no check failed.
+func (c *Checklist) OK() bool {
+	return len(c.Failed()) == 0
+}
+
+// Failed returns the failed checks.
+func (c *Checklist) Failed() []Check {
+	var failed []Check
+	for _, check := range c.Checks {
+		if check.Status == StatusFail {
+			failed = append(failed, check)
+		}
+	}
+	return failed
+}
+
+// Print writes the checklist, one check per line.
+func (c *Checklist) Print(w io.Writer) {
+	for _, check := range c.Checks {
+		if check.Detail != "" {
+			fmt.Fprintf(w, "  [%-4s] %s: %s\n", check.Status, check.Name, check.Detail)
+		} else {
+			fmt.Fprintf(w, "  [%-4s] %s\n", check.Status, check.Name)
+		}
+	}
+}
+
+// Options selects what the checklist verifies.
+type Options struct {
+	Config   *node.Config
+	DataDir  string                     // Expanded data directory
+	APIAddr  string                     // host:port the API listens on
+	Backends map[string]backend.Backend // By chain symbol
+	Timeout  time.Duration              // Bounds the backend and clock checks
+}
+
+// Run runs the startup checks.
+func Run(ctx context.Context, opts Options) *Checklist {
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = node.DefaultConfig().StartupChecks.Timeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	list := &Checklist{}
+	list.Checks = append(list.Checks, checkDataDir(opts.DataDir))
+	list.Checks = append(list.Checks, checkPorts(opts.Config.Network.ListenAddrs, opts.APIAddr)...)
+	list.Checks = append(list.Checks, checkClock(ctx, opts.Config.Clock))
+	list.Checks = append(list.Checks, checkBackends(ctx, opts.Backends)...)
+	list.Checks = append(list.Checks, checkAPIAuth(opts.Config.API, opts.APIAddr))
+	list.Checks = append(list.Checks, checkWallet(opts.DataDir))
+	return list
+}
+
+// checkDataDir verifies the data directory exists and is writable.
+func checkDataDir(dir string) Check {
+	check := Check{Name: "data_dir", Status: StatusOK, Detail: dir}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		check.Status, check.Detail = StatusFail, err.Error()
+		return check
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		check.Status, check.Detail = StatusFail, fmt.Sprintf("not writable: %v", err)
+		return check
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return check
+}
+
+// checkPorts verifies the P2P listen addresses and the API address can be
+// bound.
+func checkPorts(listenAddrs []string, apiAddr string) []Check {
+	var checks []Check
+	for _, addr := range listenAddrs {
+		check := Check{Name: "port " + addr, Status: StatusOK}
+		network, hostPort, err := listenTarget(addr)
+		if err != nil {
+			check.Status, check.Detail = StatusFail, err.Error()
+		} else if hostPort != "" {
+			check.Status, check.Detail = ProbePort(network, hostPort)
+		}
+		checks = append(checks, check)
+	}
+	if apiAddr != "" {
+		check := Check{Name: "port api " + apiAddr}
+		check.Status, check.Detail = ProbePort("tcp", apiAddr)
+		checks = append(checks, check)
+	}
+	return checks
+}
+
+// listenTarget returns the network and host:port a listen multiaddr binds,
+// or "" for addresses that can't be probed (port 0, other transports).
+func listenTarget(addr string) (string, string, error) {
+	m, err := ma.NewMultiaddr(addr)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid listen address: %w", err)
+	}
+	host, err := m.ValueForProtocol(ma.P_IP4)
+	if err != nil {
+		if host, err = m.ValueForProtocol(ma.P_IP6); err != nil {
+			return "", "", nil
+		}
+	}
+	network := "tcp"
+	port, err := m.ValueForProtocol(ma.P_TCP)
+	if err != nil {
+		network = "udp"
+		if port, err = m.ValueForProtocol(ma.P_UDP); err != nil {
+			return "", "", nil
+		}
+	}
+	if port == "0" {
+		return "", "", nil
+	}
+	return network, net.JoinHostPort(host, port), nil
+}
+
+// ProbePort tries to bind a tcp or udp address. A port in use fails; other
+// bind errors (e.g. no IPv6 on the host) only warn.
+func ProbePort(network, hostPort string) (string, string) {
+	var closer io.Closer
+	var err error
+	if network == "udp" {
+		closer, err = net.ListenPacket(network, hostPort)
+	} else {
+		closer, err = net.Listen(network, hostPort)
+	}
+	if err == nil {
+		closer.Close()
+		return StatusOK, ""
+	}
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return StatusFail, "in use"
+	}
+	return StatusWarn, err.Error()
+}
+
+// checkClock compares the local clock with the configured NTP servers.
+func checkClock(ctx context.Context, cfg node.ClockConfig) Check {
+	status := node.NewClockMonitor(cfg).Check(ctx)
+	check := Check{Name: "clock", Status: StatusOK}
+	switch {
+	case status.Source == "":
+		check.Status, check.Detail = StatusWarn, "no NTP server answered, offset unknown"
+	case status.Skewed:
+		check.Status = StatusFail
+		check.Detail = fmt.Sprintf("offset %s exceeds %s", status.Offset(), time.Duration(status.MaxSkewMs)*time.Millisecond)
+	default:
+		check.Detail = fmt.Sprintf("offset %s", status.Offset())
+	}
+	return check
+}
+
+// checkBackends connects to each chain backend concurrently and reads its
+// block height.
+func checkBackends(ctx context.Context, backends map[string]backend.Backend) []Check {
+	symbols := make([]string, 0, len(backends))
+	for symbol := range backends {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	checks := make([]Check, len(symbols))
+	var wg sync.WaitGroup
+	for i, symbol := range symbols {
+		wg.Add(1)
+		go func(i int, symbol string) {
+			defer wg.Done()
+			checks[i] = Check{Name: "backend " + symbol}
+			checks[i].Status, checks[i].Detail = ProbeBackend(ctx, backends[symbol])
+		}(i, symbol)
+	}
+	wg.Wait()
+	return checks
+}
+
+// ProbeBackend connects to a backend and reads its block height.
+func ProbeBackend(ctx context.Context, b backend.Backend) (string, string) {
+	if err := b.Connect(ctx); err != nil {
+		return StatusFail, err.Error()
+	}
+	height, err := b.GetBlockHeight(ctx)
+	if err != nil {
+		return StatusFail, err.Error()
+	}
+	return StatusOK, fmt.Sprintf("height %d", height)
+}
+
+// checkAPIAuth verifies an API reachable from the network requires keys.
+func checkAPIAuth(cfg node.APIConfig, apiAddr string) Check {
+	check := Check{Name: "api_auth", Status: StatusOK}
+	keys := len(cfg.Keys) + len(cfg.AdminKeys)
+	loopback := isLoopback(apiAddr)
+	switch {
+	case keys == 0 && loopback:
+		check.Status, check.Detail = StatusWarn, "no API keys: any local process can use the wallet"
+	case keys == 0:
+		check.Status, check.Detail = StatusFail, "no API keys on a non-loopback address"
+	case !loopback && !cfg.TLS.Enabled():
+		check.Status, check.Detail = StatusWarn, "API keys are sent without TLS"
+	}
+	return check
+}
+
+// isLoopback returns true if a host:port only listens on loopback.
+func isLoopback(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// checkWallet verifies a wallet was created.
+func checkWallet(dataDir string) Check {
+	if _, err := os.Stat(filepath.Join(dataDir, "wallet.seed")); err != nil {
+		return Check{Name: "wallet", Status: StatusWarn, Detail: "no wallet yet, create one with klingond -init or wallet_create"}
+	}
+	return Check{Name: "wallet", Status: StatusOK}
+}
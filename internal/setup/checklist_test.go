@@ -0,0 +1,168 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+)
+
+// newHeightServer returns a mempool-style API answering the tip height.
+func newHeightServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/blocks/tip/height" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("850000"))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// findCheck returns the check with a name.
+func findCheck(t *testing.T, list *Checklist, name string) Check {
+	t.Helper()
+	for _, check := range list.Checks {
+		if check.Name == name {
+			return check
+		}
+	}
+	t.Fatalf("no %s check in %+v", name, list.Checks)
+	return Check{}
+}
+
+func TestRun(t *testing.T) {
+	srv := newHeightServer(t)
+	down := httptest.NewServer(http.NotFoundHandler())
+	down.Close()
+
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	port := taken.Addr().(*net.TCPAddr).Port
+
+	cfg := node.DefaultConfig()
+	cfg.Clock.NTPServers = nil
+	cfg.Network.ListenAddrs = []string{"/ip4/127.0.0.1/tcp/0", "/ip4/127.0.0.1/tcp/" + strconv.Itoa(port)}
+	dir := t.TempDir()
+
+	list := Run(context.Background(), Options{
+		Config:  cfg,
+		DataDir: dir,
+		APIAddr: "127.0.0.1:0",
+		Backends: map[string]backend.Backend{
+			"BTC": backend.NewMempoolBackend(srv.URL),
+			"LTC": backend.NewMempoolBackend(down.URL),
+		},
+	})
+
+	want := map[string]string{
+		"data_dir":                  StatusOK,
+		"port /ip4/127.0.0.1/tcp/0": StatusOK,
+		"port /ip4/127.0.0.1/tcp/" + strconv.Itoa(port): StatusFail,
+		"port api 127.0.0.1:0":                          StatusOK,
+		"clock":                                         StatusWarn,
+		"backend BTC":                                   StatusOK,
+		"backend LTC":                                   StatusFail,
+		"api_auth":                                      StatusWarn,
+		"wallet":                                        StatusWarn,
+	}
+	for name, status := range want {
+		if check := findCheck(t, list, name); check.Status != status {
+			t.Errorf("%s = %s (%s), want %s", name, check.Status, check.Detail, status)
+		}
+	}
+	if d := findCheck(t, list, "backend BTC").Detail; d != "height 850000" {
+		t.Errorf("BTC detail = %q", d)
+	}
+	if list.OK() || len(list.Failed()) != 2 {
+		t.Errorf("Failed() = %+v, want the taken port and LTC", list.Failed())
+	}
+
+	var out bytes.Buffer
+	list.Print(&out)
+	if !strings.Contains(out.String(), "[fail] backend LTC:") || !strings.Contains(out.String(), "[ok  ] backend BTC: height 850000") {
+		t.Errorf("Print() =\n%s", out.String())
+	}
+
+	// A wallet and keys clear the warnings
+	os.WriteFile(filepath.Join(dir, "wallet.seed"), []byte("seed"), 0600)
+	cfg.API.Keys = []string{"key"}
+	cfg.Network.ListenAddrs = nil
+	list = Run(context.Background(), Options{Config: cfg, DataDir: dir, APIAddr: "127.0.0.1:0"})
+	if check := findCheck(t, list, "wallet"); check.Status != StatusOK {
+		t.Errorf("wallet = %+v", check)
+	}
+	if check := findCheck(t, list, "api_auth"); check.Status != StatusOK {
+		t.Errorf("api_auth = %+v", check)
+	}
+	if !list.OK() {
+		t.Errorf("Failed() = %+v", list.Failed())
+	}
+}
+
+func TestCheckAPIAuth(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  node.APIConfig
+		addr string
+		want string
+	}{
+		{"loopback without keys", node.APIConfig{}, "127.0.0.1:8080", StatusWarn},
+		{"localhost with keys", node.APIConfig{Keys: []string{"k"}}, "localhost:8080", StatusOK},
+		{"public without keys", node.APIConfig{}, "0.0.0.0:8080", StatusFail},
+		{"public with admin keys, no TLS", node.APIConfig{AdminKeys: []string{"k"}}, ":8080", StatusWarn},
+		{"public with keys and TLS", node.APIConfig{Keys: []string{"k"}, TLS: node.TLSConfig{CertFile: "c", KeyFile: "k"}}, "10.0.0.1:8080", StatusOK},
+	}
+	for _, tt := range tests {
+		if got := checkAPIAuth(tt.cfg, tt.addr); got.Status != tt.want {
+			t.Errorf("%s: status = %s (%s), want %s", tt.name, got.Status, got.Detail, tt.want)
+		}
+	}
+}
+
+func TestListenTarget(t *testing.T) {
+	tests := []struct {
+		addr, network, hostPort string
+	}{
+		{"/ip4/0.0.0.0/tcp/4001", "tcp", "0.0.0.0:4001"},
+		{"/ip4/0.0.0.0/udp/4001/quic-v1", "udp", "0.0.0.0:4001"},
+		{"/ip6/::/tcp/4001", "tcp", "[::]:4001"},
+		{"/ip4/0.0.0.0/tcp/0", "", ""},
+		{"/dns4/example.com/tcp/4001", "", ""},
+	}
+	for _, tt := range tests {
+		network, hostPort, err := listenTarget(tt.addr)
+		if err != nil || network != tt.network || hostPort != tt.hostPort {
+			t.Errorf("listenTarget(%s) = %s, %s, %v", tt.addr, network, hostPort, err)
+		}
+	}
+	if _, _, err := listenTarget("not a multiaddr"); err == nil {
+		t.Error("listenTarget() accepted an invalid address")
+	}
+}
+
+func TestCheckDataDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "new")
+	if check := checkDataDir(dir); check.Status != StatusOK {
+		t.Errorf("checkDataDir(new) = %+v", check)
+	}
+	file := filepath.Join(t.TempDir(), "file")
+	os.WriteFile(file, nil, 0600)
+	if check := checkDataDir(file); check.Status != StatusFail {
+		t.Errorf("checkDataDir(file) = %+v, want fail", check)
+	}
+}
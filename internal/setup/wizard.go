@@ -0,0 +1,451 @@
+package setup
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/charmbracelet/x/term"
+)
+
+// ErrInputClosed is returned when the input ends before the wizard does.
+var ErrInputClosed = errors.New("input closed")
+
+// Wizard walks a user through the first run: network, data directory, P2P
+// port, chain backends, wallet and API key, checking each answer before it
+// is kept. Nothing is written until the last step, except the wallet seed.
+type Wizard struct {
+	in  *bufio.Scanner
+	out io.Writer
+
+	// readSecret reads a line without echo when the input is a terminal
+	// (nil: secrets are read like other answers).
+	readSecret func() (string, error)
+
+	// Probes, replaced in tests
+	probePort    func(network, hostPort string) (string, string)
+	probeBackend func(ctx context.Context, b backend.Backend) (string, string)
+	checkClock   func(ctx context.Context, cfg node.ClockConfig) Check
+}
+
+// Result is what the wizard set up.
+type Result struct {
+	DataDir    string `json:"data_dir"` // Data directory of the chosen network
+	Testnet    bool   `json:"testnet"`
+	ConfigPath string `json:"config_path"`
+	Wallet     bool   `json:"wallet"`            // A wallet exists
+	APIKey     string `json:"api_key,omitempty"` // Generated API key
+}
+
+// NewWizard creates a wizard reading answers from in and prompting on out.
+func NewWizard(in io.Reader, out io.Writer) *Wizard {
+	w := &Wizard{
+		in:           bufio.NewScanner(in),
+		out:          out,
+		probePort:    ProbePort,
+		probeBackend: ProbeBackend,
+		checkClock:   checkClock,
+	}
+	if f, ok := in.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		w.readSecret = func() (string, error) {
+			line, err := term.ReadPassword(f.Fd())
+			fmt.Fprintln(out) // The user's enter wasn't echoed
+			return string(line), err
+		}
+	}
+	return w
+}
+
+// Run asks the questions and saves the configuration. baseDir is the
+// default data directory; testnet data lives in its testnet subdirectory.
+func (w *Wizard) Run(ctx context.Context, baseDir string) (*Result, error) {
+	fmt.Fprintln(w.out, "Klingon node setup. Press enter to accept the [default].")
+
+	// Network
+	result := &Result{}
+	for {
+		answer, err := w.ask("Network (mainnet, testnet)", "mainnet")
+		if err != nil {
+			return nil, err
+		}
+		if answer == "mainnet" || answer == "testnet" {
+			result.Testnet = answer == "testnet"
+			break
+		}
+		fmt.Fprintln(w.out, "  Enter mainnet or testnet.")
+	}
+	network := chain.Mainnet
+	if result.Testnet {
+		network = chain.Testnet
+	}
+
+	// Data directory
+	dir, err := w.ask("Data directory", baseDir)
+	if err != nil {
+		return nil, err
+	}
+	result.DataDir = dir
+	if result.Testnet {
+		result.DataDir = filepath.Join(dir, "testnet")
+	}
+	dataPath := expandPath(result.DataDir)
+	if check := checkDataDir(dataPath); check.Status != StatusOK {
+		return nil, fmt.Errorf("data directory %s: %s", dataPath, check.Detail)
+	}
+	result.ConfigPath = node.ConfigPath(result.DataDir)
+
+	cfg := node.DefaultConfig()
+	if _, err := os.Stat(result.ConfigPath); err == nil {
+		keep, err := w.confirm(fmt.Sprintf("A configuration exists at %s. Start from it?", result.ConfigPath), true)
+		if err != nil {
+			return nil, err
+		}
+		if keep {
+			if cfg, err = node.LoadConfig(result.DataDir); err != nil {
+				return nil, err
+			}
+		}
+	}
+	cfg.Storage.DataDir = result.DataDir
+	cfg.NetworkType = node.NetworkMainnet
+	if result.Testnet {
+		cfg.NetworkType = node.NetworkTestnet
+	}
+
+	steps := []func(context.Context, *node.Config, *Result, chain.Network) error{
+		w.portStep,
+		w.backendStep,
+		w.walletStep,
+		w.apiStep,
+		w.clockStep,
+	}
+	for _, step := range steps {
+		if err := step(ctx, cfg, result, network); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := cfg.Save(result.ConfigPath); err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(w.out, "\nConfiguration saved to %s\n", result.ConfigPath)
+	start := "klingond -data-dir " + dir
+	if result.Testnet {
+		start += " -testnet"
+	}
+	fmt.Fprintf(w.out, "Start the node with: %s\n", start)
+	return result, nil
+}
+
+// portStep asks for a free P2P port and listens on it over TCP and QUIC.
+func (w *Wizard) portStep(_ context.Context, cfg *node.Config, _ *Result, _ chain.Network) error {
+	current := "4001"
+	if len(cfg.Network.ListenAddrs) > 0 {
+		if _, hostPort, err := listenTarget(cfg.Network.ListenAddrs[0]); err == nil && hostPort != "" {
+			current = hostPort[strings.LastIndex(hostPort, ":")+1:]
+		}
+	}
+	for {
+		answer, err := w.ask("P2P port", current)
+		if err != nil {
+			return err
+		}
+		port, err := strconv.Atoi(answer)
+		if err != nil || port < 1 || port > 65535 {
+			fmt.Fprintln(w.out, "  Enter a port between 1 and 65535.")
+			continue
+		}
+		if status, detail := w.probePort("tcp", fmt.Sprintf("0.0.0.0:%d", port)); status == StatusFail {
+			fmt.Fprintf(w.out, "  Port %d is %s, choose another.\n", port, detail)
+			continue
+		}
+		if answer != current {
+			cfg.Network.ListenAddrs = []string{
+				fmt.Sprintf("/ip4/0.0.0.0/tcp/%d", port),
+				fmt.Sprintf("/ip4/0.0.0.0/udp/%d/quic-v1", port),
+				fmt.Sprintf("/ip6/::/tcp/%d", port),
+				fmt.Sprintf("/ip6/::/udp/%d/quic-v1", port),
+			}
+		}
+		return nil
+	}
+}
+
+// backendStep keeps the public backends or sets the user's own per chain,
+// checking each is reachable.
+func (w *Wizard) backendStep(ctx context.Context, cfg *node.Config, _ *Result, network chain.Network) error {
+	public, err := w.confirm("Use the public blockchain backends?", true)
+	if err != nil || public {
+		return err
+	}
+
+	defaults := backend.DefaultConfigs()
+	var symbols []string
+	for symbol, def := range defaults {
+		if backend.New(def, "http://localhost") != nil {
+			symbols = append(symbols, symbol)
+		}
+	}
+	sort.Strings(symbols)
+
+	answer, err := w.ask(fmt.Sprintf("Chains to configure (%s)", strings.Join(symbols, ", ")), "")
+	if err != nil {
+		return err
+	}
+	for _, symbol := range strings.Split(answer, ",") {
+		symbol = strings.ToUpper(strings.TrimSpace(symbol))
+		if symbol == "" {
+			continue
+		}
+		def, ok := defaults[symbol]
+		if !ok || backend.New(def, "http://localhost") == nil {
+			fmt.Fprintf(w.out, "  %s has no configurable backend, skipped.\n", symbol)
+			continue
+		}
+		if err := w.backendURL(ctx, cfg, symbol, def, network); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backendURL asks for one chain's backend URL until it answers or the user
+// keeps it anyway.
+func (w *Wizard) backendURL(ctx context.Context, cfg *node.Config, symbol string, def *backend.Config, network chain.Network) error {
+	for {
+		url, err := w.ask(fmt.Sprintf("%s %s URL", symbol, def.Type), def.URL(network, chain.ActiveTestnet(symbol)))
+		if err != nil {
+			return err
+		}
+		bcfg := &backend.Config{Type: def.Type, RPCType: def.RPCType}
+		if network == chain.Testnet {
+			bcfg.TestnetURL = url
+		} else {
+			bcfg.MainnetURL = url
+		}
+
+		probeCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+		status, detail := w.probeBackend(probeCtx, backend.New(bcfg, url))
+		cancel()
+		if status == StatusOK {
+			fmt.Fprintf(w.out, "  %s backend reachable (%s).\n", symbol, detail)
+		} else {
+			fmt.Fprintf(w.out, "  %s backend unreachable: %s\n", symbol, detail)
+			keep, err := w.confirm("Keep it anyway?", false)
+			if err != nil {
+				return err
+			}
+			if !keep {
+				continue
+			}
+		}
+		if cfg.Backends == nil {
+			cfg.Backends = make(map[string]*backend.Config)
+		}
+		if existing, ok := cfg.Backends[symbol]; ok && existing.Type == bcfg.Type {
+			// Keep the other network's URL
+			if network == chain.Testnet {
+				existing.TestnetURL, existing.TestnetURLs = url, nil
+			} else {
+				existing.MainnetURL = url
+			}
+			return nil
+		}
+		cfg.Backends[symbol] = bcfg
+		return nil
+	}
+}
+
+// walletStep creates or restores the wallet, unless one exists.
+func (w *Wizard) walletStep(_ context.Context, cfg *node.Config, result *Result, network chain.Network) error {
+	svc := wallet.NewService(&wallet.ServiceConfig{DataDir: expandPath(result.DataDir), Network: network})
+	if svc.HasWallet() {
+		fmt.Fprintln(w.out, "  A wallet exists in the data directory, keeping it.")
+		result.Wallet = true
+		return nil
+	}
+
+	var mnemonic string
+	for mnemonic == "" {
+		answer, err := w.ask("Wallet (create, restore, skip)", "create")
+		if err != nil {
+			return err
+		}
+		switch answer {
+		case "skip":
+			fmt.Fprintln(w.out, "  No wallet: create one later with wallet_create.")
+			return nil
+		case "create":
+			if mnemonic, err = svc.GenerateMnemonic(); err != nil {
+				return err
+			}
+			fmt.Fprintf(w.out, "\nRecovery phrase, write it down and keep it offline:\n\n  %s\n\n", mnemonic)
+			for {
+				saved, err := w.confirm("Have you written it down?", false)
+				if err != nil {
+					return err
+				}
+				if saved {
+					break
+				}
+			}
+		case "restore":
+			for {
+				answer, err := w.askSecret("Recovery phrase")
+				if err != nil {
+					return err
+				}
+				if svc.ValidateMnemonic(answer) {
+					mnemonic = answer
+					break
+				}
+				fmt.Fprintln(w.out, "  Invalid recovery phrase.")
+			}
+		default:
+			fmt.Fprintln(w.out, "  Enter create, restore or skip.")
+		}
+	}
+
+	passphrase, err := w.askSecret("BIP39 passphrase (optional)")
+	if err != nil {
+		return err
+	}
+	for {
+		password, err := w.askSecret("Wallet password")
+		if err != nil {
+			return err
+		}
+		if err := wallet.ValidatePassword(password); err != nil {
+			fmt.Fprintf(w.out, "  %v\n", err)
+			continue
+		}
+		again, err := w.askSecret("Repeat password")
+		if err != nil {
+			return err
+		}
+		if again != password {
+			fmt.Fprintln(w.out, "  Passwords differ.")
+			continue
+		}
+		if err := svc.CreateWallet(mnemonic, passphrase, password); err != nil {
+			return err
+		}
+		break
+	}
+	fmt.Fprintln(w.out, "  Wallet created.")
+	result.Wallet = true
+	return nil
+}
+
+// apiStep generates an API key, unless keys are configured.
+func (w *Wizard) apiStep(_ context.Context, cfg *node.Config, result *Result, _ chain.Network) error {
+	if len(cfg.API.Keys) > 0 || len(cfg.API.AdminKeys) > 0 {
+		fmt.Fprintln(w.out, "  API keys are configured, keeping them.")
+		return nil
+	}
+	protect, err := w.confirm("Require an API key?", true)
+	if err != nil || !protect {
+		return err
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	result.APIKey = hex.EncodeToString(key)
+	cfg.API.Keys = append(cfg.API.Keys, result.APIKey)
+	fmt.Fprintf(w.out, "  API key (send as \"Authorization: Bearer <key>\"): %s\n", result.APIKey)
+	return nil
+}
+
+// clockStep reports whether the clock is sane; it can't be fixed here.
+func (w *Wizard) clockStep(ctx context.Context, cfg *node.Config, _ *Result, _ chain.Network) error {
+	check := w.checkClock(ctx, cfg.Clock)
+	switch check.Status {
+	case StatusOK:
+		fmt.Fprintf(w.out, "  Clock in sync (%s).\n", check.Detail)
+	case StatusWarn:
+		fmt.Fprintf(w.out, "  Clock not checked: %s.\n", check.Detail)
+	default:
+		fmt.Fprintf(w.out, "  Clock skewed: %s. Fix it before trading, swap deadlines depend on it.\n", check.Detail)
+	}
+	return nil
+}
+
+// ask prompts for a line, returning def for an empty answer.
+func (w *Wizard) ask(prompt, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(w.out, "%s [%s]: ", prompt, def)
+	} else {
+		fmt.Fprintf(w.out, "%s: ", prompt)
+	}
+	if !w.in.Scan() {
+		if err := w.in.Err(); err != nil {
+			return "", err
+		}
+		return "", ErrInputClosed
+	}
+	answer := strings.TrimSpace(w.in.Text())
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askSecret asks for a password or recovery phrase, without echo on a
+// terminal.
+func (w *Wizard) askSecret(prompt string) (string, error) {
+	if w.readSecret == nil {
+		return w.ask(prompt, "")
+	}
+	fmt.Fprintf(w.out, "%s: ", prompt)
+	answer, err := w.readSecret()
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// confirm asks a yes/no question.
+func (w *Wizard) confirm(prompt string, def bool) (bool, error) {
+	hint := "y/N"
+	if def {
+		hint = "Y/n"
+	}
+	for {
+		answer, err := w.ask(prompt+" ("+hint+")", "")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return def, nil
+		case "y", "yes":
+			return true, nil
+		case "n", "no":
+			return false, nil
+		}
+	}
+}
+
+// expandPath expands ~ to the home directory.
+func expandPath(path string) string {
+	if len(path) > 0 && path[0] == '~' {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
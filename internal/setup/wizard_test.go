@@ -0,0 +1,158 @@
+package setup
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// newTestWizard returns a wizard answering lines, with port 4100 taken and
+// the clock in sync.
+func newTestWizard(lines ...string) (*Wizard, *bytes.Buffer) {
+	out := &bytes.Buffer{}
+	w := NewWizard(strings.NewReader(strings.Join(lines, "\n")+"\n"), out)
+	w.probePort = func(network, hostPort string) (string, string) {
+		if hostPort == "0.0.0.0:4100" {
+			return StatusFail, "in use"
+		}
+		return StatusOK, ""
+	}
+	w.checkClock = func(context.Context, node.ClockConfig) Check {
+		return Check{Name: "clock", Status: StatusOK, Detail: "offset 12ms"}
+	}
+	return w, out
+}
+
+func TestWizardRestore(t *testing.T) {
+	srv := newHeightServer(t)
+	mnemonic, err := wallet.GenerateMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+
+	w, out := newTestWizard(
+		"testnet",
+		dir,
+		"abc", "4100", "4200", // Invalid, taken, free
+		"n", "BTC, FOO", srv.URL,
+		"restore", "not a phrase", mnemonic, "",
+		"short", "Password123", "Password124", "Password123", "Password123",
+		"", // API key
+	)
+	result, err := w.Run(context.Background(), "~/.klingon")
+	if err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+
+	testDir := filepath.Join(dir, "testnet")
+	if !result.Testnet || result.DataDir != testDir || !result.Wallet || len(result.APIKey) != 64 {
+		t.Errorf("Run() = %+v", result)
+	}
+	for _, want := range []string{"Port 4100 is in use", "FOO has no configurable backend", "BTC backend reachable (height 850000)", "Invalid recovery phrase", "Passwords differ", "Clock in sync", "-testnet"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(testDir, "wallet.seed")); err != nil {
+		t.Errorf("wallet seed not written: %v", err)
+	}
+
+	cfg, err := node.LoadConfig(testDir)
+	if err != nil {
+		t.Fatalf("LoadConfig() error = %v", err)
+	}
+	if !cfg.IsTestnet() || cfg.Storage.DataDir != testDir {
+		t.Errorf("config network %s, data dir %s", cfg.NetworkType, cfg.Storage.DataDir)
+	}
+	if len(cfg.Network.ListenAddrs) != 4 || cfg.Network.ListenAddrs[0] != "/ip4/0.0.0.0/tcp/4200" {
+		t.Errorf("listen addrs = %v", cfg.Network.ListenAddrs)
+	}
+	if b := cfg.Backends["BTC"]; b == nil || b.TestnetURL != srv.URL || b.MainnetURL != "" {
+		t.Errorf("BTC backend = %+v", b)
+	}
+	if len(cfg.API.Keys) != 1 || cfg.API.Keys[0] != result.APIKey {
+		t.Errorf("API keys = %v", cfg.API.Keys)
+	}
+
+	// A second run starts from the saved config and keeps wallet and keys
+	w, out = newTestWizard("testnet", dir, "", "", "")
+	if result, err = w.Run(context.Background(), dir); err != nil {
+		t.Fatalf("second Run() error = %v\n%s", err, out.String())
+	}
+	if !result.Wallet || result.APIKey != "" {
+		t.Errorf("second Run() = %+v", result)
+	}
+	cfg, _ = node.LoadConfig(testDir)
+	if cfg.Network.ListenAddrs[0] != "/ip4/0.0.0.0/tcp/4200" || cfg.Backends["BTC"] == nil || len(cfg.API.Keys) != 1 {
+		t.Errorf("second run lost settings: %v %v %v", cfg.Network.ListenAddrs, cfg.Backends, cfg.API.Keys)
+	}
+}
+
+func TestWizardCreate(t *testing.T) {
+	dir := t.TempDir()
+	w, out := newTestWizard(
+		"", dir, "", "",
+		"create", "n", "y", "", "Password123", "Password123",
+		"n", // No API key
+	)
+	result, err := w.Run(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+	if result.Testnet || result.DataDir != dir || !result.Wallet || result.APIKey != "" {
+		t.Errorf("Run() = %+v", result)
+	}
+	if !strings.Contains(out.String(), "Recovery phrase, write it down") {
+		t.Errorf("mnemonic not shown:\n%s", out.String())
+	}
+	cfg, err := node.LoadConfig(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.IsTestnet() || len(cfg.Backends) != 0 || len(cfg.API.Keys) != 0 {
+		t.Errorf("config = network %s, backends %v, keys %v", cfg.NetworkType, cfg.Backends, cfg.API.Keys)
+	}
+}
+
+func TestWizardSecretsWithoutEcho(t *testing.T) {
+	dir := t.TempDir()
+	w, out := newTestWizard("", dir, "", "", "create", "y", "n")
+	secrets := []string{"", "Password123", "Password123"}
+	w.readSecret = func() (string, error) {
+		if len(secrets) == 0 {
+			return "", ErrInputClosed
+		}
+		secret := secrets[0]
+		secrets = secrets[1:]
+		return secret, nil
+	}
+	result, err := w.Run(context.Background(), dir)
+	if err != nil {
+		t.Fatalf("Run() error = %v\n%s", err, out.String())
+	}
+	if !result.Wallet || len(secrets) != 0 {
+		t.Errorf("Run() = %+v, %d secrets unread", result, len(secrets))
+	}
+	if strings.Contains(out.String(), "Password123") {
+		t.Errorf("password in output:\n%s", out.String())
+	}
+}
+
+func TestWizardInputClosed(t *testing.T) {
+	dir := t.TempDir()
+	w, _ := newTestWizard("mainnet", dir, "", "", "skip")
+	if _, err := w.Run(context.Background(), dir); !errors.Is(err, ErrInputClosed) {
+		t.Errorf("Run() error = %v, want ErrInputClosed", err)
+	}
+	if _, err := os.Stat(node.ConfigPath(dir)); err == nil {
+		t.Error("config saved by an unfinished wizard")
+	}
+}
@@ -0,0 +1,107 @@
+// Package storage - Address reuse across wallet sends, swap payouts and
+// receives.
+package storage
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Address use kinds.
+const (
+	AddressUseSend    = "send"    // Destination of a wallet send
+	AddressUsePayout  = "payout"  // External payout address of a swap
+	AddressUseReceive = "receive" // Our wallet address, received on
+)
+
+// AddressUse is how often an address was used in one way.
+type AddressUse struct {
+	Kind    string   `json:"kind"`
+	Chain   string   `json:"chain"`
+	Address string   `json:"address"`
+	Count   int      `json:"count"`
+	Refs    []string `json:"refs"` // Send and receive txids, payout trade IDs
+}
+
+// AddressUses returns the uses of an address on a chain, by kind. An empty
+// chain or address matches all.
+func (s *Storage) AddressUses(chain, address string) ([]*AddressUse, error) {
+	return s.addressUses(chain, address, 1)
+}
+
+// ReusedAddresses returns the addresses used more than once in the same
+// way, of one chain or of all for an empty chain.
+func (s *Storage) ReusedAddresses(chain string) ([]*AddressUse, error) {
+	return s.addressUses(chain, "", 2)
+}
+
+// addressUses collects the uses of addresses, filtered by chain and address
+// when set, keeping those used at least minCount times.
+func (s *Storage) addressUses(chain, address string, minCount int) ([]*AddressUse, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	queries := []struct {
+		kind  string
+		query string
+	}{
+		{AddressUseSend, `
+			SELECT chain, address, txid FROM wallet_transactions
+			WHERE kind = 'send' AND address != ''
+			ORDER BY created_at, id`},
+		// The maker initiates and receives on the request chain
+		{AddressUsePayout, `
+			SELECT CASE our_role WHEN 'maker' THEN request_chain ELSE offer_chain END,
+				payout_address, id
+			FROM trades WHERE payout_address != ''
+			ORDER BY created_at, id`},
+		{AddressUseReceive, `
+			SELECT chain, address, txid FROM wallet_utxos
+			GROUP BY chain, address, txid
+			ORDER BY MIN(created_at), txid`},
+	}
+
+	uses := make([]*AddressUse, 0)
+	for _, q := range queries {
+		byAddr := make(map[string]*AddressUse)
+		var order []*AddressUse
+
+		rows, err := s.db.Query(q.query)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query %s addresses: %w", q.kind, err)
+		}
+		for rows.Next() {
+			var c, addr, ref string
+			if err := rows.Scan(&c, &addr, &ref); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("failed to scan %s address: %w", q.kind, err)
+			}
+			if (chain != "" && c != chain) || (address != "" && addr != address) {
+				continue
+			}
+			use, ok := byAddr[c+":"+addr]
+			if !ok {
+				use = &AddressUse{Kind: q.kind, Chain: c, Address: addr}
+				byAddr[c+":"+addr] = use
+				order = append(order, use)
+			}
+			use.Count++
+			use.Refs = append(use.Refs, ref)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, use := range order {
+			if use.Count >= minCount {
+				uses = append(uses, use)
+			}
+		}
+	}
+
+	// Most reused first; ties keep their first-use order
+	sort.SliceStable(uses, func(i, j int) bool { return uses[i].Count > uses[j].Count })
+	return uses, nil
+}
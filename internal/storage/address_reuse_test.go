@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAddressReuse(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	// Two sends to the same address, one to another
+	for i, tx := range []*WalletTransaction{
+		{Chain: "BTC", Kind: WalletTxSend, TxID: "s1", Address: "bc1qshop", CreatedAt: 100},
+		{Chain: "BTC", Kind: WalletTxSend, TxID: "s2", Address: "bc1qother", CreatedAt: 200},
+		{Chain: "BTC", Kind: WalletTxSend, TxID: "s3", Address: "bc1qshop", CreatedAt: 300},
+		{Chain: "BTC", Kind: WalletTxConsolidation, TxID: "c1", Address: "bc1qshop", CreatedAt: 400},
+	} {
+		if err := store.RecordWalletTransaction(tx); err != nil {
+			t.Fatalf("RecordWalletTransaction(%d) error = %v", i, err)
+		}
+	}
+
+	// Two swaps paying out to the same LTC address: as maker we receive the
+	// request chain, as taker the offer chain
+	now := time.Now()
+	for _, trade := range []*Trade{
+		{ID: "t1", OurRole: TradeRoleMaker, OfferChain: "BTC", RequestChain: "LTC", PayoutAddress: "ltc1qcold", CreatedAt: now},
+		{ID: "t2", OurRole: TradeRoleTaker, OfferChain: "LTC", RequestChain: "BTC", PayoutAddress: "ltc1qcold", CreatedAt: now.Add(time.Second)},
+	} {
+		trade.OrderID, trade.MakerPeerID, trade.TakerPeerID = "o", "maker", "taker"
+		trade.Method, trade.State = "htlc_bitcoin", TradeStateInit
+		if err := store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+
+	// Three receives on our address, two outputs of one transaction
+	for _, utxo := range []*WalletUTXO{
+		{TxID: "r1", Vout: 0, Amount: 1000, Address: "bc1qmine", Chain: "BTC", Status: UTXOStatusConfirmed},
+		{TxID: "r1", Vout: 1, Amount: 1000, Address: "bc1qmine", Chain: "BTC", Status: UTXOStatusConfirmed},
+		{TxID: "r2", Vout: 0, Amount: 1000, Address: "bc1qmine", Chain: "BTC", Status: UTXOStatusConfirmed},
+		{TxID: "r3", Vout: 0, Amount: 1000, Address: "bc1qfresh", Chain: "BTC", Status: UTXOStatusConfirmed},
+	} {
+		if err := store.SaveWalletUTXO(utxo); err != nil {
+			t.Fatalf("SaveWalletUTXO() error = %v", err)
+		}
+	}
+
+	reused, err := store.ReusedAddresses("")
+	if err != nil {
+		t.Fatalf("ReusedAddresses() error = %v", err)
+	}
+	if len(reused) != 3 {
+		t.Fatalf("ReusedAddresses() = %d findings, want 3: %+v", len(reused), reused)
+	}
+	want := map[string]AddressUse{
+		AddressUseSend:    {Chain: "BTC", Address: "bc1qshop", Count: 2},
+		AddressUsePayout:  {Chain: "LTC", Address: "ltc1qcold", Count: 2},
+		AddressUseReceive: {Chain: "BTC", Address: "bc1qmine", Count: 2},
+	}
+	for _, use := range reused {
+		w := want[use.Kind]
+		if use.Chain != w.Chain || use.Address != w.Address || use.Count != w.Count || len(use.Refs) != w.Count {
+			t.Errorf("%s finding = %+v, want %+v", use.Kind, use, w)
+		}
+	}
+
+	ltc, err := store.ReusedAddresses("LTC")
+	if err != nil {
+		t.Fatalf("ReusedAddresses(LTC) error = %v", err)
+	}
+	if len(ltc) != 1 || ltc[0].Kind != AddressUsePayout || ltc[0].Refs[0] != "t1" {
+		t.Errorf("ReusedAddresses(LTC) = %+v", ltc)
+	}
+
+	uses, err := store.AddressUses("BTC", "bc1qother")
+	if err != nil {
+		t.Fatalf("AddressUses() error = %v", err)
+	}
+	if len(uses) != 1 || uses[0].Kind != AddressUseSend || uses[0].Count != 1 || uses[0].Refs[0] != "s2" {
+		t.Errorf("AddressUses(bc1qother) = %+v", uses)
+	}
+	if uses, _ := store.AddressUses("LTC", "bc1qother"); len(uses) != 0 {
+		t.Errorf("AddressUses() on another chain = %+v", uses)
+	}
+}
@@ -0,0 +1,131 @@
+// Package storage - Per-trade auto-claim policy overrides and counterparty
+// claims.
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// AutoClaimOverride replaces the node's auto-claim policy for one trade.
+type AutoClaimOverride struct {
+	TradeID       string    `json:"trade_id"`
+	Mode          string    `json:"mode"`
+	Confirmations uint32    `json:"confirmations,omitempty"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// SaveAutoClaimOverride stores or replaces a trade's override.
+func (s *Storage) SaveAutoClaimOverride(o *AutoClaimOverride) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	o.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO auto_claim_overrides (trade_id, mode, confirmations, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(trade_id) DO UPDATE SET
+			mode = excluded.mode, confirmations = excluded.confirmations,
+			updated_at = excluded.updated_at
+	`, o.TradeID, o.Mode, o.Confirmations, o.UpdatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save auto-claim override: %w", err)
+	}
+	return nil
+}
+
+// DeleteAutoClaimOverride removes a trade's override. Deleting a missing
+// override is not an error.
+func (s *Storage) DeleteAutoClaimOverride(tradeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`DELETE FROM auto_claim_overrides WHERE trade_id = ?`, tradeID); err != nil {
+		return fmt.Errorf("failed to delete auto-claim override: %w", err)
+	}
+	return nil
+}
+
+// GetAutoClaimOverrides returns all per-trade overrides.
+func (s *Storage) GetAutoClaimOverrides() ([]*AutoClaimOverride, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT trade_id, mode, confirmations, updated_at
+		FROM auto_claim_overrides
+		ORDER BY trade_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query auto-claim overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []*AutoClaimOverride
+	for rows.Next() {
+		var o AutoClaimOverride
+		var updatedAt int64
+		if err := rows.Scan(&o.TradeID, &o.Mode, &o.Confirmations, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan auto-claim override: %w", err)
+		}
+		o.UpdatedAt = time.Unix(updatedAt, 0)
+		overrides = append(overrides, &o)
+	}
+	return overrides, rows.Err()
+}
+
+// CounterpartyClaim is the counterparty's claim transaction of a trade, whose
+// confirmations gate our claim in confirmations mode.
+type CounterpartyClaim struct {
+	TradeID   string    `json:"trade_id"`
+	Chain     string    `json:"chain"`
+	TxID      string    `json:"txid"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SaveCounterpartyClaim stores or replaces a trade's counterparty claim.
+func (s *Storage) SaveCounterpartyClaim(cl *CounterpartyClaim) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cl.UpdatedAt = time.Now()
+	_, err := s.db.Exec(`
+		INSERT INTO auto_claim_counterparty_claims (trade_id, chain, txid, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(trade_id) DO UPDATE SET
+			chain = excluded.chain, txid = excluded.txid,
+			updated_at = excluded.updated_at
+	`, cl.TradeID, cl.Chain, cl.TxID, cl.UpdatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save counterparty claim: %w", err)
+	}
+	return nil
+}
+
+// GetCounterpartyClaims returns the counterparty claims of all trades.
+func (s *Storage) GetCounterpartyClaims() ([]*CounterpartyClaim, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT trade_id, chain, txid, updated_at
+		FROM auto_claim_counterparty_claims
+		ORDER BY trade_id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counterparty claims: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []*CounterpartyClaim
+	for rows.Next() {
+		var cl CounterpartyClaim
+		var updatedAt int64
+		if err := rows.Scan(&cl.TradeID, &cl.Chain, &cl.TxID, &updatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan counterparty claim: %w", err)
+		}
+		cl.UpdatedAt = time.Unix(updatedAt, 0)
+		claims = append(claims, &cl)
+	}
+	return claims, rows.Err()
+}
@@ -0,0 +1,69 @@
+package storage
+
+import "testing"
+
+func TestAutoClaimOverrides(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if err := store.SaveAutoClaimOverride(&AutoClaimOverride{TradeID: "trade-b", Mode: "manual"}); err != nil {
+		t.Fatalf("SaveAutoClaimOverride() error = %v", err)
+	}
+	if err := store.SaveAutoClaimOverride(&AutoClaimOverride{TradeID: "trade-a", Mode: "immediate"}); err != nil {
+		t.Fatalf("SaveAutoClaimOverride() error = %v", err)
+	}
+
+	// Replacing keeps one row per trade
+	if err := store.SaveAutoClaimOverride(&AutoClaimOverride{TradeID: "trade-a", Mode: "confirmations", Confirmations: 3}); err != nil {
+		t.Fatalf("SaveAutoClaimOverride() replace error = %v", err)
+	}
+
+	got, err := store.GetAutoClaimOverrides()
+	if err != nil {
+		t.Fatalf("GetAutoClaimOverrides() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetAutoClaimOverrides() returned %d overrides, want 2", len(got))
+	}
+	if got[0].TradeID != "trade-a" || got[0].Mode != "confirmations" || got[0].Confirmations != 3 {
+		t.Errorf("override[0] = %+v, want trade-a confirmations/3", got[0])
+	}
+	if got[0].UpdatedAt.IsZero() {
+		t.Error("override[0].UpdatedAt not set")
+	}
+	if got[1].TradeID != "trade-b" || got[1].Mode != "manual" {
+		t.Errorf("override[1] = %+v, want trade-b manual", got[1])
+	}
+
+	if err := store.DeleteAutoClaimOverride("trade-b"); err != nil {
+		t.Fatalf("DeleteAutoClaimOverride() error = %v", err)
+	}
+	if err := store.DeleteAutoClaimOverride("missing"); err != nil {
+		t.Errorf("DeleteAutoClaimOverride(missing) error = %v", err)
+	}
+	got, _ = store.GetAutoClaimOverrides()
+	if len(got) != 1 || got[0].TradeID != "trade-a" {
+		t.Errorf("GetAutoClaimOverrides() after delete = %+v, want only trade-a", got)
+	}
+}
+
+func TestCounterpartyClaims(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if err := store.SaveCounterpartyClaim(&CounterpartyClaim{TradeID: "trade-a", Chain: "LTC", TxID: "tx1"}); err != nil {
+		t.Fatalf("SaveCounterpartyClaim() error = %v", err)
+	}
+	// A replacement claim (e.g. after an RBF) keeps one row per trade
+	if err := store.SaveCounterpartyClaim(&CounterpartyClaim{TradeID: "trade-a", Chain: "LTC", TxID: "tx2"}); err != nil {
+		t.Fatalf("SaveCounterpartyClaim() replace error = %v", err)
+	}
+
+	got, err := store.GetCounterpartyClaims()
+	if err != nil {
+		t.Fatalf("GetCounterpartyClaims() error = %v", err)
+	}
+	if len(got) != 1 || got[0].TradeID != "trade-a" || got[0].Chain != "LTC" || got[0].TxID != "tx2" || got[0].UpdatedAt.IsZero() {
+		t.Errorf("GetCounterpartyClaims() = %+v, want trade-a LTC/tx2", got)
+	}
+}
@@ -0,0 +1,174 @@
+// Package storage - Hash-chained compliance audit records and their delivery queue.
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ComplianceRecord is a signed audit record queued for the compliance endpoint.
+// Payload holds the exact bytes that were hashed and signed.
+type ComplianceRecord struct {
+	Seq         int64           `json:"seq"`
+	TradeID     string          `json:"trade_id"`
+	Checkpoint  string          `json:"checkpoint"`
+	Hash        string          `json:"hash"`
+	Payload     json.RawMessage `json:"payload"`
+	CreatedAt   time.Time       `json:"created_at"`
+	DeliveredAt *time.Time      `json:"delivered_at,omitempty"`
+	Attempts    int             `json:"attempts"`
+	LastError   string          `json:"last_error,omitempty"`
+}
+
+// ComplianceStats summarizes the compliance record queue.
+type ComplianceStats struct {
+	Total   int   `json:"total"`
+	Pending int   `json:"pending"`
+	LastSeq int64 `json:"last_seq"`
+}
+
+// AppendComplianceRecord stores a new record. Seq must be the next number in
+// the chain; a duplicate Seq fails so the chain cannot fork.
+func (s *Storage) AppendComplianceRecord(r *ComplianceRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	_, err := s.db.Exec(`
+		INSERT INTO compliance_records (seq, trade_id, checkpoint, hash, payload, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, r.Seq, r.TradeID, r.Checkpoint, r.Hash, string(r.Payload), r.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to append compliance record: %w", err)
+	}
+	return nil
+}
+
+// LastComplianceRecord returns the head of the chain, or nil if it is empty.
+func (s *Storage) LastComplianceRecord() (*ComplianceRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	r, err := scanComplianceRecord(s.db.QueryRow(`
+		SELECT seq, trade_id, checkpoint, hash, payload, created_at, delivered_at, attempts, last_error
+		FROM compliance_records ORDER BY seq DESC LIMIT 1
+	`))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last compliance record: %w", err)
+	}
+	return r, nil
+}
+
+// PendingComplianceRecords returns undelivered records in chain order.
+func (s *Storage) PendingComplianceRecords(limit int) ([]*ComplianceRecord, error) {
+	return s.queryComplianceRecords(`
+		SELECT seq, trade_id, checkpoint, hash, payload, created_at, delivered_at, attempts, last_error
+		FROM compliance_records WHERE delivered_at IS NULL ORDER BY seq ASC LIMIT ?
+	`, limit)
+}
+
+// ListComplianceRecords returns records after afterSeq in chain order,
+// optionally limited to one trade.
+func (s *Storage) ListComplianceRecords(tradeID string, afterSeq int64, limit int) ([]*ComplianceRecord, error) {
+	query := `
+		SELECT seq, trade_id, checkpoint, hash, payload, created_at, delivered_at, attempts, last_error
+		FROM compliance_records WHERE seq > ?`
+	args := []interface{}{afterSeq}
+	if tradeID != "" {
+		query += " AND trade_id = ?"
+		args = append(args, tradeID)
+	}
+	query += " ORDER BY seq ASC LIMIT ?"
+	args = append(args, limit)
+	return s.queryComplianceRecords(query, args...)
+}
+
+// MarkComplianceRecordDelivered records a successful delivery.
+func (s *Storage) MarkComplianceRecordDelivered(seq int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		UPDATE compliance_records
+		SET delivered_at = ?, attempts = attempts + 1, last_error = ''
+		WHERE seq = ?
+	`, time.Now().Unix(), seq)
+	return err
+}
+
+// MarkComplianceRecordFailed records a failed delivery attempt.
+func (s *Storage) MarkComplianceRecordFailed(seq int64, errMsg string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		UPDATE compliance_records
+		SET attempts = attempts + 1, last_error = ?
+		WHERE seq = ?
+	`, errMsg, seq)
+	return err
+}
+
+// GetComplianceStats returns queue totals.
+func (s *Storage) GetComplianceStats() (*ComplianceStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var stats ComplianceStats
+	err := s.db.QueryRow(`
+		SELECT COUNT(*),
+		       COUNT(*) - COUNT(delivered_at),
+		       COALESCE(MAX(seq), 0)
+		FROM compliance_records
+	`).Scan(&stats.Total, &stats.Pending, &stats.LastSeq)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get compliance stats: %w", err)
+	}
+	return &stats, nil
+}
+
+func (s *Storage) queryComplianceRecords(query string, args ...interface{}) ([]*ComplianceRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query compliance records: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*ComplianceRecord{}
+	for rows.Next() {
+		r, err := scanComplianceRecord(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan compliance record: %w", err)
+		}
+		records = append(records, r)
+	}
+	return records, rows.Err()
+}
+
+func scanComplianceRecord(row interface{ Scan(...interface{}) error }) (*ComplianceRecord, error) {
+	var r ComplianceRecord
+	var payload string
+	var createdAt int64
+	var deliveredAt sql.NullInt64
+	if err := row.Scan(&r.Seq, &r.TradeID, &r.Checkpoint, &r.Hash, &payload,
+		&createdAt, &deliveredAt, &r.Attempts, &r.LastError); err != nil {
+		return nil, err
+	}
+	r.Payload = json.RawMessage(payload)
+	r.CreatedAt = time.Unix(createdAt, 0)
+	if deliveredAt.Valid {
+		t := time.Unix(deliveredAt.Int64, 0)
+		r.DeliveredAt = &t
+	}
+	return &r, nil
+}
@@ -0,0 +1,69 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+)
+
+func TestComplianceRecords(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	last, err := store.LastComplianceRecord()
+	if err != nil || last != nil {
+		t.Fatalf("LastComplianceRecord() on empty chain = %v, %v", last, err)
+	}
+
+	for i := int64(1); i <= 3; i++ {
+		r := &ComplianceRecord{
+			Seq:        i,
+			TradeID:    fmt.Sprintf("t%d", (i+1)/2),
+			Checkpoint: "started",
+			Hash:       fmt.Sprintf("hash%d", i),
+			Payload:    json.RawMessage(fmt.Sprintf(`{"seq":%d}`, i)),
+		}
+		if err := store.AppendComplianceRecord(r); err != nil {
+			t.Fatalf("AppendComplianceRecord(%d) error = %v", i, err)
+		}
+	}
+	if err := store.AppendComplianceRecord(&ComplianceRecord{Seq: 2, Payload: json.RawMessage(`{}`)}); err == nil {
+		t.Error("AppendComplianceRecord() should reject a duplicate seq")
+	}
+
+	last, err = store.LastComplianceRecord()
+	if err != nil || last.Seq != 3 || last.Hash != "hash3" || string(last.Payload) != `{"seq":3}` {
+		t.Fatalf("LastComplianceRecord() = %+v, %v", last, err)
+	}
+
+	if err := store.MarkComplianceRecordFailed(1, "connection refused"); err != nil {
+		t.Fatalf("MarkComplianceRecordFailed() error = %v", err)
+	}
+	if err := store.MarkComplianceRecordDelivered(1); err != nil {
+		t.Fatalf("MarkComplianceRecordDelivered() error = %v", err)
+	}
+	if err := store.MarkComplianceRecordFailed(2, "timeout"); err != nil {
+		t.Fatalf("MarkComplianceRecordFailed() error = %v", err)
+	}
+
+	pending, err := store.PendingComplianceRecords(10)
+	if err != nil || len(pending) != 2 || pending[0].Seq != 2 || pending[0].Attempts != 1 || pending[0].LastError != "timeout" {
+		t.Fatalf("PendingComplianceRecords() = %+v, %v", pending, err)
+	}
+
+	list, err := store.ListComplianceRecords("t1", 0, 10)
+	if err != nil || len(list) != 2 {
+		t.Fatalf("ListComplianceRecords(t1) = %d, %v, want 2", len(list), err)
+	}
+	if list[0].DeliveredAt == nil || list[0].Attempts != 2 || list[0].LastError != "" {
+		t.Errorf("delivered record = %+v", list[0])
+	}
+	if list, _ := store.ListComplianceRecords("", 2, 10); len(list) != 1 || list[0].Seq != 3 {
+		t.Errorf("ListComplianceRecords(after 2) = %+v", list)
+	}
+
+	stats, err := store.GetComplianceStats()
+	if err != nil || stats.Total != 3 || stats.Pending != 2 || stats.LastSeq != 3 {
+		t.Errorf("GetComplianceStats() = %+v, %v", stats, err)
+	}
+}
@@ -0,0 +1,290 @@
+// Package storage - Durable coordinator job queue for on-chain side effects.
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+)
+
+// ErrJobNotFound is returned when a coordinator job does not exist.
+var ErrJobNotFound = errors.New("job not found")
+
+// JobStatus represents the status of a coordinator job.
+type JobStatus string
+
+const (
+	JobStatusPending   JobStatus = "pending"   // Persisted, not yet confirmed executed
+	JobStatusBroadcast JobStatus = "broadcast" // Transaction sent, not yet confirmed
+	JobStatusDone      JobStatus = "done"      // Executed successfully
+	JobStatusFailed    JobStatus = "failed"    // Abandoned; may be replaced by a new attempt
+)
+
+// JobKind identifies how a job is executed.
+type JobKind string
+
+const (
+	JobKindBroadcastTx JobKind = "broadcast_tx" // Broadcast a signed raw transaction
+	JobKindEVMCall     JobKind = "evm_call"     // Send an EVM contract call
+)
+
+// Job is a side effect the coordinator decided to perform.
+// It is persisted before execution and marked done afterwards.
+type Job struct {
+	ID         string    `json:"id"`
+	TradeID    string    `json:"trade_id"`
+	Chain      string    `json:"chain"`
+	Kind       JobKind   `json:"kind"`
+	Action     string    `json:"action"`
+	Payload    string    `json:"payload"`
+	ResultTxID string    `json:"result_txid"`
+	Status     JobStatus `json:"status"`
+	Attempts   int       `json:"attempts"`
+	LastError  string    `json:"last_error"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// EnqueueJob persists a job unless one with the same ID is already live.
+// If a pending, broadcast or done job exists it is returned unchanged with created=false,
+// so the first decision wins and a conflicting side effect is never executed.
+// A failed job is replaced by the new one.
+func (s *Storage) EnqueueJob(job *Job) (*Job, bool, error) {
+	if err := chaos.StorageError("enqueue_job"); err != nil {
+		return nil, false, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.getJobUnlocked(job.ID)
+	if err != nil && !errors.Is(err, ErrJobNotFound) {
+		return nil, false, err
+	}
+	if existing != nil && existing.Status != JobStatusFailed {
+		return existing, false, nil
+	}
+
+	now := time.Now()
+	_, err = s.db.Exec(`
+		INSERT INTO coordinator_jobs (
+			id, trade_id, chain, kind, action, payload, result_txid,
+			status, attempts, last_error, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, '', 'pending', 0, '', ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			payload = excluded.payload, result_txid = '', status = 'pending',
+			attempts = 0, last_error = '', updated_at = excluded.updated_at
+	`,
+		job.ID, job.TradeID, job.Chain, job.Kind, job.Action, job.Payload,
+		now.Unix(), now.Unix(),
+	)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to enqueue job: %w", err)
+	}
+
+	stored, err := s.getJobUnlocked(job.ID)
+	if err != nil {
+		return nil, false, err
+	}
+	return stored, true, nil
+}
+
+// GetJob retrieves a coordinator job by ID.
+func (s *Storage) GetJob(id string) (*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getJobUnlocked(id)
+}
+
+func (s *Storage) getJobUnlocked(id string) (*Job, error) {
+	row := s.db.QueryRow(`
+		SELECT id, trade_id, chain, kind, action, payload, result_txid,
+		       status, attempts, last_error, created_at, updated_at
+		FROM coordinator_jobs WHERE id = ?
+	`, id)
+
+	job, err := scanJob(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrJobNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+	return job, nil
+}
+
+// GetPendingJobs returns jobs that were persisted but not confirmed executed.
+func (s *Storage) GetPendingJobs() ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, trade_id, chain, kind, action, payload, result_txid,
+		       status, attempts, last_error, created_at, updated_at
+		FROM coordinator_jobs
+		WHERE status = 'pending'
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pending jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetBroadcastJobs returns jobs whose transaction was sent but has not
+// confirmed yet.
+func (s *Storage) GetBroadcastJobs() ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, trade_id, chain, kind, action, payload, result_txid,
+		       status, attempts, last_error, created_at, updated_at
+		FROM coordinator_jobs
+		WHERE status = 'broadcast'
+		ORDER BY created_at ASC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query broadcast jobs: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// GetJobsByTrade returns all jobs for a trade.
+func (s *Storage) GetJobsByTrade(tradeID string) ([]*Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, trade_id, chain, kind, action, payload, result_txid,
+		       status, attempts, last_error, created_at, updated_at
+		FROM coordinator_jobs
+		WHERE trade_id = ?
+		ORDER BY created_at ASC
+	`, tradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs for trade: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []*Job
+	for rows.Next() {
+		job, err := scanJob(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, rows.Err()
+}
+
+// CompleteJob marks a job as executed with its resulting transaction ID.
+func (s *Storage) CompleteJob(id, txID string) error {
+	if err := chaos.StorageError("complete_job"); err != nil {
+		return err
+	}
+	return s.updateJob(`
+		UPDATE coordinator_jobs
+		SET status = 'done', result_txid = ?, attempts = attempts + 1, last_error = '', updated_at = ?
+		WHERE id = ?
+	`, txID, time.Now().Unix(), id)
+}
+
+// MarkJobBroadcast records that a job's transaction was sent. The job is
+// completed once the transaction confirms.
+func (s *Storage) MarkJobBroadcast(id, txID string) error {
+	if err := chaos.StorageError("complete_job"); err != nil {
+		return err
+	}
+	return s.updateJob(`
+		UPDATE coordinator_jobs
+		SET status = 'broadcast', result_txid = ?, attempts = attempts + 1, last_error = '', updated_at = ?
+		WHERE id = ?
+	`, txID, time.Now().Unix(), id)
+}
+
+// CompleteConfirmedJob marks a sent job as executed once its transaction
+// confirmed.
+func (s *Storage) CompleteConfirmedJob(id string) error {
+	return s.updateJob(`
+		UPDATE coordinator_jobs
+		SET status = 'done', updated_at = ?
+		WHERE id = ?
+	`, time.Now().Unix(), id)
+}
+
+// RecordJobAttempt records a failed execution attempt; the job stays pending.
+func (s *Storage) RecordJobAttempt(id, errMsg string) error {
+	return s.updateJob(`
+		UPDATE coordinator_jobs
+		SET attempts = attempts + 1, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, errMsg, time.Now().Unix(), id)
+}
+
+// FailJob abandons a job so a new decision can replace it.
+func (s *Storage) FailJob(id, errMsg string) error {
+	return s.updateJob(`
+		UPDATE coordinator_jobs
+		SET status = 'failed', last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, errMsg, time.Now().Unix(), id)
+}
+
+// updateJob runs a single-row job update and reports missing jobs.
+func (s *Storage) updateJob(query string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrJobNotFound
+	}
+	return nil
+}
+
+// scanJob scans a job from a row.
+func scanJob(scanner interface{ Scan(...interface{}) error }) (*Job, error) {
+	var job Job
+	var createdAt, updatedAt int64
+	err := scanner.Scan(
+		&job.ID, &job.TradeID, &job.Chain, &job.Kind, &job.Action, &job.Payload,
+		&job.ResultTxID, &job.Status, &job.Attempts, &job.LastError, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	job.CreatedAt = time.Unix(createdAt, 0)
+	job.UpdatedAt = time.Unix(updatedAt, 0)
+	return &job, nil
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestEnqueueJobFirstDecisionWins(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	job, created, err := store.EnqueueJob(&Job{
+		ID: "claim:trade-1:BTC", TradeID: "trade-1", Chain: "BTC",
+		Kind: JobKindBroadcastTx, Action: "claim", Payload: "aa",
+	})
+	if err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if !created || job.Status != JobStatusPending || job.Payload != "aa" {
+		t.Fatalf("EnqueueJob() = %+v, created=%v", job, created)
+	}
+
+	// A second decision for the same side effect must not replace the first
+	job, created, err = store.EnqueueJob(&Job{
+		ID: "claim:trade-1:BTC", TradeID: "trade-1", Chain: "BTC",
+		Kind: JobKindBroadcastTx, Action: "claim", Payload: "bb",
+	})
+	if err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if created || job.Payload != "aa" {
+		t.Errorf("expected existing job with payload aa, got %+v created=%v", job, created)
+	}
+}
+
+func TestJobLifecycle(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	const id = "refund:trade-2:LTC"
+	if _, _, err := store.EnqueueJob(&Job{
+		ID: id, TradeID: "trade-2", Chain: "LTC",
+		Kind: JobKindBroadcastTx, Action: "refund", Payload: "cc",
+	}); err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+
+	if err := store.RecordJobAttempt(id, "backend unavailable"); err != nil {
+		t.Fatalf("RecordJobAttempt() error = %v", err)
+	}
+	pending, err := store.GetPendingJobs()
+	if err != nil {
+		t.Fatalf("GetPendingJobs() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].Attempts != 1 || pending[0].LastError != "backend unavailable" {
+		t.Fatalf("GetPendingJobs() = %+v", pending)
+	}
+
+	if err := store.CompleteJob(id, "txid-1"); err != nil {
+		t.Fatalf("CompleteJob() error = %v", err)
+	}
+	job, err := store.GetJob(id)
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != JobStatusDone || job.ResultTxID != "txid-1" || job.Attempts != 2 || job.LastError != "" {
+		t.Errorf("GetJob() = %+v", job)
+	}
+
+	pending, _ = store.GetPendingJobs()
+	if len(pending) != 0 {
+		t.Errorf("expected no pending jobs, got %d", len(pending))
+	}
+
+	jobs, err := store.GetJobsByTrade("trade-2")
+	if err != nil || len(jobs) != 1 {
+		t.Errorf("GetJobsByTrade() = %d jobs, err=%v", len(jobs), err)
+	}
+}
+
+func TestBroadcastJobLifecycle(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	const id = "claim:trade-3:BTC"
+	if _, _, err := store.EnqueueJob(&Job{
+		ID: id, TradeID: "trade-3", Chain: "BTC",
+		Kind: JobKindBroadcastTx, Action: "claim", Payload: "dd",
+	}); err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if err := store.MarkJobBroadcast(id, "txid-1"); err != nil {
+		t.Fatalf("MarkJobBroadcast() error = %v", err)
+	}
+
+	// Sent jobs are neither pending nor replaced by a new decision
+	if pending, _ := store.GetPendingJobs(); len(pending) != 0 {
+		t.Errorf("expected no pending jobs, got %d", len(pending))
+	}
+	job, created, err := store.EnqueueJob(&Job{
+		ID: id, TradeID: "trade-3", Chain: "BTC",
+		Kind: JobKindBroadcastTx, Action: "claim", Payload: "ee",
+	})
+	if err != nil || created || job.Payload != "dd" {
+		t.Errorf("EnqueueJob() = %+v, %v, %v; want the sent job", job, created, err)
+	}
+	sent, err := store.GetBroadcastJobs()
+	if err != nil {
+		t.Fatalf("GetBroadcastJobs() error = %v", err)
+	}
+	if len(sent) != 1 || sent[0].Status != JobStatusBroadcast || sent[0].ResultTxID != "txid-1" || sent[0].Attempts != 1 {
+		t.Fatalf("GetBroadcastJobs() = %+v", sent)
+	}
+
+	if err := store.CompleteConfirmedJob(id); err != nil {
+		t.Fatalf("CompleteConfirmedJob() error = %v", err)
+	}
+	job, _ = store.GetJob(id)
+	if job.Status != JobStatusDone || job.ResultTxID != "txid-1" || job.Attempts != 1 {
+		t.Errorf("GetJob() = %+v", job)
+	}
+	if sent, _ := store.GetBroadcastJobs(); len(sent) != 0 {
+		t.Errorf("expected no broadcast jobs, got %d", len(sent))
+	}
+}
+
+func TestFailedJobIsReplaced(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	const id = "claim:trade-3:BTC"
+	store.EnqueueJob(&Job{ID: id, TradeID: "trade-3", Chain: "BTC", Kind: JobKindBroadcastTx, Action: "claim", Payload: "old"})
+	if err := store.FailJob(id, "input already spent"); err != nil {
+		t.Fatalf("FailJob() error = %v", err)
+	}
+
+	job, created, err := store.EnqueueJob(&Job{ID: id, TradeID: "trade-3", Chain: "BTC", Kind: JobKindBroadcastTx, Action: "claim", Payload: "new"})
+	if err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if !created || job.Payload != "new" || job.Status != JobStatusPending || job.Attempts != 0 {
+		t.Errorf("expected replaced pending job, got %+v created=%v", job, created)
+	}
+}
+
+func TestJobNotFound(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if _, err := store.GetJob("missing"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("GetJob() error = %v, want ErrJobNotFound", err)
+	}
+	if err := store.CompleteJob("missing", "tx"); !errors.Is(err, ErrJobNotFound) {
+		t.Errorf("CompleteJob() error = %v, want ErrJobNotFound", err)
+	}
+}
@@ -0,0 +1,140 @@
+// Package storage - Audit log of events sent to WebSocket clients.
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EventRecord is an event the node sent to WebSocket clients.
+type EventRecord struct {
+	ID        int64           `json:"id"`
+	Type      string          `json:"type"`
+	TradeID   string          `json:"trade_id,omitempty"`
+	Data      json.RawMessage `json:"data"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// EventFilter selects events from the log. Zero fields match everything.
+type EventFilter struct {
+	Types    []string
+	TradeID  string
+	Since    time.Time // Inclusive
+	Until    time.Time // Inclusive
+	BeforeID int64     // Page through older events
+	Limit    int
+}
+
+// SaveEvent appends an event to the log and sets its ID.
+func (s *Storage) SaveEvent(e *EventRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if e.CreatedAt.IsZero() {
+		e.CreatedAt = time.Now()
+	}
+	data := e.Data
+	if len(data) == 0 {
+		data = json.RawMessage("null")
+	}
+
+	result, err := s.db.Exec(`
+		INSERT INTO event_log (type, trade_id, data, created_at)
+		VALUES (?, ?, ?, ?)
+	`, e.Type, e.TradeID, string(data), e.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save event: %w", err)
+	}
+	e.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// QueryEvents returns matching events, newest first.
+func (s *Storage) QueryEvents(filter EventFilter) ([]*EventRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `SELECT id, type, trade_id, data, created_at FROM event_log WHERE 1=1`
+	args := []interface{}{}
+
+	if len(filter.Types) > 0 {
+		query += " AND type IN (?" + strings.Repeat(", ?", len(filter.Types)-1) + ")"
+		for _, t := range filter.Types {
+			args = append(args, t)
+		}
+	}
+	if filter.TradeID != "" {
+		query += " AND trade_id = ?"
+		args = append(args, filter.TradeID)
+	}
+	if !filter.Since.IsZero() {
+		query += " AND created_at >= ?"
+		args = append(args, filter.Since.Unix())
+	}
+	if !filter.Until.IsZero() {
+		query += " AND created_at <= ?"
+		args = append(args, filter.Until.Unix())
+	}
+	if filter.BeforeID > 0 {
+		query += " AND id < ?"
+		args = append(args, filter.BeforeID)
+	}
+
+	query += " ORDER BY id DESC"
+	if filter.Limit > 0 {
+		query += " LIMIT ?"
+		args = append(args, filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*EventRecord{}
+	for rows.Next() {
+		var e EventRecord
+		var data string
+		var createdAt int64
+		if err := rows.Scan(&e.ID, &e.Type, &e.TradeID, &data, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan event: %w", err)
+		}
+		e.Data = json.RawMessage(data)
+		e.CreatedAt = time.Unix(createdAt, 0)
+		events = append(events, &e)
+	}
+	return events, rows.Err()
+}
+
+// PruneEvents deletes events older than olderThan and, if maxEvents > 0,
+// all but the newest maxEvents. It returns the number of events deleted.
+func (s *Storage) PruneEvents(olderThan time.Time, maxEvents int) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	if !olderThan.IsZero() {
+		result, err := s.db.Exec(`DELETE FROM event_log WHERE created_at < ?`, olderThan.Unix())
+		if err != nil {
+			return 0, fmt.Errorf("failed to prune events: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	if maxEvents > 0 {
+		result, err := s.db.Exec(`
+			DELETE FROM event_log WHERE id <= (
+				SELECT id FROM event_log ORDER BY id DESC LIMIT 1 OFFSET ?
+			)
+		`, maxEvents)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to prune events: %w", err)
+		}
+		n, _ := result.RowsAffected()
+		deleted += n
+	}
+	return deleted, nil
+}
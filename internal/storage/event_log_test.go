@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestEventLog(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	base := time.Unix(1700000000, 0)
+	events := []*EventRecord{
+		{Type: "trade_started", TradeID: "t1", Data: json.RawMessage(`{"trade_id":"t1"}`), CreatedAt: base},
+		{Type: "peer_connected", Data: json.RawMessage(`{"peer_id":"p1"}`), CreatedAt: base.Add(time.Minute)},
+		{Type: "swap_refunded", TradeID: "t1", Data: json.RawMessage(`{"trade_id":"t1"}`), CreatedAt: base.Add(2 * time.Minute)},
+		{Type: "trade_started", TradeID: "t2", CreatedAt: base.Add(3 * time.Minute)},
+	}
+	for _, e := range events {
+		if err := store.SaveEvent(e); err != nil {
+			t.Fatalf("SaveEvent() error = %v", err)
+		}
+		if e.ID == 0 {
+			t.Fatal("SaveEvent() did not set the ID")
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter EventFilter
+		want   []int64
+	}{
+		{"all newest first", EventFilter{}, []int64{4, 3, 2, 1}},
+		{"by trade", EventFilter{TradeID: "t1"}, []int64{3, 1}},
+		{"by types", EventFilter{Types: []string{"trade_started", "peer_connected"}}, []int64{4, 2, 1}},
+		{"time range", EventFilter{Since: base.Add(time.Minute), Until: base.Add(2 * time.Minute)}, []int64{3, 2}},
+		{"page", EventFilter{BeforeID: 3, Limit: 1}, []int64{2}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.QueryEvents(tt.filter)
+			if err != nil {
+				t.Fatalf("QueryEvents() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("QueryEvents() returned %d events, want %d", len(got), len(tt.want))
+			}
+			for i, e := range got {
+				if e.ID != events[tt.want[i]-1].ID {
+					t.Errorf("event %d = %d, want %d", i, e.ID, tt.want[i])
+				}
+			}
+		})
+	}
+
+	got, _ := store.QueryEvents(EventFilter{TradeID: "t2"})
+	if string(got[0].Data) != "null" {
+		t.Errorf("empty data stored as %s, want null", got[0].Data)
+	}
+
+	// Age-based then count-based retention
+	deleted, err := store.PruneEvents(base.Add(time.Minute), 0)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PruneEvents(age) = %d, %v; want 1", deleted, err)
+	}
+	deleted, err = store.PruneEvents(time.Time{}, 2)
+	if err != nil || deleted != 1 {
+		t.Fatalf("PruneEvents(count) = %d, %v; want 1", deleted, err)
+	}
+	remaining, _ := store.QueryEvents(EventFilter{})
+	if len(remaining) != 2 || remaining[0].ID != events[3].ID || remaining[1].ID != events[2].ID {
+		t.Errorf("remaining events = %d, want the 2 newest", len(remaining))
+	}
+}
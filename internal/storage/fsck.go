@@ -0,0 +1,375 @@
+// Package storage - Integrity checking and repair of stored records.
+//
+// Fsck runs SQLite's integrity check and verifies the invariants handlers
+// rely on: swap records carry a known state and parseable method data,
+// orders name their maker and terms, cached UTXOs parse and secrets match
+// their hash. With repair, bad records that can be set aside are moved to the
+// quarantine table (swaps, orders) or deleted when a wallet rescan restores
+// them (UTXOs). Trades and secrets are only reported: they are needed to
+// claim or refund funds and must be fixed by hand.
+package storage
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Fsck repair actions.
+const (
+	FsckActionNone       = ""           // Reported only
+	FsckActionQuarantine = "quarantine" // Moved to the quarantine table
+	FsckActionDelete     = "delete"     // Deleted, restored by a wallet rescan
+)
+
+// FsckIssue is a record that failed a check.
+type FsckIssue struct {
+	Table    string `json:"table"`
+	Key      string `json:"key"`
+	Problem  string `json:"problem"`
+	Action   string `json:"action,omitempty"`
+	Repaired bool   `json:"repaired"`
+}
+
+// FsckReport is the outcome of a storage check.
+type FsckReport struct {
+	Integrity []string       `json:"integrity"` // SQLite integrity check, "ok" when sound
+	Checked   map[string]int `json:"checked"`   // Records checked by table
+	Issues    []*FsckIssue   `json:"issues"`
+	Repaired  int            `json:"repaired"`
+}
+
+// OK reports whether no problem was found.
+func (r *FsckReport) OK() bool {
+	return len(r.Issues) == 0 && len(r.Integrity) == 1 && r.Integrity[0] == "ok"
+}
+
+// QuarantinedRecord is a record set aside by Fsck.
+type QuarantinedRecord struct {
+	ID            int64           `json:"id"`
+	Table         string          `json:"table"`
+	Key           string          `json:"key"`
+	Problem       string          `json:"problem"`
+	Data          json.RawMessage `json:"data"` // The record's columns
+	QuarantinedAt time.Time       `json:"quarantined_at"`
+}
+
+// fsckCheck validates the records of a table. The query selects the rowid
+// and a key identifying the record, then the columns passed to check.
+type fsckCheck struct {
+	table  string
+	query  string
+	action string
+	check  func(cols []sql.NullString) string
+}
+
+var fsckChecks = []fsckCheck{
+	{
+		table: "active_swaps",
+		query: `SELECT rowid, trade_id, state, our_role, offer_chain, request_chain,
+			offer_amount, request_amount, method_data, fee_terms FROM active_swaps`,
+		action: FsckActionQuarantine,
+		check: func(c []sql.NullString) string {
+			switch SwapState(c[0].String) {
+			case SwapStateInit, SwapStateFunding, SwapStateFunded, SwapStateSigning,
+				SwapStateRedeemed, SwapStateRefunded, SwapStateFailed, SwapStateCancelled:
+			default:
+				return fmt.Sprintf("unknown state %q", c[0].String)
+			}
+			if c[1].String != "maker" && c[1].String != "taker" {
+				return fmt.Sprintf("unknown role %q", c[1].String)
+			}
+			if c[2].String == "" || c[3].String == "" {
+				return "missing chain"
+			}
+			if !fsckPositive(c[4]) || !fsckPositive(c[5]) {
+				return "non-positive amount"
+			}
+			if c[6].String != "" && !json.Valid([]byte(c[6].String)) {
+				return "method data is not valid JSON"
+			}
+			if c[7].String != "" && !json.Valid([]byte(c[7].String)) {
+				return "fee terms are not valid JSON"
+			}
+			return ""
+		},
+	},
+	{
+		table: "orders",
+		query: `SELECT rowid, id, peer_id, status, offer_chain, request_chain,
+			offer_amount, request_amount, fee_terms FROM orders`,
+		action: FsckActionQuarantine,
+		check: func(c []sql.NullString) string {
+			if c[0].String == "" {
+				return "missing maker peer"
+			}
+			switch OrderStatus(c[1].String) {
+			case OrderStatusOpen, OrderStatusMatched, OrderStatusCompleted,
+				OrderStatusCancelled, OrderStatusExpired, OrderStatusFailed:
+			default:
+				return fmt.Sprintf("unknown status %q", c[1].String)
+			}
+			if c[2].String == "" || c[3].String == "" {
+				return "missing chain"
+			}
+			if !fsckPositive(c[4]) || !fsckPositive(c[5]) {
+				return "non-positive amount"
+			}
+			if c[6].String != "" && !json.Valid([]byte(c[6].String)) {
+				return "fee terms are not valid JSON"
+			}
+			return ""
+		},
+	},
+	{
+		// Orders may be deleted after their trades, so only the state is fatal
+		table: "trades",
+		query: `SELECT trades.rowid, trades.id, trades.state, trades.maker_peer_id,
+			trades.taker_peer_id, orders.id
+			FROM trades LEFT JOIN orders ON orders.id = trades.order_id`,
+		action: FsckActionNone,
+		check: func(c []sql.NullString) string {
+			switch TradeState(c[0].String) {
+			case TradeStateInit, TradeStateAccepted, TradeStateFunding, TradeStateFunded,
+				TradeStateRedeemed, TradeStateRefunded, TradeStateFailed, TradeStateAborted:
+			default:
+				return fmt.Sprintf("unknown state %q", c[0].String)
+			}
+			if c[1].String == "" || c[2].String == "" {
+				return "missing maker or taker peer"
+			}
+			if !c[3].Valid {
+				return "order not found"
+			}
+			return ""
+		},
+	},
+	{
+		table: "wallet_utxos",
+		query: `SELECT rowid, txid || ':' || vout, txid, amount, status, script_pubkey
+			FROM wallet_utxos`,
+		action: FsckActionDelete,
+		check: func(c []sql.NullString) string {
+			if !fsckHex32(c[0].String) {
+				return "invalid txid"
+			}
+			if !fsckPositive(c[1]) {
+				return "non-positive amount"
+			}
+			switch UTXOStatus(c[2].String) {
+			case UTXOStatusUnconfirmed, UTXOStatusConfirmed, UTXOStatusPendingSpend, UTXOStatusSpent:
+			default:
+				return fmt.Sprintf("unknown status %q", c[2].String)
+			}
+			if _, err := hex.DecodeString(c[3].String); err != nil {
+				return "script is not hex"
+			}
+			return ""
+		},
+	},
+	{
+		table:  "secrets",
+		query:  `SELECT rowid, id, secret_hash, secret, created_by FROM secrets`,
+		action: FsckActionNone,
+		check: func(c []sql.NullString) string {
+			if !fsckHex32(c[0].String) {
+				return "invalid secret hash"
+			}
+			if c[1].String != "" {
+				preimage, err := hex.DecodeString(c[1].String)
+				if err != nil || len(preimage) != 32 {
+					return "secret does not decode"
+				}
+				if sum := sha256.Sum256(preimage); !strings.EqualFold(hex.EncodeToString(sum[:]), c[0].String) {
+					return "secret does not match its hash"
+				}
+			}
+			if c[2].String != string(SecretCreatorUs) && c[2].String != string(SecretCreatorThem) {
+				return fmt.Sprintf("unknown creator %q", c[2].String)
+			}
+			return ""
+		},
+	},
+}
+
+// fsckPositive reports whether an integer column is greater than zero.
+func fsckPositive(v sql.NullString) bool {
+	n, err := strconv.ParseInt(v.String, 10, 64)
+	return err == nil && n > 0
+}
+
+// fsckHex32 reports whether s is 32 bytes of hex.
+func fsckHex32(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) == 32
+}
+
+// Fsck checks the database and its records. With repair, bad records are
+// quarantined or deleted according to their table.
+func (s *Storage) Fsck(repair bool) (*FsckReport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	report := &FsckReport{Integrity: []string{}, Checked: make(map[string]int), Issues: []*FsckIssue{}}
+	rows, err := s.db.Query("PRAGMA integrity_check")
+	if err != nil {
+		return nil, fmt.Errorf("failed to run integrity check: %w", err)
+	}
+	for rows.Next() {
+		var line string
+		if err := rows.Scan(&line); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		report.Integrity = append(report.Integrity, line)
+	}
+	rows.Close()
+
+	for _, c := range fsckChecks {
+		issues, rowids, checked, err := s.runFsckCheck(c)
+		if err != nil {
+			return nil, err
+		}
+		report.Checked[c.table] = checked
+		for i, issue := range issues {
+			if repair && issue.Action != FsckActionNone {
+				if err := s.repairRecord(issue, rowids[i]); err != nil {
+					return nil, err
+				}
+				issue.Repaired = true
+				report.Repaired++
+			}
+			report.Issues = append(report.Issues, issue)
+		}
+	}
+	return report, nil
+}
+
+// runFsckCheck returns the failing records of a check with their rowids,
+// and how many records were checked.
+func (s *Storage) runFsckCheck(c fsckCheck) ([]*FsckIssue, []int64, int, error) {
+	rows, err := s.db.Query(c.query)
+	if err != nil {
+		return nil, nil, 0, fmt.Errorf("failed to check %s: %w", c.table, err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var issues []*FsckIssue
+	var rowids []int64
+	checked := 0
+	for rows.Next() {
+		var rowid int64
+		vals := make([]sql.NullString, len(columns)-1)
+		dest := []interface{}{&rowid}
+		for i := range vals {
+			dest = append(dest, &vals[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, nil, 0, fmt.Errorf("failed to scan %s: %w", c.table, err)
+		}
+		checked++
+		if problem := c.check(vals[1:]); problem != "" {
+			issues = append(issues, &FsckIssue{Table: c.table, Key: vals[0].String, Problem: problem, Action: c.action})
+			rowids = append(rowids, rowid)
+		}
+	}
+	return issues, rowids, checked, rows.Err()
+}
+
+// repairRecord quarantines or deletes a bad record.
+func (s *Storage) repairRecord(issue *FsckIssue, rowid int64) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if issue.Action == FsckActionQuarantine {
+		data, err := recordJSON(tx, issue.Table, rowid)
+		if err != nil {
+			return fmt.Errorf("failed to read %s %s: %w", issue.Table, issue.Key, err)
+		}
+		if _, err := tx.Exec(`
+			INSERT INTO quarantine (table_name, record_key, problem, data, quarantined_at)
+			VALUES (?, ?, ?, ?, ?)`,
+			issue.Table, issue.Key, issue.Problem, string(data), time.Now().Unix(),
+		); err != nil {
+			return fmt.Errorf("failed to quarantine %s %s: %w", issue.Table, issue.Key, err)
+		}
+	}
+	// The table name comes from fsckChecks, never from input
+	if _, err := tx.Exec("DELETE FROM "+issue.Table+" WHERE rowid = ?", rowid); err != nil {
+		return fmt.Errorf("failed to remove %s %s: %w", issue.Table, issue.Key, err)
+	}
+	return tx.Commit()
+}
+
+// recordJSON returns a record's columns as a JSON object.
+func recordJSON(tx *sql.Tx, table string, rowid int64) ([]byte, error) {
+	rows, err := tx.Query("SELECT * FROM "+table+" WHERE rowid = ?", rowid)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+	if !rows.Next() {
+		return nil, sql.ErrNoRows
+	}
+	vals := make([]interface{}, len(columns))
+	dest := make([]interface{}, len(columns))
+	for i := range vals {
+		dest[i] = &vals[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, err
+	}
+	record := make(map[string]interface{}, len(columns))
+	for i, col := range columns {
+		if b, ok := vals[i].([]byte); ok {
+			vals[i] = string(b)
+		}
+		record[col] = vals[i]
+	}
+	return json.Marshal(record)
+}
+
+// ListQuarantined returns the quarantined records, newest first.
+func (s *Storage) ListQuarantined() ([]*QuarantinedRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, table_name, record_key, problem, data, quarantined_at
+		FROM quarantine ORDER BY id DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantine: %w", err)
+	}
+	defer rows.Close()
+
+	records := []*QuarantinedRecord{}
+	for rows.Next() {
+		var r QuarantinedRecord
+		var data string
+		var at int64
+		if err := rows.Scan(&r.ID, &r.Table, &r.Key, &r.Problem, &data, &at); err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined record: %w", err)
+		}
+		r.Data = json.RawMessage(data)
+		r.QuarantinedAt = time.Unix(at, 0)
+		records = append(records, &r)
+	}
+	return records, rows.Err()
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFsck(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now()
+	for _, id := range []string{"o1", "o2"} {
+		if err := store.CreateOrder(&Order{ID: id, PeerID: "maker", Status: OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: now}); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+	for _, id := range []string{"t1", "t2"} {
+		if err := store.CreateTrade(&Trade{ID: id, OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "taker", OurRole: TradeRoleMaker, Method: "htlc", State: TradeStateInit, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, CreatedAt: now}); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+		if err := store.SaveSwap(&SwapRecord{TradeID: id, OrderID: "o1", MakerPeerID: "maker", TakerPeerID: "taker", OurRole: "maker", IsMaker: true, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, State: SwapStateInit, MethodData: json.RawMessage(`{}`), CreatedAt: now, UpdatedAt: now}); err != nil {
+			t.Fatalf("SaveSwap() error = %v", err)
+		}
+	}
+	txid := strings.Repeat("ab", 32)
+	for vout := uint32(0); vout < 2; vout++ {
+		if err := store.SaveWalletUTXO(&WalletUTXO{TxID: txid, Vout: vout, Amount: 1000, Address: "bc1qmine", Chain: "BTC", Status: UTXOStatusConfirmed}); err != nil {
+			t.Fatalf("SaveWalletUTXO() error = %v", err)
+		}
+	}
+	preimage := strings.Repeat("01", 32)
+	sum := sha256.Sum256([]byte{1})
+	for i, hash := range []string{hashHex(preimage), hex.EncodeToString(sum[:])} {
+		if err := store.CreateSecret(&Secret{ID: "s" + string(rune('1'+i)), TradeID: "t1", SecretHash: hash, Secret: preimage, CreatedBy: SecretCreatorUs, CreatedAt: now}); err != nil {
+			t.Fatalf("CreateSecret() error = %v", err)
+		}
+	}
+
+	report, err := store.Fsck(false)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+	// s2's preimage doesn't match its hash
+	if len(report.Issues) != 1 || report.Issues[0].Key != "s2" {
+		t.Fatalf("sound records: issues = %+v", report.Issues)
+	}
+	if report.Checked["active_swaps"] != 2 || report.Checked["wallet_utxos"] != 2 || report.Integrity[0] != "ok" {
+		t.Errorf("report = %+v", report)
+	}
+
+	// Corrupt one record of each table
+	for _, q := range []string{
+		"UPDATE active_swaps SET method_data = '{broken' WHERE trade_id = 't2'",
+		"UPDATE orders SET peer_id = '' WHERE id = 'o2'",
+		"UPDATE trades SET order_id = 'gone' WHERE id = 't2'",
+		"UPDATE wallet_utxos SET txid = 'zz' WHERE vout = 1",
+	} {
+		if _, err := store.db.Exec(q); err != nil {
+			t.Fatalf("%s: %v", q, err)
+		}
+	}
+
+	report, err = store.Fsck(false)
+	if err != nil {
+		t.Fatalf("Fsck() error = %v", err)
+	}
+	want := map[string]string{
+		"active_swaps": FsckActionQuarantine,
+		"orders":       FsckActionQuarantine,
+		"trades":       FsckActionNone,
+		"wallet_utxos": FsckActionDelete,
+		"secrets":      FsckActionNone,
+	}
+	if len(report.Issues) != len(want) || report.Repaired != 0 || report.OK() {
+		t.Fatalf("issues = %d, repaired = %d, want %d found, none repaired", len(report.Issues), report.Repaired, len(want))
+	}
+	for _, issue := range report.Issues {
+		if action, ok := want[issue.Table]; !ok || issue.Action != action || issue.Repaired {
+			t.Errorf("issue = %+v", issue)
+		}
+	}
+	if _, err := store.GetSwap("t2"); err != nil {
+		t.Error("check without repair should change nothing")
+	}
+
+	report, err = store.Fsck(true)
+	if err != nil {
+		t.Fatalf("Fsck(repair) error = %v", err)
+	}
+	if report.Repaired != 3 {
+		t.Errorf("Repaired = %d, want 3", report.Repaired)
+	}
+	if _, err := store.GetSwap("t2"); err == nil {
+		t.Error("corrupt swap still active")
+	}
+	if _, err := store.GetSwap("t1"); err != nil {
+		t.Errorf("sound swap removed: %v", err)
+	}
+	if utxos, _ := store.GetAllUTXOs("BTC"); len(utxos) != 1 {
+		t.Errorf("UTXOs = %d, want 1", len(utxos))
+	}
+
+	quarantined, err := store.ListQuarantined()
+	if err != nil {
+		t.Fatalf("ListQuarantined() error = %v", err)
+	}
+	if len(quarantined) != 2 || quarantined[0].Table != "orders" || quarantined[1].Key != "t2" {
+		t.Fatalf("quarantined = %+v", quarantined)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(quarantined[1].Data, &data); err != nil || data["method_data"] != "{broken" {
+		t.Errorf("quarantined data = %s", quarantined[1].Data)
+	}
+
+	// Only the records to fix by hand remain
+	report, _ = store.Fsck(true)
+	if len(report.Issues) != 2 || report.Repaired != 0 {
+		t.Errorf("after repair: issues = %+v", report.Issues)
+	}
+}
+
+func hashHex(preimageHex string) string {
+	b, _ := hex.DecodeString(preimageHex)
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
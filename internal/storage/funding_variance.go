@@ -0,0 +1,42 @@
+// Package storage - Escrow top-up deadlines of funding variance checks.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SaveTopUpDeadline stores the time by which the counterparty of a trade must
+// re-fund its escrow in full.
+func (s *Storage) SaveTopUpDeadline(tradeID string, deadline time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO funding_variance_top_ups (trade_id, deadline)
+		VALUES (?, ?)
+		ON CONFLICT(trade_id) DO UPDATE SET deadline = excluded.deadline
+	`, tradeID, deadline.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save top-up deadline: %w", err)
+	}
+	return nil
+}
+
+// GetTopUpDeadline returns a trade's top-up deadline, or the zero time if it
+// has none.
+func (s *Storage) GetTopUpDeadline(tradeID string) (time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var deadline int64
+	err := s.db.QueryRow(`SELECT deadline FROM funding_variance_top_ups WHERE trade_id = ?`, tradeID).Scan(&deadline)
+	if err == sql.ErrNoRows {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get top-up deadline: %w", err)
+	}
+	return time.Unix(deadline, 0), nil
+}
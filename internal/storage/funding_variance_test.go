@@ -0,0 +1,25 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTopUpDeadlines(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if got, err := store.GetTopUpDeadline("trade-a"); err != nil || !got.IsZero() {
+		t.Fatalf("GetTopUpDeadline(missing) = %v, %v, want zero", got, err)
+	}
+
+	deadline := time.Unix(1700000000, 0)
+	for _, d := range []time.Time{deadline.Add(-time.Hour), deadline} {
+		if err := store.SaveTopUpDeadline("trade-a", d); err != nil {
+			t.Fatalf("SaveTopUpDeadline() error = %v", err)
+		}
+	}
+	if got, err := store.GetTopUpDeadline("trade-a"); err != nil || !got.Equal(deadline) {
+		t.Errorf("GetTopUpDeadline() = %v, %v, want %v", got, err, deadline)
+	}
+}
@@ -0,0 +1,167 @@
+// Package storage - Merging trade and order history replicated from the
+// user's other nodes.
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// UpdatedUnix returns when the trade last changed (its creation if never updated).
+func (t *Trade) UpdatedUnix() int64 {
+	if t.UpdatedAt != nil {
+		return t.UpdatedAt.Unix()
+	}
+	return t.CreatedAt.Unix()
+}
+
+// UpdatedUnix returns when the order last changed (its creation if never updated).
+func (o *Order) UpdatedUnix() int64 {
+	if o.UpdatedAt != nil {
+		return o.UpdatedAt.Unix()
+	}
+	return o.CreatedAt.Unix()
+}
+
+// MergeTrade stores a trade replicated from another node. An existing trade
+// is replaced only if the incoming copy was updated later, so the most
+// recent record wins. Returns true if the trade was written.
+func (s *Storage) MergeTrade(trade *Trade) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, err := s.db.Exec(`
+		INSERT INTO trades (
+			id, order_id, maker_peer_id, taker_peer_id, maker_pubkey, taker_pubkey,
+			our_role, method, state,
+			offer_chain, offer_amount, request_chain, request_amount,
+			created_at, updated_at, completed_at, failure_reason, payout_address, fee_adjustment
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			maker_pubkey = excluded.maker_pubkey,
+			taker_pubkey = excluded.taker_pubkey,
+			state = excluded.state,
+			updated_at = excluded.updated_at,
+			completed_at = excluded.completed_at,
+			failure_reason = excluded.failure_reason,
+			payout_address = excluded.payout_address,
+			fee_adjustment = excluded.fee_adjustment
+		WHERE COALESCE(excluded.updated_at, excluded.created_at) > COALESCE(trades.updated_at, trades.created_at)
+	`,
+		trade.ID, trade.OrderID, trade.MakerPeerID, trade.TakerPeerID,
+		nullString(trade.MakerPubKey), nullString(trade.TakerPubKey),
+		trade.OurRole, trade.Method, trade.State,
+		trade.OfferChain, trade.OfferAmount,
+		trade.RequestChain, trade.RequestAmount,
+		trade.CreatedAt.Unix(), unixOrNull(trade.UpdatedAt), unixOrNull(trade.CompletedAt),
+		nullString(trade.FailureReason), trade.PayoutAddress, trade.FeeAdjustment,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to merge trade: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// MergeOrder stores an order replicated from another node, replacing an
+// existing one only if the incoming copy was updated later. Returns true if
+// the order was written.
+func (s *Storage) MergeOrder(order *Order) (bool, error) {
+	defer s.orderChanged([]string{order.ID})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	methodsJSON, err := json.Marshal(order.PreferredMethods)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal preferred methods: %w", err)
+	}
+
+	isLocal := 0
+	if order.IsLocal {
+		isLocal = 1
+	}
+
+	res, err := s.db.Exec(`
+		INSERT INTO orders (
+			id, peer_id, status, offer_chain, offer_amount,
+			request_chain, request_amount, preferred_methods,
+			created_at, expires_at, updated_at, is_local, signature, fee_terms, identity,
+			offer_token, request_token, settlement, filled_amount, min_fill_amount, fill_seq
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			expires_at = excluded.expires_at,
+			updated_at = excluded.updated_at,
+			filled_amount = `+mergeFilledAmount+`,
+			fill_seq = MAX(orders.fill_seq, excluded.fill_seq)
+		WHERE COALESCE(excluded.updated_at, excluded.created_at) > COALESCE(orders.updated_at, orders.created_at)
+	`,
+		order.ID, order.PeerID, order.Status,
+		order.OfferChain, order.OfferAmount,
+		order.RequestChain, order.RequestAmount,
+		string(methodsJSON),
+		order.CreatedAt.Unix(), unixOrNull(order.ExpiresAt), unixOrNull(order.UpdatedAt),
+		isLocal, order.Signature, order.FeeTerms, order.Identity,
+		order.OfferToken, order.RequestToken, strings.Join(order.Settlement, ","),
+		order.FilledAmount, order.MinFillAmount, order.FillSeq,
+	)
+	if err != nil {
+		return false, fmt.Errorf("failed to merge order: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+	return n > 0, nil
+}
+
+// GetSetting returns a value from the settings table, or "" if unset.
+func (s *Storage) GetSetting(key string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var value sql.NullString
+	err := s.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get setting: %w", err)
+	}
+	return value.String, nil
+}
+
+// SetSetting stores a value in the settings table.
+func (s *Storage) SetSetting(key, value string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+	`, key, value, time.Now().Unix())
+	if err != nil {
+		return fmt.Errorf("failed to set setting: %w", err)
+	}
+	return nil
+}
+
+// unixOrNull converts an optional time to a nullable Unix timestamp.
+func unixOrNull(t *time.Time) *int64 {
+	if t == nil {
+		return nil
+	}
+	ts := t.Unix()
+	return &ts
+}
+
+// nullString stores empty strings as NULL.
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
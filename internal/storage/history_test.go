@@ -0,0 +1,136 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeTrade(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	created := time.Unix(1700000000, 0)
+	updated := created.Add(time.Hour)
+	trade := &Trade{
+		ID: "trade-1", OrderID: "order-1", MakerPeerID: "12D3KooWMaker", TakerPeerID: "12D3KooWTaker",
+		OurRole: TradeRoleMaker, Method: "htlc", State: TradeStateRedeemed,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000,
+		CreatedAt: created, UpdatedAt: &updated, CompletedAt: &updated, MakerPubKey: "02aa",
+	}
+	if written, err := store.MergeTrade(trade); err != nil || !written {
+		t.Fatalf("MergeTrade() new = %v, %v, want written", written, err)
+	}
+	got, err := store.GetTrade("trade-1")
+	if err != nil {
+		t.Fatalf("GetTrade() error = %v", err)
+	}
+	if got.State != TradeStateRedeemed || got.UpdatedUnix() != updated.Unix() || got.MakerPubKey != "02aa" || got.TakerPubKey != "" {
+		t.Errorf("merged trade = %+v", got)
+	}
+
+	// An older or equally old copy loses
+	stale := *trade
+	stale.State = TradeStateFailed
+	if written, err := store.MergeTrade(&stale); err != nil || written {
+		t.Errorf("MergeTrade() same age = %v, %v, want kept", written, err)
+	}
+
+	// A newer copy wins
+	later := updated.Add(time.Minute)
+	newer := *trade
+	newer.State = TradeStateRefunded
+	newer.UpdatedAt = &later
+	if written, err := store.MergeTrade(&newer); err != nil || !written {
+		t.Fatalf("MergeTrade() newer = %v, %v, want written", written, err)
+	}
+	if got, _ := store.GetTrade("trade-1"); got.State != TradeStateRefunded {
+		t.Errorf("state = %s, want refunded", got.State)
+	}
+}
+
+func TestTradeHistoryFilter(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	base := time.Unix(1700000000, 0)
+	for i, state := range []TradeState{TradeStateRedeemed, TradeStateFunded, TradeStateAborted, TradeStateRefunded} {
+		updated := base.Add(time.Duration(3-i) * time.Minute)
+		trade := &Trade{
+			ID: string(rune('a' + i)), OrderID: "order", OurRole: TradeRoleTaker, Method: "htlc", State: state,
+			OfferChain: "BTC", RequestChain: "LTC", CreatedAt: base, UpdatedAt: &updated,
+		}
+		if _, err := store.MergeTrade(trade); err != nil {
+			t.Fatalf("MergeTrade() error = %v", err)
+		}
+	}
+
+	since := base.Add(time.Minute).Unix()
+	trades, err := store.ListTrades(TradeFilter{Terminal: true, UpdatedSince: &since})
+	if err != nil {
+		t.Fatalf("ListTrades() error = %v", err)
+	}
+	// Terminal trades updated since, oldest update first: c (+1m), a (+3m)
+	if len(trades) != 2 || trades[0].ID != "c" || trades[1].ID != "a" {
+		t.Errorf("history = %v, want [c a]", tradeIDs(trades))
+	}
+}
+
+func TestMergeOrder(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	created := time.Unix(1700000000, 0)
+	order := &Order{
+		ID: "order-1", PeerID: "12D3KooWMaker", Status: OrderStatusCompleted, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000,
+		PreferredMethods: []string{"htlc"}, CreatedAt: created,
+	}
+	if written, err := store.MergeOrder(order); err != nil || !written {
+		t.Fatalf("MergeOrder() new = %v, %v, want written", written, err)
+	}
+
+	updated := created.Add(time.Hour)
+	cancelled := *order
+	cancelled.Status = OrderStatusCancelled
+	cancelled.UpdatedAt = &updated
+	if written, err := store.MergeOrder(&cancelled); err != nil || !written {
+		t.Fatalf("MergeOrder() newer = %v, %v, want written", written, err)
+	}
+	if written, _ := store.MergeOrder(order); written {
+		t.Error("older order replaced a newer one")
+	}
+
+	since := created.Unix()
+	orders, err := store.ListOrders(OrderFilter{Terminal: true, UpdatedSince: &since})
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("ListOrders() = %d orders, %v", len(orders), err)
+	}
+	if orders[0].Status != OrderStatusCancelled || !orders[0].IsLocal || orders[0].UpdatedUnix() != updated.Unix() {
+		t.Errorf("merged order = %+v", orders[0])
+	}
+}
+
+func TestSettings(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if v, err := store.GetSetting("missing"); err != nil || v != "" {
+		t.Errorf("GetSetting() unset = %q, %v", v, err)
+	}
+	for _, v := range []string{"one", "two"} {
+		if err := store.SetSetting("key", v); err != nil {
+			t.Fatalf("SetSetting() error = %v", err)
+		}
+	}
+	if v, _ := store.GetSetting("key"); v != "two" {
+		t.Errorf("GetSetting() = %q, want two", v)
+	}
+}
+
+func tradeIDs(trades []*Trade) []string {
+	ids := make([]string, len(trades))
+	for i, t := range trades {
+		ids[i] = t.ID
+	}
+	return ids
+}
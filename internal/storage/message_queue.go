@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
 )
 
 // =============================================================================
@@ -73,6 +75,9 @@ type MessageSequence struct {
 
 // EnqueueMessage adds a message to the outbox for delivery.
 func (s *Storage) EnqueueMessage(msg *OutboxMessage) error {
+	if err := chaos.StorageError("enqueue_message"); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -6,6 +6,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,8 @@ import (
 var (
 	ErrOrderNotFound = errors.New("order not found")
 	ErrOrderExpired  = errors.New("order expired")
+	ErrOrderFilled   = errors.New("order has no remaining amount")
+	ErrInvalidFill   = errors.New("invalid fill amount")
 )
 
 // OrderStatus represents the status of an order.
@@ -41,20 +44,121 @@ type Order struct {
 	RequestChain  string
 	RequestAmount uint64
 
+	// FilledAmount is the part of OfferAmount taken by trades so far
+	FilledAmount uint64
+
+	// MinFillAmount is the smallest partial fill the maker accepts, in
+	// offer units (0 = the order can only be taken whole)
+	MinFillAmount uint64
+
+	// FillSeq counts the maker's changes to FilledAmount. A release lowers
+	// the fill, so peers apply the announcement with the higher sequence
+	// rather than the larger fill (0 = no fill announced with a sequence)
+	FillSeq uint64
+
+	// ERC-20 contract addresses of the offered and requested tokens
+	// (empty = the chain's native coin)
+	OfferToken   string
+	RequestToken string
+
 	// Preferred swap methods in priority order
 	PreferredMethods []string
 
+	// Settlement lists the maker's alternative ways of settling the request
+	// leg, e.g. "lightning" (empty = on chain only)
+	Settlement []string
+
+	// FeeTerms is the JSON-encoded negotiated fee split (empty = protocol defaults)
+	FeeTerms string
+
 	// Timing
 	CreatedAt time.Time
 	ExpiresAt *time.Time
 	UpdatedAt *time.Time
 
-	// Ownership proof
+	// Ownership proof: signature by the maker's wallet identity key
 	Signature string
+
+	// Identity is the JSON-encoded maker identity key and receive address
+	// proofs the signature covers (empty = unsigned order)
+	Identity string
+}
+
+// Remaining returns the offer amount still available to takers.
+func (o *Order) Remaining() uint64 {
+	if o.FilledAmount >= o.OfferAmount {
+		return 0
+	}
+	return o.OfferAmount - o.FilledAmount
+}
+
+// IsNewerFill reports whether a maker's announcement of filled offer at
+// fill sequence seq supersedes the fill recorded for o. Announcements
+// without a sequence, from makers that never release fills, only grow it.
+func (o *Order) IsNewerFill(filled, seq uint64) bool {
+	if seq == 0 {
+		return o.FillSeq == 0 && filled > o.FilledAmount
+	}
+	return seq > o.FillSeq
+}
+
+// mergeFilledAmount is the filled amount of an order upserted over a stored
+// copy: that with the higher fill sequence, the larger at equal sequences.
+const mergeFilledAmount = `CASE
+				WHEN excluded.fill_seq > orders.fill_seq THEN excluded.filled_amount
+				WHEN excluded.fill_seq < orders.fill_seq THEN orders.filled_amount
+				ELSE MAX(orders.filled_amount, excluded.filled_amount)
+			END`
+
+// CheckFill checks that a taker may take amount of the offer: all of the
+// remaining amount, or, when the order allows partial fills, at least
+// MinFillAmount of it.
+func (o *Order) CheckFill(amount uint64) error {
+	remaining := o.Remaining()
+	if o.Status != OrderStatusOpen || remaining == 0 {
+		return fmt.Errorf("%w: status %s", ErrOrderFilled, o.Status)
+	}
+	switch {
+	case amount == 0:
+		return fmt.Errorf("%w: zero", ErrInvalidFill)
+	case amount > remaining:
+		return fmt.Errorf("%w: %d exceeds the remaining %d", ErrInvalidFill, amount, remaining)
+	case amount == remaining:
+		return nil
+	case o.MinFillAmount == 0:
+		return fmt.Errorf("%w: the order can only be taken whole (%d)", ErrInvalidFill, remaining)
+	case amount < o.MinFillAmount:
+		return fmt.Errorf("%w: %d is below the minimum fill %d", ErrInvalidFill, amount, o.MinFillAmount)
+	}
+	return nil
+}
+
+// OrderChangeFunc is called after orders were written, with their IDs. Nil
+// IDs mean any order may have changed.
+type OrderChangeFunc func(ids []string)
+
+// OnOrderChange registers fn to be called after every order write, whether
+// it succeeded or not. fn runs on the writer's goroutine without the
+// storage lock held.
+func (s *Storage) OnOrderChange(fn OrderChangeFunc) {
+	s.hookMu.Lock()
+	defer s.hookMu.Unlock()
+	s.orderHooks = append(s.orderHooks, fn)
+}
+
+// orderChanged calls the order change hooks.
+func (s *Storage) orderChanged(ids []string) {
+	s.hookMu.RLock()
+	hooks := s.orderHooks
+	s.hookMu.RUnlock()
+	for _, fn := range hooks {
+		fn(ids)
+	}
 }
 
 // CreateOrder creates a new order in the database.
 func (s *Storage) CreateOrder(order *Order) error {
+	defer s.orderChanged([]string{order.ID}) // After the unlock, hooks may read orders
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -78,15 +182,18 @@ func (s *Storage) CreateOrder(order *Order) error {
 		INSERT INTO orders (
 			id, peer_id, status, offer_chain, offer_amount,
 			request_chain, request_amount, preferred_methods,
-			created_at, expires_at, is_local, signature
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			created_at, expires_at, is_local, signature, fee_terms, identity,
+			offer_token, request_token, settlement, filled_amount, min_fill_amount, fill_seq
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		order.ID, order.PeerID, order.Status,
 		order.OfferChain, order.OfferAmount,
 		order.RequestChain, order.RequestAmount,
 		string(methodsJSON),
 		order.CreatedAt.Unix(), expiresAt,
-		isLocal, order.Signature,
+		isLocal, order.Signature, order.FeeTerms, order.Identity,
+		order.OfferToken, order.RequestToken, strings.Join(order.Settlement, ","),
+		order.FilledAmount, order.MinFillAmount, order.FillSeq,
 	)
 
 	if err != nil {
@@ -99,6 +206,7 @@ func (s *Storage) CreateOrder(order *Order) error {
 // SaveOrder saves an order (insert or update).
 // This is used for syncing orders from other peers.
 func (s *Storage) SaveOrder(order *Order) error {
+	defer s.orderChanged([]string{order.ID}) // After the unlock, hooks may read orders
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -122,18 +230,23 @@ func (s *Storage) SaveOrder(order *Order) error {
 		INSERT INTO orders (
 			id, peer_id, status, offer_chain, offer_amount,
 			request_chain, request_amount, preferred_methods,
-			created_at, expires_at, is_local, signature
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			created_at, expires_at, is_local, signature, fee_terms, identity,
+			offer_token, request_token, settlement, filled_amount, min_fill_amount, fill_seq
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			status = excluded.status,
-			expires_at = excluded.expires_at
+			expires_at = excluded.expires_at,
+			filled_amount = `+mergeFilledAmount+`,
+			fill_seq = MAX(orders.fill_seq, excluded.fill_seq)
 	`,
 		order.ID, order.PeerID, order.Status,
 		order.OfferChain, order.OfferAmount,
 		order.RequestChain, order.RequestAmount,
 		string(methodsJSON),
 		order.CreatedAt.Unix(), expiresAt,
-		isLocal, order.Signature,
+		isLocal, order.Signature, order.FeeTerms, order.Identity,
+		order.OfferToken, order.RequestToken, strings.Join(order.Settlement, ","),
+		order.FilledAmount, order.MinFillAmount, order.FillSeq,
 	)
 
 	if err != nil {
@@ -149,14 +262,15 @@ func (s *Storage) GetOrder(id string) (*Order, error) {
 	defer s.mu.RUnlock()
 
 	var order Order
-	var methodsJSON string
+	var methodsJSON, settlement string
 	var createdAt, expiresAt, updatedAt sql.NullInt64
 	var isLocal int
 
 	err := s.db.QueryRow(`
 		SELECT id, peer_id, status, offer_chain, offer_amount,
 			request_chain, request_amount, preferred_methods,
-			created_at, expires_at, updated_at, is_local, signature
+			created_at, expires_at, updated_at, is_local, signature, fee_terms, identity,
+			offer_token, request_token, settlement, filled_amount, min_fill_amount, fill_seq
 		FROM orders WHERE id = ?
 	`, id).Scan(
 		&order.ID, &order.PeerID, &order.Status,
@@ -164,7 +278,9 @@ func (s *Storage) GetOrder(id string) (*Order, error) {
 		&order.RequestChain, &order.RequestAmount,
 		&methodsJSON,
 		&createdAt, &expiresAt, &updatedAt,
-		&isLocal, &order.Signature,
+		&isLocal, &order.Signature, &order.FeeTerms, &order.Identity,
+		&order.OfferToken, &order.RequestToken, &settlement,
+		&order.FilledAmount, &order.MinFillAmount, &order.FillSeq,
 	)
 
 	if err == sql.ErrNoRows {
@@ -178,6 +294,7 @@ func (s *Storage) GetOrder(id string) (*Order, error) {
 	if err := json.Unmarshal([]byte(methodsJSON), &order.PreferredMethods); err != nil {
 		return nil, fmt.Errorf("failed to parse preferred methods: %w", err)
 	}
+	order.Settlement = splitSettlement(settlement)
 
 	// Convert timestamps
 	order.CreatedAt = time.Unix(createdAt.Int64, 0)
@@ -194,8 +311,17 @@ func (s *Storage) GetOrder(id string) (*Order, error) {
 	return &order, nil
 }
 
+// splitSettlement parses the comma-separated settlement column.
+func splitSettlement(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
 // UpdateOrderStatus updates the status of an order.
 func (s *Storage) UpdateOrderStatus(id string, status OrderStatus) error {
+	defer s.orderChanged([]string{id}) // After the unlock, hooks may read orders
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -215,6 +341,134 @@ func (s *Storage) UpdateOrderStatus(id string, status OrderStatus) error {
 	return nil
 }
 
+// FillOrder takes amount of an order's remaining offer for a trade. The
+// order stays open while some remains and becomes matched once it is
+// fully taken. It returns the updated order.
+func (s *Storage) FillOrder(id string, amount uint64) (*Order, error) {
+	order, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.orderChanged([]string{id}) // After the unlock, hooks may read orders
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := order.CheckFill(amount); err != nil {
+		return nil, err
+	}
+	order.FilledAmount += amount
+	order.FillSeq++
+	if order.Remaining() == 0 {
+		order.Status = OrderStatusMatched
+	}
+	now := time.Now()
+	order.UpdatedAt = &now
+
+	// The filled amount guards against a concurrent fill since the read
+	result, err := s.db.Exec(`
+		UPDATE orders SET filled_amount = ?, fill_seq = ?, status = ?, updated_at = ?
+		WHERE id = ? AND filled_amount = ? AND status = ?
+	`, order.FilledAmount, order.FillSeq, order.Status, now.Unix(), id, order.FilledAmount-amount, OrderStatusOpen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fill order: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("%w: order changed concurrently", ErrInvalidFill)
+	}
+	return order, nil
+}
+
+// ReleaseOrderFill returns amount of an order's filled offer, taken by a
+// trade that never completed, to what remains. A matched order reopens;
+// cancelled and expired orders stay closed. It returns the updated order.
+func (s *Storage) ReleaseOrderFill(id string, amount uint64) (*Order, error) {
+	order, err := s.GetOrder(id)
+	if err != nil {
+		return nil, err
+	}
+
+	defer s.orderChanged([]string{id}) // After the unlock, hooks may read orders
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	previous := order.FilledAmount
+	order.FilledAmount -= min(amount, order.FilledAmount)
+	order.FillSeq++
+	if order.Status == OrderStatusMatched && order.Remaining() > 0 {
+		order.Status = OrderStatusOpen
+	}
+	now := time.Now()
+	order.UpdatedAt = &now
+
+	// The filled amount guards against a concurrent fill since the read
+	result, err := s.db.Exec(`
+		UPDATE orders SET filled_amount = ?, fill_seq = ?, status = ?, updated_at = ?
+		WHERE id = ? AND filled_amount = ?
+	`, order.FilledAmount, order.FillSeq, order.Status, now.Unix(), id, previous)
+	if err != nil {
+		return nil, fmt.Errorf("failed to release order fill: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, fmt.Errorf("%w: order changed concurrently", ErrInvalidFill)
+	}
+	return order, nil
+}
+
+// ReleaseTradeFill releases the fill a trade took of its order, once: it
+// returns nil without changing the order if the fill was already released.
+func (s *Storage) ReleaseTradeFill(tradeID string) (*Order, error) {
+	trade, err := s.GetTrade(tradeID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	result, err := s.db.Exec("UPDATE trades SET fill_released = 1 WHERE id = ? AND fill_released = 0", tradeID)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf("failed to release trade fill: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, nil
+	}
+
+	order, err := s.ReleaseOrderFill(trade.OrderID, trade.OfferAmount)
+	if err != nil {
+		// Leave it to be released again
+		s.mu.Lock()
+		s.db.Exec("UPDATE trades SET fill_released = 0 WHERE id = ?", tradeID)
+		s.mu.Unlock()
+		return nil, err
+	}
+	return order, nil
+}
+
+// UpdateOrderFill records the filled amount, fill sequence and status a
+// maker announced for its order. Only open and matched orders change, and
+// stale announcements are ignored (see Order.IsNewerFill).
+func (s *Storage) UpdateOrderFill(id string, filled, seq uint64, status OrderStatus) error {
+	defer s.orderChanged([]string{id}) // After the unlock, hooks may read orders
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE orders SET filled_amount = ?, fill_seq = ?, status = ?, updated_at = ?
+		WHERE id = ? AND ? <= offer_amount AND status IN (?, ?)
+			AND CASE WHEN ? > 0 THEN fill_seq < ? ELSE fill_seq = 0 AND filled_amount < ? END
+	`, filled, seq, status, time.Now().Unix(), id, filled, OrderStatusOpen, OrderStatusMatched, seq, seq, filled)
+	if err != nil {
+		return fmt.Errorf("failed to update order fill: %w", err)
+	}
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		var exists int
+		if err := s.db.QueryRow("SELECT 1 FROM orders WHERE id = ?", id).Scan(&exists); err == sql.ErrNoRows {
+			return ErrOrderNotFound
+		}
+	}
+	return nil
+}
+
 // ListOrders lists orders matching the given filters.
 type OrderFilter struct {
 	Status       *OrderStatus
@@ -224,6 +478,13 @@ type OrderFilter struct {
 	IsLocal      *bool
 	Limit        int
 	Offset       int
+
+	// Terminal limits to completed, cancelled, expired and failed orders.
+	Terminal bool
+
+	// UpdatedSince limits to orders updated at or after this Unix time and
+	// lists them oldest update first, for paging through history.
+	UpdatedSince *int64
 }
 
 // ListOrders returns orders matching the filter.
@@ -234,7 +495,8 @@ func (s *Storage) ListOrders(filter OrderFilter) ([]*Order, error) {
 	query := `
 		SELECT id, peer_id, status, offer_chain, offer_amount,
 			request_chain, request_amount, preferred_methods,
-			created_at, expires_at, updated_at, is_local, signature
+			created_at, expires_at, updated_at, is_local, signature, fee_terms, identity,
+			offer_token, request_token, settlement, filled_amount, min_fill_amount, fill_seq
 		FROM orders WHERE 1=1
 	`
 	args := []interface{}{}
@@ -263,8 +525,17 @@ func (s *Storage) ListOrders(filter OrderFilter) ([]*Order, error) {
 		query += " AND is_local = ?"
 		args = append(args, isLocal)
 	}
+	if filter.Terminal {
+		query += " AND status IN (?, ?, ?, ?)"
+		args = append(args, OrderStatusCompleted, OrderStatusCancelled, OrderStatusExpired, OrderStatusFailed)
+	}
 
-	query += " ORDER BY created_at DESC"
+	if filter.UpdatedSince != nil {
+		query += " AND COALESCE(updated_at, created_at) >= ? ORDER BY COALESCE(updated_at, created_at), id"
+		args = append(args, *filter.UpdatedSince)
+	} else {
+		query += " ORDER BY created_at DESC"
+	}
 
 	if filter.Limit > 0 {
 		query += " LIMIT ?"
@@ -284,7 +555,7 @@ func (s *Storage) ListOrders(filter OrderFilter) ([]*Order, error) {
 	var orders []*Order
 	for rows.Next() {
 		var order Order
-		var methodsJSON string
+		var methodsJSON, settlement string
 		var createdAt, expiresAt, updatedAt sql.NullInt64
 		var isLocal int
 
@@ -294,7 +565,9 @@ func (s *Storage) ListOrders(filter OrderFilter) ([]*Order, error) {
 			&order.RequestChain, &order.RequestAmount,
 			&methodsJSON,
 			&createdAt, &expiresAt, &updatedAt,
-			&isLocal, &order.Signature,
+			&isLocal, &order.Signature, &order.FeeTerms, &order.Identity,
+			&order.OfferToken, &order.RequestToken, &settlement,
+			&order.FilledAmount, &order.MinFillAmount, &order.FillSeq,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan order: %w", err)
@@ -303,6 +576,7 @@ func (s *Storage) ListOrders(filter OrderFilter) ([]*Order, error) {
 		if err := json.Unmarshal([]byte(methodsJSON), &order.PreferredMethods); err != nil {
 			return nil, fmt.Errorf("failed to parse preferred methods: %w", err)
 		}
+		order.Settlement = splitSettlement(settlement)
 
 		order.CreatedAt = time.Unix(createdAt.Int64, 0)
 		if expiresAt.Valid {
@@ -341,6 +615,7 @@ func (s *Storage) GetMyOrders() ([]*Order, error) {
 
 // DeleteOrder deletes an order (use with caution).
 func (s *Storage) DeleteOrder(id string) error {
+	defer s.orderChanged([]string{id}) // After the unlock, hooks may read orders
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -359,6 +634,7 @@ func (s *Storage) DeleteOrder(id string) error {
 
 // ExpireOldOrders marks expired orders as expired.
 func (s *Storage) ExpireOldOrders() (int64, error) {
+	defer s.orderChanged(nil) // After the unlock, hooks may read orders
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"errors"
 	"os"
 	"testing"
 	"time"
@@ -416,3 +417,385 @@ func TestCountOrders(t *testing.T) {
 		t.Errorf("CountOrders(open) = %d, want 2", openCount)
 	}
 }
+
+func TestOrderFeeTerms(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	terms := `{"dao_fee_payer":"taker"}`
+	orders := []*Order{
+		{ID: "with-terms", PeerID: "peer", Status: OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, PreferredMethods: []string{"htlc"}, FeeTerms: terms, CreatedAt: time.Now()},
+		{ID: "no-terms", PeerID: "peer", Status: OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000, PreferredMethods: []string{"htlc"}, CreatedAt: time.Now()},
+	}
+	for _, o := range orders {
+		if err := store.SaveOrder(o); err != nil {
+			t.Fatalf("SaveOrder() error = %v", err)
+		}
+	}
+
+	got, err := store.GetOrder("with-terms")
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if got.FeeTerms != terms {
+		t.Errorf("FeeTerms = %q, want %q", got.FeeTerms, terms)
+	}
+
+	list, err := store.ListOrders(OrderFilter{})
+	if err != nil {
+		t.Fatalf("ListOrders() error = %v", err)
+	}
+	for _, o := range list {
+		if o.ID == "no-terms" && o.FeeTerms != "" {
+			t.Errorf("no-terms FeeTerms = %q, want empty", o.FeeTerms)
+		}
+	}
+}
+
+func TestOrderTokens(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	order := &Order{
+		ID: "tokens", PeerID: "peer", Status: OrderStatusOpen,
+		OfferChain: "ARBITRUM", OfferAmount: 1_000_000, OfferToken: "0xaf88d065e77c8cC2239327C5EDb3A432268e5831",
+		RequestChain: "BSC", RequestAmount: 1_000_000_000_000_000_000, RequestToken: "0x55d398326f99059fF775485246999027B3197955",
+		PreferredMethods: []string{"htlc"}, CreatedAt: time.Now(),
+	}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	got, err := store.GetOrder("tokens")
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if got.OfferToken != order.OfferToken || got.RequestToken != order.RequestToken {
+		t.Errorf("tokens = %q, %q, want %q, %q", got.OfferToken, got.RequestToken, order.OfferToken, order.RequestToken)
+	}
+
+	list, err := store.ListOrders(OrderFilter{})
+	if err != nil {
+		t.Fatalf("ListOrders() error = %v", err)
+	}
+	if len(list) != 1 || list[0].OfferToken != order.OfferToken {
+		t.Errorf("ListOrders() = %+v", list)
+	}
+}
+
+func TestOrderSettlement(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	for _, order := range []*Order{
+		{ID: "ln", Settlement: []string{"lightning"}},
+		{ID: "onchain"},
+	} {
+		order.PeerID, order.Status, order.CreatedAt = "peer", OrderStatusOpen, time.Now()
+		order.OfferChain, order.OfferAmount, order.RequestChain, order.RequestAmount = "LTC", 100_000_000, "BTC", 1_000_000
+		order.PreferredMethods = []string{"htlc"}
+		if err := store.SaveOrder(order); err != nil {
+			t.Fatalf("SaveOrder() error = %v", err)
+		}
+	}
+
+	got, err := store.GetOrder("ln")
+	if err != nil {
+		t.Fatalf("GetOrder() error = %v", err)
+	}
+	if len(got.Settlement) != 1 || got.Settlement[0] != "lightning" {
+		t.Errorf("Settlement = %v, want [lightning]", got.Settlement)
+	}
+	if got, _ := store.GetOrder("onchain"); got.Settlement != nil {
+		t.Errorf("Settlement = %v, want none", got.Settlement)
+	}
+
+	list, err := store.ListOrders(OrderFilter{Status: &got.Status})
+	if err != nil {
+		t.Fatalf("ListOrders() error = %v", err)
+	}
+	for _, o := range list {
+		if (o.ID == "ln") != (len(o.Settlement) == 1) {
+			t.Errorf("ListOrders() %s settlement = %v", o.ID, o.Settlement)
+		}
+	}
+}
+
+func TestFillOrder(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	for _, order := range []*Order{
+		{ID: "partial", MinFillAmount: 20_000_000},
+		{ID: "whole"},
+	} {
+		order.PeerID, order.Status, order.CreatedAt = "peer", OrderStatusOpen, time.Now()
+		order.OfferChain, order.OfferAmount, order.RequestChain, order.RequestAmount = "LTC", 100_000_000, "BTC", 1_000_000
+		order.PreferredMethods = []string{"htlc"}
+		if err := store.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+	}
+
+	// Fills below the minimum or beyond the remainder are refused
+	for _, amount := range []uint64{0, 10_000_000, 100_000_001} {
+		if _, err := store.FillOrder("partial", amount); !errors.Is(err, ErrInvalidFill) {
+			t.Errorf("FillOrder(%d) error = %v, want ErrInvalidFill", amount, err)
+		}
+	}
+
+	got, err := store.FillOrder("partial", 60_000_000)
+	if err != nil {
+		t.Fatalf("FillOrder() error = %v", err)
+	}
+	if got.Status != OrderStatusOpen || got.Remaining() != 40_000_000 {
+		t.Errorf("after a partial fill: status %s, remaining %d", got.Status, got.Remaining())
+	}
+
+	// The remainder may be taken even when it is below the minimum
+	store.FillOrder("partial", 30_000_000)
+	got, err = store.FillOrder("partial", 10_000_000)
+	if err != nil {
+		t.Fatalf("FillOrder(remainder) error = %v", err)
+	}
+	if got, _ := store.GetOrder("partial"); got.Status != OrderStatusMatched || got.FilledAmount != 100_000_000 {
+		t.Errorf("filled order: status %s, filled %d", got.Status, got.FilledAmount)
+	}
+	if _, err := store.FillOrder("partial", 1); !errors.Is(err, ErrOrderFilled) {
+		t.Errorf("fill of a filled order error = %v, want ErrOrderFilled", err)
+	}
+
+	if _, err := store.FillOrder("whole", 50_000_000); !errors.Is(err, ErrInvalidFill) {
+		t.Errorf("partial fill of a whole-only order error = %v", err)
+	}
+	if _, err := store.FillOrder("whole", 100_000_000); err != nil {
+		t.Errorf("FillOrder(whole) error = %v", err)
+	}
+}
+
+func TestReleaseOrderFill(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	for _, id := range []string{"matched", "cancelled"} {
+		order := &Order{
+			ID: id, PeerID: "peer", Status: OrderStatusOpen, CreatedAt: time.Now(),
+			OfferChain: "LTC", OfferAmount: 100_000_000, RequestChain: "BTC", RequestAmount: 1_000_000,
+			PreferredMethods: []string{"htlc"}, MinFillAmount: 10_000_000,
+		}
+		if err := store.CreateOrder(order); err != nil {
+			t.Fatalf("CreateOrder() error = %v", err)
+		}
+		if _, err := store.FillOrder(id, 100_000_000); err != nil {
+			t.Fatalf("FillOrder() error = %v", err)
+		}
+		trade := &Trade{
+			ID: "trade-" + id, OrderID: id, MakerPeerID: "peer", TakerPeerID: "us", Method: "htlc",
+			State: TradeStateInit, OfferAmount: 100_000_000, RequestAmount: 1_000_000, CreatedAt: time.Now(),
+		}
+		if err := store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+	if err := store.UpdateOrderStatus("cancelled", OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+
+	// A released matched order is open again with its whole offer
+	got, err := store.ReleaseTradeFill("trade-matched")
+	if err != nil {
+		t.Fatalf("ReleaseTradeFill() error = %v", err)
+	}
+	if got == nil || got.Status != OrderStatusOpen || got.Remaining() != 100_000_000 {
+		t.Errorf("released order = %+v", got)
+	}
+	if got, _ := store.GetOrder("matched"); got.Status != OrderStatusOpen || got.FilledAmount != 0 || got.FillSeq != 2 {
+		t.Errorf("stored order: status %s, filled %d, seq %d", got.Status, got.FilledAmount, got.FillSeq)
+	}
+
+	// Releasing the same trade again doesn't give back more
+	if _, err := store.FillOrder("matched", 40_000_000); err != nil {
+		t.Fatalf("FillOrder() after release error = %v", err)
+	}
+	if got, err := store.ReleaseTradeFill("trade-matched"); err != nil || got != nil {
+		t.Errorf("second ReleaseTradeFill() = %+v, %v, want nil", got, err)
+	}
+	if got, _ := store.GetOrder("matched"); got.FilledAmount != 40_000_000 {
+		t.Errorf("filled after a second release = %d, want 40000000", got.FilledAmount)
+	}
+
+	// A cancelled order stays closed
+	if _, err := store.ReleaseTradeFill("trade-cancelled"); err != nil {
+		t.Fatalf("ReleaseTradeFill(cancelled) error = %v", err)
+	}
+	if got, _ := store.GetOrder("cancelled"); got.Status != OrderStatusCancelled || got.FilledAmount != 0 {
+		t.Errorf("cancelled order: status %s, filled %d", got.Status, got.FilledAmount)
+	}
+
+	if _, err := store.ReleaseTradeFill("missing"); !errors.Is(err, ErrTradeNotFound) {
+		t.Errorf("ReleaseTradeFill(missing) error = %v", err)
+	}
+}
+
+func TestUpdateOrderFill(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	order := &Order{
+		ID: "remote", PeerID: "peer", Status: OrderStatusOpen, CreatedAt: time.Now(),
+		OfferChain: "LTC", OfferAmount: 100_000_000, RequestChain: "BTC", RequestAmount: 1_000_000,
+		PreferredMethods: []string{"htlc"}, MinFillAmount: 1_000_000,
+	}
+	if err := store.SaveOrder(order); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.UpdateOrderFill("remote", 40_000_000, 0, OrderStatusOpen); err != nil {
+		t.Fatalf("UpdateOrderFill() error = %v", err)
+	}
+	// A stale update doesn't shrink the fill
+	if err := store.UpdateOrderFill("remote", 10_000_000, 0, OrderStatusOpen); err != nil {
+		t.Fatalf("UpdateOrderFill(stale) error = %v", err)
+	}
+	got, _ := store.GetOrder("remote")
+	if got.FilledAmount != 40_000_000 || got.MinFillAmount != 1_000_000 {
+		t.Errorf("order = filled %d, min fill %d", got.FilledAmount, got.MinFillAmount)
+	}
+
+	// Neither does a re-sync of the original announcement
+	if err := store.SaveOrder(order); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetOrder("remote"); got.FilledAmount != 40_000_000 {
+		t.Errorf("re-saved order filled = %d, want 40000000", got.FilledAmount)
+	}
+
+	if err := store.UpdateOrderFill("remote", 100_000_000, 0, OrderStatusMatched); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetOrder("remote"); got.Status != OrderStatusMatched || got.Remaining() != 0 {
+		t.Errorf("order = %s, remaining %d", got.Status, got.Remaining())
+	}
+	if err := store.UpdateOrderFill("missing", 1, 0, OrderStatusOpen); !errors.Is(err, ErrOrderNotFound) {
+		t.Errorf("UpdateOrderFill(missing) error = %v", err)
+	}
+}
+
+func TestUpdateOrderFillSequence(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	order := &Order{
+		ID: "remote", PeerID: "peer", Status: OrderStatusOpen, CreatedAt: time.Now(),
+		OfferChain: "LTC", OfferAmount: 100_000_000, RequestChain: "BTC", RequestAmount: 1_000_000,
+		PreferredMethods: []string{"htlc"},
+	}
+	if err := store.SaveOrder(order); err != nil {
+		t.Fatal(err)
+	}
+
+	// Taken whole, then released by the maker: the order reopens
+	if err := store.UpdateOrderFill("remote", 100_000_000, 1, OrderStatusMatched); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateOrderFill("remote", 0, 2, OrderStatusOpen); err != nil {
+		t.Fatalf("UpdateOrderFill(release) error = %v", err)
+	}
+	got, _ := store.GetOrder("remote")
+	if got.Status != OrderStatusOpen || got.FilledAmount != 0 || got.FillSeq != 2 {
+		t.Errorf("released order = %s, filled %d, seq %d", got.Status, got.FilledAmount, got.FillSeq)
+	}
+
+	// The take announced late is stale, whichever way it arrives
+	if err := store.UpdateOrderFill("remote", 100_000_000, 1, OrderStatusMatched); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateOrderFill("remote", 100_000_000, 0, OrderStatusMatched); err != nil {
+		t.Fatal(err)
+	}
+	late := *order
+	late.FilledAmount, late.FillSeq = 100_000_000, 1
+	if err := store.SaveOrder(&late); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetOrder("remote"); got.Status != OrderStatusOpen || got.FilledAmount != 0 {
+		t.Errorf("order after stale updates = %s, filled %d", got.Status, got.FilledAmount)
+	}
+
+	// A re-sync with a newer sequence lowers the fill too
+	synced := *order
+	synced.FilledAmount, synced.FillSeq = 30_000_000, 3
+	if err := store.SaveOrder(&synced); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetOrder("remote"); got.FilledAmount != 30_000_000 || got.FillSeq != 3 {
+		t.Errorf("re-synced order filled %d, seq %d", got.FilledAmount, got.FillSeq)
+	}
+
+	// Closed orders don't change
+	if err := store.UpdateOrderStatus("remote", OrderStatusCancelled); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateOrderFill("remote", 0, 4, OrderStatusOpen); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetOrder("remote"); got.Status != OrderStatusCancelled || got.FilledAmount != 30_000_000 {
+		t.Errorf("cancelled order = %s, filled %d", got.Status, got.FilledAmount)
+	}
+}
+
+func TestOnOrderChange(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	var changes [][]string
+	store.OnOrderChange(func(ids []string) {
+		// Hooks run without the storage lock and may read orders
+		for _, id := range ids {
+			store.GetOrder(id)
+		}
+		changes = append(changes, ids)
+	})
+
+	order := &Order{ID: "o1", PeerID: "peer", Status: OrderStatusOpen, OfferChain: "BTC", OfferAmount: 1, RequestChain: "LTC", RequestAmount: 2, CreatedAt: time.Now()}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	if err := store.UpdateOrderStatus("o1", OrderStatusCancelled); err != nil {
+		t.Fatalf("UpdateOrderStatus() error = %v", err)
+	}
+	if _, err := store.ExpireOldOrders(); err != nil {
+		t.Fatalf("ExpireOldOrders() error = %v", err)
+	}
+	if err := store.DeleteOrder("o1"); err != nil {
+		t.Fatalf("DeleteOrder() error = %v", err)
+	}
+
+	if len(changes) != 4 || changes[0][0] != "o1" || changes[1][0] != "o1" || changes[2] != nil || changes[3][0] != "o1" {
+		t.Errorf("changes = %v", changes)
+	}
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"database/sql"
+	"time"
+)
+
+// PeerQualityRecord holds the path quality measurements of a peer.
+type PeerQualityRecord struct {
+	PeerID            string
+	RTTMicros         int64 // Smoothed ping round-trip time
+	StreamSetupMicros int64 // Smoothed direct stream open time
+	MessagesSent      int64
+	MessagesLost      int64 // Direct messages sent without an ACK
+	UpdatedAt         time.Time
+}
+
+// SavePeerQuality inserts or replaces a peer's quality record.
+func (s *Storage) SavePeerQuality(q *PeerQualityRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO peer_quality (peer_id, rtt_us, stream_setup_us, messages_sent, messages_lost, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(peer_id) DO UPDATE SET
+			rtt_us = excluded.rtt_us,
+			stream_setup_us = excluded.stream_setup_us,
+			messages_sent = excluded.messages_sent,
+			messages_lost = excluded.messages_lost,
+			updated_at = excluded.updated_at
+	`, q.PeerID, q.RTTMicros, q.StreamSetupMicros, q.MessagesSent, q.MessagesLost, q.UpdatedAt.Unix())
+	return err
+}
+
+// GetPeerQuality returns a peer's quality record, or nil if none.
+func (s *Storage) GetPeerQuality(peerID string) (*PeerQualityRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row := s.db.QueryRow(`
+		SELECT peer_id, rtt_us, stream_setup_us, messages_sent, messages_lost, updated_at
+		FROM peer_quality WHERE peer_id = ?
+	`, peerID)
+	q, err := scanPeerQuality(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return q, err
+}
+
+// ListPeerQuality returns the quality records of all peers.
+func (s *Storage) ListPeerQuality() ([]*PeerQualityRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT peer_id, rtt_us, stream_setup_us, messages_sent, messages_lost, updated_at
+		FROM peer_quality ORDER BY updated_at DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var records []*PeerQualityRecord
+	for rows.Next() {
+		q, err := scanPeerQuality(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, q)
+	}
+	return records, rows.Err()
+}
+
+// DeletePeerQualityBefore removes records not updated since the cutoff.
+func (s *Storage) DeletePeerQualityBefore(cutoff time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM peer_quality WHERE updated_at < ?", cutoff.Unix())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+func scanPeerQuality(row interface{ Scan(...interface{}) error }) (*PeerQualityRecord, error) {
+	var q PeerQualityRecord
+	var updatedAt int64
+	if err := row.Scan(&q.PeerID, &q.RTTMicros, &q.StreamSetupMicros, &q.MessagesSent, &q.MessagesLost, &updatedAt); err != nil {
+		return nil, err
+	}
+	q.UpdatedAt = time.Unix(updatedAt, 0)
+	return &q, nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPeerQuality(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	got, err := store.GetPeerQuality("peer-1")
+	if err != nil || got != nil {
+		t.Fatalf("GetPeerQuality() on empty store = %+v, %v, want nil, nil", got, err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	q := &PeerQualityRecord{PeerID: "peer-1", RTTMicros: 25000, StreamSetupMicros: 40000, MessagesSent: 10, MessagesLost: 1, UpdatedAt: now}
+	if err := store.SavePeerQuality(q); err != nil {
+		t.Fatalf("SavePeerQuality() error = %v", err)
+	}
+	q.RTTMicros = 30000
+	q.MessagesSent = 11
+	if err := store.SavePeerQuality(q); err != nil {
+		t.Fatalf("SavePeerQuality() update error = %v", err)
+	}
+	if err := store.SavePeerQuality(&PeerQualityRecord{PeerID: "peer-2", RTTMicros: 5000, UpdatedAt: now.Add(-48 * time.Hour)}); err != nil {
+		t.Fatalf("SavePeerQuality() error = %v", err)
+	}
+
+	got, err = store.GetPeerQuality("peer-1")
+	if err != nil {
+		t.Fatalf("GetPeerQuality() error = %v", err)
+	}
+	if got.RTTMicros != 30000 || got.StreamSetupMicros != 40000 || got.MessagesSent != 11 || got.MessagesLost != 1 || !got.UpdatedAt.Equal(now) {
+		t.Errorf("GetPeerQuality() = %+v", got)
+	}
+
+	all, err := store.ListPeerQuality()
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListPeerQuality() = %d records, %v, want 2", len(all), err)
+	}
+
+	deleted, err := store.DeletePeerQualityBefore(now.Add(-24 * time.Hour))
+	if err != nil || deleted != 1 {
+		t.Errorf("DeletePeerQualityBefore() = %d, %v, want 1", deleted, err)
+	}
+}
@@ -0,0 +1,73 @@
+// Package storage - Read-only access to a primary node's database for replicas.
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// replicaConns is the connection pool of a read-only storage, one of them
+// pinned for DataVersion.
+const replicaConns = 5
+
+// openReadOnly opens a database another process writes, for serving reads.
+// SQLite's WAL lets it read while the primary writes; every write through it
+// fails. The schema is left to the primary.
+func openReadOnly(dbPath string) (*Storage, error) {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, fmt.Errorf("no database to open read-only: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro&_busy_timeout=5000")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	db.SetMaxOpenConns(replicaConns)
+	db.SetMaxIdleConns(replicaConns)
+
+	var tables int
+	if err := db.QueryRow("SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'orders'").Scan(&tables); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to read database: %w", err)
+	}
+	if tables == 0 {
+		db.Close()
+		return nil, fmt.Errorf("database %s has no schema, start the primary node first", dbPath)
+	}
+
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	return &Storage{
+		db:          db,
+		dbPath:      dbPath,
+		readOnly:    true,
+		versionConn: conn,
+	}, nil
+}
+
+// ReadOnly reports whether the storage was opened read-only.
+func (s *Storage) ReadOnly() bool {
+	return s.readOnly
+}
+
+// DataVersion returns a number that changes whenever another connection,
+// such as the primary's, commits to the database. A replica polls it to know
+// when its caches are stale.
+func (s *Storage) DataVersion(ctx context.Context) (int64, error) {
+	var row *sql.Row
+	if s.versionConn != nil {
+		row = s.versionConn.QueryRowContext(ctx, "PRAGMA data_version")
+	} else {
+		row = s.db.QueryRowContext(ctx, "PRAGMA data_version")
+	}
+	var version int64
+	if err := row.Scan(&version); err != nil {
+		return 0, fmt.Errorf("failed to read data version: %w", err)
+	}
+	return version, nil
+}
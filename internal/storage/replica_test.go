@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestReadOnlyReplica(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := New(&Config{DataDir: dir, ReadOnly: true}); err == nil {
+		t.Fatal("opened a missing database read-only")
+	}
+
+	primary, err := New(&Config{DataDir: dir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer primary.Close()
+	replica, err := New(&Config{DataDir: dir, ReadOnly: true})
+	if err != nil {
+		t.Fatalf("New(read-only) error = %v", err)
+	}
+	defer replica.Close()
+	if primary.ReadOnly() || !replica.ReadOnly() {
+		t.Errorf("ReadOnly() = %v, %v, want false, true", primary.ReadOnly(), replica.ReadOnly())
+	}
+
+	ctx := context.Background()
+	before, err := replica.DataVersion(ctx)
+	if err != nil {
+		t.Fatalf("DataVersion() error = %v", err)
+	}
+
+	// The replica reads what the primary writes and notices it
+	order := &Order{
+		ID: "order-1", PeerID: "peer", Status: OrderStatusOpen,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000,
+		CreatedAt: time.Now(),
+	}
+	if err := primary.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+	after, err := replica.DataVersion(ctx)
+	if err != nil {
+		t.Fatalf("DataVersion() error = %v", err)
+	}
+	if after == before {
+		t.Error("DataVersion() unchanged after the primary wrote")
+	}
+	if got, err := replica.GetOrder("order-1"); err != nil || got.OfferAmount != 100000 {
+		t.Errorf("replica GetOrder() = %+v, %v", got, err)
+	}
+	if again, _ := replica.DataVersion(ctx); again != after {
+		t.Errorf("DataVersion() = %d without writes, want %d", again, after)
+	}
+
+	// and can't write
+	order.ID = "order-2"
+	if err := replica.CreateOrder(order); err == nil {
+		t.Error("replica wrote an order")
+	}
+}
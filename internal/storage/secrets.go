@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -12,6 +13,7 @@ import (
 var (
 	ErrSecretNotFound      = errors.New("secret not found")
 	ErrSecretAlreadyExists = errors.New("secret already exists for this trade and hash")
+	ErrSecretHashReused    = errors.New("secret hash already used by another trade")
 )
 
 // SecretCreator indicates who created the secret.
@@ -431,6 +433,68 @@ func (s *Storage) DeleteSecretsByTradeID(tradeID string) error {
 	return nil
 }
 
+// ClearFinishedSecrets clears the preimages of secrets whose swap or trade
+// has ended, keeping the hashes. It returns how many were cleared.
+func (s *Storage) ClearFinishedSecrets() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(`
+		UPDATE secrets SET secret = NULL
+		WHERE secret IS NOT NULL AND (
+			trade_id IN (SELECT trade_id FROM active_swaps WHERE state IN (?, ?, ?, ?))
+			OR trade_id IN (SELECT id FROM trades WHERE state IN (?, ?, ?, ?))
+		)
+	`, SwapStateRedeemed, SwapStateRefunded, SwapStateFailed, SwapStateCancelled,
+		TradeStateRedeemed, TradeStateRefunded, TradeStateFailed, TradeStateAborted)
+	if err != nil {
+		return 0, fmt.Errorf("failed to clear finished secrets: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return int(rows), nil
+}
+
+// ClaimSecretHash binds a secret hash (hex) to a trade. It returns
+// ErrSecretHashReused if the hash is bound to another trade; claiming it
+// again for the same trade is a no-op.
+func (s *Storage) ClaimSecretHash(secretHash, tradeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	secretHash = strings.ToLower(secretHash)
+	if _, err := s.db.Exec(`
+		INSERT INTO secret_hashes (secret_hash, trade_id, first_seen) VALUES (?, ?, ?)
+		ON CONFLICT(secret_hash) DO NOTHING
+	`, secretHash, tradeID, time.Now().Unix()); err != nil {
+		return fmt.Errorf("failed to claim secret hash: %w", err)
+	}
+
+	var owner string
+	if err := s.db.QueryRow("SELECT trade_id FROM secret_hashes WHERE secret_hash = ?", secretHash).Scan(&owner); err != nil {
+		return fmt.Errorf("failed to read secret hash: %w", err)
+	}
+	if owner != tradeID {
+		return fmt.Errorf("%w: %s", ErrSecretHashReused, owner)
+	}
+	return nil
+}
+
+// GetSecretHashTrade returns the trade a secret hash (hex) is bound to.
+func (s *Storage) GetSecretHashTrade(secretHash string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var tradeID string
+	err := s.db.QueryRow("SELECT trade_id FROM secret_hashes WHERE secret_hash = ?", strings.ToLower(secretHash)).Scan(&tradeID)
+	if err == sql.ErrNoRows {
+		return "", ErrSecretNotFound
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret hash: %w", err)
+	}
+	return tradeID, nil
+}
+
 // isUniqueConstraintError checks if an error is a SQLite unique constraint violation.
 func isUniqueConstraintError(err error) bool {
 	if err == nil {
@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"errors"
+	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -475,6 +478,45 @@ func TestHasSecretPreimage(t *testing.T) {
 	}
 }
 
+func TestClaimSecretHash(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "klingon-secrets-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	store, err := New(&Config{DataDir: tmpDir})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	hash := "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	if _, err := store.GetSecretHashTrade(hash); err != ErrSecretNotFound {
+		t.Errorf("GetSecretHashTrade() error = %v, want ErrSecretNotFound", err)
+	}
+
+	if err := store.ClaimSecretHash(hash, "trade-1"); err != nil {
+		t.Fatalf("ClaimSecretHash() error = %v", err)
+	}
+	// The same trade may claim it again
+	if err := store.ClaimSecretHash(hash, "trade-1"); err != nil {
+		t.Errorf("ClaimSecretHash() again error = %v", err)
+	}
+	// Another trade may not, whatever the case of the hex
+	if err := store.ClaimSecretHash(strings.ToUpper(hash), "trade-2"); !errors.Is(err, ErrSecretHashReused) {
+		t.Errorf("ClaimSecretHash(trade-2) error = %v, want ErrSecretHashReused", err)
+	}
+
+	tradeID, err := store.GetSecretHashTrade(strings.ToUpper(hash))
+	if err != nil {
+		t.Fatalf("GetSecretHashTrade() error = %v", err)
+	}
+	if tradeID != "trade-1" {
+		t.Errorf("GetSecretHashTrade() = %s, want trade-1", tradeID)
+	}
+}
+
 func TestContainsHelper(t *testing.T) {
 	if !contains("hello world", "world") {
 		t.Error("contains should find 'world' in 'hello world'")
@@ -486,3 +528,44 @@ func TestContainsHelper(t *testing.T) {
 		t.Error("contains should find 'UNIQUE constraint' in error")
 	}
 }
+
+func TestClearFinishedSecrets(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	for _, trade := range []*Trade{
+		{ID: "done", OrderID: "o1", State: TradeStateRedeemed, CreatedAt: time.Now()},
+		{ID: "running", OrderID: "o2", State: TradeStateFunded, CreatedAt: time.Now()},
+	} {
+		if err := store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+	for i, tradeID := range []string{"done", "running"} {
+		secret := &Secret{
+			ID:         "secret-" + tradeID,
+			TradeID:    tradeID,
+			SecretHash: fmt.Sprintf("%064d", i),
+			Secret:     fmt.Sprintf("%064d", i+10),
+			CreatedBy:  SecretCreatorUs,
+			CreatedAt:  time.Now(),
+		}
+		if err := store.CreateSecret(secret); err != nil {
+			t.Fatalf("CreateSecret() error = %v", err)
+		}
+	}
+
+	cleared, err := store.ClearFinishedSecrets()
+	if err != nil || cleared != 1 {
+		t.Fatalf("ClearFinishedSecrets() = %d, %v, want 1", cleared, err)
+	}
+	if done, _ := store.GetSecret("secret-done"); done.Secret != "" || done.SecretHash == "" {
+		t.Errorf("finished secret = %+v, want the hash only", done)
+	}
+	if running, _ := store.GetSecret("secret-running"); running.Secret == "" {
+		t.Error("running swap's preimage cleared")
+	}
+	if cleared, _ := store.ClearFinishedSecrets(); cleared != 0 {
+		t.Errorf("second ClearFinishedSecrets() = %d, want 0", cleared)
+	}
+}
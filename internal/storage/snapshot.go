@@ -0,0 +1,23 @@
+// Package storage - Consistent database snapshots for backups.
+package storage
+
+import (
+	"fmt"
+	"os"
+)
+
+// Snapshot writes a consistent copy of the database to path. The copy is a
+// complete SQLite database that can replace klingon.db on restore.
+func (s *Storage) Snapshot(path string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// VACUUM INTO refuses to overwrite an existing file
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove old snapshot: %w", err)
+	}
+	if _, err := s.db.Exec(`VACUUM INTO ?`, path); err != nil {
+		return fmt.Errorf("failed to snapshot database: %w", err)
+	}
+	return os.Chmod(path, 0600)
+}
@@ -0,0 +1,45 @@
+package storage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSnapshot(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	order := &Order{
+		ID: "order-1", PeerID: "peer-1", Status: OrderStatusOpen, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateOrder(order); err != nil {
+		t.Fatalf("CreateOrder() error = %v", err)
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, DBFileName)
+	if err := store.Snapshot(path); err != nil {
+		t.Fatalf("Snapshot() error = %v", err)
+	}
+	// Overwrites a previous snapshot
+	if err := store.Snapshot(path); err != nil {
+		t.Fatalf("second Snapshot() error = %v", err)
+	}
+
+	restored, err := New(&Config{DataDir: dir})
+	if err != nil {
+		t.Fatalf("New() on snapshot error = %v", err)
+	}
+	defer restored.Close()
+
+	got, err := restored.GetOrder("order-1")
+	if err != nil {
+		t.Fatalf("GetOrder() on snapshot error = %v", err)
+	}
+	if got.OfferAmount != 1000 || got.RequestChain != "LTC" {
+		t.Errorf("restored order = %+v", got)
+	}
+}
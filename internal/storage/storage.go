@@ -17,23 +17,40 @@ type Storage struct {
 	db     *sql.DB
 	dbPath string
 	mu     sync.RWMutex
+
+	// Read-only replica of a primary's database (see replica.go)
+	readOnly    bool
+	versionConn *sql.Conn
+
+	// Called after order writes (see OnOrderChange)
+	hookMu     sync.RWMutex
+	orderHooks []OrderChangeFunc
 }
 
+// DBFileName is the database file name within the data directory.
+const DBFileName = "klingon.db"
+
 // Config holds storage configuration.
 type Config struct {
 	DataDir string
+
+	// ReadOnly opens the existing database of a primary node read-only
+	ReadOnly bool
 }
 
 // New creates a new Storage instance.
 func New(cfg *Config) (*Storage, error) {
 	dataDir := expandPath(cfg.DataDir)
+	if cfg.ReadOnly {
+		return openReadOnly(filepath.Join(dataDir, DBFileName))
+	}
 
 	// Ensure directory exists
 	if err := os.MkdirAll(dataDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create data directory: %w", err)
 	}
 
-	dbPath := filepath.Join(dataDir, "klingon.db")
+	dbPath := filepath.Join(dataDir, DBFileName)
 
 	// Open database
 	db, err := sql.Open("sqlite3", dbPath+"?_journal_mode=WAL&_synchronous=NORMAL&_busy_timeout=5000")
@@ -68,6 +85,9 @@ func New(cfg *Config) (*Storage, error) {
 
 // Close closes the database connection.
 func (s *Storage) Close() error {
+	if s.versionConn != nil {
+		s.versionConn.Close()
+	}
 	return s.db.Close()
 }
 
@@ -76,6 +96,12 @@ func (s *Storage) DB() *sql.DB {
 	return s.db
 }
 
+// SQLite returns the store itself. Wrappers that embed a *Storage hand on
+// the store they wrap through it (see klingdex.Storage).
+func (s *Storage) SQLite() *Storage {
+	return s
+}
+
 // initSchema creates all database tables.
 func (s *Storage) initSchema() error {
 	schema := `
@@ -133,6 +159,26 @@ func (s *Storage) initSchema() error {
 		-- Signature proving ownership (for verification)
 		signature TEXT,
 
+		-- Negotiated fee split (JSON, empty = protocol defaults)
+		fee_terms TEXT NOT NULL DEFAULT '',
+
+		-- Maker wallet identity and address proofs (JSON, empty = unsigned)
+		identity TEXT NOT NULL DEFAULT '',
+
+		-- ERC-20 token contract addresses (empty = native coin)
+		offer_token TEXT NOT NULL DEFAULT '',
+		request_token TEXT NOT NULL DEFAULT '',
+
+		-- Alternative settlement of the request leg (comma-separated, e.g. lightning)
+		settlement TEXT NOT NULL DEFAULT '',
+
+		-- Partial fills: offer amount taken so far, and the smallest fill
+		-- accepted (0 = the order can only be taken whole)
+		filled_amount INTEGER NOT NULL DEFAULT 0,
+		min_fill_amount INTEGER NOT NULL DEFAULT 0,
+		-- Bumped by the maker on every fill and release (see Order.FillSeq)
+		fill_seq INTEGER NOT NULL DEFAULT 0,
+
 		FOREIGN KEY (peer_id) REFERENCES peers(peer_id)
 	);
 
@@ -178,6 +224,15 @@ func (s *Storage) initSchema() error {
 		-- Failure tracking
 		failure_reason TEXT,
 
+		-- External payout address for our receiving leg (empty: our wallet)
+		payout_address TEXT NOT NULL DEFAULT '',
+
+		-- Negotiated request amount change compensating mining fees
+		fee_adjustment INTEGER NOT NULL DEFAULT 0,
+
+		-- Set once the order fill of a trade that didn't complete is released
+		fill_released INTEGER NOT NULL DEFAULT 0,
+
 		FOREIGN KEY (order_id) REFERENCES orders(id)
 	);
 
@@ -261,6 +316,15 @@ func (s *Storage) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_secrets_trade ON secrets(trade_id);
 	CREATE INDEX IF NOT EXISTS idx_secrets_hash ON secrets(secret_hash);
 
+	-- Every secret hash seen in a swap, bound to the first trade using it.
+	-- Kept after secrets and swaps are deleted or archived, so a hash can
+	-- never be reused for another trade.
+	CREATE TABLE IF NOT EXISTS secret_hashes (
+		secret_hash TEXT PRIMARY KEY,   -- Lowercase hex
+		trade_id TEXT NOT NULL,
+		first_seen INTEGER NOT NULL
+	);
+
 	-- Message log (for debugging and audit)
 	CREATE TABLE IF NOT EXISTS message_log (
 		id TEXT PRIMARY KEY,
@@ -327,7 +391,14 @@ func (s *Storage) initSchema() error {
 		-- Timing
 		created_at INTEGER NOT NULL,
 		updated_at INTEGER NOT NULL,
-		completed_at INTEGER
+		completed_at INTEGER,
+
+		-- Negotiated fee split (JSON, empty = protocol defaults)
+		fee_terms TEXT NOT NULL DEFAULT '',
+
+		-- ERC-20 token contract addresses (empty = native coin)
+		offer_token TEXT NOT NULL DEFAULT '',
+		request_token TEXT NOT NULL DEFAULT ''
 	);
 
 	CREATE INDEX IF NOT EXISTS idx_active_swaps_state ON active_swaps(state);
@@ -491,6 +562,276 @@ func (s *Storage) initSchema() error {
 		remote_seq INTEGER DEFAULT 0,         -- Last received inbound sequence number
 		updated_at INTEGER NOT NULL
 	);
+
+	-- =========================================================================
+	-- Coordinator Job Queue (durable on-chain side effects)
+	-- =========================================================================
+
+	-- Jobs are persisted before a broadcast or contract call is executed and
+	-- marked done once it confirms, so a restart never repeats or forgets an action.
+	CREATE TABLE IF NOT EXISTS coordinator_jobs (
+		id TEXT PRIMARY KEY,                  -- Idempotency key: action:trade_id:chain
+		trade_id TEXT NOT NULL,               -- Associated swap trade
+		chain TEXT NOT NULL,                  -- Chain the side effect targets
+		kind TEXT NOT NULL,                   -- broadcast_tx, evm_call
+		action TEXT NOT NULL,                 -- fund, claim, refund, redeem, ...
+		payload TEXT NOT NULL DEFAULT '',     -- Signed raw tx hex (broadcast_tx)
+		result_txid TEXT NOT NULL DEFAULT '', -- Resulting transaction ID
+		status TEXT NOT NULL DEFAULT 'pending', -- pending, broadcast, done, failed
+		attempts INTEGER NOT NULL DEFAULT 0,  -- Number of execution attempts
+		last_error TEXT NOT NULL DEFAULT '',  -- Last execution error
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_jobs_status ON coordinator_jobs(status);
+	CREATE INDEX IF NOT EXISTS idx_jobs_trade ON coordinator_jobs(trade_id);
+
+	-- =========================================================================
+	-- Watchtower (pre-signed spends held for trusted offline peers)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS watchtower_bundles (
+		id TEXT PRIMARY KEY,                  -- kind:trade_id:chain, scoped by client
+		client_peer TEXT NOT NULL,            -- Peer that registered the bundle
+		trade_id TEXT NOT NULL,
+		chain TEXT NOT NULL,                  -- Chain the transaction is broadcast on
+		kind TEXT NOT NULL,                   -- refund, claim
+		tx_hex TEXT NOT NULL,                 -- Pre-signed transaction
+		funding_txid TEXT NOT NULL,           -- HTLC output spent by tx_hex
+		funding_vout INTEGER NOT NULL,
+		timeout_blocks INTEGER NOT NULL DEFAULT 0, -- Refund CSV timelock
+		watch_chain TEXT NOT NULL DEFAULT '', -- Claim: chain where the secret is revealed
+		watch_address TEXT NOT NULL DEFAULT '',
+		watch_txid TEXT NOT NULL DEFAULT '',
+		watch_vout INTEGER NOT NULL DEFAULT 0,
+		secret_hash TEXT NOT NULL DEFAULT '',
+		status TEXT NOT NULL DEFAULT 'active', -- active, broadcast, cancelled
+		broadcast_txid TEXT NOT NULL DEFAULT '',
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_watchtower_client ON watchtower_bundles(client_peer, status);
+	CREATE INDEX IF NOT EXISTS idx_watchtower_status ON watchtower_bundles(status);
+
+	-- =========================================================================
+	-- Wallet spend ledger (daily spending limits)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS wallet_spends (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		policy_key TEXT NOT NULL,             -- Chain symbol, or SYMBOL:token for ERC-20
+		to_address TEXT NOT NULL,
+		amount TEXT NOT NULL,                 -- Smallest units, decimal string (fits wei)
+		txid TEXT NOT NULL,
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_wallet_spends_key ON wallet_spends(policy_key, created_at);
+
+	-- =========================================================================
+	-- Per-trade auto-claim policy overrides
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS auto_claim_overrides (
+		trade_id TEXT PRIMARY KEY,
+		mode TEXT NOT NULL,                   -- immediate, confirmations, manual
+		confirmations INTEGER NOT NULL DEFAULT 0,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- Counterparty claims gating auto-claims in confirmations mode
+	CREATE TABLE IF NOT EXISTS auto_claim_counterparty_claims (
+		trade_id TEXT PRIMARY KEY,
+		chain TEXT NOT NULL,                  -- Chain of their claim
+		txid TEXT NOT NULL,
+		updated_at INTEGER NOT NULL
+	);
+
+	-- =========================================================================
+	-- Deadlines of escrow top-ups requested over short counterparty funding
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS funding_variance_top_ups (
+		trade_id TEXT PRIMARY KEY,
+		deadline INTEGER NOT NULL
+	);
+
+	-- =========================================================================
+	-- Counterparty funding accepted before confirmation (zero-conf)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS zero_conf_acceptances (
+		trade_id TEXT PRIMARY KEY,
+		txid TEXT NOT NULL,                   -- Accepted funding transaction
+		fee_rate INTEGER NOT NULL DEFAULT 0,  -- sat/vB
+		first_seen_at INTEGER NOT NULL,
+		accepted_at INTEGER NOT NULL
+	);
+
+	-- =========================================================================
+	-- Audit log of events sent to WebSocket clients
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS event_log (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		type TEXT NOT NULL,
+		trade_id TEXT NOT NULL DEFAULT '',    -- Empty for node-wide events
+		data TEXT NOT NULL,                   -- JSON payload as sent
+		created_at INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_event_log_created ON event_log(created_at);
+	CREATE INDEX IF NOT EXISTS idx_event_log_trade ON event_log(trade_id, created_at);
+
+	-- =========================================================================
+	-- Peer path quality (latency and message loss)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS peer_quality (
+		peer_id TEXT PRIMARY KEY,
+		rtt_us INTEGER NOT NULL DEFAULT 0,          -- Smoothed ping round-trip time
+		stream_setup_us INTEGER NOT NULL DEFAULT 0, -- Smoothed direct stream open time
+		messages_sent INTEGER NOT NULL DEFAULT 0,
+		messages_lost INTEGER NOT NULL DEFAULT 0,   -- Sent without an ACK
+		updated_at INTEGER NOT NULL
+	);
+
+	-- =========================================================================
+	-- Imported output descriptors (watch-only or signing)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS wallet_descriptors (
+		id TEXT PRIMARY KEY,
+		symbol TEXT NOT NULL,
+		descriptor TEXT NOT NULL,       -- Public descriptor with checksum
+		label TEXT NOT NULL DEFAULT '',
+		watch_only INTEGER NOT NULL DEFAULT 1,
+		sealed_private BLOB,            -- Private descriptor sealed with the wallet key
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_wallet_descriptors_symbol ON wallet_descriptors(symbol);
+
+	-- =========================================================================
+	-- Compliance audit records (hash-chained, queued for delivery)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS compliance_records (
+		seq INTEGER PRIMARY KEY,        -- Position in the hash chain
+		trade_id TEXT NOT NULL,
+		checkpoint TEXT NOT NULL,
+		hash TEXT NOT NULL,             -- Hex SHA-256 over the record, chained via prev_hash
+		payload TEXT NOT NULL,          -- Signed record JSON as delivered
+		created_at INTEGER NOT NULL,
+		delivered_at INTEGER,           -- NULL until the endpoint accepts it
+		attempts INTEGER NOT NULL DEFAULT 0,
+		last_error TEXT NOT NULL DEFAULT ''
+	);
+	CREATE INDEX IF NOT EXISTS idx_compliance_records_trade ON compliance_records(trade_id);
+
+	-- =========================================================================
+	-- Index of swaps moved to archive files
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS swap_archive (
+		trade_id TEXT PRIMARY KEY,
+		order_id TEXT NOT NULL,
+		maker_peer_id TEXT NOT NULL,
+		taker_peer_id TEXT NOT NULL,
+		offer_chain TEXT NOT NULL,
+		request_chain TEXT NOT NULL,
+		state TEXT NOT NULL,
+		completed_at INTEGER NOT NULL,  -- Completion (or last update) time
+		file TEXT NOT NULL,             -- Archive file name in the archive dir
+		archived_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_swap_archive_order ON swap_archive(order_id);
+	CREATE INDEX IF NOT EXISTS idx_swap_archive_completed ON swap_archive(completed_at);
+
+	-- =========================================================================
+	-- Snapshot of the pending swaps for fast startup
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS swap_snapshot (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		schema TEXT NOT NULL,           -- Fingerprint of the SwapRecord fields
+		taken_at INTEGER NOT NULL,
+		swaps INTEGER NOT NULL,
+		data BLOB NOT NULL              -- gzip-compressed JSON of the records
+	);
+
+	-- =========================================================================
+	-- Keys and wallet paths used by each swap (public material only)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS swap_key_usage (
+		trade_id TEXT NOT NULL,
+		purpose TEXT NOT NULL,              -- swap_key, receive, refund, funding_input, change
+		chain TEXT NOT NULL DEFAULT '',     -- Empty for a key used on every Bitcoin-family leg
+		address TEXT NOT NULL DEFAULT '',
+		pubkey TEXT NOT NULL DEFAULT '',    -- Hex, for ephemeral keys
+		derivation_path TEXT NOT NULL DEFAULT '',
+		recovery TEXT NOT NULL,             -- seed, backup or external
+		created_at INTEGER NOT NULL,
+		PRIMARY KEY (trade_id, purpose, chain, address, pubkey)
+	);
+
+	-- =========================================================================
+	-- Wallet transaction history (transactions the node makes on its own)
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS wallet_transactions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		chain TEXT NOT NULL,
+		kind TEXT NOT NULL,                 -- consolidation, send
+		txid TEXT NOT NULL,
+		inputs INTEGER NOT NULL DEFAULT 0,
+		amount INTEGER NOT NULL DEFAULT 0,  -- Smallest units received by address
+		fee INTEGER NOT NULL DEFAULT 0,
+		fee_rate INTEGER NOT NULL DEFAULT 0,
+		address TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_wallet_transactions_chain ON wallet_transactions(chain, created_at);
+
+	-- =========================================================================
+	-- Trades combined into one aggregate swap
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS trade_aggregates (
+		trade_id TEXT PRIMARY KEY,          -- Member trade, aborted in favor of the aggregate
+		aggregate_id TEXT NOT NULL,         -- Combined trade executing the swap
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_trade_aggregates_aggregate ON trade_aggregates(aggregate_id);
+
+	-- =========================================================================
+	-- Why swaps failed
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS swap_failures (
+		trade_id TEXT PRIMARY KEY,
+		category TEXT NOT NULL,             -- swap.FailureCategory, first cause recorded
+		reason TEXT NOT NULL DEFAULT '',
+		created_at INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_swap_failures_created ON swap_failures(created_at);
+
+	-- =========================================================================
+	-- Records set aside by storage_fsck
+	-- =========================================================================
+
+	CREATE TABLE IF NOT EXISTS quarantine (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		table_name TEXT NOT NULL,
+		record_key TEXT NOT NULL,
+		problem TEXT NOT NULL,
+		data TEXT NOT NULL,                 -- The record's columns (JSON)
+		quarantined_at INTEGER NOT NULL
+	);
 	`
 
 	_, err := s.db.Exec(schema)
@@ -510,6 +851,22 @@ func (s *Storage) runMigrations() error {
 	migrations := []string{
 		"ALTER TABLE secrets ADD COLUMN remote_offer_wallet_addr TEXT",
 		"ALTER TABLE secrets ADD COLUMN remote_request_wallet_addr TEXT",
+		"ALTER TABLE orders ADD COLUMN fee_terms TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE active_swaps ADD COLUMN fee_terms TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE trades ADD COLUMN payout_address TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE orders ADD COLUMN identity TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE orders ADD COLUMN offer_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE orders ADD COLUMN request_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE active_swaps ADD COLUMN offer_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE active_swaps ADD COLUMN request_token TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE orders ADD COLUMN settlement TEXT NOT NULL DEFAULT ''",
+		"ALTER TABLE trades ADD COLUMN fee_adjustment INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE orders ADD COLUMN filled_amount INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE orders ADD COLUMN min_fill_amount INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE trades ADD COLUMN fill_released INTEGER NOT NULL DEFAULT 0",
+		"ALTER TABLE orders ADD COLUMN fill_seq INTEGER NOT NULL DEFAULT 0",
+		// Register the hashes of swaps from before the secret hash registry
+		"INSERT OR IGNORE INTO secret_hashes (secret_hash, trade_id, first_seen) SELECT lower(secret_hash), trade_id, created_at FROM secrets",
 	}
 
 	for _, migration := range migrations {
@@ -0,0 +1,278 @@
+// Package storage - Index of swaps moved to cold storage archive files.
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrArchivedSwapNotFound is returned when a trade is not in the archive.
+var ErrArchivedSwapNotFound = errors.New("archived swap not found")
+
+// terminalSwapStates are the states a swap is archived in.
+const terminalSwapStates = "'redeemed', 'refunded', 'failed', 'cancelled'"
+
+// ArchivedSwap is the index entry of a swap stored in an archive file.
+type ArchivedSwap struct {
+	TradeID      string    `json:"trade_id"`
+	OrderID      string    `json:"order_id"`
+	MakerPeerID  string    `json:"maker_peer_id"`
+	TakerPeerID  string    `json:"taker_peer_id"`
+	OfferChain   string    `json:"offer_chain"`
+	RequestChain string    `json:"request_chain"`
+	State        SwapState `json:"state"`
+	CompletedAt  time.Time `json:"completed_at"`
+	File         string    `json:"file"`
+	ArchivedAt   time.Time `json:"archived_at"`
+}
+
+// ArchiveFilter selects archived swaps. Empty fields match everything.
+type ArchiveFilter struct {
+	TradeID string
+	OrderID string
+	PeerID  string // Maker or taker
+	Chain   string // Offer or request chain
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+}
+
+// swapFinishedAt is when a swap reached its terminal state; swaps that
+// failed before completion only have their last update time.
+func swapFinishedAt(r *SwapRecord) time.Time {
+	if !r.CompletedAt.IsZero() && r.CompletedAt.Unix() > 0 {
+		return r.CompletedAt
+	}
+	return r.UpdatedAt
+}
+
+// ListArchivableSwaps returns up to limit swaps in a terminal state that
+// finished before cutoff, oldest first.
+func (s *Storage) ListArchivableSwaps(cutoff time.Time, limit int) ([]*SwapRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT trade_id, order_id, maker_peer_id, taker_peer_id,
+			our_role, is_maker, offer_chain, offer_amount,
+			request_chain, request_amount, state, method_data,
+			local_funding_txid, local_funding_vout,
+			remote_funding_txid, remote_funding_vout,
+			timeout_height, request_timeout_height, timeout_timestamp,
+			redeem_txid, refund_txid, failure_reason,
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
+		FROM active_swaps
+		WHERE state IN (` + terminalSwapStates + `)
+			AND (CASE WHEN completed_at > 0 THEN completed_at ELSE updated_at END) < ?
+		ORDER BY (CASE WHEN completed_at > 0 THEN completed_at ELSE updated_at END) ASC
+	`
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+
+	rows, err := s.db.Query(query, cutoff.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list archivable swaps: %w", err)
+	}
+	defer rows.Close()
+
+	var swaps []*SwapRecord
+	for rows.Next() {
+		swap, err := scanSwapRecordRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, swap)
+	}
+	return swaps, rows.Err()
+}
+
+// MarkSwapsArchived indexes swaps written to an archive file and removes
+// them and their legs from the hot tables, in one transaction. Trades are
+// kept: they are small and feed the history and peer statistics.
+func (s *Storage) MarkSwapsArchived(file string, swaps []*SwapRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	for _, r := range swaps {
+		if _, err := tx.Exec(`
+			INSERT INTO swap_archive (
+				trade_id, order_id, maker_peer_id, taker_peer_id,
+				offer_chain, request_chain, state, completed_at, file, archived_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			ON CONFLICT(trade_id) DO UPDATE SET
+				state = excluded.state,
+				completed_at = excluded.completed_at,
+				file = excluded.file,
+				archived_at = excluded.archived_at
+		`, r.TradeID, r.OrderID, r.MakerPeerID, r.TakerPeerID,
+			r.OfferChain, r.RequestChain, string(r.State),
+			swapFinishedAt(r).Unix(), file, now,
+		); err != nil {
+			return fmt.Errorf("failed to index archived swap %s: %w", r.TradeID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM active_swaps WHERE trade_id = ?", r.TradeID); err != nil {
+			return fmt.Errorf("failed to remove archived swap %s: %w", r.TradeID, err)
+		}
+		if _, err := tx.Exec("DELETE FROM swap_legs WHERE trade_id = ?", r.TradeID); err != nil {
+			return fmt.Errorf("failed to remove archived swap legs %s: %w", r.TradeID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetArchivedSwap returns the index entry of an archived swap.
+func (s *Storage) GetArchivedSwap(tradeID string) (*ArchivedSwap, error) {
+	list, err := s.SearchArchivedSwaps(ArchiveFilter{TradeID: tradeID, Limit: 1})
+	if err != nil {
+		return nil, err
+	}
+	if len(list) == 0 {
+		return nil, ErrArchivedSwapNotFound
+	}
+	return list[0], nil
+}
+
+// SearchArchivedSwaps returns archived swaps matching filter, most recently
+// completed first.
+func (s *Storage) SearchArchivedSwaps(filter ArchiveFilter) ([]*ArchivedSwap, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var where []string
+	var args []interface{}
+	if filter.TradeID != "" {
+		where = append(where, "trade_id = ?")
+		args = append(args, filter.TradeID)
+	}
+	if filter.OrderID != "" {
+		where = append(where, "order_id = ?")
+		args = append(args, filter.OrderID)
+	}
+	if filter.PeerID != "" {
+		where = append(where, "(maker_peer_id = ? OR taker_peer_id = ?)")
+		args = append(args, filter.PeerID, filter.PeerID)
+	}
+	if filter.Chain != "" {
+		where = append(where, "(offer_chain = ? OR request_chain = ?)")
+		args = append(args, filter.Chain, filter.Chain)
+	}
+	if !filter.Since.IsZero() {
+		where = append(where, "completed_at >= ?")
+		args = append(args, filter.Since.Unix())
+	}
+	if !filter.Until.IsZero() {
+		where = append(where, "completed_at < ?")
+		args = append(args, filter.Until.Unix())
+	}
+
+	query := `
+		SELECT trade_id, order_id, maker_peer_id, taker_peer_id,
+			offer_chain, request_chain, state, completed_at, file, archived_at
+		FROM swap_archive
+	`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY completed_at DESC"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search archived swaps: %w", err)
+	}
+	defer rows.Close()
+
+	var list []*ArchivedSwap
+	for rows.Next() {
+		var a ArchivedSwap
+		var completedAt, archivedAt int64
+		if err := rows.Scan(&a.TradeID, &a.OrderID, &a.MakerPeerID, &a.TakerPeerID,
+			&a.OfferChain, &a.RequestChain, &a.State, &completedAt, &a.File, &archivedAt); err != nil {
+			return nil, err
+		}
+		a.CompletedAt = time.Unix(completedAt, 0)
+		a.ArchivedAt = time.Unix(archivedAt, 0)
+		list = append(list, &a)
+	}
+	return list, rows.Err()
+}
+
+// RestoreArchivedSwap puts an archived swap and its legs back into the hot
+// tables and drops its index entry, in one transaction.
+func (s *Storage) RestoreArchivedSwap(swap *SwapRecord, legs []*SwapLeg) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`
+		INSERT OR REPLACE INTO active_swaps (
+			trade_id, order_id, maker_peer_id, taker_peer_id,
+			our_role, is_maker, offer_chain, offer_amount,
+			request_chain, request_amount, state, method_data,
+			local_funding_txid, local_funding_vout,
+			remote_funding_txid, remote_funding_vout,
+			timeout_height, request_timeout_height, timeout_timestamp,
+			redeem_txid, refund_txid, failure_reason,
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`,
+		swap.TradeID, swap.OrderID, swap.MakerPeerID, swap.TakerPeerID,
+		swap.OurRole, boolToInt(swap.IsMaker), swap.OfferChain, swap.OfferAmount,
+		swap.RequestChain, swap.RequestAmount, string(swap.State), string(swap.MethodData),
+		swap.LocalFundingTxID, swap.LocalFundingVout,
+		swap.RemoteFundingTxID, swap.RemoteFundingVout,
+		swap.TimeoutHeight, swap.RequestTimeoutHeight, swap.TimeoutTimestamp,
+		swap.RedeemTxID, swap.RefundTxID, swap.FailureReason,
+		swap.CreatedAt.Unix(), swap.UpdatedAt.Unix(), timeToUnixOrZero(swap.CompletedAt),
+		string(swap.FeeTerms), swap.OfferToken, swap.RequestToken,
+	); err != nil {
+		return fmt.Errorf("failed to restore swap: %w", err)
+	}
+
+	for _, leg := range legs {
+		var methodData *string
+		if len(leg.MethodData) > 0 && string(leg.MethodData) != "null" {
+			md := string(leg.MethodData)
+			methodData = &md
+		}
+		if _, err := tx.Exec(`
+			INSERT OR REPLACE INTO swap_legs (
+				id, trade_id, leg_type, chain, amount, our_role, state,
+				funding_txid, funding_vout, funding_confirms, funding_address,
+				redeem_txid, refund_txid, timeout_height, timeout_timestamp,
+				method_data, created_at, updated_at
+			) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`,
+			leg.ID, leg.TradeID, leg.LegType, leg.Chain, leg.Amount, leg.OurRole, leg.State,
+			nullString(leg.FundingTxID), leg.FundingVout, leg.FundingConfirms, nullString(leg.FundingAddress),
+			nullString(leg.RedeemTxID), nullString(leg.RefundTxID), leg.TimeoutHeight, leg.TimeoutTimestamp,
+			methodData, leg.CreatedAt.Unix(), unixOrNull(leg.UpdatedAt),
+		); err != nil {
+			return fmt.Errorf("failed to restore swap leg %s: %w", leg.ID, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM swap_archive WHERE trade_id = ?", swap.TradeID); err != nil {
+		return fmt.Errorf("failed to drop archive index entry: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,79 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSwapArchiveIndex(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	old := time.Now().Add(-48 * time.Hour)
+	swaps := []*SwapRecord{
+		{TradeID: "a", OrderID: "o1", MakerPeerID: "makerA", TakerPeerID: "us", OurRole: "taker", OfferChain: "BTC", RequestChain: "LTC", State: SwapStateRedeemed, CompletedAt: old},
+		{TradeID: "b", OrderID: "o2", MakerPeerID: "us", TakerPeerID: "takerB", OurRole: "maker", IsMaker: true, OfferChain: "ETH", RequestChain: "BTC", State: SwapStateRefunded, CompletedAt: old.Add(time.Hour)},
+		{TradeID: "c", OrderID: "o3", MakerPeerID: "makerA", TakerPeerID: "us", OurRole: "taker", OfferChain: "BTC", RequestChain: "LTC", State: SwapStateFunded},
+		{TradeID: "d", OrderID: "o4", MakerPeerID: "makerA", TakerPeerID: "us", OurRole: "taker", OfferChain: "BTC", RequestChain: "LTC", State: SwapStateRedeemed, CompletedAt: time.Now()},
+	}
+	for _, r := range swaps {
+		r.MethodData = []byte(`{}`)
+		if err := store.SaveSwap(r); err != nil {
+			t.Fatalf("SaveSwap(%s) error = %v", r.TradeID, err)
+		}
+	}
+
+	due, err := store.ListArchivableSwaps(time.Now().Add(-24*time.Hour), 0)
+	if err != nil {
+		t.Fatalf("ListArchivableSwaps() error = %v", err)
+	}
+	if len(due) != 2 || due[0].TradeID != "a" || due[1].TradeID != "b" {
+		t.Fatalf("ListArchivableSwaps() = %d swaps, want a and b oldest first", len(due))
+	}
+
+	if err := store.MarkSwapsArchived("swaps-1.json.gz", due); err != nil {
+		t.Fatalf("MarkSwapsArchived() error = %v", err)
+	}
+	if _, err := store.GetSwap("a"); !errors.Is(err, ErrSwapNotFound) {
+		t.Errorf("GetSwap(a) after archival error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter ArchiveFilter
+		want   int
+	}{
+		{"all", ArchiveFilter{}, 2},
+		{"trade", ArchiveFilter{TradeID: "b"}, 1},
+		{"order", ArchiveFilter{OrderID: "o1"}, 1},
+		{"peer", ArchiveFilter{PeerID: "takerB"}, 1},
+		{"chain", ArchiveFilter{Chain: "BTC"}, 2},
+		{"since", ArchiveFilter{Since: old.Add(30 * time.Minute)}, 1},
+		{"limit", ArchiveFilter{Limit: 1}, 1},
+	}
+	for _, tt := range tests {
+		got, err := store.SearchArchivedSwaps(tt.filter)
+		if err != nil {
+			t.Fatalf("%s: SearchArchivedSwaps() error = %v", tt.name, err)
+		}
+		if len(got) != tt.want {
+			t.Errorf("%s: SearchArchivedSwaps() = %d, want %d", tt.name, len(got), tt.want)
+		}
+	}
+
+	entry, err := store.GetArchivedSwap("b")
+	if err != nil || entry.File != "swaps-1.json.gz" || entry.State != SwapStateRefunded {
+		t.Errorf("GetArchivedSwap(b) = %+v, %v", entry, err)
+	}
+
+	if err := store.RestoreArchivedSwap(due[0], nil); err != nil {
+		t.Fatalf("RestoreArchivedSwap() error = %v", err)
+	}
+	if _, err := store.GetSwap("a"); err != nil {
+		t.Errorf("GetSwap(a) after restore error = %v", err)
+	}
+	if _, err := store.GetArchivedSwap("a"); !errors.Is(err, ErrArchivedSwapNotFound) {
+		t.Errorf("GetArchivedSwap(a) after restore error = %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+// Package storage - Categorized swap failures.
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// SwapFailure is the failure category recorded for a trade.
+type SwapFailure struct {
+	TradeID   string `json:"trade_id"`
+	Category  string `json:"category"`
+	Reason    string `json:"reason,omitempty"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// RecordSwapFailure records why a trade failed. Only the first failure of a
+// trade is kept, since later ones (a refund after an abort) follow from it;
+// recorded reports whether this one was.
+func (s *Storage) RecordSwapFailure(f *SwapFailure) (recorded bool, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if f.CreatedAt == 0 {
+		f.CreatedAt = time.Now().Unix()
+	}
+	result, err := s.db.Exec(`
+		INSERT OR IGNORE INTO swap_failures (trade_id, category, reason, created_at)
+		VALUES (?, ?, ?, ?)
+	`, f.TradeID, f.Category, f.Reason, f.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record swap failure: %w", err)
+	}
+	rows, _ := result.RowsAffected()
+	return rows > 0, nil
+}
+
+// CountSwapFailures counts the failures recorded at or after since (unix
+// seconds) by category.
+func (s *Storage) CountSwapFailures(since int64) (map[string]int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT category, COUNT(*) FROM swap_failures WHERE created_at >= ? GROUP BY category
+	`, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count swap failures: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var category string
+		var count int
+		if err := rows.Scan(&category, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan swap failure count: %w", err)
+		}
+		counts[category] = count
+	}
+	return counts, rows.Err()
+}
+
+// ListSwapFailures returns the failures recorded at or after since, newest
+// first, of one category or of all for an empty category. limit <= 0
+// returns all.
+func (s *Storage) ListSwapFailures(since int64, category string, limit int) ([]*SwapFailure, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT trade_id, category, reason, created_at
+		FROM swap_failures WHERE created_at >= ?`
+	args := []interface{}{since}
+	if category != "" {
+		query += ` AND category = ?`
+		args = append(args, category)
+	}
+	query += ` ORDER BY created_at DESC, rowid DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swap failures: %w", err)
+	}
+	defer rows.Close()
+
+	failures := make([]*SwapFailure, 0)
+	for rows.Next() {
+		var f SwapFailure
+		if err := rows.Scan(&f.TradeID, &f.Category, &f.Reason, &f.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan swap failure: %w", err)
+		}
+		failures = append(failures, &f)
+	}
+	return failures, rows.Err()
+}
@@ -0,0 +1,48 @@
+package storage
+
+import "testing"
+
+func TestSwapFailures(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	for _, f := range []*SwapFailure{
+		{TradeID: "t1", Category: "peer_timeout", CreatedAt: 100},
+		{TradeID: "t2", Category: "funding_insufficient", Reason: "escrow short", CreatedAt: 200},
+		{TradeID: "t3", Category: "peer_timeout", CreatedAt: 300},
+	} {
+		recorded, err := store.RecordSwapFailure(f)
+		if err != nil || !recorded {
+			t.Fatalf("RecordSwapFailure() = %v, %v", recorded, err)
+		}
+	}
+
+	// The first cause of a trade is kept
+	recorded, err := store.RecordSwapFailure(&SwapFailure{TradeID: "t2", Category: "peer_timeout", CreatedAt: 400})
+	if err != nil || recorded {
+		t.Errorf("second RecordSwapFailure() = %v, %v, want not recorded", recorded, err)
+	}
+
+	counts, err := store.CountSwapFailures(0)
+	if err != nil {
+		t.Fatalf("CountSwapFailures() error = %v", err)
+	}
+	if counts["peer_timeout"] != 2 || counts["funding_insufficient"] != 1 || len(counts) != 2 {
+		t.Errorf("CountSwapFailures(0) = %v", counts)
+	}
+	if counts, _ := store.CountSwapFailures(250); counts["peer_timeout"] != 1 || counts["funding_insufficient"] != 0 {
+		t.Errorf("CountSwapFailures(250) = %v", counts)
+	}
+
+	all, err := store.ListSwapFailures(0, "", 0)
+	if err != nil {
+		t.Fatalf("ListSwapFailures() error = %v", err)
+	}
+	if len(all) != 3 || all[0].TradeID != "t3" || all[1].Reason != "escrow short" {
+		t.Errorf("ListSwapFailures() = %+v, want t3 first", all)
+	}
+	timeouts, _ := store.ListSwapFailures(0, "peer_timeout", 1)
+	if len(timeouts) != 1 || timeouts[0].TradeID != "t3" {
+		t.Errorf("ListSwapFailures(peer_timeout, 1) = %+v", timeouts)
+	}
+}
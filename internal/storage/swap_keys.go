@@ -0,0 +1,85 @@
+// Package storage - Audit of the keys and wallet paths used by each swap.
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Key usage purposes.
+const (
+	KeyPurposeSwapKey      = "swap_key"      // Ephemeral MuSig2/HTLC key
+	KeyPurposeReceive      = "receive"       // Where we receive the counterparty's coins
+	KeyPurposeRefund       = "refund"        // Our address on the chain we fund
+	KeyPurposeFundingInput = "funding_input" // Wallet address spent by the funding transaction
+	KeyPurposeChange       = "change"        // Change output of the funding transaction
+)
+
+// How funds controlled by a key can be recovered.
+const (
+	KeyRecoverySeed     = "seed"     // Derivable from the wallet seed alone
+	KeyRecoveryBackup   = "backup"   // Random; only in the database and its backups
+	KeyRecoveryExternal = "external" // Not our key, e.g. an external payout address
+)
+
+// SwapKeyUsage records one key or wallet address a swap used. It never
+// holds private material: ephemeral keys are identified by their public key
+// and wallet addresses by their derivation path.
+type SwapKeyUsage struct {
+	TradeID        string `json:"trade_id"`
+	Purpose        string `json:"purpose"`
+	Chain          string `json:"chain,omitempty"`
+	Address        string `json:"address,omitempty"`
+	PubKey         string `json:"pubkey,omitempty"`
+	DerivationPath string `json:"derivation_path,omitempty"`
+	Recovery       string `json:"recovery"`
+	CreatedAt      int64  `json:"created_at"`
+}
+
+// RecordSwapKeyUsage stores a key usage. Recording the same usage again
+// keeps the first record.
+func (s *Storage) RecordSwapKeyUsage(u *SwapKeyUsage) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if u.CreatedAt == 0 {
+		u.CreatedAt = time.Now().Unix()
+	}
+	_, err := s.db.Exec(`
+		INSERT OR IGNORE INTO swap_key_usage (
+			trade_id, purpose, chain, address, pubkey, derivation_path, recovery, created_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, u.TradeID, u.Purpose, u.Chain, u.Address, u.PubKey, u.DerivationPath, u.Recovery, u.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record swap key usage: %w", err)
+	}
+	return nil
+}
+
+// GetSwapKeyUsage returns the keys a trade used, oldest first.
+func (s *Storage) GetSwapKeyUsage(tradeID string) ([]*SwapKeyUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT trade_id, purpose, chain, address, pubkey, derivation_path, recovery, created_at
+		FROM swap_key_usage
+		WHERE trade_id = ?
+		ORDER BY created_at, rowid
+	`, tradeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query swap key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usages := make([]*SwapKeyUsage, 0)
+	for rows.Next() {
+		var u SwapKeyUsage
+		if err := rows.Scan(&u.TradeID, &u.Purpose, &u.Chain, &u.Address, &u.PubKey,
+			&u.DerivationPath, &u.Recovery, &u.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan swap key usage: %w", err)
+		}
+		usages = append(usages, &u)
+	}
+	return usages, rows.Err()
+}
@@ -0,0 +1,46 @@
+package storage
+
+import "testing"
+
+func TestSwapKeyUsage(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	usages := []*SwapKeyUsage{
+		{TradeID: "trade-1", Purpose: KeyPurposeSwapKey, PubKey: "02aa", Recovery: KeyRecoveryBackup, CreatedAt: 100},
+		{TradeID: "trade-1", Purpose: KeyPurposeReceive, Chain: "LTC", Address: "tltc1qreceive",
+			DerivationPath: "m/84'/1'/0'/0/3", Recovery: KeyRecoverySeed, CreatedAt: 101},
+		{TradeID: "trade-2", Purpose: KeyPurposeSwapKey, PubKey: "02bb", Recovery: KeyRecoveryBackup, CreatedAt: 100},
+	}
+	for _, u := range usages {
+		if err := store.RecordSwapKeyUsage(u); err != nil {
+			t.Fatalf("RecordSwapKeyUsage() error = %v", err)
+		}
+	}
+
+	// Recording the same usage again keeps the first record
+	if err := store.RecordSwapKeyUsage(&SwapKeyUsage{
+		TradeID: "trade-1", Purpose: KeyPurposeSwapKey, PubKey: "02aa", Recovery: KeyRecoveryBackup, CreatedAt: 200,
+	}); err != nil {
+		t.Fatalf("RecordSwapKeyUsage() duplicate error = %v", err)
+	}
+
+	got, err := store.GetSwapKeyUsage("trade-1")
+	if err != nil {
+		t.Fatalf("GetSwapKeyUsage() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("GetSwapKeyUsage() returned %d usages, want 2", len(got))
+	}
+	if got[0].Purpose != KeyPurposeSwapKey || got[0].PubKey != "02aa" || got[0].CreatedAt != 100 {
+		t.Errorf("usage[0] = %+v, want the first swap key record", got[0])
+	}
+	if got[1].DerivationPath != "m/84'/1'/0'/0/3" || got[1].Recovery != KeyRecoverySeed || got[1].Chain != "LTC" {
+		t.Errorf("usage[1] = %+v, want the receive address with its path", got[1])
+	}
+
+	none, err := store.GetSwapKeyUsage("missing")
+	if err != nil || len(none) != 0 {
+		t.Errorf("GetSwapKeyUsage(missing) = %v, %v; want empty", none, err)
+	}
+}
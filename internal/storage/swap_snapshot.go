@@ -0,0 +1,192 @@
+// Package storage - Compact snapshot of the pending swaps for fast startup.
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SwapSnapshotInfo describes the stored swap snapshot.
+type SwapSnapshotInfo struct {
+	TakenAt time.Time `json:"taken_at"`
+	Swaps   int       `json:"swaps"`
+	Size    int       `json:"size"` // Compressed bytes
+}
+
+// swapSnapshotSchema fingerprints the SwapRecord fields, so a snapshot
+// written before a release added a field is not loaded with it zeroed.
+var swapSnapshotSchema = func() string {
+	t := reflect.TypeOf(SwapRecord{})
+	fields := make([]string, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		fields = append(fields, f.Tag.Get("json")+":"+f.Type.String())
+	}
+	sum := sha256.Sum256([]byte(strings.Join(fields, ",")))
+	return hex.EncodeToString(sum[:8])
+}()
+
+// SaveSwapSnapshot stores all pending swaps as one compressed blob,
+// replacing the previous snapshot.
+func (s *Storage) SaveSwapSnapshot() (*SwapSnapshotInfo, error) {
+	// Updates during the read are newer than takenAt and so are picked up
+	// by the differential load
+	takenAt := time.Now()
+	records, err := s.GetPendingSwaps()
+	if err != nil {
+		return nil, err
+	}
+	if records == nil {
+		records = []*SwapRecord{}
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(records); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.db.Exec(`
+		INSERT INTO swap_snapshot (id, schema, taken_at, swaps, data) VALUES (1, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			schema = excluded.schema,
+			taken_at = excluded.taken_at,
+			swaps = excluded.swaps,
+			data = excluded.data
+	`, swapSnapshotSchema, takenAt.Unix(), len(records), buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to save swap snapshot: %w", err)
+	}
+	return &SwapSnapshotInfo{TakenAt: time.Unix(takenAt.Unix(), 0), Swaps: len(records), Size: buf.Len()}, nil
+}
+
+// loadSwapSnapshot returns the records of the stored snapshot, or nil if
+// there is none or it was written with other SwapRecord fields.
+func (s *Storage) loadSwapSnapshot() ([]*SwapRecord, *SwapSnapshotInfo, error) {
+	s.mu.RLock()
+	var schema string
+	var takenAt int64
+	var data []byte
+	err := s.db.QueryRow("SELECT schema, taken_at, data FROM swap_snapshot WHERE id = 1").Scan(&schema, &takenAt, &data)
+	s.mu.RUnlock()
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if schema != swapSnapshotSchema {
+		return nil, nil, nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, nil, fmt.Errorf("corrupt swap snapshot: %w", err)
+	}
+	raw, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("corrupt swap snapshot: %w", err)
+	}
+	var records []*SwapRecord
+	if err := json.Unmarshal(raw, &records); err != nil {
+		return nil, nil, fmt.Errorf("corrupt swap snapshot: %w", err)
+	}
+	return records, &SwapSnapshotInfo{TakenAt: time.Unix(takenAt, 0), Swaps: len(records), Size: len(data)}, nil
+}
+
+// GetSwapsUpdatedSince returns the swaps in any state updated at or after
+// since, oldest first.
+func (s *Storage) GetSwapsUpdatedSince(since time.Time) ([]*SwapRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT trade_id, order_id, maker_peer_id, taker_peer_id,
+			our_role, is_maker, offer_chain, offer_amount,
+			request_chain, request_amount, state, method_data,
+			local_funding_txid, local_funding_vout,
+			remote_funding_txid, remote_funding_vout,
+			timeout_height, request_timeout_height, timeout_timestamp,
+			redeem_txid, refund_txid, failure_reason,
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
+		FROM active_swaps
+		WHERE updated_at >= ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := s.db.Query(query, since.Unix())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var swaps []*SwapRecord
+	for rows.Next() {
+		swap, err := scanSwapRecordRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		swaps = append(swaps, swap)
+	}
+	return swaps, rows.Err()
+}
+
+// GetPendingSwapsFromSnapshot returns the pending swaps from the stored
+// snapshot plus the swaps updated since it was taken, which is cheaper than
+// GetPendingSwaps when most swaps did not change. Without a usable snapshot
+// it falls back to GetPendingSwaps and returns a nil info.
+func (s *Storage) GetPendingSwapsFromSnapshot() ([]*SwapRecord, *SwapSnapshotInfo, error) {
+	snapshot, info, err := s.loadSwapSnapshot()
+	if err != nil || info == nil {
+		records, pendingErr := s.GetPendingSwaps()
+		if pendingErr != nil {
+			return nil, nil, pendingErr
+		}
+		return records, nil, nil
+	}
+
+	changed, err := s.GetSwapsUpdatedSince(info.TakenAt)
+	if err != nil {
+		return nil, nil, err
+	}
+	byID := make(map[string]*SwapRecord, len(snapshot)+len(changed))
+	for _, r := range snapshot {
+		byID[r.TradeID] = r
+	}
+	for _, r := range changed {
+		if isTerminalState(r.State) {
+			delete(byID, r.TradeID)
+			continue
+		}
+		byID[r.TradeID] = r
+	}
+
+	records := make([]*SwapRecord, 0, len(byID))
+	for _, r := range byID {
+		records = append(records, r)
+	}
+	sort.Slice(records, func(i, j int) bool {
+		if records[i].CreatedAt.Equal(records[j].CreatedAt) {
+			return records[i].TradeID < records[j].TradeID
+		}
+		return records[i].CreatedAt.Before(records[j].CreatedAt)
+	})
+	return records, info, nil
+}
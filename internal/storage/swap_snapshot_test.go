@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"testing"
+)
+
+func TestSwapSnapshot(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	// Without a snapshot the pending swaps come from the database
+	for _, id := range []string{"trade-1", "trade-2", "trade-3"} {
+		if err := store.SaveSwap(createTestSwapRecord(id)); err != nil {
+			t.Fatalf("SaveSwap() error = %v", err)
+		}
+	}
+	records, info, err := store.GetPendingSwapsFromSnapshot()
+	if err != nil || info != nil || len(records) != 3 {
+		t.Fatalf("GetPendingSwapsFromSnapshot() = %d records, %+v, %v, want 3 without snapshot", len(records), info, err)
+	}
+
+	saved, err := store.SaveSwapSnapshot()
+	if err != nil {
+		t.Fatalf("SaveSwapSnapshot() error = %v", err)
+	}
+	if saved.Swaps != 3 || saved.Size == 0 {
+		t.Errorf("SaveSwapSnapshot() = %+v", saved)
+	}
+
+	// An update that predates the snapshot is not read again: trade-1
+	// comes from the snapshot
+	if _, err := store.db.Exec("UPDATE active_swaps SET state = 'funding', updated_at = 0 WHERE trade_id = 'trade-1'"); err != nil {
+		t.Fatal(err)
+	}
+	// Updates since the snapshot are
+	done := createTestSwapRecord("trade-2")
+	done.State = SwapStateRedeemed
+	if err := store.SaveSwap(done); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+	funded := createTestSwapRecord("trade-3")
+	funded.State = SwapStateFunded
+	if err := store.SaveSwap(funded); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+	if err := store.SaveSwap(createTestSwapRecord("trade-4")); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+
+	records, info, err = store.GetPendingSwapsFromSnapshot()
+	if err != nil || info == nil || info.Swaps != 3 {
+		t.Fatalf("GetPendingSwapsFromSnapshot() info = %+v, %v", info, err)
+	}
+	states := make(map[string]SwapState)
+	for _, r := range records {
+		states[r.TradeID] = r.State
+	}
+	want := map[string]SwapState{"trade-1": SwapStateInit, "trade-3": SwapStateFunded, "trade-4": SwapStateInit}
+	if len(states) != len(want) {
+		t.Fatalf("records = %v, want %v", states, want)
+	}
+	for id, state := range want {
+		if states[id] != state {
+			t.Errorf("%s state = %q, want %q", id, states[id], state)
+		}
+	}
+
+	// A snapshot of other SwapRecord fields is ignored
+	if _, err := store.db.Exec("UPDATE swap_snapshot SET schema = 'old'"); err != nil {
+		t.Fatal(err)
+	}
+	records, info, err = store.GetPendingSwapsFromSnapshot()
+	if err != nil || info != nil || len(records) != 3 {
+		t.Errorf("GetPendingSwapsFromSnapshot() with an old snapshot = %d records, %+v, %v", len(records), info, err)
+	}
+
+	// Deleting a swap drops the snapshot so it cannot be restored
+	if _, err := store.SaveSwapSnapshot(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.DeleteSwap("trade-4"); err != nil {
+		t.Fatalf("DeleteSwap() error = %v", err)
+	}
+	if _, info, _ = store.GetPendingSwapsFromSnapshot(); info != nil {
+		t.Error("snapshot survived DeleteSwap()")
+	}
+}
@@ -9,6 +9,8 @@ import (
 	"errors"
 	"fmt"
 	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
 )
 
 // Swap persistence errors
@@ -56,6 +58,13 @@ type SwapRecord struct {
 	// State
 	State SwapState `json:"state"`
 
+	// ERC-20 contract addresses (empty = native coin)
+	OfferToken   string `json:"offer_token,omitempty"`
+	RequestToken string `json:"request_token,omitempty"`
+
+	// Negotiated fee split (JSON blob, empty = protocol defaults)
+	FeeTerms json.RawMessage `json:"fee_terms,omitempty"`
+
 	// MuSig2 data (JSON blob - contains keys, nonces, etc.)
 	// This is the critical data for recovery
 	MethodData json.RawMessage `json:"method_data"`
@@ -85,6 +94,9 @@ type SwapRecord struct {
 // SaveSwap saves or updates a swap record.
 // Uses UPSERT pattern - creates if not exists, updates if exists.
 func (s *Storage) SaveSwap(swap *SwapRecord) error {
+	if err := chaos.StorageError("save_swap"); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -103,8 +115,9 @@ func (s *Storage) SaveSwap(swap *SwapRecord) error {
 			remote_funding_txid, remote_funding_vout,
 			timeout_height, request_timeout_height, timeout_timestamp,
 			redeem_txid, refund_txid, failure_reason,
-			created_at, updated_at, completed_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(trade_id) DO UPDATE SET
 			state = excluded.state,
 			method_data = excluded.method_data,
@@ -148,6 +161,9 @@ func (s *Storage) SaveSwap(swap *SwapRecord) error {
 		swap.CreatedAt.Unix(),
 		swap.UpdatedAt.Unix(),
 		timeToUnixOrZero(swap.CompletedAt),
+		string(swap.FeeTerms),
+		swap.OfferToken,
+		swap.RequestToken,
 	)
 	return err
 }
@@ -165,7 +181,8 @@ func (s *Storage) GetSwap(tradeID string) (*SwapRecord, error) {
 			remote_funding_txid, remote_funding_vout,
 			timeout_height, request_timeout_height, timeout_timestamp,
 			redeem_txid, refund_txid, failure_reason,
-			created_at, updated_at, completed_at
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
 		FROM active_swaps WHERE trade_id = ?
 	`
 
@@ -187,7 +204,8 @@ func (s *Storage) GetPendingSwaps() ([]*SwapRecord, error) {
 			remote_funding_txid, remote_funding_vout,
 			timeout_height, request_timeout_height, timeout_timestamp,
 			redeem_txid, refund_txid, failure_reason,
-			created_at, updated_at, completed_at
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
 		FROM active_swaps
 		WHERE state NOT IN ('redeemed', 'refunded', 'failed', 'cancelled')
 		ORDER BY created_at ASC
@@ -227,7 +245,8 @@ func (s *Storage) GetSwapsNearingTimeout(currentHeight uint32, safetyMargin uint
 			remote_funding_txid, remote_funding_vout,
 			timeout_height, request_timeout_height, timeout_timestamp,
 			redeem_txid, refund_txid, failure_reason,
-			created_at, updated_at, completed_at
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
 		FROM active_swaps
 		WHERE state IN ('funded', 'signing')
 		AND timeout_height > 0
@@ -267,7 +286,8 @@ func (s *Storage) GetSwapsPastTimeout(currentHeight uint32) ([]*SwapRecord, erro
 			remote_funding_txid, remote_funding_vout,
 			timeout_height, request_timeout_height, timeout_timestamp,
 			redeem_txid, refund_txid, failure_reason,
-			created_at, updated_at, completed_at
+			created_at, updated_at, completed_at, fee_terms,
+			offer_token, request_token
 		FROM active_swaps
 		WHERE state IN ('funded', 'signing')
 		AND timeout_height > 0
@@ -295,6 +315,9 @@ func (s *Storage) GetSwapsPastTimeout(currentHeight uint32) ([]*SwapRecord, erro
 
 // UpdateSwapState updates the state of a swap.
 func (s *Storage) UpdateSwapState(tradeID string, state SwapState) error {
+	if err := chaos.StorageError("update_swap_state"); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -328,6 +351,9 @@ func (s *Storage) UpdateSwapState(tradeID string, state SwapState) error {
 
 // UpdateSwapMethodData updates the method_data JSON blob for a swap.
 func (s *Storage) UpdateSwapMethodData(tradeID string, methodData json.RawMessage) error {
+	if err := chaos.StorageError("update_swap_method_data"); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -351,6 +377,9 @@ func (s *Storage) UpdateSwapMethodData(tradeID string, methodData json.RawMessag
 
 // UpdateSwapFunding updates funding transaction info for a swap.
 func (s *Storage) UpdateSwapFunding(tradeID string, isLocal bool, txid string, vout uint32) error {
+	if err := chaos.StorageError("update_swap_funding"); err != nil {
+		return err
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -383,7 +412,11 @@ func (s *Storage) DeleteSwap(tradeID string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	_, err := s.db.Exec("DELETE FROM active_swaps WHERE trade_id = ?", tradeID)
+	if _, err := s.db.Exec("DELETE FROM active_swaps WHERE trade_id = ?", tradeID); err != nil {
+		return err
+	}
+	// A deletion leaves no update behind, so the snapshot would restore it
+	_, err := s.db.Exec("DELETE FROM swap_snapshot")
 	return err
 }
 
@@ -402,7 +435,8 @@ func (s *Storage) ListSwaps(limit int, includeCompleted bool) ([]*SwapRecord, er
 				remote_funding_txid, remote_funding_vout,
 				timeout_height, request_timeout_height, timeout_timestamp,
 				redeem_txid, refund_txid, failure_reason,
-				created_at, updated_at, completed_at
+				created_at, updated_at, completed_at, fee_terms,
+				offer_token, request_token
 			FROM active_swaps
 			ORDER BY updated_at DESC
 		`
@@ -415,7 +449,8 @@ func (s *Storage) ListSwaps(limit int, includeCompleted bool) ([]*SwapRecord, er
 				remote_funding_txid, remote_funding_vout,
 				timeout_height, request_timeout_height, timeout_timestamp,
 				redeem_txid, refund_txid, failure_reason,
-				created_at, updated_at, completed_at
+				created_at, updated_at, completed_at, fee_terms,
+				offer_token, request_token
 			FROM active_swaps
 			WHERE state NOT IN ('redeemed', 'refunded', 'failed', 'cancelled')
 			ORDER BY updated_at DESC
@@ -477,6 +512,7 @@ func scanSwapRecord(row *sql.Row) (*SwapRecord, error) {
 	var isMaker int
 	var methodData, localFundingTxID, remoteFundingTxID, redeemTxID, refundTxID, failureReason sql.NullString
 	var createdAt, updatedAt, completedAt int64
+	var feeTerms string
 
 	err := row.Scan(
 		&swap.TradeID,
@@ -504,6 +540,9 @@ func scanSwapRecord(row *sql.Row) (*SwapRecord, error) {
 		&createdAt,
 		&updatedAt,
 		&completedAt,
+		&feeTerms,
+		&swap.OfferToken,
+		&swap.RequestToken,
 	)
 	if err != nil {
 		if err == sql.ErrNoRows {
@@ -513,6 +552,9 @@ func scanSwapRecord(row *sql.Row) (*SwapRecord, error) {
 	}
 
 	swap.IsMaker = isMaker == 1
+	if feeTerms != "" {
+		swap.FeeTerms = json.RawMessage(feeTerms)
+	}
 	if methodData.Valid {
 		swap.MethodData = json.RawMessage(methodData.String)
 	}
@@ -546,6 +588,7 @@ func scanSwapRecordRows(rows *sql.Rows) (*SwapRecord, error) {
 	var isMaker int
 	var methodData, localFundingTxID, remoteFundingTxID, redeemTxID, refundTxID, failureReason sql.NullString
 	var createdAt, updatedAt, completedAt int64
+	var feeTerms string
 
 	err := rows.Scan(
 		&swap.TradeID,
@@ -573,12 +616,18 @@ func scanSwapRecordRows(rows *sql.Rows) (*SwapRecord, error) {
 		&createdAt,
 		&updatedAt,
 		&completedAt,
+		&feeTerms,
+		&swap.OfferToken,
+		&swap.RequestToken,
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	swap.IsMaker = isMaker == 1
+	if feeTerms != "" {
+		swap.FeeTerms = json.RawMessage(feeTerms)
+	}
 	if methodData.Valid {
 		swap.MethodData = json.RawMessage(methodData.String)
 	}
@@ -569,3 +569,69 @@ func TestSwapNotFound(t *testing.T) {
 		t.Error("GetSwap(non-existent) should return nil")
 	}
 }
+
+func TestSwapFeeTerms(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	swap := createTestSwapRecord("trade-terms")
+	swap.FeeTerms = json.RawMessage(`{"claim_fee_payer":"taker","claim_fee_allowance":2000}`)
+	if err := store.SaveSwap(swap); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+	if err := store.SaveSwap(createTestSwapRecord("trade-default")); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+
+	got, err := store.GetSwap("trade-terms")
+	if err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	if string(got.FeeTerms) != string(swap.FeeTerms) {
+		t.Errorf("FeeTerms = %s, want %s", got.FeeTerms, swap.FeeTerms)
+	}
+
+	pending, err := store.GetPendingSwaps()
+	if err != nil {
+		t.Fatalf("GetPendingSwaps() error = %v", err)
+	}
+	for _, s := range pending {
+		if s.TradeID == "trade-default" && len(s.FeeTerms) != 0 {
+			t.Errorf("default swap FeeTerms = %s, want empty", s.FeeTerms)
+		}
+	}
+}
+
+func TestSwapTokens(t *testing.T) {
+	store, err := New(&Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	swap := createTestSwapRecord("trade-tokens")
+	swap.OfferToken = "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	swap.RequestToken = "0x55d398326f99059fF775485246999027B3197955"
+	if err := store.SaveSwap(swap); err != nil {
+		t.Fatalf("SaveSwap() error = %v", err)
+	}
+
+	got, err := store.GetSwap("trade-tokens")
+	if err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	if got.OfferToken != swap.OfferToken || got.RequestToken != swap.RequestToken {
+		t.Errorf("tokens = %q, %q, want %q, %q", got.OfferToken, got.RequestToken, swap.OfferToken, swap.RequestToken)
+	}
+
+	pending, err := store.GetPendingSwaps()
+	if err != nil {
+		t.Fatalf("GetPendingSwaps() error = %v", err)
+	}
+	if len(pending) != 1 || pending[0].RequestToken != swap.RequestToken {
+		t.Errorf("GetPendingSwaps() = %+v", pending)
+	}
+}
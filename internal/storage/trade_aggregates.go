@@ -0,0 +1,99 @@
+// Package storage - Trades combined into one aggregate swap.
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrTradeAggregated is returned when a trade was already combined into an
+// aggregate.
+var ErrTradeAggregated = errors.New("trade already aggregated")
+
+// AggregateTrades links the member trades to their aggregate and aborts
+// them, all or none. Members must still be in the init state.
+func (s *Storage) AggregateTrades(aggregateID string, memberIDs []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	now := time.Now().Unix()
+	reason := "aggregated into " + aggregateID
+	for _, id := range memberIDs {
+		var linked int
+		if err := tx.QueryRow(`SELECT COUNT(*) FROM trade_aggregates WHERE trade_id = ?`, id).Scan(&linked); err != nil {
+			return fmt.Errorf("failed to check trade %s: %w", id, err)
+		}
+		if linked > 0 {
+			return fmt.Errorf("%w: %s", ErrTradeAggregated, id)
+		}
+
+		result, err := tx.Exec(`
+			UPDATE trades SET state = ?, failure_reason = ?, updated_at = ?, completed_at = ?
+			WHERE id = ? AND state = ?
+		`, TradeStateAborted, reason, now, now, id, TradeStateInit)
+		if err != nil {
+			return fmt.Errorf("failed to abort trade %s: %w", id, err)
+		}
+		if rows, _ := result.RowsAffected(); rows == 0 {
+			return fmt.Errorf("%w: %s not in the init state", ErrTradeNotFound, id)
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO trade_aggregates (trade_id, aggregate_id, created_at) VALUES (?, ?, ?)
+		`, id, aggregateID, now); err != nil {
+			return fmt.Errorf("failed to link trade %s: %w", id, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAggregateMembers returns the IDs of the trades combined into an
+// aggregate, in the order they were linked.
+func (s *Storage) GetAggregateMembers(aggregateID string) ([]string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT trade_id FROM trade_aggregates WHERE aggregate_id = ? ORDER BY rowid
+	`, aggregateID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query aggregate members: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to scan aggregate member: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// GetTradeAggregate returns the aggregate a trade was combined into, or ""
+// when it wasn't.
+func (s *Storage) GetTradeAggregate(tradeID string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var id string
+	err := s.db.QueryRow(`SELECT aggregate_id FROM trade_aggregates WHERE trade_id = ?`, tradeID).Scan(&id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get trade aggregate: %w", err)
+	}
+	return id, nil
+}
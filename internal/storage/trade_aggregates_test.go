@@ -0,0 +1,63 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestAggregateTrades(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	for _, id := range []string{"t1", "t2", "t3"} {
+		if err := store.CreateTrade(&Trade{
+			ID: id, OrderID: "o-" + id, MakerPeerID: "maker", TakerPeerID: "taker",
+			Method: "htlc_bitcoin", State: TradeStateInit, CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+	if err := store.UpdateTradeState("t3", TradeStateFunding); err != nil {
+		t.Fatalf("UpdateTradeState() error = %v", err)
+	}
+
+	// A started member fails the whole aggregation
+	if err := store.AggregateTrades("agg0", []string{"t1", "t3"}); !errors.Is(err, ErrTradeNotFound) {
+		t.Fatalf("AggregateTrades() with a started trade error = %v, want ErrTradeNotFound", err)
+	}
+	if trade, _ := store.GetTrade("t1"); trade.State != TradeStateInit {
+		t.Errorf("t1 state after failed aggregation = %s, want init", trade.State)
+	}
+
+	if err := store.AggregateTrades("agg1", []string{"t2", "t1"}); err != nil {
+		t.Fatalf("AggregateTrades() error = %v", err)
+	}
+	for _, id := range []string{"t1", "t2"} {
+		trade, err := store.GetTrade(id)
+		if err != nil {
+			t.Fatalf("GetTrade() error = %v", err)
+		}
+		if trade.State != TradeStateAborted || trade.FailureReason != "aggregated into agg1" {
+			t.Errorf("%s = %s %q, want aborted into agg1", id, trade.State, trade.FailureReason)
+		}
+		if agg, _ := store.GetTradeAggregate(id); agg != "agg1" {
+			t.Errorf("GetTradeAggregate(%s) = %q, want agg1", id, agg)
+		}
+	}
+
+	members, err := store.GetAggregateMembers("agg1")
+	if err != nil {
+		t.Fatalf("GetAggregateMembers() error = %v", err)
+	}
+	if len(members) != 2 || members[0] != "t2" || members[1] != "t1" {
+		t.Errorf("GetAggregateMembers() = %v, want [t2 t1]", members)
+	}
+	if agg, err := store.GetTradeAggregate("t3"); err != nil || agg != "" {
+		t.Errorf("GetTradeAggregate(t3) = %q, %v, want none", agg, err)
+	}
+
+	if err := store.AggregateTrades("agg2", []string{"t1"}); !errors.Is(err, ErrTradeAggregated) {
+		t.Errorf("AggregateTrades() twice error = %v, want ErrTradeAggregated", err)
+	}
+}
@@ -60,6 +60,14 @@ type Trade struct {
 
 	// Failure tracking
 	FailureReason string
+
+	// PayoutAddress is an external address for our receiving leg (empty: our wallet)
+	PayoutAddress string
+
+	// FeeAdjustment is the negotiated change of the request amount (smallest
+	// unit of the request chain) compensating the party bearing the higher
+	// mining fees: positive when the taker pays the maker more.
+	FeeAdjustment int64
 }
 
 // CreateTrade creates a new trade in the database.
@@ -70,14 +78,16 @@ func (s *Storage) CreateTrade(trade *Trade) error {
 	_, err := s.db.Exec(`
 		INSERT INTO trades (
 			id, order_id, maker_peer_id, taker_peer_id, our_role, method, state,
-			offer_chain, offer_amount, request_chain, request_amount, created_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			offer_chain, offer_amount, request_chain, request_amount, created_at,
+			payout_address, fee_adjustment
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		trade.ID, trade.OrderID, trade.MakerPeerID, trade.TakerPeerID,
 		trade.OurRole, trade.Method, trade.State,
 		trade.OfferChain, trade.OfferAmount,
 		trade.RequestChain, trade.RequestAmount,
 		trade.CreatedAt.Unix(),
+		trade.PayoutAddress, trade.FeeAdjustment,
 	)
 
 	if err != nil {
@@ -100,7 +110,7 @@ func (s *Storage) GetTrade(id string) (*Trade, error) {
 		SELECT id, order_id, maker_peer_id, taker_peer_id, maker_pubkey, taker_pubkey,
 			our_role, method, state,
 			offer_chain, offer_amount, request_chain, request_amount,
-			created_at, updated_at, completed_at, failure_reason
+			created_at, updated_at, completed_at, failure_reason, payout_address, fee_adjustment
 		FROM trades WHERE id = ?
 	`, id).Scan(
 		&trade.ID, &trade.OrderID, &trade.MakerPeerID, &trade.TakerPeerID,
@@ -108,7 +118,7 @@ func (s *Storage) GetTrade(id string) (*Trade, error) {
 		&trade.OurRole, &trade.Method, &trade.State,
 		&trade.OfferChain, &trade.OfferAmount,
 		&trade.RequestChain, &trade.RequestAmount,
-		&createdAt, &updatedAt, &completedAt, &failureReason,
+		&createdAt, &updatedAt, &completedAt, &failureReason, &trade.PayoutAddress, &trade.FeeAdjustment,
 	)
 
 	if err == sql.ErrNoRows {
@@ -154,7 +164,7 @@ func (s *Storage) GetTradeByOrderID(orderID string) (*Trade, error) {
 		SELECT id, order_id, maker_peer_id, taker_peer_id, maker_pubkey, taker_pubkey,
 			our_role, method, state,
 			offer_chain, offer_amount, request_chain, request_amount,
-			created_at, updated_at, completed_at, failure_reason
+			created_at, updated_at, completed_at, failure_reason, payout_address, fee_adjustment
 		FROM trades WHERE order_id = ?
 	`, orderID).Scan(
 		&trade.ID, &trade.OrderID, &trade.MakerPeerID, &trade.TakerPeerID,
@@ -162,7 +172,7 @@ func (s *Storage) GetTradeByOrderID(orderID string) (*Trade, error) {
 		&trade.OurRole, &trade.Method, &trade.State,
 		&trade.OfferChain, &trade.OfferAmount,
 		&trade.RequestChain, &trade.RequestAmount,
-		&createdAt, &updatedAt, &completedAt, &failureReason,
+		&createdAt, &updatedAt, &completedAt, &failureReason, &trade.PayoutAddress, &trade.FeeAdjustment,
 	)
 
 	if err == sql.ErrNoRows {
@@ -275,6 +285,48 @@ func (s *Storage) UpdateTradePubKey(id string, isMaker bool, pubkey string) erro
 	return nil
 }
 
+// UpdateTradePayoutAddress records the external payout address of a trade.
+func (s *Storage) UpdateTradePayoutAddress(id, address string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(
+		"UPDATE trades SET payout_address = ?, updated_at = ? WHERE id = ?",
+		address, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update trade payout address: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrTradeNotFound
+	}
+
+	return nil
+}
+
+// UpdateTradeFeeAdjustment records the negotiated fee adjustment of a trade.
+func (s *Storage) UpdateTradeFeeAdjustment(id string, adjustment int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(
+		"UPDATE trades SET fee_adjustment = ?, updated_at = ? WHERE id = ?",
+		adjustment, time.Now().Unix(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update trade fee adjustment: %w", err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return ErrTradeNotFound
+	}
+
+	return nil
+}
+
 // TradeFilter defines filters for listing trades.
 type TradeFilter struct {
 	State       *TradeState
@@ -284,6 +336,13 @@ type TradeFilter struct {
 	TakerPeerID string
 	Limit       int
 	Offset      int
+
+	// Terminal limits to redeemed, refunded, failed and aborted trades.
+	Terminal bool
+
+	// UpdatedSince limits to trades updated at or after this Unix time and
+	// lists them oldest update first, for paging through history.
+	UpdatedSince *int64
 }
 
 // ListTrades returns trades matching the filter.
@@ -295,7 +354,7 @@ func (s *Storage) ListTrades(filter TradeFilter) ([]*Trade, error) {
 		SELECT id, order_id, maker_peer_id, taker_peer_id, maker_pubkey, taker_pubkey,
 			our_role, method, state,
 			offer_chain, offer_amount, request_chain, request_amount,
-			created_at, updated_at, completed_at, failure_reason
+			created_at, updated_at, completed_at, failure_reason, payout_address, fee_adjustment
 		FROM trades WHERE 1=1
 	`
 	args := []interface{}{}
@@ -320,8 +379,17 @@ func (s *Storage) ListTrades(filter TradeFilter) ([]*Trade, error) {
 		query += " AND taker_peer_id = ?"
 		args = append(args, filter.TakerPeerID)
 	}
+	if filter.Terminal {
+		query += " AND state IN (?, ?, ?, ?)"
+		args = append(args, TradeStateRedeemed, TradeStateRefunded, TradeStateFailed, TradeStateAborted)
+	}
 
-	query += " ORDER BY created_at DESC"
+	if filter.UpdatedSince != nil {
+		query += " AND COALESCE(updated_at, created_at) >= ? ORDER BY COALESCE(updated_at, created_at), id"
+		args = append(args, *filter.UpdatedSince)
+	} else {
+		query += " ORDER BY created_at DESC"
+	}
 
 	if filter.Limit > 0 {
 		query += " LIMIT ?"
@@ -350,7 +418,7 @@ func (s *Storage) ListTrades(filter TradeFilter) ([]*Trade, error) {
 			&trade.OurRole, &trade.Method, &trade.State,
 			&trade.OfferChain, &trade.OfferAmount,
 			&trade.RequestChain, &trade.RequestAmount,
-			&createdAt, &updatedAt, &completedAt, &failureReason,
+			&createdAt, &updatedAt, &completedAt, &failureReason, &trade.PayoutAddress, &trade.FeeAdjustment,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trade: %w", err)
@@ -390,7 +458,7 @@ func (s *Storage) GetActiveTrades() ([]*Trade, error) {
 		SELECT id, order_id, maker_peer_id, taker_peer_id, maker_pubkey, taker_pubkey,
 			our_role, method, state,
 			offer_chain, offer_amount, request_chain, request_amount,
-			created_at, updated_at, completed_at, failure_reason
+			created_at, updated_at, completed_at, failure_reason, payout_address, fee_adjustment
 		FROM trades
 		WHERE state NOT IN (?, ?, ?, ?)
 		ORDER BY created_at ASC
@@ -415,7 +483,7 @@ func (s *Storage) GetActiveTrades() ([]*Trade, error) {
 			&trade.OurRole, &trade.Method, &trade.State,
 			&trade.OfferChain, &trade.OfferAmount,
 			&trade.RequestChain, &trade.RequestAmount,
-			&createdAt, &updatedAt, &completedAt, &failureReason,
+			&createdAt, &updatedAt, &completedAt, &failureReason, &trade.PayoutAddress, &trade.FeeAdjustment,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan trade: %w", err)
@@ -467,6 +535,68 @@ func (s *Storage) CountTrades(state *TradeState) (int, error) {
 	return count, nil
 }
 
+// PeerTradeStats summarizes our local trade history with a single peer.
+type PeerTradeStats struct {
+	PeerID          string
+	TotalTrades     int
+	CompletedTrades int // Redeemed
+	FailedTrades    int // Refunded, failed or aborted
+	ActiveTrades    int
+
+	// AvgSettlementSecs is the mean time from creation to completion of redeemed trades.
+	AvgSettlementSecs int64
+
+	// FailureRateBps is FailedTrades / (CompletedTrades + FailedTrades) in basis points (0-10000).
+	FailureRateBps uint32
+}
+
+// GetPeerTradeStats computes trade statistics for a peer from local history.
+// Trades where the peer was either maker or taker are included.
+func (s *Storage) GetPeerTradeStats(peerID string) (*PeerTradeStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var total int
+	var completed, failed, settlementCount, settlementSum sql.NullInt64
+
+	err := s.db.QueryRow(`
+		SELECT
+			COUNT(*),
+			SUM(CASE WHEN state = ? THEN 1 ELSE 0 END),
+			SUM(CASE WHEN state IN (?, ?, ?) THEN 1 ELSE 0 END),
+			SUM(CASE WHEN state = ? AND completed_at IS NOT NULL THEN 1 ELSE 0 END),
+			SUM(CASE WHEN state = ? AND completed_at IS NOT NULL THEN completed_at - created_at ELSE 0 END)
+		FROM trades
+		WHERE maker_peer_id = ? OR taker_peer_id = ?
+	`,
+		TradeStateRedeemed,
+		TradeStateRefunded, TradeStateFailed, TradeStateAborted,
+		TradeStateRedeemed,
+		TradeStateRedeemed,
+		peerID, peerID,
+	).Scan(&total, &completed, &failed, &settlementCount, &settlementSum)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get peer trade stats: %w", err)
+	}
+
+	stats := &PeerTradeStats{
+		PeerID:          peerID,
+		TotalTrades:     total,
+		CompletedTrades: int(completed.Int64),
+		FailedTrades:    int(failed.Int64),
+	}
+	stats.ActiveTrades = stats.TotalTrades - stats.CompletedTrades - stats.FailedTrades
+
+	if settlementCount.Int64 > 0 {
+		stats.AvgSettlementSecs = settlementSum.Int64 / settlementCount.Int64
+	}
+	if finished := stats.CompletedTrades + stats.FailedTrades; finished > 0 {
+		stats.FailureRateBps = uint32(stats.FailedTrades * 10000 / finished)
+	}
+
+	return stats, nil
+}
+
 // DeleteTrade deletes a trade (use with caution - prefer state updates).
 func (s *Storage) DeleteTrade(id string) error {
 	s.mu.Lock()
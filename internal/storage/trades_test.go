@@ -173,6 +173,92 @@ func TestTradeFailure(t *testing.T) {
 	}
 }
 
+func TestTradePayoutAddress(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	trade := &Trade{
+		ID:            "trade-payout",
+		OrderID:       "order-789",
+		MakerPeerID:   "12D3KooWMaker",
+		TakerPeerID:   "12D3KooWTaker",
+		OurRole:       TradeRoleMaker,
+		Method:        "htlc_bitcoin",
+		State:         TradeStateInit,
+		OfferChain:    "BTC",
+		OfferAmount:   100000,
+		RequestChain:  "LTC",
+		RequestAmount: 1000000,
+		CreatedAt:     time.Now(),
+	}
+	if err := store.CreateTrade(trade); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+
+	got, _ := store.GetTrade(trade.ID)
+	if got.PayoutAddress != "" {
+		t.Errorf("PayoutAddress = %q, want empty", got.PayoutAddress)
+	}
+
+	const addr = "tltc1qw508d6qejxtdg4y5r3zarvary0c5xw7kzp034v"
+	if err := store.UpdateTradePayoutAddress(trade.ID, addr); err != nil {
+		t.Fatalf("UpdateTradePayoutAddress() error = %v", err)
+	}
+	got, _ = store.GetTrade(trade.ID)
+	if got.PayoutAddress != addr {
+		t.Errorf("PayoutAddress = %q, want %q", got.PayoutAddress, addr)
+	}
+
+	active, err := store.GetActiveTrades()
+	if err != nil || len(active) != 1 || active[0].PayoutAddress != addr {
+		t.Errorf("GetActiveTrades() payout address not returned: %v", err)
+	}
+
+	if err := store.UpdateTradePayoutAddress("nonexistent", addr); err != ErrTradeNotFound {
+		t.Errorf("UpdateTradePayoutAddress(nonexistent) error = %v, want ErrTradeNotFound", err)
+	}
+}
+
+func TestTradeFeeAdjustment(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	trade := &Trade{
+		ID:            "trade-fees",
+		OrderID:       "order-fees",
+		MakerPeerID:   "12D3KooWMaker",
+		TakerPeerID:   "12D3KooWTaker",
+		OurRole:       TradeRoleTaker,
+		Method:        "htlc",
+		State:         TradeStateInit,
+		OfferChain:    "ETH",
+		OfferAmount:   1000000000000000000,
+		RequestChain:  "LTC",
+		RequestAmount: 5000000000,
+		CreatedAt:     time.Now(),
+	}
+	if err := store.CreateTrade(trade); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+
+	// Negative adjustments (the maker compensates the taker) round-trip
+	if err := store.UpdateTradeFeeAdjustment(trade.ID, -25000); err != nil {
+		t.Fatalf("UpdateTradeFeeAdjustment() error = %v", err)
+	}
+	got, _ := store.GetTrade(trade.ID)
+	if got.FeeAdjustment != -25000 {
+		t.Errorf("FeeAdjustment = %d, want -25000", got.FeeAdjustment)
+	}
+	active, err := store.GetActiveTrades()
+	if err != nil || len(active) != 1 || active[0].FeeAdjustment != -25000 {
+		t.Errorf("GetActiveTrades() fee adjustment not returned: %v", err)
+	}
+
+	if err := store.UpdateTradeFeeAdjustment("nonexistent", 1); err != ErrTradeNotFound {
+		t.Errorf("UpdateTradeFeeAdjustment(nonexistent) error = %v, want ErrTradeNotFound", err)
+	}
+}
+
 func TestListTrades(t *testing.T) {
 	tmpDir, err := os.MkdirTemp("", "klingon-trades-test-*")
 	if err != nil {
@@ -401,3 +487,79 @@ func TestTradeTerminalStatesSetCompletedAt(t *testing.T) {
 		t.Error("CompletedAt should be set for terminal state")
 	}
 }
+
+func TestGetPeerTradeStats(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "klingon-trades-test-*")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	cfg := &Config{DataDir: tmpDir}
+	store, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	defer store.Close()
+
+	created := time.Now().Add(-100 * time.Second)
+	trades := []struct {
+		id    string
+		maker string
+		taker string
+		final TradeState
+	}{
+		{"t1", "peerA", "us", TradeStateRedeemed},
+		{"t2", "us", "peerA", TradeStateRedeemed},
+		{"t3", "peerA", "us", TradeStateRefunded},
+		{"t4", "peerA", "us", TradeStateFunding},
+		{"t5", "peerB", "us", TradeStateFailed},
+	}
+	for _, tr := range trades {
+		trade := &Trade{
+			ID: tr.id, OrderID: "order-" + tr.id,
+			MakerPeerID: tr.maker, TakerPeerID: tr.taker,
+			OurRole: TradeRoleTaker, Method: "htlc", State: TradeStateInit,
+			OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+			CreatedAt: created,
+		}
+		if err := store.CreateTrade(trade); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+		if err := store.UpdateTradeState(tr.id, tr.final); err != nil {
+			t.Fatalf("UpdateTradeState() error = %v", err)
+		}
+	}
+
+	stats, err := store.GetPeerTradeStats("peerA")
+	if err != nil {
+		t.Fatalf("GetPeerTradeStats() error = %v", err)
+	}
+	if stats.TotalTrades != 4 {
+		t.Errorf("TotalTrades = %d, want 4", stats.TotalTrades)
+	}
+	if stats.CompletedTrades != 2 {
+		t.Errorf("CompletedTrades = %d, want 2", stats.CompletedTrades)
+	}
+	if stats.FailedTrades != 1 {
+		t.Errorf("FailedTrades = %d, want 1", stats.FailedTrades)
+	}
+	if stats.ActiveTrades != 1 {
+		t.Errorf("ActiveTrades = %d, want 1", stats.ActiveTrades)
+	}
+	if stats.FailureRateBps != 3333 {
+		t.Errorf("FailureRateBps = %d, want 3333", stats.FailureRateBps)
+	}
+	if stats.AvgSettlementSecs < 99 || stats.AvgSettlementSecs > 102 {
+		t.Errorf("AvgSettlementSecs = %d, want ~100", stats.AvgSettlementSecs)
+	}
+
+	// Unknown peer has empty stats
+	empty, err := store.GetPeerTradeStats("nobody")
+	if err != nil {
+		t.Fatalf("GetPeerTradeStats() error = %v", err)
+	}
+	if empty.TotalTrades != 0 || empty.FailureRateBps != 0 || empty.AvgSettlementSecs != 0 {
+		t.Errorf("expected empty stats, got %+v", empty)
+	}
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ErrWalletDescriptorNotFound is returned when a descriptor does not exist.
+var ErrWalletDescriptorNotFound = errors.New("wallet descriptor not found")
+
+// WalletDescriptor is an imported output descriptor.
+type WalletDescriptor struct {
+	ID            string
+	Symbol        string
+	Descriptor    string // Public descriptor with checksum
+	Label         string
+	WatchOnly     bool
+	SealedPrivate []byte // Private descriptor sealed with the wallet key, if imported with one
+	CreatedAt     time.Time
+}
+
+// SaveWalletDescriptor inserts or replaces an imported descriptor.
+func (s *Storage) SaveWalletDescriptor(d *WalletDescriptor) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO wallet_descriptors (id, symbol, descriptor, label, watch_only, sealed_private, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			label = excluded.label,
+			watch_only = excluded.watch_only,
+			sealed_private = excluded.sealed_private
+	`, d.ID, d.Symbol, d.Descriptor, d.Label, d.WatchOnly, d.SealedPrivate, d.CreatedAt.Unix())
+	return err
+}
+
+// GetWalletDescriptor returns an imported descriptor, or nil if none.
+func (s *Storage) GetWalletDescriptor(id string) (*WalletDescriptor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	row := s.db.QueryRow(`
+		SELECT id, symbol, descriptor, label, watch_only, sealed_private, created_at
+		FROM wallet_descriptors WHERE id = ?
+	`, id)
+	d, err := scanWalletDescriptor(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return d, err
+}
+
+// ListWalletDescriptors returns the imported descriptors of a chain, or of
+// all chains if symbol is empty.
+func (s *Storage) ListWalletDescriptors(symbol string) ([]*WalletDescriptor, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT id, symbol, descriptor, label, watch_only, sealed_private, created_at
+		FROM wallet_descriptors`
+	var args []interface{}
+	if symbol != "" {
+		query += " WHERE symbol = ?"
+		args = append(args, symbol)
+	}
+	query += " ORDER BY created_at, id"
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var descriptors []*WalletDescriptor
+	for rows.Next() {
+		d, err := scanWalletDescriptor(rows)
+		if err != nil {
+			return nil, err
+		}
+		descriptors = append(descriptors, d)
+	}
+	return descriptors, rows.Err()
+}
+
+// DeleteWalletDescriptor removes an imported descriptor.
+func (s *Storage) DeleteWalletDescriptor(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec("DELETE FROM wallet_descriptors WHERE id = ?", id)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrWalletDescriptorNotFound
+	}
+	return nil
+}
+
+func scanWalletDescriptor(row interface{ Scan(...interface{}) error }) (*WalletDescriptor, error) {
+	var d WalletDescriptor
+	var createdAt int64
+	if err := row.Scan(&d.ID, &d.Symbol, &d.Descriptor, &d.Label, &d.WatchOnly, &d.SealedPrivate, &createdAt); err != nil {
+		return nil, err
+	}
+	d.CreatedAt = time.Unix(createdAt, 0)
+	return &d, nil
+}
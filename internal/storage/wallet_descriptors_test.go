@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestWalletDescriptors(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	got, err := store.GetWalletDescriptor("desc-1")
+	if err != nil || got != nil {
+		t.Fatalf("GetWalletDescriptor() on empty store = %+v, %v, want nil, nil", got, err)
+	}
+
+	now := time.Unix(1700000000, 0)
+	d := &WalletDescriptor{ID: "desc-1", Symbol: "BTC", Descriptor: "wpkh(xpub/<0;1>/*)#abcdefgh", Label: "sparrow", WatchOnly: true, CreatedAt: now}
+	if err := store.SaveWalletDescriptor(d); err != nil {
+		t.Fatalf("SaveWalletDescriptor() error = %v", err)
+	}
+	if err := store.SaveWalletDescriptor(&WalletDescriptor{ID: "desc-2", Symbol: "LTC", Descriptor: "wpkh(xpub/0/*)", SealedPrivate: []byte{1, 2, 3}, CreatedAt: now.Add(time.Second)}); err != nil {
+		t.Fatalf("SaveWalletDescriptor() error = %v", err)
+	}
+
+	// Re-import updates the label and keys, not the descriptor
+	d.Label = "core"
+	d.WatchOnly = false
+	if err := store.SaveWalletDescriptor(d); err != nil {
+		t.Fatalf("SaveWalletDescriptor() update error = %v", err)
+	}
+
+	got, err = store.GetWalletDescriptor("desc-1")
+	if err != nil {
+		t.Fatalf("GetWalletDescriptor() error = %v", err)
+	}
+	if got.Symbol != "BTC" || got.Label != "core" || got.WatchOnly || got.SealedPrivate != nil || !got.CreatedAt.Equal(now) {
+		t.Errorf("GetWalletDescriptor() = %+v", got)
+	}
+
+	all, err := store.ListWalletDescriptors("")
+	if err != nil || len(all) != 2 {
+		t.Fatalf("ListWalletDescriptors(\"\") = %d, %v, want 2", len(all), err)
+	}
+	ltc, err := store.ListWalletDescriptors("LTC")
+	if err != nil || len(ltc) != 1 || ltc[0].ID != "desc-2" || !bytes.Equal(ltc[0].SealedPrivate, []byte{1, 2, 3}) {
+		t.Fatalf("ListWalletDescriptors(LTC) = %+v, %v", ltc, err)
+	}
+
+	if err := store.DeleteWalletDescriptor("desc-1"); err != nil {
+		t.Fatalf("DeleteWalletDescriptor() error = %v", err)
+	}
+	if err := store.DeleteWalletDescriptor("desc-1"); err != ErrWalletDescriptorNotFound {
+		t.Errorf("DeleteWalletDescriptor() twice error = %v, want ErrWalletDescriptorNotFound", err)
+	}
+}
@@ -0,0 +1,65 @@
+// Package storage - Wallet spend ledger for daily spending limits.
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// WalletSpend is an outbound wallet payment counted against spending limits.
+type WalletSpend struct {
+	ID        int64     `json:"id"`
+	PolicyKey string    `json:"policy_key"`
+	ToAddress string    `json:"to_address"`
+	Amount    string    `json:"amount"` // Smallest units, decimal string
+	TxID      string    `json:"txid"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RecordWalletSpend appends a spend to the ledger.
+func (s *Storage) RecordWalletSpend(spend *WalletSpend) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if spend.CreatedAt.IsZero() {
+		spend.CreatedAt = time.Now()
+	}
+	result, err := s.db.Exec(`
+		INSERT INTO wallet_spends (policy_key, to_address, amount, txid, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, spend.PolicyKey, spend.ToAddress, spend.Amount, spend.TxID, spend.CreatedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to record wallet spend: %w", err)
+	}
+	spend.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetWalletSpendsSince returns the spends for a policy key made at or after since.
+func (s *Storage) GetWalletSpendsSince(policyKey string, since time.Time) ([]*WalletSpend, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rows, err := s.db.Query(`
+		SELECT id, policy_key, to_address, amount, txid, created_at
+		FROM wallet_spends
+		WHERE policy_key = ? AND created_at >= ?
+		ORDER BY created_at ASC
+	`, policyKey, since.Unix())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet spends: %w", err)
+	}
+	defer rows.Close()
+
+	var spends []*WalletSpend
+	for rows.Next() {
+		var sp WalletSpend
+		var createdAt int64
+		if err := rows.Scan(&sp.ID, &sp.PolicyKey, &sp.ToAddress, &sp.Amount, &sp.TxID, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet spend: %w", err)
+		}
+		sp.CreatedAt = time.Unix(createdAt, 0)
+		spends = append(spends, &sp)
+	}
+	return spends, rows.Err()
+}
@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestWalletSpendsSince(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	now := time.Now()
+	spends := []*WalletSpend{
+		{PolicyKey: "BTC", ToAddress: "bc1qold", Amount: "5000", TxID: "tx-old", CreatedAt: now.Add(-25 * time.Hour)},
+		{PolicyKey: "BTC", ToAddress: "bc1qnew", Amount: "7000", TxID: "tx-new", CreatedAt: now.Add(-time.Hour)},
+		{PolicyKey: "ETH", ToAddress: "0xabc", Amount: "1000000000000000000000", TxID: "tx-eth"},
+	}
+	for _, sp := range spends {
+		if err := store.RecordWalletSpend(sp); err != nil {
+			t.Fatalf("RecordWalletSpend() error = %v", err)
+		}
+	}
+
+	got, err := store.GetWalletSpendsSince("BTC", now.Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("GetWalletSpendsSince() error = %v", err)
+	}
+	if len(got) != 1 || got[0].TxID != "tx-new" || got[0].Amount != "7000" {
+		t.Errorf("GetWalletSpendsSince(BTC) = %+v, want only tx-new", got)
+	}
+
+	got, _ = store.GetWalletSpendsSince("ETH", now.Add(-time.Minute))
+	if len(got) != 1 || got[0].Amount != "1000000000000000000000" {
+		t.Errorf("GetWalletSpendsSince(ETH) = %+v, want wei amount preserved", got)
+	}
+}
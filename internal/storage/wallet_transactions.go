@@ -0,0 +1,85 @@
+// Package storage - History of the wallet transactions the node makes on
+// its own.
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// Wallet transaction kinds.
+const (
+	WalletTxConsolidation = "consolidation" // Small UTXOs merged into one output
+	WalletTxSend          = "send"          // Payment requested over RPC
+)
+
+// WalletTransaction is a wallet transaction the node made on its own.
+type WalletTransaction struct {
+	ID        int64  `json:"id"`
+	Chain     string `json:"chain"`
+	Kind      string `json:"kind"`
+	TxID      string `json:"txid"`
+	Inputs    int    `json:"inputs"`
+	Amount    uint64 `json:"amount"` // Received by Address
+	Fee       uint64 `json:"fee"`
+	FeeRate   uint64 `json:"fee_rate"` // sat/vB
+	Address   string `json:"address"`
+	CreatedAt int64  `json:"created_at"`
+}
+
+// RecordWalletTransaction appends a transaction to the history.
+func (s *Storage) RecordWalletTransaction(tx *WalletTransaction) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if tx.CreatedAt == 0 {
+		tx.CreatedAt = time.Now().Unix()
+	}
+	result, err := s.db.Exec(`
+		INSERT INTO wallet_transactions (chain, kind, txid, inputs, amount, fee, fee_rate, address, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, tx.Chain, tx.Kind, tx.TxID, tx.Inputs, tx.Amount, tx.Fee, tx.FeeRate, tx.Address, tx.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record wallet transaction: %w", err)
+	}
+	tx.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// ListWalletTransactions returns the newest transactions first, of one
+// chain or of all for an empty chain. limit <= 0 returns all.
+func (s *Storage) ListWalletTransactions(chain string, limit int) ([]*WalletTransaction, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `
+		SELECT id, chain, kind, txid, inputs, amount, fee, fee_rate, address, created_at
+		FROM wallet_transactions`
+	var args []interface{}
+	if chain != "" {
+		query += ` WHERE chain = ?`
+		args = append(args, chain)
+	}
+	query += ` ORDER BY created_at DESC, id DESC`
+	if limit > 0 {
+		query += ` LIMIT ?`
+		args = append(args, limit)
+	}
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wallet transactions: %w", err)
+	}
+	defer rows.Close()
+
+	txs := make([]*WalletTransaction, 0)
+	for rows.Next() {
+		var tx WalletTransaction
+		if err := rows.Scan(&tx.ID, &tx.Chain, &tx.Kind, &tx.TxID, &tx.Inputs, &tx.Amount,
+			&tx.Fee, &tx.FeeRate, &tx.Address, &tx.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wallet transaction: %w", err)
+		}
+		txs = append(txs, &tx)
+	}
+	return txs, rows.Err()
+}
@@ -0,0 +1,40 @@
+package storage
+
+import "testing"
+
+func TestWalletTransactions(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	for _, tx := range []*WalletTransaction{
+		{Chain: "BTC", Kind: WalletTxConsolidation, TxID: "aa", Inputs: 12, Amount: 90000, Fee: 800, FeeRate: 1, Address: "tb1qa", CreatedAt: 100},
+		{Chain: "LTC", Kind: WalletTxConsolidation, TxID: "bb", Inputs: 5, Amount: 50000, Fee: 300, FeeRate: 1, Address: "tltc1qb", CreatedAt: 200},
+		{Chain: "BTC", Kind: WalletTxConsolidation, TxID: "cc", Inputs: 8, Amount: 70000, Fee: 500, FeeRate: 2, Address: "tb1qc", CreatedAt: 300},
+	} {
+		if err := store.RecordWalletTransaction(tx); err != nil {
+			t.Fatalf("RecordWalletTransaction() error = %v", err)
+		}
+		if tx.ID == 0 {
+			t.Error("RecordWalletTransaction() did not set the ID")
+		}
+	}
+
+	btc, err := store.ListWalletTransactions("BTC", 0)
+	if err != nil {
+		t.Fatalf("ListWalletTransactions() error = %v", err)
+	}
+	if len(btc) != 2 || btc[0].TxID != "cc" || btc[1].TxID != "aa" {
+		t.Fatalf("ListWalletTransactions(BTC) = %+v, want cc then aa", btc)
+	}
+	if btc[1].Inputs != 12 || btc[1].Amount != 90000 || btc[1].Fee != 800 || btc[1].Address != "tb1qa" {
+		t.Errorf("transaction fields not stored: %+v", btc[1])
+	}
+
+	all, err := store.ListWalletTransactions("", 2)
+	if err != nil {
+		t.Fatalf("ListWalletTransactions() error = %v", err)
+	}
+	if len(all) != 2 || all[0].TxID != "cc" || all[1].TxID != "bb" {
+		t.Errorf("ListWalletTransactions(all, 2) = %+v, want cc then bb", all)
+	}
+}
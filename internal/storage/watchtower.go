@@ -0,0 +1,230 @@
+// Package storage - Watchtower bundles held for trusted offline peers.
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrBundleNotFound is returned when a watchtower bundle does not exist.
+var ErrBundleNotFound = errors.New("watchtower bundle not found")
+
+// BundleStatus represents the status of a watchtower bundle.
+type BundleStatus string
+
+const (
+	BundleStatusActive    BundleStatus = "active"    // Watching for the trigger
+	BundleStatusBroadcast BundleStatus = "broadcast" // Transaction broadcast, obligation fulfilled
+	BundleStatusCancelled BundleStatus = "cancelled" // Released by the client
+)
+
+// WatchtowerBundle is a pre-signed HTLC spend a watchtower broadcasts for a client.
+type WatchtowerBundle struct {
+	ID            string       `json:"id"`
+	ClientPeer    string       `json:"client_peer"`
+	TradeID       string       `json:"trade_id"`
+	Chain         string       `json:"chain"`
+	Kind          string       `json:"kind"`
+	TxHex         string       `json:"tx_hex"`
+	FundingTxID   string       `json:"funding_txid"`
+	FundingVout   uint32       `json:"funding_vout"`
+	TimeoutBlocks uint32       `json:"timeout_blocks,omitempty"`
+	WatchChain    string       `json:"watch_chain,omitempty"`
+	WatchAddress  string       `json:"watch_address,omitempty"`
+	WatchTxID     string       `json:"watch_txid,omitempty"`
+	WatchVout     uint32       `json:"watch_vout,omitempty"`
+	SecretHash    string       `json:"secret_hash,omitempty"`
+	Status        BundleStatus `json:"status"`
+	BroadcastTxID string       `json:"broadcast_txid,omitempty"`
+	Attempts      int          `json:"attempts"`
+	LastError     string       `json:"last_error,omitempty"`
+	CreatedAt     time.Time    `json:"created_at"`
+	UpdatedAt     time.Time    `json:"updated_at"`
+}
+
+// SaveWatchtowerBundle stores a bundle. An active bundle with the same ID from
+// the same client is replaced (e.g. re-signed at a new fee rate); bundles owned
+// by another client or no longer active are never overwritten.
+func (s *Storage) SaveWatchtowerBundle(b *WatchtowerBundle) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing, err := s.getWatchtowerBundleUnlocked(b.ID)
+	if err != nil && !errors.Is(err, ErrBundleNotFound) {
+		return err
+	}
+	if existing != nil {
+		if existing.ClientPeer != b.ClientPeer {
+			return fmt.Errorf("bundle %s is registered by another peer", b.ID)
+		}
+		if existing.Status != BundleStatusActive {
+			return fmt.Errorf("bundle %s is already %s", b.ID, existing.Status)
+		}
+	}
+
+	now := time.Now().Unix()
+	_, err = s.db.Exec(`
+		INSERT INTO watchtower_bundles (
+			id, client_peer, trade_id, chain, kind, tx_hex, funding_txid, funding_vout,
+			timeout_blocks, watch_chain, watch_address, watch_txid, watch_vout, secret_hash,
+			status, broadcast_txid, attempts, last_error, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, 'active', '', 0, '', ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			tx_hex = excluded.tx_hex, funding_txid = excluded.funding_txid,
+			funding_vout = excluded.funding_vout, timeout_blocks = excluded.timeout_blocks,
+			watch_chain = excluded.watch_chain, watch_address = excluded.watch_address,
+			watch_txid = excluded.watch_txid, watch_vout = excluded.watch_vout,
+			secret_hash = excluded.secret_hash, attempts = 0, last_error = '',
+			updated_at = excluded.updated_at
+	`,
+		b.ID, b.ClientPeer, b.TradeID, b.Chain, b.Kind, b.TxHex, b.FundingTxID, b.FundingVout,
+		b.TimeoutBlocks, b.WatchChain, b.WatchAddress, b.WatchTxID, b.WatchVout, b.SecretHash,
+		now, now,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save watchtower bundle: %w", err)
+	}
+	return nil
+}
+
+// GetWatchtowerBundle retrieves a bundle by ID.
+func (s *Storage) GetWatchtowerBundle(id string) (*WatchtowerBundle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.getWatchtowerBundleUnlocked(id)
+}
+
+func (s *Storage) getWatchtowerBundleUnlocked(id string) (*WatchtowerBundle, error) {
+	row := s.db.QueryRow(`
+		SELECT `+watchtowerBundleColumns+`
+		FROM watchtower_bundles WHERE id = ?
+	`, id)
+
+	b, err := scanWatchtowerBundle(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrBundleNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get watchtower bundle: %w", err)
+	}
+	return b, nil
+}
+
+// ListWatchtowerBundles returns bundles, optionally filtered by client peer and status.
+func (s *Storage) ListWatchtowerBundles(clientPeer string, status BundleStatus) ([]*WatchtowerBundle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	query := `SELECT ` + watchtowerBundleColumns + ` FROM watchtower_bundles WHERE 1=1`
+	var args []interface{}
+	if clientPeer != "" {
+		query += ` AND client_peer = ?`
+		args = append(args, clientPeer)
+	}
+	if status != "" {
+		query += ` AND status = ?`
+		args = append(args, status)
+	}
+	query += ` ORDER BY created_at ASC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query watchtower bundles: %w", err)
+	}
+	defer rows.Close()
+
+	var bundles []*WatchtowerBundle
+	for rows.Next() {
+		b, err := scanWatchtowerBundle(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan watchtower bundle: %w", err)
+		}
+		bundles = append(bundles, b)
+	}
+	return bundles, rows.Err()
+}
+
+// CountActiveWatchtowerBundles returns the number of active obligations for a client.
+func (s *Storage) CountActiveWatchtowerBundles(clientPeer string) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var count int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM watchtower_bundles WHERE client_peer = ? AND status = 'active'
+	`, clientPeer).Scan(&count)
+	if err != nil {
+		return 0, fmt.Errorf("failed to count watchtower bundles: %w", err)
+	}
+	return count, nil
+}
+
+// MarkWatchtowerBundleBroadcast records that a bundle's transaction was broadcast.
+func (s *Storage) MarkWatchtowerBundleBroadcast(id, txID string) error {
+	return s.updateWatchtowerBundle(`
+		UPDATE watchtower_bundles
+		SET status = 'broadcast', broadcast_txid = ?, attempts = attempts + 1, last_error = '', updated_at = ?
+		WHERE id = ?
+	`, txID, time.Now().Unix(), id)
+}
+
+// CancelWatchtowerBundle releases an active bundle.
+func (s *Storage) CancelWatchtowerBundle(id string) error {
+	return s.updateWatchtowerBundle(`
+		UPDATE watchtower_bundles
+		SET status = 'cancelled', updated_at = ?
+		WHERE id = ? AND status = 'active'
+	`, time.Now().Unix(), id)
+}
+
+// RecordWatchtowerAttempt records a failed broadcast attempt; the bundle stays active.
+func (s *Storage) RecordWatchtowerAttempt(id, errMsg string) error {
+	return s.updateWatchtowerBundle(`
+		UPDATE watchtower_bundles
+		SET attempts = attempts + 1, last_error = ?, updated_at = ?
+		WHERE id = ?
+	`, errMsg, time.Now().Unix(), id)
+}
+
+// updateWatchtowerBundle runs a single-row bundle update and reports missing bundles.
+func (s *Storage) updateWatchtowerBundle(query string, args ...interface{}) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result, err := s.db.Exec(query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to update watchtower bundle: %w", err)
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrBundleNotFound
+	}
+	return nil
+}
+
+const watchtowerBundleColumns = `id, client_peer, trade_id, chain, kind, tx_hex, funding_txid, funding_vout,
+	timeout_blocks, watch_chain, watch_address, watch_txid, watch_vout, secret_hash,
+	status, broadcast_txid, attempts, last_error, created_at, updated_at`
+
+// scanWatchtowerBundle scans a bundle from a row.
+func scanWatchtowerBundle(scanner interface{ Scan(...interface{}) error }) (*WatchtowerBundle, error) {
+	var b WatchtowerBundle
+	var createdAt, updatedAt int64
+	err := scanner.Scan(
+		&b.ID, &b.ClientPeer, &b.TradeID, &b.Chain, &b.Kind, &b.TxHex, &b.FundingTxID, &b.FundingVout,
+		&b.TimeoutBlocks, &b.WatchChain, &b.WatchAddress, &b.WatchTxID, &b.WatchVout, &b.SecretHash,
+		&b.Status, &b.BroadcastTxID, &b.Attempts, &b.LastError, &createdAt, &updatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	b.CreatedAt = time.Unix(createdAt, 0)
+	b.UpdatedAt = time.Unix(updatedAt, 0)
+	return &b, nil
+}
@@ -0,0 +1,84 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+)
+
+func testBundle(id, client string) *WatchtowerBundle {
+	return &WatchtowerBundle{
+		ID: id, ClientPeer: client, TradeID: "trade-1", Chain: "BTC", Kind: "refund",
+		TxHex: "aa", FundingTxID: "fund-1", FundingVout: 0, TimeoutBlocks: 144,
+	}
+}
+
+func TestWatchtowerBundleLifecycle(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if err := store.SaveWatchtowerBundle(testBundle("refund:trade-1:BTC", "peer-a")); err != nil {
+		t.Fatalf("SaveWatchtowerBundle() error = %v", err)
+	}
+
+	count, err := store.CountActiveWatchtowerBundles("peer-a")
+	if err != nil || count != 1 {
+		t.Fatalf("CountActiveWatchtowerBundles() = %d, %v", count, err)
+	}
+
+	if err := store.RecordWatchtowerAttempt("refund:trade-1:BTC", "non-final"); err != nil {
+		t.Fatalf("RecordWatchtowerAttempt() error = %v", err)
+	}
+	if err := store.MarkWatchtowerBundleBroadcast("refund:trade-1:BTC", "txid-1"); err != nil {
+		t.Fatalf("MarkWatchtowerBundleBroadcast() error = %v", err)
+	}
+
+	b, err := store.GetWatchtowerBundle("refund:trade-1:BTC")
+	if err != nil {
+		t.Fatalf("GetWatchtowerBundle() error = %v", err)
+	}
+	if b.Status != BundleStatusBroadcast || b.BroadcastTxID != "txid-1" || b.Attempts != 2 || b.TimeoutBlocks != 144 {
+		t.Errorf("GetWatchtowerBundle() = %+v", b)
+	}
+
+	active, _ := store.ListWatchtowerBundles("peer-a", BundleStatusActive)
+	if len(active) != 0 {
+		t.Errorf("expected no active bundles, got %d", len(active))
+	}
+	all, _ := store.ListWatchtowerBundles("", "")
+	if len(all) != 1 {
+		t.Errorf("expected 1 bundle, got %d", len(all))
+	}
+
+	// A fulfilled bundle is not overwritten by a re-registration
+	if err := store.SaveWatchtowerBundle(testBundle("refund:trade-1:BTC", "peer-a")); err == nil {
+		t.Error("expected error re-registering a broadcast bundle")
+	}
+}
+
+func TestWatchtowerBundleOwnership(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	store.SaveWatchtowerBundle(testBundle("refund:trade-1:BTC", "peer-a"))
+
+	if err := store.SaveWatchtowerBundle(testBundle("refund:trade-1:BTC", "peer-b")); err == nil {
+		t.Error("expected error when another peer registers the same bundle")
+	}
+
+	replacement := testBundle("refund:trade-1:BTC", "peer-a")
+	replacement.TxHex = "bb"
+	if err := store.SaveWatchtowerBundle(replacement); err != nil {
+		t.Fatalf("SaveWatchtowerBundle() replace error = %v", err)
+	}
+	b, _ := store.GetWatchtowerBundle("refund:trade-1:BTC")
+	if b.TxHex != "bb" {
+		t.Errorf("TxHex = %s, want bb", b.TxHex)
+	}
+
+	if err := store.CancelWatchtowerBundle("refund:trade-1:BTC"); err != nil {
+		t.Fatalf("CancelWatchtowerBundle() error = %v", err)
+	}
+	if err := store.CancelWatchtowerBundle("refund:trade-1:BTC"); !errors.Is(err, ErrBundleNotFound) {
+		t.Errorf("second cancel error = %v, want ErrBundleNotFound", err)
+	}
+}
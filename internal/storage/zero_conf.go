@@ -0,0 +1,70 @@
+// Package storage - Zero-conf acceptances of counterparty funding.
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// ZeroConfAcceptance records the counterparty funding of a trade that the
+// zero-conf policy accepted before it confirmed.
+type ZeroConfAcceptance struct {
+	TradeID     string    `json:"trade_id"`
+	TxID        string    `json:"txid"`
+	FeeRate     uint64    `json:"fee_rate"` // sat/vB
+	FirstSeenAt time.Time `json:"first_seen_at"`
+	AcceptedAt  time.Time `json:"accepted_at"`
+}
+
+// SaveZeroConfAcceptance stores or replaces a trade's acceptance.
+func (s *Storage) SaveZeroConfAcceptance(a *ZeroConfAcceptance) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := s.db.Exec(`
+		INSERT INTO zero_conf_acceptances (trade_id, txid, fee_rate, first_seen_at, accepted_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(trade_id) DO UPDATE SET
+			txid = excluded.txid, fee_rate = excluded.fee_rate,
+			first_seen_at = excluded.first_seen_at, accepted_at = excluded.accepted_at
+	`, a.TradeID, a.TxID, a.FeeRate, a.FirstSeenAt.Unix(), a.AcceptedAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to save zero-conf acceptance: %w", err)
+	}
+	return nil
+}
+
+// GetZeroConfAcceptance returns a trade's acceptance, or nil if it has none.
+func (s *Storage) GetZeroConfAcceptance(tradeID string) (*ZeroConfAcceptance, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	a := ZeroConfAcceptance{TradeID: tradeID}
+	var firstSeenAt, acceptedAt int64
+	err := s.db.QueryRow(`
+		SELECT txid, fee_rate, first_seen_at, accepted_at
+		FROM zero_conf_acceptances WHERE trade_id = ?
+	`, tradeID).Scan(&a.TxID, &a.FeeRate, &firstSeenAt, &acceptedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get zero-conf acceptance: %w", err)
+	}
+	a.FirstSeenAt = time.Unix(firstSeenAt, 0)
+	a.AcceptedAt = time.Unix(acceptedAt, 0)
+	return &a, nil
+}
+
+// DeleteZeroConfAcceptance removes a trade's acceptance. Deleting a missing
+// acceptance is not an error.
+func (s *Storage) DeleteZeroConfAcceptance(tradeID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.db.Exec(`DELETE FROM zero_conf_acceptances WHERE trade_id = ?`, tradeID); err != nil {
+		return fmt.Errorf("failed to delete zero-conf acceptance: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestZeroConfAcceptances(t *testing.T) {
+	store, cleanup := setupTestStorage(t)
+	defer cleanup()
+
+	if got, err := store.GetZeroConfAcceptance("trade-a"); err != nil || got != nil {
+		t.Fatalf("GetZeroConfAcceptance(missing) = %+v, %v, want nil", got, err)
+	}
+
+	seen := time.Unix(1700000000, 0)
+	for _, txID := range []string{"tx1", "tx2"} {
+		if err := store.SaveZeroConfAcceptance(&ZeroConfAcceptance{
+			TradeID: "trade-a", TxID: txID, FeeRate: 12,
+			FirstSeenAt: seen, AcceptedAt: seen.Add(time.Minute),
+		}); err != nil {
+			t.Fatalf("SaveZeroConfAcceptance(%s) error = %v", txID, err)
+		}
+	}
+
+	got, err := store.GetZeroConfAcceptance("trade-a")
+	if err != nil || got == nil {
+		t.Fatalf("GetZeroConfAcceptance() = %+v, %v", got, err)
+	}
+	if got.TxID != "tx2" || got.FeeRate != 12 || !got.FirstSeenAt.Equal(seen) || !got.AcceptedAt.Equal(seen.Add(time.Minute)) {
+		t.Errorf("GetZeroConfAcceptance() = %+v, want tx2 at 12 sat/vB", got)
+	}
+
+	if err := store.DeleteZeroConfAcceptance("trade-a"); err != nil {
+		t.Fatalf("DeleteZeroConfAcceptance() error = %v", err)
+	}
+	if err := store.DeleteZeroConfAcceptance("missing"); err != nil {
+		t.Errorf("DeleteZeroConfAcceptance(missing) error = %v", err)
+	}
+	if got, _ := store.GetZeroConfAcceptance("trade-a"); got != nil {
+		t.Errorf("GetZeroConfAcceptance() after delete = %+v, want nil", got)
+	}
+}
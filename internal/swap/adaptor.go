@@ -0,0 +1,190 @@
+// Package swap - BIP-340 adaptor signatures.
+//
+// An adaptor signature (pre-signature) is a Schnorr signature encrypted
+// under a point T = t*G. It can be checked against T without being a valid
+// signature; adding t completes it, and anyone holding both the
+// pre-signature and the completed signature learns t. The Monero swaps use
+// this to make spending the BTC lock reveal a Monero key share.
+package swap
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// AdaptorSignatureSize is the size of a serialized adaptor signature.
+const AdaptorSignatureSize = 64
+
+// adaptorNonceTag is the tag of the adaptor signature nonce derivation.
+var adaptorNonceTag = []byte("klingdex/adaptor/nonce")
+
+// ErrInvalidAdaptorSignature is returned when a pre-signature doesn't verify
+// or doesn't match a completed signature.
+var ErrInvalidAdaptorSignature = errors.New("invalid adaptor signature")
+
+// AdaptorSignature is a BIP-340 signature encrypted under an adaptor point.
+// R is the x coordinate of the completed signature's nonce R' = k*G + T
+// (even y); S is k + e*d, which misses the adaptor secret.
+type AdaptorSignature struct {
+	R [32]byte
+	S [32]byte
+}
+
+// AdaptorSign creates a pre-signature of msg (32 bytes) under adaptor point T.
+func AdaptorSign(key *btcec.PrivateKey, msg []byte, adaptor *btcec.PublicKey) (*AdaptorSignature, error) {
+	if len(msg) != 32 {
+		return nil, fmt.Errorf("message must be 32 bytes, got %d", len(msg))
+	}
+	d := key.Key
+	var P btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&d, &P)
+	P.ToAffine()
+	if P.Y.IsOdd() {
+		d.Negate()
+	}
+	pBytes := schnorr.SerializePubKey(key.PubKey())
+
+	var T btcec.JacobianPoint
+	adaptor.AsJacobian(&T)
+
+	var aux [32]byte
+	if _, err := rand.Read(aux[:]); err != nil {
+		return nil, err
+	}
+	dBytes := d.Bytes()
+
+	// Retry until k*G + T has an even y, as BIP-340 needs of the final nonce
+	for counter := uint32(0); ; counter++ {
+		var ctr [4]byte
+		binary.BigEndian.PutUint32(ctr[:], counter)
+		h := chainhash.TaggedHash(adaptorNonceTag, dBytes[:], pBytes, adaptor.SerializeCompressed(), msg, aux[:], ctr[:])
+		var k btcec.ModNScalar
+		k.SetBytes((*[32]byte)(h))
+		if k.IsZero() {
+			continue
+		}
+
+		var kG, R btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(&k, &kG)
+		btcec.AddNonConst(&kG, &T, &R)
+		if isInfinity(&R) {
+			continue
+		}
+		R.ToAffine()
+		if R.Y.IsOdd() {
+			continue
+		}
+
+		sig := &AdaptorSignature{}
+		R.X.PutBytesUnchecked(sig.R[:])
+		e := bip340Challenge(sig.R[:], pBytes, msg)
+		s := new(btcec.ModNScalar).Mul2(&e, &d).Add(&k)
+		s.PutBytesUnchecked(sig.S[:])
+		return sig, nil
+	}
+}
+
+// Verify checks that the pre-signature completes, with the secret of
+// adaptor point T, to a valid signature of msg by pubKey.
+func (a *AdaptorSignature) Verify(pubKey *btcec.PublicKey, msg []byte, adaptor *btcec.PublicKey) error {
+	if len(msg) != 32 {
+		return fmt.Errorf("message must be 32 bytes, got %d", len(msg))
+	}
+	var s btcec.ModNScalar
+	if s.SetBytes(&a.S) != 0 {
+		return fmt.Errorf("%w: s overflows", ErrInvalidAdaptorSignature)
+	}
+	pBytes := schnorr.SerializePubKey(pubKey)
+	evenKey, err := schnorr.ParsePubKey(pBytes)
+	if err != nil {
+		return err
+	}
+	e := bip340Challenge(a.R[:], pBytes, msg)
+	e.Negate()
+
+	// k*G = s*G - e*P, and the nonce is k*G + T
+	var P, T, sG, eP, kG, R btcec.JacobianPoint
+	evenKey.AsJacobian(&P)
+	adaptor.AsJacobian(&T)
+	btcec.ScalarBaseMultNonConst(&s, &sG)
+	btcec.ScalarMultNonConst(&e, &P, &eP)
+	btcec.AddNonConst(&sG, &eP, &kG)
+	btcec.AddNonConst(&kG, &T, &R)
+	if isInfinity(&R) {
+		return fmt.Errorf("%w: nonce at infinity", ErrInvalidAdaptorSignature)
+	}
+	R.ToAffine()
+	var rx [32]byte
+	R.X.PutBytesUnchecked(rx[:])
+	if R.Y.IsOdd() || rx != a.R {
+		return ErrInvalidAdaptorSignature
+	}
+	return nil
+}
+
+// Complete adds the adaptor secret, returning a valid BIP-340 signature.
+func (a *AdaptorSignature) Complete(secret *btcec.ModNScalar) (*schnorr.Signature, error) {
+	var r btcec.FieldVal
+	if r.SetBytes(&a.R) != 0 {
+		return nil, fmt.Errorf("%w: r overflows", ErrInvalidAdaptorSignature)
+	}
+	var s btcec.ModNScalar
+	s.SetBytes(&a.S)
+	s.Add(secret)
+	return schnorr.NewSignature(&r, &s), nil
+}
+
+// Extract recovers the adaptor secret from the completed signature sig and
+// checks it against the adaptor point.
+func (a *AdaptorSignature) Extract(sig *schnorr.Signature, adaptor *btcec.PublicKey) (*btcec.ModNScalar, error) {
+	raw := sig.Serialize()
+	if [32]byte(raw[:32]) != a.R {
+		return nil, fmt.Errorf("%w: signature has another nonce", ErrInvalidAdaptorSignature)
+	}
+	var s, sPre btcec.ModNScalar
+	s.SetByteSlice(raw[32:])
+	sPre.SetBytes(&a.S)
+	t := s.Add(sPre.Negate())
+
+	var T btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(t, &T)
+	T.ToAffine()
+	if !btcec.NewPublicKey(&T.X, &T.Y).IsEqual(adaptor) {
+		return nil, fmt.Errorf("%w: extracted secret doesn't match the adaptor point", ErrInvalidAdaptorSignature)
+	}
+	return t, nil
+}
+
+// Serialize returns R || S.
+func (a *AdaptorSignature) Serialize() []byte {
+	return append(a.R[:], a.S[:]...)
+}
+
+// ParseAdaptorSignature parses a serialized adaptor signature.
+func ParseAdaptorSignature(b []byte) (*AdaptorSignature, error) {
+	if len(b) != AdaptorSignatureSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidAdaptorSignature, len(b))
+	}
+	a := &AdaptorSignature{}
+	copy(a.R[:], b[:32])
+	copy(a.S[:], b[32:])
+	return a, nil
+}
+
+// bip340Challenge returns tagged_hash("BIP0340/challenge", r || P || m) mod n.
+func bip340Challenge(r, p, msg []byte) btcec.ModNScalar {
+	h := chainhash.TaggedHash(chainhash.TagBIP0340Challenge, r, p, msg)
+	var e btcec.ModNScalar
+	e.SetBytes((*[32]byte)(h))
+	return e
+}
+
+func isInfinity(p *btcec.JacobianPoint) bool {
+	return (p.X.IsZero() && p.Y.IsZero()) || p.Z.IsZero()
+}
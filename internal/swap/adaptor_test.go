@@ -0,0 +1,83 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+func TestAdaptorSignatureRoundTrip(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	secretKey, _ := btcec.NewPrivateKey()
+	adaptor := secretKey.PubKey()
+	msg := sha256.Sum256([]byte("redeem"))
+
+	pre, err := AdaptorSign(key, msg[:], adaptor)
+	if err != nil {
+		t.Fatalf("AdaptorSign() error = %v", err)
+	}
+	if err := pre.Verify(key.PubKey(), msg[:], adaptor); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	parsed, err := ParseAdaptorSignature(pre.Serialize())
+	if err != nil || *parsed != *pre {
+		t.Fatalf("ParseAdaptorSignature() = %v, %v", parsed, err)
+	}
+
+	sig, err := pre.Complete(&secretKey.Key)
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if !sig.Verify(msg[:], key.PubKey()) {
+		t.Fatal("completed signature doesn't verify")
+	}
+
+	secret, err := pre.Extract(sig, adaptor)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	if !secret.Equals(&secretKey.Key) {
+		t.Error("extracted another secret")
+	}
+}
+
+func TestAdaptorSignatureRejects(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	secretKey, _ := btcec.NewPrivateKey()
+	other, _ := btcec.NewPrivateKey()
+	msg := sha256.Sum256([]byte("redeem"))
+
+	pre, err := AdaptorSign(key, msg[:], secretKey.PubKey())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := pre.Verify(key.PubKey(), msg[:], other.PubKey()); !errors.Is(err, ErrInvalidAdaptorSignature) {
+		t.Errorf("verified under another adaptor: %v", err)
+	}
+	if err := pre.Verify(other.PubKey(), msg[:], secretKey.PubKey()); !errors.Is(err, ErrInvalidAdaptorSignature) {
+		t.Errorf("verified under another key: %v", err)
+	}
+	wrongMsg := sha256.Sum256([]byte("refund"))
+	if err := pre.Verify(key.PubKey(), wrongMsg[:], secretKey.PubKey()); !errors.Is(err, ErrInvalidAdaptorSignature) {
+		t.Errorf("verified another message: %v", err)
+	}
+
+	// Completing with the wrong secret gives an invalid signature
+	sig, _ := pre.Complete(&other.Key)
+	if sig.Verify(msg[:], key.PubKey()) {
+		t.Error("completed with the wrong secret")
+	}
+	if _, err := pre.Extract(sig, secretKey.PubKey()); !errors.Is(err, ErrInvalidAdaptorSignature) {
+		t.Errorf("extracted from a bad signature: %v", err)
+	}
+
+	if _, err := AdaptorSign(key, msg[:31], secretKey.PubKey()); err == nil {
+		t.Error("signed a short message")
+	}
+	if _, err := ParseAdaptorSignature(make([]byte, 63)); !errors.Is(err, ErrInvalidAdaptorSignature) {
+		t.Errorf("parsed a short signature: %v", err)
+	}
+}
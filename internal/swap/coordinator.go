@@ -13,18 +13,22 @@ import (
 // NewCoordinator creates a new swap coordinator.
 func NewCoordinator(cfg *CoordinatorConfig) *Coordinator {
 	ctx, cancel := context.WithCancel(context.Background())
+	log := logging.GetDefault().Component("swap")
 
 	return &Coordinator{
-		store:         cfg.Store,
-		wallet:        cfg.Wallet,
-		walletService: cfg.WalletService,
-		backends:      cfg.Backends,
-		network:       cfg.Network,
-		swaps:         make(map[string]*ActiveSwap),
-		eventHandlers: make([]EventHandler, 0),
-		log:           logging.GetDefault().Component("swap"),
-		ctx:           ctx,
-		cancel:        cancel,
+		store:          cfg.Store,
+		wallet:         cfg.Wallet,
+		walletService:  cfg.WalletService,
+		backends:       cfg.Backends,
+		network:        cfg.Network,
+		jobMaxAttempts: cfg.JobMaxAttempts,
+		swaps:          make(map[string]*ActiveSwap),
+		events:         &eventBus{log: log, done: make(chan struct{})},
+		autoClaim:      AutoClaimPolicy{Mode: AutoClaimManual},
+		autoClaimWake:  make(chan struct{}, 1),
+		log:            log,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
 }
 
@@ -52,35 +56,79 @@ func (c *Coordinator) SetBackend(chainSymbol string, b backend.Backend) {
 	c.backends[chainSymbol] = b
 }
 
-// OnEvent registers an event handler.
-func (c *Coordinator) OnEvent(handler EventHandler) {
+// SetDegraded pauses (true) or resumes (false) accepting new trades.
+func (c *Coordinator) SetDegraded(degraded bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.degraded = degraded
+}
+
+// IsDegraded returns true while new trades are paused.
+func (c *Coordinator) IsDegraded() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.degraded
+}
+
+// SetClockSkew sets the measured offset of the local clock (true time =
+// local time + offset), used for deadlines. With refuse, new trades are
+// paused until it is called again without.
+func (c *Coordinator) SetClockSkew(offset time.Duration, refuse bool) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
-	c.eventHandlers = append(c.eventHandlers, handler)
+	c.clockOffset = offset
+	c.clockSkewed = refuse
+}
+
+// IsClockSkewed returns true while new trades are paused for clock skew.
+func (c *Coordinator) IsClockSkewed() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.clockSkewed
+}
+
+// OnEvent registers an event handler with the default queue and overflow
+// policy.
+func (c *Coordinator) OnEvent(handler EventHandler) {
+	c.Subscribe(handler, SubscriberOptions{})
 }
 
-// emitEvent emits an event to all handlers.
+// Subscribe registers an event handler. Events are delivered in order on the
+// subscriber's own goroutine; if the handler falls behind by more than the
+// queue size, events are lost according to opts.Overflow.
+func (c *Coordinator) Subscribe(handler EventHandler, opts SubscriberOptions) *EventSubscription {
+	return c.events.subscribe(handler, opts)
+}
+
+// EventSubscribers returns the queue state of each event subscriber.
+func (c *Coordinator) EventSubscribers() []EventSubscriberStats {
+	return c.events.stats()
+}
+
+// emitEvent queues an event for all subscribers without blocking.
 // NOTE: Caller must hold c.mu (read or write lock).
 func (c *Coordinator) emitEvent(tradeID, eventType string, data interface{}) {
-	event := SwapEvent{
+	c.events.publish(SwapEvent{
 		TradeID:   tradeID,
 		EventType: eventType,
 		Data:      data,
 		Timestamp: time.Now(),
-	}
-
-	// Copy handlers while we already hold the lock (caller holds c.mu)
-	handlers := make([]EventHandler, len(c.eventHandlers))
-	copy(handlers, c.eventHandlers)
-
-	for _, handler := range handlers {
-		go handler(event)
-	}
+	})
 }
 
 // Close shuts down the coordinator.
 func (c *Coordinator) Close() error {
 	c.cancel()
+
+	// A fresh snapshot keeps the next startup's differential load small
+	c.mu.RLock()
+	snapshots := c.snapshotInterval > 0
+	c.mu.RUnlock()
+	if snapshots {
+		if _, err := c.TakeSnapshot(); err != nil {
+			c.log.Warn("Failed to take swap snapshot", "error", err)
+		}
+	}
+	c.events.close()
 	return nil
 }
-
@@ -0,0 +1,300 @@
+// Package swap - Affordability pre-check.
+//
+// A swap commits us to funding an escrow long after the trade was accepted.
+// Without a check up front, an order taken with funds already promised to
+// other swaps only failed at funding construction, after the counterparty
+// had locked its side. Before a swap is initiated or joined, the funding
+// leg is checked against the available balance of its chain: confirmed
+// wallet UTXOs, minus the escrow and DAO fee of every other swap we have
+// not funded yet, minus the projected fees of all those funding
+// transactions. EVM legs are checked against the wallet account instead:
+// its native balance must cover the gas of every HTLC create we still have
+// to send or have pending on the chain, plus their native amounts, and for
+// token legs its token balance must cover the token amounts.
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// ErrInsufficientAvailableBalance is returned when the funding leg of a new
+// swap exceeds the available balance.
+var ErrInsufficientAvailableBalance = errors.New("insufficient available balance")
+
+// fundingVSizeEstimate is the projected size of a funding transaction: one
+// P2WPKH input, escrow, DAO fee and change outputs.
+const fundingVSizeEstimate = 10 + 68 + 3*34
+
+// AffordabilityPolicy is the node-wide affordability pre-check configuration.
+type AffordabilityPolicy struct {
+	// Enabled rejects swaps whose funding leg exceeds the available
+	// balance. Disable it to fund swaps from an external wallet.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// MinConfirmations a wallet UTXO needs to count as available
+	// (at least 1).
+	MinConfirmations int64 `yaml:"min_confirmations,omitempty" json:"min_confirmations,omitempty"`
+}
+
+// Validate checks the policy.
+func (p *AffordabilityPolicy) Validate() error {
+	if p.MinConfirmations < 0 {
+		return fmt.Errorf("affordability.min_confirmations must not be negative")
+	}
+	return nil
+}
+
+// AvailableBalance is what the wallet can commit to a new swap on a chain.
+type AvailableBalance struct {
+	Chain         string `json:"chain"`
+	Confirmed     uint64 `json:"confirmed"`      // Wallet UTXOs with enough confirmations
+	Unconfirmed   uint64 `json:"unconfirmed"`    // Not counted
+	Reserved      uint64 `json:"reserved"`       // Escrow and DAO fee of swaps not funded yet
+	PendingSwaps  int    `json:"pending_swaps"`  // Swaps holding a reservation
+	ProjectedFees uint64 `json:"projected_fees"` // Funding fees of those swaps
+	FeeRate       uint64 `json:"fee_rate"`       // sat/vB the projection uses
+	Available     uint64 `json:"available"`
+}
+
+// SetAffordabilityPolicy sets the node-wide affordability pre-check policy.
+func (c *Coordinator) SetAffordabilityPolicy(policy AffordabilityPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.affordability = policy
+	return nil
+}
+
+// GetAvailableBalance returns the available balance of a UTXO chain.
+func (c *Coordinator) GetAvailableBalance(ctx context.Context, symbol string) (*AvailableBalance, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	params, ok := chain.Get(symbol, c.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	if params.Type != chain.ChainTypeBitcoin {
+		return nil, fmt.Errorf("available balance is only tracked for UTXO chains, not %s", symbol)
+	}
+	if c.walletService == nil {
+		return nil, errors.New("wallet service not available")
+	}
+	return c.availableBalanceUnlocked(ctx, symbol, "")
+}
+
+// CheckAffordable checks that we can fund our leg of offer as the maker or
+// the taker, before a trade is accepted. It is a no-op when the policy is
+// disabled.
+func (c *Coordinator) CheckAffordable(ctx context.Context, offer Offer, isMaker bool) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkAffordableUnlocked(ctx, "", offer, isMaker)
+}
+
+// checkAffordableUnlocked checks the funding leg of a new swap, not
+// counting tradeID's own reservation. Nodes without a wallet service and
+// chains other than UTXO and EVM ones are not checked.
+// NOTE: Caller must hold c.mu (read or write lock).
+func (c *Coordinator) checkAffordableUnlocked(ctx context.Context, tradeID string, offer Offer, isMaker bool) error {
+	if !c.affordability.Enabled || c.walletService == nil {
+		return nil
+	}
+
+	role := RoleResponder
+	if isMaker {
+		role = RoleInitiator
+	}
+	symbol := FundingChain(offer, role)
+	params, ok := chain.Get(symbol, c.network)
+	if ok && params.Type == chain.ChainTypeEVM {
+		return c.checkEVMAffordableUnlocked(ctx, tradeID, symbol, offer, isMaker)
+	}
+	if !ok || params.Type != chain.ChainTypeBitcoin {
+		return nil
+	}
+
+	bal, err := c.availableBalanceUnlocked(ctx, symbol, tradeID)
+	if err != nil {
+		return fmt.Errorf("failed to check available balance: %w", err)
+	}
+
+	need := fundingOutputs(offer, isMaker) + fundingVSizeEstimate*bal.FeeRate
+	if bal.Available < need {
+		return fmt.Errorf("%w on %s: need %d, have %d (%d locked in %d pending swaps)",
+			ErrInsufficientAvailableBalance, symbol, need, bal.Available, bal.Reserved+bal.ProjectedFees, bal.PendingSwaps)
+	}
+	return nil
+}
+
+// availableBalanceUnlocked computes the available balance of symbol,
+// leaving out the reservation of exceptTradeID.
+// NOTE: Caller must hold c.mu (read or write lock).
+func (c *Coordinator) availableBalanceUnlocked(ctx context.Context, symbol, exceptTradeID string) (*AvailableBalance, error) {
+	b, ok := c.backends[symbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoBackend, symbol)
+	}
+
+	utxos, err := c.walletService.ListAllUTXOs(ctx, symbol, c.store)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan wallet UTXOs: %w", err)
+	}
+
+	minConf := max(c.affordability.MinConfirmations, 1)
+	bal := &AvailableBalance{Chain: symbol, FeeRate: fundingFeeRate(ctx, b)}
+	for _, u := range utxos {
+		if u.Confirmations >= minConf {
+			bal.Confirmed += u.Amount
+		} else {
+			bal.Unconfirmed += u.Amount
+		}
+	}
+
+	for tradeID, active := range c.swaps {
+		s := active.Swap
+		if tradeID == exceptTradeID || s.IsTerminal() || s.LocalFundingTxID != "" || s.ExternalFunding || s.SettlesOverLightning() {
+			continue
+		}
+		if FundingChain(s.Offer, s.Role) != symbol {
+			continue
+		}
+		bal.Reserved += fundingOutputs(s.Offer, s.Role == RoleInitiator)
+		bal.ProjectedFees += fundingVSizeEstimate * bal.FeeRate
+		bal.PendingSwaps++
+	}
+
+	if committed := bal.Reserved + bal.ProjectedFees; bal.Confirmed > committed {
+		bal.Available = bal.Confirmed - committed
+	}
+	return bal, nil
+}
+
+// fundingOutputs returns the escrow and DAO fee a party's funding
+// transaction pays, as FundSwap builds it.
+func fundingOutputs(offer Offer, isMaker bool) uint64 {
+	if isMaker {
+		return offer.OfferEscrowAmount() + offer.FeeTerms.DAOFee(offer.OfferAmount, true)
+	}
+	return offer.RequestEscrowAmount() + offer.FeeTerms.DAOFee(offer.RequestAmount, false)
+}
+
+// checkEVMAffordableUnlocked checks an EVM funding leg against the wallet
+// account HTLCs are created from, after what the other swaps funding on the
+// chain still have to send.
+// NOTE: Caller must hold c.mu (read or write lock).
+func (c *Coordinator) checkEVMAffordableUnlocked(ctx context.Context, tradeID, symbol string, offer Offer, isMaker bool) error {
+	gasPrice, err := c.walletService.GetEVMGasPrice(ctx, symbol)
+	if err != nil {
+		return fmt.Errorf("failed to check available balance: %w", err)
+	}
+	native, err := c.walletService.GetEVMBalance(ctx, symbol, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to check available balance: %w", err)
+	}
+
+	token := evmLegToken(&offer, symbol)
+	needNative := new(big.Int)
+	needToken := new(big.Int)
+	add := func(offer Offer, isMaker bool) {
+		legToken := evmLegToken(&offer, symbol)
+		needNative.Add(needNative, evmFundingGasCost(gasPrice, legToken))
+		amount := new(big.Int).SetUint64(evmLegAmount(offer, isMaker))
+		switch legToken {
+		case common.Address{}:
+			needNative.Add(needNative, amount)
+		case token:
+			needToken.Add(needToken, amount)
+		}
+	}
+	add(offer, isMaker)
+
+	pending := 0
+	for id, active := range c.swaps {
+		s := active.Swap
+		if id == tradeID || s.IsTerminal() || s.ExternalFunding || FundingChain(s.Offer, s.Role) != symbol {
+			continue
+		}
+		if c.evmCreateSettledUnlocked(ctx, active, symbol) {
+			continue
+		}
+		add(s.Offer, s.Role == RoleInitiator)
+		pending++
+	}
+
+	if native.Cmp(needNative) < 0 {
+		return fmt.Errorf("%w on %s: need %s, have %s including gas (%d pending swaps)",
+			ErrInsufficientAvailableBalance, symbol, needNative, native, pending)
+	}
+	if token == (common.Address{}) {
+		return nil
+	}
+
+	addr, err := c.walletService.GetAddress(symbol, 0, 0)
+	if err != nil {
+		return fmt.Errorf("failed to check available balance: %w", err)
+	}
+	held, err := c.walletService.GetERC20BalanceForAddress(ctx, symbol, token.Hex(), addr)
+	if err != nil {
+		return fmt.Errorf("failed to check available token balance: %w", err)
+	}
+	if held.Cmp(needToken) < 0 {
+		return fmt.Errorf("%w on %s: need %s of token %s, have %s (%d pending swaps)",
+			ErrInsufficientAvailableBalance, symbol, needToken, token.Hex(), held, pending)
+	}
+	return nil
+}
+
+// evmCreateSettledUnlocked reports whether a swap's HTLC create on an EVM
+// chain was mined, so the account balance no longer includes what it locks.
+// A create not sent yet, or still pending, is not.
+// NOTE: Caller must hold c.mu (read or write lock).
+func (c *Coordinator) evmCreateSettledUnlocked(ctx context.Context, active *ActiveSwap, symbol string) bool {
+	if active.EVMHTLC == nil {
+		return false
+	}
+	data := active.EVMHTLC.RequestChain
+	if symbol == active.Swap.Offer.OfferChain {
+		data = active.EVMHTLC.OfferChain
+	}
+	if data == nil || data.Session == nil {
+		return false
+	}
+	create := data.Session.GetCreateTxHash()
+	if create == (common.Hash{}) {
+		return false
+	}
+	replacer := c.evmTxReplacerLocked(symbol, data.Session)
+	if replacer == nil {
+		return false
+	}
+	receipt, err := replacer.Receipt(ctx, create)
+	return err == nil && receipt != nil
+}
+
+// evmLegAmount returns the amount a party locks in its EVM HTLC, as
+// computeEVMSwapParams sets it.
+func evmLegAmount(offer Offer, isMaker bool) uint64 {
+	if isMaker {
+		return offer.OfferAmount
+	}
+	return offer.RequestAmount
+}
+
+// evmFundingGasCost prices an HTLC create, with its ERC-20 approval for
+// token legs.
+func evmFundingGasCost(gasPrice *big.Int, token common.Address) *big.Int {
+	gas := int64(EVMFundingGas)
+	if token != (common.Address{}) {
+		gas = EVMTokenFundingGas
+	}
+	return new(big.Int).Mul(gasPrice, big.NewInt(gas))
+}
@@ -0,0 +1,232 @@
+package swap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+// fakeUTXOBackend serves wallet UTXOs from memory.
+type fakeUTXOBackend struct {
+	backend.Backend
+	utxos map[string][]backend.UTXO
+}
+
+func (f *fakeUTXOBackend) Connect(ctx context.Context) error { return nil }
+
+func (f *fakeUTXOBackend) GetAddressUTXOs(ctx context.Context, address string) ([]backend.UTXO, error) {
+	return f.utxos[address], nil
+}
+
+func (f *fakeUTXOBackend) GetFeeEstimates(ctx context.Context) (*backend.FeeEstimate, error) {
+	return &backend.FeeEstimate{HalfHourFee: 5}, nil
+}
+
+// newAffordabilityCoordinator returns a testnet coordinator whose wallet
+// holds 100000 confirmed and 50000 unconfirmed sats on BTC.
+func newAffordabilityCoordinator(t *testing.T) *Coordinator {
+	t.Helper()
+	fb := &fakeUTXOBackend{utxos: make(map[string][]backend.UTXO)}
+	registry := backend.NewRegistry()
+	registry.Register("BTC", fb)
+
+	ws, recv, change := newTestWalletService(t, registry)
+	fb.utxos[recv] = []backend.UTXO{{TxID: strings.Repeat("aa", 32), Amount: 100000, Confirmations: 3}}
+	fb.utxos[change] = []backend.UTXO{{TxID: strings.Repeat("bb", 32), Amount: 50000}}
+
+	coord := NewCoordinator(&CoordinatorConfig{
+		WalletService: ws,
+		Backends:      map[string]backend.Backend{"BTC": fb},
+		Network:       chain.Testnet,
+	})
+	t.Cleanup(func() { coord.Close() })
+	if err := coord.SetAffordabilityPolicy(AffordabilityPolicy{Enabled: true, MinConfirmations: 1}); err != nil {
+		t.Fatalf("SetAffordabilityPolicy() error = %v", err)
+	}
+	return coord
+}
+
+func TestAvailableBalance(t *testing.T) {
+	coord := newAffordabilityCoordinator(t)
+	ctx := context.Background()
+
+	pending := Offer{OfferChain: "BTC", OfferAmount: 40000, RequestChain: "LTC", RequestAmount: 400000}
+	coord.swaps["pending"] = &ActiveSwap{Swap: &Swap{ID: "pending", Role: RoleInitiator, State: StateInit, Offer: pending}}
+	// Funded, externally funded and other-chain swaps hold no reservation
+	coord.swaps["funded"] = &ActiveSwap{Swap: &Swap{Role: RoleInitiator, State: StateFunding, Offer: pending, LocalFundingTxID: "f"}}
+	coord.swaps["external"] = &ActiveSwap{Swap: &Swap{Role: RoleInitiator, State: StateInit, Offer: pending, ExternalFunding: true}}
+	coord.swaps["ltc"] = &ActiveSwap{Swap: &Swap{Role: RoleResponder, State: StateInit, Offer: Offer{OfferChain: "BTC", RequestChain: "LTC", RequestAmount: 1}}}
+
+	bal, err := coord.GetAvailableBalance(ctx, "BTC")
+	if err != nil {
+		t.Fatalf("GetAvailableBalance() error = %v", err)
+	}
+	reserved := fundingOutputs(pending, true)
+	fee := uint64(fundingVSizeEstimate * 5)
+	if bal.Confirmed != 100000 || bal.Unconfirmed != 50000 || bal.FeeRate != 5 {
+		t.Errorf("balance = %+v, want 100000 confirmed and 50000 unconfirmed at 5 sat/vB", bal)
+	}
+	if bal.Reserved != reserved || bal.PendingSwaps != 1 || bal.ProjectedFees != fee || bal.Available != 100000-reserved-fee {
+		t.Errorf("balance = %+v, want %d reserved by 1 swap", bal, reserved)
+	}
+
+	if _, err := coord.GetAvailableBalance(ctx, "ETH"); err == nil {
+		t.Error("GetAvailableBalance(ETH) should fail")
+	}
+}
+
+func TestCheckAffordable(t *testing.T) {
+	coord := newAffordabilityCoordinator(t)
+	ctx := context.Background()
+
+	pending := Offer{OfferChain: "BTC", OfferAmount: 40000, RequestChain: "LTC", RequestAmount: 400000}
+	coord.swaps["pending"] = &ActiveSwap{Swap: &Swap{ID: "pending", Role: RoleInitiator, State: StateInit, Offer: pending}}
+
+	// As the taker of an LTC/BTC order we fund BTC
+	fits := Offer{OfferChain: "LTC", OfferAmount: 500000, RequestChain: "BTC", RequestAmount: 50000}
+	if err := coord.CheckAffordable(ctx, fits, false); err != nil {
+		t.Errorf("CheckAffordable() error = %v", err)
+	}
+	tooBig := fits
+	tooBig.RequestAmount = 58000
+	err := coord.CheckAffordable(ctx, tooBig, false)
+	if !errors.Is(err, ErrInsufficientAvailableBalance) || !strings.Contains(err.Error(), "in 1 pending swaps") {
+		t.Errorf("CheckAffordable() = %v, want insufficient available balance with 1 pending swap", err)
+	}
+	// As its maker we would fund LTC, which has no backend to check
+	if err := coord.CheckAffordable(ctx, tooBig, true); err == nil {
+		t.Error("CheckAffordable() without an LTC backend should fail")
+	}
+
+	// The pending swap's own reservation doesn't count against it
+	coord.mu.Lock()
+	err = coord.checkAffordableUnlocked(ctx, "pending", pending, true)
+	coord.mu.Unlock()
+	if err != nil {
+		t.Errorf("checkAffordableUnlocked(pending) error = %v", err)
+	}
+
+	coord.SetAffordabilityPolicy(AffordabilityPolicy{})
+	if err := coord.CheckAffordable(ctx, tooBig, false); err != nil {
+		t.Errorf("disabled CheckAffordable() error = %v", err)
+	}
+	if err := (&AffordabilityPolicy{MinConfirmations: -1}).Validate(); err == nil {
+		t.Error("Validate() accepted negative min_confirmations")
+	}
+}
+
+// fakeEVMNode is an EVM JSON-RPC node holding one account's balances.
+type fakeEVMNode struct {
+	balance *big.Int // Native, in wei
+	tokens  *big.Int // Of any ERC-20 token
+}
+
+func (f *fakeEVMNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage `json:"id"`
+		Method string          `json:"method"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	var result string
+	switch req.Method {
+	case "eth_gasPrice":
+		result = "0x3b9aca00" // 1 gwei
+	case "eth_getBalance":
+		result = "0x" + f.balance.Text(16)
+	case "eth_call":
+		result = "0x" + fmt.Sprintf("%064x", f.tokens)
+	default:
+		result = "0x0"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+// newEVMAffordabilityCoordinator returns a testnet coordinator whose wallet
+// account holds 1 ETH and 100 units of any token, at a 1 gwei gas price.
+func newEVMAffordabilityCoordinator(t *testing.T) (*Coordinator, *fakeEVMNode) {
+	t.Helper()
+	node := &fakeEVMNode{balance: big.NewInt(1e18), tokens: big.NewInt(100)}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	eth := backend.NewJSONRPCBackend(server.URL, backend.RPCTypeEVM, "", "")
+	registry := backend.NewRegistry()
+	registry.Register("ETH", eth)
+
+	ws, _, _ := newTestWalletService(t, registry)
+	coord := NewCoordinator(&CoordinatorConfig{
+		WalletService: ws,
+		Backends:      map[string]backend.Backend{"ETH": eth},
+		Network:       chain.Testnet,
+	})
+	t.Cleanup(func() { coord.Close() })
+	if err := coord.SetAffordabilityPolicy(AffordabilityPolicy{Enabled: true}); err != nil {
+		t.Fatalf("SetAffordabilityPolicy() error = %v", err)
+	}
+	return coord, node
+}
+
+func TestCheckAffordableEVM(t *testing.T) {
+	coord, node := newEVMAffordabilityCoordinator(t)
+	ctx := context.Background()
+	gas := uint64(EVMFundingGas * 1e9)
+
+	// As the maker of an ETH/BTC order we lock ETH
+	offer := Offer{OfferChain: "ETH", OfferAmount: 1e18 - gas, RequestChain: "BTC", RequestAmount: 100000}
+	if err := coord.CheckAffordable(ctx, offer, true); err != nil {
+		t.Errorf("CheckAffordable() error = %v", err)
+	}
+	tooBig := offer
+	tooBig.OfferAmount++
+	if err := coord.CheckAffordable(ctx, tooBig, true); !errors.Is(err, ErrInsufficientAvailableBalance) {
+		t.Errorf("CheckAffordable() of the amount and gas above the balance = %v", err)
+	}
+
+	// Swaps that haven't created their HTLC yet, or whose create is still
+	// pending, hold their amount and gas; mined ones are in the balance
+	pending := Offer{OfferChain: "ETH", OfferAmount: 4e17, RequestChain: "BTC", RequestAmount: 100000}
+	coord.swaps["unsent"] = &ActiveSwap{Swap: &Swap{Role: RoleInitiator, State: StateInit, Offer: pending}}
+	created := common.HexToHash("0xc1")
+	coord.swaps["mined"] = &ActiveSwap{
+		Swap:    &Swap{Role: RoleInitiator, State: StateFunding, Offer: pending},
+		EVMHTLC: &EVMHTLCSwapData{OfferChain: &ChainEVMHTLCData{Session: &EVMHTLCSession{symbol: "ETH", createTxHash: created}}},
+	}
+	coord.evmReplacers = map[string]EVMTxReplacer{"ETH": &fakeReplacer{
+		receipts: map[common.Hash]*types.Receipt{created: {}},
+	}}
+	offer.OfferAmount = 6e17 - 2*gas + 1
+	err := coord.CheckAffordable(ctx, offer, true)
+	if !errors.Is(err, ErrInsufficientAvailableBalance) || !strings.Contains(err.Error(), "1 pending swaps") {
+		t.Errorf("CheckAffordable() = %v, want insufficient available balance with 1 pending swap", err)
+	}
+	offer.OfferAmount -= 1
+	if err := coord.CheckAffordable(ctx, offer, true); err != nil {
+		t.Errorf("CheckAffordable() next to a pending swap error = %v", err)
+	}
+	delete(coord.swaps, "unsent")
+
+	// Token legs need the tokens, and the native coin only for gas
+	token := Offer{OfferChain: "ETH", OfferAmount: 100, OfferToken: "0x00000000000000000000000000000000000000aa", RequestChain: "BTC", RequestAmount: 100000}
+	if err := coord.CheckAffordable(ctx, token, true); err != nil {
+		t.Errorf("CheckAffordable(token) error = %v", err)
+	}
+	token.OfferAmount = 101
+	if err := coord.CheckAffordable(ctx, token, true); !errors.Is(err, ErrInsufficientAvailableBalance) || !strings.Contains(err.Error(), "of token") {
+		t.Errorf("CheckAffordable(token) above the token balance = %v", err)
+	}
+	token.OfferAmount = 100
+	node.balance = big.NewInt(EVMTokenFundingGas*1e9 - 1)
+	if err := coord.CheckAffordable(ctx, token, true); !errors.Is(err, ErrInsufficientAvailableBalance) {
+		t.Errorf("CheckAffordable(token) without gas = %v", err)
+	}
+}
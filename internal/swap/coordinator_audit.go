@@ -0,0 +1,110 @@
+// Package swap - Point-in-time swap view for compliance audit records.
+package swap
+
+import (
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// AuditLeg is one chain leg of a swap in an audit snapshot.
+type AuditLeg struct {
+	Leg            string `json:"leg"`  // offer, request
+	Role           string `json:"role"` // sender, receiver
+	Chain          string `json:"chain"`
+	Amount         uint64 `json:"amount"`
+	FundingAddress string `json:"funding_address,omitempty"`
+	FundingTxID    string `json:"funding_txid,omitempty"`
+	RedeemTxID     string `json:"redeem_txid,omitempty"`
+	RefundTxID     string `json:"refund_txid,omitempty"`
+}
+
+// AuditSnapshot is what a compliance audit record captures about a swap:
+// the counterparty, addresses, amounts, timestamps and transaction IDs.
+type AuditSnapshot struct {
+	TradeID            string `json:"trade_id"`
+	OrderID            string `json:"order_id,omitempty"`
+	Method             string `json:"method"`
+	Role               string `json:"role"`                 // initiator, responder
+	TradeRole          string `json:"trade_role,omitempty"` // maker, taker
+	State              string `json:"state"`
+	CounterpartyPeerID string `json:"counterparty_peer_id,omitempty"`
+
+	OfferChain    string `json:"offer_chain"`
+	OfferAmount   uint64 `json:"offer_amount"`
+	RequestChain  string `json:"request_chain"`
+	RequestAmount uint64 `json:"request_amount"`
+
+	OfferEscrowAddress   string `json:"offer_escrow_address,omitempty"`
+	RequestEscrowAddress string `json:"request_escrow_address,omitempty"`
+	LocalOfferAddress    string `json:"local_offer_address,omitempty"`
+	LocalRequestAddress  string `json:"local_request_address,omitempty"`
+	RemoteOfferAddress   string `json:"remote_offer_address,omitempty"`
+	RemoteRequestAddress string `json:"remote_request_address,omitempty"`
+	PayoutAddress        string `json:"payout_address,omitempty"`
+
+	LocalFundingTxID  string     `json:"local_funding_txid,omitempty"`
+	RemoteFundingTxID string     `json:"remote_funding_txid,omitempty"`
+	Legs              []AuditLeg `json:"legs,omitempty"`
+
+	CreatedAt   int64 `json:"created_at"`
+	CompletedAt int64 `json:"completed_at,omitempty"`
+}
+
+// AuditSnapshot returns the current audit view of a swap.
+func (c *Coordinator) AuditSnapshot(tradeID string) (*AuditSnapshot, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	s := active.Swap
+
+	snap := &AuditSnapshot{
+		TradeID:              tradeID,
+		Method:               string(s.Method),
+		Role:                 string(s.Role),
+		State:                string(s.State),
+		OfferChain:           s.Offer.OfferChain,
+		OfferAmount:          s.Offer.OfferAmount,
+		RequestChain:         s.Offer.RequestChain,
+		RequestAmount:        s.Offer.RequestAmount,
+		LocalOfferAddress:    s.LocalOfferWalletAddr,
+		LocalRequestAddress:  s.LocalRequestWalletAddr,
+		RemoteOfferAddress:   s.RemoteOfferWalletAddr,
+		RemoteRequestAddress: s.RemoteRequestWalletAddr,
+		PayoutAddress:        s.PayoutAddress,
+		LocalFundingTxID:     s.LocalFundingTxID,
+		RemoteFundingTxID:    s.RemoteFundingTxID,
+		CreatedAt:            s.CreatedAt.Unix(),
+	}
+	snap.OfferEscrowAddress, snap.RequestEscrowAddress = escrowAddressesUnlocked(active)
+
+	if t := active.Trade; t != nil {
+		snap.OrderID = t.OrderID
+		snap.TradeRole = string(t.OurRole)
+		snap.CounterpartyPeerID = t.MakerPeerID
+		if t.OurRole == storage.TradeRoleMaker {
+			snap.CounterpartyPeerID = t.TakerPeerID
+		}
+		if t.CompletedAt != nil {
+			snap.CompletedAt = t.CompletedAt.Unix()
+		}
+	}
+	for _, leg := range []*storage.SwapLeg{active.OfferLeg, active.RequestLeg} {
+		if leg == nil {
+			continue
+		}
+		snap.Legs = append(snap.Legs, AuditLeg{
+			Leg:            string(leg.LegType),
+			Role:           string(leg.OurRole),
+			Chain:          leg.Chain,
+			Amount:         leg.Amount,
+			FundingAddress: leg.FundingAddress,
+			FundingTxID:    leg.FundingTxID,
+			RedeemTxID:     leg.RedeemTxID,
+			RefundTxID:     leg.RefundTxID,
+		})
+	}
+	return snap, nil
+}
@@ -0,0 +1,57 @@
+package swap
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestAuditSnapshot(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	t.Cleanup(func() { coord.Close() })
+
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleResponder, Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	s.LocalFundingTxID = "local-fund"
+	s.RemoteRequestWalletAddr = "tltc1qremote"
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap: s,
+		Trade: &storage.Trade{
+			ID: "t1", OrderID: "o1",
+			MakerPeerID: "maker-peer", TakerPeerID: "taker-peer",
+			OurRole: storage.TradeRoleMaker,
+		},
+		OfferLeg: &storage.SwapLeg{
+			LegType: storage.SwapLegTypeOffer, OurRole: storage.SwapLegRoleSender,
+			Chain: "BTC", Amount: 100000, FundingTxID: "local-fund", RedeemTxID: "redeem",
+		},
+	}
+
+	if _, err := coord.AuditSnapshot("missing"); err != ErrSwapNotFound {
+		t.Errorf("AuditSnapshot(missing) error = %v, want ErrSwapNotFound", err)
+	}
+
+	snap, err := coord.AuditSnapshot("t1")
+	if err != nil {
+		t.Fatalf("AuditSnapshot() error = %v", err)
+	}
+	if snap.CounterpartyPeerID != "taker-peer" || snap.OrderID != "o1" || snap.TradeRole != "maker" {
+		t.Errorf("AuditSnapshot() trade fields = %+v", snap)
+	}
+	if snap.OfferAmount != 100000 || snap.RequestChain != "LTC" || snap.LocalFundingTxID != "local-fund" || snap.RemoteRequestAddress != "tltc1qremote" {
+		t.Errorf("AuditSnapshot() swap fields = %+v", snap)
+	}
+	if len(snap.Legs) != 1 || snap.Legs[0].RedeemTxID != "redeem" || snap.Legs[0].Leg != "offer" {
+		t.Errorf("AuditSnapshot() legs = %+v", snap.Legs)
+	}
+	if snap.CreatedAt == 0 {
+		t.Error("AuditSnapshot() CreatedAt = 0")
+	}
+}
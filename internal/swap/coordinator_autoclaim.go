@@ -0,0 +1,496 @@
+// Package swap - Automatic claim once the counterparty reveals the secret.
+//
+// When we are the responder, the initiator claims our funds first and the
+// secret becomes known to us (P2P reveal, their claim transaction or the EVM
+// claim event). The auto-claim policy decides what happens next: claim
+// immediately, claim after their claim has N confirmations, or leave the
+// claim to the operator. Every decision is emitted as an auto_claim_* event.
+package swap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// AutoClaimMode selects when we claim after learning the secret.
+type AutoClaimMode string
+
+const (
+	AutoClaimImmediate     AutoClaimMode = "immediate"     // Claim as soon as the secret is known
+	AutoClaimConfirmations AutoClaimMode = "confirmations" // Claim once their claim has N confirmations
+	AutoClaimManual        AutoClaimMode = "manual"        // Never claim automatically
+)
+
+// Auto-claim events, emitted once per decision.
+const (
+	EventAutoClaimManual    = "auto_claim_manual"    // Secret known, claim left to the operator
+	EventAutoClaimWaiting   = "auto_claim_waiting"   // Waiting for confirmations of their claim
+	EventAutoClaimTriggered = "auto_claim_triggered" // Claim is being broadcast
+	EventAutoClaimClaimed   = "auto_claim_claimed"   // Claim broadcast
+	EventAutoClaimFailed    = "auto_claim_failed"    // Claim failed, retried on the next check
+)
+
+// Auto-claim rule sources, most specific first.
+const (
+	AutoClaimSourceTrade   = "trade"
+	AutoClaimSourceChain   = "chain"
+	AutoClaimSourceDefault = "default"
+)
+
+// AutoClaimRule is the claim behavior for a chain or a trade.
+type AutoClaimRule struct {
+	Mode AutoClaimMode `yaml:"mode,omitempty" json:"mode"`
+
+	// Confirmations of the counterparty's claim required in confirmations mode.
+	Confirmations uint32 `yaml:"confirmations,omitempty" json:"confirmations,omitempty"`
+}
+
+// Validate checks the mode and that confirmations mode has a count.
+func (r AutoClaimRule) Validate() error {
+	switch r.Mode {
+	case AutoClaimImmediate, AutoClaimManual:
+		return nil
+	case AutoClaimConfirmations:
+		if r.Confirmations == 0 {
+			return fmt.Errorf("auto-claim mode %s requires confirmations > 0", r.Mode)
+		}
+		return nil
+	default:
+		return fmt.Errorf("invalid auto-claim mode %q (immediate, confirmations or manual)", r.Mode)
+	}
+}
+
+// AutoClaimPolicy is the node-wide auto-claim configuration.
+type AutoClaimPolicy struct {
+	// Mode and Confirmations apply to chains without an override.
+	Mode          AutoClaimMode `yaml:"mode,omitempty" json:"mode"`
+	Confirmations uint32        `yaml:"confirmations,omitempty" json:"confirmations,omitempty"`
+
+	// Chains overrides the policy for claims on a chain symbol (the chain we
+	// claim on). Confirmations are counted on the counterparty's claim chain.
+	Chains map[string]AutoClaimRule `yaml:"chains,omitempty" json:"chains,omitempty"`
+
+	// CheckInterval is how often swaps are re-evaluated. Learning a secret
+	// triggers an evaluation right away.
+	CheckInterval time.Duration `yaml:"check_interval,omitempty" json:"check_interval,omitempty"`
+}
+
+// Validate checks the default rule and every chain override.
+func (p *AutoClaimPolicy) Validate() error {
+	if err := p.defaultRule().Validate(); err != nil {
+		return err
+	}
+	for symbol, rule := range p.Chains {
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("auto_claim.chains.%s: %w", symbol, err)
+		}
+	}
+	return nil
+}
+
+// RuleFor returns the rule for claims on a chain and its source.
+func (p *AutoClaimPolicy) RuleFor(chainSymbol string) (AutoClaimRule, string) {
+	if rule, ok := p.Chains[chainSymbol]; ok {
+		return rule, AutoClaimSourceChain
+	}
+	return p.defaultRule(), AutoClaimSourceDefault
+}
+
+func (p *AutoClaimPolicy) defaultRule() AutoClaimRule {
+	return AutoClaimRule{Mode: p.Mode, Confirmations: p.Confirmations}
+}
+
+// AutoClaimStatus describes the auto-claim state of a swap.
+type AutoClaimStatus struct {
+	TradeID  string        `json:"trade_id"`
+	Chain    string        `json:"chain,omitempty"` // Chain we claim on
+	Rule     AutoClaimRule `json:"rule"`
+	Source   string        `json:"source"`             // trade, chain or default
+	Decision string        `json:"decision,omitempty"` // Last auto_claim_* event
+
+	CounterpartyClaimChain string `json:"counterparty_claim_chain,omitempty"`
+	CounterpartyClaimTxID  string `json:"counterparty_claim_txid,omitempty"`
+	Confirmations          int64  `json:"confirmations,omitempty"`
+
+	ClaimTxID string `json:"claim_txid,omitempty"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+// autoClaimState is the decision state of a swap. Only the counterparty claim
+// is stored; decisions are made again after a restart.
+type autoClaimState struct {
+	decision      string
+	claimChain    string // Counterparty claim
+	claimTxID     string
+	confirmations int64
+	claimedTxID   string // Our claim
+	lastError     string
+}
+
+// SetAutoClaimPolicy sets the node-wide policy and loads per-trade overrides
+// and the counterparty claims recorded before a restart.
+func (c *Coordinator) SetAutoClaimPolicy(policy AutoClaimPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+
+	overrides := make(map[string]AutoClaimRule)
+	var claims []*storage.CounterpartyClaim
+	if c.store != nil {
+		stored, err := c.store.GetAutoClaimOverrides()
+		if err != nil {
+			return err
+		}
+		for _, o := range stored {
+			overrides[o.TradeID] = AutoClaimRule{Mode: AutoClaimMode(o.Mode), Confirmations: o.Confirmations}
+		}
+		if claims, err = c.store.GetCounterpartyClaims(); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.autoClaim = policy
+	c.autoClaimOverrides = overrides
+	for _, cl := range claims {
+		if st := c.autoClaimStateUnlocked(cl.TradeID); st.claimTxID == "" {
+			st.claimChain = cl.Chain
+			st.claimTxID = cl.TxID
+		}
+	}
+	return nil
+}
+
+// SetAutoClaimOverride sets the auto-claim rule for one trade. A nil rule
+// removes the override so the node policy applies again.
+func (c *Coordinator) SetAutoClaimOverride(tradeID string, rule *AutoClaimRule) error {
+	if rule != nil {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.swaps[tradeID]; !ok {
+		return ErrSwapNotFound
+	}
+
+	if c.store != nil {
+		var err error
+		if rule == nil {
+			err = c.store.DeleteAutoClaimOverride(tradeID)
+		} else {
+			err = c.store.SaveAutoClaimOverride(&storage.AutoClaimOverride{
+				TradeID:       tradeID,
+				Mode:          string(rule.Mode),
+				Confirmations: rule.Confirmations,
+			})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	if c.autoClaimOverrides == nil {
+		c.autoClaimOverrides = make(map[string]AutoClaimRule)
+	}
+	if rule == nil {
+		delete(c.autoClaimOverrides, tradeID)
+	} else {
+		c.autoClaimOverrides[tradeID] = *rule
+	}
+
+	// Re-evaluate under the new rule
+	if st, ok := c.autoClaims[tradeID]; ok {
+		st.decision = ""
+	}
+	c.wakeAutoClaim()
+	return nil
+}
+
+// RecordCounterpartyClaim records the counterparty's claim transaction, whose
+// confirmations gate the claim in confirmations mode.
+func (c *Coordinator) RecordCounterpartyClaim(tradeID, chainSymbol, txID string) error {
+	if chainSymbol == "" || txID == "" {
+		return fmt.Errorf("chain and txid are required")
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.swaps[tradeID]; !ok {
+		return ErrSwapNotFound
+	}
+	c.recordCounterpartyClaimUnlocked(tradeID, chainSymbol, txID)
+	return nil
+}
+
+// recordCounterpartyClaimUnlocked records the counterparty's claim transaction.
+// Caller must hold c.mu.
+func (c *Coordinator) recordCounterpartyClaimUnlocked(tradeID, chainSymbol, txID string) {
+	st := c.autoClaimStateUnlocked(tradeID)
+	if st.claimTxID != txID {
+		st.claimChain = chainSymbol
+		st.claimTxID = txID
+		st.confirmations = 0
+		if c.store != nil {
+			if err := c.store.SaveCounterpartyClaim(&storage.CounterpartyClaim{
+				TradeID: tradeID,
+				Chain:   chainSymbol,
+				TxID:    txID,
+			}); err != nil {
+				c.log.Warn("Failed to save counterparty claim", "trade_id", tradeID, "txid", txID, "error", err)
+			}
+		}
+	}
+	c.wakeAutoClaim()
+}
+
+// GetAutoClaimStatus returns the effective rule and the last decision for a swap.
+func (c *Coordinator) GetAutoClaimStatus(tradeID string) (*AutoClaimStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+
+	claimChain := active.Swap.Offer.OfferChain
+	if active.Swap.Role == RoleInitiator {
+		claimChain = active.Swap.Offer.RequestChain
+	}
+	rule, source := c.autoClaimRuleUnlocked(tradeID, claimChain)
+
+	status := &AutoClaimStatus{
+		TradeID: tradeID,
+		Chain:   claimChain,
+		Rule:    rule,
+		Source:  source,
+	}
+	if st, ok := c.autoClaims[tradeID]; ok {
+		status.Decision = st.decision
+		status.CounterpartyClaimChain = st.claimChain
+		status.CounterpartyClaimTxID = st.claimTxID
+		status.Confirmations = st.confirmations
+		status.ClaimTxID = st.claimedTxID
+		status.LastError = st.lastError
+	}
+	return status, nil
+}
+
+// StartAutoClaimMonitor evaluates swaps every policy CheckInterval and
+// whenever a secret or counterparty claim is learned.
+func (c *Coordinator) StartAutoClaimMonitor() {
+	c.mu.RLock()
+	interval := c.autoClaim.CheckInterval
+	c.mu.RUnlock()
+	if interval <= 0 {
+		c.log.Warn("Auto-claim monitor not started: check interval not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+			case <-c.autoClaimWake:
+			}
+			c.CheckAutoClaims(c.ctx)
+		}
+	}()
+}
+
+// wakeAutoClaim requests an immediate evaluation without blocking.
+func (c *Coordinator) wakeAutoClaim() {
+	select {
+	case c.autoClaimWake <- struct{}{}:
+	default:
+	}
+}
+
+// CheckAutoClaims evaluates the auto-claim policy for every swap.
+func (c *Coordinator) CheckAutoClaims(ctx context.Context) {
+	c.mu.RLock()
+	tradeIDs := make([]string, 0, len(c.swaps))
+	for id := range c.swaps {
+		tradeIDs = append(tradeIDs, id)
+	}
+	c.mu.RUnlock()
+
+	for _, tradeID := range tradeIDs {
+		c.evaluateAutoClaim(ctx, tradeID)
+	}
+}
+
+// evaluateAutoClaim applies the policy to one swap and claims when it allows.
+func (c *Coordinator) evaluateAutoClaim(ctx context.Context, tradeID string) {
+	c.mu.Lock()
+	active, ok := c.swaps[tradeID]
+	if !ok || !c.autoClaimEligibleUnlocked(active) {
+		c.mu.Unlock()
+		return
+	}
+	claimChain := active.Swap.Offer.OfferChain
+	rule, source := c.autoClaimRuleUnlocked(tradeID, claimChain)
+	st := c.autoClaimStateUnlocked(tradeID)
+	if st.claimedTxID != "" {
+		c.mu.Unlock()
+		return
+	}
+	decision := map[string]interface{}{
+		"chain":  claimChain,
+		"mode":   rule.Mode,
+		"source": source,
+	}
+
+	switch rule.Mode {
+	case AutoClaimManual:
+		c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimManual, decision)
+		c.mu.Unlock()
+		return
+
+	case AutoClaimConfirmations:
+		decision["required"] = rule.Confirmations
+		if st.claimTxID == "" {
+			decision["reason"] = "counterparty claim transaction unknown"
+			c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimWaiting, decision)
+			c.mu.Unlock()
+			return
+		}
+		b, ok := c.backends[st.claimChain]
+		claimTxID, watchChain, seen := st.claimTxID, st.claimChain, st.confirmations
+		c.mu.Unlock()
+		if !ok {
+			c.log.Debug("No backend for counterparty claim chain", "trade_id", tradeID, "chain", watchChain)
+			return
+		}
+
+		tx, err := b.GetTransaction(ctx, claimTxID)
+		if err != nil {
+			c.log.Debug("Failed to get counterparty claim", "trade_id", tradeID, "txid", claimTxID, "error", err)
+			return
+		}
+
+		c.mu.Lock()
+		st.confirmations = tx.Confirmations
+		if tx.Confirmations < int64(rule.Confirmations) {
+			decision["counterparty_claim_tx"] = claimTxID
+			decision["confirmations"] = tx.Confirmations
+			if st.decision != EventAutoClaimWaiting || seen != tx.Confirmations {
+				st.decision = ""
+			}
+			c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimWaiting, decision)
+			c.mu.Unlock()
+			return
+		}
+		decision["counterparty_claim_tx"] = claimTxID
+		decision["confirmations"] = tx.Confirmations
+
+	case AutoClaimImmediate:
+	default:
+		c.mu.Unlock()
+		return
+	}
+
+	// A retry after a failure is not a new decision
+	if st.decision != EventAutoClaimFailed {
+		c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimTriggered, decision)
+	}
+	c.mu.Unlock()
+
+	txID, err := c.autoClaimBroadcast(ctx, tradeID, claimChain)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if err != nil {
+		if st.lastError != err.Error() {
+			st.lastError = err.Error()
+			st.decision = ""
+			c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimFailed, map[string]interface{}{
+				"chain": claimChain,
+				"error": err.Error(),
+			})
+		}
+		c.log.Warn("Auto-claim failed", "trade_id", tradeID, "chain", claimChain, "error", err)
+		return
+	}
+
+	st.claimedTxID = txID
+	st.lastError = ""
+	c.autoClaimDecideUnlocked(tradeID, st, EventAutoClaimClaimed, map[string]interface{}{
+		"chain":    claimChain,
+		"claim_tx": txID,
+	})
+	c.log.Info("Auto-claimed", "trade_id", tradeID, "chain", claimChain, "txid", txID)
+}
+
+// autoClaimBroadcast claims our side of the swap on the chain.
+func (c *Coordinator) autoClaimBroadcast(ctx context.Context, tradeID, chainSymbol string) (string, error) {
+	if IsEVMChain(chainSymbol, c.network) {
+		txHash, err := c.ClaimEVMHTLC(ctx, tradeID, chainSymbol)
+		if err != nil {
+			return "", err
+		}
+		return txHash.Hex(), nil
+	}
+	return c.ClaimHTLC(ctx, tradeID, chainSymbol)
+}
+
+// autoClaimEligibleUnlocked returns true if the swap waits for our claim with
+// a secret learned from the counterparty: we are the HTLC responder, the swap
+// is not finished and the secret is known. Caller must hold c.mu.
+func (c *Coordinator) autoClaimEligibleUnlocked(active *ActiveSwap) bool {
+	if active.Swap.Role != RoleResponder || active.Swap.IsTerminal() {
+		return false
+	}
+	if active.HTLC == nil && active.EVMHTLC == nil {
+		return false
+	}
+	_, err := c.getSecretFromSwap(active)
+	return err == nil
+}
+
+// autoClaimRuleUnlocked resolves the rule for a trade claiming on a chain.
+// Caller must hold c.mu.
+func (c *Coordinator) autoClaimRuleUnlocked(tradeID, chainSymbol string) (AutoClaimRule, string) {
+	if rule, ok := c.autoClaimOverrides[tradeID]; ok {
+		return rule, AutoClaimSourceTrade
+	}
+	return c.autoClaim.RuleFor(chainSymbol)
+}
+
+// autoClaimStateUnlocked returns the decision state of a trade, creating it.
+// Caller must hold c.mu.
+func (c *Coordinator) autoClaimStateUnlocked(tradeID string) *autoClaimState {
+	if c.autoClaims == nil {
+		c.autoClaims = make(map[string]*autoClaimState)
+	}
+	st, ok := c.autoClaims[tradeID]
+	if !ok {
+		st = &autoClaimState{}
+		c.autoClaims[tradeID] = st
+	}
+	return st
+}
+
+// autoClaimDecideUnlocked emits a decision event unless it was the last one.
+// Caller must hold c.mu.
+func (c *Coordinator) autoClaimDecideUnlocked(tradeID string, st *autoClaimState, decision string, data map[string]interface{}) {
+	if st.decision == decision {
+		return
+	}
+	st.decision = decision
+	c.emitEvent(tradeID, decision, data)
+}
@@ -0,0 +1,276 @@
+package swap
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// newAutoClaimCoordinator returns a coordinator holding a BTC/LTC HTLC swap in
+// which we are the responder and have learned the secret. No funding is
+// recorded, so every claim attempt fails after the policy allowed it.
+func newAutoClaimCoordinator(t *testing.T, store *storage.Storage) (*Coordinator, *fakeChainBackend) {
+	t.Helper()
+	ltc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("LTC", ltc))
+
+	key, err := GenerateEphemeralKey()
+	if err != nil {
+		t.Fatalf("GenerateEphemeralKey() error = %v", err)
+	}
+	htlcChain := func(symbol string) *ChainHTLCData {
+		session, err := NewHTLCSessionWithKey(symbol, chain.Testnet, key)
+		if err != nil {
+			t.Fatalf("NewHTLCSessionWithKey() error = %v", err)
+		}
+		return &ChainHTLCData{Session: session}
+	}
+	for _, role := range []Role{RoleResponder, RoleInitiator} {
+		s := newTestHTLCSwap(t, chain.Testnet, role)
+		s.Secret = make([]byte, 32)
+		s.Secret[0] = 1
+		coord.swaps[string(role)] = &ActiveSwap{
+			Swap: s,
+			HTLC: &HTLCSwapData{LocalPrivKey: key, OfferChain: htlcChain("BTC"), RequestChain: htlcChain("LTC")},
+		}
+	}
+	return coord, ltc
+}
+
+// recordEvents collects the auto-claim events emitted by the coordinator.
+func recordEvents(coord *Coordinator) func() []string {
+	var mu sync.Mutex
+	var events []string
+	coord.OnEvent(func(e SwapEvent) {
+		if !strings.HasPrefix(e.EventType, "auto_claim_") {
+			return
+		}
+		mu.Lock()
+		events = append(events, e.EventType)
+		mu.Unlock()
+	})
+	return func() []string {
+		// Handlers run in goroutines
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), events...)
+	}
+}
+
+func autoClaimStatus(t *testing.T, coord *Coordinator, tradeID string) *AutoClaimStatus {
+	t.Helper()
+	status, err := coord.GetAutoClaimStatus(tradeID)
+	if err != nil {
+		t.Fatalf("GetAutoClaimStatus() error = %v", err)
+	}
+	return status
+}
+
+func TestAutoClaimPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  AutoClaimPolicy
+		wantErr bool
+	}{
+		{"immediate", AutoClaimPolicy{Mode: AutoClaimImmediate}, false},
+		{"manual", AutoClaimPolicy{Mode: AutoClaimManual}, false},
+		{"confirmations", AutoClaimPolicy{Mode: AutoClaimConfirmations, Confirmations: 2}, false},
+		{"confirmations without count", AutoClaimPolicy{Mode: AutoClaimConfirmations}, true},
+		{"empty mode", AutoClaimPolicy{}, true},
+		{"unknown mode", AutoClaimPolicy{Mode: "later"}, true},
+		{"bad chain override", AutoClaimPolicy{
+			Mode:   AutoClaimImmediate,
+			Chains: map[string]AutoClaimRule{"BTC": {Mode: AutoClaimConfirmations}},
+		}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestAutoClaimRuleResolution(t *testing.T) {
+	coord, _ := newAutoClaimCoordinator(t, nil)
+	policy := AutoClaimPolicy{
+		Mode:   AutoClaimImmediate,
+		Chains: map[string]AutoClaimRule{"BTC": {Mode: AutoClaimConfirmations, Confirmations: 6}},
+	}
+	if err := coord.SetAutoClaimPolicy(policy); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+
+	// The responder claims on BTC (chain override)
+	status := autoClaimStatus(t, coord, "responder")
+	if status.Chain != "BTC" || status.Source != AutoClaimSourceChain || status.Rule.Confirmations != 6 {
+		t.Errorf("responder status = %+v, want BTC chain override", status)
+	}
+
+	// The initiator claims on LTC (default)
+	status = autoClaimStatus(t, coord, "initiator")
+	if status.Chain != "LTC" || status.Source != AutoClaimSourceDefault || status.Rule.Mode != AutoClaimImmediate {
+		t.Errorf("initiator status = %+v, want LTC default", status)
+	}
+
+	// A trade override beats the chain override
+	if err := coord.SetAutoClaimOverride("responder", &AutoClaimRule{Mode: AutoClaimManual}); err != nil {
+		t.Fatalf("SetAutoClaimOverride() error = %v", err)
+	}
+	status = autoClaimStatus(t, coord, "responder")
+	if status.Source != AutoClaimSourceTrade || status.Rule.Mode != AutoClaimManual {
+		t.Errorf("status after override = %+v, want trade manual", status)
+	}
+
+	if err := coord.SetAutoClaimOverride("responder", nil); err != nil {
+		t.Fatalf("SetAutoClaimOverride(nil) error = %v", err)
+	}
+	if status = autoClaimStatus(t, coord, "responder"); status.Source != AutoClaimSourceChain {
+		t.Errorf("status after clearing = %+v, want chain override", status)
+	}
+
+	if err := coord.SetAutoClaimOverride("responder", &AutoClaimRule{Mode: "soon"}); err == nil {
+		t.Error("SetAutoClaimOverride() accepted an invalid mode")
+	}
+	if err := coord.SetAutoClaimOverride("missing", &AutoClaimRule{Mode: AutoClaimManual}); err != ErrSwapNotFound {
+		t.Errorf("SetAutoClaimOverride(missing) error = %v, want ErrSwapNotFound", err)
+	}
+}
+
+func TestAutoClaimManual(t *testing.T) {
+	coord, _ := newAutoClaimCoordinator(t, nil)
+	events := recordEvents(coord)
+	if err := coord.SetAutoClaimPolicy(AutoClaimPolicy{Mode: AutoClaimManual}); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+
+	ctx := context.Background()
+	coord.CheckAutoClaims(ctx)
+	coord.CheckAutoClaims(ctx)
+
+	status := autoClaimStatus(t, coord, "responder")
+	if status.Decision != EventAutoClaimManual || status.LastError != "" {
+		t.Errorf("status = %+v, want manual decision without claim attempt", status)
+	}
+	// The initiator reveals the secret by claiming; it is never auto-claimed
+	if status = autoClaimStatus(t, coord, "initiator"); status.Decision != "" {
+		t.Errorf("initiator decision = %q, want none", status.Decision)
+	}
+	if got := events(); len(got) != 1 || got[0] != EventAutoClaimManual {
+		t.Errorf("events = %v, want one %s", got, EventAutoClaimManual)
+	}
+}
+
+func TestAutoClaimImmediate(t *testing.T) {
+	coord, _ := newAutoClaimCoordinator(t, nil)
+	events := recordEvents(coord)
+	if err := coord.SetAutoClaimPolicy(AutoClaimPolicy{Mode: AutoClaimImmediate}); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+
+	ctx := context.Background()
+	coord.CheckAutoClaims(ctx)
+	coord.CheckAutoClaims(ctx) // Retry with the same error emits nothing new
+
+	status := autoClaimStatus(t, coord, "responder")
+	if status.Decision != EventAutoClaimFailed || !strings.Contains(status.LastError, "funding") {
+		t.Errorf("status = %+v, want failed claim attempt", status)
+	}
+	// Handlers run concurrently, so only the set of events is checked
+	all := events()
+	got := strings.Join(all, ",")
+	if len(all) != 2 || !strings.Contains(got, EventAutoClaimTriggered) || !strings.Contains(got, EventAutoClaimFailed) {
+		t.Errorf("events = %v, want triggered and failed once", got)
+	}
+}
+
+func TestAutoClaimAfterConfirmations(t *testing.T) {
+	coord, ltc := newAutoClaimCoordinator(t, nil)
+	if err := coord.SetAutoClaimPolicy(AutoClaimPolicy{Mode: AutoClaimConfirmations, Confirmations: 3}); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+	ctx := context.Background()
+
+	// Their claim is not known yet
+	coord.CheckAutoClaims(ctx)
+	status := autoClaimStatus(t, coord, "responder")
+	if status.Decision != EventAutoClaimWaiting || status.CounterpartyClaimTxID != "" {
+		t.Fatalf("status = %+v, want waiting for unknown claim", status)
+	}
+
+	if err := coord.RecordCounterpartyClaim("responder", "LTC", "claimtx"); err != nil {
+		t.Fatalf("RecordCounterpartyClaim() error = %v", err)
+	}
+	ltc.setConfirmations("claimtx", 1)
+	coord.CheckAutoClaims(ctx)
+	status = autoClaimStatus(t, coord, "responder")
+	if status.Decision != EventAutoClaimWaiting || status.Confirmations != 1 || status.LastError != "" {
+		t.Fatalf("status = %+v, want waiting at 1 confirmation", status)
+	}
+
+	ltc.setConfirmations("claimtx", 3)
+	coord.CheckAutoClaims(ctx)
+	status = autoClaimStatus(t, coord, "responder")
+	if status.Decision != EventAutoClaimFailed || status.Confirmations != 3 {
+		t.Errorf("status = %+v, want claim attempted at 3 confirmations", status)
+	}
+
+	if err := coord.RecordCounterpartyClaim("missing", "LTC", "tx"); err != ErrSwapNotFound {
+		t.Errorf("RecordCounterpartyClaim(missing) error = %v, want ErrSwapNotFound", err)
+	}
+}
+
+func TestAutoClaimOverridePersisted(t *testing.T) {
+	store := newTestStore(t)
+	policy := AutoClaimPolicy{Mode: AutoClaimImmediate}
+
+	coord, _ := newAutoClaimCoordinator(t, store)
+	if err := coord.SetAutoClaimPolicy(policy); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+	if err := coord.SetAutoClaimOverride("responder", &AutoClaimRule{Mode: AutoClaimManual}); err != nil {
+		t.Fatalf("SetAutoClaimOverride() error = %v", err)
+	}
+
+	// A restarted coordinator keeps the override
+	restarted, _ := newAutoClaimCoordinator(t, store)
+	if err := restarted.SetAutoClaimPolicy(policy); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+	status := autoClaimStatus(t, restarted, "responder")
+	if status.Source != AutoClaimSourceTrade || status.Rule.Mode != AutoClaimManual {
+		t.Errorf("status after restart = %+v, want persisted manual override", status)
+	}
+}
+
+func TestAutoClaimCounterpartyClaimPersisted(t *testing.T) {
+	store := newTestStore(t)
+	policy := AutoClaimPolicy{Mode: AutoClaimConfirmations, Confirmations: 3}
+
+	coord, _ := newAutoClaimCoordinator(t, store)
+	if err := coord.SetAutoClaimPolicy(policy); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+	if err := coord.RecordCounterpartyClaim("responder", "LTC", "claimtx"); err != nil {
+		t.Fatalf("RecordCounterpartyClaim() error = %v", err)
+	}
+
+	// A restarted coordinator keeps counting confirmations of their claim
+	restarted, ltc := newAutoClaimCoordinator(t, store)
+	if err := restarted.SetAutoClaimPolicy(policy); err != nil {
+		t.Fatalf("SetAutoClaimPolicy() error = %v", err)
+	}
+	ltc.setConfirmations("claimtx", 1)
+	restarted.CheckAutoClaims(context.Background())
+	status := autoClaimStatus(t, restarted, "responder")
+	if status.CounterpartyClaimChain != "LTC" || status.CounterpartyClaimTxID != "claimtx" || status.Confirmations != 1 {
+		t.Errorf("status after restart = %+v, want the recorded claim at 1 confirmation", status)
+	}
+}
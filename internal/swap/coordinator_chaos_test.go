@@ -0,0 +1,86 @@
+//go:build chaos
+
+package swap
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// broadcastCrashing runs BroadcastOnce and reports the crash point it
+// crashed at, if any.
+func broadcastCrashing(coord *Coordinator, b *fakeChainBackend, txHex string) (txID, point string, err error) {
+	defer func() {
+		if crash, ok := recover().(*chaos.Crash); ok {
+			point = crash.Point
+		}
+	}()
+	txID, err = coord.BroadcastOnce(context.Background(), b, "refund", "trade-1", "BTC", txHex)
+	return txID, "", err
+}
+
+func configureChaos(t *testing.T, cfg chaos.Config) {
+	t.Helper()
+	if err := chaos.Configure(cfg); err != nil {
+		t.Fatalf("chaos.Configure() error = %v", err)
+	}
+	t.Cleanup(func() { chaos.Configure(chaos.Config{}) })
+}
+
+// A crash after broadcasting but before the job is marked done must not let a
+// restarted coordinator broadcast a conflicting transaction.
+func TestChaosCrashAfterSideEffect(t *testing.T) {
+	store, fake := newTestStore(t), newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	first, second := testTxHex(t, 1000), testTxHex(t, 2000)
+
+	configureChaos(t, chaos.Config{
+		CrashPoints: map[string]int{chaos.PointAfterSideEffect: 10000},
+		CrashPanic:  true,
+	})
+	if _, point, _ := broadcastCrashing(coord, fake, first); point != chaos.PointAfterSideEffect {
+		t.Fatalf("crashed at %q, want %s", point, chaos.PointAfterSideEffect)
+	}
+	chaos.Configure(chaos.Config{})
+
+	// "Restart" and decide differently
+	restarted := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	txID, point, err := broadcastCrashing(restarted, fake, second)
+	if point != "" || err != nil {
+		t.Fatalf("BroadcastOnce() after restart crashed at %q, error = %v", point, err)
+	}
+	firstTx, _ := DeserializeTx(first)
+	if txID != firstTx.TxHash().String() {
+		t.Errorf("txid = %s, want the first decision %s", txID, firstTx.TxHash())
+	}
+	for _, b := range fake.broadcasts {
+		if b == second {
+			t.Fatal("conflicting transaction was broadcast after restart")
+		}
+	}
+	job, err := store.GetJob(JobID("refund", "trade-1", "BTC"))
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != storage.JobStatusBroadcast {
+		t.Errorf("job status = %s, want broadcast", job.Status)
+	}
+}
+
+// Storage write failures surface as errors instead of side effects going
+// out unrecorded.
+func TestChaosStorageErrors(t *testing.T) {
+	fake := newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withBackend("BTC", fake))
+	configureChaos(t, chaos.Config{StorageErrorBps: 10000})
+
+	if _, _, err := broadcastCrashing(coord, fake, testTxHex(t, 1000)); err == nil {
+		t.Fatal("BroadcastOnce() succeeded with failing storage")
+	}
+	if len(fake.broadcasts) != 0 {
+		t.Errorf("broadcast %d transactions without a persisted job", len(fake.broadcasts))
+	}
+}
@@ -0,0 +1,22 @@
+// Package swap - Cold mode: claim and refund only.
+//
+// A coordinator in cold mode never originates or joins swaps and never
+// builds funding transactions, EVM HTLCs or Lightning payments. It still
+// loads, monitors, claims and refunds the swaps it holds keys for, so a
+// recovery machine can be brought online to rescue in-flight trades
+// without risking new funds.
+package swap
+
+// SetColdMode turns cold mode on or off.
+func (c *Coordinator) SetColdMode(cold bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.cold = cold
+}
+
+// IsColdMode returns true while the coordinator only claims and refunds.
+func (c *Coordinator) IsColdMode() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.cold
+}
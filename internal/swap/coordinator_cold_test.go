@@ -0,0 +1,111 @@
+package swap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestColdModeRefusesNewSwaps(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	defer coord.Close()
+
+	coord.SetColdMode(true)
+	if !coord.IsColdMode() {
+		t.Fatal("IsColdMode() = false after SetColdMode(true)")
+	}
+
+	offer := Offer{OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 5000000, Method: MethodMuSig2}
+	ctx := context.Background()
+	if _, err := coord.InitiateSwap(ctx, "trade-1", "order-1", offer, MethodMuSig2); !errors.Is(err, ErrColdMode) {
+		t.Errorf("InitiateSwap() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.RespondToSwap(ctx, "trade-2", offer, make([]byte, 33), nil, MethodMuSig2); !errors.Is(err, ErrColdMode) {
+		t.Errorf("RespondToSwap() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.InitiateCrossChainSwap(ctx, "trade-3", "order-3", offer); !errors.Is(err, ErrColdMode) {
+		t.Errorf("InitiateCrossChainSwap() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.RespondToCrossChainSwap(ctx, "trade-4", offer, make([]byte, 33), nil, ""); !errors.Is(err, ErrColdMode) {
+		t.Errorf("RespondToCrossChainSwap() error = %v, want ErrColdMode", err)
+	}
+}
+
+func TestColdModeRefusesFunding(t *testing.T) {
+	coord := newTestCoordinator(t, withBackend("LTC", newFakeChainBackend()))
+	newLightningSwap(t, coord, "t1", RoleInitiator, bytes.Repeat([]byte{9}, 32))
+	coord.SetColdMode(true)
+
+	ctx := context.Background()
+	if _, err := coord.FundSwap(ctx, "t1"); !errors.Is(err, ErrColdMode) {
+		t.Errorf("FundSwap() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.CreateFundingTx(ctx, "t1"); !errors.Is(err, ErrColdMode) {
+		t.Errorf("CreateFundingTx() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.CreateEVMHTLC(ctx, "t1", "ETH"); !errors.Is(err, ErrColdMode) {
+		t.Errorf("CreateEVMHTLC() error = %v, want ErrColdMode", err)
+	}
+	if _, _, err := coord.PayLightningInvoice(ctx, "t1"); !errors.Is(err, ErrColdMode) {
+		t.Errorf("PayLightningInvoice() error = %v, want ErrColdMode", err)
+	}
+	if _, err := coord.RequestExternalFunding(ctx, "t1"); !errors.Is(err, ErrColdMode) {
+		t.Errorf("RequestExternalFunding() error = %v, want ErrColdMode", err)
+	}
+
+	// The swap stays loaded for monitoring, claims and refunds
+	if _, err := coord.GetSwap("t1"); err != nil {
+		t.Errorf("GetSwap() error = %v", err)
+	}
+}
+
+func TestColdModeHeldFundingStep(t *testing.T) {
+	ltc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	requireConfirmations(t, coord)
+	step := holdTestFunding(t, coord)
+	coord.SetColdMode(true)
+
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, true); !errors.Is(err, ErrColdMode) {
+		t.Errorf("ConfirmStep(approve) error = %v, want ErrColdMode", err)
+	}
+	if len(ltc.txs) != 0 {
+		t.Error("held funding broadcast in cold mode")
+	}
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, false); err != nil {
+		t.Errorf("ConfirmStep(reject) error = %v", err)
+	}
+}
+
+func TestColdModeResumePendingJobs(t *testing.T) {
+	store := newTestStore(t)
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", newFakeChainBackend()))
+	coord.SetColdMode(true)
+	ctx := context.Background()
+
+	for _, action := range []string{JobActionFund, JobActionRefund} {
+		if _, _, err := store.EnqueueJob(&storage.Job{
+			ID: JobID(action, "trade-1", "BTC"), TradeID: "trade-1", Chain: "BTC",
+			Kind: storage.JobKindBroadcastTx, Action: action, Payload: testTxHex(t, 1000),
+		}); err != nil {
+			t.Fatalf("EnqueueJob() error = %v", err)
+		}
+	}
+
+	// The refund goes out, the funding waits
+	resumed, err := coord.ResumePendingJobs(ctx)
+	if err != nil {
+		t.Fatalf("ResumePendingJobs() error = %v", err)
+	}
+	if resumed != 1 {
+		t.Errorf("ResumePendingJobs() = %d, want 1", resumed)
+	}
+	pending, _ := store.GetPendingJobs()
+	if len(pending) != 1 || pending[0].Action != JobActionFund {
+		t.Errorf("pending jobs = %+v, want the funding job", pending)
+	}
+}
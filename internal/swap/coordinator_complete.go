@@ -7,6 +7,8 @@ import (
 	"crypto/sha256"
 	"errors"
 	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
 )
 
 // =============================================================================
@@ -41,6 +43,7 @@ func (c *Coordinator) CompleteSwap(tradeID string, redeemTxID string) error {
 
 // RefundSwap initiates a refund after timeout.
 func (c *Coordinator) RefundSwap(ctx context.Context, tradeID string) error {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -116,8 +119,11 @@ func (c *Coordinator) SetRemoteSecretHash(tradeID string, secretHash []byte) err
 		return ErrSwapNotFound
 	}
 
-	if len(secretHash) != 32 {
-		return fmt.Errorf("invalid secret hash length: expected 32, got %d", len(secretHash))
+	if len(active.Swap.SecretHash) > 0 && !bytes.Equal(active.Swap.SecretHash, secretHash) {
+		return errors.New("secret hash differs from the one the swap was set up with")
+	}
+	if err := c.claimSecretHashUnlocked(tradeID, secretHash); err != nil {
+		return err
 	}
 
 	active.Swap.SecretHash = secretHash
@@ -179,5 +185,6 @@ func (c *Coordinator) SetRevealedSecret(tradeID string, secret []byte) error {
 	}
 
 	c.emitEvent(tradeID, "secret_revealed", nil)
+	c.wakeAutoClaim()
 	return nil
 }
@@ -0,0 +1,425 @@
+// Package swap - Confirmation of fund-moving steps.
+//
+// With the manual confirmation policy, FundSwap stops after building and
+// signing the funding transaction: the transaction is held as a pending
+// step with a preview of exactly what will be broadcast, and an
+// EventStepConfirmationRequired event gives wallets a place to show a
+// "you are about to lock 0.5 BTC" dialog. ConfirmStep broadcasts the held
+// transaction unchanged, or drops it. CreateEVMHTLC is held the same way
+// before it signs anything, as the contract call is signed with the
+// account's next nonce when it's sent; ConfirmStep then creates the HTLC
+// previewed. Steps at or below a chain's auto-confirm amount proceed
+// without asking. Claims and refunds pay our own wallet and are time-critical, so they are
+// never held.
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"sort"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/pkg/helpers"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// Confirmation modes.
+const (
+	ConfirmAuto   = "auto"   // Fund-moving steps proceed without asking
+	ConfirmManual = "manual" // Fund-moving steps wait for ConfirmStep
+)
+
+// Step kinds.
+const (
+	StepFund      = "fund"       // Funding our leg's escrow
+	StepEVMCreate = "evm_create" // Creating our leg's EVM HTLC
+)
+
+// Step confirmation events.
+const (
+	EventStepConfirmationRequired = "step_confirmation_required" // A step waits for ConfirmStep
+	EventStepConfirmed            = "step_confirmed"             // Approved and broadcast
+	EventStepRejected             = "step_rejected"              // Rejected or expired
+)
+
+// Step confirmation errors.
+var (
+	ErrConfirmationRequired = errors.New("step requires confirmation")
+	ErrStepNotFound         = errors.New("pending step not found")
+	ErrStepExpired          = errors.New("pending step expired")
+)
+
+// ConfirmationPolicy is the node-wide confirmation policy of fund-moving
+// steps.
+type ConfirmationPolicy struct {
+	// Mode is "auto" (default) or "manual".
+	Mode string `yaml:"mode,omitempty" json:"mode,omitempty"`
+
+	// AutoConfirmBelow maps chain symbols to an amount (smallest unit) at or
+	// below which steps proceed without asking in manual mode.
+	AutoConfirmBelow map[string]uint64 `yaml:"auto_confirm_below,omitempty" json:"auto_confirm_below,omitempty"`
+
+	// Timeout is how long a pending step can be confirmed. The held
+	// transaction is dropped after it; funding again builds a new one.
+	Timeout time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`
+}
+
+// Validate checks the policy.
+func (p *ConfirmationPolicy) Validate() error {
+	switch p.Mode {
+	case "", ConfirmAuto, ConfirmManual:
+	default:
+		return fmt.Errorf("unknown confirmation.mode %q (auto, manual)", p.Mode)
+	}
+	if p.Mode == ConfirmManual && p.Timeout <= 0 {
+		return fmt.Errorf("confirmation.timeout must be positive in manual mode")
+	}
+	return nil
+}
+
+// StepPreview is what a pending step will do once confirmed.
+type StepPreview struct {
+	StepID  string `json:"step_id"`
+	TradeID string `json:"trade_id"`
+	Kind    string `json:"kind"`
+	Chain   string `json:"chain"`
+
+	// Amount locked in the escrow and the DAO fee paid alongside, in the
+	// chain's smallest unit, and the same in whole coins for display.
+	Amount        uint64 `json:"amount"`
+	DAOFee        uint64 `json:"dao_fee"`
+	Fee           uint64 `json:"fee"` // Mining fee
+	AmountDisplay string `json:"amount_display"`
+	Summary       string `json:"summary"`
+
+	// The exact signed transaction to be broadcast. EVM steps have the
+	// HTLC contract as the escrow address and no transaction yet.
+	EscrowAddr string `json:"escrow_address"`
+	EscrowVout uint32 `json:"escrow_vout"`
+	TxID       string `json:"txid"`
+	TxHex      string `json:"tx_hex"`
+	InputCount int    `json:"input_count"`
+	TotalInput uint64 `json:"total_input"`
+	Change     uint64 `json:"change"`
+	VSize      int64  `json:"vsize"`
+
+	CreatedAt int64 `json:"created_at"`
+	ExpiresAt int64 `json:"expires_at"`
+}
+
+// ConfirmationRequiredError is returned by steps held for confirmation.
+type ConfirmationRequiredError struct {
+	Step *StepPreview
+}
+
+func (e *ConfirmationRequiredError) Error() string {
+	return fmt.Sprintf("%v: %s (confirm step %s)", ErrConfirmationRequired, e.Step.Summary, e.Step.StepID)
+}
+
+func (e *ConfirmationRequiredError) Unwrap() error { return ErrConfirmationRequired }
+
+// pendingStep is a held step and what it needs to proceed.
+type pendingStep struct {
+	preview StepPreview
+	funding *fundingBuild // Funding steps
+}
+
+// SetConfirmationPolicy sets the node-wide confirmation policy.
+func (c *Coordinator) SetConfirmationPolicy(policy ConfirmationPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.confirmation = policy
+	return nil
+}
+
+// needsConfirmationUnlocked returns true when a step moving amount on chain
+// must be confirmed. Caller must hold c.mu.
+func (c *Coordinator) needsConfirmationUnlocked(symbol string, amount uint64) bool {
+	if c.confirmation.Mode != ConfirmManual {
+		return false
+	}
+	limit, ok := c.confirmation.AutoConfirmBelow[symbol]
+	return !ok || amount > limit
+}
+
+// stepID returns the ID of a trade's pending step of a kind. A trade has
+// at most one pending step per kind.
+func stepID(tradeID, kind string) string {
+	return tradeID + ":" + kind
+}
+
+// holdFundingUnlocked holds a built funding transaction for confirmation
+// and returns the ConfirmationRequiredError describing it. Caller must hold
+// c.mu.
+func (c *Coordinator) holdFundingUnlocked(tradeID string, built *fundingBuild) error {
+	now := time.Now()
+	display := c.displayAmount(built.chain, built.amount)
+	step := &pendingStep{
+		preview: StepPreview{
+			StepID:        stepID(tradeID, StepFund),
+			TradeID:       tradeID,
+			Kind:          StepFund,
+			Chain:         built.chain,
+			Amount:        built.amount,
+			DAOFee:        built.daoFee,
+			Fee:           built.tx.Fee,
+			AmountDisplay: display,
+			Summary:       fmt.Sprintf("lock %s %s in the swap escrow", display, built.chain),
+			EscrowAddr:    built.escrowAddr,
+			EscrowVout:    built.escrowVout,
+			TxID:          built.tx.TxID,
+			TxHex:         built.tx.TxHex,
+			InputCount:    built.tx.InputCount,
+			TotalInput:    built.tx.TotalInput,
+			Change:        built.tx.Change,
+			VSize:         built.tx.VirtualSize,
+			CreatedAt:     now.Unix(),
+			ExpiresAt:     now.Add(c.confirmation.Timeout).Unix(),
+		},
+		funding: built,
+	}
+	return c.holdStepUnlocked(step)
+}
+
+// holdEVMCreateUnlocked holds the creation of an EVM HTLC for confirmation
+// and returns the ConfirmationRequiredError describing it. Caller must hold
+// c.mu.
+func (c *Coordinator) holdEVMCreateUnlocked(tradeID, chainSymbol string, session *EVMHTLCSession) error {
+	now := time.Now()
+	amount := evmStepAmount(session.GetAmount())
+	display := c.displayAmount(chainSymbol, amount)
+	return c.holdStepUnlocked(&pendingStep{
+		preview: StepPreview{
+			StepID:        stepID(tradeID, StepEVMCreate),
+			TradeID:       tradeID,
+			Kind:          StepEVMCreate,
+			Chain:         chainSymbol,
+			Amount:        amount,
+			AmountDisplay: display,
+			Summary:       fmt.Sprintf("lock %s %s in the swap HTLC contract", display, chainSymbol),
+			EscrowAddr:    session.ContractAddress().Hex(),
+			CreatedAt:     now.Unix(),
+			ExpiresAt:     now.Add(c.confirmation.Timeout).Unix(),
+		},
+	})
+}
+
+// evmStepAmount returns an EVM amount as a step amount, capped at the
+// largest uint64.
+func evmStepAmount(amount *big.Int) uint64 {
+	switch {
+	case amount == nil || amount.Sign() <= 0:
+		return 0
+	case amount.IsUint64():
+		return amount.Uint64()
+	default:
+		return math.MaxUint64
+	}
+}
+
+// displayAmount formats an amount of a chain in whole coins.
+func (c *Coordinator) displayAmount(symbol string, amount uint64) string {
+	if params, ok := chain.Get(symbol, c.network); ok {
+		return helpers.FormatAmount(amount, params.Decimals)
+	}
+	return fmt.Sprintf("%d", amount)
+}
+
+// holdStepUnlocked stores a pending step, replacing an earlier one of the
+// trade and kind, and announces it. Caller must hold c.mu.
+func (c *Coordinator) holdStepUnlocked(step *pendingStep) error {
+	tradeID := step.preview.TradeID
+	if c.pendingSteps == nil {
+		c.pendingSteps = make(map[string]*pendingStep)
+	}
+	// Holding again replaces the held step with a fresh one
+	c.pendingSteps[step.preview.StepID] = step
+
+	preview := step.preview
+	c.emitEvent(tradeID, EventStepConfirmationRequired, &preview)
+	return &ConfirmationRequiredError{Step: &preview}
+}
+
+// PendingSteps returns the steps waiting for confirmation, oldest first.
+// Expired steps are dropped.
+func (c *Coordinator) PendingSteps() []StepPreview {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireStepsUnlocked(time.Now())
+	steps := make([]StepPreview, 0, len(c.pendingSteps))
+	for _, step := range c.pendingSteps {
+		steps = append(steps, step.preview)
+	}
+	sort.Slice(steps, func(i, j int) bool {
+		if steps[i].CreatedAt != steps[j].CreatedAt {
+			return steps[i].CreatedAt < steps[j].CreatedAt
+		}
+		return steps[i].StepID < steps[j].StepID
+	})
+	return steps
+}
+
+// ConfirmStep approves or rejects a pending step and returns its trade. An
+// approved funding step broadcasts the held transaction unchanged and
+// returns its result; an approved EVM step creates the HTLC previewed and
+// returns the contract call's hash as the TxID. Nothing is sent holding
+// c.mu.
+func (c *Coordinator) ConfirmStep(ctx context.Context, id string, approve bool) (string, *FundSwapResult, error) {
+	step, send, err := c.takeStep(id, approve)
+	if step == nil {
+		return "", nil, err
+	}
+	tradeID := step.preview.TradeID
+	if err != nil || send == nil {
+		return tradeID, nil, err
+	}
+
+	record, err := send(backend.WithTradeID(ctx, tradeID))
+	if err != nil {
+		return tradeID, nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	result, err := record()
+	if err != nil {
+		return tradeID, nil, err
+	}
+	c.emitEvent(tradeID, EventStepConfirmed, map[string]interface{}{
+		"step_id": id,
+		"kind":    step.preview.Kind,
+		"txid":    result.TxID,
+	})
+	return tradeID, result, nil
+}
+
+// stepSend sends an approved step without c.mu and returns how to record
+// it, which runs holding c.mu.
+type stepSend func(ctx context.Context) (record func() (*FundSwapResult, error), err error)
+
+// takeStep removes a pending step and, if approved and its swap still needs
+// it, returns how to send it. A step that can't be found is returned nil.
+func (c *Coordinator) takeStep(id string, approve bool) (*pendingStep, stepSend, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.expireStepsUnlocked(time.Now())
+	step, ok := c.pendingSteps[id]
+	if !ok {
+		return nil, nil, ErrStepNotFound
+	}
+	if approve && c.cold {
+		return step, nil, ErrColdMode // Can still be rejected
+	}
+	delete(c.pendingSteps, id)
+	tradeID := step.preview.TradeID
+
+	if !approve {
+		c.emitEvent(tradeID, EventStepRejected, map[string]interface{}{
+			"step_id": id,
+			"kind":    step.preview.Kind,
+		})
+		return step, nil, nil
+	}
+
+	// The swap may have moved on while the step waited
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return step, nil, ErrSwapNotFound
+	}
+	if step.preview.Kind == StepEVMCreate {
+		send, err := c.evmCreateSendUnlocked(tradeID, active, step)
+		return step, send, err
+	}
+	if active.Swap.LocalFundingTxID != "" {
+		return step, nil, ErrAlreadyFunded
+	}
+	if active.Swap.ExternalFunding {
+		return step, nil, ErrExternalFunding
+	}
+	if active.Swap.SettlesOverLightning() {
+		return step, nil, ErrLightningSettlement
+	}
+	if active.Swap.IsTerminal() {
+		return step, nil, fmt.Errorf("swap is %s", active.Swap.State)
+	}
+	b, ok := c.backends[step.funding.chain]
+	if !ok {
+		return step, nil, fmt.Errorf("%w: %s", ErrNoBackend, step.funding.chain)
+	}
+
+	return step, func(ctx context.Context) (func() (*FundSwapResult, error), error) {
+		sent, err := c.broadcastJob(ctx, b, JobActionFund, tradeID, step.funding.chain, step.funding.tx.TxHex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to broadcast funding tx: %w", err)
+		}
+		return func() (*FundSwapResult, error) {
+			active, ok := c.swaps[tradeID]
+			if !ok {
+				return nil, ErrSwapNotFound
+			}
+			if txid := active.Swap.LocalFundingTxID; txid != "" && txid != sent.TxID {
+				c.log.Warn("Swap funded another way while the confirmed funding was broadcast", "trade_id", tradeID, "txid", txid, "broadcast", sent.TxID)
+				return nil, ErrAlreadyFunded
+			}
+			return c.recordFundingUnlocked(tradeID, active, step.funding, sent)
+		}, nil
+	}, nil
+}
+
+// evmCreateSendUnlocked checks that a held EVM HTLC creation can still go
+// ahead and returns how to send it. Caller must hold c.mu.
+func (c *Coordinator) evmCreateSendUnlocked(tradeID string, active *ActiveSwap, step *pendingStep) (stepSend, error) {
+	chainSymbol := step.preview.Chain
+	if active.Swap.IsTerminal() {
+		return nil, fmt.Errorf("swap is %s", active.Swap.State)
+	}
+	session, err := c.prepareEVMCreateUnlocked(active, chainSymbol)
+	if err != nil {
+		return nil, err
+	}
+	if session.GetCreateTxHash() != (common.Hash{}) {
+		return nil, ErrAlreadyFunded
+	}
+
+	return func(ctx context.Context) (func() (*FundSwapResult, error), error) {
+		txHash, err := c.runEVMCreate(ctx, tradeID, chainSymbol, session)
+		if err != nil {
+			return nil, err
+		}
+		return func() (*FundSwapResult, error) {
+			c.recordEVMCreateUnlocked(tradeID, chainSymbol, session, txHash)
+			return &FundSwapResult{
+				TxID:       txHash.Hex(),
+				Chain:      chainSymbol,
+				Amount:     step.preview.Amount,
+				EscrowAddr: step.preview.EscrowAddr,
+			}, nil
+		}, nil
+	}, nil
+}
+
+// expireStepsUnlocked drops pending steps past their expiry. Caller must
+// hold c.mu.
+func (c *Coordinator) expireStepsUnlocked(now time.Time) {
+	for id, step := range c.pendingSteps {
+		if now.Unix() < step.preview.ExpiresAt {
+			continue
+		}
+		delete(c.pendingSteps, id)
+		c.emitEvent(step.preview.TradeID, EventStepRejected, map[string]interface{}{
+			"step_id": id,
+			"kind":    step.preview.Kind,
+			"reason":  ErrStepExpired.Error(),
+		})
+	}
+}
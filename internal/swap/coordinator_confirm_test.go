@@ -0,0 +1,206 @@
+package swap
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// requireConfirmations holds every step for confirmation, for a minute.
+func requireConfirmations(t *testing.T, coord *Coordinator) {
+	t.Helper()
+	if err := coord.SetConfirmationPolicy(ConfirmationPolicy{Mode: ConfirmManual, Timeout: time.Minute}); err != nil {
+		t.Fatalf("SetConfirmationPolicy() error = %v", err)
+	}
+}
+
+// holdTestFunding holds a built funding transaction of trade t1, as FundSwap
+// does under the manual policy.
+func holdTestFunding(t *testing.T, coord *Coordinator) *StepPreview {
+	t.Helper()
+	newLightningSwap(t, coord, "t1", RoleInitiator, bytes.Repeat([]byte{9}, 32))
+	built := &fundingBuild{
+		chain: "LTC", amount: 50_000_000, daoFee: 100_000, escrowAddr: "tltc1escrow",
+		tx: &wallet.MultiAddressTxResult{TxHex: testTxHex(t, 1), TxID: "txid-signed", Fee: 1_500, InputCount: 2, TotalInput: 60_000_000, Change: 9_898_500, VirtualSize: 210},
+	}
+	coord.mu.Lock()
+	err := coord.holdFundingUnlocked("t1", built)
+	coord.mu.Unlock()
+
+	var held *ConfirmationRequiredError
+	if !errors.As(err, &held) || !errors.Is(err, ErrConfirmationRequired) {
+		t.Fatalf("holdFundingUnlocked() error = %v, want ConfirmationRequiredError", err)
+	}
+	return held.Step
+}
+
+func TestConfirmationPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ConfirmationPolicy
+		wantErr bool
+	}{
+		{"default", ConfirmationPolicy{}, false},
+		{"auto", ConfirmationPolicy{Mode: ConfirmAuto}, false},
+		{"manual", ConfirmationPolicy{Mode: ConfirmManual, Timeout: time.Minute}, false},
+		{"manual without timeout", ConfirmationPolicy{Mode: ConfirmManual}, true},
+		{"unknown mode", ConfirmationPolicy{Mode: "ask"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNeedsConfirmation(t *testing.T) {
+	coord := newTestCoordinator(t, withBackend("LTC", newFakeChainBackend()))
+	if coord.needsConfirmationUnlocked("BTC", 1) {
+		t.Error("auto policy asks for confirmation")
+	}
+
+	coord.SetConfirmationPolicy(ConfirmationPolicy{
+		Mode:             ConfirmManual,
+		Timeout:          time.Minute,
+		AutoConfirmBelow: map[string]uint64{"BTC": 10_000},
+	})
+	tests := []struct {
+		chain  string
+		amount uint64
+		want   bool
+	}{
+		{"BTC", 10_000, false},
+		{"BTC", 10_001, true},
+		{"LTC", 1, true},
+	}
+	for _, tt := range tests {
+		if got := coord.needsConfirmationUnlocked(tt.chain, tt.amount); got != tt.want {
+			t.Errorf("needsConfirmationUnlocked(%s, %d) = %v, want %v", tt.chain, tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestConfirmStepApprove(t *testing.T) {
+	ltc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	requireConfirmations(t, coord)
+	step := holdTestFunding(t, coord)
+
+	if step.StepID != "t1:fund" || step.TxHex != testTxHex(t, 1) || step.Fee != 1_500 || step.AmountDisplay != "0.5" {
+		t.Errorf("preview = %+v", step)
+	}
+	if !strings.Contains(step.Summary, "lock 0.5 LTC") {
+		t.Errorf("Summary = %q", step.Summary)
+	}
+	if len(ltc.broadcasts) != 0 {
+		t.Fatal("held step was broadcast")
+	}
+	if steps := coord.PendingSteps(); len(steps) != 1 || steps[0].StepID != step.StepID {
+		t.Fatalf("PendingSteps() = %+v", steps)
+	}
+
+	tradeID, result, err := coord.ConfirmStep(context.Background(), step.StepID, true)
+	if err != nil {
+		t.Fatalf("ConfirmStep() error = %v", err)
+	}
+	if tradeID != "t1" || len(ltc.broadcasts) != 1 || ltc.broadcasts[0] != step.TxHex || ltc.txs[result.TxID] == nil {
+		t.Errorf("ConfirmStep() = %+v, broadcast %v", result, ltc.broadcasts)
+	}
+	if s := coord.swaps["t1"].Swap; s.LocalFundingTxID != result.TxID {
+		t.Errorf("LocalFundingTxID = %q", s.LocalFundingTxID)
+	}
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, true); !errors.Is(err, ErrStepNotFound) {
+		t.Errorf("second ConfirmStep() error = %v, want ErrStepNotFound", err)
+	}
+}
+
+func TestConfirmStepReject(t *testing.T) {
+	ltc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	requireConfirmations(t, coord)
+	step := holdTestFunding(t, coord)
+
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, false); err != nil {
+		t.Fatalf("ConfirmStep(reject) error = %v", err)
+	}
+	if len(ltc.broadcasts) != 0 || len(coord.PendingSteps()) != 0 {
+		t.Errorf("rejected step broadcast %v, pending %d", ltc.broadcasts, len(coord.PendingSteps()))
+	}
+}
+
+func TestConfirmStepChecks(t *testing.T) {
+	ltc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	requireConfirmations(t, coord)
+
+	// Funded another way while the step waited
+	step := holdTestFunding(t, coord)
+	coord.swaps["t1"].Swap.LocalFundingTxID = "other"
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, true); !errors.Is(err, ErrAlreadyFunded) {
+		t.Errorf("ConfirmStep() error = %v, want ErrAlreadyFunded", err)
+	}
+
+	// Expired
+	step = holdTestFunding(t, coord)
+	coord.pendingSteps[step.StepID].preview.ExpiresAt = time.Now().Unix()
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, true); !errors.Is(err, ErrStepNotFound) {
+		t.Errorf("ConfirmStep() of an expired step error = %v, want ErrStepNotFound", err)
+	}
+	if len(ltc.broadcasts) != 0 {
+		t.Errorf("broadcast %v", ltc.broadcasts)
+	}
+}
+
+func TestConfirmStepEVMCreate(t *testing.T) {
+	coord := newTestCoordinator(t, withBackend("LTC", newFakeChainBackend()))
+	requireConfirmations(t, coord)
+	contract := common.HexToAddress("0x00000000000000000000000000000000000000cc")
+	session := &EVMHTLCSession{symbol: "ETH", contract: contract, amount: big.NewInt(2e18)}
+	coord.swaps["t1"] = &ActiveSwap{Swap: &Swap{
+		Role: RoleInitiator, State: StateFunding,
+		Offer: Offer{OfferChain: "ETH", RequestChain: "BTC"},
+	}}
+
+	hold := func() *StepPreview {
+		coord.mu.Lock()
+		err := coord.holdEVMCreateUnlocked("t1", "ETH", session)
+		coord.mu.Unlock()
+		var held *ConfirmationRequiredError
+		if !errors.As(err, &held) {
+			t.Fatalf("holdEVMCreateUnlocked() error = %v, want ConfirmationRequiredError", err)
+		}
+		return held.Step
+	}
+
+	step := hold()
+	if step.StepID != "t1:evm_create" || step.Kind != StepEVMCreate || step.Amount != 2e18 || step.EscrowAddr != contract.Hex() {
+		t.Errorf("preview = %+v", step)
+	}
+	if _, _, err := coord.ConfirmStep(context.Background(), step.StepID, false); err != nil {
+		t.Fatalf("ConfirmStep(reject) error = %v", err)
+	}
+	if len(coord.PendingSteps()) != 0 {
+		t.Error("rejected step still pending")
+	}
+
+	// Approval checks the swap again before anything is sent
+	step = hold()
+	coord.swaps["t1"].Swap.State = StateRefunded
+	if _, result, err := coord.ConfirmStep(context.Background(), step.StepID, true); err == nil || result != nil {
+		t.Errorf("ConfirmStep() of a refunded swap = %+v, %v", result, err)
+	}
+
+	if got := evmStepAmount(new(big.Int).Lsh(big.NewInt(1), 70)); got != math.MaxUint64 {
+		t.Errorf("evmStepAmount(2^70) = %d, want MaxUint64", got)
+	}
+}
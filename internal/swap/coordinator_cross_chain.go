@@ -10,6 +10,7 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 )
 
 // =============================================================================
@@ -18,7 +19,23 @@ import (
 
 // InitiateCrossChainSwap starts a cross-chain swap as the initiator.
 // Handles EVM ↔ EVM, EVM ↔ Bitcoin, and Bitcoin ↔ Bitcoin swaps.
-func (c *Coordinator) InitiateCrossChainSwap(ctx context.Context, tradeID, orderID string, offer Offer) (*ActiveSwap, error) {
+func (c *Coordinator) InitiateCrossChainSwap(ctx context.Context, tradeID, orderID string, offer Offer) (_ *ActiveSwap, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.initiate_cross_chain")
+	defer func() { tracing.End(span, err) }()
+
+	if c.IsDegraded() {
+		return nil, ErrDegradedMode
+	}
+	if c.IsClockSkewed() {
+		return nil, ErrClockSkewed
+	}
+	if c.IsColdMode() {
+		return nil, ErrColdMode
+	}
+	if err := c.checkContractsActive(offer.OfferChain, offer.RequestChain); err != nil {
+		return nil, err
+	}
+
 	// Determine swap type based on chains
 	swapType := GetCrossChainSwapType(offer.OfferChain, offer.RequestChain, c.network)
 
@@ -49,7 +66,27 @@ func (c *Coordinator) InitiateCrossChainSwap(ctx context.Context, tradeID, order
 }
 
 // RespondToCrossChainSwap joins a cross-chain swap as the responder.
-func (c *Coordinator) RespondToCrossChainSwap(ctx context.Context, tradeID string, offer Offer, remotePubKey []byte, secretHash []byte, remoteEVMAddr string) (*ActiveSwap, error) {
+func (c *Coordinator) RespondToCrossChainSwap(ctx context.Context, tradeID string, offer Offer, remotePubKey []byte, secretHash []byte, remoteEVMAddr string) (_ *ActiveSwap, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.respond_cross_chain")
+	defer func() { tracing.End(span, err) }()
+
+	if c.IsDegraded() {
+		return nil, ErrDegradedMode
+	}
+	if c.IsClockSkewed() {
+		return nil, ErrClockSkewed
+	}
+	if c.IsColdMode() {
+		return nil, ErrColdMode
+	}
+	if err := c.checkContractsActive(offer.OfferChain, offer.RequestChain); err != nil {
+		return nil, err
+	}
+	// Refuse malformed hashes and hashes of other trades
+	if err := c.ClaimSecretHash(tradeID, secretHash); err != nil {
+		return nil, err
+	}
+
 	// Determine swap type based on chains
 	swapType := GetCrossChainSwapType(offer.OfferChain, offer.RequestChain, c.network)
 
@@ -299,7 +336,7 @@ func (c *Coordinator) initiateBitcoinToEVMSwap(ctx context.Context, tradeID stri
 	// Store local wallet addresses for P2P exchange
 	// Offer chain is Bitcoin (need to derive address from wallet)
 	if c.wallet != nil {
-		btcAddr, err := c.wallet.DeriveAddress(offer.OfferChain, 0, 0)
+		btcAddr, err := c.walletAddressUnlocked(offer.OfferChain)
 		if err == nil {
 			swap.LocalOfferWalletAddr = btcAddr
 		}
@@ -398,7 +435,7 @@ func (c *Coordinator) initiateEVMToBitcoinSwap(ctx context.Context, tradeID stri
 	swap.LocalOfferWalletAddr = evmSession.GetLocalAddress().Hex()
 	// Request chain is Bitcoin (need to derive address from wallet)
 	if c.wallet != nil {
-		btcAddr, err := c.wallet.DeriveAddress(offer.RequestChain, 0, 0)
+		btcAddr, err := c.walletAddressUnlocked(offer.RequestChain)
 		if err == nil {
 			swap.LocalRequestWalletAddr = btcAddr
 		}
@@ -506,7 +543,7 @@ func (c *Coordinator) respondBitcoinToEVMSwap(ctx context.Context, tradeID strin
 	// Store local wallet addresses for P2P exchange
 	// Offer chain is Bitcoin (need to derive address from wallet)
 	if c.wallet != nil {
-		btcAddr, err := c.wallet.DeriveAddress(offer.OfferChain, 0, 0)
+		btcAddr, err := c.walletAddressUnlocked(offer.OfferChain)
 		if err == nil {
 			swap.LocalOfferWalletAddr = btcAddr
 		}
@@ -625,7 +662,7 @@ func (c *Coordinator) respondEVMToBitcoinSwap(ctx context.Context, tradeID strin
 	swap.LocalOfferWalletAddr = evmSession.GetLocalAddress().Hex()
 	// Request chain is Bitcoin (need to derive address from wallet)
 	if c.wallet != nil {
-		btcAddr, err := c.wallet.DeriveAddress(offer.RequestChain, 0, 0)
+		btcAddr, err := c.walletAddressUnlocked(offer.RequestChain)
 		if err == nil {
 			swap.LocalRequestWalletAddr = btcAddr
 		}
@@ -0,0 +1,275 @@
+// Package swap - Swap lifecycle deadlines (SLA timers) for clients.
+//
+// Every active swap has three critical deadlines: when our refund becomes
+// available, when the counterparty's refund becomes available, and the latest
+// time we should claim their funds (their refund minus a safety margin).
+// Bitcoin-family chains count blocks; EVM chains use the HTLC timestamp.
+package swap
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+// Deadline kinds.
+const (
+	DeadlineOurRefund   = "our_refund"   // Our funds can be refunded
+	DeadlineTheirRefund = "their_refund" // The counterparty can refund theirs
+	DeadlineLatestClaim = "latest_claim" // Recommended latest claim of their funds
+)
+
+// EventSwapDeadlines is emitted when a swap's deadlines change (new block,
+// deadline passed).
+const EventSwapDeadlines = "swap_deadlines"
+
+// Deadline is a critical point in a swap's lifecycle.
+type Deadline struct {
+	Kind  string `json:"kind"`
+	Chain string `json:"chain"`
+
+	// Height and CurrentHeight are set for block-based deadlines.
+	Height          uint32 `json:"height,omitempty"`
+	CurrentHeight   uint32 `json:"current_height,omitempty"`
+	BlocksRemaining int64  `json:"blocks_remaining"`
+
+	// SecondsRemaining and At are exact for timestamp deadlines and
+	// estimated from the average block time otherwise.
+	SecondsRemaining int64 `json:"seconds_remaining"`
+	At               int64 `json:"at"` // Unix seconds
+	Passed           bool  `json:"passed"`
+}
+
+// SwapDeadlines holds the deadlines of a swap.
+type SwapDeadlines struct {
+	TradeID   string     `json:"trade_id"`
+	Deadlines []Deadline `json:"deadlines"`
+	UpdatedAt int64      `json:"updated_at"`
+}
+
+// refundPoint is when a chain's HTLC becomes refundable: a block height or,
+// for EVM HTLCs, a Unix timestamp.
+type refundPoint struct {
+	chain    string
+	height   uint32
+	timelock int64
+}
+
+func (p refundPoint) known() bool {
+	return p.chain != "" && (p.height > 0 || p.timelock > 0)
+}
+
+// GetSwapDeadlines returns the current deadlines of a swap.
+func (c *Coordinator) GetSwapDeadlines(ctx context.Context, tradeID string) (*SwapDeadlines, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.RLock()
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		c.mu.RUnlock()
+		return nil, ErrSwapNotFound
+	}
+	ours, theirs := refundPointsUnlocked(active)
+	isTestnet := active.Swap.Network == chain.Testnet
+	now := time.Now().Add(c.clockOffset)
+	c.mu.RUnlock()
+
+	heights := c.deadlineHeights(ctx, map[string]uint32{}, ours, theirs)
+	return buildDeadlines(tradeID, ours, theirs, heights, isTestnet, now), nil
+}
+
+// StartDeadlineMonitor emits an EventSwapDeadlines event for every active
+// swap whose deadlines changed, checking every interval.
+func (c *Coordinator) StartDeadlineMonitor(interval time.Duration) {
+	if interval <= 0 {
+		c.log.Warn("Deadline monitor not started: update interval not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		last := make(map[string]string)
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				c.emitDeadlineChanges(c.ctx, last)
+			}
+		}
+	}()
+}
+
+// emitDeadlineChanges emits deadlines that differ from last, which maps trade
+// IDs to the previously emitted deadlines. Block heights are fetched once per
+// chain.
+func (c *Coordinator) emitDeadlineChanges(ctx context.Context, last map[string]string) {
+	type swapPoints struct {
+		tradeID      string
+		ours, theirs refundPoint
+		isTestnet    bool
+	}
+
+	c.mu.RLock()
+	var active []swapPoints
+	for tradeID, a := range c.swaps {
+		if a.Swap.IsTerminal() {
+			continue
+		}
+		ours, theirs := refundPointsUnlocked(a)
+		active = append(active, swapPoints{tradeID, ours, theirs, a.Swap.Network == chain.Testnet})
+	}
+	now := time.Now().Add(c.clockOffset)
+	c.mu.RUnlock()
+
+	seen := make(map[string]bool, len(active))
+	heights := make(map[string]uint32)
+	for _, sp := range active {
+		seen[sp.tradeID] = true
+		heights = c.deadlineHeights(ctx, heights, sp.ours, sp.theirs)
+		deadlines := buildDeadlines(sp.tradeID, sp.ours, sp.theirs, heights, sp.isTestnet, now)
+		if len(deadlines.Deadlines) == 0 {
+			continue
+		}
+		key := deadlines.changeKey()
+		if last[sp.tradeID] == key {
+			continue
+		}
+		last[sp.tradeID] = key
+
+		c.mu.Lock()
+		c.emitEvent(sp.tradeID, EventSwapDeadlines, deadlines)
+		c.mu.Unlock()
+	}
+
+	for tradeID := range last {
+		if !seen[tradeID] {
+			delete(last, tradeID)
+		}
+	}
+}
+
+// deadlineHeights adds the current heights of the block-based chains to
+// heights. Chains already present or unreachable are skipped.
+func (c *Coordinator) deadlineHeights(ctx context.Context, heights map[string]uint32, points ...refundPoint) map[string]uint32 {
+	for _, p := range points {
+		if p.height == 0 {
+			continue
+		}
+		if _, ok := heights[p.chain]; ok {
+			continue
+		}
+		c.mu.RLock()
+		b, ok := c.backends[p.chain]
+		c.mu.RUnlock()
+		if !ok {
+			continue
+		}
+		height, err := b.GetBlockHeight(ctx)
+		if err != nil {
+			c.log.Debug("Failed to get block height for deadlines", "chain", p.chain, "error", err)
+			continue
+		}
+		heights[p.chain] = uint32(height)
+	}
+	return heights
+}
+
+// refundPointsUnlocked returns when our and the counterparty's HTLCs become
+// refundable. The initiator locks on the offer chain, the responder on the
+// request chain. Caller must hold c.mu.
+func refundPointsUnlocked(active *ActiveSwap) (ours, theirs refundPoint) {
+	s := active.Swap
+	offer := refundPoint{chain: s.Offer.OfferChain, height: s.OfferChainTimeoutHeight}
+	request := refundPoint{chain: s.Offer.RequestChain, height: s.RequestChainTimeoutHeight}
+	if active.EVMHTLC != nil {
+		if cd := active.EVMHTLC.OfferChain; cd != nil && cd.Session != nil {
+			if tl := cd.Session.GetTimelock(); tl > 0 {
+				offer = refundPoint{chain: offer.chain, timelock: tl}
+			}
+		}
+		if cd := active.EVMHTLC.RequestChain; cd != nil && cd.Session != nil {
+			if tl := cd.Session.GetTimelock(); tl > 0 {
+				request = refundPoint{chain: request.chain, timelock: tl}
+			}
+		}
+	}
+
+	if s.Role == RoleInitiator {
+		return offer, request
+	}
+	return request, offer
+}
+
+// buildDeadlines computes the deadlines from the refund points and the current
+// chain heights. Unknown refund points are left out.
+func buildDeadlines(tradeID string, ours, theirs refundPoint, heights map[string]uint32, isTestnet bool, now time.Time) *SwapDeadlines {
+	result := &SwapDeadlines{TradeID: tradeID, Deadlines: []Deadline{}, UpdatedAt: now.Unix()}
+
+	if ours.known() {
+		result.Deadlines = append(result.Deadlines, newDeadline(DeadlineOurRefund, ours, heights, isTestnet, now))
+	}
+	if theirs.known() {
+		result.Deadlines = append(result.Deadlines, newDeadline(DeadlineTheirRefund, theirs, heights, isTestnet, now))
+
+		// Claim before their refund opens, leaving the chain's safety margin
+		claim := theirs
+		if claim.timelock > 0 {
+			claim.timelock -= int64(config.DefaultSwapConfig().ClaimSafetyMargin / time.Second)
+		} else if timeouts, ok := config.GetChainTimeout(theirs.chain, isTestnet); ok && claim.height > timeouts.SafetyMarginBlocks {
+			claim.height -= timeouts.SafetyMarginBlocks
+		}
+		result.Deadlines = append(result.Deadlines, newDeadline(DeadlineLatestClaim, claim, heights, isTestnet, now))
+	}
+	return result
+}
+
+// newDeadline converts a refund point into a deadline relative to now.
+func newDeadline(kind string, p refundPoint, heights map[string]uint32, isTestnet bool, now time.Time) Deadline {
+	d := Deadline{Kind: kind, Chain: p.chain}
+
+	if p.timelock > 0 {
+		d.At = p.timelock
+		d.SecondsRemaining = p.timelock - now.Unix()
+		d.Passed = d.SecondsRemaining <= 0
+		return d
+	}
+
+	d.Height = p.height
+	current, ok := heights[p.chain]
+	if !ok {
+		// Without the current height only the target height is known
+		return d
+	}
+	d.CurrentHeight = current
+	d.BlocksRemaining = int64(p.height) - int64(current)
+	d.Passed = d.BlocksRemaining <= 0
+
+	var blockTime int64
+	if timeouts, ok := config.GetChainTimeout(p.chain, isTestnet); ok {
+		blockTime = int64(timeouts.AvgBlockTimeSeconds)
+	}
+	d.SecondsRemaining = d.BlocksRemaining * blockTime
+	d.At = now.Unix() + d.SecondsRemaining
+	return d
+}
+
+// changeKey identifies the deadline state that clients care about: block
+// counts and passed flags. Timestamp deadlines only change when passing.
+func (d *SwapDeadlines) changeKey() string {
+	var b strings.Builder
+	for _, dl := range d.Deadlines {
+		fmt.Fprintf(&b, "%s:%s:%d:%d:%t;", dl.Kind, dl.Chain, dl.Height, dl.BlocksRemaining, dl.Passed)
+		if dl.Height == 0 {
+			b.WriteString(strconv.FormatInt(dl.At, 10))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,187 @@
+package swap
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func findDeadline(t *testing.T, d *SwapDeadlines, kind string) Deadline {
+	t.Helper()
+	for _, dl := range d.Deadlines {
+		if dl.Kind == kind {
+			return dl
+		}
+	}
+	t.Fatalf("deadline %s not found in %+v", kind, d.Deadlines)
+	return Deadline{}
+}
+
+func TestBuildDeadlinesBlocks(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ours := refundPoint{chain: "BTC", height: 1100}
+	theirs := refundPoint{chain: "LTC", height: 2000}
+	heights := map[string]uint32{"BTC": 1000, "LTC": 1990}
+
+	d := buildDeadlines("t1", ours, theirs, heights, true, now)
+	if len(d.Deadlines) != 3 {
+		t.Fatalf("got %d deadlines, want 3", len(d.Deadlines))
+	}
+
+	our := findDeadline(t, d, DeadlineOurRefund)
+	if our.BlocksRemaining != 100 || our.SecondsRemaining != 100*600 || our.Passed {
+		t.Errorf("our_refund = %+v, want 100 blocks / 60000s", our)
+	}
+	if our.At != now.Unix()+60000 {
+		t.Errorf("our_refund.At = %d, want %d", our.At, now.Unix()+60000)
+	}
+
+	their := findDeadline(t, d, DeadlineTheirRefund)
+	if their.BlocksRemaining != 10 || their.SecondsRemaining != 10*150 || their.Passed {
+		t.Errorf("their_refund = %+v, want 10 blocks / 1500s", their)
+	}
+
+	// LTC testnet safety margin is 24 blocks, so the claim window has closed
+	claim := findDeadline(t, d, DeadlineLatestClaim)
+	if claim.Height != 1976 || claim.BlocksRemaining != -14 || !claim.Passed {
+		t.Errorf("latest_claim = %+v, want height 1976, passed", claim)
+	}
+}
+
+func TestBuildDeadlinesTimelock(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	ours := refundPoint{chain: "BTC", height: 1100}
+	theirs := refundPoint{chain: "ETH", timelock: now.Unix() + 7200}
+
+	// No BTC height: only the target height is known
+	d := buildDeadlines("t1", ours, theirs, map[string]uint32{}, true, now)
+
+	our := findDeadline(t, d, DeadlineOurRefund)
+	if our.Height != 1100 || our.CurrentHeight != 0 || our.At != 0 || our.Passed {
+		t.Errorf("our_refund = %+v, want height only", our)
+	}
+
+	their := findDeadline(t, d, DeadlineTheirRefund)
+	if their.SecondsRemaining != 7200 || their.At != now.Unix()+7200 || their.Height != 0 {
+		t.Errorf("their_refund = %+v, want 7200s", their)
+	}
+
+	claim := findDeadline(t, d, DeadlineLatestClaim)
+	if claim.SecondsRemaining != 3600 || claim.Passed {
+		t.Errorf("latest_claim = %+v, want 3600s", claim)
+	}
+
+	if d := buildDeadlines("t1", refundPoint{}, refundPoint{chain: "LTC"}, nil, true, now); len(d.Deadlines) != 0 {
+		t.Errorf("unknown refund points produced deadlines: %+v", d.Deadlines)
+	}
+}
+
+// addDeadlineTrade adds trade t1, in which we respond to a BTC/LTC swap
+// whose timelocks expire at BTC height 500 and LTC height 1200.
+func addDeadlineTrade(t *testing.T, coord *Coordinator) {
+	t.Helper()
+	s := newTestHTLCSwap(t, chain.Testnet, RoleResponder)
+	s.OfferChainTimeoutHeight = 500
+	s.RequestChainTimeoutHeight = 1200
+	coord.swaps["t1"] = &ActiveSwap{Swap: s}
+}
+
+func TestGetSwapDeadlines(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	addDeadlineTrade(t, coord)
+
+	if _, err := coord.GetSwapDeadlines(context.Background(), "missing"); err != ErrSwapNotFound {
+		t.Errorf("GetSwapDeadlines(missing) error = %v, want ErrSwapNotFound", err)
+	}
+
+	d, err := coord.GetSwapDeadlines(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("GetSwapDeadlines() error = %v", err)
+	}
+
+	// The responder locks on the request chain (LTC)
+	our := findDeadline(t, d, DeadlineOurRefund)
+	if our.Chain != "LTC" || our.CurrentHeight != 1000 || our.BlocksRemaining != 200 {
+		t.Errorf("our_refund = %+v, want LTC with 200 blocks left", our)
+	}
+
+	// No BTC backend: the current height is unknown
+	their := findDeadline(t, d, DeadlineTheirRefund)
+	if their.Chain != "BTC" || their.Height != 500 || their.CurrentHeight != 0 {
+		t.Errorf("their_refund = %+v, want BTC at height 500", their)
+	}
+}
+
+func TestGetSwapDeadlinesClockSkew(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	addDeadlineTrade(t, coord)
+
+	d, err := coord.GetSwapDeadlines(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("GetSwapDeadlines() error = %v", err)
+	}
+	before := findDeadline(t, d, DeadlineOurRefund)
+
+	// Our clock is an hour behind: the estimate moves an hour later
+	coord.SetClockSkew(time.Hour, false)
+	d, err = coord.GetSwapDeadlines(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("GetSwapDeadlines() error = %v", err)
+	}
+	after := findDeadline(t, d, DeadlineOurRefund)
+	if shift := after.At - before.At; shift < 3599 || shift > 3601 {
+		t.Errorf("At moved by %ds, want 3600s", shift)
+	}
+}
+
+func TestEmitDeadlineChanges(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	addDeadlineTrade(t, coord)
+
+	var mu sync.Mutex
+	var events []*SwapDeadlines
+	coord.OnEvent(func(e SwapEvent) {
+		if e.EventType != EventSwapDeadlines {
+			return
+		}
+		mu.Lock()
+		events = append(events, e.Data.(*SwapDeadlines))
+		mu.Unlock()
+	})
+	count := func() int {
+		// Handlers run in goroutines
+		time.Sleep(50 * time.Millisecond)
+		mu.Lock()
+		defer mu.Unlock()
+		return len(events)
+	}
+
+	last := make(map[string]string)
+	coord.emitDeadlineChanges(context.Background(), last)
+	coord.emitDeadlineChanges(context.Background(), last)
+	if got := count(); got != 1 {
+		t.Fatalf("got %d events for unchanged deadlines, want 1", got)
+	}
+
+	ltc.height.Store(1001)
+	coord.emitDeadlineChanges(context.Background(), last)
+	if got := count(); got != 2 {
+		t.Fatalf("got %d events after a new block, want 2", got)
+	}
+
+	// Terminal swaps are dropped from the monitor
+	coord.swaps["t1"].Swap.State = StateRedeemed
+	coord.emitDeadlineChanges(context.Background(), last)
+	if len(last) != 0 {
+		t.Errorf("terminal swap still tracked: %v", last)
+	}
+}
@@ -13,8 +13,9 @@ import (
 	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/chain"
-	"github.com/Klingon-tech/klingdex/internal/config"
 	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // =============================================================================
@@ -23,53 +24,97 @@ import (
 
 // CreateEVMHTLC creates an HTLC on an EVM chain.
 // This is called after the swap has been initialized and parameters are set.
-func (c *Coordinator) CreateEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (common.Hash, error) {
+func (c *Coordinator) CreateEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (_ common.Hash, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.create_evm_htlc", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cold {
+		return common.Hash{}, ErrColdMode
+	}
 	active, ok := c.swaps[tradeID]
 	if !ok {
 		return common.Hash{}, ErrSwapNotFound
 	}
+	evmSession, err := c.prepareEVMCreateUnlocked(active, chainSymbol)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	// Fund-moving steps may wait for the user's confirmation
+	if c.needsConfirmationUnlocked(chainSymbol, evmStepAmount(evmSession.GetAmount())) {
+		return common.Hash{}, c.holdEVMCreateUnlocked(tradeID, chainSymbol, evmSession)
+	}
+
+	txHash, err := c.runEVMCreate(ctx, tradeID, chainSymbol, evmSession)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	c.recordEVMCreateUnlocked(tradeID, chainSymbol, evmSession, txHash)
+	return txHash, nil
+}
 
+// prepareEVMCreateUnlocked checks that our leg's HTLC can be created on an
+// EVM chain and returns its session. Caller must hold c.mu.
+func (c *Coordinator) prepareEVMCreateUnlocked(active *ActiveSwap, chainSymbol string) (*EVMHTLCSession, error) {
 	// Validate chain is EVM
 	if !IsEVMChain(chainSymbol, c.network) {
-		return common.Hash{}, fmt.Errorf("chain %s is not an EVM chain", chainSymbol)
+		return nil, fmt.Errorf("chain %s is not an EVM chain", chainSymbol)
+	}
+	if err := c.checkContractsActiveUnlocked(chainSymbol); err != nil {
+		return nil, err
+	}
+	if active.Swap.ExternalFunding && chainSymbol == localLegChain(active.Swap) {
+		return nil, ErrExternalFunding
 	}
 
 	// Get the EVM session for this chain
 	evmSession, err := c.getOrCreateEVMSession(active, chainSymbol)
 	if err != nil {
-		return common.Hash{}, fmt.Errorf("failed to get EVM session: %w", err)
+		return nil, fmt.Errorf("failed to get EVM session: %w", err)
 	}
 
 	// Validate receiver is set (requires P2P address exchange to have completed)
 	receiver := evmSession.GetRemoteAddress()
 	if receiver == (common.Address{}) {
-		return common.Hash{}, fmt.Errorf("counterparty EVM address not set - ensure P2P address exchange is complete before creating HTLC")
+		return nil, fmt.Errorf("counterparty EVM address not set - ensure P2P address exchange is complete before creating HTLC")
 	}
+	return evmSession, nil
+}
 
-	// Determine if this is a native token or ERC20 swap
-	// For now, we assume native token. Token address can be added to swap params later.
+// runEVMCreate sends the contract call creating our leg's HTLC. It needs
+// no c.mu: the session has its own lock.
+func (c *Coordinator) runEVMCreate(ctx context.Context, tradeID, chainSymbol string, evmSession *EVMHTLCSession) (common.Hash, error) {
+	// The leg's token comes from the offer (see evmLegToken)
 	isNativeToken := evmSession.tokenAddress == (common.Address{})
 
 	var txHash common.Hash
+	var err error
 	if isNativeToken {
-		txHash, err = evmSession.CreateSwapNative(ctx)
+		txHash, err = c.runEVMJob(ctx, JobActionEVMCreate, tradeID, chainSymbol, evmSession.CreateSwapNative)
 	} else {
-		txHash, err = evmSession.CreateSwapERC20(ctx)
+		txHash, err = c.runEVMJob(ctx, JobActionEVMCreate, tradeID, chainSymbol, evmSession.CreateSwapERC20)
 	}
 
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("failed to create EVM HTLC: %w", err)
 	}
+	return txHash, nil
+}
 
+// recordEVMCreateUnlocked logs and announces a created HTLC. Caller must
+// hold c.mu.
+func (c *Coordinator) recordEVMCreateUnlocked(tradeID, chainSymbol string, evmSession *EVMHTLCSession, txHash common.Hash) {
 	swapID := evmSession.GetSwapID()
 	c.log.Info("Created EVM HTLC",
 		"trade_id", tradeID,
 		"chain", chainSymbol,
 		"tx_hash", txHash.Hex(),
 		"swap_id", common.Bytes2Hex(swapID[:]),
+		"token", evmSession.tokenAddress.Hex(),
 	)
 
 	// Emit event
@@ -77,9 +122,8 @@ func (c *Coordinator) CreateEVMHTLC(ctx context.Context, tradeID string, chainSy
 		"chain":   chainSymbol,
 		"tx_hash": txHash.Hex(),
 		"swap_id": common.Bytes2Hex(swapID[:]),
+		"token":   evmSession.tokenAddress.Hex(),
 	})
-
-	return txHash, nil
 }
 
 // =============================================================================
@@ -87,7 +131,11 @@ func (c *Coordinator) CreateEVMHTLC(ctx context.Context, tradeID string, chainSy
 // =============================================================================
 
 // ClaimEVMHTLC claims an EVM HTLC using the secret.
-func (c *Coordinator) ClaimEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (common.Hash, error) {
+func (c *Coordinator) ClaimEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (_ common.Hash, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.claim_evm_htlc", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -120,7 +168,7 @@ func (c *Coordinator) ClaimEVMHTLC(ctx context.Context, tradeID string, chainSym
 	}
 
 	// Claim the HTLC
-	txHash, err := evmSession.Claim(ctx)
+	txHash, err := c.runEVMJob(ctx, JobActionEVMClaim, tradeID, chainSymbol, evmSession.Claim)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("failed to claim EVM HTLC: %w", err)
 	}
@@ -145,7 +193,11 @@ func (c *Coordinator) ClaimEVMHTLC(ctx context.Context, tradeID string, chainSym
 // =============================================================================
 
 // RefundEVMHTLC refunds an EVM HTLC after timeout.
-func (c *Coordinator) RefundEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (common.Hash, error) {
+func (c *Coordinator) RefundEVMHTLC(ctx context.Context, tradeID string, chainSymbol string) (_ common.Hash, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.refund_evm_htlc", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -153,7 +205,11 @@ func (c *Coordinator) RefundEVMHTLC(ctx context.Context, tradeID string, chainSy
 	if !ok {
 		return common.Hash{}, ErrSwapNotFound
 	}
+	return c.refundEVMHTLCLocked(ctx, tradeID, active, chainSymbol)
+}
 
+// refundEVMHTLCLocked refunds an EVM HTLC after timeout. Caller must hold c.mu.
+func (c *Coordinator) refundEVMHTLCLocked(ctx context.Context, tradeID string, active *ActiveSwap, chainSymbol string) (common.Hash, error) {
 	// Validate chain is EVM
 	if !IsEVMChain(chainSymbol, c.network) {
 		return common.Hash{}, fmt.Errorf("chain %s is not an EVM chain", chainSymbol)
@@ -176,7 +232,7 @@ func (c *Coordinator) RefundEVMHTLC(ctx context.Context, tradeID string, chainSy
 	}
 
 	// Refund the HTLC
-	txHash, err := evmSession.Refund(ctx)
+	txHash, err := c.runEVMJob(ctx, JobActionEVMRefund, tradeID, chainSymbol, evmSession.Refund)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("failed to refund EVM HTLC: %w", err)
 	}
@@ -202,6 +258,7 @@ func (c *Coordinator) RefundEVMHTLC(ctx context.Context, tradeID string, chainSy
 
 // GetEVMHTLCStatus returns the on-chain status of an EVM HTLC.
 func (c *Coordinator) GetEVMHTLCStatus(ctx context.Context, tradeID string, chainSymbol string) (*htlc.Swap, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -225,6 +282,7 @@ func (c *Coordinator) GetEVMHTLCStatus(ctx context.Context, tradeID string, chai
 
 // WaitForEVMSecret waits for the counterparty to claim and reveal the secret.
 func (c *Coordinator) WaitForEVMSecret(ctx context.Context, tradeID string, chainSymbol string) ([32]byte, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.RLock()
 	active, ok := c.swaps[tradeID]
 	c.mu.RUnlock()
@@ -239,9 +297,11 @@ func (c *Coordinator) WaitForEVMSecret(ctx context.Context, tradeID string, chai
 		return [32]byte{}, fmt.Errorf("failed to get EVM session: %w", err)
 	}
 
+	ctx, op := c.beginOperation(ctx, tradeID, "wait_secret", OpSecretWait)
+	defer op.end()
 	secret, err := evmSession.WaitForSecret(ctx)
 	if err != nil {
-		return [32]byte{}, fmt.Errorf("failed waiting for secret: %w", err)
+		return [32]byte{}, fmt.Errorf("failed waiting for secret: %w", op.wrap(err))
 	}
 
 	c.log.Info("Received secret from EVM claim",
@@ -259,6 +319,7 @@ func (c *Coordinator) WaitForEVMSecret(ctx context.Context, tradeID string, chai
 		"chain":  chainSymbol,
 		"source": "evm_claim",
 	})
+	c.wakeAutoClaim()
 
 	return secret, nil
 }
@@ -305,6 +366,7 @@ func (c *Coordinator) SetEVMSecret(tradeID string, secret [32]byte) error {
 		c.log.Warn("Failed to save swap state after setting secret", "error", err)
 	}
 
+	c.wakeAutoClaim()
 	return nil
 }
 
@@ -343,12 +405,7 @@ func (c *Coordinator) getOrCreateEVMSession(active *ActiveSwap, chainSymbol stri
 	}
 
 	// Create new session
-	rpcURL := c.getEVMRPCURL(chainSymbol)
-	if rpcURL == "" {
-		return nil, fmt.Errorf("no RPC URL configured for chain %s", chainSymbol)
-	}
-
-	session, err := NewEVMHTLCSession(chainSymbol, c.network, rpcURL)
+	session, err := c.newEVMSessionUnlocked(active, chainSymbol, evmData)
 	if err != nil {
 		return nil, err
 	}
@@ -396,15 +453,12 @@ func (c *Coordinator) getOrCreateEVMSession(active *ActiveSwap, chainSymbol stri
 
 	// Set swap parameters
 	swapID, receiver, amount, timelock := c.computeEVMSwapParams(active, chainSymbol, session)
-	session.SetSwapParams(swapID, receiver, common.Address{}, amount, timelock)
+	session.SetSwapParams(swapID, receiver, evmLegToken(&active.Swap.Offer, chainSymbol), amount, timelock)
 
 	// Store session
-	chainParams, _ := chain.Get(chainSymbol, c.network)
-	contractAddr := config.GetHTLCContract(chainParams.ChainID)
-
 	newData := &ChainEVMHTLCData{
 		Session:         session,
-		ContractAddress: contractAddr,
+		ContractAddress: session.ContractAddress(),
 		SwapID:          swapID,
 	}
 
@@ -442,12 +496,7 @@ func (c *Coordinator) getEVMSession(active *ActiveSwap, chainSymbol string) (*EV
 	// Create session lazily if needed (for recovered swaps)
 	if evmData == nil || evmData.Session == nil {
 		c.log.Debug("Creating EVM session lazily", "chain", chainSymbol, "trade_id", active.Swap.ID)
-		rpcURL := c.getEVMRPCURL(chainSymbol)
-		if rpcURL == "" {
-			return nil, fmt.Errorf("no RPC URL configured for chain %s", chainSymbol)
-		}
-
-		session, err := NewEVMHTLCSession(chainSymbol, c.network, rpcURL)
+		session, err := c.newEVMSessionUnlocked(active, chainSymbol, evmData)
 		if err != nil {
 			return nil, fmt.Errorf("failed to create EVM session: %w", err)
 		}
@@ -474,13 +523,9 @@ func (c *Coordinator) getEVMSession(active *ActiveSwap, chainSymbol string) (*EV
 			_ = session.SetSecret(secret)
 		}
 
-		// Get contract address
-		chainParams, _ := chain.Get(chainSymbol, c.network)
-		contractAddr := config.GetHTLCContract(chainParams.ChainID)
-
 		evmData = &ChainEVMHTLCData{
 			Session:         session,
-			ContractAddress: contractAddr,
+			ContractAddress: session.ContractAddress(),
 		}
 
 		// Store the session
@@ -498,6 +543,120 @@ func (c *Coordinator) getEVMSession(active *ActiveSwap, chainSymbol string) (*EV
 	return evmData.Session, nil
 }
 
+// newEVMSessionUnlocked connects a session for a leg that has none, taking
+// the one dialed without c.mu if there is one. evmData may be nil. Caller
+// must hold c.mu.
+func (c *Coordinator) newEVMSessionUnlocked(active *ActiveSwap, chainSymbol string, evmData *ChainEVMHTLCData) (*EVMHTLCSession, error) {
+	key := evmDialKey(active.Swap.ID, chainSymbol)
+	if d, ok := c.evmDialed[key]; ok {
+		delete(c.evmDialed, key)
+		return d.session, d.err
+	}
+
+	rpcURL := c.getEVMRPCURL(chainSymbol)
+	if rpcURL == "" {
+		return nil, fmt.Errorf("no RPC URL configured for chain %s", chainSymbol)
+	}
+	// A leg restored from storage keeps the contract it was created on
+	var pinned common.Address
+	if evmData != nil {
+		pinned = evmData.Contract()
+	}
+	return NewEVMHTLCSessionAt(chainSymbol, c.network, rpcURL, pinned)
+}
+
+// evmDialKey keys the sessions dialed without c.mu.
+func evmDialKey(tradeID, chainSymbol string) string {
+	return tradeID + "/" + chainSymbol
+}
+
+// evmSessionDial connects the EVM session of a leg without c.mu: connecting
+// reads the chain ID from the node.
+type evmSessionDial struct {
+	key     string
+	chain   string
+	network chain.Network
+	rpcURL  string
+	pinned  common.Address
+
+	session *EVMHTLCSession
+	err     error
+}
+
+// evmSessionDialLocked prepares the connection of the session of a leg on
+// an EVM chain; it returns nil when the leg has one already, or isn't on
+// one. Caller must hold c.mu.
+func (c *Coordinator) evmSessionDialLocked(active *ActiveSwap, chainSymbol string) *evmSessionDial {
+	if !IsEVMChain(chainSymbol, c.network) {
+		return nil
+	}
+	evmData := active.evmChainData(chainSymbol)
+	if evmData != nil && evmData.Session != nil {
+		return nil
+	}
+	d := &evmSessionDial{
+		key:     evmDialKey(active.Swap.ID, chainSymbol),
+		chain:   chainSymbol,
+		network: c.network,
+		rpcURL:  c.getEVMRPCURL(chainSymbol),
+	}
+	if d.rpcURL == "" {
+		return nil // Reported when the session is needed
+	}
+	if evmData != nil {
+		d.pinned = evmData.Contract()
+	}
+	return d
+}
+
+// run connects the session. It must not be called holding c.mu.
+func (d *evmSessionDial) run() {
+	if d == nil {
+		return
+	}
+	d.session, d.err = NewEVMHTLCSessionAt(d.chain, d.network, d.rpcURL, d.pinned)
+}
+
+// dialEVMSessionLocked connects the session d prepares, releasing c.mu
+// meanwhile, and offers it to the leg. The caller must hold c.mu and look
+// up what it needs again afterwards.
+func (c *Coordinator) dialEVMSessionLocked(d *evmSessionDial) {
+	if d == nil {
+		return
+	}
+	c.mu.Unlock()
+	d.run()
+	c.mu.Lock()
+	c.offerEVMSessionLocked(d)
+}
+
+// offerEVMSessionLocked leaves the dialed session, or the dial's error, for
+// newEVMSessionUnlocked to take. Caller must hold c.mu.
+func (c *Coordinator) offerEVMSessionLocked(d *evmSessionDial) {
+	if d == nil {
+		return
+	}
+	if c.evmDialed == nil {
+		c.evmDialed = make(map[string]*evmSessionDial)
+	}
+	c.dropEVMSessionLocked(d)
+	c.evmDialed[d.key] = d
+}
+
+// dropEVMSessionLocked closes a dialed session the leg didn't take, having
+// got one meanwhile or failed before needing it. Caller must hold c.mu.
+func (c *Coordinator) dropEVMSessionLocked(d *evmSessionDial) {
+	if d == nil {
+		return
+	}
+	if old, ok := c.evmDialed[d.key]; ok {
+		delete(c.evmDialed, d.key)
+		if old.session != nil {
+			old.session.Close()
+		}
+	}
+}
+
 // getEVMRPCURL gets the RPC URL for an EVM chain from the backend config.
 func (c *Coordinator) getEVMRPCURL(chainSymbol string) string {
 	// Get from backend if available
@@ -514,10 +673,7 @@ func (c *Coordinator) getEVMRPCURL(chainSymbol string) string {
 	// This ensures we use the same RPC endpoints defined in backend/backend.go
 	backendConfigs := backend.DefaultConfigs()
 	if cfg, ok := backendConfigs[chainSymbol]; ok {
-		if c.network == chain.Testnet {
-			return cfg.TestnetURL
-		}
-		return cfg.MainnetURL
+		return cfg.URL(c.network, chain.ActiveTestnet(chainSymbol))
 	}
 
 	// Chain not found in configs
@@ -544,6 +700,19 @@ func (c *Coordinator) getEVMPrivateKey(active *ActiveSwap, chainSymbol string) (
 	return btcPrivKey.ToECDSA(), nil
 }
 
+// evmLegToken returns the ERC-20 token of the leg on chainSymbol, or the zero
+// address for the native coin.
+func evmLegToken(offer *Offer, chainSymbol string) common.Address {
+	token := offer.RequestToken
+	if chainSymbol == offer.OfferChain {
+		token = offer.OfferToken
+	}
+	if token == "" {
+		return common.Address{}
+	}
+	return common.HexToAddress(token)
+}
+
 // computeEVMSwapParams computes the swap parameters for an EVM HTLC.
 func (c *Coordinator) computeEVMSwapParams(active *ActiveSwap, chainSymbol string, session *EVMHTLCSession) (swapID [32]byte, receiver common.Address, amount *big.Int, timelock *big.Int) {
 	isOfferChain := chainSymbol == active.Swap.Offer.OfferChain
@@ -608,14 +777,19 @@ func (c *Coordinator) computeEVMSwapParams(active *ActiveSwap, chainSymbol strin
 	// Use a deterministic ID based on trade parameters
 	secretHash := session.GetSecretHash()
 	// Use Swap.ID (always set) instead of Trade.ID (may be nil)
-	tradeID := active.Swap.ID
-	swapIDData := append([]byte(tradeID), secretHash[:]...)
-	swapIDData = append(swapIDData, []byte(chainSymbol)...)
-	swapID = crypto.Keccak256Hash(swapIDData)
+	swapID = ComputeEVMSwapID(active.Swap.ID, secretHash, chainSymbol)
 
 	return swapID, receiver, amount, timelock
 }
 
+// ComputeEVMSwapID returns the ID of a trade's HTLC on an EVM chain:
+// keccak256(tradeID || secretHash || chainSymbol).
+func ComputeEVMSwapID(tradeID string, secretHash [32]byte, chainSymbol string) [32]byte {
+	data := append([]byte(tradeID), secretHash[:]...)
+	data = append(data, []byte(chainSymbol)...)
+	return crypto.Keccak256Hash(data)
+}
+
 // getSecretFromSwap retrieves the secret from the swap, checking all sources.
 func (c *Coordinator) getSecretFromSwap(active *ActiveSwap) ([32]byte, error) {
 	// Check swap record
@@ -688,7 +862,7 @@ func (c *Coordinator) ensureEVMSwapParamsSet(active *ActiveSwap, chainSymbol str
 
 	// Set swap parameters
 	newSwapID, receiver, amount, timelock := c.computeEVMSwapParams(active, chainSymbol, session)
-	session.SetSwapParams(newSwapID, receiver, common.Address{}, amount, timelock)
+	session.SetSwapParams(newSwapID, receiver, evmLegToken(&active.Swap.Offer, chainSymbol), amount, timelock)
 
 	c.log.Debug("Set EVM swap params on existing session",
 		"chain", chainSymbol,
@@ -0,0 +1,329 @@
+// Package swap - Cancelling pending EVM HTLC creations.
+//
+// A trade aborted while our HTLC create transaction is still in the mempool
+// races that transaction's nonce with a zero-value transfer to ourselves at
+// higher fees. If the replacement is mined first, nothing was locked and the
+// cancellation is done. If the create wins, the HTLC exists and is refunded
+// as soon as its timelock passes. The coordinator follows the race and
+// records which way it went.
+package swap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// EVMCreateCancelInterval is how often unfinished cancellations are checked,
+// about an EVM block.
+const EVMCreateCancelInterval = 15 * time.Second
+
+// ErrNoEVMCreate is returned when cancelling a create we never sent.
+var ErrNoEVMCreate = errors.New("no EVM HTLC create transaction to cancel")
+
+// EVMCreateOutcome is where the cancellation of an HTLC create stands.
+type EVMCreateOutcome string
+
+// EVM create cancellation outcomes.
+const (
+	EVMCreateReplacing       EVMCreateOutcome = "replacing"        // Replacement broadcast, neither mined yet
+	EVMCreateCancelled       EVMCreateOutcome = "cancelled"        // The create never locked funds
+	EVMCreateRefundScheduled EVMCreateOutcome = "refund_scheduled" // The create was mined, refund at the timelock
+	EVMCreateRefunded        EVMCreateOutcome = "refunded"         // The HTLC was refunded
+)
+
+// IsFinal reports whether the cancellation is over.
+func (o EVMCreateOutcome) IsFinal() bool {
+	return o == EVMCreateCancelled || o == EVMCreateRefunded
+}
+
+// Swap events of EVM create cancellations, emitted with the
+// EVMCreateCancellation as data.
+const (
+	EventEVMCreateReplacing       = "evm_create_replacing"
+	EventEVMCreateCancelled       = "evm_create_cancelled"
+	EventEVMCreateRefundScheduled = "evm_create_refund_scheduled"
+	EventEVMCreateRefunded        = "evm_create_refunded"
+)
+
+// EVMCreateCancellation tracks the cancellation of our HTLC create on one
+// EVM chain.
+type EVMCreateCancellation struct {
+	Chain        string           `json:"chain"`
+	CreateTxHash string           `json:"create_tx_hash"`
+	CancelTxHash string           `json:"cancel_tx_hash,omitempty"` // Empty when the create was already mined
+	Outcome      EVMCreateOutcome `json:"outcome"`
+	RefundAt     int64            `json:"refund_at"` // Unix time of the HTLC timelock
+	RefundTxHash string           `json:"refund_tx_hash,omitempty"`
+	Error        string           `json:"error,omitempty"` // Last failed refund attempt
+	UpdatedAt    int64            `json:"updated_at"`
+}
+
+// EVMTxReplacer finds, replaces and follows transactions on an EVM chain.
+// *htlc.Client implements it.
+type EVMTxReplacer interface {
+	PendingTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error)
+	Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error)
+	CancelTransaction(ctx context.Context, privateKey *ecdsa.PrivateKey, tx *types.Transaction) (*types.Transaction, error)
+}
+
+// CancelEVMHTLCCreate cancels our HTLC create on an EVM chain of an aborted
+// trade. A create still pending is replaced by a self-transfer at higher
+// fees; a mined one has its refund scheduled at the timelock. Calling it
+// again returns the cancellation under way.
+func (c *Coordinator) CancelEVMHTLCCreate(ctx context.Context, tradeID string, chainSymbol string) (_ *EVMCreateCancellation, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.cancel_evm_create", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if !IsEVMChain(chainSymbol, c.network) {
+		return nil, fmt.Errorf("chain %s is not an EVM chain", chainSymbol)
+	}
+	if cancel := active.evmCreateCancel(chainSymbol); cancel != nil {
+		copied := *cancel
+		return &copied, nil
+	}
+	if active.Swap.State == StateRedeemed || active.Swap.State == StateRefunded {
+		return nil, fmt.Errorf("swap already %s", active.Swap.State)
+	}
+
+	session, err := c.getEVMSession(active, chainSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EVM session: %w", err)
+	}
+	createHash := session.GetCreateTxHash()
+	if createHash == (common.Hash{}) {
+		return nil, ErrNoEVMCreate
+	}
+	replacer := c.evmTxReplacerLocked(chainSymbol, session)
+	if replacer == nil {
+		return nil, fmt.Errorf("no EVM client for chain %s", chainSymbol)
+	}
+
+	tx, pending, err := replacer.PendingTransaction(ctx, createHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up create transaction %s: %w", createHash.Hex(), err)
+	}
+	cancel := &EVMCreateCancellation{
+		Chain:        chainSymbol,
+		CreateTxHash: createHash.Hex(),
+		Outcome:      EVMCreateReplacing,
+		RefundAt:     session.GetTimelock(),
+		UpdatedAt:    time.Now().Unix(),
+	}
+	if pending {
+		key := session.localKey()
+		if key == nil {
+			return nil, fmt.Errorf("local private key not set")
+		}
+		replacement, err := replacer.CancelTransaction(ctx, key, tx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to replace create transaction: %w", err)
+		}
+		cancel.CancelTxHash = replacement.Hash().Hex()
+	}
+
+	if active.EVMHTLC.CreateCancels == nil {
+		active.EVMHTLC.CreateCancels = make(map[string]*EVMCreateCancellation)
+	}
+	active.EVMHTLC.CreateCancels[chainSymbol] = cancel
+	c.log.Info("Cancelling EVM HTLC create",
+		"trade_id", tradeID,
+		"chain", chainSymbol,
+		"create_tx", cancel.CreateTxHash,
+		"cancel_tx", cancel.CancelTxHash,
+	)
+	if pending {
+		c.emitEvent(tradeID, EventEVMCreateReplacing, *cancel)
+	}
+
+	// A mined create resolves at once; a replacement usually takes a block
+	if err := c.resolveEVMCreateLocked(ctx, tradeID, active, cancel, replacer); err != nil {
+		c.log.Warn("Failed to resolve EVM create cancellation", "trade_id", tradeID, "chain", chainSymbol, "error", err)
+	}
+	c.saveEVMCreateCancelLocked(tradeID)
+
+	copied := *cancel
+	return &copied, nil
+}
+
+// GetEVMCreateCancellation returns the cancellation of a trade's HTLC create
+// on a chain, nil if there is none.
+func (c *Coordinator) GetEVMCreateCancellation(tradeID string, chainSymbol string) (*EVMCreateCancellation, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	cancel := active.evmCreateCancel(chainSymbol)
+	if cancel == nil {
+		return nil, nil
+	}
+	copied := *cancel
+	return &copied, nil
+}
+
+// CheckEVMCreateCancellations follows every unfinished cancellation: which
+// of the create and its replacement was mined, and the refund of created
+// HTLCs once their timelock passed.
+func (c *Coordinator) CheckEVMCreateCancellations(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for tradeID, active := range c.swaps {
+		if active.EVMHTLC == nil {
+			continue
+		}
+		for chainSymbol, cancel := range active.EVMHTLC.CreateCancels {
+			if cancel.Outcome.IsFinal() {
+				continue
+			}
+			tradeCtx := backend.WithTradeID(ctx, tradeID)
+			session, err := c.getEVMSession(active, chainSymbol)
+			if err != nil {
+				c.log.Warn("Failed to get EVM session", "trade_id", tradeID, "chain", chainSymbol, "error", err)
+				continue
+			}
+			replacer := c.evmTxReplacerLocked(chainSymbol, session)
+			if replacer == nil {
+				continue
+			}
+			before := *cancel
+			if err := c.resolveEVMCreateLocked(tradeCtx, tradeID, active, cancel, replacer); err != nil {
+				c.log.Warn("Failed to resolve EVM create cancellation", "trade_id", tradeID, "chain", chainSymbol, "error", err)
+			}
+			if *cancel != before {
+				c.saveEVMCreateCancelLocked(tradeID)
+			}
+		}
+	}
+}
+
+// StartEVMCreateCancelMonitor runs CheckEVMCreateCancellations every
+// interval.
+func (c *Coordinator) StartEVMCreateCancelMonitor(interval time.Duration) {
+	if interval <= 0 {
+		c.log.Warn("EVM create cancel monitor not started: check interval not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				c.CheckEVMCreateCancellations(c.ctx)
+			}
+		}
+	}()
+}
+
+// resolveEVMCreateLocked advances a cancellation from the chain. Caller must
+// hold c.mu.
+func (c *Coordinator) resolveEVMCreateLocked(ctx context.Context, tradeID string, active *ActiveSwap, cancel *EVMCreateCancellation, replacer EVMTxReplacer) error {
+	if cancel.Outcome == EVMCreateReplacing {
+		receipt, err := replacer.Receipt(ctx, common.HexToHash(cancel.CreateTxHash))
+		if err != nil {
+			return fmt.Errorf("failed to get create receipt: %w", err)
+		}
+		switch {
+		case receipt != nil && receipt.Status == types.ReceiptStatusSuccessful:
+			c.setEVMCreateOutcomeLocked(tradeID, active, cancel, EVMCreateRefundScheduled)
+		case receipt != nil:
+			// A reverted create took the nonce but locked nothing
+			c.setEVMCreateOutcomeLocked(tradeID, active, cancel, EVMCreateCancelled)
+		case cancel.CancelTxHash != "":
+			receipt, err := replacer.Receipt(ctx, common.HexToHash(cancel.CancelTxHash))
+			if err != nil {
+				return fmt.Errorf("failed to get replacement receipt: %w", err)
+			}
+			if receipt != nil {
+				c.setEVMCreateOutcomeLocked(tradeID, active, cancel, EVMCreateCancelled)
+			}
+		}
+	}
+
+	if cancel.Outcome != EVMCreateRefundScheduled || time.Now().Unix() < cancel.RefundAt {
+		return nil
+	}
+	txHash, err := c.refundEVMHTLCLocked(ctx, tradeID, active, cancel.Chain)
+	if err != nil {
+		cancel.Error = err.Error()
+		cancel.UpdatedAt = time.Now().Unix()
+		return err
+	}
+	cancel.RefundTxHash = txHash.Hex()
+	cancel.Error = ""
+	c.setEVMCreateOutcomeLocked(tradeID, active, cancel, EVMCreateRefunded)
+	return nil
+}
+
+// setEVMCreateOutcomeLocked records how a cancellation went. A create that
+// never locked funds fails the swap; a refunded one refunds it. Caller must
+// hold c.mu.
+func (c *Coordinator) setEVMCreateOutcomeLocked(tradeID string, active *ActiveSwap, cancel *EVMCreateCancellation, outcome EVMCreateOutcome) {
+	cancel.Outcome = outcome
+	cancel.UpdatedAt = time.Now().Unix()
+
+	var state State
+	switch outcome {
+	case EVMCreateCancelled:
+		state = StateFailed
+	case EVMCreateRefunded:
+		state = StateRefunded
+	}
+	if state != "" && !active.Swap.State.IsTerminal() {
+		if err := active.Swap.TransitionTo(state); err != nil {
+			c.log.Warn("Failed to update swap state", "trade_id", tradeID, "state", state, "error", err)
+		}
+	}
+
+	c.log.Info("EVM create cancellation resolved", "trade_id", tradeID, "chain", cancel.Chain, "outcome", outcome)
+	c.emitEvent(tradeID, "evm_create_"+string(outcome), *cancel)
+}
+
+// saveEVMCreateCancelLocked persists a swap after its cancellation changed.
+// Caller must hold c.mu.
+func (c *Coordinator) saveEVMCreateCancelLocked(tradeID string) {
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+}
+
+// evmTxReplacerLocked returns the replacer of a chain: the one set on the
+// coordinator, else the session's client. Caller must hold c.mu.
+func (c *Coordinator) evmTxReplacerLocked(chainSymbol string, session *EVMHTLCSession) EVMTxReplacer {
+	if r, ok := c.evmReplacers[chainSymbol]; ok {
+		return r
+	}
+	return session.txReplacer()
+}
+
+// evmCreateCancel returns the cancellation of the HTLC create on a chain.
+func (a *ActiveSwap) evmCreateCancel(chainSymbol string) *EVMCreateCancellation {
+	if a.EVMHTLC == nil {
+		return nil
+	}
+	return a.EVMHTLC.CreateCancels[chainSymbol]
+}
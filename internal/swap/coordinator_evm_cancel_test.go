@@ -0,0 +1,250 @@
+package swap
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+// fakeReplacer is an EVM chain whose mempool and receipts tests set.
+type fakeReplacer struct {
+	mu       sync.Mutex
+	pending  map[common.Hash]*types.Transaction
+	receipts map[common.Hash]*types.Receipt
+	sent     []*types.Transaction
+}
+
+func (f *fakeReplacer) PendingTransaction(ctx context.Context, hash common.Hash) (*types.Transaction, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if tx, ok := f.pending[hash]; ok {
+		return tx, true, nil
+	}
+	if _, ok := f.receipts[hash]; ok {
+		return types.NewTransaction(0, common.Address{}, nil, 0, nil, nil), false, nil
+	}
+	return nil, false, errors.New("not found")
+}
+
+func (f *fakeReplacer) Receipt(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.receipts[hash], nil
+}
+
+func (f *fakeReplacer) CancelTransaction(ctx context.Context, privateKey *ecdsa.PrivateKey, tx *types.Transaction) (*types.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	from := crypto.PubkeyToAddress(privateKey.PublicKey)
+	replacement := types.NewTransaction(tx.Nonce(), from, new(big.Int), 21000, new(big.Int).Add(tx.GasPrice(), big.NewInt(1)), nil)
+	f.sent = append(f.sent, replacement)
+	return replacement, nil
+}
+
+// mine gives a transaction a receipt and drops it from the mempool.
+func (f *fakeReplacer) mine(hash common.Hash, status uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delete(f.pending, hash)
+	f.receipts[hash] = &types.Receipt{TxHash: hash, Status: status}
+}
+
+// addCancelTrade adds trade-1, an ETH->BTC swap whose ETH HTLC create,
+// returned, waits in the fake chain's mempool, and subscribes to events.
+func addCancelTrade(t *testing.T, coord *Coordinator) (*fakeReplacer, *types.Transaction, chan SwapEvent) {
+	t.Helper()
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("GenerateKey() error = %v", err)
+	}
+	create := types.NewTransaction(5, common.HexToAddress("0x00000000000000000000000000000000000000bb"), big.NewInt(1e18), 200000, big.NewInt(100), []byte{1})
+	session := &EVMHTLCSession{
+		symbol:       "ETH",
+		localPrivKey: key,
+		swapID:       [32]byte{1},
+		timelock:     big.NewInt(time.Now().Add(time.Hour).Unix()),
+		createTxHash: create.Hash(),
+		state:        EVMSwapStateActive,
+	}
+	coord.swaps["trade-1"] = &ActiveSwap{
+		Swap: &Swap{
+			Role: RoleInitiator, State: StateFunding,
+			Offer: Offer{OfferChain: "ETH", RequestChain: "BTC"},
+		},
+		EVMHTLC: &EVMHTLCSwapData{OfferChain: &ChainEVMHTLCData{Session: session}},
+	}
+
+	chainFake := &fakeReplacer{
+		pending:  map[common.Hash]*types.Transaction{create.Hash(): create},
+		receipts: make(map[common.Hash]*types.Receipt),
+	}
+	coord.evmReplacers = map[string]EVMTxReplacer{"ETH": chainFake}
+
+	events := make(chan SwapEvent, 16)
+	coord.OnEvent(func(e SwapEvent) { events <- e })
+	return chainFake, create, events
+}
+
+func waitCancelEvent(t *testing.T, events chan SwapEvent, eventType string) EVMCreateCancellation {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.EventType == eventType {
+				return e.Data.(EVMCreateCancellation)
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", eventType)
+		}
+	}
+}
+
+func TestCancelEVMHTLCCreateReplaced(t *testing.T) {
+	coord := newTestCoordinator(t)
+	chainFake, create, events := addCancelTrade(t, coord)
+	ctx := context.Background()
+
+	cancel, err := coord.CancelEVMHTLCCreate(ctx, "trade-1", "ETH")
+	if err != nil {
+		t.Fatalf("CancelEVMHTLCCreate() error = %v", err)
+	}
+	if cancel.Outcome != EVMCreateReplacing || cancel.CreateTxHash != create.Hash().Hex() {
+		t.Fatalf("cancellation = %+v, want the create being replaced", cancel)
+	}
+	if len(chainFake.sent) != 1 || chainFake.sent[0].Nonce() != create.Nonce() || cancel.CancelTxHash != chainFake.sent[0].Hash().Hex() {
+		t.Fatalf("sent = %v, want one replacement at the create's nonce", chainFake.sent)
+	}
+	waitCancelEvent(t, events, EventEVMCreateReplacing)
+
+	// Calling again doesn't send another replacement
+	again, err := coord.CancelEVMHTLCCreate(ctx, "trade-1", "ETH")
+	if err != nil || again.CancelTxHash != cancel.CancelTxHash || len(chainFake.sent) != 1 {
+		t.Errorf("second CancelEVMHTLCCreate() = %+v, %v, sent %d", again, err, len(chainFake.sent))
+	}
+
+	// Nothing mined yet
+	coord.CheckEVMCreateCancellations(ctx)
+	if got, _ := coord.GetEVMCreateCancellation("trade-1", "ETH"); got.Outcome != EVMCreateReplacing {
+		t.Errorf("outcome = %s before either was mined, want replacing", got.Outcome)
+	}
+
+	// The replacement wins the nonce
+	chainFake.mine(chainFake.sent[0].Hash(), types.ReceiptStatusSuccessful)
+	delete(chainFake.pending, create.Hash())
+	coord.CheckEVMCreateCancellations(ctx)
+	got := waitCancelEvent(t, events, EventEVMCreateCancelled)
+	if got.Outcome != EVMCreateCancelled {
+		t.Errorf("event outcome = %s, want cancelled", got.Outcome)
+	}
+	active, _ := coord.GetSwap("trade-1")
+	if active.Swap.State != StateFailed {
+		t.Errorf("swap state = %s, want failed", active.Swap.State)
+	}
+}
+
+func TestCancelEVMHTLCCreateRaceLost(t *testing.T) {
+	coord := newTestCoordinator(t)
+	chainFake, create, events := addCancelTrade(t, coord)
+	ctx := context.Background()
+
+	if _, err := coord.CancelEVMHTLCCreate(ctx, "trade-1", "ETH"); err != nil {
+		t.Fatalf("CancelEVMHTLCCreate() error = %v", err)
+	}
+
+	// The create is mined before the replacement
+	chainFake.mine(create.Hash(), types.ReceiptStatusSuccessful)
+	coord.CheckEVMCreateCancellations(ctx)
+	got := waitCancelEvent(t, events, EventEVMCreateRefundScheduled)
+	if got.RefundAt != coord.swaps["trade-1"].EVMHTLC.OfferChain.Session.GetTimelock() {
+		t.Errorf("refund at %d, want the timelock", got.RefundAt)
+	}
+
+	// The refund waits for the timelock
+	coord.CheckEVMCreateCancellations(ctx)
+	cancel, _ := coord.GetEVMCreateCancellation("trade-1", "ETH")
+	if cancel.Outcome != EVMCreateRefundScheduled || cancel.RefundTxHash != "" {
+		t.Errorf("cancellation = %+v, want the refund still scheduled", cancel)
+	}
+	if active, _ := coord.GetSwap("trade-1"); active.Swap.State != StateFunding {
+		t.Errorf("swap state = %s, want funding until refunded", active.Swap.State)
+	}
+}
+
+func TestCancelEVMHTLCCreateAlreadyMined(t *testing.T) {
+	coord := newTestCoordinator(t)
+	chainFake, create, _ := addCancelTrade(t, coord)
+	chainFake.mine(create.Hash(), types.ReceiptStatusSuccessful)
+
+	cancel, err := coord.CancelEVMHTLCCreate(context.Background(), "trade-1", "ETH")
+	if err != nil {
+		t.Fatalf("CancelEVMHTLCCreate() error = %v", err)
+	}
+	if cancel.Outcome != EVMCreateRefundScheduled || cancel.CancelTxHash != "" || len(chainFake.sent) != 0 {
+		t.Errorf("cancellation = %+v, want the refund scheduled without a replacement", cancel)
+	}
+}
+
+func TestCancelEVMHTLCCreateReverted(t *testing.T) {
+	coord := newTestCoordinator(t)
+	chainFake, create, _ := addCancelTrade(t, coord)
+	chainFake.mine(create.Hash(), types.ReceiptStatusFailed)
+
+	cancel, err := coord.CancelEVMHTLCCreate(context.Background(), "trade-1", "ETH")
+	if err != nil {
+		t.Fatalf("CancelEVMHTLCCreate() error = %v", err)
+	}
+	if cancel.Outcome != EVMCreateCancelled {
+		t.Errorf("outcome = %s for a reverted create, want cancelled", cancel.Outcome)
+	}
+}
+
+func TestCancelEVMHTLCCreateErrors(t *testing.T) {
+	coord := newTestCoordinator(t)
+	addCancelTrade(t, coord)
+	ctx := context.Background()
+
+	if _, err := coord.CancelEVMHTLCCreate(ctx, "missing", "ETH"); !errors.Is(err, ErrSwapNotFound) {
+		t.Errorf("missing swap error = %v, want ErrSwapNotFound", err)
+	}
+	if _, err := coord.CancelEVMHTLCCreate(ctx, "trade-1", "BTC"); err == nil {
+		t.Error("cancelled a create on a non-EVM chain")
+	}
+
+	coord.swaps["trade-1"].EVMHTLC.OfferChain.Session.createTxHash = common.Hash{}
+	if _, err := coord.CancelEVMHTLCCreate(ctx, "trade-1", "ETH"); !errors.Is(err, ErrNoEVMCreate) {
+		t.Errorf("no create error = %v, want ErrNoEVMCreate", err)
+	}
+	if got, err := coord.GetEVMCreateCancellation("trade-1", "ETH"); err != nil || got != nil {
+		t.Errorf("GetEVMCreateCancellation() = %+v, %v, want none", got, err)
+	}
+}
+
+func TestEVMCreateCancellationStorage(t *testing.T) {
+	coord := newTestCoordinator(t)
+	addCancelTrade(t, coord)
+	if _, err := coord.CancelEVMHTLCCreate(context.Background(), "trade-1", "ETH"); err != nil {
+		t.Fatalf("CancelEVMHTLCCreate() error = %v", err)
+	}
+
+	raw, err := coord.getEVMHTLCStorageDataUnlocked(coord.swaps["trade-1"])
+	if err != nil {
+		t.Fatalf("getEVMHTLCStorageDataUnlocked() error = %v", err)
+	}
+	var data CoordinatorEVMHTLCStorageData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := data.CreateCancels["ETH"]; got == nil || got.Outcome != EVMCreateReplacing || got.CancelTxHash == "" {
+		t.Errorf("stored cancellation = %+v", got)
+	}
+}
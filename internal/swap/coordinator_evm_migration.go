@@ -0,0 +1,211 @@
+// Package swap - HTLC contract upgrades.
+//
+// When a new KlingonHTLC version is deployed, the registry lists it as the
+// chain's HTLC contract and keeps the previous ones as legacy contracts
+// (config.EVMContractAddresses). Each EVM leg of a trade is pinned to the
+// contract its session was created on: new trades use the current
+// contract, in-flight ones claim and refund on theirs, across restarts too.
+//
+// Both parties must lock a chain's funds in one contract: the key exchange
+// messages carry each side's contracts and a mismatch is refused.
+package swap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+var (
+	ErrEVMContractMismatch = errors.New("counterparty uses another HTLC contract version")
+	ErrUnknownEVMContract  = errors.New("unknown HTLC contract")
+)
+
+// EVMContractVersion is an HTLC contract version of a chain and the
+// unfinished trades pinned to it.
+type EVMContractVersion struct {
+	Address  string   `json:"address"`
+	Current  bool     `json:"current"`             // New swaps use it
+	InFlight []string `json:"in_flight,omitempty"` // Unfinished trade IDs
+}
+
+// EVMContractMigration reports the HTLC contract versions of a chain.
+type EVMContractMigration struct {
+	Chain     string                `json:"chain"`
+	ChainID   uint64                `json:"chain_id"`
+	Contracts []*EVMContractVersion `json:"contracts"` // Current first, then legacy, newest first
+
+	// Drained is true when no unfinished trade uses a legacy contract.
+	Drained bool `json:"drained"`
+}
+
+// Contract returns the HTLC contract the leg is pinned to, zero if none yet.
+func (d *ChainEVMHTLCData) Contract() common.Address {
+	if d.ContractAddress != (common.Address{}) {
+		return d.ContractAddress
+	}
+	if d.Session != nil {
+		return d.Session.ContractAddress()
+	}
+	return common.Address{}
+}
+
+// evmChainData returns the EVM data of one of the swap's chains, nil if it
+// has none.
+func (a *ActiveSwap) evmChainData(chainSymbol string) *ChainEVMHTLCData {
+	if a.EVMHTLC == nil {
+		return nil
+	}
+	switch chainSymbol {
+	case a.Swap.Offer.OfferChain:
+		return a.EVMHTLC.OfferChain
+	case a.Swap.Offer.RequestChain:
+		return a.EVMHTLC.RequestChain
+	}
+	return nil
+}
+
+// evmContractLocked returns the HTLC contract a trade uses on an EVM chain:
+// the one its leg is pinned to, else the chain's current contract. active
+// may be nil for a trade we have no swap of yet. Caller must hold c.mu.
+func (c *Coordinator) evmContractLocked(active *ActiveSwap, chainSymbol string) (common.Address, error) {
+	params, ok := chain.Get(chainSymbol, c.network)
+	if !ok || params.Type != chain.ChainTypeEVM {
+		return common.Address{}, fmt.Errorf("%s is not an EVM chain", chainSymbol)
+	}
+	if active != nil {
+		if data := active.evmChainData(chainSymbol); data != nil {
+			if addr := data.Contract(); addr != (common.Address{}) {
+				return addr, nil
+			}
+		}
+	}
+	addr := config.GetHTLCContract(params.ChainID)
+	if addr == (common.Address{}) {
+		return common.Address{}, fmt.Errorf("HTLC contract not deployed on %s (chainID %d)", chainSymbol, params.ChainID)
+	}
+	return addr, nil
+}
+
+// EVMContracts returns the HTLC contract of each EVM leg of a trade, by
+// chain symbol, for the counterparty to check.
+func (c *Coordinator) EVMContracts(tradeID string) (map[string]string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+
+	contracts := make(map[string]string)
+	for _, symbol := range []string{active.Swap.Offer.OfferChain, active.Swap.Offer.RequestChain} {
+		if params, ok := chain.Get(symbol, c.network); !ok || params.Type != chain.ChainTypeEVM {
+			continue
+		}
+		if addr, err := c.evmContractLocked(active, symbol); err == nil {
+			contracts[symbol] = addr.Hex()
+		}
+	}
+	return contracts, nil
+}
+
+// CheckEVMContracts checks the counterparty's HTLC contracts of a trade,
+// by chain symbol, against ours: the ones the trade is pinned to, or the
+// current ones before we have its swap. Both must be the same version.
+func (c *Coordinator) CheckEVMContracts(tradeID string, remote map[string]string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	active := c.swaps[tradeID]
+
+	symbols := make([]string, 0, len(remote))
+	for symbol := range remote {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+	for _, symbol := range symbols {
+		ours, err := c.evmContractLocked(active, symbol)
+		if err != nil {
+			return err
+		}
+		if !common.IsHexAddress(remote[symbol]) {
+			return fmt.Errorf("%w %q on %s", ErrUnknownEVMContract, remote[symbol], symbol)
+		}
+		theirs := common.HexToAddress(remote[symbol])
+		if theirs == ours {
+			continue
+		}
+		params, _ := chain.Get(symbol, c.network)
+		if !config.IsKnownHTLCContract(params.ChainID, theirs) {
+			return fmt.Errorf("%w %s on %s: the counterparty's contract registry differs from ours", ErrUnknownEVMContract, theirs.Hex(), symbol)
+		}
+		return fmt.Errorf("%w: trade %s on %s uses %s, the counterparty %s; one contract version per trade, the node on the older one must update",
+			ErrEVMContractMismatch, tradeID, symbol, ours.Hex(), theirs.Hex())
+	}
+	return nil
+}
+
+// EVMContractMigrations reports the HTLC contract versions of each EVM
+// chain with a deployed contract and the unfinished trades on each.
+func (c *Coordinator) EVMContractMigrations() []*EVMContractMigration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	// Unfinished trades by chain and contract
+	inFlight := make(map[string]map[common.Address][]string)
+	for tradeID, active := range c.swaps {
+		if active.Swap.IsTerminal() {
+			continue
+		}
+		for _, symbol := range []string{active.Swap.Offer.OfferChain, active.Swap.Offer.RequestChain} {
+			data := active.evmChainData(symbol)
+			if data == nil {
+				continue
+			}
+			if addr := data.Contract(); addr != (common.Address{}) {
+				if inFlight[symbol] == nil {
+					inFlight[symbol] = make(map[common.Address][]string)
+				}
+				inFlight[symbol][addr] = append(inFlight[symbol][addr], tradeID)
+			}
+		}
+	}
+
+	chains := chain.ListEVMChains(c.network)
+	symbols := make([]string, 0, len(chains))
+	for symbol := range chains {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	var migrations []*EVMContractMigration
+	for _, symbol := range symbols {
+		chainID := chains[symbol]
+		current := config.GetHTLCContract(chainID)
+		legacy := config.GetLegacyHTLCContracts(chainID)
+		if current == (common.Address{}) && len(legacy) == 0 {
+			continue
+		}
+
+		m := &EVMContractMigration{Chain: symbol, ChainID: chainID, Drained: true}
+		version := func(addr common.Address, isCurrent bool) {
+			trades := inFlight[symbol][addr]
+			sort.Strings(trades)
+			m.Contracts = append(m.Contracts, &EVMContractVersion{Address: addr.Hex(), Current: isCurrent, InFlight: trades})
+			if !isCurrent && len(trades) > 0 {
+				m.Drained = false
+			}
+		}
+		if current != (common.Address{}) {
+			version(current, true)
+		}
+		for _, addr := range legacy {
+			version(addr, false)
+		}
+		migrations = append(migrations, m)
+	}
+	return migrations
+}
@@ -0,0 +1,143 @@
+package swap
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+const sepoliaChainID = 11155111
+
+// upgradeSepolia deploys a second HTLC version on Sepolia for the test and
+// returns both versions.
+func upgradeSepolia(t *testing.T) (v1, v2 common.Address) {
+	t.Helper()
+	saved := *config.GetEVMContracts(sepoliaChainID)
+	t.Cleanup(func() { config.RegisterEVMContracts(sepoliaChainID, &saved) })
+
+	v1 = saved.HTLCContract
+	v2 = common.HexToAddress("0x00000000000000000000000000000000000000c2")
+	config.UpgradeHTLCContract(sepoliaChainID, v2)
+	return v1, v2
+}
+
+// addMigrationTrades adds an ETH->BTC trade pinned to the old contract and
+// one without an ETH leg yet.
+func addMigrationTrades(coord *Coordinator, v1 common.Address) {
+	offer := Offer{OfferChain: "ETH", RequestChain: "BTC"}
+	coord.swaps["trade-old"] = &ActiveSwap{
+		Swap:    &Swap{Role: RoleInitiator, State: StateFunding, Offer: offer},
+		EVMHTLC: &EVMHTLCSwapData{OfferChain: &ChainEVMHTLCData{ContractAddress: v1}},
+	}
+	coord.swaps["trade-new"] = &ActiveSwap{
+		Swap: &Swap{Role: RoleInitiator, State: StateInit, Offer: offer},
+	}
+}
+
+func TestEVMContractPinning(t *testing.T) {
+	v1, v2 := upgradeSepolia(t)
+	coord := newTestCoordinator(t)
+	addMigrationTrades(coord, v1)
+
+	// The in-flight trade stays on the old contract, new ones use the new one
+	if got, err := coord.EVMContracts("trade-old"); err != nil || got["ETH"] != v1.Hex() || len(got) != 1 {
+		t.Errorf("EVMContracts(trade-old) = %v, %v, want v1", got, err)
+	}
+	if got, _ := coord.EVMContracts("trade-new"); got["ETH"] != v2.Hex() {
+		t.Errorf("EVMContracts(trade-new) = %v, want v2", got)
+	}
+	if _, err := coord.EVMContracts("missing"); !errors.Is(err, ErrSwapNotFound) {
+		t.Errorf("EVMContracts(missing) error = %v", err)
+	}
+
+	// The counterparty must use the same version
+	if err := coord.CheckEVMContracts("trade-old", map[string]string{"ETH": v1.Hex()}); err != nil {
+		t.Errorf("CheckEVMContracts(same) error = %v", err)
+	}
+	if err := coord.CheckEVMContracts("trade-old", map[string]string{"ETH": v2.Hex()}); !errors.Is(err, ErrEVMContractMismatch) {
+		t.Errorf("CheckEVMContracts(newer) error = %v, want ErrEVMContractMismatch", err)
+	} else if ClassifyFailure(err) != FailureValidationMismatch {
+		t.Errorf("mismatch classified as %s", ClassifyFailure(err))
+	}
+	if err := coord.CheckEVMContracts("trade-new", map[string]string{"ETH": v1.Hex()}); !errors.Is(err, ErrEVMContractMismatch) {
+		t.Errorf("CheckEVMContracts(older) error = %v, want ErrEVMContractMismatch", err)
+	}
+	if err := coord.CheckEVMContracts("not-started", map[string]string{"ETH": v2.Hex()}); err != nil {
+		t.Errorf("CheckEVMContracts(trade without swap) error = %v", err)
+	}
+	if err := coord.CheckEVMContracts("trade-new", map[string]string{"ETH": "0x00000000000000000000000000000000000000ff"}); !errors.Is(err, ErrUnknownEVMContract) {
+		t.Errorf("CheckEVMContracts(unknown) error = %v, want ErrUnknownEVMContract", err)
+	}
+	if err := coord.CheckEVMContracts("trade-new", map[string]string{"BTC": v2.Hex()}); err == nil {
+		t.Error("CheckEVMContracts() accepted a contract on a non-EVM chain")
+	}
+
+	// Sessions are only created on a known contract
+	if _, err := NewEVMHTLCSessionAt("ETH", chain.Testnet, "http://127.0.0.1:1", common.HexToAddress("0xff")); !errors.Is(err, ErrUnknownEVMContract) {
+		t.Errorf("NewEVMHTLCSessionAt(unknown) error = %v, want ErrUnknownEVMContract", err)
+	}
+}
+
+func TestEVMContractMigrations(t *testing.T) {
+	v1, v2 := upgradeSepolia(t)
+	coord := newTestCoordinator(t)
+	addMigrationTrades(coord, v1)
+
+	var eth *EVMContractMigration
+	for _, m := range coord.EVMContractMigrations() {
+		if m.Chain == "ETH" {
+			eth = m
+		}
+	}
+	if eth == nil || eth.ChainID != sepoliaChainID || len(eth.Contracts) != 2 {
+		t.Fatalf("ETH migration = %+v", eth)
+	}
+	current, legacy := eth.Contracts[0], eth.Contracts[1]
+	if !current.Current || current.Address != v2.Hex() || len(current.InFlight) != 0 {
+		t.Errorf("current version = %+v", current)
+	}
+	if legacy.Current || legacy.Address != v1.Hex() || len(legacy.InFlight) != 1 || legacy.InFlight[0] != "trade-old" {
+		t.Errorf("legacy version = %+v", legacy)
+	}
+	if eth.Drained {
+		t.Error("drained with a trade on the legacy contract")
+	}
+
+	coord.swaps["trade-old"].Swap.State = StateRedeemed
+	for _, m := range coord.EVMContractMigrations() {
+		if m.Chain == "ETH" && !m.Drained {
+			t.Errorf("ETH migration = %+v, want drained once the trade finished", m)
+		}
+	}
+}
+
+func TestEVMContractPinStorage(t *testing.T) {
+	v1, _ := upgradeSepolia(t)
+	coord := newTestCoordinator(t)
+	addMigrationTrades(coord, v1)
+
+	raw, err := coord.getEVMHTLCStorageDataUnlocked(coord.swaps["trade-old"])
+	if err != nil {
+		t.Fatalf("getEVMHTLCStorageDataUnlocked() error = %v", err)
+	}
+	var data CoordinatorEVMHTLCStorageData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	restored := restoreEVMChainData(data.OfferChain)
+	if restored == nil || restored.Contract() != v1 {
+		t.Errorf("restored leg = %+v, want pinned to v1", restored)
+	}
+
+	// Swaps stored before pinning use the current contract
+	if got := restoreEVMChainData(&EVMHTLCChainStorageData{ContractAddress: common.Address{}.Hex()}); got != nil {
+		t.Errorf("restored unpinned leg = %+v, want nil", got)
+	}
+	if got := restoreEVMChainData(nil); got != nil {
+		t.Errorf("restoreEVMChainData(nil) = %+v", got)
+	}
+}
@@ -0,0 +1,600 @@
+// Package swap - Funding a swap leg from an external wallet.
+//
+// Users who keep funds in a hardware or exchange wallet can't let the node
+// sign the funding transaction. RequestExternalFunding instead returns what
+// to pay: the escrow outputs (and DAO fee) on UTXO chains, or the contract
+// call and value on EVM chains. The escrow is checked against the swap's own
+// keys, secret hash and timelock before it is handed out, and the monitor
+// then watches the chain for a matching funding from any source. A UTXO
+// funding is adopted as our local funding (its value goes through the usual
+// funding variance check on the counterparty's side); an EVM contract swap
+// must carry exactly the negotiated receiver, amount, secret hash and
+// timelock.
+package swap
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+)
+
+// External funding events.
+const (
+	EventExternalFundingRequested = "external_funding_requested" // Instructions handed out
+	EventExternalFundingDetected  = "external_funding_detected"  // Matching funding found on chain
+	EventExternalFundingRejected  = "external_funding_rejected"  // EVM swap with other terms found
+)
+
+// ErrExternalFunding is returned when the node is asked to fund a leg that
+// awaits funding from an external wallet.
+var ErrExternalFunding = errors.New("swap awaits external funding")
+
+// Purposes of external funding outputs.
+const (
+	FundingOutputEscrow = "escrow"
+	FundingOutputDAOFee = "dao_fee"
+)
+
+// ExternalFundingOutput is an output the external wallet must pay.
+type ExternalFundingOutput struct {
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+	Purpose string `json:"purpose"`
+}
+
+// ExternalFunding describes how to fund our leg of a swap from an external
+// wallet, and what was found on chain.
+type ExternalFunding struct {
+	TradeID string `json:"trade_id"`
+	Chain   string `json:"chain"`
+	Method  Method `json:"method"`
+	Amount  uint64 `json:"amount"` // Escrow amount (smallest unit)
+
+	// UTXO chains: pay the outputs, ideally in one transaction
+	Outputs       []ExternalFundingOutput `json:"outputs,omitempty"`
+	EscrowAddress string                  `json:"escrow_address,omitempty"`
+	RedeemScript  string                  `json:"redeem_script,omitempty"` // HTLC P2WSH script
+	RefundScript  string                  `json:"refund_script,omitempty"` // MuSig2 refund leaf
+	SecretHash    string                  `json:"secret_hash,omitempty"`
+	TimeoutBlocks uint32                  `json:"timeout_blocks,omitempty"`
+
+	// EVM chains: send CallData to Contract with Value. Only the funding
+	// wallet can refund, with RefundCallData after Timelock.
+	Contract       string `json:"contract,omitempty"`
+	CallData       string `json:"call_data,omitempty"`
+	Value          string `json:"value,omitempty"` // Wei
+	SwapID         string `json:"swap_id,omitempty"`
+	Receiver       string `json:"receiver,omitempty"`
+	Timelock       int64  `json:"timelock,omitempty"`
+	RefundCallData string `json:"refund_call_data,omitempty"`
+
+	// Detection
+	Detected   bool   `json:"detected"`
+	TxID       string `json:"txid,omitempty"`
+	Vout       uint32 `json:"vout,omitempty"`
+	Funded     uint64 `json:"funded,omitempty"` // Escrow value found
+	DAOFeePaid bool   `json:"dao_fee_paid,omitempty"`
+	Sender     string `json:"sender,omitempty"` // EVM funding wallet
+	Rejected   string `json:"rejected,omitempty"`
+}
+
+// RequestExternalFunding returns the instructions for funding our leg of a
+// swap from an external wallet and starts watching for the funding. The node
+// won't fund the leg itself afterwards. Calling it again returns the same
+// instructions.
+func (c *Coordinator) RequestExternalFunding(ctx context.Context, tradeID string) (*ExternalFunding, error) {
+	defer c.lockTrade(tradeID)()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, err := c.externalFundableLocked(tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if st, ok := c.externalFundings[tradeID]; ok {
+		out := *st
+		return &out, nil
+	}
+
+	// Connecting the session of an EVM leg reads from its node
+	dial := c.evmSessionDialLocked(active, localLegChain(active.Swap))
+	defer c.dropEVMSessionLocked(dial)
+	if dial != nil {
+		c.dialEVMSessionLocked(dial)
+		if active, err = c.externalFundableLocked(tradeID); err != nil {
+			return nil, err
+		}
+	}
+
+	ef, err := c.externalFundingUnlocked(active)
+	if err != nil {
+		return nil, err
+	}
+	if c.externalFundings == nil {
+		c.externalFundings = make(map[string]*ExternalFunding)
+	}
+	c.externalFundings[tradeID] = ef
+	active.Swap.ExternalFunding = true
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+
+	c.log.Info("Awaiting external funding", "trade_id", tradeID, "chain", ef.Chain, "amount", ef.Amount)
+	c.emitEvent(tradeID, EventExternalFundingRequested, map[string]interface{}{
+		"chain":  ef.Chain,
+		"amount": ef.Amount,
+	})
+	out := *ef
+	return &out, nil
+}
+
+// externalFundableLocked returns a swap whose leg can be funded externally.
+// Caller must hold c.mu.
+func (c *Coordinator) externalFundableLocked(tradeID string) (*ActiveSwap, error) {
+	if c.cold {
+		return nil, ErrColdMode
+	}
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if active.Swap.LocalFundingTxID != "" {
+		return nil, ErrAlreadyFunded
+	}
+	if active.Swap.State != StateInit && active.Swap.State != StateFunding {
+		return nil, fmt.Errorf("swap is %s, not awaiting funding", active.Swap.State)
+	}
+	return active, nil
+}
+
+// GetExternalFunding returns the external funding state of a swap.
+func (c *Coordinator) GetExternalFunding(tradeID string) (*ExternalFunding, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if !active.Swap.ExternalFunding {
+		return nil, fmt.Errorf("external funding not requested")
+	}
+	if _, ok := c.externalFundings[tradeID]; !ok {
+		// Rebuilding the instructions of an EVM leg connects its session
+		dial := c.evmSessionDialLocked(active, localLegChain(active.Swap))
+		defer c.dropEVMSessionLocked(dial)
+		if dial != nil {
+			c.dialEVMSessionLocked(dial)
+			if active, ok = c.swaps[tradeID]; !ok {
+				return nil, ErrSwapNotFound
+			}
+		}
+	}
+	st, err := c.externalFundingStateUnlocked(tradeID, active)
+	if err != nil {
+		return nil, err
+	}
+	out := *st
+	return &out, nil
+}
+
+// externalFundingStateUnlocked returns the external funding state of a swap,
+// rebuilding the instructions after a restart. Caller must hold c.mu.
+func (c *Coordinator) externalFundingStateUnlocked(tradeID string, active *ActiveSwap) (*ExternalFunding, error) {
+	if st, ok := c.externalFundings[tradeID]; ok {
+		return st, nil
+	}
+	ef, err := c.externalFundingUnlocked(active)
+	if err != nil {
+		return nil, err
+	}
+	if active.Swap.LocalFundingTxID != "" {
+		ef.Detected = true
+		ef.TxID = active.Swap.LocalFundingTxID
+		ef.Vout = active.Swap.LocalFundingVout
+	}
+	if c.externalFundings == nil {
+		c.externalFundings = make(map[string]*ExternalFunding)
+	}
+	c.externalFundings[tradeID] = ef
+	return ef, nil
+}
+
+// externalFundingUnlocked builds the funding instructions for our leg after
+// checking its escrow. Caller must hold c.mu.
+func (c *Coordinator) externalFundingUnlocked(active *ActiveSwap) (*ExternalFunding, error) {
+	isMaker := active.Swap.Role == RoleInitiator
+	chainSymbol := localLegChain(active.Swap)
+	ef := &ExternalFunding{
+		TradeID: active.Swap.ID,
+		Chain:   chainSymbol,
+		Method:  active.Swap.Method,
+		Amount:  escrowAmountFor(active, isMaker),
+	}
+
+	if IsEVMChain(chainSymbol, c.network) {
+		if err := c.evmExternalFundingUnlocked(active, ef); err != nil {
+			return nil, err
+		}
+		return ef, nil
+	}
+
+	if err := c.checkEscrowUnlocked(active, ef); err != nil {
+		return nil, err
+	}
+	ef.Outputs = []ExternalFundingOutput{{Address: ef.EscrowAddress, Amount: ef.Amount, Purpose: FundingOutputEscrow}}
+
+	amount := active.Swap.Offer.RequestAmount
+	if isMaker {
+		amount = active.Swap.Offer.OfferAmount
+	}
+	daoFee := active.Swap.Offer.FeeTerms.DAOFee(amount, isMaker)
+	daoAddress := config.NewExchangeConfig(config.NetworkType(c.network)).GetDAOAddress(chainSymbol)
+	if daoFee > 0 && daoAddress != "" {
+		ef.Outputs = append(ef.Outputs, ExternalFundingOutput{Address: daoAddress, Amount: daoFee, Purpose: FundingOutputDAOFee})
+	}
+	return ef, nil
+}
+
+// checkEscrowUnlocked verifies that our escrow address commits to the swap's
+// secret hash and a refund path to our key, and fills in the escrow details.
+// Caller must hold c.mu.
+func (c *Coordinator) checkEscrowUnlocked(active *ActiveSwap, ef *ExternalFunding) error {
+	localPub := active.Swap.LocalPubKey
+	if len(localPub) == 0 {
+		return errors.New("swap keys not exchanged yet")
+	}
+	isOffer := active.Swap.Role == RoleInitiator
+
+	switch {
+	case active.IsHTLC():
+		data := active.HTLC.RequestChain
+		if isOffer {
+			data = active.HTLC.OfferChain
+		}
+		if data == nil || data.Session == nil || data.HTLCAddress == "" {
+			return errors.New("HTLC address not set - exchange secret hash first")
+		}
+		script := data.Session.GetHTLCScript()
+		secretHash, _, sender, timeout, err := ParseHTLCScript(script)
+		if err != nil {
+			return fmt.Errorf("invalid HTLC script: %w", err)
+		}
+		if want := htlcSecretHashUnlocked(active); len(want) > 0 && !bytes.Equal(secretHash, want) {
+			return errors.New("HTLC script does not commit to the swap's secret hash")
+		}
+		if !bytes.Equal(sender, localPub) {
+			return errors.New("HTLC refund path does not pay our key")
+		}
+		if timeout == 0 {
+			return errors.New("HTLC script has no timelock")
+		}
+		addr, err := HTLCAddressFromScript(script, ef.Chain, c.network)
+		if err != nil {
+			return err
+		}
+		if addr != data.HTLCAddress {
+			return fmt.Errorf("HTLC address %s does not match its script", data.HTLCAddress)
+		}
+		ef.EscrowAddress = addr
+		ef.RedeemScript = hex.EncodeToString(script)
+		ef.SecretHash = hex.EncodeToString(secretHash)
+		ef.TimeoutBlocks = timeout
+
+	case active.IsMuSig2():
+		data := active.MuSig2.RequestChain
+		if isOffer {
+			data = active.MuSig2.OfferChain
+		}
+		if data == nil || data.Session == nil || data.TaprootAddress == "" {
+			return errors.New("taproot address not set - exchange pubkeys first")
+		}
+		tree := data.Session.GetScriptTree()
+		if tree == nil {
+			return errors.New("taproot escrow has no refund path")
+		}
+		agg, err := data.Session.AggregatedPubKey()
+		if err != nil {
+			return err
+		}
+		if !tree.InternalKey.IsEqual(agg) {
+			return errors.New("taproot escrow is not keyed to both parties")
+		}
+		pub, err := btcec.ParsePubKey(localPub)
+		if err != nil {
+			return fmt.Errorf("invalid local pubkey: %w", err)
+		}
+		if !bytes.Contains(tree.RefundScript, schnorr.SerializePubKey(pub)) {
+			return errors.New("taproot refund path does not pay our key")
+		}
+		if tree.TimeoutBlocks == 0 {
+			return errors.New("taproot refund path has no timelock")
+		}
+		params, ok := chain.Get(ef.Chain, c.network)
+		if !ok {
+			return fmt.Errorf("unsupported chain: %s", ef.Chain)
+		}
+		addr, err := tree.TaprootAddress(params.Bech32HRP)
+		if err != nil {
+			return err
+		}
+		if addr != data.TaprootAddress {
+			return fmt.Errorf("taproot address %s does not match its script tree", data.TaprootAddress)
+		}
+		ef.EscrowAddress = addr
+		ef.RefundScript = hex.EncodeToString(tree.RefundScript)
+		ef.TimeoutBlocks = tree.TimeoutBlocks
+
+	default:
+		return errors.New("unknown swap method")
+	}
+	return nil
+}
+
+// evmExternalFundingUnlocked fills in the contract call creating our EVM
+// HTLC. Caller must hold c.mu.
+func (c *Coordinator) evmExternalFundingUnlocked(active *ActiveSwap, ef *ExternalFunding) error {
+	if err := c.checkContractsActiveUnlocked(ef.Chain); err != nil {
+		return err
+	}
+	session, err := c.getOrCreateEVMSession(active, ef.Chain)
+	if err != nil {
+		return fmt.Errorf("failed to get EVM session: %w", err)
+	}
+	if session.tokenAddress != (common.Address{}) {
+		return errors.New("external funding of ERC-20 legs is not supported")
+	}
+	receiver := session.GetRemoteAddress()
+	if receiver == (common.Address{}) {
+		return errors.New("counterparty EVM address not set")
+	}
+
+	swapID := session.GetSwapID()
+	secretHash := session.GetSecretHash()
+	timelock := big.NewInt(session.GetTimelock())
+	createData, refundData, err := evmFundingCallData(swapID, receiver, secretHash, timelock)
+	if err != nil {
+		return err
+	}
+	params, _ := chain.Get(ef.Chain, c.network)
+
+	ef.Contract = config.GetHTLCContract(params.ChainID).Hex()
+	ef.CallData = hex.EncodeToString(createData)
+	amount := session.GetAmount()
+	if amount == nil || amount.Sign() <= 0 {
+		return errors.New("EVM swap amount not set")
+	}
+	ef.Value = amount.String()
+	ef.SwapID = hex.EncodeToString(swapID[:])
+	ef.Receiver = receiver.Hex()
+	ef.SecretHash = hex.EncodeToString(secretHash[:])
+	ef.Timelock = timelock.Int64()
+	ef.RefundCallData = hex.EncodeToString(refundData)
+	return nil
+}
+
+// evmFundingCallData packs the createSwapNative and refund calls of a swap.
+func evmFundingCallData(swapID [32]byte, receiver common.Address, secretHash [32]byte, timelock *big.Int) (create, refund []byte, err error) {
+	abi, err := htlc.KlingonHTLCMetaData.GetAbi()
+	if err != nil {
+		return nil, nil, err
+	}
+	if create, err = abi.Pack("createSwapNative", swapID, receiver, secretHash, timelock); err != nil {
+		return nil, nil, err
+	}
+	if refund, err = abi.Pack("refund", swapID); err != nil {
+		return nil, nil, err
+	}
+	return create, refund, nil
+}
+
+// externalFundingQuery is the lookup of our leg's funding sent from an
+// external wallet: the escrow address's transactions on UTXO chains, the
+// contract swap on EVM chains.
+type externalFundingQuery struct {
+	st      *ExternalFunding
+	chain   string
+	address string
+	b       backend.Backend
+	session *EVMHTLCSession
+
+	txs     []backend.Transaction
+	onChain *htlc.Swap
+	err     error
+}
+
+// externalFundingQueryLocked prepares the lookup of our leg's external
+// funding; it returns nil when there is nothing to look for. Caller must
+// hold c.mu.
+func (c *Coordinator) externalFundingQueryLocked(tradeID string, active *ActiveSwap) *externalFundingQuery {
+	if !externalFundingPending(active) {
+		return nil
+	}
+	st, err := c.externalFundingStateUnlocked(tradeID, active)
+	if err != nil || st.Detected {
+		return nil
+	}
+
+	q := &externalFundingQuery{st: st, chain: st.Chain, address: st.EscrowAddress}
+	if IsEVMChain(st.Chain, c.network) {
+		if q.session, err = c.getEVMSession(active, st.Chain); err != nil {
+			return nil
+		}
+		return q
+	}
+	if q.b = c.backends[st.Chain]; q.b == nil {
+		return nil
+	}
+	return q
+}
+
+// externalFundingDialLocked prepares the connection of the EVM session the
+// lookup of our leg's external funding needs; nil when it needs none.
+// Caller must hold c.mu.
+func (c *Coordinator) externalFundingDialLocked(active *ActiveSwap) *evmSessionDial {
+	if !externalFundingPending(active) {
+		return nil
+	}
+	return c.evmSessionDialLocked(active, localLegChain(active.Swap))
+}
+
+// externalFundingPending reports whether our leg awaits external funding.
+func externalFundingPending(active *ActiveSwap) bool {
+	if !active.Swap.ExternalFunding || active.Swap.LocalFundingTxID != "" {
+		return false
+	}
+	return active.Swap.State == StateInit || active.Swap.State == StateFunding
+}
+
+// run makes the lookup. It must not be called holding c.mu.
+func (q *externalFundingQuery) run(ctx context.Context) {
+	switch {
+	case q == nil:
+	case q.session != nil:
+		q.onChain, q.err = q.session.GetSwapFromChain(ctx)
+	default:
+		q.txs, q.err = q.b.GetAddressTxs(ctx, q.address, "")
+	}
+}
+
+// applyExternalFundingLocked records the external funding of our leg the
+// query found. The swap is checked again, as it may have been funded or
+// moved on since the query was prepared. Caller must hold c.mu.
+func (c *Coordinator) applyExternalFundingLocked(tradeID string, active *ActiveSwap, q *externalFundingQuery) {
+	if q == nil || !externalFundingPending(active) || q.st.Detected || c.externalFundings[tradeID] != q.st {
+		return
+	}
+	st := q.st
+	if q.err != nil {
+		c.log.Debug("External funding lookup failed", "trade_id", tradeID, "error", q.err)
+		return
+	}
+	if q.session != nil {
+		c.applyEVMExternalFundingLocked(tradeID, st, q.onChain)
+		return
+	}
+	tx, vout, ok := findEscrowFunding(q.txs, q.address)
+	if !ok {
+		return
+	}
+
+	st.Detected = true
+	st.TxID = tx.TxID
+	st.Vout = vout
+	st.Funded = tx.Outputs[vout].Value
+	for _, out := range st.Outputs {
+		if out.Purpose == FundingOutputDAOFee {
+			st.DAOFeePaid = paysAtLeast(tx, out.Address, out.Amount)
+		}
+	}
+
+	active.Swap.LocalFundingTxID = tx.TxID
+	active.Swap.LocalFundingVout = vout
+	active.Swap.UpdateLocalConfirmations(uint32(tx.Confirmations))
+	if active.Swap.State == StateInit {
+		if err := active.Swap.TransitionTo(StateFunding); err != nil {
+			c.log.Warn("Failed to transition state", "trade_id", tradeID, "error", err)
+		}
+	}
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+
+	c.log.Info("External funding detected", "trade_id", tradeID, "txid", tx.TxID, "vout", vout,
+		"expected", st.Amount, "actual", st.Funded, "dao_fee_paid", st.DAOFeePaid)
+	c.emitEvent(tradeID, EventExternalFundingDetected, map[string]interface{}{
+		"chain":        st.Chain,
+		"txid":         tx.TxID,
+		"vout":         vout,
+		"expected":     st.Amount,
+		"actual":       st.Funded,
+		"dao_fee_paid": st.DAOFeePaid,
+	})
+}
+
+// applyEVMExternalFundingLocked checks the contract swap created from an
+// external wallet against the negotiated terms. Caller must hold c.mu.
+func (c *Coordinator) applyEVMExternalFundingLocked(tradeID string, st *ExternalFunding, onChain *htlc.Swap) {
+	if onChain == nil || onChain.State == htlc.SwapStateEmpty {
+		return
+	}
+
+	reason := evmFundingMismatch(onChain, st)
+	if reason != "" {
+		if st.Rejected != reason {
+			st.Rejected = reason
+			c.log.Warn("External EVM funding does not match the swap", "trade_id", tradeID, "reason", reason)
+			c.emitEvent(tradeID, EventExternalFundingRejected, map[string]interface{}{
+				"chain":  st.Chain,
+				"reason": reason,
+				"sender": onChain.Sender.Hex(),
+			})
+		}
+		return
+	}
+
+	st.Detected = true
+	st.Rejected = ""
+	st.Sender = onChain.Sender.Hex()
+	st.Funded = onChain.Amount.Uint64()
+	c.log.Info("External EVM funding detected", "trade_id", tradeID, "chain", st.Chain, "sender", st.Sender)
+	c.emitEvent(tradeID, EventExternalFundingDetected, map[string]interface{}{
+		"chain":   st.Chain,
+		"swap_id": st.SwapID,
+		"sender":  st.Sender,
+		"actual":  st.Funded,
+	})
+}
+
+// evmFundingMismatch returns why a contract swap doesn't match the funding
+// instructions, or "" if it does.
+func evmFundingMismatch(onChain *htlc.Swap, st *ExternalFunding) string {
+	value, _ := new(big.Int).SetString(st.Value, 10)
+	switch {
+	case onChain.Token != (common.Address{}):
+		return "swap locks a token, not the native coin"
+	case onChain.Receiver != common.HexToAddress(st.Receiver):
+		return fmt.Sprintf("receiver %s, want %s", onChain.Receiver.Hex(), st.Receiver)
+	case value == nil || onChain.Amount == nil || onChain.Amount.Cmp(value) != 0:
+		return fmt.Sprintf("amount %v, want %s", onChain.Amount, st.Value)
+	case hex.EncodeToString(onChain.SecretHash[:]) != st.SecretHash:
+		return "secret hash does not match"
+	case onChain.Timelock == nil || onChain.Timelock.Int64() != st.Timelock:
+		return fmt.Sprintf("timelock %v, want %d", onChain.Timelock, st.Timelock)
+	}
+	return ""
+}
+
+// findEscrowFunding returns the first output paying the escrow address.
+func findEscrowFunding(txs []backend.Transaction, addr string) (*backend.Transaction, uint32, bool) {
+	for i := range txs {
+		for vout, out := range txs[i].Outputs {
+			if out.ScriptPubKeyAddr == addr {
+				return &txs[i], uint32(vout), true
+			}
+		}
+	}
+	return nil, 0, false
+}
+
+// paysAtLeast reports whether a transaction pays an address at least amount.
+func paysAtLeast(tx *backend.Transaction, addr string, amount uint64) bool {
+	for _, out := range tx.Outputs {
+		if out.ScriptPubKeyAddr == addr && out.Value >= amount {
+			return true
+		}
+	}
+	return false
+}
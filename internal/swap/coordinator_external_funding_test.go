@@ -0,0 +1,348 @@
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// addExternalFundingTrade adds trade t1, in which we are the maker of a
+// BTC/LTC HTLC swap whose BTC escrow is set up but unfunded, and returns the
+// escrow address.
+func addExternalFundingTrade(t *testing.T, coord *Coordinator) string {
+	t.Helper()
+	local, err := NewHTLCSession("BTC", chain.Testnet)
+	if err != nil {
+		t.Fatalf("NewHTLCSession() error = %v", err)
+	}
+	remote, err := NewHTLCSession("BTC", chain.Testnet)
+	if err != nil {
+		t.Fatalf("NewHTLCSession() error = %v", err)
+	}
+	_, hash, err := local.GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() error = %v", err)
+	}
+	if err := local.SetRemotePubKey(remote.GetLocalPubKey()); err != nil {
+		t.Fatalf("SetRemotePubKey() error = %v", err)
+	}
+	escrow, err := local.GenerateSwapAddress(144, nil)
+	if err != nil {
+		t.Fatalf("GenerateSwapAddress() error = %v", err)
+	}
+
+	s := newTestHTLCSwap(t, chain.Testnet, RoleInitiator)
+	s.LocalPubKey = local.GetLocalPubKey().SerializeCompressed()
+	s.SecretHash = hash
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap:  s,
+		Trade: &storage.Trade{ID: "t1", OurRole: storage.TradeRoleMaker},
+		HTLC: &HTLCSwapData{
+			OfferChain: &ChainHTLCData{Session: local, HTLCAddress: escrow},
+		},
+	}
+	return escrow
+}
+
+func TestRequestExternalFunding(t *testing.T) {
+	coord := newTestCoordinator(t, withBackend("BTC", newFakeChainBackend()))
+	escrow := addExternalFundingTrade(t, coord)
+
+	var mu sync.Mutex
+	var events []string
+	coord.OnEvent(func(e SwapEvent) {
+		mu.Lock()
+		events = append(events, e.EventType)
+		mu.Unlock()
+	})
+
+	ef, err := coord.RequestExternalFunding(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("RequestExternalFunding() error = %v", err)
+	}
+	if ef.Chain != "BTC" || ef.EscrowAddress != escrow || ef.Amount != 100000 {
+		t.Errorf("got chain %s escrow %s amount %d", ef.Chain, ef.EscrowAddress, ef.Amount)
+	}
+	if ef.Outputs[0].Purpose != FundingOutputEscrow || ef.Outputs[0].Address != escrow {
+		t.Errorf("first output = %+v, want the escrow", ef.Outputs[0])
+	}
+	if ef.TimeoutBlocks != 144 || ef.RedeemScript == "" {
+		t.Errorf("timeout %d script %q", ef.TimeoutBlocks, ef.RedeemScript)
+	}
+	active := coord.swaps["t1"]
+	if ef.SecretHash != hex.EncodeToString(active.Swap.SecretHash) {
+		t.Errorf("secret hash = %s", ef.SecretHash)
+	}
+	if !active.Swap.ExternalFunding {
+		t.Error("swap should be marked as externally funded")
+	}
+
+	// Idempotent
+	again, err := coord.RequestExternalFunding(context.Background(), "t1")
+	if err != nil || again.RedeemScript != ef.RedeemScript {
+		t.Errorf("second request = %+v, %v", again, err)
+	}
+
+	// The node no longer funds the leg itself
+	if _, err := coord.FundSwap(context.Background(), "t1"); !errors.Is(err, ErrExternalFunding) {
+		t.Errorf("FundSwap() error = %v, want ErrExternalFunding", err)
+	}
+
+	// Handlers run in goroutines
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 1 || events[0] != EventExternalFundingRequested {
+		t.Errorf("events = %v, want one %s", events, EventExternalFundingRequested)
+	}
+}
+
+func TestRequestExternalFundingRejectsForeignEscrow(t *testing.T) {
+	coord := newTestCoordinator(t, withBackend("BTC", newFakeChainBackend()))
+	addExternalFundingTrade(t, coord)
+	active := coord.swaps["t1"]
+
+	// An escrow whose refund path pays someone else
+	other, _ := NewHTLCSession("BTC", chain.Testnet)
+	active.Swap.LocalPubKey = other.GetLocalPubKey().SerializeCompressed()
+	if _, err := coord.RequestExternalFunding(context.Background(), "t1"); err == nil {
+		t.Error("expected an error for an escrow not refunding to our key")
+	}
+
+	// An escrow for another secret hash
+	coord2 := newTestCoordinator(t, withBackend("BTC", newFakeChainBackend()))
+	addExternalFundingTrade(t, coord2)
+	coord2.swaps["t1"].Swap.SecretHash = make([]byte, 32)
+	if _, err := coord2.RequestExternalFunding(context.Background(), "t1"); err == nil {
+		t.Error("expected an error for a different secret hash")
+	}
+
+	// Already funded
+	coord3 := newTestCoordinator(t, withBackend("BTC", newFakeChainBackend()))
+	addExternalFundingTrade(t, coord3)
+	coord3.swaps["t1"].Swap.LocalFundingTxID = "funded"
+	if _, err := coord3.RequestExternalFunding(context.Background(), "t1"); !errors.Is(err, ErrAlreadyFunded) {
+		t.Errorf("error = %v, want ErrAlreadyFunded", err)
+	}
+}
+
+func TestExternalFundingDetected(t *testing.T) {
+	btc := newFakeChainBackend()
+	coord := newTestCoordinator(t, withBackend("BTC", btc))
+	escrow := addExternalFundingTrade(t, coord)
+	ctx := context.Background()
+
+	ef, err := coord.RequestExternalFunding(ctx, "t1")
+	if err != nil {
+		t.Fatalf("RequestExternalFunding() error = %v", err)
+	}
+
+	// Nothing on chain yet
+	coord.UpdateConfirmations(ctx, "t1")
+	if st, _ := coord.GetExternalFunding("t1"); st.Detected {
+		t.Fatal("funding detected before it was sent")
+	}
+
+	// Funded from some other wallet, paying escrow and DAO fee
+	outputs := []backend.TxOutput{{ScriptPubKeyAddr: "tb1qchange", Value: 5000}}
+	for _, out := range ef.Outputs {
+		outputs = append(outputs, backend.TxOutput{ScriptPubKeyAddr: out.Address, Value: out.Amount})
+	}
+	tx := backend.Transaction{TxID: "ext-funding", Outputs: outputs}
+	btc.mu.Lock()
+	btc.history[escrow] = []backend.Transaction{tx}
+	btc.txs["ext-funding"] = &tx
+	btc.mu.Unlock()
+
+	coord.UpdateConfirmations(ctx, "t1")
+
+	st, err := coord.GetExternalFunding("t1")
+	if err != nil {
+		t.Fatalf("GetExternalFunding() error = %v", err)
+	}
+	if !st.Detected || st.TxID != "ext-funding" || st.Vout != 1 || st.Funded != 100000 {
+		t.Errorf("status = %+v", st)
+	}
+	if len(ef.Outputs) > 1 && !st.DAOFeePaid {
+		t.Error("DAO fee output should be detected")
+	}
+	active := coord.swaps["t1"]
+	if active.Swap.LocalFundingTxID != "ext-funding" || active.Swap.LocalFundingVout != 1 {
+		t.Errorf("local funding = %s:%d", active.Swap.LocalFundingTxID, active.Swap.LocalFundingVout)
+	}
+	if active.Swap.State != StateFunding {
+		t.Errorf("state = %s, want funding", active.Swap.State)
+	}
+}
+
+// urlBackend is a backend whose EVM sessions connect to url.
+type urlBackend struct {
+	backend.Backend
+	url string
+}
+
+func (b *urlBackend) GetURL() string { return b.url }
+
+func TestRequestExternalFundingEVMConnectsUnlocked(t *testing.T) {
+	// The node holds the chain ID read connecting a session until released
+	dialing := make(chan struct{}, 1)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			ID     json.RawMessage `json:"id"`
+			Method string          `json:"method"`
+		}
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.Method == "eth_chainId" {
+			select {
+			case dialing <- struct{}{}:
+			default:
+			}
+			<-release
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": "0xaa36a7"})
+	}))
+	t.Cleanup(server.Close)
+	eth := backend.NewJSONRPCBackend(server.URL, backend.RPCTypeEVM, "", "")
+	registry := backend.NewRegistry()
+	registry.Register("ETH", eth)
+	ws, _, _ := newTestWalletService(t, registry)
+	coord := newTestCoordinator(t, withWallet(ws), withBackend("ETH", &urlBackend{Backend: eth, url: server.URL}))
+
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleInitiator, Offer{
+		OfferChain: "ETH", OfferAmount: 1e16,
+		RequestChain: "BTC", RequestAmount: 100000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	s.ID = "t1"
+	s.RemoteOfferWalletAddr = "0x00000000000000000000000000000000000000aa"
+	coord.swaps["t1"] = &ActiveSwap{Swap: s}
+
+	type result struct {
+		ef  *ExternalFunding
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		ef, err := coord.RequestExternalFunding(context.Background(), "t1")
+		done <- result{ef, err}
+	}()
+	select {
+	case <-dialing:
+	case <-time.After(5 * time.Second):
+		t.Fatal("session never connected")
+	}
+
+	// Other swaps are served while the node answers
+	status := make(chan error, 1)
+	go func() {
+		_, err := coord.GetZeroConfStatus("t1")
+		status <- err
+	}()
+	select {
+	case err := <-status:
+		if err != nil {
+			t.Errorf("GetZeroConfStatus() error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("coordinator lock held while connecting the EVM session")
+	}
+
+	close(release)
+	res := <-done
+	if res.err != nil {
+		t.Fatalf("RequestExternalFunding() error = %v", res.err)
+	}
+	if res.ef.Value != "10000000000000000" || res.ef.CallData == "" || res.ef.Receiver != common.HexToAddress(s.RemoteOfferWalletAddr).Hex() {
+		t.Errorf("funding = %+v", res.ef)
+	}
+	if len(coord.evmDialed) != 0 {
+		t.Errorf("%d dialed sessions left over", len(coord.evmDialed))
+	}
+}
+
+func TestEVMFundingCallData(t *testing.T) {
+	swapID := [32]byte{1}
+	secretHash := [32]byte{2}
+	receiver := common.HexToAddress("0x00000000000000000000000000000000000000aa")
+	create, refund, err := evmFundingCallData(swapID, receiver, secretHash, big.NewInt(1700000000))
+	if err != nil {
+		t.Fatalf("evmFundingCallData() error = %v", err)
+	}
+
+	abi, _ := htlc.KlingonHTLCMetaData.GetAbi()
+	method, err := abi.MethodById(create[:4])
+	if err != nil || method.Name != "createSwapNative" {
+		t.Fatalf("create selector = %v, %v", method, err)
+	}
+	args, err := method.Inputs.Unpack(create[4:])
+	if err != nil {
+		t.Fatalf("Unpack() error = %v", err)
+	}
+	if args[0].([32]byte) != swapID || args[1].(common.Address) != receiver || args[2].([32]byte) != secretHash {
+		t.Errorf("create args = %v", args)
+	}
+	if args[3].(*big.Int).Int64() != 1700000000 {
+		t.Errorf("timelock = %v", args[3])
+	}
+
+	method, err = abi.MethodById(refund[:4])
+	if err != nil || method.Name != "refund" {
+		t.Fatalf("refund selector = %v, %v", method, err)
+	}
+}
+
+func TestEVMFundingMismatch(t *testing.T) {
+	st := &ExternalFunding{
+		Receiver:   "0x00000000000000000000000000000000000000aa",
+		Value:      "1000",
+		SecretHash: hex.EncodeToString(make([]byte, 32)),
+		Timelock:   100,
+	}
+	good := &htlc.Swap{
+		Receiver: common.HexToAddress(st.Receiver),
+		Amount:   big.NewInt(1000),
+		Timelock: big.NewInt(100),
+	}
+	if reason := evmFundingMismatch(good, st); reason != "" {
+		t.Errorf("matching swap rejected: %s", reason)
+	}
+
+	bad := *good
+	bad.Amount = big.NewInt(999)
+	if evmFundingMismatch(&bad, st) == "" {
+		t.Error("short amount accepted")
+	}
+	bad = *good
+	bad.Timelock = big.NewInt(50)
+	if evmFundingMismatch(&bad, st) == "" {
+		t.Error("shorter timelock accepted")
+	}
+	bad = *good
+	bad.SecretHash = [32]byte{9}
+	if evmFundingMismatch(&bad, st) == "" {
+		t.Error("other secret hash accepted")
+	}
+	bad = *good
+	bad.Receiver = common.HexToAddress("0xbb")
+	if evmFundingMismatch(&bad, st) == "" {
+		t.Error("other receiver accepted")
+	}
+}
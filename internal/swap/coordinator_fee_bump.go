@@ -0,0 +1,440 @@
+// Package swap - Fee bumping of stuck funding transactions.
+//
+// Funding transactions signal RBF, so one stuck at too low a feerate can be
+// replaced: the same inputs, escrow and DAO outputs are signed again and
+// the higher fee is taken from the change. The replacement becomes our
+// funding transaction before it is broadcast, so recovery follows it, and
+// the counterparty is sent its txid. MuSig2 swaps can't be replaced once
+// partial signatures exist, as they sign the funding outpoint. Instead of
+// replacing, a child spending the change can pay for both (CPFP), which
+// leaves the funding txid unchanged.
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Fee bump methods.
+const (
+	FeeBumpRBF  = "rbf"  // Replace the funding transaction
+	FeeBumpCPFP = "cpfp" // Spend its change with a higher fee
+)
+
+// EventFundingFeeBumped is emitted when a fee bump was broadcast.
+const EventFundingFeeBumped = "funding_fee_bumped"
+
+var (
+	ErrFundingConfirmed = errors.New("funding transaction already confirmed")
+	ErrFundingSigned    = errors.New("funding outpoint already signed, can't replace the funding transaction")
+	ErrNoFundingChange  = errors.New("funding transaction has no change to pay a higher fee")
+)
+
+// FeeBumpResult is the outcome of a fee bump.
+type FeeBumpResult struct {
+	TradeID     string `json:"trade_id"`
+	Chain       string `json:"chain"`
+	Method      string `json:"method"`
+	BumpedTxID  string `json:"bumped_txid"`  // Funding transaction before the bump
+	TxID        string `json:"txid"`         // Replacement (rbf) or child (cpfp)
+	FundingTxID string `json:"funding_txid"` // Funding transaction after the bump
+	EscrowVout  uint32 `json:"escrow_vout"`
+	FeeRate     uint64 `json:"fee_rate"` // sat/vB of the funding, with its child for cpfp
+	Fee         uint64 `json:"fee"`      // Fee of the transaction broadcast
+}
+
+// broadcastFunding is our broadcast funding transaction with the wallet
+// outputs it spends.
+type broadcastFunding struct {
+	chain  string
+	tx     *wire.MsgTx
+	info   *backend.Transaction
+	inputs []*wallet.AddressUTXO
+	fee    uint64
+	vsize  uint64
+	change int // Index of the change output, -1 for none
+}
+
+// BumpFundingFee raises the feerate of our unconfirmed funding transaction
+// to feeRate sat/vB, by replacing it (FeeBumpRBF, the default) or by
+// spending its change (FeeBumpCPFP). A zero feeRate uses the chain's fee
+// estimate; either way it is at least 1 sat/vB above the current feerate.
+func (c *Coordinator) BumpFundingFee(ctx context.Context, tradeID, method string, feeRate uint64) (_ *FeeBumpResult, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.bump_fee")
+	defer func() { tracing.End(span, err) }()
+
+	if method == "" {
+		method = FeeBumpRBF
+	}
+	if method != FeeBumpRBF && method != FeeBumpCPFP {
+		return nil, fmt.Errorf("unknown fee bump method %q", method)
+	}
+
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if c.walletService == nil {
+		return nil, errors.New("wallet service not available")
+	}
+	if active.Swap.LocalFundingTxID == "" {
+		return nil, errors.New("swap not funded yet")
+	}
+	if active.Swap.ExternalFunding {
+		return nil, errors.New("swap was funded from an external wallet")
+	}
+	if active.Swap.IsTerminal() {
+		return nil, fmt.Errorf("swap is %s", active.Swap.State)
+	}
+
+	chainSymbol := active.Swap.Offer.RequestChain
+	if active.Swap.Role == RoleInitiator {
+		chainSymbol = active.Swap.Offer.OfferChain
+	}
+	if IsEVMChain(chainSymbol, c.network) {
+		return nil, fmt.Errorf("fee bumping is not supported on %s", chainSymbol)
+	}
+	b, ok := c.backends[chainSymbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoBackend, chainSymbol)
+	}
+
+	funding, err := c.loadFundingTxUnlocked(ctx, b, chainSymbol, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// BIP 125 wants the replacement to pay for its own relay on top
+	currentRate := (funding.fee + funding.vsize - 1) / funding.vsize
+	if feeRate == 0 {
+		feeRate = fundingFeeRate(ctx, b)
+	}
+	if feeRate <= currentRate {
+		feeRate = currentRate + 1
+	}
+
+	if method == FeeBumpRBF {
+		return c.replaceFundingUnlocked(ctx, b, tradeID, active, funding, feeRate)
+	}
+	return c.spendFundingChangeUnlocked(ctx, b, tradeID, active, funding, feeRate)
+}
+
+// loadFundingTxUnlocked fetches our funding transaction and resolves its
+// inputs to wallet outputs. Caller must hold c.mu.
+func (c *Coordinator) loadFundingTxUnlocked(ctx context.Context, b backend.Backend, chainSymbol string, active *ActiveSwap) (*broadcastFunding, error) {
+	if c.store == nil {
+		return nil, errors.New("storage not available")
+	}
+	txID := active.Swap.LocalFundingTxID
+
+	info, err := b.GetTransaction(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding tx: %w", err)
+	}
+	if info.Confirmed || info.Confirmations > 0 {
+		return nil, ErrFundingConfirmed
+	}
+	raw, err := b.GetRawTransaction(ctx, txID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding tx: %w", err)
+	}
+	tx, err := decodeRawTx(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid funding tx: %w", err)
+	}
+
+	funding := &broadcastFunding{chain: chainSymbol, tx: tx, info: info, change: -1}
+	var totalIn, totalOut uint64
+	for i, in := range tx.TxIn {
+		prev, err := prevOutput(ctx, b, info, i, in.PreviousOutPoint)
+		if err != nil {
+			return nil, err
+		}
+		utxo, err := c.walletOutput(prev, in.PreviousOutPoint)
+		if err != nil {
+			return nil, fmt.Errorf("funding input %d: %w", i, err)
+		}
+		funding.inputs = append(funding.inputs, utxo)
+		totalIn += utxo.Amount
+	}
+	for _, out := range tx.TxOut {
+		totalOut += uint64(out.Value)
+	}
+	if totalOut > totalIn {
+		return nil, errors.New("funding tx outputs exceed its inputs")
+	}
+	funding.fee = totalIn - totalOut
+	funding.vsize = txVSize(tx)
+
+	// Outputs are escrow, DAO fee if any, and change last
+	last := len(tx.TxOut) - 1
+	if last > 0 && uint32(last) != active.Swap.LocalFundingVout && last < len(info.Outputs) {
+		if addr, err := c.store.GetWalletAddress(info.Outputs[last].ScriptPubKeyAddr); err == nil && addr != nil {
+			funding.change = last
+		}
+	}
+	return funding, nil
+}
+
+// replaceFundingUnlocked signs and broadcasts a replacement of the funding
+// transaction paying feeRate, and makes it our funding. Caller must hold
+// c.mu.
+func (c *Coordinator) replaceFundingUnlocked(ctx context.Context, b backend.Backend, tradeID string, active *ActiveSwap, funding *broadcastFunding, feeRate uint64) (*FeeBumpResult, error) {
+	if active.IsMuSig2() && musig2Signed(active) {
+		return nil, ErrFundingSigned
+	}
+	if funding.change < 0 {
+		return nil, ErrNoFundingChange
+	}
+
+	replacement := funding.tx.Copy()
+	for _, in := range replacement.TxIn {
+		in.SignatureScript = nil
+		in.Witness = nil
+	}
+	fee := feeRate * funding.vsize
+	extra := fee - funding.fee
+	change := uint64(replacement.TxOut[funding.change].Value)
+	if change <= extra {
+		return nil, fmt.Errorf("%w: change %d, fee increase %d", ErrNoFundingChange, change, extra)
+	}
+	if change-extra < DustThreshold(funding.chain, feeRate) {
+		// Change that would be dust goes to the miners
+		fee += change - extra
+		replacement.TxOut = append(replacement.TxOut[:funding.change], replacement.TxOut[funding.change+1:]...)
+	} else {
+		replacement.TxOut[funding.change].Value = int64(change - extra)
+	}
+	if err := c.signWalletInputs(ctx, replacement, funding.chain, funding.inputs); err != nil {
+		return nil, err
+	}
+	txHex, err := SerializeTx(replacement)
+	if err != nil {
+		return nil, err
+	}
+
+	// Recovery follows the replacement from the moment it may be broadcast
+	bumped := active.Swap.LocalFundingTxID
+	active.Swap.LocalFundingTxID = replacement.TxHash().String()
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("BumpFundingFee: failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	txID, err := c.BroadcastOnce(ctx, b, feeBumpJobAction(FeeBumpRBF, bumped, feeRate), tradeID, funding.chain, txHex)
+	if err != nil {
+		active.Swap.LocalFundingTxID = bumped
+		if err := c.saveSwapState(tradeID); err != nil {
+			c.log.Warn("BumpFundingFee: failed to save swap state", "trade_id", tradeID, "error", err)
+		}
+		return nil, fmt.Errorf("failed to broadcast replacement: %w", err)
+	}
+	active.Swap.LocalFundingTxID = txID
+	active.Swap.LocalFundingConfirms = 0
+	c.supersedeBroadcastJob(tradeID, bumped, txID)
+
+	result := &FeeBumpResult{
+		TradeID:     tradeID,
+		Chain:       funding.chain,
+		Method:      FeeBumpRBF,
+		BumpedTxID:  bumped,
+		TxID:        txID,
+		FundingTxID: txID,
+		EscrowVout:  active.Swap.LocalFundingVout,
+		FeeRate:     feeRate,
+		Fee:         fee,
+	}
+	c.log.Info("Replaced funding transaction", "trade_id", tradeID, "chain", funding.chain, "replaced", bumped, "txid", txID, "fee_rate", feeRate)
+	c.emitEvent(tradeID, EventFundingFeeBumped, result)
+	return result, nil
+}
+
+// spendFundingChangeUnlocked signs and broadcasts a child spending the
+// funding transaction's change, paying for both at feeRate. Caller must
+// hold c.mu.
+func (c *Coordinator) spendFundingChangeUnlocked(ctx context.Context, b backend.Backend, tradeID string, active *ActiveSwap, funding *broadcastFunding, feeRate uint64) (*FeeBumpResult, error) {
+	if funding.change < 0 {
+		return nil, ErrNoFundingChange
+	}
+	parentID := funding.tx.TxHash()
+	out := funding.tx.TxOut[funding.change]
+	input, err := c.walletOutput(&funding.info.Outputs[funding.change], *wire.NewOutPoint(&parentID, uint32(funding.change)))
+	if err != nil {
+		return nil, fmt.Errorf("funding change: %w", err)
+	}
+
+	childVSize := uint64(estimateFundingVSize([]*wallet.AddressUTXO{input}, 1))
+	fee := feeRate * (funding.vsize + childVSize)
+	if fee > funding.fee {
+		fee -= funding.fee
+	} else {
+		fee = feeRate * childVSize
+	}
+	if uint64(out.Value) <= fee || uint64(out.Value)-fee < DustThreshold(funding.chain, feeRate) {
+		return nil, fmt.Errorf("%w: change %d, child fee %d", ErrNoFundingChange, out.Value, fee)
+	}
+
+	chainParams, ok := chain.Get(funding.chain, c.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", funding.chain)
+	}
+	destAddr, err := c.getWalletAddress(funding.chain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get wallet address: %w", err)
+	}
+	destScript, err := wallet.ParseAddressToScript(destAddr, chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid wallet address: %w", err)
+	}
+
+	child := wire.NewMsgTx(wire.TxVersion)
+	txIn := wire.NewTxIn(wire.NewOutPoint(&parentID, uint32(funding.change)), nil, nil)
+	txIn.Sequence = wire.MaxTxInSequenceNum - 2 // Enable RBF
+	child.AddTxIn(txIn)
+	child.AddTxOut(wire.NewTxOut(out.Value-int64(fee), destScript))
+	if err := c.signWalletInputs(ctx, child, funding.chain, []*wallet.AddressUTXO{input}); err != nil {
+		return nil, err
+	}
+	txHex, err := SerializeTx(child)
+	if err != nil {
+		return nil, err
+	}
+
+	bumped := active.Swap.LocalFundingTxID
+	txID, err := c.BroadcastOnce(ctx, b, feeBumpJobAction(FeeBumpCPFP, bumped, feeRate), tradeID, funding.chain, txHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast child: %w", err)
+	}
+
+	result := &FeeBumpResult{
+		TradeID:     tradeID,
+		Chain:       funding.chain,
+		Method:      FeeBumpCPFP,
+		BumpedTxID:  bumped,
+		TxID:        txID,
+		FundingTxID: bumped,
+		EscrowVout:  active.Swap.LocalFundingVout,
+		FeeRate:     feeRate,
+		Fee:         fee,
+	}
+	c.log.Info("Bumped funding transaction with a child", "trade_id", tradeID, "chain", funding.chain, "funding", bumped, "child", txID, "fee_rate", feeRate)
+	c.emitEvent(tradeID, EventFundingFeeBumped, result)
+	return result, nil
+}
+
+// musig2Signed returns true once either party signed over the funding
+// outpoints. Caller must hold c.mu.
+func musig2Signed(active *ActiveSwap) bool {
+	if active.MuSig2 == nil {
+		return false
+	}
+	for _, cd := range []*ChainMuSig2Data{active.MuSig2.OfferChain, active.MuSig2.RequestChain} {
+		if cd != nil && (cd.PartialSig != nil || cd.RemotePartialSig != nil) {
+			return true
+		}
+	}
+	return false
+}
+
+// feeBumpJobAction is the job action of a fee bump. Each bump of a
+// transaction to a feerate is its own side effect.
+func feeBumpJobAction(method, bumpedTxID string, feeRate uint64) string {
+	return fmt.Sprintf("%s:%s:%d", method, bumpedTxID, feeRate)
+}
+
+// walletOutput resolves an output paying one of our wallet addresses to the
+// UTXO that signs for it.
+func (c *Coordinator) walletOutput(out *backend.TxOutput, outpoint wire.OutPoint) (*wallet.AddressUTXO, error) {
+	addr, err := c.store.GetWalletAddress(out.ScriptPubKeyAddr)
+	if err != nil || addr == nil {
+		return nil, fmt.Errorf("%s is not a wallet address", out.ScriptPubKeyAddr)
+	}
+	return &wallet.AddressUTXO{
+		TxID:         outpoint.Hash.String(),
+		Vout:         outpoint.Index,
+		Amount:       out.Value,
+		Address:      addr.Address,
+		Account:      addr.Account,
+		Change:       addr.Change,
+		AddressIndex: addr.AddressIndex,
+		AddressType:  addr.AddressType,
+	}, nil
+}
+
+// signWalletInputs signs every input of tx, which spends utxos in order.
+func (c *Coordinator) signWalletInputs(ctx context.Context, tx *wire.MsgTx, symbol string, utxos []*wallet.AddressUTXO) error {
+	if c.walletService.HasHardwareSigner(symbol) {
+		return c.walletService.SignTx(ctx, symbol, tx, utxos)
+	}
+
+	chainParams, ok := chain.Get(symbol, c.network)
+	if !ok {
+		return fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for i, utxo := range utxos {
+		script, err := wallet.ParseAddressToScript(utxo.Address, chainParams)
+		if err != nil {
+			return fmt.Errorf("invalid UTXO address %s: %w", utxo.Address, err)
+		}
+		prevOuts[tx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(int64(utxo.Amount), script)
+	}
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+
+	for i, utxo := range utxos {
+		privKey, err := c.walletService.DerivePrivateKeyWithChange(symbol, utxo.Account, utxo.Change, utxo.AddressIndex)
+		if err != nil {
+			return fmt.Errorf("failed to derive key for input %d: %w", i, err)
+		}
+		if err := signFundingInput(tx, i, privKey, prevOutFetcher, utxo, chainParams); err != nil {
+			return fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// prevOutput returns the output spent by input i of a transaction, from the
+// backend's prevout data or else from the parent transaction.
+func prevOutput(ctx context.Context, b backend.Backend, info *backend.Transaction, i int, outpoint wire.OutPoint) (*backend.TxOutput, error) {
+	if i < len(info.Inputs) && info.Inputs[i].PrevOut != nil && info.Inputs[i].PrevOut.ScriptPubKeyAddr != "" {
+		return info.Inputs[i].PrevOut, nil
+	}
+	parent, err := b.GetTransaction(ctx, outpoint.Hash.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get input %d: %w", i, err)
+	}
+	if int(outpoint.Index) >= len(parent.Outputs) {
+		return nil, fmt.Errorf("input %d spends missing output %s", i, outpoint)
+	}
+	return &parent.Outputs[outpoint.Index], nil
+}
+
+// decodeRawTx decodes a raw transaction as backends return it, hex-encoded
+// or as bytes.
+func decodeRawTx(raw []byte) (*wire.MsgTx, error) {
+	if s := strings.TrimSpace(string(raw)); len(s)%2 == 0 {
+		if _, err := hex.DecodeString(s); err == nil {
+			return DeserializeTx(s)
+		}
+	}
+	return DeserializeTx(hex.EncodeToString(raw))
+}
+
+// txVSize returns the virtual size of a transaction.
+func txVSize(tx *wire.MsgTx) uint64 {
+	weight := tx.SerializeSizeStripped()*3 + tx.SerializeSize()
+	return uint64(weight+3) / 4
+}
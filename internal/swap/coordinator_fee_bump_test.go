@@ -0,0 +1,195 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// addFeeBumpTrade adds trade t1, funded by a 100000 sat output of the
+// coordinator's wallet: 50000 to the escrow, 48000 change and a 2000 sat
+// fee. The funding transaction is left unconfirmed in btc.
+func addFeeBumpTrade(t *testing.T, coord *Coordinator, btc *fakeChainBackend) *wire.MsgTx {
+	t.Helper()
+	ws := coord.walletService
+	recv, _ := ws.GetAddressWithChange("BTC", 0, 0, 0)
+	change, _ := ws.GetAddressWithChange("BTC", 0, 1, 3)
+	escrow, _ := ws.GetAddressWithChange("BTC", 5, 0, 0) // Not a wallet address
+	for _, a := range []*storage.WalletAddress{
+		{Address: recv, Chain: "BTC", AddressType: "p2wpkh"},
+		{Address: change, Chain: "BTC", Change: 1, AddressIndex: 3, AddressType: "p2wpkh"},
+	} {
+		if err := coord.store.SaveWalletAddress(a); err != nil {
+			t.Fatalf("SaveWalletAddress() error = %v", err)
+		}
+	}
+
+	params, _ := chain.Get("BTC", chain.Testnet)
+	script := func(addr string) []byte {
+		s, err := wallet.ParseAddressToScript(addr, params)
+		if err != nil {
+			t.Fatalf("ParseAddressToScript() error = %v", err)
+		}
+		return s
+	}
+	parent := wire.NewMsgTx(wire.TxVersion)
+	in := wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0xaa}, 1), nil, nil)
+	in.Sequence = wire.MaxTxInSequenceNum - 2
+	parent.AddTxIn(in)
+	parent.AddTxOut(wire.NewTxOut(50000, script(escrow)))
+	parent.AddTxOut(wire.NewTxOut(48000, script(change)))
+	if err := coord.signWalletInputs(context.Background(), parent, "BTC", []*wallet.AddressUTXO{{Address: recv, Amount: 100000, AddressType: "p2wpkh"}}); err != nil {
+		t.Fatalf("signWalletInputs() error = %v", err)
+	}
+	rawHex, _ := SerializeTx(parent)
+	btc.raw[parent.TxHash().String()] = []byte(rawHex)
+	btc.txs[parent.TxHash().String()] = &backend.Transaction{
+		TxID:    parent.TxHash().String(),
+		Inputs:  []backend.TxInput{{PrevOut: &backend.TxOutput{ScriptPubKeyAddr: recv, Value: 100000}}},
+		Outputs: []backend.TxOutput{{ScriptPubKeyAddr: escrow, Value: 50000}, {ScriptPubKeyAddr: change, Value: 48000}},
+	}
+
+	coord.swaps["t1"] = &ActiveSwap{Swap: &Swap{
+		ID:               "t1",
+		Method:           MethodHTLC,
+		Role:             RoleInitiator,
+		State:            StateFunding,
+		Offer:            Offer{OfferChain: "BTC", OfferAmount: 50000, RequestChain: "LTC", RequestAmount: 500000},
+		LocalFundingTxID: parent.TxHash().String(),
+	}}
+	return parent
+}
+
+// verifyInput runs the script of input i of tx against its prevout.
+func verifyInput(t *testing.T, tx *wire.MsgTx, i int, prevOut *wire.TxOut) {
+	t.Helper()
+	fetcher := txscript.NewCannedPrevOutputFetcher(prevOut.PkScript, prevOut.Value)
+	engine, err := txscript.NewEngine(prevOut.PkScript, tx, i, txscript.StandardVerifyFlags, nil, txscript.NewTxSigHashes(tx, fetcher), prevOut.Value, fetcher)
+	if err != nil {
+		t.Fatalf("NewEngine() error = %v", err)
+	}
+	if err := engine.Execute(); err != nil {
+		t.Errorf("input %d does not verify: %v", i, err)
+	}
+}
+
+func TestBumpFundingFeeRBF(t *testing.T) {
+	btc := newFakeChainBackend()
+	ws, _, _ := newTestWalletService(t, backend.NewRegistry())
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withWallet(ws), withBackend("BTC", btc))
+	parent := addFeeBumpTrade(t, coord, btc)
+	vsize := txVSize(parent)
+	fundJob := JobID(JobActionFund, "t1", "BTC")
+	rawHex, _ := SerializeTx(parent)
+	coord.store.EnqueueJob(&storage.Job{ID: fundJob, TradeID: "t1", Chain: "BTC", Kind: storage.JobKindBroadcastTx, Action: JobActionFund, Payload: rawHex})
+	coord.store.MarkJobBroadcast(fundJob, parent.TxHash().String())
+
+	result, err := coord.BumpFundingFee(context.Background(), "t1", "", 30)
+	if err != nil {
+		t.Fatalf("BumpFundingFee() error = %v", err)
+	}
+	if len(btc.broadcasts) != 1 {
+		t.Fatalf("broadcasts = %d, want 1", len(btc.broadcasts))
+	}
+	replacement, _ := DeserializeTx(btc.broadcasts[0])
+
+	// Same input and escrow, the fee increase taken from the change
+	if replacement.TxIn[0].PreviousOutPoint != parent.TxIn[0].PreviousOutPoint || replacement.TxOut[0].Value != 50000 {
+		t.Errorf("replacement doesn't conflict with the funding: %+v", replacement)
+	}
+	if want := int64(100000 - 50000 - 30*vsize); replacement.TxOut[1].Value != want {
+		t.Errorf("change = %d, want %d", replacement.TxOut[1].Value, want)
+	}
+	params, _ := chain.Get("BTC", chain.Testnet)
+	recvScript, _ := wallet.ParseAddressToScript(btc.txs[parent.TxHash().String()].Inputs[0].PrevOut.ScriptPubKeyAddr, params)
+	verifyInput(t, replacement, 0, wire.NewTxOut(100000, recvScript))
+
+	if result.Method != FeeBumpRBF || result.BumpedTxID != parent.TxHash().String() || result.Fee != 30*vsize {
+		t.Errorf("result = %+v", result)
+	}
+	if s := coord.swaps["t1"].Swap; s.LocalFundingTxID != replacement.TxHash().String() || result.FundingTxID != s.LocalFundingTxID {
+		t.Errorf("LocalFundingTxID = %s, want the replacement %s", s.LocalFundingTxID, replacement.TxHash())
+	}
+	if record, err := coord.store.GetSwap("t1"); err == nil && record.LocalFundingTxID != result.TxID {
+		t.Errorf("stored funding txid = %s, want %s", record.LocalFundingTxID, result.TxID)
+	}
+	// The replaced funding is not rebroadcast
+	if job, _ := coord.store.GetJob(fundJob); job.Status != storage.JobStatusFailed {
+		t.Errorf("funding job status = %s, want failed", job.Status)
+	}
+}
+
+func TestBumpFundingFeeCPFP(t *testing.T) {
+	btc := newFakeChainBackend()
+	ws, _, _ := newTestWalletService(t, backend.NewRegistry())
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withWallet(ws), withBackend("BTC", btc))
+	parent := addFeeBumpTrade(t, coord, btc)
+	vsize := txVSize(parent)
+
+	result, err := coord.BumpFundingFee(context.Background(), "t1", FeeBumpCPFP, 30)
+	if err != nil {
+		t.Fatalf("BumpFundingFee() error = %v", err)
+	}
+	child, _ := DeserializeTx(btc.broadcasts[0])
+	parentID := parent.TxHash()
+	if child.TxIn[0].PreviousOutPoint != *wire.NewOutPoint(&parentID, 1) {
+		t.Errorf("child spends %v, want the funding change", child.TxIn[0].PreviousOutPoint)
+	}
+	childVSize := uint64(estimateFundingVSize([]*wallet.AddressUTXO{{AddressType: "p2wpkh"}}, 1))
+	wantFee := 30*(vsize+childVSize) - 2000
+	if result.Fee != wantFee || child.TxOut[0].Value != int64(48000-wantFee) {
+		t.Errorf("child fee = %d, output %d, want fee %d", result.Fee, child.TxOut[0].Value, wantFee)
+	}
+	verifyInput(t, child, 0, parent.TxOut[1])
+
+	// The funding txid is unchanged
+	if s := coord.swaps["t1"].Swap; s.LocalFundingTxID != parent.TxHash().String() || result.FundingTxID != s.LocalFundingTxID {
+		t.Errorf("LocalFundingTxID = %s", s.LocalFundingTxID)
+	}
+}
+
+func TestBumpFundingFeeRefused(t *testing.T) {
+	ctx := context.Background()
+
+	btc := newFakeChainBackend()
+	ws, _, _ := newTestWalletService(t, backend.NewRegistry())
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withWallet(ws), withBackend("BTC", btc))
+	parent := addFeeBumpTrade(t, coord, btc)
+	btc.setConfirmations(parent.TxHash().String(), 1)
+	if _, err := coord.BumpFundingFee(ctx, "t1", FeeBumpRBF, 30); !errors.Is(err, ErrFundingConfirmed) {
+		t.Errorf("confirmed funding: error = %v, want ErrFundingConfirmed", err)
+	}
+
+	// MuSig2 partial signatures commit to the funding outpoint
+	btc = newFakeChainBackend()
+	coord = newTestCoordinator(t, withStore(newTestStore(t)), withWallet(ws), withBackend("BTC", btc))
+	addFeeBumpTrade(t, coord, btc)
+	active := coord.swaps["t1"]
+	active.Swap.Method = MethodMuSig2
+	active.MuSig2 = &MuSig2SwapData{OfferChain: &ChainMuSig2Data{RemotePartialSig: &musig2.PartialSignature{}}}
+	if _, err := coord.BumpFundingFee(ctx, "t1", FeeBumpRBF, 30); !errors.Is(err, ErrFundingSigned) {
+		t.Errorf("signed MuSig2 funding: error = %v, want ErrFundingSigned", err)
+	}
+
+	// The fee increase can't exceed the change
+	btc = newFakeChainBackend()
+	coord = newTestCoordinator(t, withStore(newTestStore(t)), withWallet(ws), withBackend("BTC", btc))
+	addFeeBumpTrade(t, coord, btc)
+	if _, err := coord.BumpFundingFee(ctx, "t1", FeeBumpRBF, 400); !errors.Is(err, ErrNoFundingChange) {
+		t.Errorf("fee above change: error = %v, want ErrNoFundingChange", err)
+	}
+	if _, err := coord.BumpFundingFee(ctx, "t1", "replace", 30); err == nil || !strings.Contains(err.Error(), "unknown") {
+		t.Errorf("unknown method: error = %v", err)
+	}
+}
@@ -12,9 +12,12 @@ import (
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
+	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // =============================================================================
@@ -22,10 +25,17 @@ import (
 // =============================================================================
 
 // CreateFundingTx creates a funding transaction for our side of the swap.
-func (c *Coordinator) CreateFundingTx(ctx context.Context, tradeID string) (string, error) {
+func (c *Coordinator) CreateFundingTx(ctx context.Context, tradeID string) (_ string, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.create_funding_tx")
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cold {
+		return "", ErrColdMode
+	}
 	active, ok := c.swaps[tradeID]
 	if !ok {
 		return "", ErrSwapNotFound
@@ -34,6 +44,12 @@ func (c *Coordinator) CreateFundingTx(ctx context.Context, tradeID string) (stri
 	if active.Swap.LocalFundingTxID != "" {
 		return "", ErrAlreadyFunded
 	}
+	if active.Swap.ExternalFunding {
+		return "", ErrExternalFunding
+	}
+	if active.Swap.SettlesOverLightning() {
+		return "", ErrLightningSettlement
+	}
 
 	// Determine which chain we're funding based on role
 	var chainSymbol string
@@ -65,9 +81,10 @@ func (c *Coordinator) CreateFundingTx(ctx context.Context, tradeID string) (stri
 		return "", fmt.Errorf("failed to get wallet address: %w", err)
 	}
 
-	// Calculate DAO fee
+	// Calculate DAO fee per the negotiated fee terms
 	isMaker := active.Swap.Role == RoleInitiator
-	daoFee := CalculateDAOFee(amount, isMaker)
+	daoFee := active.Swap.Offer.FeeTerms.DAOFee(amount, isMaker)
+	amount = escrowAmountFor(active, isMaker)
 
 	// Get DAO address from config based on network
 	exchangeCfg := config.NewExchangeConfig(config.NetworkType(c.network))
@@ -114,6 +131,7 @@ func (c *Coordinator) CreateFundingTx(ctx context.Context, tradeID string) (stri
 	}
 
 	txHex := txResult.TxHex
+	c.recordFundingKeyUsageUnlocked(tradeID, chainSymbol, walletUTXOs, txResult.UsedUTXOs, walletAddr, txResult.Change)
 
 	c.emitEvent(tradeID, "funding_tx_created", map[string]interface{}{
 		"chain":  chainSymbol,
@@ -168,47 +186,68 @@ func (c *Coordinator) SetFundingTx(tradeID string, txID string, vout uint32, isL
 // =============================================================================
 
 // UpdateConfirmations updates confirmation counts for funding transactions.
+// It holds the trade's lock throughout but releases c.mu while querying the
+// backends, so confirmation updates of different trades run concurrently.
 func (c *Coordinator) UpdateConfirmations(ctx context.Context, tradeID string) error {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	ctx, op := c.beginOperation(ctx, tradeID, "update_confirmations", OpChainQuery)
+	defer op.end()
+	defer c.lockTrade(tradeID)()
 	c.mu.Lock()
-	defer c.mu.Unlock()
 
 	active, ok := c.swaps[tradeID]
 	if !ok {
+		c.mu.Unlock()
 		return ErrSwapNotFound
 	}
 
-	// Update local funding confirmations
-	if active.Swap.LocalFundingTxID != "" {
-		var chainSymbol string
-		if active.Swap.Role == RoleInitiator {
-			chainSymbol = active.Swap.Offer.OfferChain
-		} else {
-			chainSymbol = active.Swap.Offer.RequestChain
-		}
+	// The EVM session an external funding lookup needs connects first
+	dial := c.externalFundingDialLocked(active)
+	c.dialEVMSessionLocked(dial)
+	if active, ok = c.swaps[tradeID]; !ok {
+		c.dropEVMSessionLocked(dial)
+		c.mu.Unlock()
+		return ErrSwapNotFound
+	}
 
-		confirms, err := c.getConfirmations(ctx, chainSymbol, active.Swap.LocalFundingTxID)
-		if err == nil {
-			active.Swap.UpdateLocalConfirmations(confirms)
-		}
+	external := c.externalFundingQueryLocked(tradeID, active)
+	c.dropEVMSessionLocked(dial)
+	local := c.fundingQueryLocked(active, true)
+	remote := c.fundingQueryLocked(active, false)
+	zeroConf := c.zeroConfQueryLocked(tradeID, active)
+	c.mu.Unlock()
+
+	external.run(ctx)
+	local.run(ctx)
+	remote.run(ctx)
+	zeroConf.run(ctx, remote)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if active, ok = c.swaps[tradeID]; !ok {
+		return ErrSwapNotFound
 	}
 
-	// Update remote funding confirmations
-	if active.Swap.RemoteFundingTxID != "" {
-		var chainSymbol string
-		if active.Swap.Role == RoleInitiator {
-			chainSymbol = active.Swap.Offer.RequestChain
-		} else {
-			chainSymbol = active.Swap.Offer.OfferChain
-		}
+	// Funding sent from an external wallet
+	c.applyExternalFundingLocked(tradeID, active, external)
 
-		confirms, err := c.getConfirmations(ctx, chainSymbol, active.Swap.RemoteFundingTxID)
-		if err == nil {
-			active.Swap.UpdateRemoteConfirmations(confirms)
-		}
+	// Counts of funding replaced meanwhile are dropped
+	if local.ok && active.Swap.LocalFundingTxID == local.txID {
+		active.Swap.UpdateLocalConfirmations(local.confirms)
+	}
+	if remote.ok && active.Swap.RemoteFundingTxID == remote.txID {
+		active.Swap.UpdateRemoteConfirmations(remote.confirms)
 	}
+	defer func() { c.publishViewLocked(tradeID, active) }()
+
+	// Escrow values that differ from the negotiated amounts
+	c.checkFundingVarianceLocked(tradeID, active, local, remote)
+
+	// Small swaps may act on unconfirmed counterparty funding
+	c.checkZeroConfLocked(tradeID, active, remote, zeroConf)
 
 	// Check if we should transition to funded state
-	if active.Swap.State == StateFunding && active.Swap.IsFundingConfirmed() {
+	if active.Swap.State == StateFunding && active.Swap.IsFundingConfirmed() && !c.fundingVarianceBlocksLocked(tradeID) {
 		if err := active.Swap.TransitionTo(StateFunded); err != nil {
 			return err
 		}
@@ -218,21 +257,77 @@ func (c *Coordinator) UpdateConfirmations(ctx context.Context, tradeID string) e
 	return nil
 }
 
+// fundingQuery is a confirmation count query of one funding transaction.
+type fundingQuery struct {
+	b        backend.Backend
+	txID     string
+	tx       *backend.Transaction
+	confirms uint32
+	ok       bool
+}
+
+// fundingQueryLocked prepares the query of our (local) or the
+// counterparty's funding transaction; it does nothing without one or without
+// a backend for its chain. Caller must hold c.mu.
+func (c *Coordinator) fundingQueryLocked(active *ActiveSwap, local bool) *fundingQuery {
+	q := &fundingQuery{}
+	chainSymbol := active.Swap.Offer.OfferChain
+	if local {
+		q.txID = active.Swap.LocalFundingTxID
+		if active.Swap.Role != RoleInitiator {
+			chainSymbol = active.Swap.Offer.RequestChain
+		}
+	} else {
+		q.txID = active.Swap.RemoteFundingTxID
+		if active.Swap.Role == RoleInitiator {
+			chainSymbol = active.Swap.Offer.RequestChain
+		}
+	}
+	if q.txID != "" {
+		q.b = c.backends[chainSymbol]
+	}
+	return q
+}
+
+// run queries the backend. It must not be called holding c.mu.
+func (q *fundingQuery) run(ctx context.Context) {
+	if q.b == nil {
+		return
+	}
+	tx, err := q.b.GetTransaction(ctx, q.txID)
+	if err != nil {
+		return
+	}
+	q.tx = tx
+	q.confirms = uint32(tx.Confirmations)
+	q.ok = true
+}
+
+// fundingTx returns the transaction the query read if it is txid, nil
+// otherwise: funding replaced since the read is checked on the next update.
+func (q *fundingQuery) fundingTx(txid string) *backend.Transaction {
+	if q == nil || q.txID != txid {
+		return nil
+	}
+	return q.tx
+}
+
 // =============================================================================
 // Auto-Funding (Sign + Broadcast + Set)
 // =============================================================================
 
 // FundSwapResult contains the result of funding a swap.
 type FundSwapResult struct {
-	TxID         string `json:"txid"`
-	Chain        string `json:"chain"`
-	Amount       uint64 `json:"amount"`
-	Fee          uint64 `json:"fee"`
-	EscrowVout   uint32 `json:"escrow_vout"`
-	EscrowAddr   string `json:"escrow_address"`
-	InputCount   int    `json:"input_count"`
-	TotalInput   uint64 `json:"total_input"`
-	Change       uint64 `json:"change"`
+	TxID       string      `json:"txid"`
+	Chain      string      `json:"chain"`
+	Amount     uint64      `json:"amount"`
+	Fee        uint64      `json:"fee"`
+	EscrowVout uint32      `json:"escrow_vout"`
+	EscrowAddr string      `json:"escrow_address"`
+	InputCount int         `json:"input_count"`
+	TotalInput uint64      `json:"total_input"`
+	Change     uint64      `json:"change"`
+	Dust       *DustReport `json:"dust,omitempty"` // Set when outputs were folded into the fee
 }
 
 // FundSwap automatically funds the swap escrow address by:
@@ -240,10 +335,20 @@ type FundSwapResult struct {
 // 2. Building and signing a funding transaction
 // 3. Broadcasting to the network
 // 4. Setting the funding info on the swap
-func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapResult, error) {
+//
+// Under the manual confirmation policy it stops after step 2 and returns a
+// ConfirmationRequiredError; ConfirmStep broadcasts the held transaction.
+func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (_ *FundSwapResult, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.fund")
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.cold {
+		return nil, ErrColdMode
+	}
 	active, ok := c.swaps[tradeID]
 	if !ok {
 		return nil, ErrSwapNotFound
@@ -252,7 +357,44 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 	if active.Swap.LocalFundingTxID != "" {
 		return nil, ErrAlreadyFunded
 	}
+	if active.Swap.ExternalFunding {
+		return nil, ErrExternalFunding
+	}
+	if active.Swap.SettlesOverLightning() {
+		return nil, ErrLightningSettlement
+	}
+	if active.IsXMR() {
+		return nil, ErrAdaptorSwap
+	}
+
+	built, err := c.buildFundingUnlocked(ctx, active)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fund-moving steps may wait for the user's confirmation
+	if c.needsConfirmationUnlocked(built.chain, built.amount) {
+		return nil, c.holdFundingUnlocked(tradeID, built)
+	}
+	return c.broadcastFundingUnlocked(ctx, tradeID, active, built)
+}
+
+// fundingBuild is a signed funding transaction not broadcast yet.
+type fundingBuild struct {
+	chain      string
+	amount     uint64 // Escrow
+	daoFee     uint64
+	escrowAddr string
+	utxos      []*wallet.AddressUTXO
+	changeAddr string
+	tx         *wallet.MultiAddressTxResult
+	escrowVout uint32
+	dust       DustReport
+}
 
+// buildFundingUnlocked builds and signs the funding transaction of our leg.
+// Caller must hold c.mu.
+func (c *Coordinator) buildFundingUnlocked(ctx context.Context, active *ActiveSwap) (*fundingBuild, error) {
 	if c.walletService == nil {
 		return nil, errors.New("wallet service not available")
 	}
@@ -292,6 +434,21 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 			return nil, errors.New("HTLC address not set - exchange secret hash first")
 		}
 		escrowAddr = chainData.HTLCAddress
+	} else if active.IsXMR() {
+		// Only the BTC party funds, to the lock output
+		chainSymbol = active.XMR.BTCChain
+		amount = active.Swap.Offer.OfferAmount
+		if active.Swap.Role == RoleResponder {
+			amount = active.Swap.Offer.RequestAmount
+		}
+		if active.XMR.Role != XMRRoleBTC || active.XMR.Scripts == nil {
+			return nil, errors.New("lock address not set - exchange key shares first")
+		}
+		lockAddr, err := active.XMR.Scripts.LockAddress(chainSymbol, c.network)
+		if err != nil {
+			return nil, err
+		}
+		escrowAddr = lockAddr
 	} else {
 		return nil, errors.New("unknown swap method")
 	}
@@ -302,9 +459,10 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 		return nil, fmt.Errorf("%w: %s", ErrNoBackend, chainSymbol)
 	}
 
-	// Calculate DAO fee
+	// Calculate DAO fee per the negotiated fee terms
 	isMaker := active.Swap.Role == RoleInitiator
-	daoFee := CalculateDAOFee(amount, isMaker)
+	daoFee := active.Swap.Offer.FeeTerms.DAOFee(amount, isMaker)
+	amount = escrowAmountFor(active, isMaker)
 
 	// Get DAO address from config
 	exchangeCfg := config.NewExchangeConfig(config.NetworkType(c.network))
@@ -313,20 +471,7 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 	// Total amount needed: escrow + DAO fee
 	totalNeeded := amount + daoFee
 
-	// Get fee rate with minimum floor to ensure relay
-	const minFeeRate = uint64(2) // Minimum 2 sat/vB to ensure relay
-	feeRate := uint64(10) // Default
-	if feeEstimate, err := b.GetFeeEstimates(ctx); err == nil && feeEstimate != nil {
-		if feeEstimate.HalfHourFee > 0 {
-			feeRate = feeEstimate.HalfHourFee
-		} else if feeEstimate.HourFee > 0 {
-			feeRate = feeEstimate.HourFee
-		}
-	}
-	// Ensure minimum fee rate
-	if feeRate < minFeeRate {
-		feeRate = minFeeRate
-	}
+	feeRate := fundingFeeRate(ctx, b)
 
 	// Scan wallet UTXOs
 	utxos, err := c.walletService.ListAllUTXOs(ctx, chainSymbol, c.store)
@@ -345,6 +490,7 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 
 	// Build and sign the funding transaction
 	// We need to create outputs: 1) escrow, 2) DAO fee (if > 0), 3) change
+	var dustReport DustReport
 	txResult, escrowVout, err := c.buildAndSignFundingTx(ctx, &fundingBuildParams{
 		symbol:      chainSymbol,
 		utxos:       utxos,
@@ -355,20 +501,114 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 		changeAddr:  changeAddr,
 		feeRate:     feeRate,
 		totalNeeded: totalNeeded,
+		dust:        DustPolicy{Report: &dustReport},
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to build funding tx: %w", err)
 	}
 
+	return &fundingBuild{
+		chain: chainSymbol, amount: amount, daoFee: daoFee, escrowAddr: escrowAddr,
+		utxos: utxos, changeAddr: changeAddr, tx: txResult, escrowVout: escrowVout, dust: dustReport,
+	}, nil
+}
+
+// decodeFundingTx describes a funding transaction decided earlier for the
+// same swap leg: its escrow output, the inputs it spends and its change to
+// built's change address. The input total and fee are known only when all of
+// its inputs are among built's UTXOs.
+func (c *Coordinator) decodeFundingTx(txHex string, built *fundingBuild) (*wallet.MultiAddressTxResult, uint32, error) {
+	tx, err := DeserializeTx(txHex)
+	if err != nil {
+		return nil, 0, err
+	}
+	params, ok := chain.Get(built.chain, c.network)
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown chain: %s", built.chain)
+	}
+	escrowScript, err := wallet.ParseAddressToScript(built.escrowAddr, params)
+	if err != nil {
+		return nil, 0, err
+	}
+	changeScript, _ := wallet.ParseAddressToScript(built.changeAddr, params)
+
+	result := &wallet.MultiAddressTxResult{
+		TxHex:       txHex,
+		TxID:        tx.TxHash().String(),
+		InputCount:  len(tx.TxIn),
+		OutputCount: len(tx.TxOut),
+	}
+	escrowVout := -1
+	for i, out := range tx.TxOut {
+		result.TotalOutput += uint64(out.Value)
+		switch {
+		case escrowVout < 0 && bytes.Equal(out.PkScript, escrowScript):
+			escrowVout = i
+		case changeScript != nil && bytes.Equal(out.PkScript, changeScript):
+			result.Change += uint64(out.Value)
+		}
+	}
+	if escrowVout < 0 {
+		return nil, 0, fmt.Errorf("no output pays escrow %s", built.escrowAddr)
+	}
+
+	amounts := make(map[string]uint64, len(built.utxos))
+	for _, u := range built.utxos {
+		amounts[fmt.Sprintf("%s:%d", u.TxID, u.Vout)] = u.Amount
+	}
+	known := true
+	for _, in := range tx.TxIn {
+		outpoint := in.PreviousOutPoint.String()
+		result.UsedUTXOs = append(result.UsedUTXOs, outpoint)
+		amount, ok := amounts[outpoint]
+		known = known && ok
+		result.TotalInput += amount
+	}
+	if known && result.TotalInput >= result.TotalOutput {
+		result.Fee = result.TotalInput - result.TotalOutput
+	} else {
+		result.TotalInput = 0
+	}
+	return result, uint32(escrowVout), nil
+}
+
+// broadcastFundingUnlocked broadcasts a built funding transaction and
+// records it as our funding. Caller must hold c.mu.
+func (c *Coordinator) broadcastFundingUnlocked(ctx context.Context, tradeID string, active *ActiveSwap, built *fundingBuild) (*FundSwapResult, error) {
+	b, ok := c.backends[built.chain]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoBackend, built.chain)
+	}
+
 	// Broadcast the transaction
-	txid, err := b.BroadcastTransaction(ctx, txResult.TxHex)
+	sent, err := c.broadcastJob(ctx, b, JobActionFund, tradeID, built.chain, built.tx.TxHex)
 	if err != nil {
 		return nil, fmt.Errorf("failed to broadcast funding tx: %w", err)
 	}
+	return c.recordFundingUnlocked(tradeID, active, built, sent)
+}
+
+// recordFundingUnlocked records a broadcast funding transaction as our
+// funding. Caller must hold c.mu.
+func (c *Coordinator) recordFundingUnlocked(tradeID string, active *ActiveSwap, built *fundingBuild, sent *JobTx) (*FundSwapResult, error) {
+	chainSymbol, amount, escrowVout, escrowAddr := built.chain, built.amount, built.escrowVout, built.escrowAddr
+	txResult, dustReport := built.tx, built.dust
+	txid := sent.TxID
+	var err error
+	if sent.Earlier {
+		// An earlier funding decision went out instead of ours: record its
+		// escrow output, inputs and change
+		txResult, escrowVout, err = c.decodeFundingTx(sent.TxHex, built)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode broadcast funding tx %s: %w", txid, err)
+		}
+		dustReport = DustReport{}
+	}
 
 	// Set funding info on the swap
 	active.Swap.LocalFundingTxID = txid
 	active.Swap.LocalFundingVout = escrowVout
+	c.recordFundingKeyUsageUnlocked(tradeID, chainSymbol, built.utxos, txResult.UsedUTXOs, built.changeAddr, txResult.Change)
 
 	// Transition to funding state
 	if active.Swap.State == StateInit {
@@ -393,6 +633,10 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 		TotalInput: txResult.TotalInput,
 		Change:     txResult.Change,
 	}
+	if len(dustReport.Folded) > 0 {
+		result.Dust = &dustReport
+		c.log.Info("Folded dust outputs into funding fee", "trade_id", tradeID, "chain", chainSymbol, "fee_added", dustReport.FeeAdded)
+	}
 
 	c.emitEvent(tradeID, "funding_broadcast", map[string]interface{}{
 		"txid":        txid,
@@ -404,6 +648,25 @@ func (c *Coordinator) FundSwap(ctx context.Context, tradeID string) (*FundSwapRe
 	return result, nil
 }
 
+// fundingFeeRate returns the fee rate (sat/vB) funding transactions pay.
+func fundingFeeRate(ctx context.Context, b backend.Backend) uint64 {
+	// Get fee rate with minimum floor to ensure relay
+	const minFeeRate = uint64(2) // Minimum 2 sat/vB to ensure relay
+	feeRate := uint64(10) // Default
+	if feeEstimate, err := b.GetFeeEstimates(ctx); err == nil && feeEstimate != nil {
+		if feeEstimate.HalfHourFee > 0 {
+			feeRate = feeEstimate.HalfHourFee
+		} else if feeEstimate.HourFee > 0 {
+			feeRate = feeEstimate.HourFee
+		}
+	}
+	// Ensure minimum fee rate
+	if feeRate < minFeeRate {
+		feeRate = minFeeRate
+	}
+	return feeRate
+}
+
 // fundingBuildParams holds parameters for building a funding transaction.
 type fundingBuildParams struct {
 	symbol      string
@@ -415,11 +678,15 @@ type fundingBuildParams struct {
 	changeAddr  string
 	feeRate     uint64
 	totalNeeded uint64
+	dust        DustPolicy
 }
 
 // buildAndSignFundingTx builds and signs a funding transaction with escrow and DAO outputs.
 // Output order: escrow (vout 0), DAO fee (vout 1 if present), change (last vout)
-func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *fundingBuildParams) (*wallet.MultiAddressTxResult, uint32, error) {
+func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *fundingBuildParams) (_ *wallet.MultiAddressTxResult, _ uint32, err error) {
+	ctx, span := tracing.Start(ctx, "swap", "swap.build_funding_tx", trace.WithAttributes(tracing.AttrChain.String(params.symbol)))
+	defer func() { tracing.End(span, err) }()
+
 	// Get chain params for script generation
 	chainParams, ok := chain.Get(params.symbol, c.network)
 	if !ok {
@@ -447,6 +714,11 @@ func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *funding
 		tx.AddTxIn(txIn)
 	}
 
+	dust := newDustCheck(params.symbol, params.feeRate, params.dust)
+	if err := dust.payment("escrow output", params.escrowAmt); err != nil {
+		return nil, 0, err
+	}
+
 	// Parse escrow address script
 	escrowScript, err := wallet.ParseAddressToScript(params.escrowAddr, chainParams)
 	if err != nil {
@@ -458,8 +730,14 @@ func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *funding
 	outputCount := 1
 	hasDAOOutput := false
 
-	// Add DAO fee output (vout 1) if present
-	if params.daoFee > 0 && params.daoAddr != "" {
+	// Add DAO fee output (vout 1) if present and not dust
+	keepDAO := false
+	if params.daoAddr != "" {
+		if keepDAO, err = dust.keep(DustOutputDAOFee, params.daoFee); err != nil {
+			return nil, 0, err
+		}
+	}
+	if keepDAO {
 		daoScript, err := wallet.ParseAddressToScript(params.daoAddr, chainParams)
 		if err != nil {
 			c.log.Warn("Invalid DAO address, skipping DAO output", "address", params.daoAddr, "error", err)
@@ -475,15 +753,23 @@ func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *funding
 	estimatedVSize := estimateFundingVSize(selectedUTXOs, outputCount+1) // +1 for potential change
 	fee := uint64(estimatedVSize) * params.feeRate
 
+	// A folded DAO fee goes to the miners, not back to change
+	if !keepDAO && params.daoAddr != "" {
+		fee += params.daoFee
+	}
+
 	// Calculate change
 	totalOutput := params.escrowAmt
 	if hasDAOOutput {
 		totalOutput += params.daoFee
 	}
 	change := totalInput - totalOutput - fee
-	dustThreshold := uint64(546)
 
-	if change > dustThreshold {
+	keepChange, err := dust.keep(DustOutputChange, change)
+	if err != nil {
+		return nil, 0, err
+	}
+	if keepChange {
 		changeScript, err := wallet.ParseAddressToScript(params.changeAddr, chainParams)
 		if err != nil {
 			return nil, 0, fmt.Errorf("invalid change address: %w", err)
@@ -496,34 +782,8 @@ func (c *Coordinator) buildAndSignFundingTx(ctx context.Context, params *funding
 		change = 0
 	}
 
-	// Build prevout fetcher for signing
-	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
-	for i, utxo := range selectedUTXOs {
-		script, err := wallet.ParseAddressToScript(utxo.Address, chainParams)
-		if err != nil {
-			return nil, 0, fmt.Errorf("invalid UTXO address %s: %w", utxo.Address, err)
-		}
-		prevOuts[tx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(int64(utxo.Amount), script)
-	}
-	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
-
-	// Sign each input
-	for i, utxo := range selectedUTXOs {
-		// Derive private key for this UTXO
-		privKey, err := c.walletService.DerivePrivateKeyWithChange(
-			params.symbol,
-			utxo.Account,
-			utxo.Change,
-			utxo.AddressIndex,
-		)
-		if err != nil {
-			return nil, 0, fmt.Errorf("failed to derive key for input %d: %w", i, err)
-		}
-
-		// Sign based on address type
-		if err := signFundingInput(tx, i, privKey, prevOutFetcher, utxo, chainParams); err != nil {
-			return nil, 0, fmt.Errorf("failed to sign input %d: %w", i, err)
-		}
+	if err := c.signWalletInputs(ctx, tx, params.symbol, selectedUTXOs); err != nil {
+		return nil, 0, err
 	}
 
 	// Serialize transaction
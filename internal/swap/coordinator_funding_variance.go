@@ -0,0 +1,387 @@
+// Package swap - Funding variance handling.
+//
+// A counterparty may fund its escrow with slightly more or less than the
+// negotiated amount (a fee shaved off the output, rounding in an external
+// wallet). The spending transactions commit to the escrow value, so before
+// this check any mismatch wedged the swap at signing or claim time. Once the
+// counterparty's funding is visible its escrow output is compared with the
+// negotiated amount:
+//
+//   - over-funding, or a shortfall within ToleranceBps of the leg, still
+//     satisfies the agreed rate: the swap proceeds and signs and claims
+//     against the actual output value
+//   - a shortfall within TopUpBps asks the counterparty to re-fund the
+//     escrow in full and holds the swap in Funding for up to TopUpTimeout
+//   - a larger shortfall, or a top-up that does not arrive in time, aborts:
+//     a swap we have not funded fails, a funded one waits for its refund
+package swap
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// Funding variance events, emitted once per decision.
+const (
+	EventFundingVarianceAccepted = "funding_variance_accepted" // Proceeding on the actual escrow value
+	EventFundingVarianceTopUp    = "funding_variance_top_up"   // Counterparty asked to re-fund in full
+	EventFundingVarianceAborted  = "funding_variance_aborted"  // Shortfall too large, swap aborted
+)
+
+// FundingVariancePolicy is the node-wide funding variance configuration.
+type FundingVariancePolicy struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled"`
+
+	// ToleranceBps is the largest shortfall, in basis points of the
+	// counterparty's escrow amount, accepted without a top-up.
+	ToleranceBps uint32 `yaml:"tolerance_bps,omitempty" json:"tolerance_bps,omitempty"`
+
+	// TopUpBps is the largest shortfall for which a top-up is requested;
+	// larger shortfalls abort. 0 never requests a top-up.
+	TopUpBps uint32 `yaml:"top_up_bps,omitempty" json:"top_up_bps,omitempty"`
+
+	// TopUpTimeout is how long the counterparty has to re-fund in full.
+	TopUpTimeout time.Duration `yaml:"top_up_timeout,omitempty" json:"top_up_timeout,omitempty"`
+}
+
+// Validate checks the thresholds and the top-up timeout.
+func (p *FundingVariancePolicy) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.ToleranceBps > 10000 || p.TopUpBps > 10000 {
+		return fmt.Errorf("funding_variance thresholds must be at most 10000 bps")
+	}
+	if p.TopUpBps != 0 && p.TopUpBps < p.ToleranceBps {
+		return fmt.Errorf("funding_variance.top_up_bps must not be below tolerance_bps")
+	}
+	if p.TopUpBps > p.ToleranceBps && p.TopUpTimeout <= 0 {
+		return fmt.Errorf("funding_variance.top_up_timeout must be positive")
+	}
+	return nil
+}
+
+// FundingVarianceStatus describes the funding variance state of a swap.
+type FundingVarianceStatus struct {
+	TradeID       string `json:"trade_id"`
+	Decision      string `json:"decision,omitempty"` // Last funding_variance_* event
+	Reason        string `json:"reason,omitempty"`
+	TxID          string `json:"txid,omitempty"`     // Counterparty funding checked
+	Expected      uint64 `json:"expected,omitempty"` // Negotiated escrow amount
+	Actual        uint64 `json:"actual,omitempty"`   // Escrow output value
+	ShortfallBps  uint32 `json:"shortfall_bps,omitempty"`
+	TopUpDeadline int64  `json:"top_up_deadline,omitempty"`
+}
+
+// fundingVarianceState is the in-memory variance state of a swap.
+// The check is deterministic for a funding transaction, so it is simply
+// redone after a restart; only the top-up deadline is stored, so a restart
+// does not grant a new one.
+type fundingVarianceState struct {
+	localTxID     string // Our funding whose escrow value was recorded
+	txid          string // Counterparty funding last checked
+	checked       bool
+	decision      string
+	reason        string
+	expected      uint64
+	actual        uint64
+	shortfallBps  uint32
+	topUpDeadline time.Time
+}
+
+// OfferFundingAmount returns the value of the offer chain escrow output.
+func (s *Swap) OfferFundingAmount() uint64 {
+	if s.OfferFundedAmount > 0 {
+		return s.OfferFundedAmount
+	}
+	return s.Offer.OfferEscrowAmount()
+}
+
+// RequestFundingAmount returns the value of the request chain escrow output.
+func (s *Swap) RequestFundingAmount() uint64 {
+	if s.RequestFundedAmount > 0 {
+		return s.RequestFundedAmount
+	}
+	return s.Offer.RequestEscrowAmount()
+}
+
+// remoteFundingAmount returns the value of the counterparty's escrow output.
+func remoteFundingAmount(s *Swap) uint64 {
+	if s.Role == RoleInitiator {
+		return s.RequestFundingAmount()
+	}
+	return s.OfferFundingAmount()
+}
+
+// SetFundingVariancePolicy sets the node-wide funding variance policy.
+func (c *Coordinator) SetFundingVariancePolicy(policy FundingVariancePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.fundingVariance = policy
+	return nil
+}
+
+// GetFundingVarianceStatus returns the funding variance state of a swap.
+func (c *Coordinator) GetFundingVarianceStatus(tradeID string) (*FundingVarianceStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.swaps[tradeID]; !ok {
+		return nil, ErrSwapNotFound
+	}
+	return c.fundingVarianceStatusLocked(tradeID), nil
+}
+
+// fundingVarianceStatusLocked returns the funding variance state of a swap.
+// Caller must hold c.mu.
+func (c *Coordinator) fundingVarianceStatusLocked(tradeID string) *FundingVarianceStatus {
+	status := &FundingVarianceStatus{TradeID: tradeID}
+	if st, ok := c.fundingVariances[tradeID]; ok && st.checked {
+		status.Decision = st.decision
+		status.Reason = st.reason
+		status.TxID = st.txid
+		status.Expected = st.expected
+		status.Actual = st.actual
+		status.ShortfallBps = st.shortfallBps
+		if !st.topUpDeadline.IsZero() {
+			status.TopUpDeadline = st.topUpDeadline.Unix()
+		}
+	}
+	return status
+}
+
+// fundingVarianceBlocksLocked reports whether a pending top-up or an abort
+// holds the swap in Funding. Caller must hold c.mu.
+func (c *Coordinator) fundingVarianceBlocksLocked(tradeID string) bool {
+	st, ok := c.fundingVariances[tradeID]
+	return ok && (st.decision == EventFundingVarianceTopUp || st.decision == EventFundingVarianceAborted)
+}
+
+// checkFundingVarianceLocked compares the escrow outputs of a funding swap
+// with the negotiated amounts, using the funding transactions the
+// confirmation update read. Caller must hold c.mu.
+func (c *Coordinator) checkFundingVarianceLocked(tradeID string, active *ActiveSwap, local, remote *fundingQuery) {
+	if !c.fundingVariance.Enabled || active.Swap.State != StateFunding {
+		return
+	}
+	if !active.IsMuSig2() && !active.IsHTLC() {
+		return
+	}
+	if c.fundingVariances == nil {
+		c.fundingVariances = make(map[string]*fundingVarianceState)
+	}
+	st, ok := c.fundingVariances[tradeID]
+	if !ok {
+		st = &fundingVarianceState{}
+		c.fundingVariances[tradeID] = st
+	}
+
+	c.recordLocalFundingLocked(tradeID, active, st, local)
+
+	txid := active.Swap.RemoteFundingTxID
+	if txid == "" || st.decision == EventFundingVarianceAborted {
+		return
+	}
+	if st.txid != txid {
+		// New funding from the counterparty (a top-up re-funds); judge it afresh
+		st.txid = txid
+		st.checked = false
+		c.recordFundedAmountLocked(tradeID, active, active.Swap.Role == RoleResponder, 0)
+	}
+	if st.checked {
+		if st.decision == EventFundingVarianceTopUp && time.Now().After(st.topUpDeadline) {
+			c.abortFundingVarianceLocked(tradeID, active, st, "top-up not received in time")
+		}
+		return
+	}
+
+	remoteChain, _ := remoteLeg(active.Swap)
+	offerLeg := active.Swap.Role == RoleResponder
+	value, ok := c.escrowOutputValueLocked(active, remoteChain, remote.fundingTx(txid), active.Swap.RemoteFundingVout, offerLeg)
+	if !ok {
+		return // Not visible yet, or not our escrow; retried on the next check
+	}
+
+	expected := escrowAmountFor(active, offerLeg)
+	st.checked = true
+	st.decision = ""
+	st.reason = ""
+	st.expected = expected
+	st.actual = value
+	st.shortfallBps = 0
+	if value == expected {
+		return
+	}
+
+	data := map[string]interface{}{
+		"txid":     txid,
+		"chain":    remoteChain,
+		"expected": expected,
+		"actual":   value,
+	}
+	if value > expected {
+		c.recordFundedAmountLocked(tradeID, active, offerLeg, value)
+		st.decision = EventFundingVarianceAccepted
+		st.reason = "escrow over-funded"
+		c.log.Info("Counterparty over-funded escrow", "trade_id", tradeID, "expected", expected, "actual", value)
+		c.emitEvent(tradeID, EventFundingVarianceAccepted, data)
+		return
+	}
+
+	st.shortfallBps = shortfallBps(expected-value, expected)
+	data["shortfall_bps"] = st.shortfallBps
+	switch {
+	case st.shortfallBps <= c.fundingVariance.ToleranceBps:
+		c.recordFundedAmountLocked(tradeID, active, offerLeg, value)
+		st.decision = EventFundingVarianceAccepted
+		st.reason = fmt.Sprintf("shortfall %d bps within tolerance", st.shortfallBps)
+		c.log.Info("Accepting short escrow within tolerance", "trade_id", tradeID, "expected", expected, "actual", value, "shortfall_bps", st.shortfallBps)
+		c.emitEvent(tradeID, EventFundingVarianceAccepted, data)
+
+	case st.shortfallBps <= c.fundingVariance.TopUpBps:
+		if st.topUpDeadline.IsZero() {
+			st.topUpDeadline = time.Now().Add(c.fundingVariance.TopUpTimeout)
+			if c.store != nil {
+				if err := c.store.SaveTopUpDeadline(tradeID, st.topUpDeadline); err != nil {
+					c.log.Warn("Failed to save top-up deadline", "trade_id", tradeID, "error", err)
+				}
+			}
+		} else if time.Now().After(st.topUpDeadline) {
+			c.abortFundingVarianceLocked(tradeID, active, st, "top-up not received in time")
+			return
+		}
+		st.decision = EventFundingVarianceTopUp
+		st.reason = fmt.Sprintf("shortfall %d bps needs a top-up", st.shortfallBps)
+		data["top_up_deadline"] = st.topUpDeadline.Unix()
+		c.log.Warn("Requesting escrow top-up", "trade_id", tradeID, "expected", expected, "actual", value, "shortfall_bps", st.shortfallBps)
+		c.emitEvent(tradeID, EventFundingVarianceTopUp, data)
+
+	default:
+		c.abortFundingVarianceLocked(tradeID, active, st, fmt.Sprintf("shortfall %d bps above limit", st.shortfallBps))
+	}
+}
+
+// restoreFundingVarianceUnlocked reloads the top-up deadline of a recovered
+// swap. Caller must hold c.mu.
+func (c *Coordinator) restoreFundingVarianceUnlocked(tradeID string) {
+	if _, ok := c.swaps[tradeID]; !ok || c.store == nil {
+		return
+	}
+	deadline, err := c.store.GetTopUpDeadline(tradeID)
+	if err != nil {
+		c.log.Warn("Failed to load top-up deadline", "trade_id", tradeID, "error", err)
+		return
+	}
+	if deadline.IsZero() {
+		return
+	}
+	if c.fundingVariances == nil {
+		c.fundingVariances = make(map[string]*fundingVarianceState)
+	}
+	c.fundingVariances[tradeID] = &fundingVarianceState{topUpDeadline: deadline}
+}
+
+// abortFundingVarianceLocked aborts a swap over its counterparty's funding.
+// Without funds of ours at stake the swap fails; otherwise it stays in
+// Funding, is never signed, and refunds at timeout. Caller must hold c.mu.
+func (c *Coordinator) abortFundingVarianceLocked(tradeID string, active *ActiveSwap, st *fundingVarianceState, reason string) {
+	st.decision = EventFundingVarianceAborted
+	st.reason = reason
+
+	localFunded := active.Swap.LocalFundingTxID != ""
+	if !localFunded {
+		if err := active.Swap.TransitionTo(StateFailed); err != nil {
+			c.log.Warn("Failed to fail swap", "trade_id", tradeID, "error", err)
+		} else if err := c.saveSwapState(tradeID); err != nil {
+			c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+		}
+	}
+	c.log.Warn("Aborting swap over counterparty funding", "trade_id", tradeID, "reason", reason, "local_funded", localFunded)
+	c.emitEvent(tradeID, EventFundingVarianceAborted, map[string]interface{}{
+		"reason":       reason,
+		"txid":         st.txid,
+		"expected":     st.expected,
+		"actual":       st.actual,
+		"local_funded": localFunded,
+	})
+}
+
+// recordLocalFundingLocked records the value of our own escrow output when it
+// differs from the negotiated amount, so both parties sign against what is on
+// chain. Caller must hold c.mu.
+func (c *Coordinator) recordLocalFundingLocked(tradeID string, active *ActiveSwap, st *fundingVarianceState, local *fundingQuery) {
+	txid := active.Swap.LocalFundingTxID
+	if txid == "" || st.localTxID == txid {
+		return
+	}
+	offerLeg := active.Swap.Role == RoleInitiator
+	localChain := active.Swap.Offer.OfferChain
+	if !offerLeg {
+		localChain = active.Swap.Offer.RequestChain
+	}
+	value, ok := c.escrowOutputValueLocked(active, localChain, local.fundingTx(txid), active.Swap.LocalFundingVout, offerLeg)
+	if !ok {
+		return
+	}
+	st.localTxID = txid
+	if expected := escrowAmountFor(active, offerLeg); value != expected {
+		c.recordFundedAmountLocked(tradeID, active, offerLeg, value)
+		c.log.Warn("Our escrow differs from the negotiated amount", "trade_id", tradeID, "expected", expected, "actual", value)
+	}
+}
+
+// escrowOutputValueLocked returns the value of a funding output paying the
+// escrow of a leg; tx is nil when it wasn't read. Caller must hold c.mu.
+func (c *Coordinator) escrowOutputValueLocked(active *ActiveSwap, chainSymbol string, tx *backend.Transaction, vout uint32, offerLeg bool) (uint64, bool) {
+	if params, ok := chain.Get(chainSymbol, c.network); !ok || params.Type == chain.ChainTypeEVM {
+		return 0, false
+	}
+	if tx == nil {
+		return 0, false
+	}
+	return escrowOutputValue(active, tx, vout, offerLeg)
+}
+
+// escrowOutputValue returns the value of output vout if it pays the escrow address of a leg.
+func escrowOutputValue(active *ActiveSwap, tx *backend.Transaction, vout uint32, offerLeg bool) (uint64, bool) {
+	offerAddr, requestAddr := escrowAddressesUnlocked(active)
+	escrowAddr := requestAddr
+	if offerLeg {
+		escrowAddr = offerAddr
+	}
+	if escrowAddr == "" || int(vout) >= len(tx.Outputs) || tx.Outputs[vout].ScriptPubKeyAddr != escrowAddr {
+		return 0, false
+	}
+	return tx.Outputs[vout].Value, true
+}
+
+// recordFundedAmountLocked records the escrow output value of a leg
+// (0: as negotiated) and saves the swap. Caller must hold c.mu.
+func (c *Coordinator) recordFundedAmountLocked(tradeID string, active *ActiveSwap, offerLeg bool, value uint64) {
+	field := &active.Swap.RequestFundedAmount
+	if offerLeg {
+		field = &active.Swap.OfferFundedAmount
+	}
+	if *field == value {
+		return
+	}
+	*field = value
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+}
+
+// shortfallBps returns shortfall as basis points of expected, rounded up.
+func shortfallBps(shortfall, expected uint64) uint32 {
+	if expected == 0 || shortfall >= expected || shortfall > (math.MaxUint64-expected)/10000 {
+		return 10000
+	}
+	return uint32((shortfall*10000 + expected - 1) / expected)
+}
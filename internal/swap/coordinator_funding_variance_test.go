@@ -0,0 +1,268 @@
+package swap
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+const (
+	fvOfferEscrow   = "bc1qofferescrow"
+	fvRequestEscrow = "ltc1qrequestescrow"
+)
+
+func fundingTx(txID, escrow string, value uint64) *backend.Transaction {
+	return &backend.Transaction{
+		TxID:          txID,
+		Confirmations: 10,
+		Outputs:       []backend.TxOutput{{ScriptPubKeyAddr: escrow, Value: value}},
+	}
+}
+
+// varianceBackends returns BTC and LTC chains holding both funding
+// transactions, confirmed; the taker's LTC escrow output holds remoteValue
+// (1000000 negotiated).
+func varianceBackends(remoteValue uint64) (btc, ltc *fakeChainBackend) {
+	btc, ltc = newFakeChainBackend(), newFakeChainBackend()
+	btc.txs["local-funding"] = fundingTx("local-funding", fvOfferEscrow, 100000)
+	ltc.txs["remote-funding"] = fundingTx("remote-funding", fvRequestEscrow, remoteValue)
+	return btc, ltc
+}
+
+// addVarianceTrade adds trade t1, in which we are the maker of a BTC/LTC
+// HTLC swap with both legs funded, and enables the funding variance policy.
+func addVarianceTrade(t *testing.T, coord *Coordinator) {
+	t.Helper()
+	s := newTestHTLCSwap(t, chain.Mainnet, RoleInitiator)
+	s.State = StateFunding
+	s.LocalFundingTxID = "local-funding"
+	s.RemoteFundingTxID = "remote-funding"
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap:  s,
+		Trade: &storage.Trade{ID: "t1", OurRole: storage.TradeRoleMaker},
+		HTLC: &HTLCSwapData{
+			OfferChain:   &ChainHTLCData{HTLCAddress: fvOfferEscrow},
+			RequestChain: &ChainHTLCData{HTLCAddress: fvRequestEscrow},
+		},
+	}
+
+	if err := coord.SetFundingVariancePolicy(FundingVariancePolicy{
+		Enabled:      true,
+		ToleranceBps: 50,
+		TopUpBps:     500,
+		TopUpTimeout: 20 * time.Millisecond,
+	}); err != nil {
+		t.Fatalf("SetFundingVariancePolicy() error = %v", err)
+	}
+}
+
+func varianceStatus(t *testing.T, coord *Coordinator) *FundingVarianceStatus {
+	t.Helper()
+	status, err := coord.GetFundingVarianceStatus("t1")
+	if err != nil {
+		t.Fatalf("GetFundingVarianceStatus() error = %v", err)
+	}
+	return status
+}
+
+func TestFundingVariancePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  FundingVariancePolicy
+		wantErr bool
+	}{
+		{"disabled", FundingVariancePolicy{TopUpBps: 20000}, false},
+		{"tolerance only", FundingVariancePolicy{Enabled: true, ToleranceBps: 50}, false},
+		{"top-up band", FundingVariancePolicy{Enabled: true, ToleranceBps: 50, TopUpBps: 500, TopUpTimeout: time.Hour}, false},
+		{"above 100%", FundingVariancePolicy{Enabled: true, ToleranceBps: 10001}, true},
+		{"top-up below tolerance", FundingVariancePolicy{Enabled: true, ToleranceBps: 50, TopUpBps: 10, TopUpTimeout: time.Hour}, true},
+		{"no top-up timeout", FundingVariancePolicy{Enabled: true, ToleranceBps: 50, TopUpBps: 500}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestFundingVarianceDecisions(t *testing.T) {
+	tests := []struct {
+		name       string
+		value      uint64
+		decision   string
+		state      State
+		fundingAmt uint64
+	}{
+		{"exact", 1000000, "", StateFunded, 1000000},
+		{"over-funded", 1000100, EventFundingVarianceAccepted, StateFunded, 1000100},
+		{"within tolerance", 996000, EventFundingVarianceAccepted, StateFunded, 996000},
+		{"top-up", 980000, EventFundingVarianceTopUp, StateFunding, 1000000},
+		{"too short", 900000, EventFundingVarianceAborted, StateFunding, 1000000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			btc, ltc := varianceBackends(tt.value)
+			coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+			addVarianceTrade(t, coord)
+
+			if err := coord.UpdateConfirmations(context.Background(), "t1"); err != nil {
+				t.Fatalf("UpdateConfirmations() error = %v", err)
+			}
+			st := varianceStatus(t, coord)
+			if st.Decision != tt.decision {
+				t.Errorf("decision = %q (%s), want %q", st.Decision, st.Reason, tt.decision)
+			}
+			s := coord.swaps["t1"].Swap
+			if s.State != tt.state {
+				t.Errorf("state = %s, want %s", s.State, tt.state)
+			}
+			if got := s.RequestFundingAmount(); got != tt.fundingAmt {
+				t.Errorf("RequestFundingAmount() = %d, want %d", got, tt.fundingAmt)
+			}
+		})
+	}
+}
+
+func TestFundingVarianceTopUp(t *testing.T) {
+	btc, ltc := varianceBackends(980000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	ctx := context.Background()
+
+	coord.UpdateConfirmations(ctx, "t1")
+	st := varianceStatus(t, coord)
+	if st.Decision != EventFundingVarianceTopUp || st.ShortfallBps != 200 || st.TopUpDeadline == 0 {
+		t.Fatalf("status = %+v, want top-up of 200 bps", st)
+	}
+
+	// The counterparty re-funds the escrow in full
+	ltc.mu.Lock()
+	ltc.txs["refunded"] = fundingTx("refunded", fvRequestEscrow, 1000000)
+	ltc.mu.Unlock()
+	if err := coord.SetFundingTx("t1", "refunded", 0, false); err != nil {
+		t.Fatalf("SetFundingTx() error = %v", err)
+	}
+	coord.UpdateConfirmations(ctx, "t1")
+
+	if st := varianceStatus(t, coord); st.Decision != "" || st.TxID != "refunded" {
+		t.Errorf("status after top-up = %+v, want clean", st)
+	}
+	if s := coord.swaps["t1"].Swap; s.State != StateFunded {
+		t.Errorf("state = %s, want funded", s.State)
+	}
+}
+
+func TestFundingVarianceTopUpTimeout(t *testing.T) {
+	btc, ltc := varianceBackends(980000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	ctx := context.Background()
+
+	coord.UpdateConfirmations(ctx, "t1")
+	time.Sleep(30 * time.Millisecond)
+	coord.UpdateConfirmations(ctx, "t1")
+
+	if st := varianceStatus(t, coord); st.Decision != EventFundingVarianceAborted {
+		t.Errorf("status = %+v, want aborted", st)
+	}
+	// Our funds are locked: no failure, the swap waits for its refund
+	if s := coord.swaps["t1"].Swap; s.State != StateFunding {
+		t.Errorf("state = %s, want funding", s.State)
+	}
+}
+
+func TestFundingVarianceTopUpDeadlineRestored(t *testing.T) {
+	store := newTestStore(t)
+	btc, ltc := varianceBackends(980000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	policy := coord.fundingVariance
+	policy.TopUpTimeout = time.Hour
+	coord.SetFundingVariancePolicy(policy)
+	ctx := context.Background()
+
+	coord.UpdateConfirmations(ctx, "t1")
+	deadline := varianceStatus(t, coord).TopUpDeadline
+	if deadline == 0 {
+		t.Fatal("no top-up requested")
+	}
+	coord.mu.Lock()
+	coord.swaps["t1"].Swap.SecretHash = make([]byte, 32) // Recovered as an HTLC swap
+	if err := coord.saveSwapState("t1"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	coord.mu.Unlock()
+
+	// A restarted node keeps the deadline rather than granting a new one
+	restarted := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("BTC", btc), withBackend("LTC", ltc))
+	policy.TopUpTimeout = 2 * time.Hour
+	restarted.SetFundingVariancePolicy(policy)
+	if err := restarted.LoadPendingSwaps(ctx); err != nil {
+		t.Fatalf("LoadPendingSwaps() error = %v", err)
+	}
+	restarted.UpdateConfirmations(ctx, "t1")
+	if st := varianceStatus(t, restarted); st.Decision != EventFundingVarianceTopUp || st.TopUpDeadline != deadline {
+		t.Errorf("status after restart = %+v, want a top-up due at %d", st, deadline)
+	}
+}
+
+func TestFundingVarianceAbortUnfunded(t *testing.T) {
+	btc, ltc := varianceBackends(900000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	coord.swaps["t1"].Swap.LocalFundingTxID = ""
+
+	coord.UpdateConfirmations(context.Background(), "t1")
+	if s := coord.swaps["t1"].Swap; s.State != StateFailed {
+		t.Errorf("state = %s, want failed", s.State)
+	}
+}
+
+func TestFundingVarianceLocalEscrow(t *testing.T) {
+	btc, ltc := varianceBackends(1000000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	btc.txs["local-funding"].Outputs[0].Value = 100500
+
+	coord.UpdateConfirmations(context.Background(), "t1")
+	if got := coord.swaps["t1"].Swap.OfferFundingAmount(); got != 100500 {
+		t.Errorf("OfferFundingAmount() = %d, want the on-chain 100500", got)
+	}
+}
+
+func TestFundingVarianceDisabled(t *testing.T) {
+	btc, ltc := varianceBackends(900000)
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addVarianceTrade(t, coord)
+	coord.SetFundingVariancePolicy(FundingVariancePolicy{})
+
+	coord.UpdateConfirmations(context.Background(), "t1")
+	if st := varianceStatus(t, coord); st.Decision != "" {
+		t.Errorf("status = %+v, want no decision", st)
+	}
+	if s := coord.swaps["t1"].Swap; s.State != StateFunded {
+		t.Errorf("state = %s, want funded", s.State)
+	}
+}
+
+func TestShortfallBps(t *testing.T) {
+	tests := []struct {
+		shortfall, expected uint64
+		want                uint32
+	}{
+		{0, 1000000, 0},
+		{1, 1000000, 1}, // Rounded up
+		{4000, 1000000, 40},
+		{1000000, 1000000, 10000},
+		{1, 0, 10000},
+	}
+	for _, tt := range tests {
+		if got := shortfallBps(tt.shortfall, tt.expected); got != tt.want {
+			t.Errorf("shortfallBps(%d, %d) = %d, want %d", tt.shortfall, tt.expected, got, tt.want)
+		}
+	}
+}
@@ -17,9 +17,24 @@ import (
 // Chain Data Helpers
 // =============================================================================
 
+// musig2Sessions returns the swap's MuSig2 data if both chain sessions exist.
+// Remote messages can target HTLC or EVM swaps, or MuSig2 swaps recovered
+// from storage without their ephemeral key, so check before use.
+func musig2Sessions(active *ActiveSwap) (*MuSig2SwapData, error) {
+	m := active.MuSig2
+	if m == nil || m.OfferChain == nil || m.RequestChain == nil ||
+		m.OfferChain.Session == nil || m.RequestChain.Session == nil {
+		return nil, ErrNoMuSig2Session
+	}
+	return m, nil
+}
+
 // getChainData returns MuSig2 chain data for the given chain symbol.
 // NOTE: Caller must hold c.mu lock.
 func (c *Coordinator) getChainData(active *ActiveSwap, chainSymbol string) *ChainMuSig2Data {
+	if active.MuSig2 == nil {
+		return nil
+	}
 	if chainSymbol == active.Swap.Offer.OfferChain {
 		return active.MuSig2.OfferChain
 	}
@@ -32,6 +47,9 @@ func (c *Coordinator) getChainData(active *ActiveSwap, chainSymbol string) *Chai
 // getChainDataRLocked returns MuSig2 chain data for the given chain symbol.
 // NOTE: Caller must hold c.mu read lock.
 func (c *Coordinator) getChainDataRLocked(active *ActiveSwap, chainSymbol string) *ChainMuSig2Data {
+	if active.MuSig2 == nil {
+		return nil
+	}
 	if chainSymbol == active.Swap.Offer.OfferChain {
 		return active.MuSig2.OfferChain
 	}
@@ -58,6 +76,22 @@ func (c *Coordinator) GetSwap(tradeID string) (*ActiveSwap, error) {
 	return active, nil
 }
 
+// HasActiveSwapOn reports whether a swap that hasn't finished uses the chain.
+func (c *Coordinator) HasActiveSwapOn(symbol string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	for _, active := range c.swaps {
+		if active.Swap.IsTerminal() {
+			continue
+		}
+		if active.Swap.Offer.OfferChain == symbol || active.Swap.Offer.RequestChain == symbol {
+			return true
+		}
+	}
+	return false
+}
+
 // =============================================================================
 // Backend Helpers
 // =============================================================================
@@ -77,21 +111,6 @@ func (c *Coordinator) getBlockHeight(ctx context.Context, chainSymbol string) (u
 	return uint32(height), nil
 }
 
-// getConfirmations gets the confirmation count for a transaction.
-func (c *Coordinator) getConfirmations(ctx context.Context, chainSymbol, txID string) (uint32, error) {
-	b, ok := c.backends[chainSymbol]
-	if !ok {
-		return 0, fmt.Errorf("%w: %s", ErrNoBackend, chainSymbol)
-	}
-
-	tx, err := b.GetTransaction(ctx, txID)
-	if err != nil {
-		return 0, err
-	}
-
-	return uint32(tx.Confirmations), nil
-}
-
 // getWalletAddress derives a wallet address for a chain using proper index management.
 // It tracks used indices in storage to avoid address reuse.
 func (c *Coordinator) getWalletAddress(chainSymbol string) (string, error) {
@@ -0,0 +1,172 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// fakeChainBackend is a chain held in memory. It serves transactions,
+// address histories, raw transactions and the block height, and accepts
+// broadcasts into its mempool. Tests set the fields they need.
+type fakeChainBackend struct {
+	backend.Backend
+	mu         sync.Mutex
+	txs        map[string]*backend.Transaction
+	history    map[string][]backend.Transaction
+	raw        map[string][]byte
+	broadcasts []string
+	failNext   error // Returned by the next broadcast
+	height     atomic.Int64
+}
+
+func newFakeChainBackend() *fakeChainBackend {
+	return &fakeChainBackend{
+		txs:     make(map[string]*backend.Transaction),
+		history: make(map[string][]backend.Transaction),
+		raw:     make(map[string][]byte),
+	}
+}
+
+func (f *fakeChainBackend) GetTransaction(ctx context.Context, txID string) (*backend.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, ok := f.txs[txID]
+	if !ok {
+		return nil, backend.ErrTxNotFound
+	}
+	cp := *tx
+	return &cp, nil
+}
+
+func (f *fakeChainBackend) GetRawTransaction(ctx context.Context, txID string) ([]byte, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	raw, ok := f.raw[txID]
+	if !ok {
+		return nil, backend.ErrTxNotFound
+	}
+	return raw, nil
+}
+
+func (f *fakeChainBackend) GetAddressTxs(ctx context.Context, address, lastSeenTxID string) ([]backend.Transaction, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.history[address], nil
+}
+
+func (f *fakeChainBackend) GetFeeEstimates(ctx context.Context) (*backend.FeeEstimate, error) {
+	return &backend.FeeEstimate{HourFee: 5}, nil
+}
+
+func (f *fakeChainBackend) GetBlockHeight(ctx context.Context) (int64, error) {
+	return f.height.Load(), nil
+}
+
+func (f *fakeChainBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.broadcasts = append(f.broadcasts, rawTxHex)
+	if f.failNext != nil {
+		err := f.failNext
+		f.failNext = nil
+		return "", err
+	}
+	tx, err := DeserializeTx(rawTxHex)
+	if err != nil {
+		return "", err
+	}
+	txID := tx.TxHash().String()
+	if _, ok := f.txs[txID]; ok {
+		return "", errors.New("txn-already-known")
+	}
+	f.txs[txID] = &backend.Transaction{TxID: txID}
+	return txID, nil
+}
+
+// setConfirmations sets the confirmations of a transaction, adding it if
+// it is not known yet.
+func (f *fakeChainBackend) setConfirmations(txID string, confs int64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	tx, ok := f.txs[txID]
+	if !ok {
+		tx = &backend.Transaction{TxID: txID}
+		f.txs[txID] = tx
+	}
+	tx.Confirmations = confs
+	tx.Confirmed = confs > 0
+}
+
+// testOption configures the coordinator of newTestCoordinator.
+type testOption func(*CoordinatorConfig)
+
+func withNetwork(network chain.Network) testOption {
+	return func(cfg *CoordinatorConfig) { cfg.Network = network }
+}
+
+func withStore(store *storage.Storage) testOption {
+	return func(cfg *CoordinatorConfig) { cfg.Store = store }
+}
+
+func withBackend(symbol string, b backend.Backend) testOption {
+	return func(cfg *CoordinatorConfig) {
+		if cfg.Backends == nil {
+			cfg.Backends = make(map[string]backend.Backend)
+		}
+		cfg.Backends[symbol] = b
+	}
+}
+
+func withWallet(ws *wallet.Service) testOption {
+	return func(cfg *CoordinatorConfig) {
+		cfg.Wallet = ws.GetWallet()
+		cfg.WalletService = ws
+	}
+}
+
+// newTestCoordinator returns a testnet coordinator without a store, wallet
+// or backends unless options add them. It is closed when the test ends.
+func newTestCoordinator(t *testing.T, opts ...testOption) *Coordinator {
+	t.Helper()
+	cfg := &CoordinatorConfig{Network: chain.Testnet}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	coord := NewCoordinator(cfg)
+	t.Cleanup(func() { coord.Close() })
+	return coord
+}
+
+// newTestStore returns a store in the test's temporary directory.
+func newTestStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// newTestHTLCSwap returns an HTLC swap of 100000 sat of BTC for 1000000
+// litoshi of LTC.
+func newTestHTLCSwap(t *testing.T, network chain.Network, role Role) *Swap {
+	t.Helper()
+	s, err := NewSwap(network, MethodHTLC, role, Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	return s
+}
@@ -5,7 +5,10 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // =============================================================================
@@ -15,7 +18,11 @@ import (
 // ClaimHTLC claims the HTLC output on the specified chain using the secret.
 // For initiator: claims responder's chain (request chain) after funding
 // For responder: claims initiator's chain (offer chain) after secret is revealed
-func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol string) (string, error) {
+func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol string) (_ string, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.claim_htlc", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -24,76 +31,117 @@ func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol
 		return "", ErrSwapNotFound
 	}
 
+	in, err := c.htlcClaimInputsUnlocked(active, chainSymbol)
+	if err != nil {
+		return "", err
+	}
+
+	// Get the secret - try session first, then fall back to swap record
+	secret := in.session.GetSecret()
+	if len(secret) != 32 {
+		// Try the offer chain session (initiator stores secret there)
+		if active.HTLC.OfferChain != nil && active.HTLC.OfferChain.Session != nil {
+			secret = active.HTLC.OfferChain.Session.GetSecret()
+		}
+	}
+	if len(secret) != 32 {
+		// Fall back to swap record
+		secret = active.Swap.Secret
+	}
+	if len(secret) != 32 {
+		return "", fmt.Errorf("secret not available for claim")
+	}
+
+	txHex, err := c.buildHTLCClaimTxUnlocked(ctx, active, chainSymbol, in, secret)
+	if err != nil {
+		return "", err
+	}
+
+	b := c.backends[chainSymbol]
+	txID, err := c.BroadcastOnce(ctx, b, JobActionClaim, tradeID, chainSymbol, txHex)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast claim transaction: %w", err)
+	}
+
+	// Update swap state
+	active.Swap.State = StateRedeemed
+	c.emitEvent(tradeID, "htlc_claimed", map[string]string{
+		"chain":    chainSymbol,
+		"claim_tx": txID,
+	})
+
+	// Save state
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+
+	return txID, nil
+}
+
+// htlcSpendInputs holds the HTLC output being spent by a claim or refund.
+type htlcSpendInputs struct {
+	session       *HTLCSession
+	fundingTxID   string
+	fundingVout   uint32
+	fundingAmount uint64
+	timeoutBlocks uint32 // Refund only
+}
+
+// htlcClaimInputsUnlocked resolves the counterparty's HTLC output we can claim on a chain.
+// Caller must hold c.mu.
+func (c *Coordinator) htlcClaimInputsUnlocked(active *ActiveSwap, chainSymbol string) (*htlcSpendInputs, error) {
 	if active.Swap.Offer.Method != MethodHTLC {
-		return "", fmt.Errorf("swap method is %s, not HTLC", active.Swap.Offer.Method)
+		return nil, fmt.Errorf("swap method is %s, not HTLC", active.Swap.Offer.Method)
 	}
 
 	if active.HTLC == nil {
-		return "", fmt.Errorf("no HTLC data for swap")
+		return nil, fmt.Errorf("no HTLC data for swap")
 	}
 
-	// Get the appropriate HTLC session based on the chain
-	var htlcSession *HTLCSession
-	var fundingTxID string
-	var fundingVout uint32
-	var fundingAmount uint64
-
 	// Determine claim scenario:
 	// - Initiator claims on request chain (responder's chain)
 	// - Responder claims on offer chain (initiator's chain)
+	in := &htlcSpendInputs{
+		fundingTxID: active.Swap.RemoteFundingTxID,
+		fundingVout: active.Swap.RemoteFundingVout,
+	}
 	if chainSymbol == active.Swap.Offer.RequestChain {
 		// Initiator claiming responder's funds
-		htlcSession = active.HTLC.RequestChain.Session
-		fundingTxID = active.Swap.RemoteFundingTxID
-		fundingVout = active.Swap.RemoteFundingVout
-		fundingAmount = active.Swap.Offer.RequestAmount
+		in.session = active.HTLC.RequestChain.Session
+		in.fundingAmount = active.Swap.RequestFundingAmount()
 	} else if chainSymbol == active.Swap.Offer.OfferChain {
 		// Responder claiming initiator's funds
-		htlcSession = active.HTLC.OfferChain.Session
-		fundingTxID = active.Swap.RemoteFundingTxID
-		fundingVout = active.Swap.RemoteFundingVout
-		fundingAmount = active.Swap.Offer.OfferAmount
+		in.session = active.HTLC.OfferChain.Session
+		in.fundingAmount = active.Swap.OfferFundingAmount()
 	} else {
-		return "", fmt.Errorf("invalid chain for claim: %s", chainSymbol)
+		return nil, fmt.Errorf("invalid chain for claim: %s", chainSymbol)
 	}
 
-	if htlcSession == nil {
-		return "", fmt.Errorf("no HTLC session for chain %s", chainSymbol)
+	if in.session == nil {
+		return nil, fmt.Errorf("no HTLC session for chain %s", chainSymbol)
 	}
 
-	if fundingTxID == "" {
-		return "", fmt.Errorf("no funding transaction recorded for claim")
+	if in.fundingTxID == "" {
+		return nil, fmt.Errorf("no funding transaction recorded for claim")
 	}
 
-	// Get the secret - try session first, then fall back to swap record
-	secret := htlcSession.GetSecret()
-	if len(secret) != 32 {
-		// Try the offer chain session (initiator stores secret there)
-		if active.HTLC.OfferChain != nil && active.HTLC.OfferChain.Session != nil {
-			secret = active.HTLC.OfferChain.Session.GetSecret()
-		}
-	}
-	if len(secret) != 32 {
-		// Fall back to swap record
-		secret = active.Swap.Secret
-	}
-	if len(secret) != 32 {
-		return "", fmt.Errorf("secret not available for claim")
-	}
+	return in, nil
+}
 
+// buildHTLCClaimTxUnlocked builds and signs a claim transaction and returns it serialized.
+// The secret is not covered by the signature, so it may be a placeholder that is
+// filled in later (see PresignHTLCClaim). Caller must hold c.mu.
+func (c *Coordinator) buildHTLCClaimTxUnlocked(ctx context.Context, active *ActiveSwap, chainSymbol string, in *htlcSpendInputs, secret []byte) (string, error) {
 	// Get HTLC script
-	htlcScript := htlcSession.GetHTLCScript()
+	htlcScript := in.session.GetHTLCScript()
 	if len(htlcScript) == 0 {
 		return "", fmt.Errorf("HTLC script not available")
 	}
 
-	// Get destination address
-	if c.wallet == nil {
-		return "", fmt.Errorf("wallet not available for deriving claim address")
-	}
-	destAddress, err := c.wallet.DeriveAddress(chainSymbol, 0, 0)
+	// Get destination address (external payout address or our wallet)
+	destAddress, err := c.claimAddressUnlocked(active, chainSymbol)
 	if err != nil {
-		return "", fmt.Errorf("failed to derive claim address: %w", err)
+		return "", err
 	}
 
 	// Get fee rate
@@ -110,15 +158,14 @@ func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol
 	// Get the private key for signing
 	// For claiming, we use the remote pubkey's corresponding private key
 	// which is actually our local key on the counterparty's chain
-	privKey := htlcSession.GetLocalPrivKey()
+	privKey := in.session.GetLocalPrivKey()
 	if privKey == nil {
 		return "", fmt.Errorf("private key not available for claim")
 	}
 
-	// Calculate DAO fee - claimer pays the fee
+	// Calculate DAO fee - claimer pays the fee unless the fee terms say otherwise
 	// Initiator claims on request chain, Responder claims on offer chain
-	isMaker := active.Swap.Role == RoleInitiator
-	daoFee := CalculateDAOFee(fundingAmount, isMaker)
+	daoFee := active.Swap.claimDAOFee(chainSymbol)
 
 	// Get DAO address from config
 	exchangeCfg := config.NewExchangeConfig(config.NetworkType(c.network))
@@ -126,18 +173,19 @@ func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol
 
 	c.log.Debug("Building HTLC claim tx",
 		"chain", chainSymbol,
-		"funding_amount", fundingAmount,
+		"funding_amount", in.fundingAmount,
 		"dao_fee", daoFee,
 		"dao_address", daoAddress,
 	)
 
 	// Build claim transaction
+	var dustReport DustReport
 	claimTx, err := BuildHTLCClaimTx(&HTLCClaimTxParams{
 		Symbol:        chainSymbol,
 		Network:       c.network,
-		FundingTxID:   fundingTxID,
-		FundingVout:   fundingVout,
-		FundingAmount: fundingAmount,
+		FundingTxID:   in.fundingTxID,
+		FundingVout:   in.fundingVout,
+		FundingAmount: in.fundingAmount,
 		HTLCScript:    htlcScript,
 		Secret:        secret,
 		DestAddress:   destAddress,
@@ -145,27 +193,58 @@ func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol
 		DAOFee:        daoFee,
 		FeeRate:       feeRate,
 		PrivKey:       privKey,
+		Dust:          DustPolicy{Report: &dustReport},
 	})
 	if err != nil {
 		return "", fmt.Errorf("failed to build claim transaction: %w", err)
 	}
+	if len(dustReport.Folded) > 0 {
+		c.log.Info("Folded dust DAO fee into claim fee", "chain", chainSymbol, "dao_fee", daoFee, "threshold", dustReport.Threshold)
+	}
 
-	// Serialize and broadcast
 	txHex, err := SerializeTx(claimTx)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize claim transaction: %w", err)
 	}
+	return txHex, nil
+}
+
+// RefundHTLC refunds the HTLC output on the specified chain after the CSV timeout.
+// Only the original sender can refund their own chain's output.
+func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbol string) (_ string, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.refund_htlc", trace.WithAttributes(tracing.AttrChain.String(chainSymbol)))
+	defer func() { tracing.End(span, err) }()
+
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return "", ErrSwapNotFound
+	}
 
-	txID, err := b.BroadcastTransaction(ctx, txHex)
+	in, err := c.htlcRefundInputsUnlocked(active, chainSymbol)
 	if err != nil {
-		return "", fmt.Errorf("failed to broadcast claim transaction: %w", err)
+		return "", err
+	}
+
+	txHex, err := c.buildHTLCRefundTxUnlocked(ctx, chainSymbol, in)
+	if err != nil {
+		return "", err
+	}
+
+	b := c.backends[chainSymbol]
+	txID, err := c.BroadcastOnce(ctx, b, JobActionRefund, tradeID, chainSymbol, txHex)
+	if err != nil {
+		return "", fmt.Errorf("failed to broadcast refund transaction: %w", err)
 	}
 
 	// Update swap state
-	active.Swap.State = StateRedeemed
-	c.emitEvent(tradeID, "htlc_claimed", map[string]string{
-		"chain":    chainSymbol,
-		"claim_tx": txID,
+	active.Swap.State = StateRefunded
+	c.emitEvent(tradeID, "htlc_refunded", map[string]string{
+		"chain":     chainSymbol,
+		"refund_tx": txID,
 	})
 
 	// Save state
@@ -176,65 +255,56 @@ func (c *Coordinator) ClaimHTLC(ctx context.Context, tradeID string, chainSymbol
 	return txID, nil
 }
 
-// RefundHTLC refunds the HTLC output on the specified chain after the CSV timeout.
-// Only the original sender can refund their own chain's output.
-func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbol string) (string, error) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-
-	active, ok := c.swaps[tradeID]
-	if !ok {
-		return "", ErrSwapNotFound
-	}
-
+// htlcRefundInputsUnlocked resolves our own HTLC output we can refund on a chain.
+// Caller must hold c.mu.
+func (c *Coordinator) htlcRefundInputsUnlocked(active *ActiveSwap, chainSymbol string) (*htlcSpendInputs, error) {
 	if active.Swap.Offer.Method != MethodHTLC {
-		return "", fmt.Errorf("swap method is %s, not HTLC", active.Swap.Offer.Method)
+		return nil, fmt.Errorf("swap method is %s, not HTLC", active.Swap.Offer.Method)
 	}
 
 	if active.HTLC == nil {
-		return "", fmt.Errorf("no HTLC data for swap")
+		return nil, fmt.Errorf("no HTLC data for swap")
 	}
 
-	// Get the appropriate HTLC session and funding info
-	var htlcSession *HTLCSession
-	var fundingTxID string
-	var fundingVout uint32
-	var fundingAmount uint64
-	var timeoutBlocks uint32
-
 	// Determine refund scenario:
 	// - Initiator refunds their own offer chain output
 	// - Responder refunds their own request chain output
 	isMaker := active.Swap.Role == RoleInitiator
+	in := &htlcSpendInputs{
+		fundingTxID: active.Swap.LocalFundingTxID,
+		fundingVout: active.Swap.LocalFundingVout,
+	}
 
 	if active.Swap.Role == RoleInitiator && chainSymbol == active.Swap.Offer.OfferChain {
 		// Initiator refunding their offer
-		htlcSession = active.HTLC.OfferChain.Session
-		fundingTxID = active.Swap.LocalFundingTxID
-		fundingVout = active.Swap.LocalFundingVout
-		fundingAmount = active.Swap.Offer.OfferAmount
-		timeoutBlocks = GetTimeoutBlocks(chainSymbol, isMaker)
+		in.session = active.HTLC.OfferChain.Session
+		in.fundingAmount = active.Swap.OfferFundingAmount()
+		in.timeoutBlocks = GetTimeoutBlocks(chainSymbol, isMaker)
 	} else if active.Swap.Role == RoleResponder && chainSymbol == active.Swap.Offer.RequestChain {
 		// Responder refunding their request
-		htlcSession = active.HTLC.RequestChain.Session
-		fundingTxID = active.Swap.LocalFundingTxID
-		fundingVout = active.Swap.LocalFundingVout
-		fundingAmount = active.Swap.Offer.RequestAmount
-		timeoutBlocks = GetTimeoutBlocks(chainSymbol, !isMaker)
+		in.session = active.HTLC.RequestChain.Session
+		in.fundingAmount = active.Swap.RequestFundingAmount()
+		in.timeoutBlocks = GetTimeoutBlocks(chainSymbol, !isMaker)
 	} else {
-		return "", fmt.Errorf("cannot refund: you are %s but trying to refund %s", active.Swap.Role, chainSymbol)
+		return nil, fmt.Errorf("cannot refund: you are %s but trying to refund %s", active.Swap.Role, chainSymbol)
 	}
 
-	if htlcSession == nil {
-		return "", fmt.Errorf("no HTLC session for chain %s", chainSymbol)
+	if in.session == nil {
+		return nil, fmt.Errorf("no HTLC session for chain %s", chainSymbol)
 	}
 
-	if fundingTxID == "" {
-		return "", fmt.Errorf("no funding transaction recorded for refund")
+	if in.fundingTxID == "" {
+		return nil, fmt.Errorf("no funding transaction recorded for refund")
 	}
 
+	return in, nil
+}
+
+// buildHTLCRefundTxUnlocked builds and signs a refund transaction and returns it serialized.
+// Caller must hold c.mu.
+func (c *Coordinator) buildHTLCRefundTxUnlocked(ctx context.Context, chainSymbol string, in *htlcSpendInputs) (string, error) {
 	// Get HTLC script
-	htlcScript := htlcSession.GetHTLCScript()
+	htlcScript := in.session.GetHTLCScript()
 	if len(htlcScript) == 0 {
 		return "", fmt.Errorf("HTLC script not available")
 	}
@@ -243,7 +313,7 @@ func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbo
 	if c.wallet == nil {
 		return "", fmt.Errorf("wallet not available for deriving refund address")
 	}
-	destAddress, err := c.wallet.DeriveAddress(chainSymbol, 0, 0)
+	destAddress, err := c.walletAddressUnlocked(chainSymbol)
 	if err != nil {
 		return "", fmt.Errorf("failed to derive refund address: %w", err)
 	}
@@ -260,7 +330,7 @@ func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbo
 	}
 
 	// Get the private key (sender's key for refund)
-	privKey := htlcSession.GetLocalPrivKey()
+	privKey := in.session.GetLocalPrivKey()
 	if privKey == nil {
 		return "", fmt.Errorf("private key not available for refund")
 	}
@@ -269,11 +339,11 @@ func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbo
 	refundTx, err := BuildHTLCRefundTx(&HTLCRefundTxParams{
 		Symbol:        chainSymbol,
 		Network:       c.network,
-		FundingTxID:   fundingTxID,
-		FundingVout:   fundingVout,
-		FundingAmount: fundingAmount,
+		FundingTxID:   in.fundingTxID,
+		FundingVout:   in.fundingVout,
+		FundingAmount: in.fundingAmount,
 		HTLCScript:    htlcScript,
-		TimeoutBlocks: timeoutBlocks,
+		TimeoutBlocks: in.timeoutBlocks,
 		DestAddress:   destAddress,
 		FeeRate:       feeRate,
 		PrivKey:       privKey,
@@ -282,35 +352,17 @@ func (c *Coordinator) RefundHTLC(ctx context.Context, tradeID string, chainSymbo
 		return "", fmt.Errorf("failed to build refund transaction: %w", err)
 	}
 
-	// Serialize and broadcast
 	txHex, err := SerializeTx(refundTx)
 	if err != nil {
 		return "", fmt.Errorf("failed to serialize refund transaction: %w", err)
 	}
-
-	txID, err := b.BroadcastTransaction(ctx, txHex)
-	if err != nil {
-		return "", fmt.Errorf("failed to broadcast refund transaction: %w", err)
-	}
-
-	// Update swap state
-	active.Swap.State = StateRefunded
-	c.emitEvent(tradeID, "htlc_refunded", map[string]string{
-		"chain":     chainSymbol,
-		"refund_tx": txID,
-	})
-
-	// Save state
-	if c.store != nil {
-		_ = c.saveSwapState(tradeID)
-	}
-
-	return txID, nil
+	return txHex, nil
 }
 
 // ExtractSecretFromTx extracts the secret from an HTLC claim transaction.
 // This is used by the counterparty to learn the secret after the initiator claims.
 func (c *Coordinator) ExtractSecretFromTx(ctx context.Context, tradeID string, txID string, chainSymbol string) ([]byte, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -370,6 +422,7 @@ func (c *Coordinator) ExtractSecretFromTx(ctx context.Context, tradeID string, t
 							_ = c.saveSwapState(tradeID)
 						}
 
+						c.recordCounterpartyClaimUnlocked(tradeID, chainSymbol, txID)
 						return potentialSecret, nil
 					}
 				}
@@ -0,0 +1,330 @@
+// Package swap - Batch HTLC claim/refund settlement for the Coordinator.
+package swap
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+// HTLCBatchResult holds the result of a batch HTLC settlement.
+type HTLCBatchResult struct {
+	Chain    string      `json:"chain"`
+	TxID     string      `json:"txid"`
+	Claimed  []string    `json:"claimed"`  // Trade IDs settled via the claim path
+	Refunded []string    `json:"refunded"` // Trade IDs settled via the refund path
+	Fee      uint64      `json:"fee"`
+	Dust     *DustReport `json:"dust,omitempty"` // Set when the DAO fee was folded into the fee
+}
+
+// batchCandidate pairs a batch input with the swap it settles.
+type batchCandidate struct {
+	tradeID   string
+	active    *ActiveSwap
+	chainData *ChainHTLCData
+	input     *HTLCBatchInput
+}
+
+// BatchSettleHTLCs claims and refunds all eligible Bitcoin-family HTLC outputs on a chain
+// in a single transaction paying to our wallet.
+//
+// A swap is eligible for claim when the counterparty funded this chain and the secret is known.
+// A swap is eligible for refund when we funded this chain and the CSV timelock has matured.
+// The shared txid is recorded against every swap included in the batch.
+func (c *Coordinator) BatchSettleHTLCs(ctx context.Context, chainSymbol string) (*HTLCBatchResult, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	b, ok := c.backends[chainSymbol]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoBackend, chainSymbol)
+	}
+	if c.wallet == nil {
+		return nil, fmt.Errorf("wallet not available for deriving settlement address")
+	}
+
+	candidates, err := c.collectBatchCandidatesUnlocked(ctx, chainSymbol)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("no eligible HTLCs to settle on %s", chainSymbol)
+	}
+
+	destAddress, err := c.walletAddressUnlocked(chainSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive settlement address: %w", err)
+	}
+
+	// Claims are time-sensitive, refunds can use the slower rate
+	hasClaims := false
+	inputs := make([]*HTLCBatchInput, 0, len(candidates))
+	for _, cand := range candidates {
+		inputs = append(inputs, cand.input)
+		if cand.input.IsClaim() {
+			hasClaims = true
+		}
+	}
+
+	var feeRate uint64 = 20
+	feeEstimate, err := b.GetFeeEstimates(ctx)
+	if err == nil && feeEstimate != nil {
+		if hasClaims {
+			feeRate = feeEstimate.HalfHourFee
+		} else {
+			feeRate = feeEstimate.HourFee
+		}
+		if feeRate == 0 {
+			feeRate = 20
+		}
+	}
+
+	exchangeCfg := config.NewExchangeConfig(config.NetworkType(c.network))
+
+	var dustReport DustReport
+	batchTx, err := BuildHTLCBatchTx(&HTLCBatchTxParams{
+		Symbol:      chainSymbol,
+		Network:     c.network,
+		Inputs:      inputs,
+		DestAddress: destAddress,
+		DAOAddress:  exchangeCfg.GetDAOAddress(chainSymbol),
+		FeeRate:     feeRate,
+		Dust:        DustPolicy{Report: &dustReport},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build batch transaction: %w", err)
+	}
+
+	txHex, err := SerializeTx(batchTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize batch transaction: %w", err)
+	}
+
+	// The batch job is keyed by the settled trades, so retrying the same set
+	// re-broadcasts the first batch instead of a conflicting one
+	tradeIDs := make([]string, 0, len(candidates))
+	for _, cand := range candidates {
+		tradeIDs = append(tradeIDs, cand.tradeID)
+	}
+	txID, err := c.BroadcastOnce(ctx, b, JobActionBatch, strings.Join(tradeIDs, ","), chainSymbol, txHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast batch transaction: %w", err)
+	}
+
+	result := &HTLCBatchResult{
+		Chain: chainSymbol,
+		TxID:  txID,
+	}
+	var totalIn, totalOut uint64
+	for _, in := range inputs {
+		totalIn += in.FundingAmount
+	}
+	for _, out := range batchTx.TxOut {
+		totalOut += uint64(out.Value)
+	}
+	result.Fee = totalIn - totalOut
+	if len(dustReport.Folded) > 0 {
+		result.Dust = &dustReport
+	}
+
+	// Record the shared txid against each swap
+	for _, cand := range candidates {
+		if cand.input.IsClaim() {
+			cand.chainData.ClaimTxID = txID
+			cand.active.Swap.State = StateRedeemed
+			result.Claimed = append(result.Claimed, cand.tradeID)
+			c.emitEvent(cand.tradeID, "htlc_claimed", map[string]string{
+				"chain":    chainSymbol,
+				"claim_tx": txID,
+				"batched":  "true",
+			})
+		} else {
+			cand.chainData.RefundTxID = txID
+			cand.active.Swap.State = StateRefunded
+			result.Refunded = append(result.Refunded, cand.tradeID)
+			c.emitEvent(cand.tradeID, "htlc_refunded", map[string]string{
+				"chain":     chainSymbol,
+				"refund_tx": txID,
+				"batched":   "true",
+			})
+		}
+
+		if c.store != nil {
+			_ = c.saveSwapState(cand.tradeID)
+		}
+	}
+
+	c.log.Info("Broadcast batch HTLC settlement",
+		"chain", chainSymbol,
+		"txid", txID,
+		"claimed", len(result.Claimed),
+		"refunded", len(result.Refunded),
+	)
+
+	return result, nil
+}
+
+// collectBatchCandidatesUnlocked groups all HTLC swaps that can be settled on a chain (caller must hold lock).
+func (c *Coordinator) collectBatchCandidatesUnlocked(ctx context.Context, chainSymbol string) ([]*batchCandidate, error) {
+	// Sort trade IDs so the input order is deterministic
+	tradeIDs := make([]string, 0, len(c.swaps))
+	for tradeID := range c.swaps {
+		tradeIDs = append(tradeIDs, tradeID)
+	}
+	sort.Strings(tradeIDs)
+
+	var candidates []*batchCandidate
+	for _, tradeID := range tradeIDs {
+		active := c.swaps[tradeID]
+		if !active.IsHTLC() || active.IsCrossChain() || active.Swap.IsTerminal() {
+			continue
+		}
+
+		if cand := c.claimCandidateUnlocked(tradeID, active, chainSymbol); cand != nil {
+			candidates = append(candidates, cand)
+			continue
+		}
+
+		cand, err := c.refundCandidateUnlocked(ctx, tradeID, active, chainSymbol)
+		if err != nil {
+			c.log.Debug("Skipping HTLC refund candidate", "trade_id", tradeID, "error", err)
+			continue
+		}
+		if cand != nil {
+			candidates = append(candidates, cand)
+		}
+	}
+
+	return candidates, nil
+}
+
+// claimCandidateUnlocked returns a claim input if the swap can be claimed on the chain (caller must hold lock).
+func (c *Coordinator) claimCandidateUnlocked(tradeID string, active *ActiveSwap, chainSymbol string) *batchCandidate {
+	var chainData *ChainHTLCData
+	var fundingAmount uint64
+
+	// Initiator claims on request chain, responder claims on offer chain
+	if active.Swap.Role == RoleInitiator && chainSymbol == active.Swap.Offer.RequestChain {
+		chainData = active.HTLC.RequestChain
+		fundingAmount = active.Swap.RequestFundingAmount()
+	} else if active.Swap.Role == RoleResponder && chainSymbol == active.Swap.Offer.OfferChain {
+		chainData = active.HTLC.OfferChain
+		fundingAmount = active.Swap.OfferFundingAmount()
+	} else {
+		return nil
+	}
+
+	if chainData == nil || chainData.Session == nil || chainData.ClaimTxID != "" {
+		return nil
+	}
+	// The batch pays our wallet; external payouts are claimed individually
+	if active.Swap.PayoutAddress != "" {
+		return nil
+	}
+	if active.Swap.RemoteFundingTxID == "" {
+		return nil
+	}
+
+	htlcScript := chainData.Session.GetHTLCScript()
+	privKey := chainData.Session.GetLocalPrivKey()
+	if len(htlcScript) == 0 || privKey == nil {
+		return nil
+	}
+
+	secret := c.knownSecretUnlocked(active, chainData)
+	if len(secret) != 32 {
+		return nil
+	}
+
+	return &batchCandidate{
+		tradeID:   tradeID,
+		active:    active,
+		chainData: chainData,
+		input: &HTLCBatchInput{
+			TradeID:       tradeID,
+			FundingTxID:   active.Swap.RemoteFundingTxID,
+			FundingVout:   active.Swap.RemoteFundingVout,
+			FundingAmount: fundingAmount,
+			HTLCScript:    htlcScript,
+			Secret:        secret,
+			DAOFee:        active.Swap.claimDAOFee(chainSymbol),
+			PrivKey:       privKey,
+		},
+	}
+}
+
+// refundCandidateUnlocked returns a refund input if our HTLC on the chain has matured (caller must hold lock).
+func (c *Coordinator) refundCandidateUnlocked(ctx context.Context, tradeID string, active *ActiveSwap, chainSymbol string) (*batchCandidate, error) {
+	var chainData *ChainHTLCData
+	var fundingAmount uint64
+
+	// Initiator refunds offer chain, responder refunds request chain
+	if active.Swap.Role == RoleInitiator && chainSymbol == active.Swap.Offer.OfferChain {
+		chainData = active.HTLC.OfferChain
+		fundingAmount = active.Swap.OfferFundingAmount()
+	} else if active.Swap.Role == RoleResponder && chainSymbol == active.Swap.Offer.RequestChain {
+		chainData = active.HTLC.RequestChain
+		fundingAmount = active.Swap.RequestFundingAmount()
+	} else {
+		return nil, nil
+	}
+
+	if chainData == nil || chainData.Session == nil || chainData.RefundTxID != "" {
+		return nil, nil
+	}
+	if active.Swap.LocalFundingTxID == "" {
+		return nil, nil
+	}
+
+	htlcScript := chainData.Session.GetHTLCScript()
+	privKey := chainData.Session.GetLocalPrivKey()
+	if len(htlcScript) == 0 || privKey == nil {
+		return nil, nil
+	}
+
+	// Use the timelock committed in the script rather than recomputing it
+	_, _, _, timeoutBlocks, err := ParseHTLCScript(htlcScript)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTLC script: %w", err)
+	}
+
+	// CSV is relative to the funding confirmation
+	tx, err := c.backends[chainSymbol].GetTransaction(ctx, active.Swap.LocalFundingTxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get funding transaction: %w", err)
+	}
+	if tx.Confirmations < int64(timeoutBlocks) {
+		return nil, nil
+	}
+
+	return &batchCandidate{
+		tradeID:   tradeID,
+		active:    active,
+		chainData: chainData,
+		input: &HTLCBatchInput{
+			TradeID:       tradeID,
+			FundingTxID:   active.Swap.LocalFundingTxID,
+			FundingVout:   active.Swap.LocalFundingVout,
+			FundingAmount: fundingAmount,
+			HTLCScript:    htlcScript,
+			TimeoutBlocks: timeoutBlocks,
+			PrivKey:       privKey,
+		},
+	}, nil
+}
+
+// knownSecretUnlocked returns the swap secret if we know it (caller must hold lock).
+// Mirrors the lookup order used by ClaimHTLC.
+func (c *Coordinator) knownSecretUnlocked(active *ActiveSwap, chainData *ChainHTLCData) []byte {
+	secret := chainData.Session.GetSecret()
+	if len(secret) != 32 && active.HTLC.OfferChain != nil && active.HTLC.OfferChain.Session != nil {
+		secret = active.HTLC.OfferChain.Session.GetSecret()
+	}
+	if len(secret) != 32 {
+		secret = active.Swap.Secret
+	}
+	return secret
+}
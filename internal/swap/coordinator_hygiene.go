@@ -0,0 +1,261 @@
+// Package swap - Key hygiene of finished swaps.
+//
+// A swap's ephemeral private key, secret and secret nonces are only needed
+// until it ends. When a finished swap is saved they are overwritten in
+// memory, its persisted method data is stored without them and the
+// preimages of finished swaps are cleared from the secrets table.
+// AuditKeyHygiene, run every audit interval, checks that no finished swap
+// still holds any of them in memory or in the hot store, scrubs what it
+// finds and reports it: a finding means a swap ended on a path that skipped
+// the save.
+package swap
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// privateMethodDataKeys are the method data fields holding private key
+// material, at any depth.
+var privateMethodDataKeys = map[string]bool{
+	"local_privkey":   true,
+	"local_priv_key":  true,
+	"local_spend_key": true,
+	"secret":          true,
+}
+
+// KeyHygieneReport is the outcome of a key hygiene audit.
+type KeyHygieneReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+	Swaps     int       `json:"swaps"`     // Finished swaps checked, in memory and in the store
+	InMemory  []string  `json:"in_memory"` // Finished swaps still holding keys or secrets in memory
+	InStore   []string  `json:"in_store"`  // Finished swaps whose method data still held them
+	Preimages int       `json:"preimages"` // Preimages of finished swaps cleared from the secrets table
+}
+
+// Clean reports whether the audit found nothing to scrub.
+func (r *KeyHygieneReport) Clean() bool {
+	return len(r.InMemory) == 0 && len(r.InStore) == 0 && r.Preimages == 0
+}
+
+// StartKeyHygieneAudit audits the finished swaps every interval.
+func (c *Coordinator) StartKeyHygieneAudit(interval time.Duration) {
+	if interval <= 0 {
+		c.log.Warn("Key hygiene audit not started: interval not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.AuditKeyHygiene(); err != nil {
+					c.log.Warn("Key hygiene audit failed", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// AuditKeyHygiene checks that no finished swap retains its private key,
+// secret or secret nonces in memory or in the store, and scrubs any that
+// does.
+func (c *Coordinator) AuditKeyHygiene() (*KeyHygieneReport, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	report := &KeyHygieneReport{CheckedAt: time.Now(), InMemory: []string{}, InStore: []string{}}
+	for tradeID, active := range c.swaps {
+		if !active.Swap.IsTerminal() {
+			continue
+		}
+		report.Swaps++
+		if zeroizeActiveSwap(active) {
+			report.InMemory = append(report.InMemory, tradeID)
+		}
+	}
+
+	if c.store != nil {
+		records, err := c.store.ListSwaps(0, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list swaps: %w", err)
+		}
+		for _, record := range records {
+			if !storageStateToSwap(record.State).IsTerminal() {
+				continue
+			}
+			if _, inMemory := c.swaps[record.TradeID]; !inMemory {
+				report.Swaps++
+			}
+			scrubbed, found, err := scrubMethodData(record.MethodData)
+			if err != nil {
+				c.log.Warn("Key hygiene audit: unreadable method data", "trade_id", record.TradeID, "error", err)
+				continue
+			}
+			if !found {
+				continue
+			}
+			if err := c.store.UpdateSwapMethodData(record.TradeID, scrubbed); err != nil {
+				return nil, fmt.Errorf("failed to scrub swap %s: %w", record.TradeID, err)
+			}
+			report.InStore = append(report.InStore, record.TradeID)
+		}
+
+		if report.Preimages, err = c.store.ClearFinishedSecrets(); err != nil {
+			return nil, err
+		}
+	}
+
+	if !report.Clean() {
+		c.log.Warn("Key hygiene audit scrubbed finished swaps",
+			"in_memory", len(report.InMemory),
+			"in_store", len(report.InStore),
+			"preimages", report.Preimages,
+		)
+	}
+	c.lastHygiene = report
+	return report, nil
+}
+
+// LastKeyHygieneReport returns the latest audit's report, nil before the
+// first audit.
+func (c *Coordinator) LastKeyHygieneReport() *KeyHygieneReport {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lastHygiene
+}
+
+// forgetFinishedSwapUnlocked overwrites the keys and secrets of a swap that
+// was saved in a terminal state. Caller must hold c.mu.
+func (c *Coordinator) forgetFinishedSwapUnlocked(tradeID string, active *ActiveSwap) {
+	zeroizeActiveSwap(active)
+	if c.store == nil {
+		return
+	}
+	if _, err := c.store.ClearFinishedSecrets(); err != nil {
+		c.log.Warn("Failed to clear finished secrets", "trade_id", tradeID, "error", err)
+	}
+}
+
+// zeroizeActiveSwap overwrites a swap's private keys, secret and secret
+// nonces in memory. It reports whether any was still held.
+func zeroizeActiveSwap(active *ActiveSwap) bool {
+	held := zeroizeBytes(active.Swap.Secret)
+	active.Swap.Secret = nil
+
+	if m := active.MuSig2; m != nil {
+		held = zeroizePrivKey(m.LocalPrivKey) || held
+		for _, chainData := range []*ChainMuSig2Data{m.OfferChain, m.RequestChain} {
+			if chainData != nil && chainData.Session != nil {
+				held = chainData.Session.Zeroize() || held
+			}
+		}
+	}
+	if h := active.HTLC; h != nil {
+		held = zeroizePrivKey(h.LocalPrivKey) || held
+		for _, chainData := range []*ChainHTLCData{h.OfferChain, h.RequestChain} {
+			if chainData != nil && chainData.Session != nil {
+				held = chainData.Session.Zeroize() || held
+			}
+		}
+	}
+	if x := active.XMR; x != nil && x.Secrets != nil {
+		held = x.Secrets.Zeroize() || held
+	}
+	if e := active.EVMHTLC; e != nil {
+		held = zeroizeECDSAKey(e.LocalPrivKey) || held
+		for _, chainData := range []*ChainEVMHTLCData{e.OfferChain, e.RequestChain} {
+			if chainData != nil && chainData.Session != nil {
+				held = chainData.Session.Zeroize() || held
+			}
+		}
+	}
+	return held
+}
+
+// zeroizePrivKey overwrites a private key, reporting whether it was set.
+func zeroizePrivKey(key *btcec.PrivateKey) bool {
+	if key == nil || key.Key.IsZero() {
+		return false
+	}
+	key.Zero()
+	return true
+}
+
+// zeroizeECDSAKey overwrites an ECDSA private key, reporting whether it
+// was set.
+func zeroizeECDSAKey(key *ecdsa.PrivateKey) bool {
+	if key == nil || key.D == nil || key.D.Sign() == 0 {
+		return false
+	}
+	words := key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	key.D.SetInt64(0)
+	return true
+}
+
+// zeroizeBytes overwrites b, reporting whether it held anything.
+func zeroizeBytes(b []byte) bool {
+	held := false
+	for i := range b {
+		if b[i] != 0 {
+			held = true
+		}
+		b[i] = 0
+	}
+	return held
+}
+
+// scrubMethodData removes the private key material from persisted method
+// data. found reports whether there was any.
+func scrubMethodData(raw json.RawMessage) (scrubbed json.RawMessage, found bool, err error) {
+	if len(raw) == 0 {
+		return raw, false, nil
+	}
+	// Numbers are kept as written so amounts don't lose precision
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var data interface{}
+	if err := dec.Decode(&data); err != nil {
+		return nil, false, err
+	}
+	if !scrubValue(data) {
+		return raw, false, nil
+	}
+	scrubbed, err = json.Marshal(data)
+	return scrubbed, true, err
+}
+
+// scrubValue deletes the private fields of decoded JSON in place.
+func scrubValue(v interface{}) bool {
+	found := false
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if privateMethodDataKeys[key] {
+				if s, ok := field.(string); !ok || s != "" {
+					found = true
+				}
+				delete(v, key)
+				continue
+			}
+			found = scrubValue(field) || found
+		}
+	case []interface{}:
+		for _, item := range v {
+			found = scrubValue(item) || found
+		}
+	}
+	return found
+}
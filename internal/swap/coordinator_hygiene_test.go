@@ -0,0 +1,144 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestSaveFinishedSwapZeroizesKeys(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+	coord, _ := newAutoClaimCoordinator(t, store)
+
+	active := coord.swaps["initiator"]
+	key := active.HTLC.LocalPrivKey
+	secret := active.Swap.Secret
+	session := active.HTLC.OfferChain.Session
+	hash := sha256.Sum256(secret)
+	if err := session.SetSecretHash(hash[:]); err != nil {
+		t.Fatalf("SetSecretHash() error = %v", err)
+	}
+	if err := session.SetSecret(secret); err != nil {
+		t.Fatalf("SetSecret() error = %v", err)
+	}
+
+	// A live swap keeps its secret in memory and in the store
+	if err := coord.saveSwapState("initiator"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	record, err := store.GetSwap("initiator")
+	if err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	if !strings.Contains(string(record.MethodData), `"secret"`) {
+		t.Fatalf("live swap stored without its secret: %s", record.MethodData)
+	}
+
+	active.Swap.State = StateRedeemed
+	if err := coord.saveSwapState("initiator"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	if !key.Key.IsZero() {
+		t.Error("local key not zeroized")
+	}
+	if active.Swap.Secret != nil || secret[0] != 0 {
+		t.Error("secret not zeroized")
+	}
+	if session.HasSecret() {
+		t.Error("session secret not zeroized")
+	}
+	if record, err = store.GetSwap("initiator"); err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	if strings.Contains(string(record.MethodData), `"secret"`) {
+		t.Errorf("finished swap stored with its secret: %s", record.MethodData)
+	}
+
+	// Nothing left for the audit
+	report, err := coord.AuditKeyHygiene()
+	if err != nil {
+		t.Fatalf("AuditKeyHygiene() error = %v", err)
+	}
+	if !report.Clean() || report.Swaps != 1 {
+		t.Errorf("AuditKeyHygiene() = %+v, want one clean swap", report)
+	}
+}
+
+func TestAuditKeyHygiene(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+	coord, _ := newAutoClaimCoordinator(t, store)
+	if coord.LastKeyHygieneReport() != nil {
+		t.Fatal("report before the first audit")
+	}
+
+	// Both swaps saved live, then finished on a path that skipped the save
+	for _, tradeID := range []string{"initiator", "responder"} {
+		if err := coord.saveSwapState(tradeID); err != nil {
+			t.Fatalf("saveSwapState(%s) error = %v", tradeID, err)
+		}
+	}
+	coord.swaps["initiator"].Swap.State = StateRefunded
+	if err := store.UpdateSwapState("initiator", storage.SwapStateRefunded); err != nil {
+		t.Fatalf("UpdateSwapState() error = %v", err)
+	}
+
+	report, err := coord.AuditKeyHygiene()
+	if err != nil {
+		t.Fatalf("AuditKeyHygiene() error = %v", err)
+	}
+	if report.Swaps != 1 || len(report.InMemory) != 1 || len(report.InStore) != 1 {
+		t.Fatalf("AuditKeyHygiene() = %+v, want the refunded swap scrubbed", report)
+	}
+	if coord.swaps["initiator"].Swap.Secret != nil {
+		t.Error("refunded swap's secret not zeroized")
+	}
+	if coord.swaps["responder"].Swap.Secret == nil {
+		t.Error("live swap's secret zeroized")
+	}
+	record, err := store.GetSwap("initiator")
+	if err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	if strings.Contains(string(record.MethodData), `"secret"`) {
+		t.Errorf("refunded swap's secret still stored: %s", record.MethodData)
+	}
+	if coord.LastKeyHygieneReport() != report {
+		t.Error("LastKeyHygieneReport() is not the latest report")
+	}
+
+	if report, err = coord.AuditKeyHygiene(); err != nil || !report.Clean() {
+		t.Errorf("second AuditKeyHygiene() = %+v, %v, want clean", report, err)
+	}
+}
+
+func TestScrubMethodData(t *testing.T) {
+	raw := json.RawMessage(`{"local_privkey":"aa","secret_hash":"bb","amount":123456789012345678,` +
+		`"offer_chain":{"secret":"cc","htlc_address":"addr"},"legs":[{"local_priv_key":"dd"}]}`)
+	scrubbed, found, err := scrubMethodData(raw)
+	if err != nil || !found {
+		t.Fatalf("scrubMethodData() = %s, %v, %v", scrubbed, found, err)
+	}
+	want := `{"amount":123456789012345678,"legs":[{}],"offer_chain":{"htlc_address":"addr"},"secret_hash":"bb"}`
+	if string(scrubbed) != want {
+		t.Errorf("scrubMethodData() = %s, want %s", scrubbed, want)
+	}
+
+	clean := json.RawMessage(`{"secret_hash":"bb","secret":""}`)
+	if _, found, err := scrubMethodData(clean); err != nil || found {
+		t.Errorf("scrubMethodData(clean) found = %v, err = %v", found, err)
+	}
+	if _, _, err := scrubMethodData(json.RawMessage(`{`)); err == nil {
+		t.Error("scrubMethodData() accepted invalid JSON")
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/Klingon-tech/klingdex/internal/tracing"
 	"github.com/btcsuite/btcd/btcec/v2"
 )
 
@@ -15,16 +16,35 @@ import (
 // InitiateSwap starts a new swap as the maker (initiator).
 // Called when someone takes our order.
 // The method parameter specifies MuSig2 or HTLC.
-func (c *Coordinator) InitiateSwap(ctx context.Context, tradeID, orderID string, offer Offer, method Method) (*ActiveSwap, error) {
+func (c *Coordinator) InitiateSwap(ctx context.Context, tradeID, orderID string, offer Offer, method Method) (_ *ActiveSwap, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.initiate")
+	defer func() { tracing.End(span, err) }()
+
 	c.log.Debug("InitiateSwap: acquiring lock", "trade_id", tradeID, "method", method)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 	c.log.Debug("InitiateSwap: lock acquired", "trade_id", tradeID)
 
+	if c.degraded {
+		return nil, ErrDegradedMode
+	}
+	if c.clockSkewed {
+		return nil, ErrClockSkewed
+	}
+	if c.cold {
+		return nil, ErrColdMode
+	}
+	if err := c.checkContractsActiveUnlocked(offer.OfferChain, offer.RequestChain); err != nil {
+		return nil, err
+	}
+
 	// Check if swap already exists
 	if _, exists := c.swaps[tradeID]; exists {
 		return nil, ErrSwapExists
 	}
+	if err := c.checkAffordableUnlocked(ctx, tradeID, offer, true); err != nil {
+		return nil, err
+	}
 
 	c.log.Debug("InitiateSwap: creating swap", "trade_id", tradeID)
 	// Create swap
@@ -46,19 +66,22 @@ func (c *Coordinator) InitiateSwap(ctx context.Context, tradeID, orderID string,
 	}
 	swap.SetLocalPubKey(privKey.PubKey())
 
-	// Get current block heights
-	c.log.Debug("InitiateSwap: getting block heights", "trade_id", tradeID, "offer_chain", offer.OfferChain, "backends", len(c.backends))
-	offerHeight, err := c.getBlockHeight(ctx, offer.OfferChain)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get offer chain height: %w", err)
+	// Get current block heights. Adaptor swaps time out relative to their
+	// BTC lock instead.
+	if method != MethodAdaptor {
+		c.log.Debug("InitiateSwap: getting block heights", "trade_id", tradeID, "offer_chain", offer.OfferChain, "backends", len(c.backends))
+		offerHeight, err := c.getBlockHeight(ctx, offer.OfferChain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get offer chain height: %w", err)
+		}
+		c.log.Debug("InitiateSwap: got offer height", "trade_id", tradeID, "offer_height", offerHeight, "request_chain", offer.RequestChain)
+		requestHeight, err := c.getBlockHeight(ctx, offer.RequestChain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request chain height: %w", err)
+		}
+		c.log.Debug("InitiateSwap: got request height", "trade_id", tradeID, "request_height", requestHeight)
+		swap.SetBlockHeights(offerHeight, requestHeight)
 	}
-	c.log.Debug("InitiateSwap: got offer height", "trade_id", tradeID, "offer_height", offerHeight, "request_chain", offer.RequestChain)
-	requestHeight, err := c.getBlockHeight(ctx, offer.RequestChain)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get request chain height: %w", err)
-	}
-	c.log.Debug("InitiateSwap: got request height", "trade_id", tradeID, "request_height", requestHeight)
-	swap.SetBlockHeights(offerHeight, requestHeight)
 
 	var active *ActiveSwap
 
@@ -67,6 +90,8 @@ func (c *Coordinator) InitiateSwap(ctx context.Context, tradeID, orderID string,
 		active, err = c.initiateMuSig2Swap(tradeID, swap, offer, privKey)
 	case MethodHTLC:
 		active, err = c.initiateHTLCSwap(tradeID, swap, offer, privKey)
+	case MethodAdaptor:
+		active, err = c.newXMRSwap(swap, offer, privKey)
 	default:
 		return nil, fmt.Errorf("unsupported swap method: %s", method)
 	}
@@ -164,14 +189,40 @@ func (c *Coordinator) initiateHTLCSwap(tradeID string, swap *Swap, offer Offer,
 // RespondToSwap joins a swap as the taker (responder).
 // Called when we take someone's order.
 // The method parameter specifies MuSig2 or HTLC.
-func (c *Coordinator) RespondToSwap(ctx context.Context, tradeID string, offer Offer, remotePubKey []byte, secretHash []byte, method Method) (*ActiveSwap, error) {
+func (c *Coordinator) RespondToSwap(ctx context.Context, tradeID string, offer Offer, remotePubKey []byte, secretHash []byte, method Method) (_ *ActiveSwap, err error) {
+	ctx, span := tracing.StartTrade(ctx, "swap", tradeID, "swap.respond")
+	defer func() { tracing.End(span, err) }()
+
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	if c.degraded {
+		return nil, ErrDegradedMode
+	}
+	if c.clockSkewed {
+		return nil, ErrClockSkewed
+	}
+	if c.cold {
+		return nil, ErrColdMode
+	}
+	if err := c.checkContractsActiveUnlocked(offer.OfferChain, offer.RequestChain); err != nil {
+		return nil, err
+	}
+
 	// Check if swap already exists
 	if _, exists := c.swaps[tradeID]; exists {
 		return nil, ErrSwapExists
 	}
+	if err := c.checkAffordableUnlocked(ctx, tradeID, offer, false); err != nil {
+		return nil, err
+	}
+
+	// Refuse malformed hashes and hashes of other trades
+	if method == MethodHTLC || len(secretHash) > 0 {
+		if err := c.claimSecretHashUnlocked(tradeID, secretHash); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create swap as responder
 	swap, err := NewSwap(c.network, method, RoleResponder, offer)
@@ -196,16 +247,19 @@ func (c *Coordinator) RespondToSwap(ctx context.Context, tradeID string, offer O
 	}
 	swap.SetLocalPubKey(privKey.PubKey())
 
-	// Get current block heights
-	offerHeight, err := c.getBlockHeight(ctx, offer.OfferChain)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get offer chain height: %w", err)
-	}
-	requestHeight, err := c.getBlockHeight(ctx, offer.RequestChain)
-	if err != nil {
-		return nil, fmt.Errorf("failed to get request chain height: %w", err)
+	// Get current block heights. Adaptor swaps time out relative to their
+	// BTC lock instead.
+	if method != MethodAdaptor {
+		offerHeight, err := c.getBlockHeight(ctx, offer.OfferChain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get offer chain height: %w", err)
+		}
+		requestHeight, err := c.getBlockHeight(ctx, offer.RequestChain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get request chain height: %w", err)
+		}
+		swap.SetBlockHeights(offerHeight, requestHeight)
 	}
-	swap.SetBlockHeights(offerHeight, requestHeight)
 
 	var active *ActiveSwap
 
@@ -214,6 +268,9 @@ func (c *Coordinator) RespondToSwap(ctx context.Context, tradeID string, offer O
 		active, err = c.respondMuSig2Swap(tradeID, swap, offer, privKey, remotePub)
 	case MethodHTLC:
 		active, err = c.respondHTLCSwap(tradeID, swap, offer, privKey, remotePub, secretHash)
+	case MethodAdaptor:
+		// The maker's key share arrives over P2P
+		active, err = c.newXMRSwap(swap, offer, privKey)
 	default:
 		return nil, fmt.Errorf("unsupported swap method: %s", method)
 	}
@@ -0,0 +1,341 @@
+// Package swap - Durable job queue for on-chain side effects.
+//
+// Every broadcast or contract call is persisted as a job before it is executed
+// and marked done afterwards. A crash between deciding and executing leaves a
+// pending job that is resumed on restart, and a repeated decision for the same
+// side effect reuses the first one instead of executing a conflicting action.
+//
+// A broadcast job is only done once its transaction confirms. Until then the
+// job monitor checks that the transaction is still known to the backend and
+// rebroadcasts it if the mempool evicted it.
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chaos"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// BroadcastJobInterval is how often sent transactions are checked for
+// confirmation or eviction.
+const BroadcastJobInterval = time.Minute
+
+// Job actions (part of the job idempotency key).
+const (
+	JobActionFund      = "fund"
+	JobActionClaim     = "claim"
+	JobActionRefund    = "refund"
+	JobActionRedeem    = "redeem"
+	JobActionBatch     = "batch"
+	JobActionCancel    = "cancel" // Adaptor swaps: lock to cancel output
+	JobActionPunish    = "punish" // Adaptor swaps: cancel output to the XMR party
+	JobActionEVMCreate = "evm_create"
+	JobActionEVMClaim  = "evm_claim"
+	JobActionEVMRefund = "evm_refund"
+)
+
+// JobID returns the idempotency key of a side effect on a trade's chain leg.
+func JobID(action, tradeID, chainSymbol string) string {
+	return action + ":" + tradeID + ":" + chainSymbol
+}
+
+// JobTx is the transaction of a broadcast job.
+type JobTx struct {
+	TxID  string
+	TxHex string
+	// Earlier is set when TxHex is an earlier decision's transaction rather
+	// than the one passed in; what the caller derived from its own
+	// transaction (outputs, inputs spent) doesn't apply.
+	Earlier bool
+}
+
+// BroadcastOnce broadcasts a signed transaction as a durable job.
+// If the side effect was already executed, the recorded txid is returned without
+// broadcasting. If an earlier decision is still pending, its transaction is
+// re-broadcast instead of txHex, so two conflicting transactions never go out.
+// Without storage it falls back to a plain broadcast.
+func (c *Coordinator) BroadcastOnce(ctx context.Context, b backend.Backend, action, tradeID, chainSymbol, txHex string) (string, error) {
+	sent, err := c.broadcastJob(ctx, b, action, tradeID, chainSymbol, txHex)
+	if err != nil {
+		return "", err
+	}
+	return sent.TxID, nil
+}
+
+// broadcastJob is BroadcastOnce returning the transaction that went out,
+// for callers that record details of it.
+func (c *Coordinator) broadcastJob(ctx context.Context, b backend.Backend, action, tradeID, chainSymbol, txHex string) (*JobTx, error) {
+	ctx, op := c.beginOperation(ctx, tradeID, action, OpBroadcast)
+	defer op.end()
+
+	if c.store == nil {
+		txID, err := b.BroadcastTransaction(ctx, txHex)
+		if err != nil {
+			return nil, op.wrap(err)
+		}
+		return &JobTx{TxID: txID, TxHex: txHex}, nil
+	}
+
+	job, created, err := c.store.EnqueueJob(&storage.Job{
+		ID:      JobID(action, tradeID, chainSymbol),
+		TradeID: tradeID,
+		Chain:   chainSymbol,
+		Kind:    storage.JobKindBroadcastTx,
+		Action:  action,
+		Payload: txHex,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to persist job: %w", err)
+	}
+	chaos.CrashPoint(chaos.PointAfterJobPersist)
+
+	sent := &JobTx{TxHex: job.Payload, Earlier: job.Payload != txHex}
+	if job.Status == storage.JobStatusDone || job.Status == storage.JobStatusBroadcast {
+		c.log.Info("Side effect already executed", "job", job.ID, "txid", job.ResultTxID)
+		sent.TxID = job.ResultTxID
+		return sent, nil
+	}
+	if !created && sent.Earlier {
+		c.log.Warn("Re-broadcasting previously decided transaction", "job", job.ID, "attempts", job.Attempts)
+	}
+
+	sent.TxID, err = c.executeBroadcastJob(ctx, b, job)
+	if err != nil {
+		return nil, op.wrap(err)
+	}
+	return sent, nil
+}
+
+// executeBroadcastJob broadcasts a job's transaction and records the outcome.
+// The job stays unfinished until CheckBroadcastJobs sees the transaction
+// confirm.
+func (c *Coordinator) executeBroadcastJob(ctx context.Context, b backend.Backend, job *storage.Job) (string, error) {
+	txID, err := b.BroadcastTransaction(ctx, job.Payload)
+	if err != nil {
+		// The transaction may have gone out before a crash; the backend then
+		// rejects the duplicate but already knows the txid.
+		if known := knownTxID(ctx, b, job.Payload); known != "" {
+			txID, err = known, nil
+		}
+	}
+	if err != nil {
+		c.recordJobFailure(job, err)
+		return "", err
+	}
+	chaos.CrashPoint(chaos.PointAfterSideEffect)
+
+	if err := c.store.MarkJobBroadcast(job.ID, txID); err != nil {
+		c.log.Warn("Failed to mark job broadcast", "job", job.ID, "error", err)
+	}
+	return txID, nil
+}
+
+// recordJobFailure records a failed attempt and abandons the job once it has
+// exhausted its attempts, so a fresh decision can replace it.
+func (c *Coordinator) recordJobFailure(job *storage.Job, cause error) {
+	if err := c.store.RecordJobAttempt(job.ID, cause.Error()); err != nil {
+		c.log.Warn("Failed to record job attempt", "job", job.ID, "error", err)
+	}
+	maxAttempts := c.jobMaxAttempts
+	if maxAttempts > 0 && job.Attempts+1 >= maxAttempts {
+		c.log.Warn("Abandoning job after max attempts", "job", job.ID, "attempts", job.Attempts+1, "error", cause)
+		if err := c.store.FailJob(job.ID, cause.Error()); err != nil {
+			c.log.Warn("Failed to mark job failed", "job", job.ID, "error", err)
+		}
+	}
+}
+
+// supersedeBroadcastJob abandons the sent job of a trade whose transaction
+// was replaced, so the job monitor doesn't rebroadcast it.
+func (c *Coordinator) supersedeBroadcastJob(tradeID, txID, replacement string) {
+	if c.store == nil {
+		return
+	}
+	jobs, err := c.store.GetJobsByTrade(tradeID)
+	if err != nil {
+		c.log.Warn("Failed to get jobs of trade", "trade_id", tradeID, "error", err)
+		return
+	}
+	for _, job := range jobs {
+		if job.Status != storage.JobStatusBroadcast || job.ResultTxID != txID {
+			continue
+		}
+		if err := c.store.FailJob(job.ID, "replaced by "+replacement); err != nil {
+			c.log.Warn("Failed to mark job failed", "job", job.ID, "error", err)
+		}
+	}
+}
+
+// knownTxID returns the txid of a raw transaction if the backend already has it.
+func knownTxID(ctx context.Context, b backend.Backend, txHex string) string {
+	tx, err := DeserializeTx(txHex)
+	if err != nil {
+		return ""
+	}
+	txID := tx.TxHash().String()
+	if _, err := b.GetTransaction(ctx, txID); err != nil {
+		return ""
+	}
+	return txID
+}
+
+// runEVMJob executes an EVM contract call as a durable job.
+// A call already marked done returns its recorded hash. A call left pending by a
+// crash is executed again: the HTLC contract accepts a single create, claim or
+// refund per swap ID, so a repeated call reverts instead of moving funds twice.
+func (c *Coordinator) runEVMJob(ctx context.Context, action, tradeID, chainSymbol string, call func(context.Context) (common.Hash, error)) (common.Hash, error) {
+	ctx, op := c.beginOperation(ctx, tradeID, action, OpBroadcast)
+	defer op.end()
+
+	if c.store == nil {
+		txHash, err := call(ctx)
+		return txHash, op.wrap(err)
+	}
+
+	job, _, err := c.store.EnqueueJob(&storage.Job{
+		ID:      JobID(action, tradeID, chainSymbol),
+		TradeID: tradeID,
+		Chain:   chainSymbol,
+		Kind:    storage.JobKindEVMCall,
+		Action:  action,
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to persist job: %w", err)
+	}
+	chaos.CrashPoint(chaos.PointAfterJobPersist)
+	if job.Status == storage.JobStatusDone {
+		c.log.Info("Side effect already executed", "job", job.ID, "tx_hash", job.ResultTxID)
+		return common.HexToHash(job.ResultTxID), nil
+	}
+
+	txHash, err := call(ctx)
+	if err != nil {
+		c.recordJobFailure(job, err)
+		return common.Hash{}, op.wrap(err)
+	}
+	chaos.CrashPoint(chaos.PointAfterSideEffect)
+	if err := c.store.CompleteJob(job.ID, txHash.Hex()); err != nil {
+		c.log.Warn("Failed to mark job done", "job", job.ID, "error", err)
+	}
+	return txHash, nil
+}
+
+// ResumePendingJobs re-executes broadcast jobs left pending by a crash or a
+// failed attempt. Re-broadcasting the same signed transaction is idempotent.
+// EVM calls are not replayed automatically; they are logged so the operator can
+// retry the claim or refund, which reuses the pending job.
+// Returns the number of jobs resumed.
+func (c *Coordinator) ResumePendingJobs(ctx context.Context) (int, error) {
+	if c.store == nil {
+		return 0, nil
+	}
+
+	jobs, err := c.store.GetPendingJobs()
+	if err != nil {
+		return 0, err
+	}
+
+	resumed := 0
+	for _, job := range jobs {
+		if job.Kind != storage.JobKindBroadcastTx {
+			c.log.Warn("Pending EVM call needs to be retried", "job", job.ID, "last_error", job.LastError)
+			continue
+		}
+		if job.Action == JobActionFund && c.IsColdMode() {
+			c.log.Warn("Cold mode, not broadcasting pending funding", "job", job.ID)
+			continue
+		}
+
+		c.mu.RLock()
+		b, ok := c.backends[job.Chain]
+		c.mu.RUnlock()
+		if !ok {
+			c.log.Warn("No backend for pending job", "job", job.ID, "chain", job.Chain)
+			continue
+		}
+
+		txID, err := c.executeBroadcastJob(ctx, b, job)
+		if err != nil {
+			c.log.Warn("Failed to resume job", "job", job.ID, "error", err)
+			continue
+		}
+		c.log.Info("Resumed pending job", "job", job.ID, "txid", txID)
+		resumed++
+	}
+
+	return resumed, nil
+}
+
+// CheckBroadcastJobs follows the transactions of sent broadcast jobs. A
+// confirmed transaction completes its job; one the backend no longer knows
+// was evicted from the mempool and is broadcast again, until the job
+// exhausts its attempts. Returns the number of jobs completed.
+func (c *Coordinator) CheckBroadcastJobs(ctx context.Context) (int, error) {
+	if c.store == nil {
+		return 0, nil
+	}
+
+	jobs, err := c.store.GetBroadcastJobs()
+	if err != nil {
+		return 0, err
+	}
+
+	completed := 0
+	for _, job := range jobs {
+		c.mu.RLock()
+		b, ok := c.backends[job.Chain]
+		c.mu.RUnlock()
+		if !ok {
+			continue
+		}
+
+		jobCtx := backend.WithTradeID(ctx, job.TradeID)
+		tx, err := b.GetTransaction(jobCtx, job.ResultTxID)
+		switch {
+		case errors.Is(err, backend.ErrTxNotFound):
+			c.log.Warn("Broadcast transaction evicted, re-broadcasting", "job", job.ID, "txid", job.ResultTxID)
+			if _, err := c.executeBroadcastJob(jobCtx, b, job); err != nil {
+				c.log.Warn("Failed to re-broadcast job", "job", job.ID, "error", err)
+			}
+		case err != nil:
+			c.log.Warn("Failed to check broadcast job", "job", job.ID, "txid", job.ResultTxID, "error", err)
+		case tx.Confirmed:
+			if err := c.store.CompleteConfirmedJob(job.ID); err != nil {
+				c.log.Warn("Failed to mark job done", "job", job.ID, "error", err)
+				continue
+			}
+			completed++
+		}
+	}
+	return completed, nil
+}
+
+// StartBroadcastJobMonitor runs CheckBroadcastJobs every interval.
+func (c *Coordinator) StartBroadcastJobMonitor(interval time.Duration) {
+	if interval <= 0 {
+		c.log.Warn("Broadcast job monitor not started: check interval not set")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.CheckBroadcastJobs(c.ctx); err != nil {
+					c.log.Warn("Failed to check broadcast jobs", "error", err)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,269 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// testTxHex returns a distinct serialized transaction per value.
+func testTxHex(t *testing.T, value int64) string {
+	t.Helper()
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(value, []byte{0x51}))
+	txHex, err := SerializeTx(tx)
+	if err != nil {
+		t.Fatalf("SerializeTx() error = %v", err)
+	}
+	return txHex
+}
+
+func TestBroadcastOnceSkipsCompletedJob(t *testing.T) {
+	fake := newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withBackend("BTC", fake))
+	ctx := context.Background()
+
+	first, err := coord.BroadcastOnce(ctx, fake, JobActionClaim, "trade-1", "BTC", testTxHex(t, 1000))
+	if err != nil {
+		t.Fatalf("BroadcastOnce() error = %v", err)
+	}
+
+	// A second decision (e.g. rebuilt at a different fee rate) must not go out
+	second, err := coord.BroadcastOnce(ctx, fake, JobActionClaim, "trade-1", "BTC", testTxHex(t, 900))
+	if err != nil {
+		t.Fatalf("BroadcastOnce() error = %v", err)
+	}
+	if second != first {
+		t.Errorf("second BroadcastOnce() = %s, want %s", second, first)
+	}
+	if len(fake.broadcasts) != 1 {
+		t.Errorf("expected 1 broadcast, got %d", len(fake.broadcasts))
+	}
+}
+
+func TestBroadcastOnceReusesPendingDecision(t *testing.T) {
+	store, fake := newTestStore(t), newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	ctx := context.Background()
+	original := testTxHex(t, 1000)
+
+	fake.failNext = errors.New("connection reset")
+	if _, err := coord.BroadcastOnce(ctx, fake, JobActionRefund, "trade-2", "BTC", original); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+
+	if _, err := coord.BroadcastOnce(ctx, fake, JobActionRefund, "trade-2", "BTC", testTxHex(t, 900)); err != nil {
+		t.Fatalf("BroadcastOnce() error = %v", err)
+	}
+	if got := fake.broadcasts[len(fake.broadcasts)-1]; got != original {
+		t.Error("retry must re-broadcast the originally decided transaction")
+	}
+
+	job, err := store.GetJob(JobID(JobActionRefund, "trade-2", "BTC"))
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != storage.JobStatusBroadcast || job.Attempts != 2 {
+		t.Errorf("job = %+v, want broadcast after 2 attempts", job)
+	}
+}
+
+func TestBroadcastJobReportsEarlierDecision(t *testing.T) {
+	store, fake := newTestStore(t), newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	coord.jobMaxAttempts = 3
+	ctx := context.Background()
+	original := testTxHex(t, 1000)
+
+	fake.failNext = errors.New("connection reset")
+	if _, err := coord.broadcastJob(ctx, fake, JobActionFund, "trade-3", "BTC", original); err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	sent, err := coord.broadcastJob(ctx, fake, JobActionFund, "trade-3", "BTC", testTxHex(t, 900))
+	if err != nil {
+		t.Fatalf("broadcastJob() error = %v", err)
+	}
+	if !sent.Earlier || sent.TxHex != original {
+		t.Errorf("broadcastJob() = %+v, want the earlier transaction", sent)
+	}
+
+	// Sent: the recorded transaction is still reported
+	if sent, err = coord.broadcastJob(ctx, fake, JobActionFund, "trade-3", "BTC", testTxHex(t, 800)); err != nil || !sent.Earlier || sent.TxHex != original {
+		t.Errorf("broadcastJob() = %+v, %v; want the earlier transaction", sent, err)
+	}
+	if sent, err = coord.broadcastJob(ctx, fake, JobActionFund, "trade-4", "BTC", testTxHex(t, 700)); err != nil || sent.Earlier {
+		t.Errorf("broadcastJob() = %+v, %v; want our transaction", sent, err)
+	}
+
+	// The configured attempts abandon a job
+	for i := 0; i < 3; i++ {
+		fake.failNext = errors.New("connection reset")
+		coord.broadcastJob(ctx, fake, JobActionClaim, "trade-5", "BTC", testTxHex(t, 600))
+	}
+	job, err := store.GetJob(JobID(JobActionClaim, "trade-5", "BTC"))
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != storage.JobStatusFailed || job.Attempts != 3 {
+		t.Errorf("job = %+v, want failed after 3 attempts", job)
+	}
+}
+
+func TestDecodeFundingTx(t *testing.T) {
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withBackend("BTC", newFakeChainBackend()))
+	coord.network = chain.Testnet
+	ws, recv, change := newTestWalletService(t, backend.NewRegistry())
+	escrow, _ := ws.GetAddressWithChange("BTC", 5, 0, 0)
+	params, _ := chain.Get("BTC", chain.Testnet)
+	script := func(addr string) []byte {
+		s, err := wallet.ParseAddressToScript(addr, params)
+		if err != nil {
+			t.Fatalf("ParseAddressToScript() error = %v", err)
+		}
+		return s
+	}
+
+	// The earlier decision spent other UTXOs and put the escrow second
+	tx := wire.NewMsgTx(wire.TxVersion)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0xaa}, 1), nil, nil))
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{0xbb}, 0), nil, nil))
+	tx.AddTxOut(wire.NewTxOut(20000, script(change)))
+	tx.AddTxOut(wire.NewTxOut(50000, script(escrow)))
+	txHex, err := SerializeTx(tx)
+	if err != nil {
+		t.Fatalf("SerializeTx() error = %v", err)
+	}
+	built := &fundingBuild{
+		chain:      "BTC",
+		escrowAddr: escrow,
+		changeAddr: change,
+		utxos: []*wallet.AddressUTXO{
+			{TxID: chainhash.Hash{0xaa}.String(), Vout: 1, Amount: 40000, Address: recv},
+			{TxID: chainhash.Hash{0xbb}.String(), Vout: 0, Amount: 31000, Address: recv},
+		},
+	}
+
+	result, vout, err := coord.decodeFundingTx(txHex, built)
+	if err != nil {
+		t.Fatalf("decodeFundingTx() error = %v", err)
+	}
+	want := []string{chainhash.Hash{0xaa}.String() + ":1", chainhash.Hash{0xbb}.String() + ":0"}
+	if vout != 1 || result.Change != 20000 || result.Fee != 1000 || !reflect.DeepEqual(result.UsedUTXOs, want) {
+		t.Errorf("decodeFundingTx() = %+v, vout %d; want vout 1, change 20000, fee 1000, inputs %v", result, vout, want)
+	}
+
+	// Without a known input the fee is unknown
+	built.utxos = built.utxos[:1]
+	if result, _, _ = coord.decodeFundingTx(txHex, built); result.Fee != 0 || result.TotalInput != 0 {
+		t.Errorf("decodeFundingTx() = %+v, want no fee", result)
+	}
+
+	built.escrowAddr = recv
+	if _, _, err := coord.decodeFundingTx(txHex, built); err == nil {
+		t.Error("decodeFundingTx() without an escrow output succeeded")
+	}
+}
+
+func TestResumePendingJobs(t *testing.T) {
+	store, fake := newTestStore(t), newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	ctx := context.Background()
+	txHex := testTxHex(t, 1000)
+
+	// Simulate a crash after the job was persisted but before the result was recorded,
+	// with the transaction already in the mempool
+	if _, _, err := store.EnqueueJob(&storage.Job{
+		ID: JobID(JobActionFund, "trade-3", "BTC"), TradeID: "trade-3", Chain: "BTC",
+		Kind: storage.JobKindBroadcastTx, Action: JobActionFund, Payload: txHex,
+	}); err != nil {
+		t.Fatalf("EnqueueJob() error = %v", err)
+	}
+	if _, err := fake.BroadcastTransaction(ctx, txHex); err != nil {
+		t.Fatal(err)
+	}
+
+	resumed, err := coord.ResumePendingJobs(ctx)
+	if err != nil {
+		t.Fatalf("ResumePendingJobs() error = %v", err)
+	}
+	if resumed != 1 {
+		t.Errorf("ResumePendingJobs() = %d, want 1", resumed)
+	}
+	pending, _ := store.GetPendingJobs()
+	if len(pending) != 0 {
+		t.Errorf("expected no pending jobs, got %d", len(pending))
+	}
+}
+
+func TestCheckBroadcastJobs(t *testing.T) {
+	store, fake := newTestStore(t), newFakeChainBackend()
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", fake))
+	ctx := context.Background()
+	txHex := testTxHex(t, 1000)
+
+	txID, err := coord.BroadcastOnce(ctx, fake, JobActionClaim, "trade-6", "BTC", txHex)
+	if err != nil {
+		t.Fatalf("BroadcastOnce() error = %v", err)
+	}
+	id := JobID(JobActionClaim, "trade-6", "BTC")
+
+	// In the mempool: the job waits
+	if done, err := coord.CheckBroadcastJobs(ctx); err != nil || done != 0 {
+		t.Fatalf("CheckBroadcastJobs() = %d, %v; want 0", done, err)
+	}
+
+	// Evicted: the same transaction goes out again
+	fake.mu.Lock()
+	delete(fake.txs, txID)
+	fake.mu.Unlock()
+	if done, err := coord.CheckBroadcastJobs(ctx); err != nil || done != 0 {
+		t.Fatalf("CheckBroadcastJobs() = %d, %v; want 0", done, err)
+	}
+	if len(fake.broadcasts) != 2 || fake.broadcasts[1] != txHex {
+		t.Fatalf("broadcasts = %d, want the evicted transaction again", len(fake.broadcasts))
+	}
+	if job, _ := store.GetJob(id); job.Status != storage.JobStatusBroadcast || job.Attempts != 2 {
+		t.Errorf("job = %+v, want broadcast after 2 attempts", job)
+	}
+
+	// Confirmed: the job is done
+	fake.setConfirmations(txID, 1)
+	if done, err := coord.CheckBroadcastJobs(ctx); err != nil || done != 1 {
+		t.Fatalf("CheckBroadcastJobs() = %d, %v; want 1", done, err)
+	}
+	if job, _ := store.GetJob(id); job.Status != storage.JobStatusDone || job.ResultTxID != txID {
+		t.Errorf("job = %+v, want done", job)
+	}
+}
+
+func TestRunEVMJobExecutesOnce(t *testing.T) {
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withBackend("BTC", newFakeChainBackend()))
+	calls := 0
+	call := func(ctx context.Context) (common.Hash, error) {
+		calls++
+		return common.HexToHash("0xabc"), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		hash, err := coord.runEVMJob(context.Background(), JobActionEVMClaim, "trade-4", "ETH", call)
+		if err != nil {
+			t.Fatalf("runEVMJob() error = %v", err)
+		}
+		if hash != common.HexToHash("0xabc") {
+			t.Errorf("runEVMJob() = %s", hash.Hex())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("contract call executed %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,130 @@
+// Package swap - Audit of the keys and wallet paths each swap uses.
+//
+// A swap spends and receives through two kinds of keys. Wallet addresses are
+// derived from the seed and can be found again by a wallet restored from it;
+// the ephemeral MuSig2/HTLC key is random and exists only in the swap record,
+// so funds in an escrow it controls are recoverable only from the database or
+// one of its backups. Every key a swap touches is recorded, with public
+// material only, so swap_getKeyUsage can tell which is which.
+package swap
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// recordKeyUsageUnlocked records the ephemeral key and the wallet addresses
+// of a swap. Caller must hold c.mu.
+func (c *Coordinator) recordKeyUsageUnlocked(tradeID string, active *ActiveSwap) {
+	if c.store == nil {
+		return
+	}
+	var usages []*storage.SwapKeyUsage
+
+	if len(active.Swap.LocalPubKey) > 0 {
+		pubKey := hex.EncodeToString(active.Swap.LocalPubKey)
+		for _, symbol := range []string{active.Swap.Offer.OfferChain, active.Swap.Offer.RequestChain} {
+			if params, ok := chain.Get(symbol, c.network); ok && params.Type == chain.ChainTypeEVM {
+				continue // EVM legs are signed by the wallet key
+			}
+			if IsMoneroChain(symbol, c.network) {
+				continue // XMR legs lock to the shared address
+			}
+			usages = append(usages, &storage.SwapKeyUsage{
+				TradeID:  tradeID,
+				Purpose:  storage.KeyPurposeSwapKey,
+				Chain:    symbol,
+				PubKey:   pubKey,
+				Recovery: storage.KeyRecoveryBackup,
+			})
+		}
+	}
+
+	receiving := ReceivingChain(active.Swap.Offer, active.Swap.Role)
+	for _, leg := range []struct{ symbol, address string }{
+		{active.Swap.Offer.OfferChain, active.Swap.LocalOfferWalletAddr},
+		{active.Swap.Offer.RequestChain, active.Swap.LocalRequestWalletAddr},
+	} {
+		if leg.address == "" {
+			continue
+		}
+		purpose := storage.KeyPurposeRefund
+		if leg.symbol == receiving {
+			purpose = storage.KeyPurposeReceive
+		}
+		usages = append(usages, c.walletKeyUsage(tradeID, purpose, leg.symbol, leg.address))
+	}
+
+	c.saveKeyUsage(usages)
+}
+
+// recordFundingKeyUsageUnlocked records the wallet addresses a funding
+// transaction spent from and its change address. used lists the spent
+// outpoints as txid:vout. Caller must hold c.mu.
+func (c *Coordinator) recordFundingKeyUsageUnlocked(tradeID, symbol string, utxos []*wallet.AddressUTXO, used []string, changeAddr string, change uint64) {
+	if c.store == nil {
+		return
+	}
+	spent := make(map[string]bool, len(used))
+	for _, outpoint := range used {
+		spent[outpoint] = true
+	}
+
+	var usages []*storage.SwapKeyUsage
+	seen := make(map[string]bool)
+	for _, u := range utxos {
+		if !spent[fmt.Sprintf("%s:%d", u.TxID, u.Vout)] || seen[u.Address] {
+			continue
+		}
+		seen[u.Address] = true
+		usage := &storage.SwapKeyUsage{
+			TradeID:  tradeID,
+			Purpose:  storage.KeyPurposeFundingInput,
+			Chain:    symbol,
+			Address:  u.Address,
+			Recovery: storage.KeyRecoverySeed,
+		}
+		if params, ok := chain.Get(symbol, c.network); ok {
+			usage.DerivationPath = params.DerivationPathString(u.Account, u.Change, u.AddressIndex)
+		}
+		usages = append(usages, usage)
+	}
+	if change > 0 && changeAddr != "" {
+		usages = append(usages, c.walletKeyUsage(tradeID, storage.KeyPurposeChange, symbol, changeAddr))
+	}
+
+	c.saveKeyUsage(usages)
+}
+
+// walletKeyUsage describes an address we use, with its derivation path if
+// the wallet derived it. Other addresses are external payouts.
+func (c *Coordinator) walletKeyUsage(tradeID, purpose, symbol, address string) *storage.SwapKeyUsage {
+	usage := &storage.SwapKeyUsage{
+		TradeID:  tradeID,
+		Purpose:  purpose,
+		Chain:    symbol,
+		Address:  address,
+		Recovery: storage.KeyRecoveryExternal,
+	}
+	addr, err := c.store.GetWalletAddress(address)
+	if err != nil || addr == nil {
+		return usage
+	}
+	usage.Recovery = storage.KeyRecoverySeed
+	if params, ok := chain.Get(addr.Chain, c.network); ok {
+		usage.DerivationPath = params.DerivationPathString(addr.Account, addr.Change, addr.AddressIndex)
+	}
+	return usage
+}
+
+func (c *Coordinator) saveKeyUsage(usages []*storage.SwapKeyUsage) {
+	for _, u := range usages {
+		if err := c.store.RecordSwapKeyUsage(u); err != nil {
+			c.log.Warn("Failed to record swap key usage", "trade_id", u.TradeID, "purpose", u.Purpose, "error", err)
+		}
+	}
+}
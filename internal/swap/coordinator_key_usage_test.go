@@ -0,0 +1,109 @@
+package swap
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestRecordKeyUsage(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+	coord, _ := newAutoClaimCoordinator(t, store)
+
+	const receiveAddr = "tltc1qkeyusagereceive"
+	if err := store.SaveWalletAddress(&storage.WalletAddress{
+		Address: receiveAddr, Chain: "LTC", AddressIndex: 7, AddressType: "p2wpkh",
+	}); err != nil {
+		t.Fatalf("SaveWalletAddress() error = %v", err)
+	}
+
+	// The initiator funds BTC and receives on LTC
+	active := coord.swaps["initiator"]
+	active.Swap.SetLocalPubKey(active.HTLC.LocalPrivKey.PubKey())
+	active.Swap.LocalOfferWalletAddr = testnetPayoutAddr
+	active.Swap.LocalRequestWalletAddr = receiveAddr
+	for i := 0; i < 2; i++ {
+		if err := coord.saveSwapState("initiator"); err != nil {
+			t.Fatalf("saveSwapState() error = %v", err)
+		}
+	}
+
+	got, err := store.GetSwapKeyUsage("initiator")
+	if err != nil {
+		t.Fatalf("GetSwapKeyUsage() error = %v", err)
+	}
+	if len(got) != 4 {
+		t.Fatalf("recorded %d usages, want 4: %+v", len(got), got)
+	}
+	byPurpose := make(map[string][]*storage.SwapKeyUsage)
+	for _, u := range got {
+		byPurpose[u.Purpose] = append(byPurpose[u.Purpose], u)
+	}
+
+	pubKey := hex.EncodeToString(active.Swap.LocalPubKey)
+	swapKeys := byPurpose[storage.KeyPurposeSwapKey]
+	if len(swapKeys) != 2 {
+		t.Fatalf("swap keys = %+v, want one per Bitcoin-family leg", swapKeys)
+	}
+	for _, u := range swapKeys {
+		if u.PubKey != pubKey || u.Recovery != storage.KeyRecoveryBackup || u.DerivationPath != "" {
+			t.Errorf("swap key usage = %+v, want backup-only public key", u)
+		}
+	}
+
+	receive := byPurpose[storage.KeyPurposeReceive]
+	if len(receive) != 1 || receive[0].Chain != "LTC" || receive[0].Recovery != storage.KeyRecoverySeed ||
+		receive[0].DerivationPath != "m/84'/1'/0'/0/7" {
+		t.Errorf("receive usage = %+v, want seed path m/84'/1'/0'/0/7 on LTC", receive)
+	}
+
+	// Not derived by our wallet
+	refund := byPurpose[storage.KeyPurposeRefund]
+	if len(refund) != 1 || refund[0].Chain != "BTC" || refund[0].Recovery != storage.KeyRecoveryExternal {
+		t.Errorf("refund usage = %+v, want external address on BTC", refund)
+	}
+}
+
+func TestRecordFundingKeyUsage(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+	coord, _ := newAutoClaimCoordinator(t, store)
+
+	const changeAddr = "tb1qkeyusagechange"
+	if err := store.SaveWalletAddress(&storage.WalletAddress{
+		Address: changeAddr, Chain: "BTC", AddressIndex: 9, AddressType: "p2wpkh",
+	}); err != nil {
+		t.Fatalf("SaveWalletAddress() error = %v", err)
+	}
+
+	utxos := []*wallet.AddressUTXO{
+		{TxID: "aa", Vout: 0, Address: "tb1qinput", AddressIndex: 2},
+		{TxID: "aa", Vout: 1, Address: "tb1qinput", AddressIndex: 2},
+		{TxID: "bb", Vout: 0, Address: "tb1qunused", AddressIndex: 3},
+	}
+	coord.recordFundingKeyUsageUnlocked("initiator", "BTC", utxos, []string{"aa:0", "aa:1"}, changeAddr, 5000)
+
+	got, err := store.GetSwapKeyUsage("initiator")
+	if err != nil {
+		t.Fatalf("GetSwapKeyUsage() error = %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("recorded %d usages, want input and change: %+v", len(got), got)
+	}
+	if got[0].Purpose != storage.KeyPurposeFundingInput || got[0].Address != "tb1qinput" ||
+		got[0].DerivationPath != "m/84'/1'/0'/0/2" || got[0].Recovery != storage.KeyRecoverySeed {
+		t.Errorf("funding input usage = %+v", got[0])
+	}
+	if got[1].Purpose != storage.KeyPurposeChange || got[1].DerivationPath != "m/84'/1'/0'/0/9" {
+		t.Errorf("change usage = %+v", got[1])
+	}
+}
@@ -97,6 +97,12 @@ func (c *Coordinator) SetRemotePubKey(tradeID string, remotePubKey []byte) error
 	}
 
 	// MuSig2: Set remote pubkey in both chain sessions
+	if _, err := musig2Sessions(active); err != nil {
+		return err
+	}
+	if active.MuSig2.LocalPrivKey == nil {
+		return errors.New("swap recovered from database without ephemeral key - cannot continue")
+	}
 	if err := active.MuSig2.OfferChain.Session.SetRemotePubKey(remotePub); err != nil {
 		return fmt.Errorf("failed to set remote pubkey in offer session: %w", err)
 	}
@@ -196,6 +202,9 @@ func (c *Coordinator) GetTaprootAddresses(tradeID string) (offerAddr, requestAdd
 	if !ok {
 		return "", "", ErrSwapNotFound
 	}
+	if active.MuSig2 == nil || active.MuSig2.OfferChain == nil || active.MuSig2.RequestChain == nil {
+		return "", "", ErrNoMuSig2Session
+	}
 
 	return active.MuSig2.OfferChain.TaprootAddress, active.MuSig2.RequestChain.TaprootAddress, nil
 }
@@ -234,6 +243,13 @@ func (c *Coordinator) GetSwapAddresses(tradeID string) (offerAddr, requestAddr s
 		return "", "", ErrSwapNotFound
 	}
 
+	offerAddr, requestAddr = escrowAddressesUnlocked(active)
+	return offerAddr, requestAddr, nil
+}
+
+// escrowAddressesUnlocked returns the escrow addresses of a swap. Caller must
+// hold c.mu.
+func escrowAddressesUnlocked(active *ActiveSwap) (offerAddr, requestAddr string) {
 	if active.IsMuSig2() && active.MuSig2 != nil {
 		if active.MuSig2.OfferChain != nil {
 			offerAddr = active.MuSig2.OfferChain.TaprootAddress
@@ -248,7 +264,17 @@ func (c *Coordinator) GetSwapAddresses(tradeID string) (offerAddr, requestAddr s
 		if active.HTLC.RequestChain != nil {
 			requestAddr = active.HTLC.RequestChain.HTLCAddress
 		}
+	} else if active.IsXMR() && active.XMR.Scripts != nil {
+		// The BTC lock output and the shared XMR address
+		x, network := active.XMR, active.Swap.Network
+		btcAddr, _ := x.Scripts.LockAddress(x.BTCChain, network)
+		btcShare, xmrShare := x.shares()
+		xmrAddr, _ := XMRSharedAddress(btcShare, xmrShare, network)
+		offerAddr, requestAddr = btcAddr, xmrAddr
+		if x.BTCChain == active.Swap.Offer.RequestChain {
+			offerAddr, requestAddr = xmrAddr, btcAddr
+		}
 	}
 
-	return offerAddr, requestAddr, nil
+	return offerAddr, requestAddr
 }
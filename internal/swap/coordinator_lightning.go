@@ -0,0 +1,519 @@
+// Package swap - Lightning settlement of the BTC leg (submarine swaps).
+//
+// In an HTLC swap with BTC as the request chain, the taker can pay the BTC
+// over Lightning instead of funding an on-chain HTLC. The maker issues an
+// invoice for its swap secret (CreateLightningInvoice) and sends it to the
+// taker. The taker pays it only after checking that it is locked to the
+// swap's secret hash and amount and that the maker's offer-chain HTLC is
+// funded, confirmed and far enough from its refund (PayLightningInvoice).
+// The payment returns the secret, which claims the maker's leg on chain;
+// settling the invoice is the maker's claim.
+//
+// The maker has no on-chain claim to pay its DAO fee from: its invoice is
+// short of the fee, and the taker pays it to the DAO on its offer-chain
+// claim instead (lightningInvoiceAmount, claimDAOFee).
+package swap
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/lightning"
+)
+
+// LightningChain is the chain whose leg can settle over Lightning.
+const LightningChain = "BTC"
+
+// SettlementLightning is the order settlement option of makers that accept
+// the BTC request leg over Lightning.
+const SettlementLightning = "lightning"
+
+// Lightning settlement events.
+const (
+	EventLightningInvoiceCreated  = "lightning_invoice_created"  // Maker issued the invoice
+	EventLightningInvoiceReceived = "lightning_invoice_received" // Taker received it
+	EventLightningPaid            = "lightning_paid"             // Taker paid and learned the secret
+	EventLightningSettled         = "lightning_settled"          // Maker was paid
+	EventLightningCanceled        = "lightning_invoice_canceled" // Invoice expired unpaid
+)
+
+// Lightning leg states.
+const (
+	LightningIssued   = "issued"   // Maker: invoice open
+	LightningSettled  = "settled"  // Maker: invoice paid
+	LightningCanceled = "canceled" // Maker: invoice expired or canceled
+	LightningReceived = "received" // Taker: invoice to pay
+	LightningPaying   = "paying"   // Taker: payment in flight
+	LightningPaid     = "paid"     // Taker: paid, secret known
+)
+
+// ErrLightningSettlement is returned when the node is asked to fund a leg
+// that settles over Lightning.
+var ErrLightningSettlement = errors.New("swap leg settles over Lightning")
+
+// lightningPollInterval is how often issued invoices are looked up.
+const lightningPollInterval = 5 * time.Second
+
+// LightningLeg is the Lightning invoice standing in for the BTC HTLC.
+type LightningLeg struct {
+	Invoice     string `json:"invoice"`
+	PaymentHash string `json:"payment_hash"`
+	AmountSat   uint64 `json:"amount_sat"`
+	ExpiresAt   int64  `json:"expires_at,omitempty"`
+	State       string `json:"state"`
+	FeeSat      uint64 `json:"fee_sat,omitempty"` // Routing fee paid
+	Error       string `json:"error,omitempty"`   // Last payment failure
+}
+
+// SettlesOverLightning returns true once the taker pays the BTC leg over
+// Lightning instead of funding it on chain.
+func (s *Swap) SettlesOverLightning() bool {
+	return s.Lightning != nil && (s.Lightning.State == LightningPaying || s.Lightning.State == LightningPaid)
+}
+
+// lightningInvoiceAmount returns the amount of the maker's invoice: the
+// request amount less the maker's DAO fee on it.
+func lightningInvoiceAmount(o *Offer) (uint64, error) {
+	fee := o.DAOFeeOnChain(o.RequestChain, true)
+	if fee >= o.RequestAmount {
+		return 0, fmt.Errorf("request amount %d doesn't cover the DAO fee %d", o.RequestAmount, fee)
+	}
+	return o.RequestAmount - fee, nil
+}
+
+// claimDAOFee returns the DAO fee paid on our claim of the leg of
+// chainSymbol. A taker paying over Lightning also pays the maker's DAO fee,
+// at the maker's rate on the offer amount.
+func (s *Swap) claimDAOFee(chainSymbol string) uint64 {
+	isMaker := s.Role == RoleInitiator
+	fee := s.Offer.DAOFeeOnChain(chainSymbol, isMaker)
+	if !isMaker && chainSymbol == s.Offer.OfferChain && s.SettlesOverLightning() {
+		fee += s.Offer.FeeTerms.DAOFee(s.Offer.OfferAmount, true)
+	}
+	return fee
+}
+
+// SetLightning connects the coordinator to a Lightning node.
+func (c *Coordinator) SetLightning(client lightning.Client, cfg lightning.Config) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lightning = client
+	c.lightningCfg = cfg
+}
+
+// LightningEnabled returns true when a Lightning node is connected.
+func (c *Coordinator) LightningEnabled() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.lightning != nil
+}
+
+// checkLightningSwapUnlocked checks that a swap's request leg can settle
+// over Lightning. Caller must hold c.mu.
+func (c *Coordinator) checkLightningSwapUnlocked(active *ActiveSwap, role Role) error {
+	if c.lightning == nil {
+		return errors.New("lightning not configured")
+	}
+	if !active.IsHTLC() || active.IsCrossChain() {
+		return errors.New("lightning settlement needs an HTLC swap")
+	}
+	if active.Swap.Offer.RequestChain != LightningChain {
+		return fmt.Errorf("only a %s request leg settles over Lightning", LightningChain)
+	}
+	if active.Swap.Role != role {
+		return fmt.Errorf("only the %s can do this", role)
+	}
+	if active.Swap.IsTerminal() {
+		return fmt.Errorf("swap is %s", active.Swap.State)
+	}
+	return nil
+}
+
+// CreateLightningInvoice issues the maker's invoice for the BTC leg, locked
+// to the swap secret, and emits it for the taker. Calling it again returns
+// the same invoice.
+func (c *Coordinator) CreateLightningInvoice(ctx context.Context, tradeID string) (*LightningLeg, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if err := c.checkLightningSwapUnlocked(active, RoleInitiator); err != nil {
+		return nil, err
+	}
+	s := active.Swap
+	if s.Lightning != nil && s.Lightning.State != LightningCanceled {
+		leg := *s.Lightning
+		return &leg, nil
+	}
+	if s.RemoteFundingTxID != "" {
+		return nil, errors.New("the taker already funded the BTC leg on chain")
+	}
+	if len(s.Secret) != 32 {
+		return nil, errors.New("swap secret not available")
+	}
+
+	amount, err := lightningInvoiceAmount(&s.Offer)
+	if err != nil {
+		return nil, err
+	}
+	expiry := c.lightningCfg.InvoiceExpiry
+	inv, err := c.lightning.AddInvoice(ctx, s.Secret, amount, expiry, "klingdex swap "+tradeID)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(inv.PaymentHash, s.SecretHash) {
+		return nil, errors.New("lightning node returned an invoice for another payment hash")
+	}
+
+	s.Lightning = &LightningLeg{
+		Invoice:     inv.PaymentRequest,
+		PaymentHash: hex.EncodeToString(inv.PaymentHash),
+		AmountSat:   inv.AmountSat,
+		ExpiresAt:   time.Now().Add(expiry).Unix(),
+		State:       LightningIssued,
+	}
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+	c.emitEvent(tradeID, EventLightningInvoiceCreated, map[string]interface{}{
+		"invoice":      inv.PaymentRequest,
+		"payment_hash": s.Lightning.PaymentHash,
+		"amount_sat":   inv.AmountSat,
+	})
+	go c.watchLightningInvoice(tradeID, inv.PaymentHash)
+
+	leg := *s.Lightning
+	return &leg, nil
+}
+
+// watchLightningInvoice waits for the maker's invoice to settle or expire.
+func (c *Coordinator) watchLightningInvoice(tradeID string, paymentHash []byte) {
+	ticker := time.NewTicker(lightningPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		if c.checkLightningInvoice(tradeID, paymentHash) {
+			return
+		}
+	}
+}
+
+// checkLightningInvoice looks up the maker's invoice and records a
+// settlement. It returns true when there is nothing left to watch.
+func (c *Coordinator) checkLightningInvoice(tradeID string, paymentHash []byte) (done bool) {
+	c.mu.RLock()
+	client := c.lightning
+	c.mu.RUnlock()
+	if client == nil {
+		return true
+	}
+
+	ctx, cancel := context.WithTimeout(c.ctx, 30*time.Second)
+	defer cancel()
+	inv, err := client.LookupInvoice(ctx, paymentHash)
+	if err != nil {
+		c.log.Debug("Failed to look up lightning invoice", "trade_id", tradeID, "error", err)
+		return errors.Is(err, lightning.ErrInvoiceNotFound)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active, ok := c.swaps[tradeID]
+	if !ok || active.Swap.Lightning == nil || active.Swap.Lightning.State != LightningIssued {
+		return true
+	}
+	s := active.Swap
+	switch inv.State {
+	case lightning.InvoiceSettled:
+		// The BTC leg is ours; the taker claims the offer leg with the secret
+		s.Lightning.State = LightningSettled
+		s.State = StateRedeemed
+		c.emitEvent(tradeID, EventLightningSettled, map[string]interface{}{
+			"payment_hash": s.Lightning.PaymentHash,
+			"amount_sat":   inv.AmountSat,
+		})
+	case lightning.InvoiceCanceled:
+		s.Lightning.State = LightningCanceled
+		c.emitEvent(tradeID, EventLightningCanceled, map[string]interface{}{
+			"payment_hash": s.Lightning.PaymentHash,
+		})
+	default:
+		return false
+	}
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+	return true
+}
+
+// resumeLightningUnlocked restarts the watch of a maker's open invoice after
+// a restart. Caller must hold c.mu.
+func (c *Coordinator) resumeLightningUnlocked(tradeID string) {
+	active, ok := c.swaps[tradeID]
+	if !ok || active.Swap.Lightning == nil {
+		return
+	}
+	leg := active.Swap.Lightning
+	switch leg.State {
+	case LightningIssued:
+		if hash, err := hex.DecodeString(leg.PaymentHash); err == nil && c.lightning != nil {
+			go c.watchLightningInvoice(tradeID, hash)
+		}
+	case LightningPaying:
+		// The payment may have completed or still be in flight: the
+		// Lightning node knows, and its preimage claims the maker's leg
+		hash, err := hex.DecodeString(leg.PaymentHash)
+		if err != nil || len(hash) == 0 || c.lightning == nil {
+			// Paying the same invoice again is refused by the Lightning
+			// node, so the payment can safely be retried
+			leg.State = LightningReceived
+			leg.Error = "payment interrupted by a restart; check the Lightning node before retrying"
+			return
+		}
+		go c.recoverLightningPayment(tradeID, hash)
+	}
+}
+
+// recoverLightningPayment waits for a payment interrupted by a restart to
+// complete and finishes it like PayLightningInvoice: a succeeded payment
+// claims the maker's leg, a failed or never made one can be retried.
+func (c *Coordinator) recoverLightningPayment(tradeID string, paymentHash []byte) {
+	c.mu.RLock()
+	client, cfg := c.lightning, c.lightningCfg
+	c.mu.RUnlock()
+
+	for {
+		ctx, cancel := context.WithTimeout(c.ctx, cfg.PaymentTimeout)
+		payment, err := client.TrackPayment(ctx, paymentHash)
+		cancel()
+		if c.ctx.Err() != nil {
+			return
+		}
+		if err == nil || errors.Is(err, lightning.ErrPaymentFailed) || errors.Is(err, lightning.ErrPaymentNotFound) {
+			if _, _, err := c.finishLightningPayment(c.ctx, tradeID, payment, err); err != nil {
+				c.log.Warn("Interrupted lightning payment did not complete", "trade_id", tradeID, "error", err)
+			}
+			return
+		}
+		c.log.Debug("Failed to track lightning payment", "trade_id", tradeID, "error", err)
+
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-time.After(lightningPollInterval):
+		}
+	}
+}
+
+// SetLightningInvoice records the maker's invoice on the taker's side.
+func (c *Coordinator) SetLightningInvoice(tradeID, invoice string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return ErrSwapNotFound
+	}
+	if err := c.checkLightningSwapUnlocked(active, RoleResponder); err != nil {
+		return err
+	}
+	s := active.Swap
+	if s.SettlesOverLightning() {
+		return errors.New("lightning payment already made")
+	}
+
+	s.Lightning = &LightningLeg{Invoice: invoice, State: LightningReceived}
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+	c.emitEvent(tradeID, EventLightningInvoiceReceived, map[string]interface{}{
+		"invoice": invoice,
+	})
+	return nil
+}
+
+// PayLightningInvoice pays the maker's invoice instead of funding the BTC
+// leg on chain, then claims the maker's offer-chain HTLC with the secret the
+// payment returns. The claim is best effort: on failure the secret is kept
+// and the usual claim paths retry it.
+func (c *Coordinator) PayLightningInvoice(ctx context.Context, tradeID string) (*LightningLeg, string, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	if c.cold {
+		c.mu.Unlock()
+		return nil, "", ErrColdMode
+	}
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		c.mu.Unlock()
+		return nil, "", ErrSwapNotFound
+	}
+	if err := c.checkLightningSwapUnlocked(active, RoleResponder); err != nil {
+		c.mu.Unlock()
+		return nil, "", err
+	}
+	client, cfg := c.lightning, c.lightningCfg
+	s := active.Swap
+	_, theirs := refundPointsUnlocked(active)
+	isTestnet := s.Network == chain.Testnet
+	c.mu.Unlock()
+
+	// Block heights are fetched without the lock
+	heights := c.deadlineHeights(ctx, map[string]uint32{}, theirs)
+
+	c.mu.Lock()
+	leg, err := c.checkLightningPaymentUnlocked(ctx, client, s, theirs, heights, isTestnet)
+	if err != nil {
+		c.mu.Unlock()
+		return nil, "", err
+	}
+	leg.State = LightningPaying
+	leg.Error = ""
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+	c.mu.Unlock()
+
+	payCtx, cancel := context.WithTimeout(ctx, cfg.PaymentTimeout)
+	payment, payErr := client.PayInvoice(payCtx, leg.Invoice, cfg.MaxFee(leg.AmountSat))
+	cancel()
+	return c.finishLightningPayment(ctx, tradeID, payment, payErr)
+}
+
+// finishLightningPayment records the outcome of the taker's payment. A
+// succeeded payment stores the secret and claims the maker's offer-chain
+// HTLC; on failure the invoice can be paid again.
+func (c *Coordinator) finishLightningPayment(ctx context.Context, tradeID string, payment *lightning.Payment, payErr error) (*LightningLeg, string, error) {
+	c.mu.Lock()
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		c.mu.Unlock()
+		return nil, "", ErrSwapNotFound
+	}
+	s := active.Swap
+	leg := s.Lightning
+	if leg == nil || leg.State != LightningPaying {
+		c.mu.Unlock()
+		return nil, "", errors.New("no lightning payment in flight")
+	}
+	if payErr == nil && !s.VerifySecret(payment.Preimage) {
+		payErr = errors.New("payment returned a preimage that doesn't match the secret hash")
+	}
+	if payErr != nil {
+		leg.State = LightningReceived
+		leg.Error = payErr.Error()
+		if c.store != nil {
+			_ = c.saveSwapState(tradeID)
+		}
+		c.mu.Unlock()
+		return nil, "", payErr
+	}
+
+	leg.State = LightningPaid
+	leg.FeeSat = payment.FeeSat
+	c.emitEvent(tradeID, EventLightningPaid, map[string]interface{}{
+		"payment_hash": leg.PaymentHash,
+		"amount_sat":   leg.AmountSat,
+		"fee_sat":      leg.FeeSat,
+	})
+	result := *leg
+	c.mu.Unlock()
+
+	// Verified above, so this only stores it in the swap and HTLC sessions
+	if err := c.SetRevealedSecret(tradeID, payment.Preimage); err != nil {
+		return nil, "", err
+	}
+	c.mu.Lock()
+	if c.store != nil {
+		_ = c.saveSwapState(tradeID)
+	}
+	c.mu.Unlock()
+
+	claimTxID, err := c.ClaimHTLC(ctx, tradeID, s.Offer.OfferChain)
+	if err != nil {
+		c.log.Warn("Failed to claim after lightning payment", "trade_id", tradeID, "error", err)
+	}
+	return &result, claimTxID, nil
+}
+
+// checkLightningPaymentUnlocked checks the maker's invoice before paying it
+// and fills in the leg. Caller must hold c.mu.
+func (c *Coordinator) checkLightningPaymentUnlocked(ctx context.Context, client lightning.Client, s *Swap, theirs refundPoint, heights map[string]uint32, isTestnet bool) (*LightningLeg, error) {
+	leg := s.Lightning
+	switch {
+	case leg == nil:
+		return nil, errors.New("no lightning invoice from the maker")
+	case leg.State == LightningPaying || leg.State == LightningPaid:
+		return nil, errors.New("lightning payment already made")
+	case s.LocalFundingTxID != "" || s.ExternalFunding:
+		return nil, errors.New("the BTC leg is already funded on chain")
+	}
+
+	// The maker's leg must be locked before the secret is bought
+	if status := s.GetRemoteFundingStatus(); status == nil || !status.IsFinal {
+		return nil, errors.New("the maker's offer-chain HTLC is not funded and confirmed yet")
+	}
+	deadlines := buildDeadlines(s.ID, refundPoint{}, theirs, heights, isTestnet, time.Now().Add(c.clockOffset))
+	claimable := false
+	for _, d := range deadlines.Deadlines {
+		// At is unset when the chain height is unknown
+		if d.Kind == DeadlineLatestClaim && !d.Passed && d.At > 0 {
+			claimable = true
+		}
+	}
+	if !claimable {
+		return nil, errors.New("can't claim the maker's leg before its refund; not paying")
+	}
+
+	dec, err := client.DecodeInvoice(ctx, leg.Invoice)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(dec.PaymentHash, s.SecretHash) {
+		return nil, errors.New("invoice is not locked to the swap's secret hash")
+	}
+	amount, err := lightningInvoiceAmount(&s.Offer)
+	if err != nil {
+		return nil, err
+	}
+	if dec.AmountSat != amount {
+		return nil, fmt.Errorf("invoice is for %d sat, the swap for %d", dec.AmountSat, amount)
+	}
+	if !time.Now().Before(dec.ExpiresAt()) {
+		return nil, errors.New("invoice expired")
+	}
+
+	leg.PaymentHash = hex.EncodeToString(dec.PaymentHash)
+	leg.AmountSat = dec.AmountSat
+	leg.ExpiresAt = dec.ExpiresAt().Unix()
+	return leg, nil
+}
+
+// GetLightningLeg returns the Lightning leg of a swap.
+func (c *Coordinator) GetLightningLeg(tradeID string) (*LightningLeg, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if active.Swap.Lightning == nil {
+		return nil, errors.New("swap has no lightning leg")
+	}
+	leg := *active.Swap.Lightning
+	return &leg, nil
+}
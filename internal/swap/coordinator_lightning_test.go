@@ -0,0 +1,354 @@
+package swap
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/lightning"
+)
+
+// fakeLightning is a Lightning node holding invoices in memory. Paying an
+// invoice it issued settles it.
+type fakeLightning struct {
+	mu       sync.Mutex
+	invoices map[string]*lightning.Invoice // Payment request -> invoice
+	preimage map[string][]byte
+	decoded  *lightning.DecodedInvoice // Overrides decoding when set
+	payErr   error
+	paid     int
+	tracked  map[string]*lightning.Payment // Payment hash (hex) -> completed payment
+}
+
+func newFakeLightning() *fakeLightning {
+	return &fakeLightning{invoices: make(map[string]*lightning.Invoice), preimage: make(map[string][]byte)}
+}
+
+func (f *fakeLightning) AddInvoice(ctx context.Context, preimage []byte, amountSat uint64, expiry time.Duration, memo string) (*lightning.Invoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	req := "lntb" + memo
+	inv := &lightning.Invoice{PaymentRequest: req, PaymentHash: HashSecret(preimage), AmountSat: amountSat, State: lightning.InvoiceOpen}
+	f.invoices[req] = inv
+	f.preimage[req] = preimage
+	return inv, nil
+}
+
+func (f *fakeLightning) LookupInvoice(ctx context.Context, paymentHash []byte) (*lightning.Invoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, inv := range f.invoices {
+		if bytes.Equal(inv.PaymentHash, paymentHash) {
+			cp := *inv
+			return &cp, nil
+		}
+	}
+	return nil, lightning.ErrInvoiceNotFound
+}
+
+func (f *fakeLightning) DecodeInvoice(ctx context.Context, req string) (*lightning.DecodedInvoice, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.decoded != nil {
+		return f.decoded, nil
+	}
+	inv, ok := f.invoices[req]
+	if !ok {
+		return nil, errors.New("invalid payment request")
+	}
+	return &lightning.DecodedInvoice{PaymentHash: inv.PaymentHash, AmountSat: inv.AmountSat, CreatedAt: time.Now(), Expiry: time.Hour}, nil
+}
+
+func (f *fakeLightning) PayInvoice(ctx context.Context, req string, maxFeeSat uint64) (*lightning.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.payErr != nil {
+		return nil, f.payErr
+	}
+	inv, ok := f.invoices[req]
+	if !ok {
+		return nil, lightning.ErrPaymentFailed
+	}
+	inv.State = lightning.InvoiceSettled
+	f.paid++
+	return &lightning.Payment{Preimage: f.preimage[req], FeeSat: 3}, nil
+}
+
+func (f *fakeLightning) TrackPayment(ctx context.Context, paymentHash []byte) (*lightning.Payment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	p, ok := f.tracked[hex.EncodeToString(paymentHash)]
+	if !ok {
+		return nil, lightning.ErrPaymentNotFound
+	}
+	return p, nil
+}
+
+var testLightningConfig = lightning.Config{MaxFeeBps: 50, InvoiceExpiry: time.Hour, PaymentTimeout: time.Minute}
+
+// newLightningSwap adds an HTLC swap of LTC for BTC to coord.
+func newLightningSwap(t *testing.T, coord *Coordinator, tradeID string, role Role, secret []byte) *Swap {
+	t.Helper()
+	s, err := NewSwap(chain.Testnet, MethodHTLC, role, Offer{
+		OfferChain: "LTC", OfferAmount: 10_000_000,
+		RequestChain: "BTC", RequestAmount: 50_000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	s.ID = tradeID
+	s.SecretHash = HashSecret(secret)
+	if role == RoleInitiator {
+		s.Secret = secret
+	}
+	coord.swaps[tradeID] = &ActiveSwap{Swap: s, HTLC: &HTLCSwapData{OfferChain: &ChainHTLCData{}, RequestChain: &ChainHTLCData{}}}
+	return s
+}
+
+// setFakeLightning connects coord to a fake Lightning node.
+func setFakeLightning(coord *Coordinator) *fakeLightning {
+	ln := newFakeLightning()
+	coord.SetLightning(ln, testLightningConfig)
+	return ln
+}
+
+func TestLightningSettlement(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	ln := setFakeLightning(coord)
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte{5}, 32)
+
+	maker := newLightningSwap(t, coord, "maker", RoleInitiator, secret)
+	taker := newLightningSwap(t, coord, "taker", RoleResponder, secret)
+
+	leg, err := coord.CreateLightningInvoice(ctx, "maker")
+	if err != nil {
+		t.Fatalf("CreateLightningInvoice() error = %v", err)
+	}
+	// The maker's DAO fee is left to the taker's claim
+	if leg.State != LightningIssued || leg.AmountSat != 50_000-CalculateDAOFee(50_000, true) {
+		t.Errorf("CreateLightningInvoice() = %+v", leg)
+	}
+	if again, _ := coord.CreateLightningInvoice(ctx, "maker"); again.Invoice != leg.Invoice {
+		t.Errorf("second CreateLightningInvoice() issued %s, want %s", again.Invoice, leg.Invoice)
+	}
+	if err := coord.SetLightningInvoice("maker", leg.Invoice); err == nil {
+		t.Error("SetLightningInvoice() accepted the maker's own swap")
+	}
+
+	if err := coord.SetLightningInvoice("taker", leg.Invoice); err != nil {
+		t.Fatalf("SetLightningInvoice() error = %v", err)
+	}
+
+	// Not paying before the maker's leg is locked
+	if _, _, err := coord.PayLightningInvoice(ctx, "taker"); err == nil || !strings.Contains(err.Error(), "not funded") {
+		t.Errorf("PayLightningInvoice() before funding error = %v", err)
+	}
+	taker.RemoteFundingTxID = "ltc-funding"
+	taker.RemoteFundingConfirms = 6
+	taker.OfferChainTimeoutHeight = 1200
+
+	got, _, err := coord.PayLightningInvoice(ctx, "taker")
+	if err != nil {
+		t.Fatalf("PayLightningInvoice() error = %v", err)
+	}
+	if got.State != LightningPaid || got.FeeSat != 3 || ln.paid != 1 {
+		t.Errorf("PayLightningInvoice() = %+v, %d payments", got, ln.paid)
+	}
+	if !bytes.Equal(taker.Secret, secret) {
+		t.Error("payment preimage not stored as the swap secret")
+	}
+	if _, err := coord.FundSwap(ctx, "taker"); !errors.Is(err, ErrLightningSettlement) {
+		t.Errorf("FundSwap() error = %v, want ErrLightningSettlement", err)
+	}
+	if _, _, err := coord.PayLightningInvoice(ctx, "taker"); err == nil {
+		t.Error("PayLightningInvoice() paid twice")
+	}
+
+	// The maker sees its invoice settle
+	if !coord.checkLightningInvoice("maker", maker.SecretHash) {
+		t.Error("checkLightningInvoice() still watching a settled invoice")
+	}
+	if maker.Lightning.State != LightningSettled || maker.State != StateRedeemed {
+		t.Errorf("maker leg = %s, swap %s, want settled, redeemed", maker.Lightning.State, maker.State)
+	}
+}
+
+func TestPayLightningInvoiceChecks(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	ln := setFakeLightning(coord)
+	ctx := context.Background()
+	secret := bytes.Repeat([]byte{6}, 32)
+
+	maker := newLightningSwap(t, coord, "maker", RoleInitiator, secret)
+	leg, err := coord.CreateLightningInvoice(ctx, "maker")
+	if err != nil {
+		t.Fatalf("CreateLightningInvoice() error = %v", err)
+	}
+
+	taker := newLightningSwap(t, coord, "taker", RoleResponder, secret)
+	taker.RemoteFundingTxID = "ltc-funding"
+	taker.RemoteFundingConfirms = 6
+	taker.OfferChainTimeoutHeight = 1200
+	if err := coord.SetLightningInvoice("taker", leg.Invoice); err != nil {
+		t.Fatalf("SetLightningInvoice() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		decoded *lightning.DecodedInvoice
+		setup   func()
+		want    string
+	}{
+		{"other hash", &lightning.DecodedInvoice{PaymentHash: make([]byte, 32), AmountSat: 50_000, CreatedAt: time.Now(), Expiry: time.Hour}, nil, "secret hash"},
+		{"other amount", &lightning.DecodedInvoice{PaymentHash: maker.SecretHash, AmountSat: 40_000, CreatedAt: time.Now(), Expiry: time.Hour}, nil, "40000 sat"},
+		{"full amount", &lightning.DecodedInvoice{PaymentHash: maker.SecretHash, AmountSat: 50_000, CreatedAt: time.Now(), Expiry: time.Hour}, nil, "50000 sat"},
+		{"expired", &lightning.DecodedInvoice{PaymentHash: maker.SecretHash, AmountSat: leg.AmountSat, CreatedAt: time.Now().Add(-2 * time.Hour), Expiry: time.Hour}, nil, "expired"},
+		// LTC testnet safety margin is 24 blocks
+		{"too close to refund", nil, func() { taker.OfferChainTimeoutHeight = 1020 }, "refund"},
+		{"funded on chain", nil, func() { taker.LocalFundingTxID = "btc-funding" }, "funded on chain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln.decoded = tt.decoded
+			if tt.setup != nil {
+				tt.setup()
+			}
+			_, _, err := coord.PayLightningInvoice(ctx, "taker")
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("PayLightningInvoice() error = %v, want %q", err, tt.want)
+			}
+			if ln.paid != 0 {
+				t.Errorf("paid an invoice that failed the checks")
+			}
+		})
+	}
+
+	// A failed payment can be retried
+	ln.decoded = nil
+	taker.OfferChainTimeoutHeight = 1200
+	taker.LocalFundingTxID = ""
+	ln.payErr = lightning.ErrPaymentFailed
+	if _, _, err := coord.PayLightningInvoice(ctx, "taker"); !errors.Is(err, lightning.ErrPaymentFailed) {
+		t.Errorf("PayLightningInvoice() error = %v, want ErrPaymentFailed", err)
+	}
+	if taker.Lightning.State != LightningReceived || taker.Lightning.Error == "" || taker.SettlesOverLightning() {
+		t.Errorf("leg after failed payment = %+v", taker.Lightning)
+	}
+}
+
+func TestLightningRequiresBTCRequestLeg(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	setFakeLightning(coord)
+	s := newLightningSwap(t, coord, "t1", RoleInitiator, bytes.Repeat([]byte{7}, 32))
+	s.Offer.OfferChain, s.Offer.RequestChain = "BTC", "LTC"
+	if _, err := coord.CreateLightningInvoice(context.Background(), "t1"); err == nil {
+		t.Error("CreateLightningInvoice() accepted an LTC request leg")
+	}
+
+	off := newTestCoordinator(t)
+	newLightningSwap(t, off, "t1", RoleInitiator, bytes.Repeat([]byte{7}, 32))
+	if off.LightningEnabled() {
+		t.Error("LightningEnabled() without a node")
+	}
+	if _, err := off.CreateLightningInvoice(context.Background(), "t1"); err == nil {
+		t.Error("CreateLightningInvoice() without a Lightning node")
+	}
+}
+
+func TestLightningDAOFee(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	setFakeLightning(coord)
+	maker := newLightningSwap(t, coord, "maker", RoleInitiator, bytes.Repeat([]byte{3}, 32))
+	taker := newLightningSwap(t, coord, "taker", RoleResponder, bytes.Repeat([]byte{3}, 32))
+	ownFee := CalculateDAOFee(10_000_000, false)
+	makerFee := CalculateDAOFee(10_000_000, true)
+
+	if got := taker.claimDAOFee("LTC"); got != ownFee {
+		t.Errorf("on-chain taker claim DAO fee = %d, want %d", got, ownFee)
+	}
+	taker.Lightning = &LightningLeg{State: LightningPaid}
+	if got := taker.claimDAOFee("LTC"); got != ownFee+makerFee {
+		t.Errorf("lightning taker claim DAO fee = %d, want %d", got, ownFee+makerFee)
+	}
+	if got := maker.claimDAOFee("BTC"); got != CalculateDAOFee(50_000, true) {
+		t.Errorf("maker claim DAO fee = %d", got)
+	}
+
+	// The maker paying both fees charges the taker's on its invoice too
+	taker.Offer.FeeTerms.DAOFeePayer = FeePayerMaker
+	if got := taker.claimDAOFee("LTC"); got != ownFee+makerFee {
+		t.Errorf("maker-paid lightning taker claim DAO fee = %d, want %d", got, ownFee+makerFee)
+	}
+	want := 50_000 - CalculateDAOFee(50_000, true) - CalculateDAOFee(50_000, false)
+	if got, err := lightningInvoiceAmount(&taker.Offer); err != nil || got != want {
+		t.Errorf("lightningInvoiceAmount() = %d, %v, want %d", got, err, want)
+	}
+
+	taker.Offer.RequestAmount = 100
+	if _, err := lightningInvoiceAmount(&taker.Offer); err == nil {
+		t.Error("lightningInvoiceAmount() accepted an amount below the DAO fee")
+	}
+}
+
+func TestResumeLightningPaying(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	setFakeLightning(coord)
+	s := newLightningSwap(t, coord, "t1", RoleResponder, bytes.Repeat([]byte{8}, 32))
+	s.Lightning = &LightningLeg{Invoice: "lntb1", State: LightningPaying}
+
+	coord.resumeLightningUnlocked("t1")
+	if s.Lightning.State != LightningReceived || s.Lightning.Error == "" {
+		t.Errorf("interrupted payment = %+v, want received with a note", s.Lightning)
+	}
+}
+
+func TestRecoverLightningPayment(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	ln := setFakeLightning(coord)
+	secret := bytes.Repeat([]byte{9}, 32)
+	hash := hex.EncodeToString(HashSecret(secret))
+
+	// Never made: the invoice can be paid again
+	s := newLightningSwap(t, coord, "t1", RoleResponder, secret)
+	s.Lightning = &LightningLeg{Invoice: "lntb1", PaymentHash: hash, State: LightningPaying}
+	coord.recoverLightningPayment("t1", HashSecret(secret))
+	if s.Lightning.State != LightningReceived || !strings.Contains(s.Lightning.Error, "not found") {
+		t.Errorf("never made payment = %+v, want received", s.Lightning)
+	}
+
+	// Completed while the node was down: the secret is recovered
+	ln.tracked = map[string]*lightning.Payment{hash: {Preimage: secret, FeeSat: 4}}
+	s.Lightning.State = LightningPaying
+	coord.recoverLightningPayment("t1", HashSecret(secret))
+	if s.Lightning.State != LightningPaid || s.Lightning.FeeSat != 4 || !bytes.Equal(s.Secret, secret) {
+		t.Errorf("completed payment = %+v, secret %x", s.Lightning, s.Secret)
+	}
+
+	// A preimage for another hash is not accepted
+	other := newLightningSwap(t, coord, "t2", RoleResponder, bytes.Repeat([]byte{1}, 32))
+	other.Lightning = &LightningLeg{Invoice: "lntb2", PaymentHash: hash, State: LightningPaying}
+	coord.recoverLightningPayment("t2", HashSecret(secret))
+	if other.Lightning.State != LightningReceived || len(other.Secret) != 0 {
+		t.Errorf("mismatched preimage = %+v", other.Lightning)
+	}
+}
@@ -0,0 +1,263 @@
+// Package swap - Relayed EVM claims for receivers without native gas.
+//
+// The receiver of an EVM leg signs a claim authorization and hands it, with
+// the secret, to a relayer (the counterparty or a third party). The relayer
+// submits claimWithSignature, pays the gas and keeps the relayer fee out of
+// the receiver's amount. The fee the counterparty charges is negotiated in
+// the offer's FeeTerms.MetaClaimFeeBps.
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// metaClaimTimelockMargin keeps authorizations from outliving the point at
+// which the claim could still be mined before the HTLC times out.
+const metaClaimTimelockMargin = 10 * time.Minute
+
+// EVMMetaClaim is a receiver's signed claim of an EVM HTLC, ready for a
+// relayer to submit.
+type EVMMetaClaim struct {
+	Chain      string `json:"chain"`
+	SwapID     string `json:"swap_id"`     // Hex
+	Secret     string `json:"secret"`      // Hex
+	Relayer    string `json:"relayer"`     // Allowed submitter; zero address lets anyone submit
+	RelayerFee string `json:"relayer_fee"` // Smallest unit, decimal
+	Deadline   int64  `json:"deadline"`    // Unix seconds
+	Signature  string `json:"signature"`   // Hex, 65 bytes
+}
+
+// authorization decodes the claim into the contract binding's form.
+func (m *EVMMetaClaim) authorization() (*htlc.ClaimAuthorization, [32]byte, error) {
+	var secret [32]byte
+	swapID, err := decodeHex32(m.SwapID)
+	if err != nil {
+		return nil, secret, fmt.Errorf("invalid swap_id: %w", err)
+	}
+	if secret, err = decodeHex32(m.Secret); err != nil {
+		return nil, secret, fmt.Errorf("invalid secret: %w", err)
+	}
+	if !common.IsHexAddress(m.Relayer) {
+		return nil, secret, fmt.Errorf("invalid relayer address %q", m.Relayer)
+	}
+	fee, ok := new(big.Int).SetString(m.RelayerFee, 10)
+	if !ok || fee.Sign() < 0 {
+		return nil, secret, fmt.Errorf("invalid relayer_fee %q", m.RelayerFee)
+	}
+	sig, err := hex.DecodeString(m.Signature)
+	if err != nil || len(sig) != 65 {
+		return nil, secret, fmt.Errorf("invalid signature")
+	}
+	return &htlc.ClaimAuthorization{
+		SwapID:     swapID,
+		Relayer:    common.HexToAddress(m.Relayer),
+		RelayerFee: fee,
+		Deadline:   big.NewInt(m.Deadline),
+		Signature:  sig,
+	}, secret, nil
+}
+
+func decodeHex32(s string) ([32]byte, error) {
+	var out [32]byte
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return out, err
+	}
+	if len(b) != 32 {
+		return out, fmt.Errorf("expected 32 bytes, got %d", len(b))
+	}
+	copy(out[:], b)
+	return out, nil
+}
+
+// AuthorizeEVMMetaClaim signs a claim of our EVM leg on chainSymbol for a
+// relayer. A nil relayer is the counterparty; the zero address lets anyone
+// submit. A nil relayerFee is the negotiated meta-claim fee. The
+// authorization expires after validFor, or earlier near the HTLC timelock.
+//
+// The relayer learns the secret. As initiator we hold the secret until our
+// claim reveals it, so relaying through the counterparty (or letting anyone
+// submit) is refused: it could claim our funds and leave our claim
+// unsubmitted. The HTLC contract must support claimWithSignature.
+func (c *Coordinator) AuthorizeEVMMetaClaim(ctx context.Context, tradeID, chainSymbol string, relayer *common.Address, relayerFee *big.Int, validFor time.Duration) (*EVMMetaClaim, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if !IsEVMChain(chainSymbol, c.network) {
+		return nil, fmt.Errorf("chain %s is not an EVM chain", chainSymbol)
+	}
+	if chainSymbol == localLegChain(active.Swap) {
+		return nil, fmt.Errorf("we fund %s; only the receiver authorizes a claim", chainSymbol)
+	}
+	if err := c.checkContractsActiveUnlocked(chainSymbol); err != nil {
+		return nil, err
+	}
+
+	evmSession, err := c.getEVMSession(active, chainSymbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get EVM session: %w", err)
+	}
+	onChain, err := evmSession.GetSwapFromChain(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read EVM HTLC: %w", err)
+	}
+	if !onChain.IsActive() {
+		return nil, fmt.Errorf("EVM HTLC is %s", onChain.State)
+	}
+
+	if relayer == nil {
+		relayer = &onChain.Sender
+	}
+	if err := checkMetaClaimRelayer(active.Swap.Role, *relayer, onChain.Sender, evmSession.GetRemoteAddress()); err != nil {
+		return nil, err
+	}
+	supported, err := evmSession.SupportsMetaClaims(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check the HTLC contract: %w", err)
+	}
+	if !supported {
+		return nil, fmt.Errorf("the %s HTLC contract does not support relayed claims", chainSymbol)
+	}
+	if relayerFee == nil {
+		terms := active.Swap.Offer.FeeTerms
+		if terms.MetaClaimFeeBps == 0 {
+			return nil, fmt.Errorf("no meta-claim fee was negotiated; give a relayer fee")
+		}
+		relayerFee = terms.MetaClaimFee(new(big.Int).Sub(onChain.Amount, onChain.DaoFee))
+	}
+
+	secret, err := c.getSecretFromSwap(active)
+	if err != nil {
+		return nil, fmt.Errorf("secret not available for claim: %w", err)
+	}
+
+	deadline := time.Now().Add(validFor).Unix()
+	if latest := onChain.Timelock.Int64() - int64(metaClaimTimelockMargin/time.Second); latest < deadline {
+		deadline = latest
+	}
+	if deadline <= time.Now().Unix() {
+		return nil, fmt.Errorf("EVM HTLC is too close to its timelock for a relayed claim")
+	}
+
+	auth, err := evmSession.AuthorizeClaim(*relayer, relayerFee, deadline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to authorize claim: %w", err)
+	}
+
+	c.log.Info("Authorized relayed EVM claim",
+		"trade_id", tradeID,
+		"chain", chainSymbol,
+		"relayer", relayer.Hex(),
+		"relayer_fee", relayerFee.String(),
+	)
+	c.emitEvent(tradeID, "evm_meta_claim_authorized", map[string]interface{}{
+		"chain":       chainSymbol,
+		"relayer":     relayer.Hex(),
+		"relayer_fee": relayerFee.String(),
+		"deadline":    deadline,
+	})
+
+	return &EVMMetaClaim{
+		Chain:      chainSymbol,
+		SwapID:     hex.EncodeToString(auth.SwapID[:]),
+		Secret:     hex.EncodeToString(secret[:]),
+		Relayer:    relayer.Hex(),
+		RelayerFee: relayerFee.String(),
+		Deadline:   deadline,
+		Signature:  hex.EncodeToString(auth.Signature),
+	}, nil
+}
+
+// checkMetaClaimRelayer refuses, for the initiator, a relayer that could be
+// the counterparty: its HTLC sender or EVM address, or the zero address that
+// lets anyone submit. The initiator's claim is the first to reveal the
+// secret, so the counterparty must not learn it first.
+func checkMetaClaimRelayer(role Role, relayer common.Address, counterparty ...common.Address) error {
+	if role != RoleInitiator {
+		return nil
+	}
+	if relayer == (common.Address{}) {
+		return fmt.Errorf("the initiator must name a third-party relayer; the zero address lets the counterparty submit")
+	}
+	for _, addr := range counterparty {
+		if addr != (common.Address{}) && relayer == addr {
+			return fmt.Errorf("the initiator must not reveal the secret to the counterparty; use a third-party relayer")
+		}
+	}
+	return nil
+}
+
+// RelayEVMMetaClaim submits the counterparty's signed claim of the EVM HTLC
+// we funded, paying the gas for the negotiated meta-claim fee. The caller
+// passes the claim's secret to SetRevealedSecret to claim our own leg.
+func (c *Coordinator) RelayEVMMetaClaim(ctx context.Context, tradeID string, claim *EVMMetaClaim) (common.Hash, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return common.Hash{}, ErrSwapNotFound
+	}
+	if !IsEVMChain(claim.Chain, c.network) {
+		return common.Hash{}, fmt.Errorf("chain %s is not an EVM chain", claim.Chain)
+	}
+	if claim.Chain != localLegChain(active.Swap) {
+		return common.Hash{}, fmt.Errorf("we do not fund %s", claim.Chain)
+	}
+	if err := c.checkContractsActiveUnlocked(claim.Chain); err != nil {
+		return common.Hash{}, err
+	}
+	terms := active.Swap.Offer.FeeTerms
+	if terms.MetaClaimFeeBps == 0 {
+		return common.Hash{}, fmt.Errorf("no meta-claim fee was negotiated")
+	}
+
+	auth, secret, err := claim.authorization()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	evmSession, err := c.getEVMSession(active, claim.Chain)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to get EVM session: %w", err)
+	}
+	onChain, err := evmSession.GetSwapFromChain(ctx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to read EVM HTLC: %w", err)
+	}
+	minFee := terms.MetaClaimFee(new(big.Int).Sub(onChain.Amount, onChain.DaoFee))
+
+	txHash, err := c.runEVMJob(ctx, JobActionEVMClaim, tradeID, claim.Chain, func(ctx context.Context) (common.Hash, error) {
+		return evmSession.ClaimWithSignature(ctx, secret, auth, minFee)
+	})
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to relay EVM claim: %w", err)
+	}
+
+	c.log.Info("Relayed EVM claim",
+		"trade_id", tradeID,
+		"chain", claim.Chain,
+		"tx_hash", txHash.Hex(),
+		"relayer_fee", auth.RelayerFee.String(),
+	)
+	c.emitEvent(tradeID, "evm_meta_claim_relayed", map[string]interface{}{
+		"chain":       claim.Chain,
+		"tx_hash":     txHash.Hex(),
+		"relayer_fee": auth.RelayerFee.String(),
+	})
+
+	return txHash, nil
+}
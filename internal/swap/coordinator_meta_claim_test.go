@@ -0,0 +1,117 @@
+package swap
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+func newTestEVMMetaClaim() *EVMMetaClaim {
+	return &EVMMetaClaim{
+		Chain:      "ETH",
+		SwapID:     strings.Repeat("01", 32),
+		Secret:     strings.Repeat("02", 32),
+		Relayer:    "0x5E1A000000000000000000000000000000005E1A",
+		RelayerFee: "2500000000000000",
+		Deadline:   1900000000,
+		Signature:  strings.Repeat("03", 65),
+	}
+}
+
+func TestEVMMetaClaimAuthorization(t *testing.T) {
+	auth, secret, err := newTestEVMMetaClaim().authorization()
+	if err != nil {
+		t.Fatalf("authorization() error = %v", err)
+	}
+	if auth.SwapID[0] != 1 || secret[0] != 2 || len(auth.Signature) != 65 {
+		t.Errorf("authorization() = %+v, secret %x", auth, secret)
+	}
+	if auth.RelayerFee.String() != "2500000000000000" || auth.Deadline.Int64() != 1900000000 {
+		t.Errorf("fee %s, deadline %s", auth.RelayerFee, auth.Deadline)
+	}
+
+	tests := []struct {
+		name string
+		edit func(m *EVMMetaClaim)
+	}{
+		{"short swap id", func(m *EVMMetaClaim) { m.SwapID = "01" }},
+		{"bad secret", func(m *EVMMetaClaim) { m.Secret = "zz" }},
+		{"bad relayer", func(m *EVMMetaClaim) { m.Relayer = "relayer" }},
+		{"negative fee", func(m *EVMMetaClaim) { m.RelayerFee = "-1" }},
+		{"short signature", func(m *EVMMetaClaim) { m.Signature = strings.Repeat("03", 64) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claim := newTestEVMMetaClaim()
+			tt.edit(claim)
+			if _, _, err := claim.authorization(); err == nil {
+				t.Error("authorization() accepted an invalid claim")
+			}
+		})
+	}
+}
+
+func TestEVMMetaClaimLegs(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleInitiator, Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "ETH", RequestAmount: 1_000_000_000_000_000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	coord.swaps["t1"] = &ActiveSwap{Swap: s}
+	ctx := context.Background()
+
+	if _, err := coord.AuthorizeEVMMetaClaim(ctx, "t1", "BTC", nil, nil, 0); err == nil {
+		t.Error("AuthorizeEVMMetaClaim() accepted a non-EVM chain")
+	}
+	if _, err := coord.AuthorizeEVMMetaClaim(ctx, "missing", "ETH", nil, nil, 0); err != ErrSwapNotFound {
+		t.Errorf("AuthorizeEVMMetaClaim(missing) error = %v, want ErrSwapNotFound", err)
+	}
+
+	// We receive on ETH, so we never relay a claim of it
+	if _, err := coord.RelayEVMMetaClaim(ctx, "t1", newTestEVMMetaClaim()); err == nil || !strings.Contains(err.Error(), "do not fund") {
+		t.Errorf("RelayEVMMetaClaim() on the leg we receive error = %v", err)
+	}
+
+	// As responder we fund ETH, but only relay for a negotiated fee
+	s.Role = RoleResponder
+	if _, err := coord.RelayEVMMetaClaim(ctx, "t1", newTestEVMMetaClaim()); err == nil || !strings.Contains(err.Error(), "negotiated") {
+		t.Errorf("RelayEVMMetaClaim() without a negotiated fee error = %v", err)
+	}
+	if _, err := coord.AuthorizeEVMMetaClaim(ctx, "t1", "ETH", nil, nil, 0); err == nil || !strings.Contains(err.Error(), "only the receiver") {
+		t.Errorf("AuthorizeEVMMetaClaim() on the leg we fund error = %v", err)
+	}
+}
+
+func TestCheckMetaClaimRelayer(t *testing.T) {
+	sender := common.HexToAddress("0x5E4D000000000000000000000000000000005E4D")
+	remote := common.HexToAddress("0x4E30000000000000000000000000000000004E30")
+	third := common.HexToAddress("0x5E1A000000000000000000000000000000005E1A")
+	tests := []struct {
+		name    string
+		role    Role
+		relayer common.Address
+		wantErr bool
+	}{
+		{"initiator, third party", RoleInitiator, third, false},
+		{"initiator, HTLC sender", RoleInitiator, sender, true},
+		{"initiator, counterparty address", RoleInitiator, remote, true},
+		{"initiator, anyone", RoleInitiator, common.Address{}, true},
+		{"responder, HTLC sender", RoleResponder, sender, false},
+		{"responder, anyone", RoleResponder, common.Address{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkMetaClaimRelayer(tt.role, tt.relayer, sender, remote, common.Address{})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkMetaClaimRelayer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
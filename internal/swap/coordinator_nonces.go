@@ -19,6 +19,9 @@ func (c *Coordinator) GenerateNonces(tradeID string) (offerNonce, requestNonce [
 	if !ok {
 		return nil, nil, ErrSwapNotFound
 	}
+	if _, err := musig2Sessions(active); err != nil {
+		return nil, nil, err
+	}
 
 	// Generate nonces for offer chain
 	_, err = active.MuSig2.OfferChain.Session.GenerateNonces()
@@ -41,6 +44,7 @@ func (c *Coordinator) GenerateNonces(tradeID string) (offerNonce, requestNonce [
 		return nil, nil, fmt.Errorf("failed to get request chain public nonce: %w", err)
 	}
 	active.MuSig2.RequestChain.LocalNonce = requestPubNonce[:]
+	c.publishViewLocked(tradeID, active)
 
 	return offerPubNonce[:], requestPubNonce[:], nil
 }
@@ -54,6 +58,9 @@ func (c *Coordinator) SetRemoteNonces(tradeID string, offerNonce, requestNonce [
 	if !ok {
 		return ErrSwapNotFound
 	}
+	if _, err := musig2Sessions(active); err != nil {
+		return err
+	}
 
 	if len(offerNonce) != 66 {
 		return fmt.Errorf("invalid offer nonce size: expected 66, got %d", len(offerNonce))
@@ -71,6 +78,7 @@ func (c *Coordinator) SetRemoteNonces(tradeID string, offerNonce, requestNonce [
 
 	active.MuSig2.RequestChain.Session.SetRemoteNonce(requestNonceArr)
 	active.MuSig2.RequestChain.RemoteNonce = requestNonce
+	c.publishViewLocked(tradeID, active)
 
 	c.emitEvent(tradeID, "nonces_exchanged", nil)
 	return nil
@@ -0,0 +1,201 @@
+// Package swap - Deadlines and cancellation of in-flight swap operations.
+//
+// Every step that talks to a chain or waits on the counterparty runs as an
+// operation: its context gets the deadline configured for its kind on top
+// of the caller's, and swap_cancelOperation can abort it. Each operation is
+// also a span in the trade's trace. Aborting is safe
+// at any point: a broadcast whose job was already persisted stays pending
+// and the next attempt re-sends the same transaction, and a wait can simply
+// be started again.
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/Klingon-tech/klingdex/internal/tracing"
+)
+
+// Operation kinds, each with its own deadline.
+const (
+	OpBroadcast  = "broadcast"   // Building and broadcasting a transaction or contract call
+	OpChainQuery = "chain_query" // Reading confirmations and escrow state
+	OpSecretWait = "secret_wait" // Waiting for the counterparty to reveal the secret
+)
+
+// EventOperationCancelled is emitted when swap operations are aborted.
+const EventOperationCancelled = "swap_operation_cancelled"
+
+// ErrOperationCancelled is the cause of an operation aborted by
+// CancelOperations.
+var ErrOperationCancelled = errors.New("operation cancelled")
+
+// ErrOperationDeadline is the cause of an operation that ran out of time.
+var ErrOperationDeadline = errors.New("operation deadline exceeded")
+
+// OperationTimeouts bounds each kind of operation. Zero leaves the kind
+// bounded only by the caller's context.
+type OperationTimeouts struct {
+	Broadcast  time.Duration `yaml:"broadcast,omitempty" json:"broadcast,omitempty"`
+	ChainQuery time.Duration `yaml:"chain_query,omitempty" json:"chain_query,omitempty"`
+	SecretWait time.Duration `yaml:"secret_wait,omitempty" json:"secret_wait,omitempty"`
+}
+
+// Validate checks the timeouts.
+func (t *OperationTimeouts) Validate() error {
+	if t.Broadcast < 0 || t.ChainQuery < 0 || t.SecretWait < 0 {
+		return fmt.Errorf("operation_timeouts must not be negative")
+	}
+	return nil
+}
+
+func (t *OperationTimeouts) forKind(kind string) time.Duration {
+	switch kind {
+	case OpBroadcast:
+		return t.Broadcast
+	case OpChainQuery:
+		return t.ChainQuery
+	case OpSecretWait:
+		return t.SecretWait
+	}
+	return 0
+}
+
+// Operation describes an in-flight operation.
+type Operation struct {
+	ID        uint64 `json:"id"`
+	TradeID   string `json:"trade_id"`
+	Name      string `json:"name"` // e.g. "fund", "claim_htlc", "refund"
+	Kind      string `json:"kind"`
+	StartedAt int64  `json:"started_at"`
+	Deadline  int64  `json:"deadline,omitempty"` // Unix; 0 when only the caller bounds it
+}
+
+type operation struct {
+	Operation
+	ctx     context.Context
+	cancel  context.CancelCauseFunc
+	release context.CancelFunc
+	span    trace.Span
+	c       *Coordinator
+}
+
+// SetOperationTimeouts sets the per-kind operation deadlines.
+func (c *Coordinator) SetOperationTimeouts(timeouts OperationTimeouts) error {
+	if err := timeouts.Validate(); err != nil {
+		return err
+	}
+	c.opMu.Lock()
+	defer c.opMu.Unlock()
+	c.opTimeouts = timeouts
+	return nil
+}
+
+// beginOperation registers an operation on a trade and returns its context.
+// The caller must call end, and should pass its error through wrap.
+func (c *Coordinator) beginOperation(ctx context.Context, tradeID, name, kind string) (context.Context, *operation) {
+	c.opMu.Lock()
+	defer c.opMu.Unlock()
+
+	now := time.Now()
+	op := &operation{
+		Operation: Operation{TradeID: tradeID, Name: name, Kind: kind, StartedAt: now.Unix()},
+		c:         c,
+	}
+	ctx, op.span = tracing.StartTrade(ctx, "swap", tradeID, "swap."+name,
+		trace.WithAttributes(attribute.String("klingdex.operation.kind", kind)))
+	op.ctx, op.cancel = context.WithCancelCause(ctx)
+	op.release = func() {}
+	if timeout := c.opTimeouts.forKind(kind); timeout > 0 {
+		cause := fmt.Errorf("%w: %s after %s", ErrOperationDeadline, name, timeout)
+		op.ctx, op.release = context.WithTimeoutCause(op.ctx, timeout, cause)
+	}
+	if deadline, ok := op.ctx.Deadline(); ok {
+		op.Deadline = deadline.Unix()
+	}
+
+	c.nextOpID++
+	op.ID = c.nextOpID
+	if c.operations == nil {
+		c.operations = make(map[uint64]*operation)
+	}
+	c.operations[op.ID] = op
+	return op.ctx, op
+}
+
+// end unregisters the operation and releases its context.
+func (op *operation) end() {
+	op.c.opMu.Lock()
+	delete(op.c.operations, op.ID)
+	op.c.opMu.Unlock()
+	op.release()
+	op.cancel(nil)
+	op.span.End()
+}
+
+// wrap makes an error caused by cancellation or the deadline say so. A
+// backend error wrapping context.Canceled alone doesn't tell the operator
+// who cancelled.
+func (op *operation) wrap(err error) error {
+	if err != nil && op.ctx.Err() != nil {
+		if cause := context.Cause(op.ctx); !errors.Is(err, cause) {
+			err = fmt.Errorf("%w: %w", cause, err)
+		}
+	}
+	if err != nil {
+		op.span.RecordError(err)
+		op.span.SetStatus(codes.Error, err.Error())
+	}
+	return err
+}
+
+// Operations returns the in-flight operations of a trade, or of all trades
+// for an empty tradeID, oldest first.
+func (c *Coordinator) Operations(tradeID string) []Operation {
+	c.opMu.Lock()
+	defer c.opMu.Unlock()
+	ops := make([]Operation, 0)
+	for _, op := range c.operations {
+		if tradeID == "" || op.TradeID == tradeID {
+			ops = append(ops, op.Operation)
+		}
+	}
+	sort.Slice(ops, func(i, j int) bool { return ops[i].ID < ops[j].ID })
+	return ops
+}
+
+// CancelOperations aborts the in-flight operations of a trade, only the one
+// with the given ID if id is non-zero. Returns the aborted operations.
+func (c *Coordinator) CancelOperations(tradeID string, id uint64) []Operation {
+	c.opMu.Lock()
+	var cancelled []*operation
+	for _, op := range c.operations {
+		if op.TradeID == tradeID && (id == 0 || op.ID == id) {
+			cancelled = append(cancelled, op)
+		}
+	}
+	c.opMu.Unlock()
+
+	sort.Slice(cancelled, func(i, j int) bool { return cancelled[i].ID < cancelled[j].ID })
+	result := make([]Operation, 0, len(cancelled))
+	names := make([]string, 0, len(cancelled))
+	for _, op := range cancelled {
+		op.cancel(ErrOperationCancelled)
+		result = append(result, op.Operation)
+		names = append(names, op.Name)
+		c.log.Warn("Swap operation cancelled", "trade_id", tradeID, "operation", op.Name, "id", op.ID)
+	}
+	if len(result) > 0 {
+		c.emitEvent(tradeID, EventOperationCancelled, map[string]interface{}{
+			"operations": names,
+		})
+	}
+	return result
+}
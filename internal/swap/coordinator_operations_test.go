@@ -0,0 +1,98 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// stuckBackend never answers a broadcast before the context ends.
+type stuckBackend struct {
+	backend.Backend
+	started chan struct{}
+}
+
+func (b *stuckBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	close(b.started)
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func (b *stuckBackend) GetTransaction(ctx context.Context, txID string) (*backend.Transaction, error) {
+	return nil, backend.ErrTxNotFound
+}
+
+func TestBroadcastOnceDeadline(t *testing.T) {
+	store := newTestStore(t)
+	coord := newTestCoordinator(t, withStore(store), withBackend("BTC", newFakeChainBackend()))
+	if err := coord.SetOperationTimeouts(OperationTimeouts{Broadcast: 50 * time.Millisecond}); err != nil {
+		t.Fatalf("SetOperationTimeouts() error = %v", err)
+	}
+	stuck := &stuckBackend{started: make(chan struct{})}
+
+	_, err := coord.BroadcastOnce(context.Background(), stuck, JobActionRefund, "trade-1", "BTC", testTxHex(t, 1000))
+	if !errors.Is(err, ErrOperationDeadline) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("BroadcastOnce() error = %v, want deadline exceeded", err)
+	}
+
+	// The decision stays pending for the next attempt
+	job, err := store.GetJob(JobID(JobActionRefund, "trade-1", "BTC"))
+	if err != nil {
+		t.Fatalf("GetJob() error = %v", err)
+	}
+	if job.Status != storage.JobStatusPending {
+		t.Errorf("job status = %s, want pending", job.Status)
+	}
+	if ops := coord.Operations(""); len(ops) != 0 {
+		t.Errorf("Operations() = %+v after the broadcast returned", ops)
+	}
+}
+
+func TestCancelOperations(t *testing.T) {
+	coord := newTestCoordinator(t, withStore(newTestStore(t)), withBackend("BTC", newFakeChainBackend()))
+	stuck := &stuckBackend{started: make(chan struct{})}
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := coord.BroadcastOnce(context.Background(), stuck, JobActionClaim, "trade-1", "BTC", testTxHex(t, 1000))
+		errCh <- err
+	}()
+	<-stuck.started
+
+	ops := coord.Operations("trade-1")
+	if len(ops) != 1 || ops[0].Name != JobActionClaim || ops[0].Kind != OpBroadcast {
+		t.Fatalf("Operations() = %+v", ops)
+	}
+	if got := coord.CancelOperations("trade-2", 0); len(got) != 0 {
+		t.Errorf("CancelOperations(other trade) = %+v", got)
+	}
+	if got := coord.CancelOperations("trade-1", ops[0].ID+1); len(got) != 0 {
+		t.Errorf("CancelOperations(other id) = %+v", got)
+	}
+
+	if got := coord.CancelOperations("trade-1", ops[0].ID); len(got) != 1 {
+		t.Fatalf("CancelOperations() = %+v, want one", got)
+	}
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, ErrOperationCancelled) {
+			t.Errorf("BroadcastOnce() error = %v, want ErrOperationCancelled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("BroadcastOnce() did not return after cancellation")
+	}
+	if ops := coord.Operations("trade-1"); len(ops) != 0 {
+		t.Errorf("Operations() = %+v after cancellation", ops)
+	}
+}
+
+func TestOperationTimeoutsValidate(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{})
+	if err := coord.SetOperationTimeouts(OperationTimeouts{ChainQuery: -time.Second}); err == nil {
+		t.Error("SetOperationTimeouts() accepted a negative timeout")
+	}
+}
@@ -0,0 +1,362 @@
+// Package swap - HTLC contract pause awareness.
+//
+// The owner of the EVM HTLC contract can pause it. A paused contract rejects
+// new swaps (createSwapNative/createSwapERC20); claims and refunds are not
+// gated by the pause. The coordinator watches the Paused event on each EVM
+// chain, refuses new swaps on a paused chain, predicts the impact on every
+// in-flight swap with a leg there, and repeats the alert every AlertInterval
+// until the contract is unpaused.
+package swap
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+)
+
+// Contract pause events.
+const (
+	EventContractPaused      = "contract_paused"       // Contract paused, repeated every alert interval
+	EventContractUnpaused    = "contract_unpaused"     // Contract unpaused, new swaps resume
+	EventContractPauseImpact = "contract_pause_impact" // Predicted impact on an in-flight swap
+)
+
+// pauseWebhookTimeout bounds a webhook delivery.
+const pauseWebhookTimeout = 10 * time.Second
+
+// ContractPausePolicy is the node-wide contract pause monitoring configuration.
+type ContractPausePolicy struct {
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// PollInterval re-reads paused() as a fallback for RPC endpoints that
+	// can't subscribe to events (HTTP) and for missed events.
+	PollInterval time.Duration `yaml:"poll_interval,omitempty" json:"poll_interval,omitempty"`
+
+	// AlertInterval repeats the contract_paused alert while paused.
+	AlertInterval time.Duration `yaml:"alert_interval,omitempty" json:"alert_interval,omitempty"`
+
+	// WebhookURL receives every contract_* event as a JSON POST.
+	WebhookURL string `yaml:"webhook_url,omitempty" json:"webhook_url,omitempty"`
+}
+
+// PauseWatcher reports the pause state of a chain's HTLC contract.
+type PauseWatcher interface {
+	IsPaused(ctx context.Context) (bool, error)
+	WatchPaused(ctx context.Context) (<-chan bool, error)
+}
+
+// ContractPauseAlert is the data of contract_paused and contract_unpaused.
+type ContractPauseAlert struct {
+	Chain    string        `json:"chain"`
+	Paused   bool          `json:"paused"`
+	Since    int64         `json:"since"` // When we saw the pause or unpause (Unix)
+	InFlight []PauseImpact `json:"in_flight,omitempty"`
+}
+
+// PauseImpact predicts what a contract pause means for an in-flight swap.
+type PauseImpact struct {
+	TradeID string `json:"trade_id"`
+	Chain   string `json:"chain"`
+	State   State  `json:"state"`
+
+	// FundingBlocked is set when an HTLC on the paused chain (ours or the
+	// counterparty's) is not created yet and can't be until unpaused.
+	FundingBlocked bool   `json:"funding_blocked"`
+	ClaimPossible  bool   `json:"claim_possible"`
+	RefundPossible bool   `json:"refund_possible"`
+	Warning        string `json:"warning"`
+}
+
+// Validate checks the intervals.
+func (p *ContractPausePolicy) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.PollInterval <= 0 || p.AlertInterval <= 0 {
+		return fmt.Errorf("contract_pause.poll_interval and alert_interval must be positive")
+	}
+	return nil
+}
+
+// SetContractPausePolicy sets the contract pause monitoring policy.
+func (c *Coordinator) SetContractPausePolicy(policy ContractPausePolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.contractPause = policy
+	return nil
+}
+
+// IsContractPaused returns true if the chain's HTLC contract is paused.
+func (c *Coordinator) IsContractPaused(chainSymbol string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, paused := c.contractPaused[chainSymbol]
+	return paused
+}
+
+// PausedContracts returns the chains whose HTLC contract is paused.
+func (c *Coordinator) PausedContracts() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var chains []string
+	for symbol := range c.contractPaused {
+		chains = append(chains, symbol)
+	}
+	sort.Strings(chains)
+	return chains
+}
+
+// checkContractsActiveUnlocked returns ErrContractPaused if a chain's HTLC
+// contract is paused. Caller must hold c.mu.
+func (c *Coordinator) checkContractsActiveUnlocked(chains ...string) error {
+	for _, symbol := range chains {
+		if _, paused := c.contractPaused[symbol]; paused {
+			return fmt.Errorf("%w on %s", ErrContractPaused, symbol)
+		}
+	}
+	return nil
+}
+
+// checkContractsActive is checkContractsActiveUnlocked taking the lock.
+func (c *Coordinator) checkContractsActive(chains ...string) error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.checkContractsActiveUnlocked(chains...)
+}
+
+// StartContractPauseMonitor watches the HTLC contract of every EVM chain
+// with a backend, using the policy set with SetContractPausePolicy.
+func (c *Coordinator) StartContractPauseMonitor() {
+	c.mu.Lock()
+	policy := c.contractPause
+	if !policy.Enabled {
+		c.mu.Unlock()
+		return
+	}
+	if c.pauseWatchers == nil {
+		c.pauseWatchers = make(map[string]PauseWatcher)
+	}
+	for symbol, chainID := range chain.ListEVMChains(c.network) {
+		if _, ok := c.backends[symbol]; !ok || c.pauseWatchers[symbol] != nil {
+			continue
+		}
+		contract := config.GetHTLCContract(chainID)
+		if contract == (common.Address{}) {
+			continue
+		}
+		client, err := htlc.NewClient(c.getEVMRPCURL(symbol), contract)
+		if err != nil {
+			c.log.Warn("Contract pause monitor: failed to connect", "chain", symbol, "error", err)
+			continue
+		}
+		c.pauseWatchers[symbol] = client
+	}
+	watchers := make(map[string]PauseWatcher, len(c.pauseWatchers))
+	for symbol, w := range c.pauseWatchers {
+		watchers[symbol] = w
+	}
+	c.mu.Unlock()
+
+	for symbol, w := range watchers {
+		go c.watchContractPause(symbol, w, policy)
+	}
+	if len(watchers) > 0 {
+		c.log.Info("Contract pause monitor started", "chains", len(watchers))
+	}
+}
+
+// watchContractPause follows one contract's pause state: Paused events when
+// the endpoint supports subscriptions, polling otherwise.
+func (c *Coordinator) watchContractPause(symbol string, w PauseWatcher, policy ContractPausePolicy) {
+	check := func() {
+		paused, err := w.IsPaused(c.ctx)
+		if err != nil {
+			c.log.Debug("Failed to read contract pause state", "chain", symbol, "error", err)
+			return
+		}
+		c.setContractPaused(symbol, paused)
+	}
+	check()
+
+	events, err := w.WatchPaused(c.ctx)
+	if err != nil {
+		c.log.Debug("Paused event subscription unavailable, polling", "chain", symbol, "error", err)
+	}
+
+	poll := time.NewTicker(policy.PollInterval)
+	defer poll.Stop()
+	alert := time.NewTicker(policy.AlertInterval)
+	defer alert.Stop()
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case paused, ok := <-events:
+			if !ok {
+				events = nil // Subscription lost, keep polling
+				continue
+			}
+			c.setContractPaused(symbol, paused)
+		case <-poll.C:
+			check()
+		case <-alert.C:
+			c.mu.RLock()
+			if since, paused := c.contractPaused[symbol]; paused {
+				c.emitContractPauseUnlocked(symbol, true, since)
+			}
+			c.mu.RUnlock()
+		}
+	}
+}
+
+// setContractPaused records a chain's pause state and alerts on changes.
+func (c *Coordinator) setContractPaused(symbol string, paused bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, was := c.contractPaused[symbol]; was == paused {
+		return
+	}
+	now := time.Now().Unix()
+	if paused {
+		if c.contractPaused == nil {
+			c.contractPaused = make(map[string]int64)
+		}
+		c.contractPaused[symbol] = now
+		c.log.Warn("HTLC contract paused, refusing new swaps", "chain", symbol)
+	} else {
+		delete(c.contractPaused, symbol)
+		c.log.Info("HTLC contract unpaused", "chain", symbol)
+	}
+	c.emitContractPauseUnlocked(symbol, paused, now)
+}
+
+// emitContractPauseUnlocked emits the pause alert with the impact on each
+// in-flight swap, and posts it to the webhook. Caller must hold c.mu.
+func (c *Coordinator) emitContractPauseUnlocked(symbol string, paused bool, since int64) {
+	alert := &ContractPauseAlert{Chain: symbol, Paused: paused, Since: since}
+	event := EventContractUnpaused
+	if paused {
+		event = EventContractPaused
+		alert.InFlight = c.pauseImpactsUnlocked(symbol)
+		for _, impact := range alert.InFlight {
+			c.emitEvent(impact.TradeID, EventContractPauseImpact, impact)
+		}
+	}
+	c.emitEvent("", event, alert)
+	c.postPauseWebhook(event, alert)
+}
+
+// pauseImpactsUnlocked predicts the impact of a pause on in-flight swaps with
+// a leg on the chain. Caller must hold c.mu.
+func (c *Coordinator) pauseImpactsUnlocked(symbol string) []PauseImpact {
+	var impacts []PauseImpact
+	for tradeID, active := range c.swaps {
+		s := active.Swap
+		if s.State != StateInit && s.State != StateFunding && s.State != StateFunded {
+			continue
+		}
+		if s.Offer.OfferChain != symbol && s.Offer.RequestChain != symbol {
+			continue
+		}
+		impacts = append(impacts, predictPauseImpact(tradeID, active, symbol))
+	}
+	sort.Slice(impacts, func(i, j int) bool { return impacts[i].TradeID < impacts[j].TradeID })
+	return impacts
+}
+
+// predictPauseImpact works out what still works on a paused chain. The
+// contract only gates swap creation, so existing HTLCs stay claimable and
+// refundable; an HTLC that isn't created yet is blocked.
+func predictPauseImpact(tradeID string, active *ActiveSwap, symbol string) PauseImpact {
+	impact := PauseImpact{
+		TradeID:        tradeID,
+		Chain:          symbol,
+		State:          active.Swap.State,
+		ClaimPossible:  true,
+		RefundPossible: true,
+	}
+
+	ours := localLegChain(active.Swap) == symbol
+	created := evmHTLCCreated(active, symbol, ours)
+	switch {
+	case created:
+		impact.Warning = fmt.Sprintf("%s HTLC exists: claims and refunds remain possible while paused", symbol)
+	case ours:
+		impact.FundingBlocked = true
+		impact.ClaimPossible, impact.RefundPossible = false, false
+		impact.Warning = fmt.Sprintf("We can't fund on %s until the contract is unpaused; nothing of ours is locked there", symbol)
+	default:
+		impact.FundingBlocked = true
+		impact.ClaimPossible, impact.RefundPossible = false, false
+		impact.Warning = fmt.Sprintf("The counterparty can't fund on %s until the contract is unpaused; refund our leg after its timelock if the swap stalls", symbol)
+	}
+	return impact
+}
+
+// evmHTLCCreated returns true if our (local) or the counterparty's HTLC on
+// the chain was created.
+func evmHTLCCreated(active *ActiveSwap, symbol string, local bool) bool {
+	if local && active.Swap.LocalFundingTxID != "" || !local && active.Swap.RemoteFundingTxID != "" {
+		return true
+	}
+	if active.EVMHTLC == nil {
+		return false
+	}
+	data := active.EVMHTLC.RequestChain
+	if active.Swap.Offer.OfferChain == symbol {
+		data = active.EVMHTLC.OfferChain
+	}
+	return data != nil && data.Session != nil && data.Session.GetState() != EVMSwapStateEmpty
+}
+
+// postPauseWebhook delivers a pause event to the configured webhook in the
+// background.
+func (c *Coordinator) postPauseWebhook(event string, alert *ContractPauseAlert) {
+	url := c.contractPause.WebhookURL
+	if url == "" {
+		return
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"type":      event,
+		"data":      alert,
+		"timestamp": time.Now().Unix(),
+	})
+	if err != nil {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(c.ctx, pauseWebhookTimeout)
+		defer cancel()
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			c.log.Warn("Invalid contract pause webhook", "error", err)
+			return
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			c.log.Warn("Contract pause webhook failed", "event", event, "error", err)
+			return
+		}
+		resp.Body.Close()
+		if resp.StatusCode >= 300 {
+			c.log.Warn("Contract pause webhook rejected", "event", event, "status", resp.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,186 @@
+package swap
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// fakePauseWatcher serves a contract pause state from memory.
+type fakePauseWatcher struct {
+	mu     sync.Mutex
+	paused bool
+	events chan bool
+}
+
+func (f *fakePauseWatcher) IsPaused(ctx context.Context) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.paused, nil
+}
+
+func (f *fakePauseWatcher) WatchPaused(ctx context.Context) (<-chan bool, error) {
+	return f.events, nil
+}
+
+func (f *fakePauseWatcher) set(paused bool) {
+	f.mu.Lock()
+	f.paused = paused
+	f.mu.Unlock()
+	f.events <- paused
+}
+
+// startPauseMonitor adds two in-flight ETH swaps, one where our ETH HTLC
+// exists and one where the counterparty has not funded on ETH yet, and
+// starts watching a fake ETH contract.
+func startPauseMonitor(t *testing.T, coord *Coordinator, webhook string) (*fakePauseWatcher, chan SwapEvent) {
+	t.Helper()
+	coord.swaps["funded"] = &ActiveSwap{Swap: &Swap{
+		Role: RoleInitiator, State: StateFunded, LocalFundingTxID: "0xfund",
+		Offer: Offer{OfferChain: "ETH", RequestChain: "BTC"},
+	}}
+	coord.swaps["waiting"] = &ActiveSwap{Swap: &Swap{
+		Role: RoleInitiator, State: StateFunding, LocalFundingTxID: "btc-fund",
+		Offer: Offer{OfferChain: "BTC", RequestChain: "ETH"},
+	}}
+	coord.swaps["other"] = &ActiveSwap{Swap: &Swap{
+		Role: RoleInitiator, State: StateFunding,
+		Offer: Offer{OfferChain: "BTC", RequestChain: "LTC"},
+	}}
+
+	events := make(chan SwapEvent, 32)
+	coord.OnEvent(func(e SwapEvent) { events <- e })
+
+	if err := coord.SetContractPausePolicy(ContractPausePolicy{
+		Enabled:       true,
+		PollInterval:  time.Hour,
+		AlertInterval: time.Hour,
+		WebhookURL:    webhook,
+	}); err != nil {
+		t.Fatalf("SetContractPausePolicy() error = %v", err)
+	}
+	w := &fakePauseWatcher{events: make(chan bool)}
+	coord.pauseWatchers = map[string]PauseWatcher{"ETH": w}
+	coord.StartContractPauseMonitor()
+	return w, events
+}
+
+func waitPauseEvent(t *testing.T, events chan SwapEvent, eventType string) SwapEvent {
+	t.Helper()
+	timeout := time.After(2 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.EventType == eventType {
+				return e
+			}
+		case <-timeout:
+			t.Fatalf("no %s event", eventType)
+		}
+	}
+}
+
+func TestContractPausePolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ContractPausePolicy
+		wantErr bool
+	}{
+		{"disabled", ContractPausePolicy{}, false},
+		{"valid", ContractPausePolicy{Enabled: true, PollInterval: time.Minute, AlertInterval: time.Minute}, false},
+		{"no poll", ContractPausePolicy{Enabled: true, AlertInterval: time.Minute}, true},
+		{"no alert", ContractPausePolicy{Enabled: true, PollInterval: time.Minute}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestContractPauseHaltsTrading(t *testing.T) {
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet))
+	w, events := startPauseMonitor(t, coord, "")
+
+	w.set(true)
+	alert := waitPauseEvent(t, events, EventContractPaused).Data.(*ContractPauseAlert)
+	if alert.Chain != "ETH" || !alert.Paused || alert.Since == 0 || len(alert.InFlight) != 2 {
+		t.Fatalf("alert = %+v", alert)
+	}
+	if got := coord.PausedContracts(); len(got) != 1 || got[0] != "ETH" {
+		t.Errorf("PausedContracts() = %v, want [ETH]", got)
+	}
+
+	offer := Offer{OfferChain: "BTC", OfferAmount: 100000, RequestChain: "ETH", RequestAmount: 1000000, Method: MethodHTLC}
+	if _, err := coord.InitiateSwap(context.Background(), "new", "order", offer, MethodHTLC); !errors.Is(err, ErrContractPaused) {
+		t.Errorf("InitiateSwap() error = %v, want ErrContractPaused", err)
+	}
+	if _, err := coord.RespondToSwap(context.Background(), "new", offer, make([]byte, 33), nil, MethodHTLC); !errors.Is(err, ErrContractPaused) {
+		t.Errorf("RespondToSwap() error = %v, want ErrContractPaused", err)
+	}
+
+	w.set(false)
+	unpaused := waitPauseEvent(t, events, EventContractUnpaused).Data.(*ContractPauseAlert)
+	if unpaused.Paused || coord.IsContractPaused("ETH") {
+		t.Errorf("still paused after unpause: %+v", unpaused)
+	}
+	if _, err := coord.InitiateSwap(context.Background(), "new", "order", offer, MethodHTLC); errors.Is(err, ErrContractPaused) {
+		t.Errorf("InitiateSwap() after unpause error = %v", err)
+	}
+}
+
+func TestContractPauseImpact(t *testing.T) {
+	w, events := startPauseMonitor(t, newTestCoordinator(t, withNetwork(chain.Mainnet)), "")
+
+	w.set(true)
+	impacts := make(map[string]PauseImpact)
+	for i := 0; i < 2; i++ {
+		e := waitPauseEvent(t, events, EventContractPauseImpact)
+		impacts[e.TradeID] = e.Data.(PauseImpact)
+	}
+
+	// Our ETH HTLC exists: the pause doesn't gate claim or refund
+	if got := impacts["funded"]; got.FundingBlocked || !got.ClaimPossible || !got.RefundPossible {
+		t.Errorf("funded impact = %+v", got)
+	}
+	// The counterparty can't create its ETH HTLC
+	if got := impacts["waiting"]; !got.FundingBlocked || got.ClaimPossible || got.RefundPossible || got.Warning == "" {
+		t.Errorf("waiting impact = %+v", got)
+	}
+	if _, ok := impacts["other"]; ok {
+		t.Error("swap without an ETH leg got an impact event")
+	}
+}
+
+func TestContractPauseWebhook(t *testing.T) {
+	posts := make(chan map[string]interface{}, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil {
+			posts <- body
+		}
+	}))
+	defer srv.Close()
+
+	w, _ := startPauseMonitor(t, newTestCoordinator(t, withNetwork(chain.Mainnet)), srv.URL)
+	w.set(true)
+
+	select {
+	case body := <-posts:
+		data, _ := body["data"].(map[string]interface{})
+		if body["type"] != EventContractPaused || data["chain"] != "ETH" || data["paused"] != true {
+			t.Errorf("webhook body = %v", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("webhook not called")
+	}
+}
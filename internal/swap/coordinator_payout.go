@@ -0,0 +1,127 @@
+// Package swap - External payout addresses.
+//
+// A trader may have the receiving leg of a swap paid straight to an address
+// outside the node's wallet (cold storage, an exchange deposit address). The
+// address is chosen at swap_init and recorded in the trade. Bitcoin-family
+// claims pay it directly and MuSig2 swaps announce it to the counterparty as
+// our redeem address. EVM HTLCs always pay the claiming address, so external
+// payouts are refused there.
+package swap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// ErrPayoutNotSupported is returned for external payouts on chains whose
+// HTLC pays the claimer.
+var ErrPayoutNotSupported = errors.New("external payout address not supported on this chain")
+
+// ReceivingChain returns the chain on which the given role receives funds:
+// the initiator receives on the request chain, the responder on the offer chain.
+func ReceivingChain(offer Offer, role Role) string {
+	if role == RoleInitiator {
+		return offer.RequestChain
+	}
+	return offer.OfferChain
+}
+
+// FundingChain returns the chain on which the given role funds its escrow:
+// the initiator funds the offer chain, the responder the request chain.
+func FundingChain(offer Offer, role Role) string {
+	if role == RoleInitiator {
+		return offer.OfferChain
+	}
+	return offer.RequestChain
+}
+
+// ValidatePayoutAddress checks an external payout address for a chain and
+// returns its canonical form.
+func ValidatePayoutAddress(symbol string, network chain.Network, address string) (string, error) {
+	params, ok := chain.Get(symbol, network)
+	if !ok {
+		return "", fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	if params.Type == chain.ChainTypeEVM {
+		// TODO: forward EVM proceeds to the payout address after claiming
+		return "", fmt.Errorf("%w: %s", ErrPayoutNotSupported, symbol)
+	}
+
+	v := wallet.ValidateAddressDetailed(address, params)
+	if !v.Valid {
+		return "", fmt.Errorf("invalid %s address: %s", symbol, v.Error)
+	}
+	return v.Normalized, nil
+}
+
+// SetPayoutAddress directs the swap's receiving leg to an external address.
+// It must be set before the wallet addresses are exchanged with the
+// counterparty; the address replaces our wallet address on the receiving chain
+// and is recorded in the trade.
+func (c *Coordinator) SetPayoutAddress(tradeID, address string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return ErrSwapNotFound
+	}
+	if active.Swap.LocalFundingTxID != "" || active.Swap.RemoteFundingTxID != "" {
+		return fmt.Errorf("payout address cannot change after funding")
+	}
+
+	receiving := ReceivingChain(active.Swap.Offer, active.Swap.Role)
+	normalized, err := ValidatePayoutAddress(receiving, c.network, address)
+	if err != nil {
+		return err
+	}
+
+	active.Swap.PayoutAddress = normalized
+	if receiving == active.Swap.Offer.OfferChain {
+		active.Swap.LocalOfferWalletAddr = normalized
+	} else {
+		active.Swap.LocalRequestWalletAddr = normalized
+	}
+
+	if c.store != nil {
+		if err := c.store.UpdateTradePayoutAddress(tradeID, normalized); err != nil {
+			return fmt.Errorf("failed to record payout address: %w", err)
+		}
+	}
+	c.log.Info("External payout address set", "trade_id", tradeID, "chain", receiving, "address", normalized)
+	return c.saveSwapState(tradeID)
+}
+
+// claimAddressUnlocked returns where a claim on the chain pays: the payout
+// address for our receiving leg if one is set, otherwise our wallet.
+// Caller must hold c.mu.
+func (c *Coordinator) claimAddressUnlocked(active *ActiveSwap, chainSymbol string) (string, error) {
+	if active.Swap.PayoutAddress != "" && chainSymbol == ReceivingChain(active.Swap.Offer, active.Swap.Role) {
+		return active.Swap.PayoutAddress, nil
+	}
+	if c.wallet == nil {
+		return "", fmt.Errorf("wallet not available for deriving claim address")
+	}
+	address, err := c.walletAddressUnlocked(chainSymbol)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive claim address: %w", err)
+	}
+	return address, nil
+}
+
+// restorePayoutAddressUnlocked reloads a recovered swap's payout address from
+// its trade. Caller must hold c.mu.
+func (c *Coordinator) restorePayoutAddressUnlocked(tradeID string) {
+	active, ok := c.swaps[tradeID]
+	if !ok || c.store == nil {
+		return
+	}
+	trade, err := c.store.GetTrade(tradeID)
+	if err != nil {
+		return
+	}
+	active.Swap.PayoutAddress = trade.PayoutAddress
+}
@@ -0,0 +1,124 @@
+package swap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+const (
+	testnetPayoutAddr = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+	mainnetPayoutAddr = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+)
+
+func TestReceivingChain(t *testing.T) {
+	offer := Offer{OfferChain: "BTC", RequestChain: "LTC"}
+	if got := ReceivingChain(offer, RoleInitiator); got != "LTC" {
+		t.Errorf("ReceivingChain(initiator) = %s, want LTC", got)
+	}
+	if got := ReceivingChain(offer, RoleResponder); got != "BTC" {
+		t.Errorf("ReceivingChain(responder) = %s, want BTC", got)
+	}
+}
+
+func TestValidatePayoutAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		symbol  string
+		address string
+		wantErr error
+		ok      bool
+	}{
+		{"valid", "BTC", testnetPayoutAddr, nil, true},
+		{"uppercase bech32", "BTC", "TB1QW508D6QEJXTDG4Y5R3ZARVARY0C5XW7KXPJZSX", nil, true},
+		{"wrong network", "BTC", mainnetPayoutAddr, nil, false},
+		{"garbage", "BTC", "not-an-address", nil, false},
+		{"EVM", "ETH", "0x000000000000000000000000000000000000dEaD", ErrPayoutNotSupported, false},
+		{"unknown chain", "NOPE", testnetPayoutAddr, nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ValidatePayoutAddress(tt.symbol, chain.Testnet, tt.address)
+			if tt.ok {
+				if err != nil {
+					t.Fatalf("ValidatePayoutAddress() error = %v", err)
+				}
+				if got != testnetPayoutAddr {
+					t.Errorf("ValidatePayoutAddress() = %s, want %s", got, testnetPayoutAddr)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("ValidatePayoutAddress() accepted an invalid address")
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("ValidatePayoutAddress() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSetPayoutAddress(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	// The responder receives on the offer chain (BTC)
+	coord, _ := newAutoClaimCoordinator(t, store)
+	if err := store.CreateTrade(&storage.Trade{
+		ID: "responder", OrderID: "order-1", MakerPeerID: "maker", TakerPeerID: "taker",
+		OurRole: storage.TradeRoleTaker, Method: "htlc_bitcoin", State: storage.TradeStateInit,
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000,
+		CreatedAt: time.Now(),
+	}); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+
+	if err := coord.SetPayoutAddress("missing", testnetPayoutAddr); err != ErrSwapNotFound {
+		t.Errorf("SetPayoutAddress(missing) error = %v, want ErrSwapNotFound", err)
+	}
+	if err := coord.SetPayoutAddress("responder", mainnetPayoutAddr); err == nil {
+		t.Error("SetPayoutAddress() accepted a mainnet address on testnet")
+	}
+	if err := coord.SetPayoutAddress("responder", testnetPayoutAddr); err != nil {
+		t.Fatalf("SetPayoutAddress() error = %v", err)
+	}
+
+	active := coord.swaps["responder"]
+	if active.Swap.PayoutAddress != testnetPayoutAddr || active.Swap.LocalOfferWalletAddr != testnetPayoutAddr {
+		t.Errorf("payout not applied to the offer chain: %+v", active.Swap)
+	}
+	if active.Swap.LocalRequestWalletAddr == testnetPayoutAddr {
+		t.Error("payout address replaced our refund-side wallet address")
+	}
+
+	// Claims on the receiving chain pay the payout address; other chains need the wallet
+	if addr, err := coord.claimAddressUnlocked(active, "BTC"); err != nil || addr != testnetPayoutAddr {
+		t.Errorf("claimAddressUnlocked(BTC) = %s, %v; want payout address", addr, err)
+	}
+	if _, err := coord.claimAddressUnlocked(active, "LTC"); err == nil {
+		t.Error("claimAddressUnlocked(LTC) without a wallet should fail")
+	}
+
+	// Recorded in the trade and restored on recovery
+	trade, err := store.GetTrade("responder")
+	if err != nil || trade.PayoutAddress != testnetPayoutAddr {
+		t.Fatalf("trade payout address = %+v, %v", trade, err)
+	}
+	active.Swap.PayoutAddress = ""
+	coord.restorePayoutAddressUnlocked("responder")
+	if active.Swap.PayoutAddress != testnetPayoutAddr {
+		t.Errorf("restored payout address = %q", active.Swap.PayoutAddress)
+	}
+
+	// Changing the payout after funding is refused
+	active.Swap.RemoteFundingTxID = "funding"
+	if err := coord.SetPayoutAddress("responder", testnetPayoutAddr); err == nil {
+		t.Error("SetPayoutAddress() allowed a change after funding")
+	}
+}
@@ -0,0 +1,33 @@
+// Package swap - Fresh wallet addresses for claims, refunds and swap
+// exchanges.
+//
+// By default a claim or refund to our own wallet pays the chain's first
+// address, which links every swap the node ever settled. With fresh
+// addresses each one pays the next unused address instead.
+package swap
+
+import "fmt"
+
+// SetFreshAddresses turns fresh addresses on or off.
+func (c *Coordinator) SetFreshAddresses(fresh bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.freshAddresses = fresh
+}
+
+// walletAddressUnlocked returns the wallet address a claim, refund or swap
+// exchange on the chain uses: the first address, or the next unused one
+// with fresh addresses. Caller must hold c.mu.
+func (c *Coordinator) walletAddressUnlocked(chainSymbol string) (string, error) {
+	if c.wallet == nil {
+		return "", ErrNoWallet
+	}
+	if c.freshAddresses {
+		return c.getWalletAddress(chainSymbol)
+	}
+	address, err := c.wallet.DeriveAddress(chainSymbol, 0, 0)
+	if err != nil {
+		return "", fmt.Errorf("failed to derive wallet address: %w", err)
+	}
+	return address, nil
+}
@@ -0,0 +1,50 @@
+package swap
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+func TestFreshAddresses(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	w, err := wallet.NewFromMnemonic(testMnemonic, "", chain.Testnet)
+	if err != nil {
+		t.Fatalf("NewFromMnemonic() error = %v", err)
+	}
+	coord := NewCoordinator(&CoordinatorConfig{Store: store, Wallet: w, Network: chain.Testnet})
+	defer coord.Close()
+
+	first, _ := w.DeriveAddress("BTC", 0, 0)
+	active := &ActiveSwap{Swap: &Swap{Role: RoleInitiator, Offer: Offer{OfferChain: "BTC", RequestChain: "LTC"}}}
+
+	// By default every claim pays the first address
+	for i := 0; i < 2; i++ {
+		if addr, err := coord.claimAddressUnlocked(active, "BTC"); err != nil || addr != first {
+			t.Errorf("claimAddressUnlocked() = %s, %v; want first address", addr, err)
+		}
+	}
+
+	coord.SetFreshAddresses(true)
+	seen := map[string]bool{}
+	for i := 0; i < 3; i++ {
+		addr, err := coord.claimAddressUnlocked(active, "BTC")
+		if err != nil {
+			t.Fatalf("claimAddressUnlocked() error = %v", err)
+		}
+		if seen[addr] {
+			t.Errorf("claimAddressUnlocked() reused %s with fresh addresses", addr)
+		}
+		seen[addr] = true
+	}
+	if addrs, _ := store.ListWalletAddresses("BTC"); len(addrs) != 3 {
+		t.Errorf("saved %d wallet addresses, want 3 for the wallet to track", len(addrs))
+	}
+}
@@ -0,0 +1,402 @@
+// Package swap - Startup reconciliation of recovered swaps against the chains.
+package swap
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// EventSwapReconciled is emitted when reconciliation finds on-chain activity
+// the persisted state did not know about.
+const EventSwapReconciled = "swap_reconciled"
+
+// Reconciliation findings.
+const (
+	FindingLocalFunded          = "local_funded"          // Our funding tx found
+	FindingRemoteFunded         = "remote_funded"         // Counterparty funding tx found
+	FindingRedeemed             = "redeemed"              // We claimed the counterparty's escrow
+	FindingRefunded             = "refunded"              // We refunded our escrow
+	FindingCounterpartyClaimed  = "counterparty_claimed"  // Counterparty claimed our escrow
+	FindingCounterpartyRefunded = "counterparty_refunded" // Counterparty refunded their escrow
+	FindingSecretExtracted      = "secret_extracted"      // Secret learned from a claim
+)
+
+// EVMSwapReader reads HTLC contract swaps and their claims on an EVM chain.
+// *htlc.Client implements it.
+type EVMSwapReader interface {
+	GetSwap(ctx context.Context, swapID [32]byte) (*htlc.Swap, error)
+	FindSwapClaimed(ctx context.Context, swapID [32]byte, fromBlock uint64) (*htlc.SwapClaimedEvent, error)
+}
+
+// ReconcileReport describes what reconciliation found for a swap.
+type ReconcileReport struct {
+	TradeID       string   `json:"trade_id"`
+	PreviousState State    `json:"previous_state"`
+	State         State    `json:"state"`
+	Findings      []string `json:"findings,omitempty"`
+	Errors        []string `json:"errors,omitempty"` // Legs that could not be checked
+}
+
+// escrowObservation is what the chain shows for one escrow.
+type escrowObservation struct {
+	fundingTxID string
+	fundingVout uint32
+	spent       bool
+	spendTxID   string // Empty for EVM contract swaps
+	secret      []byte // Preimage revealed by a claim
+	claimed     bool   // Spent by a claim rather than a refund
+}
+
+// ReconcileSwap checks a swap against the chains and updates its state.
+func (c *Coordinator) ReconcileSwap(ctx context.Context, tradeID string) (*ReconcileReport, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
+	ctx, op := c.beginOperation(ctx, tradeID, "reconcile", OpChainQuery)
+	defer op.end()
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.swaps[tradeID]; !ok {
+		return nil, ErrSwapNotFound
+	}
+	return c.reconcileSwapUnlocked(ctx, tradeID), nil
+}
+
+// reconcileSwapUnlocked queries the chains for the escrows of a recovered
+// swap, records funding, claims and refunds that happened while the node
+// was offline, and extracts revealed secrets before the protocol resumes.
+// Funding only records txids; confirmations still gate the funded state.
+// Caller must hold c.mu.
+func (c *Coordinator) reconcileSwapUnlocked(ctx context.Context, tradeID string) *ReconcileReport {
+	delete(c.reconcileBacklog, tradeID)
+	active := c.swaps[tradeID]
+	s := active.Swap
+	report := &ReconcileReport{TradeID: tradeID, PreviousState: s.State, State: s.State}
+	if s.IsTerminal() || active.IsXMR() {
+		// The driver of an adaptor swap looks up its chains itself
+		return report
+	}
+
+	localChain := localLegChain(s)
+	remoteChain, _ := remoteLeg(s)
+
+	local, err := c.observeLegUnlocked(ctx, active, localChain)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", localChain, err))
+	}
+	remote, err := c.observeLegUnlocked(ctx, active, remoteChain)
+	if err != nil {
+		report.Errors = append(report.Errors, fmt.Sprintf("%s: %v", remoteChain, err))
+	}
+
+	if len(report.Errors) > 0 {
+		c.log.Warn("Could not reconcile all swap legs", "trade_id", tradeID, "errors", report.Errors)
+	}
+
+	find := func(f string) { report.Findings = append(report.Findings, f) }
+
+	if local != nil && local.fundingTxID != "" && s.LocalFundingTxID == "" {
+		s.LocalFundingTxID = local.fundingTxID
+		s.LocalFundingVout = local.fundingVout
+		find(FindingLocalFunded)
+	}
+	if remote != nil && remote.fundingTxID != "" && s.RemoteFundingTxID == "" {
+		s.RemoteFundingTxID = remote.fundingTxID
+		s.RemoteFundingVout = remote.fundingVout
+		find(FindingRemoteFunded)
+	}
+
+	if local != nil && local.spent {
+		if local.claimed {
+			find(FindingCounterpartyClaimed)
+			if len(local.secret) == 32 && c.setRevealedSecretUnlocked(active, local.secret) {
+				find(FindingSecretExtracted)
+			}
+			if local.spendTxID != "" {
+				c.recordCounterpartyClaimUnlocked(tradeID, localChain, local.spendTxID)
+			}
+		} else {
+			setHTLCSpend(active, localChain, "", local.spendTxID)
+			find(FindingRefunded)
+			reconcileTransition(s, StateRefunded)
+		}
+	}
+	if remote != nil && remote.spent {
+		if remote.claimed {
+			setHTLCSpend(active, remoteChain, remote.spendTxID, "")
+			find(FindingRedeemed)
+			reconcileTransition(s, StateRedeemed)
+		} else {
+			find(FindingCounterpartyRefunded)
+		}
+	}
+
+	report.State = s.State
+	if len(report.Findings) == 0 {
+		return report
+	}
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save reconciled swap", "trade_id", tradeID, "error", err)
+	}
+	c.log.Info("Reconciled swap against chain",
+		"trade_id", tradeID,
+		"previous_state", report.PreviousState,
+		"state", report.State,
+		"findings", report.Findings,
+	)
+	c.emitEvent(tradeID, EventSwapReconciled, map[string]interface{}{
+		"previous_state": string(report.PreviousState),
+		"state":          string(report.State),
+		"findings":       report.Findings,
+	})
+	return report
+}
+
+// reconcileTransition moves a swap to a terminal state reached on chain,
+// passing through funded if the node went down while funding.
+func reconcileTransition(s *Swap, target State) {
+	if s.State == StateInit {
+		s.State = StateFunding
+	}
+	if target == StateRedeemed && s.State == StateFunding {
+		s.State = StateFunded
+	}
+	_ = s.TransitionTo(target)
+}
+
+// observeLegUnlocked returns what the chain shows for a leg's escrow, or
+// nil if the escrow is not known yet. Caller must hold c.mu.
+func (c *Coordinator) observeLegUnlocked(ctx context.Context, active *ActiveSwap, chainSymbol string) (*escrowObservation, error) {
+	if IsEVMChain(chainSymbol, c.network) {
+		return c.observeEVMLegUnlocked(ctx, active, chainSymbol)
+	}
+
+	offerAddr, requestAddr := escrowAddressesUnlocked(active)
+	addr := offerAddr
+	if chainSymbol == active.Swap.Offer.RequestChain {
+		addr = requestAddr
+	}
+	fundingTxID := active.Swap.RemoteFundingTxID
+	if chainSymbol == localLegChain(active.Swap) {
+		fundingTxID = active.Swap.LocalFundingTxID
+	}
+	if addr == "" {
+		return nil, nil
+	}
+	b, ok := c.backends[chainSymbol]
+	if !ok {
+		return nil, fmt.Errorf("no backend")
+	}
+
+	txs, err := b.GetAddressTxs(ctx, addr, "")
+	if err != nil {
+		return nil, err
+	}
+	return observeEscrow(txs, addr, fundingTxID, htlcSecretHashUnlocked(active)), nil
+}
+
+// observeEscrow finds the funding and spend of an escrow address in its
+// transactions. knownFunding selects the funding tx if the escrow was funded
+// more than once. With a secret hash, a spend revealing the preimage is a
+// claim and any other spend a refund; without one (MuSig2), a key-path
+// spend is the cooperative claim and a script-path spend the refund.
+func observeEscrow(txs []backend.Transaction, addr, knownFunding string, secretHash []byte) *escrowObservation {
+	obs := &escrowObservation{}
+	for _, tx := range txs {
+		for i, out := range tx.Outputs {
+			if out.ScriptPubKeyAddr != addr {
+				continue
+			}
+			if obs.fundingTxID == "" || tx.TxID == knownFunding {
+				obs.fundingTxID = tx.TxID
+				obs.fundingVout = uint32(i)
+			}
+		}
+	}
+
+	for _, tx := range txs {
+		for _, in := range tx.Inputs {
+			spendsEscrow := (in.PrevOut != nil && in.PrevOut.ScriptPubKeyAddr == addr) ||
+				(obs.fundingTxID != "" && in.TxID == obs.fundingTxID && in.Vout == obs.fundingVout)
+			if !spendsEscrow {
+				continue
+			}
+			obs.spent = true
+			obs.spendTxID = tx.TxID
+			if len(secretHash) == 0 {
+				obs.claimed = len(in.Witness) == 1
+				return obs
+			}
+			for _, item := range in.Witness {
+				data, err := hex.DecodeString(item)
+				if err != nil || len(data) != 32 {
+					continue
+				}
+				if hash := sha256.Sum256(data); bytes.Equal(hash[:], secretHash) {
+					obs.claimed = true
+					obs.secret = data
+					return obs
+				}
+			}
+			return obs
+		}
+	}
+	return obs
+}
+
+// observeEVMLegUnlocked returns the contract state of an EVM leg.
+// Caller must hold c.mu.
+func (c *Coordinator) observeEVMLegUnlocked(ctx context.Context, active *ActiveSwap, chainSymbol string) (*escrowObservation, error) {
+	if active.EVMHTLC == nil {
+		return nil, nil
+	}
+	session, err := c.getEVMSession(active, chainSymbol)
+	if err != nil {
+		return nil, err
+	}
+	swapID := session.GetSwapID()
+	if swapID == ([32]byte{}) {
+		return nil, nil // Contract swap not created yet
+	}
+	reader := c.evmSwapReaderUnlocked(chainSymbol, session)
+	if reader == nil {
+		return nil, fmt.Errorf("no contract client")
+	}
+	onChain, err := reader.GetSwap(ctx, swapID)
+	if err != nil {
+		return nil, err
+	}
+
+	obs := &escrowObservation{}
+	switch onChain.State {
+	case htlc.SwapStateClaimed:
+		obs.spent = true
+		obs.claimed = true
+		// The claim's event log carries the secret. Without it
+		// WaitForEVMSecret still picks it up once the protocol resumes.
+		secret, err := c.evmClaimSecretUnlocked(ctx, reader, chainSymbol, session)
+		if err != nil {
+			c.log.Warn("Could not read the secret of an EVM claim", "trade_id", active.Swap.ID, "chain", chainSymbol, "error", err)
+		} else if secret != nil {
+			obs.secret = secret
+		}
+	case htlc.SwapStateRefunded:
+		obs.spent = true
+	}
+	return obs, nil
+}
+
+// evmClaimSecretUnlocked returns the secret revealed by the SwapClaimed event
+// of a session's contract swap, nil if there is none. The search starts at
+// the block our create was mined in, when we know it. Caller must hold c.mu.
+func (c *Coordinator) evmClaimSecretUnlocked(ctx context.Context, reader EVMSwapReader, chainSymbol string, session *EVMHTLCSession) ([]byte, error) {
+	var fromBlock uint64
+	if create := session.GetCreateTxHash(); create != (common.Hash{}) {
+		if replacer := c.evmTxReplacerLocked(chainSymbol, session); replacer != nil {
+			if receipt, err := replacer.Receipt(ctx, create); err == nil && receipt != nil && receipt.BlockNumber != nil {
+				fromBlock = receipt.BlockNumber.Uint64()
+			}
+		}
+	}
+
+	event, err := reader.FindSwapClaimed(ctx, session.GetSwapID(), fromBlock)
+	if err != nil || event == nil {
+		return nil, err
+	}
+	hash := session.GetSecretHash()
+	if !htlc.VerifySecret(event.Secret, hash) {
+		return nil, fmt.Errorf("claim secret does not match the swap's hash")
+	}
+	return event.Secret[:], nil
+}
+
+// evmSwapReaderUnlocked returns the contract reader of a chain: the one set on
+// the coordinator, else the session's client. Caller must hold c.mu.
+func (c *Coordinator) evmSwapReaderUnlocked(chainSymbol string, session *EVMHTLCSession) EVMSwapReader {
+	if r, ok := c.evmReaders[chainSymbol]; ok {
+		return r
+	}
+	return session.swapReader()
+}
+
+// localLegChain returns the chain we fund.
+func localLegChain(s *Swap) string {
+	if s.Role == RoleInitiator {
+		return s.Offer.OfferChain
+	}
+	return s.Offer.RequestChain
+}
+
+// htlcSecretHashUnlocked returns the secret hash of an HTLC swap, or nil for
+// MuSig2. Caller must hold c.mu.
+func htlcSecretHashUnlocked(active *ActiveSwap) []byte {
+	if !active.IsHTLC() {
+		return nil
+	}
+	if len(active.Swap.SecretHash) == 32 {
+		return active.Swap.SecretHash
+	}
+	if active.HTLC != nil {
+		for _, data := range []*ChainHTLCData{active.HTLC.OfferChain, active.HTLC.RequestChain} {
+			if data != nil && data.Session != nil {
+				if hash := data.Session.GetSecretHash(); len(hash) == 32 {
+					return hash
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// setRevealedSecretUnlocked stores a secret learned on chain, reporting
+// whether it was new. Caller must hold c.mu.
+func (c *Coordinator) setRevealedSecretUnlocked(active *ActiveSwap, secret []byte) bool {
+	if len(active.Swap.Secret) == 32 {
+		return false
+	}
+	active.Swap.Secret = secret
+	if active.HTLC != nil {
+		for _, data := range []*ChainHTLCData{active.HTLC.OfferChain, active.HTLC.RequestChain} {
+			if data != nil && data.Session != nil && !data.Session.HasSecret() {
+				_ = data.Session.SetSecret(secret)
+			}
+		}
+	}
+	if active.EVMHTLC != nil {
+		var preimage [32]byte
+		copy(preimage[:], secret)
+		for _, data := range []*ChainEVMHTLCData{active.EVMHTLC.OfferChain, active.EVMHTLC.RequestChain} {
+			if data != nil && data.Session != nil && !data.Session.HasSecret() {
+				_ = data.Session.SetSecret(preimage)
+			}
+		}
+	}
+	return true
+}
+
+// setHTLCSpend records a claim or refund txid on an HTLC leg.
+func setHTLCSpend(active *ActiveSwap, chainSymbol, claimTxID, refundTxID string) {
+	if active.HTLC == nil {
+		return
+	}
+	data := active.HTLC.OfferChain
+	if chainSymbol == active.Swap.Offer.RequestChain {
+		data = active.HTLC.RequestChain
+	}
+	if data == nil {
+		return
+	}
+	if claimTxID != "" && data.ClaimTxID == "" {
+		data.ClaimTxID = claimTxID
+	}
+	if refundTxID != "" && data.RefundTxID == "" {
+		data.RefundTxID = refundTxID
+	}
+}
@@ -0,0 +1,279 @@
+package swap
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+)
+
+const (
+	rcOfferEscrow   = "bc1qreconcileoffer"
+	rcRequestEscrow = "ltc1qreconcilerequest"
+)
+
+var rcSecret = []byte("reconcile-secret-32-bytes-long!!")
+
+// escrowFunding returns a tx paying an escrow.
+func escrowFunding(txID, escrow string) backend.Transaction {
+	return backend.Transaction{TxID: txID, Outputs: []backend.TxOutput{{ScriptPubKeyAddr: escrow, Value: 100000}}}
+}
+
+// escrowSpend returns a tx spending an escrow funding output with witness.
+func escrowSpend(txID, fundingTxID, escrow string, witness ...string) backend.Transaction {
+	return backend.Transaction{TxID: txID, Inputs: []backend.TxInput{{
+		TxID:    fundingTxID,
+		Witness: witness,
+		PrevOut: &backend.TxOutput{ScriptPubKeyAddr: escrow, Value: 100000},
+	}}}
+}
+
+// addReconcileTrade adds trade t1, a BTC/LTC HTLC swap in the funding state
+// with both escrows known.
+func addReconcileTrade(t *testing.T, coord *Coordinator, role Role) {
+	t.Helper()
+	s := newTestHTLCSwap(t, chain.Mainnet, role)
+	hash := sha256.Sum256(rcSecret)
+	s.ID = "t1"
+	s.State = StateFunding
+	s.SecretHash = hash[:]
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap: s,
+		HTLC: &HTLCSwapData{
+			OfferChain:   &ChainHTLCData{HTLCAddress: rcOfferEscrow},
+			RequestChain: &ChainHTLCData{HTLCAddress: rcRequestEscrow},
+		},
+	}
+}
+
+func hasFinding(r *ReconcileReport, f string) bool {
+	for _, got := range r.Findings {
+		if got == f {
+			return true
+		}
+	}
+	return false
+}
+
+func TestReconcileCounterpartyClaimRevealsSecret(t *testing.T) {
+	// We are the taker: we fund LTC, the maker claims it with the secret
+	btc, ltc := newFakeChainBackend(), newFakeChainBackend()
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addReconcileTrade(t, coord, RoleResponder)
+	btc.history[rcOfferEscrow] = []backend.Transaction{escrowFunding("maker-fund", rcOfferEscrow)}
+	ltc.history[rcRequestEscrow] = []backend.Transaction{
+		escrowSpend("maker-claim", "our-fund", rcRequestEscrow, "3044", hex.EncodeToString(rcSecret), "01", "a914"),
+		escrowFunding("our-fund", rcRequestEscrow),
+	}
+
+	report, err := coord.ReconcileSwap(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("ReconcileSwap() error = %v", err)
+	}
+	for _, f := range []string{FindingLocalFunded, FindingRemoteFunded, FindingCounterpartyClaimed, FindingSecretExtracted} {
+		if !hasFinding(report, f) {
+			t.Errorf("findings = %v, missing %s", report.Findings, f)
+		}
+	}
+	s := coord.swaps["t1"].Swap
+	if string(s.Secret) != string(rcSecret) {
+		t.Error("secret not extracted")
+	}
+	if s.LocalFundingTxID != "our-fund" || s.RemoteFundingTxID != "maker-fund" {
+		t.Errorf("funding = %s / %s", s.LocalFundingTxID, s.RemoteFundingTxID)
+	}
+	// Still ours to claim
+	if s.State != StateFunding {
+		t.Errorf("state = %s, want funding", s.State)
+	}
+	if st := coord.autoClaims["t1"]; st == nil || st.claimTxID != "maker-claim" {
+		t.Error("counterparty claim not recorded for auto-claim")
+	}
+}
+
+func TestReconcileRefunded(t *testing.T) {
+	// We are the maker and refunded our BTC while offline
+	btc, ltc := newFakeChainBackend(), newFakeChainBackend()
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", btc), withBackend("LTC", ltc))
+	addReconcileTrade(t, coord, RoleInitiator)
+	coord.swaps["t1"].Swap.LocalFundingTxID = "our-fund"
+	btc.history[rcOfferEscrow] = []backend.Transaction{
+		escrowSpend("our-refund", "our-fund", rcOfferEscrow, "3044", "", "a914"),
+		escrowFunding("our-fund", rcOfferEscrow),
+	}
+
+	report, _ := coord.ReconcileSwap(context.Background(), "t1")
+	if !hasFinding(report, FindingRefunded) || report.State != StateRefunded {
+		t.Errorf("report = %+v, want refunded", report)
+	}
+	if got := coord.swaps["t1"].HTLC.OfferChain.RefundTxID; got != "our-refund" {
+		t.Errorf("RefundTxID = %q", got)
+	}
+}
+
+func TestReconcileNothingNew(t *testing.T) {
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withBackend("BTC", newFakeChainBackend()))
+	addReconcileTrade(t, coord, RoleInitiator)
+
+	report, _ := coord.ReconcileSwap(context.Background(), "t1")
+	if len(report.Findings) != 0 || report.State != StateFunding {
+		t.Errorf("report = %+v, want no findings", report)
+	}
+	if len(report.Errors) != 1 {
+		t.Errorf("errors = %v, want the missing LTC backend", report.Errors)
+	}
+	if _, err := coord.ReconcileSwap(context.Background(), "missing"); err != ErrSwapNotFound {
+		t.Errorf("ReconcileSwap(missing) error = %v", err)
+	}
+}
+
+func TestLoadPendingSwapsReconciles(t *testing.T) {
+	store := newTestStore(t)
+
+	// Persist a funding swap, as left by a node that went down
+	before := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store))
+	addReconcileTrade(t, before, RoleInitiator)
+	before.mu.Lock()
+	before.swaps["t1"].Swap.LocalFundingTxID = "our-fund"
+	before.swaps["t1"].Swap.RemoteFundingTxID = "taker-fund"
+	if err := before.saveSwapState("t1"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	before.mu.Unlock()
+
+	// Meanwhile our claim of the taker's LTC confirmed
+	ltc := newFakeChainBackend()
+	after := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("LTC", ltc))
+	ltc.history[rcRequestEscrow] = []backend.Transaction{
+		escrowSpend("our-claim", "taker-fund", rcRequestEscrow, "3044", hex.EncodeToString(rcSecret), "01", "a914"),
+		escrowFunding("taker-fund", rcRequestEscrow),
+	}
+
+	if err := after.LoadPendingSwaps(context.Background()); err != nil {
+		t.Fatalf("LoadPendingSwaps() error = %v", err)
+	}
+	active := after.swaps["t1"]
+	if active.Swap.State != StateRedeemed {
+		t.Errorf("state = %s, want redeemed", active.Swap.State)
+	}
+	if active.HTLC.RequestChain.ClaimTxID != "our-claim" {
+		t.Errorf("ClaimTxID = %q", active.HTLC.RequestChain.ClaimTxID)
+	}
+	record, err := store.GetSwap("t1")
+	if err != nil || record.State != storage.SwapStateRedeemed {
+		t.Errorf("stored state = %v, %v", record, err)
+	}
+}
+
+func TestObserveEscrowMuSig2(t *testing.T) {
+	fund := escrowFunding("fund", "bc1pescrow")
+	tests := []struct {
+		name    string
+		witness []string
+		claimed bool
+	}{
+		{"key path", []string{"sig"}, true},
+		{"script path", []string{"sig", "script", "control"}, false},
+	}
+	for _, tt := range tests {
+		spend := escrowSpend("spend", "fund", "bc1pescrow", tt.witness...)
+		obs := observeEscrow([]backend.Transaction{spend, fund}, "bc1pescrow", "", nil)
+		if obs.fundingTxID != "fund" || !obs.spent || obs.claimed != tt.claimed {
+			t.Errorf("%s: observation = %+v", tt.name, obs)
+		}
+	}
+}
+
+// fakeSwapReader is an HTLC contract holding one swap.
+type fakeSwapReader struct {
+	swap      *htlc.Swap
+	claim     *htlc.SwapClaimedEvent
+	fromBlock uint64
+}
+
+func (f *fakeSwapReader) GetSwap(ctx context.Context, swapID [32]byte) (*htlc.Swap, error) {
+	return f.swap, nil
+}
+
+func (f *fakeSwapReader) FindSwapClaimed(ctx context.Context, swapID [32]byte, fromBlock uint64) (*htlc.SwapClaimedEvent, error) {
+	f.fromBlock = fromBlock
+	return f.claim, nil
+}
+
+func TestReconcileEVMClaimRevealsSecret(t *testing.T) {
+	store := newTestStore(t)
+
+	// We are the maker: we locked ETH, the taker claimed it while we were offline
+	coord := newTestCoordinator(t, withStore(store))
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleInitiator, Offer{
+		OfferChain: "ETH", OfferAmount: 1e16,
+		RequestChain: "BTC", RequestAmount: 100000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	hash := sha256.Sum256(rcSecret)
+	s.ID = "t1"
+	s.State = StateFunding
+	s.SecretHash = hash[:]
+	create := common.HexToHash("0xc1")
+	session := &EVMHTLCSession{symbol: "ETH", swapID: [32]byte{1}, secretHash: hash, createTxHash: create}
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap:    s,
+		EVMHTLC: &EVMHTLCSwapData{OfferChain: &ChainEVMHTLCData{Session: session}},
+	}
+
+	var secret [32]byte
+	copy(secret[:], rcSecret)
+	reader := &fakeSwapReader{
+		swap:  &htlc.Swap{State: htlc.SwapStateClaimed},
+		claim: &htlc.SwapClaimedEvent{SwapID: [32]byte{1}, Secret: secret, BlockNum: 120},
+	}
+	coord.evmReaders = map[string]EVMSwapReader{"ETH": reader}
+	coord.evmReplacers = map[string]EVMTxReplacer{"ETH": &fakeReplacer{
+		receipts: map[common.Hash]*types.Receipt{create: {BlockNumber: big.NewInt(100)}},
+	}}
+
+	report, err := coord.ReconcileSwap(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("ReconcileSwap() error = %v", err)
+	}
+	if !hasFinding(report, FindingCounterpartyClaimed) || !hasFinding(report, FindingSecretExtracted) {
+		t.Errorf("findings = %v", report.Findings)
+	}
+	if reader.fromBlock != 100 {
+		t.Errorf("claim searched from block %d, want the create's 100", reader.fromBlock)
+	}
+	if string(s.Secret) != string(rcSecret) || session.GetSecret() != secret {
+		t.Error("secret not extracted")
+	}
+	record, err := store.GetSwap("t1")
+	if err != nil {
+		t.Fatalf("GetSwap() error = %v", err)
+	}
+	var stored struct {
+		Secret string `json:"secret"`
+	}
+	if err := json.Unmarshal(record.MethodData, &stored); err != nil || stored.Secret != hex.EncodeToString(rcSecret) {
+		t.Errorf("stored secret = %q, %v", stored.Secret, err)
+	}
+
+	// A claim revealing a preimage of another hash is not taken
+	s.Secret = nil
+	session.hasSecret = false
+	reader.claim.Secret = [32]byte{2}
+	report, _ = coord.ReconcileSwap(context.Background(), "t1")
+	if hasFinding(report, FindingSecretExtracted) || s.Secret != nil {
+		t.Errorf("findings = %v, secret %x", report.Findings, s.Secret)
+	}
+}
@@ -0,0 +1,306 @@
+// Package swap - Per-trade locks and lock-free swap views.
+//
+// c.mu guards the coordinator's maps and the swaps in them. Holding it
+// across chain queries made every swap wait for the slowest backend call of
+// any other, so the confirmation path, the hottest one, works per trade:
+//
+//   - each trade has a slot in a sharded registry; the slot's mutex
+//     serializes the trade's confirmation updates
+//   - a trade's slot is taken before c.mu, never while holding it
+//   - under its slot, UpdateConfirmations drops c.mu for the backend
+//     queries, external funding, zero-conf and funding variance lookups
+//     included, and re-checks what they were for when it takes c.mu back,
+//     as paths that only take c.mu may have changed it meanwhile
+//   - external funding requests take the slot too; the EVM session a leg's
+//     external funding needs is connected without c.mu, as connecting
+//     reads the chain ID from the node
+//
+// Funding keeps c.mu for the whole build: concurrent coin selection on one
+// wallet would spend the same UTXOs twice.
+//
+// Each slot also holds a read-only SwapView, published whenever the swap is
+// saved or its confirmations are updated. Status queries read views without
+// taking c.mu. A swap's slot is removed once it publishes a terminal view;
+// a finished swap's view is rebuilt from its final state when asked for.
+package swap
+
+import (
+	"context"
+	"hash/maphash"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// tradeShards is the number of registry shards, a power of two.
+const tradeShards = 64
+
+// SwapView is a consistent, read-only copy of the fields status queries
+// report, as of the swap's last save or confirmation update.
+type SwapView struct {
+	TradeID      string         `json:"trade_id"`
+	Method       Method         `json:"method"`
+	Role         Role           `json:"role"`
+	State        State          `json:"state"`
+	SwapType     CrossChainType `json:"swap_type"`
+	LocalPubKey  []byte         `json:"local_pubkey,omitempty"`
+	RemotePubKey []byte         `json:"remote_pubkey,omitempty"`
+
+	// Escrow addresses: taproot for MuSig2, P2WSH for HTLC swaps
+	OfferEscrowAddress   string `json:"offer_escrow_address,omitempty"`
+	RequestEscrowAddress string `json:"request_escrow_address,omitempty"`
+
+	// MuSig2 signing progress
+	HasOfferNonces   bool `json:"has_offer_nonces"`
+	HasRequestNonces bool `json:"has_request_nonces"`
+	HasOfferSigs     bool `json:"has_offer_sigs"`
+	HasRequestSigs   bool `json:"has_request_sigs"`
+
+	LocalOfferWalletAddr   string `json:"local_offer_wallet_addr,omitempty"`
+	LocalRequestWalletAddr string `json:"local_request_wallet_addr,omitempty"`
+	PayoutAddress          string `json:"payout_address,omitempty"`
+
+	LocalFundingChain     string `json:"local_funding_chain"`
+	LocalFundingAmount    uint64 `json:"local_funding_amount"`
+	LocalFundingTxID      string `json:"local_funding_txid,omitempty"`
+	LocalFundingVout      uint32 `json:"local_funding_vout"`
+	LocalFundingConfirms  uint32 `json:"local_funding_confirms"`
+	RemoteFundingChain    string `json:"remote_funding_chain"`
+	RemoteFundingAmount   uint64 `json:"remote_funding_amount"`
+	RemoteFundingTxID     string `json:"remote_funding_txid,omitempty"`
+	RemoteFundingVout     uint32 `json:"remote_funding_vout"`
+	RemoteFundingConfirms uint32 `json:"remote_funding_confirms"`
+
+	// Set once the zero-conf or funding variance policy decided
+	ZeroConf        *ZeroConfStatus        `json:"zero_conf,omitempty"`
+	FundingVariance *FundingVarianceStatus `json:"funding_variance,omitempty"`
+
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// When our and the counterparty's escrows become refundable
+	ourRefund, theirRefund refundPoint
+	testnet                bool
+}
+
+// IsTerminal reports whether the swap had finished.
+func (v *SwapView) IsTerminal() bool {
+	return v.State.IsTerminal()
+}
+
+// tradeSlot is a trade's lock and its latest view.
+type tradeSlot struct {
+	mu   sync.Mutex
+	view atomic.Pointer[SwapView]
+}
+
+// tradeShard maps trade IDs to slots. Lookups load the map without locking;
+// inserts and removals copy it under mu.
+type tradeShard struct {
+	mu    sync.Mutex
+	slots atomic.Pointer[map[string]*tradeSlot]
+}
+
+// tradeRegistry holds the slots of all trades. The zero value is ready.
+type tradeRegistry struct {
+	shards [tradeShards]tradeShard
+}
+
+// tradeSeed keys the shard hash for the life of the process.
+var tradeSeed = maphash.MakeSeed()
+
+func (r *tradeRegistry) shard(tradeID string) *tradeShard {
+	return &r.shards[maphash.String(tradeSeed, tradeID)&(tradeShards-1)]
+}
+
+// lookup returns a trade's slot, nil when it has none.
+func (r *tradeRegistry) lookup(tradeID string) *tradeSlot {
+	if m := r.shard(tradeID).slots.Load(); m != nil {
+		return (*m)[tradeID]
+	}
+	return nil
+}
+
+// slot returns a trade's slot, creating it on first use.
+func (r *tradeRegistry) slot(tradeID string) *tradeSlot {
+	if s := r.lookup(tradeID); s != nil {
+		return s
+	}
+	sh := r.shard(tradeID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	var next map[string]*tradeSlot
+	if m := sh.slots.Load(); m != nil {
+		if s, ok := (*m)[tradeID]; ok {
+			return s
+		}
+		next = make(map[string]*tradeSlot, len(*m)+1)
+		for id, s := range *m {
+			next[id] = s
+		}
+	} else {
+		next = make(map[string]*tradeSlot, 1)
+	}
+	s := &tradeSlot{}
+	next[tradeID] = s
+	sh.slots.Store(&next)
+	return s
+}
+
+// remove drops a trade's slot if it is still s. A caller already waiting on
+// the slot's lock still gets it; the next lockTrade starts a new slot.
+func (r *tradeRegistry) remove(tradeID string, s *tradeSlot) {
+	sh := r.shard(tradeID)
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+
+	m := sh.slots.Load()
+	if m == nil || (*m)[tradeID] != s {
+		return
+	}
+	next := make(map[string]*tradeSlot, len(*m)-1)
+	for id, slot := range *m {
+		if id != tradeID {
+			next[id] = slot
+		}
+	}
+	sh.slots.Store(&next)
+}
+
+// views returns the published views of all trades.
+func (r *tradeRegistry) views() []*SwapView {
+	var views []*SwapView
+	for i := range r.shards {
+		m := r.shards[i].slots.Load()
+		if m == nil {
+			continue
+		}
+		for _, s := range *m {
+			if v := s.view.Load(); v != nil {
+				views = append(views, v)
+			}
+		}
+	}
+	return views
+}
+
+// lockTrade takes a trade's lock and returns its release. Callers must not
+// hold c.mu. A slot left without a view, for a trade that isn't known or
+// hasn't been saved, is removed on release.
+func (c *Coordinator) lockTrade(tradeID string) func() {
+	s := c.trades.slot(tradeID)
+	s.mu.Lock()
+	return func() {
+		s.mu.Unlock()
+		if s.view.Load() == nil {
+			c.trades.remove(tradeID, s)
+		}
+	}
+}
+
+// publishViewLocked publishes the current view of a swap. A terminal view
+// removes the swap's slot: nothing updates a finished swap's view again.
+// Caller must hold c.mu.
+func (c *Coordinator) publishViewLocked(tradeID string, active *ActiveSwap) *SwapView {
+	v := c.buildViewLocked(tradeID, active)
+	slot := c.trades.slot(tradeID)
+	slot.view.Store(v)
+	if v.IsTerminal() {
+		c.trades.remove(tradeID, slot)
+	}
+	return v
+}
+
+// buildViewLocked copies a swap's reported fields. Caller must hold c.mu.
+func (c *Coordinator) buildViewLocked(tradeID string, active *ActiveSwap) *SwapView {
+	s := active.Swap
+	v := &SwapView{
+		TradeID:                tradeID,
+		Method:                 s.Method,
+		Role:                   s.Role,
+		State:                  s.State,
+		SwapType:               GetCrossChainSwapType(s.Offer.OfferChain, s.Offer.RequestChain, c.network),
+		LocalPubKey:            append([]byte(nil), s.LocalPubKey...),
+		RemotePubKey:           append([]byte(nil), s.RemotePubKey...),
+		LocalOfferWalletAddr:   s.LocalOfferWalletAddr,
+		LocalRequestWalletAddr: s.LocalRequestWalletAddr,
+		PayoutAddress:          s.PayoutAddress,
+		LocalFundingTxID:       s.LocalFundingTxID,
+		LocalFundingVout:       s.LocalFundingVout,
+		LocalFundingConfirms:   s.LocalFundingConfirms,
+		RemoteFundingTxID:      s.RemoteFundingTxID,
+		RemoteFundingVout:      s.RemoteFundingVout,
+		RemoteFundingConfirms:  s.RemoteFundingConfirms,
+		UpdatedAt:              time.Now(),
+	}
+	v.OfferEscrowAddress, v.RequestEscrowAddress = escrowAddressesUnlocked(active)
+
+	// The initiator funds the offer chain, the responder the request chain
+	v.LocalFundingChain, v.LocalFundingAmount = s.Offer.RequestChain, s.Offer.RequestAmount
+	v.RemoteFundingChain, v.RemoteFundingAmount = s.Offer.OfferChain, s.Offer.OfferAmount
+	if s.Role == RoleInitiator {
+		v.LocalFundingChain, v.LocalFundingAmount = s.Offer.OfferChain, s.Offer.OfferAmount
+		v.RemoteFundingChain, v.RemoteFundingAmount = s.Offer.RequestChain, s.Offer.RequestAmount
+	}
+
+	if active.IsMuSig2() && active.MuSig2 != nil {
+		if cd := active.MuSig2.OfferChain; cd != nil {
+			v.HasOfferNonces = cd.LocalNonce != nil && cd.RemoteNonce != nil
+			v.HasOfferSigs = cd.PartialSig != nil && cd.RemotePartialSig != nil
+		}
+		if cd := active.MuSig2.RequestChain; cd != nil {
+			v.HasRequestNonces = cd.LocalNonce != nil && cd.RemoteNonce != nil
+			v.HasRequestSigs = cd.PartialSig != nil && cd.RemotePartialSig != nil
+		}
+	}
+
+	if zc := c.zeroConfStatusLocked(tradeID); zc.Decision != "" {
+		v.ZeroConf = zc
+	}
+	if fv := c.fundingVarianceStatusLocked(tradeID); fv.Decision != "" {
+		v.FundingVariance = fv
+	}
+	v.ourRefund, v.theirRefund = refundPointsUnlocked(active)
+	v.testnet = s.Network == chain.Testnet
+	return v
+}
+
+// ViewDeadlines returns the deadlines of a swap from its view, without
+// taking c.mu for longer than a backend lookup.
+func (c *Coordinator) ViewDeadlines(ctx context.Context, v *SwapView) *SwapDeadlines {
+	ctx = backend.WithTradeID(ctx, v.TradeID)
+	heights := c.deadlineHeights(ctx, map[string]uint32{}, v.ourRefund, v.theirRefund)
+	return buildDeadlines(v.TradeID, v.ourRefund, v.theirRefund, heights, v.testnet, time.Now().Add(c.clockOffset))
+}
+
+// SwapSnapshot returns the latest view of a swap without taking c.mu. A swap
+// that has no view yet (loaded or created but not saved since) gets one
+// published from its live state, as does a finished swap.
+func (c *Coordinator) SwapSnapshot(tradeID string) (*SwapView, error) {
+	if s := c.trades.lookup(tradeID); s != nil {
+		if v := s.view.Load(); v != nil {
+			return v, nil
+		}
+	}
+
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	return c.publishViewLocked(tradeID, active), nil
+}
+
+// SwapSnapshots returns the published views of all unfinished swaps,
+// ordered by trade ID, without taking c.mu. Swaps without a view yet are
+// left out.
+func (c *Coordinator) SwapSnapshots() []*SwapView {
+	views := c.trades.views()
+	sort.Slice(views, func(i, j int) bool { return views[i].TradeID < views[j].TradeID })
+	return views
+}
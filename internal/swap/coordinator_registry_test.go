@@ -0,0 +1,341 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// latencyBackend answers confirmation queries after a delay, or once
+// released when it has a gate.
+type latencyBackend struct {
+	backend.Backend
+	delay   time.Duration
+	gate    chan struct{}
+	entered chan string
+	queries atomic.Int64
+}
+
+func (b *latencyBackend) GetTransaction(ctx context.Context, txID string) (*backend.Transaction, error) {
+	b.queries.Add(1)
+	if b.entered != nil {
+		b.entered <- txID
+	}
+	if b.gate != nil {
+		<-b.gate
+	}
+	time.Sleep(b.delay)
+	return &backend.Transaction{TxID: txID, Confirmations: 3}, nil
+}
+
+// newRegistryCoordinator returns a coordinator holding n funding BTC/LTC
+// swaps, trade-0 to trade-n-1, whose funding is queried on b.
+func newRegistryCoordinator(tb testing.TB, n int, b backend.Backend) *Coordinator {
+	tb.Helper()
+	coord := NewCoordinator(&CoordinatorConfig{
+		Network:  chain.Testnet,
+		Backends: map[string]backend.Backend{"BTC": b, "LTC": b},
+	})
+	tb.Cleanup(func() { coord.Close() })
+
+	for i := 0; i < n; i++ {
+		s, err := NewSwap(chain.Testnet, MethodHTLC, RoleInitiator, Offer{
+			OfferChain: "BTC", OfferAmount: 100000,
+			RequestChain: "LTC", RequestAmount: 1000000,
+			Method: MethodHTLC,
+		})
+		if err != nil {
+			tb.Fatalf("NewSwap() error = %v", err)
+		}
+		s.State = StateFunding
+		s.LocalFundingTxID = fmt.Sprintf("local-%d", i)
+		coord.swaps[fmt.Sprintf("trade-%d", i)] = &ActiveSwap{Swap: s}
+	}
+	return coord
+}
+
+func TestTradeRegistry(t *testing.T) {
+	var r tradeRegistry
+	if r.lookup("a") != nil {
+		t.Fatal("lookup() found a slot in an empty registry")
+	}
+
+	// Concurrent first use hands out one slot per trade
+	slots := make([]*tradeSlot, 16)
+	var wg sync.WaitGroup
+	for i := range slots {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			slots[i] = r.slot("a")
+		}(i)
+	}
+	wg.Wait()
+	for _, s := range slots {
+		if s != slots[0] {
+			t.Fatal("slot() returned different slots for one trade")
+		}
+	}
+	if r.lookup("a") != slots[0] {
+		t.Error("lookup() doesn't return the trade's slot")
+	}
+	if r.slot("b") == slots[0] {
+		t.Error("two trades share a slot")
+	}
+
+	if views := r.views(); len(views) != 0 {
+		t.Errorf("views() = %d views before any was published", len(views))
+	}
+	slots[0].view.Store(&SwapView{TradeID: "a"})
+	if views := r.views(); len(views) != 1 || views[0].TradeID != "a" {
+		t.Errorf("views() = %v, want the view of a", views)
+	}
+}
+
+func TestSwapSnapshot(t *testing.T) {
+	coord := newRegistryCoordinator(t, 2, &latencyBackend{})
+
+	if _, err := coord.SwapSnapshot("missing"); !errors.Is(err, ErrSwapNotFound) {
+		t.Errorf("SwapSnapshot(missing) error = %v, want ErrSwapNotFound", err)
+	}
+	if views := coord.SwapSnapshots(); len(views) != 0 {
+		t.Errorf("SwapSnapshots() = %d views before any was published", len(views))
+	}
+
+	// A swap without a view gets one from its live state
+	view, err := coord.SwapSnapshot("trade-1")
+	if err != nil {
+		t.Fatalf("SwapSnapshot() error = %v", err)
+	}
+	if view.State != StateFunding || view.LocalFundingTxID != "local-1" || view.Role != RoleInitiator {
+		t.Errorf("SwapSnapshot() = %+v", view)
+	}
+
+	// Unsaved changes stay out of the view until the next save
+	coord.mu.Lock()
+	coord.swaps["trade-1"].Swap.State = StateFunded
+	coord.mu.Unlock()
+	if view, _ = coord.SwapSnapshot("trade-1"); view.State != StateFunding {
+		t.Errorf("view state = %s before the save, want funding", view.State)
+	}
+	coord.mu.Lock()
+	err = coord.saveSwapState("trade-1")
+	coord.mu.Unlock()
+	if err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	if view, _ = coord.SwapSnapshot("trade-1"); view.State != StateFunded {
+		t.Errorf("view state = %s after the save, want funded", view.State)
+	}
+
+	if _, err := coord.SwapSnapshot("trade-0"); err != nil {
+		t.Fatalf("SwapSnapshot() error = %v", err)
+	}
+	views := coord.SwapSnapshots()
+	if len(views) != 2 || views[0].TradeID != "trade-0" || views[1].TradeID != "trade-1" {
+		t.Errorf("SwapSnapshots() = %v, want trade-0 and trade-1", views)
+	}
+}
+
+func TestTradeSlotRemoval(t *testing.T) {
+	coord := newRegistryCoordinator(t, 1, &latencyBackend{})
+
+	// A slot without a view doesn't outlive its lock
+	coord.lockTrade("missing")()
+	if coord.trades.lookup("missing") != nil {
+		t.Error("lockTrade() left a slot for an unknown trade")
+	}
+
+	if err := coord.UpdateConfirmations(context.Background(), "trade-0"); err != nil {
+		t.Fatalf("UpdateConfirmations() error = %v", err)
+	}
+	if coord.trades.lookup("trade-0") == nil {
+		t.Fatal("no slot for a swap in progress")
+	}
+
+	// A finished swap's slot goes with its terminal save
+	coord.mu.Lock()
+	coord.swaps["trade-0"].Swap.State = StateRefunded
+	err := coord.saveSwapState("trade-0")
+	coord.mu.Unlock()
+	if err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	if coord.trades.lookup("trade-0") != nil {
+		t.Error("slot kept after the swap finished")
+	}
+	if views := coord.SwapSnapshots(); len(views) != 0 {
+		t.Errorf("SwapSnapshots() = %v, want no unfinished swaps", views)
+	}
+
+	// Its view is still served, without bringing the slot back
+	view, err := coord.SwapSnapshot("trade-0")
+	if err != nil {
+		t.Fatalf("SwapSnapshot() error = %v", err)
+	}
+	if view.State != StateRefunded || view.LocalFundingConfirms != 3 {
+		t.Errorf("SwapSnapshot() = state %s, %d confirmations", view.State, view.LocalFundingConfirms)
+	}
+	if coord.trades.lookup("trade-0") != nil {
+		t.Error("SwapSnapshot() recreated the slot of a finished swap")
+	}
+}
+
+func TestUpdateConfirmationsReleasesCoordinator(t *testing.T) {
+	slow := &latencyBackend{gate: make(chan struct{}), entered: make(chan string, 1)}
+	coord := newRegistryCoordinator(t, 1, slow)
+	fast := &latencyBackend{}
+	coord.SetBackend("LTC", fast)
+	coord.mu.Lock()
+	coord.swaps["trade-0"].Swap.LocalFundingTxID = "funding-a"
+	coord.swaps["trade-b"] = &ActiveSwap{Swap: &Swap{
+		Role: RoleResponder, State: StateFunding,
+		Offer:            Offer{OfferChain: "BTC", RequestChain: "LTC"},
+		LocalFundingTxID: "funding-b",
+	}}
+	coord.mu.Unlock()
+
+	done := make(chan error, 1)
+	go func() { done <- coord.UpdateConfirmations(context.Background(), "trade-0") }()
+	<-slow.entered
+
+	// trade-0 waits on its backend; other trades and status queries don't
+	if err := coord.UpdateConfirmations(context.Background(), "trade-b"); err != nil {
+		t.Fatalf("UpdateConfirmations(trade-b) error = %v", err)
+	}
+	if view, err := coord.SwapSnapshot("trade-b"); err != nil || view.LocalFundingConfirms != 3 {
+		t.Errorf("SwapSnapshot(trade-b) = %+v, %v, want 3 confirmations", view, err)
+	}
+	if _, err := coord.GetSwap("trade-0"); err != nil {
+		t.Errorf("GetSwap() error = %v", err)
+	}
+
+	// Funding replaced during the query: its stale count is dropped
+	coord.mu.Lock()
+	coord.swaps["trade-0"].Swap.LocalFundingTxID = "funding-a2"
+	coord.mu.Unlock()
+	close(slow.gate)
+	if err := <-done; err != nil {
+		t.Fatalf("UpdateConfirmations(trade-0) error = %v", err)
+	}
+	view, err := coord.SwapSnapshot("trade-0")
+	if err != nil {
+		t.Fatalf("SwapSnapshot() error = %v", err)
+	}
+	if view.LocalFundingTxID != "funding-a2" || view.LocalFundingConfirms != 0 {
+		t.Errorf("SwapSnapshot(trade-0) = %+v, want funding-a2 unconfirmed", view)
+	}
+}
+
+func TestUpdateConfirmationsSerializesTrade(t *testing.T) {
+	b := &latencyBackend{delay: time.Millisecond}
+	coord := newRegistryCoordinator(t, 4, b)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 32; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tradeID := fmt.Sprintf("trade-%d", i%4)
+			if err := coord.UpdateConfirmations(context.Background(), tradeID); err != nil {
+				t.Errorf("UpdateConfirmations(%s) error = %v", tradeID, err)
+			}
+			if _, err := coord.SwapSnapshot(tradeID); err != nil {
+				t.Errorf("SwapSnapshot(%s) error = %v", tradeID, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if got := b.queries.Load(); got != 32 {
+		t.Errorf("backend queries = %d, want 32", got)
+	}
+	for _, view := range coord.SwapSnapshots() {
+		if view.LocalFundingConfirms != 3 {
+			t.Errorf("%s confirmations = %d, want 3", view.TradeID, view.LocalFundingConfirms)
+		}
+	}
+}
+
+func TestMonitorChecksSwapsConcurrently(t *testing.T) {
+	b := &latencyBackend{delay: 50 * time.Millisecond}
+	coord := newRegistryCoordinator(t, 8, b)
+	m := NewMonitor(&MonitorConfig{Coordinator: coord, Workers: 8})
+
+	start := time.Now()
+	m.checkAllSwaps()
+	if elapsed := time.Since(start); elapsed > 300*time.Millisecond {
+		t.Errorf("checkAllSwaps() took %s, want the checks overlapped", elapsed)
+	}
+	if got := len(coord.SwapSnapshots()); got != 8 {
+		t.Errorf("SwapSnapshots() = %d views, want 8", got)
+	}
+}
+
+// BenchmarkUpdateConfirmationsParallel measures confirmation updates of
+// distinct trades against a backend with 1ms of latency. With the backend
+// queried outside c.mu, throughput grows with the number of goroutines
+// (-cpu 1,4,8) instead of staying at one update per millisecond.
+func BenchmarkUpdateConfirmationsParallel(b *testing.B) {
+	const trades = 256
+	coord := newRegistryCoordinator(b, trades, &latencyBackend{delay: time.Millisecond})
+	ids := make([]string, trades)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("trade-%d", i)
+	}
+	var next atomic.Int64
+
+	b.SetParallelism(4)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			tradeID := ids[next.Add(1)%trades]
+			if err := coord.UpdateConfirmations(context.Background(), tradeID); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
+
+// BenchmarkSwapSnapshotParallel measures status reads of swaps whose
+// confirmations are updated at the same time.
+func BenchmarkSwapSnapshotParallel(b *testing.B) {
+	const trades = 256
+	coord := newRegistryCoordinator(b, trades, &latencyBackend{})
+	ids := make([]string, trades)
+	for i := range ids {
+		ids[i] = fmt.Sprintf("trade-%d", i)
+		if err := coord.UpdateConfirmations(context.Background(), ids[i]); err != nil {
+			b.Fatal(err)
+		}
+	}
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		for i := 0; ; i++ {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = coord.UpdateConfirmations(context.Background(), ids[i%trades])
+			}
+		}
+	}()
+	var next atomic.Int64
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			if _, err := coord.SwapSnapshot(ids[next.Add(1)%trades]); err != nil {
+				b.Error(err)
+			}
+		}
+	})
+}
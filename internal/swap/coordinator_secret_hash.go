@@ -0,0 +1,93 @@
+// Package swap - Secret hash validation and uniqueness.
+//
+// A secret hash must never serve two trades. If a counterparty reused the
+// hash of another trade we take part in (or one we are still in), the secret
+// revealed to claim one trade would unlock the other, and the attacker could
+// claim our funds there. Every hash is therefore bound to the first trade it
+// is seen in, in storage, and responders refuse swaps whose hash is bound to
+// another trade.
+package swap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Secret hash errors
+var (
+	ErrInvalidSecretHash = errors.New("invalid secret hash")
+	ErrSecretHashReused  = errors.New("secret hash already used by another trade")
+)
+
+// ParseSecretHash decodes a hex secret hash strictly: exactly 64 hex digits
+// (no prefix or whitespace) of a non-zero 32-byte hash.
+func ParseSecretHash(s string) ([]byte, error) {
+	if len(s) != 64 {
+		return nil, fmt.Errorf("%w: expected 64 hex characters, got %d", ErrInvalidSecretHash, len(s))
+	}
+	hash, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSecretHash, err)
+	}
+	if err := ValidateSecretHash(hash); err != nil {
+		return nil, err
+	}
+	return hash, nil
+}
+
+// ValidateSecretHash checks that hash is a non-zero 32-byte SHA-256 hash.
+func ValidateSecretHash(hash []byte) error {
+	if len(hash) != 32 {
+		return fmt.Errorf("%w: expected 32 bytes, got %d", ErrInvalidSecretHash, len(hash))
+	}
+	if bytes.Equal(hash, make([]byte, 32)) {
+		return fmt.Errorf("%w: all zero", ErrInvalidSecretHash)
+	}
+	return nil
+}
+
+// ClaimSecretHash validates a secret hash received for tradeID and binds it
+// to the trade. It fails with ErrSecretHashReused if another trade, past or
+// concurrent, uses the same hash.
+func (c *Coordinator) ClaimSecretHash(tradeID string, hash []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.claimSecretHashUnlocked(tradeID, hash)
+}
+
+// claimSecretHashUnlocked is ClaimSecretHash with c.mu held.
+func (c *Coordinator) claimSecretHashUnlocked(tradeID string, hash []byte) error {
+	if err := ValidateSecretHash(hash); err != nil {
+		return err
+	}
+	for id, active := range c.swaps {
+		if id != tradeID && bytes.Equal(active.Swap.SecretHash, hash) {
+			return fmt.Errorf("%w: %s", ErrSecretHashReused, id)
+		}
+	}
+	if c.store == nil {
+		return nil
+	}
+	if err := c.store.ClaimSecretHash(hex.EncodeToString(hash), tradeID); err != nil {
+		if errors.Is(err, storage.ErrSecretHashReused) {
+			return fmt.Errorf("%w: %v", ErrSecretHashReused, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// recordSecretHashUnlocked registers the hash of a swap we persist, so our
+// own hashes can't be reused against us either.
+func (c *Coordinator) recordSecretHashUnlocked(tradeID string, hash []byte) {
+	if c.store == nil || len(hash) == 0 {
+		return
+	}
+	if err := c.store.ClaimSecretHash(hex.EncodeToString(hash), tradeID); err != nil {
+		c.log.Warn("Secret hash is bound to another trade", "trade_id", tradeID, "error", err)
+	}
+}
@@ -0,0 +1,115 @@
+package swap
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func TestParseSecretHash(t *testing.T) {
+	valid := sha256.Sum256([]byte("secret"))
+	validHex := hex.EncodeToString(valid[:])
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{"valid", validHex, false},
+		{"uppercase", strings.ToUpper(validHex), false},
+		{"empty", "", true},
+		{"short", validHex[:62], true},
+		{"long", validHex + "00", true},
+		{"0x prefix", "0x" + validHex[:62], true},
+		{"whitespace", " " + validHex[:63], true},
+		{"not hex", "zz" + validHex[2:], true},
+		{"all zero", strings.Repeat("0", 64), true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hash, err := ParseSecretHash(tt.input)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidSecretHash) {
+					t.Errorf("ParseSecretHash() error = %v, want ErrInvalidSecretHash", err)
+				}
+				return
+			}
+			if err != nil || !bytes.Equal(hash, valid[:]) {
+				t.Errorf("ParseSecretHash() = %x, %v", hash, err)
+			}
+		})
+	}
+}
+
+func TestClaimSecretHash(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet, Store: store})
+	t.Cleanup(func() { coord.Close() })
+
+	hash := sha256.Sum256([]byte("secret"))
+	if err := coord.ClaimSecretHash("trade-1", hash[:]); err != nil {
+		t.Fatalf("ClaimSecretHash() error = %v", err)
+	}
+	// Claiming again for the same trade (a resent message) is fine
+	if err := coord.ClaimSecretHash("trade-1", hash[:]); err != nil {
+		t.Errorf("ClaimSecretHash() again error = %v", err)
+	}
+	// Another trade can't use it, even after a restart of the coordinator
+	if err := coord.ClaimSecretHash("trade-2", hash[:]); !errors.Is(err, ErrSecretHashReused) {
+		t.Errorf("ClaimSecretHash(trade-2) error = %v, want ErrSecretHashReused", err)
+	}
+	if err := coord.ClaimSecretHash("trade-2", hash[:31]); !errors.Is(err, ErrInvalidSecretHash) {
+		t.Errorf("ClaimSecretHash(short) error = %v, want ErrInvalidSecretHash", err)
+	}
+
+	// A concurrent swap's hash is refused before it is persisted
+	other := sha256.Sum256([]byte("other"))
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleResponder, Offer{
+		OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000, Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	s.SecretHash = other[:]
+	coord.swaps["trade-3"] = &ActiveSwap{Swap: s}
+	if err := coord.ClaimSecretHash("trade-4", other[:]); !errors.Is(err, ErrSecretHashReused) {
+		t.Errorf("ClaimSecretHash(trade-4) error = %v, want ErrSecretHashReused", err)
+	}
+}
+
+func TestSetRemoteSecretHashRefusesReuse(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	t.Cleanup(func() { coord.Close() })
+
+	hash := sha256.Sum256([]byte("secret"))
+	for _, id := range []string{"trade-1", "trade-2"} {
+		s, err := NewSwap(chain.Testnet, MethodHTLC, RoleResponder, Offer{
+			OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000, Method: MethodHTLC,
+		})
+		if err != nil {
+			t.Fatalf("NewSwap() error = %v", err)
+		}
+		coord.swaps[id] = &ActiveSwap{Swap: s}
+	}
+
+	if err := coord.SetRemoteSecretHash("trade-1", hash[:]); err != nil {
+		t.Fatalf("SetRemoteSecretHash() error = %v", err)
+	}
+	if err := coord.SetRemoteSecretHash("trade-2", hash[:]); !errors.Is(err, ErrSecretHashReused) {
+		t.Errorf("SetRemoteSecretHash(trade-2) error = %v, want ErrSecretHashReused", err)
+	}
+	other := sha256.Sum256([]byte("other"))
+	if err := coord.SetRemoteSecretHash("trade-1", other[:]); err == nil {
+		t.Error("SetRemoteSecretHash() replaced the hash of a set-up swap")
+	}
+}
@@ -30,6 +30,9 @@ func (c *Coordinator) CreatePartialSignatures(ctx context.Context, tradeID strin
 	if active.Swap.State != StateFunded {
 		return nil, nil, ErrNotReadyToSign
 	}
+	if _, err := musig2Sessions(active); err != nil {
+		return nil, nil, err
+	}
 
 	// Check safety margin
 	offerHeight, _ := c.getBlockHeight(ctx, active.Swap.Offer.OfferChain)
@@ -91,7 +94,7 @@ func (c *Coordinator) CombineSignatures(tradeID string, chainSymbol string, remo
 		return nil, fmt.Errorf("unknown chain: %s", chainSymbol)
 	}
 
-	if chainData.PartialSig == nil {
+	if chainData.PartialSig == nil || chainData.Session == nil {
 		return nil, errors.New("local partial signature not created for this chain")
 	}
 
@@ -127,6 +130,9 @@ func (c *Coordinator) SetRemotePartialSigs(tradeID string, offerSig, requestSig
 	if !ok {
 		return ErrSwapNotFound
 	}
+	if _, err := musig2Sessions(active); err != nil {
+		return err
+	}
 
 	if len(offerSig) != 32 || len(requestSig) != 32 {
 		return fmt.Errorf("invalid partial sig length: expected 32 bytes each")
@@ -168,6 +174,9 @@ func (c *Coordinator) HasRemotePartialSigs(tradeID string) (hasOffer, hasRequest
 	if !ok {
 		return false, false
 	}
+	if active.MuSig2 == nil {
+		return false, false
+	}
 
 	hasOffer = active.MuSig2.OfferChain != nil && active.MuSig2.OfferChain.RemotePartialSig != nil
 	hasRequest = active.MuSig2.RequestChain != nil && active.MuSig2.RequestChain.RemotePartialSig != nil
@@ -0,0 +1,84 @@
+// Package swap - Swap state snapshots for fast restarts.
+//
+// With snapshots on, the pending swaps are written to one compact blob in
+// the store every interval and at shutdown. A restart reads the blob plus
+// only the swaps updated since it was taken, makes the swaps available at
+// once and reconciles them against the chains in the background, instead
+// of querying the chains for every swap before the node is ready.
+package swap
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// StartSnapshots takes a swap snapshot every interval and makes
+// LoadPendingSwaps start from the latest one. Call it before
+// LoadPendingSwaps.
+func (c *Coordinator) StartSnapshots(interval time.Duration) {
+	if interval <= 0 {
+		c.log.Warn("Swap snapshots not started: interval not set")
+		return
+	}
+	c.mu.Lock()
+	c.snapshotInterval = interval
+	c.mu.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-c.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := c.TakeSnapshot(); err != nil {
+					c.log.Warn("Failed to take swap snapshot", "error", err)
+				}
+			}
+		}
+	}()
+}
+
+// TakeSnapshot writes the pending swaps to the store's snapshot.
+func (c *Coordinator) TakeSnapshot() (*storage.SwapSnapshotInfo, error) {
+	if c.store == nil {
+		return nil, fmt.Errorf("no storage configured")
+	}
+	info, err := c.store.SaveSwapSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	c.log.Debug("Swap snapshot taken", "swaps", info.Swaps, "bytes", info.Size)
+	return info, nil
+}
+
+// ReconcileBacklog returns how many swaps loaded at startup still wait for
+// background reconciliation.
+func (c *Coordinator) ReconcileBacklog() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.reconcileBacklog)
+}
+
+// reconcileInBackground reconciles swaps loaded at startup one at a time,
+// releasing the lock between them so the node serves requests meanwhile.
+// Swaps reconciled on request in the meantime are skipped.
+func (c *Coordinator) reconcileInBackground(tradeIDs []string) {
+	start := time.Now()
+	for _, tradeID := range tradeIDs {
+		if c.ctx.Err() != nil {
+			return
+		}
+		c.mu.Lock()
+		if _, ok := c.swaps[tradeID]; ok && c.reconcileBacklog[tradeID] {
+			c.reconcileSwapUnlocked(backend.WithTradeID(c.ctx, tradeID), tradeID)
+		}
+		delete(c.reconcileBacklog, tradeID)
+		c.mu.Unlock()
+	}
+	c.log.Info("Background swap reconciliation finished", "swaps", len(tradeIDs), "duration", time.Since(start).Round(time.Millisecond))
+}
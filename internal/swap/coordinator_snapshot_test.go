@@ -0,0 +1,69 @@
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestLoadPendingSwapsFromSnapshot(t *testing.T) {
+	store := newTestStore(t)
+
+	// Persist a funding swap and snapshot it
+	before := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store))
+	addReconcileTrade(t, before, RoleInitiator)
+	before.mu.Lock()
+	before.swaps["t1"].Swap.LocalFundingTxID = "our-fund"
+	before.swaps["t1"].Swap.RemoteFundingTxID = "taker-fund"
+	if err := before.saveSwapState("t1"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	before.mu.Unlock()
+	if info, err := before.TakeSnapshot(); err != nil || info.Swaps != 1 {
+		t.Fatalf("TakeSnapshot() = %+v, %v", info, err)
+	}
+
+	// Meanwhile our claim of the taker's LTC confirmed
+	ltc := newFakeChainBackend()
+	after := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("LTC", ltc))
+	ltc.history[rcRequestEscrow] = []backend.Transaction{
+		escrowSpend("our-claim", "taker-fund", rcRequestEscrow, "3044", hex.EncodeToString(rcSecret), "01", "a914"),
+		escrowFunding("taker-fund", rcRequestEscrow),
+	}
+	after.StartSnapshots(time.Hour)
+
+	if err := after.LoadPendingSwaps(context.Background()); err != nil {
+		t.Fatalf("LoadPendingSwaps() error = %v", err)
+	}
+	after.mu.RLock()
+	_, loaded := after.swaps["t1"]
+	after.mu.RUnlock()
+	if !loaded {
+		t.Fatal("swap not loaded from the snapshot")
+	}
+
+	// Reconciliation catches up in the background
+	deadline := time.Now().Add(5 * time.Second)
+	for after.ReconcileBacklog() > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("background reconciliation did not finish")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	after.mu.RLock()
+	state := after.swaps["t1"].Swap.State
+	after.mu.RUnlock()
+	if state != StateRedeemed {
+		t.Errorf("state = %s, want redeemed", state)
+	}
+
+	// Closing takes a final snapshot, which no longer holds the finished swap
+	after.Close()
+	if _, info, err := store.GetPendingSwapsFromSnapshot(); err != nil || info == nil || info.Swaps != 0 {
+		t.Errorf("snapshot after Close() = %+v, %v", info, err)
+	}
+}
@@ -7,9 +7,14 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"time"
 
+	"filippo.io/edwards25519"
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chaos"
 	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/ethereum/go-ethereum/common"
 )
 
 // =============================================================================
@@ -85,11 +90,15 @@ func (c *Coordinator) GetMuSig2StorageData(tradeID string) (json.RawMessage, err
 // This should be called after any state change to enable recovery after restart.
 // NOTE: Caller must hold c.mu lock.
 func (c *Coordinator) saveSwapState(tradeID string) error {
+	active, ok := c.swaps[tradeID]
+	if ok {
+		// Status queries see the state being saved
+		c.publishViewLocked(tradeID, active)
+	}
+
 	if c.store == nil {
 		return nil // No storage configured, skip persistence
 	}
-
-	active, ok := c.swaps[tradeID]
 	if !ok {
 		return ErrSwapNotFound
 	}
@@ -111,12 +120,28 @@ func (c *Coordinator) saveSwapState(tradeID string) error {
 	} else if active.IsHTLC() {
 		// HTLC Bitcoin swap
 		methodData, err = c.getHTLCStorageDataUnlocked(active)
+	} else if active.IsXMR() {
+		// BTC/XMR adaptor swap
+		methodData, err = c.getXMRStorageDataUnlocked(active)
 	} else {
 		return fmt.Errorf("unknown swap method: %s", active.Swap.Offer.Method)
 	}
 	if err != nil {
 		return fmt.Errorf("failed to get method data: %w", err)
 	}
+	if active.Swap.IsTerminal() {
+		// A finished swap is stored without its keys and secret
+		if methodData, _, err = scrubMethodData(methodData); err != nil {
+			return fmt.Errorf("failed to scrub method data: %w", err)
+		}
+	}
+
+	var feeTerms json.RawMessage
+	if !active.Swap.Offer.FeeTerms.IsDefault() {
+		if feeTerms, err = json.Marshal(active.Swap.Offer.FeeTerms); err != nil {
+			return fmt.Errorf("failed to marshal fee terms: %w", err)
+		}
+	}
 
 	// Build swap record
 	record := &storage.SwapRecord{
@@ -131,6 +156,9 @@ func (c *Coordinator) saveSwapState(tradeID string) error {
 		OfferAmount:   active.Swap.Offer.OfferAmount,
 		RequestChain:  active.Swap.Offer.RequestChain,
 		RequestAmount: active.Swap.Offer.RequestAmount,
+		OfferToken:    active.Swap.Offer.OfferToken,
+		RequestToken:  active.Swap.Offer.RequestToken,
+		FeeTerms:      feeTerms,
 
 		State:      swapStateToStorage(active.Swap.State),
 		MethodData: methodData,
@@ -151,7 +179,44 @@ func (c *Coordinator) saveSwapState(tradeID string) error {
 		record.TakerPeerID = active.Trade.TakerPeerID
 	}
 
-	return c.store.SaveSwap(record)
+	c.recordSecretHashUnlocked(tradeID, active.Swap.SecretHash)
+	c.recordKeyUsageUnlocked(tradeID, active)
+	chaos.CrashPoint(chaos.PointBeforeSaveSwap)
+	if err := c.store.SaveSwap(record); err != nil {
+		return err
+	}
+	chaos.CrashPoint(chaos.PointAfterSaveSwap)
+	if active.Swap.IsTerminal() {
+		if active.Swap.State != StateRedeemed {
+			c.releaseOrderFillUnlocked(tradeID)
+		}
+		c.forgetFinishedSwapUnlocked(tradeID, active)
+	}
+	return nil
+}
+
+// EventOrderFillReleased is emitted when an unfinished trade gave the fill
+// it took back to its order. The maker announces the order's new fill.
+const EventOrderFillReleased = "order_fill_released"
+
+// releaseOrderFillUnlocked returns the fill a trade took of its order when
+// the swap was cancelled, failed or refunded, so the order can be taken
+// again. Only the first release changes the order.
+func (c *Coordinator) releaseOrderFillUnlocked(tradeID string) {
+	order, err := c.store.ReleaseTradeFill(tradeID)
+	if err != nil {
+		if !errors.Is(err, storage.ErrTradeNotFound) {
+			c.log.Warn("Failed to release order fill", "trade_id", tradeID, "error", err)
+		}
+		return
+	}
+	if order != nil {
+		c.log.Info("Released order fill of unfinished trade", "trade_id", tradeID, "order_id", order.ID, "remaining", order.Remaining())
+		c.emitEvent(tradeID, EventOrderFillReleased, map[string]interface{}{
+			"order_id":  order.ID,
+			"remaining": order.Remaining(),
+		})
+	}
 }
 
 // getMuSig2StorageDataUnlocked gets MuSig2 storage data without locking.
@@ -163,6 +228,9 @@ func (c *Coordinator) getMuSig2StorageDataUnlocked(active *ActiveSwap) (json.Raw
 		LocalRequestWalletAddr:  active.Swap.LocalRequestWalletAddr,
 		RemoteOfferWalletAddr:   active.Swap.RemoteOfferWalletAddr,
 		RemoteRequestWalletAddr: active.Swap.RemoteRequestWalletAddr,
+		OfferFundedAmount:       active.Swap.OfferFundedAmount,
+		RequestFundedAmount:     active.Swap.RequestFundedAmount,
+		ExternalFunding:         active.Swap.ExternalFunding,
 		OfferChain:              getChainStorageData(active.MuSig2.OfferChain, active.Swap.Offer.OfferChain),
 		RequestChain:            getChainStorageData(active.MuSig2.RequestChain, active.Swap.Offer.RequestChain),
 	}
@@ -183,6 +251,10 @@ func (c *Coordinator) getHTLCStorageDataUnlocked(active *ActiveSwap) (json.RawMe
 		LocalRequestWalletAddr:  active.Swap.LocalRequestWalletAddr,
 		RemoteOfferWalletAddr:   active.Swap.RemoteOfferWalletAddr,
 		RemoteRequestWalletAddr: active.Swap.RemoteRequestWalletAddr,
+		OfferFundedAmount:       active.Swap.OfferFundedAmount,
+		RequestFundedAmount:     active.Swap.RequestFundedAmount,
+		ExternalFunding:         active.Swap.ExternalFunding,
+		Lightning:               active.Swap.Lightning,
 	}
 
 	// Add secret/secret hash
@@ -199,6 +271,8 @@ func (c *Coordinator) getHTLCStorageDataUnlocked(active *ActiveSwap) (json.RawMe
 			data.OfferChain = &HTLCChainStorageData{
 				Symbol:      active.Swap.Offer.OfferChain,
 				HTLCAddress: active.HTLC.OfferChain.HTLCAddress,
+				ClaimTxID:   active.HTLC.OfferChain.ClaimTxID,
+				RefundTxID:  active.HTLC.OfferChain.RefundTxID,
 			}
 			if active.HTLC.OfferChain.Session != nil {
 				sessionData, _ := active.HTLC.OfferChain.Session.MarshalStorageData()
@@ -209,6 +283,8 @@ func (c *Coordinator) getHTLCStorageDataUnlocked(active *ActiveSwap) (json.RawMe
 			data.RequestChain = &HTLCChainStorageData{
 				Symbol:      active.Swap.Offer.RequestChain,
 				HTLCAddress: active.HTLC.RequestChain.HTLCAddress,
+				ClaimTxID:   active.HTLC.RequestChain.ClaimTxID,
+				RefundTxID:  active.HTLC.RequestChain.RefundTxID,
 			}
 			if active.HTLC.RequestChain.Session != nil {
 				sessionData, _ := active.HTLC.RequestChain.Session.MarshalStorageData()
@@ -220,6 +296,27 @@ func (c *Coordinator) getHTLCStorageDataUnlocked(active *ActiveSwap) (json.RawMe
 	return json.Marshal(data)
 }
 
+// getXMRStorageDataUnlocked returns the storage data of an adaptor swap.
+func (c *Coordinator) getXMRStorageDataUnlocked(active *ActiveSwap) (json.RawMessage, error) {
+	x := active.XMR
+	data := CoordinatorXMRStorageData{
+		XMRLeg:                  x.XMRLeg,
+		LocalShare:              x.Secrets.Share(),
+		RemoteShare:             x.Remote,
+		LocalOfferWalletAddr:    active.Swap.LocalOfferWalletAddr,
+		LocalRequestWalletAddr:  active.Swap.LocalRequestWalletAddr,
+		RemoteOfferWalletAddr:   active.Swap.RemoteOfferWalletAddr,
+		RemoteRequestWalletAddr: active.Swap.RemoteRequestWalletAddr,
+	}
+	if x.Secrets.BTCKey != nil {
+		data.LocalPrivKey = hex.EncodeToString(x.Secrets.BTCKey.Serialize())
+	}
+	if x.Secrets.SpendKey != nil {
+		data.LocalSpendKey = hex.EncodeToString(x.Secrets.SpendKey.Bytes())
+	}
+	return json.Marshal(data)
+}
+
 // getEVMHTLCStorageDataUnlocked gets EVM HTLC storage data without locking.
 func (c *Coordinator) getEVMHTLCStorageDataUnlocked(active *ActiveSwap) (json.RawMessage, error) {
 	data := CoordinatorEVMHTLCStorageData{
@@ -239,10 +336,11 @@ func (c *Coordinator) getEVMHTLCStorageDataUnlocked(active *ActiveSwap) (json.Ra
 
 	// Add EVM HTLC chain data
 	if active.EVMHTLC != nil {
+		data.CreateCancels = active.EVMHTLC.CreateCancels
 		if active.EVMHTLC.OfferChain != nil {
 			data.OfferChain = &EVMHTLCChainStorageData{
 				Symbol:          active.Swap.Offer.OfferChain,
-				ContractAddress: active.EVMHTLC.OfferChain.ContractAddress.Hex(),
+				ContractAddress: active.EVMHTLC.OfferChain.Contract().Hex(),
 				SwapID:          hex.EncodeToString(active.EVMHTLC.OfferChain.SwapID[:]),
 				CreateTxHash:    active.EVMHTLC.OfferChain.CreateTxHash.Hex(),
 				ClaimTxHash:     active.EVMHTLC.OfferChain.ClaimTxHash.Hex(),
@@ -258,7 +356,7 @@ func (c *Coordinator) getEVMHTLCStorageDataUnlocked(active *ActiveSwap) (json.Ra
 		if active.EVMHTLC.RequestChain != nil {
 			data.RequestChain = &EVMHTLCChainStorageData{
 				Symbol:          active.Swap.Offer.RequestChain,
-				ContractAddress: active.EVMHTLC.RequestChain.ContractAddress.Hex(),
+				ContractAddress: active.EVMHTLC.RequestChain.Contract().Hex(),
 				SwapID:          hex.EncodeToString(active.EVMHTLC.RequestChain.SwapID[:]),
 				CreateTxHash:    active.EVMHTLC.RequestChain.CreateTxHash.Hex(),
 				ClaimTxHash:     active.EVMHTLC.RequestChain.ClaimTxHash.Hex(),
@@ -337,8 +435,10 @@ func (c *Coordinator) getCrossChainStorageDataUnlocked(active *ActiveSwap) (json
 	return json.Marshal(data)
 }
 
-// LoadPendingSwaps loads all pending swaps from the database on startup.
-// This enables recovery after a node restart.
+// LoadPendingSwaps loads all pending swaps from the database on startup and
+// reconciles each against the chains. This enables recovery after a node
+// restart. With snapshots on, the swaps are loaded from the latest snapshot
+// and reconciled in the background after this returns.
 func (c *Coordinator) LoadPendingSwaps(ctx context.Context) error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -347,16 +447,49 @@ func (c *Coordinator) LoadPendingSwaps(ctx context.Context) error {
 		return nil // No storage configured
 	}
 
-	records, err := c.store.GetPendingSwaps()
+	start := time.Now()
+	lazy := c.snapshotInterval > 0
+	var records []*storage.SwapRecord
+	var snapshot *storage.SwapSnapshotInfo
+	var err error
+	if lazy {
+		records, snapshot, err = c.store.GetPendingSwapsFromSnapshot()
+	} else {
+		records, err = c.store.GetPendingSwaps()
+	}
 	if err != nil {
 		return fmt.Errorf("failed to get pending swaps: %w", err)
 	}
 
 	var recoveryErrors []error
+	var loaded []string
 	for _, record := range records {
 		if err := c.recoverSwapFromRecord(ctx, record); err != nil {
 			recoveryErrors = append(recoveryErrors, fmt.Errorf("swap %s: %w", record.TradeID, err))
+			continue
 		}
+		if lazy {
+			loaded = append(loaded, record.TradeID)
+			continue
+		}
+		c.reconcileSwapUnlocked(backend.WithTradeID(ctx, record.TradeID), record.TradeID)
+	}
+
+	if len(loaded) > 0 {
+		if c.reconcileBacklog == nil {
+			c.reconcileBacklog = make(map[string]bool)
+		}
+		for _, tradeID := range loaded {
+			c.reconcileBacklog[tradeID] = true
+		}
+		go c.reconcileInBackground(loaded)
+	}
+	if snapshot != nil {
+		c.log.Info("Pending swaps loaded from snapshot",
+			"swaps", len(records),
+			"snapshot_age", time.Since(snapshot.TakenAt).Round(time.Second),
+			"duration", time.Since(start).Round(time.Millisecond),
+		)
 	}
 
 	if len(recoveryErrors) > 0 {
@@ -377,17 +510,29 @@ func (c *Coordinator) recoverSwapFromRecord(ctx context.Context, record *storage
 	// Detect swap type by attempting to parse different storage formats
 	swapType := c.detectSwapTypeFromMethodData(record.MethodData, record.OfferChain, record.RequestChain)
 
+	var err error
 	switch swapType {
 	case "evm_htlc":
-		return c.recoverEVMHTLCSwap(ctx, record)
+		err = c.recoverEVMHTLCSwap(ctx, record)
 	case "bitcoin_htlc":
-		return c.recoverBitcoinHTLCSwap(ctx, record)
+		err = c.recoverBitcoinHTLCSwap(ctx, record)
 	case "cross_chain":
-		return c.recoverCrossChainSwap(ctx, record)
+		err = c.recoverCrossChainSwap(ctx, record)
+	case "xmr":
+		err = c.recoverXMRSwap(ctx, record)
 	default:
 		// Default to MuSig2 for backward compatibility
-		return c.recoverMuSig2Swap(ctx, record)
+		err = c.recoverMuSig2Swap(ctx, record)
 	}
+	if err != nil {
+		return err
+	}
+
+	c.restorePayoutAddressUnlocked(record.TradeID)
+	c.restoreZeroConfUnlocked(record.TradeID)
+	c.restoreFundingVarianceUnlocked(record.TradeID)
+	c.resumeLightningUnlocked(record.TradeID)
+	return nil
 }
 
 // detectSwapTypeFromMethodData determines the swap type from stored method data.
@@ -398,12 +543,6 @@ func (c *Coordinator) detectSwapTypeFromMethodData(methodData json.RawMessage, o
 
 	// Try to detect based on field presence in the JSON
 	var probe struct {
-		// EVM HTLC specific
-		OfferChainEVM   *EVMHTLCChainStorageData `json:"offer_chain,omitempty"`
-		RequestChainEVM *EVMHTLCChainStorageData `json:"request_chain,omitempty"`
-		// Bitcoin HTLC specific
-		OfferChainBTC   *HTLCChainStorageData `json:"offer_chain,omitempty"`
-		RequestChainBTC *HTLCChainStorageData `json:"request_chain,omitempty"`
 		// Cross-chain specific
 		BitcoinHTLC *CoordinatorHTLCStorageData    `json:"bitcoin_htlc,omitempty"`
 		EVMHTLC     *CoordinatorEVMHTLCStorageData `json:"evm_htlc,omitempty"`
@@ -411,9 +550,15 @@ func (c *Coordinator) detectSwapTypeFromMethodData(methodData json.RawMessage, o
 		SecretHash string `json:"secret_hash,omitempty"`
 		// MuSig2 specific
 		LocalPrivKey string `json:"local_priv_key,omitempty"`
+		// Adaptor specific
+		XMRRole XMRRole `json:"xmr_role,omitempty"`
 	}
 	_ = json.Unmarshal(methodData, &probe)
 
+	if probe.XMRRole != "" {
+		return "xmr"
+	}
+
 	// Cross-chain swap (has both Bitcoin and EVM data)
 	if probe.BitcoinHTLC != nil || probe.EVMHTLC != nil {
 		return "cross_chain"
@@ -447,8 +592,15 @@ func (c *Coordinator) recoverMuSig2Swap(ctx context.Context, record *storage.Swa
 		OfferAmount:   record.OfferAmount,
 		RequestChain:  record.RequestChain,
 		RequestAmount: record.RequestAmount,
+		OfferToken:    record.OfferToken,
+		RequestToken:  record.RequestToken,
 		Method:        MethodMuSig2,
 	}
+	feeTerms, err := ParseFeeTerms(record.FeeTerms)
+	if err != nil {
+		return err
+	}
+	offer.FeeTerms = feeTerms
 
 	// Determine role
 	role := Role(record.OurRole)
@@ -499,6 +651,9 @@ func (c *Coordinator) recoverMuSig2Swap(ctx context.Context, record *storage.Swa
 	swap.LocalRequestWalletAddr = methodData.LocalRequestWalletAddr
 	swap.RemoteOfferWalletAddr = methodData.RemoteOfferWalletAddr
 	swap.RemoteRequestWalletAddr = methodData.RemoteRequestWalletAddr
+	swap.OfferFundedAmount = methodData.OfferFundedAmount
+	swap.RequestFundedAmount = methodData.RequestFundedAmount
+	swap.ExternalFunding = methodData.ExternalFunding
 
 	// Reconstruct private key
 	var privKey *btcec.PrivateKey
@@ -619,8 +774,15 @@ func (c *Coordinator) recoverEVMHTLCSwap(ctx context.Context, record *storage.Sw
 		OfferAmount:   record.OfferAmount,
 		RequestChain:  record.RequestChain,
 		RequestAmount: record.RequestAmount,
+		OfferToken:    record.OfferToken,
+		RequestToken:  record.RequestToken,
 		Method:        MethodHTLC,
 	}
+	feeTerms, err := ParseFeeTerms(record.FeeTerms)
+	if err != nil {
+		return err
+	}
+	offer.FeeTerms = feeTerms
 
 	// Determine role
 	role := Role(record.OurRole)
@@ -654,10 +816,15 @@ func (c *Coordinator) recoverEVMHTLCSwap(ctx context.Context, record *storage.Sw
 		swap.SecretHash, _ = hex.DecodeString(methodData.SecretHash)
 	}
 
-	// Create active swap - sessions will be recreated when needed via getOrCreateEVMSession
+	// Create active swap - sessions will be recreated when needed via getOrCreateEVMSession,
+	// on the contracts the legs were created on
 	active := &ActiveSwap{
-		Swap:    swap,
-		EVMHTLC: &EVMHTLCSwapData{},
+		Swap: swap,
+		EVMHTLC: &EVMHTLCSwapData{
+			OfferChain:    restoreEVMChainData(methodData.OfferChain),
+			RequestChain:  restoreEVMChainData(methodData.RequestChain),
+			CreateCancels: methodData.CreateCancels,
+		},
 	}
 
 	c.swaps[record.TradeID] = active
@@ -671,6 +838,20 @@ func (c *Coordinator) recoverEVMHTLCSwap(ctx context.Context, record *storage.Sw
 	return nil
 }
 
+// restoreEVMChainData restores the HTLC contract an EVM leg is pinned to,
+// nil if none was stored (the leg then uses the current one). Its session
+// is recreated when needed.
+func restoreEVMChainData(stored *EVMHTLCChainStorageData) *ChainEVMHTLCData {
+	if stored == nil || !common.IsHexAddress(stored.ContractAddress) {
+		return nil
+	}
+	addr := common.HexToAddress(stored.ContractAddress)
+	if addr == (common.Address{}) {
+		return nil
+	}
+	return &ChainEVMHTLCData{ContractAddress: addr}
+}
+
 // recoverBitcoinHTLCSwap recovers a Bitcoin-family HTLC swap from storage.
 func (c *Coordinator) recoverBitcoinHTLCSwap(ctx context.Context, record *storage.SwapRecord) error {
 	var methodData CoordinatorHTLCStorageData
@@ -684,8 +865,15 @@ func (c *Coordinator) recoverBitcoinHTLCSwap(ctx context.Context, record *storag
 		OfferAmount:   record.OfferAmount,
 		RequestChain:  record.RequestChain,
 		RequestAmount: record.RequestAmount,
+		OfferToken:    record.OfferToken,
+		RequestToken:  record.RequestToken,
 		Method:        MethodHTLC,
 	}
+	feeTerms, err := ParseFeeTerms(record.FeeTerms)
+	if err != nil {
+		return err
+	}
+	offer.FeeTerms = feeTerms
 
 	// Determine role
 	role := Role(record.OurRole)
@@ -716,6 +904,10 @@ func (c *Coordinator) recoverBitcoinHTLCSwap(ctx context.Context, record *storag
 	swap.LocalRequestWalletAddr = methodData.LocalRequestWalletAddr
 	swap.RemoteOfferWalletAddr = methodData.RemoteOfferWalletAddr
 	swap.RemoteRequestWalletAddr = methodData.RemoteRequestWalletAddr
+	swap.OfferFundedAmount = methodData.OfferFundedAmount
+	swap.RequestFundedAmount = methodData.RequestFundedAmount
+	swap.ExternalFunding = methodData.ExternalFunding
+	swap.Lightning = methodData.Lightning
 
 	// Restore public keys
 	if methodData.LocalPubKey != "" {
@@ -742,7 +934,10 @@ func (c *Coordinator) recoverBitcoinHTLCSwap(ctx context.Context, record *storag
 	// Create active swap - sessions will be recreated when needed
 	active := &ActiveSwap{
 		Swap: swap,
-		HTLC: &HTLCSwapData{},
+		HTLC: &HTLCSwapData{
+			OfferChain:   restoreHTLCChainData(methodData.OfferChain),
+			RequestChain: restoreHTLCChainData(methodData.RequestChain),
+		},
 	}
 
 	c.swaps[record.TradeID] = active
@@ -756,6 +951,102 @@ func (c *Coordinator) recoverBitcoinHTLCSwap(ctx context.Context, record *storag
 	return nil
 }
 
+// restoreHTLCChainData rebuilds per-chain HTLC data from storage.
+// Returns nil if nothing was stored for the chain.
+func restoreHTLCChainData(stored *HTLCChainStorageData) *ChainHTLCData {
+	if stored == nil {
+		return nil
+	}
+	data := &ChainHTLCData{
+		HTLCAddress: stored.HTLCAddress,
+		ClaimTxID:   stored.ClaimTxID,
+		RefundTxID:  stored.RefundTxID,
+	}
+	if stored.SessionData != "" {
+		if session, err := UnmarshalHTLCStorageData([]byte(stored.SessionData)); err == nil {
+			data.Session = session
+		}
+	}
+	return data
+}
+
+// recoverXMRSwap recovers a BTC/XMR adaptor swap from storage and resumes
+// its driver.
+func (c *Coordinator) recoverXMRSwap(ctx context.Context, record *storage.SwapRecord) error {
+	var methodData CoordinatorXMRStorageData
+	if err := json.Unmarshal(record.MethodData, &methodData); err != nil {
+		return fmt.Errorf("failed to unmarshal adaptor swap data: %w", err)
+	}
+
+	offer := Offer{
+		OfferChain:    record.OfferChain,
+		OfferAmount:   record.OfferAmount,
+		RequestChain:  record.RequestChain,
+		RequestAmount: record.RequestAmount,
+		Method:        MethodAdaptor,
+	}
+	role := RoleResponder
+	if record.IsMaker {
+		role = RoleInitiator
+	}
+	swap, err := NewSwap(c.network, MethodAdaptor, role, offer)
+	if err != nil {
+		return fmt.Errorf("failed to create swap: %w", err)
+	}
+	swap.ID = record.TradeID
+	swap.State = storageStateToSwap(record.State)
+	swap.LocalFundingTxID = record.LocalFundingTxID
+	swap.LocalFundingVout = record.LocalFundingVout
+	swap.RemoteFundingTxID = record.RemoteFundingTxID
+	swap.RemoteFundingVout = record.RemoteFundingVout
+	swap.LocalOfferWalletAddr = methodData.LocalOfferWalletAddr
+	swap.LocalRequestWalletAddr = methodData.LocalRequestWalletAddr
+	swap.RemoteOfferWalletAddr = methodData.RemoteOfferWalletAddr
+	swap.RemoteRequestWalletAddr = methodData.RemoteRequestWalletAddr
+
+	// Keys, which a finished swap was stored without
+	x := &XMRSwapData{XMRLeg: methodData.XMRLeg, Remote: methodData.RemoteShare}
+	privBytes, err := hex.DecodeString(methodData.LocalPrivKey)
+	if err != nil || len(privBytes) != 32 {
+		return fmt.Errorf("missing or invalid swap key")
+	}
+	spendBytes, err := hex.DecodeString(methodData.LocalSpendKey)
+	if err != nil {
+		return fmt.Errorf("invalid spend key share: %w", err)
+	}
+	spend, err := edwards25519.NewScalar().SetCanonicalBytes(spendBytes)
+	if err != nil {
+		return fmt.Errorf("invalid spend key share: %w", err)
+	}
+	privKey, _ := btcec.PrivKeyFromBytes(privBytes)
+	if x.Secrets, err = restoreXMRSecrets(privKey, spend, methodData.LocalShare); err != nil {
+		return err
+	}
+	swap.SetLocalPubKey(privKey.PubKey())
+	if x.Remote != nil {
+		_ = swap.SetRemotePubKey(x.Remote.BTCKey)
+		btcShare, xmrShare := x.shares()
+		t, err := xmrTimeouts(x.BTCChain, c.network)
+		if err != nil {
+			return err
+		}
+		if x.Scripts, err = BuildXMRScripts(btcShare.BTCKey, xmrShare.BTCKey, t.CancelTimeout, t.PunishTimeout); err != nil {
+			return fmt.Errorf("failed to rebuild lock scripts: %w", err)
+		}
+	}
+
+	active := &ActiveSwap{Swap: swap, XMR: x}
+	c.swaps[record.TradeID] = active
+	c.startXMRDriverUnlocked(record.TradeID, active)
+
+	c.log.Info("Recovered adaptor swap", "trade_id", record.TradeID, "state", record.State, "xmr_role", string(x.Role))
+	c.emitEvent(record.TradeID, "swap_recovered", map[string]interface{}{
+		"type":  "xmr",
+		"state": string(record.State),
+	})
+	return nil
+}
+
 // recoverCrossChainSwap recovers a cross-chain (Bitcoin <-> EVM) swap from storage.
 func (c *Coordinator) recoverCrossChainSwap(ctx context.Context, record *storage.SwapRecord) error {
 	// Parse cross-chain storage data
@@ -781,8 +1072,15 @@ func (c *Coordinator) recoverCrossChainSwap(ctx context.Context, record *storage
 		OfferAmount:   record.OfferAmount,
 		RequestChain:  record.RequestChain,
 		RequestAmount: record.RequestAmount,
+		OfferToken:    record.OfferToken,
+		RequestToken:  record.RequestToken,
 		Method:        MethodHTLC,
 	}
+	feeTerms, err := ParseFeeTerms(record.FeeTerms)
+	if err != nil {
+		return err
+	}
+	offer.FeeTerms = feeTerms
 
 	// Determine role
 	role := Role(record.OurRole)
@@ -828,6 +1126,11 @@ func (c *Coordinator) recoverCrossChainSwap(ctx context.Context, record *storage
 		HTLC:    &HTLCSwapData{},
 		EVMHTLC: &EVMHTLCSwapData{},
 	}
+	if methodData.EVMHTLC != nil {
+		active.EVMHTLC.OfferChain = restoreEVMChainData(methodData.EVMHTLC.OfferChain)
+		active.EVMHTLC.RequestChain = restoreEVMChainData(methodData.EVMHTLC.RequestChain)
+		active.EVMHTLC.CreateCancels = methodData.EVMHTLC.CreateCancels
+	}
 
 	c.swaps[record.TradeID] = active
 
@@ -842,6 +1145,7 @@ func (c *Coordinator) recoverCrossChainSwap(ctx context.Context, record *storage
 
 // RecoverSwap loads and recovers a single swap from the database.
 func (c *Coordinator) RecoverSwap(ctx context.Context, tradeID string) error {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -854,7 +1158,11 @@ func (c *Coordinator) RecoverSwap(ctx context.Context, tradeID string) error {
 		return fmt.Errorf("failed to get swap: %w", err)
 	}
 
-	return c.recoverSwapFromRecord(ctx, record)
+	if err := c.recoverSwapFromRecord(ctx, record); err != nil {
+		return err
+	}
+	c.reconcileSwapUnlocked(ctx, tradeID)
+	return nil
 }
 
 // ListSwaps returns info about all swaps (both memory and database).
@@ -2,12 +2,33 @@ package swap
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
+	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
 )
 
+// testMnemonic is the BIP-39 test vector the coordinator tests derive their
+// wallets from.
+const testMnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+
+// newTestWalletService creates a wallet service over registry from
+// testMnemonic and returns it with its first BTC receive address and the
+// BTC change address at index 3.
+func newTestWalletService(t *testing.T, registry *backend.Registry) (ws *wallet.Service, recv, change string) {
+	t.Helper()
+	ws = wallet.NewService(&wallet.ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Backends: registry})
+	if err := ws.CreateWallet(testMnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	recv, _ = ws.GetAddressWithChange("BTC", 0, 0, 0)
+	change, _ = ws.GetAddressWithChange("BTC", 0, 1, 3)
+	return ws, recv, change
+}
+
 func TestNewCoordinator(t *testing.T) {
 	cfg := &CoordinatorConfig{
 		Network: chain.Testnet,
@@ -26,8 +47,8 @@ func TestNewCoordinator(t *testing.T) {
 		t.Error("swaps map not initialized")
 	}
 
-	if coord.eventHandlers == nil {
-		t.Error("eventHandlers not initialized")
+	if coord.events == nil {
+		t.Error("event bus not initialized")
 	}
 
 	// Close coordinator
@@ -140,6 +161,34 @@ func TestGetSwapNotFound(t *testing.T) {
 	}
 }
 
+func TestHasActiveSwapOn(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{
+		Network: chain.Testnet,
+	})
+	defer coord.Close()
+
+	s, err := NewSwap(chain.Testnet, MethodHTLC, RoleInitiator, Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		Method: MethodHTLC,
+	})
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	coord.swaps["trade-1"] = &ActiveSwap{Swap: s}
+
+	if !coord.HasActiveSwapOn("BTC") || !coord.HasActiveSwapOn("LTC") {
+		t.Error("HasActiveSwapOn() = false for a chain of a running swap")
+	}
+	if coord.HasActiveSwapOn("DOGE") {
+		t.Error("HasActiveSwapOn(DOGE) = true")
+	}
+	s.State = StateRedeemed
+	if coord.HasActiveSwapOn("BTC") {
+		t.Error("HasActiveSwapOn() = true for a finished swap")
+	}
+}
+
 func TestGetLocalPubKeyNotFound(t *testing.T) {
 	coord := NewCoordinator(&CoordinatorConfig{
 		Network: chain.Testnet,
@@ -366,3 +415,85 @@ func TestSetBackend(t *testing.T) {
 		t.Error("backends map should be initialized after SetBackend")
 	}
 }
+
+func TestInitiateSwapDegraded(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{
+		Network: chain.Testnet,
+	})
+	defer coord.Close()
+
+	coord.SetDegraded(true)
+	if !coord.IsDegraded() {
+		t.Fatal("IsDegraded() = false after SetDegraded(true)")
+	}
+
+	offer := Offer{
+		OfferChain:    "BTC",
+		OfferAmount:   100000,
+		RequestChain:  "LTC",
+		RequestAmount: 5000000,
+		Method:        MethodMuSig2,
+	}
+
+	_, err := coord.InitiateSwap(context.Background(), "trade-1", "order-1", offer, MethodMuSig2)
+	if !errors.Is(err, ErrDegradedMode) {
+		t.Errorf("InitiateSwap while degraded: err = %v, want ErrDegradedMode", err)
+	}
+
+	_, err = coord.RespondToSwap(context.Background(), "trade-2", offer, make([]byte, 33), nil, MethodMuSig2)
+	if !errors.Is(err, ErrDegradedMode) {
+		t.Errorf("RespondToSwap while degraded: err = %v, want ErrDegradedMode", err)
+	}
+}
+
+func TestInitiateSwapClockSkewed(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{
+		Network: chain.Testnet,
+	})
+	defer coord.Close()
+
+	coord.SetClockSkew(time.Minute, true)
+	offer := Offer{
+		OfferChain:    "BTC",
+		OfferAmount:   100000,
+		RequestChain:  "LTC",
+		RequestAmount: 5000000,
+		Method:        MethodMuSig2,
+	}
+
+	_, err := coord.InitiateSwap(context.Background(), "trade-1", "order-1", offer, MethodMuSig2)
+	if !errors.Is(err, ErrClockSkewed) {
+		t.Errorf("InitiateSwap while skewed: err = %v, want ErrClockSkewed", err)
+	}
+	_, err = coord.RespondToSwap(context.Background(), "trade-2", offer, make([]byte, 33), nil, MethodMuSig2)
+	if !errors.Is(err, ErrClockSkewed) {
+		t.Errorf("RespondToSwap while skewed: err = %v, want ErrClockSkewed", err)
+	}
+
+	// Only adjusting deadlines doesn't pause trades
+	coord.SetClockSkew(time.Minute, false)
+	if coord.IsClockSkewed() {
+		t.Error("IsClockSkewed() = true without refusing trades")
+	}
+}
+
+func TestMuSig2MessagesForSwapWithoutSession(t *testing.T) {
+	coord := newFuzzCoordinator(t)
+
+	for _, id := range []string{"htlc", "bare", "recovered"} {
+		if err := coord.SetRemoteNonces(id, make([]byte, 66), make([]byte, 66)); !errors.Is(err, ErrNoMuSig2Session) {
+			t.Errorf("SetRemoteNonces(%s) = %v, want ErrNoMuSig2Session", id, err)
+		}
+		if err := coord.SetRemotePartialSigs(id, make([]byte, 32), make([]byte, 32)); !errors.Is(err, ErrNoMuSig2Session) {
+			t.Errorf("SetRemotePartialSigs(%s) = %v, want ErrNoMuSig2Session", id, err)
+		}
+		if _, _, err := coord.GenerateNonces(id); !errors.Is(err, ErrNoMuSig2Session) {
+			t.Errorf("GenerateNonces(%s) = %v, want ErrNoMuSig2Session", id, err)
+		}
+	}
+
+	pubKey, _, _, _, _ := fuzzSeeds(t)
+	if err := coord.SetRemotePubKey("bare", pubKey); !errors.Is(err, ErrNoMuSig2Session) {
+		t.Errorf("SetRemotePubKey(bare) = %v, want ErrNoMuSig2Session", err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"context"
 	"fmt"
 	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
 )
 
 // =========================================================================
@@ -141,12 +143,12 @@ func (c *Coordinator) buildAndBroadcastRefundUnlocked(ctx context.Context, trade
 		// Initiator refunding their offer chain (BTC in BTC->LTC swap)
 		fundingTxID = active.Swap.LocalFundingTxID
 		fundingVout = active.Swap.LocalFundingVout
-		fundingAmount = active.Swap.Offer.OfferAmount
+		fundingAmount = active.Swap.OfferFundingAmount()
 	} else if active.Swap.Role == RoleResponder && chainSymbol == active.Swap.Offer.RequestChain {
 		// Responder refunding their request chain (LTC in BTC->LTC swap)
 		fundingTxID = active.Swap.LocalFundingTxID
 		fundingVout = active.Swap.LocalFundingVout
-		fundingAmount = active.Swap.Offer.RequestAmount
+		fundingAmount = active.Swap.RequestFundingAmount()
 	} else {
 		return "", fmt.Errorf("cannot refund: you are %s but trying to refund %s (offer=%s, request=%s)",
 			active.Swap.Role, chainSymbol, active.Swap.Offer.OfferChain, active.Swap.Offer.RequestChain)
@@ -229,7 +231,7 @@ func (c *Coordinator) buildAndBroadcastRefundUnlocked(ctx context.Context, trade
 		return "", fmt.Errorf("failed to serialize refund transaction: %w", err)
 	}
 
-	txID, err := b.BroadcastTransaction(ctx, txHex)
+	txID, err := c.BroadcastOnce(ctx, b, JobActionRefund, tradeID, chainSymbol, txHex)
 	if err != nil {
 		return "", fmt.Errorf("failed to broadcast refund transaction: %w", err)
 	}
@@ -287,6 +289,7 @@ func (c *Coordinator) Stop() {
 
 // GetSwapTimeoutInfo returns timeout information for a swap.
 func (c *Coordinator) GetSwapTimeoutInfo(ctx context.Context, tradeID string) (map[string]interface{}, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
@@ -348,6 +351,7 @@ func (c *Coordinator) GetSwapTimeoutInfo(ctx context.Context, tradeID string) (m
 // This will fail on-chain if the CSV timelock hasn't passed.
 // Useful for testing or when user wants to try refunding manually.
 func (c *Coordinator) ForceRefund(ctx context.Context, tradeID string, chainSymbol string) (string, error) {
+	ctx = backend.WithTradeID(ctx, tradeID)
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
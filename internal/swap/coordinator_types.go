@@ -11,6 +11,7 @@ import (
 	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
 	"github.com/Klingon-tech/klingdex/internal/backend"
 	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/lightning"
 	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/internal/wallet"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
@@ -25,6 +26,11 @@ var (
 	ErrAlreadyFunded    = errors.New("already funded")
 	ErrNotReadyToSign   = errors.New("not ready to sign")
 	ErrNotReadyToRedeem = errors.New("not ready to redeem")
+	ErrDegradedMode     = errors.New("node is in degraded mode (network isolated), not accepting new trades")
+	ErrContractPaused   = errors.New("HTLC contract is paused, not accepting new swaps")
+	ErrClockSkewed      = errors.New("local clock is skewed, not accepting new trades")
+	ErrColdMode         = errors.New("node is in cold mode (claim and refund only), not starting or funding swaps")
+	ErrNoMuSig2Session  = errors.New("swap has no MuSig2 session")
 )
 
 // SwapEvent represents an event that occurred during a swap.
@@ -80,6 +86,7 @@ type ActiveSwap struct {
 	MuSig2     *MuSig2SwapData  // Populated for MuSig2 swaps
 	HTLC       *HTLCSwapData    // Populated for Bitcoin HTLC swaps
 	EVMHTLC    *EVMHTLCSwapData // Populated for EVM HTLC swaps
+	XMR        *XMRSwapData     // Populated for BTC/XMR adaptor swaps
 }
 
 // IsHTLC returns true if this swap uses HTLC method (Bitcoin-family).
@@ -102,6 +109,11 @@ func (a *ActiveSwap) IsCrossChain() bool {
 	return a.HTLC != nil && a.EVMHTLC != nil
 }
 
+// IsXMR returns true if this is a BTC/XMR adaptor swap.
+func (a *ActiveSwap) IsXMR() bool {
+	return a.Swap.Method == MethodAdaptor && a.XMR != nil
+}
+
 // Coordinator manages active swaps.
 type Coordinator struct {
 	mu sync.RWMutex
@@ -115,11 +127,97 @@ type Coordinator struct {
 	// Network
 	network chain.Network
 
+	// Attempts of a side effect before its job is abandoned, 0: unlimited
+	jobMaxAttempts int
+
 	// Active swaps (tradeID -> ActiveSwap)
 	swaps map[string]*ActiveSwap
 
-	// Event handlers
-	eventHandlers []EventHandler
+	// Per-trade locks and the swaps' published views
+	trades tradeRegistry
+
+	// Event subscribers
+	events *eventBus
+
+	// degraded pauses new trades while the node is network isolated;
+	// existing swaps, refunds and timeout monitoring continue
+	degraded bool
+
+	// clockOffset corrects the local clock in displayed deadlines;
+	// clockSkewed pauses new trades until the clock is fixed
+	clockOffset time.Duration
+	clockSkewed bool
+
+	// cold refuses new swaps and funding; claims and refunds go on
+	cold bool
+
+	// freshAddresses pays claims and refunds to unused wallet addresses
+	freshAddresses bool
+
+	// Auto-claim policy, per-trade overrides and decision state
+	autoClaim          AutoClaimPolicy
+	autoClaimOverrides map[string]AutoClaimRule
+	autoClaims         map[string]*autoClaimState
+	autoClaimWake      chan struct{}
+
+	// Zero-conf policy and per-swap monitoring state
+	zeroConf  ZeroConfPolicy
+	zeroConfs map[string]*zeroConfState
+
+	// Affordability pre-check of new swaps
+	affordability AffordabilityPolicy
+
+	// Funding variance policy and per-swap decisions
+	fundingVariance  FundingVariancePolicy
+	fundingVariances map[string]*fundingVarianceState
+
+	// Funding instructions of legs funded from an external wallet
+	externalFundings map[string]*ExternalFunding
+
+	// Lightning node settling BTC legs over Lightning (nil: disabled)
+	lightning    lightning.Client
+	lightningCfg lightning.Config
+
+	// monero-wallet-rpc of the XMR legs (nil: adaptor swaps refused) and
+	// the wallet file it reopens after sweeping a shared address
+	monero           MoneroWallet
+	moneroWalletFile string
+
+	// Confirmation policy of fund-moving steps and the steps held for it
+	confirmation ConfirmationPolicy
+	pendingSteps map[string]*pendingStep
+
+	// Contract pause policy, watcher per EVM chain and paused chains (Unix
+	// time the pause was seen)
+	contractPause  ContractPausePolicy
+	pauseWatchers  map[string]PauseWatcher
+	contractPaused map[string]int64
+
+	// Transaction replacers cancelling HTLC creates, by EVM chain; chains
+	// without one use their HTLC session's client
+	evmReplacers map[string]EVMTxReplacer
+
+	// Contract readers reconciliation uses, by EVM chain; chains without
+	// one use their HTLC session's client
+	evmReaders map[string]EVMSwapReader
+
+	// EVM sessions connected without c.mu for legs that had none, by
+	// trade and chain, awaiting their setup under c.mu
+	evmDialed map[string]*evmSessionDial
+
+	// Interval swap snapshots are taken at (0 = off) and the swaps loaded
+	// at startup that still wait for background reconciliation
+	snapshotInterval time.Duration
+	reconcileBacklog map[string]bool
+
+	// Latest key hygiene audit (nil before the first)
+	lastHygiene *KeyHygieneReport
+
+	// In-flight operations and their per-kind deadlines
+	opMu       sync.Mutex
+	opTimeouts OperationTimeouts
+	operations map[uint64]*operation
+	nextOpID   uint64
 
 	// Logger
 	log *logging.Logger
@@ -136,6 +234,11 @@ type CoordinatorConfig struct {
 	WalletService *wallet.Service // For transaction building/signing
 	Backends      map[string]backend.Backend
 	Network       chain.Network
+
+	// JobMaxAttempts is how many times an on-chain side effect is attempted
+	// before its job is abandoned (config.SwapConfig.JobMaxAttempts). Zero
+	// retries forever.
+	JobMaxAttempts int
 }
 
 // =============================================================================
@@ -171,6 +274,13 @@ type MuSig2StorageData struct {
 	RemoteOfferWalletAddr   string `json:"remote_offer_wallet_addr,omitempty"`
 	RemoteRequestWalletAddr string `json:"remote_request_wallet_addr,omitempty"`
 
+	// Escrow values recorded by the funding variance check
+	OfferFundedAmount   uint64 `json:"offer_funded_amount,omitempty"`
+	RequestFundedAmount uint64 `json:"request_funded_amount,omitempty"`
+
+	// Our leg is funded from an external wallet
+	ExternalFunding bool `json:"external_funding,omitempty"`
+
 	OfferChain   *ChainStorageData `json:"offer_chain"`
 	RequestChain *ChainStorageData `json:"request_chain"`
 }
@@ -186,6 +296,16 @@ type CoordinatorHTLCStorageData struct {
 	RemoteOfferWalletAddr   string `json:"remote_offer_wallet_addr,omitempty"`
 	RemoteRequestWalletAddr string `json:"remote_request_wallet_addr,omitempty"`
 
+	// Escrow values recorded by the funding variance check
+	OfferFundedAmount   uint64 `json:"offer_funded_amount,omitempty"`
+	RequestFundedAmount uint64 `json:"request_funded_amount,omitempty"`
+
+	// Our leg is funded from an external wallet
+	ExternalFunding bool `json:"external_funding,omitempty"`
+
+	// The BTC leg settles over Lightning
+	Lightning *LightningLeg `json:"lightning,omitempty"`
+
 	// HTLC-specific fields
 	Secret     string `json:"secret,omitempty"`      // Hex, only for initiator
 	SecretHash string `json:"secret_hash,omitempty"` // Hex
@@ -194,11 +314,29 @@ type CoordinatorHTLCStorageData struct {
 	RequestChain *HTLCChainStorageData `json:"request_chain,omitempty"`
 }
 
+// CoordinatorXMRStorageData is the storage format for BTC/XMR adaptor swaps.
+type CoordinatorXMRStorageData struct {
+	XMRLeg
+
+	LocalPrivKey  string       `json:"local_privkey,omitempty"`   // Hex, key on the BTC scripts
+	LocalSpendKey string       `json:"local_spend_key,omitempty"` // Hex, spend key share
+	LocalShare    *XMRKeyShare `json:"local_share,omitempty"`
+	RemoteShare   *XMRKeyShare `json:"remote_share,omitempty"`
+
+	// Wallet addresses for redemption
+	LocalOfferWalletAddr    string `json:"local_offer_wallet_addr,omitempty"`
+	LocalRequestWalletAddr  string `json:"local_request_wallet_addr,omitempty"`
+	RemoteOfferWalletAddr   string `json:"remote_offer_wallet_addr,omitempty"`
+	RemoteRequestWalletAddr string `json:"remote_request_wallet_addr,omitempty"`
+}
+
 // HTLCChainStorageData stores per-chain HTLC data.
 type HTLCChainStorageData struct {
 	Symbol      string `json:"symbol"`
 	HTLCAddress string `json:"htlc_address"`
 	SessionData string `json:"session_data,omitempty"` // JSON of HTLCSession
+	ClaimTxID   string `json:"claim_txid,omitempty"`   // May be shared by a batch settlement
+	RefundTxID  string `json:"refund_txid,omitempty"`  // May be shared by a batch settlement
 }
 
 // =============================================================================
@@ -219,6 +357,9 @@ type CoordinatorEVMHTLCStorageData struct {
 
 	OfferChain   *EVMHTLCChainStorageData `json:"offer_chain,omitempty"`
 	RequestChain *EVMHTLCChainStorageData `json:"request_chain,omitempty"`
+
+	// Cancellations of our HTLC creates, by chain symbol
+	CreateCancels map[string]*EVMCreateCancellation `json:"create_cancels,omitempty"`
 }
 
 // EVMHTLCChainStorageData stores per-chain EVM HTLC data.
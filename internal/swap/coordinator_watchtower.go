@@ -0,0 +1,142 @@
+// Package swap - Pre-signed HTLC spends for delegation to a watchtower.
+package swap
+
+import (
+	"context"
+	"fmt"
+)
+
+// Pre-signed spend kinds.
+const (
+	PresignedRefund = "refund"
+	PresignedClaim  = "claim"
+)
+
+// PresignedSpend is an HTLC claim or refund signed ahead of time, so a
+// watchtower can broadcast it while this node is offline.
+type PresignedSpend struct {
+	TradeID string
+	Chain   string
+	Kind    string
+	TxHex   string
+
+	// FundingTxID/FundingVout is the HTLC output the transaction spends.
+	FundingTxID string
+	FundingVout uint32
+
+	// TimeoutBlocks is the refund's relative timelock (refund only).
+	TimeoutBlocks uint32
+
+	// Claim only: our own HTLC output on the other chain. The counterparty's
+	// claim of it reveals the secret, which is filled into TxHex before broadcast.
+	WatchChain   string
+	WatchAddress string
+	WatchTxID    string
+	WatchVout    uint32
+	SecretHash   []byte
+}
+
+// PresignHTLCRefund signs our HTLC refund on a chain without broadcasting it.
+// The transaction only becomes valid once the CSV timelock has passed.
+func (c *Coordinator) PresignHTLCRefund(ctx context.Context, tradeID, chainSymbol string) (*PresignedSpend, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+
+	in, err := c.htlcRefundInputsUnlocked(active, chainSymbol)
+	if err != nil {
+		return nil, err
+	}
+	txHex, err := c.buildHTLCRefundTxUnlocked(ctx, chainSymbol, in)
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresignedSpend{
+		TradeID:       tradeID,
+		Chain:         chainSymbol,
+		Kind:          PresignedRefund,
+		TxHex:         txHex,
+		FundingTxID:   in.fundingTxID,
+		FundingVout:   in.fundingVout,
+		TimeoutBlocks: in.timeoutBlocks,
+	}, nil
+}
+
+// PresignHTLCClaim signs our HTLC claim on a chain with a placeholder secret.
+// It is meant for the party that does not know the secret yet: the real secret
+// is revealed when the counterparty claims our HTLC on the other chain.
+func (c *Coordinator) PresignHTLCClaim(ctx context.Context, tradeID, chainSymbol string) (*PresignedSpend, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+
+	in, err := c.htlcClaimInputsUnlocked(active, chainSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	// The counterparty claims our HTLC on the other chain
+	watchChain := active.Swap.Offer.OfferChain
+	watchData := active.HTLC.OfferChain
+	if chainSymbol == active.Swap.Offer.OfferChain {
+		watchChain = active.Swap.Offer.RequestChain
+		watchData = active.HTLC.RequestChain
+	}
+	if watchData == nil || watchData.HTLCAddress == "" {
+		return nil, fmt.Errorf("HTLC address not set on %s", watchChain)
+	}
+	if active.Swap.LocalFundingTxID == "" {
+		return nil, fmt.Errorf("no local funding transaction recorded")
+	}
+
+	secretHash := in.session.GetSecretHash()
+	if len(secretHash) != 32 {
+		return nil, fmt.Errorf("secret hash not available")
+	}
+
+	txHex, err := c.buildHTLCClaimTxUnlocked(ctx, active, chainSymbol, in, make([]byte, 32))
+	if err != nil {
+		return nil, err
+	}
+
+	return &PresignedSpend{
+		TradeID:      tradeID,
+		Chain:        chainSymbol,
+		Kind:         PresignedClaim,
+		TxHex:        txHex,
+		FundingTxID:  in.fundingTxID,
+		FundingVout:  in.fundingVout,
+		WatchChain:   watchChain,
+		WatchAddress: watchData.HTLCAddress,
+		WatchTxID:    active.Swap.LocalFundingTxID,
+		WatchVout:    active.Swap.LocalFundingVout,
+		SecretHash:   secretHash,
+	}, nil
+}
+
+// FillClaimSecret inserts a revealed secret into a pre-signed HTLC claim.
+// The claim witness is [signature, secret, 0x01, script]; the signature does
+// not commit to the witness, so the placeholder can be replaced.
+func FillClaimSecret(txHex string, secret []byte) (string, error) {
+	if len(secret) != 32 {
+		return "", fmt.Errorf("secret must be 32 bytes, got %d", len(secret))
+	}
+	tx, err := DeserializeTx(txHex)
+	if err != nil {
+		return "", err
+	}
+	if len(tx.TxIn) != 1 || len(tx.TxIn[0].Witness) != 4 || len(tx.TxIn[0].Witness[1]) != 32 {
+		return "", fmt.Errorf("not an HTLC claim transaction")
+	}
+	tx.TxIn[0].Witness[1] = secret
+	return SerializeTx(tx)
+}
@@ -0,0 +1,47 @@
+package swap
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestFillClaimSecret(t *testing.T) {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{7}, 0), nil,
+		BuildHTLCClaimWitness([]byte{0x30, 0x01}, make([]byte, 32), []byte{0x63})))
+	tx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+	txHex, err := SerializeTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret := bytes.Repeat([]byte{0xab}, 32)
+	filled, err := FillClaimSecret(txHex, secret)
+	if err != nil {
+		t.Fatalf("FillClaimSecret() error = %v", err)
+	}
+	got, err := DeserializeTx(filled)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got.TxIn[0].Witness[1], secret) {
+		t.Error("secret not placed in witness")
+	}
+	if !bytes.Equal(got.TxIn[0].Witness[0], []byte{0x30, 0x01}) {
+		t.Error("signature must be left unchanged")
+	}
+
+	if _, err := FillClaimSecret(txHex, []byte{1}); err == nil {
+		t.Error("expected error for short secret")
+	}
+
+	refund := wire.NewMsgTx(2)
+	refund.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{7}, 0), nil, BuildHTLCRefundWitness([]byte{0x30}, []byte{0x63})))
+	refundHex, _ := SerializeTx(refund)
+	if _, err := FillClaimSecret(refundHex, secret); err == nil {
+		t.Error("expected error for non-claim transaction")
+	}
+}
@@ -0,0 +1,1284 @@
+// Package swap - BTC/XMR adaptor swaps in the coordinator.
+//
+// Adaptor swaps are driven by a goroutine per swap (driveXMRSwap) that
+// looks up both chains, records what it saw in the swap's XMRObservation
+// and carries out NextXMRAction. The messages between the parties go
+// through the P2P layer, which relays the events below and hands the
+// counterparty's messages to the Set* methods:
+//
+//  1. Both parties send their key share (SetXMRKeyShare), which gives the
+//     BTC lock and cancel scripts and the shared XMR address.
+//  2. The BTC party builds and signs the lock transaction without
+//     broadcasting it, and signs the cancel (EventXMRLockPrepared,
+//     SetXMRLockInfo).
+//  3. The XMR party signs the cancel and pre-signs the refund under the
+//     BTC party's adaptor point (EventXMRRefundSigned, SetXMRRefundSigs).
+//     Only then does the BTC party broadcast the lock.
+//  4. The XMR party locks XMR once the BTC lock confirmed (EventXMRLocked,
+//     SetXMRLocked), and the BTC party hands over the redeem
+//     pre-signature once the XMR confirmed (EventXMRRedeemPresig,
+//     SetXMRRedeemPresig).
+//
+// The monero-wallet-rpc has one open wallet. Sweeping a shared address
+// opens it in place of the main wallet, so every wallet call that depends
+// on the open wallet is made under c.mu.
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Adaptor swap events. The P2P layer relays the first four to the
+// counterparty.
+const (
+	EventXMRLockPrepared = "xmr_lock_prepared" // BTC party: lock built, cancel signed
+	EventXMRRefundSigned = "xmr_refund_signed" // XMR party: cancel signed, refund pre-signed
+	EventXMRLocked       = "xmr_locked"        // XMR party: XMR sent to the shared address
+	EventXMRRedeemPresig = "xmr_redeem_presig" // BTC party: redeem pre-signed
+	EventXMRAction       = "xmr_action"        // A transaction of the swap went out
+)
+
+// ErrNoMoneroWallet is returned for adaptor swaps on a node without a
+// monero-wallet-rpc.
+var ErrNoMoneroWallet = errors.New("monero wallet not configured")
+
+// ErrAdaptorSwap is returned when the node is asked to fund an adaptor
+// swap leg, which the coordinator locks itself.
+var ErrAdaptorSwap = errors.New("adaptor swap legs are locked by the coordinator")
+
+// xmrPollInterval is how often the driver of an adaptor swap looks up the
+// chains.
+const xmrPollInterval = 15 * time.Second
+
+// xmrResendInterval is how often the BTC party resends its lock info while
+// it waits for the refund pre-signature.
+const xmrResendInterval = time.Minute
+
+// xmrSpendVSize is the estimated vsize of the spends of the lock and cancel
+// outputs, with room for a DAO output.
+const xmrSpendVSize = 200
+
+// MoneroWallet is the monero-wallet-rpc holding the XMR legs, implemented by
+// *backend.MoneroWalletRPC.
+type MoneroWallet interface {
+	GetHeight(ctx context.Context) (int64, error)
+	GetAddress(ctx context.Context) (string, error)
+	Transfer(ctx context.Context, address string, amount uint64) (*backend.MoneroTransfer, error)
+	CheckTxKey(ctx context.Context, txID, txKey, address string) (*backend.MoneroTxProof, error)
+	GenerateFromKeys(ctx context.Context, filename, address, spendKey, viewKey string, restoreHeight int64) error
+	OpenWallet(ctx context.Context, filename string) error
+	Refresh(ctx context.Context) error
+	SweepAll(ctx context.Context, address string) ([]string, error)
+}
+
+// XMRLockInfo is the BTC lock output, whose spends the parties pre-sign.
+type XMRLockInfo struct {
+	TxID     string `json:"txid"`
+	Vout     uint32 `json:"vout"`
+	Amount   uint64 `json:"amount"`
+	SpendFee uint64 `json:"spend_fee"` // Fee of each spend of the lock and cancel outputs
+}
+
+// XMRMoneroLock is the XMR party's transfer to the shared address.
+type XMRMoneroLock struct {
+	TxID          string `json:"txid"`
+	TxKey         string `json:"tx_key"`
+	RestoreHeight int64  `json:"restore_height"` // Wallet height before the transfer
+}
+
+// XMRLeg is the persisted progress of an adaptor swap.
+type XMRLeg struct {
+	Role     XMRRole `json:"xmr_role"`
+	BTCChain string  `json:"btc_chain"`
+
+	Lock      *XMRLockInfo `json:"lock,omitempty"`
+	LockTxHex string       `json:"lock_tx_hex,omitempty"` // BTC party: signed lock not broadcast yet
+
+	// Hex signatures: both cancel signatures, the XMR party's refund
+	// pre-signature under the BTC party's adaptor point and the BTC
+	// party's redeem pre-signature under the XMR party's
+	BTCCancelSig string `json:"btc_cancel_sig,omitempty"`
+	XMRCancelSig string `json:"xmr_cancel_sig,omitempty"`
+	RefundPresig string `json:"refund_presig,omitempty"`
+	RedeemPresig string `json:"redeem_presig,omitempty"`
+
+	// XMR party: the transfer was started; a crash before it was recorded
+	// leaves it unknown, and it is never sent twice
+	XMRLockStarted bool           `json:"xmr_lock_started,omitempty"`
+	XMRLock        *XMRMoneroLock `json:"xmr_lock,omitempty"`
+
+	CancelTxID   string   `json:"cancel_txid,omitempty"`
+	SpendTxID    string   `json:"spend_txid,omitempty"`    // Redeem, refund or punish
+	SpendWitness []string `json:"spend_witness,omitempty"` // Hex; a redeem or refund reveals a key share
+	SweepTxIDs   []string `json:"sweep_txids,omitempty"`
+
+	Observation XMRObservation `json:"observation"`
+}
+
+// XMRSwapData holds the runtime data of an adaptor swap.
+type XMRSwapData struct {
+	XMRLeg
+	Secrets *XMRSecrets
+	Remote  *XMRKeyShare // nil until the counterparty's share arrived
+	Scripts *XMRScripts  // Built from both shares
+
+	driving bool
+	wake    chan struct{}
+	sentAt  time.Time // Last EventXMRLockPrepared
+}
+
+// shares returns the BTC party's and the XMR party's key shares.
+func (x *XMRSwapData) shares() (btcShare, xmrShare *XMRKeyShare) {
+	if x.Role == XMRRoleBTC {
+		return x.Secrets.Share(), x.Remote
+	}
+	return x.Remote, x.Secrets.Share()
+}
+
+// SetMonero connects the monero-wallet-rpc holding the XMR legs. walletFile
+// is the wallet it reopens after sweeping a shared address.
+func (c *Coordinator) SetMonero(w MoneroWallet, walletFile string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.monero = w
+	c.moneroWalletFile = walletFile
+}
+
+// xmrRole returns a party's side of an adaptor swap and the swap's
+// BTC-family chain.
+func xmrRole(offer Offer, role Role, network chain.Network) (XMRRole, string) {
+	btcChain := offer.OfferChain
+	if IsMoneroChain(btcChain, network) {
+		btcChain = offer.RequestChain
+	}
+	if FundingChain(offer, role) == btcChain {
+		return XMRRoleBTC, btcChain
+	}
+	return XMRRoleXMR, btcChain
+}
+
+// xmrAmount returns the piconero locked on the XMR leg.
+func xmrAmount(offer Offer, network chain.Network) uint64 {
+	if IsMoneroChain(offer.OfferChain, network) {
+		return offer.OfferAmount
+	}
+	return offer.RequestAmount
+}
+
+// xmrTimeouts derives the timeouts of an adaptor swap from the BTC chain's
+// timeout configuration and the XMR confirmation target.
+func xmrTimeouts(btcChain string, network chain.Network) (XMRTimeouts, error) {
+	cfg, ok := config.GetChainTimeout(btcChain, network == chain.Testnet)
+	if !ok {
+		return XMRTimeouts{}, fmt.Errorf("no timeout configuration for %s", btcChain)
+	}
+	btcConfs := cfg.MinConfirmations
+	if btcConfs == 0 {
+		btcConfs = 1
+	}
+	xmrConfs := uint32(10) // Outputs unlock after 10 blocks
+	if params, ok := config.NewExchangeConfig(config.NetworkType(network)).GetChainParams("XMR"); ok && params.Confirmations > 0 {
+		xmrConfs = params.Confirmations
+	}
+	t := XMRTimeouts{
+		BTCConfirmations: btcConfs,
+		XMRConfirmations: xmrConfs,
+		CancelTimeout:    cfg.TakerBlocks,
+		PunishTimeout:    cfg.TakerBlocks,
+		SafetyMargin:     cfg.SafetyMarginBlocks,
+	}
+	return t, t.Validate()
+}
+
+// newXMRSwap creates the adaptor swap data of a new swap around its
+// ephemeral key.
+func (c *Coordinator) newXMRSwap(swap *Swap, offer Offer, privKey *btcec.PrivateKey) (*ActiveSwap, error) {
+	if c.monero == nil {
+		return nil, ErrNoMoneroWallet
+	}
+	role, btcChain := xmrRole(offer, swap.Role, c.network)
+	if _, err := xmrTimeouts(btcChain, c.network); err != nil {
+		return nil, err
+	}
+	secrets, err := newXMRSecrets(privKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate XMR key share: %w", err)
+	}
+	return &ActiveSwap{
+		Swap: swap,
+		XMR:  &XMRSwapData{XMRLeg: XMRLeg{Role: role, BTCChain: btcChain}, Secrets: secrets},
+	}, nil
+}
+
+// xmrSwapUnlocked returns an adaptor swap whose key shares were exchanged.
+// Caller must hold c.mu.
+func (c *Coordinator) xmrSwapUnlocked(tradeID string, role XMRRole) (*ActiveSwap, error) {
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	if !active.IsXMR() {
+		return nil, fmt.Errorf("swap %s is not an adaptor swap", tradeID)
+	}
+	if active.XMR.Role != role {
+		return nil, fmt.Errorf("swap %s: message is for the %s party", tradeID, role)
+	}
+	if active.XMR.Scripts == nil {
+		return nil, fmt.Errorf("swap %s: key shares not exchanged", tradeID)
+	}
+	return active, nil
+}
+
+// LocalXMRKeyShare returns our key share of an adaptor swap and our
+// address on its BTC chain, to send to the counterparty.
+func (c *Coordinator) LocalXMRKeyShare(tradeID string) (*XMRKeyShare, string, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, "", ErrSwapNotFound
+	}
+	if !active.IsXMR() {
+		return nil, "", fmt.Errorf("swap %s is not an adaptor swap", tradeID)
+	}
+	local, _ := xmrBTCWalletAddrs(active)
+	return active.XMR.Secrets.Share(), local, nil
+}
+
+// xmrBTCWalletAddrs returns our and the counterparty's wallet address on
+// the BTC chain.
+func xmrBTCWalletAddrs(active *ActiveSwap) (local, remote string) {
+	s := active.Swap
+	if active.XMR.BTCChain == s.Offer.OfferChain {
+		return s.LocalOfferWalletAddr, s.RemoteOfferWalletAddr
+	}
+	return s.LocalRequestWalletAddr, s.RemoteRequestWalletAddr
+}
+
+// SetXMRKeyShare records the counterparty's key share and its address on
+// the BTC chain, and starts driving the swap.
+func (c *Coordinator) SetXMRKeyShare(tradeID string, share *XMRKeyShare, btcAddr string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return ErrSwapNotFound
+	}
+	if !active.IsXMR() {
+		return fmt.Errorf("swap %s is not an adaptor swap", tradeID)
+	}
+	x := active.XMR
+	if err := share.Verify(); err != nil {
+		return err
+	}
+	if x.Remote != nil {
+		if x.Remote.BTCKey.IsEqual(share.BTCKey) && x.Remote.SpendKey.Equal(share.SpendKey) == 1 {
+			return nil // Resent
+		}
+		return errors.New("counterparty key share already set")
+	}
+	remotePub := share.BTCKey.SerializeCompressed()
+	if len(active.Swap.RemotePubKey) > 0 && hex.EncodeToString(active.Swap.RemotePubKey) != hex.EncodeToString(remotePub) {
+		return errors.New("key share doesn't match the counterparty's public key")
+	}
+	addr, err := ValidatePayoutAddress(x.BTCChain, c.network, btcAddr)
+	if err != nil {
+		return fmt.Errorf("counterparty address: %w", err)
+	}
+
+	x.Remote = share
+	btcShare, xmrShare := x.shares()
+	t, err := xmrTimeouts(x.BTCChain, c.network)
+	if err == nil {
+		x.Scripts, err = BuildXMRScripts(btcShare.BTCKey, xmrShare.BTCKey, t.CancelTimeout, t.PunishTimeout)
+	}
+	if err != nil {
+		x.Remote = nil
+		return fmt.Errorf("failed to build lock scripts: %w", err)
+	}
+	_ = active.Swap.SetRemotePubKey(share.BTCKey)
+	if x.BTCChain == active.Swap.Offer.OfferChain {
+		active.Swap.RemoteOfferWalletAddr = addr
+	} else {
+		active.Swap.RemoteRequestWalletAddr = addr
+	}
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	c.startXMRDriverUnlocked(tradeID, active)
+	return nil
+}
+
+// SetXMRLockInfo records the BTC party's lock output and cancel signature.
+// The XMR party signs the cancel and pre-signs the refund in return.
+func (c *Coordinator) SetXMRLockInfo(tradeID string, lock XMRLockInfo, cancelSig string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, err := c.xmrSwapUnlocked(tradeID, XMRRoleXMR)
+	if err != nil {
+		return err
+	}
+	x := active.XMR
+	if x.Lock != nil {
+		if *x.Lock == lock && x.BTCCancelSig == cancelSig && x.RefundPresig != "" {
+			// Our reply was lost
+			c.emitXMRRefundSignedUnlocked(tradeID, x)
+			return nil
+		}
+		return errors.New("lock output already set")
+	}
+
+	// The BTC party is the maker when we are the taker
+	if want := escrowAmountFor(active, active.Swap.Role == RoleResponder); lock.Amount != want {
+		return fmt.Errorf("lock amount %d, want %d", lock.Amount, want)
+	}
+	if lock.SpendFee == 0 || lock.SpendFee > lock.Amount/10 {
+		return fmt.Errorf("spend fee %d out of range for a lock of %d", lock.SpendFee, lock.Amount)
+	}
+	if _, err := chainhash.NewHashFromStr(lock.TxID); err != nil {
+		return fmt.Errorf("invalid lock txid: %w", err)
+	}
+
+	x.Lock = &lock
+	if err := c.verifyXMRSigUnlocked(active, XMRPathCancel, x.Scripts.BTCKey, cancelSig); err != nil {
+		x.Lock = nil
+		return err
+	}
+	own, err := c.signXMRSpendUnlocked(active, XMRPathCancel)
+	if err != nil {
+		x.Lock = nil
+		return err
+	}
+	btcShare, _ := x.shares()
+	presig, err := c.presignXMRSpendUnlocked(active, XMRPathRefund, btcShare.Adaptor)
+	if err != nil {
+		x.Lock = nil
+		return err
+	}
+	x.BTCCancelSig = cancelSig
+	x.XMRCancelSig = hex.EncodeToString(own.Serialize())
+	x.RefundPresig = hex.EncodeToString(presig.Serialize())
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	c.emitXMRRefundSignedUnlocked(tradeID, x)
+	return nil
+}
+
+func (c *Coordinator) emitXMRRefundSignedUnlocked(tradeID string, x *XMRSwapData) {
+	c.emitEvent(tradeID, EventXMRRefundSigned, map[string]interface{}{
+		"cancel_sig":    x.XMRCancelSig,
+		"refund_presig": x.RefundPresig,
+	})
+}
+
+// SetXMRRefundSigs records the XMR party's cancel signature and refund
+// pre-signature, after which the BTC party broadcasts the lock.
+func (c *Coordinator) SetXMRRefundSigs(tradeID, cancelSig, refundPresig string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, err := c.xmrSwapUnlocked(tradeID, XMRRoleBTC)
+	if err != nil {
+		return err
+	}
+	x := active.XMR
+	if x.Lock == nil {
+		return errors.New("lock not prepared")
+	}
+	if x.RefundPresig != "" {
+		if x.RefundPresig == refundPresig && x.XMRCancelSig == cancelSig {
+			return nil // Resent
+		}
+		return errors.New("refund pre-signature already set")
+	}
+	if err := c.verifyXMRSigUnlocked(active, XMRPathCancel, x.Scripts.XMRKey, cancelSig); err != nil {
+		return err
+	}
+	presig, err := parseAdaptorSigHex(refundPresig)
+	if err != nil {
+		return err
+	}
+	sigHash, err := c.xmrSigHashUnlocked(active, XMRPathRefund)
+	if err != nil {
+		return err
+	}
+	if err := presig.Verify(x.Scripts.XMRKey, sigHash, x.Secrets.Share().Adaptor); err != nil {
+		return fmt.Errorf("invalid refund pre-signature: %w", err)
+	}
+	x.XMRCancelSig = cancelSig
+	x.RefundPresig = refundPresig
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	wakeXMRDriver(x)
+	return nil
+}
+
+// SetXMRLocked records the XMR party's transfer to the shared address. The
+// driver checks it with the tx key before trusting it.
+func (c *Coordinator) SetXMRLocked(tradeID, txID, txKey string, restoreHeight int64) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, err := c.xmrSwapUnlocked(tradeID, XMRRoleBTC)
+	if err != nil {
+		return err
+	}
+	x := active.XMR
+	if txID == "" || txKey == "" {
+		return errors.New("txid and tx key required")
+	}
+	if x.XMRLock != nil {
+		if x.XMRLock.TxID == txID && x.XMRLock.TxKey == txKey {
+			return nil // Resent
+		}
+		return errors.New("XMR lock already set")
+	}
+	x.XMRLock = &XMRMoneroLock{TxID: txID, TxKey: txKey, RestoreHeight: restoreHeight}
+	active.Swap.RemoteFundingTxID = txID
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	wakeXMRDriver(x)
+	return nil
+}
+
+// SetXMRRedeemPresig records the BTC party's redeem pre-signature, which
+// the XMR party completes to redeem the BTC.
+func (c *Coordinator) SetXMRRedeemPresig(tradeID, presigHex string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	active, err := c.xmrSwapUnlocked(tradeID, XMRRoleXMR)
+	if err != nil {
+		return err
+	}
+	x := active.XMR
+	if x.Lock == nil {
+		return errors.New("lock output not known")
+	}
+	if x.RedeemPresig != "" {
+		if x.RedeemPresig == presigHex {
+			return nil // Resent
+		}
+		return errors.New("redeem pre-signature already set")
+	}
+	presig, err := parseAdaptorSigHex(presigHex)
+	if err != nil {
+		return err
+	}
+	sigHash, err := c.xmrSigHashUnlocked(active, XMRPathRedeem)
+	if err != nil {
+		return err
+	}
+	if err := presig.Verify(x.Scripts.BTCKey, sigHash, x.Secrets.Share().Adaptor); err != nil {
+		return fmt.Errorf("invalid redeem pre-signature: %w", err)
+	}
+	x.RedeemPresig = presigHex
+	x.Observation.PresigExchanged = true
+
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	wakeXMRDriver(x)
+	return nil
+}
+
+// =============================================================================
+// Transactions
+// =============================================================================
+
+// xmrSpendTxUnlocked builds the unsigned spend of path and returns it with
+// the value of the output it spends. The redeem pays the XMR party's DAO
+// fee out of the BTC it receives. Caller must hold c.mu.
+func (c *Coordinator) xmrSpendTxUnlocked(active *ActiveSwap, path XMRPath) (*wire.MsgTx, uint64, error) {
+	x := active.XMR
+	if x.Lock == nil || x.Scripts == nil {
+		return nil, 0, errors.New("lock output not known")
+	}
+	params, ok := chain.Get(x.BTCChain, c.network)
+	if !ok {
+		return nil, 0, fmt.Errorf("unknown chain: %s", x.BTCChain)
+	}
+	hash, err := chainhash.NewHashFromStr(x.Lock.TxID)
+	if err != nil {
+		return nil, 0, err
+	}
+	prevOut := wire.OutPoint{Hash: *hash, Index: x.Lock.Vout}
+	amount := x.Lock.Amount
+
+	local, remote := xmrBTCWalletAddrs(active)
+	btcAddr, xmrAddr := local, remote
+	if x.Role == XMRRoleXMR {
+		btcAddr, xmrAddr = remote, local
+	}
+	dest := xmrAddr
+	if path == XMRPathRefund || path == XMRPathPunish {
+		cancel, _, err := c.xmrSpendTxUnlocked(active, XMRPathCancel)
+		if err != nil {
+			return nil, 0, err
+		}
+		prevOut = wire.OutPoint{Hash: cancel.TxHash(), Index: 0}
+		amount -= x.Lock.SpendFee
+		if path == XMRPathRefund {
+			dest = btcAddr
+		}
+	}
+
+	var destScript []byte
+	if path != XMRPathCancel {
+		if dest == "" {
+			return nil, 0, fmt.Errorf("no %s destination address", path)
+		}
+		if destScript, err = wallet.ParseAddressToScript(dest, params); err != nil {
+			return nil, 0, err
+		}
+	}
+	tx, err := x.Scripts.SpendTx(path, prevOut, amount, x.Lock.SpendFee, destScript)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if path == XMRPathRedeem {
+		xmrIsMaker := (x.Role == XMRRoleXMR) == (active.Swap.Role == RoleInitiator)
+		fee := active.Swap.Offer.DAOFeeOnChain(x.BTCChain, xmrIsMaker)
+		daoAddr := config.NewExchangeConfig(config.NetworkType(c.network)).GetDAOAddress(x.BTCChain)
+		// Both parties build the redeem, so the dust threshold is taken at
+		// the rate of the agreed spend fee
+		dust := newDustCheck(x.BTCChain, x.Lock.SpendFee/xmrSpendVSize, DustPolicy{})
+		if fee >= uint64(tx.TxOut[0].Value) {
+			return nil, 0, fmt.Errorf("redeem output doesn't cover the DAO fee %d", fee)
+		}
+		if keep, _ := dust.keep(DustOutputDAOFee, fee); keep && daoAddr != "" {
+			daoScript, err := wallet.ParseAddressToScript(daoAddr, params)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid DAO address: %w", err)
+			}
+			tx.AddTxOut(wire.NewTxOut(int64(fee), daoScript))
+		}
+		if daoAddr != "" {
+			tx.TxOut[0].Value -= int64(fee)
+		}
+	}
+	return tx, amount, nil
+}
+
+// xmrSigHashUnlocked returns the sighash the signers of path sign. Caller
+// must hold c.mu.
+func (c *Coordinator) xmrSigHashUnlocked(active *ActiveSwap, path XMRPath) ([]byte, error) {
+	tx, amount, err := c.xmrSpendTxUnlocked(active, path)
+	if err != nil {
+		return nil, err
+	}
+	return active.XMR.Scripts.SigHash(path, tx, amount)
+}
+
+// signXMRSpendUnlocked signs path with our key. Caller must hold c.mu.
+func (c *Coordinator) signXMRSpendUnlocked(active *ActiveSwap, path XMRPath) (*schnorr.Signature, error) {
+	sigHash, err := c.xmrSigHashUnlocked(active, path)
+	if err != nil {
+		return nil, err
+	}
+	return schnorr.Sign(active.XMR.Secrets.BTCKey, sigHash)
+}
+
+// presignXMRSpendUnlocked pre-signs path with our key under adaptor.
+// Caller must hold c.mu.
+func (c *Coordinator) presignXMRSpendUnlocked(active *ActiveSwap, path XMRPath, adaptor *btcec.PublicKey) (*AdaptorSignature, error) {
+	sigHash, err := c.xmrSigHashUnlocked(active, path)
+	if err != nil {
+		return nil, err
+	}
+	return AdaptorSign(active.XMR.Secrets.BTCKey, sigHash, adaptor)
+}
+
+// verifyXMRSigUnlocked checks a counterparty's hex signature of path.
+// Caller must hold c.mu.
+func (c *Coordinator) verifyXMRSigUnlocked(active *ActiveSwap, path XMRPath, pub *btcec.PublicKey, sigHex string) error {
+	sig, err := parseSchnorrSigHex(sigHex)
+	if err != nil {
+		return err
+	}
+	sigHash, err := c.xmrSigHashUnlocked(active, path)
+	if err != nil {
+		return err
+	}
+	if !sig.Verify(sigHash, pub) {
+		return fmt.Errorf("invalid %s signature", path)
+	}
+	return nil
+}
+
+func parseSchnorrSigHex(s string) (*schnorr.Signature, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature hex: %w", err)
+	}
+	return schnorr.ParseSignature(b)
+}
+
+func parseAdaptorSigHex(s string) (*AdaptorSignature, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid pre-signature hex: %w", err)
+	}
+	return ParseAdaptorSignature(b)
+}
+
+// xmrJobActions are the job actions of the BTC spends.
+var xmrJobActions = map[XMRPath]string{
+	XMRPathRedeem: JobActionClaim,
+	XMRPathCancel: JobActionCancel,
+	XMRPathRefund: JobActionRefund,
+	XMRPathPunish: JobActionPunish,
+}
+
+// spendXMRLockUnlocked signs and broadcasts a spend of the lock or cancel
+// output. Caller must hold c.mu.
+func (c *Coordinator) spendXMRLockUnlocked(ctx context.Context, tradeID string, active *ActiveSwap, path XMRPath) error {
+	x := active.XMR
+	tx, _, err := c.xmrSpendTxUnlocked(active, path)
+	if err != nil {
+		return err
+	}
+	own, err := c.signXMRSpendUnlocked(active, path)
+	if err != nil {
+		return err
+	}
+
+	// Our signature and the counterparty's, completed where it was a
+	// pre-signature
+	var other *schnorr.Signature
+	switch path {
+	case XMRPathCancel:
+		remote := x.XMRCancelSig
+		if x.Role == XMRRoleXMR {
+			remote = x.BTCCancelSig
+		}
+		other, err = parseSchnorrSigHex(remote)
+	case XMRPathRedeem, XMRPathRefund:
+		presigHex := x.RedeemPresig
+		if path == XMRPathRefund {
+			presigHex = x.RefundPresig
+		}
+		var presig *AdaptorSignature
+		if presig, err = parseAdaptorSigHex(presigHex); err == nil {
+			other, err = presig.Complete(x.Secrets.AdaptorSecret())
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("%s signature: %w", path, err)
+	}
+	btcSig, xmrSig := own, other
+	if x.Role == XMRRoleXMR {
+		btcSig, xmrSig = other, own
+	}
+	witness, err := x.Scripts.Witness(path, btcSig, xmrSig)
+	if err != nil {
+		return err
+	}
+	tx.TxIn[0].Witness = witness
+
+	txHex, err := SerializeTx(tx)
+	if err != nil {
+		return err
+	}
+	b, ok := c.backends[x.BTCChain]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoBackend, x.BTCChain)
+	}
+	sent, err := c.broadcastJob(ctx, b, xmrJobActions[path], tradeID, x.BTCChain, txHex)
+	if err != nil {
+		return fmt.Errorf("failed to broadcast %s: %w", path, err)
+	}
+
+	o := &x.Observation
+	switch path {
+	case XMRPathCancel:
+		x.CancelTxID = sent.TxID
+		o.CancelSeen = true
+	default:
+		x.SpendTxID = sent.TxID
+		x.SpendWitness = x.SpendWitness[:0]
+		for _, item := range witness {
+			x.SpendWitness = append(x.SpendWitness, hex.EncodeToString(item))
+		}
+		o.RedeemSeen = o.RedeemSeen || path == XMRPathRedeem
+		o.RefundSeen = o.RefundSeen || path == XMRPathRefund
+		o.PunishSeen = o.PunishSeen || path == XMRPathPunish
+	}
+	c.log.Info("Adaptor swap transaction broadcast", "trade_id", tradeID, "path", path.String(), "txid", sent.TxID)
+	c.emitEvent(tradeID, EventXMRAction, map[string]interface{}{
+		"action": path.String(),
+		"chain":  x.BTCChain,
+		"txid":   sent.TxID,
+	})
+	return nil
+}
+
+// =============================================================================
+// Driver
+// =============================================================================
+
+// startXMRDriverUnlocked starts the driver of a swap whose key shares were
+// exchanged, unless it runs. Caller must hold c.mu.
+func (c *Coordinator) startXMRDriverUnlocked(tradeID string, active *ActiveSwap) {
+	x := active.XMR
+	if x.driving || x.Scripts == nil || active.Swap.IsTerminal() {
+		return
+	}
+	x.driving = true
+	x.wake = make(chan struct{}, 1)
+	go c.driveXMRSwap(tradeID, x.wake)
+}
+
+// wakeXMRDriver makes the driver step now rather than on its next tick.
+func wakeXMRDriver(x *XMRSwapData) {
+	select {
+	case x.wake <- struct{}{}:
+	default:
+	}
+}
+
+// driveXMRSwap steps a swap until it is over or the coordinator stops.
+func (c *Coordinator) driveXMRSwap(tradeID string, wake <-chan struct{}) {
+	ticker := time.NewTicker(xmrPollInterval)
+	defer ticker.Stop()
+
+	for !c.stepXMRSwap(c.ctx, tradeID) {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// xmrWatch is what the driver looks up for a swap, copied under c.mu.
+type xmrWatch struct {
+	role         XMRRole
+	lock         XMRLockInfo
+	lockScript   string // Hex
+	lockAddr     string
+	cancelAddr   string
+	cancelTxID   string
+	xmrLock      *XMRMoneroLock
+	sharedAddr   string
+	xmrAmount    uint64
+	lookupSpends bool
+}
+
+// xmrSeen is what the driver found.
+type xmrSeen struct {
+	lockFound   bool
+	lockConfs   uint32
+	cancelFound bool
+	cancelConfs uint32
+	spendPath   XMRPath
+	spendTxID   string
+	spendWit    []string
+	xmrChecked  bool
+	xmrConfs    uint32
+}
+
+// stepXMRSwap looks up a swap's chains and carries out its next action.
+// It reports whether the swap is over.
+func (c *Coordinator) stepXMRSwap(ctx context.Context, tradeID string) bool {
+	c.mu.RLock()
+	active, ok := c.swaps[tradeID]
+	if !ok || !active.IsXMR() || active.Swap.IsTerminal() {
+		c.mu.RUnlock()
+		return true
+	}
+	w, err := c.xmrWatchUnlocked(active)
+	b := c.backends[active.XMR.BTCChain]
+	monero := c.monero
+	c.mu.RUnlock()
+	if err != nil {
+		c.log.Warn("Adaptor swap lookup failed", "trade_id", tradeID, "error", err)
+		return false
+	}
+
+	seen := c.observeXMRSwap(ctx, tradeID, b, monero, w)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	active, ok = c.swaps[tradeID]
+	if !ok || !active.IsXMR() || active.Swap.IsTerminal() {
+		return true
+	}
+	x := active.XMR
+	applyXMRSeen(x, seen)
+
+	t, err := xmrTimeouts(x.BTCChain, c.network)
+	if err != nil {
+		c.log.Warn("Adaptor swap timeouts", "trade_id", tradeID, "error", err)
+		return false
+	}
+	if action := NextXMRAction(x.Role, t, x.Observation); action != XMRActionNone {
+		if err := c.runXMRActionUnlocked(ctx, tradeID, active, action); err != nil {
+			c.log.Warn("Adaptor swap step failed", "trade_id", tradeID, "action", string(action), "error", err)
+		}
+	}
+
+	if state := xmrSwapState(x.Role, x.Observation); state != active.Swap.State {
+		c.log.Info("Adaptor swap state changed", "trade_id", tradeID, "from", string(active.Swap.State), "to", string(state))
+		active.Swap.State = state
+	}
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	return active.Swap.IsTerminal()
+}
+
+// xmrWatchUnlocked copies what the driver looks up. Caller must hold c.mu.
+func (c *Coordinator) xmrWatchUnlocked(active *ActiveSwap) (*xmrWatch, error) {
+	x := active.XMR
+	btcShare, xmrShare := x.shares()
+	w := &xmrWatch{
+		role:      x.Role,
+		xmrAmount: xmrAmount(active.Swap.Offer, c.network),
+	}
+	w.sharedAddr, _ = XMRSharedAddress(btcShare, xmrShare, c.network)
+	if x.XMRLock != nil {
+		lock := *x.XMRLock
+		w.xmrLock = &lock
+	}
+	if x.Lock == nil {
+		return w, nil
+	}
+	w.lock = *x.Lock
+	w.lockScript = hex.EncodeToString(x.Scripts.LockPkScript)
+	// Spends are only looked up once the lock can be spent
+	w.lookupSpends = x.RefundPresig != ""
+
+	var err error
+	if w.lockAddr, err = x.Scripts.LockAddress(x.BTCChain, c.network); err != nil {
+		return nil, err
+	}
+	cancel, _, err := c.xmrSpendTxUnlocked(active, XMRPathCancel)
+	if err != nil {
+		return nil, err
+	}
+	w.cancelTxID = cancel.TxHash().String()
+	cancelKey, err := schnorr.ParsePubKey(x.Scripts.CancelPkScript[2:])
+	if err != nil {
+		return nil, err
+	}
+	params, _ := chain.Get(x.BTCChain, c.network)
+	if w.cancelAddr, err = encodeTaprootAddress(cancelKey, params.Bech32HRP); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+// observeXMRSwap looks up the lock, its spends and the XMR lock. Failed
+// lookups leave their part of the result unset.
+func (c *Coordinator) observeXMRSwap(ctx context.Context, tradeID string, b backend.Backend, monero MoneroWallet, w *xmrWatch) *xmrSeen {
+	seen := &xmrSeen{}
+
+	if w.lock.TxID != "" && b != nil {
+		if tx, err := b.GetTransaction(ctx, w.lock.TxID); err == nil {
+			// The XMR party only trusts a lock paying the agreed output
+			valid := int(w.lock.Vout) < len(tx.Outputs) &&
+				tx.Outputs[w.lock.Vout].Value == w.lock.Amount &&
+				tx.Outputs[w.lock.Vout].ScriptPubKey == w.lockScript
+			if valid || w.role == XMRRoleBTC {
+				seen.lockFound = true
+				seen.lockConfs = uint32(max(tx.Confirmations, 0))
+			} else {
+				c.log.Warn("Adaptor swap lock doesn't pay the agreed output", "trade_id", tradeID, "txid", w.lock.TxID)
+			}
+		}
+	}
+
+	if seen.lockFound && w.lookupSpends {
+		if spend := findXMRSpend(ctx, b, w.lockAddr, w.lock.TxID, w.lock.Vout); spend != nil {
+			if spend.TxID == w.cancelTxID {
+				seen.cancelFound = true
+				if tx, err := b.GetTransaction(ctx, spend.TxID); err == nil {
+					seen.cancelConfs = uint32(max(tx.Confirmations, 0))
+				}
+			} else {
+				seen.spendPath, seen.spendTxID, seen.spendWit = XMRPathRedeem, spend.TxID, spend.witness
+			}
+		}
+		if seen.cancelFound {
+			if spend := findXMRSpend(ctx, b, w.cancelAddr, w.cancelTxID, 0); spend != nil {
+				// The refund takes both signatures, the punish one
+				path := XMRPathPunish
+				if len(spend.witness) == 4 {
+					path = XMRPathRefund
+				}
+				seen.spendPath, seen.spendTxID, seen.spendWit = path, spend.TxID, spend.witness
+			}
+		}
+	}
+
+	if w.xmrLock != nil && monero != nil {
+		proof, err := monero.CheckTxKey(ctx, w.xmrLock.TxID, w.xmrLock.TxKey, w.sharedAddr)
+		switch {
+		case err != nil:
+			c.log.Debug("XMR lock lookup failed", "trade_id", tradeID, "error", err)
+		case proof.Received < w.xmrAmount:
+			c.log.Warn("XMR lock short of the swap amount", "trade_id", tradeID, "received", proof.Received, "want", w.xmrAmount)
+		default:
+			seen.xmrChecked = true
+			if !proof.InPool {
+				seen.xmrConfs = uint32(proof.Confirmations)
+			}
+		}
+	}
+	return seen
+}
+
+// xmrSpend is a transaction spending a lock or cancel output.
+type xmrSpend struct {
+	TxID    string
+	witness []string
+}
+
+// findXMRSpend returns the transaction of address spending txID:vout.
+func findXMRSpend(ctx context.Context, b backend.Backend, address, txID string, vout uint32) *xmrSpend {
+	txs, err := b.GetAddressTxs(ctx, address, "")
+	if err != nil {
+		return nil
+	}
+	for _, tx := range txs {
+		for _, in := range tx.Inputs {
+			if in.TxID == txID && in.Vout == vout {
+				return &xmrSpend{TxID: tx.TxID, witness: in.Witness}
+			}
+		}
+	}
+	return nil
+}
+
+// applyXMRSeen merges what the driver found into the observation.
+func applyXMRSeen(x *XMRSwapData, seen *xmrSeen) {
+	o := &x.Observation
+	if seen.lockFound {
+		o.BTCLockBroadcast = true
+		o.BTCLockConfs = seen.lockConfs
+	}
+	if seen.cancelFound {
+		o.CancelSeen = true
+		o.CancelConfs = seen.cancelConfs
+	}
+	if seen.spendTxID != "" {
+		x.SpendTxID, x.SpendWitness = seen.spendTxID, seen.spendWit
+		switch seen.spendPath {
+		case XMRPathRedeem:
+			o.RedeemSeen = true
+		case XMRPathRefund:
+			o.RefundSeen = true
+		case XMRPathPunish:
+			o.PunishSeen = true
+		}
+	}
+	if seen.xmrChecked {
+		o.XMRLockBroadcast = true
+		o.XMRLockConfs = seen.xmrConfs
+	}
+}
+
+// xmrSwapState maps an adaptor swap's progress to a party's swap state.
+func xmrSwapState(role XMRRole, o XMRObservation) State {
+	switch {
+	case o.PunishSeen:
+		// The XMR party was paid in BTC; its XMR stays locked
+		if role == XMRRoleXMR {
+			return StateRedeemed
+		}
+		return StateFailed
+	case o.RefundSeen:
+		if role == XMRRoleBTC || o.XMRSwept || !o.XMRLockBroadcast {
+			return StateRefunded
+		}
+	case o.RedeemSeen:
+		if role == XMRRoleXMR || o.XMRSwept {
+			return StateRedeemed
+		}
+	case o.BTCLockConfs > 0 && o.XMRLockConfs > 0:
+		return StateFunded
+	case o.BTCLockBroadcast || o.XMRLockBroadcast:
+		return StateFunding
+	default:
+		return StateInit
+	}
+	return StateFunded
+}
+
+// runXMRActionUnlocked carries out an action of NextXMRAction. Caller must
+// hold c.mu.
+func (c *Coordinator) runXMRActionUnlocked(ctx context.Context, tradeID string, active *ActiveSwap, action XMRAction) error {
+	x := active.XMR
+	switch action {
+	case XMRActionLockBTC:
+		if x.Lock == nil {
+			return c.prepareXMRLockUnlocked(ctx, tradeID, active)
+		}
+		if x.RefundPresig == "" {
+			// The lock info may have been lost
+			if time.Since(x.sentAt) >= xmrResendInterval {
+				c.emitXMRLockPreparedUnlocked(tradeID, x)
+			}
+			return nil
+		}
+		return c.broadcastXMRLockUnlocked(ctx, tradeID, active)
+	case XMRActionLockXMR:
+		return c.lockXMRUnlocked(ctx, tradeID, active)
+	case XMRActionSendRedeemPresig:
+		presig, err := c.presignXMRSpendUnlocked(active, XMRPathRedeem, x.Remote.Adaptor)
+		if err != nil {
+			return err
+		}
+		x.RedeemPresig = hex.EncodeToString(presig.Serialize())
+		x.Observation.PresigExchanged = true
+		c.emitEvent(tradeID, EventXMRRedeemPresig, map[string]interface{}{"presig": x.RedeemPresig})
+		return nil
+	case XMRActionRedeemBTC:
+		return c.spendXMRLockUnlocked(ctx, tradeID, active, XMRPathRedeem)
+	case XMRActionCancel:
+		return c.spendXMRLockUnlocked(ctx, tradeID, active, XMRPathCancel)
+	case XMRActionRefund:
+		return c.spendXMRLockUnlocked(ctx, tradeID, active, XMRPathRefund)
+	case XMRActionPunish:
+		return c.spendXMRLockUnlocked(ctx, tradeID, active, XMRPathPunish)
+	case XMRActionSweepXMR, XMRActionRecoverXMR:
+		return c.sweepXMRUnlocked(ctx, tradeID, active)
+	}
+	return fmt.Errorf("unknown action %q", action)
+}
+
+// prepareXMRLockUnlocked builds and signs the BTC party's lock and signs
+// the cancel. The lock is held until the refund is pre-signed. Caller must
+// hold c.mu.
+func (c *Coordinator) prepareXMRLockUnlocked(ctx context.Context, tradeID string, active *ActiveSwap) error {
+	if c.cold {
+		return ErrColdMode
+	}
+	x := active.XMR
+	b, ok := c.backends[x.BTCChain]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoBackend, x.BTCChain)
+	}
+	built, err := c.buildFundingUnlocked(ctx, active)
+	if err != nil {
+		return err
+	}
+	x.Lock = &XMRLockInfo{
+		TxID:     built.tx.TxID,
+		Vout:     built.escrowVout,
+		Amount:   built.amount,
+		SpendFee: fundingFeeRate(ctx, b) * xmrSpendVSize,
+	}
+	sig, err := c.signXMRSpendUnlocked(active, XMRPathCancel)
+	if err != nil {
+		x.Lock = nil
+		return err
+	}
+	x.LockTxHex = built.tx.TxHex
+	x.BTCCancelSig = hex.EncodeToString(sig.Serialize())
+	if err := c.saveSwapState(tradeID); err != nil {
+		c.log.Warn("Failed to save swap state", "trade_id", tradeID, "error", err)
+	}
+	c.emitXMRLockPreparedUnlocked(tradeID, x)
+	return nil
+}
+
+func (c *Coordinator) emitXMRLockPreparedUnlocked(tradeID string, x *XMRSwapData) {
+	x.sentAt = time.Now()
+	c.emitEvent(tradeID, EventXMRLockPrepared, map[string]interface{}{
+		"lock":       *x.Lock,
+		"cancel_sig": x.BTCCancelSig,
+	})
+}
+
+// broadcastXMRLockUnlocked broadcasts the BTC party's lock. Caller must
+// hold c.mu.
+func (c *Coordinator) broadcastXMRLockUnlocked(ctx context.Context, tradeID string, active *ActiveSwap) error {
+	x := active.XMR
+	b, ok := c.backends[x.BTCChain]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrNoBackend, x.BTCChain)
+	}
+	sent, err := c.broadcastJob(ctx, b, JobActionFund, tradeID, x.BTCChain, x.LockTxHex)
+	if err != nil {
+		return fmt.Errorf("failed to broadcast lock: %w", err)
+	}
+	if sent.TxID != x.Lock.TxID {
+		return fmt.Errorf("lock job broadcast %s, pre-signed spends are of %s", sent.TxID, x.Lock.TxID)
+	}
+	active.Swap.LocalFundingTxID = x.Lock.TxID
+	active.Swap.LocalFundingVout = x.Lock.Vout
+	x.Observation.BTCLockBroadcast = true
+	x.LockTxHex = ""
+
+	c.emitEvent(tradeID, "funding_broadcast", map[string]interface{}{
+		"txid":        x.Lock.TxID,
+		"chain":       x.BTCChain,
+		"amount":      x.Lock.Amount,
+		"escrow_vout": x.Lock.Vout,
+	})
+	return nil
+}
+
+// lockXMRUnlocked sends the XMR party's XMR to the shared address. Caller
+// must hold c.mu.
+func (c *Coordinator) lockXMRUnlocked(ctx context.Context, tradeID string, active *ActiveSwap) error {
+	if c.monero == nil {
+		return ErrNoMoneroWallet
+	}
+	if c.cold {
+		return ErrColdMode
+	}
+	x := active.XMR
+	if x.XMRLock != nil {
+		x.Observation.XMRLockBroadcast = true
+		return nil
+	}
+	if x.XMRLockStarted {
+		return errors.New("an XMR transfer was interrupted; check the wallet before locking again")
+	}
+
+	btcShare, xmrShare := x.shares()
+	addr, _ := XMRSharedAddress(btcShare, xmrShare, c.network)
+	height, err := c.monero.GetHeight(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet height: %w", err)
+	}
+	x.XMRLockStarted = true
+	if err := c.saveSwapState(tradeID); err != nil {
+		x.XMRLockStarted = false
+		return fmt.Errorf("failed to save swap state: %w", err)
+	}
+	transfer, err := c.monero.Transfer(ctx, addr, xmrAmount(active.Swap.Offer, c.network))
+	if err != nil {
+		// monero-wallet-rpc doesn't relay a failed transfer
+		x.XMRLockStarted = false
+		return fmt.Errorf("failed to lock XMR: %w", err)
+	}
+	x.XMRLock = &XMRMoneroLock{TxID: transfer.TxHash, TxKey: transfer.TxKey, RestoreHeight: height}
+	x.Observation.XMRLockBroadcast = true
+	active.Swap.LocalFundingTxID = transfer.TxHash
+
+	c.log.Info("XMR locked", "trade_id", tradeID, "txid", transfer.TxHash, "fee", transfer.Fee)
+	c.emitEvent(tradeID, EventXMRLocked, map[string]interface{}{
+		"txid":           transfer.TxHash,
+		"tx_key":         transfer.TxKey,
+		"restore_height": height,
+	})
+	return nil
+}
+
+// sweepXMRUnlocked learns the counterparty's spend key share from the
+// pre-signature it completed on chain, opens the shared address in the
+// wallet and sweeps it to the main wallet. Caller must hold c.mu.
+func (c *Coordinator) sweepXMRUnlocked(ctx context.Context, tradeID string, active *ActiveSwap) error {
+	if c.monero == nil {
+		return ErrNoMoneroWallet
+	}
+	x := active.XMR
+
+	// The BTC party's redeem pre-signature was completed with s_b, the XMR
+	// party's refund pre-signature with s_a
+	witness := make(wire.TxWitness, len(x.SpendWitness))
+	for i, item := range x.SpendWitness {
+		b, err := hex.DecodeString(item)
+		if err != nil {
+			return fmt.Errorf("invalid spend witness: %w", err)
+		}
+		witness[i] = b
+	}
+	sig, err := WitnessXMRSig(witness)
+	presigHex := x.RefundPresig
+	if x.Role == XMRRoleBTC {
+		sig, err = WitnessBTCSig(witness)
+		presigHex = x.RedeemPresig
+	}
+	if err != nil {
+		return err
+	}
+	presig, err := parseAdaptorSigHex(presigHex)
+	if err != nil {
+		return err
+	}
+	t, err := presig.Extract(sig, x.Remote.Adaptor)
+	if err != nil {
+		return err
+	}
+	remoteSpend, err := XMRSpendKeyFromAdaptorSecret(t)
+	if err != nil {
+		return err
+	}
+	if new(edwards25519.Point).ScalarBaseMult(remoteSpend).Equal(x.Remote.SpendKey) != 1 {
+		return fmt.Errorf("%w: revealed key doesn't match the spend key share", ErrInvalidXMRKeyShare)
+	}
+
+	dest, err := c.monero.GetAddress(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get wallet address: %w", err)
+	}
+	btcShare, xmrShare := x.shares()
+	addr, view := XMRSharedAddress(btcShare, xmrShare, c.network)
+	spend := XMRSharedSpendKey(x.Secrets.SpendKey, remoteSpend)
+	var restoreHeight int64
+	if x.XMRLock != nil {
+		restoreHeight = x.XMRLock.RestoreHeight
+	}
+
+	file := "klingdex-xmr-" + tradeID
+	if err := c.monero.OpenWallet(ctx, file); err != nil {
+		if err := c.monero.GenerateFromKeys(ctx, file, addr, hex.EncodeToString(spend.Bytes()), hex.EncodeToString(view.Bytes()), restoreHeight); err != nil {
+			return fmt.Errorf("failed to restore the shared wallet: %w", err)
+		}
+	}
+	defer func() {
+		if err := c.monero.OpenWallet(ctx, c.moneroWalletFile); err != nil {
+			c.log.Error("Failed to reopen the main monero wallet", "file", c.moneroWalletFile, "error", err)
+		}
+	}()
+	if err := c.monero.Refresh(ctx); err != nil {
+		return fmt.Errorf("failed to refresh the shared wallet: %w", err)
+	}
+	txIDs, err := c.monero.SweepAll(ctx, dest)
+	if err != nil {
+		// Outputs are locked for 10 blocks after they confirm
+		return fmt.Errorf("failed to sweep the shared wallet: %w", err)
+	}
+	x.SweepTxIDs = txIDs
+	x.Observation.XMRSwept = true
+
+	c.log.Info("XMR swept", "trade_id", tradeID, "txids", txIDs)
+	c.emitEvent(tradeID, EventXMRAction, map[string]interface{}{
+		"action": "sweep",
+		"chain":  "XMR",
+		"txids":  txIDs,
+	})
+	return nil
+}
@@ -0,0 +1,268 @@
+package swap
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// fakeMoneroWallet is a monero-wallet-rpc that is never asked to move funds
+// in these tests.
+type fakeMoneroWallet struct{}
+
+func (fakeMoneroWallet) GetHeight(ctx context.Context) (int64, error)   { return 1000, nil }
+func (fakeMoneroWallet) GetAddress(ctx context.Context) (string, error) { return "main", nil }
+func (fakeMoneroWallet) Transfer(ctx context.Context, address string, amount uint64) (*backend.MoneroTransfer, error) {
+	return &backend.MoneroTransfer{TxHash: "xmr-lock", TxKey: "key", Amount: amount}, nil
+}
+func (fakeMoneroWallet) CheckTxKey(ctx context.Context, txID, txKey, address string) (*backend.MoneroTxProof, error) {
+	return &backend.MoneroTxProof{}, nil
+}
+func (fakeMoneroWallet) GenerateFromKeys(ctx context.Context, filename, address, spendKey, viewKey string, restoreHeight int64) error {
+	return nil
+}
+func (fakeMoneroWallet) OpenWallet(ctx context.Context, filename string) error { return nil }
+func (fakeMoneroWallet) Refresh(ctx context.Context) error                     { return nil }
+func (fakeMoneroWallet) SweepAll(ctx context.Context, address string) ([]string, error) {
+	return nil, nil
+}
+
+var xmrTestOffer = Offer{
+	OfferChain: "BTC", OfferAmount: 100_000,
+	RequestChain: "XMR", RequestAmount: 50_000_000_000,
+	Method: MethodAdaptor,
+}
+
+// newXMRCoordinator returns a coordinator holding the adaptor swap "xmr" in
+// role, paying to btcAddr on the BTC chain.
+func newXMRCoordinator(t *testing.T, store *storage.Storage, role Role, btcAddr string) *Coordinator {
+	t.Helper()
+	coord := NewCoordinator(&CoordinatorConfig{Store: store, Network: chain.Testnet})
+	t.Cleanup(func() { coord.Close() })
+	coord.SetMonero(fakeMoneroWallet{}, "main")
+
+	s, err := NewSwap(chain.Testnet, MethodAdaptor, role, xmrTestOffer)
+	if err != nil {
+		t.Fatalf("NewSwap() error = %v", err)
+	}
+	s.ID = "xmr"
+	s.LocalOfferWalletAddr = btcAddr
+	key, err := GenerateEphemeralKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	active, err := coord.newXMRSwap(s, xmrTestOffer, key)
+	if err != nil {
+		t.Fatalf("newXMRSwap() error = %v", err)
+	}
+	coord.swaps["xmr"] = active
+	return coord
+}
+
+// exchangeXMRKeyShares hands each coordinator the other's key share.
+func exchangeXMRKeyShares(t *testing.T, a, b *Coordinator) {
+	t.Helper()
+	for _, pair := range [][2]*Coordinator{{a, b}, {b, a}} {
+		share, addr, err := pair[0].LocalXMRKeyShare("xmr")
+		if err != nil {
+			t.Fatalf("LocalXMRKeyShare() error = %v", err)
+		}
+		if err := pair[1].SetXMRKeyShare("xmr", share, addr); err != nil {
+			t.Fatalf("SetXMRKeyShare() error = %v", err)
+		}
+	}
+}
+
+func TestXMRRole(t *testing.T) {
+	tests := []struct {
+		offer Offer
+		role  Role
+		want  XMRRole
+	}{
+		{xmrTestOffer, RoleInitiator, XMRRoleBTC},
+		{xmrTestOffer, RoleResponder, XMRRoleXMR},
+		{Offer{OfferChain: "XMR", RequestChain: "BTC"}, RoleInitiator, XMRRoleXMR},
+		{Offer{OfferChain: "XMR", RequestChain: "BTC"}, RoleResponder, XMRRoleBTC},
+	}
+	for _, tt := range tests {
+		got, btcChain := xmrRole(tt.offer, tt.role, chain.Testnet)
+		if got != tt.want || btcChain != "BTC" {
+			t.Errorf("xmrRole(%s/%s, %s) = %s, %s, want %s, BTC", tt.offer.OfferChain, tt.offer.RequestChain, tt.role, got, btcChain, tt.want)
+		}
+	}
+
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	defer coord.Close()
+	s, _ := NewSwap(chain.Testnet, MethodAdaptor, RoleInitiator, xmrTestOffer)
+	key, _ := GenerateEphemeralKey()
+	if _, err := coord.newXMRSwap(s, xmrTestOffer, key); err != ErrNoMoneroWallet {
+		t.Errorf("newXMRSwap() without a monero wallet error = %v, want %v", err, ErrNoMoneroWallet)
+	}
+}
+
+func TestCoordinatorXMRSignatureExchange(t *testing.T) {
+	// The maker offers BTC, so it is the BTC party
+	btc := newXMRCoordinator(t, nil, RoleInitiator, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx")
+	xmr := newXMRCoordinator(t, nil, RoleResponder, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7")
+
+	// Lock info before the key shares is refused
+	if err := xmr.SetXMRLockInfo("xmr", XMRLockInfo{}, ""); err == nil {
+		t.Error("SetXMRLockInfo() before the key shares succeeded")
+	}
+	exchangeXMRKeyShares(t, btc, xmr)
+	exchangeXMRKeyShares(t, btc, xmr) // Resent shares are ignored
+
+	// Another share is refused
+	other, err := NewXMRSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xmr.SetXMRKeyShare("xmr", other.Share(), "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"); err == nil {
+		t.Error("SetXMRKeyShare() replaced the counterparty's share")
+	}
+
+	btcSwap, xmrSwap := btc.swaps["xmr"], xmr.swaps["xmr"]
+	if btcSwap.XMR.Scripts == nil || xmrSwap.XMR.Scripts == nil {
+		t.Fatal("lock scripts not built")
+	}
+	if hex.EncodeToString(btcSwap.XMR.Scripts.LockPkScript) != hex.EncodeToString(xmrSwap.XMR.Scripts.LockPkScript) {
+		t.Fatal("parties built different lock scripts")
+	}
+
+	// The BTC party's lock, as prepareXMRLockUnlocked would build it
+	btc.mu.Lock()
+	lock := XMRLockInfo{
+		TxID:     strings.Repeat("ab", 32),
+		Amount:   escrowAmountFor(btcSwap, true),
+		SpendFee: 1000,
+	}
+	btcSwap.XMR.Lock = &lock
+	cancelSig, err := btc.signXMRSpendUnlocked(btcSwap, XMRPathCancel)
+	btc.mu.Unlock()
+	if err != nil {
+		t.Fatalf("signXMRSpendUnlocked() error = %v", err)
+	}
+	btcCancel := hex.EncodeToString(cancelSig.Serialize())
+
+	short := lock
+	short.Amount--
+	if err := xmr.SetXMRLockInfo("xmr", short, btcCancel); err == nil || !strings.Contains(err.Error(), "lock amount") {
+		t.Errorf("SetXMRLockInfo() with a short lock error = %v", err)
+	}
+	if err := xmr.SetXMRLockInfo("xmr", lock, strings.Repeat("00", 64)); err == nil {
+		t.Error("SetXMRLockInfo() accepted an invalid cancel signature")
+	}
+	if err := xmr.SetXMRLockInfo("xmr", lock, btcCancel); err != nil {
+		t.Fatalf("SetXMRLockInfo() error = %v", err)
+	}
+	if err := xmr.SetXMRLockInfo("xmr", lock, btcCancel); err != nil {
+		t.Errorf("resent SetXMRLockInfo() error = %v", err)
+	}
+
+	xmr.mu.RLock()
+	xmrCancel, refundPresig := xmrSwap.XMR.XMRCancelSig, xmrSwap.XMR.RefundPresig
+	xmr.mu.RUnlock()
+	if err := btc.SetXMRRefundSigs("xmr", btcCancel, refundPresig); err == nil {
+		t.Error("SetXMRRefundSigs() accepted the BTC party's own cancel signature")
+	}
+	if err := btc.SetXMRRefundSigs("xmr", xmrCancel, refundPresig); err != nil {
+		t.Fatalf("SetXMRRefundSigs() error = %v", err)
+	}
+
+	// The redeem pre-signature lets the XMR party redeem
+	btc.mu.Lock()
+	presig, err := btc.presignXMRSpendUnlocked(btcSwap, XMRPathRedeem, btcSwap.XMR.Remote.Adaptor)
+	btc.mu.Unlock()
+	if err != nil {
+		t.Fatalf("presignXMRSpendUnlocked() error = %v", err)
+	}
+	if err := xmr.SetXMRRedeemPresig("xmr", refundPresig); err == nil {
+		t.Error("SetXMRRedeemPresig() accepted the refund pre-signature")
+	}
+	if err := xmr.SetXMRRedeemPresig("xmr", hex.EncodeToString(presig.Serialize())); err != nil {
+		t.Fatalf("SetXMRRedeemPresig() error = %v", err)
+	}
+	xmr.mu.RLock()
+	defer xmr.mu.RUnlock()
+	if !xmrSwap.XMR.Observation.PresigExchanged {
+		t.Error("redeem pre-signature not recorded")
+	}
+	if _, _, err := xmr.xmrSpendTxUnlocked(xmrSwap, XMRPathRedeem); err != nil {
+		t.Errorf("xmrSpendTxUnlocked(redeem) error = %v", err)
+	}
+}
+
+func TestCoordinatorXMRRecovery(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	xmr := newXMRCoordinator(t, store, RoleResponder, "tb1qrp33g0q5c5txsp9arysrx4k6zdkfs4nce4xj0gdcccefvpysxf3q0sl5k7")
+	btc := newXMRCoordinator(t, nil, RoleInitiator, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx")
+	exchangeXMRKeyShares(t, btc, xmr)
+
+	xmr.mu.RLock()
+	want := xmr.swaps["xmr"].XMR
+	wantShare, wantScript := want.Secrets.Share(), hex.EncodeToString(want.Scripts.LockPkScript)
+	xmr.mu.RUnlock()
+
+	restarted := NewCoordinator(&CoordinatorConfig{Store: store, Network: chain.Testnet})
+	defer restarted.Close()
+	restarted.SetMonero(fakeMoneroWallet{}, "main")
+	if err := restarted.LoadPendingSwaps(context.Background()); err != nil {
+		t.Fatalf("LoadPendingSwaps() error = %v", err)
+	}
+
+	restarted.mu.RLock()
+	defer restarted.mu.RUnlock()
+	active, ok := restarted.swaps["xmr"]
+	if !ok || !active.IsXMR() {
+		t.Fatal("adaptor swap not recovered")
+	}
+	x := active.XMR
+	if x.Role != XMRRoleXMR || x.BTCChain != "BTC" || x.Remote == nil || x.Scripts == nil {
+		t.Fatalf("recovered swap = %+v", x.XMRLeg)
+	}
+	if !x.Secrets.Share().BTCKey.IsEqual(wantShare.BTCKey) || x.Secrets.Share().SpendKey.Equal(wantShare.SpendKey) != 1 {
+		t.Error("recovered another key share")
+	}
+	if hex.EncodeToString(x.Scripts.LockPkScript) != wantScript {
+		t.Error("recovered another lock script")
+	}
+	if active.Swap.RemoteOfferWalletAddr != "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx" {
+		t.Errorf("recovered counterparty address %q", active.Swap.RemoteOfferWalletAddr)
+	}
+}
+
+func TestXMRSwapState(t *testing.T) {
+	tests := []struct {
+		name string
+		role XMRRole
+		o    XMRObservation
+		want State
+	}{
+		{"nothing yet", XMRRoleBTC, XMRObservation{}, StateInit},
+		{"btc locked", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true}, StateFunding},
+		{"both locked", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 1, XMRLockBroadcast: true, XMRLockConfs: 1}, StateFunded},
+		{"redeemed, xmr party", XMRRoleXMR, XMRObservation{RedeemSeen: true}, StateRedeemed},
+		{"redeemed, not swept", XMRRoleBTC, XMRObservation{RedeemSeen: true}, StateFunded},
+		{"redeemed and swept", XMRRoleBTC, XMRObservation{RedeemSeen: true, XMRSwept: true}, StateRedeemed},
+		{"refunded, btc party", XMRRoleBTC, XMRObservation{RefundSeen: true}, StateRefunded},
+		{"refunded, xmr not recovered", XMRRoleXMR, XMRObservation{RefundSeen: true, XMRLockBroadcast: true}, StateFunded},
+		{"refunded before the xmr lock", XMRRoleXMR, XMRObservation{RefundSeen: true}, StateRefunded},
+		{"punished, btc party", XMRRoleBTC, XMRObservation{PunishSeen: true}, StateFailed},
+		{"punished, xmr party", XMRRoleXMR, XMRObservation{PunishSeen: true}, StateRedeemed},
+	}
+	for _, tt := range tests {
+		if got := xmrSwapState(tt.role, tt.o); got != tt.want {
+			t.Errorf("%s: xmrSwapState() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,474 @@
+// Package swap - Zero-conf mode for small swaps.
+//
+// With the zero-conf policy enabled, a swap whose counterparty leg is below
+// the chain's cap may proceed on the counterparty's unconfirmed funding
+// transaction instead of waiting for MinConfirmations. The funding must pay
+// the escrow in full, pay a sane feerate, not signal replace-by-fee, come
+// from a peer with a good trade history, and stay in the mempool with no
+// conflicting spend of its inputs for the whole monitoring window. Any check
+// that fails falls back to the normal confirmation requirement.
+package swap
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Zero-conf events, emitted once per decision.
+const (
+	EventZeroConfMonitoring  = "zero_conf_monitoring"   // Unconfirmed funding seen, window started
+	EventZeroConfAccepted    = "zero_conf_accepted"     // Funding accepted without confirmations
+	EventZeroConfRejected    = "zero_conf_rejected"     // Falling back to confirmations
+	EventZeroConfDoubleSpend = "zero_conf_double_spend" // Conflicting spend of the funding inputs
+)
+
+// maxZeroConfInputs bounds the funding inputs watched for conflicting spends.
+const maxZeroConfInputs = 10
+
+// rbfSequenceThreshold is the BIP-125 opt-in limit: inputs with a lower
+// sequence signal replaceability.
+const rbfSequenceThreshold = 0xfffffffe
+
+// ZeroConfPolicy is the node-wide zero-conf configuration.
+type ZeroConfPolicy struct {
+	Enabled bool `yaml:"enabled,omitempty" json:"enabled"`
+
+	// MaxAmounts caps the counterparty leg per chain symbol, in smallest
+	// units. Chains without a cap never use zero-conf.
+	MaxAmounts map[string]uint64 `yaml:"max_amounts,omitempty" json:"max_amounts,omitempty"`
+
+	// MonitorWindow is how long the unconfirmed funding must stay in the
+	// mempool without a conflicting spend.
+	MonitorWindow time.Duration `yaml:"monitor_window,omitempty" json:"monitor_window,omitempty"`
+
+	// MinFeeRate is the minimum funding feerate in sat/vB. 0 uses the
+	// backend's one-hour fee estimate.
+	MinFeeRate uint64 `yaml:"min_fee_rate,omitempty" json:"min_fee_rate,omitempty"`
+
+	// MinCompletedTrades and MaxFailureRateBps gate on the counterparty's
+	// trade history with us.
+	MinCompletedTrades int    `yaml:"min_completed_trades,omitempty" json:"min_completed_trades,omitempty"`
+	MaxFailureRateBps  uint32 `yaml:"max_failure_rate_bps,omitempty" json:"max_failure_rate_bps,omitempty"`
+}
+
+// Validate checks the window and failure rate.
+func (p *ZeroConfPolicy) Validate() error {
+	if !p.Enabled {
+		return nil
+	}
+	if p.MonitorWindow <= 0 {
+		return fmt.Errorf("zero_conf.monitor_window must be positive")
+	}
+	if p.MaxFailureRateBps > 10000 {
+		return fmt.Errorf("zero_conf.max_failure_rate_bps must be at most 10000")
+	}
+	if len(p.MaxAmounts) == 0 {
+		return fmt.Errorf("zero_conf enabled without max_amounts")
+	}
+	return nil
+}
+
+// ZeroConfStatus describes the zero-conf state of a swap.
+type ZeroConfStatus struct {
+	TradeID     string `json:"trade_id"`
+	Decision    string `json:"decision,omitempty"` // Last zero_conf_* event
+	Reason      string `json:"reason,omitempty"`   // Why zero-conf was rejected
+	FirstSeenAt int64  `json:"first_seen_at,omitempty"`
+	FeeRate     uint64 `json:"fee_rate,omitempty"` // Funding feerate in sat/vB
+}
+
+// zeroConfState is the in-memory monitoring state of a swap. Acceptances are
+// also stored, so a restarted node keeps relying on the accepted funding.
+type zeroConfState struct {
+	decision  string
+	reason    string
+	firstSeen time.Time
+	feeRate   uint64
+}
+
+// SetZeroConfPolicy sets the node-wide zero-conf policy.
+func (c *Coordinator) SetZeroConfPolicy(policy ZeroConfPolicy) error {
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.zeroConf = policy
+	return nil
+}
+
+// GetZeroConfStatus returns the zero-conf state of a swap.
+func (c *Coordinator) GetZeroConfStatus(tradeID string) (*ZeroConfStatus, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if _, ok := c.swaps[tradeID]; !ok {
+		return nil, ErrSwapNotFound
+	}
+	return c.zeroConfStatusLocked(tradeID), nil
+}
+
+// zeroConfStatusLocked returns the zero-conf state of a swap. Caller must
+// hold c.mu.
+func (c *Coordinator) zeroConfStatusLocked(tradeID string) *ZeroConfStatus {
+	status := &ZeroConfStatus{TradeID: tradeID}
+	if st, ok := c.zeroConfs[tradeID]; ok {
+		status.Decision = st.decision
+		status.Reason = st.reason
+		status.FeeRate = st.feeRate
+		if !st.firstSeen.IsZero() {
+			status.FirstSeenAt = st.firstSeen.Unix()
+		}
+	}
+	return status
+}
+
+// zeroConfQuery is the chain reads zero-conf monitoring makes of the
+// counterparty's unconfirmed funding, beyond the funding itself.
+type zeroConfQuery struct {
+	b    backend.Backend
+	fees bool // The feerate floor comes from the backend's estimate
+
+	hourFee     uint64
+	conflict    string
+	conflictErr error
+}
+
+// zeroConfQueryLocked prepares the reads of zero-conf monitoring; it returns
+// nil when the swap is not monitored. Caller must hold c.mu.
+func (c *Coordinator) zeroConfQueryLocked(tradeID string, active *ActiveSwap) *zeroConfQuery {
+	if !c.zeroConfWatchedLocked(active) || active.Swap.RemoteFundingConfirms > 0 {
+		return nil
+	}
+	if st, ok := c.zeroConfs[tradeID]; ok && (st.decision == EventZeroConfRejected || st.decision == EventZeroConfDoubleSpend) {
+		return nil
+	}
+	remoteChain, _ := remoteLeg(active.Swap)
+	b, ok := c.backends[remoteChain]
+	if !ok {
+		return nil
+	}
+	return &zeroConfQuery{b: b, fees: !active.Swap.FundingZeroConf && c.zeroConf.MinFeeRate == 0}
+}
+
+// zeroConfWatchedLocked reports whether a swap's counterparty funding is
+// under zero-conf monitoring. Caller must hold c.mu.
+func (c *Coordinator) zeroConfWatchedLocked(active *ActiveSwap) bool {
+	if !c.zeroConf.Enabled || active.Swap.RemoteFundingTxID == "" {
+		return false
+	}
+	return active.Swap.State == StateFunding || (active.Swap.State == StateFunded && active.Swap.FundingZeroConf)
+}
+
+// run makes the reads for the funding transaction the remote query found.
+// It must not be called holding c.mu.
+func (q *zeroConfQuery) run(ctx context.Context, remote *fundingQuery) {
+	if q == nil || remote.tx == nil || remote.tx.Confirmed {
+		return
+	}
+	if q.fees {
+		if est, err := q.b.GetFeeEstimates(ctx); err == nil && est != nil {
+			q.hourFee = est.HourFee
+		}
+	}
+	q.conflict, q.conflictErr = findConflictingSpend(ctx, q.b, remote.tx)
+}
+
+// checkZeroConfLocked advances zero-conf monitoring for a swap from the
+// chain reads of a confirmation update. Accepted funding stays monitored for
+// conflicting spends until it confirms. Caller must hold c.mu.
+func (c *Coordinator) checkZeroConfLocked(tradeID string, active *ActiveSwap, remote *fundingQuery, q *zeroConfQuery) {
+	if !c.zeroConfWatchedLocked(active) {
+		return
+	}
+	if c.zeroConfs == nil {
+		c.zeroConfs = make(map[string]*zeroConfState)
+	}
+	st, ok := c.zeroConfs[tradeID]
+	if !ok {
+		st = &zeroConfState{}
+		c.zeroConfs[tradeID] = st
+	}
+	if st.decision == EventZeroConfRejected || st.decision == EventZeroConfDoubleSpend {
+		return
+	}
+	if active.Swap.RemoteFundingConfirms > 0 {
+		return // Confirmed; no double-spend risk left to watch
+	}
+
+	reject := func(event, reason string) {
+		wasAccepted := active.Swap.FundingZeroConf
+		active.Swap.FundingZeroConf = false
+		st.decision = event
+		st.reason = reason
+		if wasAccepted && c.store != nil {
+			if err := c.store.DeleteZeroConfAcceptance(tradeID); err != nil {
+				c.log.Warn("Failed to delete zero-conf acceptance", "trade_id", tradeID, "error", err)
+			}
+		}
+		if wasAccepted && active.Swap.State != StateFunding {
+			// Too late to wait for confirmations; the operator must act
+			c.log.Error("Zero-conf funding invalidated after the swap proceeded", "trade_id", tradeID, "state", active.Swap.State, "reason", reason)
+		} else {
+			c.log.Warn("Zero-conf rejected, waiting for confirmations", "trade_id", tradeID, "reason", reason, "was_accepted", wasAccepted)
+		}
+		c.emitEvent(tradeID, event, map[string]interface{}{
+			"reason":       reason,
+			"was_accepted": wasAccepted,
+			"state":        string(active.Swap.State),
+		})
+	}
+
+	remoteChain, amount := remoteLeg(active.Swap)
+	accepted := active.Swap.FundingZeroConf
+	if !accepted {
+		if reason := c.zeroConfIneligibleLocked(active, remoteChain, amount); reason != "" {
+			reject(EventZeroConfRejected, reason)
+			return
+		}
+	}
+
+	if _, ok := c.backends[remoteChain]; !ok {
+		reject(EventZeroConfRejected, fmt.Sprintf("no backend for %s", remoteChain))
+		return
+	}
+	if q == nil || remote.txID != active.Swap.RemoteFundingTxID {
+		return // Funding changed since the reads; checked on the next update
+	}
+	tx := remote.tx
+	if tx == nil {
+		if !st.firstSeen.IsZero() {
+			reject(EventZeroConfRejected, "funding transaction left the mempool")
+		}
+		return
+	}
+	if tx.Confirmed {
+		return
+	}
+
+	// Fee estimates move; the transaction itself is only judged before acceptance
+	if !accepted {
+		feeRate, reason := c.checkZeroConfTx(active, tx, q.hourFee)
+		st.feeRate = feeRate
+		if reason != "" {
+			reject(EventZeroConfRejected, reason)
+			return
+		}
+	}
+	if q.conflictErr != nil {
+		c.log.Debug("Zero-conf conflict check failed", "trade_id", tradeID, "error", q.conflictErr)
+		return // Retry on the next check; the window does not advance without a clean check
+	} else if q.conflict != "" {
+		reject(EventZeroConfDoubleSpend, fmt.Sprintf("funding input spent by %s", q.conflict))
+		return
+	}
+
+	if st.firstSeen.IsZero() {
+		st.firstSeen = time.Now()
+		st.decision = EventZeroConfMonitoring
+		c.emitEvent(tradeID, EventZeroConfMonitoring, map[string]interface{}{
+			"txid":     tx.TxID,
+			"fee_rate": st.feeRate,
+			"window":   c.zeroConf.MonitorWindow.String(),
+		})
+	}
+	if !accepted && time.Since(st.firstSeen) >= c.zeroConf.MonitorWindow {
+		active.Swap.FundingZeroConf = true
+		st.decision = EventZeroConfAccepted
+		if c.store != nil {
+			if err := c.store.SaveZeroConfAcceptance(&storage.ZeroConfAcceptance{
+				TradeID:     tradeID,
+				TxID:        tx.TxID,
+				FeeRate:     st.feeRate,
+				FirstSeenAt: st.firstSeen,
+				AcceptedAt:  time.Now(),
+			}); err != nil {
+				c.log.Warn("Failed to save zero-conf acceptance", "trade_id", tradeID, "txid", tx.TxID, "error", err)
+			}
+		}
+		c.log.Info("Zero-conf funding accepted", "trade_id", tradeID, "txid", tx.TxID, "amount", amount)
+		c.emitEvent(tradeID, EventZeroConfAccepted, map[string]interface{}{
+			"txid":   tx.TxID,
+			"amount": amount,
+		})
+	}
+}
+
+// restoreZeroConfUnlocked reloads a recovered swap's zero-conf acceptance, so
+// the swap keeps relying on its accepted funding, still monitored for
+// conflicting spends. Funding replaced since is judged anew. Caller must
+// hold c.mu.
+func (c *Coordinator) restoreZeroConfUnlocked(tradeID string) {
+	active, ok := c.swaps[tradeID]
+	if !ok || c.store == nil {
+		return
+	}
+	a, err := c.store.GetZeroConfAcceptance(tradeID)
+	if err != nil {
+		c.log.Warn("Failed to load zero-conf acceptance", "trade_id", tradeID, "error", err)
+		return
+	}
+	if a == nil || a.TxID != active.Swap.RemoteFundingTxID || active.Swap.IsTerminal() {
+		return
+	}
+	if c.zeroConfs == nil {
+		c.zeroConfs = make(map[string]*zeroConfState)
+	}
+	c.zeroConfs[tradeID] = &zeroConfState{
+		decision:  EventZeroConfAccepted,
+		firstSeen: a.FirstSeenAt,
+		feeRate:   a.FeeRate,
+	}
+	active.Swap.FundingZeroConf = true
+}
+
+// zeroConfIneligibleLocked returns why a swap cannot use zero-conf, or "".
+func (c *Coordinator) zeroConfIneligibleLocked(active *ActiveSwap, remoteChain string, amount uint64) string {
+	if !active.IsMuSig2() && !active.IsHTLC() {
+		return "swap method not supported"
+	}
+	if params, ok := chain.Get(remoteChain, c.network); !ok || params.Type == chain.ChainTypeEVM {
+		return fmt.Sprintf("zero-conf not supported on %s", remoteChain)
+	}
+	maxAmount, ok := c.zeroConf.MaxAmounts[remoteChain]
+	if !ok {
+		return fmt.Sprintf("no zero-conf cap for %s", remoteChain)
+	}
+	if amount > maxAmount {
+		return fmt.Sprintf("amount %d above zero-conf cap %d", amount, maxAmount)
+	}
+
+	if active.Trade == nil || c.store == nil {
+		return "counterparty unknown"
+	}
+	peerID := active.Trade.MakerPeerID
+	if active.Trade.OurRole == storage.TradeRoleMaker {
+		peerID = active.Trade.TakerPeerID
+	}
+	stats, err := c.store.GetPeerTradeStats(peerID)
+	if err != nil {
+		return "counterparty history unavailable"
+	}
+	if stats.CompletedTrades < c.zeroConf.MinCompletedTrades {
+		return fmt.Sprintf("counterparty has %d completed trades, need %d", stats.CompletedTrades, c.zeroConf.MinCompletedTrades)
+	}
+	if stats.FailureRateBps > c.zeroConf.MaxFailureRateBps {
+		return fmt.Sprintf("counterparty failure rate %d bps above %d", stats.FailureRateBps, c.zeroConf.MaxFailureRateBps)
+	}
+	return ""
+}
+
+// checkZeroConfTx checks the escrow output, RBF signaling and feerate of the
+// unconfirmed funding against hourFee, the backend's one-hour fee estimate.
+// It returns the feerate and why the tx is unsuitable, or "".
+func (c *Coordinator) checkZeroConfTx(active *ActiveSwap, tx *backend.Transaction, hourFee uint64) (uint64, string) {
+	// The escrow output must pay the counterparty escrow in full
+	offerAddr, requestAddr := escrowAddressesUnlocked(active)
+	escrowAddr := requestAddr
+	if active.Swap.Role == RoleResponder {
+		escrowAddr = offerAddr
+	}
+	vout := active.Swap.RemoteFundingVout
+	if escrowAddr == "" || int(vout) >= len(tx.Outputs) {
+		return 0, "escrow output not found"
+	}
+	out := tx.Outputs[vout]
+	if out.ScriptPubKeyAddr != escrowAddr {
+		return 0, "funding does not pay the escrow address"
+	}
+	if want := remoteFundingAmount(active.Swap); out.Value < want {
+		return 0, fmt.Sprintf("escrow output %d below %d", out.Value, want)
+	}
+
+	if len(tx.Inputs) > maxZeroConfInputs {
+		return 0, fmt.Sprintf("%d inputs, at most %d are monitored", len(tx.Inputs), maxZeroConfInputs)
+	}
+	for _, in := range tx.Inputs {
+		if in.Sequence < rbfSequenceThreshold {
+			return 0, "funding signals replace-by-fee"
+		}
+	}
+
+	feeRate := txFeeRate(tx)
+	if feeRate == 0 {
+		return 0, "funding fee unknown"
+	}
+	minFeeRate := c.zeroConf.MinFeeRate
+	if minFeeRate == 0 {
+		minFeeRate = hourFee
+	}
+	if feeRate < minFeeRate {
+		return feeRate, fmt.Sprintf("feerate %d sat/vB below %d", feeRate, minFeeRate)
+	}
+	return feeRate, ""
+}
+
+// txFeeRate returns the feerate of tx in sat/vB, or 0 if it is unknown.
+func txFeeRate(tx *backend.Transaction) uint64 {
+	fee := tx.Fee
+	if fee == 0 {
+		var in, out uint64
+		for _, i := range tx.Inputs {
+			if i.PrevOut == nil {
+				return 0
+			}
+			in += i.PrevOut.Value
+		}
+		for _, o := range tx.Outputs {
+			out += o.Value
+		}
+		if in <= out {
+			return 0
+		}
+		fee = in - out
+	}
+	vsize := tx.VSize
+	if vsize <= 0 && tx.Weight > 0 {
+		vsize = (tx.Weight + 3) / 4
+	}
+	if vsize <= 0 {
+		vsize = tx.Size
+	}
+	if vsize <= 0 {
+		return 0
+	}
+	return fee / uint64(vsize)
+}
+
+// findConflictingSpend looks for another transaction spending one of tx's
+// inputs and returns its txid, or "".
+func findConflictingSpend(ctx context.Context, b backend.Backend, tx *backend.Transaction) (string, error) {
+	for _, in := range tx.Inputs {
+		if in.PrevOut == nil || in.PrevOut.ScriptPubKeyAddr == "" {
+			return "", fmt.Errorf("input %s:%d has no prevout address", in.TxID, in.Vout)
+		}
+		txs, err := b.GetAddressTxs(ctx, in.PrevOut.ScriptPubKeyAddr, "")
+		if err != nil {
+			return "", err
+		}
+		for _, other := range txs {
+			if other.TxID == tx.TxID {
+				continue
+			}
+			for _, oin := range other.Inputs {
+				if oin.TxID == in.TxID && oin.Vout == in.Vout {
+					return other.TxID, nil
+				}
+			}
+		}
+	}
+	return "", nil
+}
+
+// remoteLeg returns the chain and amount the counterparty funds.
+func remoteLeg(s *Swap) (string, uint64) {
+	if s.Role == RoleInitiator {
+		return s.Offer.RequestChain, s.Offer.RequestAmount
+	}
+	return s.Offer.OfferChain, s.Offer.OfferAmount
+}
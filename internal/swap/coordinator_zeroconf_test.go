@@ -0,0 +1,273 @@
+package swap
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+const (
+	zcEscrow    = "ltc1qescrowaddress"
+	zcFundingID = "remote-funding"
+	zcPrevTxID  = "prev-tx"
+	zcPrevAddr  = "ltc1qcounterpartywallet"
+)
+
+// zeroConfBackend returns an LTC chain whose mempool holds the taker's
+// unconfirmed funding of the escrow.
+func zeroConfBackend() *fakeChainBackend {
+	ltc := newFakeChainBackend()
+	ltc.txs[zcFundingID] = &backend.Transaction{
+		TxID:    zcFundingID,
+		VSize:   200,
+		Fee:     2000, // 10 sat/vB
+		Inputs:  []backend.TxInput{{TxID: zcPrevTxID, Vout: 1, Sequence: 0xffffffff, PrevOut: &backend.TxOutput{ScriptPubKeyAddr: zcPrevAddr, Value: 1100000}}},
+		Outputs: []backend.TxOutput{{ScriptPubKeyAddr: zcEscrow, Value: 1000000}},
+	}
+	return ltc
+}
+
+// zeroConfStore returns a store holding completed trades with the taker.
+func zeroConfStore(t *testing.T, completed int) *storage.Storage {
+	t.Helper()
+	store := newTestStore(t)
+	for i := 0; i < completed; i++ {
+		id := fmt.Sprintf("past-%d", i)
+		if err := store.CreateTrade(&storage.Trade{
+			ID: id, OrderID: "order-" + id, MakerPeerID: "us", TakerPeerID: "taker",
+			OurRole: storage.TradeRoleMaker, State: storage.TradeStateRedeemed, CreatedAt: time.Now(),
+		}); err != nil {
+			t.Fatalf("CreateTrade() error = %v", err)
+		}
+	}
+	return store
+}
+
+// addZeroConfTrade enables zero-conf and adds trade t1, a mainnet BTC/LTC
+// HTLC swap in which we are the maker and the taker's LTC funding is
+// unconfirmed.
+func addZeroConfTrade(t *testing.T, coord *Coordinator) {
+	t.Helper()
+	s := newTestHTLCSwap(t, chain.Mainnet, RoleInitiator)
+	s.State = StateFunding
+	s.LocalFundingTxID = "local-funding"
+	s.RemoteFundingTxID = zcFundingID
+	coord.swaps["t1"] = &ActiveSwap{
+		Swap:  s,
+		Trade: &storage.Trade{ID: "t1", MakerPeerID: "us", TakerPeerID: "taker", OurRole: storage.TradeRoleMaker},
+		HTLC:  &HTLCSwapData{RequestChain: &ChainHTLCData{HTLCAddress: zcEscrow}},
+	}
+
+	if err := coord.SetZeroConfPolicy(ZeroConfPolicy{
+		Enabled:            true,
+		MaxAmounts:         map[string]uint64{"LTC": 5000000},
+		MonitorWindow:      20 * time.Millisecond,
+		MinCompletedTrades: 2,
+		MaxFailureRateBps:  1000,
+	}); err != nil {
+		t.Fatalf("SetZeroConfPolicy() error = %v", err)
+	}
+}
+
+func zeroConfStatus(t *testing.T, coord *Coordinator) *ZeroConfStatus {
+	t.Helper()
+	status, err := coord.GetZeroConfStatus("t1")
+	if err != nil {
+		t.Fatalf("GetZeroConfStatus() error = %v", err)
+	}
+	return status
+}
+
+func TestZeroConfPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  ZeroConfPolicy
+		wantErr bool
+	}{
+		{"disabled", ZeroConfPolicy{}, false},
+		{"valid", ZeroConfPolicy{Enabled: true, MonitorWindow: time.Minute, MaxAmounts: map[string]uint64{"BTC": 1}}, false},
+		{"no window", ZeroConfPolicy{Enabled: true, MaxAmounts: map[string]uint64{"BTC": 1}}, true},
+		{"no caps", ZeroConfPolicy{Enabled: true, MonitorWindow: time.Minute}, true},
+		{"failure rate", ZeroConfPolicy{Enabled: true, MonitorWindow: time.Minute, MaxAmounts: map[string]uint64{"BTC": 1}, MaxFailureRateBps: 10001}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: Validate() error = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestZeroConfAccepted(t *testing.T) {
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(zeroConfStore(t, 3)), withBackend("LTC", zeroConfBackend()))
+	addZeroConfTrade(t, coord)
+	ctx := context.Background()
+
+	if err := coord.UpdateConfirmations(ctx, "t1"); err != nil {
+		t.Fatalf("UpdateConfirmations() error = %v", err)
+	}
+	st := zeroConfStatus(t, coord)
+	if st.Decision != EventZeroConfMonitoring || st.FeeRate != 10 || st.FirstSeenAt == 0 {
+		t.Fatalf("status after first check = %+v, want monitoring", st)
+	}
+	if coord.swaps["t1"].Swap.State != StateFunding {
+		t.Fatal("swap funded before the monitoring window elapsed")
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := coord.UpdateConfirmations(ctx, "t1"); err != nil {
+		t.Fatalf("UpdateConfirmations() error = %v", err)
+	}
+	if st := zeroConfStatus(t, coord); st.Decision != EventZeroConfAccepted {
+		t.Errorf("status after window = %+v, want accepted", st)
+	}
+	if s := coord.swaps["t1"].Swap; s.State != StateFunded || !s.FundingZeroConf {
+		t.Errorf("swap state = %s, zero-conf = %v, want funded", s.State, s.FundingZeroConf)
+	}
+}
+
+func TestZeroConfRejected(t *testing.T) {
+	tests := []struct {
+		name      string
+		completed int
+		modify    func(*Coordinator, *fakeChainBackend)
+		want      string
+	}{
+		{"above cap", 3, func(c *Coordinator, _ *fakeChainBackend) {
+			c.zeroConf.MaxAmounts["LTC"] = 999999
+		}, "above zero-conf cap"},
+		{"chain without cap", 3, func(c *Coordinator, _ *fakeChainBackend) {
+			delete(c.zeroConf.MaxAmounts, "LTC")
+		}, "no zero-conf cap"},
+		{"new counterparty", 1, func(*Coordinator, *fakeChainBackend) {}, "completed trades"},
+		{"replaceable", 3, func(_ *Coordinator, b *fakeChainBackend) {
+			b.txs[zcFundingID].Inputs[0].Sequence = 0xfffffffd
+		}, "replace-by-fee"},
+		{"low feerate", 3, func(_ *Coordinator, b *fakeChainBackend) {
+			b.txs[zcFundingID].Fee = 400
+		}, "below 5"},
+		{"short escrow", 3, func(_ *Coordinator, b *fakeChainBackend) {
+			b.txs[zcFundingID].Outputs[0].Value = 999999
+		}, "escrow output"},
+		{"wrong address", 3, func(_ *Coordinator, b *fakeChainBackend) {
+			b.txs[zcFundingID].Outputs[0].ScriptPubKeyAddr = "ltc1qother"
+		}, "escrow address"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ltc := zeroConfBackend()
+			coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(zeroConfStore(t, tt.completed)), withBackend("LTC", ltc))
+			addZeroConfTrade(t, coord)
+			tt.modify(coord, ltc)
+
+			if err := coord.UpdateConfirmations(context.Background(), "t1"); err != nil {
+				t.Fatalf("UpdateConfirmations() error = %v", err)
+			}
+			st := zeroConfStatus(t, coord)
+			if st.Decision != EventZeroConfRejected || !strings.Contains(st.Reason, tt.want) {
+				t.Errorf("status = %+v, want rejected with %q", st, tt.want)
+			}
+			if coord.swaps["t1"].Swap.State != StateFunding {
+				t.Error("rejected swap should keep waiting for confirmations")
+			}
+		})
+	}
+}
+
+func TestZeroConfDoubleSpend(t *testing.T) {
+	ltc := zeroConfBackend()
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(zeroConfStore(t, 3)), withBackend("LTC", ltc))
+	addZeroConfTrade(t, coord)
+	ctx := context.Background()
+
+	coord.UpdateConfirmations(ctx, "t1")
+	time.Sleep(30 * time.Millisecond)
+	coord.UpdateConfirmations(ctx, "t1")
+	if !coord.swaps["t1"].Swap.FundingZeroConf {
+		t.Fatal("funding not accepted")
+	}
+
+	// A conflicting spend of the funding input shows up
+	ltc.mu.Lock()
+	ltc.history[zcPrevAddr] = []backend.Transaction{
+		{TxID: zcFundingID, Inputs: []backend.TxInput{{TxID: zcPrevTxID, Vout: 1}}},
+		{TxID: "conflict", Inputs: []backend.TxInput{{TxID: zcPrevTxID, Vout: 1}}},
+	}
+	ltc.mu.Unlock()
+
+	// Accepted funding stays monitored after the swap proceeded
+	coord.UpdateConfirmations(ctx, "t1")
+	st := zeroConfStatus(t, coord)
+	if st.Decision != EventZeroConfDoubleSpend || !strings.Contains(st.Reason, "conflict") {
+		t.Errorf("status = %+v, want double spend", st)
+	}
+	if coord.swaps["t1"].Swap.FundingZeroConf {
+		t.Error("zero-conf acceptance not revoked")
+	}
+}
+
+func TestZeroConfAcceptanceRestored(t *testing.T) {
+	store, ltc := zeroConfStore(t, 3), zeroConfBackend()
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("LTC", ltc))
+	addZeroConfTrade(t, coord)
+	ctx := context.Background()
+
+	coord.UpdateConfirmations(ctx, "t1")
+	time.Sleep(30 * time.Millisecond)
+	coord.UpdateConfirmations(ctx, "t1")
+	if !coord.swaps["t1"].Swap.FundingZeroConf {
+		t.Fatal("funding not accepted")
+	}
+	coord.mu.Lock()
+	coord.swaps["t1"].Swap.SecretHash = make([]byte, 32) // Recovered as an HTLC swap
+	if err := coord.saveSwapState("t1"); err != nil {
+		t.Fatalf("saveSwapState() error = %v", err)
+	}
+	coord.mu.Unlock()
+
+	// A restarted node keeps relying on the accepted funding
+	restarted := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(store), withBackend("LTC", ltc))
+	if err := restarted.SetZeroConfPolicy(coord.zeroConf); err != nil {
+		t.Fatalf("SetZeroConfPolicy() error = %v", err)
+	}
+	if err := restarted.LoadPendingSwaps(ctx); err != nil {
+		t.Fatalf("LoadPendingSwaps() error = %v", err)
+	}
+	if s := restarted.swaps["t1"].Swap; s.State != StateFunded || !s.FundingZeroConf {
+		t.Fatalf("restored swap state = %s, zero-conf = %v, want funded on zero-conf", s.State, s.FundingZeroConf)
+	}
+	if st := zeroConfStatus(t, restarted); st.Decision != EventZeroConfAccepted || st.FeeRate != 10 || st.FirstSeenAt == 0 {
+		t.Errorf("restored status = %+v, want accepted", st)
+	}
+
+	// and keeps watching it for conflicting spends
+	ltc.mu.Lock()
+	ltc.history[zcPrevAddr] = []backend.Transaction{
+		{TxID: zcFundingID, Inputs: []backend.TxInput{{TxID: zcPrevTxID, Vout: 1}}},
+		{TxID: "conflict", Inputs: []backend.TxInput{{TxID: zcPrevTxID, Vout: 1}}},
+	}
+	ltc.mu.Unlock()
+	restarted.UpdateConfirmations(ctx, "t1")
+	if st := zeroConfStatus(t, restarted); st.Decision != EventZeroConfDoubleSpend {
+		t.Errorf("status = %+v, want double spend", st)
+	}
+	if a, _ := store.GetZeroConfAcceptance("t1"); a != nil {
+		t.Errorf("revoked acceptance still stored: %+v", a)
+	}
+}
+
+func TestZeroConfDisabled(t *testing.T) {
+	coord := newTestCoordinator(t, withNetwork(chain.Mainnet), withStore(zeroConfStore(t, 3)), withBackend("LTC", zeroConfBackend()))
+	addZeroConfTrade(t, coord)
+	coord.SetZeroConfPolicy(ZeroConfPolicy{})
+
+	coord.UpdateConfirmations(context.Background(), "t1")
+	if st := zeroConfStatus(t, coord); st.Decision != "" {
+		t.Errorf("status = %+v, want no decision", st)
+	}
+}
@@ -0,0 +1,379 @@
+// Package swap - Cross-group discrete log equality proofs.
+//
+// A Monero swap encrypts BTC signatures under a secp256k1 point T = t*G
+// whose secret t is a party's ed25519 spend key share, public as
+// T' = t*G'. ProveDLEQ shows both points have the same t without revealing
+// it: t (below 2^252, so valid on both curves) is committed bit by bit on
+// each curve with Pedersen commitments, and a two-member ring signature per
+// bit shows the two commitments open to the same bit. The blindings of each
+// curve sum to zero, so the commitments sum to T and T'.
+package swap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// dleqBits is the number of committed bits; secrets must be below 2^252.
+const dleqBits = 252
+
+var (
+	dleqGeneratorTag = []byte("klingdex/dleq/generator")
+	dleqChallengeTag = []byte("klingdex/dleq/challenge")
+)
+
+// ErrInvalidDLEQProof is returned when a cross-group proof doesn't verify.
+var ErrInvalidDLEQProof = errors.New("invalid cross-group DLEQ proof")
+
+// DLEQBitProof commits to one bit of the secret on both curves and proves
+// the commitments hold the same bit.
+type DLEQBitProof struct {
+	C   [33]byte    // secp256k1 commitment, compressed
+	CEd [32]byte    // ed25519 commitment
+	E0  [32]byte    // ring challenge, big-endian, below 2^250
+	Z   [2][32]byte // secp256k1 responses, big-endian
+	ZEd [2][32]byte // ed25519 responses, little-endian
+}
+
+// DLEQProof proves a secp256k1 point and an ed25519 point share a secret.
+type DLEQProof struct {
+	Bits [dleqBits]DLEQBitProof
+}
+
+// dleqGenerators are the second generators of the commitments, with unknown
+// discrete logs: hashed to each curve by try-and-increment.
+var dleqGenerators = sync.OnceValues(func() (*btcec.PublicKey, *edwards25519.Point) {
+	var h *btcec.PublicKey
+	for i := uint32(0); h == nil; i++ {
+		x := dleqHash(dleqGeneratorTag, []byte("secp256k1"), binary.BigEndian.AppendUint32(nil, i))
+		h, _ = btcec.ParsePubKey(append([]byte{0x02}, x[:]...))
+	}
+	var hEd *edwards25519.Point
+	for i := uint32(0); hEd == nil; i++ {
+		y := dleqHash(dleqGeneratorTag, []byte("ed25519"), binary.BigEndian.AppendUint32(nil, i))
+		if p, err := new(edwards25519.Point).SetBytes(y[:]); err == nil {
+			// Clear the cofactor to land in the prime-order subgroup
+			if p.MultByCofactor(p); p.Equal(edwards25519.NewIdentityPoint()) != 1 {
+				hEd = p
+			}
+		}
+	}
+	return h, hEd
+})
+
+// ProveDLEQ proves that secret*G on secp256k1 and secret*G' on ed25519 share
+// the secret, an ed25519 scalar below 2^252. It returns the proof and both
+// points.
+func ProveDLEQ(secret *edwards25519.Scalar) (*DLEQProof, *btcec.PublicKey, *edwards25519.Point, error) {
+	le := secret.Bytes()
+	if le[31]&0xf0 != 0 {
+		return nil, nil, nil, fmt.Errorf("secret must be below 2^252")
+	}
+	h, hEd := dleqGenerators()
+	var H btcec.JacobianPoint
+	h.AsJacobian(&H)
+
+	proof := &DLEQProof{}
+	var rSum btcec.ModNScalar
+	sSum := edwards25519.NewScalar()
+	for i := 0; i < dleqBits; i++ {
+		bit := int(le[i/8]>>(i%8)) & 1
+
+		// Blindings of each curve sum to zero over all bits
+		var r btcec.ModNScalar
+		s := edwards25519.NewScalar()
+		if i < dleqBits-1 {
+			r = randomSecpScalar()
+			s = randomEdScalar()
+			rSum.Add(&r)
+			sSum.Add(sSum, s)
+		} else {
+			r.Set(&rSum).Negate()
+			s.Negate(sSum)
+		}
+
+		p := &proof.Bits[i]
+		C := secpMulAdd(&r, &H, bit, i)
+		copy(p.C[:], secpBytes(&C))
+		CEd := new(edwards25519.Point).ScalarMult(s, hEd)
+		if bit == 1 {
+			CEd.Add(CEd, new(edwards25519.Point).ScalarBaseMult(edPowerOfTwo(i)))
+		}
+		copy(p.CEd[:], CEd.Bytes())
+
+		// Ring of two: the commitments open to 0 (member 0) or 2^i (member 1)
+		members, membersEd := dleqMembers(&C, CEd, i)
+		a := randomSecpScalar()
+		aEd := randomEdScalar()
+		var R btcec.JacobianPoint
+		btcec.ScalarMultNonConst(&a, &H, &R)
+		REd := new(edwards25519.Point).ScalarMult(aEd, hEd)
+
+		var e [2][32]byte
+		other := 1 - bit
+		e[other] = dleqChallenge(i, p, &R, REd)
+
+		// Simulate the other member
+		z := randomSecpScalar()
+		zEd := randomEdScalar()
+		z.PutBytesUnchecked(p.Z[other][:])
+		copy(p.ZEd[other][:], zEd.Bytes())
+		R, REd = dleqNonces(&H, hEd, &z, zEd, &e[other], &members[other], membersEd[other])
+		e[bit] = dleqChallenge(i, p, &R, REd)
+
+		// Close the ring with the real opening
+		eS := secpScalar(e[bit])
+		eEd := edScalar(e[bit])
+		a.Add(new(btcec.ModNScalar).Mul2(&eS, &r))
+		a.PutBytesUnchecked(p.Z[bit][:])
+		copy(p.ZEd[bit][:], aEd.MultiplyAdd(eEd, s, aEd).Bytes())
+		p.E0 = e[0]
+	}
+
+	var T btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(ptrSecpScalar(secpScalarFromEd(secret)), &T)
+	T.ToAffine()
+	return proof, btcec.NewPublicKey(&T.X, &T.Y), new(edwards25519.Point).ScalarBaseMult(secret), nil
+}
+
+// Verify checks that point (secp256k1) and pointEd (ed25519) share a secret.
+func (proof *DLEQProof) Verify(point *btcec.PublicKey, pointEd *edwards25519.Point) error {
+	h, hEd := dleqGenerators()
+	var H btcec.JacobianPoint
+	h.AsJacobian(&H)
+
+	var sum btcec.JacobianPoint
+	sumEd := edwards25519.NewIdentityPoint()
+	for i := range proof.Bits {
+		p := &proof.Bits[i]
+		c, err := btcec.ParsePubKey(p.C[:])
+		if err != nil {
+			return fmt.Errorf("%w: bit %d commitment: %v", ErrInvalidDLEQProof, i, err)
+		}
+		var C btcec.JacobianPoint
+		c.AsJacobian(&C)
+		CEd, err := new(edwards25519.Point).SetBytes(p.CEd[:])
+		if err != nil || !torsionFree(CEd) {
+			return fmt.Errorf("%w: bit %d ed25519 commitment", ErrInvalidDLEQProof, i)
+		}
+		if p.E0[0]&0xfc != 0 {
+			return fmt.Errorf("%w: bit %d challenge out of range", ErrInvalidDLEQProof, i)
+		}
+
+		members, membersEd := dleqMembers(&C, CEd, i)
+		e := p.E0
+		for j := 0; j < 2; j++ {
+			var z btcec.ModNScalar
+			if z.SetBytes(&p.Z[j]) != 0 {
+				return fmt.Errorf("%w: bit %d response overflows", ErrInvalidDLEQProof, i)
+			}
+			zEd, err := edwards25519.NewScalar().SetCanonicalBytes(p.ZEd[j][:])
+			if err != nil {
+				return fmt.Errorf("%w: bit %d ed25519 response", ErrInvalidDLEQProof, i)
+			}
+			R, REd := dleqNonces(&H, hEd, &z, zEd, &e, &members[j], membersEd[j])
+			e = dleqChallenge(i, p, &R, REd)
+		}
+		if e != p.E0 {
+			return fmt.Errorf("%w: bit %d ring doesn't close", ErrInvalidDLEQProof, i)
+		}
+
+		btcec.AddNonConst(&sum, &C, &sum)
+		sumEd.Add(sumEd, CEd)
+	}
+
+	sum.ToAffine()
+	if !btcec.NewPublicKey(&sum.X, &sum.Y).IsEqual(point) {
+		return fmt.Errorf("%w: commitments don't sum to the secp256k1 point", ErrInvalidDLEQProof)
+	}
+	if sumEd.Equal(pointEd) != 1 {
+		return fmt.Errorf("%w: commitments don't sum to the ed25519 point", ErrInvalidDLEQProof)
+	}
+	return nil
+}
+
+// Serialize returns the proof's bits back to back.
+func (proof *DLEQProof) Serialize() []byte {
+	var buf bytes.Buffer
+	for i := range proof.Bits {
+		p := &proof.Bits[i]
+		buf.Write(p.C[:])
+		buf.Write(p.CEd[:])
+		buf.Write(p.E0[:])
+		buf.Write(p.Z[0][:])
+		buf.Write(p.Z[1][:])
+		buf.Write(p.ZEd[0][:])
+		buf.Write(p.ZEd[1][:])
+	}
+	return buf.Bytes()
+}
+
+// ParseDLEQProof parses a serialized proof.
+func ParseDLEQProof(b []byte) (*DLEQProof, error) {
+	const bitSize = 33 + 32*6
+	if len(b) != dleqBits*bitSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidDLEQProof, len(b))
+	}
+	proof := &DLEQProof{}
+	r := bytes.NewReader(b)
+	for i := range proof.Bits {
+		p := &proof.Bits[i]
+		for _, f := range [][]byte{p.C[:], p.CEd[:], p.E0[:], p.Z[0][:], p.Z[1][:], p.ZEd[0][:], p.ZEd[1][:]} {
+			r.Read(f)
+		}
+	}
+	return proof, nil
+}
+
+// dleqMembers returns the ring members of bit i: the commitments minus 0
+// and minus 2^i.
+func dleqMembers(C *btcec.JacobianPoint, CEd *edwards25519.Point, i int) ([2]btcec.JacobianPoint, [2]*edwards25519.Point) {
+	var members [2]btcec.JacobianPoint
+	members[0] = *C
+	pow := secpPowerOfTwo(i)
+	pow.Negate()
+	var negPowG btcec.JacobianPoint
+	btcec.ScalarBaseMultNonConst(&pow, &negPowG)
+	btcec.AddNonConst(C, &negPowG, &members[1])
+
+	powEd := new(edwards25519.Point).ScalarBaseMult(edPowerOfTwo(i))
+	return members, [2]*edwards25519.Point{CEd, new(edwards25519.Point).Subtract(CEd, powEd)}
+}
+
+// dleqNonces returns z*H - e*P on both curves.
+func dleqNonces(H *btcec.JacobianPoint, hEd *edwards25519.Point, z *btcec.ModNScalar, zEd *edwards25519.Scalar,
+	e *[32]byte, P *btcec.JacobianPoint, PEd *edwards25519.Point) (btcec.JacobianPoint, *edwards25519.Point) {
+	eS := secpScalar(*e)
+	eS.Negate()
+	var zH, eP, R btcec.JacobianPoint
+	btcec.ScalarMultNonConst(z, H, &zH)
+	btcec.ScalarMultNonConst(&eS, P, &eP)
+	btcec.AddNonConst(&zH, &eP, &R)
+
+	eEd := edScalar(*e)
+	REd := new(edwards25519.Point).ScalarMult(zEd, hEd)
+	REd.Subtract(REd, new(edwards25519.Point).ScalarMult(eEd, PEd))
+	return R, REd
+}
+
+// dleqChallenge hashes a ring step to a challenge below 2^250, a valid
+// scalar on both curves.
+func dleqChallenge(i int, p *DLEQBitProof, R *btcec.JacobianPoint, REd *edwards25519.Point) [32]byte {
+	e := dleqHash(dleqChallengeTag, binary.BigEndian.AppendUint16(nil, uint16(i)), p.C[:], p.CEd[:], secpBytes(R), REd.Bytes())
+	e[0] &= 0x03
+	return e
+}
+
+func dleqHash(tag []byte, parts ...[]byte) [32]byte {
+	h := sha256.New()
+	h.Write(tag)
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return [32]byte(h.Sum(nil))
+}
+
+// secpMulAdd returns r*H, plus 2^i*G when bit is set.
+func secpMulAdd(r *btcec.ModNScalar, H *btcec.JacobianPoint, bit, i int) btcec.JacobianPoint {
+	var C btcec.JacobianPoint
+	btcec.ScalarMultNonConst(r, H, &C)
+	if bit == 1 {
+		pow := secpPowerOfTwo(i)
+		var powG btcec.JacobianPoint
+		btcec.ScalarBaseMultNonConst(&pow, &powG)
+		btcec.AddNonConst(&C, &powG, &C)
+	}
+	return C
+}
+
+func secpBytes(p *btcec.JacobianPoint) []byte {
+	if isInfinity(p) {
+		return make([]byte, 33)
+	}
+	a := *p
+	a.ToAffine()
+	return btcec.NewPublicKey(&a.X, &a.Y).SerializeCompressed()
+}
+
+func secpPowerOfTwo(i int) btcec.ModNScalar {
+	var b [32]byte
+	b[31-i/8] = 1 << (i % 8)
+	var s btcec.ModNScalar
+	s.SetBytes(&b)
+	return s
+}
+
+func edPowerOfTwo(i int) *edwards25519.Scalar {
+	var b [32]byte
+	b[i/8] = 1 << (i % 8)
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(b[:])
+	return s
+}
+
+// secpScalar reads a big-endian challenge as a secp256k1 scalar.
+func secpScalar(b [32]byte) btcec.ModNScalar {
+	var s btcec.ModNScalar
+	s.SetBytes(&b)
+	return s
+}
+
+// edScalar reads a big-endian challenge below 2^250 as an ed25519 scalar.
+func edScalar(b [32]byte) *edwards25519.Scalar {
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(reverse32(b[:]))
+	return s
+}
+
+// secpScalarFromEd converts an ed25519 scalar (below both group orders) to
+// a secp256k1 scalar of the same integer.
+func secpScalarFromEd(s *edwards25519.Scalar) btcec.ModNScalar {
+	return secpScalar([32]byte(reverse32(s.Bytes())))
+}
+
+func ptrSecpScalar(s btcec.ModNScalar) *btcec.ModNScalar { return &s }
+
+func reverse32(b []byte) []byte {
+	out := make([]byte, 32)
+	for i := range out {
+		out[i] = b[31-i]
+	}
+	return out
+}
+
+func randomSecpScalar() btcec.ModNScalar {
+	var b [32]byte
+	for {
+		if _, err := rand.Read(b[:]); err != nil {
+			panic(err)
+		}
+		var s btcec.ModNScalar
+		if s.SetBytes(&b) == 0 && !s.IsZero() {
+			return s
+		}
+	}
+}
+
+func randomEdScalar() *edwards25519.Scalar {
+	var b [64]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	s, _ := edwards25519.NewScalar().SetUniformBytes(b[:])
+	return s
+}
+
+// torsionFree reports whether an ed25519 point is in the prime-order
+// subgroup: clearing its cofactor and dividing by 8 gives it back.
+func torsionFree(p *edwards25519.Point) bool {
+	eight, _ := edwards25519.NewScalar().SetCanonicalBytes(append([]byte{8}, make([]byte, 31)...))
+	inv := edwards25519.NewScalar().Invert(eight)
+	q := new(edwards25519.Point).MultByCofactor(p)
+	return q.ScalarMult(inv, q).Equal(p) == 1
+}
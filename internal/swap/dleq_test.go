@@ -0,0 +1,56 @@
+package swap
+
+import (
+	"errors"
+	"testing"
+
+	"filippo.io/edwards25519"
+)
+
+func TestDLEQProof(t *testing.T) {
+	secrets, err := NewXMRSecrets()
+	if err != nil {
+		t.Fatalf("NewXMRSecrets() error = %v", err)
+	}
+	share := secrets.Share()
+	if err := share.Proof.Verify(share.Adaptor, share.SpendKey); err != nil {
+		t.Fatalf("Verify() error = %v", err)
+	}
+
+	parsed, err := ParseDLEQProof(share.Proof.Serialize())
+	if err != nil {
+		t.Fatalf("ParseDLEQProof() error = %v", err)
+	}
+	if err := parsed.Verify(share.Adaptor, share.SpendKey); err != nil {
+		t.Errorf("parsed proof doesn't verify: %v", err)
+	}
+
+	other, _ := NewXMRSecrets()
+	if err := share.Proof.Verify(other.Share().Adaptor, share.SpendKey); !errors.Is(err, ErrInvalidDLEQProof) {
+		t.Errorf("verified another secp256k1 point: %v", err)
+	}
+	if err := share.Proof.Verify(share.Adaptor, other.Share().SpendKey); !errors.Is(err, ErrInvalidDLEQProof) {
+		t.Errorf("verified another ed25519 point: %v", err)
+	}
+
+	tampered := *share.Proof
+	tampered.Bits[17].Z[0][31] ^= 1
+	if err := tampered.Verify(share.Adaptor, share.SpendKey); !errors.Is(err, ErrInvalidDLEQProof) {
+		t.Errorf("verified a tampered proof: %v", err)
+	}
+	if _, err := ParseDLEQProof(share.Proof.Serialize()[1:]); err == nil {
+		t.Error("parsed a truncated proof")
+	}
+}
+
+func TestProveDLEQRejectsLargeSecret(t *testing.T) {
+	b := make([]byte, 32)
+	b[31] = 0x10 // 2^252
+	s, err := edwards25519.NewScalar().SetCanonicalBytes(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, err := ProveDLEQ(s); err == nil {
+		t.Error("proved a secret of 2^252")
+	}
+}
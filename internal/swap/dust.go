@@ -0,0 +1,94 @@
+// Package swap - Dust policy for swap transaction builders.
+//
+// Every builder checks its outputs against the chain's dust threshold at the
+// current fee rate (see config.ChainDustConfig). The payment output (swap
+// escrow, claim or refund destination) is never folded: building fails with
+// ErrDustOutput. Optional outputs (change, DAO fee) are folded into the miner
+// fee unless the policy rejects them.
+package swap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+// ErrDustOutput is returned when an output would be uneconomical to spend.
+var ErrDustOutput = errors.New("output below dust threshold")
+
+// Foldable output kinds.
+const (
+	DustOutputChange = "change"
+	DustOutputDAOFee = "dao_fee"
+)
+
+// DustPolicy controls how a builder handles optional outputs below the dust
+// threshold. The zero value folds them into the fee.
+type DustPolicy struct {
+	// Reject fails the build with ErrDustOutput instead of folding.
+	Reject bool
+
+	// Report, if set, receives the outputs folded into the fee.
+	Report *DustReport
+}
+
+// FoldedOutput is an output that was dropped and added to the fee.
+type FoldedOutput struct {
+	Kind   string `json:"kind"`
+	Amount uint64 `json:"amount"`
+}
+
+// DustReport describes the dust handling of a built transaction.
+type DustReport struct {
+	Threshold uint64         `json:"threshold"`
+	Folded    []FoldedOutput `json:"folded,omitempty"`
+	FeeAdded  uint64         `json:"fee_added"`
+}
+
+// DustThreshold returns the smallest output worth creating on a chain at the
+// given fee rate (sat/vB).
+func DustThreshold(symbol string, feeRate uint64) uint64 {
+	return config.GetChainDust(symbol).Threshold(feeRate)
+}
+
+// dustCheck applies a DustPolicy to the outputs of one transaction.
+type dustCheck struct {
+	policy    DustPolicy
+	threshold uint64
+}
+
+func newDustCheck(symbol string, feeRate uint64, policy DustPolicy) *dustCheck {
+	d := &dustCheck{policy: policy, threshold: DustThreshold(symbol, feeRate)}
+	if policy.Report != nil {
+		*policy.Report = DustReport{Threshold: d.threshold}
+	}
+	return d
+}
+
+// payment fails if the payment output is below the threshold.
+func (d *dustCheck) payment(name string, amount uint64) error {
+	if amount < d.threshold {
+		return fmt.Errorf("%w: %s %d < %d", ErrDustOutput, name, amount, d.threshold)
+	}
+	return nil
+}
+
+// keep reports whether an optional output should be created. Non-zero
+// amounts below the threshold are folded into the fee, or rejected.
+func (d *dustCheck) keep(kind string, amount uint64) (bool, error) {
+	if amount == 0 {
+		return false, nil
+	}
+	if amount >= d.threshold {
+		return true, nil
+	}
+	if d.policy.Reject {
+		return false, fmt.Errorf("%w: %s %d < %d", ErrDustOutput, kind, amount, d.threshold)
+	}
+	if r := d.policy.Report; r != nil {
+		r.Folded = append(r.Folded, FoldedOutput{Kind: kind, Amount: amount})
+		r.FeeAdded += amount
+	}
+	return false, nil
+}
@@ -0,0 +1,134 @@
+package swap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+const testDustAddress = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+func TestDustThreshold(t *testing.T) {
+	if got := DustThreshold("BTC", 1); got != 546 {
+		t.Errorf("DustThreshold(BTC, 1) = %d, want 546", got)
+	}
+	if got := DustThreshold("BTC", 20); got != 68*20 {
+		t.Errorf("DustThreshold(BTC, 20) = %d, want %d", got, 68*20)
+	}
+}
+
+func TestBuildFundingTxFoldsDustChange(t *testing.T) {
+	// 10 + 58 + 43*3 = 197 vbytes at 10 sat/vB leaves 100 sats of change
+	params := &FundingTxParams{
+		Symbol:        "BTC",
+		Network:       chain.Testnet,
+		UTXOs:         []backend.UTXO{{TxID: "0000000000000000000000000000000000000000000000000000000000000001", Amount: 50000 + 5000 + 1970 + 100}},
+		ChangeAddress: testDustAddress,
+		SwapAddress:   testDustAddress,
+		SwapAmount:    50000,
+		DAOAddress:    testDustAddress,
+		DAOFee:        5000,
+		FeeRate:       10,
+	}
+	var report DustReport
+	params.Dust = DustPolicy{Report: &report}
+
+	tx, err := BuildFundingTx(params)
+	if err != nil {
+		t.Fatalf("BuildFundingTx() error = %v", err)
+	}
+	if len(tx.TxOut) != 2 {
+		t.Errorf("outputs = %d, want 2 (swap + dao)", len(tx.TxOut))
+	}
+	if len(report.Folded) != 1 || report.Folded[0].Kind != DustOutputChange || report.FeeAdded != 100 {
+		t.Errorf("report = %+v, want 100 sats of change folded", report)
+	}
+
+	params.Dust = DustPolicy{Reject: true}
+	if _, err := BuildFundingTx(params); !errors.Is(err, ErrDustOutput) {
+		t.Errorf("BuildFundingTx() with Reject error = %v, want ErrDustOutput", err)
+	}
+
+	params.Dust = DustPolicy{}
+	params.SwapAmount = 500
+	if _, err := BuildFundingTx(params); !errors.Is(err, ErrDustOutput) {
+		t.Errorf("BuildFundingTx() with dust swap output error = %v, want ErrDustOutput", err)
+	}
+}
+
+func TestBuildHTLCClaimTxFoldsDustDAOFee(t *testing.T) {
+	receiverPriv, _ := btcec.NewPrivateKey()
+	senderPriv, _ := btcec.NewPrivateKey()
+	secret, secretHash, _ := GenerateSecret()
+	htlcScript, err := BuildHTLCScript(secretHash, receiverPriv.PubKey().SerializeCompressed(),
+		senderPriv.PubKey().SerializeCompressed(), 144)
+	if err != nil {
+		t.Fatalf("BuildHTLCScript() error = %v", err)
+	}
+
+	var report DustReport
+	params := &HTLCClaimTxParams{
+		Symbol:        "BTC",
+		Network:       chain.Testnet,
+		FundingTxID:   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		FundingAmount: 100000,
+		HTLCScript:    htlcScript,
+		Secret:        secret,
+		DestAddress:   testDustAddress,
+		DAOAddress:    testDustAddress,
+		DAOFee:        600, // Below 68 * 20 sat/vB
+		FeeRate:       20,
+		PrivKey:       receiverPriv,
+		Dust:          DustPolicy{Report: &report},
+	}
+
+	tx, err := BuildHTLCClaimTx(params)
+	if err != nil {
+		t.Fatalf("BuildHTLCClaimTx() error = %v", err)
+	}
+	if len(tx.TxOut) != 1 {
+		t.Fatalf("outputs = %d, want 1 (DAO fee folded)", len(tx.TxOut))
+	}
+	fee := uint64(10+41+43+52) * params.FeeRate
+	if want := int64(params.FundingAmount - fee - params.DAOFee); tx.TxOut[0].Value != want {
+		t.Errorf("claim output = %d, want %d", tx.TxOut[0].Value, want)
+	}
+	if report.Threshold != 68*20 || report.FeeAdded != 600 {
+		t.Errorf("report = %+v, want 600 sats folded at threshold %d", report, 68*20)
+	}
+
+	params.Dust = DustPolicy{Reject: true}
+	if _, err := BuildHTLCClaimTx(params); !errors.Is(err, ErrDustOutput) {
+		t.Errorf("BuildHTLCClaimTx() with Reject error = %v, want ErrDustOutput", err)
+	}
+}
+
+func TestBuildHTLCRefundTxRejectsDustOutput(t *testing.T) {
+	senderPriv, _ := btcec.NewPrivateKey()
+	receiverPriv, _ := btcec.NewPrivateKey()
+	_, secretHash, _ := GenerateSecret()
+	htlcScript, err := BuildHTLCScript(secretHash, receiverPriv.PubKey().SerializeCompressed(),
+		senderPriv.PubKey().SerializeCompressed(), 144)
+	if err != nil {
+		t.Fatalf("BuildHTLCScript() error = %v", err)
+	}
+
+	// 138 vbytes at 10 sat/vB leaves 620 sats, below 68 * 10
+	_, err = BuildHTLCRefundTx(&HTLCRefundTxParams{
+		Symbol:        "BTC",
+		Network:       chain.Testnet,
+		FundingTxID:   "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+		FundingAmount: 2000,
+		HTLCScript:    htlcScript,
+		TimeoutBlocks: 144,
+		DestAddress:   testDustAddress,
+		FeeRate:       10,
+		PrivKey:       senderPriv,
+	})
+	if !errors.Is(err, ErrDustOutput) {
+		t.Errorf("BuildHTLCRefundTx() error = %v, want ErrDustOutput", err)
+	}
+}
@@ -0,0 +1,272 @@
+// Package swap - Swap event delivery.
+//
+// emitEvent runs with the coordinator lock held, often on goroutines that
+// are claiming, refunding or funding a swap, so it must never wait for a
+// subscriber. Each subscriber gets a bounded queue and a goroutine that
+// delivers its events in order; publishing only appends to the queues. When
+// a queue is full the subscriber's overflow policy decides which event is
+// lost, and the loss is counted in EventSubscribers.
+package swap
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// DefaultEventQueueSize is the queue length of subscribers that don't set one.
+const DefaultEventQueueSize = 256
+
+// OverflowPolicy decides what happens to an event for a full queue.
+type OverflowPolicy string
+
+// Overflow policies.
+const (
+	// OverflowDropOldest discards the oldest queued event (the default).
+	OverflowDropOldest OverflowPolicy = "drop_oldest"
+
+	// OverflowDropNewest discards the new event. With a queue of one it
+	// turns the subscriber into a "something happened" trigger.
+	OverflowDropNewest OverflowPolicy = "drop_newest"
+
+	// OverflowCoalesce replaces a queued event of the same trade and type
+	// with the new one, for subscribers that only need the latest state;
+	// without one it drops the oldest.
+	OverflowCoalesce OverflowPolicy = "coalesce"
+)
+
+// SubscriberOptions configures an event subscriber.
+type SubscriberOptions struct {
+	Name      string // Shown in EventSubscribers
+	QueueSize int    // Default DefaultEventQueueSize
+	Overflow  OverflowPolicy
+}
+
+// EventSubscriberStats describes a subscriber's queue.
+type EventSubscriberStats struct {
+	Name      string         `json:"name"`
+	Overflow  OverflowPolicy `json:"overflow"`
+	QueueSize int            `json:"queue_size"`
+	Queued    int            `json:"queued"`
+	HighWater int            `json:"high_water"` // Longest the queue has been
+	Delivered uint64         `json:"delivered"`
+	Dropped   uint64         `json:"dropped"`
+	Coalesced uint64         `json:"coalesced"`
+}
+
+// EventSubscription is a registered event handler.
+type EventSubscription struct {
+	bus     *eventBus
+	handler EventHandler
+	opts    SubscriberOptions
+
+	mu        sync.Mutex
+	queue     []SwapEvent
+	closed    bool
+	lagging   bool // Lost events since the queue was last empty
+	highWater int
+	delivered uint64
+	dropped   uint64
+	coalesced uint64
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// Close unsubscribes. Events already queued are still delivered.
+func (s *EventSubscription) Close() {
+	s.bus.remove(s)
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.done)
+	}
+	s.mu.Unlock()
+}
+
+// push queues an event without blocking. It returns true when the
+// subscriber starts losing events, which is reported once until its queue
+// drains.
+func (s *EventSubscription) push(event SwapEvent) (startedLagging bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return false
+	}
+
+	if len(s.queue) >= s.opts.QueueSize {
+		switch s.opts.Overflow {
+		case OverflowDropNewest:
+			s.dropped++
+			return s.markLagging()
+		case OverflowCoalesce:
+			for i := len(s.queue) - 1; i >= 0; i-- {
+				if s.queue[i].TradeID == event.TradeID && s.queue[i].EventType == event.EventType {
+					s.queue[i] = event
+					s.coalesced++
+					return false
+				}
+			}
+			fallthrough
+		default:
+			s.queue[0] = SwapEvent{}
+			s.queue = s.queue[1:]
+			s.dropped++
+			startedLagging = s.markLagging()
+		}
+	}
+	s.queue = append(s.queue, event)
+	if len(s.queue) > s.highWater {
+		s.highWater = len(s.queue)
+	}
+
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+	return startedLagging
+}
+
+// markLagging records a lost event. s.mu must be held.
+func (s *EventSubscription) markLagging() bool {
+	if s.lagging {
+		return false
+	}
+	s.lagging = true
+	return true
+}
+
+// run delivers queued events in order until the subscription or bus closes.
+func (s *EventSubscription) run() {
+	for {
+		s.mu.Lock()
+		if len(s.queue) == 0 {
+			s.lagging = false
+			s.mu.Unlock()
+			select {
+			case <-s.wake:
+				continue
+			case <-s.done:
+				return
+			case <-s.bus.done:
+				return
+			}
+		}
+		event := s.queue[0]
+		s.queue[0] = SwapEvent{}
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		s.deliver(event)
+	}
+}
+
+func (s *EventSubscription) deliver(event SwapEvent) {
+	defer func() {
+		if r := recover(); r != nil {
+			s.bus.log.Error("Swap event handler panicked", "subscriber", s.opts.Name, "event", event.EventType, "panic", fmt.Sprint(r))
+		}
+	}()
+	s.handler(event)
+
+	s.mu.Lock()
+	s.delivered++
+	s.mu.Unlock()
+}
+
+func (s *EventSubscription) stats() EventSubscriberStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return EventSubscriberStats{
+		Name:      s.opts.Name,
+		Overflow:  s.opts.Overflow,
+		QueueSize: s.opts.QueueSize,
+		Queued:    len(s.queue),
+		HighWater: s.highWater,
+		Delivered: s.delivered,
+		Dropped:   s.dropped,
+		Coalesced: s.coalesced,
+	}
+}
+
+// eventBus fans swap events out to the subscribers' queues.
+type eventBus struct {
+	mu   sync.RWMutex
+	subs []*EventSubscription
+	log  *logging.Logger
+
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func (b *eventBus) subscribe(handler EventHandler, opts SubscriberOptions) *EventSubscription {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = DefaultEventQueueSize
+	}
+	if opts.Overflow == "" {
+		opts.Overflow = OverflowDropOldest
+	}
+	if opts.Name == "" {
+		b.mu.RLock()
+		opts.Name = fmt.Sprintf("subscriber-%d", len(b.subs)+1)
+		b.mu.RUnlock()
+	}
+
+	s := &EventSubscription{
+		bus:     b,
+		handler: handler,
+		opts:    opts,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	b.mu.Lock()
+	b.subs = append(b.subs, s)
+	b.mu.Unlock()
+	go s.run()
+	return s
+}
+
+func (b *eventBus) remove(s *EventSubscription) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for i, sub := range b.subs {
+		if sub == s {
+			b.subs = append(b.subs[:i:i], b.subs[i+1:]...)
+			return
+		}
+	}
+}
+
+// publish queues event for every subscriber without blocking.
+func (b *eventBus) publish(event SwapEvent) {
+	select {
+	case <-b.done:
+		return
+	default:
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, s := range b.subs {
+		if s.push(event) {
+			b.log.Warn("Swap event subscriber is falling behind, dropping events",
+				"subscriber", s.opts.Name, "overflow", s.opts.Overflow, "queue_size", s.opts.QueueSize)
+		}
+	}
+}
+
+func (b *eventBus) stats() []EventSubscriberStats {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make([]EventSubscriberStats, 0, len(b.subs))
+	for _, s := range b.subs {
+		out = append(out, s.stats())
+	}
+	return out
+}
+
+// close stops publishing; subscribers exit once their queues are delivered.
+func (b *eventBus) close() {
+	b.closeOnce.Do(func() { close(b.done) })
+}
@@ -0,0 +1,213 @@
+package swap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+func newTestEventBus(t *testing.T) *eventBus {
+	b := &eventBus{log: logging.GetDefault().Component("swap"), done: make(chan struct{})}
+	t.Cleanup(b.close)
+	return b
+}
+
+// waitDelivered waits until sub has delivered n events.
+func waitDelivered(t *testing.T, sub *EventSubscription, n uint64) EventSubscriberStats {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		st := sub.stats()
+		if st.Delivered >= n {
+			return st
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("delivered %d events, want %d", st.Delivered, n)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestEventBusOrder(t *testing.T) {
+	b := newTestEventBus(t)
+
+	var mu sync.Mutex
+	var got []string
+	sub := b.subscribe(func(e SwapEvent) {
+		mu.Lock()
+		got = append(got, e.TradeID)
+		mu.Unlock()
+	}, SubscriberOptions{})
+
+	for i := 0; i < 100; i++ {
+		b.publish(SwapEvent{TradeID: fmt.Sprint(i), EventType: "state_change"})
+	}
+	st := waitDelivered(t, sub, 100)
+
+	mu.Lock()
+	defer mu.Unlock()
+	for i, id := range got {
+		if id != fmt.Sprint(i) {
+			t.Fatalf("event %d delivered as %s, want in order", i, id)
+		}
+	}
+	if st.Name != "subscriber-1" || st.QueueSize != DefaultEventQueueSize || st.Dropped != 0 {
+		t.Errorf("stats = %+v", st)
+	}
+}
+
+func TestEventBusSlowSubscriber(t *testing.T) {
+	tests := []struct {
+		overflow      OverflowPolicy
+		events        []SwapEvent
+		wantDelivered []string
+		wantDropped   uint64
+		wantCoalesced uint64
+	}{
+		{
+			overflow:      OverflowDropOldest,
+			events:        []SwapEvent{{TradeID: "a"}, {TradeID: "b"}, {TradeID: "c"}, {TradeID: "d"}},
+			wantDelivered: []string{"block", "c", "d"},
+			wantDropped:   2,
+		},
+		{
+			overflow:      OverflowDropNewest,
+			events:        []SwapEvent{{TradeID: "a"}, {TradeID: "b"}, {TradeID: "c"}, {TradeID: "d"}},
+			wantDelivered: []string{"block", "a", "b"},
+			wantDropped:   2,
+		},
+		{
+			overflow: OverflowCoalesce,
+			events: []SwapEvent{
+				{TradeID: "a", EventType: "deadlines", Data: 1},
+				{TradeID: "b", EventType: "deadlines", Data: 1},
+				{TradeID: "a", EventType: "deadlines", Data: 2},
+				{TradeID: "c", EventType: "deadlines", Data: 1},
+			},
+			// a is replaced in place, then c pushes out the oldest
+			wantDelivered: []string{"block", "b", "c"},
+			wantDropped:   1,
+			wantCoalesced: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.overflow), func(t *testing.T) {
+			b := newTestEventBus(t)
+			release := make(chan struct{})
+			started := make(chan struct{})
+
+			var mu sync.Mutex
+			var got []string
+			sub := b.subscribe(func(e SwapEvent) {
+				if e.TradeID == "block" {
+					close(started)
+					<-release
+				}
+				mu.Lock()
+				got = append(got, e.TradeID)
+				mu.Unlock()
+			}, SubscriberOptions{Name: "slow", QueueSize: 2, Overflow: tt.overflow})
+
+			b.publish(SwapEvent{TradeID: "block"})
+			<-started
+
+			// The blocked handler must not hold up publishing
+			published := make(chan struct{})
+			go func() {
+				for _, e := range tt.events {
+					b.publish(e)
+				}
+				close(published)
+			}()
+			select {
+			case <-published:
+			case <-time.After(time.Second):
+				t.Fatal("publish blocked on a slow subscriber")
+			}
+
+			st := sub.stats()
+			if st.Queued != 2 || st.HighWater != 2 || st.Dropped != tt.wantDropped || st.Coalesced != tt.wantCoalesced {
+				t.Errorf("stats = %+v", st)
+			}
+
+			close(release)
+			waitDelivered(t, sub, uint64(len(tt.wantDelivered)))
+			mu.Lock()
+			defer mu.Unlock()
+			if fmt.Sprint(got) != fmt.Sprint(tt.wantDelivered) {
+				t.Errorf("delivered %v, want %v", got, tt.wantDelivered)
+			}
+		})
+	}
+}
+
+func TestEventBusPanicAndClose(t *testing.T) {
+	b := newTestEventBus(t)
+
+	events := make(chan SwapEvent, 10)
+	sub := b.subscribe(func(e SwapEvent) {
+		if e.EventType == "boom" {
+			panic("handler bug")
+		}
+		events <- e
+	}, SubscriberOptions{Name: "panicky"})
+	other := b.subscribe(func(SwapEvent) {}, SubscriberOptions{Name: "other"})
+
+	b.publish(SwapEvent{EventType: "boom"})
+	b.publish(SwapEvent{EventType: "after"})
+	select {
+	case e := <-events:
+		if e.EventType != "after" {
+			t.Errorf("got %s, want the event after the panic", e.EventType)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("subscriber stopped after a handler panic")
+	}
+
+	sub.Close()
+	sub.Close()
+	if stats := b.stats(); len(stats) != 1 || stats[0].Name != "other" {
+		t.Errorf("stats after Close = %+v", stats)
+	}
+	b.publish(SwapEvent{EventType: "closed"})
+	waitDelivered(t, other, 3)
+	select {
+	case e := <-events:
+		t.Errorf("closed subscription received %s", e.EventType)
+	default:
+	}
+
+	b.close()
+	b.publish(SwapEvent{EventType: "late"})
+	if st := other.stats(); st.Queued != 0 {
+		t.Errorf("closed bus queued %d events", st.Queued)
+	}
+}
+
+func TestCoordinatorSubscribe(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{})
+	defer coord.Close()
+
+	events := make(chan SwapEvent, 1)
+	coord.Subscribe(func(e SwapEvent) { events <- e }, SubscriberOptions{Name: "test", QueueSize: 4})
+
+	coord.mu.Lock()
+	coord.emitEvent("t1", "state_change", nil)
+	coord.mu.Unlock()
+	select {
+	case e := <-events:
+		if e.TradeID != "t1" || e.Timestamp.IsZero() {
+			t.Errorf("event = %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+
+	subs := coord.EventSubscribers()
+	if len(subs) != 1 || subs[0].Name != "test" || subs[0].QueueSize != 4 {
+		t.Errorf("EventSubscribers() = %+v", subs)
+	}
+}
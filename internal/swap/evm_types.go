@@ -9,6 +9,7 @@ import (
 	"sync"
 
 	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
 	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/Klingon-tech/klingdex/internal/config"
 	"github.com/Klingon-tech/klingdex/internal/contracts/htlc"
@@ -48,8 +49,9 @@ type EVMHTLCSession struct {
 	chainID uint64
 	network chain.Network
 
-	// Client for contract interaction
-	client *htlc.Client
+	// Client for contract interaction, and the HTLC contract it's bound to
+	client   *htlc.Client
+	contract common.Address
 
 	// Keys
 	localPrivKey *ecdsa.PrivateKey
@@ -122,14 +124,25 @@ type EVMHTLCSwapData struct {
 	LocalPrivKey *ecdsa.PrivateKey
 	OfferChain   *ChainEVMHTLCData  // Only set if offer chain is EVM
 	RequestChain *ChainEVMHTLCData  // Only set if request chain is EVM
+
+	// Cancellations of our HTLC creates, by chain symbol
+	CreateCancels map[string]*EVMCreateCancellation
 }
 
 // =============================================================================
 // EVMHTLCSession Constructor
 // =============================================================================
 
-// NewEVMHTLCSession creates a new EVM HTLC session.
+// NewEVMHTLCSession creates a new EVM HTLC session on the chain's current
+// HTLC contract.
 func NewEVMHTLCSession(symbol string, network chain.Network, rpcURL string) (*EVMHTLCSession, error) {
+	return NewEVMHTLCSessionAt(symbol, network, rpcURL, common.Address{})
+}
+
+// NewEVMHTLCSessionAt creates a new EVM HTLC session on the given HTLC
+// contract, the chain's current or a legacy one. The zero address selects
+// the current one.
+func NewEVMHTLCSessionAt(symbol string, network chain.Network, rpcURL string, contractAddr common.Address) (*EVMHTLCSession, error) {
 	// Validate chain is EVM
 	chainParams, ok := chain.Get(symbol, network)
 	if !ok {
@@ -140,9 +153,13 @@ func NewEVMHTLCSession(symbol string, network chain.Network, rpcURL string) (*EV
 	}
 
 	// Get contract address
-	contractAddr := config.GetHTLCContract(chainParams.ChainID)
 	if contractAddr == (common.Address{}) {
-		return nil, fmt.Errorf("HTLC contract not deployed on %s (chainID %d)", symbol, chainParams.ChainID)
+		contractAddr = config.GetHTLCContract(chainParams.ChainID)
+		if contractAddr == (common.Address{}) {
+			return nil, fmt.Errorf("HTLC contract not deployed on %s (chainID %d)", symbol, chainParams.ChainID)
+		}
+	} else if !config.IsKnownHTLCContract(chainParams.ChainID, contractAddr) {
+		return nil, fmt.Errorf("%w %s on %s (chainID %d)", ErrUnknownEVMContract, contractAddr.Hex(), symbol, chainParams.ChainID)
 	}
 
 	// Create client
@@ -152,11 +169,12 @@ func NewEVMHTLCSession(symbol string, network chain.Network, rpcURL string) (*EV
 	}
 
 	return &EVMHTLCSession{
-		symbol:  symbol,
-		chainID: chainParams.ChainID,
-		network: network,
-		client:  client,
-		state:   EVMSwapStateEmpty,
+		symbol:   symbol,
+		chainID:  chainParams.ChainID,
+		network:  network,
+		client:   client,
+		contract: contractAddr,
+		state:    EVMSwapStateEmpty,
 	}, nil
 }
 
@@ -223,6 +241,17 @@ func (s *EVMHTLCSession) SetSecret(secret [32]byte) error {
 	return nil
 }
 
+// Zeroize overwrites the local private key and the secret once the session
+// is no longer needed. It reports whether either was still held.
+func (s *EVMHTLCSession) Zeroize() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := zeroizeECDSAKey(s.localPrivKey)
+	held = zeroizeBytes(s.secret[:]) || held
+	s.hasSecret = false
+	return held
+}
+
 // GetSecret returns the secret if available.
 func (s *EVMHTLCSession) GetSecret() [32]byte {
 	s.mu.RLock()
@@ -284,6 +313,26 @@ func (s *EVMHTLCSession) SetSwapParams(swapID [32]byte, receiver common.Address,
 	s.timelock = timelock
 }
 
+// GetTimelock returns the refund timelock as a Unix timestamp (0 if not set).
+func (s *EVMHTLCSession) GetTimelock() int64 {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.timelock == nil || !s.timelock.IsInt64() {
+		return 0
+	}
+	return s.timelock.Int64()
+}
+
+// GetAmount returns the amount to lock (nil if not set).
+func (s *EVMHTLCSession) GetAmount() *big.Int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.amount == nil {
+		return nil
+	}
+	return new(big.Int).Set(s.amount)
+}
+
 // GetSwapID returns the swap ID.
 func (s *EVMHTLCSession) GetSwapID() [32]byte {
 	s.mu.RLock()
@@ -291,6 +340,49 @@ func (s *EVMHTLCSession) GetSwapID() [32]byte {
 	return s.swapID
 }
 
+// GetCreateTxHash returns the hash of our create transaction, zero if none.
+func (s *EVMHTLCSession) GetCreateTxHash() common.Hash {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.createTxHash
+}
+
+// ContractAddress returns the HTLC contract the session uses.
+func (s *EVMHTLCSession) ContractAddress() common.Address {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.contract
+}
+
+// txReplacer returns the session's client as a transaction replacer, nil
+// once closed.
+func (s *EVMHTLCSession) txReplacer() EVMTxReplacer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.client == nil {
+		return nil
+	}
+	return s.client
+}
+
+// swapReader returns the session's client as a contract reader, nil once
+// closed.
+func (s *EVMHTLCSession) swapReader() EVMSwapReader {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.client == nil {
+		return nil
+	}
+	return s.client
+}
+
+// localKey returns the key our transactions are signed with.
+func (s *EVMHTLCSession) localKey() *ecdsa.PrivateKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.localPrivKey
+}
+
 // CreateSwapNative creates an HTLC with native token (ETH/BNB/etc).
 func (s *EVMHTLCSession) CreateSwapNative(ctx context.Context) (common.Hash, error) {
 	s.mu.Lock()
@@ -310,7 +402,8 @@ func (s *EVMHTLCSession) CreateSwapNative(ctx context.Context) (common.Hash, err
 	return tx.Hash(), nil
 }
 
-// CreateSwapERC20 creates an HTLC with ERC20 token.
+// CreateSwapERC20 creates an HTLC with ERC20 token. It first approves the
+// HTLC contract to pull the amount and waits for the approval to be mined.
 func (s *EVMHTLCSession) CreateSwapERC20(ctx context.Context) (common.Hash, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -322,6 +415,18 @@ func (s *EVMHTLCSession) CreateSwapERC20(ctx context.Context) (common.Hash, erro
 		return common.Hash{}, fmt.Errorf("token address not set for ERC20 swap")
 	}
 
+	approveTx, err := s.client.ApproveERC20(ctx, s.localPrivKey, s.tokenAddress, s.amount)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to approve token: %w", err)
+	}
+	receipt, err := s.client.WaitForTx(ctx, approveTx)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to wait for token approval: %w", err)
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return common.Hash{}, fmt.Errorf("token approval %s reverted", approveTx.Hash().Hex())
+	}
+
 	tx, err := s.client.CreateSwapERC20(ctx, s.localPrivKey, s.swapID, s.receiver, s.tokenAddress, s.amount, s.secretHash, s.timelock)
 	if err != nil {
 		return common.Hash{}, fmt.Errorf("failed to create ERC20 swap: %w", err)
@@ -373,6 +478,66 @@ func (s *EVMHTLCSession) Refund(ctx context.Context) (common.Hash, error) {
 	return tx.Hash(), nil
 }
 
+// AuthorizeClaim signs a claim authorization with the local key, letting
+// relayer submit our claim and keep relayerFee out of the amount.
+func (s *EVMHTLCSession) AuthorizeClaim(relayer common.Address, relayerFee *big.Int, deadline int64) (*htlc.ClaimAuthorization, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.localPrivKey == nil {
+		return nil, fmt.Errorf("local private key not set")
+	}
+
+	auth := &htlc.ClaimAuthorization{
+		SwapID:     s.swapID,
+		Relayer:    relayer,
+		RelayerFee: relayerFee,
+		Deadline:   big.NewInt(deadline),
+	}
+	if err := s.client.SignClaimAuthorization(s.localPrivKey, auth); err != nil {
+		return nil, err
+	}
+	return auth, nil
+}
+
+// SupportsMetaClaims reports whether the HTLC contract accepts relayed
+// claims.
+func (s *EVMHTLCSession) SupportsMetaClaims(ctx context.Context) (bool, error) {
+	return s.client.SupportsMetaClaims(ctx)
+}
+
+// ClaimWithSignature submits the counterparty's claim of this HTLC with
+// its authorization, paying the gas with the local key. minFee is the least
+// relayer fee we accept.
+func (s *EVMHTLCSession) ClaimWithSignature(ctx context.Context, secret [32]byte, auth *htlc.ClaimAuthorization, minFee *big.Int) (common.Hash, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.localPrivKey == nil {
+		return common.Hash{}, fmt.Errorf("local private key not set")
+	}
+	if auth.SwapID != s.swapID {
+		return common.Hash{}, fmt.Errorf("authorization is for another swap")
+	}
+	if auth.RelayerFee == nil || auth.RelayerFee.Cmp(minFee) < 0 {
+		return common.Hash{}, fmt.Errorf("relayer fee %s is below the agreed %s", auth.RelayerFee, minFee)
+	}
+	if _, err := s.client.VerifyClaimAuthorization(ctx, auth, secret, s.localAddress); err != nil {
+		return common.Hash{}, fmt.Errorf("invalid claim authorization: %w", err)
+	}
+
+	tx, err := s.client.ClaimWithSignature(ctx, s.localPrivKey, secret, auth)
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("failed to claim with signature: %w", err)
+	}
+
+	s.secret = secret
+	s.hasSecret = true
+	s.claimTxHash = tx.Hash()
+	s.state = EVMSwapStateClaimed
+	return tx.Hash(), nil
+}
+
 // =============================================================================
 // Status Queries
 // =============================================================================
@@ -497,6 +662,24 @@ func IsEVMChain(symbol string, network chain.Network) bool {
 	return params.Type == chain.ChainTypeEVM
 }
 
+// ResolveToken returns the registry entry of the ERC-20 token at address on
+// the EVM chain symbol. Only registered tokens can be swapped, so both sides
+// agree on the token's decimals.
+func ResolveToken(symbol, address string, network chain.Network) (*chain.TokenInfo, error) {
+	params, ok := chain.Get(symbol, network)
+	if !ok || params.Type != chain.ChainTypeEVM {
+		return nil, fmt.Errorf("tokens are only supported on EVM chains, not %s", symbol)
+	}
+	if !common.IsHexAddress(address) {
+		return nil, fmt.Errorf("invalid token address %q", address)
+	}
+	token := chain.GetTokenByAddress(params.ChainID, address)
+	if token == nil {
+		return nil, fmt.Errorf("token %s is not registered on %s", address, symbol)
+	}
+	return token, nil
+}
+
 // IsBitcoinChain returns true if the chain is a Bitcoin-family chain.
 func IsBitcoinChain(symbol string, network chain.Network) bool {
 	params, ok := chain.Get(symbol, network)
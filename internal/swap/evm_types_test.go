@@ -372,3 +372,33 @@ func TestActiveSwapIsEVMHTLC(t *testing.T) {
 		})
 	}
 }
+
+func TestResolveToken(t *testing.T) {
+	token, err := ResolveToken("BSC", "0x55d398326f99059ff775485246999027b3197955", chain.Mainnet)
+	if err != nil {
+		t.Fatalf("ResolveToken() error = %v", err)
+	}
+	if token.Symbol != "USDT" || token.Decimals != 18 {
+		t.Errorf("ResolveToken() = %+v, want USDT with 18 decimals", token)
+	}
+
+	for _, tt := range []struct{ chain, address string }{
+		{"BTC", bscUSDT},
+		{"BSC", "USDT"},
+		{"BSC", arbitrumUSDC},
+	} {
+		if _, err := ResolveToken(tt.chain, tt.address, chain.Mainnet); err == nil {
+			t.Errorf("ResolveToken(%s, %s) accepted an invalid token", tt.chain, tt.address)
+		}
+	}
+}
+
+func TestEVMLegToken(t *testing.T) {
+	offer := &Offer{OfferChain: "ARBITRUM", OfferToken: arbitrumUSDC, RequestChain: "BSC"}
+	if got := evmLegToken(offer, "ARBITRUM"); got != common.HexToAddress(arbitrumUSDC) {
+		t.Errorf("offer leg token = %s, want %s", got.Hex(), arbitrumUSDC)
+	}
+	if got := evmLegToken(offer, "BSC"); got != (common.Address{}) {
+		t.Errorf("native request leg token = %s, want zero address", got.Hex())
+	}
+}
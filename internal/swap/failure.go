@@ -0,0 +1,79 @@
+// Package swap - Taxonomy of swap failures.
+//
+// Every swap that fails, is refunded or is refused before it starts is
+// filed under one failure category, so operators can see why their fill
+// rate is poor instead of reading through logs. ClassifyFailure maps the
+// coordinator's and backends' errors to a category.
+package swap
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+)
+
+// FailureCategory is why a swap failed.
+type FailureCategory string
+
+// Failure categories.
+const (
+	FailurePeerTimeout         FailureCategory = "peer_timeout"         // Counterparty stopped responding, swap ran into its timelock
+	FailureFundingInsufficient FailureCategory = "funding_insufficient" // Escrow short or our wallet couldn't fund it
+	FailureBackendError        FailureCategory = "backend_error"        // Chain backend unreachable or rejecting transactions
+	FailureTimelockRaced       FailureCategory = "timelock_raced"       // Too close to a timelock to complete safely
+	FailureUserCancelled       FailureCategory = "user_cancelled"       // Operator cancelled the swap's operations
+	FailureValidationMismatch  FailureCategory = "validation_mismatch"  // Terms, keys, secret or protocol version didn't match
+	FailureUnknown             FailureCategory = "unknown"              // None of the above
+)
+
+// FailureCategories lists every category, FailureUnknown last.
+func FailureCategories() []FailureCategory {
+	return []FailureCategory{
+		FailurePeerTimeout,
+		FailureFundingInsufficient,
+		FailureBackendError,
+		FailureTimelockRaced,
+		FailureUserCancelled,
+		FailureValidationMismatch,
+		FailureUnknown,
+	}
+}
+
+// IsValid reports whether c is one of the categories.
+func (c FailureCategory) IsValid() bool {
+	for _, known := range FailureCategories() {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyFailure files an error under a failure category, FailureUnknown
+// when it matches none.
+func ClassifyFailure(err error) FailureCategory {
+	switch {
+	case err == nil:
+		return FailureUnknown
+	case errors.Is(err, ErrTimeoutRace), errors.Is(err, ErrSwapExpired):
+		return FailureTimelockRaced
+	case errors.Is(err, ErrOperationCancelled):
+		return FailureUserCancelled
+	case errors.Is(err, ErrOperationDeadline), errors.Is(err, context.DeadlineExceeded):
+		return FailurePeerTimeout
+	case errors.Is(err, ErrInsufficientFunds), errors.Is(err, ErrInsufficientAvailableBalance),
+		errors.Is(err, ErrNoUTXOs), errors.Is(err, ErrDustOutput):
+		return FailureFundingInsufficient
+	case errors.Is(err, ErrTermsMismatch), errors.Is(err, ErrInvalidOffer),
+		errors.Is(err, ErrSecretMismatch), errors.Is(err, ErrInvalidSecretHash),
+		errors.Is(err, ErrSecretHashReused), errors.Is(err, ErrInvalidPubKey),
+		errors.Is(err, ErrEVMContractMismatch):
+		return FailureValidationMismatch
+	case errors.Is(err, ErrNoBackend), errors.Is(err, backend.ErrNotConnected),
+		errors.Is(err, backend.ErrBroadcastFailed), errors.Is(err, backend.ErrRateLimited):
+		return FailureBackendError
+	default:
+		return FailureUnknown
+	}
+}
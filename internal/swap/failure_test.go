@@ -0,0 +1,46 @@
+package swap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+)
+
+func TestClassifyFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want FailureCategory
+	}{
+		{nil, FailureUnknown},
+		{errors.New("something else"), FailureUnknown},
+		{fmt.Errorf("%w: BTC has 3 blocks left", ErrTimeoutRace), FailureTimelockRaced},
+		{fmt.Errorf("sign: %w", ErrOperationCancelled), FailureUserCancelled},
+		{ErrOperationDeadline, FailurePeerTimeout},
+		{context.DeadlineExceeded, FailurePeerTimeout},
+		{fmt.Errorf("fund: %w", ErrInsufficientAvailableBalance), FailureFundingInsufficient},
+		{ErrNoUTXOs, FailureFundingInsufficient},
+		{fmt.Errorf("%w: digest differs", ErrTermsMismatch), FailureValidationMismatch},
+		{ErrSecretHashReused, FailureValidationMismatch},
+		{fmt.Errorf("broadcast: %w", backend.ErrBroadcastFailed), FailureBackendError},
+		{ErrNoBackend, FailureBackendError},
+	}
+	for _, tt := range tests {
+		if got := ClassifyFailure(tt.err); got != tt.want {
+			t.Errorf("ClassifyFailure(%v) = %s, want %s", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestFailureCategoryIsValid(t *testing.T) {
+	for _, c := range FailureCategories() {
+		if !c.IsValid() {
+			t.Errorf("%s is not valid", c)
+		}
+	}
+	if FailureCategory("bad_luck").IsValid() {
+		t.Error("unknown category is valid")
+	}
+}
@@ -0,0 +1,137 @@
+// Package swap - Fee adjustment for chains with unbalanced mining fees.
+//
+// Each party funds its escrow on one chain and claims on the other, so when
+// one chain's fees dominate (ETH mainnet against LTC) the parties' costs
+// differ widely. They may agree to change the request amount so the expected
+// mining fees are split evenly: a positive adjustment has the taker pay the
+// maker more, a negative one lowers what the taker pays. The agreed
+// adjustment is part of the trade terms digest.
+package swap
+
+import (
+	"fmt"
+	"math/big"
+)
+
+// Expected sizes of the swap transactions, used to price them.
+const (
+	FundingTxVBytes    = 197    // One input; escrow, DAO and change outputs
+	ClaimTxVBytes      = 146    // HTLC claim to one output
+	EVMFundingGas      = 150000 // HTLC create with the native coin
+	EVMTokenFundingGas = 250000 // ERC-20 approve and HTLC create
+	EVMClaimGas        = 80000  // HTLC claim
+)
+
+// MaxFeeAdjustmentBps bounds a fee adjustment (10% of the request amount).
+const MaxFeeAdjustmentBps = 1000
+
+// ChainFees are the expected mining fees of the swap transactions on one
+// chain, in its smallest unit.
+type ChainFees struct {
+	Funding uint64 `json:"funding"`
+	Claim   uint64 `json:"claim"`
+}
+
+// UTXOChainFees prices the swap transactions of a UTXO chain at a fee rate
+// in sat/vB.
+func UTXOChainFees(feeRate uint64) ChainFees {
+	return ChainFees{Funding: FundingTxVBytes * feeRate, Claim: ClaimTxVBytes * feeRate}
+}
+
+// EVMChainFees prices the swap transactions of an EVM chain at a gas price
+// in wei; token selects the ERC-20 funding path.
+func EVMChainFees(gasPrice uint64, token bool) ChainFees {
+	funding := uint64(EVMFundingGas)
+	if token {
+		funding = EVMTokenFundingGas
+	}
+	return ChainFees{Funding: funding * gasPrice, Claim: EVMClaimGas * gasPrice}
+}
+
+// FeeSplit is the expected mining fees of each party, in the smallest unit
+// of the request chain, and the adjustment splitting them evenly.
+type FeeSplit struct {
+	MakerFees  uint64 `json:"maker_fees"`
+	TakerFees  uint64 `json:"taker_fees"`
+	Adjustment int64  `json:"adjustment"`
+
+	// Precision is the request leg's precision the adjustment was rounded
+	// to by Round, zero while exact.
+	Precision Precision `json:"precision"`
+}
+
+// Round rounds the adjustment to the request leg's quote precision, so
+// estimates of both parties land on the same steps.
+func (f *FeeSplit) Round(p Precision) {
+	f.Adjustment = p.RoundAdjustment(f.Adjustment)
+	f.Precision = p
+}
+
+// SplitFees computes the adjustment that evens out the parties' mining fees.
+// The maker funds on the offer chain and claims on the request chain, the
+// taker the reverse; offer chain fees are converted at the order's rate. The
+// adjustment is bounded by maxBps of the request amount.
+func SplitFees(offer Offer, offerFees, requestFees ChainFees, maxBps uint32) FeeSplit {
+	if maxBps > MaxFeeAdjustmentBps {
+		maxBps = MaxFeeAdjustmentBps
+	}
+	toRequest := func(amount uint64) *big.Int {
+		v := new(big.Int).SetUint64(amount)
+		if offer.OfferAmount == 0 {
+			return v.SetUint64(0)
+		}
+		v.Mul(v, new(big.Int).SetUint64(offer.RequestAmount))
+		return v.Quo(v, new(big.Int).SetUint64(offer.OfferAmount))
+	}
+
+	maker := toRequest(offerFees.Funding)
+	maker.Add(maker, new(big.Int).SetUint64(requestFees.Claim))
+	taker := toRequest(offerFees.Claim)
+	taker.Add(taker, new(big.Int).SetUint64(requestFees.Funding))
+
+	adjustment := new(big.Int).Sub(maker, taker)
+	adjustment.Quo(adjustment, big.NewInt(2))
+	bound := new(big.Int).SetUint64(offer.RequestAmount)
+	bound.Mul(bound, big.NewInt(int64(maxBps)))
+	bound.Quo(bound, big.NewInt(10000))
+	if adjustment.CmpAbs(bound) > 0 {
+		if adjustment.Sign() > 0 {
+			adjustment.Set(bound)
+		} else {
+			adjustment.Neg(bound)
+		}
+	}
+
+	return FeeSplit{
+		MakerFees:  saturateUint64(maker),
+		TakerFees:  saturateUint64(taker),
+		Adjustment: adjustment.Int64(), // Bounded, fits for maxBps <= MaxFeeAdjustmentBps
+	}
+}
+
+// saturateUint64 returns v, or the largest uint64 when it doesn't fit.
+func saturateUint64(v *big.Int) uint64 {
+	if !v.IsUint64() {
+		return ^uint64(0)
+	}
+	return v.Uint64()
+}
+
+// ApplyFeeAdjustment changes the offer's request amount by an agreed
+// adjustment.
+func ApplyFeeAdjustment(offer *Offer, adjustment int64) error {
+	switch {
+	case adjustment < 0:
+		decrease := uint64(-adjustment)
+		if decrease >= offer.RequestAmount {
+			return fmt.Errorf("fee adjustment %d exceeds the request amount %d", adjustment, offer.RequestAmount)
+		}
+		offer.RequestAmount -= decrease
+	case adjustment > 0:
+		if offer.RequestAmount+uint64(adjustment) < offer.RequestAmount {
+			return fmt.Errorf("fee adjustment %d overflows the request amount", adjustment)
+		}
+		offer.RequestAmount += uint64(adjustment)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package swap
+
+import "testing"
+
+func TestChainFees(t *testing.T) {
+	if fees := UTXOChainFees(10); fees.Funding != 1970 || fees.Claim != 1460 {
+		t.Errorf("UTXOChainFees(10) = %+v", fees)
+	}
+	native := EVMChainFees(20_000_000_000, false)
+	token := EVMChainFees(20_000_000_000, true)
+	if native.Funding != 3_000_000_000_000_000 || native.Claim != 1_600_000_000_000_000 {
+		t.Errorf("EVMChainFees(native) = %+v", native)
+	}
+	if token.Funding <= native.Funding || token.Claim != native.Claim {
+		t.Errorf("EVMChainFees(token) = %+v, want a costlier funding", token)
+	}
+}
+
+func TestSplitFees(t *testing.T) {
+	// 1 ETH for 20 LTC
+	offer := Offer{OfferChain: "ETH", OfferAmount: 1_000_000_000_000_000_000, RequestChain: "LTC", RequestAmount: 2_000_000_000}
+	eth := ChainFees{Funding: 3_000_000_000_000_000, Claim: 1_000_000_000_000_000} // 0.06 and 0.02 LTC
+	ltc := ChainFees{Funding: 2000, Claim: 1000}
+
+	split := SplitFees(offer, eth, ltc, 1000)
+	// Maker: ETH funding 6_000_000 + LTC claim 1000; taker: ETH claim 2_000_000 + LTC funding 2000
+	if split.MakerFees != 6_001_000 || split.TakerFees != 2_002_000 {
+		t.Fatalf("fees = %d / %d, want 6001000 / 2002000", split.MakerFees, split.TakerFees)
+	}
+	if split.Adjustment != 1_999_500 {
+		t.Errorf("Adjustment = %d, want 1999500", split.Adjustment)
+	}
+
+	// Bounded by the request amount
+	if split := SplitFees(offer, eth, ltc, 5); split.Adjustment != 1_000_000 {
+		t.Errorf("bounded Adjustment = %d, want 1000000 (5 bps)", split.Adjustment)
+	}
+
+	// Reversed, the maker is compensated less than it pays: negative
+	reversed := Offer{OfferChain: "LTC", OfferAmount: 2_000_000_000, RequestChain: "ETH", RequestAmount: 1_000_000_000_000_000_000}
+	if split := SplitFees(reversed, ltc, eth, 1000); split.Adjustment >= 0 {
+		t.Errorf("reversed Adjustment = %d, want negative", split.Adjustment)
+	}
+
+	// Balanced chains need no adjustment
+	if split := SplitFees(Offer{OfferAmount: 100, RequestAmount: 100}, ltc, ltc, 1000); split.Adjustment != 0 {
+		t.Errorf("balanced Adjustment = %d, want 0", split.Adjustment)
+	}
+}
+
+func TestApplyFeeAdjustment(t *testing.T) {
+	offer := Offer{RequestAmount: 1000}
+	if err := ApplyFeeAdjustment(&offer, 50); err != nil || offer.RequestAmount != 1050 {
+		t.Errorf("ApplyFeeAdjustment(+50) = %d, %v", offer.RequestAmount, err)
+	}
+	if err := ApplyFeeAdjustment(&offer, -100); err != nil || offer.RequestAmount != 950 {
+		t.Errorf("ApplyFeeAdjustment(-100) = %d, %v", offer.RequestAmount, err)
+	}
+	if err := ApplyFeeAdjustment(&offer, -950); err == nil {
+		t.Error("ApplyFeeAdjustment() accepted an adjustment consuming the request amount")
+	}
+	if err := ApplyFeeAdjustment(&Offer{RequestAmount: ^uint64(0)}, 1); err == nil {
+		t.Error("ApplyFeeAdjustment() accepted an overflow")
+	}
+}
@@ -0,0 +1,168 @@
+// Package swap - Negotiated fee terms (who pays DAO and claim mining fees).
+package swap
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+)
+
+// FeePayer identifies the party that bears a fee.
+type FeePayer string
+
+const (
+	// FeePayerDefault means each party pays its own fee (protocol default).
+	FeePayerDefault FeePayer = ""
+	// FeePayerMaker means the maker (initiator) pays the fee for both parties.
+	FeePayerMaker FeePayer = "maker"
+	// FeePayerTaker means the taker (responder) pays the fee for both parties.
+	FeePayerTaker FeePayer = "taker"
+)
+
+// Valid returns true if the payer is a known value.
+func (p FeePayer) Valid() bool {
+	return p == FeePayerDefault || p == FeePayerMaker || p == FeePayerTaker
+}
+
+// FeeTerms are the fee terms negotiated in an offer.
+// The zero value keeps the protocol defaults: each party pays its own DAO fee
+// and the mining fee of its own claim.
+type FeeTerms struct {
+	// DAOFeePayer is the party that pays both parties' DAO fees.
+	// The counterparty's fee is charged at the counterparty's rate on the
+	// payer's own leg, wherever the payer's DAO fee is normally charged.
+	DAOFeePayer FeePayer `json:"dao_fee_payer,omitempty"`
+
+	// ClaimFeePayer is the party that pays the mining fees of both claims.
+	// It covers the counterparty's claim by locking ClaimFeeAllowance extra
+	// in its own escrow, which the counterparty's claim spends as mining fee.
+	ClaimFeePayer FeePayer `json:"claim_fee_payer,omitempty"`
+
+	// ClaimFeeAllowance is the extra escrow amount (smallest unit of the
+	// ClaimFeePayer's funding chain) covering the counterparty's claim fee.
+	ClaimFeeAllowance uint64 `json:"claim_fee_allowance,omitempty"`
+
+	// MetaClaimFeeBps is what a receiver of an EVM leg pays the counterparty,
+	// in basis points of its claimed amount, for submitting its claim when it
+	// has no native gas (see AuthorizeEVMMetaClaim). The fee is paid in kind
+	// out of the claim. 0 means the counterparty doesn't relay claims.
+	MetaClaimFeeBps uint32 `json:"meta_claim_fee_bps,omitempty"`
+}
+
+// MaxMetaClaimFeeBps bounds the negotiated relayer fee (10%).
+const MaxMetaClaimFeeBps = 1000
+
+// IsDefault returns true if the terms keep the protocol defaults.
+func (t FeeTerms) IsDefault() bool {
+	return t == FeeTerms{}
+}
+
+// Validate checks the terms against the offer amounts.
+func (t FeeTerms) Validate(offerAmount, requestAmount uint64) error {
+	if !t.DAOFeePayer.Valid() {
+		return fmt.Errorf("invalid dao_fee_payer: %q", t.DAOFeePayer)
+	}
+	if !t.ClaimFeePayer.Valid() {
+		return fmt.Errorf("invalid claim_fee_payer: %q", t.ClaimFeePayer)
+	}
+
+	if t.MetaClaimFeeBps > MaxMetaClaimFeeBps {
+		return fmt.Errorf("meta_claim_fee_bps must be at most %d", MaxMetaClaimFeeBps)
+	}
+
+	switch t.ClaimFeePayer {
+	case FeePayerDefault:
+		if t.ClaimFeeAllowance != 0 {
+			return fmt.Errorf("claim_fee_allowance requires claim_fee_payer")
+		}
+	case FeePayerMaker:
+		if t.ClaimFeeAllowance == 0 || t.ClaimFeeAllowance >= offerAmount {
+			return fmt.Errorf("claim_fee_allowance must be between 1 and the offer amount")
+		}
+	case FeePayerTaker:
+		if t.ClaimFeeAllowance == 0 || t.ClaimFeeAllowance >= requestAmount {
+			return fmt.Errorf("claim_fee_allowance must be between 1 and the request amount")
+		}
+	}
+	return nil
+}
+
+// SplitsFees returns true if the terms move the DAO or claim fees between
+// the parties, as opposed to only agreeing a relayer fee.
+func (t FeeTerms) SplitsFees() bool {
+	return t.DAOFeePayer != FeePayerDefault || t.ClaimFeePayer != FeePayerDefault || t.ClaimFeeAllowance != 0
+}
+
+// MetaClaimFee returns the relayer fee for a claimed amount.
+func (t FeeTerms) MetaClaimFee(amount *big.Int) *big.Int {
+	fee := new(big.Int).Mul(amount, big.NewInt(int64(t.MetaClaimFeeBps)))
+	return fee.Div(fee, big.NewInt(10000))
+}
+
+// DAOFee returns the DAO fee charged to the paying party on a leg.
+// payerIsMaker identifies the party building the fee-carrying transaction
+// (the funder, or the claimer for claim-time fees); amount is the leg amount.
+func (t FeeTerms) DAOFee(amount uint64, payerIsMaker bool) uint64 {
+	payer := FeePayerTaker
+	if payerIsMaker {
+		payer = FeePayerMaker
+	}
+
+	switch t.DAOFeePayer {
+	case FeePayerDefault:
+		return CalculateDAOFee(amount, payerIsMaker)
+	case payer:
+		// Own fee plus the counterparty's fee at the counterparty's rate
+		return CalculateDAOFee(amount, payerIsMaker) + CalculateDAOFee(amount, !payerIsMaker)
+	default:
+		// The counterparty pays our fee
+		return 0
+	}
+}
+
+// ParseFeeTerms decodes JSON-encoded fee terms. Empty input yields the defaults.
+func ParseFeeTerms(data []byte) (FeeTerms, error) {
+	var terms FeeTerms
+	if len(data) == 0 {
+		return terms, nil
+	}
+	if err := json.Unmarshal(data, &terms); err != nil {
+		return terms, fmt.Errorf("invalid fee terms: %w", err)
+	}
+	return terms, nil
+}
+
+// OfferEscrowAmount returns the amount locked on the offer chain: the offer
+// amount plus the claim fee allowance when the maker covers the taker's claim.
+func (o *Offer) OfferEscrowAmount() uint64 {
+	if o.FeeTerms.ClaimFeePayer == FeePayerMaker {
+		return o.OfferAmount + o.FeeTerms.ClaimFeeAllowance
+	}
+	return o.OfferAmount
+}
+
+// RequestEscrowAmount returns the amount locked on the request chain: the request
+// amount plus the claim fee allowance when the taker covers the maker's claim.
+func (o *Offer) RequestEscrowAmount() uint64 {
+	if o.FeeTerms.ClaimFeePayer == FeePayerTaker {
+		return o.RequestAmount + o.FeeTerms.ClaimFeeAllowance
+	}
+	return o.RequestAmount
+}
+
+// DAOFeeOnChain returns the DAO fee the acting party pays on the leg of chainSymbol.
+func (o *Offer) DAOFeeOnChain(chainSymbol string, payerIsMaker bool) uint64 {
+	amount := o.RequestAmount
+	if chainSymbol == o.OfferChain {
+		amount = o.OfferAmount
+	}
+	return o.FeeTerms.DAOFee(amount, payerIsMaker)
+}
+
+// escrowAmountFor returns the escrow amount the given party locks on its funding leg.
+func escrowAmountFor(active *ActiveSwap, isMaker bool) uint64 {
+	if isMaker {
+		return active.Swap.Offer.OfferEscrowAmount()
+	}
+	return active.Swap.Offer.RequestEscrowAmount()
+}
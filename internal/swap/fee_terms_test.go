@@ -0,0 +1,170 @@
+package swap
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestFeeTermsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		terms   FeeTerms
+		wantErr bool
+	}{
+		{"defaults", FeeTerms{}, false},
+		{"maker pays DAO", FeeTerms{DAOFeePayer: FeePayerMaker}, false},
+		{"taker pays claims", FeeTerms{ClaimFeePayer: FeePayerTaker, ClaimFeeAllowance: 2000}, false},
+		{"unknown DAO payer", FeeTerms{DAOFeePayer: "dao"}, true},
+		{"unknown claim payer", FeeTerms{ClaimFeePayer: "both"}, true},
+		{"allowance without payer", FeeTerms{ClaimFeeAllowance: 100}, true},
+		{"payer without allowance", FeeTerms{ClaimFeePayer: FeePayerMaker}, true},
+		{"allowance exceeds leg", FeeTerms{ClaimFeePayer: FeePayerMaker, ClaimFeeAllowance: 100000}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.terms.Validate(100000, 1000000)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFeeTermsDAOFee(t *testing.T) {
+	const amount = 100000000 // 1 BTC
+	makerFee := CalculateDAOFee(amount, true)
+	takerFee := CalculateDAOFee(amount, false)
+
+	tests := []struct {
+		name         string
+		payer        FeePayer
+		payerIsMaker bool
+		want         uint64
+	}{
+		{"default maker", FeePayerDefault, true, makerFee},
+		{"default taker", FeePayerDefault, false, takerFee},
+		{"maker pays both", FeePayerMaker, true, makerFee + takerFee},
+		{"maker pays, taker leg", FeePayerMaker, false, 0},
+		{"taker pays both", FeePayerTaker, false, makerFee + takerFee},
+		{"taker pays, maker leg", FeePayerTaker, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			terms := FeeTerms{DAOFeePayer: tt.payer}
+			if got := terms.DAOFee(amount, tt.payerIsMaker); got != tt.want {
+				t.Errorf("DAOFee() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOfferEscrowAmounts(t *testing.T) {
+	offer := Offer{OfferChain: "BTC", OfferAmount: 100000, RequestChain: "LTC", RequestAmount: 1000000}
+	if offer.OfferEscrowAmount() != 100000 || offer.RequestEscrowAmount() != 1000000 {
+		t.Error("default terms must not change escrow amounts")
+	}
+
+	offer.FeeTerms = FeeTerms{ClaimFeePayer: FeePayerTaker, ClaimFeeAllowance: 3000}
+	if offer.OfferEscrowAmount() != 100000 {
+		t.Errorf("OfferEscrowAmount() = %d, want 100000", offer.OfferEscrowAmount())
+	}
+	if offer.RequestEscrowAmount() != 1003000 {
+		t.Errorf("RequestEscrowAmount() = %d, want 1003000", offer.RequestEscrowAmount())
+	}
+
+	offer.FeeTerms = FeeTerms{ClaimFeePayer: FeePayerMaker, ClaimFeeAllowance: 500}
+	if offer.OfferEscrowAmount() != 100500 {
+		t.Errorf("OfferEscrowAmount() = %d, want 100500", offer.OfferEscrowAmount())
+	}
+}
+
+func TestOfferDAOFeeOnChain(t *testing.T) {
+	offer := Offer{
+		OfferChain: "BTC", OfferAmount: 100000000,
+		RequestChain: "LTC", RequestAmount: 200000000,
+		FeeTerms: FeeTerms{DAOFeePayer: FeePayerMaker},
+	}
+	// Maker claims on the request chain and carries both fees on that leg
+	want := CalculateDAOFee(200000000, true) + CalculateDAOFee(200000000, false)
+	if got := offer.DAOFeeOnChain("LTC", true); got != want {
+		t.Errorf("DAOFeeOnChain(LTC, maker) = %d, want %d", got, want)
+	}
+	if got := offer.DAOFeeOnChain("BTC", false); got != 0 {
+		t.Errorf("DAOFeeOnChain(BTC, taker) = %d, want 0", got)
+	}
+}
+
+func TestOfferValidateFeeTerms(t *testing.T) {
+	offer := Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		Method:   MethodHTLC,
+		FeeTerms: FeeTerms{ClaimFeePayer: FeePayerTaker, ClaimFeeAllowance: 2000},
+	}
+	if err := offer.Validate(chain.Testnet); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	offer.FeeTerms.ClaimFeeAllowance = 0
+	if err := offer.Validate(chain.Testnet); err == nil {
+		t.Error("expected error for claim payer without allowance")
+	}
+
+	evm := Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "ETH", RequestAmount: 10000000000000000,
+		Method:   MethodHTLC,
+		FeeTerms: FeeTerms{DAOFeePayer: FeePayerMaker},
+	}
+	if err := evm.Validate(chain.Testnet); err == nil {
+		t.Error("expected error for fee terms on an EVM leg")
+	}
+
+	// A relayer fee for meta-claims is allowed, and only with an EVM leg
+	evm.FeeTerms = FeeTerms{MetaClaimFeeBps: 50}
+	if err := evm.Validate(chain.Testnet); err != nil {
+		t.Errorf("Validate() with a meta-claim fee error = %v", err)
+	}
+	evm.FeeTerms.MetaClaimFeeBps = MaxMetaClaimFeeBps + 1
+	if err := evm.Validate(chain.Testnet); err == nil {
+		t.Error("expected error for a meta-claim fee above the maximum")
+	}
+	offer.FeeTerms = FeeTerms{MetaClaimFeeBps: 50}
+	if err := offer.Validate(chain.Testnet); err == nil {
+		t.Error("expected error for a meta-claim fee without an EVM leg")
+	}
+}
+
+func TestMetaClaimFee(t *testing.T) {
+	terms := FeeTerms{MetaClaimFeeBps: 25}
+	if got := terms.MetaClaimFee(big.NewInt(1_000_000_000_000_000_000)); got.Cmp(big.NewInt(2_500_000_000_000_000)) != 0 {
+		t.Errorf("MetaClaimFee() = %s, want 2500000000000000", got)
+	}
+	if got := (FeeTerms{}).MetaClaimFee(big.NewInt(1000)); got.Sign() != 0 {
+		t.Errorf("MetaClaimFee() without terms = %s, want 0", got)
+	}
+}
+
+func TestParseFeeTerms(t *testing.T) {
+	terms, err := ParseFeeTerms(nil)
+	if err != nil || !terms.IsDefault() {
+		t.Errorf("ParseFeeTerms(nil) = %+v, %v", terms, err)
+	}
+
+	terms, err = ParseFeeTerms([]byte(`{"dao_fee_payer":"taker","claim_fee_payer":"taker","claim_fee_allowance":1500}`))
+	if err != nil {
+		t.Fatalf("ParseFeeTerms() error = %v", err)
+	}
+	want := FeeTerms{DAOFeePayer: FeePayerTaker, ClaimFeePayer: FeePayerTaker, ClaimFeeAllowance: 1500}
+	if terms != want {
+		t.Errorf("ParseFeeTerms() = %+v, want %+v", terms, want)
+	}
+
+	if _, err := ParseFeeTerms([]byte(`{bad`)); err == nil {
+		t.Error("expected error for invalid JSON")
+	}
+}
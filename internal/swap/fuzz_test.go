@@ -0,0 +1,226 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/fuzz"
+)
+
+// fuzzTradeIDs are the swaps set up by newFuzzCoordinator: a MuSig2 swap, a
+// Bitcoin HTLC swap, a swap without method data (e.g. EVM-only) and a MuSig2
+// swap recovered from storage without its ephemeral key.
+var fuzzTradeIDs = []string{"musig2", "htlc", "bare", "recovered", "unknown"}
+
+// newFuzzCoordinator returns a coordinator holding one swap per kind that a
+// protocol message may target.
+func newFuzzCoordinator(t testing.TB) *Coordinator {
+	t.Helper()
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+
+	newSwap := func(method Method) *Swap {
+		s, err := NewSwap(chain.Testnet, method, RoleInitiator, Offer{
+			OfferChain: "BTC", OfferAmount: 100000,
+			RequestChain: "LTC", RequestAmount: 1000000,
+			Method: method,
+		})
+		if err != nil {
+			t.Fatalf("NewSwap() error = %v", err)
+		}
+		return s
+	}
+	key, err := GenerateEphemeralKey()
+	if err != nil {
+		t.Fatalf("GenerateEphemeralKey() error = %v", err)
+	}
+	musigChain := func(symbol string) *ChainMuSig2Data {
+		session, err := NewMuSig2Session(symbol, chain.Testnet, key)
+		if err != nil {
+			t.Fatalf("NewMuSig2Session() error = %v", err)
+		}
+		return &ChainMuSig2Data{Session: session}
+	}
+	htlcChain := func(symbol string) *ChainHTLCData {
+		session, err := NewHTLCSessionWithKey(symbol, chain.Testnet, key)
+		if err != nil {
+			t.Fatalf("NewHTLCSessionWithKey() error = %v", err)
+		}
+		return &ChainHTLCData{Session: session}
+	}
+
+	musigSwap := newSwap(MethodMuSig2)
+	musigSwap.SetLocalPubKey(key.PubKey())
+	coord.swaps["musig2"] = &ActiveSwap{
+		Swap:   musigSwap,
+		MuSig2: &MuSig2SwapData{LocalPrivKey: key, OfferChain: musigChain("BTC"), RequestChain: musigChain("LTC")},
+	}
+	coord.swaps["htlc"] = &ActiveSwap{
+		Swap: newSwap(MethodHTLC),
+		HTLC: &HTLCSwapData{LocalPrivKey: key, OfferChain: htlcChain("BTC"), RequestChain: htlcChain("LTC")},
+	}
+	coord.swaps["bare"] = &ActiveSwap{Swap: newSwap(MethodHTLC)}
+	coord.swaps["recovered"] = &ActiveSwap{
+		Swap:   newSwap(MethodMuSig2),
+		MuSig2: &MuSig2SwapData{OfferChain: &ChainMuSig2Data{}, RequestChain: &ChainMuSig2Data{}},
+	}
+	t.Cleanup(func() { coord.Close() })
+	return coord
+}
+
+// fuzzSeeds returns well-formed protocol values to derive seeds from.
+func fuzzSeeds(t testing.TB) (pubKey, nonce, partialSig, secret, secretHash []byte) {
+	t.Helper()
+	key, err := btcec.NewPrivateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session, err := NewMuSig2Session("BTC", chain.Testnet, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := session.GenerateNonces(); err != nil {
+		t.Fatal(err)
+	}
+	pubNonce, err := session.LocalPubNonce()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := key.Key.Bytes()
+	secret = make([]byte, 32)
+	secret[0] = 1
+	hash := sha256.Sum256(secret)
+	return key.PubKey().SerializeCompressed(), pubNonce[:], sig[:], secret, hash[:]
+}
+
+func FuzzSetRemotePubKey(f *testing.F) {
+	pubKey, _, _, _, _ := fuzzSeeds(f)
+	for _, seed := range fuzz.Bytes(pubKey) {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		coord := newFuzzCoordinator(t)
+		for _, id := range fuzzTradeIDs {
+			coord.SetRemotePubKey(id, data)
+		}
+	})
+}
+
+func FuzzSetRemoteNonces(f *testing.F) {
+	_, nonce, _, _, _ := fuzzSeeds(f)
+	seeds := fuzz.Bytes(nonce)
+	for _, offer := range seeds {
+		f.Add(offer, nonce)
+		f.Add(nonce, offer)
+	}
+	f.Fuzz(func(t *testing.T, offer, request []byte) {
+		coord := newFuzzCoordinator(t)
+		for _, id := range fuzzTradeIDs {
+			if err := coord.SetRemoteNonces(id, offer, request); err == nil && (len(offer) != 66 || len(request) != 66) {
+				t.Fatalf("SetRemoteNonces(%s) accepted nonce sizes %d/%d", id, len(offer), len(request))
+			}
+		}
+	})
+}
+
+func FuzzSetRemotePartialSigs(f *testing.F) {
+	_, _, sig, _, _ := fuzzSeeds(f)
+	for _, seed := range fuzz.Bytes(sig) {
+		f.Add(seed, sig)
+		f.Add(sig, seed)
+	}
+	f.Fuzz(func(t *testing.T, offer, request []byte) {
+		coord := newFuzzCoordinator(t)
+		for _, id := range fuzzTradeIDs {
+			if err := coord.SetRemotePartialSigs(id, offer, request); err == nil && (len(offer) != 32 || len(request) != 32) {
+				t.Fatalf("SetRemotePartialSigs(%s) accepted sig sizes %d/%d", id, len(offer), len(request))
+			}
+			coord.HasRemotePartialSigs(id)
+			coord.GetRemotePartialSig(id, "BTC")
+			coord.CombineSignatures(id, "LTC", offer)
+		}
+	})
+}
+
+func FuzzSecrets(f *testing.F) {
+	_, _, _, secret, hash := fuzzSeeds(f)
+	for _, seed := range fuzz.Bytes(secret) {
+		f.Add(hash, seed)
+	}
+	for _, seed := range fuzz.Bytes(hash) {
+		f.Add(seed, secret)
+	}
+	f.Fuzz(func(t *testing.T, secretHash, secret []byte) {
+		coord := newFuzzCoordinator(t)
+		for _, id := range fuzzTradeIDs {
+			hashErr := coord.SetRemoteSecretHash(id, secretHash)
+			if err := coord.SetRevealedSecret(id, secret); err == nil {
+				sum := sha256.Sum256(secret)
+				if hashErr == nil && string(sum[:]) != string(secretHash) {
+					t.Fatalf("SetRevealedSecret(%s) accepted a secret that does not match the hash", id)
+				}
+			}
+		}
+	})
+}
+
+// FuzzProtocolSequence applies protocol messages in arbitrary order, to
+// arbitrary swaps, with arbitrary payload lengths.
+func FuzzProtocolSequence(f *testing.F) {
+	f.Add([]byte{0, 0, 33, 1, 0, 66, 2, 0, 32, 3, 0, 32}) // pubkey, nonces, sigs, combine
+	f.Add([]byte{2, 0, 32, 1, 0, 66, 0, 0, 33})           // reversed
+	f.Add([]byte{5, 1, 32, 6, 1, 32, 7, 1, 64, 8, 1, 20}) // HTLC flow
+	f.Add([]byte{1, 2, 66, 2, 3, 32, 0, 3, 33, 9, 3, 0})  // wrong method
+	f.Add([]byte{4, 0, 0, 1, 0, 65, 2, 0, 31, 6, 1, 31})  // truncated
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		coord := newFuzzCoordinator(t)
+		pubKey, nonce, sig, secret, hash := fuzzSeeds(t)
+
+		// payload returns n bytes derived from a well-formed value, so
+		// valid lengths usually carry plausible data
+		payload := func(valid []byte, n byte, salt int) []byte {
+			out := make([]byte, int(n))
+			for i := range out {
+				if i < len(valid) {
+					out[i] = valid[i]
+				} else {
+					out[i] = byte(i + salt)
+				}
+			}
+			return out
+		}
+
+		for i := 0; i+2 < len(ops) && i < 3*32; i += 3 {
+			op, id, n := ops[i]%10, fuzzTradeIDs[int(ops[i+1])%len(fuzzTradeIDs)], ops[i+2]
+			switch op {
+			case 0:
+				coord.SetRemotePubKey(id, payload(pubKey, n, i))
+			case 1:
+				coord.SetRemoteNonces(id, payload(nonce, n, i), payload(nonce, n, i+1))
+			case 2:
+				coord.SetRemotePartialSigs(id, payload(sig, n, i), payload(sig, n, i+1))
+			case 3:
+				coord.CombineSignatures(id, "BTC", payload(sig, n, i))
+			case 4:
+				coord.GenerateNonces(id)
+			case 5:
+				coord.SetRemoteSecretHash(id, payload(hash, n, i))
+			case 6:
+				coord.SetRevealedSecret(id, payload(secret, n, i))
+			case 7:
+				var vout [4]byte
+				binary.BigEndian.PutUint32(vout[:], uint32(n))
+				coord.SetFundingTx(id, string(payload(nil, n, i)), binary.BigEndian.Uint32(vout[:]), n%2 == 0)
+			case 8:
+				coord.SetRemoteWalletAddresses(id, string(payload(nil, n, i)), "0x"+string(payload(nil, n, i)))
+			case 9:
+				coord.HasRemotePartialSigs(id)
+				coord.GetRemotePartialSig(id, "LTC")
+				coord.GetSwapAddresses(id)
+			}
+		}
+	})
+}
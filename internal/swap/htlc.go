@@ -392,6 +392,17 @@ func (h *HTLCSession) SetSecret(secret []byte) error {
 	return nil
 }
 
+// Zeroize overwrites the local private key and the secret once the session
+// is no longer needed. It reports whether either was still held.
+func (h *HTLCSession) Zeroize() bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	held := zeroizePrivKey(h.localPrivKey)
+	held = zeroizeBytes(h.secret) || held
+	h.secret = nil
+	return held
+}
+
 // GetSecret returns the secret (nil if not known).
 func (h *HTLCSession) GetSecret() []byte {
 	h.mu.RLock()
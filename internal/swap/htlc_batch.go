@@ -0,0 +1,229 @@
+// Package swap - Batch transaction building for Bitcoin-family HTLCs.
+// This file builds a single transaction that claims and/or refunds several
+// P2WSH HTLC outputs belonging to different swaps, paying to one wallet address.
+package swap
+
+import (
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+	btcecdsa "github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Estimated virtual sizes used for batch fee calculation (see BuildHTLCClaimTx/BuildHTLCRefundTx).
+const (
+	batchTxOverheadVBytes    = 10
+	batchInputBaseVBytes     = 41
+	batchClaimWitnessVBytes  = 52 // sig + secret + selector + script, witness-discounted
+	batchRefundWitnessVBytes = 44 // sig + empty + script, witness-discounted
+	batchOutputVBytes        = 43
+)
+
+// HTLCBatchInput describes one HTLC output to spend in a batch transaction.
+// If Secret is set the input is spent via the claim path, otherwise via the refund path.
+type HTLCBatchInput struct {
+	// TradeID identifies the swap this input belongs to (informational)
+	TradeID string
+
+	// The P2WSH HTLC output to spend
+	FundingTxID   string
+	FundingVout   uint32
+	FundingAmount uint64
+
+	// HTLC script (the witness script)
+	HTLCScript []byte
+
+	// Secret for claiming (32 bytes). Empty means refund.
+	Secret []byte
+
+	// CSV timelock in blocks (refund only, must match HTLCScript)
+	TimeoutBlocks uint32
+
+	// DAO fee deducted from this input (claim only)
+	DAOFee uint64
+
+	// Private key for signing (receiver key for claims, sender key for refunds)
+	PrivKey *btcec.PrivateKey
+}
+
+// IsClaim returns true if this input is spent via the claim (secret) path.
+func (in *HTLCBatchInput) IsClaim() bool {
+	return len(in.Secret) > 0
+}
+
+// HTLCBatchTxParams contains parameters for creating a batch HTLC transaction.
+type HTLCBatchTxParams struct {
+	// Chain parameters
+	Symbol  string
+	Network chain.Network
+
+	// HTLC outputs to spend (claims and refunds may be mixed)
+	Inputs []*HTLCBatchInput
+
+	// Output address for all settled funds
+	DestAddress string
+
+	// DAO fee output (aggregated DAO fees of all claim inputs)
+	DAOAddress string
+
+	// Fee rate in sat/vB
+	FeeRate uint64
+
+	// Dust handling for the DAO fee output
+	Dust DustPolicy
+}
+
+// EstimateHTLCBatchVSize estimates the virtual size of a batch transaction.
+func EstimateHTLCBatchVSize(inputs []*HTLCBatchInput, outputCount int) int64 {
+	vsize := int64(batchTxOverheadVBytes + batchOutputVBytes*outputCount)
+	for _, in := range inputs {
+		vsize += batchInputBaseVBytes
+		if in.IsClaim() {
+			vsize += batchClaimWitnessVBytes
+		} else {
+			vsize += batchRefundWitnessVBytes
+		}
+	}
+	return vsize
+}
+
+// BuildHTLCBatchTx creates a single transaction spending multiple HTLC P2WSH outputs.
+// All funds go to one destination output (plus one aggregated DAO output if any
+// claim input carries a DAO fee), so the fixed per-transaction cost is paid once.
+//
+// Claim inputs use witness [signature, secret, 0x01, htlc_script].
+// Refund inputs use witness [signature, 0x00, htlc_script] with the CSV sequence set.
+func BuildHTLCBatchTx(params *HTLCBatchTxParams) (*wire.MsgTx, error) {
+	if len(params.Inputs) == 0 {
+		return nil, fmt.Errorf("at least one HTLC input required")
+	}
+
+	// Get chain params
+	chainParams, ok := chain.Get(params.Symbol, params.Network)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedChain, params.Symbol)
+	}
+
+	// Version 2 is required for BIP 68 / CSV on refund inputs
+	tx := wire.NewMsgTx(2)
+
+	var totalIn, totalDAOFee uint64
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	seen := make(map[wire.OutPoint]bool, len(params.Inputs))
+
+	for i, in := range params.Inputs {
+		if in.PrivKey == nil {
+			return nil, fmt.Errorf("input %d: private key required", i)
+		}
+		if len(in.HTLCScript) == 0 {
+			return nil, fmt.Errorf("input %d: HTLC script required", i)
+		}
+		if in.IsClaim() {
+			if len(in.Secret) != 32 {
+				return nil, fmt.Errorf("input %d: secret must be 32 bytes, got %d", i, len(in.Secret))
+			}
+		} else {
+			if in.TimeoutBlocks == 0 {
+				return nil, fmt.Errorf("input %d: timeout blocks must be > 0", i)
+			}
+			if in.DAOFee > 0 {
+				return nil, fmt.Errorf("input %d: DAO fee only applies to claims", i)
+			}
+		}
+
+		txHash, err := chainhash.NewHashFromStr(in.FundingTxID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTxID, in.FundingTxID)
+		}
+		outpoint := wire.NewOutPoint(txHash, in.FundingVout)
+		if seen[*outpoint] {
+			return nil, fmt.Errorf("input %d: duplicate outpoint %s", i, outpoint)
+		}
+		seen[*outpoint] = true
+
+		txIn := wire.NewTxIn(outpoint, nil, nil)
+		if in.IsClaim() {
+			txIn.Sequence = wire.MaxTxInSequenceNum
+		} else {
+			// Set sequence for CSV - the timelock value enables relative lock-time
+			txIn.Sequence = in.TimeoutBlocks
+		}
+		tx.AddTxIn(txIn)
+
+		prevOuts.AddPrevOut(*outpoint, wire.NewTxOut(int64(in.FundingAmount), BuildP2WSHScriptPubKey(in.HTLCScript)))
+		totalIn += in.FundingAmount
+		totalDAOFee += in.DAOFee
+	}
+
+	// A dust DAO fee is folded into the fee (still deducted from the output)
+	dust := newDustCheck(params.Symbol, params.FeeRate, params.Dust)
+	hasDAOOutput := false
+	if params.DAOAddress != "" {
+		var err error
+		if hasDAOOutput, err = dust.keep(DustOutputDAOFee, totalDAOFee); err != nil {
+			return nil, err
+		}
+	} else {
+		totalDAOFee = 0
+	}
+	outputCount := 1
+	if hasDAOOutput {
+		outputCount = 2
+	}
+
+	fee := uint64(EstimateHTLCBatchVSize(params.Inputs, outputCount)) * params.FeeRate
+	totalRequired := fee + totalDAOFee
+	if totalIn <= totalRequired {
+		return nil, fmt.Errorf("%w: inputs %d <= fee+dao %d", ErrInsufficientFunds, totalIn, totalRequired)
+	}
+	outputAmount := totalIn - totalRequired
+	if err := dust.payment("batch output", outputAmount); err != nil {
+		return nil, err
+	}
+
+	// Add DAO fee output first (if present), matching single-claim layout
+	if hasDAOOutput {
+		daoScript, err := addressToScript(params.DAOAddress, chainParams)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DAO address: %w", err)
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(totalDAOFee), daoScript))
+	}
+
+	destScript, err := addressToScript(params.DestAddress, chainParams)
+	if err != nil {
+		return nil, fmt.Errorf("invalid destination address: %w", err)
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(outputAmount), destScript))
+
+	// Sign every input (BIP 143 sighash commits to all outputs)
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range params.Inputs {
+		sighash, err := txscript.CalcWitnessSigHash(
+			in.HTLCScript,
+			sigHashes,
+			txscript.SigHashAll,
+			tx,
+			i,
+			int64(in.FundingAmount),
+		)
+		if err != nil {
+			return nil, fmt.Errorf("input %d: failed to compute sighash: %w", i, err)
+		}
+
+		sig := btcecdsa.Sign(in.PrivKey, sighash)
+		sigBytes := append(sig.Serialize(), byte(txscript.SigHashAll))
+
+		if in.IsClaim() {
+			tx.TxIn[i].Witness = BuildHTLCClaimWitness(sigBytes, in.Secret, in.HTLCScript)
+		} else {
+			tx.TxIn[i].Witness = BuildHTLCRefundWitness(sigBytes, in.HTLCScript)
+		}
+	}
+
+	return tx, nil
+}
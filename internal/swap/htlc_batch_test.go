@@ -0,0 +1,285 @@
+package swap
+
+import (
+	"crypto/sha256"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// newTestBatchInput builds an HTLC and returns a batch input spending it.
+// If claim is true the input is spent via the secret path, otherwise via refund.
+func newTestBatchInput(t *testing.T, txid string, amount uint64, claim bool) *HTLCBatchInput {
+	t.Helper()
+
+	receiverKey, _ := btcec.NewPrivateKey()
+	senderKey, _ := btcec.NewPrivateKey()
+
+	secret, secretHash, err := GenerateSecret()
+	if err != nil {
+		t.Fatalf("GenerateSecret() failed: %v", err)
+	}
+
+	script, err := BuildHTLCScript(secretHash, receiverKey.PubKey().SerializeCompressed(),
+		senderKey.PubKey().SerializeCompressed(), 144)
+	if err != nil {
+		t.Fatalf("BuildHTLCScript() failed: %v", err)
+	}
+
+	in := &HTLCBatchInput{
+		FundingTxID:   txid,
+		FundingVout:   0,
+		FundingAmount: amount,
+		HTLCScript:    script,
+	}
+	if claim {
+		in.Secret = secret
+		in.PrivKey = receiverKey
+	} else {
+		in.TimeoutBlocks = 144
+		in.PrivKey = senderKey
+	}
+	return in
+}
+
+// newTestP2WSHAddress returns a valid testnet P2WSH address.
+func newTestP2WSHAddress(t *testing.T) string {
+	t.Helper()
+	key1, _ := btcec.NewPrivateKey()
+	key2, _ := btcec.NewPrivateKey()
+	secretHash := sha256.Sum256([]byte("address"))
+	data, err := BuildHTLCScriptData(secretHash[:], key1.PubKey(), key2.PubKey(), 144, "BTC", chain.Testnet)
+	if err != nil {
+		t.Fatalf("BuildHTLCScriptData() failed: %v", err)
+	}
+	return data.Address
+}
+
+func TestEstimateHTLCBatchVSize(t *testing.T) {
+	claim := &HTLCBatchInput{Secret: make([]byte, 32)}
+	refund := &HTLCBatchInput{}
+
+	tests := []struct {
+		name    string
+		inputs  []*HTLCBatchInput
+		outputs int
+		want    int64
+	}{
+		{"single claim", []*HTLCBatchInput{claim}, 1, 10 + 41 + 52 + 43},
+		{"single refund", []*HTLCBatchInput{refund}, 1, 10 + 41 + 44 + 43},
+		{"mixed with dao", []*HTLCBatchInput{claim, refund, claim}, 2, 10 + 3*41 + 2*52 + 44 + 2*43},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := EstimateHTLCBatchVSize(tt.inputs, tt.outputs); got != tt.want {
+				t.Errorf("EstimateHTLCBatchVSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildHTLCBatchTx(t *testing.T) {
+	claim1 := newTestBatchInput(t, strings.Repeat("11", 32), 100000, true)
+	claim1.DAOFee = 2000
+	claim2 := newTestBatchInput(t, strings.Repeat("22", 32), 50000, true)
+	claim2.DAOFee = 1000
+	refund := newTestBatchInput(t, strings.Repeat("33", 32), 70000, false)
+
+	params := &HTLCBatchTxParams{
+		Symbol:      "BTC",
+		Network:     chain.Testnet,
+		Inputs:      []*HTLCBatchInput{claim1, refund, claim2},
+		DestAddress: newTestP2WSHAddress(t),
+		DAOAddress:  newTestP2WSHAddress(t),
+		FeeRate:     10,
+	}
+
+	tx, err := BuildHTLCBatchTx(params)
+	if err != nil {
+		t.Fatalf("BuildHTLCBatchTx() failed: %v", err)
+	}
+
+	if tx.Version != 2 {
+		t.Errorf("tx version = %d, want 2", tx.Version)
+	}
+	if len(tx.TxIn) != 3 {
+		t.Fatalf("inputs = %d, want 3", len(tx.TxIn))
+	}
+	if len(tx.TxOut) != 2 {
+		t.Fatalf("outputs = %d, want 2 (dao + dest)", len(tx.TxOut))
+	}
+
+	// DAO fees are aggregated into a single output
+	if tx.TxOut[0].Value != 3000 {
+		t.Errorf("DAO output = %d, want 3000", tx.TxOut[0].Value)
+	}
+
+	fee := uint64(EstimateHTLCBatchVSize(params.Inputs, 2)) * params.FeeRate
+	wantDest := int64(100000 + 50000 + 70000 - 3000 - fee)
+	if tx.TxOut[1].Value != wantDest {
+		t.Errorf("dest output = %d, want %d", tx.TxOut[1].Value, wantDest)
+	}
+
+	// Sequence: final for claims, CSV for refunds
+	if tx.TxIn[0].Sequence != wire.MaxTxInSequenceNum {
+		t.Errorf("claim sequence = %d, want max", tx.TxIn[0].Sequence)
+	}
+	if tx.TxIn[1].Sequence != 144 {
+		t.Errorf("refund sequence = %d, want 144", tx.TxIn[1].Sequence)
+	}
+
+	// Every input must pass script verification
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, in := range params.Inputs {
+		hash, _ := chainhash.NewHashFromStr(in.FundingTxID)
+		prevOuts.AddPrevOut(*wire.NewOutPoint(hash, in.FundingVout),
+			wire.NewTxOut(int64(in.FundingAmount), BuildP2WSHScriptPubKey(in.HTLCScript)))
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range params.Inputs {
+		engine, err := txscript.NewEngine(BuildP2WSHScriptPubKey(in.HTLCScript), tx, i,
+			txscript.StandardVerifyFlags, nil, sigHashes, int64(in.FundingAmount), prevOuts)
+		if err != nil {
+			t.Fatalf("input %d: NewEngine() failed: %v", i, err)
+		}
+		if err := engine.Execute(); err != nil {
+			t.Errorf("input %d: script execution failed: %v", i, err)
+		}
+	}
+}
+
+func TestBuildHTLCBatchTxNoDAOOutput(t *testing.T) {
+	refund1 := newTestBatchInput(t, strings.Repeat("44", 32), 60000, false)
+	refund2 := newTestBatchInput(t, strings.Repeat("55", 32), 60000, false)
+
+	tx, err := BuildHTLCBatchTx(&HTLCBatchTxParams{
+		Symbol:      "BTC",
+		Network:     chain.Testnet,
+		Inputs:      []*HTLCBatchInput{refund1, refund2},
+		DestAddress: newTestP2WSHAddress(t),
+		DAOAddress:  newTestP2WSHAddress(t),
+		FeeRate:     5,
+	})
+	if err != nil {
+		t.Fatalf("BuildHTLCBatchTx() failed: %v", err)
+	}
+	if len(tx.TxOut) != 1 {
+		t.Errorf("outputs = %d, want 1 (refunds carry no DAO fee)", len(tx.TxOut))
+	}
+}
+
+func TestBuildHTLCBatchTxValidation(t *testing.T) {
+	dest := newTestP2WSHAddress(t)
+	txid := strings.Repeat("66", 32)
+
+	tests := []struct {
+		name   string
+		inputs func() []*HTLCBatchInput
+		fee    uint64
+		errMsg string
+	}{
+		{
+			name:   "no inputs",
+			inputs: func() []*HTLCBatchInput { return nil },
+			errMsg: "at least one HTLC input",
+		},
+		{
+			name: "bad secret length",
+			inputs: func() []*HTLCBatchInput {
+				in := newTestBatchInput(t, txid, 50000, true)
+				in.Secret = in.Secret[:16]
+				return []*HTLCBatchInput{in}
+			},
+			errMsg: "secret must be 32 bytes",
+		},
+		{
+			name: "refund without timeout",
+			inputs: func() []*HTLCBatchInput {
+				in := newTestBatchInput(t, txid, 50000, false)
+				in.TimeoutBlocks = 0
+				return []*HTLCBatchInput{in}
+			},
+			errMsg: "timeout blocks",
+		},
+		{
+			name: "refund with DAO fee",
+			inputs: func() []*HTLCBatchInput {
+				in := newTestBatchInput(t, txid, 50000, false)
+				in.DAOFee = 100
+				return []*HTLCBatchInput{in}
+			},
+			errMsg: "DAO fee only applies to claims",
+		},
+		{
+			name: "duplicate outpoint",
+			inputs: func() []*HTLCBatchInput {
+				return []*HTLCBatchInput{
+					newTestBatchInput(t, txid, 50000, true),
+					newTestBatchInput(t, txid, 50000, false),
+				}
+			},
+			errMsg: "duplicate outpoint",
+		},
+		{
+			name: "insufficient funds",
+			inputs: func() []*HTLCBatchInput {
+				return []*HTLCBatchInput{newTestBatchInput(t, txid, 1000, true)}
+			},
+			fee:    100,
+			errMsg: "insufficient funds",
+		},
+		{
+			name: "invalid txid",
+			inputs: func() []*HTLCBatchInput {
+				return []*HTLCBatchInput{newTestBatchInput(t, "nothex", 50000, true)}
+			},
+			errMsg: "invalid transaction ID",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			feeRate := tt.fee
+			if feeRate == 0 {
+				feeRate = 1
+			}
+			_, err := BuildHTLCBatchTx(&HTLCBatchTxParams{
+				Symbol:      "BTC",
+				Network:     chain.Testnet,
+				Inputs:      tt.inputs(),
+				DestAddress: dest,
+				FeeRate:     feeRate,
+			})
+			if err == nil {
+				t.Fatal("BuildHTLCBatchTx() expected error")
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("error = %q, want containing %q", err.Error(), tt.errMsg)
+			}
+		})
+	}
+}
+
+func TestRestoreHTLCChainData(t *testing.T) {
+	if restoreHTLCChainData(nil) != nil {
+		t.Error("restoreHTLCChainData(nil) should return nil")
+	}
+
+	data := restoreHTLCChainData(&HTLCChainStorageData{
+		Symbol:      "BTC",
+		HTLCAddress: "tb1qexample",
+		ClaimTxID:   "batchtx",
+	})
+	if data.HTLCAddress != "tb1qexample" || data.ClaimTxID != "batchtx" {
+		t.Errorf("restored data mismatch: %+v", data)
+	}
+	if data.Session != nil {
+		t.Error("session should be nil without session data")
+	}
+}
@@ -3,6 +3,7 @@ package swap
 
 import (
 	"context"
+	"runtime"
 	"sync"
 	"time"
 
@@ -19,6 +20,9 @@ type Monitor struct {
 	// Polling interval
 	interval time.Duration
 
+	// Swaps checked concurrently
+	workers int
+
 	// Context for background operations
 	ctx    context.Context
 	cancel context.CancelFunc
@@ -31,6 +35,7 @@ type MonitorConfig struct {
 	Coordinator *Coordinator
 	Backends    map[string]backend.Backend
 	Interval    time.Duration // Polling interval, default 30s
+	Workers     int           // Swaps checked concurrently, default GOMAXPROCS
 }
 
 // NewMonitor creates a new confirmation monitor.
@@ -41,12 +46,17 @@ func NewMonitor(cfg *MonitorConfig) *Monitor {
 	if interval == 0 {
 		interval = 30 * time.Second
 	}
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
 
 	return &Monitor{
 		coordinator: cfg.Coordinator,
 		backends:    cfg.Backends,
 		log:         logging.GetDefault().Component("swap-monitor"),
 		interval:    interval,
+		workers:     workers,
 		ctx:         ctx,
 		cancel:      cancel,
 	}
@@ -102,11 +112,25 @@ func (m *Monitor) checkAllSwaps() {
 	}
 	m.coordinator.mu.RUnlock()
 
+	// Confirmation updates of different swaps don't block each other
+	work := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < m.workers && i < len(swapIDs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tradeID := range work {
+				if err := m.checkSwapConfirmations(tradeID); err != nil {
+					m.log.Debug("Error checking confirmations", "trade_id", tradeID, "error", err)
+				}
+			}
+		}()
+	}
 	for _, tradeID := range swapIDs {
-		if err := m.checkSwapConfirmations(tradeID); err != nil {
-			m.log.Debug("Error checking confirmations", "trade_id", tradeID, "error", err)
-		}
+		work <- tradeID
 	}
+	close(work)
+	wg.Wait()
 }
 
 // checkSwapConfirmations checks confirmations for a specific swap.
@@ -9,6 +9,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/btcec/v2/schnorr"
@@ -343,6 +344,17 @@ func encodeTaprootAddress(pubKey *btcec.PublicKey, hrp string) (string, error) {
 // SECURITY: If previous nonces exist, they are marked as used to prevent reuse.
 // This is critical because reusing MuSig2 nonces LEAKS THE PRIVATE KEY.
 func (s *MuSig2Session) GenerateNonces() (*musig2.Nonces, error) {
+	return s.generateNonces()
+}
+
+// GenerateNoncesFrom generates local nonces drawing their randomness from r.
+// It exists for reproducible test vectors: a session must never sign real
+// funds with nonces from a reader whose output anyone else can predict.
+func (s *MuSig2Session) GenerateNoncesFrom(r io.Reader) (*musig2.Nonces, error) {
+	return s.generateNonces(musig2.WithCustomRand(r))
+}
+
+func (s *MuSig2Session) generateNonces(opts ...musig2.NonceGenOption) (*musig2.Nonces, error) {
 	// SECURITY: Mark previous nonce as used if it exists
 	if s.localNonces != nil {
 		s.usedNonces[s.localNonces.PubNonce] = true
@@ -353,7 +365,7 @@ func (s *MuSig2Session) GenerateNonces() (*musig2.Nonces, error) {
 	s.invalidated = false
 
 	nonces, err := musig2.GenNonces(
-		musig2.WithPublicKey(s.localPubKey),
+		append([]musig2.NonceGenOption{musig2.WithPublicKey(s.localPubKey)}, opts...)...,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate nonces: %w", err)
@@ -619,6 +631,16 @@ func (s *MuSig2Session) GetLocalPrivKey() *btcec.PrivateKey {
 	return s.localPrivKey
 }
 
+// Zeroize overwrites the local private key and secret nonce once the
+// session is no longer needed. It reports whether either was still held.
+func (s *MuSig2Session) Zeroize() bool {
+	held := zeroizePrivKey(s.localPrivKey)
+	if s.localNonces != nil {
+		held = zeroizeBytes(s.localNonces.SecNonce[:]) || held
+	}
+	return held
+}
+
 // SerializePrivKey serializes a private key to 32 bytes.
 func SerializePrivKey(privKey *btcec.PrivateKey) []byte {
 	return privKey.Serialize()
@@ -1,6 +1,8 @@
 package swap
 
 import (
+	"bytes"
+	"errors"
 	"testing"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -202,6 +204,31 @@ func TestMuSig2SessionNonceGeneration(t *testing.T) {
 	}
 }
 
+func TestMuSig2SessionNoncesFrom(t *testing.T) {
+	privKey, _ := btcec.NewPrivateKey()
+	rand := bytes.Repeat([]byte{7}, 32)
+
+	var pubNonces [2][66]byte
+	for i := range pubNonces {
+		session, _ := NewMuSig2Session("BTC", chain.Testnet, privKey)
+		nonces, err := session.GenerateNoncesFrom(bytes.NewReader(rand))
+		if err != nil {
+			t.Fatalf("GenerateNoncesFrom() error = %v", err)
+		}
+		pubNonces[i] = nonces.PubNonce
+	}
+	if pubNonces[0] != pubNonces[1] {
+		t.Error("the same key and rand gave different nonces")
+	}
+
+	// A session refuses to regenerate a nonce it already had
+	session, _ := NewMuSig2Session("BTC", chain.Testnet, privKey)
+	session.GenerateNoncesFrom(bytes.NewReader(rand))
+	if _, err := session.GenerateNoncesFrom(bytes.NewReader(rand)); !errors.Is(err, ErrNonceReuse) {
+		t.Errorf("regenerated nonce error = %v, want ErrNonceReuse", err)
+	}
+}
+
 func TestMuSig2FullSigningFlow(t *testing.T) {
 	// Create two sessions
 	alicePrivKey, _ := btcec.NewPrivateKey()
@@ -0,0 +1,207 @@
+// Package swap - Offer validation shared by every entry point.
+//
+// An offer reaches the coordinator from RPC (swap_init, swap_initCrossChain)
+// and from peers (order_take). All of them check it with ValidateOffer, which
+// reports every problem found instead of stopping at the first, so a client
+// or a log line sees the whole picture.
+package swap
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+// ErrInvalidOffer is matched by every *OfferValidationError.
+var ErrInvalidOffer = errors.New("invalid offer")
+
+// Offer violation codes.
+const (
+	ViolationUnsupportedChain  = "unsupported_chain"
+	ViolationSameChain         = "same_chain"
+	ViolationUnsupportedMethod = "unsupported_method"
+	ViolationInvalidToken      = "invalid_token"
+	ViolationAmountTooLow      = "amount_too_low"
+	ViolationAmountTooHigh     = "amount_too_high"
+	ViolationInvalidFeeTerms   = "invalid_fee_terms"
+	ViolationUnsafeTimelock    = "unsafe_timelock"
+)
+
+// OfferViolation is one problem with an offer.
+type OfferViolation struct {
+	Field   string `json:"field"` // Offer field, e.g. "offer_amount"
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// OfferValidationError lists the problems found in an offer.
+type OfferValidationError struct {
+	Violations []OfferViolation
+}
+
+func (e *OfferValidationError) Error() string {
+	parts := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		parts[i] = v.Field + ": " + v.Message
+	}
+	return strings.Join(parts, "; ")
+}
+
+// Unwrap makes errors.Is(err, ErrInvalidOffer) hold.
+func (e *OfferValidationError) Unwrap() error { return ErrInvalidOffer }
+
+func (e *OfferValidationError) add(field, code, format string, args ...interface{}) {
+	e.Violations = append(e.Violations, OfferViolation{Field: field, Code: code, Message: fmt.Sprintf(format, args...)})
+}
+
+// ValidateOffer checks that both chains are supported and distinct, the
+// method works on both, amounts are within the coin limits, fee terms are
+// consistent and the chains' timelocks are safe. It returns nil or an
+// *OfferValidationError with every violation.
+func ValidateOffer(o Offer, network chain.Network) error {
+	e := &OfferValidationError{}
+
+	offerCfg, err := NewChainConfig(o.OfferChain, network)
+	if err != nil {
+		e.add("offer_chain", ViolationUnsupportedChain, "%v", err)
+	}
+	requestCfg, err := NewChainConfig(o.RequestChain, network)
+	if err != nil {
+		e.add("request_chain", ViolationUnsupportedChain, "%v", err)
+	}
+	if o.OfferChain != "" && o.OfferChain == o.RequestChain {
+		e.add("request_chain", ViolationSameChain, "must differ from offer chain %s", o.OfferChain)
+	}
+
+	legs := []struct {
+		prefix string
+		cfg    *ChainConfig
+		symbol string
+		token  string
+		amount uint64
+	}{
+		{"offer", offerCfg, o.OfferChain, o.OfferToken, o.OfferAmount},
+		{"request", requestCfg, o.RequestChain, o.RequestToken, o.RequestAmount},
+	}
+	for _, leg := range legs {
+		if leg.cfg == nil {
+			continue
+		}
+		if !leg.cfg.SupportsMethod(o.Method) {
+			e.add("method", ViolationUnsupportedMethod, "%s does not support %s", leg.symbol, o.Method)
+		}
+		validateOfferAmount(e, leg.prefix, leg.symbol, leg.token, leg.amount, network)
+	}
+
+	if o.Method == MethodAdaptor && offerCfg != nil && requestCfg != nil &&
+		IsMoneroChain(o.OfferChain, network) == IsMoneroChain(o.RequestChain, network) {
+		e.add("method", ViolationUnsupportedMethod, "%s swaps need one XMR leg and one BTC-family leg", o.Method)
+	}
+
+	validateOfferFeeTerms(e, o, network)
+	if offerCfg != nil && requestCfg != nil {
+		validateOfferTimelocks(e, o, network)
+	}
+
+	if len(e.Violations) > 0 {
+		return e
+	}
+	return nil
+}
+
+// validateOfferAmount checks one leg's amount. Coin limits are in native
+// units, so token legs only need a registered token and a non-zero amount.
+func validateOfferAmount(e *OfferValidationError, prefix, symbol, token string, amount uint64, network chain.Network) {
+	if token != "" {
+		if _, err := ResolveToken(symbol, token, network); err != nil {
+			e.add(prefix+"_token", ViolationInvalidToken, "%v", err)
+		}
+		if amount == 0 {
+			e.add(prefix+"_amount", ViolationAmountTooLow, "must be positive")
+		}
+		return
+	}
+
+	coin, _ := config.GetCoin(symbol)
+	if amount == 0 || amount < coin.MinAmount {
+		e.add(prefix+"_amount", ViolationAmountTooLow, "below minimum: %d < %d", amount, coin.MinAmount)
+	}
+	if coin.MaxAmount > 0 && amount > coin.MaxAmount {
+		e.add(prefix+"_amount", ViolationAmountTooHigh, "above maximum: %d > %d", amount, coin.MaxAmount)
+	}
+}
+
+func validateOfferFeeTerms(e *OfferValidationError, o Offer, network chain.Network) {
+	if err := o.FeeTerms.Validate(o.OfferAmount, o.RequestAmount); err != nil {
+		e.add("fee_terms", ViolationInvalidFeeTerms, "%v", err)
+	}
+	if o.FeeTerms.SplitsFees() {
+		// TODO: EVM HTLC contracts charge the DAO fee and gas on-chain; negotiated
+		// fee splits only apply to Bitcoin-family legs for now.
+		for _, symbol := range []string{o.OfferChain, o.RequestChain} {
+			if IsEVMChain(symbol, network) {
+				e.add("fee_terms", ViolationInvalidFeeTerms, "fee terms are not supported for EVM chain %s", symbol)
+			}
+		}
+	}
+	if o.FeeTerms.SplitsFees() && o.Method == MethodAdaptor {
+		// Each party's DAO fee is an output of its own BTC transaction
+		e.add("fee_terms", ViolationInvalidFeeTerms, "fee terms are not supported for %s swaps", o.Method)
+	}
+	if o.FeeTerms.MetaClaimFeeBps > 0 && !IsEVMChain(o.OfferChain, network) && !IsEVMChain(o.RequestChain, network) {
+		e.add("fee_terms", ViolationInvalidFeeTerms, "meta_claim_fee_bps requires an EVM leg")
+	}
+}
+
+// validateOfferTimelocks checks the block timeouts of the Bitcoin-family
+// legs. Each chain's maker timeout must exceed its taker timeout by a safe
+// margin, and when both legs are script-locked the initiator's lock on the
+// offer chain must outlast the responder's lock on the request chain in
+// wall-clock time, or the initiator could be refunded before the responder
+// has a chance to claim.
+func validateOfferTimelocks(e *OfferValidationError, o Offer, network chain.Network) {
+	isTestnet := network == chain.Testnet
+	var offerTimeout, requestTimeout *config.ChainTimeoutConfig
+	for _, leg := range []struct {
+		symbol string
+		dst    **config.ChainTimeoutConfig
+	}{
+		{o.OfferChain, &offerTimeout},
+		{o.RequestChain, &requestTimeout},
+	} {
+		if IsEVMChain(leg.symbol, network) {
+			continue // The HTLC contract holds the timelock
+		}
+		if IsMoneroChain(leg.symbol, network) {
+			continue // The BTC leg's cancel and punish paths cover both legs
+		}
+		timeouts, ok := config.GetChainTimeout(leg.symbol, isTestnet)
+		if !ok {
+			e.add("timelock", ViolationUnsafeTimelock, "no timeout configuration for %s", leg.symbol)
+			continue
+		}
+		if err := ValidateTimeoutRelationship(timeouts.MakerBlocks, timeouts.TakerBlocks); err != nil {
+			e.add("timelock", ViolationUnsafeTimelock, "%s: %v", leg.symbol, err)
+			continue
+		}
+		if timeouts.TakerBlocks <= timeouts.SafetyMarginBlocks {
+			e.add("timelock", ViolationUnsafeTimelock, "%s: taker timeout (%d) must exceed the safety margin (%d)",
+				leg.symbol, timeouts.TakerBlocks, timeouts.SafetyMarginBlocks)
+			continue
+		}
+		*leg.dst = &timeouts
+	}
+
+	if offerTimeout == nil || requestTimeout == nil {
+		return
+	}
+	makerSecs := uint64(offerTimeout.MakerBlocks) * uint64(offerTimeout.AvgBlockTimeSeconds)
+	takerSecs := uint64(requestTimeout.TakerBlocks) * uint64(requestTimeout.AvgBlockTimeSeconds)
+	if makerSecs <= takerSecs {
+		e.add("timelock", ViolationUnsafeTimelock, "initiator lock on %s (~%ds) must outlast responder lock on %s (~%ds)",
+			o.OfferChain, makerSecs, o.RequestChain, takerSecs)
+	}
+}
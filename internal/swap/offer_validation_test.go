@@ -0,0 +1,115 @@
+package swap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+func violationCodes(t *testing.T, err error) map[string]string {
+	t.Helper()
+	var verr *OfferValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("error = %v, want *OfferValidationError", err)
+	}
+	if !errors.Is(err, ErrInvalidOffer) {
+		t.Error("errors.Is(err, ErrInvalidOffer) = false")
+	}
+	codes := make(map[string]string)
+	for _, v := range verr.Violations {
+		codes[v.Field] = v.Code
+	}
+	return codes
+}
+
+func TestValidateOfferReportsEveryViolation(t *testing.T) {
+	err := ValidateOffer(Offer{
+		OfferChain:    "LTC",
+		OfferAmount:   1,
+		RequestChain:  "LTC",
+		RequestAmount: 0,
+		Method:        MethodHTLC,
+		FeeTerms:      FeeTerms{MetaClaimFeeBps: 10},
+	}, chain.Testnet)
+
+	codes := violationCodes(t, err)
+	want := map[string]string{
+		"request_chain":  ViolationSameChain,
+		"offer_amount":   ViolationAmountTooLow,
+		"request_amount": ViolationAmountTooLow,
+		"fee_terms":      ViolationInvalidFeeTerms,
+	}
+	for field, code := range want {
+		if codes[field] != code {
+			t.Errorf("violation on %s = %q, want %q (all: %v)", field, codes[field], code, codes)
+		}
+	}
+}
+
+func TestValidateOfferChainsAndMethod(t *testing.T) {
+	codes := violationCodes(t, ValidateOffer(Offer{
+		OfferChain: "NOPE", OfferAmount: 100000,
+		RequestChain: "ETH", RequestAmount: 10_000_000_000_000_000,
+		Method: MethodMuSig2,
+	}, chain.Testnet))
+	if codes["offer_chain"] != ViolationUnsupportedChain {
+		t.Errorf("offer_chain violation = %q", codes["offer_chain"])
+	}
+	if codes["method"] != ViolationUnsupportedMethod {
+		t.Errorf("method violation = %q, want musig2 rejected on ETH", codes["method"])
+	}
+}
+
+func TestValidateOfferAdaptor(t *testing.T) {
+	offer := Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "XMR", RequestAmount: 50_000_000_000,
+		Method: MethodAdaptor,
+	}
+	if err := ValidateOffer(offer, chain.Testnet); err != nil {
+		t.Fatalf("ValidateOffer() error = %v", err)
+	}
+
+	noXMR := offer
+	noXMR.RequestChain, noXMR.RequestAmount = "LTC", 1000000
+	if codes := violationCodes(t, ValidateOffer(noXMR, chain.Testnet)); codes["method"] != ViolationUnsupportedMethod {
+		t.Errorf("codes = %v, want an adaptor swap without an XMR leg rejected", codes)
+	}
+
+	split := offer
+	split.FeeTerms = FeeTerms{ClaimFeeAllowance: 1000}
+	if codes := violationCodes(t, ValidateOffer(split, chain.Testnet)); codes["fee_terms"] != ViolationInvalidFeeTerms {
+		t.Errorf("codes = %v, want split fee terms rejected", codes)
+	}
+}
+
+func TestValidateOfferTimelocks(t *testing.T) {
+	offer := Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 1000000,
+		Method: MethodHTLC,
+	}
+	if err := ValidateOffer(offer, chain.Testnet); err != nil {
+		t.Fatalf("ValidateOffer() error = %v", err)
+	}
+
+	saved := config.TestnetChainTimeouts["LTC"]
+	defer func() { config.TestnetChainTimeouts["LTC"] = saved }()
+
+	// The responder's LTC lock would outlast the initiator's BTC lock
+	long := saved
+	long.MakerBlocks, long.TakerBlocks = 2000, 1000
+	config.TestnetChainTimeouts["LTC"] = long
+	if codes := violationCodes(t, ValidateOffer(offer, chain.Testnet)); codes["timelock"] != ViolationUnsafeTimelock {
+		t.Errorf("codes = %v, want unsafe timelock", codes)
+	}
+
+	inverted := saved
+	inverted.MakerBlocks, inverted.TakerBlocks = saved.TakerBlocks, saved.MakerBlocks
+	config.TestnetChainTimeouts["LTC"] = inverted
+	if codes := violationCodes(t, ValidateOffer(offer, chain.Testnet)); codes["timelock"] != ViolationUnsafeTimelock {
+		t.Errorf("codes = %v, want maker timeout below taker timeout rejected", codes)
+	}
+}
@@ -0,0 +1,174 @@
+// Package swap - Chain-aware amount precision and rounding.
+//
+// Amounts are integers in each asset's smallest unit, but the units differ
+// widely: 8 decimals for BTC, 12 for XMR, 18 for ETH. Amounts a node derives
+// rather than reads off an order (a fee adjustment converted at the order's
+// rate, a partial fill) are quoted at a coarser precision on chains with many
+// decimals, so the last digits of two independent computations can't make
+// the parties disagree by one base unit. The rules:
+//
+//   - a leg is quoted to QuoteDecimals, by default the asset's decimals
+//     capped at MaxQuoteDecimals; a node may configure fewer
+//   - the parties quote at the lower of their two precisions (a peer that
+//     doesn't send one quotes exactly, in base units)
+//   - quotes are computed exactly with integers and rounded half to even to
+//     a multiple of the leg's step, never to zero
+//
+// Quoted amounts enter the terms digest as part of the amounts they change,
+// so any remaining disagreement is caught before funding.
+package swap
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// MaxQuoteDecimals caps the quote precision of an asset, the precision of
+// BTC.
+const MaxQuoteDecimals = 8
+
+// ErrAmountPrecision is returned for a quoted amount that isn't a multiple of
+// its leg's step.
+var ErrAmountPrecision = errors.New("amount not at the quote precision")
+
+// Precision is the precision amounts of one leg are quoted at.
+type Precision struct {
+	Decimals      uint8 `json:"decimals"`       // Of the asset's smallest unit
+	QuoteDecimals uint8 `json:"quote_decimals"` // Quoted amounts are multiples of 10^-QuoteDecimals
+}
+
+// Step returns the smallest quoted increment, in base units.
+func (p Precision) Step() uint64 {
+	step := uint64(1)
+	for d := p.QuoteDecimals; d < p.Decimals; d++ {
+		step *= 10
+	}
+	return step
+}
+
+// AssetPrecision returns the default precision of a leg: its chain's native
+// coin, or the registered ERC-20 token when token is set. quoteDecimals, when
+// non-zero, lowers the quote precision below the default.
+func AssetPrecision(symbol, token string, network chain.Network, quoteDecimals uint8) (Precision, error) {
+	var decimals uint8
+	if token != "" {
+		info, err := ResolveToken(symbol, token, network)
+		if err != nil {
+			return Precision{}, err
+		}
+		decimals = info.Decimals
+	} else {
+		params, ok := chain.Get(strings.ToUpper(symbol), network)
+		if !ok {
+			return Precision{}, fmt.Errorf("unsupported chain: %s", symbol)
+		}
+		decimals = params.Decimals
+	}
+
+	p := Precision{Decimals: decimals, QuoteDecimals: decimals}
+	if p.QuoteDecimals > MaxQuoteDecimals {
+		p.QuoteDecimals = MaxQuoteDecimals
+	}
+	if quoteDecimals != 0 && quoteDecimals < p.QuoteDecimals {
+		p.QuoteDecimals = quoteDecimals
+	}
+	return p, nil
+}
+
+// Negotiate returns the precision both parties quote at, given the
+// counterparty's quote decimals for the leg. 0 is a peer that predates
+// precision negotiation and quotes in base units.
+func (p Precision) Negotiate(theirs uint8) Precision {
+	if theirs == 0 {
+		p.QuoteDecimals = p.Decimals
+		return p
+	}
+	if theirs < p.QuoteDecimals {
+		p.QuoteDecimals = theirs
+	}
+	return p
+}
+
+// RoundAmount rounds an amount half to even to a multiple of the step. A
+// positive amount never rounds to zero.
+func (p Precision) RoundAmount(amount uint64) uint64 {
+	rounded := roundHalfEven(new(big.Int).SetUint64(amount), p.Step())
+	if rounded.Sign() == 0 && amount > 0 {
+		return p.Step()
+	}
+	return saturateUint64(rounded)
+}
+
+// RoundAdjustment rounds a signed amount change half to even to a multiple
+// of the step, symmetrically around zero.
+func (p Precision) RoundAdjustment(adjustment int64) int64 {
+	rounded := roundHalfEven(big.NewInt(adjustment), p.Step())
+	if !rounded.IsInt64() {
+		return adjustment // Only within one step of the int64 limits
+	}
+	return rounded.Int64()
+}
+
+// Quote converts an amount at the rate fromAmount:toAmount and rounds the
+// result to the step. A positive result never rounds to zero.
+func (p Precision) Quote(amount, fromAmount, toAmount uint64) (uint64, error) {
+	if fromAmount == 0 {
+		return 0, fmt.Errorf("rate has a zero amount")
+	}
+	v := new(big.Int).SetUint64(amount)
+	v.Mul(v, new(big.Int).SetUint64(toAmount))
+	q := quoHalfEven(v, new(big.Int).SetUint64(fromAmount))
+	if q.Sign() == 0 && v.Sign() > 0 {
+		return p.Step(), nil
+	}
+	if !q.IsUint64() {
+		return 0, fmt.Errorf("quoted amount overflows")
+	}
+	return p.RoundAmount(q.Uint64()), nil
+}
+
+// CheckAmount returns ErrAmountPrecision when amount isn't a multiple of the
+// step.
+func (p Precision) CheckAmount(amount int64) error {
+	step := p.Step()
+	if amount < 0 {
+		amount = -amount
+	}
+	if uint64(amount)%step != 0 {
+		return fmt.Errorf("%w: %d is not a multiple of %d", ErrAmountPrecision, amount, step)
+	}
+	return nil
+}
+
+// roundHalfEven rounds v to the nearest multiple of step, ties to the even
+// multiple.
+func roundHalfEven(v *big.Int, step uint64) *big.Int {
+	if step <= 1 {
+		return v
+	}
+	s := new(big.Int).SetUint64(step)
+	q := quoHalfEven(new(big.Int).Abs(v), s)
+	q.Mul(q, s)
+	if v.Sign() < 0 {
+		q.Neg(q)
+	}
+	return q
+}
+
+// quoHalfEven divides non-negative integers, rounding half to even.
+func quoHalfEven(x, y *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(x, y, new(big.Int))
+	switch r.Lsh(r, 1).Cmp(y) {
+	case 1:
+		q.Add(q, big.NewInt(1))
+	case 0:
+		if q.Bit(0) == 1 {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
@@ -0,0 +1,124 @@
+package swap
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestAssetPrecision(t *testing.T) {
+	tests := []struct {
+		symbol, token string
+		quoteDecimals uint8
+		want          Precision
+		step          uint64
+	}{
+		{"BTC", "", 0, Precision{8, 8}, 1},
+		{"XMR", "", 0, Precision{12, 8}, 10000},
+		{"ETH", "", 0, Precision{18, 8}, 10000000000},
+		{"eth", "", 6, Precision{18, 6}, 1000000000000},
+		{"ETH", "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", 0, Precision{6, 6}, 1},
+		{"BTC", "", 10, Precision{8, 8}, 1}, // Can't be finer than the asset
+	}
+	for _, tt := range tests {
+		got, err := AssetPrecision(tt.symbol, tt.token, chain.Mainnet, tt.quoteDecimals)
+		if err != nil {
+			t.Fatalf("AssetPrecision(%s, %s) error = %v", tt.symbol, tt.token, err)
+		}
+		if got != tt.want || got.Step() != tt.step {
+			t.Errorf("AssetPrecision(%s, %s, %d) = %+v step %d, want %+v step %d", tt.symbol, tt.token, tt.quoteDecimals, got, got.Step(), tt.want, tt.step)
+		}
+	}
+	if _, err := AssetPrecision("FOO", "", chain.Mainnet, 0); err == nil {
+		t.Error("AssetPrecision() accepted an unknown chain")
+	}
+	if _, err := AssetPrecision("ETH", "0x0000000000000000000000000000000000000001", chain.Mainnet, 0); err == nil {
+		t.Error("AssetPrecision() accepted an unregistered token")
+	}
+}
+
+func TestPrecisionNegotiate(t *testing.T) {
+	eth := Precision{Decimals: 18, QuoteDecimals: 8}
+	if got := eth.Negotiate(6); got.QuoteDecimals != 6 {
+		t.Errorf("Negotiate(6) = %+v, want the lower precision", got)
+	}
+	if got := eth.Negotiate(10); got.QuoteDecimals != 8 {
+		t.Errorf("Negotiate(10) = %+v, want ours", got)
+	}
+	if got := eth.Negotiate(0); got.QuoteDecimals != 18 || got.Step() != 1 {
+		t.Errorf("Negotiate(0) = %+v, want base units", got)
+	}
+}
+
+func TestPrecisionRounding(t *testing.T) {
+	p := Precision{Decimals: 4, QuoteDecimals: 2} // Step 100
+	amounts := []struct{ in, want uint64 }{
+		{0, 0},
+		{1, 100}, // Never to zero
+		{149, 100},
+		{150, 200}, // Tie to even
+		{250, 200},
+		{251, 300},
+		{^uint64(0), 18446744073709551600},
+	}
+	for _, tt := range amounts {
+		if got := p.RoundAmount(tt.in); got != tt.want {
+			t.Errorf("RoundAmount(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	adjustments := []struct{ in, want int64 }{
+		{0, 0},
+		{49, 0},
+		{-49, 0},
+		{150, 200},
+		{-150, -200}, // Symmetric
+		{-250, -200},
+	}
+	for _, tt := range adjustments {
+		if got := p.RoundAdjustment(tt.in); got != tt.want {
+			t.Errorf("RoundAdjustment(%d) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+
+	if err := p.CheckAmount(-300); err != nil {
+		t.Errorf("CheckAmount(-300) error = %v", err)
+	}
+	if err := p.CheckAmount(301); !errors.Is(err, ErrAmountPrecision) {
+		t.Errorf("CheckAmount(301) error = %v, want ErrAmountPrecision", err)
+	}
+}
+
+func TestPrecisionQuote(t *testing.T) {
+	eth := Precision{Decimals: 18, QuoteDecimals: 8}
+
+	// 0.3 BTC of an order selling 1 BTC for 15.3 ETH
+	got, err := eth.Quote(30000000, 100000000, 15300000000000000000)
+	if err != nil || got != 4590000000000000000 {
+		t.Errorf("Quote() = %d, %v", got, err)
+	}
+	// 1 sat at 1 BTC for 3 ETH is 0.00000003 ETH, whichever side computes it
+	got, err = eth.Quote(1, 100000000, 3000000000000000000)
+	if err != nil || got != 30000000000 {
+		t.Errorf("Quote(1 sat) = %d, %v", got, err)
+	}
+	// 1/3 of a step rounds down, 2/3 up
+	exact := Precision{Decimals: 8, QuoteDecimals: 8}
+	if got, _ := exact.Quote(1, 3, 1); got != 1 {
+		t.Errorf("Quote(1/3) = %d, want 1 (never zero)", got)
+	}
+	if got, _ := exact.Quote(5, 3, 1); got != 2 {
+		t.Errorf("Quote(5/3) = %d, want 2", got)
+	}
+	if got, _ := exact.Quote(5, 2, 1); got != 2 {
+		t.Errorf("Quote(5/2) = %d, want 2 (tie to even)", got)
+	}
+
+	if _, err := eth.Quote(1, 0, 1); err == nil {
+		t.Error("Quote() accepted a zero rate")
+	}
+	if _, err := exact.Quote(^uint64(0), 1, 2); err == nil {
+		t.Error("Quote() accepted an overflow")
+	}
+}
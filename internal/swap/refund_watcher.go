@@ -0,0 +1,243 @@
+// Package swap - Automatic refund of our expired swap locks.
+//
+// A counterparty that disappears after we funded leaves our coins in the
+// swap output until its timelock expires. The refund watcher checks every
+// funded swap against the tip of the chain we locked on and, once our refund
+// path is open, refunds it the way the swap locked it: the HTLC timeout
+// branch, the Taproot refund script path of MuSig2 swaps, or refund() on
+// the EVM HTLC contract. Every attempt is emitted as an auto_refund_* event.
+package swap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Refund watcher events, emitted once per outcome.
+const (
+	EventAutoRefundBroadcast = "auto_refund_broadcast" // Refund broadcast
+	EventAutoRefundFailed    = "auto_refund_failed"    // Refund failed, retried on the next check
+)
+
+// RefundWatcherPolicy configures the refund watcher.
+type RefundWatcherPolicy struct {
+	// Enabled refunds expired swaps without an RPC call.
+	Enabled bool `yaml:"enabled" json:"enabled"`
+
+	// CheckInterval is how often funded swaps are checked.
+	CheckInterval time.Duration `yaml:"check_interval,omitempty" json:"check_interval,omitempty"`
+
+	// MarginBlocks waits this many blocks past a block-based timelock
+	// before refunding, so a reorg can't make the refund non-final.
+	MarginBlocks uint32 `yaml:"margin_blocks,omitempty" json:"margin_blocks,omitempty"`
+}
+
+// RefundAttempt is the outcome of one automatic refund.
+type RefundAttempt struct {
+	TradeID string `json:"trade_id"`
+	Chain   string `json:"chain"`
+	Method  Method `json:"method"`
+	TxID    string `json:"txid,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// RefundWatcher refunds our side of funded swaps whose refund path opened.
+type RefundWatcher struct {
+	coordinator *Coordinator
+	policy      RefundWatcherPolicy
+	log         *logging.Logger
+
+	mu        sync.Mutex
+	lastError map[string]string // Last error per trade, to emit each once
+	refunded  map[string]string // Refund txid per trade
+}
+
+// NewRefundWatcher creates a refund watcher of the coordinator's swaps.
+func NewRefundWatcher(c *Coordinator, policy RefundWatcherPolicy) *RefundWatcher {
+	return &RefundWatcher{
+		coordinator: c,
+		policy:      policy,
+		log:         logging.GetDefault().Component("refund-watcher"),
+		lastError:   make(map[string]string),
+		refunded:    make(map[string]string),
+	}
+}
+
+// Start checks swaps every CheckInterval until the coordinator closes.
+func (w *RefundWatcher) Start() {
+	if !w.policy.Enabled {
+		return
+	}
+	if w.policy.CheckInterval <= 0 {
+		w.log.Warn("Refund watcher not started: check interval not set")
+		return
+	}
+
+	ctx := w.coordinator.ctx
+	go func() {
+		ticker := time.NewTicker(w.policy.CheckInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				w.Check(ctx)
+			}
+		}
+	}()
+}
+
+// refundDue is a funded swap whose refund path is open.
+type refundDue struct {
+	tradeID string
+	chain   string
+	method  Method
+	evm     bool
+}
+
+// Check refunds every funded swap whose timelock expired and returns the
+// attempts made.
+func (w *RefundWatcher) Check(ctx context.Context) []RefundAttempt {
+	var attempts []RefundAttempt
+	for _, due := range w.dueRefunds(ctx) {
+		attempt := RefundAttempt{TradeID: due.tradeID, Chain: due.chain, Method: due.method}
+		txID, err := w.refund(ctx, due)
+		if err != nil {
+			attempt.Error = err.Error()
+		} else {
+			attempt.TxID = txID
+		}
+		w.record(attempt)
+		attempts = append(attempts, attempt)
+	}
+	return attempts
+}
+
+// dueRefunds returns the swaps we funded whose refund path is open.
+// Heights are fetched once per chain.
+func (w *RefundWatcher) dueRefunds(ctx context.Context) []refundDue {
+	c := w.coordinator
+
+	type candidate struct {
+		due   refundDue
+		point refundPoint
+	}
+	var candidates []candidate
+	w.mu.Lock()
+	c.mu.RLock()
+	for tradeID, active := range c.swaps {
+		if _, done := w.refunded[tradeID]; done {
+			continue
+		}
+		if active.Swap.State != StateFunding && active.Swap.State != StateFunded {
+			continue
+		}
+		ours, _ := refundPointsUnlocked(active)
+		if !ours.known() {
+			continue
+		}
+		due := refundDue{tradeID: tradeID, chain: ours.chain, method: active.Swap.Offer.Method}
+		due.evm = IsEVMChain(ours.chain, c.network)
+		if !lockedUnlocked(active, due) {
+			continue // Nothing of ours to refund
+		}
+		candidates = append(candidates, candidate{due, ours})
+	}
+	for _, seen := range []map[string]string{w.refunded, w.lastError} {
+		for tradeID := range seen {
+			if _, ok := c.swaps[tradeID]; !ok {
+				delete(seen, tradeID)
+			}
+		}
+	}
+	now := time.Now().Add(c.clockOffset)
+	c.mu.RUnlock()
+	w.mu.Unlock()
+
+	var due []refundDue
+	heights := make(map[string]uint32)
+	for _, cand := range candidates {
+		if cand.point.timelock > 0 {
+			if now.Unix() >= cand.point.timelock {
+				due = append(due, cand.due)
+			}
+			continue
+		}
+		heights = c.deadlineHeights(ctx, heights, cand.point)
+		height, ok := heights[cand.point.chain]
+		if ok && uint64(height) >= uint64(cand.point.height)+uint64(w.policy.MarginBlocks) {
+			due = append(due, cand.due)
+		}
+	}
+	return due
+}
+
+// lockedUnlocked returns true if we funded our side of the swap on the
+// refund chain. Caller must hold c.mu.
+func lockedUnlocked(active *ActiveSwap, due refundDue) bool {
+	if !due.evm {
+		return active.Swap.LocalFundingTxID != ""
+	}
+	if active.EVMHTLC == nil {
+		return false
+	}
+	cd := active.EVMHTLC.OfferChain
+	if due.chain == active.Swap.Offer.RequestChain {
+		cd = active.EVMHTLC.RequestChain
+	}
+	return cd != nil && cd.Session != nil && cd.Session.GetCreateTxHash() != (common.Hash{})
+}
+
+// refund refunds our side of a swap on its chain.
+func (w *RefundWatcher) refund(ctx context.Context, due refundDue) (string, error) {
+	c := w.coordinator
+	switch {
+	case due.evm:
+		txHash, err := c.RefundEVMHTLC(ctx, due.tradeID, due.chain)
+		if err != nil {
+			return "", err
+		}
+		return txHash.Hex(), nil
+	case due.method == MethodHTLC:
+		return c.RefundHTLC(ctx, due.tradeID, due.chain)
+	case due.method == MethodMuSig2 || due.method == "":
+		return c.ForceRefund(ctx, due.tradeID, due.chain)
+	default:
+		return "", fmt.Errorf("no automatic refund for method %s", due.method)
+	}
+}
+
+// record remembers an attempt and emits its event, failures only when the
+// error changed.
+func (w *RefundWatcher) record(attempt RefundAttempt) {
+	c := w.coordinator
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if attempt.Error != "" {
+		if w.lastError[attempt.TradeID] == attempt.Error {
+			return
+		}
+		w.lastError[attempt.TradeID] = attempt.Error
+		w.log.Warn("Automatic refund failed", "trade_id", attempt.TradeID, "chain", attempt.Chain, "error", attempt.Error)
+		c.mu.RLock()
+		c.emitEvent(attempt.TradeID, EventAutoRefundFailed, attempt)
+		c.mu.RUnlock()
+		return
+	}
+
+	delete(w.lastError, attempt.TradeID)
+	w.refunded[attempt.TradeID] = attempt.TxID
+	w.log.Info("Refunded expired swap", "trade_id", attempt.TradeID, "chain", attempt.Chain, "txid", attempt.TxID)
+	c.mu.RLock()
+	c.emitEvent(attempt.TradeID, EventAutoRefundBroadcast, attempt)
+	c.mu.RUnlock()
+}
@@ -0,0 +1,74 @@
+package swap
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRefundWatcher(t *testing.T) {
+	ltc := newFakeChainBackend()
+	ltc.height.Store(1000)
+	coord := newTestCoordinator(t, withBackend("LTC", ltc))
+	addDeadlineTrade(t, coord)
+	active := coord.swaps["t1"]
+	active.Swap.State = StateFunded
+
+	events := make(chan SwapEvent, 16)
+	coord.OnEvent(func(e SwapEvent) { events <- e })
+
+	w := NewRefundWatcher(coord, RefundWatcherPolicy{Enabled: true, MarginBlocks: 2})
+	ctx := context.Background()
+
+	// Nothing of ours is locked yet
+	ltc.height.Store(1300)
+	if got := w.Check(ctx); len(got) != 0 {
+		t.Errorf("unfunded swap refunded: %+v", got)
+	}
+
+	active.Swap.LocalFundingTxID = "funding"
+	for _, height := range []int64{1000, 1201} {
+		ltc.height.Store(height)
+		if got := w.Check(ctx); len(got) != 0 {
+			t.Errorf("refund attempted at height %d: %+v", height, got)
+		}
+	}
+
+	// Past the timelock and margin the refund is attempted; without HTLC
+	// data it fails, and is retried on every check
+	ltc.height.Store(1202)
+	for i := 0; i < 2; i++ {
+		got := w.Check(ctx)
+		if len(got) != 1 || got[0].Chain != "LTC" || got[0].Method != MethodHTLC || !strings.Contains(got[0].Error, "HTLC") {
+			t.Fatalf("Check() = %+v, want a failed LTC HTLC refund", got)
+		}
+	}
+
+	// The repeated failure is reported once. Events are delivered in order,
+	// so everything emitted before the marker has arrived when it does.
+	coord.emitEvent("t1", "test_marker", nil)
+	var got []string
+	timeout := time.After(2 * time.Second)
+	for done := false; !done; {
+		select {
+		case e := <-events:
+			if e.EventType == "test_marker" {
+				done = true
+			} else if strings.HasPrefix(e.EventType, "auto_refund_") {
+				got = append(got, e.EventType)
+			}
+		case <-timeout:
+			t.Fatal("no test_marker event")
+		}
+	}
+	if len(got) != 1 || got[0] != EventAutoRefundFailed {
+		t.Errorf("events = %v, want one %s", got, EventAutoRefundFailed)
+	}
+
+	// Finished swaps are left alone
+	active.Swap.State = StateRedeemed
+	if got := w.Check(ctx); len(got) != 0 {
+		t.Errorf("finished swap refunded: %+v", got)
+	}
+}
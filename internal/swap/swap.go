@@ -102,6 +102,13 @@ func (c *ChainConfig) SupportsMethod(method Method) bool {
 			return false
 		}
 		return params.Type == chain.ChainTypeBitcoin || params.Type == chain.ChainTypeEVM
+	case MethodAdaptor:
+		// The BTC leg locks to taproot scripts, the XMR leg to a shared key
+		params, ok := chain.Get(c.Symbol, c.Network)
+		if !ok {
+			return false
+		}
+		return (params.Type == chain.ChainTypeBitcoin && params.SupportsTaproot) || params.Type == chain.ChainTypeMonero
 	default:
 		return false
 	}
@@ -117,51 +124,21 @@ type Offer struct {
 	RequestChain string
 	// Amount being requested (in smallest unit)
 	RequestAmount uint64
+	// ERC-20 contract address of the offered token on OfferChain (empty = native coin)
+	OfferToken string
+	// ERC-20 contract address of the requested token on RequestChain (empty = native coin)
+	RequestToken string
 	// Preferred swap method
 	Method Method
 	// Offer expiry
 	ExpiresAt time.Time
+	// Negotiated fee terms (zero value = protocol defaults)
+	FeeTerms FeeTerms
 }
 
-// Validate checks if the offer is valid.
+// Validate checks if the offer is valid. See ValidateOffer.
 func (o *Offer) Validate(network chain.Network) error {
-	// Check both chains are supported
-	offerCfg, err := NewChainConfig(o.OfferChain, network)
-	if err != nil {
-		return fmt.Errorf("offer chain: %w", err)
-	}
-
-	requestCfg, err := NewChainConfig(o.RequestChain, network)
-	if err != nil {
-		return fmt.Errorf("request chain: %w", err)
-	}
-
-	// Check method is supported on both chains
-	if !offerCfg.SupportsMethod(o.Method) {
-		return fmt.Errorf("%s does not support %s", o.OfferChain, o.Method)
-	}
-	if !requestCfg.SupportsMethod(o.Method) {
-		return fmt.Errorf("%s does not support %s", o.RequestChain, o.Method)
-	}
-
-	// Check amounts are within limits
-	offerCoin, _ := config.GetCoin(o.OfferChain)
-	if o.OfferAmount < offerCoin.MinAmount {
-		return fmt.Errorf("offer amount below minimum: %d < %d", o.OfferAmount, offerCoin.MinAmount)
-	}
-	if offerCoin.MaxAmount > 0 && o.OfferAmount > offerCoin.MaxAmount {
-		return fmt.Errorf("offer amount above maximum: %d > %d", o.OfferAmount, offerCoin.MaxAmount)
-	}
-
-	requestCoin, _ := config.GetCoin(o.RequestChain)
-	if o.RequestAmount < requestCoin.MinAmount {
-		return fmt.Errorf("request amount below minimum: %d < %d", o.RequestAmount, requestCoin.MinAmount)
-	}
-	if requestCoin.MaxAmount > 0 && o.RequestAmount > requestCoin.MaxAmount {
-		return fmt.Errorf("request amount above maximum: %d > %d", o.RequestAmount, requestCoin.MaxAmount)
-	}
-
-	return nil
+	return ValidateOffer(*o, network)
 }
 
 // Swap represents an atomic swap between two parties.
@@ -211,6 +188,20 @@ type Swap struct {
 	RemoteFundingVout    uint32
 	RemoteFundingConfirms uint32 // Current confirmation count
 
+	// FundingZeroConf is set when the zero-conf policy accepted the
+	// counterparty's unconfirmed funding; both legs then count as final.
+	FundingZeroConf bool
+
+	// OfferFundedAmount and RequestFundedAmount are the escrow output values
+	// when the funding variance check found them differing from the
+	// negotiated amounts (0: as negotiated).
+	OfferFundedAmount   uint64
+	RequestFundedAmount uint64
+
+	// ExternalFunding is set when our leg is funded from an external wallet
+	// rather than by the node.
+	ExternalFunding bool
+
 	// Wallet addresses for redemption
 	// Each party provides their addresses on both chains
 	// Offer chain: initiator funded → responder redeems → needs responder's address
@@ -220,6 +211,12 @@ type Swap struct {
 	RemoteOfferWalletAddr  string // Counterparty's address on offer chain
 	RemoteRequestWalletAddr string // Counterparty's address on request chain
 
+	// External payout address for our receiving leg (empty: our wallet)
+	PayoutAddress string
+
+	// Lightning is the invoice settling the BTC leg, if any
+	Lightning *LightningLeg
+
 	// Secret (only initiator has this initially)
 	Secret     []byte // 32-byte secret
 	SecretHash []byte // SHA256(secret)
@@ -355,7 +352,12 @@ func (s *Swap) TransitionTo(newState State) error {
 
 // IsTerminal returns true if the swap is in a terminal state.
 func (s *Swap) IsTerminal() bool {
-	switch s.State {
+	return s.State.IsTerminal()
+}
+
+// IsTerminal returns true if the state ends the swap.
+func (s State) IsTerminal() bool {
+	switch s {
 	case StateRedeemed, StateRefunded, StateFailed, StateCancelled:
 		return true
 	default:
@@ -499,7 +501,7 @@ func (s *Swap) GetLocalFundingStatus() *FundingStatus {
 		TxID:          s.LocalFundingTxID,
 		Confirmations: s.LocalFundingConfirms,
 		Required:      chainCfg.MinConfirmations,
-		IsFinal:       s.LocalFundingConfirms >= chainCfg.MinConfirmations,
+		IsFinal:       s.LocalFundingConfirms >= chainCfg.MinConfirmations || s.FundingZeroConf,
 	}
 }
 
@@ -524,7 +526,7 @@ func (s *Swap) GetRemoteFundingStatus() *FundingStatus {
 		TxID:          s.RemoteFundingTxID,
 		Confirmations: s.RemoteFundingConfirms,
 		Required:      chainCfg.MinConfirmations,
-		IsFinal:       s.RemoteFundingConfirms >= chainCfg.MinConfirmations,
+		IsFinal:       s.RemoteFundingConfirms >= chainCfg.MinConfirmations || s.FundingZeroConf,
 	}
 }
 
@@ -101,6 +101,27 @@ func TestChainConfigSupportsMethod(t *testing.T) {
 			method:  MethodHTLC,
 			want:    true,
 		},
+		{
+			name:    "BTC supports adaptor",
+			symbol:  "BTC",
+			network: chain.Testnet,
+			method:  MethodAdaptor,
+			want:    true,
+		},
+		{
+			name:    "XMR supports adaptor",
+			symbol:  "XMR",
+			network: chain.Testnet,
+			method:  MethodAdaptor,
+			want:    true,
+		},
+		{
+			name:    "DOGE does not support adaptor",
+			symbol:  "DOGE",
+			network: chain.Mainnet,
+			method:  MethodAdaptor,
+			want:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -117,6 +138,12 @@ func TestChainConfigSupportsMethod(t *testing.T) {
 	}
 }
 
+// Registered token contracts on mainnet.
+const (
+	arbitrumUSDC = "0xaf88d065e77c8cC2239327C5EDb3A432268e5831"
+	bscUSDT      = "0x55d398326f99059fF775485246999027B3197955"
+)
+
 func TestOfferValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -176,6 +203,75 @@ func TestOfferValidation(t *testing.T) {
 			network: chain.Mainnet,
 			wantErr: true,
 		},
+		{
+			name: "USDC on Arbitrum for USDT on BSC",
+			offer: Offer{
+				OfferChain:    "ARBITRUM",
+				OfferAmount:   1_000_000, // 1 USDC (6 decimals)
+				OfferToken:    arbitrumUSDC,
+				RequestChain:  "BSC",
+				RequestAmount: 1_000_000_000_000_000_000, // 1 USDT (18 decimals)
+				RequestToken:  bscUSDT,
+				Method:        MethodHTLC,
+			},
+			network: chain.Mainnet,
+			wantErr: false,
+		},
+		{
+			name: "token not in the registry",
+			offer: Offer{
+				OfferChain:    "ARBITRUM",
+				OfferAmount:   1_000_000,
+				OfferToken:    "0x000000000000000000000000000000000000dEaD",
+				RequestChain:  "BSC",
+				RequestAmount: 1_000_000_000_000_000_000,
+				RequestToken:  bscUSDT,
+				Method:        MethodHTLC,
+			},
+			network: chain.Mainnet,
+			wantErr: true,
+		},
+		{
+			name: "token registered on another chain",
+			offer: Offer{
+				OfferChain:    "ARBITRUM",
+				OfferAmount:   1_000_000,
+				OfferToken:    bscUSDT,
+				RequestChain:  "BSC",
+				RequestAmount: 1_000_000_000_000_000_000,
+				Method:        MethodHTLC,
+			},
+			network: chain.Mainnet,
+			wantErr: true,
+		},
+		{
+			name: "token on a non-EVM chain",
+			offer: Offer{
+				OfferChain:    "BTC",
+				OfferAmount:   100000,
+				OfferToken:    arbitrumUSDC,
+				RequestChain:  "BSC",
+				RequestAmount: 1_000_000_000_000_000_000,
+				RequestToken:  bscUSDT,
+				Method:        MethodHTLC,
+			},
+			network: chain.Mainnet,
+			wantErr: true,
+		},
+		{
+			name: "zero token amount",
+			offer: Offer{
+				OfferChain:    "ARBITRUM",
+				OfferAmount:   0,
+				OfferToken:    arbitrumUSDC,
+				RequestChain:  "BSC",
+				RequestAmount: 1_000_000_000_000_000_000,
+				RequestToken:  bscUSDT,
+				Method:        MethodHTLC,
+			},
+			network: chain.Mainnet,
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,171 @@
+// Package swap - Canonical trade terms and their digest.
+//
+// Each party hashes the terms it believes were agreed (chains, amounts,
+// method, fee terms and lock times) into a terms digest. The digest is signed
+// with the swap key when the keys are exchanged and embedded in every later
+// protocol message, so a party whose view of the trade differs is detected
+// before anything is funded.
+package swap
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+)
+
+// termsDigestTag is the domain tag of the canonical serialization. Bump the
+// version when the serialized fields change.
+const termsDigestTag = "klingdex/terms/v1"
+
+// ErrTermsMismatch is returned when the counterparty's terms digest or its
+// signature doesn't match our view of the trade.
+var ErrTermsMismatch = errors.New("trade terms mismatch")
+
+// TradeTerms are the negotiated terms of a trade.
+type TradeTerms struct {
+	TradeID       string
+	OrderID       string
+	Network       chain.Network
+	Method        Method
+	OfferChain    string
+	OfferAmount   uint64
+	RequestChain  string
+	RequestAmount uint64
+	OfferToken    string
+	RequestToken  string
+	FeeTerms      FeeTerms
+	InitiatorLock time.Duration
+	ResponderLock time.Duration
+
+	// FeeAdjustment is the negotiated change of the request amount (already
+	// included in RequestAmount), 0 when none was agreed.
+	FeeAdjustment int64
+}
+
+// NewTradeTerms returns the terms of a trade for an offer, with the lock
+// times NewSwap uses.
+func NewTradeTerms(network chain.Network, tradeID, orderID string, offer Offer, method Method) *TradeTerms {
+	swapCfg := config.DefaultSwapConfig()
+	return &TradeTerms{
+		TradeID:       tradeID,
+		OrderID:       orderID,
+		Network:       network,
+		Method:        method,
+		OfferChain:    offer.OfferChain,
+		OfferAmount:   offer.OfferAmount,
+		RequestChain:  offer.RequestChain,
+		RequestAmount: offer.RequestAmount,
+		OfferToken:    offer.OfferToken,
+		RequestToken:  offer.RequestToken,
+		FeeTerms:      offer.FeeTerms,
+		InitiatorLock: swapCfg.InitiatorLockTime,
+		ResponderLock: swapCfg.ResponderLockTime,
+	}
+}
+
+// CanonicalBytes serializes the terms: the domain tag and every field in a
+// fixed order, strings length-prefixed and integers big-endian.
+func (t *TradeTerms) CanonicalBytes() []byte {
+	var buf []byte
+	field := func(s string) {
+		buf = binary.BigEndian.AppendUint32(buf, uint32(len(s)))
+		buf = append(buf, s...)
+	}
+	number := func(v uint64) {
+		buf = binary.BigEndian.AppendUint64(buf, v)
+	}
+
+	field(termsDigestTag)
+	field(t.TradeID)
+	field(t.OrderID)
+	field(string(t.Network))
+	field(string(t.Method))
+	field(t.OfferChain)
+	number(t.OfferAmount)
+	field(t.RequestChain)
+	number(t.RequestAmount)
+	field(string(t.FeeTerms.DAOFeePayer))
+	field(string(t.FeeTerms.ClaimFeePayer))
+	number(t.FeeTerms.ClaimFeeAllowance)
+	number(uint64(t.InitiatorLock / time.Second))
+	number(uint64(t.ResponderLock / time.Second))
+	// Appended only when agreed, so digests of trades without it are unchanged
+	if t.FeeTerms.MetaClaimFeeBps != 0 {
+		field("meta_claim_fee_bps")
+		number(uint64(t.FeeTerms.MetaClaimFeeBps))
+	}
+	// Token legs likewise, lower-cased so address checksums don't matter
+	if t.OfferToken != "" || t.RequestToken != "" {
+		field("tokens")
+		field(strings.ToLower(t.OfferToken))
+		field(strings.ToLower(t.RequestToken))
+	}
+	if t.FeeAdjustment != 0 {
+		field("fee_adjustment")
+		number(uint64(t.FeeAdjustment))
+	}
+	return buf
+}
+
+// Digest returns the SHA-256 of the canonical serialization.
+func (t *TradeTerms) Digest() []byte {
+	h := sha256.Sum256(t.CanonicalBytes())
+	return h[:]
+}
+
+// SignTermsDigest signs a terms digest (BIP-340).
+func SignTermsDigest(key *btcec.PrivateKey, digest []byte) ([]byte, error) {
+	sig, err := schnorr.Sign(key, digest)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign terms: %w", err)
+	}
+	return sig.Serialize(), nil
+}
+
+// VerifyTermsSignature checks the counterparty's signature of our terms
+// digest with its swap public key.
+func VerifyTermsSignature(pubKey, digest, sig []byte) error {
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return fmt.Errorf("%w: invalid pubkey: %v", ErrTermsMismatch, err)
+	}
+	parsed, err := schnorr.ParseSignature(sig)
+	if err != nil {
+		return fmt.Errorf("%w: invalid signature: %v", ErrTermsMismatch, err)
+	}
+	if !parsed.Verify(digest, key) {
+		return fmt.Errorf("%w: signature does not match our terms", ErrTermsMismatch)
+	}
+	return nil
+}
+
+// SignTerms signs a terms digest with the swap's local key.
+func (c *Coordinator) SignTerms(tradeID string, digest []byte) ([]byte, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	active, ok := c.swaps[tradeID]
+	if !ok {
+		return nil, ErrSwapNotFound
+	}
+	var key *btcec.PrivateKey
+	switch {
+	case active.MuSig2 != nil:
+		key = active.MuSig2.LocalPrivKey
+	case active.HTLC != nil:
+		key = active.HTLC.LocalPrivKey
+	}
+	if key == nil {
+		return nil, fmt.Errorf("swap has no local key")
+	}
+	return SignTermsDigest(key, digest)
+}
@@ -0,0 +1,112 @@
+package swap
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func testTradeTerms() *TradeTerms {
+	return NewTradeTerms(chain.Testnet, "trade-1", "order-1", Offer{
+		OfferChain: "BTC", OfferAmount: 100000,
+		RequestChain: "LTC", RequestAmount: 5000000,
+	}, MethodHTLC)
+}
+
+func TestTradeTermsDigest(t *testing.T) {
+	base := testTradeTerms()
+	if base.InitiatorLock == 0 || base.ResponderLock == 0 {
+		t.Fatalf("terms without lock times: %+v", base)
+	}
+	if !bytes.Equal(base.Digest(), testTradeTerms().Digest()) {
+		t.Fatal("digest is not deterministic")
+	}
+
+	// Every field changes the digest
+	changes := map[string]func(*TradeTerms){
+		"trade":          func(t *TradeTerms) { t.TradeID = "trade-2" },
+		"order":          func(t *TradeTerms) { t.OrderID = "order-2" },
+		"network":        func(t *TradeTerms) { t.Network = chain.Mainnet },
+		"method":         func(t *TradeTerms) { t.Method = MethodMuSig2 },
+		"offer amount":   func(t *TradeTerms) { t.OfferAmount++ },
+		"request chain":  func(t *TradeTerms) { t.RequestChain = "DOGE" },
+		"request amount": func(t *TradeTerms) { t.RequestAmount-- },
+		"dao fee payer":  func(t *TradeTerms) { t.FeeTerms.DAOFeePayer = FeePayerMaker },
+		"claim fee":      func(t *TradeTerms) { t.FeeTerms.ClaimFeeAllowance = 1 },
+		"meta-claim fee": func(t *TradeTerms) { t.FeeTerms.MetaClaimFeeBps = 50 },
+		"offer token":    func(t *TradeTerms) { t.OfferToken = arbitrumUSDC },
+		"request token":  func(t *TradeTerms) { t.RequestToken = bscUSDT },
+		"initiator lock": func(t *TradeTerms) { t.InitiatorLock += time.Hour },
+		"responder lock": func(t *TradeTerms) { t.ResponderLock -= time.Second },
+		"fee adjustment": func(t *TradeTerms) { t.FeeAdjustment = -1 },
+	}
+	for name, change := range changes {
+		terms := testTradeTerms()
+		change(terms)
+		if bytes.Equal(terms.Digest(), base.Digest()) {
+			t.Errorf("%s change kept the digest", name)
+		}
+	}
+
+	// Length prefixes keep adjacent fields apart
+	a, b := testTradeTerms(), testTradeTerms()
+	a.TradeID, a.OrderID = "ab", "c"
+	b.TradeID, b.OrderID = "a", "bc"
+	if bytes.Equal(a.CanonicalBytes(), b.CanonicalBytes()) {
+		t.Error("field boundaries are ambiguous")
+	}
+}
+
+func TestTermsSignature(t *testing.T) {
+	key, _ := btcec.NewPrivateKey()
+	other, _ := btcec.NewPrivateKey()
+	digest := testTradeTerms().Digest()
+
+	sig, err := SignTermsDigest(key, digest)
+	if err != nil {
+		t.Fatalf("SignTermsDigest() error = %v", err)
+	}
+	pubKey := key.PubKey().SerializeCompressed()
+	if err := VerifyTermsSignature(pubKey, digest, sig); err != nil {
+		t.Errorf("VerifyTermsSignature() error = %v", err)
+	}
+
+	changed := testTradeTerms()
+	changed.RequestAmount = 4000000
+	if err := VerifyTermsSignature(pubKey, changed.Digest(), sig); !errors.Is(err, ErrTermsMismatch) {
+		t.Errorf("other terms: err = %v, want ErrTermsMismatch", err)
+	}
+	if err := VerifyTermsSignature(other.PubKey().SerializeCompressed(), digest, sig); !errors.Is(err, ErrTermsMismatch) {
+		t.Errorf("other key: err = %v, want ErrTermsMismatch", err)
+	}
+}
+
+func TestCoordinatorSignTerms(t *testing.T) {
+	coord := NewCoordinator(&CoordinatorConfig{Network: chain.Testnet})
+	defer coord.Close()
+
+	digest := testTradeTerms().Digest()
+	if _, err := coord.SignTerms("t1", digest); !errors.Is(err, ErrSwapNotFound) {
+		t.Errorf("SignTerms() unknown swap err = %v", err)
+	}
+
+	key, _ := btcec.NewPrivateKey()
+	coord.swaps["t1"] = &ActiveSwap{Swap: &Swap{Method: MethodHTLC}, HTLC: &HTLCSwapData{LocalPrivKey: key}}
+	sig, err := coord.SignTerms("t1", digest)
+	if err != nil {
+		t.Fatalf("SignTerms() error = %v", err)
+	}
+	if err := VerifyTermsSignature(key.PubKey().SerializeCompressed(), digest, sig); err != nil {
+		t.Errorf("VerifyTermsSignature() error = %v", err)
+	}
+
+	coord.swaps["evm"] = &ActiveSwap{Swap: &Swap{Method: MethodHTLC}, EVMHTLC: &EVMHTLCSwapData{}}
+	if _, err := coord.SignTerms("evm", digest); err == nil {
+		t.Error("SignTerms() without a swap key succeeded")
+	}
+}
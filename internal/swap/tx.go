@@ -8,6 +8,7 @@ import (
 	"encoding/hex"
 	"errors"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/btcsuite/btcd/btcec/v2"
@@ -59,6 +60,9 @@ type FundingTxParams struct {
 
 	// Fee rate in sat/vB
 	FeeRate uint64
+
+	// Dust handling for the DAO fee and change outputs
+	Dust DustPolicy
 }
 
 // BuildFundingTx creates a funding transaction for a MuSig2 swap.
@@ -95,6 +99,11 @@ func BuildFundingTx(params *FundingTxParams) (*wire.MsgTx, error) {
 		tx.AddTxIn(txIn)
 	}
 
+	dust := newDustCheck(params.Symbol, params.FeeRate, params.Dust)
+	if err := dust.payment("swap output", params.SwapAmount); err != nil {
+		return nil, err
+	}
+
 	// Add swap output (P2TR)
 	swapScript, err := addressToScript(params.SwapAddress, chainParams)
 	if err != nil {
@@ -102,8 +111,14 @@ func BuildFundingTx(params *FundingTxParams) (*wire.MsgTx, error) {
 	}
 	tx.AddTxOut(wire.NewTxOut(int64(params.SwapAmount), swapScript))
 
-	// Add DAO fee output if non-zero
-	if params.DAOFee > 0 && params.DAOAddress != "" {
+	// Add DAO fee output if non-zero and not dust
+	keepDAO := false
+	if params.DAOAddress != "" {
+		if keepDAO, err = dust.keep(DustOutputDAOFee, params.DAOFee); err != nil {
+			return nil, err
+		}
+	}
+	if keepDAO {
 		daoScript, err := addressToScript(params.DAOAddress, chainParams)
 		if err != nil {
 			return nil, fmt.Errorf("invalid DAO address: %w", err)
@@ -116,7 +131,7 @@ func BuildFundingTx(params *FundingTxParams) (*wire.MsgTx, error) {
 	estimatedVSize := int64(10) // Base tx overhead
 	estimatedVSize += int64(len(params.UTXOs) * 58)
 	estimatedVSize += 43 // Swap output
-	if params.DAOFee > 0 {
+	if keepDAO {
 		estimatedVSize += 43 // DAO output
 	}
 	estimatedVSize += 43 // Change output (assume we need change)
@@ -132,9 +147,12 @@ func BuildFundingTx(params *FundingTxParams) (*wire.MsgTx, error) {
 
 	change := totalInput - totalOutput
 
-	// Add change output if above dust threshold
-	dustThreshold := uint64(546) // Standard dust threshold
-	if change > dustThreshold {
+	// Add change output unless it is dust (folded into the fee)
+	keepChange, err := dust.keep(DustOutputChange, change)
+	if err != nil {
+		return nil, err
+	}
+	if keepChange {
 		changeScript, err := addressToScript(params.ChangeAddress, chainParams)
 		if err != nil {
 			return nil, fmt.Errorf("invalid change address: %w", err)
@@ -166,6 +184,9 @@ type SpendingTxParams struct {
 
 	// Fee rate in sat/vB
 	FeeRate uint64
+
+	// Dust handling for the DAO fee output
+	Dust DustPolicy
 }
 
 // BuildSpendingTx creates a transaction to spend from a MuSig2 P2TR output.
@@ -190,9 +211,18 @@ func BuildSpendingTx(params *SpendingTxParams) (*wire.MsgTx, *chainhash.Hash, er
 	txIn.Sequence = wire.MaxTxInSequenceNum
 	tx.AddTxIn(txIn)
 
+	// A dust DAO fee is folded into the fee (still deducted from the output)
+	dust := newDustCheck(params.Symbol, params.FeeRate, params.Dust)
+	keepDAO := false
+	if params.DAOAddress != "" {
+		if keepDAO, err = dust.keep(DustOutputDAOFee, params.DAOFee); err != nil {
+			return nil, nil, err
+		}
+	}
+
 	// Estimate fee (P2TR input ~58 vbytes, P2TR output 43 vbytes each)
 	outputCount := 1
-	if params.DAOFee > 0 && params.DAOAddress != "" {
+	if keepDAO {
 		outputCount = 2
 	}
 	estimatedVSize := int64(10 + 58 + 43*outputCount)
@@ -204,9 +234,12 @@ func BuildSpendingTx(params *SpendingTxParams) (*wire.MsgTx, *chainhash.Hash, er
 		return nil, nil, fmt.Errorf("%w: funding %d <= fee+dao %d", ErrInsufficientFunds, params.FundingAmount, totalRequired)
 	}
 	outputAmount := params.FundingAmount - fee - params.DAOFee
+	if err := dust.payment("destination output", outputAmount); err != nil {
+		return nil, nil, err
+	}
 
 	// Add DAO fee output first (if present)
-	if params.DAOFee > 0 && params.DAOAddress != "" {
+	if keepDAO {
 		daoScript, err := addressToScript(params.DAOAddress, chainParams)
 		if err != nil {
 			return nil, nil, fmt.Errorf("invalid DAO address: %w", err)
@@ -346,12 +379,8 @@ func SelectUTXOs(utxos []backend.UTXO, targetAmount, feeRate uint64) ([]backend.
 
 // sortUTXOs sorts UTXOs by amount in descending order (largest first).
 func sortUTXOs(utxos []backend.UTXO) {
-	// Simple insertion sort (good enough for typical UTXO counts)
-	for i := 1; i < len(utxos); i++ {
-		for j := i; j > 0 && utxos[j].Amount > utxos[j-1].Amount; j-- {
-			utxos[j], utxos[j-1] = utxos[j-1], utxos[j]
-		}
-	}
+	// Stable, so equal amounts keep the backend's order
+	sort.SliceStable(utxos, func(i, j int) bool { return utxos[i].Amount > utxos[j].Amount })
 }
 
 // addressToScript converts an address string to a scriptPubKey.
@@ -530,6 +559,9 @@ func BuildRefundTx(params *RefundTxParams) (*wire.MsgTx, error) {
 		return nil, fmt.Errorf("%w: funding %d <= fee %d", ErrInsufficientFunds, params.FundingAmount, fee)
 	}
 	outputAmount := params.FundingAmount - fee
+	if err := newDustCheck(params.Symbol, params.FeeRate, DustPolicy{}).payment("refund output", outputAmount); err != nil {
+		return nil, err
+	}
 
 	// Add output
 	destScript, err := addressToScript(params.DestAddress, chainParams)
@@ -659,6 +691,9 @@ type HTLCClaimTxParams struct {
 
 	// Private key for signing (the receiver's key in the HTLC)
 	PrivKey *btcec.PrivateKey
+
+	// Dust handling for the DAO fee output
+	Dust DustPolicy
 }
 
 // BuildHTLCClaimTx creates a transaction to claim from an HTLC P2WSH output.
@@ -699,8 +734,15 @@ func BuildHTLCClaimTx(params *HTLCClaimTxParams) (*wire.MsgTx, error) {
 	// Witness: sig (~73) + secret (32) + branch selector (1) + script (~100) = ~206 bytes
 	// With witness discount (1/4): ~52 vbytes additional
 	// Base: 10 vbytes, Input overhead: ~41 vbytes, Output: 43 vbytes
+	dust := newDustCheck(params.Symbol, params.FeeRate, params.Dust)
+	keepDAO := false
+	if params.DAOAddress != "" {
+		if keepDAO, err = dust.keep(DustOutputDAOFee, params.DAOFee); err != nil {
+			return nil, err
+		}
+	}
 	outputCount := 1
-	if params.DAOFee > 0 && params.DAOAddress != "" {
+	if keepDAO {
 		outputCount = 2
 	}
 	estimatedVSize := int64(10 + 41 + 43*outputCount + 52)
@@ -712,9 +754,12 @@ func BuildHTLCClaimTx(params *HTLCClaimTxParams) (*wire.MsgTx, error) {
 		return nil, fmt.Errorf("%w: funding %d <= fee+dao %d", ErrInsufficientFunds, params.FundingAmount, totalRequired)
 	}
 	outputAmount := params.FundingAmount - fee - params.DAOFee
+	if err := dust.payment("claim output", outputAmount); err != nil {
+		return nil, err
+	}
 
 	// Add DAO fee output first (if present)
-	if params.DAOFee > 0 && params.DAOAddress != "" {
+	if keepDAO {
 		daoScript, err := addressToScript(params.DAOAddress, chainParams)
 		if err != nil {
 			return nil, fmt.Errorf("invalid DAO address: %w", err)
@@ -836,6 +881,9 @@ func BuildHTLCRefundTx(params *HTLCRefundTxParams) (*wire.MsgTx, error) {
 		return nil, fmt.Errorf("%w: funding %d <= fee %d", ErrInsufficientFunds, params.FundingAmount, fee)
 	}
 	outputAmount := params.FundingAmount - fee
+	if err := newDustCheck(params.Symbol, params.FeeRate, DustPolicy{}).payment("refund output", outputAmount); err != nil {
+		return nil, err
+	}
 
 	// Add output
 	destScript, err := addressToScript(params.DestAddress, chainParams)
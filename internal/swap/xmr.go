@@ -0,0 +1,626 @@
+// Package swap - BTC/XMR adaptor signature swaps.
+//
+// Monero has no scripts, so the XMR leg is a plain output owned by the sum
+// of two spend key shares, S = S_a + S_b, and the BTC leg is a taproot
+// output that reveals a key share whenever it is spent:
+//
+//  1. The BTC party locks BTC to the 2-of-2 lock output.
+//  2. The XMR party locks XMR to the shared address.
+//  3. The BTC party hands over a redeem pre-signature encrypted under the
+//     XMR party's adaptor point T_b. Completing it to redeem the BTC
+//     publishes s_b, and the BTC party sweeps the XMR with s_a + s_b.
+//  4. After CancelTimeout either party can move the BTC to the cancel
+//     output. The BTC party then refunds with the XMR party's
+//     pre-signature encrypted under T_a, which publishes s_a so the XMR
+//     party can recover its XMR; if the BTC party doesn't refund within
+//     PunishTimeout, the XMR party punishes by taking the BTC.
+//
+// The DLEQ proof of each key share ties its adaptor point on secp256k1 to
+// its spend key share on ed25519. The coordinator runs the protocol in
+// coordinator_xmr.go.
+package swap
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// xmrNUMSKey is the BIP-341 unspendable internal key: the BTC outputs are
+// only spendable through their scripts.
+var xmrNUMSKey = func() *btcec.PublicKey {
+	key, err := schnorr.ParsePubKey([]byte{
+		0x50, 0x92, 0x9b, 0x74, 0xc1, 0xa0, 0x49, 0x54, 0xb7, 0x8b, 0x4b, 0x60, 0x35, 0xe9, 0x7a, 0x5e,
+		0x07, 0x8a, 0x5a, 0x0f, 0x28, 0xec, 0x96, 0xd5, 0x47, 0xbf, 0xee, 0x9a, 0xce, 0x80, 0x3a, 0xc0,
+	})
+	if err != nil {
+		panic(err)
+	}
+	return key
+}()
+
+// ErrInvalidXMRKeyShare is returned for key shares that fail verification.
+var ErrInvalidXMRKeyShare = errors.New("invalid XMR key share")
+
+// XMRRole is a party's side of a BTC/XMR swap.
+type XMRRole string
+
+const (
+	XMRRoleBTC XMRRole = "btc" // Locks BTC, receives XMR
+	XMRRoleXMR XMRRole = "xmr" // Locks XMR, receives BTC
+)
+
+// IsMoneroChain returns true if the chain is Monero, whose legs settle
+// through adaptor signatures.
+func IsMoneroChain(symbol string, network chain.Network) bool {
+	params, ok := chain.Get(symbol, network)
+	return ok && params.Type == chain.ChainTypeMonero
+}
+
+// XMRKeyShare is what a party sends its counterparty.
+type XMRKeyShare struct {
+	// BTCKey signs on the BTC scripts
+	BTCKey *btcec.PublicKey
+	// SpendKey is the public spend key share on ed25519
+	SpendKey *edwards25519.Point
+	// Adaptor is the same secret on secp256k1, encrypting pre-signatures
+	Adaptor *btcec.PublicKey
+	// Proof ties Adaptor and SpendKey together
+	Proof *DLEQProof
+	// ViewKey is the private view key share; both parties can watch the lock
+	ViewKey *edwards25519.Scalar
+}
+
+// Verify checks the DLEQ proof of the share.
+func (k *XMRKeyShare) Verify() error {
+	if k.BTCKey == nil || k.SpendKey == nil || k.Adaptor == nil || k.Proof == nil || k.ViewKey == nil {
+		return fmt.Errorf("%w: missing fields", ErrInvalidXMRKeyShare)
+	}
+	if err := k.Proof.Verify(k.Adaptor, k.SpendKey); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidXMRKeyShare, err)
+	}
+	return nil
+}
+
+// xmrKeyShareJSON is the wire and storage form of a key share.
+type xmrKeyShareJSON struct {
+	BTCKey   string `json:"btc_key"`   // Compressed
+	SpendKey string `json:"spend_key"` // ed25519 point
+	Adaptor  string `json:"adaptor"`   // Compressed
+	Proof    string `json:"proof"`
+	ViewKey  string `json:"view_key"` // ed25519 scalar
+}
+
+// MarshalJSON encodes the share's keys and proof as hex.
+func (k *XMRKeyShare) MarshalJSON() ([]byte, error) {
+	if k.BTCKey == nil || k.SpendKey == nil || k.Adaptor == nil || k.Proof == nil || k.ViewKey == nil {
+		return nil, fmt.Errorf("%w: missing fields", ErrInvalidXMRKeyShare)
+	}
+	return json.Marshal(xmrKeyShareJSON{
+		BTCKey:   hex.EncodeToString(k.BTCKey.SerializeCompressed()),
+		SpendKey: hex.EncodeToString(k.SpendKey.Bytes()),
+		Adaptor:  hex.EncodeToString(k.Adaptor.SerializeCompressed()),
+		Proof:    hex.EncodeToString(k.Proof.Serialize()),
+		ViewKey:  hex.EncodeToString(k.ViewKey.Bytes()),
+	})
+}
+
+// UnmarshalJSON decodes a share. It doesn't check the proof; see Verify.
+func (k *XMRKeyShare) UnmarshalJSON(data []byte) error {
+	var raw xmrKeyShareJSON
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	var btcKey, spendKey, adaptor, proof, viewKey []byte
+	for _, f := range []struct {
+		name string
+		hex  string
+		dst  *[]byte
+	}{
+		{"btc_key", raw.BTCKey, &btcKey},
+		{"spend_key", raw.SpendKey, &spendKey},
+		{"adaptor", raw.Adaptor, &adaptor},
+		{"proof", raw.Proof, &proof},
+		{"view_key", raw.ViewKey, &viewKey},
+	} {
+		b, err := hex.DecodeString(f.hex)
+		if err != nil {
+			return fmt.Errorf("%w: %s: %v", ErrInvalidXMRKeyShare, f.name, err)
+		}
+		*f.dst = b
+	}
+
+	var share XMRKeyShare
+	var err error
+	if share.BTCKey, err = btcec.ParsePubKey(btcKey); err != nil {
+		return fmt.Errorf("%w: btc_key: %v", ErrInvalidXMRKeyShare, err)
+	}
+	if share.SpendKey, err = new(edwards25519.Point).SetBytes(spendKey); err != nil {
+		return fmt.Errorf("%w: spend_key: %v", ErrInvalidXMRKeyShare, err)
+	}
+	if share.Adaptor, err = btcec.ParsePubKey(adaptor); err != nil {
+		return fmt.Errorf("%w: adaptor: %v", ErrInvalidXMRKeyShare, err)
+	}
+	if share.Proof, err = ParseDLEQProof(proof); err != nil {
+		return fmt.Errorf("%w: proof: %v", ErrInvalidXMRKeyShare, err)
+	}
+	if share.ViewKey, err = edwards25519.NewScalar().SetCanonicalBytes(viewKey); err != nil {
+		return fmt.Errorf("%w: view_key: %v", ErrInvalidXMRKeyShare, err)
+	}
+	*k = share
+	return nil
+}
+
+// XMRSecrets are a party's private keys for one swap.
+type XMRSecrets struct {
+	BTCKey   *btcec.PrivateKey
+	SpendKey *edwards25519.Scalar // Below 2^252 so the DLEQ proof covers it
+	ViewKey  *edwards25519.Scalar
+	share    *XMRKeyShare
+}
+
+// NewXMRSecrets generates fresh keys and proves the key share.
+func NewXMRSecrets() (*XMRSecrets, error) {
+	btcKey, err := btcec.NewPrivateKey()
+	if err != nil {
+		return nil, err
+	}
+	return newXMRSecrets(btcKey)
+}
+
+// newXMRSecrets generates the Monero keys of a swap whose BTC key exists.
+func newXMRSecrets(btcKey *btcec.PrivateKey) (*XMRSecrets, error) {
+	var b [32]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return nil, err
+	}
+	b[31] &= 0x0f
+	spend, err := edwards25519.NewScalar().SetCanonicalBytes(b[:])
+	if err != nil {
+		return nil, err
+	}
+	proof, adaptor, spendPub, err := ProveDLEQ(spend)
+	if err != nil {
+		return nil, err
+	}
+	view := randomEdScalar()
+	return &XMRSecrets{
+		BTCKey:   btcKey,
+		SpendKey: spend,
+		ViewKey:  view,
+		share: &XMRKeyShare{
+			BTCKey:   btcKey.PubKey(),
+			SpendKey: spendPub,
+			Adaptor:  adaptor,
+			Proof:    proof,
+			ViewKey:  view,
+		},
+	}, nil
+}
+
+// restoreXMRSecrets rebuilds stored secrets around the key share they
+// proved. The share must match the keys.
+func restoreXMRSecrets(btcKey *btcec.PrivateKey, spend *edwards25519.Scalar, share *XMRKeyShare) (*XMRSecrets, error) {
+	if share == nil || share.ViewKey == nil || share.BTCKey == nil || share.SpendKey == nil {
+		return nil, fmt.Errorf("%w: missing fields", ErrInvalidXMRKeyShare)
+	}
+	if !share.BTCKey.IsEqual(btcKey.PubKey()) ||
+		new(edwards25519.Point).ScalarBaseMult(spend).Equal(share.SpendKey) != 1 {
+		return nil, fmt.Errorf("%w: keys don't match the share", ErrInvalidXMRKeyShare)
+	}
+	return &XMRSecrets{BTCKey: btcKey, SpendKey: spend, ViewKey: share.ViewKey, share: share}, nil
+}
+
+// Zeroize overwrites the private keys, reporting whether they were set.
+func (s *XMRSecrets) Zeroize() bool {
+	held := zeroizePrivKey(s.BTCKey)
+	if s.SpendKey != nil && s.SpendKey.Equal(edwards25519.NewScalar()) != 1 {
+		s.SpendKey.Set(edwards25519.NewScalar())
+		held = true
+	}
+	return held
+}
+
+// Share returns the key share to send to the counterparty.
+func (s *XMRSecrets) Share() *XMRKeyShare {
+	return s.share
+}
+
+// AdaptorSecret returns the spend key share as a secp256k1 scalar, which
+// completes pre-signatures encrypted under the share's adaptor point.
+func (s *XMRSecrets) AdaptorSecret() *btcec.ModNScalar {
+	return ptrSecpScalar(secpScalarFromEd(s.SpendKey))
+}
+
+// XMRSpendKeyFromAdaptorSecret converts a secret extracted from a completed
+// pre-signature back into the counterparty's spend key share.
+func XMRSpendKeyFromAdaptorSecret(t *btcec.ModNScalar) (*edwards25519.Scalar, error) {
+	b := t.Bytes()
+	if b[0]&0xf0 != 0 {
+		return nil, fmt.Errorf("%w: secret above 2^252", ErrInvalidXMRKeyShare)
+	}
+	return edwards25519.NewScalar().SetCanonicalBytes(reverse32(b[:]))
+}
+
+// XMRSharedAddress returns the Monero address both key shares lock to, and
+// the shared private view key that watches it.
+func XMRSharedAddress(a, b *XMRKeyShare, network chain.Network) (string, *edwards25519.Scalar) {
+	spend := new(edwards25519.Point).Add(a.SpendKey, b.SpendKey)
+	view := edwards25519.NewScalar().Add(a.ViewKey, b.ViewKey)
+	return wallet.MoneroAddress(spend, new(edwards25519.Point).ScalarBaseMult(view), network), view
+}
+
+// XMRSharedSpendKey returns the private spend key of the shared address.
+func XMRSharedSpendKey(a, b *edwards25519.Scalar) *edwards25519.Scalar {
+	return edwards25519.NewScalar().Add(a, b)
+}
+
+// XMRPath is a spend path of the BTC outputs.
+type XMRPath int
+
+const (
+	// XMRPathRedeem spends the lock output to the XMR party (both keys)
+	XMRPathRedeem XMRPath = iota
+	// XMRPathCancel spends the lock output to the cancel output after
+	// CancelTimeout (both keys)
+	XMRPathCancel
+	// XMRPathRefund spends the cancel output to the BTC party (both keys)
+	XMRPathRefund
+	// XMRPathPunish spends the cancel output to the XMR party after
+	// PunishTimeout (XMR party's key)
+	XMRPathPunish
+)
+
+func (p XMRPath) String() string {
+	switch p {
+	case XMRPathRedeem:
+		return "redeem"
+	case XMRPathCancel:
+		return "cancel"
+	case XMRPathRefund:
+		return "refund"
+	case XMRPathPunish:
+		return "punish"
+	default:
+		return fmt.Sprintf("XMRPath(%d)", int(p))
+	}
+}
+
+// XMRScripts are the BTC lock and cancel outputs of a swap.
+type XMRScripts struct {
+	BTCKey        *btcec.PublicKey // The BTC party's key (A)
+	XMRKey        *btcec.PublicKey // The XMR party's key (B)
+	CancelTimeout uint32
+	PunishTimeout uint32
+
+	LockPkScript   []byte
+	CancelPkScript []byte
+
+	leaves   [4]txscript.TapLeaf
+	controls [4][]byte
+}
+
+// BuildXMRScripts builds the lock output, with a 2-of-2 redeem leaf and a
+// 2-of-2 cancel leaf after cancelTimeout, and the cancel output, with a
+// 2-of-2 refund leaf and a punish leaf for the XMR party after
+// punishTimeout.
+func BuildXMRScripts(btcKey, xmrKey *btcec.PublicKey, cancelTimeout, punishTimeout uint32) (*XMRScripts, error) {
+	if btcKey == nil || xmrKey == nil {
+		return nil, fmt.Errorf("both keys are required")
+	}
+	for _, t := range []uint32{cancelTimeout, punishTimeout} {
+		if t == 0 || t > 0xFFFF {
+			return nil, fmt.Errorf("timeout must be in 1..65535 blocks, got %d", t)
+		}
+	}
+
+	multisig := func(pre *txscript.ScriptBuilder) ([]byte, error) {
+		return pre.AddData(schnorr.SerializePubKey(btcKey)).AddOp(txscript.OP_CHECKSIGVERIFY).
+			AddData(schnorr.SerializePubKey(xmrKey)).AddOp(txscript.OP_CHECKSIG).Script()
+	}
+	redeem, err := multisig(txscript.NewScriptBuilder())
+	if err != nil {
+		return nil, err
+	}
+	cancel, err := multisig(txscript.NewScriptBuilder().
+		AddInt64(int64(cancelTimeout)).AddOp(txscript.OP_CHECKSEQUENCEVERIFY).AddOp(txscript.OP_DROP))
+	if err != nil {
+		return nil, err
+	}
+	punish, err := BuildRefundScript(xmrKey, punishTimeout)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &XMRScripts{
+		BTCKey:        btcKey,
+		XMRKey:        xmrKey,
+		CancelTimeout: cancelTimeout,
+		PunishTimeout: punishTimeout,
+	}
+	s.leaves[XMRPathRedeem] = txscript.NewBaseTapLeaf(redeem)
+	s.leaves[XMRPathCancel] = txscript.NewBaseTapLeaf(cancel)
+	s.leaves[XMRPathRefund] = txscript.NewBaseTapLeaf(redeem)
+	s.leaves[XMRPathPunish] = txscript.NewBaseTapLeaf(punish)
+
+	for _, out := range []struct {
+		pkScript *[]byte
+		paths    [2]XMRPath
+	}{
+		{&s.LockPkScript, [2]XMRPath{XMRPathRedeem, XMRPathCancel}},
+		{&s.CancelPkScript, [2]XMRPath{XMRPathRefund, XMRPathPunish}},
+	} {
+		tree := txscript.AssembleTaprootScriptTree(s.leaves[out.paths[0]], s.leaves[out.paths[1]])
+		root := tree.RootNode.TapHash()
+		outputKey := txscript.ComputeTaprootOutputKey(xmrNUMSKey, root[:])
+		if *out.pkScript, err = txscript.PayToTaprootScript(outputKey); err != nil {
+			return nil, err
+		}
+		for i, path := range out.paths {
+			ctrl := tree.LeafMerkleProofs[i].ToControlBlock(xmrNUMSKey)
+			if s.controls[path], err = ctrl.ToBytes(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return s, nil
+}
+
+// LockAddress returns the address the BTC party funds.
+func (s *XMRScripts) LockAddress(symbol string, network chain.Network) (string, error) {
+	params, ok := chain.Get(symbol, network)
+	if !ok || !params.SupportsTaproot {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedChain, symbol)
+	}
+	key, err := schnorr.ParsePubKey(s.LockPkScript[2:])
+	if err != nil {
+		return "", err
+	}
+	return encodeTaprootAddress(key, params.Bech32HRP)
+}
+
+// prevPkScript returns the output a path spends.
+func (s *XMRScripts) prevPkScript(path XMRPath) []byte {
+	if path == XMRPathRedeem || path == XMRPathCancel {
+		return s.LockPkScript
+	}
+	return s.CancelPkScript
+}
+
+// SpendTx builds the unsigned transaction spending prevOut (worth amount)
+// through path, paying amount-fee to destScript. The cancel path always
+// pays to the cancel output and ignores destScript.
+func (s *XMRScripts) SpendTx(path XMRPath, prevOut wire.OutPoint, amount, fee uint64, destScript []byte) (*wire.MsgTx, error) {
+	if path < XMRPathRedeem || path > XMRPathPunish {
+		return nil, fmt.Errorf("unknown spend path %s", path)
+	}
+	if fee >= amount {
+		return nil, fmt.Errorf("fee %d exceeds the %s input of %d", fee, path, amount)
+	}
+	if path == XMRPathCancel {
+		destScript = s.CancelPkScript
+	}
+	if len(destScript) == 0 {
+		return nil, fmt.Errorf("destination script required")
+	}
+
+	tx := wire.NewMsgTx(2)
+	in := wire.NewTxIn(&prevOut, nil, nil)
+	switch path {
+	case XMRPathCancel:
+		in.Sequence = s.CancelTimeout
+	case XMRPathPunish:
+		in.Sequence = s.PunishTimeout
+	}
+	tx.AddTxIn(in)
+	tx.AddTxOut(wire.NewTxOut(int64(amount-fee), destScript))
+	return tx, nil
+}
+
+// SigHash returns the tapscript sighash a path's signers sign for tx, whose
+// only input is worth amount.
+func (s *XMRScripts) SigHash(path XMRPath, tx *wire.MsgTx, amount uint64) ([]byte, error) {
+	fetcher := txscript.NewCannedPrevOutputFetcher(s.prevPkScript(path), int64(amount))
+	return txscript.CalcTapscriptSignaturehash(
+		txscript.NewTxSigHashes(tx, fetcher), txscript.SigHashDefault, tx, 0, fetcher, s.leaves[path],
+	)
+}
+
+// Witness builds the witness of a path. The punish path only takes the XMR
+// party's signature; btcSig is ignored.
+func (s *XMRScripts) Witness(path XMRPath, btcSig, xmrSig *schnorr.Signature) (wire.TxWitness, error) {
+	if xmrSig == nil || (path != XMRPathPunish && btcSig == nil) {
+		return nil, fmt.Errorf("missing signature for the %s path", path)
+	}
+	if path == XMRPathPunish {
+		return wire.TxWitness{xmrSig.Serialize(), s.leaves[path].Script, s.controls[path]}, nil
+	}
+	// The script checks A's signature first, so it sits on top
+	return wire.TxWitness{xmrSig.Serialize(), btcSig.Serialize(), s.leaves[path].Script, s.controls[path]}, nil
+}
+
+// WitnessBTCSig returns the BTC party's signature from a 2-of-2 witness,
+// from which the pre-signature it completed reveals a key share.
+func WitnessBTCSig(witness wire.TxWitness) (*schnorr.Signature, error) {
+	if len(witness) != 4 {
+		return nil, fmt.Errorf("not a 2-of-2 witness: %d items", len(witness))
+	}
+	return schnorr.ParseSignature(witness[1])
+}
+
+// WitnessXMRSig returns the XMR party's signature from a 2-of-2 witness.
+func WitnessXMRSig(witness wire.TxWitness) (*schnorr.Signature, error) {
+	if len(witness) != 4 {
+		return nil, fmt.Errorf("not a 2-of-2 witness: %d items", len(witness))
+	}
+	return schnorr.ParseSignature(witness[0])
+}
+
+// XMRSwapState is the on-chain progress of a BTC/XMR swap.
+type XMRSwapState string
+
+const (
+	XMRStateNegotiating XMRSwapState = "negotiating"
+	XMRStateBTCLocked   XMRSwapState = "btc_locked"
+	XMRStateXMRLocked   XMRSwapState = "xmr_locked"
+	XMRStateBTCRedeemed XMRSwapState = "btc_redeemed"
+	XMRStateCompleted   XMRSwapState = "completed"
+	XMRStateCancelled   XMRSwapState = "cancelled"
+	XMRStateRefunded    XMRSwapState = "refunded"
+	XMRStatePunished    XMRSwapState = "punished"
+)
+
+// IsFinal reports whether no further action can change the outcome.
+func (s XMRSwapState) IsFinal() bool {
+	switch s {
+	case XMRStateCompleted, XMRStateRefunded, XMRStatePunished:
+		return true
+	default:
+		return false
+	}
+}
+
+// XMRAction is what a party should do next.
+type XMRAction string
+
+const (
+	XMRActionNone             XMRAction = ""
+	XMRActionLockBTC          XMRAction = "lock_btc"
+	XMRActionLockXMR          XMRAction = "lock_xmr"
+	XMRActionSendRedeemPresig XMRAction = "send_redeem_presig"
+	XMRActionRedeemBTC        XMRAction = "redeem_btc"
+	XMRActionSweepXMR         XMRAction = "sweep_xmr"
+	XMRActionCancel           XMRAction = "cancel"
+	XMRActionRefund           XMRAction = "refund"
+	XMRActionPunish           XMRAction = "punish"
+	XMRActionRecoverXMR       XMRAction = "recover_xmr"
+)
+
+// XMRTimeouts are the confirmation and timelock parameters of a swap.
+type XMRTimeouts struct {
+	BTCConfirmations uint32 // Before the XMR party locks XMR
+	XMRConfirmations uint32 // Before the BTC party hands over the redeem pre-signature
+	CancelTimeout    uint32 // Lock confirmations before the cancel path opens
+	PunishTimeout    uint32 // Cancel confirmations before the punish path opens
+	// SafetyMargin is how many blocks before CancelTimeout the parties stop
+	// moving forward, so a redeem can't race a cancel.
+	SafetyMargin uint32
+}
+
+// Validate checks the timeouts leave room for the happy path.
+func (t XMRTimeouts) Validate() error {
+	if t.BTCConfirmations == 0 || t.XMRConfirmations == 0 {
+		return fmt.Errorf("confirmation targets must be > 0")
+	}
+	if t.PunishTimeout == 0 {
+		return fmt.Errorf("punish timeout must be > 0")
+	}
+	if t.CancelTimeout <= t.BTCConfirmations+t.SafetyMargin {
+		return fmt.Errorf("cancel timeout (%d) must exceed BTC confirmations plus safety margin (%d)",
+			t.CancelTimeout, t.BTCConfirmations+t.SafetyMargin)
+	}
+	return nil
+}
+
+// XMRObservation is what a party knows about a swap: chain confirmations
+// (0 = not confirmed) and the steps it has taken.
+type XMRObservation struct {
+	BTCLockBroadcast bool   `json:"btc_lock_broadcast,omitempty"`
+	BTCLockConfs     uint32 `json:"btc_lock_confs,omitempty"`
+	XMRLockBroadcast bool   `json:"xmr_lock_broadcast,omitempty"`
+	XMRLockConfs     uint32 `json:"xmr_lock_confs,omitempty"`
+	PresigExchanged  bool   `json:"presig_exchanged,omitempty"` // The redeem pre-signature was sent / received
+	RedeemSeen       bool   `json:"redeem_seen,omitempty"`
+	CancelSeen       bool   `json:"cancel_seen,omitempty"`
+	CancelConfs      uint32 `json:"cancel_confs,omitempty"`
+	RefundSeen       bool   `json:"refund_seen,omitempty"`
+	PunishSeen       bool   `json:"punish_seen,omitempty"`
+	XMRSwept         bool   `json:"xmr_swept,omitempty"` // The shared address was swept: by the BTC party after a redeem, by the XMR party after a refund
+}
+
+// State derives the swap state from an observation.
+func (o XMRObservation) State() XMRSwapState {
+	switch {
+	case o.PunishSeen:
+		return XMRStatePunished
+	case o.RefundSeen && (o.XMRSwept || !o.XMRLockBroadcast):
+		return XMRStateRefunded
+	case o.RefundSeen:
+		return XMRStateCancelled
+	case o.RedeemSeen && o.XMRSwept:
+		return XMRStateCompleted
+	case o.RedeemSeen:
+		return XMRStateBTCRedeemed
+	case o.CancelSeen:
+		return XMRStateCancelled
+	case o.XMRLockConfs > 0:
+		return XMRStateXMRLocked
+	case o.BTCLockConfs > 0:
+		return XMRStateBTCLocked
+	case !o.BTCLockBroadcast:
+		return XMRStateNegotiating
+	default:
+		return XMRStateBTCLocked
+	}
+}
+
+// NextXMRAction returns what role should do next given the observation.
+// It only depends on its arguments, so it is safe to call after every
+// block and after restarts.
+func NextXMRAction(role XMRRole, t XMRTimeouts, o XMRObservation) XMRAction {
+	state := o.State()
+	if state.IsFinal() {
+		return XMRActionNone
+	}
+	// Moving forward is only safe well before the cancel path opens
+	forward := o.BTCLockConfs+t.SafetyMargin < t.CancelTimeout
+	cancellable := o.BTCLockConfs >= t.CancelTimeout
+
+	switch role {
+	case XMRRoleBTC:
+		switch {
+		case o.RedeemSeen:
+			return XMRActionSweepXMR
+		case o.RefundSeen:
+			// The XMR party recovers its XMR
+			return XMRActionNone
+		case o.CancelSeen:
+			return XMRActionRefund
+		case !o.BTCLockBroadcast:
+			return XMRActionLockBTC
+		case cancellable:
+			return XMRActionCancel
+		case !o.PresigExchanged && o.XMRLockConfs >= t.XMRConfirmations && forward:
+			return XMRActionSendRedeemPresig
+		}
+	case XMRRoleXMR:
+		switch {
+		case o.RedeemSeen:
+			return XMRActionNone
+		case o.RefundSeen:
+			return XMRActionRecoverXMR
+		case o.CancelSeen:
+			if o.CancelConfs >= t.PunishTimeout {
+				return XMRActionPunish
+			}
+		case cancellable:
+			return XMRActionCancel
+		case !o.XMRLockBroadcast && o.BTCLockConfs >= t.BTCConfirmations && forward:
+			return XMRActionLockXMR
+		case o.PresigExchanged && forward:
+			return XMRActionRedeemBTC
+		}
+	}
+	return XMRActionNone
+}
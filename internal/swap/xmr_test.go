@@ -0,0 +1,284 @@
+package swap
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"filippo.io/edwards25519"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// xmrTestSwap is a swap between a BTC party (alice) and an XMR party (bob).
+type xmrTestSwap struct {
+	alice, bob *XMRSecrets
+	scripts    *XMRScripts
+}
+
+func newXMRTestSwap(t *testing.T) *xmrTestSwap {
+	t.Helper()
+	alice, err := NewXMRSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	bob, err := NewXMRSecrets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range []*XMRKeyShare{alice.Share(), bob.Share()} {
+		if err := share.Verify(); err != nil {
+			t.Fatalf("Verify() error = %v", err)
+		}
+	}
+	scripts, err := BuildXMRScripts(alice.BTCKey.PubKey(), bob.BTCKey.PubKey(), 72, 36)
+	if err != nil {
+		t.Fatalf("BuildXMRScripts() error = %v", err)
+	}
+	return &xmrTestSwap{alice: alice, bob: bob, scripts: scripts}
+}
+
+// spend builds and signs a spend through path, with the 2-of-2 signature
+// of presigner completed from a pre-signature under the other party's
+// adaptor point. It checks the spend with the script engine.
+func (s *xmrTestSwap) spend(t *testing.T, path XMRPath, prevOut wire.OutPoint, amount uint64) (*wire.MsgTx, *AdaptorSignature) {
+	t.Helper()
+	tx, err := s.scripts.SpendTx(path, prevOut, amount, 500, s.scripts.LockPkScript)
+	if err != nil {
+		t.Fatalf("SpendTx(%s) error = %v", path, err)
+	}
+	sighash, err := s.scripts.SigHash(path, tx, amount)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pre *AdaptorSignature
+	var aliceSig, bobSig *schnorr.Signature
+	switch path {
+	case XMRPathRedeem:
+		// Alice pre-signs under Bob's adaptor point; Bob completes it
+		pre, _ = AdaptorSign(s.alice.BTCKey, sighash, s.bob.Share().Adaptor)
+		if err := pre.Verify(s.alice.BTCKey.PubKey(), sighash, s.bob.Share().Adaptor); err != nil {
+			t.Fatal(err)
+		}
+		aliceSig, _ = pre.Complete(s.bob.AdaptorSecret())
+		bobSig, _ = schnorr.Sign(s.bob.BTCKey, sighash)
+	case XMRPathRefund:
+		// Bob pre-signs under Alice's adaptor point; Alice completes it
+		pre, _ = AdaptorSign(s.bob.BTCKey, sighash, s.alice.Share().Adaptor)
+		bobSig, _ = pre.Complete(s.alice.AdaptorSecret())
+		aliceSig, _ = schnorr.Sign(s.alice.BTCKey, sighash)
+	case XMRPathCancel:
+		aliceSig, _ = schnorr.Sign(s.alice.BTCKey, sighash)
+		bobSig, _ = schnorr.Sign(s.bob.BTCKey, sighash)
+	case XMRPathPunish:
+		bobSig, _ = schnorr.Sign(s.bob.BTCKey, sighash)
+	}
+	if tx.TxIn[0].Witness, err = s.scripts.Witness(path, aliceSig, bobSig); err != nil {
+		t.Fatal(err)
+	}
+
+	fetcher := txscript.NewCannedPrevOutputFetcher(s.scripts.prevPkScript(path), int64(amount))
+	vm, err := txscript.NewEngine(s.scripts.prevPkScript(path), tx, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(tx, fetcher), int64(amount), fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := vm.Execute(); err != nil {
+		t.Fatalf("%s spend doesn't validate: %v", path, err)
+	}
+	return tx, pre
+}
+
+// checkSweep checks that the spend key recovered from a completed
+// pre-signature opens the shared Monero address.
+func (s *xmrTestSwap) checkSweep(t *testing.T, pre *AdaptorSignature, sig *schnorr.Signature, from *XMRSecrets, to *XMRSecrets) {
+	t.Helper()
+	secret, err := pre.Extract(sig, from.Share().Adaptor)
+	if err != nil {
+		t.Fatalf("Extract() error = %v", err)
+	}
+	spendShare, err := XMRSpendKeyFromAdaptorSecret(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if spendShare.Equal(from.SpendKey) != 1 {
+		t.Fatal("recovered another spend key share")
+	}
+
+	addr, view := XMRSharedAddress(s.alice.Share(), s.bob.Share(), chain.Testnet)
+	spend := XMRSharedSpendKey(to.SpendKey, spendShare)
+	want := wallet.MoneroAddress(new(edwards25519.Point).ScalarBaseMult(spend), new(edwards25519.Point).ScalarBaseMult(view), chain.Testnet)
+	if addr != want {
+		t.Errorf("recovered keys open %s, want %s", want, addr)
+	}
+}
+
+func TestXMRSwapRedeem(t *testing.T) {
+	s := newXMRTestSwap(t)
+	lock := wire.OutPoint{Hash: chainhash.Hash{1}}
+
+	tx, pre := s.spend(t, XMRPathRedeem, lock, 100_000)
+
+	// Alice learns Bob's spend key share from the redeem witness
+	sig, err := WitnessBTCSig(tx.TxIn[0].Witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.checkSweep(t, pre, sig, s.bob, s.alice)
+}
+
+func TestXMRSwapCancelRefund(t *testing.T) {
+	s := newXMRTestSwap(t)
+	lock := wire.OutPoint{Hash: chainhash.Hash{1}}
+
+	cancel, _ := s.spend(t, XMRPathCancel, lock, 100_000)
+	if cancel.TxIn[0].Sequence != 72 || string(cancel.TxOut[0].PkScript) != string(s.scripts.CancelPkScript) {
+		t.Fatalf("cancel tx = %+v", cancel)
+	}
+
+	refund, pre := s.spend(t, XMRPathRefund, wire.OutPoint{Hash: cancel.TxHash()}, 99_500)
+
+	// Bob learns Alice's spend key share from the refund witness
+	sig, err := WitnessXMRSig(refund.TxIn[0].Witness)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.checkSweep(t, pre, sig, s.alice, s.bob)
+}
+
+func TestXMRSwapCancelPunish(t *testing.T) {
+	s := newXMRTestSwap(t)
+	cancel, _ := s.spend(t, XMRPathCancel, wire.OutPoint{Hash: chainhash.Hash{1}}, 100_000)
+	punish, _ := s.spend(t, XMRPathPunish, wire.OutPoint{Hash: cancel.TxHash()}, 99_500)
+	if punish.TxIn[0].Sequence != 36 {
+		t.Errorf("punish sequence = %d, want 36", punish.TxIn[0].Sequence)
+	}
+
+	// The punish path doesn't open before the timeout
+	punish.TxIn[0].Sequence = 35
+	fetcher := txscript.NewCannedPrevOutputFetcher(s.scripts.CancelPkScript, 99_500)
+	vm, err := txscript.NewEngine(s.scripts.CancelPkScript, punish, 0, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(punish, fetcher), 99_500, fetcher)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vm.Execute() == nil {
+		t.Error("punished before the timeout")
+	}
+}
+
+func TestBuildXMRScriptsValidation(t *testing.T) {
+	s := newXMRTestSwap(t)
+	if _, err := BuildXMRScripts(s.alice.BTCKey.PubKey(), nil, 72, 36); err == nil {
+		t.Error("built scripts without the XMR party's key")
+	}
+	if _, err := BuildXMRScripts(s.alice.BTCKey.PubKey(), s.bob.BTCKey.PubKey(), 0, 36); err == nil {
+		t.Error("built scripts without a cancel timeout")
+	}
+	if _, err := s.scripts.SpendTx(XMRPathRedeem, wire.OutPoint{}, 500, 500, s.scripts.LockPkScript); err == nil {
+		t.Error("built a spend paying only fees")
+	}
+	if _, err := s.scripts.Witness(XMRPathRedeem, nil, nil); err == nil {
+		t.Error("built a witness without signatures")
+	}
+
+	addr, err := s.scripts.LockAddress("BTC", chain.Testnet)
+	if err != nil || !strings.HasPrefix(addr, "tb1p") {
+		t.Errorf("LockAddress() = %s, %v", addr, err)
+	}
+	if _, err := s.scripts.LockAddress("DOGE", chain.Mainnet); err == nil {
+		t.Error("got a lock address on a chain without taproot")
+	}
+}
+
+func TestXMRKeyShareJSON(t *testing.T) {
+	secrets, _ := NewXMRSecrets()
+	data, err := json.Marshal(secrets.Share())
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var share XMRKeyShare
+	if err := json.Unmarshal(data, &share); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if err := share.Verify(); err != nil {
+		t.Fatalf("decoded share doesn't verify: %v", err)
+	}
+	if !share.BTCKey.IsEqual(secrets.BTCKey.PubKey()) || share.SpendKey.Equal(secrets.Share().SpendKey) != 1 {
+		t.Error("decoded another share")
+	}
+	if err := json.Unmarshal([]byte(`{"btc_key":"00"}`), &share); err == nil {
+		t.Error("Unmarshal() accepted an invalid key")
+	}
+}
+
+func TestXMRKeyShareVerify(t *testing.T) {
+	a, _ := NewXMRSecrets()
+	b, _ := NewXMRSecrets()
+	share := *a.Share()
+	share.SpendKey = b.Share().SpendKey
+	if err := share.Verify(); err == nil {
+		t.Error("verified a share mixing two secrets")
+	}
+	share.Proof = nil
+	if err := share.Verify(); err == nil {
+		t.Error("verified a share without a proof")
+	}
+}
+
+func TestNextXMRAction(t *testing.T) {
+	timeouts := XMRTimeouts{BTCConfirmations: 1, XMRConfirmations: 10, CancelTimeout: 72, PunishTimeout: 36, SafetyMargin: 12}
+	if err := timeouts.Validate(); err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		role  XMRRole
+		obs   XMRObservation
+		want  XMRAction
+		state XMRSwapState
+	}{
+		{"btc locks first", XMRRoleBTC, XMRObservation{}, XMRActionLockBTC, XMRStateNegotiating},
+		{"xmr waits for the btc lock", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true}, XMRActionNone, XMRStateBTCLocked},
+		{"xmr locks", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 1}, XMRActionLockXMR, XMRStateBTCLocked},
+		{"xmr doesn't lock near the cancel timeout", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 60}, XMRActionNone, XMRStateBTCLocked},
+		{"btc waits for xmr confirmations", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 5, XMRLockBroadcast: true, XMRLockConfs: 9}, XMRActionNone, XMRStateXMRLocked},
+		{"btc sends the pre-signature", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 5, XMRLockBroadcast: true, XMRLockConfs: 10}, XMRActionSendRedeemPresig, XMRStateXMRLocked},
+		{"xmr redeems", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 5, XMRLockBroadcast: true, XMRLockConfs: 10, PresigExchanged: true}, XMRActionRedeemBTC, XMRStateXMRLocked},
+		{"xmr doesn't redeem near the cancel timeout", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 61, XMRLockBroadcast: true, XMRLockConfs: 10, PresigExchanged: true}, XMRActionNone, XMRStateXMRLocked},
+		{"btc sweeps after the redeem", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 6, XMRLockBroadcast: true, XMRLockConfs: 10, PresigExchanged: true, RedeemSeen: true}, XMRActionSweepXMR, XMRStateBTCRedeemed},
+		{"completed", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, RedeemSeen: true, XMRSwept: true}, XMRActionNone, XMRStateCompleted},
+		{"btc cancels", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 72}, XMRActionCancel, XMRStateBTCLocked},
+		{"xmr cancels", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 72, XMRLockBroadcast: true, XMRLockConfs: 10}, XMRActionCancel, XMRStateXMRLocked},
+		{"btc refunds", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, BTCLockConfs: 73, CancelSeen: true, CancelConfs: 1}, XMRActionRefund, XMRStateCancelled},
+		{"xmr waits to punish", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, XMRLockBroadcast: true, CancelSeen: true, CancelConfs: 35}, XMRActionNone, XMRStateCancelled},
+		{"xmr punishes", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, XMRLockBroadcast: true, CancelSeen: true, CancelConfs: 36}, XMRActionPunish, XMRStateCancelled},
+		{"xmr recovers after the refund", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, XMRLockBroadcast: true, CancelSeen: true, RefundSeen: true}, XMRActionRecoverXMR, XMRStateCancelled},
+		{"refunded", XMRRoleXMR, XMRObservation{BTCLockBroadcast: true, XMRLockBroadcast: true, CancelSeen: true, RefundSeen: true, XMRSwept: true}, XMRActionNone, XMRStateRefunded},
+		{"refunded before the xmr lock", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, CancelSeen: true, RefundSeen: true}, XMRActionNone, XMRStateRefunded},
+		{"punished", XMRRoleBTC, XMRObservation{BTCLockBroadcast: true, CancelSeen: true, PunishSeen: true}, XMRActionNone, XMRStatePunished},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NextXMRAction(tt.role, timeouts, tt.obs); got != tt.want {
+				t.Errorf("NextXMRAction() = %q, want %q", got, tt.want)
+			}
+			if got := tt.obs.State(); got != tt.state {
+				t.Errorf("State() = %s, want %s", got, tt.state)
+			}
+		})
+	}
+
+	bad := timeouts
+	bad.CancelTimeout = 13
+	if err := bad.Validate(); err == nil {
+		t.Error("accepted a cancel timeout inside the safety margin")
+	}
+}
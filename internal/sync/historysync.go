@@ -0,0 +1,476 @@
+// Package sync - Trade history sync between a user's own nodes.
+//
+// Nodes that pair with each other (a mutual allow-list of peer IDs; the
+// libp2p connection authenticates them) replicate completed trades and
+// orders over /klingon/historysync/1.0.0. Each node pulls the records the
+// other updated since its cursor, on connect and every interval, so both
+// converge. Conflicts resolve by update time: the most recently updated copy
+// wins. Only history is replicated - no keys, secrets or active swaps.
+package sync
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	gosync "sync"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/event"
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/peer"
+	"github.com/libp2p/go-libp2p/core/protocol"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// HistorySyncProtocol is the libp2p protocol for trade history sync.
+const HistorySyncProtocol = "/klingon/historysync/1.0.0"
+
+// MaxHistoryPerSync is the most trades (and orders) sent per page.
+const MaxHistoryPerSync = 200
+
+// historyPeersSetting persists paired peers and their cursors.
+const historyPeersSetting = "history_sync_peers"
+
+// HistorySyncRequest asks a paired node for history updated since the cursors.
+type HistorySyncRequest struct {
+	TradesSince int64 `json:"trades_since"` // Unix time, inclusive
+	OrdersSince int64 `json:"orders_since"`
+	Limit       int   `json:"limit"`
+}
+
+// HistorySyncResponse is one page of history, oldest update first.
+type HistorySyncResponse struct {
+	Trades    []*storage.Trade `json:"trades"`
+	Orders    []*storage.Order `json:"orders"`
+	HasMore   bool             `json:"has_more"`
+	Error     string           `json:"error,omitempty"`
+	Timestamp int64            `json:"timestamp"`
+}
+
+// HistoryPeerStatus is the sync state with one paired node.
+type HistoryPeerStatus struct {
+	PeerID       string `json:"peer_id"`
+	Configured   bool   `json:"configured"` // Listed in history_sync.peers
+	Connected    bool   `json:"connected"`
+	LastSyncAt   int64  `json:"last_sync_at,omitempty"`
+	LastError    string `json:"last_error,omitempty"`
+	TradesSince  int64  `json:"trades_since"` // Cursors into the peer's history
+	OrdersSince  int64  `json:"orders_since"`
+	TradesMerged int    `json:"trades_merged"` // Records written from the peer
+	OrdersMerged int    `json:"orders_merged"`
+}
+
+// HistorySync replicates completed trade and order history with paired nodes.
+type HistorySync struct {
+	host     host.Host
+	store    *storage.Storage
+	interval time.Duration
+	log      *logging.Logger
+
+	mu     gosync.RWMutex
+	peers  map[peer.ID]*HistoryPeerStatus
+	syncMu gosync.Mutex // One pull at a time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     gosync.WaitGroup
+}
+
+// NewHistorySync creates a history sync handler. Paired peers are the
+// configured ones plus those added with Pair on earlier runs.
+func NewHistorySync(h host.Host, store *storage.Storage, cfg node.HistorySyncConfig) (*HistorySync, error) {
+	if cfg.Interval <= 0 {
+		cfg.Interval = node.DefaultConfig().HistorySync.Interval
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	hs := &HistorySync{
+		host:     h,
+		store:    store,
+		interval: cfg.Interval,
+		log:      logging.GetDefault().Component("historysync"),
+		peers:    make(map[peer.ID]*HistoryPeerStatus),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	saved, err := store.GetSetting(historyPeersSetting)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	previous := make(map[peer.ID]*HistoryPeerStatus)
+	if saved != "" {
+		var statuses []*HistoryPeerStatus
+		if err := json.Unmarshal([]byte(saved), &statuses); err != nil {
+			hs.log.Warn("Ignoring corrupt paired peers", "error", err)
+		}
+		for _, st := range statuses {
+			if id, err := peer.Decode(st.PeerID); err == nil {
+				previous[id] = st
+			}
+		}
+	}
+
+	// Peers paired at runtime stay paired, peers dropped from the config
+	// are unpaired
+	for id, st := range previous {
+		if !st.Configured {
+			hs.peers[id] = st
+		}
+	}
+	for _, s := range cfg.Peers {
+		id, err := peer.Decode(s)
+		if err != nil {
+			cancel()
+			return nil, fmt.Errorf("invalid history sync peer ID %q: %w", s, err)
+		}
+		st := previous[id]
+		if st == nil {
+			st = &HistoryPeerStatus{PeerID: id.String()}
+		}
+		st.Configured = true
+		hs.peers[id] = st
+	}
+	return hs, nil
+}
+
+// Start serves paired nodes and pulls from them on connect and every interval.
+func (hs *HistorySync) Start() error {
+	// Restarted after Stop
+	if hs.ctx.Err() != nil {
+		hs.ctx, hs.cancel = context.WithCancel(context.Background())
+	}
+
+	hs.host.SetStreamHandler(protocol.ID(HistorySyncProtocol), hs.handleStream)
+
+	sub, err := hs.host.EventBus().Subscribe(new(event.EvtPeerConnectednessChanged))
+	if err != nil {
+		return fmt.Errorf("failed to subscribe to peer events: %w", err)
+	}
+
+	hs.wg.Add(1)
+	go func() {
+		defer hs.wg.Done()
+		defer sub.Close()
+		ticker := time.NewTicker(hs.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-hs.ctx.Done():
+				return
+			case ev := <-sub.Out():
+				e, ok := ev.(event.EvtPeerConnectednessChanged)
+				if ok && e.Connectedness == network.Connected && hs.IsPaired(e.Peer) {
+					hs.goSync(e.Peer)
+				}
+			case <-ticker.C:
+				for _, p := range hs.pairedPeers() {
+					if hs.host.Network().Connectedness(p) == network.Connected {
+						hs.syncLogged(p)
+					}
+				}
+			}
+		}
+	}()
+
+	hs.log.Info("History sync started", "protocol", HistorySyncProtocol, "peers", len(hs.pairedPeers()), "interval", hs.interval)
+	return nil
+}
+
+// Stop stops syncing and serving.
+func (hs *HistorySync) Stop() error {
+	hs.cancel()
+	hs.host.RemoveStreamHandler(protocol.ID(HistorySyncProtocol))
+	hs.wg.Wait()
+	return nil
+}
+
+// Pair adds a node to the allow-list and syncs with it if connected. The
+// other node must pair with us too before it serves our pulls.
+func (hs *HistorySync) Pair(p peer.ID) error {
+	if p == hs.host.ID() {
+		return fmt.Errorf("cannot pair with ourselves")
+	}
+	hs.mu.Lock()
+	if hs.peers[p] == nil {
+		hs.peers[p] = &HistoryPeerStatus{PeerID: p.String()}
+	}
+	hs.mu.Unlock()
+	if err := hs.save(); err != nil {
+		return err
+	}
+
+	hs.log.Info("Paired for history sync", "peer", shortPeerID(p))
+	if hs.host.Network().Connectedness(p) == network.Connected {
+		hs.goSync(p)
+	}
+	return nil
+}
+
+// Unpair removes a node added with Pair. Replicated records are kept.
+func (hs *HistorySync) Unpair(p peer.ID) error {
+	hs.mu.Lock()
+	st := hs.peers[p]
+	if st == nil {
+		hs.mu.Unlock()
+		return fmt.Errorf("peer %s is not paired", p)
+	}
+	if st.Configured {
+		hs.mu.Unlock()
+		return fmt.Errorf("peer %s is listed in history_sync.peers", p)
+	}
+	delete(hs.peers, p)
+	hs.mu.Unlock()
+	return hs.save()
+}
+
+// IsPaired returns true if the node is on the allow-list.
+func (hs *HistorySync) IsPaired(p peer.ID) bool {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	return hs.peers[p] != nil
+}
+
+// Status returns the sync state with each paired node.
+func (hs *HistorySync) Status() []*HistoryPeerStatus {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	statuses := make([]*HistoryPeerStatus, 0, len(hs.peers))
+	for id, st := range hs.peers {
+		cp := *st
+		cp.Connected = hs.host.Network().Connectedness(id) == network.Connected
+		statuses = append(statuses, &cp)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].PeerID < statuses[j].PeerID })
+	return statuses
+}
+
+// SyncWithPeer pulls history from a paired node until we are caught up.
+func (hs *HistorySync) SyncWithPeer(ctx context.Context, p peer.ID) error {
+	hs.syncMu.Lock()
+	defer hs.syncMu.Unlock()
+
+	hs.mu.RLock()
+	st := hs.peers[p]
+	var tradesSince, ordersSince int64
+	if st != nil {
+		tradesSince, ordersSince = st.TradesSince, st.OrdersSince
+	}
+	hs.mu.RUnlock()
+	if st == nil {
+		return fmt.Errorf("peer %s is not paired", p)
+	}
+
+	var tradesMerged, ordersMerged int
+	var err error
+	for {
+		var resp *HistorySyncResponse
+		resp, err = hs.fetchPage(ctx, p, HistorySyncRequest{
+			TradesSince: tradesSince,
+			OrdersSince: ordersSince,
+			Limit:       MaxHistoryPerSync,
+		})
+		if err != nil {
+			break
+		}
+
+		// Orders first, so merged trades find their order
+		next := ordersSince
+		for _, o := range resp.Orders {
+			written, mergeErr := hs.store.MergeOrder(o)
+			if mergeErr != nil {
+				hs.log.Debug("Failed to merge order", "id", o.ID, "error", mergeErr)
+			} else if written {
+				ordersMerged++
+			}
+			if u := o.UpdatedUnix(); u > next {
+				next = u
+			}
+		}
+		ordersSince = advanceCursor(ordersSince, next, len(resp.Orders))
+
+		next = tradesSince
+		for _, t := range resp.Trades {
+			written, mergeErr := hs.store.MergeTrade(t)
+			if mergeErr != nil {
+				hs.log.Debug("Failed to merge trade", "id", t.ID, "error", mergeErr)
+			} else if written {
+				tradesMerged++
+			}
+			if u := t.UpdatedUnix(); u > next {
+				next = u
+			}
+		}
+		tradesSince = advanceCursor(tradesSince, next, len(resp.Trades))
+
+		if !resp.HasMore {
+			break
+		}
+	}
+
+	hs.mu.Lock()
+	if st := hs.peers[p]; st != nil {
+		st.TradesSince, st.OrdersSince = tradesSince, ordersSince
+		st.TradesMerged += tradesMerged
+		st.OrdersMerged += ordersMerged
+		st.LastError = ""
+		if err != nil {
+			st.LastError = err.Error()
+		} else {
+			st.LastSyncAt = time.Now().Unix()
+		}
+	}
+	hs.mu.Unlock()
+	if saveErr := hs.save(); saveErr != nil {
+		hs.log.Warn("Failed to save history sync state", "error", saveErr)
+	}
+	if err != nil {
+		return err
+	}
+
+	hs.log.Info("History sync completed",
+		"peer", shortPeerID(p),
+		"trades", tradesMerged,
+		"orders", ordersMerged,
+	)
+	return nil
+}
+
+// advanceCursor moves a cursor to the newest update seen. Pages overlap by
+// one second because cursors are inclusive; a full page from a single
+// second skips past it so paging always makes progress.
+func advanceCursor(since, newest int64, count int) int64 {
+	if newest == since && count >= MaxHistoryPerSync {
+		return since + 1
+	}
+	return newest
+}
+
+// fetchPage requests one page of history from a peer.
+func (hs *HistorySync) fetchPage(ctx context.Context, p peer.ID, req HistorySyncRequest) (*HistorySyncResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, SyncTimeout)
+	defer cancel()
+
+	stream, err := hs.host.NewStream(ctx, p, protocol.ID(HistorySyncProtocol))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open stream: %w", err)
+	}
+	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
+
+	if err := json.NewEncoder(stream).Encode(&req); err != nil {
+		return nil, fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp HistorySyncResponse
+	if err := node.SyncResponseLimits.Decode(stream, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(resp.Trades) > req.Limit || len(resp.Orders) > req.Limit {
+		return nil, fmt.Errorf("page has %d trades and %d orders, asked for %d", len(resp.Trades), len(resp.Orders), req.Limit)
+	}
+	if resp.Error != "" {
+		return nil, fmt.Errorf("peer refused: %s", resp.Error)
+	}
+	return &resp, nil
+}
+
+// handleStream serves one page of our history to a paired node.
+func (hs *HistorySync) handleStream(stream network.Stream) {
+	defer stream.Close()
+	stream.SetDeadline(time.Now().Add(SyncTimeout))
+
+	remote := stream.Conn().RemotePeer()
+	encoder := json.NewEncoder(stream)
+	if !hs.IsPaired(remote) {
+		hs.log.Debug("History sync request from unpaired peer", "peer", shortPeerID(remote))
+		encoder.Encode(&HistorySyncResponse{Error: "not paired", Timestamp: time.Now().Unix()})
+		return
+	}
+
+	var req HistorySyncRequest
+	if err := node.SyncRequestLimits.Decode(stream, &req); err != nil {
+		if err != io.EOF {
+			hs.log.Debug("Failed to read history sync request", "error", err)
+		}
+		return
+	}
+	if req.Limit <= 0 || req.Limit > MaxHistoryPerSync {
+		req.Limit = MaxHistoryPerSync
+	}
+
+	resp := HistorySyncResponse{Timestamp: time.Now().Unix()}
+	trades, err := hs.store.ListTrades(storage.TradeFilter{Terminal: true, UpdatedSince: &req.TradesSince, Limit: req.Limit})
+	if err != nil {
+		hs.log.Debug("Failed to list trade history", "error", err)
+		resp.Error = "internal error"
+	}
+	orders, err := hs.store.ListOrders(storage.OrderFilter{Terminal: true, UpdatedSince: &req.OrdersSince, Limit: req.Limit})
+	if err != nil {
+		hs.log.Debug("Failed to list order history", "error", err)
+		resp.Error = "internal error"
+	}
+	if resp.Error == "" {
+		resp.Trades, resp.Orders = trades, orders
+		resp.HasMore = len(trades) == req.Limit || len(orders) == req.Limit
+	}
+
+	if err := encoder.Encode(&resp); err != nil {
+		hs.log.Debug("Failed to send history sync response", "error", err)
+		return
+	}
+	hs.log.Debug("Sent history sync page",
+		"to", shortPeerID(remote),
+		"trades", len(resp.Trades),
+		"orders", len(resp.Orders),
+	)
+}
+
+// goSync syncs with a peer in the background.
+func (hs *HistorySync) goSync(p peer.ID) {
+	hs.wg.Add(1)
+	go func() {
+		defer hs.wg.Done()
+		hs.syncLogged(p)
+	}()
+}
+
+// syncLogged syncs with a peer, logging failures.
+func (hs *HistorySync) syncLogged(p peer.ID) {
+	if err := hs.SyncWithPeer(hs.ctx, p); err != nil {
+		hs.log.Debug("History sync failed", "peer", shortPeerID(p), "error", err)
+	}
+}
+
+// pairedPeers returns the allow-list.
+func (hs *HistorySync) pairedPeers() []peer.ID {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+	ids := make([]peer.ID, 0, len(hs.peers))
+	for id := range hs.peers {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// save persists paired peers and their cursors.
+func (hs *HistorySync) save() error {
+	statuses := hs.Status()
+	for _, st := range statuses {
+		st.Connected = false
+	}
+	data, err := json.Marshal(statuses)
+	if err != nil {
+		return err
+	}
+	return hs.store.SetSetting(historyPeersSetting, string(data))
+}
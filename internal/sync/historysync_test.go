@@ -0,0 +1,257 @@
+package sync
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/host"
+	"github.com/libp2p/go-libp2p/core/network"
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newHistoryStore(t *testing.T) *storage.Storage {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+func newHistorySync(t *testing.T, h host.Host, store *storage.Storage, peers ...host.Host) *HistorySync {
+	t.Helper()
+	var cfg node.HistorySyncConfig
+	for _, p := range peers {
+		cfg.Peers = append(cfg.Peers, p.ID().String())
+	}
+	hs, err := NewHistorySync(h, store, cfg)
+	if err != nil {
+		t.Fatalf("NewHistorySync() error = %v", err)
+	}
+	if err := hs.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	t.Cleanup(func() { hs.Stop() })
+	return hs
+}
+
+func addTrade(t *testing.T, store *storage.Storage, id string, state storage.TradeState, updated time.Time) {
+	t.Helper()
+	trade := &storage.Trade{
+		ID: id, OrderID: "order-" + id, MakerPeerID: "maker", TakerPeerID: "taker",
+		OurRole: storage.TradeRoleMaker, Method: "htlc", State: state,
+		OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		CreatedAt: updated.Add(-time.Hour), UpdatedAt: &updated,
+	}
+	if _, err := store.MergeTrade(trade); err != nil {
+		t.Fatalf("MergeTrade() error = %v", err)
+	}
+	order := &storage.Order{
+		ID: "order-" + id, PeerID: "maker", Status: storage.OrderStatusCompleted, IsLocal: true,
+		OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+		CreatedAt: updated.Add(-2 * time.Hour), UpdatedAt: &updated,
+	}
+	if _, err := store.MergeOrder(order); err != nil {
+		t.Fatalf("MergeOrder() error = %v", err)
+	}
+}
+
+func TestHistorySyncBidirectional(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	base := time.Unix(1700000000, 0)
+	storeA, storeB := newHistoryStore(t), newHistoryStore(t)
+	addTrade(t, storeA, "a-only", storage.TradeStateRedeemed, base)
+	addTrade(t, storeA, "active", storage.TradeStateFunded, base) // Not history yet
+	addTrade(t, storeB, "b-only", storage.TradeStateRefunded, base)
+	// Conflict: A's copy of "shared" is older than B's
+	addTrade(t, storeA, "shared", storage.TradeStateFailed, base)
+	addTrade(t, storeB, "shared", storage.TradeStateRedeemed, base.Add(time.Minute))
+
+	syncA := newHistorySync(t, hosts[0], storeA, hosts[1])
+	syncB := newHistorySync(t, hosts[1], storeB, hosts[0])
+
+	ctx := context.Background()
+	if err := syncA.SyncWithPeer(ctx, hosts[1].ID()); err != nil {
+		t.Fatalf("A SyncWithPeer() error = %v", err)
+	}
+	if err := syncB.SyncWithPeer(ctx, hosts[0].ID()); err != nil {
+		t.Fatalf("B SyncWithPeer() error = %v", err)
+	}
+
+	for name, store := range map[string]*storage.Storage{"A": storeA, "B": storeB} {
+		for _, id := range []string{"a-only", "b-only", "shared"} {
+			if _, err := store.GetTrade(id); err != nil {
+				t.Errorf("%s missing trade %s: %v", name, id, err)
+			}
+			if _, err := store.GetOrder("order-" + id); err != nil {
+				t.Errorf("%s missing order-%s: %v", name, id, err)
+			}
+		}
+		if shared, _ := store.GetTrade("shared"); shared == nil || shared.State != storage.TradeStateRedeemed {
+			t.Errorf("%s shared trade = %+v, want B's newer redeemed copy", name, shared)
+		}
+	}
+	if _, err := storeB.GetTrade("active"); err == nil {
+		t.Error("active trade was replicated")
+	}
+
+	status := syncA.Status()
+	if len(status) != 1 || !status[0].Configured || status[0].LastSyncAt == 0 || status[0].TradesMerged != 2 {
+		t.Errorf("A status = %+v, want b-only and shared merged", status[0])
+	}
+	if status[0].TradesSince != base.Add(time.Minute).Unix() {
+		t.Errorf("TradesSince = %d, want newest update", status[0].TradesSince)
+	}
+
+	// Nothing new: the next sync writes nothing
+	if err := syncA.SyncWithPeer(ctx, hosts[1].ID()); err != nil {
+		t.Fatalf("SyncWithPeer() error = %v", err)
+	}
+	if got := syncA.Status()[0].TradesMerged; got != 2 {
+		t.Errorf("TradesMerged after resync = %d, want 2", got)
+	}
+}
+
+func TestHistorySyncRequiresMutualPairing(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	storeA, storeB := newHistoryStore(t), newHistoryStore(t)
+	addTrade(t, storeB, "secret", storage.TradeStateRedeemed, time.Unix(1700000000, 0))
+	syncA := newHistorySync(t, hosts[0], storeA)
+	newHistorySync(t, hosts[1], storeB) // B has not paired with A
+
+	ctx := context.Background()
+	if err := syncA.SyncWithPeer(ctx, hosts[1].ID()); err == nil {
+		t.Error("SyncWithPeer() with an unpaired peer succeeded")
+	}
+	if err := syncA.Pair(hosts[1].ID()); err != nil {
+		t.Fatalf("Pair() error = %v", err)
+	}
+	err = syncA.SyncWithPeer(ctx, hosts[1].ID())
+	if err == nil || !strings.Contains(err.Error(), "not paired") {
+		t.Errorf("SyncWithPeer() = %v, want refused by B", err)
+	}
+	if status := syncA.Status(); len(status) != 1 || !strings.Contains(status[0].LastError, "not paired") {
+		t.Errorf("status = %+v, want last error", status)
+	}
+	if _, err := storeA.GetTrade("secret"); err == nil {
+		t.Error("trade replicated from a peer that did not pair with us")
+	}
+}
+
+func TestHistorySyncBoundedResponses(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+	syncA := newHistorySync(t, hosts[0], newHistoryStore(t), hosts[1])
+
+	// A hostile peer answers with pages over the limits
+	pages := map[string]string{
+		"oversized":     `{"trades":[{"id":"` + strings.Repeat("x", int(node.SyncResponseLimits.MaxBytes)) + `"}]}`,
+		"too many":      `{"trades":[` + strings.Repeat(`{},`, 600) + `{}]}`,
+		"beyond asked":  `{"trades":[` + strings.Repeat(`{},`, MaxHistoryPerSync) + `{}]}`,
+		"deeply nested": `{"trades":` + strings.Repeat(`[`, 32) + strings.Repeat(`]`, 32) + `}`,
+	}
+	for name, page := range pages {
+		hosts[1].SetStreamHandler(protocol.ID(HistorySyncProtocol), func(s network.Stream) {
+			defer s.Close()
+			io.WriteString(s, page+"\n")
+		})
+		err := syncA.SyncWithPeer(context.Background(), hosts[1].ID())
+		if err == nil {
+			t.Errorf("%s: SyncWithPeer() accepted the page", name)
+		}
+		if name == "oversized" && !errors.Is(err, node.ErrMessageTooLarge) {
+			t.Errorf("%s: error = %v, want ErrMessageTooLarge", name, err)
+		}
+	}
+}
+
+func TestHistorySyncPairingPersists(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(3)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	store := newHistoryStore(t)
+	hs := newHistorySync(t, hosts[0], store, hosts[1])
+	if err := hs.Pair(hosts[2].ID()); err != nil {
+		t.Fatalf("Pair() error = %v", err)
+	}
+	if err := hs.Pair(hosts[0].ID()); err == nil {
+		t.Error("Pair() with ourselves succeeded")
+	}
+	if err := hs.Unpair(hosts[1].ID()); err == nil {
+		t.Error("Unpair() removed a configured peer")
+	}
+	hs.Stop()
+
+	// Runtime pairings survive a restart; peers dropped from the config don't
+	restarted, err := NewHistorySync(hosts[0], store, node.HistorySyncConfig{})
+	if err != nil {
+		t.Fatalf("NewHistorySync() error = %v", err)
+	}
+	if !restarted.IsPaired(hosts[2].ID()) || restarted.IsPaired(hosts[1].ID()) {
+		t.Errorf("paired after restart = %+v", restarted.Status())
+	}
+	if err := restarted.Unpair(hosts[2].ID()); err != nil || restarted.IsPaired(hosts[2].ID()) {
+		t.Errorf("Unpair() = %v", err)
+	}
+}
+
+func TestHistorySyncRestart(t *testing.T) {
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	defer mn.Close()
+	hosts := mn.Hosts()
+
+	storeA, storeB := newHistoryStore(t), newHistoryStore(t)
+	addTrade(t, storeB, "b-only", storage.TradeStateRedeemed, time.Unix(1700000000, 0))
+	syncA := newHistorySync(t, hosts[0], storeA, hosts[1])
+	syncB := newHistorySync(t, hosts[1], storeB, hosts[0])
+
+	ctx := context.Background()
+	if err := syncB.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	if err := syncA.SyncWithPeer(ctx, hosts[1].ID()); err == nil {
+		t.Fatal("SyncWithPeer() succeeded against a stopped peer")
+	}
+
+	if err := syncB.Start(); err != nil {
+		t.Fatalf("Start() after Stop() error = %v", err)
+	}
+	if err := syncA.SyncWithPeer(ctx, hosts[1].ID()); err != nil {
+		t.Fatalf("SyncWithPeer() after restart error = %v", err)
+	}
+	if _, err := storeA.GetTrade("b-only"); err != nil {
+		t.Errorf("trade not synced after restart: %v", err)
+	}
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/libp2p/go-libp2p/core/peer"
 	"github.com/libp2p/go-libp2p/core/protocol"
 
+	"github.com/Klingon-tech/klingdex/internal/node"
 	"github.com/Klingon-tech/klingdex/internal/storage"
 	"github.com/Klingon-tech/klingdex/pkg/logging"
 )
@@ -93,8 +94,14 @@ func NewOrderSync(h host.Host, store *storage.Storage, validator OrderValidator)
 
 // Start starts the order sync service.
 func (os *OrderSync) Start() error {
-	// Register protocol handler for incoming sync requests
+	// Restarted after Stop
+	if os.ctx.Err() != nil {
+		os.ctx, os.cancel = context.WithCancel(context.Background())
+	}
+
+	// Register protocol handlers for incoming sync requests
 	os.host.SetStreamHandler(protocol.ID(OrderSyncProtocol), os.handleSyncStream)
+	os.host.SetStreamHandler(protocol.ID(OrderReconcileProtocol), os.handleReconcileStream)
 
 	// Watch for peer connections
 	go os.watchConnections()
@@ -107,6 +114,7 @@ func (os *OrderSync) Start() error {
 func (os *OrderSync) Stop() error {
 	os.cancel()
 	os.host.RemoveStreamHandler(protocol.ID(OrderSyncProtocol))
+	os.host.RemoveStreamHandler(protocol.ID(OrderReconcileProtocol))
 	os.log.Info("Order sync stopped")
 	return nil
 }
@@ -159,7 +167,8 @@ func (os *OrderSync) onPeerConnected(p peer.ID) {
 	}
 }
 
-// SyncWithPeer synchronizes orders with a specific peer.
+// SyncWithPeer synchronizes orders with a specific peer, by set
+// reconciliation if the peer supports it.
 func (os *OrderSync) SyncWithPeer(p peer.ID) error {
 	os.log.Debug("Syncing orders with peer", "peer", shortPeerID(p))
 
@@ -167,12 +176,43 @@ func (os *OrderSync) SyncWithPeer(p peer.ID) error {
 	defer cancel()
 
 	// Open stream to peer
-	stream, err := os.host.NewStream(ctx, p, protocol.ID(OrderSyncProtocol))
+	stream, err := os.host.NewStream(ctx, p, protocol.ID(OrderReconcileProtocol), protocol.ID(OrderSyncProtocol))
 	if err != nil {
 		return fmt.Errorf("failed to open stream: %w", err)
 	}
 	defer stream.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		stream.SetDeadline(deadline)
+	}
 
+	var received, newOrders int
+	if stream.Protocol() == protocol.ID(OrderReconcileProtocol) {
+		received, newOrders, err = os.reconcileWithPeer(stream)
+	} else {
+		received, newOrders, err = os.fetchOrders(stream)
+	}
+	if err != nil {
+		return err
+	}
+
+	// Mark peer as synced
+	os.mu.Lock()
+	os.syncedPeers[p] = time.Now()
+	os.mu.Unlock()
+
+	os.log.Info("Order sync completed",
+		"peer", shortPeerID(p),
+		"protocol", stream.Protocol(),
+		"received", received,
+		"new", newOrders,
+	)
+
+	return nil
+}
+
+// fetchOrders asks a peer without reconciliation support for its open
+// orders. It returns the orders received and stored.
+func (os *OrderSync) fetchOrders(stream network.Stream) (int, int, error) {
 	// Send sync request
 	req := SyncRequest{
 		Since: 0, // Get all active orders
@@ -181,19 +221,26 @@ func (os *OrderSync) SyncWithPeer(p peer.ID) error {
 
 	encoder := json.NewEncoder(stream)
 	if err := encoder.Encode(&req); err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return 0, 0, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	// Read response
-	decoder := json.NewDecoder(stream)
 	var resp SyncResponse
-	if err := decoder.Decode(&resp); err != nil {
-		return fmt.Errorf("failed to read response: %w", err)
+	if err := node.SyncResponseLimits.Decode(stream, &resp); err != nil {
+		return 0, 0, fmt.Errorf("failed to read response: %w", err)
+	}
+	if len(resp.Orders) > req.Limit {
+		return 0, 0, fmt.Errorf("response has %d orders, asked for %d", len(resp.Orders), req.Limit)
 	}
 
-	// Process received orders
+	return len(resp.Orders), os.storeOrders(resp.Orders), nil
+}
+
+// storeOrders validates and stores orders received from a peer. It returns
+// the number of new orders.
+func (os *OrderSync) storeOrders(orders []*storage.Order) int {
 	newOrders := 0
-	for _, order := range resp.Orders {
+	for _, order := range orders {
 		// Validate order if validator is set
 		if os.validator != nil {
 			if err := os.validator(order); err != nil {
@@ -212,6 +259,16 @@ func (os *OrderSync) SyncWithPeer(p peer.ID) error {
 				if err := os.store.SaveOrder(order); err != nil {
 					os.log.Debug("Failed to update order", "id", order.ID, "error", err)
 				}
+			} else if !existing.IsLocal && (existing.Status == storage.OrderStatusOpen || existing.Status == storage.OrderStatusMatched) &&
+				existing.IsNewerFill(order.FilledAmount, order.FillSeq) {
+				// Catch up on fills and releases of a partially taken order we missed
+				status := storage.OrderStatusOpen
+				if order.FilledAmount >= existing.OfferAmount {
+					status = storage.OrderStatusMatched
+				}
+				if err := os.store.UpdateOrderFill(order.ID, order.FilledAmount, order.FillSeq, status); err != nil {
+					os.log.Debug("Failed to update order fill", "id", order.ID, "error", err)
+				}
 			}
 			continue
 		}
@@ -224,19 +281,7 @@ func (os *OrderSync) SyncWithPeer(p peer.ID) error {
 		}
 		newOrders++
 	}
-
-	// Mark peer as synced
-	os.mu.Lock()
-	os.syncedPeers[p] = time.Now()
-	os.mu.Unlock()
-
-	os.log.Info("Order sync completed",
-		"peer", shortPeerID(p),
-		"received", len(resp.Orders),
-		"new", newOrders,
-	)
-
-	return nil
+	return newOrders
 }
 
 // handleSyncStream handles incoming sync requests from peers.
@@ -247,9 +292,8 @@ func (os *OrderSync) handleSyncStream(stream network.Stream) {
 	os.log.Debug("Handling order sync request", "from", shortPeerID(remotePeer))
 
 	// Read request
-	decoder := json.NewDecoder(stream)
 	var req SyncRequest
-	if err := decoder.Decode(&req); err != nil {
+	if err := node.SyncRequestLimits.Decode(stream, &req); err != nil {
 		if err != io.EOF {
 			os.log.Debug("Failed to read sync request", "error", err)
 		}
@@ -369,6 +413,11 @@ func NewTradeSync(h host.Host, store *storage.Storage) *TradeSync {
 
 // Start starts the trade sync service.
 func (ts *TradeSync) Start() error {
+	// Restarted after Stop
+	if ts.ctx.Err() != nil {
+		ts.ctx, ts.cancel = context.WithCancel(context.Background())
+	}
+
 	ts.host.SetStreamHandler(protocol.ID(TradeSyncProtocol), ts.handleSyncStream)
 	go ts.watchConnections()
 	ts.log.Info("Trade sync started", "protocol", TradeSyncProtocol)
@@ -449,11 +498,13 @@ func (ts *TradeSync) SyncWithPeer(p peer.ID) error {
 		return fmt.Errorf("failed to send request: %w", err)
 	}
 
-	decoder := json.NewDecoder(stream)
 	var resp TradeSyncResponse
-	if err := decoder.Decode(&resp); err != nil {
+	if err := node.SyncResponseLimits.Decode(stream, &resp); err != nil {
 		return fmt.Errorf("failed to read response: %w", err)
 	}
+	if len(resp.Trades) > req.Limit {
+		return fmt.Errorf("response has %d trades, asked for %d", len(resp.Trades), req.Limit)
+	}
 
 	// Process received trades - only store if we're a participant
 	newTrades := 0
@@ -504,9 +555,8 @@ func (ts *TradeSync) handleSyncStream(stream network.Stream) {
 	remotePeer := stream.Conn().RemotePeer()
 	ts.log.Debug("Handling trade sync request", "from", shortPeerID(remotePeer))
 
-	decoder := json.NewDecoder(stream)
 	var req TradeSyncRequest
-	if err := decoder.Decode(&req); err != nil {
+	if err := node.SyncRequestLimits.Decode(stream, &req); err != nil {
 		if err != io.EOF {
 			ts.log.Debug("Failed to read sync request", "error", err)
 		}
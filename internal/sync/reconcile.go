@@ -0,0 +1,364 @@
+// Package sync - Order sync by set reconciliation.
+//
+// Peers with mostly the same order book exchange only the differences.
+// Both sides sort their open orders by ID; the syncing peer sends the
+// fingerprint of a range of IDs, a hash over the IDs and versions (creation
+// times) of its orders in it. The other side compares it with its own: an
+// equal range is done, a differing one is split into sub-ranges with their
+// fingerprints, and a range holding few orders is listed outright. Listed
+// ranges settle the difference: the syncing peer asks for the orders it
+// lacks or holds older versions of, and is sent the orders missing from the
+// ranges it listed. Rounds repeat until no range differs, so the cost grows
+// with the difference rather than the size of the books.
+//
+// Each message carries at most MaxOrdersPerSync orders and
+// maxReconcileRanges ranges; what doesn't fit is left as a fingerprint range
+// to pick up in the next round. Peers without the protocol are synced with
+// the full order list of OrderSyncProtocol.
+package sync
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/libp2p/go-libp2p/core/network"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// OrderReconcileProtocol syncs orders by set reconciliation.
+const OrderReconcileProtocol = "/klingon/ordersync/2.0.0"
+
+// Reconciliation settings
+const (
+	reconcileListMax   = 32 // Ranges with at most this many orders are listed
+	reconcileFanout    = 16 // Sub-ranges a differing range is split into
+	maxReconcileRanges = 64 // Ranges per message
+	maxReconcileRounds = 16 // Round trips per sync
+)
+
+// ReconcileItem is an order in a listed range.
+type ReconcileItem struct {
+	ID      string `json:"id"`
+	Version int64  `json:"v"` // Creation time (Unix)
+}
+
+// ReconcileRange describes the sender's orders with IDs in [Lower, Upper).
+// An empty Upper is unbounded.
+type ReconcileRange struct {
+	Lower       string          `json:"lower"`
+	Upper       string          `json:"upper,omitempty"`
+	Count       int             `json:"count"`
+	Fingerprint string          `json:"fp,omitempty"`     // Unless listed
+	Listed      bool            `json:"listed,omitempty"` // Items holds all the sender's orders in the range
+	Items       []ReconcileItem `json:"items,omitempty"`
+}
+
+// ReconcileMessage is one round of reconciliation, in either direction.
+type ReconcileMessage struct {
+	Ranges []ReconcileRange `json:"ranges,omitempty"`
+	Want   []string         `json:"want,omitempty"`   // From the syncing peer: orders to send
+	Orders []*storage.Order `json:"orders,omitempty"` // To the syncing peer
+}
+
+// validate bounds a peer's message.
+func (m *ReconcileMessage) validate() error {
+	if len(m.Ranges) > maxReconcileRanges {
+		return fmt.Errorf("%d ranges, at most %d", len(m.Ranges), maxReconcileRanges)
+	}
+	if len(m.Want) > MaxOrdersPerSync || len(m.Orders) > MaxOrdersPerSync {
+		return fmt.Errorf("more than %d orders", MaxOrdersPerSync)
+	}
+	for _, r := range m.Ranges {
+		if r.Upper != "" && r.Upper <= r.Lower {
+			return fmt.Errorf("empty range [%q, %q)", r.Lower, r.Upper)
+		}
+		if len(r.Items) > reconcileListMax {
+			return fmt.Errorf("range lists %d orders, at most %d", len(r.Items), reconcileListMax)
+		}
+	}
+	return nil
+}
+
+// orderSet is a set of orders sorted by ID.
+type orderSet []ReconcileItem
+
+// newOrderSet returns the set of orders.
+func newOrderSet(orders []*storage.Order) orderSet {
+	set := make(orderSet, len(orders))
+	for i, o := range orders {
+		set[i] = ReconcileItem{ID: o.ID, Version: o.CreatedAt.Unix()}
+	}
+	sort.Slice(set, func(i, j int) bool { return set[i].ID < set[j].ID })
+	return set
+}
+
+// slice returns the orders with IDs in [lower, upper).
+func (s orderSet) slice(lower, upper string) orderSet {
+	from := sort.Search(len(s), func(i int) bool { return s[i].ID >= lower })
+	to := len(s)
+	if upper != "" {
+		to = sort.Search(len(s), func(i int) bool { return s[i].ID >= upper })
+	}
+	if to < from {
+		to = from
+	}
+	return s[from:to]
+}
+
+// fingerprint hashes the IDs and versions of the orders.
+func (s orderSet) fingerprint() string {
+	var acc [sha256.Size]byte
+	for _, item := range s {
+		h := sha256.New()
+		h.Write([]byte("klingdex/ordersync/v2"))
+		h.Write([]byte(item.ID))
+		var v [8]byte
+		binary.BigEndian.PutUint64(v[:], uint64(item.Version))
+		h.Write(v[:])
+		for i, b := range h.Sum(nil) {
+			acc[i] ^= b
+		}
+	}
+	var n [8]byte
+	binary.BigEndian.PutUint64(n[:], uint64(len(s)))
+	sum := sha256.Sum256(append(acc[:], n[:]...))
+	return hex.EncodeToString(sum[:16])
+}
+
+// describe returns our range [lower, upper): listed if it holds few orders,
+// fingerprinted otherwise.
+func (s orderSet) describe(lower, upper string) ReconcileRange {
+	items := s.slice(lower, upper)
+	r := ReconcileRange{Lower: lower, Upper: upper, Count: len(items)}
+	if len(items) <= reconcileListMax {
+		r.Listed = true
+		r.Items = items
+	} else {
+		r.Fingerprint = items.fingerprint()
+	}
+	return r
+}
+
+// split describes our range [lower, upper) as reconcileFanout sub-ranges
+// of about the same number of orders.
+func (s orderSet) split(lower, upper string) []ReconcileRange {
+	items := s.slice(lower, upper)
+	size := (len(items) + reconcileFanout - 1) / reconcileFanout
+	var ranges []ReconcileRange
+	from := lower
+	for i := size; i < len(items); i += size {
+		ranges = append(ranges, s.describe(from, items[i].ID))
+		from = items[i].ID
+	}
+	return append(ranges, s.describe(from, upper))
+}
+
+// reconcileRange compares a peer's range with ours. It returns the ranges
+// to answer with, the orders of a listed range we lack or hold older
+// (need), and our orders the peer lacks or holds older in it (offer).
+func reconcileRange(ours orderSet, r ReconcileRange) (reply []ReconcileRange, need, offer []string) {
+	mine := ours.slice(r.Lower, r.Upper)
+	if r.Listed {
+		theirs := make(map[string]int64, len(r.Items))
+		for _, item := range r.Items {
+			// Items outside the range could shadow other ranges' orders
+			if item.ID >= r.Lower && (r.Upper == "" || item.ID < r.Upper) {
+				theirs[item.ID] = item.Version
+			}
+		}
+		have := make(map[string]int64, len(mine))
+		for _, item := range mine {
+			have[item.ID] = item.Version
+			if v, ok := theirs[item.ID]; !ok || v < item.Version {
+				offer = append(offer, item.ID)
+			}
+		}
+		for id, v := range theirs {
+			if mv, ok := have[id]; !ok || mv < v {
+				need = append(need, id)
+			}
+		}
+		sort.Strings(need)
+		return nil, need, offer
+	}
+
+	if r.Count == len(mine) && r.Fingerprint == mine.fingerprint() {
+		return nil, nil, nil
+	}
+	if len(mine) <= reconcileListMax {
+		return []ReconcileRange{ours.describe(r.Lower, r.Upper)}, nil, nil
+	}
+	return ours.split(r.Lower, r.Upper), nil, nil
+}
+
+// answerRanges reconciles a peer's ranges with ours. The syncing peer acts
+// on what it needs, the other side on what it offers; at most budget order
+// IDs are returned, and ranges with more are answered with our description
+// so the rest follows in a later round.
+func answerRanges(ours orderSet, ranges []ReconcileRange, syncing bool, budget int) ([]ReconcileRange, []string) {
+	var reply []ReconcileRange
+	var ids []string
+	for _, r := range ranges {
+		sub, need, offer := reconcileRange(ours, r)
+		take := offer
+		if syncing {
+			take = need
+		}
+		if room := budget - len(ids); len(take) > room {
+			if room < 0 {
+				room = 0
+			}
+			take = take[:room]
+			sub = []ReconcileRange{ours.describe(r.Lower, r.Upper)}
+		}
+		ids = append(ids, take...)
+		reply = append(reply, sub...)
+	}
+	return capRanges(ours, reply), ids
+}
+
+// capRanges merges ranges beyond maxReconcileRanges into one, to be split
+// again in later rounds.
+func capRanges(ours orderSet, ranges []ReconcileRange) []ReconcileRange {
+	if len(ranges) <= maxReconcileRanges {
+		return ranges
+	}
+	last := maxReconcileRanges - 1
+	merged := ours.describe(ranges[last].Lower, ranges[len(ranges)-1].Upper)
+	return append(ranges[:last:last], merged)
+}
+
+// openOrderSet returns our open orders as a set.
+func (os *OrderSync) openOrderSet() (orderSet, error) {
+	status := storage.OrderStatusOpen
+	orders, err := os.store.ListOrders(storage.OrderFilter{Status: &status})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list orders: %w", err)
+	}
+	return newOrderSet(orders), nil
+}
+
+// reconcileWithPeer pulls the orders we lack from a peer over a
+// reconciliation stream. It returns the orders received and stored.
+func (os *OrderSync) reconcileWithPeer(stream network.Stream) (int, int, error) {
+	ours, err := os.openOrderSet()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	reader := bufio.NewReader(stream)
+	encoder := json.NewEncoder(stream)
+	msg := ReconcileMessage{Ranges: []ReconcileRange{ours.describe("", "")}}
+
+	received, stored := 0, 0
+	for round := 0; ; round++ {
+		if round == maxReconcileRounds {
+			return received, stored, fmt.Errorf("not reconciled after %d rounds", round)
+		}
+		if err := encoder.Encode(&msg); err != nil {
+			return received, stored, fmt.Errorf("failed to send ranges: %w", err)
+		}
+
+		var reply ReconcileMessage
+		line, err := node.SyncResponseLimits.ReadLine(reader)
+		if err == nil || (err == io.EOF && len(line) > 0) {
+			err = node.SyncResponseLimits.Unmarshal(line, &reply)
+		}
+		if err != nil {
+			return received, stored, fmt.Errorf("failed to read reply: %w", err)
+		}
+		if err := reply.validate(); err != nil {
+			return received, stored, fmt.Errorf("invalid reply: %w", err)
+		}
+
+		received += len(reply.Orders)
+		stored += os.storeOrders(reply.Orders)
+		if len(reply.Ranges) == 0 {
+			return received, stored, nil
+		}
+
+		// Compare with what we hold now
+		if ours, err = os.openOrderSet(); err != nil {
+			return received, stored, err
+		}
+		msg = ReconcileMessage{}
+		msg.Ranges, msg.Want = answerRanges(ours, reply.Ranges, true, MaxOrdersPerSync)
+		if len(msg.Ranges) == 0 && len(msg.Want) == 0 {
+			return received, stored, nil
+		}
+	}
+}
+
+// handleReconcileStream answers a peer reconciling its orders with ours.
+func (os *OrderSync) handleReconcileStream(stream network.Stream) {
+	defer stream.Close()
+
+	remotePeer := stream.Conn().RemotePeer()
+	os.log.Debug("Handling order reconciliation", "from", shortPeerID(remotePeer))
+
+	reader := bufio.NewReader(stream)
+	encoder := json.NewEncoder(stream)
+	sent := 0
+	for round := 0; round < maxReconcileRounds; round++ {
+		var msg ReconcileMessage
+		line, err := node.SyncReconcileLimits.ReadLine(reader)
+		if err == nil || (err == io.EOF && len(line) > 0) {
+			err = node.SyncReconcileLimits.Unmarshal(line, &msg)
+		}
+		if err == nil {
+			err = msg.validate()
+		}
+		if err != nil {
+			if err != io.EOF {
+				os.log.Debug("Failed to read reconciliation message", "from", shortPeerID(remotePeer), "error", err)
+			}
+			break
+		}
+
+		reply, err := os.answerReconcile(&msg)
+		if err != nil {
+			os.log.Debug("Failed to answer reconciliation", "error", err)
+			return
+		}
+		if err := encoder.Encode(reply); err != nil {
+			os.log.Debug("Failed to send reconciliation reply", "error", err)
+			return
+		}
+		sent += len(reply.Orders)
+	}
+
+	os.log.Debug("Reconciled orders with peer", "with", shortPeerID(remotePeer), "sent", sent)
+}
+
+// answerReconcile sends the orders a peer wants or lacks and answers its
+// ranges.
+func (os *OrderSync) answerReconcile(msg *ReconcileMessage) (*ReconcileMessage, error) {
+	ours, err := os.openOrderSet()
+	if err != nil {
+		return nil, err
+	}
+
+	reply := &ReconcileMessage{}
+	for _, id := range msg.Want {
+		if order, err := os.store.GetOrder(id); err == nil && order.Status == storage.OrderStatusOpen {
+			reply.Orders = append(reply.Orders, order)
+		}
+	}
+
+	var offer []string
+	reply.Ranges, offer = answerRanges(ours, msg.Ranges, false, MaxOrdersPerSync-len(reply.Orders))
+	for _, id := range offer {
+		if order, err := os.store.GetOrder(id); err == nil {
+			reply.Orders = append(reply.Orders, order)
+		}
+	}
+	return reply, nil
+}
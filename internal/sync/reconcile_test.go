@@ -0,0 +1,255 @@
+package sync
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/libp2p/go-libp2p/core/protocol"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// testOrders returns open orders o-<from> to o-<to-1> created at base.
+func testOrders(from, to int, base time.Time) []*storage.Order {
+	var orders []*storage.Order
+	for i := from; i < to; i++ {
+		orders = append(orders, &storage.Order{
+			ID: fmt.Sprintf("o-%05d", i), PeerID: "maker", Status: storage.OrderStatusOpen,
+			OfferChain: "BTC", OfferAmount: 1000, RequestChain: "LTC", RequestAmount: 5000,
+			CreatedAt: base,
+		})
+	}
+	return orders
+}
+
+// reconcileSets runs the reconciliation rounds between two sets in memory,
+// applying what the syncing side receives. It returns the order IDs sent
+// and the rounds taken.
+func reconcileSets(t *testing.T, ours, theirs orderSet) (int, int) {
+	t.Helper()
+	held := make(map[string]int64)
+	for _, item := range ours {
+		held[item.ID] = item.Version
+	}
+	apply := func(ids []string) {
+		for _, id := range ids {
+			for _, item := range theirs {
+				if item.ID == id {
+					held[id] = item.Version
+				}
+			}
+		}
+	}
+	current := func() orderSet {
+		set := make(orderSet, 0, len(held))
+		for id, v := range held {
+			set = append(set, ReconcileItem{ID: id, Version: v})
+		}
+		return newOrderSetFromItems(set)
+	}
+
+	sent := 0
+	ranges := []ReconcileRange{ours.describe("", "")}
+	var want []string
+	for round := 1; round <= maxReconcileRounds; round++ {
+		budget := MaxOrdersPerSync - len(want)
+		apply(want)
+		sent += len(want)
+		reply, offer := answerRanges(theirs, ranges, false, budget)
+		apply(offer)
+		sent += len(offer)
+		if len(reply) == 0 {
+			return sent, round
+		}
+		ranges, want = answerRanges(current(), reply, true, MaxOrdersPerSync)
+		if len(ranges) == 0 && len(want) == 0 {
+			return sent, round
+		}
+	}
+	t.Fatalf("not reconciled after %d rounds", maxReconcileRounds)
+	return 0, 0
+}
+
+// newOrderSetFromItems sorts items into a set.
+func newOrderSetFromItems(items []ReconcileItem) orderSet {
+	orders := make([]*storage.Order, len(items))
+	for i, item := range items {
+		orders[i] = &storage.Order{ID: item.ID, CreatedAt: time.Unix(item.Version, 0)}
+	}
+	return newOrderSet(orders)
+}
+
+func TestOrderSetRanges(t *testing.T) {
+	set := newOrderSet(testOrders(0, 100, time.Unix(1700000000, 0)))
+
+	if got := set.slice("o-00010", "o-00020"); len(got) != 10 || got[0].ID != "o-00010" {
+		t.Errorf("slice() = %d items from %v", len(got), got)
+	}
+	if got := set.slice("o-00090", ""); len(got) != 10 {
+		t.Errorf("unbounded slice() = %d items", len(got))
+	}
+	if got := set.slice("o-00020", "o-00010"); len(got) != 0 {
+		t.Errorf("inverted slice() = %d items", len(got))
+	}
+
+	whole := set.describe("", "")
+	if whole.Listed || whole.Count != 100 || whole.Fingerprint == "" {
+		t.Errorf("describe() = %+v, want a fingerprint of 100", whole)
+	}
+	if small := set.describe("o-00000", "o-00005"); !small.Listed || len(small.Items) != 5 {
+		t.Errorf("describe() of 5 orders = %+v, want listed", small)
+	}
+
+	ranges := set.split("", "")
+	total := 0
+	for i, r := range ranges {
+		total += r.Count
+		if i > 0 && r.Lower != ranges[i-1].Upper {
+			t.Errorf("range %d starts at %q, previous ends at %q", i, r.Lower, ranges[i-1].Upper)
+		}
+	}
+	if total != 100 || ranges[0].Lower != "" || ranges[len(ranges)-1].Upper != "" {
+		t.Errorf("split() = %d ranges covering %d orders", len(ranges), total)
+	}
+
+	// A changed version changes the fingerprint
+	other := newOrderSet(testOrders(0, 100, time.Unix(1700000000, 0)))
+	if other.fingerprint() != set.fingerprint() {
+		t.Error("equal sets have different fingerprints")
+	}
+	other[42].Version++
+	if other.fingerprint() == set.fingerprint() {
+		t.Error("fingerprint ignores versions")
+	}
+}
+
+func TestReconcileSets(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	tests := []struct {
+		name     string
+		ours     []*storage.Order
+		theirs   []*storage.Order
+		wantSent int
+	}{
+		{"equal", testOrders(0, 2000, base), testOrders(0, 2000, base), 0},
+		{"few missing", testOrders(0, 2000, base), append(testOrders(0, 2000, base), testOrders(5000, 5005, base)...), 5},
+		{"scattered", append(testOrders(0, 1000, base), testOrders(1010, 2000, base)...), testOrders(0, 2000, base), 10},
+		{"we hold more", testOrders(0, 2000, base), testOrders(0, 1500, base), 0},
+		{"empty", nil, testOrders(0, 300, base), 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent, rounds := reconcileSets(t, newOrderSet(tt.ours), newOrderSet(tt.theirs))
+			if sent != tt.wantSent {
+				t.Errorf("sent %d orders in %d rounds, want %d", sent, rounds, tt.wantSent)
+			}
+		})
+	}
+
+	// A newer version is sent
+	theirs := testOrders(0, 500, base)
+	theirs[250].CreatedAt = base.Add(time.Minute)
+	if sent, _ := reconcileSets(t, newOrderSet(testOrders(0, 500, base)), newOrderSet(theirs)); sent != 1 {
+		t.Errorf("sent %d orders for one newer version, want 1", sent)
+	}
+}
+
+func TestReconcileMessageValidate(t *testing.T) {
+	if err := (&ReconcileMessage{Ranges: make([]ReconcileRange, maxReconcileRanges+1)}).validate(); err == nil {
+		t.Error("validate() accepted too many ranges")
+	}
+	if err := (&ReconcileMessage{Ranges: []ReconcileRange{{Lower: "b", Upper: "a"}}}).validate(); err == nil {
+		t.Error("validate() accepted an inverted range")
+	}
+	if err := (&ReconcileMessage{Ranges: []ReconcileRange{{Listed: true, Items: make([]ReconcileItem, reconcileListMax+1)}}}).validate(); err == nil {
+		t.Error("validate() accepted a long listing")
+	}
+	if err := (&ReconcileMessage{Want: make([]string, MaxOrdersPerSync+1)}).validate(); err == nil {
+		t.Error("validate() accepted too many wanted orders")
+	}
+}
+
+// newOrderSyncPair returns two started order syncs, the second holding
+// theirs and the first ours.
+func newOrderSyncPair(t *testing.T, ours, theirs []*storage.Order) (*OrderSync, *OrderSync) {
+	t.Helper()
+	mn, err := mocknet.FullMeshLinked(2)
+	if err != nil {
+		t.Fatalf("mocknet error = %v", err)
+	}
+	t.Cleanup(func() { mn.Close() })
+	hosts := mn.Hosts()
+
+	var syncs []*OrderSync
+	for i, orders := range [][]*storage.Order{ours, theirs} {
+		store := newHistoryStore(t)
+		for _, o := range orders {
+			if err := store.CreateOrder(o); err != nil {
+				t.Fatalf("CreateOrder() error = %v", err)
+			}
+		}
+		os := NewOrderSync(hosts[i], store, nil)
+		if err := os.Start(); err != nil {
+			t.Fatalf("Start() error = %v", err)
+		}
+		t.Cleanup(func() { os.Stop() })
+		syncs = append(syncs, os)
+	}
+	return syncs[0], syncs[1]
+}
+
+// openOrderCount returns the open orders of a sync's store.
+func openOrderCount(t *testing.T, os *OrderSync) int {
+	t.Helper()
+	set, err := os.openOrderSet()
+	if err != nil {
+		t.Fatalf("openOrderSet() error = %v", err)
+	}
+	return len(set)
+}
+
+func TestOrderSyncReconcile(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	a, b := newOrderSyncPair(t, testOrders(0, 400, base), append(testOrders(0, 400, base), testOrders(900, 903, base)...))
+
+	if err := a.SyncWithPeer(b.host.ID()); err != nil {
+		t.Fatalf("SyncWithPeer() error = %v", err)
+	}
+	if got := openOrderCount(t, a); got != 403 {
+		t.Errorf("open orders after sync = %d, want 403", got)
+	}
+	if order, err := a.store.GetOrder("o-00901"); err != nil || order.IsLocal {
+		t.Errorf("synced order = %+v, %v", order, err)
+	}
+	if a.GetSyncedPeersCount() != 1 {
+		t.Error("peer not marked synced")
+	}
+}
+
+func TestOrderSyncReconcileManyRounds(t *testing.T) {
+	a, b := newOrderSyncPair(t, nil, testOrders(0, 250, time.Unix(1700000000, 0)))
+
+	// More orders than fit in one message
+	if err := a.SyncWithPeer(b.host.ID()); err != nil {
+		t.Fatalf("SyncWithPeer() error = %v", err)
+	}
+	if got := openOrderCount(t, a); got != 250 {
+		t.Errorf("open orders after sync = %d, want 250", got)
+	}
+}
+
+func TestOrderSyncFallback(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	a, b := newOrderSyncPair(t, nil, testOrders(0, 20, base))
+
+	// A peer from before reconciliation
+	b.host.RemoveStreamHandler(protocol.ID(OrderReconcileProtocol))
+	if err := a.SyncWithPeer(b.host.ID()); err != nil {
+		t.Fatalf("SyncWithPeer() error = %v", err)
+	}
+	if got := openOrderCount(t, a); got != 20 {
+		t.Errorf("open orders after full sync = %d, want 20", got)
+	}
+}
@@ -0,0 +1,401 @@
+// Package telemetry publishes opt-in, anonymized network statistics.
+//
+// Every Interval the node aggregates the trades that finished in the last
+// period into a Report: swap counts per chain pair, swap sizes bucketed by
+// order of magnitude, the swap method mix and outcome counts. A report holds
+// no trade, order or peer IDs, no addresses and no exact amounts or times;
+// pairs with fewer than MinPairSwaps swaps are folded into "other", and the
+// report ID is random so reports can't be linked to each other. Reports are
+// POSTed to an HTTPS collector or published on the telemetry gossip topic.
+//
+// Preview returns exactly the report a send would produce now.
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Version names the report format.
+const Version = "klingdex/telemetry/v1"
+
+// Topic is the gossip topic reports are published on without a collector.
+const Topic = "/klingon/telemetry/1.0.0"
+
+// OtherPair collects pairs with too few swaps to report on their own.
+const OtherPair = "other"
+
+// maxTrades bounds the trades aggregated into one report.
+const maxTrades = 100000
+
+// Report is what the collector receives.
+type Report struct {
+	Version     string         `json:"version"`
+	ReportID    string         `json:"report_id"` // Random per report
+	Network     string         `json:"network"`
+	PeriodStart int64          `json:"period_start"` // Unix seconds, whole hours
+	PeriodEnd   int64          `json:"period_end"`
+	Swaps       int            `json:"swaps"`
+	Outcomes    Outcomes       `json:"outcomes"`
+	FailureBps  int            `json:"failure_rate_bps"` // Refunded or failed, in basis points
+	Methods     map[string]int `json:"methods"`
+	Pairs       []PairStats    `json:"pairs"`
+}
+
+// Outcomes counts finished swaps by outcome.
+type Outcomes struct {
+	Completed int `json:"completed"`
+	Refunded  int `json:"refunded"`
+	Failed    int `json:"failed"`
+	Aborted   int `json:"aborted"`
+}
+
+// PairStats aggregates the swaps of one chain pair. Pair names the chains in
+// alphabetical order, so both sides of a swap report the same pair.
+type PairStats struct {
+	Pair     string   `json:"pair"`
+	Swaps    int      `json:"swaps"`
+	Outcomes Outcomes `json:"outcomes"`
+	// Sizes counts swaps by the order of magnitude of the amount on the
+	// pair's first chain, in its smallest unit: "1e5" is 100000 to 999999.
+	Sizes map[string]int `json:"sizes"`
+}
+
+// Status describes the reporter state.
+type Status struct {
+	Enabled     bool    `json:"enabled"`
+	Destination string  `json:"destination"` // Collector URL or the gossip topic
+	Interval    string  `json:"interval"`
+	LastSentAt  int64   `json:"last_sent_at,omitempty"`
+	LastError   string  `json:"last_error,omitempty"`
+	LastReport  *Report `json:"last_report,omitempty"`
+}
+
+// PublishFunc publishes a report on the gossip topic.
+type PublishFunc func(ctx context.Context, data []byte) error
+
+// Reporter aggregates and sends reports.
+type Reporter struct {
+	cfg     node.TelemetryConfig
+	store   *storage.Storage
+	network string
+	client  *http.Client
+	log     *logging.Logger
+
+	mu         sync.RWMutex
+	publish    PublishFunc
+	lastSentAt time.Time
+	lastError  string
+	lastReport *Report
+
+	now    func() time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a reporter for network.
+func New(cfg node.TelemetryConfig, store *storage.Storage, network string) (*Reporter, error) {
+	defaults := node.DefaultConfig().Telemetry
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaults.Interval
+	}
+	if cfg.Interval < time.Hour {
+		return nil, fmt.Errorf("telemetry interval %s is below 1h", cfg.Interval)
+	}
+	if cfg.MinPairSwaps <= 0 {
+		cfg.MinPairSwaps = defaults.MinPairSwaps
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if cfg.Collector != "" {
+		if err := validateCollector(cfg.Collector); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Reporter{
+		cfg:     cfg,
+		store:   store,
+		network: network,
+		client:  &http.Client{Timeout: cfg.Timeout},
+		log:     logging.GetDefault().Component("telemetry"),
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+	}, nil
+}
+
+// validateCollector requires HTTPS, except for a collector on loopback.
+func validateCollector(collector string) error {
+	u, err := url.Parse(collector)
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("invalid telemetry collector %q", collector)
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		if ip := net.ParseIP(u.Hostname()); (ip != nil && ip.IsLoopback()) || u.Hostname() == "localhost" {
+			return nil
+		}
+	}
+	return fmt.Errorf("telemetry collector %q must use https", collector)
+}
+
+// SetPublisher sets how reports are published on the gossip topic.
+func (r *Reporter) SetPublisher(fn PublishFunc) {
+	r.mu.Lock()
+	r.publish = fn
+	r.mu.Unlock()
+}
+
+// Start sends a report every Interval, the first one an Interval after start.
+func (r *Reporter) Start() {
+	if !r.cfg.Enabled {
+		return
+	}
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.cfg.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := r.Send(r.ctx); err != nil {
+					r.log.Warn("Telemetry report failed", "error", err)
+				}
+			}
+		}
+	}()
+	r.log.Info("Telemetry reporting started", "destination", r.destination(), "interval", r.cfg.Interval)
+}
+
+// Stop stops reporting.
+func (r *Reporter) Stop() {
+	r.cancel()
+	r.wg.Wait()
+}
+
+func (r *Reporter) destination() string {
+	if r.cfg.Collector != "" {
+		return r.cfg.Collector
+	}
+	return Topic
+}
+
+// Preview builds the report a send would produce now, without sending it.
+func (r *Reporter) Preview() (*Report, error) {
+	return r.Build(r.now())
+}
+
+// Build aggregates the trades finished in the Interval before now, with
+// both ends truncated to the hour.
+func (r *Reporter) Build(now time.Time) (*Report, error) {
+	end := now.UTC().Truncate(time.Hour)
+	start := end.Add(-r.cfg.Interval)
+	since := start.Unix()
+
+	trades, err := r.store.ListTrades(storage.TradeFilter{
+		Terminal:     true,
+		UpdatedSince: &since,
+		Limit:        maxTrades,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list trades: %w", err)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+	report := &Report{
+		Version:     Version,
+		ReportID:    hex.EncodeToString(id),
+		Network:     r.network,
+		PeriodStart: start.Unix(),
+		PeriodEnd:   end.Unix(),
+		Methods:     make(map[string]int),
+		Pairs:       []PairStats{},
+	}
+
+	pairs := make(map[string]*PairStats)
+	for _, t := range trades {
+		finished := t.CreatedAt
+		if t.UpdatedAt != nil {
+			finished = *t.UpdatedAt
+		}
+		if finished.Before(start) || !finished.Before(end) {
+			continue
+		}
+
+		name, amount := pairOf(t)
+		ps, ok := pairs[name]
+		if !ok {
+			ps = &PairStats{Pair: name, Sizes: make(map[string]int)}
+			pairs[name] = ps
+		}
+		ps.Swaps++
+		ps.Outcomes.add(t.State)
+		ps.Sizes[sizeBucket(amount)]++
+
+		report.Swaps++
+		report.Outcomes.add(t.State)
+		method := t.Method
+		if method == "" {
+			method = "unknown"
+		}
+		report.Methods[method]++
+	}
+
+	other := PairStats{Pair: OtherPair, Sizes: make(map[string]int)}
+	for _, ps := range pairs {
+		if ps.Swaps >= r.cfg.MinPairSwaps {
+			report.Pairs = append(report.Pairs, *ps)
+			continue
+		}
+		// Too few to report without pointing at individual swaps; sizes
+		// are dropped as well
+		other.Swaps += ps.Swaps
+		other.Outcomes.merge(ps.Outcomes)
+	}
+	sort.Slice(report.Pairs, func(i, j int) bool { return report.Pairs[i].Pair < report.Pairs[j].Pair })
+	if other.Swaps > 0 {
+		report.Pairs = append(report.Pairs, other)
+	}
+
+	if report.Swaps > 0 {
+		report.FailureBps = (report.Outcomes.Refunded + report.Outcomes.Failed) * 10000 / report.Swaps
+	}
+	return report, nil
+}
+
+// pairOf names the trade's pair and returns the amount on its first chain.
+func pairOf(t *storage.Trade) (string, uint64) {
+	if t.OfferChain <= t.RequestChain {
+		return t.OfferChain + "/" + t.RequestChain, t.OfferAmount
+	}
+	return t.RequestChain + "/" + t.OfferChain, t.RequestAmount
+}
+
+// sizeBucket returns the order of magnitude of amount, e.g. "1e5".
+func sizeBucket(amount uint64) string {
+	exp := 0
+	for amount >= 10 {
+		amount /= 10
+		exp++
+	}
+	return fmt.Sprintf("1e%d", exp)
+}
+
+func (o *Outcomes) add(state storage.TradeState) {
+	switch state {
+	case storage.TradeStateRedeemed:
+		o.Completed++
+	case storage.TradeStateRefunded:
+		o.Refunded++
+	case storage.TradeStateFailed:
+		o.Failed++
+	case storage.TradeStateAborted:
+		o.Aborted++
+	}
+}
+
+func (o *Outcomes) merge(other Outcomes) {
+	o.Completed += other.Completed
+	o.Refunded += other.Refunded
+	o.Failed += other.Failed
+	o.Aborted += other.Aborted
+}
+
+// Send builds the report for the period that just ended and sends it.
+func (r *Reporter) Send(ctx context.Context) (*Report, error) {
+	report, err := r.Build(r.now())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(report)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.cfg.Collector != "" {
+		err = r.post(ctx, data)
+	} else {
+		r.mu.RLock()
+		publish := r.publish
+		r.mu.RUnlock()
+		if publish == nil {
+			err = fmt.Errorf("no gossip publisher")
+		} else {
+			err = publish(ctx, data)
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.lastError = err.Error()
+		return nil, err
+	}
+	r.lastError = ""
+	r.lastSentAt = r.now()
+	r.lastReport = report
+	r.log.Info("Telemetry report sent", "swaps", report.Swaps, "destination", r.destination())
+	return report, nil
+}
+
+func (r *Reporter) post(ctx context.Context, data []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.cfg.Collector, bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("telemetry collector unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("telemetry collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Status returns the reporter state.
+func (r *Reporter) Status() *Status {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	st := &Status{
+		Enabled:     r.cfg.Enabled,
+		Destination: r.destination(),
+		Interval:    r.cfg.Interval.String(),
+		LastError:   r.lastError,
+		LastReport:  r.lastReport,
+	}
+	if !r.lastSentAt.IsZero() {
+		st.LastSentAt = r.lastSentAt.Unix()
+	}
+	return st
+}
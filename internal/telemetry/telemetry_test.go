@@ -0,0 +1,183 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newTestReporter(t *testing.T, cfg node.TelemetryConfig) (*Reporter, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	r, err := New(cfg, store, "testnet")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	// Cover trades finished up to now
+	r.now = func() time.Time { return time.Now().Add(time.Hour) }
+	return r, store
+}
+
+func addTrade(t *testing.T, store *storage.Storage, id, offer string, offerAmount uint64, request string, requestAmount uint64, method string, state storage.TradeState) {
+	t.Helper()
+	trade := &storage.Trade{
+		ID: id, OrderID: "order-" + id, MakerPeerID: "12D3KooWMaker", TakerPeerID: "12D3KooWTaker",
+		OurRole: storage.TradeRoleMaker, Method: method, State: storage.TradeStateInit,
+		OfferChain: offer, OfferAmount: offerAmount, RequestChain: request, RequestAmount: requestAmount,
+		CreatedAt: time.Now(),
+	}
+	if err := store.CreateTrade(trade); err != nil {
+		t.Fatalf("CreateTrade() error = %v", err)
+	}
+	if err := store.UpdateTradeState(id, state); err != nil {
+		t.Fatalf("UpdateTradeState() error = %v", err)
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	r, store := newTestReporter(t, node.TelemetryConfig{Enabled: true, MinPairSwaps: 3})
+	addTrade(t, store, "t1", "BTC", 150000, "LTC", 1500000, "musig2", storage.TradeStateRedeemed)
+	addTrade(t, store, "t2", "LTC", 2000000, "BTC", 200000, "musig2", storage.TradeStateRedeemed)
+	addTrade(t, store, "t3", "BTC", 9000, "LTC", 90000, "htlc", storage.TradeStateRefunded)
+	addTrade(t, store, "t4", "ETH", 10, "BTC", 1000, "htlc", storage.TradeStateFailed)
+	addTrade(t, store, "t5", "BTC", 1000, "LTC", 1000, "htlc", storage.TradeStateFunding) // Still active
+
+	report, err := r.Preview()
+	if err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if report.Version != Version || report.Network != "testnet" || len(report.ReportID) != 32 {
+		t.Errorf("header = %+v", report)
+	}
+	if report.PeriodEnd%3600 != 0 || report.PeriodEnd-report.PeriodStart != int64(24*time.Hour/time.Second) {
+		t.Errorf("period = %d..%d, want 24 whole hours", report.PeriodStart, report.PeriodEnd)
+	}
+	if report.Swaps != 4 || report.Outcomes != (Outcomes{Completed: 2, Refunded: 1, Failed: 1}) {
+		t.Errorf("swaps = %d, outcomes = %+v", report.Swaps, report.Outcomes)
+	}
+	if report.FailureBps != 5000 {
+		t.Errorf("failure rate = %d bps, want 5000", report.FailureBps)
+	}
+	if report.Methods["musig2"] != 2 || report.Methods["htlc"] != 2 {
+		t.Errorf("methods = %v", report.Methods)
+	}
+
+	if len(report.Pairs) != 2 {
+		t.Fatalf("pairs = %+v, want BTC/LTC and other", report.Pairs)
+	}
+	btcLTC := report.Pairs[0]
+	if btcLTC.Pair != "BTC/LTC" || btcLTC.Swaps != 3 {
+		t.Errorf("pair = %+v", btcLTC)
+	}
+	// Sized by the BTC amount whichever side offered it
+	if btcLTC.Sizes["1e5"] != 2 || btcLTC.Sizes["1e3"] != 1 {
+		t.Errorf("sizes = %v", btcLTC.Sizes)
+	}
+	other := report.Pairs[1]
+	if other.Pair != OtherPair || other.Swaps != 1 || len(other.Sizes) != 0 {
+		t.Errorf("other = %+v, want the lone BTC/ETH swap without sizes", other)
+	}
+
+	// Nothing identifying leaves the node
+	data, _ := json.Marshal(report)
+	for _, leak := range []string{"t1", "order-", "12D3KooW", "150000", "2000000"} {
+		if strings.Contains(string(data), `"`+leak) {
+			t.Errorf("report contains %q: %s", leak, data)
+		}
+	}
+}
+
+func TestSizeBucket(t *testing.T) {
+	for amount, want := range map[uint64]string{0: "1e0", 9: "1e0", 10: "1e1", 999999: "1e5", 1000000: "1e6"} {
+		if got := sizeBucket(amount); got != want {
+			t.Errorf("sizeBucket(%d) = %s, want %s", amount, got, want)
+		}
+	}
+}
+
+func TestSendToCollector(t *testing.T) {
+	var bodies []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		bodies = append(bodies, string(body))
+	}))
+	defer srv.Close()
+
+	r, store := newTestReporter(t, node.TelemetryConfig{Enabled: true, Collector: srv.URL})
+	addTrade(t, store, "t1", "BTC", 150000, "LTC", 1500000, "musig2", storage.TradeStateRedeemed)
+
+	report, err := r.Send(context.Background())
+	if err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if len(bodies) != 1 || !strings.Contains(bodies[0], report.ReportID) {
+		t.Fatalf("collector received %v", bodies)
+	}
+	if st := r.Status(); st.LastSentAt == 0 || st.LastReport == nil || st.Destination != srv.URL {
+		t.Errorf("Status() = %+v", st)
+	}
+	next, _ := r.Preview()
+	if next.ReportID == report.ReportID {
+		t.Error("report IDs repeat")
+	}
+}
+
+func TestSendToGossip(t *testing.T) {
+	r, _ := newTestReporter(t, node.TelemetryConfig{Enabled: true})
+	if _, err := r.Send(context.Background()); err == nil {
+		t.Error("Send() without a publisher succeeded")
+	}
+	if r.Status().LastError == "" {
+		t.Error("failed send not reported in status")
+	}
+
+	var published []byte
+	r.SetPublisher(func(ctx context.Context, data []byte) error {
+		published = data
+		return nil
+	})
+	if _, err := r.Send(context.Background()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(published, &report); err != nil || report.Version != Version {
+		t.Errorf("published %s", published)
+	}
+	if st := r.Status(); st.Destination != Topic || st.LastError != "" {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestNewValidatesConfig(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	for _, cfg := range []node.TelemetryConfig{
+		{Collector: "http://collector.example.com/report"},
+		{Collector: "collector.example.com"},
+		{Interval: time.Minute},
+	} {
+		if _, err := New(cfg, store, "mainnet"); err == nil {
+			t.Errorf("New(%+v) accepted an invalid config", cfg)
+		}
+	}
+	for _, collector := range []string{"https://collector.example.com/report", "http://127.0.0.1:9000", "http://localhost:9000"} {
+		if _, err := New(node.TelemetryConfig{Collector: collector}, store, "mainnet"); err != nil {
+			t.Errorf("New(%s) error = %v", collector, err)
+		}
+	}
+}
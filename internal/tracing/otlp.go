@@ -0,0 +1,41 @@
+// Package tracing - OTLP exporters.
+package tracing
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+// newExporter creates the OTLP exporter of the configured protocol. An
+// http:// endpoint is used without TLS.
+func newExporter(cfg Config) (sdktrace.SpanExporter, error) {
+	ctx := context.Background()
+	if cfg.Protocol == ProtocolGRPC {
+		return otlptracegrpc.New(ctx,
+			otlptracegrpc.WithEndpointURL(cfg.Endpoint),
+			otlptracegrpc.WithHeaders(cfg.Headers),
+			otlptracegrpc.WithTimeout(cfg.Timeout),
+		)
+	}
+	return otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpointURL(cfg.Endpoint),
+		otlptracehttp.WithHeaders(cfg.Headers),
+		otlptracehttp.WithTimeout(cfg.Timeout),
+	)
+}
+
+// countingExporter counts exported and failed spans for Status.
+type countingExporter struct {
+	sdktrace.SpanExporter
+	p *Provider
+}
+
+// ExportSpans implements sdktrace.SpanExporter.
+func (e *countingExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	err := e.SpanExporter.ExportSpans(ctx, spans)
+	e.p.recordExport(len(spans), err)
+	return err
+}
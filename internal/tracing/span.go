@@ -0,0 +1,60 @@
+// Package tracing - Trace IDs and sampling of the SDK provider.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tradeIDGenerator gives root spans started under TradeContext the trace ID
+// of their trade, and random IDs otherwise.
+type tradeIDGenerator struct{}
+
+// NewIDs implements sdktrace.IDGenerator.
+func (g tradeIDGenerator) NewIDs(ctx context.Context) (trace.TraceID, trace.SpanID) {
+	id, ok := tradeTraceFromContext(ctx)
+	if !ok {
+		rand.Read(id[:])
+	}
+	return id, g.NewSpanID(ctx, id)
+}
+
+// NewSpanID implements sdktrace.IDGenerator.
+func (tradeIDGenerator) NewSpanID(ctx context.Context, traceID trace.TraceID) trace.SpanID {
+	var id trace.SpanID
+	rand.Read(id[:])
+	return id
+}
+
+// traceSampler samples a share of traces by trace ID, so every span of a
+// trace and every node decide alike.
+type traceSampler struct {
+	percent int
+}
+
+// ShouldSample implements sdktrace.Sampler.
+func (s traceSampler) ShouldSample(p sdktrace.SamplingParameters) sdktrace.SamplingResult {
+	decision := sdktrace.Drop
+	if sampled(p.TraceID, s.percent) {
+		decision = sdktrace.RecordAndSample
+	}
+	return sdktrace.SamplingResult{
+		Decision:   decision,
+		Tracestate: trace.SpanContextFromContext(p.ParentContext).TraceState(),
+	}
+}
+
+// Description implements sdktrace.Sampler.
+func (s traceSampler) Description() string {
+	return fmt.Sprintf("TradeTraceSampler{%d%%}", s.percent)
+}
+
+// sampled decides whether a trace is exported.
+func sampled(id trace.TraceID, percent int) bool {
+	return binary.BigEndian.Uint64(id[8:])%100 < uint64(percent)
+}
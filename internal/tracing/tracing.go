@@ -0,0 +1,350 @@
+// Package tracing records OpenTelemetry spans of the swap lifecycle and
+// exports them with the OpenTelemetry SDK over OTLP/HTTP or OTLP/gRPC.
+//
+// Instrumented code uses the OpenTelemetry API through Start and
+// StartTrade; until a Provider is started the global tracer provider is a
+// no-op and spans cost next to nothing. Every span of a trade belongs to the
+// same trace, whose ID is derived from the trade ID: RPC calls, P2P messages,
+// transaction construction, broadcasts and confirmation waits of a swap line
+// up in one trace, and since both parties know the trade ID, the spans of
+// the counterparty's node join it too when both export to one collector.
+//
+// Spans are sampled per trace, by trace ID, so either every span of a trade
+// is exported or none is, on both nodes alike.
+package tracing
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"net/url"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/embedded"
+
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// ScopePrefix prefixes the instrumentation scope of each component.
+const ScopePrefix = "github.com/Klingon-tech/klingdex/"
+
+// Span attributes.
+const (
+	AttrTradeID = attribute.Key("klingdex.trade_id")
+	AttrChain   = attribute.Key("klingdex.chain")
+)
+
+// OTLP transports.
+const (
+	ProtocolHTTP = "http" // OTLP/HTTP with protobuf payloads
+	ProtocolGRPC = "grpc"
+)
+
+// Config configures span export.
+type Config struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Protocol is the OTLP transport, http or grpc.
+	Protocol string `yaml:"protocol,omitempty"`
+
+	// Endpoint is the collector URL: the traces URL for http
+	// (http://host:4318/v1/traces), http(s)://host:4317 for grpc.
+	Endpoint string            `yaml:"endpoint,omitempty"`
+	Headers  map[string]string `yaml:"headers,omitempty"` // e.g. authorization
+
+	// ServiceName is reported as the service.name resource attribute.
+	ServiceName string `yaml:"service_name,omitempty"`
+
+	// SamplePercent is the share of traces exported (1-100).
+	SamplePercent int `yaml:"sample_percent,omitempty"`
+
+	// BatchSize spans are sent per request; up to QueueSize wait for
+	// export and later ones are dropped.
+	BatchSize int `yaml:"batch_size,omitempty"`
+	QueueSize int `yaml:"queue_size,omitempty"`
+
+	// FlushInterval is how often queued spans are sent, Timeout bounds
+	// each request.
+	FlushInterval time.Duration `yaml:"flush_interval,omitempty"`
+	Timeout       time.Duration `yaml:"timeout,omitempty"`
+}
+
+// DefaultConfig returns the default export settings, disabled.
+func DefaultConfig() Config {
+	return Config{
+		Protocol:      ProtocolHTTP,
+		Endpoint:      "http://127.0.0.1:4318/v1/traces",
+		ServiceName:   "klingond",
+		SamplePercent: 100,
+		BatchSize:     512,
+		QueueSize:     4096,
+		FlushInterval: 5 * time.Second,
+		Timeout:       10 * time.Second,
+	}
+}
+
+// Validate checks the settings.
+func (c *Config) Validate() error {
+	if c.Protocol != ProtocolHTTP && c.Protocol != ProtocolGRPC {
+		return fmt.Errorf("tracing protocol must be http or grpc, got %q", c.Protocol)
+	}
+	if c.SamplePercent < 1 || c.SamplePercent > 100 {
+		return fmt.Errorf("tracing sample_percent must be between 1 and 100")
+	}
+	if c.BatchSize <= 0 || c.QueueSize < c.BatchSize {
+		return fmt.Errorf("tracing queue_size must be at least batch_size")
+	}
+	if c.FlushInterval <= 0 || c.Timeout <= 0 {
+		return fmt.Errorf("tracing flush_interval and timeout must be positive")
+	}
+	if c.Enabled {
+		u, err := url.Parse(c.Endpoint)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("tracing endpoint must be an http(s) URL, got %q", c.Endpoint)
+		}
+	}
+	return nil
+}
+
+// =============================================================================
+// Instrumentation helpers
+// =============================================================================
+
+// Start starts a span with the tracer of a component.
+func Start(ctx context.Context, component, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return otel.Tracer(ScopePrefix+component).Start(ctx, name, opts...)
+}
+
+// StartTrade starts a span in the trace of a trade.
+func StartTrade(ctx context.Context, component, tradeID, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	if tradeID != "" {
+		ctx = TradeContext(ctx, tradeID)
+		opts = append(opts, trace.WithAttributes(AttrTradeID.String(tradeID)))
+	}
+	return Start(ctx, component, name, opts...)
+}
+
+// End ends a span, marking it failed with err.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+type tradeTraceKey struct{}
+
+// TradeContext returns ctx with spans started from it going to the trace of
+// tradeID. A span already in that trace is kept as the parent; any other is
+// dropped, so the next span is a root of the trade's trace.
+func TradeContext(ctx context.Context, tradeID string) context.Context {
+	if tradeID == "" {
+		return ctx
+	}
+	id := TradeTraceID(tradeID)
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		if sc.TraceID() == id {
+			return ctx
+		}
+		ctx = trace.ContextWithSpanContext(ctx, trace.SpanContext{})
+	}
+	return context.WithValue(ctx, tradeTraceKey{}, id)
+}
+
+// TradeTraceID returns the trace ID of a trade.
+func TradeTraceID(tradeID string) trace.TraceID {
+	sum := sha256.Sum256([]byte("klingdex/trace/" + tradeID))
+	var id trace.TraceID
+	copy(id[:], sum[:])
+	return id
+}
+
+func tradeTraceFromContext(ctx context.Context) (trace.TraceID, bool) {
+	id, ok := ctx.Value(tradeTraceKey{}).(trace.TraceID)
+	return id, ok
+}
+
+// =============================================================================
+// Provider
+// =============================================================================
+
+// Status describes the exporter.
+type Status struct {
+	Enabled       bool   `json:"enabled"`
+	Protocol      string `json:"protocol,omitempty"`
+	Endpoint      string `json:"endpoint,omitempty"`
+	SamplePercent int    `json:"sample_percent"`
+	Exported      uint64 `json:"exported"`
+	Dropped       uint64 `json:"dropped"` // Export failed
+	LastExportAt  int64  `json:"last_export_at,omitempty"`
+	LastError     string `json:"last_error,omitempty"`
+}
+
+// Provider is an OpenTelemetry SDK tracer provider batching ended spans to
+// an OTLP exporter.
+type Provider struct {
+	embedded.TracerProvider
+
+	cfg Config
+	tp  *sdktrace.TracerProvider
+	log *logging.Logger
+
+	mu           sync.Mutex
+	exported     uint64
+	dropped      uint64
+	lastExportAt int64
+	lastError    string
+
+	previous trace.TracerProvider
+}
+
+// New creates a provider. version is reported as service.version.
+func New(cfg Config, version string) (*Provider, error) {
+	defaults := DefaultConfig()
+	if cfg.Protocol == "" {
+		cfg.Protocol = defaults.Protocol
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = defaults.Endpoint
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = defaults.ServiceName
+	}
+	if cfg.SamplePercent == 0 {
+		cfg.SamplePercent = defaults.SamplePercent
+	}
+	if cfg.BatchSize == 0 {
+		cfg.BatchSize = defaults.BatchSize
+	}
+	if cfg.QueueSize == 0 {
+		cfg.QueueSize = max(defaults.QueueSize, cfg.BatchSize)
+	}
+	if cfg.FlushInterval == 0 {
+		cfg.FlushInterval = defaults.FlushInterval
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = defaults.Timeout
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var exporter sdktrace.SpanExporter
+	if cfg.Enabled {
+		var err error
+		if exporter, err = newExporter(cfg); err != nil {
+			return nil, fmt.Errorf("tracing exporter: %w", err)
+		}
+	}
+	return newProvider(cfg, version, exporter), nil
+}
+
+// newProvider builds the SDK provider around exporter; without one spans
+// are sampled and recorded but not exported.
+func newProvider(cfg Config, version string, exporter sdktrace.SpanExporter) *Provider {
+	p := &Provider{
+		cfg: cfg,
+		log: logging.GetDefault().Component("tracing"),
+	}
+
+	// Remote parents don't decide sampling: the trace ID does, on every node
+	sampler := traceSampler{percent: cfg.SamplePercent}
+	opts := []sdktrace.TracerProviderOption{
+		sdktrace.WithResource(resource.NewSchemaless(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", version),
+		)),
+		sdktrace.WithSampler(sdktrace.ParentBased(sampler,
+			sdktrace.WithRemoteParentSampled(sampler),
+			sdktrace.WithRemoteParentNotSampled(sampler),
+		)),
+		sdktrace.WithIDGenerator(tradeIDGenerator{}),
+	}
+	if exporter != nil {
+		opts = append(opts, sdktrace.WithBatcher(&countingExporter{SpanExporter: exporter, p: p},
+			sdktrace.WithMaxExportBatchSize(cfg.BatchSize),
+			sdktrace.WithMaxQueueSize(cfg.QueueSize),
+			sdktrace.WithBatchTimeout(cfg.FlushInterval),
+			sdktrace.WithExportTimeout(cfg.Timeout),
+		))
+	}
+	p.tp = sdktrace.NewTracerProvider(opts...)
+	return p
+}
+
+// Tracer implements trace.TracerProvider.
+func (p *Provider) Tracer(name string, opts ...trace.TracerOption) trace.Tracer {
+	return p.tp.Tracer(name, opts...)
+}
+
+// Start installs the provider as the global tracer provider. It does
+// nothing when disabled.
+func (p *Provider) Start() {
+	if !p.cfg.Enabled {
+		return
+	}
+	p.previous = otel.GetTracerProvider()
+	otel.SetTracerProvider(p)
+	p.log.Info("Tracing started", "protocol", p.cfg.Protocol, "endpoint", p.cfg.Endpoint, "sample_percent", p.cfg.SamplePercent)
+}
+
+// Stop exports the queued spans, shuts the exporter down and restores the
+// previous global provider.
+func (p *Provider) Stop() {
+	if !p.cfg.Enabled {
+		return
+	}
+	if p.previous != nil {
+		otel.SetTracerProvider(p.previous)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cfg.Timeout)
+	defer cancel()
+	if err := p.tp.Shutdown(ctx); err != nil {
+		p.log.Warn("Failed to shut down tracing", "error", err)
+	}
+}
+
+// Status returns the exporter state.
+func (p *Provider) Status() *Status {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	st := &Status{
+		Enabled:       p.cfg.Enabled,
+		SamplePercent: p.cfg.SamplePercent,
+		Exported:      p.exported,
+		Dropped:       p.dropped,
+		LastExportAt:  p.lastExportAt,
+		LastError:     p.lastError,
+	}
+	if p.cfg.Enabled {
+		st.Protocol = p.cfg.Protocol
+		st.Endpoint = p.cfg.Endpoint
+	}
+	return st
+}
+
+// recordExport counts the outcome of exporting a batch of n spans.
+func (p *Provider) recordExport(n int, err error) {
+	p.mu.Lock()
+	if err != nil {
+		p.dropped += uint64(n)
+		p.lastError = err.Error()
+	} else {
+		p.exported += uint64(n)
+		p.lastExportAt = time.Now().Unix()
+		p.lastError = ""
+	}
+	p.mu.Unlock()
+	if err != nil {
+		p.log.Warn("Failed to export spans", "spans", n, "error", err)
+	}
+}
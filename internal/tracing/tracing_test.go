@@ -0,0 +1,296 @@
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector records the spans POSTed to it.
+type collector struct {
+	mu     sync.Mutex
+	spans  []*tracepb.Span
+	header http.Header
+}
+
+func newCollector(t *testing.T) (*collector, *httptest.Server) {
+	c := &collector{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		var req coltracepb.ExportTraceServiceRequest
+		if err == nil {
+			err = proto.Unmarshal(body, &req)
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.header = r.Header.Clone()
+		for _, rs := range req.ResourceSpans {
+			for _, ss := range rs.ScopeSpans {
+				c.spans = append(c.spans, ss.Spans...)
+			}
+		}
+		w.Header().Set("Content-Type", "application/x-protobuf")
+	}))
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+func (c *collector) byName() map[string]*tracepb.Span {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]*tracepb.Span)
+	for _, s := range c.spans {
+		out[s.Name] = s
+	}
+	return out
+}
+
+func newTestProvider(t *testing.T, cfg Config) *Provider {
+	t.Helper()
+	p, err := New(cfg, "test")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return p
+}
+
+func TestTradeSpansShareTrace(t *testing.T) {
+	c, srv := newCollector(t)
+	p := newTestProvider(t, Config{
+		Enabled:       true,
+		Endpoint:      srv.URL + "/v1/traces",
+		Headers:       map[string]string{"Authorization": "Bearer secret"},
+		FlushInterval: time.Hour,
+	})
+	p.Start()
+
+	// An RPC call starts the trade, a swap step and a P2P message follow
+	ctx, rpcSpan := StartTrade(context.Background(), "rpc", "trade-1", "rpc swap_init")
+	_, fundSpan := StartTrade(ctx, "swap", "trade-1", "swap.fund")
+	End(fundSpan, errors.New("insufficient funds"))
+	End(rpcSpan, nil)
+
+	// The counterparty's node only knows the trade ID
+	_, peerSpan := StartTrade(context.Background(), "node", "trade-1", "p2p.receive swap_funded")
+	End(peerSpan, nil)
+
+	// Another trade's span is not parented to the ongoing one
+	_, otherSpan := StartTrade(ctx, "swap", "trade-2", "swap.other")
+	End(otherSpan, nil)
+
+	p.Stop()
+
+	spans := c.byName()
+	if len(spans) != 4 {
+		t.Fatalf("exported %d spans, want 4", len(spans))
+	}
+	want := TradeTraceID("trade-1").String()
+	for _, name := range []string{"rpc swap_init", "swap.fund", "p2p.receive swap_funded"} {
+		if got := hex.EncodeToString(spans[name].TraceId); got != want {
+			t.Errorf("%s trace = %s, want %s", name, got, want)
+		}
+	}
+	if hex.EncodeToString(spans["swap.fund"].ParentSpanId) != hex.EncodeToString(spans["rpc swap_init"].SpanId) {
+		t.Errorf("swap.fund parent = %x, want rpc span %x", spans["swap.fund"].ParentSpanId, spans["rpc swap_init"].SpanId)
+	}
+	if len(spans["rpc swap_init"].ParentSpanId) != 0 || len(spans["p2p.receive swap_funded"].ParentSpanId) != 0 {
+		t.Error("first spans of a trade on each node should be roots")
+	}
+	if other := spans["swap.other"]; hex.EncodeToString(other.TraceId) != TradeTraceID("trade-2").String() || len(other.ParentSpanId) != 0 {
+		t.Errorf("swap.other = %v, want root of trade-2", other)
+	}
+
+	fund := spans["swap.fund"]
+	if fund.Status.GetCode() != tracepb.Status_STATUS_CODE_ERROR || fund.Status.GetMessage() != "insufficient funds" {
+		t.Errorf("swap.fund status = %v", fund.Status)
+	}
+	if len(fund.Events) != 1 || fund.Events[0].Name != "exception" {
+		t.Errorf("swap.fund events = %v", fund.Events)
+	}
+	if c.header.Get("Authorization") != "Bearer secret" {
+		t.Error("configured headers not sent")
+	}
+	if st := p.Status(); st.Exported != 4 || st.LastError != "" || st.Protocol != ProtocolHTTP {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestSamplingIsPerTrace(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.SamplePercent = 50
+	exporter := tracetest.NewInMemoryExporter()
+	p := newProvider(cfg, "test", exporter)
+	tr := p.Tracer("test")
+
+	var yes, no string
+	for i := 0; yes == "" || no == ""; i++ {
+		id := fmt.Sprintf("trade-%d", i)
+		if sampled(TradeTraceID(id), cfg.SamplePercent) {
+			yes = id
+		} else {
+			no = id
+		}
+	}
+
+	ctx, parent := tr.Start(TradeContext(context.Background(), no), "parent")
+	_, child := tr.Start(ctx, "child")
+	if parent.IsRecording() || child.IsRecording() {
+		t.Error("spans of an unsampled trace should not record")
+	}
+	if child.SpanContext().TraceID() != TradeTraceID(no) {
+		t.Error("unsampled spans should keep the trade's trace ID")
+	}
+	parent.End()
+	child.End()
+
+	_, s := tr.Start(TradeContext(context.Background(), yes), "sampled")
+	if !s.IsRecording() || !s.SpanContext().IsSampled() {
+		t.Error("span of a sampled trace should record")
+	}
+	s.End()
+	if err := p.tp.ForceFlush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := exporter.GetSpans(); len(got) != 1 || got[0].Name != "sampled" {
+		t.Errorf("exported %d spans, want only the sampled span", len(got))
+	}
+
+	for i := 0; i < 100; i++ {
+		if !sampled(TradeTraceID(fmt.Sprintf("trade-%d", i)), 100) {
+			t.Fatal("sample_percent 100 should sample every trace")
+		}
+	}
+}
+
+func TestRemoteParentSampledLocally(t *testing.T) {
+	p := newTestProvider(t, Config{})
+	remote := trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: TradeTraceID("trade-1"),
+		SpanID:  trace.SpanID{1},
+		Remote:  true,
+	})
+	_, s := p.Tracer("test").Start(trace.ContextWithRemoteSpanContext(context.Background(), remote), "child")
+	if !s.IsRecording() {
+		t.Error("remote parent's flags should not decide local sampling")
+	}
+	if s.(sdktrace.ReadOnlySpan).Parent().SpanID() != remote.SpanID() {
+		t.Error("remote span should be the parent")
+	}
+}
+
+type grpcCollector struct {
+	coltracepb.UnimplementedTraceServiceServer
+	spans chan string
+}
+
+func (c *grpcCollector) Export(ctx context.Context, req *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			for _, s := range ss.Spans {
+				c.spans <- s.Name
+			}
+		}
+	}
+	return &coltracepb.ExportTraceServiceResponse{}, nil
+}
+
+func TestExportOverGRPC(t *testing.T) {
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &grpcCollector{spans: make(chan string, 1)}
+	srv := grpc.NewServer()
+	coltracepb.RegisterTraceServiceServer(srv, c)
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	p := newTestProvider(t, Config{Enabled: true, Protocol: ProtocolGRPC, Endpoint: "http://" + lis.Addr().String()})
+	_, s := p.Tracer("test").Start(TradeContext(context.Background(), "trade-1"), "swap.fund")
+	s.End()
+	p.Stop()
+
+	select {
+	case name := <-c.spans:
+		if name != "swap.fund" {
+			t.Errorf("exported span %q", name)
+		}
+	default:
+		t.Fatal("no span exported over gRPC")
+	}
+	if st := p.Status(); st.Exported != 1 || st.Protocol != ProtocolGRPC {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestExportFailureCountsDropped(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "rejected", http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	p := newTestProvider(t, Config{Enabled: true, Endpoint: srv.URL + "/v1/traces"})
+	_, s := p.Tracer("test").Start(context.Background(), "span")
+	s.End()
+	p.tp.ForceFlush(context.Background())
+
+	if st := p.Status(); st.Dropped != 1 || st.Exported != 0 || st.LastError == "" {
+		t.Errorf("Status() = %+v", st)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		modify  func(*Config)
+		wantErr bool
+	}{
+		{"default", func(c *Config) {}, false},
+		{"enabled", func(c *Config) { c.Enabled = true }, false},
+		{"sample zero", func(c *Config) { c.SamplePercent = 0 }, true},
+		{"sample over 100", func(c *Config) { c.SamplePercent = 101 }, true},
+		{"queue below batch", func(c *Config) { c.QueueSize = c.BatchSize - 1 }, true},
+		{"no timeout", func(c *Config) { c.Timeout = 0 }, true},
+		{"grpc", func(c *Config) {
+			c.Enabled = true
+			c.Protocol = ProtocolGRPC
+			c.Endpoint = "https://otel.example.com:4317"
+		}, false},
+		{"bad protocol", func(c *Config) { c.Protocol = "udp" }, true},
+		{"bad endpoint", func(c *Config) { c.Enabled = true; c.Endpoint = "127.0.0.1:4318" }, true},
+		{"bad endpoint disabled", func(c *Config) { c.Endpoint = "127.0.0.1:4318" }, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			tt.modify(&cfg)
+			if err := cfg.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if _, err := New(Config{SamplePercent: 200}, "test"); err == nil {
+		t.Error("New() should reject an invalid config")
+	}
+}
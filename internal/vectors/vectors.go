@@ -0,0 +1,505 @@
+// Package vectors - Deterministic protocol test vectors.
+//
+// Generate derives the keys, nonces and secrets of two parties, alice and
+// bob, from a seed and runs them through the protocol's primitives: MuSig2
+// key aggregation, nonce exchange and signing, HTLC scripts, swap IDs and
+// trade terms digests. The result is published as JSON so that other client
+// implementations can check they produce the same bytes.
+//
+// Every derived value is sha256(seed || label), with the label recorded next
+// to the value it produced. MuSig2 nonces follow BIP-327 NonceGen with the
+// recorded rand, the signer's public key and no other inputs.
+package vectors
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr/musig2"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+)
+
+// Version is the format version of a Set. Bump it when fields are added,
+// removed or computed differently.
+const Version = 1
+
+// DefaultSeed is the seed of the published vectors.
+const DefaultSeed = "6b6c696e676465782070726f746f636f6c207465737420766563746f72732031"
+
+// Fixed inputs of the spend and HTLC vectors.
+const (
+	fundingAmount = 100_000
+	fundingVout   = 1
+	feeRate       = 2
+)
+
+var (
+	musig2Chains = []string{"BTC", "LTC"}
+	htlcChains   = []string{"BTC", "LTC"}
+	evmChains    = []string{"ETH", "BSC"}
+)
+
+// Set is a complete set of test vectors.
+type Set struct {
+	Version int            `json:"version"`
+	Seed    string         `json:"seed"`
+	Network string         `json:"network"`
+	Parties []Party        `json:"parties"`
+	SwapID  string         `json:"swap_id"`
+	TradeID Derived        `json:"trade_id"`
+	OrderID Derived        `json:"order_id"`
+	Secret  Derived        `json:"secret"`
+	MuSig2  []MuSig2Vector `json:"musig2"`
+	HTLC    []HTLCVector   `json:"htlc"`
+	EVM     []EVMVector    `json:"evm_swap_ids"`
+	Terms   []TermsVector  `json:"terms"`
+}
+
+// Derived is a value derived from the seed.
+type Derived struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// Party is one side of the vectors' trades.
+type Party struct {
+	Name       string  `json:"name"`
+	PrivateKey Derived `json:"private_key"`
+	PublicKey  string  `json:"public_key"`
+}
+
+// MuSig2Vector covers a MuSig2 escrow on one chain: key aggregation, the
+// Taproot output with alice's refund path, and a cooperative spend of it.
+type MuSig2Vector struct {
+	Chain          string       `json:"chain"`
+	AggregatedKey  string       `json:"aggregated_key"`
+	RefundTimeout  uint32       `json:"refund_timeout_blocks"`
+	RefundScript   string       `json:"refund_script"`
+	OutputKey      string       `json:"output_key"`
+	TaprootAddress string       `json:"taproot_address"`
+	Nonces         []NonceEntry `json:"nonces"`
+	Spend          SpendVector  `json:"spend"`
+	PartialSigs    []PartialSig `json:"partial_signatures"`
+	Signature      string       `json:"signature"`
+}
+
+// NonceEntry is a party's MuSig2 public nonce and the rand it came from.
+type NonceEntry struct {
+	Party    string  `json:"party"`
+	Rand     Derived `json:"rand"`
+	PubNonce string  `json:"pub_nonce"`
+}
+
+// PartialSig is a party's MuSig2 partial signature.
+type PartialSig struct {
+	Party string `json:"party"`
+	Sig   string `json:"sig"`
+}
+
+// SpendVector is the key-path spend of an escrow to alice's P2TR address.
+type SpendVector struct {
+	FundingTxID   Derived `json:"funding_txid"`
+	FundingVout   uint32  `json:"funding_vout"`
+	FundingAmount uint64  `json:"funding_amount"`
+	DestAddress   string  `json:"dest_address"`
+	FeeRate       uint64  `json:"fee_rate"`
+	UnsignedTx    string  `json:"unsigned_tx"`
+	Sighash       string  `json:"sighash"`
+}
+
+// HTLCVector is an HTLC script paying bob with the secret and refunding
+// alice after the timeout.
+type HTLCVector struct {
+	Chain         string `json:"chain"`
+	SecretHash    string `json:"secret_hash"`
+	Receiver      string `json:"receiver"`
+	Sender        string `json:"sender"`
+	TimeoutBlocks uint32 `json:"timeout_blocks"`
+	Script        string `json:"script"`
+	ScriptHash    string `json:"script_hash"`
+	Address       string `json:"address"`
+}
+
+// EVMVector is the HTLC contract swap ID of a trade on an EVM chain.
+type EVMVector struct {
+	Chain      string `json:"chain"`
+	TradeID    string `json:"trade_id"`
+	SecretHash string `json:"secret_hash"`
+	SwapID     string `json:"swap_id"`
+}
+
+// TermsVector is the canonical serialization and digest of a trade's terms,
+// signed by alice.
+type TermsVector struct {
+	Name      string `json:"name"`
+	Canonical string `json:"canonical"`
+	Digest    string `json:"digest"`
+	Signature string `json:"signature"`
+}
+
+// generator holds the seed and the parties' keys while a Set is built.
+type generator struct {
+	seed    []byte
+	network chain.Network
+	alice   *btcec.PrivateKey
+	bob     *btcec.PrivateKey
+}
+
+// derive returns sha256(seed || label).
+func (g *generator) derive(label string) Derived {
+	h := sha256.Sum256(append(append([]byte{}, g.seed...), label...))
+	return Derived{Label: label, Value: hex.EncodeToString(h[:])}
+}
+
+// Generate builds the vectors of a seed on a network.
+func Generate(seedHex string, network chain.Network) (*Set, error) {
+	seed, err := hex.DecodeString(seedHex)
+	if err != nil || len(seed) == 0 {
+		return nil, fmt.Errorf("invalid seed: must be non-empty hex")
+	}
+	g := &generator{seed: seed, network: network}
+	set := &Set{Version: Version, Seed: seedHex, Network: string(network)}
+
+	for _, name := range []string{"alice", "bob"} {
+		d := g.derive(name + "/key")
+		key, _ := btcec.PrivKeyFromBytes(mustHex(d.Value))
+		set.Parties = append(set.Parties, Party{
+			Name:       name,
+			PrivateKey: d,
+			PublicKey:  hex.EncodeToString(key.PubKey().SerializeCompressed()),
+		})
+		if name == "alice" {
+			g.alice = key
+		} else {
+			g.bob = key
+		}
+	}
+	set.SwapID = swap.ComputeSwapID(g.alice.PubKey(), g.bob.PubKey())
+
+	set.Secret = g.derive("secret")
+	secretHash := sha256.Sum256(mustHex(set.Secret.Value))
+
+	for _, symbol := range musig2Chains {
+		v, err := g.musig2(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("%s musig2: %w", symbol, err)
+		}
+		set.MuSig2 = append(set.MuSig2, *v)
+	}
+	for _, symbol := range htlcChains {
+		timeout := swap.GetTimeoutBlocks(symbol, true)
+		data, err := swap.BuildHTLCScriptData(secretHash[:], g.bob.PubKey(), g.alice.PubKey(), timeout, symbol, network)
+		if err != nil {
+			return nil, fmt.Errorf("%s htlc: %w", symbol, err)
+		}
+		set.HTLC = append(set.HTLC, HTLCVector{
+			Chain:         symbol,
+			SecretHash:    hex.EncodeToString(secretHash[:]),
+			Receiver:      "bob",
+			Sender:        "alice",
+			TimeoutBlocks: timeout,
+			Script:        hex.EncodeToString(data.Script),
+			ScriptHash:    hex.EncodeToString(data.ScriptHash),
+			Address:       data.Address,
+		})
+	}
+
+	set.TradeID = g.derive("trade_id")
+	set.OrderID = g.derive("order_id")
+	tradeID := set.TradeID.Value
+	for _, symbol := range evmChains {
+		id := swap.ComputeEVMSwapID(tradeID, secretHash, symbol)
+		set.EVM = append(set.EVM, EVMVector{
+			Chain:      symbol,
+			TradeID:    tradeID,
+			SecretHash: hex.EncodeToString(secretHash[:]),
+			SwapID:     hex.EncodeToString(id[:]),
+		})
+	}
+
+	terms, err := g.terms(tradeID, set.OrderID.Value)
+	if err != nil {
+		return nil, err
+	}
+	set.Terms = terms
+	return set, nil
+}
+
+// musig2 runs a two-party MuSig2 escrow and spend on one chain.
+func (g *generator) musig2(symbol string) (*MuSig2Vector, error) {
+	params, ok := chain.Get(symbol, g.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain")
+	}
+	alice, err := swap.NewMuSig2Session(symbol, g.network, g.alice)
+	if err != nil {
+		return nil, err
+	}
+	bob, err := swap.NewMuSig2Session(symbol, g.network, g.bob)
+	if err != nil {
+		return nil, err
+	}
+	if err := alice.SetRemotePubKey(g.bob.PubKey()); err != nil {
+		return nil, err
+	}
+	if err := bob.SetRemotePubKey(g.alice.PubKey()); err != nil {
+		return nil, err
+	}
+	aggKey, err := alice.AggregatedPubKey()
+	if err != nil {
+		return nil, err
+	}
+
+	// alice funds the escrow, so the refund path is hers
+	timeout := swap.GetTimeoutBlocks(symbol, true)
+	addr, err := alice.TaprootAddressWithRefund(g.alice.PubKey(), timeout)
+	if err != nil {
+		return nil, err
+	}
+	if bobAddr, err := bob.TaprootAddressWithRefund(g.alice.PubKey(), timeout); err != nil || bobAddr != addr {
+		return nil, fmt.Errorf("parties derived different escrow addresses")
+	}
+	tree, err := swap.BuildTaprootScriptTree(aggKey, g.alice.PubKey(), timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	v := &MuSig2Vector{
+		Chain:          symbol,
+		AggregatedKey:  hex.EncodeToString(aggKey.SerializeCompressed()),
+		RefundTimeout:  timeout,
+		RefundScript:   hex.EncodeToString(tree.RefundScript),
+		OutputKey:      hex.EncodeToString(schnorr.SerializePubKey(tree.TweakedKey)),
+		TaprootAddress: addr,
+	}
+
+	// Nonce exchange
+	for _, p := range []struct {
+		name    string
+		session *swap.MuSig2Session
+	}{{"alice", alice}, {"bob", bob}} {
+		rand := g.derive(p.name + "/nonce/" + symbol)
+		nonces, err := p.session.GenerateNoncesFrom(bytes.NewReader(mustHex(rand.Value)))
+		if err != nil {
+			return nil, err
+		}
+		v.Nonces = append(v.Nonces, NonceEntry{
+			Party:    p.name,
+			Rand:     rand,
+			PubNonce: hex.EncodeToString(nonces.PubNonce[:]),
+		})
+	}
+	aliceNonce, _ := alice.LocalPubNonce()
+	bobNonce, _ := bob.LocalPubNonce()
+	alice.SetRemoteNonce(bobNonce)
+	bob.SetRemoteNonce(aliceNonce)
+	if err := alice.InitSigningSession(); err != nil {
+		return nil, err
+	}
+	if err := bob.InitSigningSession(); err != nil {
+		return nil, err
+	}
+
+	// The spend to alice's own address
+	addrs, err := wallet.AllAddressTypes(g.alice.PubKey(), params)
+	if err != nil {
+		return nil, err
+	}
+	txid := g.derive("funding_txid/" + symbol)
+	v.Spend = SpendVector{
+		FundingTxID:   txid,
+		FundingVout:   fundingVout,
+		FundingAmount: fundingAmount,
+		DestAddress:   addrs[chain.AddressP2TR],
+		FeeRate:       feeRate,
+	}
+	tx, sighash, err := swap.BuildSpendingTx(&swap.SpendingTxParams{
+		Symbol:         symbol,
+		Network:        g.network,
+		FundingTxID:    txid.Value,
+		FundingVout:    fundingVout,
+		FundingAmount:  fundingAmount,
+		TaprootAddress: addr,
+		DestAddress:    v.Spend.DestAddress,
+		FeeRate:        feeRate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if v.Spend.UnsignedTx, err = swap.SerializeTx(tx); err != nil {
+		return nil, err
+	}
+	v.Spend.Sighash = hex.EncodeToString(sighash[:])
+
+	aliceSig, err := alice.Sign(sighash)
+	if err != nil {
+		return nil, err
+	}
+	bobSig, err := bob.Sign(sighash)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range []struct {
+		name string
+		sig  *musig2.PartialSignature
+	}{{"alice", aliceSig}, {"bob", bobSig}} {
+		var buf bytes.Buffer
+		if err := p.sig.Encode(&buf); err != nil {
+			return nil, err
+		}
+		v.PartialSigs = append(v.PartialSigs, PartialSig{Party: p.name, Sig: hex.EncodeToString(buf.Bytes())})
+	}
+	final, err := alice.CombineSignatures(aliceSig, bobSig)
+	if err != nil {
+		return nil, err
+	}
+	if !final.Verify(sighash[:], tree.TweakedKey) {
+		return nil, fmt.Errorf("combined signature doesn't verify against the output key")
+	}
+	v.Signature = hex.EncodeToString(final.Serialize())
+	return v, nil
+}
+
+// terms returns the digests of a base trade and of each optional field the
+// canonical serialization appends.
+func (g *generator) terms(tradeID, orderID string) ([]TermsVector, error) {
+	base := swap.Offer{
+		OfferChain:    "BTC",
+		OfferAmount:   fundingAmount,
+		RequestChain:  "LTC",
+		RequestAmount: 25 * fundingAmount,
+	}
+	evm := swap.Offer{
+		OfferChain:    "ETH",
+		OfferAmount:   1_000_000_000_000_000,
+		RequestChain:  "BTC",
+		RequestAmount: fundingAmount,
+	}
+
+	variants := []struct {
+		name   string
+		offer  swap.Offer
+		method swap.Method
+		modify func(*swap.TradeTerms)
+	}{
+		{"base", base, swap.MethodMuSig2, nil},
+		{"fee_terms", base, swap.MethodMuSig2, func(t *swap.TradeTerms) {
+			t.FeeTerms = swap.FeeTerms{
+				DAOFeePayer:       swap.FeePayerMaker,
+				ClaimFeePayer:     swap.FeePayerTaker,
+				ClaimFeeAllowance: 500,
+			}
+		}},
+		{"htlc", base, swap.MethodHTLC, nil},
+		{"meta_claim_fee", evm, swap.MethodContract, func(t *swap.TradeTerms) {
+			t.FeeTerms.MetaClaimFeeBps = 30
+		}},
+		{"tokens", evm, swap.MethodContract, func(t *swap.TradeTerms) {
+			t.OfferToken = "0xdAC17F958D2ee523a2206206994597C13D831ec7"
+		}},
+		{"fee_adjustment", base, swap.MethodMuSig2, func(t *swap.TradeTerms) {
+			t.FeeAdjustment = -1200
+			t.RequestAmount -= 1200
+		}},
+	}
+
+	var out []TermsVector
+	for _, v := range variants {
+		terms := swap.NewTradeTerms(g.network, tradeID, orderID, v.offer, v.method)
+		if v.modify != nil {
+			v.modify(terms)
+		}
+		digest := terms.Digest()
+		sig, err := swap.SignTermsDigest(g.alice, digest)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, TermsVector{
+			Name:      v.name,
+			Canonical: hex.EncodeToString(terms.CanonicalBytes()),
+			Digest:    hex.EncodeToString(digest),
+			Signature: hex.EncodeToString(sig),
+		})
+	}
+	return out, nil
+}
+
+// Compare returns the JSON paths at which got differs from want, empty
+// when the sets are identical.
+func Compare(want, got *Set) ([]string, error) {
+	w, err := toTree(want)
+	if err != nil {
+		return nil, err
+	}
+	g, err := toTree(got)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	compareTree("", w, g, &diffs)
+	return diffs, nil
+}
+
+func toTree(s *Set) (any, error) {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var tree any
+	err = json.Unmarshal(data, &tree)
+	return tree, err
+}
+
+func compareTree(path string, want, got any, diffs *[]string) {
+	switch w := want.(type) {
+	case map[string]any:
+		g, ok := got.(map[string]any)
+		if !ok {
+			*diffs = append(*diffs, path)
+			return
+		}
+		keys := make([]string, 0, len(w)+len(g))
+		for k := range w {
+			keys = append(keys, k)
+		}
+		for k := range g {
+			if _, ok := w[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			compareTree(path+"."+k, w[k], g[k], diffs)
+		}
+	case []any:
+		g, ok := got.([]any)
+		if !ok || len(g) != len(w) {
+			*diffs = append(*diffs, path)
+			return
+		}
+		for i := range w {
+			compareTree(fmt.Sprintf("%s[%d]", path, i), w[i], g[i], diffs)
+		}
+	default:
+		if want != got {
+			*diffs = append(*diffs, path)
+		}
+	}
+}
+
+func mustHex(s string) []byte {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		panic(err)
+	}
+	return b
+}
@@ -0,0 +1,88 @@
+package vectors
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestGenerateDeterministic(t *testing.T) {
+	a, err := Generate(DefaultSeed, chain.Testnet)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	b, _ := Generate(DefaultSeed, chain.Testnet)
+	if diffs, err := Compare(a, b); err != nil || len(diffs) != 0 {
+		t.Fatalf("two runs differ at %v (%v)", diffs, err)
+	}
+	if len(a.MuSig2) != len(musig2Chains) || len(a.HTLC) != len(htlcChains) || len(a.EVM) != len(evmChains) || len(a.Terms) != 6 {
+		t.Errorf("set has %d musig2, %d htlc, %d evm and %d terms vectors", len(a.MuSig2), len(a.HTLC), len(a.EVM), len(a.Terms))
+	}
+
+	// Another seed changes every derived value
+	other, err := Generate("00", chain.Testnet)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if other.SwapID == a.SwapID || other.MuSig2[0].Signature == a.MuSig2[0].Signature || other.Terms[0].Digest == a.Terms[0].Digest {
+		t.Error("another seed gave the same vectors")
+	}
+
+	// Addresses follow the network; key material doesn't
+	mainnet, err := Generate(DefaultSeed, chain.Mainnet)
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if mainnet.MuSig2[0].AggregatedKey != a.MuSig2[0].AggregatedKey || mainnet.MuSig2[0].TaprootAddress == a.MuSig2[0].TaprootAddress {
+		t.Errorf("mainnet musig2 = %+v", mainnet.MuSig2[0])
+	}
+}
+
+func TestGenerateInvalidSeed(t *testing.T) {
+	for _, seed := range []string{"", "zz"} {
+		if _, err := Generate(seed, chain.Testnet); err == nil {
+			t.Errorf("Generate(%q) succeeded", seed)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	want, _ := Generate(DefaultSeed, chain.Testnet)
+	got, _ := Generate(DefaultSeed, chain.Testnet)
+	got.MuSig2[1].Spend.Sighash = "00"
+	got.Terms = got.Terms[:1]
+
+	diffs, err := Compare(want, got)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(diffs) != 2 || diffs[0] != ".musig2[1].spend.sighash" || diffs[1] != ".terms" {
+		t.Errorf("Compare() = %v", diffs)
+	}
+}
+
+// TestPublishedVectors fails when a change alters the protocol's bytes.
+// Regenerate docs/test-vectors.json only for an intended protocol change,
+// and bump Version with it.
+func TestPublishedVectors(t *testing.T) {
+	data, err := os.ReadFile("../../docs/test-vectors.json")
+	if err != nil {
+		t.Fatalf("ReadFile() error = %v", err)
+	}
+	var published Set
+	if err := json.Unmarshal(data, &published); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if published.Version != Version || published.Seed != DefaultSeed {
+		t.Fatalf("published version %d seed %s", published.Version, published.Seed)
+	}
+	got, err := Generate(published.Seed, chain.Network(published.Network))
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if diffs, _ := Compare(&published, got); len(diffs) != 0 {
+		t.Errorf("generated vectors differ from the published ones at %v", diffs)
+	}
+}
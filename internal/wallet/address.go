@@ -140,23 +140,25 @@ func ParseAddress(address string, params *chain.Params) (btcutil.Address, chain.
 		return nil, "", fmt.Errorf("failed to decode address: %w", err)
 	}
 
-	var addrType chain.AddressType
+	return decoded, addressTypeOf(decoded), nil
+}
+
+// addressTypeOf maps a decoded btcutil address to our address type.
+func addressTypeOf(decoded btcutil.Address) chain.AddressType {
 	switch decoded.(type) {
 	case *btcutil.AddressPubKeyHash:
-		addrType = chain.AddressP2PKH
+		return chain.AddressP2PKH
 	case *btcutil.AddressScriptHash:
-		addrType = chain.AddressP2SH
+		return chain.AddressP2SH
 	case *btcutil.AddressWitnessPubKeyHash:
-		addrType = chain.AddressP2WPKH
+		return chain.AddressP2WPKH
 	case *btcutil.AddressWitnessScriptHash:
-		addrType = chain.AddressP2WSH
+		return chain.AddressP2WSH
 	case *btcutil.AddressTaproot:
-		addrType = chain.AddressP2TR
+		return chain.AddressP2TR
 	default:
-		addrType = "unknown"
+		return "unknown"
 	}
-
-	return decoded, addrType, nil
 }
 
 // PrivateKeyToWIF converts a private key to Wallet Import Format.
@@ -209,6 +211,9 @@ func toChainCfgParams(params *chain.Params) *chaincfg.Params {
 		// Bech32
 		Bech32HRPSegwit: params.Bech32HRP,
 
+		// WIF private key prefix
+		PrivateKeyID: params.WIF,
+
 		// BIP32 HD key magic bytes (chain-specific)
 		HDPrivateKeyID: hdPrivateKeyID,
 		HDPublicKeyID:  hdPublicKeyID,
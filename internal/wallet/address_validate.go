@@ -0,0 +1,121 @@
+// Package wallet - Chain-agnostic address validation.
+package wallet
+
+import (
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// AddressValidation is the detailed result of validating an address.
+type AddressValidation struct {
+	Valid      bool
+	Symbol     string
+	ChainType  chain.ChainType
+	Type       chain.AddressType
+	Normalized string // Canonical form (lowercase bech32, EIP-55 checksummed EVM)
+	Error      string // Reason the address is invalid
+
+	// Bitcoin-family SegWit details
+	HRP            string
+	WitnessVersion *uint8
+
+	// EVM details
+	ChecksumValid bool // Mixed-case EIP-55 checksum matched (or address is single-case)
+	HasChecksum   bool // Address used mixed case
+}
+
+// ValidateAddressDetailed validates an address for a chain and reports its type and canonical form.
+// Bitcoin-family addresses are checked for checksum, network prefix/HRP and witness version;
+// EVM addresses for hex encoding and EIP-55 checksum.
+func ValidateAddressDetailed(address string, params *chain.Params) *AddressValidation {
+	result := &AddressValidation{
+		Symbol:    params.Symbol,
+		ChainType: params.Type,
+	}
+	address = strings.TrimSpace(address)
+	if address == "" {
+		result.Error = "address is empty"
+		return result
+	}
+
+	switch params.Type {
+	case chain.ChainTypeBitcoin:
+		validateBitcoinAddress(address, params, result)
+	case chain.ChainTypeEVM:
+		validateEVMAddress(address, result)
+	default:
+		result.Error = "address validation not supported for chain type " + string(params.Type)
+	}
+
+	return result
+}
+
+// validateBitcoinAddress fills in the result for a Bitcoin-family address.
+func validateBitcoinAddress(address string, params *chain.Params, result *AddressValidation) {
+	decoded, addrType, err := ParseAddress(address, params)
+	if err != nil && params.Bech32HRP != "" &&
+		strings.HasPrefix(strings.ToLower(address), params.Bech32HRP+"1") {
+		// btcutil only decodes bech32 HRPs registered with chaincfg (BTC), so
+		// fall back to the manual decoder used for LTC transactions.
+		decoded, _, err = decodeAnyAddress(strings.ToLower(address), toChainCfgParams(params), params)
+		addrType = addressTypeOf(decoded)
+	}
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if !decoded.IsForNet(toChainCfgParams(params)) {
+		result.Error = "address is for a different network"
+		return
+	}
+
+	if addrType == chain.AddressP2WPKH || addrType == chain.AddressP2WSH || addrType == chain.AddressP2TR {
+		if !params.SupportsSegWit {
+			result.Error = "chain does not support SegWit addresses"
+			return
+		}
+		if addrType == chain.AddressP2TR && !params.SupportsTaproot {
+			result.Error = "chain does not support Taproot addresses"
+			return
+		}
+	}
+
+	result.Valid = true
+	result.Type = addrType
+	result.Normalized = decoded.EncodeAddress()
+
+	if segwit, ok := decoded.(interface {
+		WitnessVersion() byte
+		Hrp() string
+	}); ok {
+		version := segwit.WitnessVersion()
+		result.WitnessVersion = &version
+		result.HRP = segwit.Hrp()
+	}
+}
+
+// validateEVMAddress fills in the result for an EVM address.
+func validateEVMAddress(address string, result *AddressValidation) {
+	if !strings.HasPrefix(address, "0x") && !strings.HasPrefix(address, "0X") {
+		result.Error = "EVM address must start with 0x"
+		return
+	}
+	address = "0x" + address[2:]
+	if !ValidateEVMAddress(address) {
+		result.Error = "EVM address must be 20 bytes of hex"
+		return
+	}
+
+	body := address[2:]
+	result.HasChecksum = body != strings.ToLower(body) && body != strings.ToUpper(body)
+	result.ChecksumValid = IsChecksumValid(address)
+	if !result.ChecksumValid {
+		result.Error = "invalid EIP-55 checksum"
+		return
+	}
+
+	result.Valid = true
+	result.Type = chain.AddressEVM
+	result.Normalized = ChecksumAddress(address)
+}
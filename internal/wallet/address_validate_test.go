@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestValidateAddressDetailed(t *testing.T) {
+	btcMain, _ := chain.Get("BTC", chain.Mainnet)
+	btcTest, _ := chain.Get("BTC", chain.Testnet)
+	eth, _ := chain.Get("ETH", chain.Mainnet)
+
+	tests := []struct {
+		name           string
+		address        string
+		params         *chain.Params
+		wantValid      bool
+		wantType       chain.AddressType
+		wantNormalized string
+		wantWitness    int // -1 if not SegWit
+	}{
+		{"btc p2pkh", "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", btcMain, true, chain.AddressP2PKH, "1A1zP1eP5QGefi2DMPTfTL5SLmv7DivfNa", -1},
+		{"btc p2sh", "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", btcMain, true, chain.AddressP2SH, "3J98t1WpEZ73CNmQviecrnyiWrnqRhWNLy", -1},
+		{"btc p2wpkh", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", btcMain, true, chain.AddressP2WPKH, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0},
+		{"btc p2wpkh uppercase normalized", "BC1QAR0SRRR7XFKVY5L643LYDNW9RE59GTZZWF5MDQ", btcMain, true, chain.AddressP2WPKH, "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdq", 0},
+		{"btc p2tr", "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", btcMain, true, chain.AddressP2TR, "bc1p5d7rjq7g6rdk2yhzks9smlaqtedr4dekq08ge8ztwac72sfr9rusxg3297", 1},
+		{"btc testnet p2wpkh", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", btcTest, true, chain.AddressP2WPKH, "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", 0},
+		{"btc testnet address on mainnet", "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", btcMain, false, "", "", -1},
+		{"btc bad checksum", "bc1qar0srrr7xfkvy5l643lydnw9re59gtzzwf5mdr", btcMain, false, "", "", -1},
+		{"eth checksummed", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", eth, true, chain.AddressEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", -1},
+		{"eth lowercase normalized", "0x5aaeb6053f3e94c9b9a09f33669435e7ef1beaed", eth, true, chain.AddressEVM, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", -1},
+		{"eth bad checksum", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD", eth, false, "", "", -1},
+		{"eth too short", "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA", eth, false, "", "", -1},
+		{"eth missing prefix", "5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", eth, false, "", "", -1},
+		{"empty", "", btcMain, false, "", "", -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ValidateAddressDetailed(tt.address, tt.params)
+			if got.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v, want %v (error: %s)", got.Valid, tt.wantValid, got.Error)
+			}
+			if !tt.wantValid {
+				if got.Error == "" {
+					t.Error("invalid address should report an error")
+				}
+				return
+			}
+			if got.Type != tt.wantType {
+				t.Errorf("Type = %s, want %s", got.Type, tt.wantType)
+			}
+			if got.Normalized != tt.wantNormalized {
+				t.Errorf("Normalized = %s, want %s", got.Normalized, tt.wantNormalized)
+			}
+			if tt.wantWitness < 0 {
+				if got.WitnessVersion != nil {
+					t.Errorf("WitnessVersion = %d, want none", *got.WitnessVersion)
+				}
+			} else if got.WitnessVersion == nil || int(*got.WitnessVersion) != tt.wantWitness {
+				t.Errorf("WitnessVersion = %v, want %d", got.WitnessVersion, tt.wantWitness)
+			}
+		})
+	}
+}
+
+func TestValidateAddressDetailedDerived(t *testing.T) {
+	w, _ := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+
+	for _, symbol := range []string{"LTC", "DOGE"} {
+		addr, err := w.DeriveAddress(symbol, 0, 0)
+		if err != nil {
+			t.Fatalf("DeriveAddress(%s) error = %v", symbol, err)
+		}
+		params, _ := chain.Get(symbol, chain.Mainnet)
+
+		got := ValidateAddressDetailed(addr, params)
+		if !got.Valid {
+			t.Errorf("%s derived address %s should be valid: %s", symbol, addr, got.Error)
+		}
+		if got.Normalized != addr {
+			t.Errorf("%s Normalized = %s, want %s", symbol, got.Normalized, addr)
+		}
+	}
+
+	// LTC address must not validate as BTC
+	ltcAddr, _ := w.DeriveAddress("LTC", 0, 0)
+	btc, _ := chain.Get("BTC", chain.Mainnet)
+	if ValidateAddressDetailed(ltcAddr, btc).Valid {
+		t.Error("LTC address should not be valid for BTC")
+	}
+}
+
+func TestValidateAddressDetailedUnsupported(t *testing.T) {
+	xmr, ok := chain.Get("XMR", chain.Mainnet)
+	if !ok {
+		t.Skip("XMR not registered")
+	}
+	got := ValidateAddressDetailed("4anything", xmr)
+	if got.Valid || got.Error == "" {
+		t.Errorf("expected unsupported error, got %+v", got)
+	}
+}
@@ -0,0 +1,103 @@
+// Package wallet - Consolidation of fragmented UTXOs.
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// ConsolidationFilter selects the UTXOs a consolidation may merge.
+type ConsolidationFilter struct {
+	// MaxInputs bounds the UTXOs merged in one transaction (0 = no bound).
+	MaxInputs int
+
+	// MaxValue only merges UTXOs worth at most this much (0 = any).
+	MaxValue uint64
+
+	// ExcludeAddresses and ExcludeOutpoints (txid:vout) are never spent.
+	ExcludeAddresses []string
+	ExcludeOutpoints []string
+}
+
+// SelectConsolidationUTXOs returns the UTXOs the filter allows, smallest
+// first so the most fragmented value is merged when MaxInputs cuts the list.
+func SelectConsolidationUTXOs(utxos []*AddressUTXO, f ConsolidationFilter) []*AddressUTXO {
+	excludedAddrs := make(map[string]bool, len(f.ExcludeAddresses))
+	for _, a := range f.ExcludeAddresses {
+		excludedAddrs[strings.ToLower(a)] = true
+	}
+	excludedOutpoints := make(map[string]bool, len(f.ExcludeOutpoints))
+	for _, o := range f.ExcludeOutpoints {
+		excludedOutpoints[strings.ToLower(o)] = true
+	}
+
+	selected := make([]*AddressUTXO, 0, len(utxos))
+	for _, u := range utxos {
+		if excludedAddrs[strings.ToLower(u.Address)] ||
+			excludedOutpoints[strings.ToLower(fmt.Sprintf("%s:%d", u.TxID, u.Vout))] {
+			continue
+		}
+		if f.MaxValue > 0 && u.Amount > f.MaxValue {
+			continue
+		}
+		selected = append(selected, u)
+	}
+	sort.SliceStable(selected, func(i, j int) bool { return selected[i].Amount < selected[j].Amount })
+	if f.MaxInputs > 0 && len(selected) > f.MaxInputs {
+		selected = selected[:f.MaxInputs]
+	}
+	return selected
+}
+
+// Consolidate merges utxos into a single output at a fresh change address
+// and broadcasts the transaction. The coins stay in the wallet, so the
+// spending policy doesn't apply. Returns the transaction and the address.
+func (s *Service) Consolidate(ctx context.Context, symbol string, utxos []*AddressUTXO, feeRate uint64, store *storage.Storage) (*MultiAddressTxResult, string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, "", fmt.Errorf("wallet not loaded")
+	}
+	if s.backends == nil {
+		return nil, "", fmt.Errorf("no backends configured")
+	}
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return nil, "", fmt.Errorf("no backend for chain: %s", symbol)
+	}
+
+	syncService := NewUTXOSyncService(&UTXOSyncConfig{
+		Wallet:   s.wallet,
+		Storage:  store,
+		Backends: s.backends,
+		Network:  s.network,
+		GapLimit: 20,
+	})
+	toAddr, _, err := syncService.GetNextChangeAddress(symbol)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to derive consolidation address: %w", err)
+	}
+
+	result, err := s.buildSendMaxTx(ctx, &SendMaxParams{
+		UTXOs:     utxos,
+		ToAddress: toAddr,
+		FeeRate:   feeRate,
+		Symbol:    symbol,
+		Network:   s.network,
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to build consolidation: %w", err)
+	}
+
+	txid, err := b.BroadcastTransaction(ctx, result.TxHex)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to broadcast: %w", err)
+	}
+	result.TxID = txid
+	return result, toAddr, nil
+}
@@ -0,0 +1,32 @@
+package wallet
+
+import "testing"
+
+func TestSelectConsolidationUTXOs(t *testing.T) {
+	utxos := []*AddressUTXO{
+		{TxID: "aa", Vout: 0, Amount: 5000, Address: "tb1qone"},
+		{TxID: "bb", Vout: 1, Amount: 1000, Address: "tb1qtwo"},
+		{TxID: "cc", Vout: 0, Amount: 900000, Address: "tb1qone"},
+		{TxID: "dd", Vout: 2, Amount: 3000, Address: "tb1qkept"},
+		{TxID: "ee", Vout: 0, Amount: 2000, Address: "tb1qone"},
+	}
+
+	got := SelectConsolidationUTXOs(utxos, ConsolidationFilter{})
+	if len(got) != 5 || got[0].TxID != "bb" || got[4].TxID != "cc" {
+		t.Errorf("no filter: got %d UTXOs starting %s, want all smallest first", len(got), got[0].TxID)
+	}
+
+	got = SelectConsolidationUTXOs(utxos, ConsolidationFilter{
+		MaxValue:         100000,
+		ExcludeAddresses: []string{"TB1QKEPT"},
+		ExcludeOutpoints: []string{"ee:0"},
+	})
+	if len(got) != 2 || got[0].TxID != "bb" || got[1].TxID != "aa" {
+		t.Errorf("filtered: got %+v, want bb and aa", got)
+	}
+
+	got = SelectConsolidationUTXOs(utxos, ConsolidationFilter{MaxInputs: 2})
+	if len(got) != 2 || got[0].TxID != "bb" || got[1].TxID != "ee" {
+		t.Errorf("MaxInputs 2: got %+v, want the two smallest", got)
+	}
+}
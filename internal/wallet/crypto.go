@@ -62,7 +62,7 @@ func EncryptMnemonic(mnemonic, password string) (*EncryptedSeed, error) {
 		argon2Parallelism,
 		argon2KeyLen,
 	)
-	defer SecureClear(key)
+	defer Protect(key)()
 
 	// Create AES-256-GCM cipher
 	block, err := aes.NewCipher(key)
@@ -120,7 +120,7 @@ func DecryptMnemonic(encrypted *EncryptedSeed, password string) (string, error)
 		parallelism,
 		argon2KeyLen,
 	)
-	defer SecureClear(key)
+	defer Protect(key)()
 
 	// Create AES-256-GCM cipher
 	block, err := aes.NewCipher(key)
@@ -138,7 +138,7 @@ func DecryptMnemonic(encrypted *EncryptedSeed, password string) (string, error)
 	if err != nil {
 		return "", fmt.Errorf("failed to decrypt (wrong password?): %w", err)
 	}
-	defer SecureClear(plaintext)
+	defer Protect(plaintext)()
 
 	return string(plaintext), nil
 }
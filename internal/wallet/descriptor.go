@@ -0,0 +1,500 @@
+// Package wallet - Output descriptor (BIP-380) import.
+//
+// Descriptors let users bring wallets from Bitcoin Core, Sparrow and other
+// descriptor wallets: the addresses are derived from the descriptor's key and
+// path instead of the symbol's BIP-44 defaults. Supported forms:
+//
+//	pkh(KEY)  wpkh(KEY)  sh(wpkh(KEY))  tr(KEY)
+//
+// KEY is an extended key with an optional origin and a derivation path that
+// may contain one multipath step (BIP-389) and a trailing wildcard:
+//
+//	[d34db33f/84h/0h/0h]xpub.../<0;1>/*
+//
+// Only key-path taproot (no script tree) is supported.
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+// DescriptorType is the script type of an output descriptor.
+type DescriptorType string
+
+// Supported descriptor types.
+const (
+	DescriptorPKH    DescriptorType = "pkh"
+	DescriptorWPKH   DescriptorType = "wpkh"
+	DescriptorSHWPKH DescriptorType = "sh(wpkh)"
+	DescriptorTR     DescriptorType = "tr"
+)
+
+// KeyOrigin is the master key fingerprint and path a descriptor key was
+// derived at.
+type KeyOrigin struct {
+	Fingerprint [4]byte
+	Path        []uint32
+}
+
+// Descriptor is a parsed single-key output descriptor.
+type Descriptor struct {
+	Type   DescriptorType
+	Origin *KeyOrigin
+
+	key   *hdkeychain.ExtendedKey
+	steps [][]uint32 // Derivation after key; a step with several values is multipath
+	multi int        // Index of the multipath step, -1 if none
+
+	wildcard         bool
+	hardenedWildcard bool
+}
+
+// ParseDescriptor parses an output descriptor. The "#checksum" suffix is
+// optional but verified when present.
+func ParseDescriptor(s string) (*Descriptor, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '#'); i >= 0 {
+		want, err := DescriptorChecksum(s[:i])
+		if err != nil {
+			return nil, err
+		}
+		if s[i+1:] != want {
+			return nil, fmt.Errorf("invalid descriptor checksum: got %s, want %s", s[i+1:], want)
+		}
+		s = s[:i]
+	}
+
+	d := &Descriptor{multi: -1}
+	var inner string
+	switch {
+	case strings.HasPrefix(s, "sh(wpkh(") && strings.HasSuffix(s, "))"):
+		d.Type, inner = DescriptorSHWPKH, s[len("sh(wpkh("):len(s)-2]
+	case strings.HasPrefix(s, "wpkh(") && strings.HasSuffix(s, ")"):
+		d.Type, inner = DescriptorWPKH, s[len("wpkh("):len(s)-1]
+	case strings.HasPrefix(s, "pkh(") && strings.HasSuffix(s, ")"):
+		d.Type, inner = DescriptorPKH, s[len("pkh("):len(s)-1]
+	case strings.HasPrefix(s, "tr(") && strings.HasSuffix(s, ")"):
+		d.Type, inner = DescriptorTR, s[len("tr("):len(s)-1]
+		if strings.Contains(inner, ",") {
+			return nil, fmt.Errorf("taproot script trees are not supported")
+		}
+	default:
+		return nil, fmt.Errorf("unsupported descriptor: %q", s)
+	}
+
+	if err := d.parseKey(inner); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// parseKey parses "[origin]xkey/path" into d.
+func (d *Descriptor) parseKey(s string) error {
+	if strings.HasPrefix(s, "[") {
+		end := strings.IndexByte(s, ']')
+		if end < 0 {
+			return fmt.Errorf("unterminated key origin")
+		}
+		origin, err := parseKeyOrigin(s[1:end])
+		if err != nil {
+			return err
+		}
+		d.Origin = origin
+		s = s[end+1:]
+	}
+
+	parts := strings.Split(s, "/")
+	key, err := hdkeychain.NewKeyFromString(parts[0])
+	if err != nil {
+		return fmt.Errorf("invalid extended key: %w", err)
+	}
+	if key.IsPrivate() {
+		// String() must be able to neuter the key for the public form
+		if _, err := key.Neuter(); err != nil {
+			return fmt.Errorf("unsupported extended private key version: %w", err)
+		}
+	}
+	d.key = key
+
+	for i, part := range parts[1:] {
+		last := i == len(parts)-2
+		switch {
+		case part == "*" || part == "*'" || part == "*h":
+			if !last {
+				return fmt.Errorf("wildcard must be the last path step")
+			}
+			d.wildcard = true
+			d.hardenedWildcard = part != "*"
+		case strings.HasPrefix(part, "<") && strings.HasSuffix(part, ">"):
+			if d.multi >= 0 {
+				return fmt.Errorf("only one multipath step is allowed")
+			}
+			var values []uint32
+			for _, v := range strings.Split(part[1:len(part)-1], ";") {
+				n, err := parsePathStep(v)
+				if err != nil {
+					return err
+				}
+				values = append(values, n)
+			}
+			// TODO: support more than two branches (BIP-389 allows any number)
+			if len(values) != 2 {
+				return fmt.Errorf("multipath step must have two values (receive;change): %s", part)
+			}
+			d.multi = len(d.steps)
+			d.steps = append(d.steps, values)
+		default:
+			n, err := parsePathStep(part)
+			if err != nil {
+				return err
+			}
+			d.steps = append(d.steps, []uint32{n})
+		}
+	}
+
+	if !key.IsPrivate() {
+		if d.hardenedWildcard {
+			return fmt.Errorf("hardened derivation requires a private key")
+		}
+		for _, step := range d.steps {
+			for _, v := range step {
+				if v >= hdkeychain.HardenedKeyStart {
+					return fmt.Errorf("hardened derivation requires a private key")
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func parseKeyOrigin(s string) (*KeyOrigin, error) {
+	parts := strings.Split(s, "/")
+	fp, err := hex.DecodeString(parts[0])
+	if err != nil || len(fp) != 4 {
+		return nil, fmt.Errorf("invalid key origin fingerprint: %q", parts[0])
+	}
+	origin := &KeyOrigin{}
+	copy(origin.Fingerprint[:], fp)
+	for _, part := range parts[1:] {
+		n, err := parsePathStep(part)
+		if err != nil {
+			return nil, err
+		}
+		origin.Path = append(origin.Path, n)
+	}
+	return origin, nil
+}
+
+// parsePathStep parses "84", "84'" or "84h".
+func parsePathStep(s string) (uint32, error) {
+	hardened := strings.HasSuffix(s, "'") || strings.HasSuffix(s, "h")
+	if hardened {
+		s = s[:len(s)-1]
+	}
+	n, err := strconv.ParseUint(s, 10, 32)
+	if err != nil || n >= hdkeychain.HardenedKeyStart {
+		return 0, fmt.Errorf("invalid path step: %q", s)
+	}
+	if hardened {
+		n += hdkeychain.HardenedKeyStart
+	}
+	return uint32(n), nil
+}
+
+// IsRange reports whether the descriptor derives one address per index.
+func (d *Descriptor) IsRange() bool {
+	return d.wildcard
+}
+
+// Branches returns the number of branches: 2 with a multipath step (0 for
+// receiving, 1 for change), 1 without.
+func (d *Descriptor) Branches() int {
+	if d.multi < 0 {
+		return 1
+	}
+	return len(d.steps[d.multi])
+}
+
+// HasPrivateKey reports whether the descriptor contains an extended private key.
+func (d *Descriptor) HasPrivateKey() bool {
+	return d.key.IsPrivate()
+}
+
+// String returns the public descriptor with its checksum.
+func (d *Descriptor) String() string {
+	key := d.key
+	if key.IsPrivate() {
+		// Neuter only fails for unknown key versions, which parseKey rejects
+		if pub, err := key.Neuter(); err == nil {
+			key = pub
+		}
+	}
+	return withChecksum(d.format(key.String()))
+}
+
+// PrivateString returns the descriptor including the private key, with its
+// checksum. It is empty for public descriptors.
+func (d *Descriptor) PrivateString() string {
+	if !d.key.IsPrivate() {
+		return ""
+	}
+	return withChecksum(d.format(d.key.String()))
+}
+
+func (d *Descriptor) format(key string) string {
+	var b strings.Builder
+	if d.Origin != nil {
+		b.WriteString("[")
+		b.WriteString(hex.EncodeToString(d.Origin.Fingerprint[:]))
+		for _, n := range d.Origin.Path {
+			b.WriteString("/")
+			b.WriteString(formatPathStep(n))
+		}
+		b.WriteString("]")
+	}
+	b.WriteString(key)
+	for i, step := range d.steps {
+		b.WriteString("/")
+		if i == d.multi {
+			values := make([]string, len(step))
+			for j, v := range step {
+				values[j] = formatPathStep(v)
+			}
+			b.WriteString("<" + strings.Join(values, ";") + ">")
+		} else {
+			b.WriteString(formatPathStep(step[0]))
+		}
+	}
+	if d.wildcard {
+		b.WriteString("/*")
+		if d.hardenedWildcard {
+			b.WriteString("h")
+		}
+	}
+
+	switch d.Type {
+	case DescriptorSHWPKH:
+		return "sh(wpkh(" + b.String() + "))"
+	default:
+		return string(d.Type) + "(" + b.String() + ")"
+	}
+}
+
+func formatPathStep(n uint32) string {
+	if n >= hdkeychain.HardenedKeyStart {
+		return strconv.FormatUint(uint64(n-hdkeychain.HardenedKeyStart), 10) + "h"
+	}
+	return strconv.FormatUint(uint64(n), 10)
+}
+
+// childPath returns the derivation steps after the descriptor key for an
+// address.
+func (d *Descriptor) childPath(branch int, index uint32) ([]uint32, error) {
+	if branch < 0 || branch >= d.Branches() {
+		return nil, fmt.Errorf("branch %d out of range", branch)
+	}
+	path := make([]uint32, 0, len(d.steps)+1)
+	for i, step := range d.steps {
+		if i == d.multi {
+			path = append(path, step[branch])
+		} else {
+			path = append(path, step[0])
+		}
+	}
+	if d.wildcard {
+		if index >= hdkeychain.HardenedKeyStart {
+			return nil, fmt.Errorf("index %d out of range", index)
+		}
+		if d.hardenedWildcard {
+			index += hdkeychain.HardenedKeyStart
+		}
+		path = append(path, index)
+	}
+	return path, nil
+}
+
+// Path returns the full derivation path of an address, starting at the
+// origin if known.
+func (d *Descriptor) Path(branch int, index uint32) string {
+	child, err := d.childPath(branch, index)
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	if d.Origin != nil {
+		b.WriteString("m")
+		for _, n := range d.Origin.Path {
+			b.WriteString("/" + formatPathStep(n))
+		}
+	} else {
+		b.WriteString("k")
+	}
+	for _, n := range child {
+		b.WriteString("/" + formatPathStep(n))
+	}
+	return b.String()
+}
+
+// DeriveKey derives the key of an address. It is private if the descriptor
+// key is.
+func (d *Descriptor) DeriveKey(branch int, index uint32) (*hdkeychain.ExtendedKey, error) {
+	child, err := d.childPath(branch, index)
+	if err != nil {
+		return nil, err
+	}
+	key := d.key
+	for _, n := range child {
+		if key, err = key.Derive(n); err != nil {
+			return nil, fmt.Errorf("failed to derive %s: %w", d.Path(branch, index), err)
+		}
+	}
+	return key, nil
+}
+
+// DeriveAddress derives the address at index on a branch.
+func (d *Descriptor) DeriveAddress(branch int, index uint32, params *chain.Params) (string, error) {
+	key, err := d.DeriveKey(branch, index)
+	if err != nil {
+		return "", err
+	}
+	pubKey, err := key.ECPubKey()
+	if err != nil {
+		return "", fmt.Errorf("failed to get public key: %w", err)
+	}
+
+	chainParams := toChainCfgParams(params)
+	switch d.Type {
+	case DescriptorPKH:
+		return deriveP2PKH(pubKey, chainParams)
+	case DescriptorSHWPKH:
+		if !params.SupportsSegWit {
+			return "", fmt.Errorf("%s does not support SegWit", params.Symbol)
+		}
+		return DeriveP2SH_P2WPKH(pubKey, chainParams)
+	case DescriptorWPKH:
+		if !params.SupportsSegWit {
+			return "", fmt.Errorf("%s does not support SegWit", params.Symbol)
+		}
+		return deriveP2WPKH(pubKey, chainParams)
+	case DescriptorTR:
+		if !params.SupportsTaproot {
+			return "", fmt.Errorf("%s does not support Taproot", params.Symbol)
+		}
+		return deriveP2TR(pubKey, chainParams)
+	default:
+		return "", fmt.Errorf("unsupported descriptor type: %s", d.Type)
+	}
+}
+
+// withPrivateKey returns a copy of d that derives from the given private key,
+// which must be the private counterpart of d's key.
+func (d *Descriptor) withPrivateKey(key *hdkeychain.ExtendedKey) (*Descriptor, error) {
+	pub, err := key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	own, err := d.key.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	if !pub.IsEqual(own) {
+		return nil, fmt.Errorf("private key does not match descriptor key")
+	}
+	cp := *d
+	cp.key = key
+	return &cp, nil
+}
+
+// MasterFingerprint returns the BIP-32 fingerprint of the wallet's master key,
+// as used in descriptor key origins.
+func (w *Wallet) MasterFingerprint() ([4]byte, error) {
+	var fp [4]byte
+	pubKey, err := w.masterKey.ECPubKey()
+	if err != nil {
+		return fp, err
+	}
+	copy(fp[:], btcutil.Hash160(pubKey.SerializeCompressed())[:4])
+	return fp, nil
+}
+
+// DeriveKeyAtPath derives a key at an arbitrary path from the master key.
+func (w *Wallet) DeriveKeyAtPath(path []uint32) (*hdkeychain.ExtendedKey, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	key := w.masterKey
+	for _, n := range path {
+		var err error
+		if key, err = key.Derive(n); err != nil {
+			return nil, fmt.Errorf("failed to derive path: %w", err)
+		}
+	}
+	return key, nil
+}
+
+// Descriptor checksum (BIP-380).
+const (
+	descriptorInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descriptorChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+var descriptorGenerator = [5]uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd}
+
+// DescriptorChecksum computes the 8-character checksum of a descriptor
+// without its "#" suffix.
+func DescriptorChecksum(desc string) (string, error) {
+	c := uint64(1)
+	var cls, clsCount uint64
+	for _, ch := range desc {
+		pos := strings.IndexRune(descriptorInputCharset, ch)
+		if pos < 0 {
+			return "", fmt.Errorf("invalid descriptor character: %q", ch)
+		}
+		c = descriptorPolymod(c, uint64(pos)&31)
+		cls = cls*3 + uint64(pos)>>5
+		clsCount++
+		if clsCount == 3 {
+			c = descriptorPolymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descriptorPolymod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = descriptorPolymod(c, 0)
+	}
+	c ^= 1
+
+	out := make([]byte, 8)
+	for i := range out {
+		out[i] = descriptorChecksumCharset[(c>>(5*(7-i)))&31]
+	}
+	return string(out), nil
+}
+
+func descriptorPolymod(c, val uint64) uint64 {
+	top := c >> 35
+	c = (c&0x7ffffffff)<<5 ^ val
+	for i, g := range descriptorGenerator {
+		if (top>>i)&1 == 1 {
+			c ^= g
+		}
+	}
+	return c
+}
+
+func withChecksum(desc string) string {
+	sum, err := DescriptorChecksum(desc)
+	if err != nil {
+		return desc
+	}
+	return desc + "#" + sum
+}
@@ -0,0 +1,185 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	// Vectors from BIP-380 and Bitcoin Core's descriptor documentation
+	tests := map[string]string{
+		"raw(deadbeef)": "89f8spxm",
+		"wpkh([d34db33f/84h/0h/0h]xpub6DJ2dNUysrn5Vt36jH2KLBT2i1auw1tTSSomg8PhqNiUtx8QX2SvC9nrHu81fT41fvDUnhMjEzQgXnQjKEu3oaqMSzhSrHMxyyoEAmUHQbY/0/*)": "cjjspncu",
+	}
+	for desc, want := range tests {
+		got, err := DescriptorChecksum(desc)
+		if err != nil || got != want {
+			t.Errorf("DescriptorChecksum(%s) = %s, %v, want %s", desc, got, err, want)
+		}
+	}
+
+	if _, err := DescriptorChecksum("wpkh(é)"); err == nil {
+		t.Error("DescriptorChecksum() should reject characters outside the charset")
+	}
+}
+
+// accountDescriptor returns a descriptor for the test wallet's account key at
+// m/purpose'/coin'/0'.
+func accountDescriptor(t *testing.T, w *Wallet, typ string, purpose, coin uint32, path string) string {
+	t.Helper()
+	origin := []uint32{hdkeychain.HardenedKeyStart + purpose, hdkeychain.HardenedKeyStart + coin, hdkeychain.HardenedKeyStart}
+	key, err := w.DeriveKeyAtPath(origin)
+	if err != nil {
+		t.Fatalf("DeriveKeyAtPath() error = %v", err)
+	}
+	pub, err := key.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter() error = %v", err)
+	}
+	fp, err := w.MasterFingerprint()
+	if err != nil {
+		t.Fatalf("MasterFingerprint() error = %v", err)
+	}
+	return fmt.Sprintf("%s([%s/%dh/%dh/0h]%s/%s)", typ, hex.EncodeToString(fp[:]), purpose, coin, pub.String(), path)
+}
+
+func TestDescriptorDeriveAddress(t *testing.T) {
+	w, err := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+	if err != nil {
+		t.Fatalf("NewFromMnemonic() error = %v", err)
+	}
+	params, _ := chain.Get("BTC", chain.Mainnet)
+
+	fp, _ := w.MasterFingerprint()
+	if hex.EncodeToString(fp[:]) != "73c5da0a" {
+		t.Errorf("MasterFingerprint() = %x, want 73c5da0a", fp)
+	}
+
+	// BIP-84 and BIP-86 test vectors for the "abandon ... about" mnemonic
+	wpkh, err := ParseDescriptor(accountDescriptor(t, w, "wpkh", 84, 0, "<0;1>/*"))
+	if err != nil {
+		t.Fatalf("ParseDescriptor(wpkh) error = %v", err)
+	}
+	if got, _ := wpkh.DeriveAddress(0, 0, params); got != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+		t.Errorf("wpkh receive 0 = %s", got)
+	}
+	want, _ := w.DeriveAddressWithChange("BTC", 0, 1, 3)
+	if got, _ := wpkh.DeriveAddress(1, 3, params); got != want {
+		t.Errorf("wpkh change 3 = %s, want %s", got, want)
+	}
+	if got := wpkh.Path(1, 3); got != "m/84h/0h/0h/1/3" {
+		t.Errorf("Path(1, 3) = %s", got)
+	}
+	if !wpkh.IsRange() || wpkh.Branches() != 2 || wpkh.HasPrivateKey() {
+		t.Errorf("IsRange/Branches/HasPrivateKey = %v/%d/%v", wpkh.IsRange(), wpkh.Branches(), wpkh.HasPrivateKey())
+	}
+
+	tr, err := ParseDescriptor(accountDescriptor(t, w, "tr", 86, 0, "0/*"))
+	if err != nil {
+		t.Fatalf("ParseDescriptor(tr) error = %v", err)
+	}
+	if got, _ := tr.DeriveAddress(0, 0, params); got != "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr" {
+		t.Errorf("tr receive 0 = %s", got)
+	}
+	if _, err := tr.DeriveAddress(1, 0, params); err == nil {
+		t.Error("DeriveAddress() should reject a branch without multipath")
+	}
+
+	// Taproot is not available on every chain
+	doge, _ := chain.Get("DOGE", chain.Mainnet)
+	if _, err := tr.DeriveAddress(0, 0, doge); err == nil {
+		t.Error("DeriveAddress(tr) on DOGE should fail")
+	}
+}
+
+func TestDescriptorStringRoundTrip(t *testing.T) {
+	w, _ := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+	desc := accountDescriptor(t, w, "sh(wpkh", 49, 0, "<0;1>/*)")
+
+	d, err := ParseDescriptor(desc)
+	if err != nil {
+		t.Fatalf("ParseDescriptor() error = %v", err)
+	}
+	if d.Type != DescriptorSHWPKH {
+		t.Errorf("Type = %s", d.Type)
+	}
+	s := d.String()
+	if !strings.HasPrefix(s, desc+"#") {
+		t.Errorf("String() = %s, want %s#...", s, desc)
+	}
+	again, err := ParseDescriptor(s)
+	if err != nil || again.String() != s {
+		t.Errorf("ParseDescriptor(String()) = %v, %v", again, err)
+	}
+
+	// Apostrophes are accepted and normalized to h
+	d, err = ParseDescriptor(strings.ReplaceAll(desc, "h/", "'/"))
+	if err != nil || d.String() != s {
+		t.Errorf("ParseDescriptor(') = %v, %v", d, err)
+	}
+
+	if _, err := ParseDescriptor(s[:len(s)-1] + "x"); err == nil {
+		t.Error("ParseDescriptor() should reject a bad checksum")
+	}
+}
+
+func TestDescriptorPrivateKey(t *testing.T) {
+	w, _ := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+	params, _ := chain.Get("BTC", chain.Mainnet)
+	key, _ := w.DeriveKeyAtPath([]uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart, hdkeychain.HardenedKeyStart})
+
+	d, err := ParseDescriptor("wpkh(" + key.String() + "/0/*)")
+	if err != nil {
+		t.Fatalf("ParseDescriptor() error = %v", err)
+	}
+	if !d.HasPrivateKey() {
+		t.Fatal("HasPrivateKey() = false")
+	}
+	if strings.Contains(d.String(), "xprv") {
+		t.Errorf("String() leaks the private key: %s", d.String())
+	}
+	if !strings.Contains(d.PrivateString(), key.String()) {
+		t.Errorf("PrivateString() = %s", d.PrivateString())
+	}
+	if got, _ := d.DeriveAddress(0, 0, params); got != "bc1qcr8te4kr609gcawutmrza0j4xv80jy8z306fyu" {
+		t.Errorf("DeriveAddress() = %s", got)
+	}
+
+	// Hardened steps need the private key
+	if _, err := ParseDescriptor("wpkh(" + key.String() + "/0h/*h)"); err != nil {
+		t.Errorf("ParseDescriptor(xprv hardened) error = %v", err)
+	}
+	pub, _ := key.Neuter()
+	if _, err := ParseDescriptor("wpkh(" + pub.String() + "/0h/*)"); err == nil {
+		t.Error("ParseDescriptor(xpub hardened) should fail")
+	}
+}
+
+func TestParseDescriptorErrors(t *testing.T) {
+	w, _ := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+	key, _ := w.DeriveKeyAtPath([]uint32{hdkeychain.HardenedKeyStart + 84})
+	pub, _ := key.Neuter()
+	xpub := pub.String()
+
+	tests := []string{
+		"wsh(" + xpub + "/0/*)",
+		"tr(" + xpub + "/0/*,pk(" + xpub + "))",
+		"wpkh(" + xpub + "/*/0)",
+		"wpkh(" + xpub + "/<0;1>/<2;3>/*)",
+		"wpkh(" + xpub + "/<0;1;2>/*)",
+		"wpkh([d34db3/84h]" + xpub + "/0/*)",
+		"wpkh([d34db33f/84h" + xpub + "/0/*)",
+		"wpkh(notakey/0/*)",
+		"wpkh(" + xpub + "/x/*)",
+	}
+	for _, desc := range tests {
+		if _, err := ParseDescriptor(desc); err == nil {
+			t.Errorf("ParseDescriptor(%s) should fail", desc)
+		}
+	}
+}
@@ -0,0 +1,221 @@
+// Package wallet - Hardware wallets.
+//
+// Ledger and Trezor One devices are reached over HID (hidraw on Linux).
+// Both sign native SegWit (p2wpkh) inputs and Trezor also signs legacy
+// (p2pkh) ones; Taproot inputs are not supported. A Ledger needs the
+// chain's app open (Bitcoin, Bitcoin Test or Litecoin) and signs with the
+// app's legacy APDU commands. A Trezor One must be unlocked and without a
+// passphrase, since the node has no way to enter either. Newer Trezor
+// models connect over WebUSB, not HID, and are not listed. Other platforms
+// have no HID transport yet (see hid_other.go).
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/txscript"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// hardwareChains are the chains devices can sign, the ones with a Ledger
+// app the node speaks to.
+var hardwareChains = map[string]bool{"BTC": true, "LTC": true}
+
+// USB IDs of supported devices.
+const (
+	ledgerVendorID     = 0x2c97
+	trezorOneVendorID  = 0x534c
+	trezorOneProductID = 0x0001
+)
+
+// hidPacketSize is the HID report size of Ledger and Trezor devices.
+const hidPacketSize = 64
+
+var (
+	ErrNoDevice       = errors.New("hardware wallet not connected")
+	ErrDeviceRejected = errors.New("rejected on the hardware wallet")
+	ErrDeviceLocked   = errors.New("hardware wallet is locked")
+	ErrHardwareKey    = errors.New("key is held by a hardware wallet")
+	ErrHIDUnsupported = errors.New("hardware wallets are not supported on this platform")
+)
+
+// DeviceInfo describes a connected hardware wallet.
+type DeviceInfo struct {
+	Path      string `json:"path"` // HID device, e.g. /dev/hidraw0
+	Type      string `json:"type"` // ledger or trezor
+	Model     string `json:"model"`
+	VendorID  uint16 `json:"vendor_id"`
+	ProductID uint16 `json:"product_id"`
+}
+
+// HardwareSigner is a Signer on a connected hardware wallet.
+type HardwareSigner interface {
+	Signer
+
+	// Info returns the device the signer is connected to.
+	Info() DeviceInfo
+
+	// PublicKey returns the extended public key at a derivation path.
+	PublicKey(ctx context.Context, params *chain.Params, path []uint32) (*hdkeychain.ExtendedKey, error)
+
+	// DisplayAddress shows the chain's default address type for a
+	// derivation path on the device, and returns it once the user
+	// confirmed it.
+	DisplayAddress(ctx context.Context, params *chain.Params, path []uint32) (string, error)
+
+	// Close disconnects from the device.
+	Close() error
+}
+
+// EnumerateDevices lists the connected hardware wallets.
+func EnumerateDevices() ([]DeviceInfo, error) {
+	return hidEnumerate()
+}
+
+// OpenDevice connects to a hardware wallet listed by EnumerateDevices.
+func OpenDevice(ctx context.Context, info DeviceInfo) (HardwareSigner, error) {
+	dev, err := hidOpen(info.Path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", info.Path, err)
+	}
+	switch info.Type {
+	case SignerLedger:
+		return newLedger(info, dev), nil
+	case SignerTrezor:
+		t := newTrezor(info, dev)
+		if err := t.initialize(ctx); err != nil {
+			dev.Close()
+			return nil, err
+		}
+		return t, nil
+	}
+	dev.Close()
+	return nil, fmt.Errorf("unknown hardware wallet type: %s", info.Type)
+}
+
+// deviceModel returns the signer type and model of a USB device, or "" if
+// it isn't a supported hardware wallet.
+func deviceModel(vendorID, productID uint16) (string, string) {
+	switch {
+	case vendorID == trezorOneVendorID && productID == trezorOneProductID:
+		return SignerTrezor, "Trezor One"
+	case vendorID != ledgerVendorID:
+		return "", ""
+	}
+	// Ledger product IDs carry the model in the high byte, or are the
+	// model itself for old firmware
+	model := productID >> 8
+	if productID < 0x100 {
+		model = productID
+	}
+	switch model {
+	case 0x01, 0x10:
+		return SignerLedger, "Nano S"
+	case 0x04, 0x40:
+		return SignerLedger, "Nano X"
+	case 0x05, 0x50:
+		return SignerLedger, "Nano S Plus"
+	case 0x06, 0x60:
+		return SignerLedger, "Stax"
+	case 0x07, 0x70:
+		return SignerLedger, "Flex"
+	}
+	return SignerLedger, "Ledger"
+}
+
+// accountPath returns the derivation path of a chain's wallet account,
+// m/purpose'/coin'/account'.
+func accountPath(params *chain.Params, account uint32) []uint32 {
+	return params.DerivationPath(account, 0, 0)[:3]
+}
+
+// formatPath returns a derivation path as a string, e.g. m/84h/0h/0h.
+func formatPath(path []uint32) string {
+	var b strings.Builder
+	b.WriteString("m")
+	for _, n := range path {
+		b.WriteString("/" + formatPathStep(n))
+	}
+	return b.String()
+}
+
+// deviceAddressType returns the address type a device signs for a chain,
+// or an error if it's one devices can't sign.
+func deviceAddressType(params *chain.Params) (chain.AddressType, error) {
+	addrType := params.DefaultAddressType
+	if addrType == "" {
+		addrType = chain.AddressP2PKH
+		if params.SupportsSegWit {
+			addrType = chain.AddressP2WPKH
+		}
+	}
+	if addrType != chain.AddressP2WPKH && addrType != chain.AddressP2PKH {
+		return "", fmt.Errorf("hardware wallets can't sign %s %s inputs", params.Symbol, addrType)
+	}
+	return addrType, nil
+}
+
+// newExtendedPublicKey assembles an extended public key reported by a
+// device for a derivation path.
+func newExtendedPublicKey(params *chain.Params, pubKey, chainCode []byte, parentFP uint32, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	key, err := btcec.ParsePubKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("device returned an invalid public key: %w", err)
+	}
+	if len(chainCode) != 32 {
+		return nil, fmt.Errorf("device returned a %d byte chain code", len(chainCode))
+	}
+	fp := make([]byte, 4)
+	binary.BigEndian.PutUint32(fp, parentFP)
+	var childNum uint32
+	if len(path) > 0 {
+		childNum = path[len(path)-1]
+	}
+	return hdkeychain.NewExtendedKey(params.HDPublicKeyID[:], key.SerializeCompressed(), chainCode, fp, uint8(len(path)), childNum, false), nil
+}
+
+// setInputSignature sets the witness or signature script of input i from a
+// device's DER signature (SIGHASH_ALL) and the input key.
+func setInputSignature(req *SignRequest, i int, der []byte, pubKey *btcec.PublicKey) error {
+	if _, err := ecdsa.ParseDERSignature(der); err != nil {
+		return fmt.Errorf("device returned an invalid signature for input %d: %w", i, err)
+	}
+	sig := append(append([]byte(nil), der...), byte(txscript.SigHashAll))
+	in := req.Tx.TxIn[i]
+	switch detectAddressType(req.UTXOs[i].Address, req.Params) {
+	case "p2wpkh":
+		in.Witness = [][]byte{sig, pubKey.SerializeCompressed()}
+	case "p2pkh":
+		script, err := txscript.NewScriptBuilder().AddData(sig).AddData(pubKey.SerializeCompressed()).Script()
+		if err != nil {
+			return err
+		}
+		in.SignatureScript = script
+	default:
+		return fmt.Errorf("hardware wallets can't sign input %d of type %s", i, detectAddressType(req.UTXOs[i].Address, req.Params))
+	}
+	return nil
+}
+
+// watchContext closes dev once ctx is done, to unblock a read waiting for
+// the user. The returned function stops watching.
+func watchContext(ctx context.Context, dev io.Closer) func() {
+	done := make(chan struct{})
+	go func() {
+		select {
+		case <-ctx.Done():
+			dev.Close()
+		case <-done:
+		}
+	}()
+	return func() { close(done) }
+}
@@ -0,0 +1,118 @@
+//go:build linux
+
+package wallet
+
+import (
+	"bufio"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const hidrawClass = "/sys/class/hidraw"
+
+// hidEnumerate lists the hidraw devices of supported hardware wallets.
+func hidEnumerate() ([]DeviceInfo, error) {
+	entries, err := os.ReadDir(hidrawClass)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var devices []DeviceInfo
+	for _, entry := range entries {
+		sysDir := filepath.Join(hidrawClass, entry.Name(), "device")
+		vendorID, productID, name, ok := readHIDUevent(filepath.Join(sysDir, "uevent"))
+		if !ok {
+			continue
+		}
+		deviceType, model := deviceModel(vendorID, productID)
+		if deviceType == "" {
+			continue
+		}
+		// The wallet is USB interface 0; Ledgers also expose a FIDO one.
+		// The device directory's parent is the interface, e.g. 1-1:1.0.
+		if target, err := filepath.EvalSymlinks(sysDir); err == nil && !strings.HasSuffix(filepath.Base(filepath.Dir(target)), ".0") {
+			continue
+		}
+		if name != "" && deviceType == SignerLedger && model == "Ledger" {
+			model = name
+		}
+		devices = append(devices, DeviceInfo{
+			Path:      filepath.Join("/dev", entry.Name()),
+			Type:      deviceType,
+			Model:     model,
+			VendorID:  vendorID,
+			ProductID: productID,
+		})
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Path < devices[j].Path })
+	return devices, nil
+}
+
+// readHIDUevent reads the USB IDs and name of a HID device, from lines
+// such as HID_ID=0003:00002C97:00005011 and HID_NAME=Ledger Nano S Plus.
+func readHIDUevent(path string) (vendorID, productID uint16, name string, ok bool) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, 0, "", false
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		key, value, _ := strings.Cut(scanner.Text(), "=")
+		switch key {
+		case "HID_ID":
+			parts := strings.Split(value, ":")
+			if len(parts) != 3 {
+				return 0, 0, "", false
+			}
+			vendor, err1 := strconv.ParseUint(parts[1], 16, 32)
+			product, err2 := strconv.ParseUint(parts[2], 16, 32)
+			if err1 != nil || err2 != nil {
+				return 0, 0, "", false
+			}
+			vendorID, productID, ok = uint16(vendor), uint16(product), true
+		case "HID_NAME":
+			name = value
+		}
+	}
+	return vendorID, productID, name, ok
+}
+
+// hidOpen opens a hidraw device.
+func hidOpen(path string) (io.ReadWriteCloser, error) {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &hidraw{f: f}, nil
+}
+
+// hidraw writes reports with the report ID 0 that devices without
+// numbered reports expect; reads return the report without it.
+type hidraw struct {
+	f *os.File
+}
+
+func (h *hidraw) Write(p []byte) (int, error) {
+	n, err := h.f.Write(append([]byte{0}, p...))
+	if n > 0 {
+		n--
+	}
+	return n, err
+}
+
+func (h *hidraw) Read(p []byte) (int, error) {
+	return h.f.Read(p)
+}
+
+func (h *hidraw) Close() error {
+	return h.f.Close()
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+// Package wallet - HID transport on platforms other than Linux. Devices are
+// only reached through hidraw for now, so hardware signing fails here with
+// ErrHIDUnsupported.
+package wallet
+
+import "io"
+
+func hidEnumerate() ([]DeviceInfo, error) {
+	return nil, ErrHIDUnsupported
+}
+
+func hidOpen(path string) (io.ReadWriteCloser, error) {
+	return nil, ErrHIDUnsupported
+}
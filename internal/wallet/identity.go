@@ -0,0 +1,58 @@
+// Package wallet - Wallet identity key for signing orders.
+package wallet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// IdentityPurpose is the BIP32 purpose of the wallet identity key
+// (m/13'/0'/0'/0/0, as in SLIP-0013). It is independent of the libp2p peer
+// key, so a maker keeps its identity when the node key changes.
+const IdentityPurpose = 13
+
+// DeriveIdentityKey derives the wallet identity key.
+func (w *Wallet) DeriveIdentityKey() (*btcec.PrivateKey, error) {
+	key, err := w.DeriveKey(IdentityPurpose, 0, 0, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPrivKey()
+}
+
+// IdentityKey returns the wallet identity key.
+func (s *Service) IdentityKey() (*btcec.PrivateKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+
+	return s.wallet.DeriveIdentityKey()
+}
+
+// AddressMatchesPubKey reports whether address is one of the addresses of
+// pubKey on a chain: any Bitcoin-family address type, or the EVM address.
+func AddressMatchesPubKey(symbol string, network chain.Network, address string, pubKey *btcec.PublicKey) bool {
+	params, ok := chain.Get(symbol, network)
+	if !ok {
+		return false
+	}
+	if params.Type == chain.ChainTypeEVM {
+		return strings.EqualFold(PublicKeyToEVMAddress(pubKey), address)
+	}
+	addresses, err := AllAddressTypes(pubKey, params)
+	if err != nil {
+		return false
+	}
+	for _, a := range addresses {
+		if a == address {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,578 @@
+// Package wallet - Import from other wallet formats.
+//
+// Besides BIP-39 mnemonics, the wallet takes keys from other wallets:
+//
+//   - Electrum seeds are imported as a descriptor of the Electrum wallet
+//     (pkh m/<0;1>/* for standard seeds, wpkh m/0h/<0;1>/* for segwit), so
+//     the account is listed, scanned and spent like other descriptors.
+//   - WIF private keys are swept: every coin on the key's addresses moves to
+//     a fresh receive address of the HD wallet.
+//   - Ethereum JSON keystore files are swept the same way, moving the
+//     balances of the chain's known ERC-20 tokens and then the native
+//     balance to the wallet's EVM address.
+//
+// Swept coins belong to the HD wallet, so they fund swaps like any other;
+// SweepDescriptor does the same for an imported descriptor account.
+package wallet
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// ElectrumSeedType is the kind of wallet an Electrum seed creates.
+type ElectrumSeedType string
+
+// Supported Electrum seed types.
+const (
+	ElectrumSeedStandard ElectrumSeedType = "standard" // P2PKH, m/<0;1>/*
+	ElectrumSeedSegwit   ElectrumSeedType = "segwit"   // P2WPKH, m/0h/<0;1>/*
+)
+
+// ElectrumSeedVersion returns the type of an Electrum (v2) seed, which is
+// encoded in the HMAC of the normalized phrase. Two-factor seeds are rejected.
+func ElectrumSeedVersion(mnemonic string) (ElectrumSeedType, error) {
+	mac := hmac.New(sha512.New, []byte("Seed version"))
+	mac.Write([]byte(normalizeElectrumText(mnemonic)))
+	version := hex.EncodeToString(mac.Sum(nil))
+
+	switch {
+	case strings.HasPrefix(version, "01"):
+		return ElectrumSeedStandard, nil
+	case strings.HasPrefix(version, "100"):
+		return ElectrumSeedSegwit, nil
+	case strings.HasPrefix(version, "101"), strings.HasPrefix(version, "102"):
+		return "", fmt.Errorf("two-factor Electrum seeds are not supported")
+	default:
+		return "", fmt.Errorf("not an Electrum seed")
+	}
+}
+
+// ElectrumDescriptor returns the private descriptor of the wallet an
+// Electrum seed creates. The extended key is encoded for the network's
+// Bitcoin version bytes; addresses come from the chain the descriptor is
+// imported on.
+func ElectrumDescriptor(mnemonic, passphrase string, network chain.Network) (*Descriptor, error) {
+	seedType, err := ElectrumSeedVersion(mnemonic)
+	if err != nil {
+		return nil, err
+	}
+
+	seed := pbkdf2.Key([]byte(normalizeElectrumText(mnemonic)), []byte("electrum"+normalizeElectrumText(passphrase)), 2048, 64, sha512.New)
+	defer SecureClear(seed)
+
+	netParams := &chaincfg.MainNetParams
+	if network == chain.Testnet {
+		netParams = &chaincfg.TestNet3Params
+	}
+	master, err := hdkeychain.NewMaster(seed, netParams)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create master key: %w", err)
+	}
+	pubKey, err := master.ECPubKey()
+	if err != nil {
+		return nil, err
+	}
+	fp := btcutil.Hash160(pubKey.SerializeCompressed())[:4]
+
+	var desc string
+	switch seedType {
+	case ElectrumSeedSegwit:
+		account, err := master.Derive(hdkeychain.HardenedKeyStart)
+		if err != nil {
+			return nil, err
+		}
+		desc = fmt.Sprintf("wpkh([%x/0h]%s/<0;1>/*)", fp, account.String())
+	default:
+		desc = fmt.Sprintf("pkh([%x]%s/<0;1>/*)", fp, master.String())
+	}
+	return ParseDescriptor(desc)
+}
+
+// normalizeElectrumText normalizes a seed phrase or passphrase as Electrum
+// does: NFKD, lower case, no accents, single spaces, and no spaces between
+// CJK characters.
+func normalizeElectrumText(s string) string {
+	s = strings.ToLower(norm.NFKD.String(s))
+	s = strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, s)
+	runes := []rune(strings.Join(strings.Fields(s), " "))
+
+	var b strings.Builder
+	for i, r := range runes {
+		if r == ' ' && isCJK(runes[i-1]) && isCJK(runes[i+1]) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// cjkRanges are the blocks Electrum treats as CJK (electrum/mnemonic.py).
+var cjkRanges = [][2]rune{
+	{0x4E00, 0x9FFF},   // CJK Unified Ideographs
+	{0x3400, 0x4DBF},   // CJK Unified Ideographs Extension A
+	{0x20000, 0x2A6DF}, // CJK Unified Ideographs Extension B
+	{0x2A700, 0x2B73F}, // CJK Unified Ideographs Extension C
+	{0x2B740, 0x2B81F}, // CJK Unified Ideographs Extension D
+	{0xF900, 0xFAFF},   // CJK Compatibility Ideographs
+	{0x2F800, 0x2FA1D}, // CJK Compatibility Ideographs Supplement
+	{0x3190, 0x319F},   // Kanbun
+	{0x2E80, 0x2EFF},   // CJK Radicals Supplement
+	{0x2F00, 0x2FDF},   // CJK Radicals
+	{0x31C0, 0x31EF},   // CJK Strokes
+	{0x2FF0, 0x2FFF},   // Ideographic Description Characters
+	{0xE0100, 0xE01EF}, // Variation Selectors Supplement
+	{0x3100, 0x312F},   // Bopomofo
+	{0x31A0, 0x31BF},   // Bopomofo Extended
+	{0xFF00, 0xFFEF},   // Halfwidth and Fullwidth Forms
+	{0x3040, 0x309F},   // Hiragana
+	{0x30A0, 0x30FF},   // Katakana
+	{0x31F0, 0x31FF},   // Katakana Phonetic Extensions
+	{0x1B000, 0x1B0FF}, // Kana Supplement
+	{0xAC00, 0xD7AF},   // Hangul Syllables
+	{0x1100, 0x11FF},   // Hangul Jamo
+	{0xA960, 0xA97F},   // Hangul Jamo Extended A
+	{0xD7B0, 0xD7FF},   // Hangul Jamo Extended B
+	{0x3130, 0x318F},   // Hangul Compatibility Jamo
+	{0xA4D0, 0xA4FF},   // Lisu
+	{0x16F00, 0x16F9F}, // Miao
+	{0xA000, 0xA48F},   // Yi Syllables
+	{0xA490, 0xA4CF},   // Yi Radicals
+}
+
+// isCJK reports whether Electrum treats r as a CJK character.
+func isCJK(r rune) bool {
+	for _, rng := range cjkRanges {
+		if r >= rng[0] && r <= rng[1] {
+			return true
+		}
+	}
+	return false
+}
+
+// ImportElectrumSeed imports the wallet of an Electrum seed on a chain as a
+// signing descriptor.
+func (s *Service) ImportElectrumSeed(symbol, mnemonic, passphrase, label string) (*DescriptorInfo, error) {
+	d, err := ElectrumDescriptor(mnemonic, passphrase, s.network)
+	if err != nil {
+		return nil, err
+	}
+	return s.ImportDescriptor(symbol, d.PrivateString(), label)
+}
+
+// SweepResult describes coins moved into the HD wallet.
+type SweepResult struct {
+	Symbol string   `json:"symbol"`
+	TxID   string   `json:"txid,omitempty"` // Empty when only tokens were swept
+	From   []string `json:"from"`           // Swept addresses
+	To     string   `json:"to"`             // Wallet address receiving the coins
+	Amount *big.Int `json:"amount"`
+	Fee    *big.Int `json:"fee"` // Including the gas of token transfers
+
+	// ERC-20 balances swept from a keystore account
+	Tokens []TokenSweep `json:"tokens,omitempty"`
+}
+
+// TokenSweep is an ERC-20 balance moved into the wallet.
+type TokenSweep struct {
+	Token    string   `json:"token"`
+	Contract string   `json:"contract"`
+	TxID     string   `json:"txid"`
+	Amount   *big.Int `json:"amount"`
+}
+
+// SweepWIF moves every coin on the P2PKH, P2WPKH, P2SH-P2WPKH and P2TR
+// addresses of a WIF private key to a fresh receive address of the HD
+// wallet. An uncompressed key only has a P2PKH address.
+func (s *Service) SweepWIF(ctx context.Context, symbol, wif string) (*SweepResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("no storage configured")
+	}
+	b, params, err := s.descriptorBackend(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if params.Type != chain.ChainTypeBitcoin {
+		return nil, fmt.Errorf("WIF keys are only supported on Bitcoin-family chains")
+	}
+
+	decoded, err := btcutil.DecodeWIF(strings.TrimSpace(wif))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode WIF: %w", err)
+	}
+	if !decoded.IsForNet(toChainCfgParams(params)) {
+		return nil, fmt.Errorf("WIF is for a different network than %s", symbol)
+	}
+
+	addresses, err := wifAddresses(decoded, params)
+	if err != nil {
+		return nil, err
+	}
+	var utxos []*AddressUTXO
+	var from []string
+	for _, addr := range addresses {
+		addrUTXOs, err := b.GetAddressUTXOs(ctx, addr.address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get UTXOs for %s: %w", addr.address, err)
+		}
+		if len(addrUTXOs) > 0 {
+			from = append(from, addr.address)
+		}
+		for _, u := range addrUTXOs {
+			utxos = append(utxos, &AddressUTXO{
+				TxID:        u.TxID,
+				Vout:        u.Vout,
+				Amount:      u.Amount,
+				Address:     addr.address,
+				AddressType: addr.addrType,
+			})
+		}
+	}
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("no coins to sweep")
+	}
+
+	result, err := s.sweepUTXOs(ctx, b, symbol, singleKeyDeriver{decoded.PrivKey}, utxos)
+	if err != nil {
+		return nil, err
+	}
+	result.From = from
+	return result, nil
+}
+
+// wifAddress is an address of a WIF key and the AddressUTXO type spending it.
+type wifAddress struct {
+	address  string
+	addrType string
+}
+
+// wifAddresses returns the addresses a WIF key's coins can be on. SegWit
+// needs compressed keys, so an uncompressed key only has a P2PKH address,
+// of the uncompressed public key.
+func wifAddresses(key *btcutil.WIF, params *chain.Params) ([]wifAddress, error) {
+	if !key.CompressPubKey {
+		addr, err := btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.SerializePubKey()), toChainCfgParams(params))
+		if err != nil {
+			return nil, err
+		}
+		return []wifAddress{{addr.EncodeAddress(), "p2pkh-uncompressed"}}, nil
+	}
+
+	all, err := AllAddressTypes(key.PrivKey.PubKey(), params)
+	if err != nil {
+		return nil, err
+	}
+	var addresses []wifAddress
+	for _, addrType := range []chain.AddressType{chain.AddressP2PKH, chain.AddressP2WPKH, chain.AddressP2SH_P2WPKH, chain.AddressP2TR} {
+		if address, ok := all[addrType]; ok {
+			addresses = append(addresses, wifAddress{address, string(addrType)})
+		}
+	}
+	return addresses, nil
+}
+
+// SweepDescriptor moves every coin of a signing descriptor to a fresh
+// receive address of the HD wallet.
+func (s *Service) SweepDescriptor(ctx context.Context, id string) (*SweepResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	rec, d, err := s.loadDescriptor(id)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.descriptorSigner(rec, d)
+	if err != nil {
+		return nil, err
+	}
+	b, params, err := s.descriptorBackend(rec.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, _, err := descriptorUTXOs(ctx, b, params, d)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.sweepUTXOs(ctx, b, rec.Symbol, descriptorKeyDeriver{signer}, utxos)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]bool)
+	for _, u := range utxos {
+		if !seen[u.Address] {
+			seen[u.Address] = true
+			result.From = append(result.From, u.Address)
+		}
+	}
+	return result, nil
+}
+
+// sweepUTXOs spends utxos in full to the next receive address of the HD
+// wallet. The coins stay ours, so the spending policy doesn't apply.
+// s.mu must be held.
+func (s *Service) sweepUTXOs(ctx context.Context, b backend.Backend, symbol string, keys KeyDeriver, utxos []*AddressUTXO) (*SweepResult, error) {
+	syncService := NewUTXOSyncService(&UTXOSyncConfig{
+		Wallet:   s.wallet,
+		Storage:  s.store,
+		Backends: s.backends,
+		Network:  s.network,
+		GapLimit: 20,
+	})
+	toAddr, _, err := syncService.GetNextReceiveAddress(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive sweep address: %w", err)
+	}
+
+	feeEst, err := b.GetFeeEstimates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee estimates: %w", err)
+	}
+	feeRate := feeEst.HalfHourFee
+	if feeRate == 0 {
+		feeRate = 10
+	}
+
+	tx, err := BuildSendMaxTx(keys, &SendMaxParams{
+		UTXOs:     utxos,
+		ToAddress: toAddr,
+		FeeRate:   feeRate,
+		Symbol:    symbol,
+		Network:   s.network,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sweep: %w", err)
+	}
+	txid, err := b.BroadcastTransaction(ctx, tx.TxHex)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+
+	return &SweepResult{
+		Symbol: symbol,
+		TxID:   txid,
+		To:     toAddr,
+		Amount: new(big.Int).SetUint64(tx.TotalOutput),
+		Fee:    new(big.Int).SetUint64(tx.Fee),
+	}, nil
+}
+
+// singleKeyDeriver signs every input with one key.
+type singleKeyDeriver struct {
+	key *btcec.PrivateKey
+}
+
+func (k singleKeyDeriver) DerivePrivateKeyWithChange(symbol string, account, change, index uint32) (*btcec.PrivateKey, error) {
+	return k.key, nil
+}
+
+// SweepEVMKeystore decrypts an Ethereum JSON keystore file and moves the
+// balances of the chain's known ERC-20 tokens, then the native balance less
+// the gas of every transfer, to the wallet's EVM address (account 0, index
+// 0). The native balance must cover the gas of the token transfers.
+func (s *Service) SweepEVMKeystore(ctx context.Context, symbol string, keystoreJSON []byte, passphrase string) (*SweepResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	b, params, err := s.descriptorBackend(symbol)
+	if err != nil {
+		return nil, err
+	}
+	if params.Type != chain.ChainTypeEVM {
+		return nil, fmt.Errorf("chain %s is not an EVM chain", symbol)
+	}
+	evmBackend, ok := b.(*backend.JSONRPCBackend)
+	if !ok || !evmBackend.IsEVM() {
+		return nil, fmt.Errorf("backend for %s is not an EVM backend", symbol)
+	}
+
+	privKey, err := decryptKeystore(keystoreJSON, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	fromAddr := PrivateKeyToEVMAddress(privKey)
+	toAddr, err := s.wallet.DeriveAddress(symbol, 0, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive address: %w", err)
+	}
+
+	info, err := evmBackend.GetAddressInfo(ctx, fromAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get balance: %w", err)
+	}
+	nonce, err := evmBackend.EVMGetNonce(ctx, fromAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get nonce: %w", err)
+	}
+	gasPrice, err := evmBackend.EVMGetGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	transfers, err := keystoreTokenTransfers(ctx, evmBackend, params, fromAddr, toAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	// The token transfers' gas comes out of the native balance first
+	balance := new(big.Int).SetUint64(info.Balance)
+	fee := new(big.Int)
+	for _, t := range transfers {
+		fee.Add(fee, new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(t.gasLimit)))
+	}
+	if fee.Cmp(balance) > 0 {
+		return nil, fmt.Errorf("balance of %s does not cover the gas of sweeping its tokens", fromAddr)
+	}
+	nativeFee := new(big.Int).Mul(gasPrice, new(big.Int).SetUint64(DefaultGasLimit))
+	amount := new(big.Int).Sub(balance, fee)
+	amount.Sub(amount, nativeFee)
+	if amount.Sign() <= 0 && len(transfers) == 0 {
+		return nil, fmt.Errorf("balance of %s does not cover the gas of a sweep", fromAddr)
+	}
+
+	result := &SweepResult{
+		Symbol: symbol,
+		From:   []string{fromAddr},
+		To:     toAddr,
+		Amount: new(big.Int),
+		Fee:    fee,
+	}
+	for _, t := range transfers {
+		tx, err := BuildAndSignEVMTx(privKey, &EVMTxParams{
+			Nonce:    nonce,
+			To:       t.token.Address,
+			Value:    big.NewInt(0),
+			Data:     t.callData,
+			ChainID:  params.ChainID,
+			GasLimit: t.gasLimit,
+			GasPrice: gasPrice,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to build %s sweep: %w", t.token.Symbol, err)
+		}
+		txHash, err := evmBackend.BroadcastTransaction(ctx, tx.RawTx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to broadcast %s sweep: %w", t.token.Symbol, err)
+		}
+		nonce++
+		result.Tokens = append(result.Tokens, TokenSweep{
+			Token:    t.token.Symbol,
+			Contract: t.token.Address,
+			TxID:     txHash,
+			Amount:   t.amount,
+		})
+	}
+	if amount.Sign() <= 0 {
+		return result, nil // Nothing left of the native balance after the tokens' gas
+	}
+
+	tx, err := BuildAndSignEVMTx(privKey, &EVMTxParams{
+		Nonce:    nonce,
+		To:       toAddr,
+		Value:    amount,
+		ChainID:  params.ChainID,
+		GasLimit: DefaultGasLimit,
+		GasPrice: gasPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sweep: %w", err)
+	}
+	txHash, err := evmBackend.BroadcastTransaction(ctx, tx.RawTx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+	result.TxID = txHash
+	result.Amount = amount
+	result.Fee.Add(result.Fee, nativeFee)
+	return result, nil
+}
+
+// tokenTransfer is the transfer sweeping a token balance.
+type tokenTransfer struct {
+	token    *chain.TokenInfo
+	amount   *big.Int
+	callData []byte
+	gasLimit uint64
+}
+
+// keystoreTokenTransfers returns the transfers moving the balances of the
+// chain's known tokens from an address to another, in token symbol order.
+func keystoreTokenTransfers(ctx context.Context, b *backend.JSONRPCBackend, params *chain.Params, from, to string) ([]tokenTransfer, error) {
+	tokens := chain.ListTokens(params.ChainID)
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].Symbol < tokens[j].Symbol })
+
+	balanceOf, err := EncodeERC20BalanceOf(from)
+	if err != nil {
+		return nil, err
+	}
+	var transfers []tokenTransfer
+	for _, token := range tokens {
+		result, err := b.EVMCall(ctx, token.Address, balanceOf)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get %s balance: %w", token.Symbol, err)
+		}
+		amount, err := DecodeERC20BalanceResult(result)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s balance: %w", token.Symbol, err)
+		}
+		if amount.Sign() <= 0 {
+			continue
+		}
+		callData, err := EncodeERC20Transfer(to, amount)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode %s transfer: %w", token.Symbol, err)
+		}
+		gasLimit, err := b.EVMEstimateGas(ctx, from, token.Address, nil, callData)
+		if err != nil {
+			gasLimit = DefaultERC20GasLimit
+		}
+		transfers = append(transfers, tokenTransfer{token: token, amount: amount, callData: callData, gasLimit: gasLimit})
+	}
+	return transfers, nil
+}
+
+// decryptKeystore returns the private key of an Ethereum JSON keystore file.
+func decryptKeystore(keystoreJSON []byte, passphrase string) (*btcec.PrivateKey, error) {
+	key, err := keystore.DecryptKey(keystoreJSON, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt keystore: %w", err)
+	}
+	raw := key.PrivateKey.D.FillBytes(make([]byte, 32))
+	defer SecureClear(raw)
+
+	privKey, _ := btcec.PrivKeyFromBytes(raw)
+	return privKey, nil
+}
@@ -0,0 +1,354 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Seeds and addresses from Electrum's own wallet tests.
+const (
+	electrumStandardSeed = "cycle rocket west magnet parrot shuffle foot correct salt library feed song"
+	electrumSegwitSeed   = "bitter grass shiver impose acquire brush forget axis eager alone wine silver"
+)
+
+func TestElectrumDescriptor(t *testing.T) {
+	params, _ := chain.Get("BTC", chain.Mainnet)
+	tests := []struct {
+		seed            string
+		seedType        ElectrumSeedType
+		receive, change string
+	}{
+		{electrumStandardSeed, ElectrumSeedStandard, "1NNkttn1YvVGdqBW4PR6zvc3Zx3H5owKRf", "1KSezYMhAJMWqFbVFB2JshYg69UpmEXR4D"},
+		{electrumSegwitSeed, ElectrumSeedSegwit, "bc1q3g5tmkmlvxryhh843v4dz026avatc0zzr6h3af", "bc1qdy94n2q5qcp0kg7v9yzwe6wvfkhnvyzje7nx2p"},
+	}
+	for _, tt := range tests {
+		// Case and spacing don't matter
+		seed := "  " + strings.ToUpper(strings.ReplaceAll(tt.seed, " ", "   "))
+		if got, err := ElectrumSeedVersion(seed); err != nil || got != tt.seedType {
+			t.Errorf("ElectrumSeedVersion(%q) = %q, %v, want %q", tt.seed, got, err, tt.seedType)
+		}
+
+		d, err := ElectrumDescriptor(seed, "", chain.Mainnet)
+		if err != nil {
+			t.Fatalf("ElectrumDescriptor() error = %v", err)
+		}
+		if !d.HasPrivateKey() || d.Branches() != 2 {
+			t.Errorf("descriptor %s should be a private receive/change descriptor", d)
+		}
+		receive, _ := d.DeriveAddress(0, 0, params)
+		change, _ := d.DeriveAddress(1, 0, params)
+		if receive != tt.receive || change != tt.change {
+			t.Errorf("%s seed addresses = %s, %s, want %s, %s", tt.seedType, receive, change, tt.receive, tt.change)
+		}
+
+		withPassphrase, _ := ElectrumDescriptor(seed, "secret", chain.Mainnet)
+		if other, _ := withPassphrase.DeriveAddress(0, 0, params); other == receive {
+			t.Error("passphrase should change the wallet")
+		}
+	}
+
+	if _, err := ElectrumSeedVersion(testMnemonic); err == nil {
+		t.Error("BIP39 mnemonic accepted as an Electrum seed")
+	}
+}
+
+func TestImportElectrumSeed(t *testing.T) {
+	svc, _ := newDescriptorTestService(t)
+	info, err := svc.ImportElectrumSeed("BTC", electrumSegwitSeed, "", "electrum")
+	if err != nil {
+		t.Fatalf("ImportElectrumSeed() error = %v", err)
+	}
+	if info.WatchOnly || info.Type != string(DescriptorWPKH) || info.Branches != 2 || info.Label != "electrum" {
+		t.Errorf("ImportElectrumSeed() = %+v", info)
+	}
+	if _, err := svc.ImportElectrumSeed("BTC", testMnemonic, "", ""); err == nil {
+		t.Error("ImportElectrumSeed() accepted a BIP39 mnemonic")
+	}
+}
+
+// decodeBroadcast decodes the only transaction broadcast to fb.
+func decodeBroadcast(t *testing.T, fb *fakeBackend) *wire.MsgTx {
+	t.Helper()
+	if len(fb.broadcast) != 1 {
+		t.Fatalf("broadcast %d transactions, want 1", len(fb.broadcast))
+	}
+	raw, _ := hex.DecodeString(fb.broadcast[0])
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	return tx
+}
+
+// verifyInputs checks that every input of tx spending fb's UTXOs verifies.
+func verifyInputs(t *testing.T, tx *wire.MsgTx, fb *fakeBackend, params *chain.Params) {
+	t.Helper()
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for addr, utxos := range fb.utxos {
+		script, _ := ParseAddressToScript(addr, params)
+		for _, u := range utxos {
+			hash, _ := chainhash.NewHashFromStr(u.TxID)
+			prevOuts.AddPrevOut(*wire.NewOutPoint(hash, u.Vout), wire.NewTxOut(int64(u.Amount), script))
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range tx.TxIn {
+		prev := prevOuts.FetchPrevOutput(in.PreviousOutPoint)
+		vm, err := txscript.NewEngine(prev.PkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prev.Value, prevOuts)
+		if err != nil {
+			t.Fatalf("NewEngine(%d) error = %v", i, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d does not verify: %v", i, err)
+		}
+	}
+}
+
+func TestSweepWIF(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	params, _ := chain.Get("BTC", chain.Testnet)
+
+	key, _ := btcec.NewPrivateKey()
+	wif, err := PrivateKeyToWIF(key, params)
+	if err != nil {
+		t.Fatalf("PrivateKeyToWIF() error = %v", err)
+	}
+	if decoded, err := WIFToPrivateKey(wif, params); err != nil || !decoded.Key.Equals(&key.Key) {
+		t.Fatalf("WIFToPrivateKey() round trip failed: %v", err)
+	}
+
+	if _, err := svc.SweepWIF(context.Background(), "BTC", wif); err == nil {
+		t.Error("SweepWIF() without coins should fail")
+	}
+
+	addrs, _ := AllAddressTypes(key.PubKey(), params)
+	txid := strings.Repeat("cd", 32)
+	fb.utxos[addrs[chain.AddressP2WPKH]] = []backend.UTXO{{TxID: txid, Vout: 0, Amount: 40000}}
+	fb.utxos[addrs[chain.AddressP2TR]] = []backend.UTXO{{TxID: txid, Vout: 1, Amount: 10000}}
+	fb.utxos[addrs[chain.AddressP2SH_P2WPKH]] = []backend.UTXO{{TxID: txid, Vout: 2, Amount: 5000}}
+
+	result, err := svc.SweepWIF(context.Background(), "BTC", wif)
+	if err != nil {
+		t.Fatalf("SweepWIF() error = %v", err)
+	}
+	want, _ := svc.GetAddressWithChange("BTC", 0, 0, 1)
+	if result.To != want || len(result.From) != 3 {
+		t.Errorf("SweepWIF() = %+v, want 3 addresses swept to %s", result, want)
+	}
+	if total := result.Amount.Uint64() + result.Fee.Uint64(); total != 55000 || result.Fee.Sign() <= 0 {
+		t.Errorf("swept %s with fee %s, want 55000 in total", result.Amount, result.Fee)
+	}
+	tx := decodeBroadcast(t, fb)
+	script, _ := ParseAddressToScript(want, params)
+	if len(tx.TxIn) != 3 || len(tx.TxOut) != 1 || !bytes.Equal(tx.TxOut[0].PkScript, script) {
+		t.Errorf("sweep tx has %d inputs and %d outputs", len(tx.TxIn), len(tx.TxOut))
+	}
+	verifyInputs(t, tx, fb, params)
+
+	mainnet, _ := chain.Get("BTC", chain.Mainnet)
+	mainnetWIF, _ := PrivateKeyToWIF(key, mainnet)
+	if _, err := svc.SweepWIF(context.Background(), "BTC", mainnetWIF); err == nil {
+		t.Error("SweepWIF() accepted a mainnet key on testnet")
+	}
+}
+
+func TestSweepWIFUncompressed(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	params, _ := chain.Get("BTC", chain.Testnet)
+
+	key, _ := btcec.NewPrivateKey()
+	wif, err := btcutil.NewWIF(key, toChainCfgParams(params), false)
+	if err != nil {
+		t.Fatalf("NewWIF() error = %v", err)
+	}
+	addr, _ := btcutil.NewAddressPubKeyHash(btcutil.Hash160(key.PubKey().SerializeUncompressed()), toChainCfgParams(params))
+	fb.utxos[addr.EncodeAddress()] = []backend.UTXO{{TxID: strings.Repeat("ab", 32), Vout: 0, Amount: 30000}}
+
+	result, err := svc.SweepWIF(context.Background(), "BTC", wif.String())
+	if err != nil {
+		t.Fatalf("SweepWIF() error = %v", err)
+	}
+	if len(result.From) != 1 || result.From[0] != addr.EncodeAddress() {
+		t.Errorf("SweepWIF() swept %v, want the uncompressed key's address", result.From)
+	}
+	// An uncompressed P2PKH input is 180 vbytes, at fakeBackend's 2 sat/vB
+	if wantFee := uint64(10+180+31+2) * 2; result.Fee.Uint64() != wantFee {
+		t.Errorf("fee = %s, want %d", result.Fee, wantFee)
+	}
+	tx := decodeBroadcast(t, fb)
+	verifyInputs(t, tx, fb, params)
+}
+
+func TestSweepDescriptor(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	params, _ := chain.Get("BTC", chain.Testnet)
+
+	info, err := svc.ImportElectrumSeed("BTC", electrumStandardSeed, "", "")
+	if err != nil {
+		t.Fatalf("ImportElectrumSeed() error = %v", err)
+	}
+	d, _ := ParseDescriptor(info.Descriptor)
+	recv, _ := d.DeriveAddress(0, 2, params)
+	fb.utxos[recv] = []backend.UTXO{{TxID: strings.Repeat("ef", 32), Vout: 0, Amount: 30000}}
+
+	result, err := svc.SweepDescriptor(context.Background(), info.ID)
+	if err != nil {
+		t.Fatalf("SweepDescriptor() error = %v", err)
+	}
+	want, _ := svc.GetAddressWithChange("BTC", 0, 0, 1)
+	if result.To != want || len(result.From) != 1 || result.From[0] != recv {
+		t.Errorf("SweepDescriptor() = %+v", result)
+	}
+	tx := decodeBroadcast(t, fb)
+	if len(tx.TxIn) != 1 || len(tx.TxOut) != 1 {
+		t.Errorf("sweep tx has %d inputs and %d outputs", len(tx.TxIn), len(tx.TxOut))
+	}
+	verifyInputs(t, tx, fb, params)
+}
+
+func TestNormalizeElectrumText(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"  Crème   Brûlée ", "creme brulee"},
+		{"が ぎ\u3000ぐ", "かきく"},                               // Dakuten dropped, no spaces between kana
+		{"漢 字 abc 한 국", "漢字 abc " + norm.NFKD.String("한국")}, // Hangul decomposes to Jamo
+		{"ａｂ ｃ", "ab c"},                                    // Fullwidth letters decompose to ASCII
+	}
+	for _, tt := range tests {
+		if got := normalizeElectrumText(tt.in); got != tt.want {
+			t.Errorf("normalizeElectrumText(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// testKeystoreJSON is go-ethereum's very-light-scrypt.json test key, with
+// an empty passphrase.
+var testKeystoreJSON = []byte(`{"address":"45dea0fb0bba44f4fcf290bba71fd57d7117cbb8","crypto":{"cipher":"aes-128-ctr","ciphertext":"b87781948a1befd247bff51ef4063f716cf6c2d3481163e9a8f42e1f9bb74145","cipherparams":{"iv":"dc4926b48a105133d2f16b96833abf1e"},"kdf":"scrypt","kdfparams":{"dklen":32,"n":2,"p":1,"r":8,"salt":"004244bbdc51cadda545b1cfa43cff9ed2ae88e08c61f1479dbb45410722f8f0"},"mac":"39990c1684557447940d4c69e06b1b82b2aceacb43f284df65c956daf3046b85"},"id":"ce541d8d-c79b-40f8-9f8c-20f59616faba","version":3}`)
+
+func TestDecryptKeystore(t *testing.T) {
+	keyJSON := testKeystoreJSON
+	key, err := decryptKeystore(keyJSON, "")
+	if err != nil {
+		t.Fatalf("decryptKeystore() error = %v", err)
+	}
+	if addr := PrivateKeyToEVMAddress(key); !strings.EqualFold(addr, "0x45dea0fb0bba44f4fcf290bba71fd57d7117cbb8") {
+		t.Errorf("keystore address = %s", addr)
+	}
+	if _, err := decryptKeystore(keyJSON, "wrong"); err == nil {
+		t.Error("decryptKeystore() accepted a wrong passphrase")
+	}
+
+	svc, _ := newDescriptorTestService(t)
+	if _, err := svc.SweepEVMKeystore(context.Background(), "BTC", keyJSON, ""); err == nil {
+		t.Error("SweepEVMKeystore() accepted a non-EVM chain")
+	}
+}
+
+// fakeEVMNode is an EVM JSON-RPC node holding one account's native and
+// ERC-20 balances, recording the transactions sent to it.
+type fakeEVMNode struct {
+	balance uint64 // Native, in wei
+	tokens  uint64
+	sent    []*types.Transaction
+}
+
+func (f *fakeEVMNode) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	json.NewDecoder(r.Body).Decode(&req)
+	var result string
+	switch req.Method {
+	case "eth_getBalance":
+		result = fmt.Sprintf("0x%x", f.balance)
+	case "eth_getTransactionCount":
+		result = "0x5"
+	case "eth_gasPrice":
+		result = "0x3b9aca00" // 1 gwei
+	case "eth_call":
+		result = fmt.Sprintf("0x%064x", f.tokens)
+	case "eth_estimateGas":
+		result = "0xea60" // 60000
+	case "eth_sendRawTransaction":
+		var raw string
+		json.Unmarshal(req.Params[0], &raw)
+		tx := new(types.Transaction)
+		if err := tx.UnmarshalBinary(common.FromHex(raw)); err == nil {
+			f.sent = append(f.sent, tx)
+			result = tx.Hash().Hex()
+		}
+	default:
+		result = "0x0"
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": req.ID, "result": result})
+}
+
+func TestSweepEVMKeystoreTokens(t *testing.T) {
+	svc, _ := newDescriptorTestService(t)
+	node := &fakeEVMNode{balance: 1e15, tokens: 2500000}
+	server := httptest.NewServer(node)
+	t.Cleanup(server.Close)
+	svc.backends.Register("ETH", backend.NewJSONRPCBackend(server.URL, backend.RPCTypeEVM, "", ""))
+	params, _ := chain.Get("ETH", chain.Testnet)
+	kgx := chain.GetToken(params.ChainID, "KGX")
+	if kgx == nil {
+		t.Fatal("no test token on the ETH testnet")
+	}
+
+	result, err := svc.SweepEVMKeystore(context.Background(), "ETH", testKeystoreJSON, "")
+	if err != nil {
+		t.Fatalf("SweepEVMKeystore() error = %v", err)
+	}
+	to, _ := svc.GetAddressWithChange("ETH", 0, 0, 0)
+	if len(node.sent) != 2 {
+		t.Fatalf("sent %d transactions, want a token and a native transfer", len(node.sent))
+	}
+
+	// The token transfer goes first, paid from the native balance
+	token, native := node.sent[0], node.sent[1]
+	if token.Nonce() != 5 || !strings.EqualFold(token.To().Hex(), kgx.Address) || token.Value().Sign() != 0 {
+		t.Errorf("token transfer: nonce %d to %s value %s", token.Nonce(), token.To().Hex(), token.Value())
+	}
+	want, _ := EncodeERC20Transfer(to, big.NewInt(2500000))
+	if !bytes.Equal(token.Data(), want) {
+		t.Errorf("token transfer data = %x, want %x", token.Data(), want)
+	}
+	if len(result.Tokens) != 1 || result.Tokens[0].Token != "KGX" || result.Tokens[0].Amount.Int64() != 2500000 || result.Tokens[0].TxID != token.Hash().Hex() {
+		t.Errorf("swept tokens = %+v", result.Tokens)
+	}
+
+	gas := big.NewInt((60000 + 21000) * 1e9)
+	wantAmount := new(big.Int).Sub(big.NewInt(1e15), gas)
+	if native.Nonce() != 6 || !strings.EqualFold(native.To().Hex(), to) || native.Value().Cmp(wantAmount) != 0 {
+		t.Errorf("native transfer: nonce %d to %s value %s, want %s", native.Nonce(), native.To().Hex(), native.Value(), wantAmount)
+	}
+	if result.TxID != native.Hash().Hex() || result.Amount.Cmp(wantAmount) != 0 || result.Fee.Cmp(gas) != 0 {
+		t.Errorf("SweepEVMKeystore() = %+v", result)
+	}
+
+	// Without the gas for the token transfer nothing is sent
+	node.balance, node.sent = 1e9, nil
+	if _, err := svc.SweepEVMKeystore(context.Background(), "ETH", testKeystoreJSON, ""); err == nil || len(node.sent) != 0 {
+		t.Errorf("SweepEVMKeystore() error = %v with %d transactions sent, want an error", err, len(node.sent))
+	}
+}
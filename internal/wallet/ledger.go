@@ -0,0 +1,344 @@
+// Package wallet - Ledger hardware wallets.
+//
+// APDUs are framed in 64 byte HID packets on channel 0x0101: a 5 byte
+// header (channel, tag 0x05, sequence number), then the APDU length in the
+// first packet and the APDU. Transactions are signed with the legacy
+// Bitcoin app commands for SegWit: the whole transaction is hashed once,
+// which shows it on the device for confirmation, then every input is
+// hashed alone with its script code and signed.
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// Bitcoin app instructions.
+const (
+	ledgerCLA                   = 0xe0
+	ledgerInsGetWalletPublicKey = 0x40
+	ledgerInsHashInputStart     = 0x44
+	ledgerInsHashSign           = 0x48
+	ledgerInsHashOutputFull     = 0x4a
+)
+
+// HID framing.
+const (
+	ledgerChannel     = 0x0101
+	ledgerTagAPDU     = 0x05
+	ledgerScriptBlock = 50 // Bytes of a script or of the outputs per APDU
+)
+
+// Status words.
+const (
+	ledgerSWOK            = 0x9000
+	ledgerSWDenied        = 0x6985
+	ledgerSWSecurity      = 0x6982
+	ledgerSWLocked        = 0x5515
+	ledgerSWInsNotFound   = 0x6d00
+	ledgerSWClaNotFound   = 0x6e00
+	ledgerSWAppNotRunning = 0x6511
+)
+
+// Address formats of GET WALLET PUBLIC KEY.
+const (
+	ledgerAddressLegacy = 0x00
+	ledgerAddressBech32 = 0x02
+)
+
+// ledger is a Ledger running the chain's Bitcoin app.
+type ledger struct {
+	info DeviceInfo
+	dev  io.ReadWriteCloser
+}
+
+func newLedger(info DeviceInfo, dev io.ReadWriteCloser) *ledger {
+	return &ledger{info: info, dev: dev}
+}
+
+func (l *ledger) Type() string {
+	return SignerLedger
+}
+
+func (l *ledger) Info() DeviceInfo {
+	return l.info
+}
+
+func (l *ledger) Close() error {
+	return l.dev.Close()
+}
+
+// PublicKey returns the extended public key at a derivation path.
+func (l *ledger) PublicKey(ctx context.Context, params *chain.Params, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	pubKey, _, chainCode, err := l.walletPublicKey(ctx, path, false, ledgerAddressLegacy)
+	if err != nil {
+		return nil, err
+	}
+	var parentFP uint32
+	if len(path) > 0 {
+		parent, _, _, err := l.walletPublicKey(ctx, path[:len(path)-1], false, ledgerAddressLegacy)
+		if err != nil {
+			return nil, err
+		}
+		parentFP = binary.BigEndian.Uint32(btcutil.Hash160(parent.SerializeCompressed())[:4])
+	}
+	return newExtendedPublicKey(params, pubKey.SerializeCompressed(), chainCode, parentFP, path)
+}
+
+// DisplayAddress shows the address of a derivation path on the device.
+func (l *ledger) DisplayAddress(ctx context.Context, params *chain.Params, path []uint32) (string, error) {
+	addrType, err := deviceAddressType(params)
+	if err != nil {
+		return "", err
+	}
+	format := byte(ledgerAddressLegacy)
+	if addrType == chain.AddressP2WPKH {
+		format = ledgerAddressBech32
+	}
+	_, address, _, err := l.walletPublicKey(ctx, path, true, format)
+	return address, err
+}
+
+// SignTx signs the p2wpkh inputs of a transaction.
+func (l *ledger) SignTx(ctx context.Context, req *SignRequest) error {
+	if len(req.UTXOs) != len(req.Tx.TxIn) {
+		return fmt.Errorf("%d UTXOs for %d inputs", len(req.UTXOs), len(req.Tx.TxIn))
+	}
+	pubKeys := make([]*btcec.PublicKey, len(req.UTXOs))
+	for i, utxo := range req.UTXOs {
+		if addrType := detectAddressType(utxo.Address, req.Params); addrType != "p2wpkh" {
+			return fmt.Errorf("ledger signs p2wpkh inputs only, input %d is %s", i, addrType)
+		}
+		pubKey, _, _, err := l.walletPublicKey(ctx, req.Params.DerivationPath(utxo.Account, utxo.Change, utxo.AddressIndex), false, ledgerAddressBech32)
+		if err != nil {
+			return err
+		}
+		pubKeys[i] = pubKey
+	}
+
+	// The whole transaction, confirmed on the device
+	all := make([]int, len(req.Tx.TxIn))
+	for i := range all {
+		all[i] = i
+	}
+	if err := l.hashInputs(ctx, req, all, nil, true); err != nil {
+		return err
+	}
+	var outputs bytes.Buffer
+	if err := wire.WriteVarInt(&outputs, 0, uint64(len(req.Tx.TxOut))); err != nil {
+		return err
+	}
+	for _, out := range req.Tx.TxOut {
+		if err := wire.WriteTxOut(&outputs, 0, req.Tx.Version, out); err != nil {
+			return err
+		}
+	}
+	for data := outputs.Bytes(); len(data) > 0; {
+		n := min(len(data), ledgerScriptBlock)
+		p1 := byte(0x00)
+		if n == len(data) {
+			p1 = 0x80 // Last block
+		}
+		if _, err := l.exchange(ctx, ledgerInsHashOutputFull, p1, 0x00, data[:n]); err != nil {
+			return err
+		}
+		data = data[n:]
+	}
+
+	// Each input with its script code
+	for i, utxo := range req.UTXOs {
+		scriptCode, err := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(pubKeys[i].SerializeCompressed())).
+			AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).
+			Script()
+		if err != nil {
+			return err
+		}
+		if err := l.hashInputs(ctx, req, []int{i}, scriptCode, false); err != nil {
+			return err
+		}
+
+		data := ledgerPath(req.Params.DerivationPath(utxo.Account, utxo.Change, utxo.AddressIndex))
+		data = append(data, 0x00) // No user validation code
+		data = binary.BigEndian.AppendUint32(data, req.Tx.LockTime)
+		data = append(data, byte(txscript.SigHashAll))
+		sig, err := l.exchange(ctx, ledgerInsHashSign, 0x00, 0x00, data)
+		if err != nil {
+			return err
+		}
+		if len(sig) < 2 {
+			return fmt.Errorf("ledger returned an empty signature for input %d", i)
+		}
+		// The first byte flags the parity of R; the last is the sighash type
+		sig[0] = 0x30
+		if err := setInputSignature(req, i, sig[:len(sig)-1], pubKeys[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashInputs sends inputs of the transaction being signed with their
+// amounts: all of them to start a new transaction, or one with its script
+// code to sign it.
+func (l *ledger) hashInputs(ctx context.Context, req *SignRequest, inputs []int, scriptCode []byte, newTx bool) error {
+	p2 := byte(0x80) // Continue the transaction
+	if newTx {
+		p2 = 0x02 // New SegWit transaction
+	}
+	var header bytes.Buffer
+	binary.Write(&header, binary.LittleEndian, req.Tx.Version)
+	wire.WriteVarInt(&header, 0, uint64(len(inputs)))
+	if _, err := l.exchange(ctx, ledgerInsHashInputStart, 0x00, p2, header.Bytes()); err != nil {
+		return err
+	}
+
+	for _, i := range inputs {
+		in := req.Tx.TxIn[i]
+		var data bytes.Buffer
+		data.WriteByte(0x02) // SegWit input: outpoint and amount
+		data.Write(in.PreviousOutPoint.Hash[:])
+		binary.Write(&data, binary.LittleEndian, in.PreviousOutPoint.Index)
+		binary.Write(&data, binary.LittleEndian, req.UTXOs[i].Amount)
+		wire.WriteVarInt(&data, 0, uint64(len(scriptCode)))
+		if _, err := l.exchange(ctx, ledgerInsHashInputStart, 0x80, p2, data.Bytes()); err != nil {
+			return err
+		}
+
+		script := binary.LittleEndian.AppendUint32(append([]byte(nil), scriptCode...), in.Sequence)
+		for len(script) > 0 {
+			// The sequence goes with the last block of the script
+			n := min(len(script), ledgerScriptBlock)
+			if len(script)-n < 4 {
+				n = len(script)
+			}
+			if _, err := l.exchange(ctx, ledgerInsHashInputStart, 0x80, p2, script[:n]); err != nil {
+				return err
+			}
+			script = script[n:]
+		}
+	}
+	return nil
+}
+
+// walletPublicKey returns the public key, address and chain code of a
+// derivation path, showing the address on the device if display is set.
+func (l *ledger) walletPublicKey(ctx context.Context, path []uint32, display bool, format byte) (*btcec.PublicKey, string, []byte, error) {
+	p1 := byte(0x00)
+	if display {
+		p1 = 0x01
+	}
+	resp, err := l.exchange(ctx, ledgerInsGetWalletPublicKey, p1, format, ledgerPath(path))
+	if err != nil {
+		return nil, "", nil, err
+	}
+
+	// Public key, address and chain code, the first two length-prefixed
+	if len(resp) < 1 || len(resp) < 1+int(resp[0])+1 {
+		return nil, "", nil, fmt.Errorf("ledger returned a short public key response")
+	}
+	pubKey, err := btcec.ParsePubKey(resp[1 : 1+resp[0]])
+	if err != nil {
+		return nil, "", nil, fmt.Errorf("ledger returned an invalid public key: %w", err)
+	}
+	resp = resp[1+resp[0]:]
+	if len(resp) != 1+int(resp[0])+32 {
+		return nil, "", nil, fmt.Errorf("ledger returned a malformed public key response")
+	}
+	return pubKey, string(resp[1 : 1+resp[0]]), resp[1+resp[0]:], nil
+}
+
+// exchange sends an APDU and returns the response data.
+func (l *ledger) exchange(ctx context.Context, ins, p1, p2 byte, data []byte) ([]byte, error) {
+	if len(data) > 255 {
+		return nil, fmt.Errorf("ledger APDU data too long: %d bytes", len(data))
+	}
+	stop := watchContext(ctx, l.dev)
+	defer stop()
+
+	apdu := append([]byte{ledgerCLA, ins, p1, p2, byte(len(data))}, data...)
+	resp, err := l.roundTrip(apdu)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("ledger: %w", err)
+	}
+	if len(resp) < 2 {
+		return nil, fmt.Errorf("ledger returned a %d byte response", len(resp))
+	}
+
+	switch sw := binary.BigEndian.Uint16(resp[len(resp)-2:]); sw {
+	case ledgerSWOK:
+		return resp[:len(resp)-2], nil
+	case ledgerSWDenied:
+		return nil, ErrDeviceRejected
+	case ledgerSWLocked, ledgerSWSecurity:
+		return nil, ErrDeviceLocked
+	case ledgerSWInsNotFound, ledgerSWClaNotFound, ledgerSWAppNotRunning:
+		return nil, fmt.Errorf("open the chain's app on the ledger (status %04x)", sw)
+	default:
+		return nil, fmt.Errorf("ledger returned status %04x", sw)
+	}
+}
+
+// roundTrip writes an APDU in HID packets and reads the response.
+func (l *ledger) roundTrip(apdu []byte) ([]byte, error) {
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(apdu)))
+	data = append(data, apdu...)
+	for seq := uint16(0); len(data) > 0; seq++ {
+		packet := make([]byte, hidPacketSize)
+		binary.BigEndian.PutUint16(packet, ledgerChannel)
+		packet[2] = ledgerTagAPDU
+		binary.BigEndian.PutUint16(packet[3:], seq)
+		data = data[copy(packet[5:], data):]
+		if _, err := l.dev.Write(packet); err != nil {
+			return nil, err
+		}
+	}
+
+	var resp []byte
+	size := -1
+	for seq := uint16(0); size < 0 || len(resp) < size; seq++ {
+		packet := make([]byte, hidPacketSize)
+		n, err := l.dev.Read(packet)
+		if err != nil {
+			return nil, err
+		}
+		packet = packet[:n]
+		if n < 5 || binary.BigEndian.Uint16(packet) != ledgerChannel || packet[2] != ledgerTagAPDU || binary.BigEndian.Uint16(packet[3:]) != seq {
+			return nil, fmt.Errorf("unexpected HID packet")
+		}
+		payload := packet[5:]
+		if seq == 0 {
+			if len(payload) < 2 {
+				return nil, fmt.Errorf("unexpected HID packet")
+			}
+			size = int(binary.BigEndian.Uint16(payload))
+			payload = payload[2:]
+		}
+		resp = append(resp, payload...)
+	}
+	return resp[:size], nil
+}
+
+// ledgerPath encodes a derivation path: its length, then big-endian steps.
+func ledgerPath(path []uint32) []byte {
+	data := []byte{byte(len(path))}
+	for _, n := range path {
+		data = binary.BigEndian.AppendUint32(data, n)
+	}
+	return data
+}
@@ -0,0 +1,308 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// fakeLedger emulates the Bitcoin app of a Ledger over HID, with the keys
+// of testDeviceMaster. It rebuilds the transaction from the hashing
+// commands and signs it as the device would.
+type fakeLedger struct {
+	master *hdkeychain.ExtendedKey
+	params *chain.Params
+	status uint16 // Returned for every APDU if set
+	reject bool   // Deny the transaction
+
+	apdu    []byte
+	size    int
+	packets [][]byte
+	display bool // An address was shown
+
+	tx       *wire.MsgTx
+	amounts  map[wire.OutPoint]int64
+	newTx    bool
+	outputs  []byte
+	input    *wire.TxIn
+	script   []byte
+	scriptSz int
+	signing  wire.OutPoint
+	code     []byte
+}
+
+func newFakeLedger(t *testing.T, params *chain.Params) *fakeLedger {
+	return &fakeLedger{master: testDeviceMaster(t), params: params}
+}
+
+func (f *fakeLedger) Write(p []byte) (int, error) {
+	if len(p) != hidPacketSize || binary.BigEndian.Uint16(p) != ledgerChannel || p[2] != ledgerTagAPDU {
+		return 0, errors.New("bad packet")
+	}
+	if binary.BigEndian.Uint16(p[3:]) == 0 {
+		f.size = int(binary.BigEndian.Uint16(p[5:]))
+		f.apdu = append([]byte(nil), p[7:]...)
+	} else {
+		f.apdu = append(f.apdu, p[5:]...)
+	}
+	if len(f.apdu) >= f.size {
+		f.respond(f.handle(f.apdu[:f.size]))
+	}
+	return len(p), nil
+}
+
+func (f *fakeLedger) Read(p []byte) (int, error) {
+	if len(f.packets) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, f.packets[0])
+	f.packets = f.packets[1:]
+	return n, nil
+}
+
+func (f *fakeLedger) Close() error {
+	return nil
+}
+
+// respond queues a response in HID packets.
+func (f *fakeLedger) respond(resp []byte) {
+	data := binary.BigEndian.AppendUint16(nil, uint16(len(resp)))
+	data = append(data, resp...)
+	for seq := uint16(0); len(data) > 0; seq++ {
+		packet := make([]byte, hidPacketSize)
+		binary.BigEndian.PutUint16(packet, ledgerChannel)
+		packet[2] = ledgerTagAPDU
+		binary.BigEndian.PutUint16(packet[3:], seq)
+		data = data[copy(packet[5:], data):]
+		f.packets = append(f.packets, packet)
+	}
+}
+
+// handle executes an APDU and returns the response with its status word.
+func (f *fakeLedger) handle(apdu []byte) []byte {
+	sw := func(data []byte, sw uint16) []byte {
+		return binary.BigEndian.AppendUint16(data, sw)
+	}
+	if f.status != 0 {
+		return sw(nil, f.status)
+	}
+	if len(apdu) < 5 || apdu[0] != ledgerCLA || int(apdu[4]) != len(apdu)-5 {
+		return sw(nil, 0x6700)
+	}
+	ins, p1, p2, data := apdu[1], apdu[2], apdu[3], apdu[5:]
+
+	switch ins {
+	case ledgerInsGetWalletPublicKey:
+		key, err := derivePath(f.master, readLedgerPath(data))
+		if err != nil {
+			return sw(nil, 0x6a80)
+		}
+		pubKey, _ := key.ECPubKey()
+		var addr string
+		if p2 == ledgerAddressBech32 {
+			addr, _ = deriveP2WPKH(pubKey, toChainCfgParams(f.params))
+		} else {
+			addr, _ = deriveP2PKH(pubKey, toChainCfgParams(f.params))
+		}
+		f.display = f.display || p1 == 0x01
+		resp := append([]byte{65}, pubKey.SerializeUncompressed()...)
+		resp = append(append(resp, byte(len(addr))), addr...)
+		return sw(append(resp, key.ChainCode()...), ledgerSWOK)
+
+	case ledgerInsHashInputStart:
+		r := bytes.NewReader(data)
+		switch {
+		case p1 == 0x00:
+			var version int32
+			binary.Read(r, binary.LittleEndian, &version)
+			f.newTx = p2 == 0x02
+			if f.newTx {
+				f.tx = wire.NewMsgTx(version)
+				f.amounts = make(map[wire.OutPoint]int64)
+			}
+		case f.input == nil:
+			// Input: outpoint, amount and script length
+			var op wire.OutPoint
+			var amount int64
+			r.ReadByte()
+			io.ReadFull(r, op.Hash[:])
+			binary.Read(r, binary.LittleEndian, &op.Index)
+			binary.Read(r, binary.LittleEndian, &amount)
+			n, _ := wire.ReadVarInt(r, 0)
+			f.input = wire.NewTxIn(&op, nil, nil)
+			f.amounts[op] = amount
+			f.script, f.scriptSz = nil, int(n)+4
+		default:
+			// Script blocks, then the sequence
+			f.script = append(f.script, data...)
+			if len(f.script) < f.scriptSz {
+				break
+			}
+			f.input.Sequence = binary.LittleEndian.Uint32(f.script[len(f.script)-4:])
+			if f.newTx {
+				f.tx.AddTxIn(f.input)
+			} else {
+				f.signing, f.code = f.input.PreviousOutPoint, f.script[:len(f.script)-4]
+			}
+			f.input = nil
+		}
+		return sw(nil, ledgerSWOK)
+
+	case ledgerInsHashOutputFull:
+		f.outputs = append(f.outputs, data...)
+		if p1 != 0x80 {
+			return sw(nil, ledgerSWOK)
+		}
+		r := bytes.NewReader(f.outputs)
+		n, _ := wire.ReadVarInt(r, 0)
+		for i := uint64(0); i < n; i++ {
+			var value int64
+			binary.Read(r, binary.LittleEndian, &value)
+			script, _ := wire.ReadVarBytes(r, 0, 10000, "script")
+			f.tx.AddTxOut(wire.NewTxOut(value, script))
+		}
+		f.outputs = nil
+		if f.reject {
+			return sw(nil, ledgerSWDenied)
+		}
+		return sw([]byte{0x00, 0x00}, ledgerSWOK)
+
+	case ledgerInsHashSign:
+		path := readLedgerPath(data)
+		rest := data[1+4*len(path):]
+		if rest[0] != 0x00 || rest[5] != byte(txscript.SigHashAll) {
+			return sw(nil, 0x6a80)
+		}
+		f.tx.LockTime = binary.BigEndian.Uint32(rest[1:])
+
+		key, err := derivePath(f.master, path)
+		if err != nil {
+			return sw(nil, 0x6a80)
+		}
+		privKey, _ := key.ECPrivKey()
+		if !bytes.Equal(f.code[3:23], btcutil.Hash160(privKey.PubKey().SerializeCompressed())) {
+			return sw(nil, 0x6a80)
+		}
+		prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+		idx := -1
+		for i, in := range f.tx.TxIn {
+			prevOuts[in.PreviousOutPoint] = wire.NewTxOut(f.amounts[in.PreviousOutPoint], make([]byte, 22))
+			if in.PreviousOutPoint == f.signing {
+				idx = i
+			}
+		}
+		sigHashes := txscript.NewTxSigHashes(f.tx, txscript.NewMultiPrevOutFetcher(prevOuts))
+		hash, err := txscript.CalcWitnessSigHash(f.code, sigHashes, txscript.SigHashAll, f.tx, idx, f.amounts[f.signing])
+		if err != nil {
+			return sw(nil, 0x6a80)
+		}
+		sig := ecdsa.Sign(privKey, hash).Serialize()
+		sig[0] |= 0x01 // Parity flag
+		return sw(append(sig, byte(txscript.SigHashAll)), ledgerSWOK)
+	}
+	return sw(nil, ledgerSWInsNotFound)
+}
+
+func readLedgerPath(data []byte) []uint32 {
+	path := make([]uint32, data[0])
+	for i := range path {
+		path[i] = binary.BigEndian.Uint32(data[1+4*i:])
+	}
+	return path
+}
+
+func TestLedgerSignTx(t *testing.T) {
+	params, _ := chain.Get("BTC", chain.Testnet)
+	fake := newFakeLedger(t, params)
+	l := newLedger(DeviceInfo{Type: SignerLedger}, fake)
+	if l.Type() != SignerLedger {
+		t.Errorf("Type() = %s, want %s", l.Type(), SignerLedger)
+	}
+
+	req := testSpend(t, params, fake.master, "p2wpkh", "p2wpkh", "p2wpkh")
+	if err := l.SignTx(context.Background(), req); err != nil {
+		t.Fatalf("SignTx() error = %v", err)
+	}
+	if fake.tx.TxHash() != req.Tx.TxHash() {
+		t.Errorf("device hashed transaction %s, want %s", fake.tx.TxHash(), req.Tx.TxHash())
+	}
+	verifySigned(t, req)
+
+	fake.reject = true
+	if err := l.SignTx(context.Background(), testSpend(t, params, fake.master, "p2wpkh")); !errors.Is(err, ErrDeviceRejected) {
+		t.Errorf("SignTx() rejected error = %v, want ErrDeviceRejected", err)
+	}
+	if err := l.SignTx(context.Background(), testSpend(t, params, fake.master, "p2pkh")); err == nil {
+		t.Error("SignTx() of a p2pkh input should fail")
+	}
+}
+
+func TestLedgerPublicKey(t *testing.T) {
+	params, _ := chain.Get("BTC", chain.Testnet)
+	fake := newFakeLedger(t, params)
+	l := newLedger(DeviceInfo{Type: SignerLedger}, fake)
+	ctx := context.Background()
+
+	path := accountPath(params, 0)
+	key, err := l.PublicKey(ctx, params, path)
+	if err != nil {
+		t.Fatalf("PublicKey() error = %v", err)
+	}
+	want, _ := derivePath(fake.master, path)
+	want, _ = want.Neuter()
+	want, _ = want.CloneWithVersion(params.HDPublicKeyID[:])
+	if key.String() != want.String() {
+		t.Errorf("PublicKey() = %s, want %s", key, want)
+	}
+
+	addressPath := params.DerivationPath(0, 0, 3)
+	addr, err := l.DisplayAddress(ctx, params, addressPath)
+	if err != nil {
+		t.Fatalf("DisplayAddress() error = %v", err)
+	}
+	addrKey, _ := derivePath(fake.master, addressPath)
+	if wantAddr, _ := DeriveAddressFromKey(addrKey, params); addr != wantAddr || !fake.display {
+		t.Errorf("DisplayAddress() = %s (shown %v), want %s shown", addr, fake.display, wantAddr)
+	}
+
+	for sw, want := range map[uint16]error{ledgerSWLocked: ErrDeviceLocked, ledgerSWDenied: ErrDeviceRejected} {
+		fake.status = sw
+		if _, err := l.PublicKey(ctx, params, path); !errors.Is(err, want) {
+			t.Errorf("status %04x: error = %v, want %v", sw, err, want)
+		}
+	}
+	fake.status = ledgerSWClaNotFound
+	if _, err := l.PublicKey(ctx, params, path); err == nil {
+		t.Error("PublicKey() with the app closed should fail")
+	}
+}
+
+func TestDeviceModel(t *testing.T) {
+	tests := []struct {
+		vendorID, productID uint16
+		wantType, wantModel string
+	}{
+		{0x2c97, 0x5011, SignerLedger, "Nano S Plus"},
+		{0x2c97, 0x0001, SignerLedger, "Nano S"},
+		{0x2c97, 0x4015, SignerLedger, "Nano X"},
+		{0x534c, 0x0001, SignerTrezor, "Trezor One"},
+		{0x1209, 0x53c1, "", ""},
+	}
+	for _, tt := range tests {
+		gotType, gotModel := deviceModel(tt.vendorID, tt.productID)
+		if gotType != tt.wantType || gotModel != tt.wantModel {
+			t.Errorf("deviceModel(%04x, %04x) = %q, %q, want %q, %q", tt.vendorID, tt.productID, gotType, gotModel, tt.wantType, tt.wantModel)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package wallet - Locking of sensitive buffers in memory.
+//
+// Derived encryption keys, decrypted mnemonics and seeds live in short-lived
+// buffers. Protect keeps such a buffer out of swap while it's in use, when
+// memory locking is enabled and the platform supports it, and zeroes it on
+// release either way.
+package wallet
+
+import (
+	"sync/atomic"
+)
+
+var (
+	memoryLocking atomic.Bool
+	lockedBytes   atomic.Int64
+	lockFailures  atomic.Int64
+)
+
+// MemoryLockStats reports the state of sensitive buffer locking.
+type MemoryLockStats struct {
+	Enabled     bool  `json:"enabled"`
+	Supported   bool  `json:"supported"`
+	LockedBytes int64 `json:"locked_bytes"` // Currently locked
+	Failures    int64 `json:"failures"`     // Buffers that couldn't be locked
+}
+
+// SetMemoryLocking enables or disables locking of sensitive buffers. It's
+// off by default: locking fails without CAP_IPC_LOCK or a large enough
+// RLIMIT_MEMLOCK.
+func SetMemoryLocking(enabled bool) {
+	memoryLocking.Store(enabled)
+}
+
+// MemoryLocking returns the state of sensitive buffer locking.
+func MemoryLocking() MemoryLockStats {
+	return MemoryLockStats{
+		Enabled:     memoryLocking.Load(),
+		Supported:   memlockSupported,
+		LockedBytes: lockedBytes.Load(),
+		Failures:    lockFailures.Load(),
+	}
+}
+
+// Protect locks buf in memory if memory locking is enabled. The returned
+// release zeroes buf and unlocks it; call it once buf is no longer needed.
+// A buffer that can't be locked is still zeroed on release.
+func Protect(buf []byte) (release func()) {
+	locked := false
+	if len(buf) > 0 && memoryLocking.Load() {
+		if err := mlock(buf); err != nil {
+			lockFailures.Add(1)
+		} else {
+			locked = true
+			lockedBytes.Add(int64(len(buf)))
+		}
+	}
+	return func() {
+		SecureClear(buf)
+		if locked {
+			_ = munlock(buf)
+			lockedBytes.Add(-int64(len(buf)))
+		}
+	}
+}
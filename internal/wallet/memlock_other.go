@@ -0,0 +1,15 @@
+//go:build !unix
+
+package wallet
+
+import "errors"
+
+const memlockSupported = false
+
+func mlock(buf []byte) error {
+	return errors.New("memory locking not supported on this platform")
+}
+
+func munlock(buf []byte) error {
+	return nil
+}
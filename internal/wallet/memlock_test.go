@@ -0,0 +1,43 @@
+package wallet
+
+import "testing"
+
+func TestProtect(t *testing.T) {
+	// Disabled: zeroed on release, never locked
+	buf := []byte{1, 2, 3}
+	Protect(buf)()
+	for _, b := range buf {
+		if b != 0 {
+			t.Fatalf("buffer not zeroed: %v", buf)
+		}
+	}
+
+	SetMemoryLocking(true)
+	defer SetMemoryLocking(false)
+	before := MemoryLocking()
+	if !before.Enabled {
+		t.Fatal("memory locking not enabled")
+	}
+
+	buf = []byte{4, 5, 6, 7}
+	release := Protect(buf)
+	during := MemoryLocking()
+	locked := during.LockedBytes - before.LockedBytes
+	failed := during.Failures - before.Failures
+	// Locking may be refused by RLIMIT_MEMLOCK; either way it's accounted for
+	if before.Supported && locked+failed*int64(len(buf)) != int64(len(buf)) {
+		t.Errorf("locked %d bytes, %d failures, want the buffer locked or failed", locked, failed)
+	}
+	if !before.Supported && failed != 1 {
+		t.Errorf("unsupported platform: %d failures, want 1", failed)
+	}
+	release()
+	if got := MemoryLocking().LockedBytes; got != before.LockedBytes {
+		t.Errorf("locked bytes after release = %d, want %d", got, before.LockedBytes)
+	}
+	for _, b := range buf {
+		if b != 0 {
+			t.Fatalf("buffer not zeroed: %v", buf)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+//go:build unix
+
+package wallet
+
+import "golang.org/x/sys/unix"
+
+const memlockSupported = true
+
+func mlock(buf []byte) error {
+	return unix.Mlock(buf)
+}
+
+func munlock(buf []byte) error {
+	return unix.Munlock(buf)
+}
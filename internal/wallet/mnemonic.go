@@ -0,0 +1,207 @@
+// Package wallet - BIP39 mnemonics in every standard wordlist.
+//
+// go-bip39 keeps a single global wordlist, so encoding and decoding are done
+// here against the per-language lists instead. Seeds follow BIP39 exactly:
+// PBKDF2-SHA512 over the NFKD-normalized mnemonic and passphrase, which is
+// byte-identical to go-bip39 for English mnemonics.
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/sha512"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/tyler-smith/go-bip39/wordlists"
+	"golang.org/x/crypto/pbkdf2"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Language is a BIP39 wordlist.
+type Language string
+
+// Supported BIP39 wordlists.
+const (
+	LanguageEnglish            Language = "english"
+	LanguageJapanese           Language = "japanese"
+	LanguageKorean             Language = "korean"
+	LanguageSpanish            Language = "spanish"
+	LanguageChineseSimplified  Language = "chinese_simplified"
+	LanguageChineseTraditional Language = "chinese_traditional"
+	LanguageFrench             Language = "french"
+	LanguageItalian            Language = "italian"
+	LanguageCzech              Language = "czech"
+)
+
+// ErrUnsupportedLanguage is returned for a wordlist that isn't known.
+var ErrUnsupportedLanguage = errors.New("unsupported mnemonic language")
+
+// ErrInvalidMnemonic is returned when a mnemonic doesn't decode in any
+// wordlist or its checksum doesn't match.
+var ErrInvalidMnemonic = errors.New("invalid mnemonic")
+
+// languages lists the wordlists in detection order. English comes first so
+// existing wallets always resolve the same way.
+var languages = []Language{
+	LanguageEnglish,
+	LanguageJapanese,
+	LanguageKorean,
+	LanguageSpanish,
+	LanguageChineseSimplified,
+	LanguageChineseTraditional,
+	LanguageFrench,
+	LanguageItalian,
+	LanguageCzech,
+}
+
+// wordlist holds a BIP39 list and its NFKD-normalized reverse index.
+type wordlist struct {
+	words []string
+	index map[string]int
+}
+
+var bip39Wordlists = map[Language]*wordlist{
+	LanguageEnglish:            newWordlist(wordlists.English),
+	LanguageJapanese:           newWordlist(wordlists.Japanese),
+	LanguageKorean:             newWordlist(wordlists.Korean),
+	LanguageSpanish:            newWordlist(wordlists.Spanish),
+	LanguageChineseSimplified:  newWordlist(wordlists.ChineseSimplified),
+	LanguageChineseTraditional: newWordlist(wordlists.ChineseTraditional),
+	LanguageFrench:             newWordlist(wordlists.French),
+	LanguageItalian:            newWordlist(wordlists.Italian),
+	LanguageCzech:              newWordlist(wordlists.Czech),
+}
+
+func newWordlist(words []string) *wordlist {
+	index := make(map[string]int, len(words))
+	for i, w := range words {
+		index[norm.NFKD.String(w)] = i
+	}
+	return &wordlist{words: words, index: index}
+}
+
+// SupportedLanguages returns the BIP39 wordlists mnemonics can be generated in.
+func SupportedLanguages() []Language {
+	return append([]Language(nil), languages...)
+}
+
+// ParseLanguage returns the language for a name; empty means English.
+func ParseLanguage(name string) (Language, error) {
+	if name == "" {
+		return LanguageEnglish, nil
+	}
+	lang := Language(strings.ToLower(name))
+	if _, ok := bip39Wordlists[lang]; !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedLanguage, name)
+	}
+	return lang, nil
+}
+
+// GenerateMnemonicInLanguage generates a new 24-word BIP39 mnemonic from the
+// given wordlist.
+func GenerateMnemonicInLanguage(lang Language) (string, error) {
+	entropy := make([]byte, 32) // 256 bits = 24 words
+	if _, err := rand.Read(entropy); err != nil {
+		return "", fmt.Errorf("failed to generate entropy: %w", err)
+	}
+	defer SecureClear(entropy)
+	return EntropyToMnemonic(entropy, lang)
+}
+
+// EntropyToMnemonic encodes 128-256 bits of entropy as a mnemonic. Japanese
+// mnemonics are joined with an ideographic space as BIP39 specifies.
+func EntropyToMnemonic(entropy []byte, lang Language) (string, error) {
+	list, ok := bip39Wordlists[lang]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedLanguage, lang)
+	}
+	if len(entropy) < 16 || len(entropy) > 32 || len(entropy)%4 != 0 {
+		return "", fmt.Errorf("entropy must be 16-32 bytes in steps of 4, got %d", len(entropy))
+	}
+
+	// Entropy bits followed by the first ENT/32 bits of its SHA-256
+	checksumBits := uint(len(entropy) / 4)
+	hash := sha256.Sum256(entropy)
+	bits := new(big.Int).SetBytes(entropy)
+	bits.Lsh(bits, checksumBits)
+	bits.Or(bits, big.NewInt(int64(hash[0]>>(8-checksumBits))))
+
+	count := (len(entropy)*8 + int(checksumBits)) / 11
+	words := make([]string, count)
+	mask := big.NewInt(2047)
+	for i := count - 1; i >= 0; i-- {
+		idx := new(big.Int).And(bits, mask).Int64()
+		words[i] = list.words[idx]
+		bits.Rsh(bits, 11)
+	}
+
+	sep := " "
+	if lang == LanguageJapanese {
+		sep = "　"
+	}
+	return strings.Join(words, sep), nil
+}
+
+// MnemonicToEntropy decodes a mnemonic in any supported wordlist, checking
+// its checksum, and returns the entropy and the wordlist it was found in.
+func MnemonicToEntropy(mnemonic string) ([]byte, Language, error) {
+	words := strings.Fields(norm.NFKD.String(mnemonic))
+	switch len(words) {
+	case 12, 15, 18, 21, 24:
+	default:
+		return nil, "", fmt.Errorf("%w: %d words", ErrInvalidMnemonic, len(words))
+	}
+
+	for _, lang := range languages {
+		entropy, ok := decodeMnemonic(words, bip39Wordlists[lang])
+		if ok {
+			return entropy, lang, nil
+		}
+	}
+	return nil, "", ErrInvalidMnemonic
+}
+
+// decodeMnemonic decodes words in one wordlist; ok is false if a word is
+// missing or the checksum doesn't match.
+func decodeMnemonic(words []string, list *wordlist) ([]byte, bool) {
+	bits := new(big.Int)
+	for _, w := range words {
+		idx, ok := list.index[w]
+		if !ok {
+			return nil, false
+		}
+		bits.Lsh(bits, 11)
+		bits.Or(bits, big.NewInt(int64(idx)))
+	}
+
+	checksumBits := uint(len(words) * 11 / 33)
+	checksum := new(big.Int).And(bits, big.NewInt(int64(1)<<checksumBits-1)).Int64()
+	bits.Rsh(bits, checksumBits)
+
+	entropy := bits.FillBytes(make([]byte, (len(words)*11-int(checksumBits))/8))
+	hash := sha256.Sum256(entropy)
+	if int64(hash[0]>>(8-checksumBits)) != checksum {
+		return nil, false
+	}
+	return entropy, true
+}
+
+// MnemonicLanguage returns the wordlist of a valid mnemonic.
+func MnemonicLanguage(mnemonic string) (Language, error) {
+	entropy, lang, err := MnemonicToEntropy(mnemonic)
+	if err != nil {
+		return "", err
+	}
+	SecureClear(entropy)
+	return lang, nil
+}
+
+// mnemonicSeed derives the 64-byte BIP39 seed of a mnemonic.
+func mnemonicSeed(mnemonic, passphrase string) []byte {
+	password := []byte(norm.NFKD.String(mnemonic))
+	salt := []byte("mnemonic" + norm.NFKD.String(passphrase))
+	return pbkdf2.Key(password, salt, 2048, 64, sha512.New)
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/tyler-smith/go-bip39"
+	"github.com/tyler-smith/go-bip39/wordlists"
+)
+
+func TestGenerateMnemonicInLanguage(t *testing.T) {
+	for _, lang := range SupportedLanguages() {
+		t.Run(string(lang), func(t *testing.T) {
+			mnemonic, err := GenerateMnemonicInLanguage(lang)
+			if err != nil {
+				t.Fatalf("GenerateMnemonicInLanguage() error = %v", err)
+			}
+			if words := strings.Fields(mnemonic); len(words) != 24 {
+				t.Errorf("expected 24 words, got %d", len(words))
+			}
+			got, err := MnemonicLanguage(mnemonic)
+			if err != nil {
+				t.Fatalf("MnemonicLanguage() error = %v", err)
+			}
+			if got != lang {
+				t.Errorf("MnemonicLanguage() = %s, want %s", got, lang)
+			}
+			if !ValidateMnemonic(mnemonic) {
+				t.Error("generated mnemonic should be valid")
+			}
+		})
+	}
+
+	if _, err := GenerateMnemonicInLanguage("klingon"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("unknown language error = %v, want ErrUnsupportedLanguage", err)
+	}
+}
+
+// TestEntropyToMnemonicMatchesBIP39 checks the encoder against go-bip39.
+func TestEntropyToMnemonicMatchesBIP39(t *testing.T) {
+	defer bip39.SetWordList(wordlists.English)
+
+	entropy, _ := hex.DecodeString("9e885d952ad362caeb4efe34a8e91bd2")
+	for lang, list := range map[Language][]string{
+		LanguageEnglish: wordlists.English,
+		LanguageSpanish: wordlists.Spanish,
+		LanguageFrench:  wordlists.French,
+		LanguageKorean:  wordlists.Korean,
+	} {
+		bip39.SetWordList(list)
+		want, err := bip39.NewMnemonic(entropy)
+		if err != nil {
+			t.Fatalf("bip39.NewMnemonic() error = %v", err)
+		}
+		got, err := EntropyToMnemonic(entropy, lang)
+		if err != nil {
+			t.Fatalf("EntropyToMnemonic(%s) error = %v", lang, err)
+		}
+		if got != want {
+			t.Errorf("EntropyToMnemonic(%s) = %q, want %q", lang, got, want)
+		}
+
+		decoded, gotLang, err := MnemonicToEntropy(got)
+		if err != nil {
+			t.Fatalf("MnemonicToEntropy(%s) error = %v", lang, err)
+		}
+		if !bytes.Equal(decoded, entropy) || gotLang != lang {
+			t.Errorf("MnemonicToEntropy(%s) = %x, %s", lang, decoded, gotLang)
+		}
+	}
+}
+
+func TestMnemonicSeed(t *testing.T) {
+	// English seeds are unchanged from go-bip39
+	if got, want := mnemonicSeed(testMnemonic, "TREZOR"), bip39.NewSeed(testMnemonic, "TREZOR"); !bytes.Equal(got, want) {
+		t.Errorf("English seed = %x, want %x", got, want)
+	}
+
+	// BIP39 Japanese test vector: ideographic spaces and NFKD-normalized passphrase
+	mnemonic, err := EntropyToMnemonic(make([]byte, 16), LanguageJapanese)
+	if err != nil {
+		t.Fatalf("EntropyToMnemonic() error = %v", err)
+	}
+	if !strings.Contains(mnemonic, "　") {
+		t.Error("Japanese mnemonic should be joined with ideographic spaces")
+	}
+	want := "a262d6fb6122ecf45be09c50492b31f92e9beb7d9a845987a02cefda57a15f9c467a17872029a9e92299b5cbdf306e3a0ee620245cbd508959b6cb7ca637bd55"
+	if got := hex.EncodeToString(mnemonicSeed(mnemonic, "㍍ガバヴァぱばぐゞちぢ十人十色")); got != want {
+		t.Errorf("Japanese seed = %s, want %s", got, want)
+	}
+}
+
+func TestMnemonicToEntropyInvalid(t *testing.T) {
+	tests := []string{
+		"",
+		"abandon abandon abandon",
+		// Valid words, bad checksum
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon",
+		"abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon klingon",
+	}
+	for _, m := range tests {
+		if _, _, err := MnemonicToEntropy(m); !errors.Is(err, ErrInvalidMnemonic) {
+			t.Errorf("MnemonicToEntropy(%q) error = %v, want ErrInvalidMnemonic", m, err)
+		}
+	}
+}
+
+func TestParseLanguage(t *testing.T) {
+	if lang, err := ParseLanguage(""); err != nil || lang != LanguageEnglish {
+		t.Errorf("ParseLanguage(\"\") = %s, %v", lang, err)
+	}
+	if lang, err := ParseLanguage("Japanese"); err != nil || lang != LanguageJapanese {
+		t.Errorf("ParseLanguage(Japanese) = %s, %v", lang, err)
+	}
+	if _, err := ParseLanguage("elvish"); !errors.Is(err, ErrUnsupportedLanguage) {
+		t.Errorf("ParseLanguage(elvish) error = %v", err)
+	}
+}
+
+func TestNewFromMnemonicJapanese(t *testing.T) {
+	mnemonic, err := GenerateMnemonicInLanguage(LanguageJapanese)
+	if err != nil {
+		t.Fatalf("GenerateMnemonicInLanguage() error = %v", err)
+	}
+	if _, err := NewFromMnemonic(mnemonic, "", "mainnet"); err != nil {
+		t.Fatalf("NewFromMnemonic() error = %v", err)
+	}
+}
@@ -0,0 +1,115 @@
+package wallet
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"filippo.io/edwards25519"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// Monero address network bytes of standard addresses.
+const (
+	moneroMainnetTag  = 18
+	moneroStagenetTag = 24
+)
+
+const moneroBase58Alphabet = "123456789ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnopqrstuvwxyz"
+
+// moneroEncodedBlockSizes maps a block's byte length to its encoded length.
+var moneroEncodedBlockSizes = []int{0, 2, 3, 5, 6, 7, 9, 10, 11}
+
+// ErrInvalidMoneroAddress is returned for malformed Monero addresses.
+var ErrInvalidMoneroAddress = errors.New("invalid monero address")
+
+// moneroTag returns the standard address tag of a network. Testnet is
+// Monero's stagenet, as in the chain params.
+func moneroTag(network chain.Network) byte {
+	if network == chain.Mainnet {
+		return moneroMainnetTag
+	}
+	return moneroStagenetTag
+}
+
+// MoneroAddress encodes the standard address of a public spend and view key.
+func MoneroAddress(spend, view *edwards25519.Point, network chain.Network) string {
+	data := append([]byte{moneroTag(network)}, spend.Bytes()...)
+	data = append(data, view.Bytes()...)
+	data = append(data, Keccak256(data)[:4]...)
+	return moneroBase58Encode(data)
+}
+
+// DecodeMoneroAddress returns the public spend and view keys of a standard
+// address on a network.
+func DecodeMoneroAddress(addr string, network chain.Network) (spend, view *edwards25519.Point, err error) {
+	data, err := moneroBase58Decode(addr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) != 69 || data[0] != moneroTag(network) {
+		return nil, nil, fmt.Errorf("%w: not a standard %s address", ErrInvalidMoneroAddress, network)
+	}
+	if !bytes.Equal(Keccak256(data[:65])[:4], data[65:]) {
+		return nil, nil, fmt.Errorf("%w: bad checksum", ErrInvalidMoneroAddress)
+	}
+	if spend, err = new(edwards25519.Point).SetBytes(data[1:33]); err != nil {
+		return nil, nil, fmt.Errorf("%w: spend key: %v", ErrInvalidMoneroAddress, err)
+	}
+	if view, err = new(edwards25519.Point).SetBytes(data[33:65]); err != nil {
+		return nil, nil, fmt.Errorf("%w: view key: %v", ErrInvalidMoneroAddress, err)
+	}
+	return spend, view, nil
+}
+
+// moneroBase58Encode encodes in 8-byte blocks of 11 characters; the last,
+// shorter block gets the length in moneroEncodedBlockSizes.
+func moneroBase58Encode(data []byte) string {
+	var out []byte
+	for len(data) > 0 {
+		n := min(8, len(data))
+		num := new(big.Int).SetBytes(data[:n])
+		block := bytes.Repeat([]byte{moneroBase58Alphabet[0]}, moneroEncodedBlockSizes[n])
+		rem := new(big.Int)
+		base := big.NewInt(58)
+		for i := len(block) - 1; i >= 0 && num.Sign() > 0; i-- {
+			num.DivMod(num, base, rem)
+			block[i] = moneroBase58Alphabet[rem.Int64()]
+		}
+		out = append(out, block...)
+		data = data[n:]
+	}
+	return string(out)
+}
+
+func moneroBase58Decode(s string) ([]byte, error) {
+	var out []byte
+	for len(s) > 0 {
+		n := min(11, len(s))
+		size := -1
+		for bytesLen, encLen := range moneroEncodedBlockSizes {
+			if encLen == n {
+				size = bytesLen
+			}
+		}
+		if size < 0 {
+			return nil, fmt.Errorf("%w: bad length", ErrInvalidMoneroAddress)
+		}
+		num := new(big.Int)
+		for _, c := range []byte(s[:n]) {
+			d := bytes.IndexByte([]byte(moneroBase58Alphabet), c)
+			if d < 0 {
+				return nil, fmt.Errorf("%w: bad character %q", ErrInvalidMoneroAddress, c)
+			}
+			num.Mul(num, big.NewInt(58)).Add(num, big.NewInt(int64(d)))
+		}
+		if num.BitLen() > size*8 {
+			return nil, fmt.Errorf("%w: block overflows", ErrInvalidMoneroAddress)
+		}
+		out = append(out, num.FillBytes(make([]byte, size))...)
+		s = s[n:]
+	}
+	return out, nil
+}
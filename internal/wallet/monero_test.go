@@ -0,0 +1,74 @@
+package wallet
+
+import (
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"filippo.io/edwards25519"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestMoneroBase58(t *testing.T) {
+	tests := []struct {
+		hex, want string
+	}{
+		{"00", "11"},
+		{"39", "1z"},
+		{"ff", "5Q"},
+		{"0000", "111"},
+		{"ffff", "LUv"},
+		{"ffffffffffffffff", "jpXCZedGfVQ"},
+		{"ffffffffffffffffff", "jpXCZedGfVQ5Q"},
+	}
+	for _, tt := range tests {
+		data, _ := hex.DecodeString(tt.hex)
+		if got := moneroBase58Encode(data); got != tt.want {
+			t.Errorf("encode(%s) = %s, want %s", tt.hex, got, tt.want)
+		}
+		back, err := moneroBase58Decode(tt.want)
+		if err != nil || hex.EncodeToString(back) != tt.hex {
+			t.Errorf("decode(%s) = %x, %v", tt.want, back, err)
+		}
+	}
+	if _, err := moneroBase58Decode("1111"); err == nil {
+		t.Error("decoded a block of impossible length")
+	}
+}
+
+func TestMoneroAddress(t *testing.T) {
+	spend := new(edwards25519.Point).ScalarBaseMult(scalarOf(7))
+	view := new(edwards25519.Point).ScalarBaseMult(scalarOf(11))
+
+	for _, tt := range []struct {
+		network chain.Network
+		prefix  string
+	}{{chain.Mainnet, "4"}, {chain.Testnet, "5"}} {
+		addr := MoneroAddress(spend, view, tt.network)
+		if len(addr) != 95 || !strings.HasPrefix(addr, tt.prefix) {
+			t.Errorf("%s address = %s", tt.network, addr)
+		}
+		s, v, err := DecodeMoneroAddress(addr, tt.network)
+		if err != nil || s.Equal(spend) != 1 || v.Equal(view) != 1 {
+			t.Errorf("DecodeMoneroAddress() = %v", err)
+		}
+	}
+
+	addr := MoneroAddress(spend, view, chain.Mainnet)
+	if _, _, err := DecodeMoneroAddress(addr, chain.Testnet); !errors.Is(err, ErrInvalidMoneroAddress) {
+		t.Errorf("decoded a mainnet address on testnet: %v", err)
+	}
+	tampered := addr[:50] + string(moneroBase58Alphabet[(strings.IndexByte(moneroBase58Alphabet, addr[50])+1)%58]) + addr[51:]
+	if _, _, err := DecodeMoneroAddress(tampered, chain.Mainnet); err == nil {
+		t.Error("decoded an address with a changed character")
+	}
+}
+
+func scalarOf(v byte) *edwards25519.Scalar {
+	b := make([]byte, 32)
+	b[0] = v
+	s, _ := edwards25519.NewScalar().SetCanonicalBytes(b)
+	return s
+}
@@ -4,13 +4,13 @@ package wallet
 
 import (
 	"bytes"
+	"context"
 	"encoding/hex"
 	"fmt"
 	"strings"
 
 	"github.com/btcsuite/btcd/btcec/v2"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
-	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/Klingon-tech/klingdex/internal/chain"
 	"github.com/Klingon-tech/klingdex/internal/storage"
@@ -36,6 +36,9 @@ type AddressUTXO struct {
 	// Address type for signing
 	AddressType string `json:"address_type"` // p2wpkh, p2tr, p2pkh
 
+	// Confirmations when the UTXO was listed (0 = unconfirmed)
+	Confirmations int64 `json:"confirmations"`
+
 	// Script (optional, can be derived from address)
 	ScriptPubKey []byte `json:"-"`
 }
@@ -43,14 +46,15 @@ type AddressUTXO struct {
 // FromStorageUTXO converts a storage.WalletUTXO to AddressUTXO.
 func FromStorageUTXO(u *storage.WalletUTXO) *AddressUTXO {
 	return &AddressUTXO{
-		TxID:         u.TxID,
-		Vout:         u.Vout,
-		Amount:       u.Amount,
-		Address:      u.Address,
-		Account:      u.Account,
-		Change:       u.Change,
-		AddressIndex: u.AddressIndex,
-		AddressType:  u.AddressType,
+		TxID:          u.TxID,
+		Vout:          u.Vout,
+		Amount:        u.Amount,
+		Address:       u.Address,
+		Account:       u.Account,
+		Change:        u.Change,
+		AddressIndex:  u.AddressIndex,
+		AddressType:   u.AddressType,
+		Confirmations: u.Confirmations,
 	}
 }
 
@@ -103,6 +107,12 @@ func BuildAndSignMultiAddressTx(
 	keyDeriver KeyDeriver,
 	params *MultiAddressTxParams,
 ) (*MultiAddressTxResult, error) {
+	return buildMultiAddressTx(context.Background(), KeySigner(keyDeriver), nil, params)
+}
+
+// buildMultiAddressTx builds a transaction using UTXOs from multiple
+// addresses and signs it with signer.
+func buildMultiAddressTx(ctx context.Context, signer Signer, prevTx PrevTxFetcher, params *MultiAddressTxParams) (*MultiAddressTxResult, error) {
 	if len(params.UTXOs) == 0 {
 		return nil, fmt.Errorf("no UTXOs provided")
 	}
@@ -119,7 +129,7 @@ func BuildAndSignMultiAddressTx(
 	}
 
 	// Select UTXOs to cover amount + fees
-	selectedUTXOs, totalInput, err := selectAddressUTXOs(params.UTXOs, params.Amount, params.FeeRate)
+	selectedUTXOs, totalInput, err := selectAddressUTXOs(params.UTXOs, params.Amount, params.FeeRate, params.ChangeAddress != "")
 	if err != nil {
 		return nil, err
 	}
@@ -171,55 +181,8 @@ func BuildAndSignMultiAddressTx(
 		change = 0
 	}
 
-	// Build prevout fetcher for all inputs
-	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
-	utxoScripts := make([][]byte, len(selectedUTXOs))
-
-	for i, utxo := range selectedUTXOs {
-		// Get script for this UTXO's address
-		script, err := parseAddressToScript(utxo.Address, netParams, chainParams)
-		if err != nil {
-			return nil, fmt.Errorf("invalid UTXO address %s: %w", utxo.Address, err)
-		}
-		utxoScripts[i] = script
-		prevOuts[tx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(int64(utxo.Amount), script)
-	}
-
-	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
-
-	// Sign each input with its own private key
-	for i, utxo := range selectedUTXOs {
-		// Derive the private key for this UTXO's address
-		privKey, err := keyDeriver.DerivePrivateKeyWithChange(
-			params.Symbol,
-			utxo.Account,
-			utxo.Change,
-			utxo.AddressIndex,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to derive key for input %d (path: %d'/%d/%d): %w",
-				i, utxo.Account, utxo.Change, utxo.AddressIndex, err)
-		}
-
-		// Determine address type and sign accordingly
-		addrType := detectAddressType(utxo.Address, chainParams)
-
-		switch addrType {
-		case "p2wpkh":
-			if err := signP2WPKH(tx, i, privKey, prevOutFetcher); err != nil {
-				return nil, fmt.Errorf("failed to sign P2WPKH input %d: %w", i, err)
-			}
-		case "p2tr":
-			if err := signP2TR(tx, i, privKey, prevOutFetcher); err != nil {
-				return nil, fmt.Errorf("failed to sign P2TR input %d: %w", i, err)
-			}
-		case "p2pkh":
-			if err := signP2PKH(tx, i, privKey, utxoScripts[i]); err != nil {
-				return nil, fmt.Errorf("failed to sign P2PKH input %d: %w", i, err)
-			}
-		default:
-			return nil, fmt.Errorf("unsupported address type for input %d: %s", i, addrType)
-		}
+	if err := signer.SignTx(ctx, &SignRequest{Params: chainParams, Tx: tx, UTXOs: selectedUTXOs, PrevTx: prevTx}); err != nil {
+		return nil, err
 	}
 
 	// Serialize
@@ -252,7 +215,8 @@ func BuildAndSignMultiAddressTx(
 }
 
 // selectAddressUTXOs selects UTXOs from multiple addresses to cover target amount.
-func selectAddressUTXOs(utxos []*AddressUTXO, targetAmount, feeRate uint64) ([]*AddressUTXO, uint64, error) {
+// Without a change output only the destination output is paid for.
+func selectAddressUTXOs(utxos []*AddressUTXO, targetAmount, feeRate uint64, withChange bool) ([]*AddressUTXO, uint64, error) {
 	if len(utxos) == 0 {
 		return nil, 0, fmt.Errorf("no UTXOs provided")
 	}
@@ -267,6 +231,9 @@ func selectAddressUTXOs(utxos []*AddressUTXO, targetAmount, feeRate uint64) ([]*
 
 	// Base fee: tx overhead + 2 outputs (destination + change)
 	baseFee := uint64(10+31+31) * feeRate
+	if !withChange {
+		baseFee = uint64(10+31) * feeRate
+	}
 
 	for _, utxo := range sorted {
 		selected = append(selected, utxo)
@@ -305,18 +272,27 @@ func sortAddressUTXOs(utxos []*AddressUTXO) {
 func calculateInputsFee(utxos []*AddressUTXO, feeRate uint64) uint64 {
 	var totalVBytes uint64
 	for _, utxo := range utxos {
-		switch utxo.AddressType {
-		case "p2tr":
-			totalVBytes += 58
-		case "p2pkh":
-			totalVBytes += 148
-		default: // p2wpkh
-			totalVBytes += 68
-		}
+		totalVBytes += uint64(inputVSize(utxo.AddressType))
 	}
 	return totalVBytes * feeRate
 }
 
+// inputVSize returns the virtual size of an input spending an address type.
+func inputVSize(addrType string) int64 {
+	switch addrType {
+	case "p2tr":
+		return 58
+	case "p2pkh":
+		return 148
+	case "p2pkh-uncompressed":
+		return 180
+	case "p2sh-p2wpkh":
+		return 91
+	default: // p2wpkh
+		return 68
+	}
+}
+
 // estimateVSize estimates the virtual size of a transaction.
 func estimateVSize(utxos []*AddressUTXO, destAddress, changeAddress string) int64 {
 	// Base transaction overhead
@@ -324,14 +300,7 @@ func estimateVSize(utxos []*AddressUTXO, destAddress, changeAddress string) int6
 
 	// Add input sizes based on type
 	for _, utxo := range utxos {
-		switch utxo.AddressType {
-		case "p2tr":
-			vsize += 58
-		case "p2pkh":
-			vsize += 148
-		default: // p2wpkh
-			vsize += 68
-		}
+		vsize += inputVSize(utxo.AddressType)
 	}
 
 	// Add output sizes based on address type
@@ -423,18 +392,12 @@ func CalculateMaxSendAmount(params *SendMaxParams) (uint64, uint64, error) {
 	// Estimate fee (no change output since we're sending max)
 	vsize := int64(10) // Base overhead
 	for _, utxo := range params.UTXOs {
-		switch utxo.AddressType {
-		case "p2tr":
-			vsize += 58
-		case "p2pkh":
-			vsize += 148
-		default:
-			vsize += 68
-		}
+		vsize += inputVSize(utxo.AddressType)
 	}
 	vsize += estimateOutputSize(params.ToAddress) // Only destination output
 
-	fee := uint64(vsize) * params.FeeRate
+	// Same 2 vbytes buffer as BuildAndSignMultiAddressTx
+	fee := uint64(vsize+2) * params.FeeRate
 
 	if totalInput <= fee {
 		return 0, fee, fmt.Errorf("insufficient funds: total %d, fee %d", totalInput, fee)
@@ -0,0 +1,289 @@
+// Package wallet provides spending policies enforced on outbound wallet sends.
+package wallet
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// ErrPolicyViolation is returned when a send is rejected by the spending policy.
+var ErrPolicyViolation = errors.New("spending policy violation")
+
+// SpendingWindow is the rolling window daily limits are measured over.
+const SpendingWindow = 24 * time.Hour
+
+// ChainPolicy holds the spending rules for one chain or ERC-20 token.
+// Amounts are decimal strings in smallest units so EVM wei values fit.
+type ChainPolicy struct {
+	// DailyLimit caps the amount sent within SpendingWindow ("" = unlimited).
+	DailyLimit string `yaml:"daily_limit,omitempty" json:"daily_limit,omitempty"`
+
+	// ApprovalThreshold requires the approval token for sends above it ("" = never).
+	ApprovalThreshold string `yaml:"approval_threshold,omitempty" json:"approval_threshold,omitempty"`
+
+	// Whitelist restricts destinations to these addresses (empty = any).
+	Whitelist []string `yaml:"whitelist,omitempty" json:"whitelist,omitempty"`
+}
+
+// SpendingPolicy holds the spending rules enforced by the wallet service.
+type SpendingPolicy struct {
+	// Chains maps a chain symbol (BTC, ETH) or an ERC-20 key (ETH:0xtoken)
+	// to its rules. A chain's whitelist also applies to its token transfers.
+	Chains map[string]*ChainPolicy `yaml:"chains,omitempty" json:"chains,omitempty"`
+
+	// ApprovalToken must accompany sends above an ApprovalThreshold.
+	ApprovalToken string `yaml:"approval_token,omitempty" json:"-"`
+}
+
+// PolicyKey returns the policy key for a native send (token == "") or an ERC-20 transfer.
+func PolicyKey(symbol, token string) string {
+	if token == "" {
+		return symbol
+	}
+	return symbol + ":" + strings.ToLower(token)
+}
+
+// Validate checks the policy amounts and that thresholds have an approval token.
+func (p *SpendingPolicy) Validate() error {
+	for key, cp := range p.Chains {
+		if cp == nil {
+			continue
+		}
+		if _, err := parsePolicyAmount(cp.DailyLimit); err != nil {
+			return fmt.Errorf("spending policy %s: daily_limit: %w", key, err)
+		}
+		threshold, err := parsePolicyAmount(cp.ApprovalThreshold)
+		if err != nil {
+			return fmt.Errorf("spending policy %s: approval_threshold: %w", key, err)
+		}
+		if threshold != nil && p.ApprovalToken == "" {
+			return fmt.Errorf("spending policy %s: approval_threshold requires approval_token", key)
+		}
+	}
+	return nil
+}
+
+// coversChain returns true if any rule applies to sends on the chain.
+func (p *SpendingPolicy) coversChain(symbol string) bool {
+	if p == nil {
+		return false
+	}
+	for key, cp := range p.Chains {
+		if cp != nil && (key == symbol || strings.HasPrefix(key, symbol+":")) {
+			return true
+		}
+	}
+	return false
+}
+
+// rule returns the rules for a policy key, or nil.
+func (p *SpendingPolicy) rule(key string) *ChainPolicy {
+	if p == nil {
+		return nil
+	}
+	return p.Chains[key]
+}
+
+// parsePolicyAmount parses a non-negative decimal amount; "" yields nil (no rule).
+func parsePolicyAmount(s string) (*big.Int, error) {
+	if s == "" {
+		return nil, nil
+	}
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok || v.Sign() < 0 {
+		return nil, fmt.Errorf("invalid amount %q", s)
+	}
+	return v, nil
+}
+
+type approvalTokenKey struct{}
+
+// WithApprovalToken attaches an approval token to a send request context.
+func WithApprovalToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, approvalTokenKey{}, token)
+}
+
+func approvalTokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(approvalTokenKey{}).(string)
+	return token
+}
+
+// spendRequest describes an outbound send checked against the policy.
+type spendRequest struct {
+	symbol string
+	token  string // ERC-20 contract, "" for native sends
+	to     string
+	amount *big.Int
+}
+
+// guardedSend checks a send against the spending policy, broadcasts it and
+// records it in the spend ledger. Checks and recording are serialized so
+// concurrent sends cannot jointly exceed a daily limit.
+func (s *Service) guardedSend(ctx context.Context, req spendRequest, broadcast func() (string, error)) (string, error) {
+	if !s.policy.coversChain(req.symbol) {
+		return broadcast()
+	}
+
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+
+	if err := s.checkSpend(ctx, req); err != nil {
+		return "", err
+	}
+
+	txid, err := broadcast()
+	if err != nil {
+		return "", err
+	}
+
+	key := PolicyKey(req.symbol, req.token)
+	if rule := s.policy.rule(key); rule != nil && rule.DailyLimit != "" {
+		if err := s.store.RecordWalletSpend(&storage.WalletSpend{
+			PolicyKey: key,
+			ToAddress: req.to,
+			Amount:    req.amount.String(),
+			TxID:      txid,
+		}); err != nil {
+			// The transaction is already broadcast; report it rather than fail
+			return txid, fmt.Errorf("sent %s but failed to record spend: %w", txid, err)
+		}
+	}
+	return txid, nil
+}
+
+// checkSpend enforces whitelists, approval thresholds and daily limits.
+func (s *Service) checkSpend(ctx context.Context, req spendRequest) error {
+	// Destination whitelists: the chain's and, for tokens, the token's own
+	keys := []string{req.symbol}
+	if req.token != "" {
+		keys = append(keys, PolicyKey(req.symbol, req.token))
+	}
+	for _, key := range keys {
+		if rule := s.policy.rule(key); rule != nil && len(rule.Whitelist) > 0 && !s.whitelisted(req.symbol, rule.Whitelist, req.to) {
+			return fmt.Errorf("%w: %s is not whitelisted for %s", ErrPolicyViolation, req.to, key)
+		}
+	}
+
+	key := PolicyKey(req.symbol, req.token)
+	rule := s.policy.rule(key)
+	if rule == nil {
+		return nil
+	}
+
+	threshold, err := parsePolicyAmount(rule.ApprovalThreshold)
+	if err != nil {
+		return err
+	}
+	if threshold != nil && req.amount.Cmp(threshold) > 0 {
+		token := approvalTokenFrom(ctx)
+		if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(s.policy.ApprovalToken)) != 1 {
+			return fmt.Errorf("%w: amount %s above approval threshold %s requires a valid approval token", ErrPolicyViolation, req.amount, threshold)
+		}
+	}
+
+	limit, err := parsePolicyAmount(rule.DailyLimit)
+	if err != nil || limit == nil {
+		return err
+	}
+	used, err := s.spentInWindow(key)
+	if err != nil {
+		return err
+	}
+	if new(big.Int).Add(used, req.amount).Cmp(limit) > 0 {
+		return fmt.Errorf("%w: daily limit %s for %s exceeded (%s already sent)", ErrPolicyViolation, limit, key, used)
+	}
+	return nil
+}
+
+// whitelisted reports whether to is in the whitelist. EVM addresses compare
+// case-insensitively (EIP-55 checksums only change case).
+func (s *Service) whitelisted(symbol string, whitelist []string, to string) bool {
+	evm := false
+	if params, ok := chain.Get(symbol, s.network); ok {
+		evm = params.Type == chain.ChainTypeEVM
+	}
+	for _, addr := range whitelist {
+		if addr == to || (evm && strings.EqualFold(addr, to)) {
+			return true
+		}
+	}
+	return false
+}
+
+// spentInWindow sums the ledger for a policy key over SpendingWindow.
+func (s *Service) spentInWindow(key string) (*big.Int, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("%w: daily limits require storage", ErrPolicyViolation)
+	}
+	spends, err := s.store.GetWalletSpendsSince(key, time.Now().Add(-SpendingWindow))
+	if err != nil {
+		return nil, err
+	}
+	used := new(big.Int)
+	for _, sp := range spends {
+		amount, ok := new(big.Int).SetString(sp.Amount, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid ledger amount %q", sp.Amount)
+		}
+		used.Add(used, amount)
+	}
+	return used, nil
+}
+
+// SpendingStatus reports the policy and current usage for a chain or token.
+type SpendingStatus struct {
+	PolicyKey         string   `json:"policy_key"`
+	DailyLimit        string   `json:"daily_limit,omitempty"`
+	SpentToday        string   `json:"spent_today"`
+	Remaining         string   `json:"remaining,omitempty"`
+	ApprovalThreshold string   `json:"approval_threshold,omitempty"`
+	Whitelist         []string `json:"whitelist,omitempty"`
+}
+
+// GetSpendingStatus returns the spending rules and rolling usage for a chain
+// (token == "") or an ERC-20 token.
+func (s *Service) GetSpendingStatus(symbol, token string) (*SpendingStatus, error) {
+	key := PolicyKey(symbol, token)
+	status := &SpendingStatus{PolicyKey: key, SpentToday: "0"}
+
+	rule := s.policy.rule(key)
+	if rule == nil {
+		if chainRule := s.policy.rule(symbol); chainRule != nil {
+			status.Whitelist = chainRule.Whitelist
+		}
+		return status, nil
+	}
+	status.ApprovalThreshold = rule.ApprovalThreshold
+	status.Whitelist = rule.Whitelist
+	if rule.DailyLimit == "" {
+		return status, nil
+	}
+
+	s.policyMu.Lock()
+	defer s.policyMu.Unlock()
+
+	limit, err := parsePolicyAmount(rule.DailyLimit)
+	if err != nil {
+		return nil, err
+	}
+	used, err := s.spentInWindow(key)
+	if err != nil {
+		return nil, err
+	}
+	remaining := new(big.Int).Sub(limit, used)
+	if remaining.Sign() < 0 {
+		remaining.SetInt64(0)
+	}
+	status.DailyLimit = limit.String()
+	status.SpentToday = used.String()
+	status.Remaining = remaining.String()
+	return status, nil
+}
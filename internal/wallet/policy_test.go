@@ -0,0 +1,150 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+func newPolicyTestService(t *testing.T, policy *SpendingPolicy) *Service {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Policy: policy, Store: store})
+}
+
+// send runs a guarded send whose broadcast always succeeds.
+func send(s *Service, ctx context.Context, symbol, token, to string, amount int64) error {
+	_, err := s.guardedSend(ctx, spendRequest{symbol: symbol, token: token, to: to, amount: big.NewInt(amount)}, func() (string, error) {
+		return "txid", nil
+	})
+	return err
+}
+
+func TestSpendingPolicyDailyLimit(t *testing.T) {
+	s := newPolicyTestService(t, &SpendingPolicy{
+		Chains: map[string]*ChainPolicy{"BTC": {DailyLimit: "100000"}},
+	})
+	ctx := context.Background()
+
+	if err := send(s, ctx, "BTC", "", "tb1qdest", 60000); err != nil {
+		t.Fatalf("first send error = %v", err)
+	}
+	if err := send(s, ctx, "BTC", "", "tb1qdest", 50000); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("send over daily limit error = %v, want ErrPolicyViolation", err)
+	}
+	if err := send(s, ctx, "BTC", "", "tb1qdest", 40000); err != nil {
+		t.Errorf("send up to the limit error = %v", err)
+	}
+	// Chains without a policy are unrestricted
+	if err := send(s, ctx, "LTC", "", "tltc1qdest", 1000000); err != nil {
+		t.Errorf("unrestricted chain error = %v", err)
+	}
+
+	status, err := s.GetSpendingStatus("BTC", "")
+	if err != nil {
+		t.Fatalf("GetSpendingStatus() error = %v", err)
+	}
+	if status.SpentToday != "100000" || status.Remaining != "0" {
+		t.Errorf("GetSpendingStatus() = %+v", status)
+	}
+}
+
+func TestSpendingPolicyFailedBroadcastNotCounted(t *testing.T) {
+	s := newPolicyTestService(t, &SpendingPolicy{
+		Chains: map[string]*ChainPolicy{"BTC": {DailyLimit: "100000"}},
+	})
+	ctx := context.Background()
+
+	_, err := s.guardedSend(ctx, spendRequest{symbol: "BTC", to: "tb1qdest", amount: big.NewInt(90000)}, func() (string, error) {
+		return "", errors.New("mempool rejected")
+	})
+	if err == nil {
+		t.Fatal("expected broadcast error")
+	}
+	if err := send(s, ctx, "BTC", "", "tb1qdest", 90000); err != nil {
+		t.Errorf("send after failed broadcast error = %v", err)
+	}
+}
+
+func TestSpendingPolicyWhitelist(t *testing.T) {
+	s := newPolicyTestService(t, &SpendingPolicy{
+		Chains: map[string]*ChainPolicy{
+			"BTC": {Whitelist: []string{"tb1qallowed"}},
+			"ETH": {Whitelist: []string{"0xAbCdEf0000000000000000000000000000000001"}},
+		},
+	})
+	ctx := context.Background()
+
+	if err := send(s, ctx, "BTC", "", "tb1qother", 1); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("non-whitelisted send error = %v, want ErrPolicyViolation", err)
+	}
+	if err := send(s, ctx, "BTC", "", "TB1QALLOWED", 1); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("UTXO whitelist must match exactly, got %v", err)
+	}
+	if err := send(s, ctx, "BTC", "", "tb1qallowed", 1); err != nil {
+		t.Errorf("whitelisted send error = %v", err)
+	}
+	// EVM addresses match regardless of checksum case, for native and token sends
+	if err := send(s, ctx, "ETH", "", "0xabcdef0000000000000000000000000000000001", 1); err != nil {
+		t.Errorf("EVM whitelisted send error = %v", err)
+	}
+	if err := send(s, ctx, "ETH", "0xToken", "0x0000000000000000000000000000000000000002", 1); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("token send to non-whitelisted address error = %v, want ErrPolicyViolation", err)
+	}
+
+	if _, err := s.BroadcastTx(ctx, "BTC", "00"); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("raw broadcast error = %v, want ErrPolicyViolation", err)
+	}
+}
+
+func TestSpendingPolicyApprovalThreshold(t *testing.T) {
+	s := newPolicyTestService(t, &SpendingPolicy{
+		ApprovalToken: "secret-token",
+		Chains: map[string]*ChainPolicy{
+			"ETH:0xtoken": {ApprovalThreshold: "1000"},
+		},
+	})
+	ctx := context.Background()
+
+	if err := send(s, ctx, "ETH", "0xTOKEN", "0x01", 1000); err != nil {
+		t.Errorf("send at threshold error = %v", err)
+	}
+	if err := send(s, ctx, "ETH", "0xTOKEN", "0x01", 1001); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("send above threshold without token error = %v, want ErrPolicyViolation", err)
+	}
+	if err := send(s, WithApprovalToken(ctx, "wrong"), "ETH", "0xTOKEN", "0x01", 1001); !errors.Is(err, ErrPolicyViolation) {
+		t.Errorf("send with wrong token error = %v, want ErrPolicyViolation", err)
+	}
+	if err := send(s, WithApprovalToken(ctx, "secret-token"), "ETH", "0xTOKEN", "0x01", 1001); err != nil {
+		t.Errorf("approved send error = %v", err)
+	}
+}
+
+func TestSpendingPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  SpendingPolicy
+		wantErr bool
+	}{
+		{"empty", SpendingPolicy{}, false},
+		{"valid", SpendingPolicy{ApprovalToken: "t", Chains: map[string]*ChainPolicy{"ETH": {DailyLimit: "1000000000000000000000", ApprovalThreshold: "1"}}}, false},
+		{"bad limit", SpendingPolicy{Chains: map[string]*ChainPolicy{"BTC": {DailyLimit: "0.5"}}}, true},
+		{"negative threshold", SpendingPolicy{ApprovalToken: "t", Chains: map[string]*ChainPolicy{"BTC": {ApprovalThreshold: "-1"}}}, true},
+		{"threshold without token", SpendingPolicy{Chains: map[string]*ChainPolicy{"BTC": {ApprovalThreshold: "100"}}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
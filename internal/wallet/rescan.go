@@ -0,0 +1,411 @@
+// Package wallet - Wallet rescan from a block height.
+package wallet
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// Rescan phases reported in RescanProgress.Phase.
+const (
+	RescanPhaseAddresses = "addresses" // Walking the address chains
+	RescanPhaseReconcile = "reconcile" // Updating the UTXO cache
+	RescanPhaseDone      = "done"
+)
+
+// maxRescanTxPages bounds the history pages fetched per address.
+const maxRescanTxPages = 100
+
+// birthdayMargin is subtracted from a birthday before it is turned into a
+// height: block timestamps may be up to two hours off.
+const birthdayMargin = 2 * time.Hour
+
+// RescanProgress reports the state of a running rescan.
+type RescanProgress struct {
+	Symbol           string `json:"symbol"`
+	Phase            string `json:"phase"`
+	StartHeight      int64  `json:"start_height"`
+	AddressesScanned int    `json:"addresses_scanned"`
+	Change           uint32 `json:"change"` // Address chain being walked (0 external, 1 change)
+	Index            uint32 `json:"index"`  // Last address index scanned on it
+	Transactions     int    `json:"transactions"`
+	UTXOsFound       int    `json:"utxos_found"`
+}
+
+// RescanResult is the outcome of a rescan.
+type RescanResult struct {
+	Symbol           string `json:"symbol"`
+	StartHeight      int64  `json:"start_height"`
+	TipHeight        int64  `json:"tip_height"`
+	AddressesScanned int    `json:"addresses_scanned"`
+	AddressesUsed    int    `json:"addresses_used"`
+	LastExternal     uint32 `json:"last_external_index"`
+	LastChange       uint32 `json:"last_change_index"`
+	Transactions     int    `json:"transactions"` // Transactions at or after StartHeight, and unconfirmed
+	UTXOsFound       int    `json:"utxos_found"`
+	UTXOsAdded       int    `json:"utxos_added"` // Not in the cache before
+	UTXOsSpent       int    `json:"utxos_spent"` // Cached but no longer unspent
+	Balance          uint64 `json:"balance"`
+	Duration         string `json:"duration"`
+}
+
+// Rescan walks the wallet's address chains with the gap limit, counts their
+// transactions from startHeight on and reconciles the persisted UTXO cache
+// with the backend: missing UTXOs are added and cached ones that are no
+// longer unspent are marked spent. progress, if set, is called after every
+// address and phase change.
+func (s *UTXOSyncService) Rescan(ctx context.Context, symbol string, startHeight int64, progress func(RescanProgress)) (*RescanResult, error) {
+	s.syncMu.Lock()
+	if s.syncing[symbol] {
+		s.syncMu.Unlock()
+		return nil, fmt.Errorf("sync already in progress for %s", symbol)
+	}
+	s.syncing[symbol] = true
+	s.syncMu.Unlock()
+
+	defer func() {
+		s.syncMu.Lock()
+		s.syncing[symbol] = false
+		s.lastSync[symbol] = time.Now()
+		s.syncMu.Unlock()
+	}()
+
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return nil, fmt.Errorf("no backend configured for chain: %s", symbol)
+	}
+	if err := b.Connect(ctx); err != nil {
+		return nil, fmt.Errorf("failed to connect backend: %w", err)
+	}
+	if progress == nil {
+		progress = func(RescanProgress) {}
+	}
+
+	start := time.Now()
+	tip, err := b.GetBlockHeight(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block height: %w", err)
+	}
+	if startHeight < 0 || startHeight > tip {
+		return nil, fmt.Errorf("start height %d outside 0..%d", startHeight, tip)
+	}
+
+	s.logger.Info("starting wallet rescan", "chain", symbol, "start_height", startHeight, "tip", tip)
+
+	chainParams, _ := chain.Get(symbol, s.network)
+	result := &RescanResult{Symbol: symbol, StartHeight: startHeight, TipHeight: tip}
+	state := RescanProgress{Symbol: symbol, Phase: RescanPhaseAddresses, StartHeight: startHeight}
+	progress(state)
+
+	found := make(map[string]*storage.WalletUTXO)
+	scanned := make(map[string]bool)
+
+	for _, change := range []uint32{0, 1} {
+		lastUsed := uint32(0)
+		consecutiveEmpty := uint32(0)
+		for index := uint32(0); consecutiveEmpty < s.gapLimit; index++ {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+
+			address, err := s.wallet.DeriveAddressWithChange(symbol, 0, change, index)
+			if err != nil {
+				return nil, fmt.Errorf("failed to derive address at index %d: %w", index, err)
+			}
+			addrType := detectAddressType(address, chainParams)
+
+			info, err := b.GetAddressInfo(ctx, address)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get address info for %s: %w", address, err)
+			}
+			utxos, err := b.GetAddressUTXOs(ctx, address)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get UTXOs for %s: %w", address, err)
+			}
+			scanned[address] = true
+			result.AddressesScanned++
+
+			if info.TxCount == 0 && len(utxos) == 0 {
+				consecutiveEmpty++
+			} else {
+				consecutiveEmpty = 0
+				lastUsed = index
+				result.AddressesUsed++
+
+				txs, err := s.rescanAddressTxs(ctx, b, address, startHeight)
+				if err != nil {
+					return nil, fmt.Errorf("failed to get transactions for %s: %w", address, err)
+				}
+				result.Transactions += txs
+
+				now := time.Now().Unix()
+				if err := s.storage.SaveWalletAddress(&storage.WalletAddress{
+					Address:      address,
+					Chain:        symbol,
+					Change:       change,
+					AddressIndex: index,
+					AddressType:  addrType,
+					TxCount:      info.TxCount,
+					LastSeenAt:   now,
+				}); err != nil {
+					s.logger.Warn("failed to save address", "address", address, "error", err)
+				}
+
+				for _, u := range utxos {
+					status := storage.UTXOStatusConfirmed
+					if u.Confirmations == 0 && u.BlockHeight == 0 {
+						status = storage.UTXOStatusUnconfirmed
+					}
+					found[utxoKey(u.TxID, u.Vout)] = &storage.WalletUTXO{
+						TxID:          u.TxID,
+						Vout:          u.Vout,
+						Amount:        u.Amount,
+						Address:       address,
+						Chain:         symbol,
+						Change:        change,
+						AddressIndex:  index,
+						AddressType:   addrType,
+						ScriptPubKey:  u.ScriptPubKey,
+						Status:        status,
+						BlockHeight:   u.BlockHeight,
+						Confirmations: u.Confirmations,
+					}
+				}
+			}
+
+			state.AddressesScanned = result.AddressesScanned
+			state.Change = change
+			state.Index = index
+			state.Transactions = result.Transactions
+			state.UTXOsFound = len(found)
+			progress(state)
+		}
+		if change == 0 {
+			result.LastExternal = lastUsed
+		} else {
+			result.LastChange = lastUsed
+		}
+	}
+
+	state.Phase = RescanPhaseReconcile
+	progress(state)
+	if err := s.reconcileUTXOs(symbol, found, scanned, result); err != nil {
+		return nil, err
+	}
+
+	syncState, err := s.storage.GetWalletSyncState(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sync state: %w", err)
+	}
+	if result.LastExternal > syncState.LastExternalIndex {
+		syncState.LastExternalIndex = result.LastExternal
+	}
+	if result.LastChange > syncState.LastChangeIndex {
+		syncState.LastChangeIndex = result.LastChange
+	}
+	syncState.LastSyncAt = time.Now().Unix()
+	syncState.LastBlockHeight = tip
+	syncState.SyncStatus = "synced"
+	syncState.GapLimit = s.gapLimit
+	if err := s.storage.SaveWalletSyncState(syncState); err != nil {
+		return nil, fmt.Errorf("failed to save sync state: %w", err)
+	}
+
+	result.UTXOsFound = len(found)
+	result.Duration = time.Since(start).Round(time.Millisecond).String()
+	state.Phase = RescanPhaseDone
+	progress(state)
+
+	s.logger.Info("wallet rescan complete",
+		"chain", symbol,
+		"addresses", result.AddressesScanned,
+		"transactions", result.Transactions,
+		"utxos", result.UTXOsFound,
+		"added", result.UTXOsAdded,
+		"spent", result.UTXOsSpent,
+	)
+	return result, nil
+}
+
+// rescanAddressTxs counts an address's transactions at or after
+// startHeight, plus unconfirmed ones. History is returned newest first, so
+// paging stops at the first older confirmed transaction.
+func (s *UTXOSyncService) rescanAddressTxs(ctx context.Context, b backend.Backend, address string, startHeight int64) (int, error) {
+	count := 0
+	seen := make(map[string]bool)
+	lastSeen := ""
+	for page := 0; page < maxRescanTxPages; page++ {
+		txs, err := b.GetAddressTxs(ctx, address, lastSeen)
+		if err != nil {
+			return count, err
+		}
+		newTxs := 0
+		for _, tx := range txs {
+			if seen[tx.TxID] {
+				continue
+			}
+			seen[tx.TxID] = true
+			newTxs++
+			if tx.Confirmed && tx.BlockHeight < startHeight {
+				return count, nil
+			}
+			count++
+			if tx.Confirmed {
+				lastSeen = tx.TxID
+			}
+		}
+		if newTxs == 0 || lastSeen == "" {
+			return count, nil
+		}
+	}
+	return count, nil
+}
+
+// reconcileUTXOs saves the UTXOs found and marks cached UTXOs of scanned
+// addresses that were not found as spent.
+func (s *UTXOSyncService) reconcileUTXOs(symbol string, found map[string]*storage.WalletUTXO, scanned map[string]bool, result *RescanResult) error {
+	cached, err := s.storage.GetAllUTXOs(symbol)
+	if err != nil {
+		return fmt.Errorf("failed to get cached UTXOs: %w", err)
+	}
+
+	known := make(map[string]*storage.WalletUTXO, len(cached))
+	for _, u := range cached {
+		known[utxoKey(u.TxID, u.Vout)] = u
+		if _, ok := found[utxoKey(u.TxID, u.Vout)]; ok || !scanned[u.Address] {
+			continue
+		}
+		if err := s.storage.MarkUTXOSpent(u.TxID, u.Vout, u.SpentTxID); err != nil {
+			s.logger.Warn("failed to mark UTXO spent", "txid", u.TxID, "vout", u.Vout, "error", err)
+			continue
+		}
+		result.UTXOsSpent++
+	}
+
+	for key, u := range found {
+		prev, ok := known[key]
+		if !ok {
+			result.UTXOsAdded++
+		} else if prev.Status == storage.UTXOStatusPendingSpend {
+			// Our spend isn't confirmed yet; keep it reserved
+			u.Status = prev.Status
+			u.SpentTxID = prev.SpentTxID
+		}
+		if err := s.storage.SaveWalletUTXO(u); err != nil {
+			return fmt.Errorf("failed to save UTXO %s: %w", key, err)
+		}
+		if u.Status != storage.UTXOStatusPendingSpend {
+			result.Balance += u.Amount
+		}
+	}
+	return nil
+}
+
+// HeightAtTime returns the height of the last block mined before t, less a
+// margin for block timestamp drift. It searches block headers and, if the
+// backend can't look them up by height, estimates from the chain's average
+// block time.
+func (s *UTXOSyncService) HeightAtTime(ctx context.Context, symbol string, t time.Time) (int64, error) {
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return 0, fmt.Errorf("no backend configured for chain: %s", symbol)
+	}
+	if err := b.Connect(ctx); err != nil {
+		return 0, fmt.Errorf("failed to connect backend: %w", err)
+	}
+	tip, err := b.GetBlockHeight(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get block height: %w", err)
+	}
+
+	target := t.Add(-birthdayMargin).Unix()
+	height, err := searchHeightAtTime(ctx, b, tip, target)
+	if err == nil {
+		return height, nil
+	}
+	s.logger.Debug("block header search failed, estimating birthday height", "chain", symbol, "error", err)
+
+	timeouts, ok := config.GetChainTimeout(symbol, s.network == chain.Testnet)
+	if !ok || timeouts.AvgBlockTimeSeconds == 0 {
+		return 0, fmt.Errorf("cannot estimate the height of %s blocks: %w", symbol, err)
+	}
+	blocks := (time.Now().Unix() - target) / int64(timeouts.AvgBlockTimeSeconds)
+	if height = tip - blocks; height < 0 {
+		height = 0
+	}
+	return height, nil
+}
+
+// searchHeightAtTime binary searches for the highest block with a timestamp
+// before target.
+func searchHeightAtTime(ctx context.Context, b backend.Backend, tip, target int64) (int64, error) {
+	lo, hi := int64(0), tip
+	for lo < hi {
+		mid := lo + (hi-lo+1)/2
+		header, err := b.GetBlockHeader(ctx, strconv.FormatInt(mid, 10))
+		if err != nil {
+			return 0, err
+		}
+		if header.Timestamp < target {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo, nil
+}
+
+func utxoKey(txid string, vout uint32) string {
+	return fmt.Sprintf("%s:%d", txid, vout)
+}
+
+// Rescan rescans a chain's addresses from startHeight and reconciles the
+// persisted UTXO cache. See UTXOSyncService.Rescan.
+func (s *Service) Rescan(ctx context.Context, symbol string, startHeight int64, storage *storage.Storage, progress func(RescanProgress)) (*RescanResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	syncService, err := s.rescanServiceLocked(storage)
+	if err != nil {
+		return nil, err
+	}
+	return syncService.Rescan(ctx, symbol, startHeight, progress)
+}
+
+// HeightAtTime returns the block height of a wallet birthday. See
+// UTXOSyncService.HeightAtTime.
+func (s *Service) HeightAtTime(ctx context.Context, symbol string, t time.Time, storage *storage.Storage) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	syncService, err := s.rescanServiceLocked(storage)
+	if err != nil {
+		return 0, err
+	}
+	return syncService.HeightAtTime(ctx, symbol, t)
+}
+
+// rescanServiceLocked returns a sync service over the wallet.
+// NOTE: Caller must hold s.mu.
+func (s *Service) rescanServiceLocked(storage *storage.Storage) (*UTXOSyncService, error) {
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	if s.backends == nil {
+		return nil, fmt.Errorf("no backends configured")
+	}
+	return NewUTXOSyncService(&UTXOSyncConfig{
+		Wallet:   s.wallet,
+		Storage:  storage,
+		Backends: s.backends,
+		Network:  s.network,
+		GapLimit: 20,
+	}), nil
+}
@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// rescanBackend adds address histories and block headers to fakeBackend.
+type rescanBackend struct {
+	*fakeBackend
+	txs        map[string][]backend.Transaction // Newest first
+	headerBase int64                            // Timestamp of block 0; blocks every 600s
+}
+
+func (r *rescanBackend) GetAddressTxs(ctx context.Context, address, lastSeenTxID string) ([]backend.Transaction, error) {
+	txs := r.txs[address]
+	for i, tx := range txs {
+		if tx.TxID == lastSeenTxID {
+			return txs[i+1:], nil
+		}
+	}
+	return txs, nil
+}
+
+func (r *rescanBackend) GetAddressInfo(ctx context.Context, address string) (*backend.AddressInfo, error) {
+	info, _ := r.fakeBackend.GetAddressInfo(ctx, address)
+	info.TxCount += int64(len(r.txs[address]))
+	return info, nil
+}
+
+func (r *rescanBackend) GetBlockHeader(ctx context.Context, hashOrHeight string) (*backend.BlockHeader, error) {
+	if r.headerBase == 0 {
+		return nil, errors.New("not found")
+	}
+	height, err := strconv.ParseInt(hashOrHeight, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &backend.BlockHeader{Height: height, Timestamp: r.headerBase + height*600}, nil
+}
+
+func TestRescan(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	rb := &rescanBackend{fakeBackend: fb, txs: make(map[string][]backend.Transaction)}
+	svc.backends.Register("BTC", rb)
+	store := svc.store
+
+	used, err := svc.GetWallet().DeriveAddressWithChange("BTC", 0, 0, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := svc.GetWallet().DeriveAddressWithChange("BTC", 0, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The cache holds a UTXO spent since, on an address the rescan covers
+	if err := store.SaveWalletUTXO(&storage.WalletUTXO{TxID: "stale", Amount: 500, Address: first, Chain: "BTC", Status: storage.UTXOStatusConfirmed}); err != nil {
+		t.Fatal(err)
+	}
+	fb.utxos[used] = []backend.UTXO{{TxID: "fresh", Vout: 1, Amount: 7000, Confirmations: 10, BlockHeight: 91}}
+	fb.utxos[first] = []backend.UTXO{{TxID: "mempool", Amount: 300}}
+	rb.txs[used] = []backend.Transaction{
+		{TxID: "t3", Confirmed: true, BlockHeight: 91},
+		{TxID: "t2", Confirmed: true, BlockHeight: 60},
+		{TxID: "t1", Confirmed: true, BlockHeight: 40},
+	}
+
+	var phases []string
+	result, err := svc.Rescan(context.Background(), "BTC", 50, store, func(p RescanProgress) {
+		if len(phases) == 0 || phases[len(phases)-1] != p.Phase {
+			phases = append(phases, p.Phase)
+		}
+	})
+	if err != nil {
+		t.Fatalf("Rescan() error = %v", err)
+	}
+
+	// Two transactions of the used address are at or after height 50
+	if result.Transactions != 2 || result.AddressesUsed != 2 || result.LastExternal != 3 {
+		t.Errorf("result = %+v, want 2 transactions on 2 used addresses up to index 3", result)
+	}
+	if result.UTXOsFound != 2 || result.UTXOsAdded != 2 || result.UTXOsSpent != 1 || result.Balance != 7300 {
+		t.Errorf("result = %+v, want 2 UTXOs added, 1 spent, balance 7300", result)
+	}
+	if want := []string{RescanPhaseAddresses, RescanPhaseReconcile, RescanPhaseDone}; len(phases) != 3 || phases[0] != want[0] || phases[2] != want[2] {
+		t.Errorf("phases = %v, want %v", phases, want)
+	}
+
+	if u, err := store.GetWalletUTXO("stale", 0); err != nil || u.Status != storage.UTXOStatusSpent {
+		t.Errorf("stale UTXO = %+v, %v, want spent", u, err)
+	}
+	if u, err := store.GetWalletUTXO("mempool", 0); err != nil || u.Status != storage.UTXOStatusUnconfirmed {
+		t.Errorf("mempool UTXO = %+v, %v, want unconfirmed", u, err)
+	}
+	state, err := store.GetWalletSyncState("BTC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.LastExternalIndex != 3 || state.LastBlockHeight != 100 {
+		t.Errorf("sync state = %+v, want external index 3 at height 100", state)
+	}
+
+	if _, err := svc.Rescan(context.Background(), "BTC", 101, store, nil); err == nil {
+		t.Error("Rescan() above the tip should fail")
+	}
+}
+
+func TestHeightAtTime(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	base := time.Now().Add(-100 * 10 * time.Minute).Unix()
+	rb := &rescanBackend{fakeBackend: fb, headerBase: base}
+	svc.backends.Register("BTC", rb)
+
+	// Block 50 was mined at base+30000; the margin moves two hours (12 blocks) back
+	height, err := svc.HeightAtTime(context.Background(), "BTC", time.Unix(base+50*600, 0), svc.store)
+	if err != nil {
+		t.Fatalf("HeightAtTime() error = %v", err)
+	}
+	if height != 37 {
+		t.Errorf("HeightAtTime() = %d, want 37", height)
+	}
+
+	// Without headers by height, the height is estimated from the block time
+	rb.headerBase = 0
+	height, err = svc.HeightAtTime(context.Background(), "BTC", time.Now().Add(-5*time.Hour), svc.store)
+	if err != nil {
+		t.Fatalf("HeightAtTime() estimate error = %v", err)
+	}
+	if height < 55 || height > 59 {
+		t.Errorf("HeightAtTime() estimate = %d, want about 58", height)
+	}
+}
@@ -3,7 +3,9 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"sync"
@@ -23,6 +25,11 @@ type Service struct {
 	// Backend registry for blockchain queries
 	backends *backend.Registry
 
+	// Spending policy and the ledger daily limits are tracked in
+	policy   *SpendingPolicy
+	store    *storage.Storage
+	policyMu sync.Mutex
+
 	mu sync.RWMutex
 }
 
@@ -31,6 +38,12 @@ type ServiceConfig struct {
 	DataDir  string
 	Network  chain.Network
 	Backends *backend.Registry
+
+	// Policy is enforced on every outbound send (nil = no restrictions).
+	Policy *SpendingPolicy
+
+	// Store holds the spend ledger; required when Policy sets daily limits.
+	Store *storage.Storage
 }
 
 // NewService creates a new wallet service.
@@ -58,6 +71,8 @@ func NewService(cfg *ServiceConfig) *Service {
 		dataDir:  dataDir,
 		network:  network,
 		backends: cfg.Backends,
+		policy:   cfg.Policy,
+		store:    cfg.Store,
 	}
 }
 
@@ -66,6 +81,12 @@ func (s *Service) GenerateMnemonic() (string, error) {
 	return GenerateMnemonic()
 }
 
+// GenerateMnemonicInLanguage generates a new 24-word mnemonic from a BIP39
+// wordlist.
+func (s *Service) GenerateMnemonicInLanguage(lang Language) (string, error) {
+	return GenerateMnemonicInLanguage(lang)
+}
+
 // ValidateMnemonic checks if a mnemonic is valid.
 func (s *Service) ValidateMnemonic(mnemonic string) bool {
 	return ValidateMnemonic(mnemonic)
@@ -89,6 +110,9 @@ func (s *Service) CreateWallet(mnemonic, passphrase, password string) error {
 		return fmt.Errorf("failed to create wallet: %w", err)
 	}
 	s.wallet = wallet
+	if err := s.loadHardwareAccounts(); err != nil {
+		return err
+	}
 
 	// Encrypt with Argon2id
 	encrypted, err := EncryptMnemonic(mnemonic, password)
@@ -132,7 +156,7 @@ func (s *Service) LoadWallet(password, passphrase string) error {
 	SecureClear([]byte(mnemonic))
 
 	s.wallet = wallet
-	return nil
+	return s.loadHardwareAccounts()
 }
 
 // IsUnlocked returns true if the wallet is loaded.
@@ -336,8 +360,27 @@ func (s *Service) GetUTXOs(ctx context.Context, symbol, address string) ([]backe
 	return b.GetAddressUTXOs(ctx, address)
 }
 
+// GetTransaction returns a transaction using the configured backend.
+func (s *Service) GetTransaction(ctx context.Context, symbol, txID string) (*backend.Transaction, error) {
+	if s.backends == nil {
+		return nil, fmt.Errorf("no backends configured")
+	}
+
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return nil, fmt.Errorf("no backend for chain: %s", symbol)
+	}
+
+	return b.GetTransaction(ctx, txID)
+}
+
 // BroadcastTx broadcasts a raw transaction.
+// Raw transactions cannot be checked against the spending policy, so they are
+// refused on chains the policy covers.
 func (s *Service) BroadcastTx(ctx context.Context, symbol, rawTxHex string) (string, error) {
+	if s.policy.coversChain(symbol) {
+		return "", fmt.Errorf("%w: raw broadcasts are disabled for %s", ErrPolicyViolation, symbol)
+	}
 	if s.backends == nil {
 		return "", fmt.Errorf("no backends configured")
 	}
@@ -519,6 +562,9 @@ func (s *Service) DerivePrivateKeyWithChange(symbol string, account, change, ind
 	if err != nil {
 		return nil, err
 	}
+	if !key.IsPrivate() {
+		return nil, ErrHardwareKey
+	}
 
 	return key.ECPrivKey()
 }
@@ -554,17 +600,12 @@ func (s *Service) SendTransactionFromPath(ctx context.Context, symbol string, to
 		return "", fmt.Errorf("unsupported chain: %s", symbol)
 	}
 
-	// Get sender address and private key with explicit change path
+	// Get sender address with explicit change path
 	fromAddress, err := s.wallet.DeriveAddressWithChange(symbol, account, change, index)
 	if err != nil {
 		return "", fmt.Errorf("failed to derive address: %w", err)
 	}
 
-	privKey, err := s.DerivePrivateKeyWithChange(symbol, account, change, index)
-	if err != nil {
-		return "", fmt.Errorf("failed to derive private key: %w", err)
-	}
-
 	// Get UTXOs
 	utxos, err := b.GetAddressUTXOs(ctx, fromAddress)
 	if err != nil {
@@ -585,14 +626,57 @@ func (s *Service) SendTransactionFromPath(ctx context.Context, symbol string, to
 	}
 
 	// Build and sign transaction
-	txHex, err := BuildAndSignTx(privKey, utxos, toAddress, fromAddress, amount, feeRate, params)
-	if err != nil {
-		return "", fmt.Errorf("failed to build transaction: %w", err)
+	var txHex string
+	if s.wallet.hardwareAccount(symbol) != nil {
+		// Signed on the device, through the multi-address builder
+		addrType := detectAddressType(fromAddress, params)
+		addrUTXOs := make([]*AddressUTXO, len(utxos))
+		for i, u := range utxos {
+			addrUTXOs[i] = &AddressUTXO{
+				TxID:          u.TxID,
+				Vout:          u.Vout,
+				Amount:        u.Amount,
+				Address:       fromAddress,
+				Account:       account,
+				Change:        change,
+				AddressIndex:  index,
+				AddressType:   addrType,
+				Confirmations: u.Confirmations,
+			}
+		}
+		result, err := s.buildAndSignMultiAddressTx(ctx, &MultiAddressTxParams{
+			UTXOs:         addrUTXOs,
+			ToAddress:     toAddress,
+			Amount:        amount,
+			ChangeAddress: fromAddress,
+			FeeRate:       feeRate,
+			Symbol:        symbol,
+			Network:       s.network,
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to build transaction: %w", err)
+		}
+		txHex = result.TxHex
+	} else {
+		privKey, err := s.DerivePrivateKeyWithChange(symbol, account, change, index)
+		if err != nil {
+			return "", fmt.Errorf("failed to derive private key: %w", err)
+		}
+		txHex, err = BuildAndSignTx(privKey, utxos, toAddress, fromAddress, amount, feeRate, params)
+		if err != nil {
+			return "", fmt.Errorf("failed to build transaction: %w", err)
+		}
 	}
 
 	// Broadcast
-	txid, err := b.BroadcastTransaction(ctx, txHex)
+	req := spendRequest{symbol: symbol, to: toAddress, amount: new(big.Int).SetUint64(amount)}
+	txid, err := s.guardedSend(ctx, req, func() (string, error) {
+		return b.BroadcastTransaction(ctx, txHex)
+	})
 	if err != nil {
+		if errors.Is(err, ErrPolicyViolation) || txid != "" {
+			return txid, err
+		}
 		return "", fmt.Errorf("failed to broadcast: %w", err)
 	}
 
@@ -663,7 +747,7 @@ func (s *Service) SendFromAllAddresses(ctx context.Context, symbol string, toAdd
 	}
 
 	// Build and sign multi-address transaction
-	result, err := BuildAndSignMultiAddressTx(s, &MultiAddressTxParams{
+	result, err := s.buildAndSignMultiAddressTx(ctx, &MultiAddressTxParams{
 		UTXOs:         utxos,
 		ToAddress:     toAddress,
 		Amount:        amount,
@@ -677,8 +761,19 @@ func (s *Service) SendFromAllAddresses(ctx context.Context, symbol string, toAdd
 	}
 
 	// Broadcast
-	txid, err := b.BroadcastTransaction(ctx, result.TxHex)
+	req := spendRequest{symbol: symbol, to: toAddress, amount: new(big.Int).SetUint64(amount)}
+	txid, err := s.guardedSend(ctx, req, func() (string, error) {
+		return b.BroadcastTransaction(ctx, result.TxHex)
+	})
 	if err != nil {
+		if txid != "" {
+			// Broadcast but not recorded: return the txid so the caller doesn't resend
+			result.TxID = txid
+			return result, err
+		}
+		if errors.Is(err, ErrPolicyViolation) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to broadcast: %w", err)
 	}
 
@@ -736,7 +831,7 @@ func (s *Service) SendMaxFromAllAddresses(ctx context.Context, symbol string, to
 	}
 
 	// Build max send transaction
-	result, err := BuildSendMaxTx(s, &SendMaxParams{
+	result, err := s.buildSendMaxTx(ctx, &SendMaxParams{
 		UTXOs:     utxos,
 		ToAddress: toAddress,
 		FeeRate:   feeRate,
@@ -748,8 +843,19 @@ func (s *Service) SendMaxFromAllAddresses(ctx context.Context, symbol string, to
 	}
 
 	// Broadcast
-	txid, err := b.BroadcastTransaction(ctx, result.TxHex)
+	req := spendRequest{symbol: symbol, to: toAddress, amount: new(big.Int).SetUint64(result.TotalOutput)}
+	txid, err := s.guardedSend(ctx, req, func() (string, error) {
+		return b.BroadcastTransaction(ctx, result.TxHex)
+	})
 	if err != nil {
+		if txid != "" {
+			// Broadcast but not recorded: return the txid so the caller doesn't resend
+			result.TxID = txid
+			return result, err
+		}
+		if errors.Is(err, ErrPolicyViolation) {
+			return nil, err
+		}
 		return nil, fmt.Errorf("failed to broadcast: %w", err)
 	}
 
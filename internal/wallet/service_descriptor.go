@@ -0,0 +1,527 @@
+// Package wallet - Imported output descriptor wallets.
+//
+// Imported descriptors are watch-only unless the wallet can sign for them:
+// either the descriptor carried an extended private key (kept sealed with a
+// key derived from the wallet seed) or its key origin resolves to the wallet's
+// own seed. Signing supports pkh, wpkh and tr descriptors.
+package wallet
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/btcsuite/btcd/btcec/v2"
+)
+
+// ErrWatchOnly is returned when spending from a descriptor the wallet cannot sign for.
+var ErrWatchOnly = errors.New("descriptor is watch-only")
+
+// descriptorSealDomain separates the descriptor sealing key from other uses of the seed.
+const descriptorSealDomain = "klingdex/descriptor-seal/v1"
+
+// DescriptorInfo describes an imported descriptor.
+type DescriptorInfo struct {
+	ID         string `json:"id"`
+	Symbol     string `json:"symbol"`
+	Descriptor string `json:"descriptor"` // Public form with checksum
+	Label      string `json:"label,omitempty"`
+	Type       string `json:"type"`
+	WatchOnly  bool   `json:"watch_only"`
+	Range      bool   `json:"range"`
+	Branches   int    `json:"branches"`
+	CreatedAt  int64  `json:"created_at"`
+}
+
+// DescriptorAddress is an address derived from a descriptor.
+type DescriptorAddress struct {
+	Address string `json:"address"`
+	Path    string `json:"path"`
+	Branch  int    `json:"branch"`
+	Index   uint32 `json:"index"`
+}
+
+// ImportDescriptor parses, validates and stores an output descriptor for a
+// chain. Importing the same descriptor again updates its label.
+func (s *Service) ImportDescriptor(symbol, descriptor, label string) (*DescriptorInfo, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	if s.store == nil {
+		return nil, fmt.Errorf("no storage configured")
+	}
+
+	params, ok := chain.Get(symbol, s.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	if params.Type != chain.ChainTypeBitcoin {
+		return nil, fmt.Errorf("descriptors are only supported on Bitcoin-family chains")
+	}
+
+	d, err := ParseDescriptor(descriptor)
+	if err != nil {
+		return nil, err
+	}
+	// Fail early if the script type is not available on this chain
+	if _, err := d.DeriveAddress(0, 0, params); err != nil {
+		return nil, err
+	}
+
+	rec := &storage.WalletDescriptor{
+		ID:         descriptorID(symbol, d),
+		Symbol:     symbol,
+		Descriptor: d.String(),
+		Label:      label,
+		WatchOnly:  true,
+		CreatedAt:  time.Now(),
+	}
+	if existing, err := s.store.GetWalletDescriptor(rec.ID); err != nil {
+		return nil, fmt.Errorf("failed to load descriptor: %w", err)
+	} else if existing != nil {
+		rec.CreatedAt = existing.CreatedAt
+		rec.SealedPrivate = existing.SealedPrivate
+	}
+
+	if d.HasPrivateKey() {
+		sealed, err := s.wallet.sealDescriptor(d.PrivateString())
+		if err != nil {
+			return nil, err
+		}
+		rec.SealedPrivate = sealed
+	}
+	if rec.SealedPrivate != nil || s.wallet.seedSigner(d) != nil {
+		rec.WatchOnly = d.Type == DescriptorSHWPKH
+	}
+
+	if err := s.store.SaveWalletDescriptor(rec); err != nil {
+		return nil, fmt.Errorf("failed to save descriptor: %w", err)
+	}
+	return descriptorInfo(rec, d), nil
+}
+
+// ListDescriptors returns the imported descriptors of a chain, or of all
+// chains if symbol is empty.
+func (s *Service) ListDescriptors(symbol string) ([]*DescriptorInfo, error) {
+	if s.store == nil {
+		return nil, fmt.Errorf("no storage configured")
+	}
+
+	records, err := s.store.ListWalletDescriptors(symbol)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]*DescriptorInfo, 0, len(records))
+	for _, rec := range records {
+		d, err := ParseDescriptor(rec.Descriptor)
+		if err != nil {
+			return nil, fmt.Errorf("stored descriptor %s: %w", rec.ID, err)
+		}
+		infos = append(infos, descriptorInfo(rec, d))
+	}
+	return infos, nil
+}
+
+// RemoveDescriptor deletes an imported descriptor.
+func (s *Service) RemoveDescriptor(id string) error {
+	if s.store == nil {
+		return fmt.Errorf("no storage configured")
+	}
+	return s.store.DeleteWalletDescriptor(id)
+}
+
+// DescriptorAddresses derives count addresses of a descriptor branch starting at start.
+func (s *Service) DescriptorAddresses(id string, branch int, start, count uint32) ([]DescriptorAddress, error) {
+	rec, d, err := s.loadDescriptor(id)
+	if err != nil {
+		return nil, err
+	}
+	params, ok := chain.Get(rec.Symbol, s.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", rec.Symbol)
+	}
+	if !d.IsRange() {
+		start, count = 0, 1
+	}
+
+	addresses := make([]DescriptorAddress, 0, count)
+	for index := start; index < start+count; index++ {
+		address, err := d.DeriveAddress(branch, index, params)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, DescriptorAddress{
+			Address: address,
+			Path:    d.Path(branch, index),
+			Branch:  branch,
+			Index:   index,
+		})
+	}
+	return addresses, nil
+}
+
+// ScanDescriptor scans the receive and change branches of a descriptor up to
+// the gap limit.
+func (s *Service) ScanDescriptor(ctx context.Context, id string, gapLimit uint32) (*ScanResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rec, d, err := s.loadDescriptor(id)
+	if err != nil {
+		return nil, err
+	}
+	b, params, err := s.descriptorBackend(rec.Symbol)
+	if err != nil {
+		return nil, err
+	}
+	return scanDescriptor(ctx, b, params, d, gapLimit), nil
+}
+
+// SendFromDescriptor builds, signs and broadcasts a transaction spending the
+// UTXOs of an imported descriptor. Change goes to the next unused address of
+// the change branch (or the receive branch without multipath).
+func (s *Service) SendFromDescriptor(ctx context.Context, id, toAddress string, amount uint64) (*MultiAddressTxResult, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	rec, d, err := s.loadDescriptor(id)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := s.descriptorSigner(rec, d)
+	if err != nil {
+		return nil, err
+	}
+	b, params, err := s.descriptorBackend(rec.Symbol)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, nextChange, err := descriptorUTXOs(ctx, b, params, d)
+	if err != nil {
+		return nil, err
+	}
+	changeAddr, err := d.DeriveAddress(d.Branches()-1, nextChange, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive change address: %w", err)
+	}
+
+	feeEst, err := b.GetFeeEstimates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fee estimates: %w", err)
+	}
+	feeRate := feeEst.HalfHourFee
+	if feeRate == 0 {
+		feeRate = 10
+	}
+
+	result, err := BuildAndSignMultiAddressTx(descriptorKeyDeriver{signer}, &MultiAddressTxParams{
+		UTXOs:         utxos,
+		ToAddress:     toAddress,
+		Amount:        amount,
+		ChangeAddress: changeAddr,
+		FeeRate:       feeRate,
+		Symbol:        rec.Symbol,
+		Network:       s.network,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to build transaction: %w", err)
+	}
+
+	req := spendRequest{symbol: rec.Symbol, to: toAddress, amount: new(big.Int).SetUint64(amount)}
+	txid, err := s.guardedSend(ctx, req, func() (string, error) {
+		return b.BroadcastTransaction(ctx, result.TxHex)
+	})
+	if err != nil {
+		if txid != "" {
+			// Broadcast but not recorded: return the txid so the caller doesn't resend
+			result.TxID = txid
+			return result, err
+		}
+		if errors.Is(err, ErrPolicyViolation) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+	result.TxID = txid
+	return result, nil
+}
+
+// descriptorUTXOs returns the UTXOs of d found within the gap limit and the
+// next unused index of its change branch.
+func descriptorUTXOs(ctx context.Context, b backend.Backend, params *chain.Params, d *Descriptor) ([]*AddressUTXO, uint32, error) {
+	scan := scanDescriptor(ctx, b, params, d, DefaultGapLimit)
+	var utxos []*AddressUTXO
+	changeBranch := d.Branches() - 1
+	nextChange := uint32(0)
+	for _, addr := range scan.Addresses {
+		branch := 0
+		if addr.IsChange {
+			branch = 1
+		}
+		if branch == changeBranch && addr.Index >= nextChange {
+			nextChange = addr.Index + 1
+		}
+
+		addrUTXOs, err := b.GetAddressUTXOs(ctx, addr.Address)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get UTXOs for %s: %w", addr.Address, err)
+		}
+		for _, u := range addrUTXOs {
+			utxos = append(utxos, &AddressUTXO{
+				TxID:         u.TxID,
+				Vout:         u.Vout,
+				Amount:       u.Amount,
+				Address:      addr.Address,
+				Change:       uint32(branch),
+				AddressIndex: addr.Index,
+				AddressType:  d.addressType(),
+			})
+		}
+	}
+	if len(utxos) == 0 {
+		return nil, 0, fmt.Errorf("no spendable UTXOs found")
+	}
+
+	if !d.IsRange() {
+		nextChange = 0
+	}
+	return utxos, nextChange, nil
+}
+
+// scanDescriptor scans all branches of d with the gap limit.
+func scanDescriptor(ctx context.Context, b backend.Backend, params *chain.Params, d *Descriptor, gapLimit uint32) *ScanResult {
+	if gapLimit == 0 {
+		gapLimit = DefaultGapLimit
+	}
+	if !d.IsRange() {
+		gapLimit = 1
+	}
+
+	result := &ScanResult{Symbol: params.Symbol, Addresses: make([]AddressBalance, 0)}
+	for branch := 0; branch < d.Branches(); branch++ {
+		var index uint32
+		for empty := uint32(0); empty < gapLimit; index++ {
+			address, err := d.DeriveAddress(branch, index, params)
+			if err != nil {
+				break
+			}
+			info, err := b.GetAddressInfo(ctx, address)
+			if err != nil || (info.Balance == 0 && info.TxCount == 0) {
+				empty++
+				continue
+			}
+			empty = 0
+
+			addr := AddressBalance{
+				Address:  address,
+				Path:     d.Path(branch, index),
+				Balance:  info.Balance,
+				IsChange: branch > 0,
+				Index:    index,
+			}
+			result.Addresses = append(result.Addresses, addr)
+			if addr.IsChange {
+				result.ChangeBalance += addr.Balance
+			} else {
+				result.ExternalBalance += addr.Balance
+			}
+		}
+		if branch == 0 {
+			result.ScannedExternal = index
+		} else {
+			result.ScannedChange += index
+		}
+	}
+	result.TotalBalance = result.ExternalBalance + result.ChangeBalance
+	return result
+}
+
+func (s *Service) loadDescriptor(id string) (*storage.WalletDescriptor, *Descriptor, error) {
+	if s.store == nil {
+		return nil, nil, fmt.Errorf("no storage configured")
+	}
+	rec, err := s.store.GetWalletDescriptor(id)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load descriptor: %w", err)
+	}
+	if rec == nil {
+		return nil, nil, storage.ErrWalletDescriptorNotFound
+	}
+	d, err := ParseDescriptor(rec.Descriptor)
+	if err != nil {
+		return nil, nil, fmt.Errorf("stored descriptor %s: %w", id, err)
+	}
+	return rec, d, nil
+}
+
+func (s *Service) descriptorBackend(symbol string) (backend.Backend, *chain.Params, error) {
+	if s.backends == nil {
+		return nil, nil, fmt.Errorf("no backends configured")
+	}
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return nil, nil, fmt.Errorf("no backend for chain: %s", symbol)
+	}
+	params, ok := chain.Get(symbol, s.network)
+	if !ok {
+		return nil, nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	return b, params, nil
+}
+
+// descriptorSigner returns a private copy of d, or ErrWatchOnly.
+func (s *Service) descriptorSigner(rec *storage.WalletDescriptor, d *Descriptor) (*Descriptor, error) {
+	if d.Type == DescriptorSHWPKH {
+		return nil, fmt.Errorf("%w: signing %s descriptors is not supported", ErrWatchOnly, d.Type)
+	}
+	if rec.SealedPrivate != nil {
+		plain, err := s.wallet.openDescriptor(rec.SealedPrivate)
+		if err != nil {
+			return nil, err
+		}
+		return ParseDescriptor(plain)
+	}
+	if signer := s.wallet.seedSigner(d); signer != nil {
+		return signer, nil
+	}
+	return nil, ErrWatchOnly
+}
+
+// seedSigner returns a private copy of d if its key origin is derived from
+// the wallet seed, or nil.
+func (w *Wallet) seedSigner(d *Descriptor) *Descriptor {
+	if d.Origin == nil {
+		return nil
+	}
+	fp, err := w.MasterFingerprint()
+	if err != nil || fp != d.Origin.Fingerprint {
+		return nil
+	}
+	key, err := w.DeriveKeyAtPath(d.Origin.Path)
+	if err != nil {
+		return nil
+	}
+	signer, err := d.withPrivateKey(key)
+	if err != nil {
+		return nil
+	}
+	return signer
+}
+
+// addressType returns the AddressUTXO address type of d's outputs.
+func (d *Descriptor) addressType() string {
+	switch d.Type {
+	case DescriptorWPKH:
+		return "p2wpkh"
+	case DescriptorTR:
+		return "p2tr"
+	case DescriptorSHWPKH:
+		return "p2sh-p2wpkh"
+	default:
+		return "p2pkh"
+	}
+}
+
+// descriptorKeyDeriver signs multi-address transactions with descriptor keys:
+// the UTXO's change field is the branch and the account is ignored.
+type descriptorKeyDeriver struct {
+	d *Descriptor
+}
+
+func (k descriptorKeyDeriver) DerivePrivateKeyWithChange(symbol string, account, change, index uint32) (*btcec.PrivateKey, error) {
+	key, err := k.d.DeriveKey(int(change), index)
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPrivKey()
+}
+
+// sealKey derives the AES-256 key that seals private descriptors.
+func (w *Wallet) sealKey() ([]byte, error) {
+	priv, err := w.masterKey.ECPrivKey()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(append([]byte(descriptorSealDomain), priv.Serialize()...))
+	return sum[:], nil
+}
+
+// sealDescriptor encrypts a private descriptor with AES-256-GCM (nonce || ciphertext).
+func (w *Wallet) sealDescriptor(plain string) ([]byte, error) {
+	gcm, err := w.descriptorCipher()
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, []byte(plain), nil), nil
+}
+
+// openDescriptor decrypts a descriptor sealed by sealDescriptor.
+func (w *Wallet) openDescriptor(sealed []byte) (string, error) {
+	gcm, err := w.descriptorCipher()
+	if err != nil {
+		return "", err
+	}
+	if len(sealed) < gcm.NonceSize() {
+		return "", fmt.Errorf("sealed descriptor too short")
+	}
+	plain, err := gcm.Open(nil, sealed[:gcm.NonceSize()], sealed[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to open sealed descriptor (different wallet seed?): %w", err)
+	}
+	return string(plain), nil
+}
+
+func (w *Wallet) descriptorCipher() (cipher.AEAD, error) {
+	key, err := w.sealKey()
+	if err != nil {
+		return nil, err
+	}
+	defer SecureClear(key)
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// descriptorID identifies a descriptor on a chain.
+func descriptorID(symbol string, d *Descriptor) string {
+	sum := sha256.Sum256([]byte(symbol + ":" + d.String()))
+	return hex.EncodeToString(sum[:8])
+}
+
+func descriptorInfo(rec *storage.WalletDescriptor, d *Descriptor) *DescriptorInfo {
+	return &DescriptorInfo{
+		ID:         rec.ID,
+		Symbol:     rec.Symbol,
+		Descriptor: rec.Descriptor,
+		Label:      rec.Label,
+		Type:       string(d.Type),
+		WatchOnly:  rec.WatchOnly,
+		Range:      d.IsRange(),
+		Branches:   d.Branches(),
+		CreatedAt:  rec.CreatedAt.Unix(),
+	}
+}
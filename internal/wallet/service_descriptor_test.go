@@ -0,0 +1,257 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// fakeBackend serves address balances and UTXOs from memory.
+type fakeBackend struct {
+	utxos     map[string][]backend.UTXO
+	broadcast []string
+}
+
+func (f *fakeBackend) Type() backend.Type                                { return backend.TypeEsplora }
+func (f *fakeBackend) Connect(ctx context.Context) error                 { return nil }
+func (f *fakeBackend) Close() error                                      { return nil }
+func (f *fakeBackend) IsConnected() bool                                 { return true }
+func (f *fakeBackend) GetBlockHeight(ctx context.Context) (int64, error) { return 100, nil }
+
+func (f *fakeBackend) GetAddressInfo(ctx context.Context, address string) (*backend.AddressInfo, error) {
+	info := &backend.AddressInfo{Address: address}
+	for _, u := range f.utxos[address] {
+		info.Balance += u.Amount
+		info.TxCount++
+	}
+	return info, nil
+}
+
+func (f *fakeBackend) GetAddressUTXOs(ctx context.Context, address string) ([]backend.UTXO, error) {
+	return f.utxos[address], nil
+}
+
+func (f *fakeBackend) GetAddressTxs(ctx context.Context, address, lastSeenTxID string) ([]backend.Transaction, error) {
+	return nil, nil
+}
+
+func (f *fakeBackend) GetTransaction(ctx context.Context, txID string) (*backend.Transaction, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeBackend) GetRawTransaction(ctx context.Context, txID string) ([]byte, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	f.broadcast = append(f.broadcast, rawTxHex)
+	return "txid", nil
+}
+
+func (f *fakeBackend) GetBlockHeader(ctx context.Context, hashOrHeight string) (*backend.BlockHeader, error) {
+	return nil, errors.New("not found")
+}
+
+func (f *fakeBackend) GetFeeEstimates(ctx context.Context) (*backend.FeeEstimate, error) {
+	return &backend.FeeEstimate{HalfHourFee: 2}, nil
+}
+
+func newDescriptorTestService(t *testing.T) (*Service, *fakeBackend) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	fb := &fakeBackend{utxos: make(map[string][]backend.UTXO)}
+	registry := backend.NewRegistry()
+	registry.Register("BTC", fb)
+
+	svc := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Backends: registry, Store: store})
+	if err := svc.CreateWallet(testMnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	return svc, fb
+}
+
+// foreignAccountKey returns an account key that is not derived from the test wallet.
+func foreignAccountKey(t *testing.T) *hdkeychain.ExtendedKey {
+	t.Helper()
+	other, err := NewFromMnemonic("legal winner thank year wave sausage worth useful legal winner thank yellow", "", chain.Testnet)
+	if err != nil {
+		t.Fatalf("NewFromMnemonic() error = %v", err)
+	}
+	key, err := other.DeriveKeyAtPath([]uint32{hdkeychain.HardenedKeyStart + 84, hdkeychain.HardenedKeyStart + 1, hdkeychain.HardenedKeyStart})
+	if err != nil {
+		t.Fatalf("DeriveKeyAtPath() error = %v", err)
+	}
+	return key
+}
+
+func TestImportDescriptor(t *testing.T) {
+	svc, _ := newDescriptorTestService(t)
+
+	own := accountDescriptor(t, svc.GetWallet(), "wpkh", 84, 1, "<0;1>/*")
+	info, err := svc.ImportDescriptor("BTC", own, "core")
+	if err != nil {
+		t.Fatalf("ImportDescriptor(own) error = %v", err)
+	}
+	if info.WatchOnly || !info.Range || info.Branches != 2 || info.Type != "wpkh" || info.Label != "core" {
+		t.Errorf("ImportDescriptor(own) = %+v, want signing range descriptor", info)
+	}
+
+	foreign := foreignAccountKey(t)
+	pub, _ := foreign.Neuter()
+	watch, err := svc.ImportDescriptor("BTC", "wpkh("+pub.String()+"/0/*)", "")
+	if err != nil {
+		t.Fatalf("ImportDescriptor(xpub) error = %v", err)
+	}
+	if !watch.WatchOnly {
+		t.Error("foreign xpub descriptor should be watch-only")
+	}
+
+	private, err := svc.ImportDescriptor("BTC", "wpkh("+foreign.String()+"/1/*)", "sparrow")
+	if err != nil {
+		t.Fatalf("ImportDescriptor(xprv) error = %v", err)
+	}
+	if private.WatchOnly || strings.Contains(private.Descriptor, "tprv") {
+		t.Errorf("ImportDescriptor(xprv) = %+v, want signing public descriptor", private)
+	}
+	rec, _ := svc.store.GetWalletDescriptor(private.ID)
+	if rec.SealedPrivate == nil || bytes.Contains(rec.SealedPrivate, []byte(foreign.String())) {
+		t.Error("private descriptor should be stored sealed")
+	}
+
+	// Re-import keeps the ID and updates the label
+	again, err := svc.ImportDescriptor("BTC", own, "renamed")
+	if err != nil || again.ID != info.ID || again.Label != "renamed" {
+		t.Errorf("re-import = %+v, %v", again, err)
+	}
+
+	list, err := svc.ListDescriptors("BTC")
+	if err != nil || len(list) != 3 {
+		t.Fatalf("ListDescriptors() = %d, %v, want 3", len(list), err)
+	}
+
+	if _, err := svc.ImportDescriptor("ETH", own, ""); err == nil {
+		t.Error("ImportDescriptor(ETH) should fail")
+	}
+	if _, err := svc.ImportDescriptor("DOGE", accountDescriptor(t, svc.GetWallet(), "tr", 86, 1, "0/*"), ""); err == nil {
+		t.Error("ImportDescriptor(tr on DOGE) should fail")
+	}
+
+	if err := svc.RemoveDescriptor(watch.ID); err != nil {
+		t.Fatalf("RemoveDescriptor() error = %v", err)
+	}
+	if _, err := svc.SendFromDescriptor(context.Background(), watch.ID, "tb1qdest", 1000); !errors.Is(err, storage.ErrWalletDescriptorNotFound) {
+		t.Errorf("SendFromDescriptor(removed) error = %v", err)
+	}
+}
+
+func TestDescriptorAddresses(t *testing.T) {
+	svc, _ := newDescriptorTestService(t)
+	info, err := svc.ImportDescriptor("BTC", accountDescriptor(t, svc.GetWallet(), "wpkh", 84, 1, "<0;1>/*"), "")
+	if err != nil {
+		t.Fatalf("ImportDescriptor() error = %v", err)
+	}
+
+	addrs, err := svc.DescriptorAddresses(info.ID, 1, 5, 3)
+	if err != nil || len(addrs) != 3 {
+		t.Fatalf("DescriptorAddresses() = %d, %v, want 3", len(addrs), err)
+	}
+	want, _ := svc.GetAddressWithChange("BTC", 0, 1, 6)
+	if addrs[1].Address != want || addrs[1].Path != "m/84h/1h/0h/1/6" || addrs[1].Branch != 1 || addrs[1].Index != 6 {
+		t.Errorf("DescriptorAddresses()[1] = %+v, want %s", addrs[1], want)
+	}
+}
+
+func TestScanAndSendFromDescriptor(t *testing.T) {
+	svc, fb := newDescriptorTestService(t)
+	params, _ := chain.Get("BTC", chain.Testnet)
+
+	foreign := foreignAccountKey(t)
+	info, err := svc.ImportDescriptor("BTC", "wpkh("+foreign.String()+"/<0;1>/*)", "")
+	if err != nil {
+		t.Fatalf("ImportDescriptor() error = %v", err)
+	}
+	d, _ := ParseDescriptor(info.Descriptor)
+
+	// Funds on receive index 3 and change index 0
+	recv, _ := d.DeriveAddress(0, 3, params)
+	change, _ := d.DeriveAddress(1, 0, params)
+	txid := strings.Repeat("ab", 32)
+	fb.utxos[recv] = []backend.UTXO{{TxID: txid, Vout: 0, Amount: 50000}}
+	fb.utxos[change] = []backend.UTXO{{TxID: txid, Vout: 1, Amount: 20000}}
+
+	scan, err := svc.ScanDescriptor(context.Background(), info.ID, 5)
+	if err != nil {
+		t.Fatalf("ScanDescriptor() error = %v", err)
+	}
+	if scan.TotalBalance != 70000 || scan.ExternalBalance != 50000 || scan.ChangeBalance != 20000 || len(scan.Addresses) != 2 {
+		t.Errorf("ScanDescriptor() = %+v", scan)
+	}
+	if scan.ScannedExternal != 9 || scan.ScannedChange != 6 {
+		t.Errorf("scanned = %d/%d, want 9/6", scan.ScannedExternal, scan.ScannedChange)
+	}
+
+	dest, _ := svc.GetAddress("BTC", 0, 0)
+	result, err := svc.SendFromDescriptor(context.Background(), info.ID, dest, 60000)
+	if err != nil {
+		t.Fatalf("SendFromDescriptor() error = %v", err)
+	}
+	if len(fb.broadcast) != 1 || result.InputCount != 2 {
+		t.Fatalf("broadcast %d txs with %d inputs, want 1 with 2", len(fb.broadcast), result.InputCount)
+	}
+
+	// Every input must satisfy its descriptor script
+	raw, _ := hex.DecodeString(fb.broadcast[0])
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		t.Fatalf("Deserialize() error = %v", err)
+	}
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for addr, utxos := range fb.utxos {
+		script, _ := ParseAddressToScript(addr, params)
+		for _, u := range utxos {
+			op := tx.TxIn[0].PreviousOutPoint
+			op.Index = u.Vout
+			prevOuts.AddPrevOut(op, wire.NewTxOut(int64(u.Amount), script))
+		}
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, in := range tx.TxIn {
+		prev := prevOuts.FetchPrevOutput(in.PreviousOutPoint)
+		vm, err := txscript.NewEngine(prev.PkScript, tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prev.Value, prevOuts)
+		if err != nil {
+			t.Fatalf("NewEngine(%d) error = %v", i, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d does not verify: %v", i, err)
+		}
+	}
+
+	// Change goes to the next unused change address
+	nextChange, _ := d.DeriveAddress(1, 1, params)
+	changeScript, _ := ParseAddressToScript(nextChange, params)
+	if len(tx.TxOut) != 2 || !bytes.Equal(tx.TxOut[1].PkScript, changeScript) {
+		t.Errorf("change output does not pay %s", nextChange)
+	}
+
+	// Watch-only descriptors cannot spend
+	pub, _ := foreign.Neuter()
+	watch, _ := svc.ImportDescriptor("BTC", "wpkh("+pub.String()+"/0/*)", "")
+	if _, err := svc.SendFromDescriptor(context.Background(), watch.ID, dest, 1000); !errors.Is(err, ErrWatchOnly) {
+		t.Errorf("SendFromDescriptor(watch-only) error = %v, want ErrWatchOnly", err)
+	}
+}
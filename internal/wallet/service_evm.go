@@ -3,6 +3,7 @@ package wallet
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"math/big"
 
@@ -97,17 +98,28 @@ func (s *Service) SendEVMTransaction(ctx context.Context, symbol string, toAddre
 	}
 
 	// Broadcast
-	txHash, err := evmBackend.BroadcastTransaction(ctx, txResult.RawTx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to broadcast: %w", err)
-	}
-
-	return &EVMSendResult{
+	req := spendRequest{symbol: symbol, to: toAddress, amount: amount}
+	txHash, err := s.guardedSend(ctx, req, func() (string, error) {
+		return evmBackend.BroadcastTransaction(ctx, txResult.RawTx)
+	})
+	result := &EVMSendResult{
 		TxHash:   txHash,
 		Nonce:    nonce,
 		GasLimit: gasLimit,
 		GasPrice: gasPrice,
-	}, nil
+	}
+	if err != nil {
+		if txHash != "" {
+			// Broadcast but not recorded: return the hash so the caller doesn't resend
+			return result, err
+		}
+		if errors.Is(err, ErrPolicyViolation) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+
+	return result, nil
 }
 
 // SendERC20Transaction sends an ERC-20 token transfer.
@@ -203,17 +215,28 @@ func (s *Service) SendERC20Transaction(ctx context.Context, symbol string, token
 	}
 
 	// Broadcast
-	txHash, err := evmBackend.BroadcastTransaction(ctx, txResult.RawTx)
-	if err != nil {
-		return nil, fmt.Errorf("failed to broadcast: %w", err)
-	}
-
-	return &EVMSendResult{
+	req := spendRequest{symbol: symbol, token: tokenContract, to: toAddress, amount: amount}
+	txHash, err := s.guardedSend(ctx, req, func() (string, error) {
+		return evmBackend.BroadcastTransaction(ctx, txResult.RawTx)
+	})
+	result := &EVMSendResult{
 		TxHash:   txHash,
 		Nonce:    nonce,
 		GasLimit: gasLimit,
 		GasPrice: gasPrice,
-	}, nil
+	}
+	if err != nil {
+		if txHash != "" {
+			// Broadcast but not recorded: return the hash so the caller doesn't resend
+			return result, err
+		}
+		if errors.Is(err, ErrPolicyViolation) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to broadcast: %w", err)
+	}
+
+	return result, nil
 }
 
 // GetERC20Balance returns the balance of an ERC-20 token for an address.
@@ -377,6 +400,59 @@ func (s *Service) GetEVMBalance(ctx context.Context, symbol string, account, ind
 	return new(big.Int).SetUint64(balance), nil
 }
 
+// IsEVMContract reports whether an EVM address has deployed code (token/contract vs EOA).
+func (s *Service) IsEVMContract(ctx context.Context, symbol, address string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.backends == nil {
+		return false, fmt.Errorf("no backends configured")
+	}
+
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return false, fmt.Errorf("no backend for chain: %s", symbol)
+	}
+
+	evmBackend, ok := b.(*backend.JSONRPCBackend)
+	if !ok || !evmBackend.IsEVM() {
+		return false, fmt.Errorf("backend for %s is not an EVM backend", symbol)
+	}
+
+	code, err := evmBackend.EVMGetCode(ctx, address)
+	if err != nil {
+		return false, fmt.Errorf("failed to get code: %w", err)
+	}
+
+	return len(code) > 0, nil
+}
+
+// GetEVMGasPrice returns the current gas price of an EVM chain in wei.
+func (s *Service) GetEVMGasPrice(ctx context.Context, symbol string) (*big.Int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.backends == nil {
+		return nil, fmt.Errorf("no backends configured")
+	}
+
+	b, ok := s.backends.Get(symbol)
+	if !ok {
+		return nil, fmt.Errorf("no backend for chain: %s", symbol)
+	}
+
+	evmBackend, ok := b.(*backend.JSONRPCBackend)
+	if !ok || !evmBackend.IsEVM() {
+		return nil, fmt.Errorf("backend for %s is not an EVM backend", symbol)
+	}
+
+	gasPrice, err := evmBackend.EVMGetGasPrice(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gas price: %w", err)
+	}
+	return gasPrice, nil
+}
+
 // IsEVMChain returns true if the given symbol is an EVM chain.
 func (s *Service) IsEVMChain(symbol string) bool {
 	params, ok := chain.Get(symbol, s.network)
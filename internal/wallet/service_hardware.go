@@ -0,0 +1,326 @@
+// Package wallet - Hardware wallet signer selection.
+//
+// A Bitcoin-family chain is signed either in software, with keys derived
+// from the mnemonic, or by a hardware wallet. Selecting a hardware wallet
+// stores its account extended public key; the chain's addresses are then
+// derived from it and its inputs are signed on the device, which must be
+// connected whenever funds are spent. Addresses scanned before switching
+// signers belong to the previous keys.
+package wallet
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// signerSettingPrefix prefixes the settings holding chains' hardware
+// accounts, e.g. wallet_signer:BTC.
+const signerSettingPrefix = "wallet_signer:"
+
+// HardwareAccount is a chain's wallet account on a hardware wallet.
+type HardwareAccount struct {
+	Symbol  string `json:"symbol"`
+	Type    string `json:"type"` // ledger or trezor
+	Model   string `json:"model"`
+	Account uint32 `json:"account"`
+	Path    string `json:"path"` // e.g. m/84h/0h/0h
+	XPub    string `json:"xpub"`
+
+	key *hdkeychain.ExtendedKey
+}
+
+// deriveKey derives the public key of an address of the account.
+func (a *HardwareAccount) deriveKey(account, change, index uint32) (*hdkeychain.ExtendedKey, error) {
+	if account != a.Account {
+		return nil, fmt.Errorf("%s is signed by account %d on a %s, not account %d", a.Symbol, a.Account, a.Type, account)
+	}
+	changeKey, err := a.key.Derive(change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive change: %w", err)
+	}
+	addressKey, err := changeKey.Derive(index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive address: %w", err)
+	}
+	return addressKey, nil
+}
+
+// AddressVerification is an address derived locally and shown on the
+// hardware wallet holding its key.
+type AddressVerification struct {
+	Symbol        string `json:"symbol"`
+	Path          string `json:"path"`
+	Address       string `json:"address"`
+	DeviceAddress string `json:"device_address"`
+	Match         bool   `json:"match"`
+}
+
+// hardwareAccount returns the hardware account of a chain, or nil if it's
+// signed in software.
+func (w *Wallet) hardwareAccount(symbol string) *HardwareAccount {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.hardware[symbol]
+}
+
+func (w *Wallet) setHardwareAccount(acct *HardwareAccount) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.hardware == nil {
+		w.hardware = make(map[string]*HardwareAccount)
+	}
+	w.hardware[acct.Symbol] = acct
+}
+
+func (w *Wallet) clearHardwareAccount(symbol string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.hardware, symbol)
+}
+
+// ListHardwareWallets lists the connected hardware wallets.
+func (s *Service) ListHardwareWallets() ([]DeviceInfo, error) {
+	return EnumerateDevices()
+}
+
+// SetSigner selects how a Bitcoin-family chain is signed: SignerSoftware,
+// or account of the SignerLedger or SignerTrezor at devicePath (the first
+// one connected if empty). Returns the hardware account, or nil for
+// software signing.
+func (s *Service) SetSigner(ctx context.Context, symbol, signerType, devicePath string, account uint32) (*HardwareAccount, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	params, ok := chain.Get(symbol, s.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	if params.Type != chain.ChainTypeBitcoin {
+		return nil, fmt.Errorf("%s can only be signed in software", symbol)
+	}
+
+	if signerType == SignerSoftware {
+		if err := s.saveHardwareAccount(symbol, ""); err != nil {
+			return nil, err
+		}
+		s.wallet.clearHardwareAccount(symbol)
+		return nil, nil
+	}
+	if signerType != SignerLedger && signerType != SignerTrezor {
+		return nil, fmt.Errorf("unknown signer type: %s", signerType)
+	}
+	if !hardwareChains[symbol] {
+		return nil, fmt.Errorf("hardware wallets only sign BTC and LTC, not %s", symbol)
+	}
+	if _, err := deviceAddressType(params); err != nil {
+		return nil, err
+	}
+
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+	var info *DeviceInfo
+	for i := range devices {
+		if devices[i].Type == signerType && (devicePath == "" || devices[i].Path == devicePath) {
+			info = &devices[i]
+			break
+		}
+	}
+	if info == nil {
+		return nil, fmt.Errorf("%w: no %s found", ErrNoDevice, signerType)
+	}
+
+	device, err := OpenDevice(ctx, *info)
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+
+	path := accountPath(params, account)
+	key, err := device.PublicKey(ctx, params, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account key: %w", err)
+	}
+	acct := &HardwareAccount{
+		Symbol:  symbol,
+		Type:    signerType,
+		Model:   info.Model,
+		Account: account,
+		Path:    formatPath(path),
+		XPub:    key.String(),
+		key:     key,
+	}
+
+	data, err := json.Marshal(acct)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.saveHardwareAccount(symbol, string(data)); err != nil {
+		return nil, err
+	}
+	s.wallet.setHardwareAccount(acct)
+	return acct, nil
+}
+
+// GetSigner returns the hardware account of a chain, or nil if it's signed
+// in software.
+func (s *Service) GetSigner(symbol string) *HardwareAccount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil
+	}
+	return s.wallet.hardwareAccount(symbol)
+}
+
+// HasHardwareSigner reports whether a chain is signed by a hardware wallet.
+func (s *Service) HasHardwareSigner(symbol string) bool {
+	return s.GetSigner(symbol) != nil
+}
+
+// Signers returns the hardware accounts of the chains signed by hardware
+// wallets, sorted by symbol.
+func (s *Service) Signers() []*HardwareAccount {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil
+	}
+	s.wallet.mu.RLock()
+	defer s.wallet.mu.RUnlock()
+	accounts := make([]*HardwareAccount, 0, len(s.wallet.hardware))
+	for _, acct := range s.wallet.hardware {
+		accounts = append(accounts, acct)
+	}
+	sort.Slice(accounts, func(i, j int) bool { return accounts[i].Symbol < accounts[j].Symbol })
+	return accounts
+}
+
+// VerifyAddress shows an address of a hardware-signed chain on its device,
+// and compares it with the address the wallet derives. The user checks
+// that the device shows the address the wallet receives on.
+func (s *Service) VerifyAddress(ctx context.Context, symbol string, change, index uint32) (*AddressVerification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, fmt.Errorf("wallet not loaded")
+	}
+	acct := s.wallet.hardwareAccount(symbol)
+	if acct == nil {
+		return nil, fmt.Errorf("%s is signed in software", symbol)
+	}
+	params, ok := chain.Get(symbol, s.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+
+	address, err := s.wallet.DeriveAddressWithChange(symbol, acct.Account, change, index)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive address: %w", err)
+	}
+
+	defer s.unlockForDevice()()
+	device, err := s.openHardwareAccount(ctx, acct)
+	if err != nil {
+		return nil, err
+	}
+	defer device.Close()
+
+	path := params.DerivationPath(acct.Account, change, index)
+	deviceAddress, err := device.DisplayAddress(ctx, params, path)
+	if err != nil {
+		return nil, err
+	}
+	return &AddressVerification{
+		Symbol:        symbol,
+		Path:          formatPath(path),
+		Address:       address,
+		DeviceAddress: deviceAddress,
+		Match:         address == deviceAddress,
+	}, nil
+}
+
+// openHardwareAccount connects to the device holding a hardware account:
+// the connected device of its type with the same account key.
+func (s *Service) openHardwareAccount(ctx context.Context, acct *HardwareAccount) (HardwareSigner, error) {
+	params, ok := chain.Get(acct.Symbol, s.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", acct.Symbol)
+	}
+	devices, err := EnumerateDevices()
+	if err != nil {
+		return nil, err
+	}
+
+	lastErr := fmt.Errorf("%w: connect the %s holding %s account %d", ErrNoDevice, acct.Type, acct.Symbol, acct.Account)
+	for _, info := range devices {
+		if info.Type != acct.Type {
+			continue
+		}
+		device, err := OpenDevice(ctx, info)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		key, err := device.PublicKey(ctx, params, accountPath(params, acct.Account))
+		if err == nil && key.String() == acct.XPub {
+			return device, nil
+		}
+		device.Close()
+		if err != nil {
+			lastErr = err
+		}
+	}
+	return nil, lastErr
+}
+
+// saveHardwareAccount persists a chain's hardware account; "" selects
+// software signing.
+func (s *Service) saveHardwareAccount(symbol, data string) error {
+	if s.store == nil {
+		return nil
+	}
+	if err := s.store.SetSetting(signerSettingPrefix+symbol, data); err != nil {
+		return fmt.Errorf("failed to save signer: %w", err)
+	}
+	return nil
+}
+
+// loadHardwareAccounts restores the hardware accounts of a newly loaded
+// wallet. The caller holds s.mu.
+func (s *Service) loadHardwareAccounts() error {
+	if s.store == nil {
+		return nil
+	}
+	for _, symbol := range chain.ListByType(chain.ChainTypeBitcoin) {
+		data, err := s.store.GetSetting(signerSettingPrefix + symbol)
+		if err != nil {
+			return fmt.Errorf("failed to load %s signer: %w", symbol, err)
+		}
+		if data == "" {
+			continue
+		}
+		var acct HardwareAccount
+		if err := json.Unmarshal([]byte(data), &acct); err != nil {
+			return fmt.Errorf("invalid %s signer: %w", symbol, err)
+		}
+		if acct.key, err = hdkeychain.NewKeyFromString(acct.XPub); err != nil {
+			return fmt.Errorf("invalid %s signer key: %w", symbol, err)
+		}
+		s.wallet.setHardwareAccount(&acct)
+	}
+	return nil
+}
@@ -0,0 +1,195 @@
+// Package wallet - Remote seed backup.
+//
+// A seed backup is the wallet mnemonic encrypted with a recovery passphrase
+// that is deliberately not the wallet password: the backup lives with a
+// cloud provider, so whoever holds it must still know a secret that was
+// never stored on this machine. It comes with a verification record that
+// can be checked without the passphrase (the backup's checksum) and, once
+// decrypted, confirms that the right seed and BIP39 passphrase came back.
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// MinRecoveryPassphraseLength is the shortest recovery passphrase accepted.
+// The backup sits with a third party, so it must resist offline guessing.
+const MinRecoveryPassphraseLength = 12
+
+const seedBackupVersion = 1
+
+// Seed backup errors
+var (
+	ErrSeedBackupChecksum   = errors.New("seed backup does not match its verification record")
+	ErrSeedBackupPassphrase = errors.New("BIP39 passphrase does not match the backed-up wallet")
+)
+
+// SeedBackup is the encrypted mnemonic as uploaded.
+type SeedBackup struct {
+	Version   int            `json:"version"`
+	Network   chain.Network  `json:"network"`
+	CreatedAt int64          `json:"created_at"`
+	Seed      *EncryptedSeed `json:"seed"` // Encrypted with the recovery passphrase
+}
+
+// SeedVerification is the verification record stored next to a backup.
+type SeedVerification struct {
+	Version      int           `json:"version"`
+	Network      chain.Network `json:"network"`
+	CreatedAt    int64         `json:"created_at"`
+	BackupSHA256 string        `json:"backup_sha256"`
+
+	// Master key fingerprints of the mnemonic alone and of the wallet
+	// (mnemonic and BIP39 passphrase)
+	SeedFingerprint   string `json:"seed_fingerprint"`
+	WalletFingerprint string `json:"wallet_fingerprint"`
+}
+
+// ValidateRecoveryPassphrase checks a recovery passphrase against the
+// wallet password it must differ from.
+func ValidateRecoveryPassphrase(recoveryPassphrase, password string) error {
+	if len(recoveryPassphrase) < MinRecoveryPassphraseLength {
+		return fmt.Errorf("recovery passphrase must be at least %d characters", MinRecoveryPassphraseLength)
+	}
+	if err := ValidatePassword(recoveryPassphrase); err != nil {
+		return fmt.Errorf("weak recovery passphrase: %w", err)
+	}
+	if ConstantTimeCompare([]byte(recoveryPassphrase), []byte(password)) {
+		return errors.New("recovery passphrase must differ from the wallet password")
+	}
+	return nil
+}
+
+// ExportSeedBackup encrypts the stored mnemonic with a recovery passphrase.
+// The password decrypts the wallet seed; the wallet must be unlocked so the
+// record can pin its BIP39 passphrase. Returns the encoded backup and its
+// verification record.
+func (s *Service) ExportSeedBackup(password, recoveryPassphrase string) ([]byte, *SeedVerification, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return nil, nil, fmt.Errorf("wallet not loaded")
+	}
+	if err := ValidateRecoveryPassphrase(recoveryPassphrase, password); err != nil {
+		return nil, nil, err
+	}
+
+	encrypted, err := LoadEncryptedSeed(filepath.Join(s.dataDir, "wallet.seed"))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to load encrypted seed: %w", err)
+	}
+	mnemonic, err := DecryptMnemonic(encrypted, password)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decrypt seed: %w", err)
+	}
+	defer SecureClear([]byte(mnemonic))
+
+	seedFP, err := mnemonicFingerprint(mnemonic, "", s.network)
+	if err != nil {
+		return nil, nil, err
+	}
+	walletFP, err := s.wallet.MasterFingerprint()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	sealed, err := EncryptMnemonic(mnemonic, recoveryPassphrase)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to encrypt seed: %w", err)
+	}
+	backup := &SeedBackup{
+		Version:   seedBackupVersion,
+		Network:   s.network,
+		CreatedAt: time.Now().Unix(),
+		Seed:      sealed,
+	}
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	return data, &SeedVerification{
+		Version:           seedBackupVersion,
+		Network:           s.network,
+		CreatedAt:         backup.CreatedAt,
+		BackupSHA256:      hex.EncodeToString(sum[:]),
+		SeedFingerprint:   hex.EncodeToString(seedFP[:]),
+		WalletFingerprint: hex.EncodeToString(walletFP[:]),
+	}, nil
+}
+
+// SeedBackupMatches checks a backup's checksum against its verification
+// record, without the recovery passphrase.
+func SeedBackupMatches(data []byte, v *SeedVerification) bool {
+	sum := sha256.Sum256(data)
+	return v != nil && hex.EncodeToString(sum[:]) == v.BackupSHA256
+}
+
+// OpenSeedBackup checks a backup against its verification record and
+// decrypts the mnemonic. The caller must SecureClear it.
+func OpenSeedBackup(data []byte, v *SeedVerification, recoveryPassphrase string) (string, error) {
+	if !SeedBackupMatches(data, v) {
+		return "", ErrSeedBackupChecksum
+	}
+	var backup SeedBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return "", fmt.Errorf("invalid seed backup: %w", err)
+	}
+	if backup.Version != seedBackupVersion || backup.Seed == nil {
+		return "", fmt.Errorf("unsupported seed backup version %d", backup.Version)
+	}
+	if backup.Network != v.Network || backup.CreatedAt != v.CreatedAt {
+		return "", ErrSeedBackupChecksum
+	}
+
+	mnemonic, err := DecryptMnemonic(backup.Seed, recoveryPassphrase)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt seed backup (wrong recovery passphrase?): %w", err)
+	}
+	fp, err := mnemonicFingerprint(mnemonic, "", backup.Network)
+	if err != nil || hex.EncodeToString(fp[:]) != v.SeedFingerprint {
+		SecureClear([]byte(mnemonic))
+		return "", ErrSeedBackupChecksum
+	}
+	return mnemonic, nil
+}
+
+// RestoreSeedBackup recovers the wallet from a seed backup and creates it
+// with a new password, as CreateWallet does. passphrase is the BIP39
+// passphrase the wallet was unlocked with, checked against the record.
+func (s *Service) RestoreSeedBackup(data []byte, v *SeedVerification, recoveryPassphrase, passphrase, password string) error {
+	if v != nil && v.Network != s.network {
+		return fmt.Errorf("seed backup is for %s, node runs %s", v.Network, s.network)
+	}
+	mnemonic, err := OpenSeedBackup(data, v, recoveryPassphrase)
+	if err != nil {
+		return err
+	}
+	defer SecureClear([]byte(mnemonic))
+
+	fp, err := mnemonicFingerprint(mnemonic, passphrase, s.network)
+	if err != nil {
+		return err
+	}
+	if hex.EncodeToString(fp[:]) != v.WalletFingerprint {
+		return ErrSeedBackupPassphrase
+	}
+	return s.CreateWallet(mnemonic, passphrase, password)
+}
+
+func mnemonicFingerprint(mnemonic, passphrase string, network chain.Network) ([4]byte, error) {
+	w, err := NewFromMnemonic(mnemonic, passphrase, network)
+	if err != nil {
+		return [4]byte{}, err
+	}
+	return w.MasterFingerprint()
+}
@@ -0,0 +1,71 @@
+package wallet
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestSeedBackupRoundTrip(t *testing.T) {
+	const (
+		mnemonic = "abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon abandon about"
+		password = "TestPassword123!"
+		recovery = "Recovery-Passphrase-42"
+	)
+	svc := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if _, _, err := svc.ExportSeedBackup(password, recovery); err == nil {
+		t.Error("ExportSeedBackup() without a wallet should fail")
+	}
+	if err := svc.CreateWallet(mnemonic, "bip39-extra", password); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	want, _ := svc.GetAddress("BTC", 0, 0)
+
+	if _, _, err := svc.ExportSeedBackup(password, password); err == nil {
+		t.Error("recovery passphrase equal to the password should be rejected")
+	}
+	if _, _, err := svc.ExportSeedBackup(password, "Short-1"); err == nil {
+		t.Error("short recovery passphrase should be rejected")
+	}
+	if _, _, err := svc.ExportSeedBackup("WrongPassword123!", recovery); err == nil {
+		t.Error("wrong wallet password should fail")
+	}
+
+	data, v, err := svc.ExportSeedBackup(password, recovery)
+	if err != nil {
+		t.Fatalf("ExportSeedBackup() error = %v", err)
+	}
+	if v.Network != chain.Testnet || v.SeedFingerprint == v.WalletFingerprint || !SeedBackupMatches(data, v) {
+		t.Errorf("verification = %+v", v)
+	}
+
+	got, err := OpenSeedBackup(data, v, recovery)
+	if err != nil || got != mnemonic {
+		t.Fatalf("OpenSeedBackup() = %q, %v", got, err)
+	}
+	if _, err := OpenSeedBackup(data, v, "Wrong-Passphrase-42"); err == nil {
+		t.Error("wrong recovery passphrase should fail")
+	}
+	tampered := append([]byte{}, data...)
+	tampered[len(tampered)-3] ^= 1
+	if _, err := OpenSeedBackup(tampered, v, recovery); !errors.Is(err, ErrSeedBackupChecksum) {
+		t.Errorf("tampered backup error = %v, want ErrSeedBackupChecksum", err)
+	}
+
+	restored := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if err := restored.RestoreSeedBackup(data, v, recovery, "", "NewPassword456!"); !errors.Is(err, ErrSeedBackupPassphrase) {
+		t.Errorf("wrong BIP39 passphrase error = %v, want ErrSeedBackupPassphrase", err)
+	}
+	if err := restored.RestoreSeedBackup(data, v, recovery, "bip39-extra", "NewPassword456!"); err != nil {
+		t.Fatalf("RestoreSeedBackup() error = %v", err)
+	}
+	if addr, _ := restored.GetAddress("BTC", 0, 0); addr != want {
+		t.Errorf("restored address = %s, want %s", addr, want)
+	}
+
+	mainnet := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Mainnet})
+	if err := mainnet.RestoreSeedBackup(data, v, recovery, "bip39-extra", "NewPassword456!"); err == nil {
+		t.Error("restoring a testnet backup on mainnet should fail")
+	}
+}
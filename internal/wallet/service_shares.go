@@ -0,0 +1,60 @@
+// Package wallet - SLIP-39 share backup of the wallet mnemonic.
+//
+// The shares encode the BIP39 entropy of the stored mnemonic, so recovering
+// them rebuilds the same mnemonic and addresses. The mnemonic's wordlist is
+// not part of the shares and must be given again on recovery.
+package wallet
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// GenerateShares splits the stored wallet mnemonic into SLIP-39 shares.
+// The password decrypts the wallet seed; the share passphrase (optional)
+// encrypts the shares. Returns the shares per group and the mnemonic's
+// wordlist.
+func (s *Service) GenerateShares(password, sharePassphrase string, groupThreshold int, groups []ShareGroup) ([][]string, Language, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	encrypted, err := LoadEncryptedSeed(filepath.Join(s.dataDir, "wallet.seed"))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to load encrypted seed: %w", err)
+	}
+	mnemonic, err := DecryptMnemonic(encrypted, password)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt seed: %w", err)
+	}
+	defer SecureClear([]byte(mnemonic))
+
+	entropy, lang, err := MnemonicToEntropy(mnemonic)
+	if err != nil {
+		return nil, "", err
+	}
+	defer SecureClear(entropy)
+
+	shares, err := GenerateShares(entropy, sharePassphrase, groupThreshold, groups)
+	if err != nil {
+		return nil, "", err
+	}
+	return shares, lang, nil
+}
+
+// RecoverFromShares recombines SLIP-39 shares into the wallet mnemonic in the
+// given wordlist and creates the wallet from it, as CreateWallet does.
+func (s *Service) RecoverFromShares(shares []string, sharePassphrase string, lang Language, passphrase, password string) error {
+	entropy, err := CombineShares(shares, sharePassphrase)
+	if err != nil {
+		return fmt.Errorf("failed to combine shares: %w", err)
+	}
+	defer SecureClear(entropy)
+
+	mnemonic, err := EntropyToMnemonic(entropy, lang)
+	if err != nil {
+		return fmt.Errorf("recovered secret is not a mnemonic: %w", err)
+	}
+	defer SecureClear([]byte(mnemonic))
+
+	return s.CreateWallet(mnemonic, passphrase, password)
+}
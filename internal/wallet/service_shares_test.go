@@ -0,0 +1,54 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+func TestServiceSharesRoundTrip(t *testing.T) {
+	password := "TestPassword123!"
+	mnemonic, err := GenerateMnemonicInLanguage(LanguageSpanish)
+	if err != nil {
+		t.Fatalf("GenerateMnemonicInLanguage() error = %v", err)
+	}
+
+	svc := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if err := svc.CreateWallet(mnemonic, "", password); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	want, err := svc.GetAddress("BTC", 0, 0)
+	if err != nil {
+		t.Fatalf("GetAddress() error = %v", err)
+	}
+
+	if _, _, err := svc.GenerateShares("WrongPassword123!", "", 1, []ShareGroup{{2, 3}}); err == nil {
+		t.Error("GenerateShares() with wrong password should fail")
+	}
+	shares, lang, err := svc.GenerateShares(password, "backup", 1, []ShareGroup{{2, 3}})
+	if err != nil {
+		t.Fatalf("GenerateShares() error = %v", err)
+	}
+	if lang != LanguageSpanish {
+		t.Errorf("language = %s, want spanish", lang)
+	}
+
+	restored := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet})
+	if err := restored.RecoverFromShares(shares[0][1:], "backup", lang, "", password); err != nil {
+		t.Fatalf("RecoverFromShares() error = %v", err)
+	}
+	got, err := restored.GetAddress("BTC", 0, 0)
+	if err != nil {
+		t.Fatalf("GetAddress() error = %v", err)
+	}
+	if got != want {
+		t.Errorf("restored address = %s, want %s", got, want)
+	}
+	if !restored.HasWallet() {
+		t.Error("restored wallet should be saved")
+	}
+
+	if err := NewService(&ServiceConfig{DataDir: t.TempDir()}).RecoverFromShares(shares[0][:1], "backup", lang, "", password); err == nil {
+		t.Error("RecoverFromShares() with one of two shares should fail")
+	}
+}
@@ -346,6 +346,15 @@ func TestServiceGetFeeEstimatesNoBackend(t *testing.T) {
 	}
 }
 
+func TestServiceGetEVMGasPriceNoBackends(t *testing.T) {
+	tmpDir := t.TempDir()
+	svc := NewService(&ServiceConfig{DataDir: tmpDir})
+
+	if _, err := svc.GetEVMGasPrice(context.Background(), "ETH"); err == nil {
+		t.Error("GetEVMGasPrice() should fail without backends configured")
+	}
+}
+
 func TestServiceEVMAddress(t *testing.T) {
 	tmpDir := t.TempDir()
 	svc := NewService(&ServiceConfig{DataDir: tmpDir})
@@ -0,0 +1,248 @@
+// Package wallet - Transaction signers.
+//
+// The wallet inputs of Bitcoin-family transactions are signed by their
+// chain's signer: keys derived from the mnemonic by default, or a hardware
+// wallet selected with SetSigner (see service_hardware.go).
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// Signer types.
+const (
+	SignerSoftware = "software" // Keys derived from the wallet mnemonic
+	SignerLedger   = "ledger"
+	SignerTrezor   = "trezor"
+)
+
+// PrevTxFetcher returns a transaction spent by a transaction being signed.
+type PrevTxFetcher func(ctx context.Context, txid chainhash.Hash) (*wire.MsgTx, error)
+
+// SignRequest is a transaction whose wallet inputs are to be signed.
+type SignRequest struct {
+	Params *chain.Params
+	Tx     *wire.MsgTx
+
+	// UTXOs[i] is the wallet UTXO spent by Tx.TxIn[i].
+	UTXOs []*AddressUTXO
+
+	// PrevTx returns the transactions spent; devices that check input
+	// amounts against them ask for them. Optional for software signing.
+	PrevTx PrevTxFetcher
+}
+
+// Signer signs the wallet inputs of Bitcoin-family transactions.
+type Signer interface {
+	// Type returns SignerSoftware, SignerLedger or SignerTrezor.
+	Type() string
+
+	// SignTx sets the signature script or witness of every input of
+	// req.Tx. Hardware signers wait for the user to confirm on the device.
+	SignTx(ctx context.Context, req *SignRequest) error
+}
+
+// KeySigner returns a Signer using private keys from a KeyDeriver.
+func KeySigner(keys KeyDeriver) Signer {
+	return &keySigner{keys: keys}
+}
+
+type keySigner struct {
+	keys KeyDeriver
+}
+
+func (k *keySigner) Type() string {
+	return SignerSoftware
+}
+
+func (k *keySigner) SignTx(ctx context.Context, req *SignRequest) error {
+	prevOuts, err := req.prevOutputs()
+	if err != nil {
+		return err
+	}
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+
+	// Sign each input with its own private key
+	for i, utxo := range req.UTXOs {
+		// Derive the private key for this UTXO's address
+		privKey, err := k.keys.DerivePrivateKeyWithChange(
+			req.Params.Symbol,
+			utxo.Account,
+			utxo.Change,
+			utxo.AddressIndex,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to derive key for input %d (path: %d'/%d/%d): %w",
+				i, utxo.Account, utxo.Change, utxo.AddressIndex, err)
+		}
+
+		// Determine address type and sign accordingly; nested SegWit and
+		// uncompressed keys can't be told apart by their address
+		addrType := detectAddressType(utxo.Address, req.Params)
+		switch utxo.AddressType {
+		case "p2sh-p2wpkh", "p2pkh-uncompressed":
+			addrType = utxo.AddressType
+		}
+
+		switch addrType {
+		case "p2wpkh":
+			if err := signP2WPKH(req.Tx, i, privKey, prevOutFetcher); err != nil {
+				return fmt.Errorf("failed to sign P2WPKH input %d: %w", i, err)
+			}
+		case "p2tr":
+			if err := signP2TR(req.Tx, i, privKey, prevOutFetcher); err != nil {
+				return fmt.Errorf("failed to sign P2TR input %d: %w", i, err)
+			}
+		case "p2sh-p2wpkh":
+			if err := signP2SHP2WPKH(req.Tx, i, privKey, prevOutFetcher); err != nil {
+				return fmt.Errorf("failed to sign P2SH-P2WPKH input %d: %w", i, err)
+			}
+		case "p2pkh", "p2pkh-uncompressed":
+			compressed := addrType == "p2pkh"
+			if err := signP2PKH(req.Tx, i, privKey, prevOuts[req.Tx.TxIn[i].PreviousOutPoint].PkScript, compressed); err != nil {
+				return fmt.Errorf("failed to sign P2PKH input %d: %w", i, err)
+			}
+		default:
+			return fmt.Errorf("unsupported address type for input %d: %s", i, addrType)
+		}
+	}
+	return nil
+}
+
+// prevOutputs returns the outputs spent by the inputs of the request.
+func (r *SignRequest) prevOutputs() (map[wire.OutPoint]*wire.TxOut, error) {
+	if len(r.UTXOs) != len(r.Tx.TxIn) {
+		return nil, fmt.Errorf("%d UTXOs for %d inputs", len(r.UTXOs), len(r.Tx.TxIn))
+	}
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut, len(r.UTXOs))
+	for i, utxo := range r.UTXOs {
+		script, err := ParseAddressToScript(utxo.Address, r.Params)
+		if err != nil {
+			return nil, fmt.Errorf("invalid UTXO address %s: %w", utxo.Address, err)
+		}
+		prevOuts[r.Tx.TxIn[i].PreviousOutPoint] = wire.NewTxOut(int64(utxo.Amount), script)
+	}
+	return prevOuts, nil
+}
+
+// SignTx signs tx, whose input i spends utxos[i], with the chain's signer:
+// the hardware wallet selected for it, or keys derived from the mnemonic.
+func (s *Service) SignTx(ctx context.Context, symbol string, tx *wire.MsgTx, utxos []*AddressUTXO) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.wallet == nil {
+		return fmt.Errorf("wallet not loaded")
+	}
+	params, ok := chain.Get(symbol, s.network)
+	if !ok {
+		return fmt.Errorf("unsupported chain: %s", symbol)
+	}
+
+	req := &SignRequest{Params: params, Tx: tx, UTXOs: utxos, PrevTx: s.prevTxFetcher(symbol)}
+	return s.withSigner(ctx, symbol, func(signer Signer) error {
+		return signer.SignTx(ctx, req)
+	})
+}
+
+// withSigner calls fn with the chain's signer, connecting to its hardware
+// wallet for the call. The caller holds s.mu for reading; it is released
+// while a hardware wallet is in use, so fn must not touch s.wallet.
+func (s *Service) withSigner(ctx context.Context, symbol string, fn func(Signer) error) error {
+	account := s.wallet.hardwareAccount(symbol)
+	if account == nil {
+		return fn(KeySigner(s))
+	}
+	defer s.unlockForDevice()()
+	device, err := s.openHardwareAccount(ctx, account)
+	if err != nil {
+		return err
+	}
+	defer device.Close()
+	return fn(device)
+}
+
+// buildAndSignMultiAddressTx builds a multi-address transaction and signs
+// it with the chain's signer. The caller holds s.mu.
+func (s *Service) buildAndSignMultiAddressTx(ctx context.Context, params *MultiAddressTxParams) (*MultiAddressTxResult, error) {
+	var result *MultiAddressTxResult
+	prevTx := s.prevTxFetcher(params.Symbol)
+	err := s.withSigner(ctx, params.Symbol, func(signer Signer) error {
+		var err error
+		result, err = buildMultiAddressTx(ctx, signer, prevTx, params)
+		return err
+	})
+	return result, err
+}
+
+// buildSendMaxTx is BuildSendMaxTx with the chain's signer. The caller
+// holds s.mu.
+func (s *Service) buildSendMaxTx(ctx context.Context, params *SendMaxParams) (*MultiAddressTxResult, error) {
+	maxAmount, _, err := CalculateMaxSendAmount(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.buildAndSignMultiAddressTx(ctx, &MultiAddressTxParams{
+		UTXOs:     params.UTXOs,
+		ToAddress: params.ToAddress,
+		Amount:    maxAmount,
+		FeeRate:   params.FeeRate,
+		Symbol:    params.Symbol,
+		Network:   params.Network,
+	})
+}
+
+// unlockForDevice releases the caller's read lock on s.mu and returns the
+// function taking it back. A hardware wallet waits for the user to confirm
+// on the device, and holding s.mu meanwhile would block every wallet writer
+// and the readers queued behind them.
+func (s *Service) unlockForDevice() (relock func()) {
+	s.mu.RUnlock()
+	return s.mu.RLock
+}
+
+// prevTxFetcher returns a PrevTxFetcher reading from the chain's backend.
+// The caller holds s.mu.
+func (s *Service) prevTxFetcher(symbol string) PrevTxFetcher {
+	backends := s.backends
+	return func(ctx context.Context, txid chainhash.Hash) (*wire.MsgTx, error) {
+		if backends == nil {
+			return nil, fmt.Errorf("no backends configured")
+		}
+		b, ok := backends.Get(symbol)
+		if !ok {
+			return nil, fmt.Errorf("no backend for chain: %s", symbol)
+		}
+		return fetchRawTx(ctx, b, txid)
+	}
+}
+
+// fetchRawTx gets and decodes a transaction. Backends return it either
+// hex-encoded or raw.
+func fetchRawTx(ctx context.Context, b backend.Backend, txid chainhash.Hash) (*wire.MsgTx, error) {
+	raw, err := b.GetRawTransaction(ctx, txid.String())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transaction %s: %w", txid, err)
+	}
+	if decoded, err := hex.DecodeString(strings.TrimSpace(string(raw))); err == nil {
+		raw = decoded
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(strings.NewReader(string(raw))); err != nil {
+		return nil, fmt.Errorf("failed to decode transaction %s: %w", txid, err)
+	}
+	if tx.TxHash() != txid {
+		return nil, fmt.Errorf("backend returned transaction %s for %s", tx.TxHash(), txid)
+	}
+	return tx, nil
+}
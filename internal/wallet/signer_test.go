@@ -0,0 +1,254 @@
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+)
+
+// testDeviceMaster is the master key of the fake hardware wallets.
+func testDeviceMaster(t *testing.T) *hdkeychain.ExtendedKey {
+	t.Helper()
+	master, err := hdkeychain.NewMaster(bytes.Repeat([]byte{0x42}, 32), &chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("NewMaster() error = %v", err)
+	}
+	return master
+}
+
+// derivePath derives a key from master along a derivation path.
+func derivePath(master *hdkeychain.ExtendedKey, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	key := master
+	for _, n := range path {
+		var err error
+		if key, err = key.Derive(n); err != nil {
+			return nil, err
+		}
+	}
+	return key, nil
+}
+
+// masterKeys is a KeyDeriver of a master key.
+type masterKeys struct {
+	master  *hdkeychain.ExtendedKey
+	network chain.Network
+}
+
+func (m masterKeys) DerivePrivateKeyWithChange(symbol string, account, change, index uint32) (*btcec.PrivateKey, error) {
+	params, ok := chain.Get(symbol, m.network)
+	if !ok {
+		return nil, fmt.Errorf("unsupported chain: %s", symbol)
+	}
+	key, err := derivePath(m.master, params.DerivationPath(account, change, index))
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPrivKey()
+}
+
+// testSpend returns a request to sign a transaction spending outputs of a
+// funding transaction, paid to addresses of master of the given types, to
+// two outputs.
+func testSpend(t *testing.T, params *chain.Params, master *hdkeychain.ExtendedKey, inputTypes ...string) *SignRequest {
+	t.Helper()
+
+	address := func(account, change, index uint32, addrType string) (string, []byte) {
+		key, err := derivePath(master, params.DerivationPath(account, change, index))
+		if err != nil {
+			t.Fatalf("derive error = %v", err)
+		}
+		pubKey, _ := key.ECPubKey()
+		var addr string
+		if addrType == "p2pkh" {
+			addr, err = deriveP2PKH(pubKey, toChainCfgParams(params))
+		} else {
+			addr, err = deriveP2WPKH(pubKey, toChainCfgParams(params))
+		}
+		if err != nil {
+			t.Fatalf("derive address error = %v", err)
+		}
+		script, err := ParseAddressToScript(addr, params)
+		if err != nil {
+			t.Fatalf("ParseAddressToScript() error = %v", err)
+		}
+		return addr, script
+	}
+
+	funding := wire.NewMsgTx(2)
+	funding.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{1}, 0), []byte{0x51}, nil))
+	var utxos []*AddressUTXO
+	for i, addrType := range inputTypes {
+		addr, script := address(0, 0, uint32(i), addrType)
+		amount := uint64(100000 * (i + 1))
+		funding.AddTxOut(wire.NewTxOut(int64(amount), script))
+		utxos = append(utxos, &AddressUTXO{
+			Vout:         uint32(i),
+			Amount:       amount,
+			Address:      addr,
+			AddressIndex: uint32(i),
+			AddressType:  detectAddressType(addr, params),
+		})
+	}
+	fundingHash := funding.TxHash()
+
+	tx := wire.NewMsgTx(2)
+	tx.LockTime = 800000
+	for i, utxo := range utxos {
+		utxo.TxID = fundingHash.String()
+		in := wire.NewTxIn(wire.NewOutPoint(&fundingHash, uint32(i)), nil, nil)
+		in.Sequence = wire.MaxTxInSequenceNum - 2
+		tx.AddTxIn(in)
+	}
+	_, dest := address(1, 0, 0, "p2wpkh")
+	_, change := address(0, 1, 0, "p2wpkh")
+	tx.AddTxOut(wire.NewTxOut(50000, dest))
+	tx.AddTxOut(wire.NewTxOut(40000, change))
+
+	return &SignRequest{
+		Params: params,
+		Tx:     tx,
+		UTXOs:  utxos,
+		PrevTx: func(ctx context.Context, txid chainhash.Hash) (*wire.MsgTx, error) {
+			if txid != fundingHash {
+				return nil, fmt.Errorf("unknown transaction %s", txid)
+			}
+			return funding, nil
+		},
+	}
+}
+
+// verifySigned runs the scripts of every input of a signed request.
+func verifySigned(t *testing.T, req *SignRequest) {
+	t.Helper()
+	prevOuts, err := req.prevOutputs()
+	if err != nil {
+		t.Fatalf("prevOutputs() error = %v", err)
+	}
+	fetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(req.Tx, fetcher)
+	for i, in := range req.Tx.TxIn {
+		prevOut := prevOuts[in.PreviousOutPoint]
+		vm, err := txscript.NewEngine(prevOut.PkScript, req.Tx, i, txscript.StandardVerifyFlags, nil, sigHashes, prevOut.Value, fetcher)
+		if err != nil {
+			t.Fatalf("input %d: NewEngine() error = %v", i, err)
+		}
+		if err := vm.Execute(); err != nil {
+			t.Errorf("input %d: signature doesn't verify: %v", i, err)
+		}
+	}
+}
+
+func TestKeySigner(t *testing.T) {
+	master := testDeviceMaster(t)
+	params, _ := chain.Get("BTC", chain.Testnet)
+	req := testSpend(t, params, master, "p2wpkh", "p2pkh")
+
+	signer := KeySigner(masterKeys{master: master, network: chain.Testnet})
+	if signer.Type() != SignerSoftware {
+		t.Errorf("Type() = %s, want %s", signer.Type(), SignerSoftware)
+	}
+	if err := signer.SignTx(context.Background(), req); err != nil {
+		t.Fatalf("SignTx() error = %v", err)
+	}
+	verifySigned(t, req)
+
+	req.UTXOs = req.UTXOs[:1]
+	if err := signer.SignTx(context.Background(), req); err == nil {
+		t.Error("SignTx() with fewer UTXOs than inputs should fail")
+	}
+}
+
+func TestServiceHardwareAccount(t *testing.T) {
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	defer store.Close()
+
+	svc := NewService(&ServiceConfig{DataDir: t.TempDir(), Network: chain.Testnet, Store: store})
+	if err := svc.CreateWallet(testMnemonic, "", "TestPassword123!"); err != nil {
+		t.Fatalf("CreateWallet() error = %v", err)
+	}
+	softwareAddr, err := svc.GetAddressWithChange("BTC", 0, 0, 0)
+	if err != nil {
+		t.Fatalf("GetAddressWithChange() error = %v", err)
+	}
+
+	// A Ledger account selected before the wallet was unlocked
+	params, _ := chain.Get("BTC", chain.Testnet)
+	master := testDeviceMaster(t)
+	accountKey, err := derivePath(master, accountPath(params, 0))
+	if err != nil {
+		t.Fatalf("derive error = %v", err)
+	}
+	xpub, err := accountKey.Neuter()
+	if err != nil {
+		t.Fatalf("Neuter() error = %v", err)
+	}
+	data, _ := json.Marshal(&HardwareAccount{Symbol: "BTC", Type: SignerLedger, Path: "m/84h/1h/0h", XPub: xpub.String()})
+	if err := store.SetSetting(signerSettingPrefix+"BTC", string(data)); err != nil {
+		t.Fatalf("SetSetting() error = %v", err)
+	}
+	svc.Lock()
+	if err := svc.LoadWallet("TestPassword123!", ""); err != nil {
+		t.Fatalf("LoadWallet() error = %v", err)
+	}
+
+	if acct := svc.GetSigner("BTC"); acct == nil || acct.Type != SignerLedger {
+		t.Fatalf("GetSigner(BTC) = %+v, want the ledger account", acct)
+	}
+	if !svc.HasHardwareSigner("BTC") || svc.HasHardwareSigner("LTC") {
+		t.Error("HasHardwareSigner() should be true for BTC only")
+	}
+	if signers := svc.Signers(); len(signers) != 1 || signers[0].Symbol != "BTC" {
+		t.Errorf("Signers() = %+v, want BTC", signers)
+	}
+
+	deviceKey, _ := derivePath(master, params.DerivationPath(0, 0, 0))
+	wantAddr, _ := DeriveAddressFromKey(deviceKey, params)
+	if addr, err := svc.GetAddressWithChange("BTC", 0, 0, 0); err != nil || addr != wantAddr {
+		t.Errorf("GetAddressWithChange() = %s, %v, want device address %s", addr, err, wantAddr)
+	}
+	if _, err := svc.GetAddressWithChange("BTC", 1, 0, 0); err == nil {
+		t.Error("GetAddressWithChange() of another account should fail")
+	}
+	if _, err := svc.DerivePrivateKeyWithChange("BTC", 0, 0, 0); !errors.Is(err, ErrHardwareKey) {
+		t.Errorf("DerivePrivateKeyWithChange() error = %v, want ErrHardwareKey", err)
+	}
+
+	// Back to software
+	if _, err := svc.SetSigner(context.Background(), "ETH", SignerSoftware, "", 0); err == nil {
+		t.Error("SetSigner(ETH) should fail")
+	}
+	if _, err := svc.SetSigner(context.Background(), "DOGE", SignerLedger, "", 0); err == nil {
+		t.Error("SetSigner(DOGE, ledger) should fail")
+	}
+	if _, err := svc.SetSigner(context.Background(), "BTC", "keepkey", "", 0); err == nil {
+		t.Error("SetSigner() with an unknown type should fail")
+	}
+	if acct, err := svc.SetSigner(context.Background(), "BTC", SignerSoftware, "", 0); err != nil || acct != nil {
+		t.Fatalf("SetSigner(software) = %+v, %v", acct, err)
+	}
+	if addr, _ := svc.GetAddressWithChange("BTC", 0, 0, 0); addr != softwareAddr {
+		t.Errorf("address after SetSigner(software) = %s, want %s", addr, softwareAddr)
+	}
+	if v, _ := store.GetSetting(signerSettingPrefix + "BTC"); v != "" {
+		t.Errorf("signer setting = %q, want cleared", v)
+	}
+	if _, err := svc.VerifyAddress(context.Background(), "BTC", 0, 0); err == nil {
+		t.Error("VerifyAddress() of a software chain should fail")
+	}
+}
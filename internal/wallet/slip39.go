@@ -0,0 +1,546 @@
+// Package wallet - SLIP-39 Shamir share backup.
+//
+// A master secret is encrypted with an optional passphrase and split into
+// groups of mnemonic shares: any GroupThreshold groups, each with Threshold of
+// its Count member shares, recover it. The encoding (wordlist, RS1024
+// checksum, Feistel encryption and GF(256) Shamir sharing) follows SLIP-0039.
+package wallet
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+// SLIP-39 parameters.
+const (
+	slip39RadixBits      = 10
+	slip39IDExpWords     = 2
+	slip39ParamsWords    = 2
+	slip39ChecksumWords  = 3
+	slip39MetadataWords  = slip39IDExpWords + slip39ParamsWords + slip39ChecksumWords
+	slip39MinSecretBytes = 16
+	slip39MinWords       = slip39MetadataWords + (slip39MinSecretBytes*8+slip39RadixBits-1)/slip39RadixBits
+	slip39MaxShareCount  = 16
+	slip39DigestBytes    = 4
+	slip39DigestIndex    = 254
+	slip39SecretIndex    = 255
+	slip39RoundCount     = 4
+	slip39BaseIterations = 10000
+
+	// slip39IterationExponent sets PBKDF2 to 10000<<1 iterations in total.
+	slip39IterationExponent = 1
+)
+
+// ErrInvalidShare is returned for a share that doesn't decode.
+var ErrInvalidShare = errors.New("invalid share")
+
+// ErrInsufficientShares is returned when the shares can't recover the secret.
+var ErrInsufficientShares = errors.New("insufficient shares")
+
+// ShareGroup is one group of a SLIP-39 split: any Threshold of its Count
+// member shares recover the group.
+type ShareGroup struct {
+	Threshold int `json:"threshold"`
+	Count     int `json:"count"`
+}
+
+var slip39Index = func() map[string]int {
+	index := make(map[string]int, len(slip39Words))
+	for i, w := range slip39Words {
+		index[w] = i
+	}
+	return index
+}()
+
+// share is a decoded SLIP-39 share.
+type share struct {
+	identifier        uint16
+	extendable        bool
+	iterationExponent int
+	groupIndex        int
+	groupThreshold    int
+	groupCount        int
+	memberIndex       int
+	memberThreshold   int
+	value             []byte
+}
+
+// GenerateShares splits a master secret into SLIP-39 mnemonic shares, one
+// slice of mnemonics per group. The secret must be at least 16 bytes and of
+// even length; the passphrase may be empty.
+func GenerateShares(masterSecret []byte, passphrase string, groupThreshold int, groups []ShareGroup) ([][]string, error) {
+	if len(masterSecret) < slip39MinSecretBytes || len(masterSecret)%2 != 0 {
+		return nil, fmt.Errorf("master secret must be at least %d bytes and of even length", slip39MinSecretBytes)
+	}
+	if err := validateSharePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+	if len(groups) == 0 || len(groups) > slip39MaxShareCount {
+		return nil, fmt.Errorf("group count must be between 1 and %d", slip39MaxShareCount)
+	}
+	if groupThreshold < 1 || groupThreshold > len(groups) {
+		return nil, fmt.Errorf("group threshold must be between 1 and %d", len(groups))
+	}
+	for i, g := range groups {
+		if g.Count < 1 || g.Count > slip39MaxShareCount {
+			return nil, fmt.Errorf("group %d: count must be between 1 and %d", i, slip39MaxShareCount)
+		}
+		if g.Threshold < 1 || g.Threshold > g.Count {
+			return nil, fmt.Errorf("group %d: threshold must be between 1 and %d", i, g.Count)
+		}
+		if g.Threshold == 1 && g.Count > 1 {
+			return nil, fmt.Errorf("group %d: use a single share instead of a 1-of-%d group", i, g.Count)
+		}
+	}
+
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate identifier: %w", err)
+	}
+	identifier := binary.BigEndian.Uint16(id[:]) & 0x7FFF
+
+	ems := slip39Encrypt(masterSecret, passphrase, slip39IterationExponent, identifier, true)
+	defer SecureClear(ems)
+
+	groupShares, err := splitSecret(groupThreshold, len(groups), ems)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]string, len(groups))
+	for gi, g := range groups {
+		memberShares, err := splitSecret(g.Threshold, g.Count, groupShares[gi])
+		if err != nil {
+			return nil, err
+		}
+		for mi, value := range memberShares {
+			s := &share{
+				identifier:        identifier,
+				extendable:        true,
+				iterationExponent: slip39IterationExponent,
+				groupIndex:        gi,
+				groupThreshold:    groupThreshold,
+				groupCount:        len(groups),
+				memberIndex:       mi,
+				memberThreshold:   g.Threshold,
+				value:             value,
+			}
+			result[gi] = append(result[gi], s.mnemonic())
+		}
+	}
+	return result, nil
+}
+
+// CombineShares recovers the master secret from SLIP-39 mnemonic shares:
+// exactly the threshold number of shares from exactly the threshold number of
+// groups.
+func CombineShares(mnemonics []string, passphrase string) ([]byte, error) {
+	if len(mnemonics) == 0 {
+		return nil, ErrInsufficientShares
+	}
+	if err := validateSharePassphrase(passphrase); err != nil {
+		return nil, err
+	}
+
+	var first *share
+	groups := make(map[int]map[int]*share)
+	for _, m := range mnemonics {
+		s, err := decodeShare(m)
+		if err != nil {
+			return nil, err
+		}
+		if first == nil {
+			first = s
+		} else if s.identifier != first.identifier || s.extendable != first.extendable ||
+			s.iterationExponent != first.iterationExponent ||
+			s.groupThreshold != first.groupThreshold || s.groupCount != first.groupCount {
+			return nil, fmt.Errorf("%w: shares belong to different backups", ErrInvalidShare)
+		}
+
+		members, ok := groups[s.groupIndex]
+		if !ok {
+			members = make(map[int]*share)
+			groups[s.groupIndex] = members
+		}
+		for _, other := range members {
+			if other.memberThreshold != s.memberThreshold {
+				return nil, fmt.Errorf("%w: group %d has mismatching member thresholds", ErrInvalidShare, s.groupIndex)
+			}
+		}
+		if dup, ok := members[s.memberIndex]; ok && !bytes.Equal(dup.value, s.value) {
+			return nil, fmt.Errorf("%w: group %d has conflicting shares for member %d", ErrInvalidShare, s.groupIndex, s.memberIndex)
+		}
+		members[s.memberIndex] = s
+	}
+
+	if len(groups) < first.groupThreshold {
+		return nil, fmt.Errorf("%w: need %d groups, got %d", ErrInsufficientShares, first.groupThreshold, len(groups))
+	}
+	if len(groups) != first.groupThreshold {
+		return nil, fmt.Errorf("%w: need exactly %d groups, got %d", ErrInvalidShare, first.groupThreshold, len(groups))
+	}
+
+	groupShares := make(map[int][]byte, len(groups))
+	for gi, members := range groups {
+		var threshold int
+		values := make(map[int][]byte, len(members))
+		for mi, s := range members {
+			threshold = s.memberThreshold
+			values[mi] = s.value
+		}
+		if len(members) < threshold {
+			return nil, fmt.Errorf("%w: group %d needs %d shares, got %d", ErrInsufficientShares, gi, threshold, len(members))
+		}
+		if len(members) != threshold {
+			return nil, fmt.Errorf("%w: group %d needs exactly %d shares, got %d", ErrInvalidShare, gi, threshold, len(members))
+		}
+		secret, err := recoverSecret(threshold, values)
+		if err != nil {
+			return nil, err
+		}
+		groupShares[gi] = secret
+	}
+
+	ems, err := recoverSecret(first.groupThreshold, groupShares)
+	if err != nil {
+		return nil, err
+	}
+	defer SecureClear(ems)
+	return slip39Decrypt(ems, passphrase, first.iterationExponent, first.identifier, first.extendable), nil
+}
+
+// validateSharePassphrase enforces SLIP-39's printable ASCII passphrases.
+func validateSharePassphrase(passphrase string) error {
+	for _, c := range []byte(passphrase) {
+		if c < 32 || c > 126 {
+			return fmt.Errorf("share passphrase must be printable ASCII")
+		}
+	}
+	return nil
+}
+
+// ========================================
+// Mnemonic encoding
+// ========================================
+
+// mnemonic encodes the share as words.
+func (s *share) mnemonic() string {
+	var flag uint64
+	if s.extendable {
+		flag = 1
+	}
+	idExp := uint64(s.identifier)<<5 | flag<<4 | uint64(s.iterationExponent)
+	params := uint64(s.groupIndex)<<16 | uint64(s.groupThreshold-1)<<12 |
+		uint64(s.groupCount-1)<<8 | uint64(s.memberIndex)<<4 | uint64(s.memberThreshold-1)
+
+	valueWords := (len(s.value)*8 + slip39RadixBits - 1) / slip39RadixBits
+	data := append(intToIndices(new(big.Int).SetUint64(idExp), slip39IDExpWords),
+		intToIndices(new(big.Int).SetUint64(params), slip39ParamsWords)...)
+	data = append(data, intToIndices(new(big.Int).SetBytes(s.value), valueWords)...)
+	data = append(data, rs1024Checksum(data, s.extendable)...)
+
+	words := make([]string, len(data))
+	for i, idx := range data {
+		words[i] = slip39Words[idx]
+	}
+	return strings.Join(words, " ")
+}
+
+// decodeShare parses and checksums a share mnemonic.
+func decodeShare(mnemonic string) (*share, error) {
+	words := strings.Fields(strings.ToLower(mnemonic))
+	if len(words) < slip39MinWords {
+		return nil, fmt.Errorf("%w: too short (%d words)", ErrInvalidShare, len(words))
+	}
+	padding := (slip39RadixBits * (len(words) - slip39MetadataWords)) % 16
+	if padding > 8 {
+		return nil, fmt.Errorf("%w: invalid length (%d words)", ErrInvalidShare, len(words))
+	}
+
+	data := make([]int, len(words))
+	for i, w := range words {
+		idx, ok := slip39Index[w]
+		if !ok {
+			return nil, fmt.Errorf("%w: unknown word %q", ErrInvalidShare, w)
+		}
+		data[i] = idx
+	}
+
+	idExp := indicesToInt(data[:slip39IDExpWords]).Uint64()
+	s := &share{
+		identifier:        uint16(idExp >> 5),
+		extendable:        (idExp>>4)&1 == 1,
+		iterationExponent: int(idExp & 0xF),
+	}
+	if !rs1024Verify(data, s.extendable) {
+		return nil, fmt.Errorf("%w: checksum mismatch", ErrInvalidShare)
+	}
+
+	params := indicesToInt(data[slip39IDExpWords : slip39IDExpWords+slip39ParamsWords]).Uint64()
+	s.groupIndex = int(params >> 16)
+	s.groupThreshold = int(params>>12&0xF) + 1
+	s.groupCount = int(params>>8&0xF) + 1
+	s.memberIndex = int(params >> 4 & 0xF)
+	s.memberThreshold = int(params&0xF) + 1
+	if s.groupCount < s.groupThreshold {
+		return nil, fmt.Errorf("%w: group threshold exceeds group count", ErrInvalidShare)
+	}
+
+	valueData := data[slip39IDExpWords+slip39ParamsWords : len(data)-slip39ChecksumWords]
+	if valueData[0] >= 1<<(slip39RadixBits-padding) {
+		return nil, fmt.Errorf("%w: invalid padding", ErrInvalidShare)
+	}
+	valueBytes := (slip39RadixBits*len(valueData) - padding) / 8
+	s.value = indicesToInt(valueData).FillBytes(make([]byte, valueBytes))
+	return s, nil
+}
+
+// intToIndices splits v into n 10-bit words, most significant first.
+func intToIndices(v *big.Int, n int) []int {
+	out := make([]int, n)
+	mask := big.NewInt(1<<slip39RadixBits - 1)
+	rest := new(big.Int).Set(v)
+	for i := n - 1; i >= 0; i-- {
+		out[i] = int(new(big.Int).And(rest, mask).Int64())
+		rest.Rsh(rest, slip39RadixBits)
+	}
+	return out
+}
+
+// indicesToInt joins 10-bit words into an integer.
+func indicesToInt(indices []int) *big.Int {
+	v := new(big.Int)
+	for _, idx := range indices {
+		v.Lsh(v, slip39RadixBits)
+		v.Or(v, big.NewInt(int64(idx)))
+	}
+	return v
+}
+
+// ========================================
+// RS1024 checksum
+// ========================================
+
+var rs1024Gen = [10]uint32{
+	0xE0E040, 0x1C1C080, 0x3838100, 0x7070200, 0xE0E0009,
+	0x1C0C2412, 0x38086C24, 0x3090FC48, 0x21B1F890, 0x3F3F120,
+}
+
+func rs1024Polymod(values []int) uint32 {
+	chk := uint32(1)
+	for _, v := range values {
+		b := chk >> 20
+		chk = (chk&0xFFFFF)<<10 ^ uint32(v)
+		for i := 0; i < 10; i++ {
+			if (b>>i)&1 == 1 {
+				chk ^= rs1024Gen[i]
+			}
+		}
+	}
+	return chk
+}
+
+// rs1024Customization returns the checksum customization string as words.
+func rs1024Customization(extendable bool) []int {
+	cs := "shamir"
+	if extendable {
+		cs = "shamir_extendable"
+	}
+	out := make([]int, len(cs))
+	for i := range cs {
+		out[i] = int(cs[i])
+	}
+	return out
+}
+
+func rs1024Checksum(data []int, extendable bool) []int {
+	values := append(rs1024Customization(extendable), data...)
+	polymod := rs1024Polymod(append(values, 0, 0, 0)) ^ 1
+	out := make([]int, slip39ChecksumWords)
+	for i := range out {
+		out[i] = int(polymod>>(slip39RadixBits*(slip39ChecksumWords-1-i))) & (1<<slip39RadixBits - 1)
+	}
+	return out
+}
+
+func rs1024Verify(data []int, extendable bool) bool {
+	return rs1024Polymod(append(rs1024Customization(extendable), data...)) == 1
+}
+
+// ========================================
+// Feistel encryption
+// ========================================
+
+func slip39Salt(identifier uint16, extendable bool) []byte {
+	if extendable {
+		return nil
+	}
+	return binary.BigEndian.AppendUint16([]byte("shamir"), identifier)
+}
+
+func slip39Round(i int, passphrase string, exponent int, salt, r []byte) []byte {
+	password := append([]byte{byte(i)}, passphrase...)
+	iterations := (slip39BaseIterations << exponent) / slip39RoundCount
+	return pbkdf2.Key(password, append(append([]byte(nil), salt...), r...), iterations, len(r), sha256.New)
+}
+
+func slip39Encrypt(secret []byte, passphrase string, exponent int, identifier uint16, extendable bool) []byte {
+	half := len(secret) / 2
+	l := append([]byte(nil), secret[:half]...)
+	r := append([]byte(nil), secret[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := 0; i < slip39RoundCount; i++ {
+		l, r = r, xorBytes(l, slip39Round(i, passphrase, exponent, salt, r))
+	}
+	return append(r, l...)
+}
+
+func slip39Decrypt(ems []byte, passphrase string, exponent int, identifier uint16, extendable bool) []byte {
+	half := len(ems) / 2
+	l := append([]byte(nil), ems[:half]...)
+	r := append([]byte(nil), ems[half:]...)
+	salt := slip39Salt(identifier, extendable)
+	for i := slip39RoundCount - 1; i >= 0; i-- {
+		l, r = r, xorBytes(l, slip39Round(i, passphrase, exponent, salt, r))
+	}
+	return append(r, l...)
+}
+
+func xorBytes(a, b []byte) []byte {
+	out := make([]byte, len(a))
+	for i := range a {
+		out[i] = a[i] ^ b[i]
+	}
+	return out
+}
+
+// ========================================
+// Shamir sharing over GF(256)
+// ========================================
+
+var gfExp, gfLog = func() ([255]int, [256]int) {
+	var exp [255]int
+	var log [256]int
+	poly := 1
+	for i := 0; i < 255; i++ {
+		exp[i] = poly
+		log[poly] = i
+		// Multiply by the generator x+1, reducing by x^8+x^4+x^3+x+1
+		poly = poly<<1 ^ poly
+		if poly&0x100 != 0 {
+			poly ^= 0x11B
+		}
+	}
+	return exp, log
+}()
+
+// interpolate evaluates at x the polynomial through the shares.
+func interpolate(shares map[int][]byte, x int) ([]byte, error) {
+	var length = -1
+	for _, v := range shares {
+		if length >= 0 && len(v) != length {
+			return nil, fmt.Errorf("%w: share values have different lengths", ErrInvalidShare)
+		}
+		length = len(v)
+	}
+	if v, ok := shares[x]; ok {
+		return append([]byte(nil), v...), nil
+	}
+
+	logProd := 0
+	for sx := range shares {
+		logProd += gfLog[sx^x]
+	}
+	result := make([]byte, length)
+	for sx, v := range shares {
+		logBasis := logProd - gfLog[sx^x]
+		for ox := range shares {
+			logBasis -= gfLog[sx^ox]
+		}
+		logBasis = ((logBasis % 255) + 255) % 255
+		for i, b := range v {
+			if b != 0 {
+				result[i] ^= byte(gfExp[(gfLog[b]+logBasis)%255])
+			}
+		}
+	}
+	return result, nil
+}
+
+// shareDigest is the first bytes of HMAC-SHA256(randomPart, secret).
+func shareDigest(randomPart, secret []byte) []byte {
+	mac := hmac.New(sha256.New, randomPart)
+	mac.Write(secret)
+	return mac.Sum(nil)[:slip39DigestBytes]
+}
+
+// splitSecret splits a secret into count shares, threshold of which recover
+// it. A digest share lets recovery detect a wrong combination.
+func splitSecret(threshold, count int, secret []byte) ([][]byte, error) {
+	if threshold < 1 || threshold > count || count > slip39MaxShareCount {
+		return nil, fmt.Errorf("invalid threshold %d of %d", threshold, count)
+	}
+	out := make([][]byte, count)
+	if threshold == 1 {
+		for i := range out {
+			out[i] = append([]byte(nil), secret...)
+		}
+		return out, nil
+	}
+
+	randomCount := threshold - 2
+	base := make(map[int][]byte, threshold)
+	for i := 0; i < randomCount; i++ {
+		v := make([]byte, len(secret))
+		if _, err := rand.Read(v); err != nil {
+			return nil, fmt.Errorf("failed to generate share: %w", err)
+		}
+		out[i] = v
+		base[i] = v
+	}
+	randomPart := make([]byte, len(secret)-slip39DigestBytes)
+	if _, err := rand.Read(randomPart); err != nil {
+		return nil, fmt.Errorf("failed to generate share: %w", err)
+	}
+	base[slip39DigestIndex] = append(shareDigest(randomPart, secret), randomPart...)
+	base[slip39SecretIndex] = secret
+
+	for i := randomCount; i < count; i++ {
+		v, err := interpolate(base, i)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+// recoverSecret recovers a secret from threshold shares and checks its digest.
+func recoverSecret(threshold int, shares map[int][]byte) ([]byte, error) {
+	if threshold == 1 {
+		for _, v := range shares {
+			return append([]byte(nil), v...), nil
+		}
+	}
+	secret, err := interpolate(shares, slip39SecretIndex)
+	if err != nil {
+		return nil, err
+	}
+	digestShare, err := interpolate(shares, slip39DigestIndex)
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(digestShare[:slip39DigestBytes], shareDigest(digestShare[slip39DigestBytes:], secret)) {
+		return nil, fmt.Errorf("%w: share digest mismatch", ErrInvalidShare)
+	}
+	return secret, nil
+}
@@ -0,0 +1,131 @@
+package wallet
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestSLIP39Wordlist(t *testing.T) {
+	if len(slip39Words) != 1024 {
+		t.Fatalf("wordlist has %d words, want 1024", len(slip39Words))
+	}
+	prefixes := make(map[string]bool)
+	for i, w := range slip39Words {
+		if i > 0 && w <= slip39Words[i-1] {
+			t.Errorf("wordlist not sorted at %q", w)
+		}
+		p := w
+		if len(p) > 4 {
+			p = p[:4]
+		}
+		if prefixes[p] {
+			t.Errorf("duplicate prefix %q", p)
+		}
+		prefixes[p] = true
+	}
+}
+
+// TestCombineSharesVector is the first SLIP-0039 test vector.
+func TestCombineSharesVector(t *testing.T) {
+	share := "duckling enlarge academic academic agency result length solution fridge kidney coal piece deal husband erode duke ajar critical decision keyboard"
+	secret, err := CombineShares([]string{share}, "TREZOR")
+	if err != nil {
+		t.Fatalf("CombineShares() error = %v", err)
+	}
+	if got := hex.EncodeToString(secret); got != "bb54aac4b89dc868ba37d9cc21b2cece" {
+		t.Errorf("secret = %s, want bb54aac4b89dc868ba37d9cc21b2cece", got)
+	}
+
+	// A changed word breaks the checksum
+	bad := strings.Replace(share, "keyboard", "kidney", 1)
+	if _, err := CombineShares([]string{bad}, "TREZOR"); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("CombineShares(bad checksum) error = %v, want ErrInvalidShare", err)
+	}
+}
+
+func TestGenerateAndCombineShares(t *testing.T) {
+	secret, _ := hex.DecodeString("0c94abf0a5f3c1bd5c6b1e2e3bb1bd3a74e8bea3d5f2a1c9d4e6f7a8b9c0d1e2")
+	groups := []ShareGroup{{Threshold: 1, Count: 1}, {Threshold: 2, Count: 3}, {Threshold: 3, Count: 5}}
+
+	shares, err := GenerateShares(secret, "pass", 2, groups)
+	if err != nil {
+		t.Fatalf("GenerateShares() error = %v", err)
+	}
+	if len(shares) != 3 || len(shares[1]) != 3 || len(shares[2]) != 5 {
+		t.Fatalf("unexpected share layout: %d groups", len(shares))
+	}
+	if words := strings.Fields(shares[0][0]); len(words) != 33 {
+		t.Errorf("256-bit share has %d words, want 33", len(words))
+	}
+
+	tests := []struct {
+		name   string
+		shares []string
+	}{
+		{"groups 0 and 1", []string{shares[0][0], shares[1][2], shares[1][0]}},
+		{"groups 1 and 2", []string{shares[1][1], shares[2][4], shares[1][2], shares[2][0], shares[2][2]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := CombineShares(tt.shares, "pass")
+			if err != nil {
+				t.Fatalf("CombineShares() error = %v", err)
+			}
+			if !bytes.Equal(got, secret) {
+				t.Errorf("CombineShares() = %x, want %x", got, secret)
+			}
+		})
+	}
+
+	// Too few members of a group
+	if _, err := CombineShares([]string{shares[0][0], shares[1][0]}, "pass"); !errors.Is(err, ErrInsufficientShares) {
+		t.Errorf("one member of a 2-of-3 group error = %v, want ErrInsufficientShares", err)
+	}
+	// Too few groups
+	if _, err := CombineShares([]string{shares[1][0], shares[1][1]}, "pass"); !errors.Is(err, ErrInsufficientShares) {
+		t.Errorf("one group error = %v, want ErrInsufficientShares", err)
+	}
+	// The passphrase decrypts, it doesn't authenticate
+	if got, err := CombineShares(tests[0].shares, "other"); err != nil || bytes.Equal(got, secret) {
+		t.Errorf("wrong passphrase = %x, %v", got, err)
+	}
+	// Shares from another backup
+	other, err := GenerateShares(secret, "pass", 1, []ShareGroup{{Threshold: 2, Count: 2}})
+	if err != nil {
+		t.Fatalf("GenerateShares() error = %v", err)
+	}
+	if _, err := CombineShares([]string{other[0][0], shares[0][0]}, "pass"); !errors.Is(err, ErrInvalidShare) {
+		t.Errorf("mixed backups error = %v, want ErrInvalidShare", err)
+	}
+}
+
+func TestGenerateSharesInvalid(t *testing.T) {
+	secret := make([]byte, 16)
+	tests := []struct {
+		name      string
+		secret    []byte
+		threshold int
+		groups    []ShareGroup
+	}{
+		{"short secret", make([]byte, 14), 1, []ShareGroup{{1, 1}}},
+		{"odd secret", make([]byte, 17), 1, []ShareGroup{{1, 1}}},
+		{"no groups", secret, 1, nil},
+		{"threshold above groups", secret, 2, []ShareGroup{{1, 1}}},
+		{"member threshold above count", secret, 1, []ShareGroup{{3, 2}}},
+		{"1-of-n group", secret, 1, []ShareGroup{{1, 3}}},
+		{"too many members", secret, 1, []ShareGroup{{2, 17}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := GenerateShares(tt.secret, "", tt.threshold, tt.groups); err == nil {
+				t.Error("GenerateShares() should fail")
+			}
+		})
+	}
+	if _, err := GenerateShares(secret, "pässword", 1, []ShareGroup{{1, 1}}); err == nil {
+		t.Error("non-ASCII passphrase should fail")
+	}
+}
@@ -0,0 +1,561 @@
+// Package wallet - Trezor hardware wallets.
+//
+// Protobuf messages are framed in 64 byte HID packets: "?##", the message
+// type and length in the first packet, then "?" and the rest of the message
+// in continuation packets. Transactions are signed with the SignTx/TxRequest
+// protocol: the device asks for the inputs and outputs of the transaction,
+// and for the transactions it spends to check their amounts.
+package wallet
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// Message types.
+const (
+	trezorMsgInitialize        = 0
+	trezorMsgFailure           = 3
+	trezorMsgGetPublicKey      = 11
+	trezorMsgPublicKey         = 12
+	trezorMsgSignTx            = 15
+	trezorMsgFeatures          = 17
+	trezorMsgPinMatrixRequest  = 18
+	trezorMsgCancel            = 20
+	trezorMsgTxRequest         = 21
+	trezorMsgTxAck             = 22
+	trezorMsgButtonRequest     = 26
+	trezorMsgButtonAck         = 27
+	trezorMsgGetAddress        = 29
+	trezorMsgAddress           = 30
+	trezorMsgPassphraseRequest = 41
+)
+
+// Failure codes.
+const (
+	trezorFailureActionCancelled = 4
+	trezorFailurePinExpected     = 5
+	trezorFailurePinCancelled    = 6
+	trezorFailurePinInvalid      = 7
+)
+
+// Request types of TxRequest.
+const (
+	trezorRequestInput    = 0
+	trezorRequestOutput   = 1
+	trezorRequestMeta     = 2
+	trezorRequestFinished = 3
+)
+
+// Input and output script types.
+const (
+	trezorSpendAddress  = 0
+	trezorSpendWitness  = 3
+	trezorPayToAddress  = 0
+	trezorPayToOpReturn = 3
+)
+
+// trezor is a Trezor One.
+type trezor struct {
+	info DeviceInfo
+	dev  io.ReadWriteCloser
+}
+
+func newTrezor(info DeviceInfo, dev io.ReadWriteCloser) *trezor {
+	return &trezor{info: info, dev: dev}
+}
+
+func (t *trezor) Type() string {
+	return SignerTrezor
+}
+
+func (t *trezor) Info() DeviceInfo {
+	return t.info
+}
+
+func (t *trezor) Close() error {
+	return t.dev.Close()
+}
+
+// initialize starts a session.
+func (t *trezor) initialize(ctx context.Context) error {
+	_, err := t.call(ctx, trezorMsgInitialize, nil, trezorMsgFeatures)
+	return err
+}
+
+// PublicKey returns the extended public key at a derivation path.
+func (t *trezor) PublicKey(ctx context.Context, params *chain.Params, path []uint32) (*hdkeychain.ExtendedKey, error) {
+	coin, err := trezorCoinName(params)
+	if err != nil {
+		return nil, err
+	}
+	var msg []byte
+	msg = appendTrezorPath(msg, 1, path)
+	msg = protowire.AppendTag(msg, 4, protowire.BytesType)
+	msg = protowire.AppendString(msg, coin)
+	resp, err := t.call(ctx, trezorMsgGetPublicKey, msg, trezorMsgPublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	node, ok := protoFields(resp)[1]
+	if !ok {
+		return nil, fmt.Errorf("trezor returned a public key without a node")
+	}
+	fields := protoFields(node.bytes)
+	return newExtendedPublicKey(params, fields[6].bytes, fields[4].bytes, uint32(fields[2].varint), path)
+}
+
+// DisplayAddress shows the address of a derivation path on the device.
+func (t *trezor) DisplayAddress(ctx context.Context, params *chain.Params, path []uint32) (string, error) {
+	coin, err := trezorCoinName(params)
+	if err != nil {
+		return "", err
+	}
+	addrType, err := deviceAddressType(params)
+	if err != nil {
+		return "", err
+	}
+	scriptType := uint64(trezorSpendAddress)
+	if addrType == chain.AddressP2WPKH {
+		scriptType = trezorSpendWitness
+	}
+	var msg []byte
+	msg = appendTrezorPath(msg, 1, path)
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendString(msg, coin)
+	msg = protowire.AppendTag(msg, 3, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, 1) // show_display
+	msg = protowire.AppendTag(msg, 5, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, scriptType)
+	resp, err := t.call(ctx, trezorMsgGetAddress, msg, trezorMsgAddress)
+	if err != nil {
+		return "", err
+	}
+	return string(protoFields(resp)[1].bytes), nil
+}
+
+// SignTx signs the p2wpkh and p2pkh inputs of a transaction.
+func (t *trezor) SignTx(ctx context.Context, req *SignRequest) error {
+	if len(req.UTXOs) != len(req.Tx.TxIn) {
+		return fmt.Errorf("%d UTXOs for %d inputs", len(req.UTXOs), len(req.Tx.TxIn))
+	}
+	if req.PrevTx == nil {
+		return fmt.Errorf("trezor needs the transactions spent to sign")
+	}
+	coin, err := trezorCoinName(req.Params)
+	if err != nil {
+		return err
+	}
+
+	// Input keys, for the witnesses and signature scripts
+	pubKeys := make([]*btcec.PublicKey, len(req.UTXOs))
+	for i, utxo := range req.UTXOs {
+		switch addrType := detectAddressType(utxo.Address, req.Params); addrType {
+		case "p2wpkh", "p2pkh":
+		default:
+			return fmt.Errorf("trezor signs p2wpkh and p2pkh inputs only, input %d is %s", i, addrType)
+		}
+		key, err := t.PublicKey(ctx, req.Params, req.Params.DerivationPath(utxo.Account, utxo.Change, utxo.AddressIndex))
+		if err != nil {
+			return err
+		}
+		if pubKeys[i], err = key.ECPubKey(); err != nil {
+			return err
+		}
+	}
+
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(len(req.Tx.TxOut)))
+	msg = protowire.AppendTag(msg, 2, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(len(req.Tx.TxIn)))
+	msg = protowire.AppendTag(msg, 3, protowire.BytesType)
+	msg = protowire.AppendString(msg, coin)
+	msg = protowire.AppendTag(msg, 4, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(uint32(req.Tx.Version)))
+	msg = protowire.AppendTag(msg, 5, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(req.Tx.LockTime))
+
+	prevTxs := make(map[chainhash.Hash]*wire.MsgTx)
+	signed := make([]bool, len(req.Tx.TxIn))
+	msgType := uint16(trezorMsgSignTx)
+	for {
+		resp, err := t.call(ctx, msgType, msg, trezorMsgTxRequest)
+		if err != nil {
+			return err
+		}
+		fields := protoFields(resp)
+
+		// A signature of the previous request's input
+		if serialized, ok := fields[3]; ok {
+			sig := protoFields(serialized.bytes)
+			if der, ok := sig[2]; ok {
+				i := int(sig[1].varint)
+				if i >= len(req.Tx.TxIn) {
+					return fmt.Errorf("trezor returned a signature for input %d of %d", i, len(req.Tx.TxIn))
+				}
+				if err := setInputSignature(req, i, der.bytes, pubKeys[i]); err != nil {
+					return err
+				}
+				signed[i] = true
+			}
+		}
+
+		details := protoFields(fields[2].bytes)
+		index := int(details[1].varint)
+		var tx *wire.MsgTx
+		if hash, ok := details[2]; ok {
+			// A transaction spent, by its hash in display order
+			if tx, err = t.prevTx(ctx, req, prevTxs, hash.bytes); err != nil {
+				return err
+			}
+		}
+
+		var ack []byte
+		switch fields[1].varint {
+		case trezorRequestInput:
+			ack, err = trezorInput(req, tx, index)
+		case trezorRequestOutput:
+			ack, err = trezorOutput(req, tx, index)
+		case trezorRequestMeta:
+			if tx == nil {
+				return fmt.Errorf("trezor asked for metadata of the transaction being signed")
+			}
+			ack = trezorTxMeta(tx)
+		case trezorRequestFinished:
+			for i, ok := range signed {
+				if !ok {
+					return fmt.Errorf("trezor didn't sign input %d", i)
+				}
+			}
+			return nil
+		default:
+			return fmt.Errorf("trezor sent unsupported request type %d", fields[1].varint)
+		}
+		if err != nil {
+			return err
+		}
+		msg = protowire.AppendTag(nil, 1, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, ack)
+		msgType = trezorMsgTxAck
+	}
+}
+
+// prevTx returns a transaction spent by the transaction being signed.
+func (t *trezor) prevTx(ctx context.Context, req *SignRequest, cache map[chainhash.Hash]*wire.MsgTx, displayHash []byte) (*wire.MsgTx, error) {
+	if len(displayHash) != chainhash.HashSize {
+		return nil, fmt.Errorf("trezor asked for a %d byte transaction hash", len(displayHash))
+	}
+	var hash chainhash.Hash
+	for i, b := range displayHash {
+		hash[chainhash.HashSize-1-i] = b
+	}
+	if tx, ok := cache[hash]; ok {
+		return tx, nil
+	}
+	tx, err := req.PrevTx(ctx, hash)
+	if err != nil {
+		return nil, err
+	}
+	cache[hash] = tx
+	return tx, nil
+}
+
+// trezorInput returns input index of the transaction being signed, or of
+// a transaction it spends if tx is set, as a TransactionType.
+func trezorInput(req *SignRequest, tx *wire.MsgTx, index int) ([]byte, error) {
+	inputs := req.Tx.TxIn
+	if tx != nil {
+		inputs = tx.TxIn
+	}
+	if index >= len(inputs) {
+		return nil, fmt.Errorf("trezor asked for input %d of %d", index, len(inputs))
+	}
+	in := inputs[index]
+
+	var input []byte
+	if tx == nil {
+		utxo := req.UTXOs[index]
+		input = appendTrezorPath(input, 1, req.Params.DerivationPath(utxo.Account, utxo.Change, utxo.AddressIndex))
+	}
+	input = protowire.AppendTag(input, 2, protowire.BytesType)
+	input = protowire.AppendBytes(input, displayOrder(in.PreviousOutPoint.Hash))
+	input = protowire.AppendTag(input, 3, protowire.VarintType)
+	input = protowire.AppendVarint(input, uint64(in.PreviousOutPoint.Index))
+	if tx != nil {
+		input = protowire.AppendTag(input, 4, protowire.BytesType)
+		input = protowire.AppendBytes(input, in.SignatureScript)
+	}
+	input = protowire.AppendTag(input, 5, protowire.VarintType)
+	input = protowire.AppendVarint(input, uint64(in.Sequence))
+	if tx == nil {
+		scriptType := uint64(trezorSpendAddress)
+		if detectAddressType(req.UTXOs[index].Address, req.Params) == "p2wpkh" {
+			scriptType = trezorSpendWitness
+		}
+		input = protowire.AppendTag(input, 6, protowire.VarintType)
+		input = protowire.AppendVarint(input, scriptType)
+		input = protowire.AppendTag(input, 8, protowire.VarintType)
+		input = protowire.AppendVarint(input, req.UTXOs[index].Amount)
+	}
+
+	msg := protowire.AppendTag(nil, 2, protowire.BytesType)
+	return protowire.AppendBytes(msg, input), nil
+}
+
+// trezorOutput returns output index of the transaction being signed, by
+// address, or of a transaction it spends if tx is set, by script, as a
+// TransactionType.
+func trezorOutput(req *SignRequest, tx *wire.MsgTx, index int) ([]byte, error) {
+	if tx != nil {
+		if index >= len(tx.TxOut) {
+			return nil, fmt.Errorf("trezor asked for output %d of %d", index, len(tx.TxOut))
+		}
+		out := tx.TxOut[index]
+		var output []byte
+		output = protowire.AppendTag(output, 1, protowire.VarintType)
+		output = protowire.AppendVarint(output, uint64(out.Value))
+		output = protowire.AppendTag(output, 2, protowire.BytesType)
+		output = protowire.AppendBytes(output, out.PkScript)
+		msg := protowire.AppendTag(nil, 3, protowire.BytesType)
+		return protowire.AppendBytes(msg, output), nil
+	}
+
+	if index >= len(req.Tx.TxOut) {
+		return nil, fmt.Errorf("trezor asked for output %d of %d", index, len(req.Tx.TxOut))
+	}
+	out := req.Tx.TxOut[index]
+	var output []byte
+	if txscript.GetScriptClass(out.PkScript) == txscript.NullDataTy {
+		pushes, err := txscript.PushedData(out.PkScript)
+		if err != nil {
+			return nil, err
+		}
+		output = protowire.AppendTag(output, 3, protowire.VarintType)
+		output = protowire.AppendVarint(output, uint64(out.Value))
+		output = protowire.AppendTag(output, 4, protowire.VarintType)
+		output = protowire.AppendVarint(output, trezorPayToOpReturn)
+		output = protowire.AppendTag(output, 6, protowire.BytesType)
+		output = protowire.AppendBytes(output, bytes.Join(pushes, nil))
+	} else {
+		_, addrs, _, err := txscript.ExtractPkScriptAddrs(out.PkScript, toChainCfgParams(req.Params))
+		if err != nil || len(addrs) != 1 {
+			return nil, fmt.Errorf("trezor can't sign output %d: not a standard address", index)
+		}
+		output = protowire.AppendTag(output, 1, protowire.BytesType)
+		output = protowire.AppendString(output, addrs[0].EncodeAddress())
+		output = protowire.AppendTag(output, 3, protowire.VarintType)
+		output = protowire.AppendVarint(output, uint64(out.Value))
+		output = protowire.AppendTag(output, 4, protowire.VarintType)
+		output = protowire.AppendVarint(output, trezorPayToAddress)
+	}
+	msg := protowire.AppendTag(nil, 5, protowire.BytesType)
+	return protowire.AppendBytes(msg, output), nil
+}
+
+// trezorTxMeta returns the version, lock time and counts of inputs and
+// outputs of a transaction, as a TransactionType.
+func trezorTxMeta(tx *wire.MsgTx) []byte {
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(uint32(tx.Version)))
+	msg = protowire.AppendTag(msg, 4, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(tx.LockTime))
+	msg = protowire.AppendTag(msg, 6, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(len(tx.TxIn)))
+	msg = protowire.AppendTag(msg, 7, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, uint64(len(tx.TxOut)))
+	return msg
+}
+
+// call sends a message and returns the response of type want, confirming
+// button requests; the user presses the button on the device.
+func (t *trezor) call(ctx context.Context, msgType uint16, msg []byte, want uint16) ([]byte, error) {
+	stop := watchContext(ctx, t.dev)
+	defer stop()
+
+	fail := func(err error) ([]byte, error) {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, fmt.Errorf("trezor: %w", err)
+	}
+
+	for {
+		if err := t.write(msgType, msg); err != nil {
+			return fail(err)
+		}
+		respType, resp, err := t.read()
+		if err != nil {
+			return fail(err)
+		}
+
+		switch respType {
+		case want:
+			return resp, nil
+		case trezorMsgButtonRequest:
+			msgType, msg = trezorMsgButtonAck, nil
+		case trezorMsgFailure:
+			return nil, trezorFailure(resp)
+		case trezorMsgPinMatrixRequest, trezorMsgPassphraseRequest:
+			// Neither can be entered here: cancel
+			if err := t.write(trezorMsgCancel, nil); err != nil {
+				return fail(err)
+			}
+			if _, _, err := t.read(); err != nil {
+				return fail(err)
+			}
+			if respType == trezorMsgPinMatrixRequest {
+				return nil, ErrDeviceLocked
+			}
+			return nil, fmt.Errorf("trezor passphrases are not supported")
+		default:
+			return nil, fmt.Errorf("trezor sent unexpected message type %d", respType)
+		}
+	}
+}
+
+// trezorFailure returns the error of a Failure message.
+func trezorFailure(msg []byte) error {
+	fields := protoFields(msg)
+	switch fields[1].varint {
+	case trezorFailureActionCancelled, trezorFailurePinCancelled:
+		return ErrDeviceRejected
+	case trezorFailurePinExpected, trezorFailurePinInvalid:
+		return ErrDeviceLocked
+	}
+	if message := fields[2].bytes; len(message) > 0 {
+		return fmt.Errorf("trezor: %s", message)
+	}
+	return fmt.Errorf("trezor failure %d", fields[1].varint)
+}
+
+// write sends a message in HID packets.
+func (t *trezor) write(msgType uint16, msg []byte) error {
+	data := []byte("##")
+	data = binary.BigEndian.AppendUint16(data, msgType)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(msg)))
+	data = append(data, msg...)
+	for len(data) > 0 {
+		packet := make([]byte, hidPacketSize)
+		packet[0] = '?'
+		data = data[copy(packet[1:], data):]
+		if _, err := t.dev.Write(packet); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// read receives a message from HID packets.
+func (t *trezor) read() (uint16, []byte, error) {
+	var data []byte
+	size := -1
+	for size < 0 || len(data) < size {
+		packet := make([]byte, hidPacketSize)
+		n, err := t.dev.Read(packet)
+		if err != nil {
+			return 0, nil, err
+		}
+		if n < 1 || packet[0] != '?' {
+			return 0, nil, errors.New("unexpected HID packet")
+		}
+		data = append(data, packet[1:n]...)
+		if size < 0 {
+			if len(data) < 8 || data[0] != '#' || data[1] != '#' {
+				return 0, nil, errors.New("unexpected HID packet")
+			}
+			size = 8 + int(binary.BigEndian.Uint32(data[4:]))
+		}
+	}
+	return binary.BigEndian.Uint16(data[2:]), data[8:size], nil
+}
+
+// trezorCoinName returns the Trezor coin name of a chain.
+func trezorCoinName(params *chain.Params) (string, error) {
+	switch {
+	case params.Symbol == "BTC" && params.Bech32HRP == "bc":
+		return "Bitcoin", nil
+	case params.Symbol == "BTC" && params.Bech32HRP == "tb":
+		return "Testnet", nil
+	case params.Symbol == "LTC" && params.Bech32HRP == "ltc":
+		return "Litecoin", nil
+	case params.Symbol == "DOGE" && params.PubKeyHashAddrID == 0x1e:
+		return "Dogecoin", nil
+	}
+	return "", fmt.Errorf("trezor doesn't support %s on this network", params.Symbol)
+}
+
+// appendTrezorPath appends a derivation path as a repeated field.
+func appendTrezorPath(msg []byte, num protowire.Number, path []uint32) []byte {
+	for _, n := range path {
+		msg = protowire.AppendTag(msg, num, protowire.VarintType)
+		msg = protowire.AppendVarint(msg, uint64(n))
+	}
+	return msg
+}
+
+// displayOrder returns a hash byte-reversed, as Trezor expects them.
+func displayOrder(hash chainhash.Hash) []byte {
+	b := make([]byte, chainhash.HashSize)
+	for i := range hash {
+		b[chainhash.HashSize-1-i] = hash[i]
+	}
+	return b
+}
+
+// protoField is the last value of a protobuf field.
+type protoField struct {
+	varint uint64
+	bytes  []byte
+}
+
+// protoFields decodes the varint and length-delimited fields of a message,
+// keeping the last value of each. Malformed input ends decoding.
+func protoFields(msg []byte) map[protowire.Number]protoField {
+	fields := make(map[protowire.Number]protoField)
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		if n < 0 {
+			break
+		}
+		msg = msg[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(msg)
+			if n < 0 {
+				return fields
+			}
+			fields[num] = protoField{varint: v}
+			msg = msg[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(msg)
+			if n < 0 {
+				return fields
+			}
+			fields[num] = protoField{bytes: v}
+			msg = msg[n:]
+		default:
+			n := protowire.ConsumeFieldValue(num, typ, msg)
+			if n < 0 {
+				return fields
+			}
+			msg = msg[n:]
+		}
+	}
+	return fields
+}
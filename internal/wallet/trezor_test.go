@@ -0,0 +1,446 @@
+package wallet
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcec/v2/ecdsa"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// trezorMessage is a message to or from the fake Trezor.
+type trezorMessage struct {
+	typ  uint16
+	data []byte
+}
+
+// fakeTrezor emulates a Trezor One over HID, with the keys of
+// testDeviceMaster. A goroutine plays the device side of each exchange:
+// it gets the host's messages from in and answers on out.
+type fakeTrezor struct {
+	master *hdkeychain.ExtendedKey
+	params *chain.Params
+	pin    bool // Ask for the PIN
+	reject bool // Cancel on the confirmation button
+
+	buf     []byte
+	packets [][]byte
+	buttons int // Button requests acknowledged
+
+	in  chan trezorMessage
+	out chan trezorMessage
+}
+
+func newFakeTrezor(t *testing.T, params *chain.Params) *fakeTrezor {
+	f := &fakeTrezor{
+		master: testDeviceMaster(t),
+		params: params,
+		in:     make(chan trezorMessage),
+		out:    make(chan trezorMessage),
+	}
+	go func() {
+		for m := range f.in {
+			f.dispatch(m)
+		}
+	}()
+	t.Cleanup(func() { close(f.in) })
+	return f
+}
+
+func (f *fakeTrezor) Write(p []byte) (int, error) {
+	if len(p) != hidPacketSize || p[0] != '?' {
+		return 0, errors.New("bad packet")
+	}
+	f.buf = append(f.buf, p[1:]...)
+	if len(f.buf) < 8 || len(f.buf) < 8+int(binary.BigEndian.Uint32(f.buf[4:])) {
+		return len(p), nil
+	}
+	size := 8 + int(binary.BigEndian.Uint32(f.buf[4:]))
+	f.in <- trezorMessage{typ: binary.BigEndian.Uint16(f.buf[2:]), data: f.buf[8:size]}
+	f.buf = nil
+
+	resp := <-f.out
+	data := []byte("##")
+	data = binary.BigEndian.AppendUint16(data, resp.typ)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(resp.data)))
+	data = append(data, resp.data...)
+	for len(data) > 0 {
+		packet := make([]byte, hidPacketSize)
+		packet[0] = '?'
+		data = data[copy(packet[1:], data):]
+		f.packets = append(f.packets, packet)
+	}
+	return len(p), nil
+}
+
+func (f *fakeTrezor) Read(p []byte) (int, error) {
+	if len(f.packets) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, f.packets[0])
+	f.packets = f.packets[1:]
+	return n, nil
+}
+
+func (f *fakeTrezor) Close() error {
+	return nil
+}
+
+// exchange sends a message to the host and returns its answer.
+func (f *fakeTrezor) exchange(typ uint16, data []byte) trezorMessage {
+	f.out <- trezorMessage{typ: typ, data: data}
+	return <-f.in
+}
+
+// failure ends an exchange with a Failure.
+func (f *fakeTrezor) failure(code uint64, message string) {
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, code)
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendString(msg, message)
+	f.out <- trezorMessage{typ: trezorMsgFailure, data: msg}
+}
+
+// confirm asks the user to press the button.
+func (f *fakeTrezor) confirm() bool {
+	if m := f.exchange(trezorMsgButtonRequest, nil); m.typ != trezorMsgButtonAck {
+		f.failure(1, "Unexpected message")
+		return false
+	}
+	f.buttons++
+	if f.reject {
+		f.failure(trezorFailureActionCancelled, "Cancelled")
+		return false
+	}
+	return true
+}
+
+func (f *fakeTrezor) dispatch(m trezorMessage) {
+	switch m.typ {
+	case trezorMsgInitialize:
+		f.out <- trezorMessage{typ: trezorMsgFeatures}
+	case trezorMsgGetPublicKey:
+		if f.pin {
+			if m := f.exchange(trezorMsgPinMatrixRequest, nil); m.typ == trezorMsgCancel {
+				f.failure(trezorFailureActionCancelled, "Cancelled")
+			}
+			return
+		}
+		f.publicKey(protoRepeated(m.data, 1))
+	case trezorMsgGetAddress:
+		path := protoRepeated(m.data, 1)
+		key, _ := derivePath(f.master, path)
+		pubKey, _ := key.ECPubKey()
+		addr, _ := deriveP2PKH(pubKey, toChainCfgParams(f.params))
+		if protoFields(m.data)[5].varint == trezorSpendWitness {
+			addr, _ = deriveP2WPKH(pubKey, toChainCfgParams(f.params))
+		}
+		if protoFields(m.data)[3].varint == 1 && !f.confirm() {
+			return
+		}
+		msg := protowire.AppendTag(nil, 1, protowire.BytesType)
+		f.out <- trezorMessage{typ: trezorMsgAddress, data: protowire.AppendString(msg, addr)}
+	case trezorMsgSignTx:
+		f.signTx(m.data)
+	default:
+		f.failure(1, "Unexpected message")
+	}
+}
+
+func (f *fakeTrezor) publicKey(path []uint32) {
+	key, err := derivePath(f.master, path)
+	if err != nil {
+		f.failure(3, "Invalid path")
+		return
+	}
+	pubKey, _ := key.ECPubKey()
+	var fingerprint uint32
+	if len(path) > 0 {
+		parent, _ := derivePath(f.master, path[:len(path)-1])
+		parentKey, _ := parent.ECPubKey()
+		fingerprint = binary.BigEndian.Uint32(btcutil.Hash160(parentKey.SerializeCompressed())[:4])
+	}
+	var node []byte
+	node = protowire.AppendTag(node, 1, protowire.VarintType)
+	node = protowire.AppendVarint(node, uint64(len(path)))
+	node = protowire.AppendTag(node, 2, protowire.VarintType)
+	node = protowire.AppendVarint(node, uint64(fingerprint))
+	node = protowire.AppendTag(node, 4, protowire.BytesType)
+	node = protowire.AppendBytes(node, key.ChainCode())
+	node = protowire.AppendTag(node, 6, protowire.BytesType)
+	node = protowire.AppendBytes(node, pubKey.SerializeCompressed())
+	msg := protowire.AppendTag(nil, 1, protowire.BytesType)
+	f.out <- trezorMessage{typ: trezorMsgPublicKey, data: protowire.AppendBytes(msg, node)}
+}
+
+// txRequest builds a TxRequest, with the signature of an input if sig is set.
+func txRequest(requestType uint64, index int, hash []byte, sigIndex int, sig []byte) []byte {
+	var details []byte
+	details = protowire.AppendTag(details, 1, protowire.VarintType)
+	details = protowire.AppendVarint(details, uint64(index))
+	if hash != nil {
+		details = protowire.AppendTag(details, 2, protowire.BytesType)
+		details = protowire.AppendBytes(details, hash)
+	}
+	var msg []byte
+	msg = protowire.AppendTag(msg, 1, protowire.VarintType)
+	msg = protowire.AppendVarint(msg, requestType)
+	msg = protowire.AppendTag(msg, 2, protowire.BytesType)
+	msg = protowire.AppendBytes(msg, details)
+	if sig != nil {
+		var serialized []byte
+		serialized = protowire.AppendTag(serialized, 1, protowire.VarintType)
+		serialized = protowire.AppendVarint(serialized, uint64(sigIndex))
+		serialized = protowire.AppendTag(serialized, 2, protowire.BytesType)
+		serialized = protowire.AppendBytes(serialized, sig)
+		msg = protowire.AppendTag(msg, 3, protowire.BytesType)
+		msg = protowire.AppendBytes(msg, serialized)
+	}
+	return msg
+}
+
+// request sends a TxRequest and returns the TransactionType acknowledged.
+func (f *fakeTrezor) request(requestType uint64, index int, hash []byte, sigIndex int, sig []byte) (map[protowire.Number]protoField, bool) {
+	m := f.exchange(trezorMsgTxRequest, txRequest(requestType, index, hash, sigIndex, sig))
+	if m.typ != trezorMsgTxAck {
+		f.failure(1, "Unexpected message")
+		return nil, false
+	}
+	return protoFields(protoFields(m.data)[1].bytes), true
+}
+
+// signTx plays the SignTx flow: the inputs and the transactions they
+// spend, the outputs, the confirmation, then a signature per input.
+func (f *fakeTrezor) signTx(msg []byte) {
+	fields := protoFields(msg)
+	tx := wire.NewMsgTx(int32(fields[4].varint))
+	tx.LockTime = uint32(fields[5].varint)
+	var paths [][]uint32
+	var witness []bool
+	var amounts []int64
+
+	for i := 0; i < int(fields[2].varint); i++ {
+		ack, ok := f.request(trezorRequestInput, i, nil, 0, nil)
+		if !ok {
+			return
+		}
+		input := protoFields(ack[2].bytes)
+		prevHash := input[2].bytes
+		var hash chainhash.Hash
+		for j, b := range prevHash {
+			hash[chainhash.HashSize-1-j] = b
+		}
+		in := wire.NewTxIn(wire.NewOutPoint(&hash, uint32(input[3].varint)), nil, nil)
+		in.Sequence = uint32(input[5].varint)
+		tx.AddTxIn(in)
+		paths = append(paths, protoRepeated(ack[2].bytes, 1))
+		witness = append(witness, input[6].varint == trezorSpendWitness)
+		amounts = append(amounts, int64(input[8].varint))
+
+		// The transaction spent, to check the amount
+		meta, ok := f.request(trezorRequestMeta, 0, prevHash, 0, nil)
+		if !ok {
+			return
+		}
+		prev := wire.NewMsgTx(int32(meta[1].varint))
+		prev.LockTime = uint32(meta[4].varint)
+		for j := 0; j < int(meta[6].varint); j++ {
+			ack, ok := f.request(trezorRequestInput, j, prevHash, 0, nil)
+			if !ok {
+				return
+			}
+			prevIn := protoFields(ack[2].bytes)
+			var h chainhash.Hash
+			for k, b := range prevIn[2].bytes {
+				h[chainhash.HashSize-1-k] = b
+			}
+			txIn := wire.NewTxIn(wire.NewOutPoint(&h, uint32(prevIn[3].varint)), prevIn[4].bytes, nil)
+			txIn.Sequence = uint32(prevIn[5].varint)
+			prev.AddTxIn(txIn)
+		}
+		for j := 0; j < int(meta[7].varint); j++ {
+			ack, ok := f.request(trezorRequestOutput, j, prevHash, 0, nil)
+			if !ok {
+				return
+			}
+			out := protoFields(ack[3].bytes)
+			prev.AddTxOut(wire.NewTxOut(int64(out[1].varint), out[2].bytes))
+		}
+		if prev.TxHash() != hash {
+			f.failure(3, "Encountered invalid prevhash")
+			return
+		}
+		if int(in.PreviousOutPoint.Index) >= len(prev.TxOut) || prev.TxOut[in.PreviousOutPoint.Index].Value != amounts[i] {
+			f.failure(3, "Invalid amount specified")
+			return
+		}
+	}
+
+	for i := 0; i < int(fields[1].varint); i++ {
+		ack, ok := f.request(trezorRequestOutput, i, nil, 0, nil)
+		if !ok {
+			return
+		}
+		out := protoFields(ack[5].bytes)
+		addr, err := btcutil.DecodeAddress(string(out[1].bytes), toChainCfgParams(f.params))
+		if err != nil {
+			f.failure(3, "Invalid address")
+			return
+		}
+		script, _ := txscript.PayToAddrScript(addr)
+		tx.AddTxOut(wire.NewTxOut(int64(out[3].varint), script))
+	}
+
+	if !f.confirm() {
+		return
+	}
+
+	prevOuts := make(map[wire.OutPoint]*wire.TxOut)
+	for i, in := range tx.TxIn {
+		prevOuts[in.PreviousOutPoint] = wire.NewTxOut(amounts[i], make([]byte, 22))
+	}
+	sigHashes := txscript.NewTxSigHashes(tx, txscript.NewMultiPrevOutFetcher(prevOuts))
+	var sig []byte
+	for i := range tx.TxIn {
+		if _, ok := f.request(trezorRequestInput, i, nil, i-1, sig); !ok {
+			return
+		}
+		key, _ := derivePath(f.master, paths[i])
+		privKey, _ := key.ECPrivKey()
+		script, _ := txscript.NewScriptBuilder().
+			AddOp(txscript.OP_DUP).AddOp(txscript.OP_HASH160).
+			AddData(btcutil.Hash160(privKey.PubKey().SerializeCompressed())).
+			AddOp(txscript.OP_EQUALVERIFY).AddOp(txscript.OP_CHECKSIG).
+			Script()
+		var hash []byte
+		if witness[i] {
+			hash, _ = txscript.CalcWitnessSigHash(script, sigHashes, txscript.SigHashAll, tx, i, amounts[i])
+		} else {
+			hash, _ = txscript.CalcSignatureHash(script, txscript.SigHashAll, tx, i)
+		}
+		sig = ecdsa.Sign(privKey, hash).Serialize()
+	}
+	f.out <- trezorMessage{typ: trezorMsgTxRequest, data: txRequest(trezorRequestFinished, 0, nil, len(tx.TxIn)-1, sig)}
+}
+
+// protoRepeated returns the values of a repeated varint field.
+func protoRepeated(msg []byte, field protowire.Number) []uint32 {
+	var values []uint32
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		msg = msg[n:]
+		n = protowire.ConsumeFieldValue(num, typ, msg)
+		if num == field && typ == protowire.VarintType {
+			v, _ := protowire.ConsumeVarint(msg)
+			values = append(values, uint32(v))
+		}
+		msg = msg[n:]
+	}
+	return values
+}
+
+func TestTrezorSignTx(t *testing.T) {
+	params, _ := chain.Get("BTC", chain.Testnet)
+	fake := newFakeTrezor(t, params)
+	ctx := context.Background()
+	tr := newTrezor(DeviceInfo{Type: SignerTrezor}, fake)
+	if err := tr.initialize(ctx); err != nil {
+		t.Fatalf("initialize() error = %v", err)
+	}
+
+	req := testSpend(t, params, fake.master, "p2wpkh", "p2pkh")
+	if err := tr.SignTx(ctx, req); err != nil {
+		t.Fatalf("SignTx() error = %v", err)
+	}
+	if fake.buttons != 1 {
+		t.Errorf("button requests = %d, want 1", fake.buttons)
+	}
+	verifySigned(t, req)
+
+	// A backend returning the wrong transaction spent
+	req = testSpend(t, params, fake.master, "p2wpkh")
+	other := testSpend(t, params, fake.master, "p2wpkh", "p2wpkh")
+	req.PrevTx = func(ctx context.Context, txid chainhash.Hash) (*wire.MsgTx, error) {
+		prev, _ := other.PrevTx(ctx, other.Tx.TxIn[0].PreviousOutPoint.Hash)
+		return prev, nil
+	}
+	if err := tr.SignTx(ctx, req); err == nil {
+		t.Error("SignTx() with the wrong transaction spent should fail")
+	}
+
+	req.PrevTx = nil
+	if err := tr.SignTx(ctx, req); err == nil {
+		t.Error("SignTx() without PrevTx should fail")
+	}
+
+	fake.reject = true
+	if err := tr.SignTx(ctx, testSpend(t, params, fake.master, "p2wpkh")); !errors.Is(err, ErrDeviceRejected) {
+		t.Errorf("SignTx() rejected error = %v, want ErrDeviceRejected", err)
+	}
+}
+
+func TestTrezorPublicKey(t *testing.T) {
+	params, _ := chain.Get("BTC", chain.Testnet)
+	fake := newFakeTrezor(t, params)
+	ctx := context.Background()
+	tr := newTrezor(DeviceInfo{Type: SignerTrezor}, fake)
+
+	path := accountPath(params, 2)
+	key, err := tr.PublicKey(ctx, params, path)
+	if err != nil {
+		t.Fatalf("PublicKey() error = %v", err)
+	}
+	want, _ := derivePath(fake.master, path)
+	want, _ = want.Neuter()
+	want, _ = want.CloneWithVersion(params.HDPublicKeyID[:])
+	if key.String() != want.String() {
+		t.Errorf("PublicKey() = %s, want %s", key, want)
+	}
+
+	addressPath := params.DerivationPath(2, 1, 7)
+	addr, err := tr.DisplayAddress(ctx, params, addressPath)
+	if err != nil {
+		t.Fatalf("DisplayAddress() error = %v", err)
+	}
+	addrKey, _ := derivePath(fake.master, addressPath)
+	if wantAddr, _ := DeriveAddressFromKey(addrKey, params); addr != wantAddr || fake.buttons != 1 {
+		t.Errorf("DisplayAddress() = %s (%d buttons), want %s confirmed", addr, fake.buttons, wantAddr)
+	}
+
+	fake.pin = true
+	if _, err := tr.PublicKey(ctx, params, path); !errors.Is(err, ErrDeviceLocked) {
+		t.Errorf("PublicKey() with a PIN error = %v, want ErrDeviceLocked", err)
+	}
+}
+
+func TestTrezorCoinName(t *testing.T) {
+	tests := []struct {
+		symbol  string
+		network chain.Network
+		want    string
+	}{
+		{"BTC", chain.Mainnet, "Bitcoin"},
+		{"BTC", chain.Testnet, "Testnet"},
+		{"LTC", chain.Mainnet, "Litecoin"},
+		{"DOGE", chain.Mainnet, "Dogecoin"},
+		{"LTC", chain.Testnet, ""},
+	}
+	for _, tt := range tests {
+		params, _ := chain.Get(tt.symbol, tt.network)
+		got, err := trezorCoinName(params)
+		if got != tt.want || (err != nil) != (tt.want == "") {
+			t.Errorf("trezorCoinName(%s %s) = %q, %v, want %q", tt.symbol, tt.network, got, err, tt.want)
+		}
+	}
+}
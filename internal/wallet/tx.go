@@ -141,7 +141,7 @@ func BuildAndSignTx(
 			}
 		case *btcutil.AddressPubKeyHash:
 			// P2PKH - Legacy
-			if err := signP2PKH(tx, i, privKey, senderScript); err != nil {
+			if err := signP2PKH(tx, i, privKey, senderScript, true); err != nil {
 				return "", fmt.Errorf("failed to sign P2PKH input %d: %w", i, err)
 			}
 		default:
@@ -186,6 +186,45 @@ func signP2WPKH(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, prevO
 	return nil
 }
 
+// signP2SHP2WPKH signs a P2SH-wrapped P2WPKH (nested SegWit) input.
+func signP2SHP2WPKH(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, prevOutFetcher txscript.PrevOutputFetcher) error {
+	outpoint := tx.TxIn[inputIndex].PreviousOutPoint
+	prevOut := prevOutFetcher.FetchPrevOutput(outpoint)
+	if prevOut == nil {
+		return fmt.Errorf("previous output not found")
+	}
+
+	// The redeem script is the P2WPKH witness program
+	pubKeyHash := btcutil.Hash160(privKey.PubKey().SerializeCompressed())
+	redeemScript, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(pubKeyHash).Script()
+	if err != nil {
+		return err
+	}
+	sigScript, err := txscript.NewScriptBuilder().AddData(redeemScript).Script()
+	if err != nil {
+		return err
+	}
+
+	sigHashes := txscript.NewTxSigHashes(tx, prevOutFetcher)
+	witness, err := txscript.WitnessSignature(
+		tx,
+		sigHashes,
+		inputIndex,
+		prevOut.Value,
+		redeemScript,
+		txscript.SigHashAll,
+		privKey,
+		true, // compressed
+	)
+	if err != nil {
+		return err
+	}
+
+	tx.TxIn[inputIndex].SignatureScript = sigScript
+	tx.TxIn[inputIndex].Witness = witness
+	return nil
+}
+
 // signP2TR signs a P2TR (Taproot) input using key-path spend.
 func signP2TR(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, prevOutFetcher txscript.PrevOutputFetcher) error {
 	outpoint := tx.TxIn[inputIndex].PreviousOutPoint
@@ -215,15 +254,16 @@ func signP2TR(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, prevOut
 	return nil
 }
 
-// signP2PKH signs a P2PKH (legacy) input.
-func signP2PKH(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, pkScript []byte) error {
+// signP2PKH signs a P2PKH (legacy) input paying the hash of the compressed
+// or the uncompressed public key.
+func signP2PKH(tx *wire.MsgTx, inputIndex int, privKey *btcec.PrivateKey, pkScript []byte, compressed bool) error {
 	sig, err := txscript.SignatureScript(
 		tx,
 		inputIndex,
 		pkScript,
 		txscript.SigHashAll,
 		privKey,
-		true, // compressed
+		compressed,
 	)
 	if err != nil {
 		return err
@@ -321,14 +321,15 @@ func (s *UTXOSyncService) GetSpendableUTXOs(ctx context.Context, symbol string,
 		}
 
 		result = append(result, &AddressUTXO{
-			TxID:         u.TxID,
-			Vout:         u.Vout,
-			Amount:       u.Amount,
-			Address:      u.Address,
-			Account:      u.Account,
-			Change:       u.Change,
-			AddressIndex: u.AddressIndex,
-			AddressType:  u.AddressType,
+			TxID:          u.TxID,
+			Vout:          u.Vout,
+			Amount:        u.Amount,
+			Address:       u.Address,
+			Account:       u.Account,
+			Change:        u.Change,
+			AddressIndex:  u.AddressIndex,
+			AddressType:   u.AddressType,
+			Confirmations: u.Confirmations,
 		})
 	}
 
@@ -350,14 +351,15 @@ func (s *UTXOSyncService) GetAllUTXOs(ctx context.Context, symbol string) ([]*Ad
 		}
 
 		result = append(result, &AddressUTXO{
-			TxID:         u.TxID,
-			Vout:         u.Vout,
-			Amount:       u.Amount,
-			Address:      u.Address,
-			Account:      u.Account,
-			Change:       u.Change,
-			AddressIndex: u.AddressIndex,
-			AddressType:  u.AddressType,
+			TxID:          u.TxID,
+			Vout:          u.Vout,
+			Amount:        u.Amount,
+			Address:       u.Address,
+			Account:       u.Account,
+			Change:        u.Change,
+			AddressIndex:  u.AddressIndex,
+			AddressType:   u.AddressType,
+			Confirmations: u.Confirmations,
 		})
 	}
 
@@ -459,14 +461,15 @@ func (s *UTXOSyncService) FreshScanUTXOs(ctx context.Context, symbol string) ([]
 
 				for _, u := range utxos {
 					allUTXOs = append(allUTXOs, &AddressUTXO{
-						TxID:         u.TxID,
-						Vout:         u.Vout,
-						Amount:       u.Amount,
-						Address:      address,
-						Account:      0,
-						Change:       change,
-						AddressIndex: index,
-						AddressType:  addrType,
+						TxID:          u.TxID,
+						Vout:          u.Vout,
+						Amount:        u.Amount,
+						Address:       address,
+						Account:       0,
+						Change:        change,
+						AddressIndex:  index,
+						AddressType:   addrType,
+						Confirmations: u.Confirmations,
 					})
 				}
 			} else {
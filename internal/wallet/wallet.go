@@ -10,7 +10,6 @@ import (
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/Klingon-tech/klingdex/internal/chain"
-	"github.com/tyler-smith/go-bip39"
 )
 
 // Wallet manages HD keys derived from a BIP39 seed.
@@ -22,37 +21,33 @@ type Wallet struct {
 
 	// Cached derived keys (purpose -> coinType -> account -> change -> index -> key)
 	cache map[uint32]map[uint32]map[uint32]map[uint32]map[uint32]*hdkeychain.ExtendedKey
+
+	// Chains signed by hardware wallets, whose keys derive from the
+	// device's account public key (see service_hardware.go)
+	hardware map[string]*HardwareAccount
 }
 
-// GenerateMnemonic generates a new 24-word BIP39 mnemonic.
+// GenerateMnemonic generates a new 24-word English BIP39 mnemonic.
 func GenerateMnemonic() (string, error) {
-	entropy, err := bip39.NewEntropy(256) // 256 bits = 24 words
-	if err != nil {
-		return "", fmt.Errorf("failed to generate entropy: %w", err)
-	}
-
-	mnemonic, err := bip39.NewMnemonic(entropy)
-	if err != nil {
-		return "", fmt.Errorf("failed to generate mnemonic: %w", err)
-	}
-
-	return mnemonic, nil
+	return GenerateMnemonicInLanguage(LanguageEnglish)
 }
 
-// ValidateMnemonic checks if a mnemonic is valid.
+// ValidateMnemonic checks if a mnemonic is valid in any supported wordlist.
 func ValidateMnemonic(mnemonic string) bool {
-	return bip39.IsMnemonicValid(mnemonic)
+	_, err := MnemonicLanguage(mnemonic)
+	return err == nil
 }
 
 // NewFromMnemonic creates a wallet from a BIP39 mnemonic.
 // The passphrase is optional (can be empty string).
 func NewFromMnemonic(mnemonic, passphrase string, network chain.Network) (*Wallet, error) {
-	if !bip39.IsMnemonicValid(mnemonic) {
+	if !ValidateMnemonic(mnemonic) {
 		return nil, fmt.Errorf("invalid mnemonic")
 	}
 
 	// Generate seed from mnemonic (with optional passphrase)
-	seed := bip39.NewSeed(mnemonic, passphrase)
+	seed := mnemonicSeed(mnemonic, passphrase)
+	defer Protect(seed)()
 
 	return NewFromSeed(seed, network)
 }
@@ -143,6 +138,10 @@ func (w *Wallet) DeriveKeyForChainWithChange(symbol string, account, change, ind
 		return nil, fmt.Errorf("unsupported chain: %s", symbol)
 	}
 
+	if acct := w.hardwareAccount(symbol); acct != nil {
+		return acct.deriveKey(account, change, index)
+	}
+
 	return w.DeriveKey(params.DefaultPurpose, params.CoinType, account, change, index)
 }
 
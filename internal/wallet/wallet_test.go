@@ -619,3 +619,29 @@ func TestPrivateKeyFromHex(t *testing.T) {
 		t.Error("restored key should produce same address")
 	}
 }
+
+func TestDeriveIdentityKey(t *testing.T) {
+	wallet, _ := NewFromMnemonic(testMnemonic, "", chain.Mainnet)
+	identity, err := wallet.DeriveIdentityKey()
+	if err != nil {
+		t.Fatalf("DeriveIdentityKey() error = %v", err)
+	}
+	btcKey, _ := wallet.DerivePrivateKey("BTC", 0, 0)
+	if identity.Key.Equals(&btcKey.Key) {
+		t.Error("identity key should not be a chain key")
+	}
+
+	pub, _ := wallet.DerivePublicKey("BTC", 0, 0)
+	addr, _ := wallet.DeriveAddress("BTC", 0, 0)
+	if !AddressMatchesPubKey("BTC", chain.Mainnet, addr, pub) {
+		t.Error("BTC address should match its key")
+	}
+	ethPub, _ := wallet.DerivePublicKey("ETH", 0, 0)
+	ethAddr, _ := wallet.DeriveAddress("ETH", 0, 0)
+	if !AddressMatchesPubKey("ETH", chain.Mainnet, strings.ToLower(ethAddr), ethPub) {
+		t.Error("EVM addresses should match case-insensitively")
+	}
+	if AddressMatchesPubKey("BTC", chain.Mainnet, addr, identity.PubKey()) {
+		t.Error("address should not match another key")
+	}
+}
@@ -0,0 +1,396 @@
+// Package watchtower broadcasts pre-signed HTLC refunds and claims for trusted
+// peers while they are offline.
+//
+// A client (e.g. the user's mobile node) registers a bundle per swap leg over
+// the authenticated, encrypted direct P2P stream. The tower stores it as an
+// obligation and broadcasts it when its on-chain trigger fires:
+//   - refund: the HTLC's CSV timelock has expired
+//   - claim: the counterparty claimed the client's HTLC on the other chain,
+//     revealing the secret, which is filled into the pre-signed claim
+package watchtower
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Errors
+var (
+	ErrUntrustedPeer  = errors.New("peer is not trusted by this watchtower")
+	ErrTooManyBundles = errors.New("watchtower bundle limit reached")
+)
+
+// BundlePayload is the payload of a watchtower_register message.
+type BundlePayload struct {
+	BundleID      string `json:"bundle_id"`
+	TradeID       string `json:"trade_id"`
+	Chain         string `json:"chain"`
+	Kind          string `json:"kind"` // refund, claim
+	TxHex         string `json:"tx_hex"`
+	FundingTxID   string `json:"funding_txid"`
+	FundingVout   uint32 `json:"funding_vout"`
+	TimeoutBlocks uint32 `json:"timeout_blocks,omitempty"`
+	WatchChain    string `json:"watch_chain,omitempty"`
+	WatchAddress  string `json:"watch_address,omitempty"`
+	WatchTxID     string `json:"watch_txid,omitempty"`
+	WatchVout     uint32 `json:"watch_vout,omitempty"`
+	SecretHash    string `json:"secret_hash,omitempty"` // hex
+}
+
+// CancelPayload is the payload of a watchtower_cancel message.
+type CancelPayload struct {
+	BundleID string `json:"bundle_id"`
+}
+
+// BundleID returns the bundle ID of a swap leg's pre-signed spend.
+func BundleID(kind, tradeID, chainSymbol string) string {
+	return kind + ":" + tradeID + ":" + chainSymbol
+}
+
+// NewBundlePayload builds the register payload for a pre-signed spend.
+func NewBundlePayload(spend *swap.PresignedSpend) *BundlePayload {
+	return &BundlePayload{
+		BundleID:      BundleID(spend.Kind, spend.TradeID, spend.Chain),
+		TradeID:       spend.TradeID,
+		Chain:         spend.Chain,
+		Kind:          spend.Kind,
+		TxHex:         spend.TxHex,
+		FundingTxID:   spend.FundingTxID,
+		FundingVout:   spend.FundingVout,
+		TimeoutBlocks: spend.TimeoutBlocks,
+		WatchChain:    spend.WatchChain,
+		WatchAddress:  spend.WatchAddress,
+		WatchTxID:     spend.WatchTxID,
+		WatchVout:     spend.WatchVout,
+		SecretHash:    hex.EncodeToString(spend.SecretHash),
+	}
+}
+
+// Validate checks that the bundle is well formed and its transaction spends
+// the declared HTLC output.
+func (p *BundlePayload) Validate() error {
+	if p.BundleID == "" || p.TradeID == "" || p.Chain == "" {
+		return fmt.Errorf("bundle_id, trade_id and chain are required")
+	}
+
+	tx, err := swap.DeserializeTx(p.TxHex)
+	if err != nil {
+		return fmt.Errorf("invalid tx_hex: %w", err)
+	}
+	if len(tx.TxIn) != 1 {
+		return fmt.Errorf("bundle transaction must have exactly one input")
+	}
+	prev := tx.TxIn[0].PreviousOutPoint
+	if prev.Hash.String() != p.FundingTxID || prev.Index != p.FundingVout {
+		return fmt.Errorf("bundle transaction does not spend %s:%d", p.FundingTxID, p.FundingVout)
+	}
+
+	switch p.Kind {
+	case swap.PresignedRefund:
+		if p.TimeoutBlocks == 0 {
+			return fmt.Errorf("timeout_blocks is required for refunds")
+		}
+	case swap.PresignedClaim:
+		if p.WatchChain == "" || p.WatchAddress == "" || p.WatchTxID == "" {
+			return fmt.Errorf("watch_chain, watch_address and watch_txid are required for claims")
+		}
+		hash, err := hex.DecodeString(p.SecretHash)
+		if err != nil || len(hash) != 32 {
+			return fmt.Errorf("secret_hash must be 32 bytes of hex")
+		}
+	default:
+		return fmt.Errorf("unknown bundle kind: %q", p.Kind)
+	}
+	return nil
+}
+
+// Tower holds bundles for trusted peers and broadcasts them when triggered.
+type Tower struct {
+	cfg      node.WatchtowerConfig
+	store    *storage.Storage
+	backends map[string]backend.Backend
+	log      *logging.Logger
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// New creates a watchtower.
+func New(cfg node.WatchtowerConfig, store *storage.Storage, backends map[string]backend.Backend) *Tower {
+	if cfg.CheckInterval <= 0 {
+		cfg.CheckInterval = node.DefaultConfig().Watchtower.CheckInterval
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Tower{
+		cfg:      cfg,
+		store:    store,
+		backends: backends,
+		log:      logging.GetDefault().Component("watchtower"),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+// Start begins evaluating bundle triggers periodically.
+func (t *Tower) Start() {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.cfg.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-t.ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := t.CheckOnce(t.ctx); err != nil {
+					t.log.Warn("Watchtower check failed", "error", err)
+				}
+			}
+		}
+	}()
+	t.log.Info("Watchtower started", "trusted_peers", len(t.cfg.TrustedPeers), "interval", t.cfg.CheckInterval)
+}
+
+// Stop stops the watchtower.
+func (t *Tower) Stop() {
+	t.cancel()
+	t.wg.Wait()
+}
+
+// Register stores a bundle from a client peer as an obligation.
+func (t *Tower) Register(clientPeer string, p *BundlePayload) error {
+	if !t.cfg.IsTrusted(clientPeer) {
+		return ErrUntrustedPeer
+	}
+	if err := p.Validate(); err != nil {
+		return err
+	}
+	if _, ok := t.backends[p.Chain]; !ok {
+		return fmt.Errorf("watchtower has no backend for %s", p.Chain)
+	}
+	if p.Kind == swap.PresignedClaim {
+		if _, ok := t.backends[p.WatchChain]; !ok {
+			return fmt.Errorf("watchtower has no backend for %s", p.WatchChain)
+		}
+	}
+
+	if t.cfg.MaxBundlesPerPeer > 0 {
+		existing, err := t.store.GetWatchtowerBundle(p.BundleID)
+		replacing := err == nil && existing.ClientPeer == clientPeer && existing.Status == storage.BundleStatusActive
+		count, err := t.store.CountActiveWatchtowerBundles(clientPeer)
+		if err != nil {
+			return err
+		}
+		if !replacing && count >= t.cfg.MaxBundlesPerPeer {
+			return ErrTooManyBundles
+		}
+	}
+
+	err := t.store.SaveWatchtowerBundle(&storage.WatchtowerBundle{
+		ID:            p.BundleID,
+		ClientPeer:    clientPeer,
+		TradeID:       p.TradeID,
+		Chain:         p.Chain,
+		Kind:          p.Kind,
+		TxHex:         p.TxHex,
+		FundingTxID:   p.FundingTxID,
+		FundingVout:   p.FundingVout,
+		TimeoutBlocks: p.TimeoutBlocks,
+		WatchChain:    p.WatchChain,
+		WatchAddress:  p.WatchAddress,
+		WatchTxID:     p.WatchTxID,
+		WatchVout:     p.WatchVout,
+		SecretHash:    p.SecretHash,
+	})
+	if err != nil {
+		return err
+	}
+
+	t.log.Info("Registered watchtower bundle", "bundle", p.BundleID, "client", clientPeer)
+	return nil
+}
+
+// Cancel releases a client's bundle.
+func (t *Tower) Cancel(clientPeer, bundleID string) error {
+	b, err := t.store.GetWatchtowerBundle(bundleID)
+	if err != nil {
+		return err
+	}
+	if b.ClientPeer != clientPeer {
+		return storage.ErrBundleNotFound
+	}
+	return t.store.CancelWatchtowerBundle(bundleID)
+}
+
+// Obligations returns the bundles held for a client (all clients if empty).
+func (t *Tower) Obligations(clientPeer string, status storage.BundleStatus) ([]*storage.WatchtowerBundle, error) {
+	return t.store.ListWatchtowerBundles(clientPeer, status)
+}
+
+// HandleRegister handles a watchtower_register direct message.
+func (t *Tower) HandleRegister(ctx context.Context, msg *node.SwapMessage) error {
+	var p BundlePayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		return fmt.Errorf("invalid bundle payload: %w", err)
+	}
+	return t.Register(msg.FromPeer, &p)
+}
+
+// HandleCancel handles a watchtower_cancel direct message.
+func (t *Tower) HandleCancel(ctx context.Context, msg *node.SwapMessage) error {
+	var p CancelPayload
+	if err := json.Unmarshal(msg.Payload, &p); err != nil {
+		return fmt.Errorf("invalid cancel payload: %w", err)
+	}
+	return t.Cancel(msg.FromPeer, p.BundleID)
+}
+
+// CheckOnce evaluates the triggers of all active bundles and broadcasts the
+// ones that fired. Returns the number of bundles broadcast.
+func (t *Tower) CheckOnce(ctx context.Context) (int, error) {
+	bundles, err := t.store.ListWatchtowerBundles("", storage.BundleStatusActive)
+	if err != nil {
+		return 0, err
+	}
+
+	broadcast := 0
+	for _, b := range bundles {
+		txHex, err := t.triggeredTx(ctx, b)
+		if err != nil {
+			t.log.Debug("Bundle trigger check failed", "bundle", b.ID, "error", err)
+			continue
+		}
+		if txHex == "" {
+			continue
+		}
+
+		txID, err := t.broadcast(ctx, b.Chain, txHex)
+		if err != nil {
+			t.log.Warn("Watchtower broadcast failed", "bundle", b.ID, "error", err)
+			if err := t.store.RecordWatchtowerAttempt(b.ID, err.Error()); err != nil {
+				t.log.Warn("Failed to record bundle attempt", "bundle", b.ID, "error", err)
+			}
+			continue
+		}
+
+		if err := t.store.MarkWatchtowerBundleBroadcast(b.ID, txID); err != nil {
+			t.log.Warn("Failed to mark bundle broadcast", "bundle", b.ID, "error", err)
+		}
+		t.log.Info("Watchtower broadcast bundle", "bundle", b.ID, "client", b.ClientPeer, "txid", txID)
+		broadcast++
+	}
+	return broadcast, nil
+}
+
+// triggeredTx returns the transaction to broadcast if the bundle's trigger
+// fired, or an empty string if it has not.
+func (t *Tower) triggeredTx(ctx context.Context, b *storage.WatchtowerBundle) (string, error) {
+	switch b.Kind {
+	case swap.PresignedRefund:
+		return t.refundTrigger(ctx, b)
+	case swap.PresignedClaim:
+		return t.claimTrigger(ctx, b)
+	}
+	return "", fmt.Errorf("unknown bundle kind: %q", b.Kind)
+}
+
+// refundTrigger fires once the refund's CSV timelock allows it into the next block.
+func (t *Tower) refundTrigger(ctx context.Context, b *storage.WatchtowerBundle) (string, error) {
+	bk, ok := t.backends[b.Chain]
+	if !ok {
+		return "", fmt.Errorf("no backend for %s", b.Chain)
+	}
+	funding, err := bk.GetTransaction(ctx, b.FundingTxID)
+	if err != nil {
+		return "", err
+	}
+	if !funding.Confirmed || funding.BlockHeight <= 0 {
+		return "", nil
+	}
+	height, err := bk.GetBlockHeight(ctx)
+	if err != nil {
+		return "", err
+	}
+	if height+1 < funding.BlockHeight+int64(b.TimeoutBlocks) {
+		return "", nil
+	}
+	return b.TxHex, nil
+}
+
+// claimTrigger fires once the counterparty's claim of the watched HTLC output
+// reveals the secret, which is then filled into the pre-signed claim.
+func (t *Tower) claimTrigger(ctx context.Context, b *storage.WatchtowerBundle) (string, error) {
+	bk, ok := t.backends[b.WatchChain]
+	if !ok {
+		return "", fmt.Errorf("no backend for %s", b.WatchChain)
+	}
+	secretHash, err := hex.DecodeString(b.SecretHash)
+	if err != nil {
+		return "", err
+	}
+
+	txs, err := bk.GetAddressTxs(ctx, b.WatchAddress, "")
+	if err != nil {
+		return "", err
+	}
+	for _, tx := range txs {
+		for _, in := range tx.Inputs {
+			if in.TxID != b.WatchTxID || in.Vout != b.WatchVout {
+				continue
+			}
+			if secret := secretFromWitness(in.Witness, secretHash); secret != nil {
+				return swap.FillClaimSecret(b.TxHex, secret)
+			}
+		}
+	}
+	return "", nil
+}
+
+// secretFromWitness returns the preimage of secretHash from an HTLC claim witness.
+func secretFromWitness(witness []string, secretHash []byte) []byte {
+	for _, item := range witness {
+		data, err := hex.DecodeString(item)
+		if err != nil || len(data) != 32 {
+			continue
+		}
+		if swap.VerifySecret(data, secretHash) {
+			return data
+		}
+	}
+	return nil
+}
+
+// broadcast sends a transaction, treating one the backend already knows as success.
+func (t *Tower) broadcast(ctx context.Context, chainSymbol, txHex string) (string, error) {
+	bk, ok := t.backends[chainSymbol]
+	if !ok {
+		return "", fmt.Errorf("no backend for %s", chainSymbol)
+	}
+	txID, err := bk.BroadcastTransaction(ctx, txHex)
+	if err == nil {
+		return txID, nil
+	}
+
+	tx, decodeErr := swap.DeserializeTx(txHex)
+	if decodeErr != nil {
+		return "", err
+	}
+	known := tx.TxHash().String()
+	if _, getErr := bk.GetTransaction(ctx, known); getErr == nil {
+		return known, nil
+	}
+	return "", err
+}
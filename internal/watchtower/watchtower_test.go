@@ -0,0 +1,251 @@
+package watchtower
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+const clientPeer = "12D3KooWClient"
+
+// fakeBackend simulates a chain for trigger evaluation.
+type fakeBackend struct {
+	backend.Backend
+	height     int64
+	txs        map[string]*backend.Transaction
+	addrTxs    map[string][]backend.Transaction
+	broadcasts []string
+}
+
+func newFakeBackend() *fakeBackend {
+	return &fakeBackend{
+		txs:     make(map[string]*backend.Transaction),
+		addrTxs: make(map[string][]backend.Transaction),
+	}
+}
+
+func (f *fakeBackend) GetTransaction(ctx context.Context, txID string) (*backend.Transaction, error) {
+	tx, ok := f.txs[txID]
+	if !ok {
+		return nil, backend.ErrTxNotFound
+	}
+	return tx, nil
+}
+
+func (f *fakeBackend) GetBlockHeight(ctx context.Context) (int64, error) {
+	return f.height, nil
+}
+
+func (f *fakeBackend) GetAddressTxs(ctx context.Context, address string, lastSeenTxID string) ([]backend.Transaction, error) {
+	return f.addrTxs[address], nil
+}
+
+func (f *fakeBackend) BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error) {
+	f.broadcasts = append(f.broadcasts, rawTxHex)
+	tx, err := swap.DeserializeTx(rawTxHex)
+	if err != nil {
+		return "", err
+	}
+	return tx.TxHash().String(), nil
+}
+
+// spendTx builds a one-input transaction spending fundingTxID:0 with the given witness.
+func spendTx(t *testing.T, fundingTxID string, witness wire.TxWitness) string {
+	t.Helper()
+	hash, err := chainhash.NewHashFromStr(fundingTxID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(hash, 0), nil, witness))
+	tx.AddTxOut(wire.NewTxOut(10000, []byte{0x51}))
+	txHex, err := swap.SerializeTx(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return txHex
+}
+
+func newTestTower(t *testing.T, backends map[string]backend.Backend) (*Tower, *storage.Storage) {
+	t.Helper()
+	store, err := storage.New(&storage.Config{DataDir: t.TempDir()})
+	if err != nil {
+		t.Fatalf("storage.New() error = %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	tower := New(node.WatchtowerConfig{
+		Enabled:           true,
+		TrustedPeers:      []string{clientPeer},
+		MaxBundlesPerPeer: 2,
+	}, store, backends)
+	return tower, store
+}
+
+var fundingTxID = chainhash.Hash{0x11}.String()
+
+func refundBundle(t *testing.T, tradeID string) *BundlePayload {
+	return &BundlePayload{
+		BundleID:      BundleID(swap.PresignedRefund, tradeID, "BTC"),
+		TradeID:       tradeID,
+		Chain:         "BTC",
+		Kind:          swap.PresignedRefund,
+		TxHex:         spendTx(t, fundingTxID, swap.BuildHTLCRefundWitness([]byte{0x30}, []byte{0x63})),
+		FundingTxID:   fundingTxID,
+		TimeoutBlocks: 144,
+	}
+}
+
+func TestRegisterRequiresTrustAndValidBundle(t *testing.T) {
+	btc := newFakeBackend()
+	tower, _ := newTestTower(t, map[string]backend.Backend{"BTC": btc})
+
+	if err := tower.Register("12D3KooWStranger", refundBundle(t, "trade-1")); !errors.Is(err, ErrUntrustedPeer) {
+		t.Errorf("Register() untrusted error = %v, want ErrUntrustedPeer", err)
+	}
+
+	bad := refundBundle(t, "trade-1")
+	bad.FundingTxID = chainhash.Hash{0x22}.String()
+	if err := tower.Register(clientPeer, bad); err == nil {
+		t.Error("expected error when the transaction does not spend the funding output")
+	}
+
+	noTimeout := refundBundle(t, "trade-1")
+	noTimeout.TimeoutBlocks = 0
+	if err := tower.Register(clientPeer, noTimeout); err == nil {
+		t.Error("expected error for refund without timeout")
+	}
+
+	unknownChain := refundBundle(t, "trade-1")
+	unknownChain.Chain = "LTC"
+	if err := tower.Register(clientPeer, unknownChain); err == nil {
+		t.Error("expected error for chain without backend")
+	}
+}
+
+func TestRegisterEnforcesBundleLimit(t *testing.T) {
+	tower, _ := newTestTower(t, map[string]backend.Backend{"BTC": newFakeBackend()})
+
+	for _, id := range []string{"trade-1", "trade-2"} {
+		if err := tower.Register(clientPeer, refundBundle(t, id)); err != nil {
+			t.Fatalf("Register(%s) error = %v", id, err)
+		}
+	}
+	if err := tower.Register(clientPeer, refundBundle(t, "trade-3")); !errors.Is(err, ErrTooManyBundles) {
+		t.Errorf("Register() error = %v, want ErrTooManyBundles", err)
+	}
+	// Replacing an existing bundle does not count against the limit
+	if err := tower.Register(clientPeer, refundBundle(t, "trade-1")); err != nil {
+		t.Errorf("Register() replace error = %v", err)
+	}
+
+	if err := tower.Cancel(clientPeer, BundleID(swap.PresignedRefund, "trade-2", "BTC")); err != nil {
+		t.Fatalf("Cancel() error = %v", err)
+	}
+	if err := tower.Register(clientPeer, refundBundle(t, "trade-3")); err != nil {
+		t.Errorf("Register() after cancel error = %v", err)
+	}
+
+	obligations, err := tower.Obligations(clientPeer, storage.BundleStatusActive)
+	if err != nil || len(obligations) != 2 {
+		t.Errorf("Obligations() = %d, %v", len(obligations), err)
+	}
+}
+
+func TestRefundTrigger(t *testing.T) {
+	btc := newFakeBackend()
+	tower, store := newTestTower(t, map[string]backend.Backend{"BTC": btc})
+	bundle := refundBundle(t, "trade-1")
+	if err := tower.Register(clientPeer, bundle); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	// Funding confirmed at 1000, CSV 144: spendable in block 1144
+	btc.txs[fundingTxID] = &backend.Transaction{TxID: fundingTxID, Confirmed: true, BlockHeight: 1000}
+	btc.height = 1142
+	if n, _ := tower.CheckOnce(ctx); n != 0 || len(btc.broadcasts) != 0 {
+		t.Fatalf("refund broadcast before timelock expiry")
+	}
+
+	btc.height = 1143
+	if n, err := tower.CheckOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("CheckOnce() = %d, %v, want 1", n, err)
+	}
+	if btc.broadcasts[0] != bundle.TxHex {
+		t.Error("broadcast transaction differs from the bundle")
+	}
+
+	b, _ := store.GetWatchtowerBundle(bundle.BundleID)
+	if b.Status != storage.BundleStatusBroadcast || b.BroadcastTxID == "" {
+		t.Errorf("bundle = %+v, want broadcast", b)
+	}
+
+	// Fulfilled obligations are not broadcast again
+	if n, _ := tower.CheckOnce(ctx); n != 0 {
+		t.Errorf("CheckOnce() = %d after fulfilment, want 0", n)
+	}
+}
+
+func TestClaimTrigger(t *testing.T) {
+	btc := newFakeBackend()
+	ltc := newFakeBackend()
+	tower, _ := newTestTower(t, map[string]backend.Backend{"BTC": btc, "LTC": ltc})
+
+	secret := bytes.Repeat([]byte{0x42}, 32)
+	secretHash := sha256.Sum256(secret)
+	watchTxID := chainhash.Hash{0x33}.String()
+
+	bundle := &BundlePayload{
+		BundleID:     BundleID(swap.PresignedClaim, "trade-1", "BTC"),
+		TradeID:      "trade-1",
+		Chain:        "BTC",
+		Kind:         swap.PresignedClaim,
+		TxHex:        spendTx(t, fundingTxID, swap.BuildHTLCClaimWitness([]byte{0x30}, make([]byte, 32), []byte{0x63})),
+		FundingTxID:  fundingTxID,
+		WatchChain:   "LTC",
+		WatchAddress: "ltc1qhtlc",
+		WatchTxID:    watchTxID,
+		SecretHash:   hex.EncodeToString(secretHash[:]),
+	}
+	if err := tower.Register(clientPeer, bundle); err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	ctx := context.Background()
+
+	if n, _ := tower.CheckOnce(ctx); n != 0 {
+		t.Fatal("claim broadcast before the secret was revealed")
+	}
+
+	// Counterparty claims the client's LTC HTLC, revealing the secret
+	ltc.addrTxs["ltc1qhtlc"] = []backend.Transaction{{
+		TxID: "claim",
+		Inputs: []backend.TxInput{{
+			TxID:    watchTxID,
+			Vout:    0,
+			Witness: []string{"30", hex.EncodeToString(secret), "01", "63"},
+		}},
+	}}
+
+	if n, err := tower.CheckOnce(ctx); err != nil || n != 1 {
+		t.Fatalf("CheckOnce() = %d, %v, want 1", n, err)
+	}
+	claim, err := swap.DeserializeTx(btc.broadcasts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(claim.TxIn[0].Witness[1], secret) {
+		t.Error("broadcast claim does not carry the revealed secret")
+	}
+}
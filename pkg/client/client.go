@@ -0,0 +1,167 @@
+// Package client is a Go client for the klingond JSON-RPC and WebSocket API.
+//
+// Request and response types are the node's own handler types, so they
+// can't drift from the server. Every method is also available untyped
+// through Call. Events stream over the WebSocket with automatic reconnect
+// and resubscribe, and helpers cover the common flows:
+//
+//	c := client.New("http://127.0.0.1:8080", client.WithAPIKey(key))
+//
+//	mnemonic, err := c.CreateWallet(ctx, password)
+//	addr, err := c.FundingAddress(ctx, "BTC")
+//	balance, err := c.WaitForBalance(ctx, "BTC", 50000)
+//
+//	tradeID, err := c.TakeOrder(ctx, orderID)
+//	status, err := c.AwaitSwap(ctx, tradeID)
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Default timing, overridden by options.
+const (
+	DefaultTimeout         = 60 * time.Second
+	DefaultPollInterval    = 10 * time.Second
+	DefaultReconnectDelay  = time.Second
+	DefaultMaxReconnectGap = 30 * time.Second
+)
+
+// Error is a JSON-RPC error returned by the node.
+type Error struct {
+	Method  string          `json:"-"`
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s (code %d)", e.Method, e.Message, e.Code)
+}
+
+// Client calls a klingond node. It is safe for concurrent use.
+type Client struct {
+	url    string
+	apiKey string
+	http   *http.Client
+	nextID atomic.Int64
+
+	pollInterval    time.Duration
+	reconnectDelay  time.Duration
+	maxReconnectGap time.Duration
+}
+
+// Option configures a Client.
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with an API key (api.keys).
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient sets the HTTP client, e.g. for TLS settings.
+func WithHTTPClient(h *http.Client) Option {
+	return func(c *Client) { c.http = h }
+}
+
+// WithPollInterval sets how often the wait helpers poll the node.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *Client) { c.pollInterval = d }
+}
+
+// WithReconnect sets the first and the longest delay before reconnecting the
+// WebSocket; the delay doubles after each failed attempt.
+func WithReconnect(first, max time.Duration) Option {
+	return func(c *Client) {
+		c.reconnectDelay = first
+		c.maxReconnectGap = max
+	}
+}
+
+// New creates a client for the node's JSON-RPC URL, e.g.
+// "http://127.0.0.1:8080".
+func New(url string, opts ...Option) *Client {
+	c := &Client{
+		url:             strings.TrimSuffix(url, "/"),
+		http:            &http.Client{Timeout: DefaultTimeout},
+		pollInterval:    DefaultPollInterval,
+		reconnectDelay:  DefaultReconnectDelay,
+		maxReconnectGap: DefaultMaxReconnectGap,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// request is a JSON-RPC 2.0 request.
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+	ID      int64           `json:"id"`
+}
+
+// response is a JSON-RPC 2.0 response with a raw result for typed decoding.
+type response struct {
+	Result json.RawMessage `json:"result"`
+	Error  *Error          `json:"error"`
+}
+
+// Call invokes a method and decodes the result into out (if non-nil).
+// Errors returned by the node are *Error.
+func (c *Client) Call(ctx context.Context, method string, params, out interface{}) error {
+	req := request{JSONRPC: "2.0", Method: method, ID: c.nextID.Add(1)}
+	if params != nil {
+		raw, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("%s: failed to marshal params: %w", method, err)
+		}
+		req.Params = raw
+	}
+	body, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("%s: failed to marshal request: %w", method, err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("%s: failed to create request: %w", method, err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.http.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("%s: %w", method, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusUnauthorized {
+		return fmt.Errorf("%s: unauthorized (check the API key)", method)
+	}
+
+	var rpcResp response
+	if err := json.NewDecoder(resp.Body).Decode(&rpcResp); err != nil {
+		return fmt.Errorf("%s: failed to decode response (HTTP %d): %w", method, resp.StatusCode, err)
+	}
+	if rpcResp.Error != nil {
+		rpcResp.Error.Method = method
+		return rpcResp.Error
+	}
+	if out != nil && len(rpcResp.Result) > 0 {
+		if err := json.Unmarshal(rpcResp.Result, out); err != nil {
+			return fmt.Errorf("%s: failed to decode result: %w", method, err)
+		}
+	}
+	return nil
+}
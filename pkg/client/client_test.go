@@ -0,0 +1,254 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// fakeNode serves JSON-RPC methods and a WebSocket that sends each
+// connection its events once subscribed, then closes it.
+type fakeNode struct {
+	t       *testing.T
+	apiKey  string
+	methods map[string]func(params json.RawMessage) (interface{}, *Error)
+
+	mu            sync.Mutex
+	calls         map[string]int
+	subscriptions [][]string
+	wsEvents      [][]Event // Per connection, in order
+}
+
+func newFakeNode(t *testing.T) (*fakeNode, *httptest.Server) {
+	f := &fakeNode{t: t, methods: make(map[string]func(json.RawMessage) (interface{}, *Error)), calls: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /", f.serveRPC)
+	mux.HandleFunc("GET /ws", f.serveWS)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeNode) handle(method string, fn func(params json.RawMessage) (interface{}, *Error)) {
+	f.methods[method] = fn
+}
+
+func (f *fakeNode) count(method string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.calls[method]
+}
+
+func (f *fakeNode) authorized(r *http.Request) bool {
+	return f.apiKey == "" || r.Header.Get("Authorization") == "Bearer "+f.apiKey
+}
+
+func (f *fakeNode) serveRPC(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var req request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		f.t.Errorf("bad request: %v", err)
+		return
+	}
+	f.mu.Lock()
+	f.calls[req.Method]++
+	f.mu.Unlock()
+
+	resp := map[string]interface{}{"jsonrpc": "2.0", "id": req.ID}
+	if fn, ok := f.methods[req.Method]; !ok {
+		resp["error"] = &Error{Code: -32601, Message: "Method not found"}
+	} else if result, rpcErr := fn(req.Params); rpcErr != nil {
+		resp["error"] = rpcErr
+	} else {
+		resp["result"] = result
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func (f *fakeNode) serveWS(w http.ResponseWriter, r *http.Request) {
+	if !f.authorized(r) {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	conn, err := (&websocket.Upgrader{}).Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	var sub subscription
+	if err := conn.ReadJSON(&sub); err != nil {
+		return
+	}
+	f.mu.Lock()
+	f.subscriptions = append(f.subscriptions, sub.Events)
+	var events []Event
+	if len(f.wsEvents) > 0 {
+		events, f.wsEvents = f.wsEvents[0], f.wsEvents[1:]
+	}
+	f.mu.Unlock()
+
+	// One batched message, as the node sends queued events
+	var batch []byte
+	for i, e := range events {
+		line, _ := json.Marshal(e)
+		if i > 0 {
+			batch = append(batch, '\n')
+		}
+		batch = append(batch, line...)
+	}
+	if len(batch) > 0 {
+		conn.WriteMessage(websocket.TextMessage, batch)
+	}
+}
+
+func TestWSURL(t *testing.T) {
+	tests := map[string]string{
+		"http://127.0.0.1:8080":   "ws://127.0.0.1:8080/ws",
+		"https://node.example/":   "wss://node.example/ws",
+		"http://127.0.0.1:18080/": "ws://127.0.0.1:18080/ws",
+	}
+	for in, want := range tests {
+		if got := New(in).wsURL(); got != want {
+			t.Errorf("wsURL(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestCall(t *testing.T) {
+	f, srv := newFakeNode(t)
+	f.apiKey = "secret"
+	f.handle("node_info", func(json.RawMessage) (interface{}, *Error) {
+		return map[string]interface{}{"peer_id": "12D3KooWtest", "peers": 3}, nil
+	})
+	f.handle("orders_get", func(params json.RawMessage) (interface{}, *Error) {
+		return nil, &Error{Code: -32602, Message: "order not found", Data: json.RawMessage(`{"id":"x"}`)}
+	})
+
+	c := New(srv.URL, WithAPIKey("secret"))
+	info, err := c.NodeInfo(context.Background())
+	if err != nil {
+		t.Fatalf("NodeInfo() error = %v", err)
+	}
+	if info.PeerID != "12D3KooWtest" || info.Peers != 3 {
+		t.Errorf("NodeInfo() = %+v", info)
+	}
+
+	_, err = c.OrdersGet(context.Background(), "x")
+	var rpcErr *Error
+	if !errors.As(err, &rpcErr) || rpcErr.Code != -32602 || rpcErr.Method != "orders_get" || string(rpcErr.Data) != `{"id":"x"}` {
+		t.Errorf("OrdersGet() error = %v, want the node's error", err)
+	}
+
+	if _, err := New(srv.URL).NodeInfo(context.Background()); err == nil || errors.As(err, &rpcErr) {
+		t.Errorf("unauthorized: error = %v", err)
+	}
+}
+
+func TestSubscribeResubscribes(t *testing.T) {
+	f, srv := newFakeNode(t)
+	f.wsEvents = [][]Event{
+		{{Type: "swap_refunded", Data: json.RawMessage(`{"trade_id":"t1"}`)}, {Type: "not json"}},
+		{{Type: "trade_started", Data: json.RawMessage(`{"trade_id":"t2"}`)}},
+	}
+
+	c := New(srv.URL, WithReconnect(10*time.Millisecond, 20*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	events := c.Subscribe(ctx, "swap_refunded", "trade_started")
+
+	var got []string
+	for len(got) < 3 {
+		select {
+		case e := <-events:
+			got = append(got, e.Type+":"+e.TradeID())
+		case <-ctx.Done():
+			t.Fatalf("events = %v", got)
+		}
+	}
+	if got[0] != "swap_refunded:t1" || got[1] != "not json:" || got[2] != "trade_started:t2" {
+		t.Errorf("events = %v", got)
+	}
+
+	f.mu.Lock()
+	subs := f.subscriptions
+	f.mu.Unlock()
+	if len(subs) < 2 || len(subs[1]) != 2 || subs[1][0] != "swap_refunded" {
+		t.Errorf("subscriptions = %v, want one per connection", subs)
+	}
+
+	cancel()
+	for range events {
+	}
+}
+
+func TestFlows(t *testing.T) {
+	f, srv := newFakeNode(t)
+	var created WalletCreateParams
+	f.handle("wallet_generate", func(json.RawMessage) (interface{}, *Error) {
+		return &WalletGenerateResult{Mnemonic: "abandon ability", Language: "english"}, nil
+	})
+	f.handle("wallet_create", func(params json.RawMessage) (interface{}, *Error) {
+		json.Unmarshal(params, &created)
+		return map[string]bool{"success": true}, nil
+	})
+	f.handle("wallet_getBalance", func(json.RawMessage) (interface{}, *Error) {
+		return &WalletGetBalanceResult{Symbol: "BTC", Balance: uint64(f.count("wallet_getBalance")) * 20000}, nil
+	})
+	f.handle("orders_take", func(json.RawMessage) (interface{}, *Error) {
+		return &OrdersTakeResult{TradeID: "t1", OrderID: "o1"}, nil
+	})
+	f.handle("swap_init", func(params json.RawMessage) (interface{}, *Error) {
+		var p SwapInitParams
+		json.Unmarshal(params, &p)
+		return &SwapInitResult{TradeID: p.TradeID, State: "init"}, nil
+	})
+	f.handle("swap_status", func(json.RawMessage) (interface{}, *Error) {
+		state := "funding"
+		if f.count("swap_status") >= 3 {
+			state = "redeemed"
+		}
+		return &SwapStatusResult{TradeID: "t1", State: state}, nil
+	})
+
+	c := New(srv.URL, WithPollInterval(10*time.Millisecond), WithReconnect(10*time.Millisecond, 10*time.Millisecond))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	mnemonic, err := c.CreateWallet(ctx, "hunter2")
+	if err != nil || mnemonic != "abandon ability" || created.Mnemonic != mnemonic || created.Password != "hunter2" {
+		t.Errorf("CreateWallet() = %q, %v; created %+v", mnemonic, err, created)
+	}
+
+	balance, err := c.WaitForBalance(ctx, "BTC", 50000)
+	if err != nil || balance != 60000 {
+		t.Errorf("WaitForBalance() = %d, %v, want 60000", balance, err)
+	}
+
+	tradeID, err := c.TakeOrder(ctx, "o1")
+	if err != nil || tradeID != "t1" || f.count("swap_init") != 1 {
+		t.Errorf("TakeOrder() = %q, %v", tradeID, err)
+	}
+
+	status, err := c.AwaitSwap(ctx, "t1")
+	if err != nil || status.State != "redeemed" {
+		t.Errorf("AwaitSwap() = %+v, %v", status, err)
+	}
+
+	// A failing wait reports the context's error
+	short, cancelShort := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancelShort()
+	if _, err := c.WaitForBalance(short, "BTC", 1<<62); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("WaitForBalance() error = %v, want deadline exceeded", err)
+	}
+}
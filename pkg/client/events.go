@@ -0,0 +1,120 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Event is a WebSocket event of the node. Data is kept raw for decoding
+// into the event's type.
+type Event struct {
+	Type      string          `json:"type"`
+	Data      json.RawMessage `json:"data"`
+	Timestamp int64           `json:"timestamp"`
+}
+
+// TradeID returns the trade_id of the event's data, if any.
+func (e *Event) TradeID() string {
+	var d struct {
+		TradeID string `json:"trade_id"`
+	}
+	json.Unmarshal(e.Data, &d)
+	return d.TradeID
+}
+
+// subscription is the subscribe request sent after each (re)connect.
+type subscription struct {
+	Action string   `json:"action"`
+	Events []string `json:"events"`
+}
+
+// wsURL returns the WebSocket endpoint of the JSON-RPC URL.
+func (c *Client) wsURL() string {
+	u := c.url
+	switch {
+	case strings.HasPrefix(u, "https://"):
+		u = "wss://" + strings.TrimPrefix(u, "https://")
+	case strings.HasPrefix(u, "http://"):
+		u = "ws://" + strings.TrimPrefix(u, "http://")
+	}
+	return u + "/ws"
+}
+
+// Subscribe streams the node's events of the given types (all events when
+// none are given) until ctx is done, when the channel is closed. The
+// connection is re-established after errors, with the subscription sent
+// again; events broadcast while disconnected are missed.
+func (c *Client) Subscribe(ctx context.Context, events ...string) <-chan Event {
+	out := make(chan Event, 64)
+	go func() {
+		defer close(out)
+		delay := c.reconnectDelay
+		for ctx.Err() == nil {
+			if c.streamEvents(ctx, events, out) {
+				delay = c.reconnectDelay // Was connected: start over
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			if delay *= 2; delay > c.maxReconnectGap {
+				delay = c.maxReconnectGap
+			}
+		}
+	}()
+	return out
+}
+
+// streamEvents subscribes on one connection and delivers its events until it
+// fails. It reports whether the subscription was made.
+func (c *Client) streamEvents(ctx context.Context, events []string, out chan<- Event) bool {
+	header := http.Header{}
+	if c.apiKey != "" {
+		header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, c.wsURL(), header)
+	if err != nil {
+		return false
+	}
+	done := make(chan struct{})
+	defer close(done)
+	defer conn.Close()
+	go func() {
+		select {
+		case <-ctx.Done():
+			conn.Close()
+		case <-done:
+		}
+	}()
+
+	if len(events) > 0 {
+		if err := conn.WriteJSON(&subscription{Action: "subscribe", Events: events}); err != nil {
+			return false
+		}
+	}
+	for {
+		_, message, err := conn.ReadMessage()
+		if err != nil {
+			return true
+		}
+		// The node batches queued events into one message, one per line
+		for _, line := range bytes.Split(message, []byte{'\n'}) {
+			var event Event
+			if err := json.Unmarshal(line, &event); err != nil || event.Type == "" {
+				continue
+			}
+			select {
+			case out <- event:
+			case <-ctx.Done():
+				return true
+			}
+		}
+	}
+}
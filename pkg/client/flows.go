@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// terminalSwapStates are the states a swap ends in.
+var terminalSwapStates = map[string]bool{
+	"redeemed":  true,
+	"refunded":  true,
+	"failed":    true,
+	"cancelled": true,
+}
+
+// CreateWallet generates a mnemonic and creates the node's wallet from it,
+// encrypted with password. The mnemonic is returned for the user to back up.
+func (c *Client) CreateWallet(ctx context.Context, password string) (string, error) {
+	generated, err := c.WalletGenerate(ctx, &WalletGenerateParams{})
+	if err != nil {
+		return "", err
+	}
+	if err := c.WalletCreate(ctx, &WalletCreateParams{Mnemonic: generated.Mnemonic, Password: password}); err != nil {
+		return "", err
+	}
+	return generated.Mnemonic, nil
+}
+
+// FundingAddress returns the wallet's receive address of a chain.
+func (c *Client) FundingAddress(ctx context.Context, symbol string) (string, error) {
+	addr, err := c.WalletGetAddress(ctx, &WalletGetAddressParams{Symbol: symbol})
+	if err != nil {
+		return "", err
+	}
+	return addr.Address, nil
+}
+
+// WaitForBalance polls the wallet balance of a chain until it reaches min,
+// and returns it.
+func (c *Client) WaitForBalance(ctx context.Context, symbol string, min uint64) (uint64, error) {
+	for {
+		balance, err := c.WalletGetBalance(ctx, &WalletGetBalanceParams{Symbol: symbol})
+		if err != nil {
+			return 0, err
+		}
+		if balance.Balance >= min {
+			return balance.Balance, nil
+		}
+		select {
+		case <-ctx.Done():
+			return balance.Balance, ctx.Err()
+		case <-time.After(c.pollInterval):
+		}
+	}
+}
+
+// TakeOrder takes an order and initializes the swap of the new trade,
+// returning the trade ID.
+func (c *Client) TakeOrder(ctx context.Context, orderID string) (string, error) {
+	taken, err := c.OrdersTake(ctx, &OrdersTakeParams{OrderID: orderID})
+	if err != nil {
+		return "", err
+	}
+	if _, err := c.SwapInit(ctx, &SwapInitParams{TradeID: taken.TradeID}); err != nil {
+		return taken.TradeID, fmt.Errorf("order taken, swap not initialized: %w", err)
+	}
+	return taken.TradeID, nil
+}
+
+// AwaitSwap waits until a swap reaches a terminal state (redeemed,
+// refunded, failed or cancelled) and returns its final status. The status is
+// checked on every event of the trade and at least every poll interval.
+func (c *Client) AwaitSwap(ctx context.Context, tradeID string) (*SwapStatusResult, error) {
+	subCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	events := c.Subscribe(subCtx)
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		status, err := c.SwapStatus(ctx, tradeID)
+		if err != nil {
+			return nil, err
+		}
+		if terminalSwapStates[status.State] {
+			return status, nil
+		}
+
+	wait:
+		for {
+			select {
+			case <-ctx.Done():
+				return status, ctx.Err()
+			case <-ticker.C:
+				break wait
+			case event, ok := <-events:
+				if !ok {
+					events = nil // Only polling from now on
+				} else if event.TradeID() == tradeID {
+					break wait
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package client
+
+import "context"
+
+// call invokes a method and returns its decoded result.
+func call[T any](ctx context.Context, c *Client, method string, params interface{}) (*T, error) {
+	var out T
+	if err := c.Call(ctx, method, params, &out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// NodeInfo returns the node's peer ID, addresses and versions (node_info).
+func (c *Client) NodeInfo(ctx context.Context) (*NodeInfoResult, error) {
+	return call[NodeInfoResult](ctx, c, "node_info", nil)
+}
+
+// NodeStatus returns the node's uptime and connection counts (node_status).
+func (c *Client) NodeStatus(ctx context.Context) (*NodeStatusResult, error) {
+	return call[NodeStatusResult](ctx, c, "node_status", nil)
+}
+
+// PeersList lists the connected peers (peers_list).
+func (c *Client) PeersList(ctx context.Context) (*PeersListResult, error) {
+	return call[PeersListResult](ctx, c, "peers_list", nil)
+}
+
+// WalletStatus reports whether a wallet exists and is unlocked
+// (wallet_status).
+func (c *Client) WalletStatus(ctx context.Context) (*WalletStatusResult, error) {
+	return call[WalletStatusResult](ctx, c, "wallet_status", nil)
+}
+
+// WalletGenerate generates a new mnemonic without storing it
+// (wallet_generate).
+func (c *Client) WalletGenerate(ctx context.Context, p *WalletGenerateParams) (*WalletGenerateResult, error) {
+	return call[WalletGenerateResult](ctx, c, "wallet_generate", p)
+}
+
+// WalletCreate creates the wallet from a mnemonic (wallet_create).
+func (c *Client) WalletCreate(ctx context.Context, p *WalletCreateParams) error {
+	return c.Call(ctx, "wallet_create", p, nil)
+}
+
+// WalletUnlock unlocks the wallet (wallet_unlock).
+func (c *Client) WalletUnlock(ctx context.Context, p *WalletUnlockParams) error {
+	return c.Call(ctx, "wallet_unlock", p, nil)
+}
+
+// WalletGetAddress returns a wallet address of a chain (wallet_getAddress).
+func (c *Client) WalletGetAddress(ctx context.Context, p *WalletGetAddressParams) (*WalletGetAddressResult, error) {
+	return call[WalletGetAddressResult](ctx, c, "wallet_getAddress", p)
+}
+
+// WalletGetBalance returns the balance of a chain (wallet_getBalance).
+func (c *Client) WalletGetBalance(ctx context.Context, p *WalletGetBalanceParams) (*WalletGetBalanceResult, error) {
+	return call[WalletGetBalanceResult](ctx, c, "wallet_getBalance", p)
+}
+
+// WalletSend sends coins from the wallet (wallet_send).
+func (c *Client) WalletSend(ctx context.Context, p *WalletSendParams) (*WalletSendResult, error) {
+	return call[WalletSendResult](ctx, c, "wallet_send", p)
+}
+
+// OrdersCreate creates and announces an order (orders_create).
+func (c *Client) OrdersCreate(ctx context.Context, p *OrderCreateParams) (*OrderInfo, error) {
+	return call[OrderInfo](ctx, c, "orders_create", p)
+}
+
+// OrdersList lists orders (orders_list).
+func (c *Client) OrdersList(ctx context.Context, p *OrdersListParams) (*OrdersListResult, error) {
+	return call[OrdersListResult](ctx, c, "orders_list", p)
+}
+
+// OrdersGet returns an order (orders_get).
+func (c *Client) OrdersGet(ctx context.Context, id string) (*OrderInfo, error) {
+	return call[OrderInfo](ctx, c, "orders_get", map[string]string{"id": id})
+}
+
+// OrdersCancel cancels one of our open orders (orders_cancel).
+func (c *Client) OrdersCancel(ctx context.Context, id string) error {
+	return c.Call(ctx, "orders_cancel", map[string]string{"id": id}, nil)
+}
+
+// OrdersTake takes an order, creating a trade (orders_take).
+func (c *Client) OrdersTake(ctx context.Context, p *OrdersTakeParams) (*OrdersTakeResult, error) {
+	return call[OrdersTakeResult](ctx, c, "orders_take", p)
+}
+
+// TradesList lists trades (trades_list).
+func (c *Client) TradesList(ctx context.Context, p *TradesListParams) (*TradesListResult, error) {
+	return call[TradesListResult](ctx, c, "trades_list", p)
+}
+
+// TradesGet returns a trade with its legs (trades_get).
+func (c *Client) TradesGet(ctx context.Context, id string) (*TradeInfo, error) {
+	return call[TradeInfo](ctx, c, "trades_get", map[string]string{"id": id})
+}
+
+// SwapInit initializes the swap of a trade (swap_init).
+func (c *Client) SwapInit(ctx context.Context, p *SwapInitParams) (*SwapInitResult, error) {
+	return call[SwapInitResult](ctx, c, "swap_init", p)
+}
+
+// SwapFund funds our escrow of a swap (swap_fund).
+func (c *Client) SwapFund(ctx context.Context, tradeID string) (*SwapFundResult, error) {
+	return call[SwapFundResult](ctx, c, "swap_fund", map[string]string{"trade_id": tradeID})
+}
+
+// SwapStatus returns the detailed status of a swap (swap_status).
+func (c *Client) SwapStatus(ctx context.Context, tradeID string) (*SwapStatusResult, error) {
+	return call[SwapStatusResult](ctx, c, "swap_status", map[string]string{"trade_id": tradeID})
+}
+
+// SwapList lists active swaps, and finished ones with IncludeCompleted
+// (swap_list).
+func (c *Client) SwapList(ctx context.Context, p *SwapListParams) (*SwapListResult, error) {
+	return call[SwapListResult](ctx, c, "swap_list", p)
+}
+
+// SwapCheckFunding checks the funding of both escrows of a swap
+// (swap_checkFunding).
+func (c *Client) SwapCheckFunding(ctx context.Context, tradeID string) (*SwapCheckFundingResult, error) {
+	return call[SwapCheckFundingResult](ctx, c, "swap_checkFunding", map[string]string{"trade_id": tradeID})
+}
+
+// SwapExchangeNonce sends our MuSig2 nonces of a swap (swap_exchangeNonce).
+func (c *Client) SwapExchangeNonce(ctx context.Context, tradeID string) (*SwapExchangeNonceResult, error) {
+	return call[SwapExchangeNonceResult](ctx, c, "swap_exchangeNonce", map[string]string{"trade_id": tradeID})
+}
+
+// SwapSign creates and sends our MuSig2 partial signatures (swap_sign).
+func (c *Client) SwapSign(ctx context.Context, tradeID string) (*SwapSignResult, error) {
+	return call[SwapSignResult](ctx, c, "swap_sign", map[string]string{"trade_id": tradeID})
+}
+
+// SwapRedeem redeems our leg of a signed MuSig2 swap (swap_redeem).
+func (c *Client) SwapRedeem(ctx context.Context, tradeID string) (*SwapRedeemResult, error) {
+	return call[SwapRedeemResult](ctx, c, "swap_redeem", map[string]string{"trade_id": tradeID})
+}
+
+// SwapHTLCRevealSecret reveals the secret of an HTLC swap we initiated
+// (swap_htlcRevealSecret).
+func (c *Client) SwapHTLCRevealSecret(ctx context.Context, tradeID string) (*SwapHTLCRevealSecretResult, error) {
+	return call[SwapHTLCRevealSecretResult](ctx, c, "swap_htlcRevealSecret", map[string]string{"trade_id": tradeID})
+}
+
+// SwapHTLCClaim claims the counterparty's HTLC (swap_htlcClaim).
+func (c *Client) SwapHTLCClaim(ctx context.Context, p *SwapHTLCClaimParams) (*SwapHTLCClaimResult, error) {
+	return call[SwapHTLCClaimResult](ctx, c, "swap_htlcClaim", p)
+}
+
+// SwapRefund refunds our escrow of a swap on a chain (swap_refund).
+func (c *Client) SwapRefund(ctx context.Context, p *SwapRefundParams) (*SwapRefundResult, error) {
+	return call[SwapRefundResult](ctx, c, "swap_refund", p)
+}
+
+// SwapRecover loads a swap from storage again (swap_recover).
+func (c *Client) SwapRecover(ctx context.Context, tradeID string) (*SwapRecoverResult, error) {
+	return call[SwapRecoverResult](ctx, c, "swap_recover", map[string]string{"trade_id": tradeID})
+}
+
+// SwapReconcile checks a swap against the chains (swap_reconcile).
+func (c *Client) SwapReconcile(ctx context.Context, tradeID string) (*ReconcileReport, error) {
+	return call[ReconcileReport](ctx, c, "swap_reconcile", map[string]string{"trade_id": tradeID})
+}
+
+// SwapNegotiateFees proposes a fee adjustment of a trade, or only estimates
+// it with dryRun (swap_negotiateFees).
+func (c *Client) SwapNegotiateFees(ctx context.Context, tradeID string, dryRun bool) (*SwapNegotiateFeesResult, error) {
+	params := map[string]interface{}{"trade_id": tradeID, "dry_run": dryRun}
+	return call[SwapNegotiateFeesResult](ctx, c, "swap_negotiateFees", params)
+}
+
+// PeerDiagnose dials a peer and reports on its connectivity (peer_diagnose).
+func (c *Client) PeerDiagnose(ctx context.Context, target string) (*PeerDiagnosis, error) {
+	return call[PeerDiagnosis](ctx, c, "peer_diagnose", map[string]string{"target": target})
+}
+
+// StorageFsck checks the node's storage, repairing with repair
+// (storage_fsck).
+func (c *Client) StorageFsck(ctx context.Context, repair bool) (*FsckReport, error) {
+	return call[FsckReport](ctx, c, "storage_fsck", map[string]bool{"repair": repair})
+}
@@ -0,0 +1,61 @@
+package client
+
+import (
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+)
+
+// Request and response types of the node's handlers. They are aliases so
+// applications can name them without importing internal packages.
+type (
+	NodeInfoResult   = rpc.NodeInfoResult
+	NodeStatusResult = rpc.NodeStatusResult
+	PeersListResult  = rpc.PeersListResult
+	PeerInfo         = rpc.PeerInfo
+
+	WalletStatusResult      = rpc.WalletStatusResult
+	WalletGenerateParams    = rpc.WalletGenerateParams
+	WalletGenerateResult    = rpc.WalletGenerateResult
+	WalletCreateParams      = rpc.WalletCreateParams
+	WalletUnlockParams      = rpc.WalletUnlockParams
+	WalletGetAddressParams  = rpc.WalletGetAddressParams
+	WalletGetAddressResult  = rpc.WalletGetAddressResult
+	WalletGetBalanceParams  = rpc.WalletGetBalanceParams
+	WalletGetBalanceResult  = rpc.WalletGetBalanceResult
+	WalletSendParams        = rpc.WalletSendParams
+	WalletSendResult        = rpc.WalletSendResult
+	OrderCreateParams       = rpc.OrderCreateParams
+	OrderInfo               = rpc.OrderInfo
+	OrdersListParams        = rpc.OrdersListParams
+	OrdersListResult        = rpc.OrdersListResult
+	OrdersTakeParams        = rpc.OrdersTakeParams
+	OrdersTakeResult        = rpc.OrdersTakeResult
+	TradeInfo               = rpc.TradeInfo
+	TradesListParams        = rpc.TradesListParams
+	TradesListResult        = rpc.TradesListResult
+	SwapInitParams          = rpc.SwapInitParams
+	SwapInitResult          = rpc.SwapInitResult
+	SwapFundResult          = rpc.SwapFundResult
+	SwapStatusResult        = rpc.SwapStatusResult
+	SwapNegotiateFeesResult = rpc.SwapNegotiateFeesResult
+	PeerDiagnosis           = node.PeerDiagnosis
+	FsckReport              = storage.FsckReport
+
+	SwapListParams             = rpc.SwapListParams
+	SwapListItem               = rpc.SwapListItem
+	SwapListResult             = rpc.SwapListResult
+	SwapCheckFundingResult     = rpc.SwapCheckFundingResult
+	SwapExchangeNonceResult    = rpc.SwapExchangeNonceResult
+	SwapSignResult             = rpc.SwapSignResult
+	SwapRedeemResult           = rpc.SwapRedeemResult
+	SwapHTLCRevealSecretResult = rpc.SwapHTLCRevealSecretResult
+	SwapHTLCClaimParams        = rpc.SwapHTLCClaimParams
+	SwapHTLCClaimResult        = rpc.SwapHTLCClaimResult
+	SwapRefundParams           = rpc.SwapRefundParams
+	SwapRefundResult           = rpc.SwapRefundResult
+	SwapRecoverResult          = rpc.SwapRecoverResult
+	ReconcileReport            = swap.ReconcileReport
+	Deadline                   = swap.Deadline
+)
@@ -106,3 +106,68 @@ func WeiToETH(wei uint64) string {
 func ETHToWei(eth string) (uint64, error) {
 	return ParseAmount(eth, 18)
 }
+
+// FormatBigAmount formats an amount in smallest units as a decimal string.
+// It is FormatAmount for amounts that may exceed uint64 (e.g. wei).
+func FormatBigAmount(amount *big.Int, decimals uint8) string {
+	if amount == nil {
+		return "0"
+	}
+	abs := new(big.Int).Abs(amount)
+	sign := ""
+	if amount.Sign() < 0 {
+		sign = "-"
+	}
+	if decimals == 0 {
+		return sign + abs.String()
+	}
+
+	divisor := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	whole, frac := new(big.Int).QuoRem(abs, divisor, new(big.Int))
+	if frac.Sign() == 0 {
+		return sign + whole.String()
+	}
+
+	fracStr := fmt.Sprintf("%0*s", int(decimals), frac.String())
+	for len(fracStr) > 0 && fracStr[len(fracStr)-1] == '0' {
+		fracStr = fracStr[:len(fracStr)-1]
+	}
+	return sign + whole.String() + "." + fracStr
+}
+
+// ParseBigAmount parses a non-negative decimal string to smallest units.
+// Unlike ParseAmount it has no uint64 limit, and it rejects amounts with more
+// fractional digits than decimals instead of truncating them.
+func ParseBigAmount(s string, decimals uint8) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("empty amount string")
+	}
+
+	wholeStr, fracStr := s, ""
+	for i, c := range s {
+		if c == '.' {
+			wholeStr, fracStr = s[:i], s[i+1:]
+			break
+		}
+	}
+	if wholeStr == "" && fracStr == "" {
+		return nil, fmt.Errorf("invalid amount: %s", s)
+	}
+	for _, c := range wholeStr + fracStr {
+		if c < '0' || c > '9' {
+			return nil, fmt.Errorf("invalid character in amount: %c", c)
+		}
+	}
+	if len(fracStr) > int(decimals) {
+		return nil, fmt.Errorf("amount %s has more than %d decimal places", s, decimals)
+	}
+	for len(fracStr) < int(decimals) {
+		fracStr += "0"
+	}
+
+	amount, ok := new(big.Int).SetString(wholeStr+fracStr, 10)
+	if !ok {
+		return nil, fmt.Errorf("invalid amount: %s", s)
+	}
+	return amount, nil
+}
@@ -1,6 +1,7 @@
 package helpers
 
 import (
+	"math/big"
 	"testing"
 )
 
@@ -160,6 +161,75 @@ func TestFormatParseRoundtrip(t *testing.T) {
 	}
 }
 
+func TestParseBigAmount(t *testing.T) {
+	tests := []struct {
+		input    string
+		decimals uint8
+		want     string
+		wantErr  bool
+	}{
+		{"1.5", 18, "1500000000000000000", false},
+		{"1000000", 18, "1000000000000000000000000", false}, // exceeds uint64
+		{"0.000001", 6, "1", false},
+		{".5", 6, "500000", false},
+		{"2.", 6, "2000000", false},
+		{"7", 0, "7", false},
+		{"0.0000001", 6, "", true}, // more precision than the token has
+		{"-1", 18, "", true},
+		{"1e18", 18, "", true},
+		{".", 18, "", true},
+		{"", 18, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseBigAmount(tt.input, tt.decimals)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %s", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("ParseBigAmount(%s, %d) = %s, want %s", tt.input, tt.decimals, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatBigAmount(t *testing.T) {
+	huge, _ := new(big.Int).SetString("1234567000000000000000000", 10)
+	tests := []struct {
+		amount   *big.Int
+		decimals uint8
+		want     string
+	}{
+		{big.NewInt(1500000000000000000), 18, "1.5"},
+		{big.NewInt(1), 18, "0.000000000000000001"},
+		{big.NewInt(1000000), 6, "1"},
+		{big.NewInt(-2500000), 6, "-2.5"},
+		{big.NewInt(42), 0, "42"},
+		{huge, 18, "1234567"},
+		{nil, 18, "0"},
+	}
+
+	for _, tt := range tests {
+		got := FormatBigAmount(tt.amount, tt.decimals)
+		if got != tt.want {
+			t.Errorf("FormatBigAmount(%s, %d) = %s, want %s", tt.amount, tt.decimals, got, tt.want)
+		}
+		if tt.amount != nil && tt.amount.Sign() >= 0 {
+			parsed, err := ParseBigAmount(got, tt.decimals)
+			if err != nil || parsed.Cmp(tt.amount) != 0 {
+				t.Errorf("roundtrip %s -> %s -> %v, %v", tt.amount, got, parsed, err)
+			}
+		}
+	}
+}
+
 func TestSatoshisBTCConversion(t *testing.T) {
 	// Test SatoshisToBTC
 	if got := SatoshisToBTC(100000000); got != "1" {
@@ -0,0 +1,466 @@
+// Package klingdex embeds Klingon atomic swaps in a Go application.
+//
+// An Engine wires up what the klingond daemon runs - storage, blockchain
+// backends, wallet, swap coordinator and the P2P node - without the HTTP
+// JSON-RPC server. Every JSON-RPC method is available in-process through
+// Call, and the components are exposed for direct use:
+//
+//	engine, err := klingdex.New(
+//		klingdex.WithDataDir("/var/lib/myapp/klingon"),
+//		klingdex.WithNetwork(klingdex.Testnet),
+//	)
+//	if err != nil {
+//		return err
+//	}
+//	defer engine.Close()
+//
+//	if err := engine.Start(ctx); err != nil { // stops when ctx is cancelled
+//		return err
+//	}
+//	info, err := engine.Call(ctx, "node_info", nil)
+package klingdex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Klingon-tech/klingdex/internal/backend"
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/internal/config"
+	"github.com/Klingon-tech/klingdex/internal/node"
+	"github.com/Klingon-tech/klingdex/internal/rpc"
+	"github.com/Klingon-tech/klingdex/internal/storage"
+	"github.com/Klingon-tech/klingdex/internal/swap"
+	klsync "github.com/Klingon-tech/klingdex/internal/sync"
+	"github.com/Klingon-tech/klingdex/internal/wallet"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Component types. They are aliases so embedders can name them without
+// importing internal packages.
+type (
+	Config        = node.Config
+	Node          = node.Node
+	SQLiteStorage = storage.Storage
+	Wallet        = wallet.Service
+	Coordinator   = swap.Coordinator
+	SwapEvent     = swap.SwapEvent
+)
+
+// Storage is the database an Engine runs on. The coordinator, wallet and
+// node keep their records in the SQLite store SQLite returns, so a custom
+// implementation wraps the *SQLiteStorage from OpenStorage, e.g. by
+// embedding it to observe settings or to close it with the application.
+type Storage interface {
+	GetSetting(key string) (string, error)
+	SetSetting(key, value string) error
+	Close() error
+
+	// SQLite returns the store the engine's components run on
+	SQLite() *SQLiteStorage
+}
+
+// Backend is a blockchain data provider. Implement it to use a custom node
+// or indexer for a chain (see WithBackend).
+type Backend interface {
+	// Type returns the backend type (mempool, esplora, etc.)
+	Type() BackendType
+
+	// Connect, Close and IsConnected manage the connection.
+	Connect(ctx context.Context) error
+	Close() error
+	IsConnected() bool
+
+	// Address operations
+	GetAddressInfo(ctx context.Context, address string) (*AddressInfo, error)
+	GetAddressUTXOs(ctx context.Context, address string) ([]UTXO, error)
+	GetAddressTxs(ctx context.Context, address string, lastSeenTxID string) ([]Transaction, error)
+
+	// Transaction operations
+	GetTransaction(ctx context.Context, txID string) (*Transaction, error)
+	GetRawTransaction(ctx context.Context, txID string) ([]byte, error)
+	BroadcastTransaction(ctx context.Context, rawTxHex string) (string, error)
+
+	// Block operations
+	GetBlockHeight(ctx context.Context) (int64, error)
+	GetBlockHeader(ctx context.Context, hashOrHeight string) (*BlockHeader, error)
+
+	// Fee estimation
+	GetFeeEstimates(ctx context.Context) (*FeeEstimate, error)
+}
+
+// The engine hands backends to the internal registry unchanged.
+var _ backend.Backend = Backend(nil)
+
+// Types used by the Backend interface.
+type (
+	BackendType = backend.Type
+	AddressInfo = backend.AddressInfo
+	UTXO        = backend.UTXO
+	Transaction = backend.Transaction
+	BlockHeader = backend.BlockHeader
+	FeeEstimate = backend.FeeEstimate
+)
+
+// Engine errors.
+var (
+	ErrNotStarted     = errors.New("engine not started")
+	ErrAlreadyStarted = errors.New("engine already started")
+	ErrClosed         = errors.New("engine closed")
+)
+
+// DefaultConfig returns the daemon's default configuration.
+func DefaultConfig() *Config {
+	return node.DefaultConfig()
+}
+
+// LoadConfig loads config.yaml from a data directory, creating it with
+// defaults if missing.
+func LoadConfig(dataDir string) (*Config, error) {
+	return node.LoadConfig(dataDir)
+}
+
+// OpenStorage opens (or creates) the database in a data directory.
+func OpenStorage(dataDir string) (*SQLiteStorage, error) {
+	return storage.New(&storage.Config{DataDir: dataDir})
+}
+
+// expandPath expands ~ to home directory.
+func expandPath(path string) string {
+	if len(path) > 0 && path[0] == '~' {
+		home, _ := os.UserHomeDir()
+		return filepath.Join(home, path[1:])
+	}
+	return path
+}
+
+// Engine is an embedded swap node.
+type Engine struct {
+	cfg       *Config
+	network   chain.Network
+	log       *logging.Logger
+	storage   Storage
+	store     *SQLiteStorage
+	ownsStore bool
+	backends  *backend.Registry
+	wallet    *Wallet
+	coord     *Coordinator
+
+	mu        sync.Mutex
+	node      *Node
+	rpc       *rpc.Server
+	orderSync *klsync.OrderSync
+	tradeSync *klsync.TradeSync
+	cancel    context.CancelFunc
+	started   bool
+	closed    bool
+}
+
+// New builds an engine: it opens storage, sets up backends, the wallet and the
+// swap coordinator, and recovers pending swaps. The P2P node is created by
+// Start.
+func New(opts ...Option) (*Engine, error) {
+	o := &options{network: Mainnet}
+	for _, opt := range opts {
+		if err := opt(o); err != nil {
+			return nil, err
+		}
+	}
+
+	// The default data dir belongs to the daemon, so embedders pick their own
+	if o.config == nil && o.dataDir == "" {
+		return nil, fmt.Errorf("data dir is required (WithDataDir or WithConfig)")
+	}
+	cfg := o.config
+	if cfg == nil {
+		cfg = DefaultConfig()
+	}
+	if o.dataDir != "" {
+		cfg.Storage.DataDir = o.dataDir
+	}
+	cfg.Storage.DataDir = expandPath(cfg.Storage.DataDir)
+	network := chain.Mainnet
+	cfg.NetworkType = node.NetworkMainnet
+	if o.network != Mainnet {
+		network = chain.Testnet
+		cfg.NetworkType = node.NetworkTestnet
+	}
+	if o.network != Mainnet && o.network != Testnet {
+		// A named test network, selected on every chain that has it
+		if cfg.Testnets == nil {
+			cfg.Testnets = make(map[string]string)
+		}
+		for _, symbol := range chain.TestnetChains(string(o.network)) {
+			cfg.Testnets[symbol] = string(o.network)
+		}
+	}
+	if len(o.listenAddrs) > 0 {
+		cfg.Network.ListenAddrs = o.listenAddrs
+	}
+	if len(o.bootstrapPeers) > 0 {
+		cfg.Network.BootstrapPeers = o.bootstrapPeers
+	}
+	if o.noDiscovery {
+		cfg.Network.EnableMDNS = false
+		cfg.Network.EnableDHT = false
+	}
+
+	if o.logger != nil {
+		logging.SetDefault(o.logger)
+	}
+
+	if err := cfg.ApplyTestnets(); err != nil {
+		return nil, fmt.Errorf("invalid testnet selection: %w", err)
+	}
+	if err := cfg.SpendingPolicy.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid spending policy: %w", err)
+	}
+
+	e := &Engine{
+		cfg:     cfg,
+		network: network,
+		log:     logging.GetDefault().Component("klingdex"),
+		storage: o.store,
+	}
+	if e.storage == nil {
+		store, err := OpenStorage(cfg.Storage.DataDir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open storage: %w", err)
+		}
+		e.storage = store
+		e.ownsStore = true
+	}
+	e.store = e.storage.SQLite()
+
+	e.backends = backend.NewDefaultRegistry(network)
+	for symbol, b := range o.backends {
+		e.backends.Register(symbol, b)
+	}
+
+	e.wallet = wallet.NewService(&wallet.ServiceConfig{
+		DataDir:  cfg.Storage.DataDir,
+		Network:  network,
+		Backends: e.backends,
+		Policy:   &cfg.SpendingPolicy,
+		Store:    e.store,
+	})
+
+	e.coord = swap.NewCoordinator(&swap.CoordinatorConfig{
+		Store:          e.store,
+		Network:        network,
+		Backends:       e.backends.All(),
+		WalletService:  e.wallet,
+		JobMaxAttempts: config.DefaultSwapConfig().JobMaxAttempts,
+	})
+	if err := e.coord.SetAutoClaimPolicy(cfg.AutoClaim); err != nil {
+		e.closeComponents()
+		return nil, fmt.Errorf("invalid auto-claim config: %w", err)
+	}
+	if err := e.coord.SetZeroConfPolicy(cfg.ZeroConf); err != nil {
+		e.closeComponents()
+		return nil, fmt.Errorf("invalid zero-conf config: %w", err)
+	}
+	if err := e.coord.SetFundingVariancePolicy(cfg.FundingVariance); err != nil {
+		e.closeComponents()
+		return nil, fmt.Errorf("invalid funding variance config: %w", err)
+	}
+
+	ctx := context.Background()
+	if err := e.coord.LoadPendingSwaps(ctx); err != nil {
+		e.log.Warn("Failed to load pending swaps", "error", err)
+	}
+	if _, err := e.coord.ResumePendingJobs(ctx); err != nil {
+		e.log.Warn("Failed to resume pending jobs", "error", err)
+	}
+
+	return e, nil
+}
+
+// Start creates and starts the P2P node, registers the swap protocol
+// handlers and starts the background monitors. The engine shuts down when
+// ctx is cancelled.
+func (e *Engine) Start(ctx context.Context) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return ErrClosed
+	}
+	if e.started {
+		return ErrAlreadyStarted
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+	n, err := node.New(runCtx, e.cfg)
+	if err != nil {
+		cancel()
+		return fmt.Errorf("failed to create node: %w", err)
+	}
+	n.SetPeerStoreAdapter(node.NewPeerStoreAdapter(e.store))
+	if err := n.LoadPersistedPeers(); err != nil {
+		e.log.Warn("Failed to load persisted peers", "error", err)
+	}
+	if err := n.SetupDirectMessaging(e.store); err != nil {
+		e.log.Warn("Failed to setup direct messaging", "error", err)
+	}
+	if err := n.Start(); err != nil {
+		cancel()
+		return fmt.Errorf("failed to start node: %w", err)
+	}
+
+	e.node = n
+	e.rpc = rpc.NewServer(n, e.store, e.wallet, e.coord)
+	e.rpc.SetupSwapHandlers()
+	e.rpc.SetCaptureDir(filepath.Join(e.cfg.Storage.DataDir, "captures"))
+	n.Partition().OnChange(func(status node.PartitionStatus) {
+		e.coord.SetDegraded(status.Degraded)
+	})
+
+	e.coord.StartAutoClaimMonitor()
+	e.coord.StartDeadlineMonitor(e.cfg.Deadlines.UpdateInterval)
+	e.coord.StartBroadcastJobMonitor(swap.BroadcastJobInterval)
+
+	e.orderSync = klsync.NewOrderSync(n.Host(), e.store, nil)
+	if err := e.orderSync.Start(); err != nil {
+		e.log.Warn("Failed to start order sync", "error", err)
+	}
+	e.tradeSync = klsync.NewTradeSync(n.Host(), e.store)
+	if err := e.tradeSync.Start(); err != nil {
+		e.log.Warn("Failed to start trade sync", "error", err)
+	}
+
+	e.cancel = cancel
+	e.started = true
+	go func() {
+		<-runCtx.Done()
+		e.Close()
+	}()
+
+	e.log.Info("Engine started", "peer_id", n.ID().String(), "network", e.network)
+	return nil
+}
+
+// Run starts the engine and blocks until ctx is cancelled.
+func (e *Engine) Run(ctx context.Context) error {
+	if err := e.Start(ctx); err != nil {
+		return err
+	}
+	<-ctx.Done()
+	return e.Close()
+}
+
+// Close stops the node and background work and closes the storage opened by
+// the engine. It is safe to call more than once.
+func (e *Engine) Close() error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if e.closed {
+		return nil
+	}
+	e.closed = true
+
+	var errs []error
+	if e.started {
+		if e.orderSync != nil {
+			e.orderSync.Stop()
+		}
+		if e.tradeSync != nil {
+			e.tradeSync.Stop()
+		}
+		if err := e.node.SavePeerCache(); err != nil {
+			e.log.Warn("Failed to save peer cache", "error", err)
+		}
+		if err := e.node.Stop(); err != nil {
+			errs = append(errs, err)
+		}
+		e.cancel()
+	}
+	if err := e.closeComponents(); err != nil {
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// closeComponents closes the coordinator and, if ours, the storage.
+func (e *Engine) closeComponents() error {
+	e.coord.Close()
+	if e.ownsStore {
+		return e.storage.Close()
+	}
+	return nil
+}
+
+// Call invokes a JSON-RPC method in-process and returns its JSON result.
+// params may be nil, a json.RawMessage or any value that marshals to the
+// method's parameter object. Methods are documented in the README.
+func (e *Engine) Call(ctx context.Context, method string, params interface{}) (json.RawMessage, error) {
+	e.mu.Lock()
+	server, started, closed := e.rpc, e.started, e.closed
+	e.mu.Unlock()
+	if closed {
+		return nil, ErrClosed
+	}
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	var raw json.RawMessage
+	switch p := params.(type) {
+	case nil:
+		raw = json.RawMessage(`{}`)
+	case json.RawMessage:
+		raw = p
+	default:
+		data, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid params: %w", err)
+		}
+		raw = data
+	}
+
+	result, err := server.Call(ctx, method, raw)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(result)
+}
+
+// OnEvent registers a handler for swap events (state changes, auto-claim
+// decisions, deadline updates). Each handler receives events in order on its
+// own goroutine; one that falls far behind loses the oldest events.
+func (e *Engine) OnEvent(handler func(SwapEvent)) {
+	e.coord.OnEvent(handler)
+}
+
+// Config returns the engine configuration.
+func (e *Engine) Config() *Config {
+	return e.cfg
+}
+
+// Storage returns the database.
+func (e *Engine) Storage() Storage {
+	return e.storage
+}
+
+// Wallet returns the wallet service.
+func (e *Engine) Wallet() *Wallet {
+	return e.wallet
+}
+
+// Coordinator returns the swap coordinator.
+func (e *Engine) Coordinator() *Coordinator {
+	return e.coord
+}
+
+// Node returns the P2P node, or nil before Start.
+func (e *Engine) Node() *Node {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.node
+}
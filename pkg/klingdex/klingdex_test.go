@@ -0,0 +1,150 @@
+package klingdex
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+)
+
+// fakeBackend is a Backend implemented outside the internal packages.
+type fakeBackend struct {
+	Backend
+}
+
+func (fakeBackend) GetBlockHeight(ctx context.Context) (int64, error) { return 42, nil }
+
+func newTestEngine(t *testing.T, opts ...Option) *Engine {
+	t.Helper()
+	opts = append([]Option{
+		WithDataDir(t.TempDir()),
+		WithNetwork(Testnet),
+		WithListenAddrs("/ip4/127.0.0.1/tcp/0"),
+		WithoutDiscovery(),
+	}, opts...)
+	e, err := New(opts...)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	t.Cleanup(func() { e.Close() })
+	return e
+}
+
+func TestNewOptions(t *testing.T) {
+	if _, err := New(); err == nil {
+		t.Error("New() without a data dir should fail")
+	}
+	if _, err := New(WithDataDir(t.TempDir()), WithNetwork("regtest")); err == nil {
+		t.Error("New() accepted an unknown network")
+	}
+	if _, err := New(WithDataDir(t.TempDir()), WithBackend("BTC", nil)); err == nil {
+		t.Error("New() accepted a nil backend")
+	}
+
+	e := newTestEngine(t, WithBackend("BTC", fakeBackend{}))
+	if e.Coordinator() == nil || e.Wallet() == nil || e.Storage() == nil {
+		t.Fatal("components not initialized")
+	}
+	if e.Node() != nil {
+		t.Error("node created before Start")
+	}
+	if e.Config().Network.EnableDHT || e.Config().Network.EnableMDNS {
+		t.Error("WithoutDiscovery did not disable discovery")
+	}
+
+	b, ok := e.backends.Get("BTC")
+	if !ok {
+		t.Fatal("BTC backend missing")
+	}
+	if height, _ := b.GetBlockHeight(context.Background()); height != 42 {
+		t.Errorf("WithBackend not applied: height = %d", height)
+	}
+}
+
+// wrappedStorage is a Storage wrapping the SQLite store.
+type wrappedStorage struct {
+	*SQLiteStorage
+}
+
+func TestNewStorageAndNetwork(t *testing.T) {
+	dir := t.TempDir()
+	sqlite, err := OpenStorage(dir)
+	if err != nil {
+		t.Fatalf("OpenStorage() error = %v", err)
+	}
+	defer sqlite.Close()
+	store := wrappedStorage{sqlite}
+
+	if _, err := New(WithDataDir(dir), WithStorage(wrappedStorage{})); err == nil {
+		t.Error("New() accepted a storage without a database")
+	}
+
+	t.Cleanup(func() { chain.SelectTestnet("ETH", chain.TestnetETHSepolia) })
+	e := newTestEngine(t, WithDataDir(dir), WithStorage(store), WithNetwork(Holesky))
+	if e.Storage() != Storage(store) {
+		t.Errorf("Storage() = %v, want the wrapper", e.Storage())
+	}
+	if got := e.Config().Testnets["ETH"]; got != "holesky" || chain.ActiveTestnet("ETH") != "holesky" {
+		t.Errorf("ETH test network = %q (active %q), want holesky", got, chain.ActiveTestnet("ETH"))
+	}
+	if e.Config().NetworkType != "testnet" {
+		t.Errorf("NetworkType = %s, want testnet", e.Config().NetworkType)
+	}
+
+	// The engine doesn't close storage it was given
+	e.Close()
+	if err := store.SetSetting("k", "v"); err != nil {
+		t.Errorf("SetSetting() after Close error = %v", err)
+	}
+}
+
+func TestEngineLifecycle(t *testing.T) {
+	e := newTestEngine(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	if _, err := e.Call(ctx, "node_info", nil); !errors.Is(err, ErrNotStarted) {
+		t.Errorf("Call() before Start error = %v, want ErrNotStarted", err)
+	}
+
+	if err := e.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if err := e.Start(ctx); !errors.Is(err, ErrAlreadyStarted) {
+		t.Errorf("second Start() error = %v, want ErrAlreadyStarted", err)
+	}
+
+	raw, err := e.Call(ctx, "node_info", nil)
+	if err != nil {
+		t.Fatalf("Call(node_info) error = %v", err)
+	}
+	var info map[string]interface{}
+	if err := json.Unmarshal(raw, &info); err != nil {
+		t.Fatalf("node_info result is not JSON: %v", err)
+	}
+	if info["peer_id"] != e.Node().ID().String() {
+		t.Errorf("node_info peer_id = %v, want %s", info["peer_id"], e.Node().ID())
+	}
+	if _, err := e.Call(ctx, "no_such_method", nil); err == nil {
+		t.Error("Call() accepted an unknown method")
+	}
+
+	// Cancelling the context shuts the engine down
+	cancel()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := e.Call(context.Background(), "node_info", nil); errors.Is(err, ErrClosed) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("engine not closed after context cancellation")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if err := e.Close(); err != nil {
+		t.Errorf("Close() after shutdown error = %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package klingdex
+
+import (
+	"fmt"
+
+	"github.com/Klingon-tech/klingdex/internal/chain"
+	"github.com/Klingon-tech/klingdex/pkg/logging"
+)
+
+// Network selects mainnet, testnet or a named test network.
+type Network string
+
+// Networks.
+const (
+	Mainnet Network = "mainnet"
+	Testnet Network = "testnet"
+
+	// Named test networks run on testnet and select the network on the
+	// chains that have it (config testnets); the other chains use their
+	// default test network.
+	Testnet3 Network = chain.TestnetBTCTestnet3
+	Testnet4 Network = chain.TestnetBTCTestnet4
+	Signet   Network = chain.TestnetBTCSignet
+	Sepolia  Network = chain.TestnetETHSepolia
+	Holesky  Network = chain.TestnetETHHolesky
+)
+
+// Option configures an Engine.
+type Option func(*options) error
+
+type options struct {
+	config   *Config
+	dataDir  string
+	network  Network
+	store    Storage
+	backends map[string]Backend
+	logger   *logging.Logger
+
+	listenAddrs    []string
+	bootstrapPeers []string
+	noDiscovery    bool
+}
+
+// WithConfig uses a full node configuration instead of DefaultConfig. Later
+// options override its fields.
+func WithConfig(cfg *Config) Option {
+	return func(o *options) error {
+		if cfg == nil {
+			return fmt.Errorf("config is nil")
+		}
+		o.config = cfg
+		return nil
+	}
+}
+
+// WithDataDir sets the directory for the database, wallet and node identity.
+func WithDataDir(dir string) Option {
+	return func(o *options) error {
+		if dir == "" {
+			return fmt.Errorf("data dir is empty")
+		}
+		o.dataDir = dir
+		return nil
+	}
+}
+
+// WithNetwork selects mainnet, testnet or a named test network such as
+// Signet or Holesky.
+func WithNetwork(network Network) Option {
+	return func(o *options) error {
+		if network != Mainnet && network != Testnet && len(chain.TestnetChains(string(network))) == 0 {
+			return fmt.Errorf("unknown network: %s", network)
+		}
+		o.network = network
+		return nil
+	}
+}
+
+// WithStorage uses an already opened database, the *SQLiteStorage from
+// OpenStorage or a wrapper of it. The engine does not close it.
+func WithStorage(store Storage) Option {
+	return func(o *options) error {
+		if store == nil || store.SQLite() == nil {
+			return fmt.Errorf("storage is nil")
+		}
+		o.store = store
+		return nil
+	}
+}
+
+// WithBackend replaces or adds the blockchain backend for a chain, e.g. a
+// private node or a test double.
+func WithBackend(symbol string, b Backend) Option {
+	return func(o *options) error {
+		if symbol == "" || b == nil {
+			return fmt.Errorf("backend requires a chain symbol and an implementation")
+		}
+		if o.backends == nil {
+			o.backends = make(map[string]Backend)
+		}
+		o.backends[symbol] = b
+		return nil
+	}
+}
+
+// WithLogger sets the logger. Components log through the process-wide
+// default logger, so this replaces it.
+func WithLogger(log *logging.Logger) Option {
+	return func(o *options) error {
+		o.logger = log
+		return nil
+	}
+}
+
+// WithListenAddrs sets the P2P listen multiaddrs.
+func WithListenAddrs(addrs ...string) Option {
+	return func(o *options) error {
+		o.listenAddrs = addrs
+		return nil
+	}
+}
+
+// WithBootstrapPeers sets the P2P bootstrap peers (multiaddrs).
+func WithBootstrapPeers(addrs ...string) Option {
+	return func(o *options) error {
+		o.bootstrapPeers = addrs
+		return nil
+	}
+}
+
+// WithoutDiscovery disables mDNS and DHT peer discovery; peers are reached
+// through the bootstrap list or Node().ConnectByAddr only.
+func WithoutDiscovery() Option {
+	return func(o *options) error {
+		o.noDiscovery = true
+		return nil
+	}
+}
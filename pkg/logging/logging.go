@@ -26,6 +26,8 @@ const (
 type Logger struct {
 	*log.Logger
 	timeFormat string
+	component  string        // Prefix, for taps
+	fields     []interface{} // Key-value pairs added by With, for taps
 }
 
 // Config holds logger configuration.
@@ -66,7 +68,7 @@ func New(cfg *Config) *Logger {
 
 	logger.SetLevel(ParseLevel(cfg.Level))
 
-	return &Logger{Logger: logger, timeFormat: cfg.TimeFormat}
+	return &Logger{Logger: logger, timeFormat: cfg.TimeFormat, component: cfg.Prefix}
 }
 
 // Default returns the default logger.
@@ -94,7 +96,8 @@ func ParseLevel(level string) Level {
 
 // With returns a new logger with the given key-value pairs.
 func (l *Logger) With(keyvals ...interface{}) *Logger {
-	return &Logger{Logger: l.Logger.With(keyvals...), timeFormat: l.timeFormat}
+	fields := append(append([]interface{}(nil), l.fields...), keyvals...)
+	return &Logger{Logger: l.Logger.With(keyvals...), timeFormat: l.timeFormat, component: l.component, fields: fields}
 }
 
 // WithPrefix returns a new logger with the given prefix.
@@ -109,7 +112,7 @@ func (l *Logger) WithPrefix(prefix string) *Logger {
 		Prefix:          prefix,
 	})
 	newLogger.SetLevel(l.GetLevel())
-	return &Logger{Logger: newLogger, timeFormat: timeFormat}
+	return &Logger{Logger: newLogger, timeFormat: timeFormat, component: prefix}
 }
 
 // Component returns a logger for a specific component.
@@ -117,6 +120,54 @@ func (l *Logger) Component(name string) *Logger {
 	return l.WithPrefix(name)
 }
 
+// Debug logs a debug message.
+func (l *Logger) Debug(msg interface{}, keyvals ...interface{}) {
+	l.Logger.Debug(msg, keyvals...)
+	l.tap(DebugLevel, msg, keyvals)
+}
+
+// Info logs an info message.
+func (l *Logger) Info(msg interface{}, keyvals ...interface{}) {
+	l.Logger.Info(msg, keyvals...)
+	l.tap(InfoLevel, msg, keyvals)
+}
+
+// Warn logs a warning message.
+func (l *Logger) Warn(msg interface{}, keyvals ...interface{}) {
+	l.Logger.Warn(msg, keyvals...)
+	l.tap(WarnLevel, msg, keyvals)
+}
+
+// Error logs an error message.
+func (l *Logger) Error(msg interface{}, keyvals ...interface{}) {
+	l.Logger.Error(msg, keyvals...)
+	l.tap(ErrorLevel, msg, keyvals)
+}
+
+// Debugf logs a formatted debug message.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.Logger.Debugf(format, args...)
+	l.tapf(DebugLevel, format, args)
+}
+
+// Infof logs a formatted info message.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.Logger.Infof(format, args...)
+	l.tapf(InfoLevel, format, args)
+}
+
+// Warnf logs a formatted warning message.
+func (l *Logger) Warnf(format string, args ...interface{}) {
+	l.Logger.Warnf(format, args...)
+	l.tapf(WarnLevel, format, args)
+}
+
+// Errorf logs a formatted error message.
+func (l *Logger) Errorf(format string, args ...interface{}) {
+	l.Logger.Errorf(format, args...)
+	l.tapf(ErrorLevel, format, args)
+}
+
 // Global default logger instance.
 var defaultLogger = Default()
 
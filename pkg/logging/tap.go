@@ -0,0 +1,104 @@
+package logging
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Record is a log entry as handed to taps.
+type Record struct {
+	Time      time.Time              `json:"time"`
+	Level     string                 `json:"level"`
+	Component string                 `json:"component,omitempty"`
+	Message   string                 `json:"message"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+
+	level Level
+}
+
+// Severity returns the record's level.
+func (r *Record) Severity() Level {
+	return r.level
+}
+
+var (
+	tapMu    sync.RWMutex
+	taps     = make(map[uint64]func(*Record))
+	tapSeq   uint64
+	tapCount atomic.Int32
+)
+
+// Tap calls fn with every record logged at or above its logger's level,
+// until untap is called. fn runs on the logging goroutine, possibly with
+// the caller's locks held: it must not block or log.
+func Tap(fn func(*Record)) (untap func()) {
+	tapMu.Lock()
+	defer tapMu.Unlock()
+	tapSeq++
+	id := tapSeq
+	taps[id] = fn
+	tapCount.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			tapMu.Lock()
+			defer tapMu.Unlock()
+			delete(taps, id)
+			tapCount.Add(-1)
+		})
+	}
+}
+
+// tap hands a record to the taps, if any.
+func (l *Logger) tap(level Level, msg interface{}, keyvals []interface{}) {
+	if tapCount.Load() == 0 || level < l.GetLevel() {
+		return
+	}
+
+	record := &Record{
+		Time:      time.Now(),
+		Level:     level.String(),
+		Component: l.component,
+		Message:   fmt.Sprint(msg),
+		level:     level,
+	}
+	if n := len(l.fields) + len(keyvals); n > 0 {
+		record.Fields = make(map[string]interface{}, n/2)
+		addFields(record.Fields, l.fields)
+		addFields(record.Fields, keyvals)
+	}
+
+	tapMu.RLock()
+	defer tapMu.RUnlock()
+	for _, fn := range taps {
+		fn(record)
+	}
+}
+
+// tapf hands a formatted record to the taps, if any.
+func (l *Logger) tapf(level Level, format string, args []interface{}) {
+	if tapCount.Load() > 0 {
+		l.tap(level, fmt.Sprintf(format, args...), nil)
+	}
+}
+
+// addFields adds key-value pairs to fields, with values that encode to JSON
+// the way they print.
+func addFields(fields map[string]interface{}, keyvals []interface{}) {
+	for i := 0; i+1 < len(keyvals); i += 2 {
+		key := fmt.Sprint(keyvals[i])
+		switch v := keyvals[i+1].(type) {
+		case nil, string, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+			fields[key] = v
+		case error:
+			fields[key] = v.Error()
+		case fmt.Stringer:
+			fields[key] = v.String()
+		default:
+			fields[key] = fmt.Sprint(v)
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package logging
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestTap(t *testing.T) {
+	var out bytes.Buffer
+	l := New(&Config{Level: "info", Output: &out}).Component("swap").With("trade_id", "t1")
+
+	var records []*Record
+	untap := Tap(func(r *Record) { records = append(records, r) })
+
+	l.Debug("hidden")
+	l.Info("funded", "amount", uint64(1000), "error", errors.New("boom"))
+	l.Warnf("retry %d", 2)
+
+	if len(records) != 2 {
+		t.Fatalf("tapped %d records, want 2 (debug is below the level)", len(records))
+	}
+	r := records[0]
+	if r.Message != "funded" || r.Level != "info" || r.Severity() != InfoLevel || r.Component != "swap" {
+		t.Errorf("record = %+v", r)
+	}
+	if r.Fields["trade_id"] != "t1" || r.Fields["amount"] != uint64(1000) || r.Fields["error"] != "boom" {
+		t.Errorf("fields = %v", r.Fields)
+	}
+	if records[1].Message != "retry 2" || records[1].Level != "warn" {
+		t.Errorf("formatted record = %+v", records[1])
+	}
+
+	untap()
+	untap()
+	l.Error("after")
+	if len(records) != 2 {
+		t.Error("record tapped after untap")
+	}
+	if tapCount.Load() != 0 {
+		t.Errorf("tap count = %d after untap", tapCount.Load())
+	}
+}